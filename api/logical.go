@@ -7,11 +7,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
@@ -165,6 +167,86 @@ func (c *Logical) List(path string) (*Secret, error) {
 }
 
 func (c *Logical) ListWithContext(ctx context.Context, path string) (*Secret, error) {
+	return c.list(ctx, path, nil)
+}
+
+// ListPage lists the keys under the path which sort after the given key, at
+// most limit of them if limit is positive. Only the paths supporting
+// pagination, such as sys/leases/lookup, honor the after and limit
+// parameters; the others return all the keys.
+func (c *Logical) ListPage(path string, after string, limit int) (*Secret, error) {
+	return c.ListPageWithContext(context.Background(), path, after, limit)
+}
+
+func (c *Logical) ListPageWithContext(ctx context.Context, path string, after string, limit int) (*Secret, error) {
+	params := make(url.Values)
+	if after != "" {
+		params.Set("after", after)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	return c.list(ctx, path, params)
+}
+
+// ListPagesWithContext lists the keys under the path page by page, calling
+// the given function with each page of at most limit keys, until all the keys
+// are listed or the function returns an error, which is then returned. If the
+// path doesn't support pagination, the function is called once with all the
+// keys.
+func (c *Logical) ListPagesWithContext(ctx context.Context, path string, limit int, f func(*Secret) error) error {
+	if limit <= 0 {
+		return errors.New("limit must be a positive integer")
+	}
+
+	var after string
+	for {
+		secret, err := c.ListPageWithContext(ctx, path, after, limit)
+		if err != nil {
+			return err
+		}
+		if secret == nil {
+			return nil
+		}
+
+		keys, _ := secret.Data["keys"].([]interface{})
+		if after != "" && len(keys) > 0 {
+			// Stop if the path ignored the marker and returned keys it already
+			// did, as asking for the next page would return them again
+			first, ok := keys[0].(string)
+			if !ok {
+				return fmt.Errorf("unexpected key %v in list response", keys[0])
+			}
+			if first <= after {
+				return nil
+			}
+		}
+
+		if err := f(secret); err != nil {
+			return err
+		}
+
+		// The paths using the pagination of the SDK set the marker of the
+		// next page when there are more keys to list
+		if nextAfter, ok := secret.Data["next_after"].(string); ok {
+			after = nextAfter
+			continue
+		}
+
+		// Stop on the last page, or if the path returned all the keys
+		if len(keys) == 0 || len(keys) != limit {
+			return nil
+		}
+		last, ok := keys[len(keys)-1].(string)
+		if !ok {
+			return fmt.Errorf("unexpected key %v in list response", keys[len(keys)-1])
+		}
+		after = last
+	}
+}
+
+func (c *Logical) list(ctx context.Context, path string, params url.Values) (*Secret, error) {
 	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
 	defer cancelFunc()
 
@@ -172,6 +254,9 @@ func (c *Logical) ListWithContext(ctx context.Context, path string) (*Secret, er
 	// Set this for broader compatibility, but we use LIST above to be able to
 	// handle the wrapping lookup function
 	r.Method = http.MethodGet
+	for k, v := range params {
+		r.Params[k] = v
+	}
 	r.Params.Set("list", "true")
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
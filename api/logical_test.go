@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestLogical_ListPagesWithContext_IgnoredMarker ensures listing stops when
+// the path ignores the after and limit parameters and returns the same page
+// of exactly limit keys again, without handing the repeated page to the
+// function.
+func TestLogical_ListPagesWithContext_IgnoredMarker(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"keys": []string{"a", "b"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Address = server.URL
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pages int
+	err = client.Logical().ListPagesWithContext(context.Background(), "secret/", 2, func(*Secret) error {
+		pages++
+		if pages > 1 {
+			t.Fatal("listing didn't stop on a repeated page")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+}
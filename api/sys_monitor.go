@@ -8,23 +8,61 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// MonitorInput holds the options of a request to stream the log messages of
+// the server.
+type MonitorInput struct {
+	// LogLevel is the level to stream log messages at, which defaults to
+	// info.
+	LogLevel string
+
+	// LogFormat is the format of the log messages, either standard or json.
+	LogFormat string
+
+	// Modules restricts the log messages to those of the given subsystems,
+	// such as expiration, raft or plugin.
+	Modules []string
+
+	// RateLimit is the maximum number of log messages streamed per second.
+	// Zero means unlimited.
+	RateLimit int
+}
+
 // Monitor returns a channel that outputs strings containing the log messages
 // coming from the server.
 func (c *Sys) Monitor(ctx context.Context, logLevel string, logFormat string) (chan string, error) {
+	return c.MonitorWithInput(ctx, &MonitorInput{
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+	})
+}
+
+// MonitorWithInput returns a channel that outputs strings containing the log
+// messages coming from the server, filtered as specified by the input.
+func (c *Sys) MonitorWithInput(ctx context.Context, input *MonitorInput) (chan string, error) {
 	r := c.c.NewRequest(http.MethodGet, "/v1/sys/monitor")
 
-	if logLevel == "" {
+	if input.LogLevel == "" {
 		r.Params.Add("log_level", "info")
 	} else {
-		r.Params.Add("log_level", logLevel)
+		r.Params.Add("log_level", input.LogLevel)
 	}
 
-	if logFormat == "" {
+	if input.LogFormat == "" {
 		r.Params.Add("log_format", "standard")
 	} else {
-		r.Params.Add("log_format", logFormat)
+		r.Params.Add("log_format", input.LogFormat)
+	}
+
+	if len(input.Modules) > 0 {
+		r.Params.Add("modules", strings.Join(input.Modules, ","))
+	}
+
+	if input.RateLimit > 0 {
+		r.Params.Add("rate_limit", strconv.Itoa(input.RateLimit))
 	}
 
 	resp, err := c.c.RawRequestWithContext(ctx, r)
@@ -72,3 +110,38 @@ func (c *Sys) Monitor(ctx context.Context, logLevel string, logFormat string) (c
 
 	return logCh, nil
 }
+
+// MonitorAudit returns a channel that outputs the audit entries of the
+// requests handled by the server, one JSON object per string. Sensitive
+// values of the entries are HMAC-ed. The channel is closed when the context is
+// done or the server ends the stream.
+func (c *Sys) MonitorAudit(ctx context.Context) (chan string, error) {
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/monitor/audit")
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	entryCh := make(chan string, 64)
+
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		// Audit entries may be much larger than the default maximum line
+		// size, e.g. for responses listing many keys.
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+		defer close(entryCh)
+		defer resp.Body.Close()
+
+		for scanner.Scan() {
+			select {
+			case entryCh <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return entryCh, nil
+}
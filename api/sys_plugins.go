@@ -215,6 +215,16 @@ type RegisterPluginInput struct {
 	// Env specifies a list of key=value pairs to add to the plugin's environment
 	// variables.
 	Env []string `json:"env,omitempty"`
+
+	// OCIArtifact specifies an OCI artifact holding the plugin binary, which
+	// Vault pulls into its plugin directory after verifying its signature. The
+	// SHA256 is optional when it is set.
+	OCIArtifact string `json:"oci_artifact,omitempty"`
+
+	// OCIUsername and OCIPassword are the credentials used to pull OCIArtifact
+	// from its registry, if any.
+	OCIUsername string `json:"oci_username,omitempty"`
+	OCIPassword string `json:"oci_password,omitempty"`
 }
 
 // RegisterPlugin wraps RegisterPluginWithContext using context.Background.
@@ -22,12 +22,14 @@ type GetPluginRuntimeInput struct {
 
 // GetPluginRuntimeResponse is the response from the GetPluginRuntime call.
 type GetPluginRuntimeResponse struct {
-	Type         string `json:"type"`
-	Name         string `json:"name"`
-	OCIRuntime   string `json:"oci_runtime"`
-	CgroupParent string `json:"cgroup_parent"`
-	CPU          int64  `json:"cpu_nanos"`
-	Memory       int64  `json:"memory_bytes"`
+	Type           string   `json:"type"`
+	Name           string   `json:"name"`
+	OCIRuntime     string   `json:"oci_runtime"`
+	CgroupParent   string   `json:"cgroup_parent"`
+	CPU            int64    `json:"cpu_nanos"`
+	Memory         int64    `json:"memory_bytes"`
+	DisableNetwork bool     `json:"disable_network"`
+	Networks       []string `json:"networks"`
 }
 
 // GetPluginRuntime retrieves information about the plugin.
@@ -66,6 +68,13 @@ type RegisterPluginRuntimeInput struct {
 	CgroupParent string `json:"cgroup_parent,omitempty"`
 	CPU          int64  `json:"cpu_nanos,omitempty"`
 	Memory       int64  `json:"memory_bytes,omitempty"`
+
+	// DisableNetwork runs the plugin containers without a network stack.
+	DisableNetwork bool `json:"disable_network,omitempty"`
+
+	// Networks are the container networks to attach the plugin containers
+	// to instead of the default one.
+	Networks []string `json:"networks,omitempty"`
 }
 
 // RegisterPluginRuntime registers the plugin with the given information.
@@ -112,12 +121,14 @@ func (c *Sys) DeregisterPluginRuntime(ctx context.Context, i *DeregisterPluginRu
 }
 
 type PluginRuntimeDetails struct {
-	Type         string `json:"type" mapstructure:"type"`
-	Name         string `json:"name" mapstructure:"name"`
-	OCIRuntime   string `json:"oci_runtime" mapstructure:"oci_runtime"`
-	CgroupParent string `json:"cgroup_parent" mapstructure:"cgroup_parent"`
-	CPU          int64  `json:"cpu_nanos" mapstructure:"cpu_nanos"`
-	Memory       int64  `json:"memory_bytes" mapstructure:"memory_bytes"`
+	Type           string   `json:"type" mapstructure:"type"`
+	Name           string   `json:"name" mapstructure:"name"`
+	OCIRuntime     string   `json:"oci_runtime" mapstructure:"oci_runtime"`
+	CgroupParent   string   `json:"cgroup_parent" mapstructure:"cgroup_parent"`
+	CPU            int64    `json:"cpu_nanos" mapstructure:"cpu_nanos"`
+	Memory         int64    `json:"memory_bytes" mapstructure:"memory_bytes"`
+	DisableNetwork bool     `json:"disable_network" mapstructure:"disable_network"`
+	Networks       []string `json:"networks" mapstructure:"networks"`
 }
 
 // ListPluginRuntimesInput is used as input to the ListPluginRuntimes function.
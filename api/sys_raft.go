@@ -7,11 +7,15 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -271,6 +275,169 @@ func (c *Sys) RaftSnapshotRestoreWithContext(ctx context.Context, snapReader io.
 	return nil
 }
 
+// RaftSnapshotUploadStatus is the progress of a snapshot uploaded in chunks
+// and of its restore.
+type RaftSnapshotUploadStatus struct {
+	UploadID      string    `mapstructure:"upload_id"`
+	State         string    `mapstructure:"state"`
+	Error         string    `mapstructure:"error"`
+	Size          int64     `mapstructure:"size"`
+	Force         bool      `mapstructure:"force"`
+	BytesReceived int64     `mapstructure:"bytes_received"`
+	BytesVerified int64     `mapstructure:"bytes_verified"`
+	BytesRestored int64     `mapstructure:"bytes_restored"`
+	RestoreSize   int64     `mapstructure:"restore_size"`
+	StartTime     time.Time `mapstructure:"start_time"`
+	UpdateTime    time.Time `mapstructure:"update_time"`
+}
+
+// RaftSnapshotUploadStartWithContext starts uploading a snapshot of the given
+// size in chunks. The snapshot is installed once all of it is uploaded, and
+// its SHA256 sum, hex encoded, is verified.
+func (c *Sys) RaftSnapshotUploadStartWithContext(ctx context.Context, size int64, sha256Sum string, force bool) (*RaftSnapshotUploadStatus, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/snapshot-upload")
+	if err := r.SetJSONBody(map[string]interface{}{
+		"size":   size,
+		"sha256": sha256Sum,
+		"force":  force,
+	}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return parseRaftSnapshotUploadStatus(resp)
+}
+
+// RaftSnapshotUploadChunkWithContext uploads a chunk of a snapshot, starting
+// at the given offset, which must be the number of bytes received so far.
+func (c *Sys) RaftSnapshotUploadChunkWithContext(ctx context.Context, uploadID string, offset int64, chunk io.Reader) (*RaftSnapshotUploadStatus, error) {
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/storage/raft/snapshot-upload/"+uploadID)
+	r.Params.Set("offset", strconv.FormatInt(offset, 10))
+	r.URL.RawQuery = r.Params.Encode()
+	r.Body = chunk
+
+	resp, err := c.c.httpRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return parseRaftSnapshotUploadStatus(resp)
+}
+
+// RaftSnapshotUploadStatusWithContext returns the progress of a snapshot
+// upload, or nil if there is no such upload.
+func (c *Sys) RaftSnapshotUploadStatusWithContext(ctx context.Context, uploadID string) (*RaftSnapshotUploadStatus, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/storage/raft/snapshot-upload/"+uploadID)
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRaftSnapshotUploadStatus(resp)
+}
+
+// RaftSnapshotRestoreInChunksWithContext uploads the snapshot in chunks of the
+// given size, resuming from the data received by Vault when a chunk fails,
+// up to the given number of retries in a row. It returns once the snapshot is
+// fully uploaded, Vault then verifying and installing it in the background.
+func (c *Sys) RaftSnapshotRestoreInChunksWithContext(ctx context.Context, snap io.ReadSeeker, force bool, chunkSize int64, retries int) (*RaftSnapshotUploadStatus, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk size must be positive")
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, snap)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := c.RaftSnapshotUploadStartWithContext(ctx, size, hex.EncodeToString(hash.Sum(nil)), force)
+	if err != nil {
+		return nil, err
+	}
+
+	var failures int
+	for status.BytesReceived < size {
+		if _, err := snap.Seek(status.BytesReceived, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		chunkStatus, err := c.RaftSnapshotUploadChunkWithContext(ctx, status.UploadID, status.BytesReceived, io.LimitReader(snap, chunkSize))
+		if err == nil {
+			status = chunkStatus
+			failures = 0
+			continue
+		}
+
+		failures++
+		if failures > retries || ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to upload snapshot chunk: %w", err)
+		}
+
+		// Resume from the data received before the chunk failed
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(failures) * time.Second):
+		}
+		chunkStatus, statusErr := c.RaftSnapshotUploadStatusWithContext(ctx, status.UploadID)
+		switch {
+		case statusErr != nil:
+			return nil, fmt.Errorf("failed to read snapshot upload status after chunk failure %q: %w", err, statusErr)
+		case chunkStatus == nil:
+			return nil, fmt.Errorf("snapshot upload was discarded after chunk failure: %w", err)
+		case chunkStatus.State == "failed":
+			return nil, fmt.Errorf("snapshot upload failed: %s", chunkStatus.Error)
+		}
+		status = chunkStatus
+	}
+
+	return status, nil
+}
+
+func parseRaftSnapshotUploadStatus(resp *Response) (*RaftSnapshotUploadStatus, error) {
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result RaftSnapshotUploadStatus
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		Result:     &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(secret.Data); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // RaftAutopilotState wraps RaftAutopilotStateWithContext using context.Background.
 func (c *Sys) RaftAutopilotState() (*AutopilotState, error) {
 	return c.RaftAutopilotStateWithContext(context.Background())
@@ -376,6 +543,37 @@ func (c *Sys) RaftAutopilotConfigurationWithContext(ctx context.Context) (*Autop
 	return &result, err
 }
 
+// RaftAutopilotCleanupDeadServers wraps RaftAutopilotCleanupDeadServersWithContext using context.Background.
+func (c *Sys) RaftAutopilotCleanupDeadServers() error {
+	return c.RaftAutopilotCleanupDeadServersWithContext(context.Background())
+}
+
+// RaftAutopilotCleanupDeadServersWithDRToken wraps RaftAutopilotCleanupDeadServersWithContext using the given token.
+func (c *Sys) RaftAutopilotCleanupDeadServersWithDRToken(drToken string) error {
+	return c.RaftAutopilotCleanupDeadServersWithContext(context.WithValue(context.Background(), "dr-token", drToken))
+}
+
+// RaftAutopilotCleanupDeadServersWithContext triggers an immediate removal of
+// the dead servers of the raft cluster by autopilot.
+func (c *Sys) RaftAutopilotCleanupDeadServersWithContext(ctx context.Context) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	if ctx.Value("dr-token") != nil {
+		c.c.SetToken(ctx.Value("dr-token").(string))
+	}
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/autopilot/cleanup-dead-servers")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
 // PutRaftAutopilotConfiguration wraps PutRaftAutopilotConfigurationWithContext using context.Background.
 func (c *Sys) PutRaftAutopilotConfiguration(opts *AutopilotConfig) error {
 	return c.PutRaftAutopilotConfigurationWithContext(context.Background(), opts)
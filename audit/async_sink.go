@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+// asyncRetryInterval is the interval at which an AsyncSink retries writing
+// the buffered events when the wrapped sink fails.
+const asyncRetryInterval = time.Second
+
+var (
+	_ eventlogger.Node   = (*AsyncSink)(nil)
+	_ eventlogger.Closer = (*AsyncSink)(nil)
+)
+
+// AsyncSink wraps a sink node, acknowledging events once they are appended to
+// a spool, which a background writer flushes to the sink. Events are written
+// in order, and the spool is persistent, so that the events which are not
+// written yet when Vault stops are written once the device is set up again.
+// The spool bounds the events waiting to be written: an event which doesn't
+// fit in it results in an error, as if the sink failed to write it.
+type AsyncSink struct {
+	spool  *Spool
+	format string
+	sink   eventlogger.Node
+
+	// flushLock serializes the flushes of the writer and of Close
+	flushLock sync.Mutex
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	notifyCh  chan struct{}
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewAsyncSink should be used to create an AsyncSink which buffers events in
+// the required format in the spool. Its background writer is started by the
+// first event, so events left in the spool by a previous sink are written
+// before it.
+func NewAsyncSink(spool *Spool, format string, sink eventlogger.Node) (*AsyncSink, error) {
+	const op = "audit.NewAsyncSink"
+
+	switch {
+	case spool == nil:
+		return nil, fmt.Errorf("%s: spool is required: %w", op, event.ErrInvalidParameter)
+	case format == "":
+		return nil, fmt.Errorf("%s: format is required: %w", op, event.ErrInvalidParameter)
+	case sink == nil:
+		return nil, fmt.Errorf("%s: sink is required: %w", op, event.ErrInvalidParameter)
+	}
+
+	return &AsyncSink{
+		spool:    spool,
+		format:   format,
+		sink:     sink,
+		notifyCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}, nil
+}
+
+// Process appends the formatted event to the spool and returns, leaving the
+// background writer to write it to the wrapped sink.
+func (s *AsyncSink) Process(_ context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	const op = "audit.(AsyncSink).Process"
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: event is nil: %w", op, event.ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(s.format)
+	if !found {
+		return nil, fmt.Errorf("%s: unable to retrieve event formatted as %q", op, s.format)
+	}
+
+	if err := s.spool.Append(formatted); err != nil {
+		metrics.IncrCounter([]string{"audit", "async", "enqueue_failure"}, 1)
+		return nil, fmt.Errorf("%s: unable to buffer event: %w", op, err)
+	}
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+	s.notify()
+
+	// return nil for the event to indicate the pipeline is complete.
+	return nil, nil
+}
+
+// notify wakes the background writer up, unless it is already due to flush.
+func (s *AsyncSink) notify() {
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// run flushes the spool each time events are appended to it, and periodically
+// while the wrapped sink fails, until the sink is closed.
+func (s *AsyncSink) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(asyncRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-s.notifyCh:
+		case <-ticker.C:
+		}
+
+		// Errors are retried on the next tick, the entries being kept in the
+		// spool.
+		_, _ = s.flush(context.Background())
+	}
+}
+
+// flush writes the events of the spool to the wrapped sink, in order, until
+// one of them fails to be written.
+func (s *AsyncSink) flush(ctx context.Context) (int, error) {
+	s.flushLock.Lock()
+	defer s.flushLock.Unlock()
+
+	start := time.Now()
+	flushed, err := s.spool.Drain(func(data []byte) error {
+		e := &eventlogger.Event{
+			Type:      eventlogger.EventType(event.AuditType.String()),
+			CreatedAt: time.Now(),
+		}
+		e.FormattedAs(s.format, data)
+
+		_, err := s.sink.Process(ctx, e)
+		return err
+	})
+	if flushed > 0 {
+		metrics.MeasureSince([]string{"audit", "async", "flush"}, start)
+		metrics.IncrCounter([]string{"audit", "async", "flushed"}, float32(flushed))
+	}
+	metrics.SetGauge([]string{"audit", "async", "buffer_size"}, float32(s.spool.Size()))
+
+	return flushed, err
+}
+
+// Close stops the background writer and flushes the spool, failing when
+// events could not be written before the context is done. Such events stay in
+// the spool, and are written once a sink using it is created again. The
+// wrapped sink is then closed, if it implements eventlogger.Closer.
+func (s *AsyncSink) Close(ctx context.Context) error {
+	const op = "audit.(AsyncSink).Close"
+
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.startOnce.Do(func() {
+		// The writer was never started
+		close(s.doneCh)
+	})
+	<-s.doneCh
+
+	for s.spool.Size() > 0 {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: unable to flush %d bytes of buffered events: %w", op, s.spool.Size(), err)
+		}
+
+		if _, err := s.flush(ctx); err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(asyncRetryInterval):
+			}
+		}
+	}
+
+	if closer, ok := s.sink.(eventlogger.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+// Reopen wraps the Reopen method of the wrapped sink.
+func (s *AsyncSink) Reopen() error {
+	return s.sink.Reopen()
+}
+
+// Type describes the type of this node (sink).
+func (s *AsyncSink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAuditEvent returns an audit event formatted as JSON.
+func newTestAuditEvent(data string) *eventlogger.Event {
+	e := &eventlogger.Event{
+		Type:      eventlogger.EventType(event.AuditType.String()),
+		CreatedAt: time.Now(),
+	}
+	e.FormattedAs(JSONFormat.String(), []byte(data))
+	return e
+}
+
+// TestNewAsyncSink ensures an AsyncSink can only be created with valid
+// parameters.
+func TestNewAsyncSink(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	require.NoError(t, err)
+
+	_, err = NewAsyncSink(nil, JSONFormat.String(), &testSink{})
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+	_, err = NewAsyncSink(s, "", &testSink{})
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+	_, err = NewAsyncSink(s, JSONFormat.String(), nil)
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+
+	asyncSink, err := NewAsyncSink(s, JSONFormat.String(), &testSink{})
+	require.NoError(t, err)
+	require.Equal(t, eventlogger.NodeTypeSink, asyncSink.Type())
+	require.NoError(t, asyncSink.Close(context.Background()))
+}
+
+// TestAsyncSink_Process ensures events are acknowledged while the sink fails,
+// and written in order by the background writer once it recovers.
+func TestAsyncSink_Process(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	require.NoError(t, err)
+
+	sink := &testSink{err: errors.New("unavailable")}
+	asyncSink, err := NewAsyncSink(s, JSONFormat.String(), sink)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = asyncSink.Process(ctx, newTestAuditEvent("foo"))
+	require.NoError(t, err)
+	_, err = asyncSink.Process(ctx, newTestAuditEvent("bar"))
+	require.NoError(t, err)
+	require.Empty(t, sink.writtenEvents())
+
+	sink.setErr(nil)
+	require.Eventually(t, func() bool {
+		return len(sink.writtenEvents()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"foo", "bar"}, sink.writtenEvents())
+
+	_, err = asyncSink.Process(ctx, newTestAuditEvent("baz"))
+	require.NoError(t, err)
+	require.NoError(t, asyncSink.Close(ctx))
+	require.Equal(t, []string{"foo", "bar", "baz"}, sink.writtenEvents())
+	require.Zero(t, s.Size())
+}
+
+// TestAsyncSink_Full ensures events which don't fit in the spool fail.
+func TestAsyncSink_Full(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), spoolHeaderSize+3)
+	require.NoError(t, err)
+
+	asyncSink, err := NewAsyncSink(s, JSONFormat.String(), &testSink{err: errors.New("unavailable")})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = asyncSink.Process(ctx, newTestAuditEvent("foo"))
+	require.NoError(t, err)
+	_, err = asyncSink.Process(ctx, newTestAuditEvent("bar"))
+	require.ErrorIs(t, err, ErrSpoolFull)
+}
+
+// TestAsyncSink_Close ensures the events which could not be flushed when
+// closing are kept, and flushed by the next sink using the spool.
+func TestAsyncSink_Close(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "spool")
+	s, err := NewSpool(path, 0)
+	require.NoError(t, err)
+
+	asyncSink, err := NewAsyncSink(s, JSONFormat.String(), &testSink{err: errors.New("unavailable")})
+	require.NoError(t, err)
+
+	_, err = asyncSink.Process(context.Background(), newTestAuditEvent("foo"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, asyncSink.Close(ctx), context.DeadlineExceeded)
+	require.NotZero(t, s.Size())
+
+	s, err = NewSpool(path, 0)
+	require.NoError(t, err)
+
+	sink := &testSink{}
+	asyncSink, err = NewAsyncSink(s, JSONFormat.String(), sink)
+	require.NoError(t, err)
+	require.NoError(t, asyncSink.Close(context.Background()))
+	require.Equal(t, []string{"foo"}, sink.writtenEvents())
+	require.Zero(t, s.Size())
+}
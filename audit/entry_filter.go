@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-bexpr"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var _ eventlogger.Node = (*EntryFilter)(nil)
+
+// EntryFilter should be used to filter audit requests and responses which
+// should make it to a sink.
+type EntryFilter struct {
+	// the evaluator for the bexpr expression that should be applied by the node.
+	evaluator *bexpr.Evaluator
+}
+
+// filterData holds the properties of a request that filter expressions can
+// be evaluated against.
+type filterData struct {
+	MountPoint string `bexpr:"mount_point"`
+	MountType  string `bexpr:"mount_type"`
+	Namespace  string `bexpr:"namespace"`
+	Operation  string `bexpr:"operation"`
+	Path       string `bexpr:"path"`
+}
+
+// NewEntryFilter should be used to create an EntryFilter node.
+// The filter supplied should be in bexpr format and reference the mount_point,
+// mount_type, namespace, operation and path fields of the request.
+func NewEntryFilter(filter string) (*EntryFilter, error) {
+	const op = "audit.NewEntryFilter"
+
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, fmt.Errorf("%s: cannot create new audit filter with empty filter expression: %w", op, event.ErrInvalidParameter)
+	}
+
+	eval, err := bexpr.CreateEvaluator(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot create new audit filter: %w", op, err)
+	}
+
+	return &EntryFilter{evaluator: eval}, nil
+}
+
+// Reopen is a no-op for the filter node.
+func (*EntryFilter) Reopen() error {
+	return nil
+}
+
+// Type describes the type of this node (filter).
+func (*EntryFilter) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeFilter
+}
+
+// Process will attempt to parse the incoming event data and decide whether it
+// should be filtered or remain in the pipeline and passed to the next node.
+func (f *EntryFilter) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	const op = "audit.(EntryFilter).Process"
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: event is nil: %w", op, event.ErrInvalidParameter)
+	}
+
+	a, ok := e.Payload.(*auditEvent)
+	if !ok {
+		return nil, fmt.Errorf("%s: cannot parse event payload: %w", op, event.ErrInvalidParameter)
+	}
+
+	allowed, err := f.Evaluate(ctx, a.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !allowed {
+		// Returning a nil event indicates the event was filtered out
+		return nil, nil
+	}
+
+	return e, nil
+}
+
+// Evaluate reports whether the specified request or response should be
+// audited. A nil filter allows everything.
+func (f *EntryFilter) Evaluate(ctx context.Context, in *logical.LogInput) (bool, error) {
+	// Events without data have nothing to filter on
+	if f == nil || in == nil || in.Request == nil {
+		return true, nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to get namespace from context: %w", err)
+	}
+
+	datum := &filterData{
+		MountPoint: in.Request.MountPoint,
+		MountType:  in.Request.MountType,
+		Namespace:  ns.Path,
+		Operation:  string(in.Request.Operation),
+		Path:       in.Request.Path,
+	}
+
+	allowed, err := f.evaluator.Evaluate(datum)
+	if err != nil {
+		return false, fmt.Errorf("unable to evaluate filter: %w", err)
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewEntryFilter ensures we can create EntryFilter nodes only from valid
+// filter expressions.
+func TestNewEntryFilter(t *testing.T) {
+	tests := map[string]struct {
+		Filter               string
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-filter": {
+			Filter:               "",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "audit.NewEntryFilter: cannot create new audit filter with empty filter expression: invalid parameter",
+		},
+		"spacey-filter": {
+			Filter:               "    ",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "audit.NewEntryFilter: cannot create new audit filter with empty filter expression: invalid parameter",
+		},
+		"bad-filter": {
+			Filter:          "____",
+			IsErrorExpected: true,
+		},
+		"good-filter": {
+			Filter:          `mount_type == "kv" and operation != "list"`,
+			IsErrorExpected: false,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewEntryFilter(tc.Filter)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				if tc.ExpectedErrorMessage != "" {
+					require.EqualError(t, err, tc.ExpectedErrorMessage)
+				}
+				require.Nil(t, f)
+			default:
+				require.NoError(t, err)
+				require.NotNil(t, f)
+			}
+		})
+	}
+}
+
+// TestEntryFilter_Type ensures we always return the right type for this node.
+func TestEntryFilter_Type(t *testing.T) {
+	f := &EntryFilter{}
+	require.Equal(t, eventlogger.NodeTypeFilter, f.Type())
+}
+
+// TestEntryFilter_Process ensures events are only passed on when they match
+// the filter expression.
+func TestEntryFilter_Process(t *testing.T) {
+	tests := map[string]struct {
+		Filter          string
+		Request         *logical.Request
+		IsFilteredOut   bool
+		IsErrorExpected bool
+	}{
+		"mount-match": {
+			Filter:  `mount_type == "kv"`,
+			Request: &logical.Request{MountType: "kv", Path: "secret/foo"},
+		},
+		"mount-no-match": {
+			Filter:        `mount_type == "kv"`,
+			Request:       &logical.Request{MountType: "pki", Path: "pki/issue/foo"},
+			IsFilteredOut: true,
+		},
+		"path-pattern": {
+			Filter:        `not (path matches "^sys/health" or path == "auth/token/lookup-self")`,
+			Request:       &logical.Request{Path: "auth/token/lookup-self", Operation: logical.ReadOperation},
+			IsFilteredOut: true,
+		},
+		"operation-and-namespace": {
+			Filter:  `operation == "update" and namespace == ""`,
+			Request: &logical.Request{Path: "secret/foo", Operation: logical.UpdateOperation},
+		},
+		"no-request": {
+			Filter:  `mount_type == "kv"`,
+			Request: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewEntryFilter(tc.Filter)
+			require.NoError(t, err)
+
+			e := fakeEvent(t, RequestType, JSONFormat, &logical.LogInput{Request: tc.Request})
+			ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+
+			e2, err := f.Process(ctx, e)
+			require.NoError(t, err)
+			if tc.IsFilteredOut {
+				require.Nil(t, e2)
+			} else {
+				require.Equal(t, e, e2)
+			}
+		})
+	}
+}
+
+// TestEntryFilter_Evaluate_NilFilter ensures a nil filter allows everything.
+func TestEntryFilter_Evaluate_NilFilter(t *testing.T) {
+	var f *EntryFilter
+	allowed, err := f.Evaluate(context.Background(), &logical.LogInput{Request: &logical.Request{Path: "foo"}})
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
@@ -248,6 +248,7 @@ func (f *EntryFormatter) FormatRequest(ctx context.Context, in *logical.LogInput
 			ReplicationCluster:            req.ReplicationCluster,
 			Headers:                       req.Headers,
 			ClientCertificateSerialNumber: getClientCertificateSerialNumber(connState),
+			AdmissionAnnotations:          req.AdmissionAnnotations(),
 		},
 	}
 
@@ -278,6 +279,10 @@ func (f *EntryFormatter) FormatRequest(ctx context.Context, in *logical.LogInput
 		reqEntry.Time = time.Now().UTC().Format(time.RFC3339Nano)
 	}
 
+	if len(f.config.Exclusions) > 0 {
+		return excludeFields(reqEntry, f.config.Exclusions)
+	}
+
 	return reqEntry, nil
 }
 
@@ -448,6 +453,7 @@ func (f *EntryFormatter) FormatResponse(ctx context.Context, in *logical.LogInpu
 			ClientCertificateSerialNumber: getClientCertificateSerialNumber(connState),
 			ReplicationCluster:            req.ReplicationCluster,
 			Headers:                       req.Headers,
+			AdmissionAnnotations:          req.AdmissionAnnotations(),
 		},
 
 		Response: &Response{
@@ -494,11 +500,15 @@ func (f *EntryFormatter) FormatResponse(ctx context.Context, in *logical.LogInpu
 		respEntry.Time = time.Now().UTC().Format(time.RFC3339Nano)
 	}
 
+	if len(f.config.Exclusions) > 0 {
+		return excludeFields(respEntry, f.config.Exclusions)
+	}
+
 	return respEntry, nil
 }
 
 // NewFormatterConfig should be used to create a FormatterConfig.
-// Accepted options: WithElision, WithHMACAccessor, WithOmitTime, WithRaw, WithFormat.
+// Accepted options: WithElision, WithExclusions, WithHMACAccessor, WithOmitTime, WithRaw, WithFormat.
 func NewFormatterConfig(opt ...Option) (FormatterConfig, error) {
 	const op = "audit.NewFormatterConfig"
 
@@ -509,6 +519,7 @@ func NewFormatterConfig(opt ...Option) (FormatterConfig, error) {
 
 	return FormatterConfig{
 		ElideListResponses: opts.withElision,
+		Exclusions:         opts.withExclusions,
 		HMACAccessor:       opts.withHMACAccessor,
 		OmitTime:           opts.withOmitTime,
 		Raw:                opts.withRaw,
@@ -581,6 +592,49 @@ func doElideListResponseData(data map[string]interface{}) {
 	}
 }
 
+// excludeFields returns a copy of the audit entry without the given fields,
+// which are dot separated paths of JSON keys, e.g. "request.data.password".
+// Fields that are not present in the entry are ignored.
+func excludeFields[T RequestEntry | ResponseEntry](entry *T, exclusions []string) (*T, error) {
+	raw, err := jsonutil.EncodeJSON(entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode audit entry to exclude fields: %w", err)
+	}
+
+	var m map[string]interface{}
+	if err := jsonutil.DecodeJSON(raw, &m); err != nil {
+		return nil, fmt.Errorf("unable to decode audit entry to exclude fields: %w", err)
+	}
+
+	for _, field := range exclusions {
+		parts := strings.Split(field, ".")
+		parent := m
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				parent = nil
+				break
+			}
+			parent = child
+		}
+		if parent != nil {
+			delete(parent, parts[len(parts)-1])
+		}
+	}
+
+	raw, err = jsonutil.EncodeJSON(m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode audit entry after excluding fields: %w", err)
+	}
+
+	result := new(T)
+	if err := jsonutil.DecodeJSON(raw, result); err != nil {
+		return nil, fmt.Errorf("unable to decode audit entry after excluding fields: %w", err)
+	}
+
+	return result, nil
+}
+
 // newTemporaryEntryFormatter creates a cloned EntryFormatter instance with a non-persistent Salter.
 func newTemporaryEntryFormatter(n *EntryFormatter) *EntryFormatter {
 	return &EntryFormatter{
@@ -336,6 +336,40 @@ func TestEntryFormatter_Process(t *testing.T) {
 	}
 }
 
+// TestEntryFormatter_FormatResponse_Exclusions ensures excluded fields are
+// removed from formatted entries, while the rest of the entry is kept.
+func TestEntryFormatter_FormatResponse_Exclusions(t *testing.T) {
+	ss := newStaticSalt(t)
+	cfg, err := NewFormatterConfig(WithRaw(true), WithExclusions([]string{"request.data", "response.data.password", "auth.metadata.missing"}))
+	require.NoError(t, err)
+
+	f, err := NewEntryFormatter(cfg, ss)
+	require.NoError(t, err)
+
+	in := &logical.LogInput{
+		Auth: &logical.Auth{Metadata: map[string]string{"role": "foo"}},
+		Request: &logical.Request{
+			Path: "auth/userpass/login/foo",
+			Data: map[string]interface{}{"password": "bar"},
+		},
+		Response: &logical.Response{
+			Data: map[string]interface{}{"username": "foo", "password": "bar"},
+		},
+	}
+	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+
+	entry, err := f.FormatResponse(ctx, in)
+	require.NoError(t, err)
+	require.Nil(t, entry.Request.Data)
+	require.Equal(t, "auth/userpass/login/foo", entry.Request.Path)
+	require.Equal(t, map[string]interface{}{"username": "foo"}, entry.Response.Data)
+	require.Equal(t, map[string]string{"role": "foo"}, entry.Auth.Metadata)
+
+	// The input must not be modified
+	require.Equal(t, "bar", in.Request.Data["password"])
+	require.Equal(t, "bar", in.Response.Data["password"])
+}
+
 // BenchmarkAuditFileSink_Process benchmarks the EntryFormatter and then event.FileSink calling Process.
 // This should replicate the original benchmark testing which used to perform both of these roles together.
 func BenchmarkAuditFileSink_Process(b *testing.B) {
@@ -61,6 +61,10 @@ func ProcessManual(ctx context.Context, data *logical.LogInput, ids []eventlogge
 		}
 
 		switch node.Type() {
+		case eventlogger.NodeTypeFilter:
+			// Test messages are never filtered out, so that the sink can be
+			// checked regardless of the filter.
+			continue
 		case eventlogger.NodeTypeFormatter:
 			// Use a temporary formatter node  which doesn't persist its salt anywhere.
 			if formatNode, ok := node.(*EntryFormatter); ok && formatNode != nil {
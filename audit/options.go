@@ -5,6 +5,7 @@ package audit
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"time"
@@ -147,6 +148,30 @@ func WithHMACAccessor(h bool) Option {
 	}
 }
 
+// WithExclusions provides an Option to represent the fields to exclude from
+// audit entries, as dot separated paths such as "request.data". Empty values are
+// ignored.
+func WithExclusions(e []string) Option {
+	return func(o *options) error {
+		var exclusions []string
+		for _, field := range e {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			for _, part := range strings.Split(field, ".") {
+				if part == "" {
+					return fmt.Errorf("invalid field to exclude: %q", field)
+				}
+			}
+			exclusions = append(exclusions, field)
+		}
+
+		o.withExclusions = exclusions
+		return nil
+	}
+}
+
 // WithHeaderFormatter provides an Option to supply a HeaderFormatter.
 // If the HeaderFormatter interface supplied is nil (type or value), the option will not be applied.
 func WithHeaderFormatter(f HeaderFormatter) Option {
@@ -534,3 +534,46 @@ type testHeaderFormatter struct{}
 func (f *testHeaderFormatter) ApplyConfig(ctx context.Context, headers map[string][]string, salter Salter) (result map[string][]string, retErr error) {
 	return nil, nil
 }
+
+// TestOptions_WithExclusions exercises WithExclusions Option to ensure it performs as expected.
+func TestOptions_WithExclusions(t *testing.T) {
+	tests := map[string]struct {
+		Value                []string
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+		ExpectedValue        []string
+	}{
+		"nil": {
+			Value:         nil,
+			ExpectedValue: nil,
+		},
+		"trimmed": {
+			Value:         []string{" request.data ", "", "response.data.password"},
+			ExpectedValue: []string{"request.data", "response.data.password"},
+		},
+		"empty-segment": {
+			Value:                []string{"request..data"},
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: `invalid field to exclude: "request..data"`,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithExclusions(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withExclusions)
+			}
+		})
+	}
+}
@@ -35,6 +35,16 @@ func (s *SinkWrapper) Reopen() error {
 	return s.Sink.Reopen()
 }
 
+// Close closes this SinkWrapper's sink field if it implements the
+// eventlogger.Closer interface, such as a syslog sink writing to a remote
+// server.
+func (s *SinkWrapper) Close(ctx context.Context) error {
+	if closer, ok := s.Sink.(eventlogger.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
 // Type simply wraps the Type method of this SinkWrapper's sink field without
 // doing any additional work.
 func (s *SinkWrapper) Type() eventlogger.NodeType {
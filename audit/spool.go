@@ -0,0 +1,510 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+// DefaultSpoolMaxSize is the maximum size in bytes of a spool when none is
+// configured.
+const DefaultSpoolMaxSize = 64 * 1024 * 1024
+
+// spoolHeaderSize is the size of the length prefix of each spooled entry.
+const spoolHeaderSize = 4
+
+// spoolOffsetSuffix is the suffix of the file storing the offset of the first
+// entry of a spool which wasn't replayed yet.
+const spoolOffsetSuffix = ".offset"
+
+// ErrSpoolFull is returned when an entry does not fit in the spool.
+var ErrSpoolFull = errors.New("audit spool is full")
+
+var (
+	_ eventlogger.Node   = (*SpoolSink)(nil)
+	_ eventlogger.Closer = (*SpoolSink)(nil)
+)
+
+// Spool is a bounded, file backed queue of formatted audit entries which could
+// not be written to an audit device, so that they can be replayed once the
+// device is available again.
+//
+// Entries are appended to the spool file, and replaying them advances a read
+// offset, persisted in a file next to it, rather than rewriting the spool
+// file. The spool file is emptied once all its entries are replayed, and
+// compacted once most of it was replayed, so that it stays within twice the
+// maximum size. An entry may be replayed twice if Vault stops right after it
+// was replayed, but is never lost.
+type Spool struct {
+	l       sync.Mutex
+	path    string
+	maxSize int64
+
+	// head is the offset of the first entry which wasn't replayed yet, and
+	// end the size of the spool file.
+	head int64
+	end  int64
+}
+
+// NewSpool creates a spool backed by the file at the specified path, which is
+// created if it doesn't exist yet. Entries left in an existing file are kept,
+// so that they are replayed after a restart. A maxSize of zero uses
+// DefaultSpoolMaxSize.
+func NewSpool(path string, maxSize int64) (*Spool, error) {
+	const op = "audit.NewSpool"
+
+	switch {
+	case path == "":
+		return nil, fmt.Errorf("%s: path is required: %w", op, event.ErrInvalidParameter)
+	case maxSize < 0:
+		return nil, fmt.Errorf("%s: invalid max size %d: %w", op, maxSize, event.ErrInvalidParameter)
+	case maxSize == 0:
+		maxSize = DefaultSpoolMaxSize
+	}
+
+	path = filepath.Clean(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to open spool file %q: %w", op, path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to stat spool file %q: %w", op, path, err)
+	}
+
+	s := &Spool{
+		path:    path,
+		maxSize: maxSize,
+		end:     info.Size(),
+	}
+
+	s.head, err = s.readOffset()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if s.head > s.end {
+		// The offset doesn't belong to this spool file, so its entries are
+		// all replayed.
+		s.head = 0
+	}
+
+	// A partially written entry can only be left at the end of the spool
+	// file by a crash, and is dropped so that entries can be appended after
+	// the last complete one.
+	complete, _, err := s.read(f, s.head, s.end, func([]byte) error { return nil })
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to read spool file %q: %w", op, path, err)
+	}
+	if s.head+complete < s.end {
+		if err := os.Truncate(path, s.head+complete); err != nil {
+			return nil, fmt.Errorf("%s: unable to truncate spool file %q: %w", op, path, err)
+		}
+		s.end = s.head + complete
+	}
+
+	return s, nil
+}
+
+// Size returns the number of bytes currently held by the spool.
+func (s *Spool) Size() int64 {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.end - s.head
+}
+
+// Append adds an entry to the end of the spool. ErrSpoolFull is returned when
+// the entry would exceed the maximum size of the spool.
+func (s *Spool) Append(data []byte) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.append(data)
+}
+
+func (s *Spool) append(data []byte) error {
+	if s.end-s.head+spoolHeaderSize+int64(len(data)) > s.maxSize {
+		return ErrSpoolFull
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	record := make([]byte, spoolHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[spoolHeaderSize:], data)
+
+	if _, err := f.Write(record); err != nil {
+		return fmt.Errorf("unable to write to spool file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("unable to sync spool file: %w", err)
+	}
+
+	s.end += int64(len(record))
+	return nil
+}
+
+// Replay writes the spooled entries in order using the supplied function,
+// removing them from the spool. Replay stops at the first entry that cannot be
+// written, which is kept along with the entries after it. The number of
+// replayed entries is returned.
+func (s *Spool) Replay(write func([]byte) error) (int, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.replay(write)
+}
+
+func (s *Spool) replay(write func([]byte) error) (int, error) {
+	if s.head == s.end {
+		return 0, nil
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	consumed, replayed, err := s.read(f, s.head, s.end, write)
+	if consumed > 0 {
+		err = multierror.Append(err, s.advance(consumed)).ErrorOrNil()
+	}
+	return replayed, err
+}
+
+// Drain writes the spooled entries in order using the supplied function and
+// removes them from the spool, like Replay, but without holding the lock of the
+// spool while writing them, so that entries can be appended meanwhile. Drain
+// must not be called concurrently with itself or Replay. The number of drained
+// entries is returned.
+func (s *Spool) Drain(write func([]byte) error) (int, error) {
+	s.l.Lock()
+	if s.head == s.end {
+		s.l.Unlock()
+		return 0, nil
+	}
+	// The spool file is only replaced by Drain and Replay, so it can be read
+	// up to its current end while entries are appended.
+	f, err := os.Open(s.path)
+	head, end := s.head, s.end
+	s.l.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("unable to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	consumed, drained, writeErr := s.read(f, head, end, write)
+	if consumed == 0 {
+		return drained, writeErr
+	}
+
+	// Only entries were appended since the spool file was opened, so the
+	// drained entries are still at the head of the spool.
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return drained, multierror.Append(writeErr, s.advance(consumed)).ErrorOrNil()
+}
+
+// read writes the entries between the from and to offsets of the spool file in
+// order using the supplied function, until one of them can't be written. The
+// number of bytes of the written entries, and their number, are returned. A
+// partially written entry at the end of the file is ignored.
+func (s *Spool) read(f io.ReaderAt, from, to int64, write func([]byte) error) (int64, int, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, from, to-from))
+
+	var consumed int64
+	var n int
+	for {
+		data, err := readSpoolRecord(r, to-from-consumed)
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			return consumed, n, nil
+		case err != nil:
+			return consumed, n, err
+		}
+
+		if err := write(data); err != nil {
+			return consumed, n, err
+		}
+		consumed += spoolHeaderSize + int64(len(data))
+		n++
+	}
+}
+
+// advance removes the given number of bytes of entries from the head of the
+// spool. The spool file is emptied when no entries are left, and compacted
+// when most of it was replayed.
+func (s *Spool) advance(consumed int64) error {
+	s.head += consumed
+
+	switch {
+	case s.head == s.end:
+		if err := s.writeOffset(0); err != nil {
+			return err
+		}
+		if err := os.Truncate(s.path, 0); err != nil {
+			return fmt.Errorf("unable to truncate spool file: %w", err)
+		}
+		s.head, s.end = 0, 0
+		return nil
+	case s.head >= s.end/2:
+		return s.compact()
+	default:
+		return s.writeOffset(s.head)
+	}
+}
+
+// compact replaces the spool file with the entries which weren't replayed yet.
+// Each compaction copies fewer bytes than were replayed since the previous
+// one.
+func (s *Spool) compact() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("unable to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	tmp := s.path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to create spool file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, io.NewSectionReader(f, s.head, s.end-s.head)); err != nil {
+		return fmt.Errorf("unable to write spool file: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("unable to sync spool file: %w", err)
+	}
+
+	// The offset is reset first: should Vault stop before the spool file is
+	// replaced, its entries are replayed again rather than skipped.
+	if err := s.writeOffset(0); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("unable to replace spool file: %w", err)
+	}
+
+	s.end -= s.head
+	s.head = 0
+	return nil
+}
+
+// readOffset returns the offset persisted in the offset file, or zero if there
+// is none.
+func (s *Spool) readOffset() (int64, error) {
+	raw, err := os.ReadFile(s.path + spoolOffsetSuffix)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("unable to read spool offset file: %w", err)
+	case len(raw) != 8:
+		// A partially written offset is ignored, replaying entries again
+		return 0, nil
+	}
+
+	return int64(binary.BigEndian.Uint64(raw)), nil
+}
+
+// writeOffset persists the offset of the head of the spool.
+func (s *Spool) writeOffset(offset int64) error {
+	f, err := os.OpenFile(s.path+spoolOffsetSuffix, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open spool offset file: %w", err)
+	}
+	defer f.Close()
+
+	var raw [8]byte
+	binary.BigEndian.PutUint64(raw[:], uint64(offset))
+	if _, err := f.WriteAt(raw[:], 0); err != nil {
+		return fmt.Errorf("unable to write spool offset file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("unable to sync spool offset file: %w", err)
+	}
+
+	return nil
+}
+
+// readSpoolRecord reads a single length prefixed record, out of the given
+// number of remaining bytes.
+func readSpoolRecord(r io.Reader, remaining int64) ([]byte, error) {
+	var header [spoolHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:])
+	if int64(length) > remaining-spoolHeaderSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// SpoolSink wraps a sink node, spooling the formatted events the sink fails to
+// write, and replaying them before the next event once the sink is available.
+type SpoolSink struct {
+	spool  *Spool
+	format string
+	sink   eventlogger.Node
+}
+
+// NewSpoolSink should be used to create a SpoolSink which spools events in the
+// required format when the supplied sink cannot write them.
+func NewSpoolSink(spool *Spool, format string, sink eventlogger.Node) (*SpoolSink, error) {
+	const op = "audit.NewSpoolSink"
+
+	switch {
+	case spool == nil:
+		return nil, fmt.Errorf("%s: spool is required: %w", op, event.ErrInvalidParameter)
+	case format == "":
+		return nil, fmt.Errorf("%s: format is required: %w", op, event.ErrInvalidParameter)
+	case sink == nil:
+		return nil, fmt.Errorf("%s: sink is required: %w", op, event.ErrInvalidParameter)
+	}
+
+	return &SpoolSink{
+		spool:  spool,
+		format: format,
+		sink:   sink,
+	}, nil
+}
+
+// Process writes the event to the wrapped sink, after replaying any spooled
+// events so that the order of events is kept. When the sink fails, the event
+// is spooled instead, and only an event which doesn't fit in the spool results
+// in an error.
+func (s *SpoolSink) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	const op = "audit.(SpoolSink).Process"
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: event is nil: %w", op, event.ErrInvalidParameter)
+	}
+
+	s.spool.l.Lock()
+	defer s.spool.l.Unlock()
+
+	_, err := s.spool.replay(func(data []byte) error {
+		replayed := &eventlogger.Event{
+			Type:      e.Type,
+			CreatedAt: e.CreatedAt,
+			Payload:   e.Payload,
+		}
+		replayed.FormattedAs(s.format, data)
+
+		_, err := s.sink.Process(ctx, replayed)
+		return err
+	})
+	if err == nil {
+		_, err = s.sink.Process(ctx, e)
+		if err == nil {
+			return nil, nil
+		}
+	}
+
+	formatted, found := e.Format(s.format)
+	if !found {
+		return nil, fmt.Errorf("%s: unable to retrieve event formatted as %q: %w", op, s.format, err)
+	}
+	if spoolErr := s.spool.append(formatted); spoolErr != nil {
+		return nil, fmt.Errorf("%s: unable to spool event: %w", op, multierror.Append(err, spoolErr))
+	}
+
+	// return nil for the event to indicate the pipeline is complete.
+	return nil, nil
+}
+
+// Close closes the wrapped sink, if it implements eventlogger.Closer. The
+// spooled events are written once a sink using the spool is created again.
+func (s *SpoolSink) Close(ctx context.Context) error {
+	if closer, ok := s.sink.(eventlogger.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+// Reopen wraps the Reopen method of the wrapped sink.
+func (s *SpoolSink) Reopen() error {
+	return s.sink.Reopen()
+}
+
+// Type describes the type of this node (sink).
+func (s *SpoolSink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
+
+// SpoolSinkFromConfig wraps the supplied sink in a SpoolSink when the audit
+// device configuration contains the spool_path option, and otherwise returns
+// the sink as is. The spool_max_size option bounds the size of the spool. When
+// the async option is enabled, the sink is wrapped in an AsyncSink using the
+// spool as its buffer instead.
+func SpoolSinkFromConfig(config map[string]string, format string, sink eventlogger.Node) (eventlogger.Node, error) {
+	var async bool
+	if raw, ok := config["async"]; ok {
+		var err error
+		async, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid async: %w", err)
+		}
+	}
+
+	path, ok := config["spool_path"]
+	if !ok || path == "" {
+		if async {
+			return nil, fmt.Errorf("spool_path is required when async is enabled")
+		}
+		return sink, nil
+	}
+
+	var maxSize uint64
+	if raw, ok := config["spool_max_size"]; ok {
+		var err error
+		maxSize, err = parseutil.ParseCapacityString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spool_max_size: %w", err)
+		}
+	}
+
+	spool, err := NewSpool(path, int64(maxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if async {
+		return NewAsyncSink(spool, format, sink)
+	}
+
+	return NewSpoolSink(spool, format, sink)
+}
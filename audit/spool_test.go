@@ -0,0 +1,284 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/stretchr/testify/require"
+)
+
+// testSink is a sink node which records the formatted events it writes, and
+// can be made to fail.
+type testSink struct {
+	l       sync.Mutex
+	err     error
+	written []string
+}
+
+func (s *testSink) Process(_ context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	formatted, _ := e.Format(JSONFormat.String())
+	s.written = append(s.written, string(formatted))
+	return nil, nil
+}
+
+// setErr makes the sink fail with the error, or succeed if it is nil.
+func (s *testSink) setErr(err error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.err = err
+}
+
+// writtenEvents returns the events written so far.
+func (s *testSink) writtenEvents() []string {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return append([]string(nil), s.written...)
+}
+
+func (s *testSink) Reopen() error {
+	return nil
+}
+
+func (s *testSink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
+
+// TestNewSpool ensures that a spool can only be created with valid parameters,
+// and that entries are kept across instances.
+func TestNewSpool(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSpool("", 0)
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+
+	path := filepath.Join(t.TempDir(), "spool")
+	_, err = NewSpool(path, -1)
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+
+	_, err = NewSpool(filepath.Join(path, "missing", "spool"), 0)
+	require.Error(t, err)
+
+	s, err := NewSpool(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(DefaultSpoolMaxSize), s.maxSize)
+	require.NoError(t, s.Append([]byte("foo")))
+
+	s, err = NewSpool(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(spoolHeaderSize+3), s.Size())
+}
+
+// TestSpool_Replay ensures entries are replayed in order, and that entries
+// which could not be replayed are kept.
+func TestSpool_Replay(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 2*(spoolHeaderSize+3))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append([]byte("foo")))
+	require.NoError(t, s.Append([]byte("bar")))
+	require.ErrorIs(t, s.Append([]byte("baz")), ErrSpoolFull)
+
+	var replayed []string
+	n, err := s.Replay(func(data []byte) error {
+		if len(replayed) == 1 {
+			return errors.New("unavailable")
+		}
+		replayed = append(replayed, string(data))
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"foo"}, replayed)
+	require.Equal(t, int64(spoolHeaderSize+3), s.Size())
+
+	n, err = s.Replay(func(data []byte) error {
+		replayed = append(replayed, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"foo", "bar"}, replayed)
+	require.Zero(t, s.Size())
+}
+
+// TestSpool_Offset ensures replayed entries are skipped after a restart
+// without rewriting the spool file, that a partially written entry is dropped,
+// and that the spool file is emptied once all its entries are replayed.
+func TestSpool_Offset(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "spool")
+	s, err := NewSpool(path, 0)
+	require.NoError(t, err)
+
+	for _, data := range []string{"foo", "bar", "baz", "qux"} {
+		require.NoError(t, s.Append([]byte(data)))
+	}
+
+	var replayed []string
+	n, err := s.Replay(func(data []byte) error {
+		if len(replayed) == 1 {
+			return errors.New("unavailable")
+		}
+		replayed = append(replayed, string(data))
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(4*(spoolHeaderSize+3)), info.Size())
+
+	// Simulate a crash while appending an entry
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 3, 'q'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = NewSpool(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3*(spoolHeaderSize+3)), s.Size())
+
+	n, err = s.Replay(func(data []byte) error {
+		replayed = append(replayed, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []string{"foo", "bar", "baz", "qux"}, replayed)
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+}
+
+// TestSpool_Drain ensures entries are drained in order, that entries can be
+// appended while draining, and that entries which could not be written are
+// kept.
+func TestSpool_Drain(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append([]byte("foo")))
+	require.NoError(t, s.Append([]byte("bar")))
+
+	var drained []string
+	n, err := s.Drain(func(data []byte) error {
+		if len(drained) == 1 {
+			return errors.New("unavailable")
+		}
+		drained = append(drained, string(data))
+		return s.Append([]byte("baz"))
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"foo"}, drained)
+	require.Equal(t, int64(2*(spoolHeaderSize+3)), s.Size())
+
+	n, err = s.Drain(func(data []byte) error {
+		drained = append(drained, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{"foo", "bar", "baz"}, drained)
+	require.Zero(t, s.Size())
+}
+
+// TestSpoolSink_Process ensures events are spooled while the sink fails, and
+// written in order once it recovers.
+func TestSpoolSink_Process(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	require.NoError(t, err)
+
+	sink := &testSink{err: errors.New("unavailable")}
+	spoolSink, err := NewSpoolSink(s, JSONFormat.String(), sink)
+	require.NoError(t, err)
+	require.Equal(t, eventlogger.NodeTypeSink, spoolSink.Type())
+
+	newEvent := func(data string) *eventlogger.Event {
+		e := &eventlogger.Event{
+			Type:      eventlogger.EventType(event.AuditType.String()),
+			CreatedAt: time.Now(),
+		}
+		e.FormattedAs(JSONFormat.String(), []byte(data))
+		return e
+	}
+
+	ctx := context.Background()
+	_, err = spoolSink.Process(ctx, newEvent("foo"))
+	require.NoError(t, err)
+	_, err = spoolSink.Process(ctx, newEvent("bar"))
+	require.NoError(t, err)
+	require.Empty(t, sink.written)
+	require.NotZero(t, s.Size())
+
+	sink.err = nil
+	_, err = spoolSink.Process(ctx, newEvent("baz"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "bar", "baz"}, sink.written)
+	require.Zero(t, s.Size())
+}
+
+// TestNewSpoolSink ensures a SpoolSink can only be created with valid
+// parameters.
+func TestNewSpoolSink(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	require.NoError(t, err)
+
+	_, err = NewSpoolSink(nil, JSONFormat.String(), &testSink{})
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+	_, err = NewSpoolSink(s, "", &testSink{})
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+	_, err = NewSpoolSink(s, JSONFormat.String(), nil)
+	require.ErrorIs(t, err, event.ErrInvalidParameter)
+
+	sink := &testSink{}
+	node, err := SpoolSinkFromConfig(map[string]string{}, JSONFormat.String(), sink)
+	require.NoError(t, err)
+	require.Equal(t, sink, node)
+
+	_, err = SpoolSinkFromConfig(map[string]string{"spool_path": s.path, "spool_max_size": "bogus"}, JSONFormat.String(), sink)
+	require.Error(t, err)
+
+	node, err = SpoolSinkFromConfig(map[string]string{"spool_path": s.path, "spool_max_size": "1MiB"}, JSONFormat.String(), sink)
+	require.NoError(t, err)
+	require.Equal(t, int64(1024*1024), node.(*SpoolSink).spool.maxSize)
+
+	_, err = SpoolSinkFromConfig(map[string]string{"async": "bogus", "spool_path": s.path}, JSONFormat.String(), sink)
+	require.Error(t, err)
+
+	_, err = SpoolSinkFromConfig(map[string]string{"async": "true"}, JSONFormat.String(), sink)
+	require.Error(t, err)
+
+	node, err = SpoolSinkFromConfig(map[string]string{"async": "true", "spool_path": s.path}, JSONFormat.String(), sink)
+	require.NoError(t, err)
+	require.IsType(t, &AsyncSink{}, node)
+	require.NoError(t, node.(*AsyncSink).Close(context.Background()))
+}
@@ -59,6 +59,7 @@ type options struct {
 	withOmitTime        bool
 	withHMACAccessor    bool
 	withHeaderFormatter HeaderFormatter
+	withExclusions      []string
 }
 
 // Salter is an interface that provides a way to obtain a Salt for hashing.
@@ -140,6 +141,10 @@ type FormatterConfig struct {
 	// This should only ever be used in a testing context
 	OmitTime bool
 
+	// Exclusions are the fields removed from audit entries once formatted, as
+	// dot separated paths such as "request.data" or "response.data.password".
+	Exclusions []string
+
 	// The required/target format for the event (supported: JSONFormat and JSONxFormat).
 	RequiredFormat format
 }
@@ -188,6 +193,7 @@ type Request struct {
 	WrapTTL                       int                    `json:"wrap_ttl,omitempty"`
 	Headers                       map[string][]string    `json:"headers,omitempty"`
 	ClientCertificateSerialNumber string                 `json:"client_certificate_serial_number,omitempty"`
+	AdmissionAnnotations          map[string]string      `json:"admission_annotations,omitempty"`
 }
 
 type Response struct {
@@ -292,6 +298,10 @@ type Backend interface {
 	// Invalidate is called for path invalidation
 	Invalidate(context.Context)
 
+	// HasFiltering reports whether the backend is configured to filter the
+	// requests and responses it logs.
+	HasFiltering() bool
+
 	// RegisterNodesAndPipeline provides an eventlogger.Broker pointer so that
 	// the Backend can call its RegisterNode and RegisterPipeline methods with
 	// the nodes and the pipeline that were created in the corresponding
@@ -313,6 +323,9 @@ type BackendConfig struct {
 
 	// MountPath is the path where this Backend is mounted
 	MountPath string
+
+	// ClusterName is the name of the cluster the Backend belongs to
+	ClusterName string
 }
 
 // Factory is the factory function to create an audit backend.
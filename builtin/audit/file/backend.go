@@ -83,6 +83,10 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 		cfgOpts = append(cfgOpts, audit.WithElision(v))
 	}
 
+	if exclude, ok := conf.Config["exclude"]; ok {
+		cfgOpts = append(cfgOpts, audit.WithExclusions(strings.Split(exclude, ",")))
+	}
+
 	mode := os.FileMode(0o600)
 	if modeRaw, ok := conf.Config["mode"]; ok {
 		m, err := strconv.ParseUint(modeRaw, 8, 32)
@@ -143,16 +147,37 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 	}
 	b.formatter = fw
 
+	if filter, ok := conf.Config["filter"]; ok && strings.TrimSpace(filter) != "" {
+		b.filter, err = audit.NewEntryFilter(filter)
+		if err != nil {
+			return nil, fmt.Errorf("error creating filter: %w", err)
+		}
+	}
+
+	// Spooling and asynchronous logging are only supported by the event logger
+	if !useEventLogger && (conf.Config["spool_path"] != "" || conf.Config["async"] != "") {
+		return nil, fmt.Errorf("spool_path and async are not supported when the event logger is disabled")
+	}
+
 	if useEventLogger {
-		b.nodeIDList = make([]eventlogger.NodeID, 2)
+		b.nodeIDList = make([]eventlogger.NodeID, 0, 3)
 		b.nodeMap = make(map[eventlogger.NodeID]eventlogger.Node)
 
+		if b.filter != nil {
+			filterNodeID, err := event.GenerateNodeID()
+			if err != nil {
+				return nil, fmt.Errorf("error generating random NodeID for filter node: %w", err)
+			}
+			b.nodeIDList = append(b.nodeIDList, filterNodeID)
+			b.nodeMap[filterNodeID] = b.filter
+		}
+
 		formatterNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for formatter node: %w", err)
 		}
 
-		b.nodeIDList[0] = formatterNodeID
+		b.nodeIDList = append(b.nodeIDList, formatterNodeID)
 		b.nodeMap[formatterNodeID] = f
 
 		var sinkNode eventlogger.Node
@@ -182,12 +207,17 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 			sinkNode = &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
 		}
 
+		sinkNode, err = audit.SpoolSinkFromConfig(conf.Config, b.formatConfig.RequiredFormat.String(), sinkNode)
+		if err != nil {
+			return nil, fmt.Errorf("error creating spool: %w", err)
+		}
+
 		sinkNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for sink node: %w", err)
 		}
 
-		b.nodeIDList[1] = sinkNodeID
+		b.nodeIDList = append(b.nodeIDList, sinkNodeID)
 		b.nodeMap[sinkNodeID] = sinkNode
 	} else {
 		switch path {
@@ -216,6 +246,7 @@ type Backend struct {
 
 	formatter    *audit.EntryFormatterWriter
 	formatConfig audit.FormatterConfig
+	filter       *audit.EntryFilter
 
 	fileLock sync.RWMutex
 	f        *os.File
@@ -257,6 +288,10 @@ func (b *Backend) Salt(ctx context.Context) (*salt.Salt, error) {
 }
 
 func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var writer io.Writer
 	switch b.path {
 	case stdout:
@@ -313,6 +348,10 @@ func (b *Backend) log(_ context.Context, buf *bytes.Buffer, writer io.Writer) er
 }
 
 func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var writer io.Writer
 	switch b.path {
 	case stdout:
@@ -420,6 +459,12 @@ func (b *Backend) Invalidate(_ context.Context) {
 	b.salt.Store((*salt.Salt)(nil))
 }
 
+// HasFiltering reports whether the backend is configured with a filter, in
+// which case it may not log every request and response.
+func (b *Backend) HasFiltering() bool {
+	return b.filter != nil
+}
+
 // RegisterNodesAndPipeline registers the nodes and a pipeline as required by
 // the audit.Backend interface.
 func (b *Backend) RegisterNodesAndPipeline(broker *eventlogger.Broker, name string) error {
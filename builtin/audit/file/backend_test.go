@@ -9,11 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/internal/observability/event"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -162,6 +165,63 @@ func TestAuditFile_EventLogger_fileModeNew(t *testing.T) {
 	}
 }
 
+// TestAuditFile_EventLogger_async verifies that entries logged by a backend
+// configured with the async option are written to the file once its pipeline
+// is removed, at the latest.
+func TestAuditFile_EventLogger_async(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "audit.log")
+
+	backend, err := Factory(context.Background(), &audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config: map[string]string{
+			"path":       file,
+			"async":      "true",
+			"spool_path": filepath.Join(dir, "spool"),
+		},
+		MountPath: "file/",
+	}, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker, err := eventlogger.NewBroker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.RegisterNodesAndPipeline(broker, "file/"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := namespace.RootContext(context.Background())
+	e, err := audit.NewEvent(audit.RequestType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Data = &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "sys/mounts",
+		},
+	}
+	if _, err := broker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.RemovePipelineAndNodes(ctx, eventlogger.EventType(event.AuditType.String()), "file/"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "sys/mounts") {
+		t.Fatalf("expected the request to be written to the file, got %q", content)
+	}
+}
+
 func BenchmarkAuditFile_request(b *testing.B) {
 	config := map[string]string{
 		"path": "/dev/null",
@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool, headersConfig audit.HeaderFormatter) (audit.Backend, error) {
+	if conf.SaltConfig == nil {
+		return nil, fmt.Errorf("nil salt config")
+	}
+	if conf.SaltView == nil {
+		return nil, fmt.Errorf("nil salt view")
+	}
+
+	endpoint, ok := conf.Config["endpoint"]
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint: scheme must be http or https")
+	}
+
+	timeout, ok := conf.Config["timeout"]
+	if !ok {
+		timeout = "10s"
+	}
+	timeoutDuration, err := parseutil.ParseDurationSecond(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	if raw, ok := conf.Config["headers"]; ok {
+		for _, header := range strings.Split(raw, ",") {
+			if strings.TrimSpace(header) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(header, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				return nil, fmt.Errorf("invalid header %q: headers must be in key=value format", header)
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+
+	// Log records hold the JSON formatted audit entry, which is parsed to
+	// describe the record.
+	if format, ok := conf.Config["format"]; ok && format != audit.JSONFormat.String() {
+		return nil, fmt.Errorf("unsupported format %q: the otlp audit device only supports the json format", format)
+	}
+	if conf.Config["prefix"] != "" {
+		return nil, fmt.Errorf("prefix is not supported by the otlp audit device")
+	}
+
+	var cfgOpts []audit.Option
+
+	// Check if hashing of accessor is disabled
+	if hmacAccessorRaw, ok := conf.Config["hmac_accessor"]; ok {
+		v, err := strconv.ParseBool(hmacAccessorRaw)
+		if err != nil {
+			return nil, err
+		}
+		cfgOpts = append(cfgOpts, audit.WithHMACAccessor(v))
+	}
+
+	// Check if raw logging is enabled
+	if raw, ok := conf.Config["log_raw"]; ok {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		cfgOpts = append(cfgOpts, audit.WithRaw(v))
+	}
+
+	if elideListResponsesRaw, ok := conf.Config["elide_list_responses"]; ok {
+		v, err := strconv.ParseBool(elideListResponsesRaw)
+		if err != nil {
+			return nil, err
+		}
+		cfgOpts = append(cfgOpts, audit.WithElision(v))
+	}
+
+	if exclude, ok := conf.Config["exclude"]; ok {
+		cfgOpts = append(cfgOpts, audit.WithExclusions(strings.Split(exclude, ",")))
+	}
+
+	cfg, err := audit.NewFormatterConfig(cfgOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = timeoutDuration
+	if caFile, ok := conf.Config["tls_ca_file"]; ok && caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tls_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls_ca_file %q", caFile)
+		}
+		client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    pool,
+		}
+	}
+
+	b := &Backend{
+		saltConfig:   conf.SaltConfig,
+		saltView:     conf.SaltView,
+		formatConfig: cfg,
+
+		exporter: &exporter{
+			client:      client,
+			endpoint:    endpoint,
+			headers:     headers,
+			clusterName: conf.ClusterName,
+			devicePath:  conf.MountPath,
+		},
+	}
+
+	// Configure the formatter for either case.
+	f, err := audit.NewEntryFormatter(b.formatConfig, b, audit.WithHeaderFormatter(headersConfig))
+	if err != nil {
+		return nil, fmt.Errorf("error creating formatter: %w", err)
+	}
+
+	fw, err := audit.NewEntryFormatterWriter(b.formatConfig, f, &audit.JSONWriter{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating formatter writer: %w", err)
+	}
+
+	b.formatter = fw
+
+	if filter, ok := conf.Config["filter"]; ok && strings.TrimSpace(filter) != "" {
+		b.filter, err = audit.NewEntryFilter(filter)
+		if err != nil {
+			return nil, fmt.Errorf("error creating filter: %w", err)
+		}
+	}
+
+	// Spooling and asynchronous logging are only supported by the event logger
+	if !useEventLogger && (conf.Config["spool_path"] != "" || conf.Config["async"] != "") {
+		return nil, fmt.Errorf("spool_path and async are not supported when the event logger is disabled")
+	}
+
+	if useEventLogger {
+		b.nodeIDList = make([]eventlogger.NodeID, 0, 3)
+		b.nodeMap = make(map[eventlogger.NodeID]eventlogger.Node)
+
+		if b.filter != nil {
+			filterNodeID, err := event.GenerateNodeID()
+			if err != nil {
+				return nil, fmt.Errorf("error generating random NodeID for filter node: %w", err)
+			}
+			b.nodeIDList = append(b.nodeIDList, filterNodeID)
+			b.nodeMap[filterNodeID] = b.filter
+		}
+
+		formatterNodeID, err := event.GenerateNodeID()
+		if err != nil {
+			return nil, fmt.Errorf("error generating random NodeID for formatter node: %w", err)
+		}
+		b.nodeIDList = append(b.nodeIDList, formatterNodeID)
+		b.nodeMap[formatterNodeID] = f
+
+		n := &sink{exporter: b.exporter, requiredFormat: b.formatConfig.RequiredFormat.String()}
+		var sinkNode eventlogger.Node = &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
+		sinkNode, err = audit.SpoolSinkFromConfig(conf.Config, b.formatConfig.RequiredFormat.String(), sinkNode)
+		if err != nil {
+			return nil, fmt.Errorf("error creating spool: %w", err)
+		}
+
+		sinkNodeID, err := event.GenerateNodeID()
+		if err != nil {
+			return nil, fmt.Errorf("error generating random NodeID for sink node: %w", err)
+		}
+		b.nodeIDList = append(b.nodeIDList, sinkNodeID)
+		b.nodeMap[sinkNodeID] = sinkNode
+	}
+
+	return b, nil
+}
+
+// Backend is the audit backend for the OpenTelemetry (OTLP) logs transport.
+type Backend struct {
+	exporter *exporter
+
+	formatter    *audit.EntryFormatterWriter
+	formatConfig audit.FormatterConfig
+	filter       *audit.EntryFilter
+
+	saltMutex  sync.RWMutex
+	salt       *salt.Salt
+	saltConfig *salt.Config
+	saltView   logical.Storage
+
+	nodeIDList []eventlogger.NodeID
+	nodeMap    map[eventlogger.NodeID]eventlogger.Node
+}
+
+var _ audit.Backend = (*Backend)(nil)
+
+func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := b.formatter.FormatAndWriteRequest(ctx, &buf, in); err != nil {
+		return err
+	}
+
+	return b.exporter.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := b.formatter.FormatAndWriteResponse(ctx, &buf, in); err != nil {
+		return err
+	}
+
+	return b.exporter.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) LogTestMessage(ctx context.Context, in *logical.LogInput, config map[string]string) error {
+	// Event logger behavior - manually Process each node
+	if len(b.nodeIDList) > 0 {
+		return audit.ProcessManual(ctx, in, b.nodeIDList, b.nodeMap)
+	}
+
+	// Old behavior
+	var buf bytes.Buffer
+
+	temporaryFormatter, err := audit.NewTemporaryFormatter(config["format"], config["prefix"])
+	if err != nil {
+		return err
+	}
+
+	if err = temporaryFormatter.FormatAndWriteRequest(ctx, &buf, in); err != nil {
+		return err
+	}
+
+	return b.exporter.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) Reload(_ context.Context) error {
+	return nil
+}
+
+func (b *Backend) Salt(ctx context.Context) (*salt.Salt, error) {
+	b.saltMutex.RLock()
+	if b.salt != nil {
+		defer b.saltMutex.RUnlock()
+		return b.salt, nil
+	}
+	b.saltMutex.RUnlock()
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	if b.salt != nil {
+		return b.salt, nil
+	}
+	salt, err := salt.NewSalt(ctx, b.saltView, b.saltConfig)
+	if err != nil {
+		return nil, err
+	}
+	b.salt = salt
+	return salt, nil
+}
+
+func (b *Backend) Invalidate(_ context.Context) {
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	b.salt = nil
+}
+
+// HasFiltering reports whether the backend is configured with a filter, in
+// which case it may not log every request and response.
+func (b *Backend) HasFiltering() bool {
+	return b.filter != nil
+}
+
+// RegisterNodesAndPipeline registers the nodes and a pipeline as required by
+// the audit.Backend interface.
+func (b *Backend) RegisterNodesAndPipeline(broker *eventlogger.Broker, name string) error {
+	for id, node := range b.nodeMap {
+		if err := broker.RegisterNode(id, node); err != nil {
+			return err
+		}
+	}
+
+	pipeline := eventlogger.Pipeline{
+		PipelineID: eventlogger.PipelineID(name),
+		EventType:  eventlogger.EventType(event.AuditType.String()),
+		NodeIDs:    b.nodeIDList,
+	}
+
+	return broker.RegisterPipeline(pipeline)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testCollector is an OTLP/HTTP logs endpoint which records the export
+// requests it receives.
+type testCollector struct {
+	l        sync.Mutex
+	requests []otlpExportLogsRequest
+	headers  []http.Header
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpExportLogsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header)
+}
+
+func attributes(kvs []otlpKeyValue) map[string]string {
+	attrs := make(map[string]string)
+	for _, kv := range kvs {
+		attrs[kv.Key] = kv.Value.StringValue
+	}
+	return attrs
+}
+
+func testBackendConfig(config map[string]string) *audit.BackendConfig {
+	return &audit.BackendConfig{
+		SaltConfig:  &salt.Config{},
+		SaltView:    &logical.InmemStorage{},
+		Config:      config,
+		MountPath:   "otlp/",
+		ClusterName: "vault-cluster-test",
+	}
+}
+
+// TestFactory_Config ensures invalid configuration is rejected.
+func TestFactory_Config(t *testing.T) {
+	tests := map[string]map[string]string{
+		"missing-endpoint": {},
+		"invalid-scheme":   {"endpoint": "tcp://localhost:4318"},
+		"invalid-headers":  {"endpoint": "http://localhost:4318/v1/logs", "headers": "foo"},
+		"invalid-timeout":  {"endpoint": "http://localhost:4318/v1/logs", "timeout": "bogus"},
+		"jsonx-format":     {"endpoint": "http://localhost:4318/v1/logs", "format": "jsonx"},
+		"prefix":           {"endpoint": "http://localhost:4318/v1/logs", "prefix": "foo"},
+		"missing-ca-file":  {"endpoint": "https://localhost:4318/v1/logs", "tls_ca_file": "/nonexistent"},
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Factory(context.Background(), testBackendConfig(config), true, nil)
+			require.Error(t, err)
+		})
+	}
+}
+
+// TestBackend_LogRequest ensures requests are exported as log records with the
+// cluster, namespace and mount as resource attributes, for both the event
+// logger and the legacy behavior.
+func TestBackend_LogRequest(t *testing.T) {
+	for _, useEventLogger := range []bool{true, false} {
+		collector := &testCollector{}
+		srv := httptest.NewServer(collector)
+		defer srv.Close()
+
+		config := map[string]string{
+			"endpoint": srv.URL + "/v1/logs",
+			"headers":  "Authorization=Bearer foo",
+		}
+		b, err := Factory(context.Background(), testBackendConfig(config), useEventLogger, nil)
+		require.NoError(t, err)
+
+		ctx := namespace.RootContext(context.Background())
+		in := &logical.LogInput{
+			Request: &logical.Request{
+				ID:         "request-id",
+				Operation:  logical.ReadOperation,
+				Path:       "secret/foo",
+				MountPoint: "secret/",
+				MountType:  "kv",
+			},
+		}
+		require.NoError(t, b.LogTestMessage(ctx, in, config))
+
+		collector.l.Lock()
+		require.Len(t, collector.requests, 1)
+		require.Equal(t, "Bearer foo", collector.headers[0].Get("Authorization"))
+		require.Equal(t, "application/json", collector.headers[0].Get("Content-Type"))
+
+		resourceLogs := collector.requests[0].ResourceLogs
+		require.Len(t, resourceLogs, 1)
+		require.Equal(t, map[string]string{
+			"service.name":         "vault",
+			"vault.cluster.name":   "vault-cluster-test",
+			"vault.namespace.id":   "root",
+			"vault.namespace.path": "",
+			"vault.mount.path":     "secret/",
+			"vault.mount.type":     "kv",
+		}, attributes(resourceLogs[0].Resource.Attributes))
+
+		records := resourceLogs[0].ScopeLogs[0].LogRecords
+		require.Len(t, records, 1)
+		require.Equal(t, map[string]string{
+			"vault.audit.type":   "request",
+			"vault.audit.device": "otlp/",
+		}, attributes(records[0].Attributes))
+		require.NotEmpty(t, records[0].TimeUnixNano)
+
+		var entry audit.RequestEntry
+		require.NoError(t, json.Unmarshal([]byte(records[0].Body.StringValue), &entry))
+		require.Equal(t, "secret/foo", entry.Request.Path)
+		collector.l.Unlock()
+	}
+}
+
+// TestBackend_LogRequest_Error ensures export failures are reported.
+func TestBackend_LogRequest_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	config := map[string]string{"endpoint": srv.URL}
+	b, err := Factory(context.Background(), testBackendConfig(config), false, nil)
+	require.NoError(t, err)
+
+	ctx := namespace.RootContext(context.Background())
+	err = b.LogRequest(ctx, &logical.LogInput{Request: &logical.Request{Path: "secret/foo"}})
+	require.ErrorContains(t, err, "status 503")
+}
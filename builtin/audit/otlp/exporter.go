@@ -0,0 +1,227 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+const (
+	// scopeName is the instrumentation scope of the exported log records.
+	scopeName = "github.com/hashicorp/vault/builtin/audit/otlp"
+
+	// severityNumberInfo is the OTLP severity number of the INFO level.
+	severityNumberInfo = 9
+)
+
+// exporter exports audit entries as OTLP log records, using the JSON encoding
+// of the OTLP/HTTP protocol.
+type exporter struct {
+	client      *http.Client
+	endpoint    string
+	headers     map[string]string
+	clusterName string
+	devicePath  string
+}
+
+// auditEntry holds the properties of a formatted audit entry the log records
+// are described with.
+type auditEntry struct {
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Request *struct {
+		MountPoint string `json:"mount_point"`
+		MountType  string `json:"mount_type"`
+		Namespace  *struct {
+			ID   string `json:"id"`
+			Path string `json:"path"`
+		} `json:"namespace"`
+	} `json:"request"`
+}
+
+// export sends the JSON formatted audit entry to the OTLP endpoint as a log
+// record. The resource of the record describes the cluster, namespace and
+// mount of the audited request.
+func (e *exporter) export(ctx context.Context, formatted []byte) error {
+	var entry auditEntry
+	if err := json.Unmarshal(formatted, &entry); err != nil {
+		return fmt.Errorf("unable to parse audit entry: %w", err)
+	}
+
+	observed := time.Now()
+	timestamp := observed
+	if t, err := time.Parse(time.RFC3339Nano, entry.Time); err == nil {
+		timestamp = t
+	}
+
+	resourceAttrs := []otlpKeyValue{
+		stringAttribute("service.name", "vault"),
+	}
+	if e.clusterName != "" {
+		resourceAttrs = append(resourceAttrs, stringAttribute("vault.cluster.name", e.clusterName))
+	}
+	if req := entry.Request; req != nil {
+		if req.Namespace != nil {
+			resourceAttrs = append(resourceAttrs,
+				stringAttribute("vault.namespace.id", req.Namespace.ID),
+				stringAttribute("vault.namespace.path", req.Namespace.Path))
+		}
+		if req.MountPoint != "" {
+			resourceAttrs = append(resourceAttrs, stringAttribute("vault.mount.path", req.MountPoint))
+		}
+		if req.MountType != "" {
+			resourceAttrs = append(resourceAttrs, stringAttribute("vault.mount.type", req.MountType))
+		}
+	}
+
+	payload := otlpExportLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: resourceAttrs},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope: otlpScope{Name: scopeName},
+				LogRecords: []otlpLogRecord{{
+					TimeUnixNano:         strconv.FormatInt(timestamp.UnixNano(), 10),
+					ObservedTimeUnixNano: strconv.FormatInt(observed.UnixNano(), 10),
+					SeverityNumber:       severityNumberInfo,
+					SeverityText:         "INFO",
+					Body:                 otlpAnyValue{StringValue: string(bytes.TrimSpace(formatted))},
+					Attributes: []otlpKeyValue{
+						stringAttribute("vault.audit.type", entry.Type),
+						stringAttribute("vault.audit.device", e.devicePath),
+					},
+				}},
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to encode log record: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to export log record: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so that the connection can be reused
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unable to export log record: endpoint responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	return nil
+}
+
+var _ eventlogger.Node = (*sink)(nil)
+
+// sink is a sink node which exports events as OTLP log records.
+type sink struct {
+	exporter       *exporter
+	requiredFormat string
+}
+
+// Process exports the formatted event.
+func (s *sink) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	const op = "otlp.(sink).Process"
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: event is nil: %w", op, event.ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(s.requiredFormat)
+	if !found {
+		return nil, fmt.Errorf("%s: unable to retrieve event formatted as %q", op, s.requiredFormat)
+	}
+
+	if err := s.exporter.export(ctx, formatted); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// return nil for the event to indicate the pipeline is complete.
+	return nil, nil
+}
+
+// Reopen is a no-op for the OTLP sink.
+func (s *sink) Reopen() error {
+	return nil
+}
+
+// Type describes the type of this node (sink).
+func (s *sink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
+
+// The following types are the JSON encoding of the OTLP logs export request.
+
+type otlpExportLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -80,6 +81,10 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 		cfgOpts = append(cfgOpts, audit.WithElision(v))
 	}
 
+	if exclude, ok := conf.Config["exclude"]; ok {
+		cfgOpts = append(cfgOpts, audit.WithExclusions(strings.Split(exclude, ",")))
+	}
+
 	cfg, err := audit.NewFormatterConfig(cfgOpts...)
 	if err != nil {
 		return nil, err
@@ -115,6 +120,18 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 
 	b.formatter = fw
 
+	if filter, ok := conf.Config["filter"]; ok && strings.TrimSpace(filter) != "" {
+		b.filter, err = audit.NewEntryFilter(filter)
+		if err != nil {
+			return nil, fmt.Errorf("error creating filter: %w", err)
+		}
+	}
+
+	// Spooling and asynchronous logging are only supported by the event logger
+	if !useEventLogger && (conf.Config["spool_path"] != "" || conf.Config["async"] != "") {
+		return nil, fmt.Errorf("spool_path and async are not supported when the event logger is disabled")
+	}
+
 	if useEventLogger {
 		var opts []event.Option
 
@@ -126,26 +143,40 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 			opts = append(opts, event.WithMaxDuration(writeDeadline))
 		}
 
-		b.nodeIDList = make([]eventlogger.NodeID, 2)
+		b.nodeIDList = make([]eventlogger.NodeID, 0, 3)
 		b.nodeMap = make(map[eventlogger.NodeID]eventlogger.Node)
 
+		if b.filter != nil {
+			filterNodeID, err := event.GenerateNodeID()
+			if err != nil {
+				return nil, fmt.Errorf("error generating random NodeID for filter node: %w", err)
+			}
+			b.nodeIDList = append(b.nodeIDList, filterNodeID)
+			b.nodeMap[filterNodeID] = b.filter
+		}
+
 		formatterNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for formatter node: %w", err)
 		}
-		b.nodeIDList[0] = formatterNodeID
+		b.nodeIDList = append(b.nodeIDList, formatterNodeID)
 		b.nodeMap[formatterNodeID] = f
 
 		n, err := event.NewSocketSink(b.formatConfig.RequiredFormat.String(), address, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("error creating socket sink node: %w", err)
 		}
-		sinkNode := &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
+		var sinkNode eventlogger.Node = &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
+		sinkNode, err = audit.SpoolSinkFromConfig(conf.Config, b.formatConfig.RequiredFormat.String(), sinkNode)
+		if err != nil {
+			return nil, fmt.Errorf("error creating spool: %w", err)
+		}
+
 		sinkNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for sink node: %w", err)
 		}
-		b.nodeIDList[1] = sinkNodeID
+		b.nodeIDList = append(b.nodeIDList, sinkNodeID)
 		b.nodeMap[sinkNodeID] = sinkNode
 	}
 
@@ -158,6 +189,7 @@ type Backend struct {
 
 	formatter    *audit.EntryFormatterWriter
 	formatConfig audit.FormatterConfig
+	filter       *audit.EntryFilter
 
 	writeDuration time.Duration
 	address       string
@@ -177,6 +209,10 @@ type Backend struct {
 var _ audit.Backend = (*Backend)(nil)
 
 func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var buf bytes.Buffer
 	if err := b.formatter.FormatAndWriteRequest(ctx, &buf, in); err != nil {
 		return err
@@ -200,6 +236,10 @@ func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
 }
 
 func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var buf bytes.Buffer
 	if err := b.formatter.FormatAndWriteResponse(ctx, &buf, in); err != nil {
 		return err
@@ -332,6 +372,12 @@ func (b *Backend) Invalidate(_ context.Context) {
 	b.salt = nil
 }
 
+// HasFiltering reports whether the backend is configured with a filter, in
+// which case it may not log every request and response.
+func (b *Backend) HasFiltering() bool {
+	return b.filter != nil
+}
+
 // RegisterNodesAndPipeline registers the nodes and a pipeline as required by
 // the audit.Backend interface.
 func (b *Backend) RegisterNodesAndPipeline(broker *eventlogger.Broker, name string) error {
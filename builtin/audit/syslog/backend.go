@@ -6,8 +6,12 @@ package syslog
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/eventlogger"
@@ -71,13 +75,27 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 		cfgOpts = append(cfgOpts, audit.WithElision(v))
 	}
 
+	if exclude, ok := conf.Config["exclude"]; ok {
+		cfgOpts = append(cfgOpts, audit.WithExclusions(strings.Split(exclude, ",")))
+	}
+
 	cfg, err := audit.NewFormatterConfig(cfgOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the logger
-	logger, err := gsyslog.NewLogger(gsyslog.LOG_INFO, facility, tag)
+	remoteOpts, err := remoteSyslogOptions(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the logger, writing to the remote syslog server if any
+	var logger gsyslog.Syslogger
+	if address := conf.Config["address"]; address != "" {
+		logger, err = event.NewRemoteSyslogger(address, append(remoteOpts, event.WithFacility(facility), event.WithTag(tag))...)
+	} else {
+		logger, err = gsyslog.NewLogger(gsyslog.LOG_INFO, facility, tag)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +128,18 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 
 	b.formatter = fw
 
+	if filter, ok := conf.Config["filter"]; ok && strings.TrimSpace(filter) != "" {
+		b.filter, err = audit.NewEntryFilter(filter)
+		if err != nil {
+			return nil, fmt.Errorf("error creating filter: %w", err)
+		}
+	}
+
+	// Spooling and asynchronous logging are only supported by the event logger
+	if !useEventLogger && (conf.Config["spool_path"] != "" || conf.Config["async"] != "") {
+		return nil, fmt.Errorf("spool_path and async are not supported when the event logger is disabled")
+	}
+
 	if useEventLogger {
 		var opts []event.Option
 
@@ -122,38 +152,113 @@ func Factory(ctx context.Context, conf *audit.BackendConfig, useEventLogger bool
 			opts = append(opts, event.WithTag(tag))
 		}
 
-		b.nodeIDList = make([]eventlogger.NodeID, 2)
+		opts = append(opts, remoteOpts...)
+
+		b.nodeIDList = make([]eventlogger.NodeID, 0, 3)
 		b.nodeMap = make(map[eventlogger.NodeID]eventlogger.Node)
 
+		if b.filter != nil {
+			filterNodeID, err := event.GenerateNodeID()
+			if err != nil {
+				return nil, fmt.Errorf("error generating random NodeID for filter node: %w", err)
+			}
+			b.nodeIDList = append(b.nodeIDList, filterNodeID)
+			b.nodeMap[filterNodeID] = b.filter
+		}
+
 		formatterNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for formatter node: %w", err)
 		}
-		b.nodeIDList[0] = formatterNodeID
+		b.nodeIDList = append(b.nodeIDList, formatterNodeID)
 		b.nodeMap[formatterNodeID] = f
 
 		n, err := event.NewSyslogSink(b.formatConfig.RequiredFormat.String(), opts...)
 		if err != nil {
 			return nil, fmt.Errorf("error creating syslog sink node: %w", err)
 		}
-		sinkNode := &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
+		var sinkNode eventlogger.Node = &audit.SinkWrapper{Name: conf.MountPath, Sink: n}
+
+		sinkNode, err = audit.SpoolSinkFromConfig(conf.Config, b.formatConfig.RequiredFormat.String(), sinkNode)
+		if err != nil {
+			return nil, fmt.Errorf("error creating spool: %w", err)
+		}
 
 		sinkNodeID, err := event.GenerateNodeID()
 		if err != nil {
 			return nil, fmt.Errorf("error generating random NodeID for sink node: %w", err)
 		}
-		b.nodeIDList[1] = sinkNodeID
+		b.nodeIDList = append(b.nodeIDList, sinkNodeID)
 		b.nodeMap[sinkNodeID] = sinkNode
 	}
 	return b, nil
 }
 
+// remoteSyslogOptions returns the options of the connection to the remote
+// syslog server of the address option, if any, from the audit device
+// configuration.
+func remoteSyslogOptions(config map[string]string) ([]event.Option, error) {
+	address := strings.TrimSpace(config["address"])
+	if address == "" {
+		for _, key := range []string{"network", "queue_size", "write_timeout", "tls_ca_cert", "tls_client_cert", "tls_client_key", "tls_server_name"} {
+			if _, ok := config[key]; ok {
+				return nil, fmt.Errorf("%s requires address", key)
+			}
+		}
+		return nil, nil
+	}
+
+	opts := []event.Option{
+		event.WithAddress(address),
+		event.WithNetwork(config["network"]),
+		event.WithMaxDuration(config["write_timeout"]),
+		event.WithQueueSize(config["queue_size"]),
+	}
+
+	network := strings.ToLower(strings.TrimSpace(config["network"]))
+	hasTLSOptions := config["tls_ca_cert"] != "" || config["tls_client_cert"] != "" || config["tls_client_key"] != "" || config["tls_server_name"] != ""
+	if network != "tls" {
+		if hasTLSOptions {
+			return nil, fmt.Errorf("the TLS options require the tls network")
+		}
+		return opts, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config["tls_server_name"],
+	}
+	if caCert := config["tls_ca_cert"]; caCert != "" {
+		caPEM, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading tls_ca_cert: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no CA certificate found in tls_ca_cert %q", caCert)
+		}
+	}
+	if (config["tls_client_cert"] == "") != (config["tls_client_key"] == "") {
+		return nil, fmt.Errorf("tls_client_cert and tls_client_key must be specified together")
+	}
+	if config["tls_client_cert"] != "" {
+		cert, err := tls.LoadX509KeyPair(config["tls_client_cert"], config["tls_client_key"])
+		if err != nil {
+			return nil, fmt.Errorf("error loading the TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return append(opts, event.WithTLSConfig(tlsConfig)), nil
+}
+
 // Backend is the audit backend for the syslog-based audit store.
 type Backend struct {
 	logger gsyslog.Syslogger
 
 	formatter    *audit.EntryFormatterWriter
 	formatConfig audit.FormatterConfig
+	filter       *audit.EntryFilter
 
 	saltMutex  sync.RWMutex
 	salt       *salt.Salt
@@ -167,6 +272,10 @@ type Backend struct {
 var _ audit.Backend = (*Backend)(nil)
 
 func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var buf bytes.Buffer
 	if err := b.formatter.FormatAndWriteRequest(ctx, &buf, in); err != nil {
 		return err
@@ -178,6 +287,10 @@ func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
 }
 
 func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	if allowed, err := b.filter.Evaluate(ctx, in); err != nil || !allowed {
+		return err
+	}
+
 	var buf bytes.Buffer
 	if err := b.formatter.FormatAndWriteResponse(ctx, &buf, in); err != nil {
 		return err
@@ -241,6 +354,12 @@ func (b *Backend) Invalidate(_ context.Context) {
 	b.salt = nil
 }
 
+// HasFiltering reports whether the backend is configured with a filter, in
+// which case it may not log every request and response.
+func (b *Backend) HasFiltering() bool {
+	return b.filter != nil
+}
+
 // RegisterNodesAndPipeline registers the nodes and a pipeline as required by
 // the audit.Backend interface.
 func (b *Backend) RegisterNodesAndPipeline(broker *eventlogger.Broker, name string) error {
@@ -258,20 +258,40 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 			//
 			// If the SecretIDNumUses is non-zero, it means that its use-count should be updated
 			// in the storage. Switch the lock from a `read` to a `write` and update
-			// the storage entry.
+			// the storage entry. A SecretID rotated with a grace period shares
+			// its uses with the other SecretID of the rotation, so both are
+			// write-locked.
 			//
 
 			secretIDLock.RUnlock()
-			secretIDLock.Lock()
-			unlockFunc = secretIDLock.Unlock
+			unlockFunc = func() {}
 
-			// Lock switching may change the data. Refresh the contents.
-			entry, err = b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
-			if err != nil {
-				return nil, err
+			peerHMAC := entry.RotationPeerHMAC
+			for {
+				unlockFunc = b.lockSecretIDs(secretIDHMAC, peerHMAC)
+
+				// Lock switching may change the data. Refresh the contents.
+				entry, err = b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+				if err != nil {
+					return nil, err
+				}
+				if entry == nil {
+					return logical.ErrorResponse(fmt.Sprintf("invalid secret_id %q", secretID)), nil
+				}
+				if entry.RotationPeerHMAC == peerHMAC {
+					break
+				}
+				unlockFunc()
+				unlockFunc = func() {}
+				peerHMAC = entry.RotationPeerHMAC
 			}
-			if entry == nil {
-				return logical.ErrorResponse(fmt.Sprintf("invalid secret_id %q", secretID)), nil
+
+			var peerEntry *secretIDStorageEntry
+			if peerHMAC != "" {
+				peerEntry, err = b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, peerHMAC)
+				if err != nil {
+					return nil, err
+				}
 			}
 
 			// If there exists a single use left, delete the SecretID entry from
@@ -287,10 +307,18 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				if err != nil {
 					return nil, fmt.Errorf("failed to delete secret ID: %w", err)
 				}
+				if peerEntry != nil {
+					if err := b.nonLockedDeleteSecretIDEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, peerHMAC, peerEntry); err != nil {
+						return nil, err
+					}
+				}
 			} else {
 				// If the use count is greater than one, decrement it and update the last updated time.
 				entry.SecretIDNumUses -= 1
 				entry.LastUpdatedTime = time.Now()
+				if peerEntry == nil {
+					entry.RotationPeerHMAC = ""
+				}
 
 				sEntry, err := logical.StorageEntryJSON(entryIndex, &entry)
 				if err != nil {
@@ -301,6 +329,14 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				if err != nil {
 					return nil, err
 				}
+
+				if peerEntry != nil {
+					peerEntry.SecretIDNumUses = entry.SecretIDNumUses
+					peerEntry.LastUpdatedTime = entry.LastUpdatedTime
+					if err := b.nonLockedSetSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, peerHMAC, peerEntry); err != nil {
+						return nil, err
+					}
+				}
 			}
 
 			// Ensure that the CIDRs on the secret ID are still a subset of that of
@@ -1087,6 +1087,64 @@ Overrides secret_id_ttl role option when supplied. May not be longer than role's
 			HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id-destroy"][0]),
 			HelpDescription: strings.TrimSpace(roleHelp["role-secret-id-destroy"][1]),
 		},
+		{
+			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id/rotate/?$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixAppRole,
+				OperationVerb:   "rotate",
+				OperationSuffix: "secret-id",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": {
+					Type:        framework.TypeString,
+					Description: fmt.Sprintf("Name of the role. Must be less than %d bytes.", maxHmacInputLength),
+				},
+				"secret_id": {
+					Type:        framework.TypeString,
+					Description: "SecretID attached to the role which should be rotated.",
+				},
+				"grace_period": {
+					Type: framework.TypeDurationSecond,
+					Description: `Duration in seconds for which the rotated SecretID remains valid.
+If not set, the rotated SecretID is destroyed immediately. The grace period never
+extends the rotated SecretID's existing expiration time.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathRoleSecretIDRotateUpdate,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"secret_id": {
+									Type:        framework.TypeString,
+									Required:    true,
+									Description: "Secret ID attached to the role.",
+								},
+								"secret_id_accessor": {
+									Type:        framework.TypeString,
+									Required:    true,
+									Description: "Accessor of the secret ID",
+								},
+								"secret_id_ttl": {
+									Type:        framework.TypeDurationSecond,
+									Required:    true,
+									Description: "Duration in seconds after which the issued secret ID expires.",
+								},
+								"secret_id_num_uses": {
+									Type:        framework.TypeInt,
+									Required:    true,
+									Description: "Number of times a secret ID can access the role, after which the secret ID will expire.",
+								},
+							},
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id-rotate"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["role-secret-id-rotate"][1]),
+		},
 		{
 			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id-accessor/lookup/?$",
 			DisplayAttrs: &framework.DisplayAttributes{
@@ -1949,6 +2007,159 @@ func (b *backend) pathRoleSecretIDDestroyUpdateDelete(ctx context.Context, req *
 	return nil, nil
 }
 
+// pathRoleSecretIDRotateUpdate issues a new SecretID carrying the properties
+// of an existing one, and lets the existing SecretID expire after the given
+// grace period.
+func (b *backend) pathRoleSecretIDRotateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	secretID := data.Get("secret_id").(string)
+	if secretID == "" {
+		return logical.ErrorResponse("missing secret_id"), nil
+	}
+
+	gracePeriod := time.Duration(data.Get("grace_period").(int)) * time.Second
+	if gracePeriod < 0 {
+		return logical.ErrorResponse("grace_period cannot be negative"), nil
+	}
+
+	roleLock := b.roleLock(roleName)
+	roleLock.RLock()
+	defer roleLock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %q does not exist", roleName)
+	}
+
+	if !role.BindSecretID {
+		return logical.ErrorResponse("bind_secret_id is not set on the role"), nil
+	}
+
+	secretIDHMAC, err := createHMAC(role.HMACKey, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HMAC of secret_id: %w", err)
+	}
+
+	roleNameHMAC, err := createHMAC(role.HMACKey, role.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HMAC of role_name: %w", err)
+	}
+
+	newSecretID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret_id: %w", err)
+	}
+	newSecretIDHMAC, err := createHMAC(role.HMACKey, newSecretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HMAC of secret_id: %w", err)
+	}
+
+	// The old and the new SecretID, as well as the peer of a previous
+	// rotation of the old one, are write-locked together as they may map to
+	// the same lock.
+	lock := b.secretIDLock(secretIDHMAC)
+	lock.RLock()
+	oldEntry, err := b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+	lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if oldEntry == nil {
+		return logical.ErrorResponse("invalid secret id"), nil
+	}
+
+	unlockFunc := func() {}
+	defer func() {
+		unlockFunc()
+	}()
+	for {
+		peerHMAC := oldEntry.RotationPeerHMAC
+		unlockFunc = b.lockSecretIDs(secretIDHMAC, newSecretIDHMAC, peerHMAC)
+
+		// The old SecretID may have been used up or destroyed in the meantime.
+		oldEntry, err = b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+		if err != nil {
+			return nil, err
+		}
+		if oldEntry == nil {
+			return logical.ErrorResponse("invalid secret id"), nil
+		}
+		if oldEntry.RotationPeerHMAC == peerHMAC {
+			break
+		}
+		unlockFunc()
+		unlockFunc = func() {}
+	}
+
+	newEntry := &secretIDStorageEntry{
+		SecretIDNumUses: oldEntry.SecretIDNumUses,
+		SecretIDTTL:     oldEntry.SecretIDTTL,
+		Metadata:        oldEntry.Metadata,
+		CIDRList:        oldEntry.CIDRList,
+		TokenBoundCIDRs: oldEntry.TokenBoundCIDRs,
+	}
+	if newEntry.Metadata == nil {
+		newEntry.Metadata = make(map[string]string)
+	}
+	// During the grace period both SecretIDs draw from the remaining uses of
+	// the old one, so that the rotation does not add uses.
+	if gracePeriod > 0 && oldEntry.SecretIDNumUses > 0 {
+		newEntry.RotationPeerHMAC = secretIDHMAC
+	}
+	if newEntry, err = b.nonLockedRegisterSecretIDEntry(ctx, req.Storage, roleNameHMAC, newSecretIDHMAC, role.SecretIDPrefix, newEntry); err != nil {
+		return nil, fmt.Errorf("failed to store secret_id: %w", err)
+	}
+
+	// The SecretID of a previous rotation sharing the uses of the old one is
+	// superseded by the new SecretID as well.
+	if oldEntry.RotationPeerHMAC != "" {
+		peerEntry, err := b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, oldEntry.RotationPeerHMAC)
+		if err != nil {
+			return nil, err
+		}
+		if peerEntry != nil {
+			if err := b.nonLockedDeleteSecretIDEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, oldEntry.RotationPeerHMAC, peerEntry); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if gracePeriod == 0 {
+		if err := b.nonLockedDeleteSecretIDEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC, oldEntry); err != nil {
+			return nil, err
+		}
+	} else {
+		currentTime := time.Now()
+		if graceExpiration := currentTime.Add(gracePeriod); oldEntry.ExpirationTime.IsZero() || graceExpiration.Before(oldEntry.ExpirationTime) {
+			oldEntry.ExpirationTime = graceExpiration
+		}
+		oldEntry.RotationPeerHMAC = ""
+		if newEntry.RotationPeerHMAC != "" {
+			oldEntry.RotationPeerHMAC = newSecretIDHMAC
+		}
+		oldEntry.LastUpdatedTime = currentTime
+		if err := b.nonLockedSetSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC, oldEntry); err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"secret_id":          newSecretID,
+			"secret_id_accessor": newEntry.SecretIDAccessor,
+			"secret_id_ttl":      int64(b.deriveSecretIDTTL(newEntry.SecretIDTTL).Seconds()),
+			"secret_id_num_uses": newEntry.SecretIDNumUses,
+		},
+	}, nil
+}
+
 // pathRoleSecretIDAccessorLookupUpdate returns the properties of the SecretID
 // given its accessor
 func (b *backend) pathRoleSecretIDAccessorLookupUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -3252,6 +3463,16 @@ role.`,
 		"Invalidate an issued secret_id",
 		`This endpoint is used to delete the properties of a secret_id associated to a
 role.`,
+	},
+	"role-secret-id-rotate": {
+		"Rotate an issued secret_id",
+		`This endpoint issues a new secret_id with the same metadata, CIDR
+restrictions, TTL and remaining number of uses as the given secret_id. The
+given secret_id remains valid for 'grace_period' seconds, after which it is
+removed by the periodic tidy of expired secret_ids. If 'grace_period' is not
+set, it is destroyed immediately. If the secret_id has a limited number of
+uses, both secret_ids draw from the same remaining uses during the grace
+period.`,
 	},
 	"role-secret-id-accessor-lookup": {
 		"Read an issued secret_id, using its accessor",
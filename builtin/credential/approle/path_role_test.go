@@ -855,6 +855,150 @@ func TestAppRole_RoleSecretIDReadDelete(t *testing.T) {
 	}
 }
 
+func TestAppRole_RoleSecretIDRotate(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	createRole(t, b, storage, "role1", "a,b")
+	resp := b.requestNoErr(t, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id",
+		Data: map[string]interface{}{
+			"metadata":  `{"env":"prod"}`,
+			"cidr_list": "127.0.0.1/32",
+		},
+	})
+	oldSecretID := resp.Data["secret_id"].(string)
+
+	lookup := func(secretID string) *logical.Response {
+		return b.requestNoErr(t, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Storage:   storage,
+			Path:      "role/role1/secret-id/lookup",
+			Data: map[string]interface{}{
+				"secret_id": secretID,
+			},
+		})
+	}
+	rotate := func(secretID string, gracePeriod string) *logical.Response {
+		return b.requestNoErr(t, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Storage:   storage,
+			Path:      "role/role1/secret-id/rotate",
+			Data: map[string]interface{}{
+				"secret_id":    secretID,
+				"grace_period": gracePeriod,
+			},
+		})
+	}
+
+	resp = rotate(oldSecretID, "1h")
+	newSecretID := resp.Data["secret_id"].(string)
+	if newSecretID == "" || newSecretID == oldSecretID {
+		t.Fatalf("expected a new secret ID, got %q", newSecretID)
+	}
+
+	resp = lookup(newSecretID)
+	if diff := deep.Equal(resp.Data["metadata"], map[string]string{"env": "prod"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(resp.Data["cidr_list"], []string{"127.0.0.1/32"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// The old secret ID stays around until the end of the grace period.
+	resp = lookup(oldSecretID)
+	if resp == nil {
+		t.Fatal("expected the rotated secret ID to remain during the grace period")
+	}
+	expiration := resp.Data["expiration_time"].(time.Time)
+	if expiration.IsZero() || expiration.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("unexpected expiration time of the rotated secret ID: %v", expiration)
+	}
+
+	// Without a grace period, the old secret ID is destroyed right away.
+	resp = rotate(newSecretID, "")
+	if resp.Data["secret_id"].(string) == newSecretID {
+		t.Fatal("expected a new secret ID")
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id/lookup",
+		Data: map[string]interface{}{
+			"secret_id": newSecretID,
+		},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("expected the rotated secret ID to be destroyed: resp: %#v, err: %v", resp, err)
+	}
+}
+
+func TestAppRole_RoleSecretIDRotate_NumUses(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	createRole(t, b, storage, "role1", "a,b")
+	resp := b.requestNoErr(t, &logical.Request{
+		Operation: logical.ReadOperation,
+		Storage:   storage,
+		Path:      "role/role1/role-id",
+	})
+	roleID := resp.Data["role_id"]
+
+	resp = b.requestNoErr(t, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id",
+		Data: map[string]interface{}{
+			"num_uses": 3,
+		},
+	})
+	oldSecretID := resp.Data["secret_id"].(string)
+
+	resp = b.requestNoErr(t, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id/rotate",
+		Data: map[string]interface{}{
+			"secret_id":    oldSecretID,
+			"grace_period": "1h",
+		},
+	})
+	newSecretID := resp.Data["secret_id"].(string)
+	if numUses := resp.Data["secret_id_num_uses"].(int); numUses != 3 {
+		t.Fatalf("expected the new secret ID to have 3 uses, got %d", numUses)
+	}
+
+	login := func(secretID string) error {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Storage:   storage,
+			Path:      "login",
+			Data: map[string]interface{}{
+				"role_id":   roleID,
+				"secret_id": secretID,
+			},
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		if err == nil && resp != nil && resp.IsError() {
+			err = resp.Error()
+		}
+		return err
+	}
+
+	// Both secret IDs share the 3 uses of the rotated secret ID.
+	for i, secretID := range []string{oldSecretID, newSecretID, oldSecretID} {
+		if err := login(secretID); err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+	}
+	for _, secretID := range []string{oldSecretID, newSecretID} {
+		if err := login(secretID); err == nil {
+			t.Fatal("expected the login to fail after the shared uses ran out")
+		}
+	}
+}
+
 func TestAppRole_RoleSecretIDAccessorReadDelete(t *testing.T) {
 	var resp *logical.Response
 	var err error
@@ -60,6 +60,11 @@ type secretIDStorageEntry struct {
 	// restrictions on the usage of the token generated by this SecretID
 	TokenBoundCIDRs []string `json:"token_cidr_list" mapstructure:"token_bound_cidrs"`
 
+	// RotationPeerHMAC is the HMAC of the other SecretID of a rotation with
+	// a grace period. Both SecretIDs draw from the same number of uses for
+	// as long as the other one exists.
+	RotationPeerHMAC string `json:"rotation_peer_hmac" mapstructure:"rotation_peer_hmac"`
+
 	// This is a deprecated field
 	SecretIDNumUsesDeprecated int `json:"SecretIDNumUses" mapstructure:"SecretIDNumUses"`
 }
@@ -126,6 +131,26 @@ func (b *backend) secretIDLock(secretIDHMAC string) *locksutil.LockEntry {
 	return locksutil.LockForKey(b.secretIDLocks, secretIDHMAC)
 }
 
+// lockSecretIDs write-locks the given SecretIDs in a consistent order and
+// returns a function releasing the locks.
+func (b *backend) lockSecretIDs(secretIDHMACs ...string) func() {
+	keys := make([]string, 0, len(secretIDHMACs))
+	for _, secretIDHMAC := range secretIDHMACs {
+		if secretIDHMAC != "" {
+			keys = append(keys, secretIDHMAC)
+		}
+	}
+	locks := locksutil.LocksForKeys(b.secretIDLocks, keys)
+	for _, lock := range locks {
+		lock.Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
 func (b *backend) secretIDAccessorLock(secretIDAccessor string) *locksutil.LockEntry {
 	return locksutil.LockForKey(b.secretIDAccessorLocks, secretIDAccessor)
 }
@@ -268,8 +293,14 @@ func (b *backend) registerSecretIDEntry(ctx context.Context, s logical.Storage,
 	lock.Lock()
 	defer lock.Unlock()
 
+	return b.nonLockedRegisterSecretIDEntry(ctx, s, roleNameHMAC, secretIDHMAC, roleSecretIDPrefix, secretEntry)
+}
+
+// nonLockedRegisterSecretIDEntry creates a new storage entry for the given
+// SecretID. The caller must hold the write lock of the SecretID.
+func (b *backend) nonLockedRegisterSecretIDEntry(ctx context.Context, s logical.Storage, roleNameHMAC, secretIDHMAC, roleSecretIDPrefix string, secretEntry *secretIDStorageEntry) (*secretIDStorageEntry, error) {
 	// But before saving a new entry, check if the secretID entry was created during the lock switch.
-	entry, err = b.nonLockedSecretIDStorageEntry(ctx, s, roleSecretIDPrefix, roleNameHMAC, secretIDHMAC)
+	entry, err := b.nonLockedSecretIDStorageEntry(ctx, s, roleSecretIDPrefix, roleNameHMAC, secretIDHMAC)
 	if err != nil {
 		return nil, err
 	}
@@ -316,6 +347,19 @@ func (b *backend) deriveSecretIDTTL(secretIDTTL time.Duration) time.Duration {
 	return secretIDTTL
 }
 
+// nonLockedDeleteSecretIDEntry deletes the given SecretID along with its
+// accessor. The caller must hold the write lock of the SecretID.
+func (b *backend) nonLockedDeleteSecretIDEntry(ctx context.Context, s logical.Storage, roleSecretIDPrefix, roleNameHMAC, secretIDHMAC string, entry *secretIDStorageEntry) error {
+	if err := b.deleteSecretIDAccessorEntry(ctx, s, entry.SecretIDAccessor, roleSecretIDPrefix); err != nil {
+		return err
+	}
+	entryIndex := fmt.Sprintf("%s%s/%s", roleSecretIDPrefix, roleNameHMAC, secretIDHMAC)
+	if err := s.Delete(ctx, entryIndex); err != nil {
+		return fmt.Errorf("failed to delete secret ID: %w", err)
+	}
+	return nil
+}
+
 // secretIDAccessorEntry is used to read the storage entry that maps an
 // accessor to a secret_id.
 func (b *backend) secretIDAccessorEntry(ctx context.Context, s logical.Storage, secretIDAccessor, roleSecretIDPrefix string) (*secretIDAccessorStorageEntry, error) {
@@ -16,6 +16,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/ocsp"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -67,6 +68,8 @@ type backend struct {
 	crlUpdateMutex  *sync.RWMutex
 	ocspClientMutex sync.RWMutex
 	ocspClient      *ocsp.Client
+	cdpCacheMutex   sync.RWMutex
+	cdpCache        *lru.TwoQueueCache
 	configUpdated   atomic.Bool
 }
 
@@ -108,8 +111,13 @@ func (b *backend) initOCSPClient(cacheSize int) {
 
 func (b *backend) updatedConfig(config *config) {
 	b.ocspClientMutex.Lock()
-	defer b.ocspClientMutex.Unlock()
 	b.initOCSPClient(config.OcspCacheSize)
+	b.ocspClientMutex.Unlock()
+
+	b.cdpCacheMutex.Lock()
+	b.initCDPCache(config.CrlCdpCacheSize)
+	b.cdpCacheMutex.Unlock()
+
 	b.configUpdated.Store(false)
 	return
 }
@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package cert
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-multierror"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// cdpFetchTimeout bounds how long a login waits on a single CRL
+	// distribution point.
+	cdpFetchTimeout = 10 * time.Second
+
+	// cdpMaxCRLSize is the largest CRL we're willing to download from a
+	// distribution point.
+	cdpMaxCRLSize = 32 * 1024 * 1024
+
+	// cdpDefaultValidity is used as the cache lifetime of CRLs which do not
+	// carry a NextUpdate time.
+	cdpDefaultValidity = time.Hour
+)
+
+// cdpClient fetches CRLs from distribution points. Unlike http.DefaultClient,
+// it honors the proxy settings of the environment and bounds every request,
+// including reading the response, by cdpFetchTimeout.
+var cdpClient = func() *http.Client {
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = cdpFetchTimeout
+	return client
+}()
+
+// cdpCRL is a parsed and verified CRL fetched from a CRL distribution point
+// of a client certificate.
+type cdpCRL struct {
+	serials    map[string]struct{}
+	nextUpdate time.Time
+}
+
+func (b *backend) initCDPCache(cacheSize int) {
+	if cacheSize < 2 {
+		cacheSize = 100
+	}
+	b.cdpCache, _ = lru.New2Q(cacheSize)
+}
+
+// checkForChainInCDPCRLs verifies every certificate of the chain against the
+// CRLs published at its CRL distribution points. It returns false if any
+// certificate of the chain has been revoked. When a CRL cannot be fetched,
+// the certificate is considered good if failOpen is set and an error is
+// returned otherwise.
+func (b *backend) checkForChainInCDPCRLs(ctx context.Context, chain []*x509.Certificate, failOpen bool) (bool, error) {
+	// The last certificate in the chain is the trust anchor, which has no
+	// issuer we could verify its CRL against.
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		if len(cert.CRLDistributionPoints) == 0 {
+			continue
+		}
+
+		crl, err := b.fetchCDPCRL(ctx, cert, issuer)
+		if err != nil {
+			if failOpen {
+				b.Logger().Warn("unable to fetch CRL from distribution point, ignoring", "serial", cert.SerialNumber.String(), "error", err)
+				continue
+			}
+			return false, fmt.Errorf("unable to check revocation status of certificate with serial %s: %w", cert.SerialNumber.String(), err)
+		}
+
+		if _, revoked := crl.serials[cert.SerialNumber.String()]; revoked {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fetchCDPCRL returns the CRL covering cert, trying each of its distribution
+// points in turn. Successfully fetched CRLs are cached until their
+// NextUpdate time.
+func (b *backend) fetchCDPCRL(ctx context.Context, cert, issuer *x509.Certificate) (*cdpCRL, error) {
+	b.cdpCacheMutex.RLock()
+	cache := b.cdpCache
+	b.cdpCacheMutex.RUnlock()
+
+	var errs *multierror.Error
+	for _, url := range cert.CRLDistributionPoints {
+		if cached, ok := cache.Get(url); ok {
+			crl := cached.(*cdpCRL)
+			if time.Now().Before(crl.nextUpdate) {
+				return crl, nil
+			}
+			cache.Remove(url)
+		}
+
+		crl, err := downloadCDPCRL(ctx, url, issuer)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		cache.Add(url, crl)
+		return crl, nil
+	}
+	return nil, errs.ErrorOrNil()
+}
+
+func downloadCDPCRL(ctx context.Context, url string, issuer *x509.Certificate) (*cdpCRL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL distribution point %q: %w", url, err)
+	}
+	response, err := cdpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching CRL from %s: %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d fetching CRL from %s", response.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, cdpMaxCRLSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading CRL from %s: %w", url, err)
+	}
+	if len(body) > cdpMaxCRLSize {
+		return nil, fmt.Errorf("CRL from %s exceeds the maximum size of %d bytes", url, cdpMaxCRLSize)
+	}
+	revList, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CRL from %s: %w", url, err)
+	}
+	if err := revList.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL from %s is not signed by the certificate's issuer: %w", url, err)
+	}
+	// A CRL past its NextUpdate time may miss revocations issued since, so it
+	// is treated like an unavailable one.
+	if !revList.NextUpdate.IsZero() && time.Now().After(revList.NextUpdate) {
+		return nil, fmt.Errorf("CRL from %s expired at %s", url, revList.NextUpdate.Format(time.RFC3339))
+	}
+
+	crl := &cdpCRL{
+		serials:    make(map[string]struct{}, len(revList.RevokedCertificates)),
+		nextUpdate: revList.NextUpdate,
+	}
+	if crl.nextUpdate.IsZero() {
+		crl.nextUpdate = time.Now().Add(cdpDefaultValidity)
+	}
+	for _, revoked := range revList.RevokedCertificates {
+		crl.serials[revoked.SerialNumber.String()] = struct{}{}
+	}
+	return crl, nil
+}
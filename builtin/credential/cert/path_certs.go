@@ -91,6 +91,16 @@ from the AuthorityInformationAccess extension on the certificate being inspected
 				Default:     false,
 				Description: "If set to true, rather than accepting the first successful OCSP response, query all servers and consider the certificate valid only if all servers agree.",
 			},
+			"crl_cdp_enabled": {
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: `Whether to check certificates at login against the CRLs published at their CRL distribution points`,
+			},
+			"crl_cdp_fail_open": {
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: "If set to true, if a CRL cannot be fetched from a certificate's CRL distribution points, login will proceed rather than failing.  If false, failing to fetch a CRL fails the request.",
+			},
 			"allowed_names": {
 				Type: framework.TypeCommaStringSlice,
 				Description: `A comma-separated list of names.
@@ -308,6 +318,8 @@ func (b *backend) pathCertRead(ctx context.Context, req *logical.Request, d *fra
 		"ocsp_servers_override":        cert.OcspServersOverride,
 		"ocsp_fail_open":               cert.OcspFailOpen,
 		"ocsp_query_all_servers":       cert.OcspQueryAllServers,
+		"crl_cdp_enabled":              cert.CrlCdpEnabled,
+		"crl_cdp_fail_open":            cert.CrlCdpFailOpen,
 	}
 	cert.PopulateTokenData(data)
 
@@ -365,6 +377,12 @@ func (b *backend) pathCertWrite(ctx context.Context, req *logical.Request, d *fr
 	if ocspQueryAll, ok := d.GetOk("ocsp_query_all_servers"); ok {
 		cert.OcspQueryAllServers = ocspQueryAll.(bool)
 	}
+	if crlCdpEnabled, ok := d.GetOk("crl_cdp_enabled"); ok {
+		cert.CrlCdpEnabled = crlCdpEnabled.(bool)
+	}
+	if crlCdpFailOpen, ok := d.GetOk("crl_cdp_fail_open"); ok {
+		cert.CrlCdpFailOpen = crlCdpFailOpen.(bool)
+	}
 	if displayNameRaw, ok := d.GetOk("display_name"); ok {
 		cert.DisplayName = displayNameRaw.(string)
 	}
@@ -515,6 +533,9 @@ type CertEntry struct {
 	OcspServersOverride []string
 	OcspFailOpen        bool
 	OcspQueryAllServers bool
+
+	CrlCdpEnabled  bool
+	CrlCdpFailOpen bool
 }
 
 const pathCertHelpSyn = `
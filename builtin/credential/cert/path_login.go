@@ -366,6 +366,15 @@ func (b *backend) matchesConstraints(ctx context.Context, clientCert *x509.Certi
 		}
 		soFar = soFar && ocspGood
 	}
+	// CRLs are only fetched from the distribution points of certificates
+	// which satisfy every other constraint.
+	if config.Entry.CrlCdpEnabled && soFar {
+		cdpGood, err := b.checkForChainInCDPCRLs(ctx, trustedChain, config.Entry.CrlCdpFailOpen)
+		if err != nil {
+			return false, err
+		}
+		soFar = soFar && cdpGood
+	}
 	return soFar, nil
 }
 
@@ -5,6 +5,7 @@ package cert
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -14,9 +15,11 @@ import (
 	mathrand "math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -357,6 +360,138 @@ func TestCert_RoleResolveOCSP(t *testing.T) {
 	}
 }
 
+func TestCert_RoleResolveCRLDistributionPoint(t *testing.T) {
+	cases := []struct {
+		name        string
+		failOpen    bool
+		revoked     bool
+		unavailable bool
+		expired     bool
+		errExpected bool
+	}{
+		{"failFalseGoodCert", false, false, false, false, false},
+		{"failFalseRevokedCert", false, true, false, false, true},
+		{"failFalseUnavailableCRL", false, false, true, false, true},
+		{"failFalseExpiredCRL", false, false, false, true, true},
+		{"failTrueGoodCert", true, false, false, false, false},
+		{"failTrueRevokedCert", true, true, false, false, true},
+		{"failTrueUnavailableCRL", true, false, true, false, false},
+		{"failTrueExpiredCRL", true, false, false, true, false},
+	}
+
+	var crlBytes []byte
+	var crlUnavailable bool
+	var crlFetches atomic.Int32
+	crlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		crlFetches.Add(1)
+		if crlUnavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(crlBytes)
+	}))
+	defer crlServer.Close()
+
+	certTemplate := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: "example.com",
+		},
+		DNSNames:    []string{"example.com"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth,
+		},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		SerialNumber:          big.NewInt(mathrand.Int63()),
+		NotBefore:             time.Now().Add(-30 * time.Second),
+		NotAfter:              time.Now().Add(262980 * time.Hour),
+		CRLDistributionPoints: []string{crlServer.URL + "/crl"},
+	}
+	tempDir, connState, err := generateTestCertAndConnState(t, certTemplate)
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+	if err != nil {
+		t.Fatalf("error testing connection state: %v", err)
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(tempDir, "ca_cert.pem"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	issuer := parsePEM(ca)
+	pkf, err := ioutil.ReadFile(filepath.Join(tempDir, "ca_key.pem"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pk, err := certutil.ParsePEMBundle(string(pkf))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var revokedCerts []pkix.RevokedCertificate
+			if c.revoked {
+				revokedCerts = append(revokedCerts, pkix.RevokedCertificate{
+					SerialNumber:   certTemplate.SerialNumber,
+					RevocationTime: time.Now(),
+				})
+			}
+			thisUpdate := time.Now()
+			if c.expired {
+				thisUpdate = thisUpdate.Add(-2 * time.Hour)
+			}
+			crlBytes, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+				Number:              big.NewInt(1),
+				ThisUpdate:          thisUpdate,
+				NextUpdate:          thisUpdate.Add(time.Hour),
+				RevokedCertificates: revokedCerts,
+			}, issuer[0], pk.PrivateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			crlUnavailable = c.unavailable
+
+			var resolveStep logicaltest.TestStep
+			var loginStep logicaltest.TestStep
+			if c.errExpected {
+				loginStep = testAccStepLoginWithNameInvalid(t, connState, "web")
+				resolveStep = testAccStepResolveRoleOCSPFail(t, connState, "web")
+			} else {
+				loginStep = testAccStepLoginWithName(t, connState, "web")
+				resolveStep = testAccStepResolveRoleWithName(t, connState, "web")
+			}
+			logicaltest.Test(t, logicaltest.TestCase{
+				CredentialBackend: testFactory(t),
+				Steps: []logicaltest.TestStep{
+					testAccStepCertWithExtraParams(t, "web", ca, "foo", allowed{dns: "example.com"}, false,
+						map[string]interface{}{"crl_cdp_enabled": true, "crl_cdp_fail_open": c.failOpen}),
+					testAccStepReadCertPolicy(t, "web", false, map[string]interface{}{"crl_cdp_enabled": true, "crl_cdp_fail_open": c.failOpen}),
+					loginStep,
+					resolveStep,
+				},
+			})
+		})
+	}
+
+	// The CRL of a certificate failing the other constraints is never fetched.
+	crlFetches.Store(0)
+	crlUnavailable = false
+	logicaltest.Test(t, logicaltest.TestCase{
+		CredentialBackend: testFactory(t),
+		Steps: []logicaltest.TestStep{
+			testAccStepCertWithExtraParams(t, "web", ca, "foo", allowed{dns: "example.org"}, false,
+				map[string]interface{}{"crl_cdp_enabled": true}),
+			testAccStepLoginWithNameInvalid(t, connState, "web"),
+		},
+	})
+	if fetches := crlFetches.Load(); fetches != 0 {
+		t.Fatalf("expected no CRL fetches for a certificate failing the constraints, got %d", fetches)
+	}
+}
+
 func serialFromBigInt(serial *big.Int) string {
 	return strings.TrimSpace(certutil.GetHexFormatted(serial.Bytes(), ":"))
 }
@@ -92,7 +92,41 @@ func Backend() *backend {
 	}
 	userMapPaths[0].Callbacks = nil
 
+	b.AppMap = &framework.PolicyMap{
+		PathMap: framework.PathMap{
+			Name: "apps",
+		},
+		DefaultKey: "default",
+	}
+
+	appMapPaths := b.AppMap.Paths()
+
+	appMapPaths[0].DisplayAttrs = &framework.DisplayAttributes{
+		OperationPrefix: operationPrefixGithub,
+		OperationSuffix: "apps",
+	}
+	appMapPaths[1].DisplayAttrs = &framework.DisplayAttributes{
+		OperationPrefix: operationPrefixGithub,
+		OperationSuffix: "app-mapping",
+	}
+	appMapPaths[0].Operations = map[logical.Operation]framework.OperationHandler{
+		logical.ListOperation: &framework.PathOperation{
+			Callback: appMapPaths[0].Callbacks[logical.ListOperation],
+			Summary:  appMapPaths[0].HelpSynopsis,
+		},
+		logical.ReadOperation: &framework.PathOperation{
+			Callback: appMapPaths[0].Callbacks[logical.ReadOperation],
+			Summary:  appMapPaths[0].HelpSynopsis,
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationVerb:   "list",
+				OperationSuffix: "apps2", // The ReadOperation is redundant with the ListOperation
+			},
+		},
+	}
+	appMapPaths[0].Callbacks = nil
+
 	allPaths := append(teamMapPaths, userMapPaths...)
+	allPaths = append(allPaths, appMapPaths...)
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -116,6 +150,8 @@ type backend struct {
 	TeamMap *framework.PolicyMap
 
 	UserMap *framework.PolicyMap
+
+	AppMap *framework.PolicyMap
 }
 
 // Client returns the GitHub client to communicate to GitHub via the
@@ -154,6 +190,10 @@ provider verifies they're part of the correct organization and then
 maps the user to a set of Vault policies according to the teams they're
 part of.
 
+GitHub Apps can log in with a JWT signed by their private key instead,
+in which case the credential provider verifies that the app is installed
+on the organization and maps it to a set of Vault policies by its slug.
+
 After enabling the credential provider, use the "config" route to
 configure it.
 `
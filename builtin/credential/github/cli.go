@@ -24,6 +24,15 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 		mount = "github"
 	}
 
+	path := fmt.Sprintf("auth/%s/login", mount)
+
+	// GitHub Apps log in with their JWT instead of a token
+	if appJWT := m["jwt"]; appJWT != "" {
+		return h.login(c, path, map[string]interface{}{
+			"jwt": strings.TrimSpace(appJWT),
+		})
+	}
+
 	// Extract or prompt for token
 	token := m["token"]
 	if token == "" {
@@ -54,10 +63,13 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 		}
 	}
 
-	path := fmt.Sprintf("auth/%s/login", mount)
-	secret, err := c.Logical().Write(path, map[string]interface{}{
+	return h.login(c, path, map[string]interface{}{
 		"token": strings.TrimSpace(token),
 	})
+}
+
+func (h *CLIHandler) login(c *api.Client, path string, data map[string]interface{}) (*api.Secret, error) {
+	secret, err := c.Logical().Write(path, data)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +92,10 @@ Usage: vault login -method=github [CONFIG K=V...]
 
       $ vault login -method=github token=abcd1234
 
+  Authenticate as a GitHub App using a JWT signed by its private key:
+
+      $ vault login -method=github jwt=eyJhbGciOiJSUzI1NiJ9...
+
 Configuration:
 
   mount=<string>
@@ -91,6 +107,9 @@ Configuration:
   token=<string>
       GitHub personal access token to use for authentication. If not provided,
       Vault will prompt for the value.
+
+  jwt=<string>
+      JWT of a GitHub App to use for authentication, in place of a token.
 `
 
 	return strings.TrimSpace(help)
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/go-github/github"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/tokenutil"
@@ -45,6 +46,23 @@ API-compatible authentication server.`,
 					Group: "GitHub Options",
 				},
 			},
+			"app_id": {
+				Type:        framework.TypeInt64,
+				Description: "The ID of the GitHub App allowed to log in with installation JWTs",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name:  "App ID",
+					Group: "GitHub App Options",
+				},
+			},
+			"app_public_key": {
+				Type: framework.TypeString,
+				Description: `The PEM encoded public key of the
+GitHub App, used to verify its JWTs.`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name:  "App public key",
+					Group: "GitHub App Options",
+				},
+			},
 			"ttl": {
 				Type:        framework.TypeDurationSecond,
 				Description: tokenutil.DeprecationText("token_ttl"),
@@ -100,6 +118,21 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, dat
 		c.OrganizationID = organizationRaw.(int64)
 	}
 
+	if appIDRaw, ok := data.GetOk("app_id"); ok {
+		c.AppID = appIDRaw.(int64)
+	}
+	if appPublicKeyRaw, ok := data.GetOk("app_public_key"); ok {
+		c.AppPublicKey = appPublicKeyRaw.(string)
+	}
+	if (c.AppID == 0) != (c.AppPublicKey == "") {
+		return logical.ErrorResponse("app_id and app_public_key must be set together"), nil
+	}
+	if c.AppPublicKey != "" {
+		if _, err := jwt.ParseRSAPublicKeyFromPEM([]byte(c.AppPublicKey)); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing given app_public_key: %s", err)), nil
+		}
+	}
+
 	var parsedURL *url.URL
 	if baseURLRaw, ok := data.GetOk("base_url"); ok {
 		baseURL := baseURLRaw.(string)
@@ -178,6 +211,8 @@ func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data
 		"organization_id": config.OrganizationID,
 		"organization":    config.Organization,
 		"base_url":        config.BaseURL,
+		"app_id":          config.AppID,
+		"app_public_key":  config.AppPublicKey,
 	}
 	config.PopulateTokenData(d)
 
@@ -226,6 +261,8 @@ type config struct {
 	OrganizationID int64         `json:"organization_id" structs:"organization_id" mapstructure:"organization_id"`
 	Organization   string        `json:"organization" structs:"organization" mapstructure:"organization"`
 	BaseURL        string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
+	AppID          int64         `json:"app_id" structs:"app_id" mapstructure:"app_id"`
+	AppPublicKey   string        `json:"app_public_key" structs:"app_public_key" mapstructure:"app_public_key"`
 	TTL            time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
 	MaxTTL         time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
 }
@@ -39,7 +39,11 @@ func setupTestServer(t *testing.T) *httptest.Server {
 	t.Helper()
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var resp string
-		if strings.Contains(r.URL.String(), "/user/orgs") {
+		if strings.Contains(r.URL.String(), "/app/installations") {
+			resp = string(listAppInstallationsResponse)
+		} else if strings.Contains(r.URL.String(), "/app") {
+			resp = getAppResponse
+		} else if strings.Contains(r.URL.String(), "/user/orgs") {
 			resp = string(listOrgResponse)
 		} else if strings.Contains(r.URL.String(), "/user/teams") {
 			resp = string(listUserTeamsResponse)
@@ -211,6 +215,45 @@ func TestGitHub_WriteConfig_ErrorNoOrg(t *testing.T) {
 	assert.Equal(t, errors.New("organization is a required parameter"), resp.Error())
 }
 
+// TestGitHub_WriteConfig_App tests that the GitHub App settings of the config
+// are validated
+func TestGitHub_WriteConfig_App(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	write := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		data["organization"] = "foo-org"
+		data["organization_id"] = 12345
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Path:      "config",
+			Operation: logical.UpdateOperation,
+			Data:      data,
+			Storage:   s,
+		})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := write(map[string]interface{}{"app_id": 4242})
+	assert.EqualError(t, resp.Error(), "app_id and app_public_key must be set together")
+
+	resp = write(map[string]interface{}{"app_id": 4242, "app_public_key": "not a key"})
+	assert.ErrorContains(t, resp.Error(), "error parsing given app_public_key")
+
+	_, publicKey := testAppKey(t)
+	resp = write(map[string]interface{}{"app_id": 4242, "app_public_key": publicKey})
+	assert.NoError(t, resp.Error())
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Path:      "config",
+		Operation: logical.ReadOperation,
+		Storage:   s,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4242), resp.Data["app_id"])
+	assert.Equal(t, publicKey, resp.Data["app_public_key"])
+}
+
 // https://docs.github.com/en/rest/reference/users#get-the-authenticated-user
 // Note: many of the fields have been omitted
 var getUserResponse = `
@@ -253,3 +296,28 @@ var listUserTeamsResponse = []byte(fmt.Sprintf(`[
     "organization": %v
   }
 ]`, getOrgResponse))
+
+// https://docs.github.com/en/rest/apps/apps#get-the-authenticated-app
+// Note: many of the fields have been omitted
+var getAppResponse = `
+{
+	"id": 4242,
+	"slug": "foo-app",
+	"name": "Foo App",
+	"owner": {
+		"login": "foo-org",
+		"id": 12345
+	}
+}
+`
+
+// https://docs.github.com/en/rest/apps/apps#list-installations-for-the-authenticated-app
+// Note: many of the fields have been omitted
+var listAppInstallationsResponse = []byte(fmt.Sprintf(`[
+{
+	"id": 77,
+	"app_id": 4242,
+	"target_type": "Organization",
+	"account": %v
+}
+]`, getOrgResponse))
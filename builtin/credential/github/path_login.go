@@ -30,6 +30,10 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "GitHub personal API token",
 			},
+			"jwt": {
+				Type:        framework.TypeString,
+				Description: "JWT of a GitHub App, signed by its private key",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -40,6 +44,22 @@ func pathLogin(b *backend) *framework.Path {
 }
 
 func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if appJWT := data.Get("jwt").(string); appJWT != "" {
+		verifyResp, err := b.verifyAppCredentials(ctx, req, appJWT)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Warnings: verifyResp.Warnings,
+			Auth: &logical.Auth{
+				Alias: &logical.Alias{
+					Name: verifyResp.botLogin(),
+				},
+			},
+		}, nil
+	}
+
 	token := data.Get("token").(string)
 
 	verifyResp, err := b.verifyCredentials(ctx, req, token)
@@ -58,6 +78,13 @@ func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Requ
 }
 
 func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if appJWT := data.Get("jwt").(string); appJWT != "" {
+		if data.Get("token").(string) != "" {
+			return logical.ErrorResponse("only one of token and jwt can be provided"), nil
+		}
+		return b.pathLoginApp(ctx, req, appJWT)
+	}
+
 	token := data.Get("token").(string)
 
 	verifyResp, err := b.verifyCredentials(ctx, req, token)
@@ -150,30 +177,15 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, t
 		return nil, errors.New("configuration has not been set")
 	}
 
-	// Check for a CIDR match.
-	if len(config.TokenBoundCIDRs) > 0 {
-		if req.Connection == nil {
-			b.Logger().Error("token bound CIDRs found but no connection information available for validation")
-			return nil, logical.ErrPermissionDenied
-		}
-		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, config.TokenBoundCIDRs) {
-			return nil, logical.ErrPermissionDenied
-		}
+	if err := b.verifyBoundCIDRs(req, config); err != nil {
+		return nil, err
 	}
 
-	client, err := b.Client(token)
+	client, err := b.configuredClient(token, config)
 	if err != nil {
 		return nil, err
 	}
 
-	if config.BaseURL != "" {
-		parsedURL, err := url.Parse(config.BaseURL)
-		if err != nil {
-			return nil, fmt.Errorf("successfully parsed base_url when set but failing to parse now: %w", err)
-		}
-		client.BaseURL = parsedURL
-	}
-
 	if config.OrganizationID == 0 {
 		// Previously we did not verify using the Org ID. So if the Org ID is
 		// not set, we will trust-on-first-use and set it now.
@@ -233,14 +245,7 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, t
 	}
 
 	if orgLoginName != config.Organization {
-		warningMsg := fmt.Sprintf(
-			"the organization name has changed to %q. It is recommended to verify and update the organization name in the config: %s=%d",
-			orgLoginName,
-			"organization_id",
-			config.OrganizationID,
-		)
-		b.Logger().Warn(warningMsg)
-		warnings = append(warnings, warningMsg)
+		warnings = append(warnings, b.orgNameChangedWarning(orgLoginName, config))
 	}
 
 	// Get the teams that this user is part of to determine the policies
@@ -298,6 +303,52 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, t
 	return verifyResp, nil
 }
 
+// verifyBoundCIDRs checks that the request originates from the token bound
+// CIDRs of the config, if any.
+func (b *backend) verifyBoundCIDRs(req *logical.Request, config *config) error {
+	if len(config.TokenBoundCIDRs) == 0 {
+		return nil
+	}
+	if req.Connection == nil {
+		b.Logger().Error("token bound CIDRs found but no connection information available for validation")
+		return logical.ErrPermissionDenied
+	}
+	if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, config.TokenBoundCIDRs) {
+		return logical.ErrPermissionDenied
+	}
+	return nil
+}
+
+// configuredClient returns a GitHub client authenticated by the given token,
+// using the base URL of the config.
+func (b *backend) configuredClient(token string, config *config) (*github.Client, error) {
+	client, err := b.Client(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.BaseURL != "" {
+		parsedURL, err := url.Parse(config.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("successfully parsed base_url when set but failing to parse now: %w", err)
+		}
+		client.BaseURL = parsedURL
+	}
+
+	return client, nil
+}
+
+func (b *backend) orgNameChangedWarning(orgLoginName string, config *config) string {
+	warningMsg := fmt.Sprintf(
+		"the organization name has changed to %q. It is recommended to verify and update the organization name in the config: %s=%d",
+		orgLoginName,
+		"organization_id",
+		config.OrganizationID,
+	)
+	b.Logger().Warn(warningMsg)
+	return warningMsg
+}
+
 type verifyCredentialsResp struct {
 	User      *github.User
 	Org       *github.Organization
@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-github/github"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// maxAppJWTLifetime is the longest lifetime GitHub accepts for the JWTs of
+// GitHub Apps.
+const maxAppJWTLifetime = 10 * time.Minute
+
+func (b *backend) pathLoginApp(ctx context.Context, req *logical.Request, appJWT string) (*logical.Response, error) {
+	verifyResp, err := b.verifyAppCredentials(ctx, req, appJWT)
+	if err != nil {
+		return nil, err
+	}
+
+	login := verifyResp.botLogin()
+	auth := &logical.Auth{
+		Metadata: map[string]string{
+			"app_id":          strconv.FormatInt(verifyResp.App.ID, 10),
+			"app_slug":        verifyResp.App.Slug,
+			"installation_id": strconv.FormatInt(verifyResp.Installation.GetID(), 10),
+			"org":             verifyResp.Installation.GetAccount().GetLogin(),
+		},
+		DisplayName: login,
+		Alias: &logical.Alias{
+			Name: login,
+		},
+	}
+	verifyResp.Config.PopulateTokenAuth(auth)
+
+	// The JWT expires within minutes, so the installation can't be verified
+	// again on renewal
+	auth.Renewable = false
+
+	if len(verifyResp.Policies) > 0 {
+		auth.Policies = append(auth.Policies, verifyResp.Policies...)
+	}
+
+	return &logical.Response{
+		Warnings: verifyResp.Warnings,
+		Auth:     auth,
+	}, nil
+}
+
+// verifyAppCredentials verifies that the JWT is signed by the configured
+// GitHub App, and that the app is installed on the configured organization.
+func (b *backend) verifyAppCredentials(ctx context.Context, req *logical.Request, appJWT string) (*verifyAppCredentialsResp, error) {
+	var warnings []string
+	config, err := b.Config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, errors.New("configuration has not been set")
+	}
+	if config.AppID == 0 || config.AppPublicKey == "" {
+		return nil, errors.New("GitHub App authentication has not been configured")
+	}
+	if config.OrganizationID == 0 {
+		return nil, errors.New("organization_id must be set in the config for GitHub App authentication")
+	}
+
+	if err := b.verifyBoundCIDRs(req, config); err != nil {
+		return nil, err
+	}
+
+	if err := verifyAppJWT(appJWT, config); err != nil {
+		return nil, err
+	}
+
+	client, err := b.configuredClient(appJWT, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the app the JWT authenticates
+	app, err := getAuthenticatedApp(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if app.ID != config.AppID {
+		return nil, errors.New("JWT does not belong to the configured GitHub App")
+	}
+
+	// Verify that the app is installed on the organization
+	var installation *github.Installation
+
+	installationOpt := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	for installation == nil {
+		installations, resp, err := client.Apps.ListInstallations(ctx, installationOpt)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range installations {
+			if i.GetAccount().GetID() == config.OrganizationID {
+				installation = i
+				break
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		installationOpt.Page = resp.NextPage
+	}
+	if installation == nil {
+		return nil, errors.New("app is not installed on required org")
+	}
+
+	if orgLoginName := installation.GetAccount().GetLogin(); orgLoginName != config.Organization {
+		warnings = append(warnings, b.orgNameChangedWarning(orgLoginName, config))
+	}
+
+	policies, err := b.AppMap.Policies(ctx, req.Storage, app.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifyAppCredentialsResp{
+		App:          app,
+		Installation: installation,
+		Policies:     policies,
+		Config:       config,
+		Warnings:     warnings,
+	}, nil
+}
+
+// verifyAppJWT checks the signature and lifetime of the JWT, and that it was
+// issued by the configured GitHub App.
+func verifyAppJWT(appJWT string, config *config) error {
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM([]byte(config.AppPublicKey))
+	if err != nil {
+		return fmt.Errorf("failed to parse the configured app_public_key: %w", err)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(appJWT, claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return fmt.Errorf("failed to verify the GitHub App JWT: %w", err)
+	}
+
+	// GitHub App libraries encode the app ID either as a number or a string
+	var issuer string
+	switch iss := claims["iss"].(type) {
+	case string:
+		issuer = iss
+	case float64:
+		issuer = strconv.FormatInt(int64(iss), 10)
+	}
+	if issuer != strconv.FormatInt(config.AppID, 10) {
+		return errors.New("JWT was not issued by the configured GitHub App")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("JWT is missing the exp claim")
+	}
+	if time.Until(time.Unix(int64(exp), 0)) > maxAppJWTLifetime {
+		return fmt.Errorf("JWT must expire within %s", maxAppJWTLifetime)
+	}
+
+	return nil
+}
+
+// githubApp holds the fields of a GitHub App used for authentication, as
+// go-github does not expose the slug.
+type githubApp struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// getAuthenticatedApp returns the GitHub App the client is authenticated as.
+func getAuthenticatedApp(ctx context.Context, client *github.Client) (*githubApp, error) {
+	req, err := client.NewRequest("GET", "app", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &githubApp{}
+	if _, err := client.Do(ctx, req, app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+type verifyAppCredentialsResp struct {
+	App          *githubApp
+	Installation *github.Installation
+	Policies     []string
+
+	// Warnings to send back to the caller
+	Warnings []string
+
+	// This is just a cache to send back to the caller
+	Config *config
+}
+
+// botLogin returns the name of the bot user GitHub associates with the app,
+// which can't collide with the login of a GitHub user.
+func (r *verifyAppCredentialsResp) botLogin() string {
+	return r.App.Slug + "[bot]"
+}
@@ -5,9 +5,18 @@ package github
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/assert"
@@ -187,3 +196,160 @@ func TestGitHub_Login_NoOrgID(t *testing.T) {
 	// the ID should be set, we grab it from the GET /orgs API
 	assert.Equal(t, int64(12345), resp.Data["organization_id"])
 }
+
+// testAppKey returns a GitHub App private key and its PEM encoded public key.
+func testAppKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// testAppJWT signs a GitHub App JWT the way GitHub App libraries do.
+func testAppJWT(t *testing.T, key *rsa.PrivateKey, issuer interface{}, lifetime time.Duration) string {
+	t.Helper()
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(lifetime).Unix(),
+		"iss": issuer,
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// TestGitHub_Login_App tests that a GitHub App installed on the configured
+// organization can login with its JWT
+func TestGitHub_Login_App(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+	ctx := namespace.RootContext(nil)
+
+	key, publicKey := testAppKey(t)
+
+	// use a test server to return our mock GH app info, which also checks
+	// that the JWT is sent as bearer token
+	var bearer string
+	ts := setupTestServer(t)
+	defer ts.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		ts.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Path:      "config",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"organization":    "foo-org",
+			"organization_id": 12345,
+			"base_url":        proxy.URL,
+			"app_id":          4242,
+			"app_public_key":  publicKey,
+		},
+		Storage: s,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Error())
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Path:      "map/apps/foo-app",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"value": "app-policy",
+		},
+		Storage: s,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Error())
+
+	login := func(appJWT string) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Path:      "login",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"jwt": appJWT,
+			},
+			Storage: s,
+		})
+	}
+
+	// the app ID can be encoded as a number or a string
+	for _, issuer := range []interface{}{4242, "4242"} {
+		appJWT := testAppJWT(t, key, issuer, 5*time.Minute)
+		resp, err = login(appJWT)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Error())
+		assert.Equal(t, appJWT, bearer)
+		assert.Equal(t, "foo-app[bot]", resp.Auth.Alias.Name)
+		assert.Equal(t, map[string]string{
+			"app_id":          "4242",
+			"app_slug":        "foo-app",
+			"installation_id": "77",
+			"org":             "foo-org",
+		}, resp.Auth.Metadata)
+		assert.Contains(t, resp.Auth.Policies, "app-policy")
+		assert.False(t, resp.Auth.Renewable)
+	}
+
+	// JWTs of other apps, signed by other keys or living too long are rejected
+	_, err = login(testAppJWT(t, key, 1111, 5*time.Minute))
+	assert.EqualError(t, err, "JWT was not issued by the configured GitHub App")
+
+	otherKey, _ := testAppKey(t)
+	_, err = login(testAppJWT(t, otherKey, 4242, 5*time.Minute))
+	assert.ErrorContains(t, err, "failed to verify the GitHub App JWT")
+
+	_, err = login(testAppJWT(t, key, 4242, time.Hour))
+	assert.EqualError(t, err, "JWT must expire within 10m0s")
+
+	_, err = login(testAppJWT(t, key, 4242, -time.Second))
+	assert.ErrorContains(t, err, "failed to verify the GitHub App JWT")
+}
+
+// TestGitHub_Login_AppNotInstalled tests that a GitHub App cannot login if it
+// is not installed on the configured organization
+func TestGitHub_Login_AppNotInstalled(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+	ctx := namespace.RootContext(nil)
+
+	key, publicKey := testAppKey(t)
+
+	ts := setupTestServer(t)
+	defer ts.Close()
+
+	config := config{
+		Organization:   "foo-org",
+		OrganizationID: 9999,
+		BaseURL:        ts.URL + "/",
+		AppID:          4242,
+		AppPublicKey:   publicKey,
+	}
+	entry, err := logical.StorageEntryJSON("config", config)
+	if err != nil {
+		t.Fatalf("failed creating storage entry")
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		t.Fatalf("writing to in mem storage failed")
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Path:      "login",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"jwt": testAppJWT(t, key, 4242, 5*time.Minute),
+		},
+		Storage: s,
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, errors.New("app is not installed on required org"), err)
+}
@@ -5,7 +5,9 @@ package radius
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"runtime"
@@ -17,6 +19,8 @@ import (
 	logicaltest "github.com/hashicorp/vault/helper/testhelpers/logical"
 	"github.com/hashicorp/vault/sdk/helper/docker"
 	"github.com/hashicorp/vault/sdk/logical"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
 )
 
 const (
@@ -190,6 +194,125 @@ func TestBackend_users(t *testing.T) {
 	})
 }
 
+func TestBackend_LoginChallenge(t *testing.T) {
+	// The pinned radius library only encodes User-Password values that are a
+	// multiple of 16 bytes long, so keep the test passwords at that length.
+	const (
+		testChallengePassword = "challenge-passwd"
+		testChallengeOTP      = "1234567890123456"
+		testStatelessPassword = "stateless-passwd"
+	)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &radius.PacketServer{
+		SecretSource: radius.StaticSecretSource([]byte("secret")),
+		Handler: radius.HandlerFunc(func(w radius.ResponseWriter, r *radius.Request) {
+			state := rfc2865.State_Get(r.Packet)
+			password := rfc2865.UserPassword_GetString(r.Packet)
+			switch {
+			case state == nil && password == testChallengePassword:
+				resp := r.Response(radius.CodeAccessChallenge)
+				rfc2865.State_Set(resp, []byte("abc"))
+				rfc2865.ReplyMessage_SetString(resp, "Enter OTP")
+				w.Write(resp)
+			case state == nil && password == testStatelessPassword:
+				resp := r.Response(radius.CodeAccessChallenge)
+				rfc2865.ReplyMessage_SetString(resp, "Enter OTP")
+				w.Write(resp)
+			case string(state) == "abc" && password == testChallengeOTP:
+				w.Write(r.Response(radius.CodeAccessAccept))
+			default:
+				w.Write(r.Response(radius.CodeAccessReject))
+			}
+		}),
+	}
+	go server.Serve(conn)
+	defer server.Shutdown(context.Background())
+
+	storage := &logical.InmemStorage{}
+	b, err := Factory(context.Background(), &logical.BackendConfig{
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: testSysTTL,
+			MaxLeaseTTLVal:     testSysMaxTTL,
+		},
+		StorageView: storage,
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"host":                       "127.0.0.1",
+			"port":                       conn.LocalAddr().(*net.UDPAddr).Port,
+			"secret":                     "secret",
+			"unregistered_user_policies": "default",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	login := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "login/test",
+			Storage:    storage,
+			Data:       data,
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp = login(map[string]interface{}{"password": testChallengePassword})
+	if resp == nil || resp.Auth != nil {
+		t.Fatalf("expected a challenge, got: %#v", resp)
+	}
+	if resp.Data["reply_message"] != "Enter OTP" {
+		t.Fatalf("bad reply_message: %#v", resp.Data)
+	}
+	state := resp.Data["challenge_state"].(string)
+	if state != base64.StdEncoding.EncodeToString([]byte("abc")) {
+		t.Fatalf("bad challenge_state: %q", state)
+	}
+
+	resp = login(map[string]interface{}{"password": "0000000000000000", "challenge_state": state})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for wrong response, got: %#v", resp)
+	}
+
+	resp = login(map[string]interface{}{"password": testChallengeOTP, "challenge_state": state})
+	if resp == nil || resp.Auth == nil {
+		t.Fatalf("expected auth, got: %#v", resp)
+	}
+	if resp.Auth.Renewable {
+		t.Fatal("expected token issued after a challenge to be non-renewable")
+	}
+	if _, ok := resp.Auth.InternalData["password"]; ok {
+		t.Fatal("challenge response should not be stored for renewal")
+	}
+
+	resp = login(map[string]interface{}{"password": testChallengeOTP, "challenge_state": "%%%"})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for invalid challenge_state, got: %#v", resp)
+	}
+
+	resp = login(map[string]interface{}{"password": testStatelessPassword})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for a challenge without a state, got: %#v", resp)
+	}
+}
+
 func TestBackend_acceptance(t *testing.T) {
 	b, err := Factory(context.Background(), &logical.BackendConfig{
 		Logger: nil,
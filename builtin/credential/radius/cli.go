@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package radius
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	pwd "github.com/hashicorp/go-secure-stdlib/password"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+// maxChallenges is the maximum number of challenges of the authentication
+// server the CLI answers during a login.
+const maxChallenges = 10
+
+type CLIHandler struct{}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	var data struct {
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"`
+		Mount    string `mapstructure:"mount"`
+	}
+	if err := mapstructure.WeakDecode(m, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Username == "" {
+		return nil, fmt.Errorf("'username' must be specified")
+	}
+	if data.Password == "" {
+		password, err := h.prompt("Password (will be hidden): ")
+		if err != nil {
+			return nil, err
+		}
+		data.Password = password
+	}
+	if data.Mount == "" {
+		data.Mount = "radius"
+	}
+
+	path := fmt.Sprintf("auth/%s/login/%s", data.Mount, data.Username)
+	options := map[string]interface{}{
+		"password": data.Password,
+	}
+
+	// Answer the challenges of the authentication server until it accepts or
+	// rejects the login
+	for challenges := 0; ; challenges++ {
+		secret, err := c.Logical().Write(path, options)
+		if err != nil {
+			return nil, err
+		}
+		if secret == nil {
+			return nil, fmt.Errorf("empty response from credential provider")
+		}
+		if secret.Auth != nil || secret.Data["challenge_state"] == nil {
+			return secret, nil
+		}
+		if challenges == maxChallenges {
+			return nil, fmt.Errorf("the authentication server sent more than %d challenges", maxChallenges)
+		}
+
+		message, _ := secret.Data["reply_message"].(string)
+		if message == "" {
+			message = "Response"
+		}
+		response, err := h.prompt(strings.TrimSpace(message) + " (will be hidden): ")
+		if err != nil {
+			return nil, err
+		}
+
+		options = map[string]interface{}{
+			"password":        response,
+			"challenge_state": secret.Data["challenge_state"],
+		}
+	}
+}
+
+func (h *CLIHandler) prompt(message string) (string, error) {
+	fmt.Fprint(os.Stderr, message)
+	value, err := pwd.Read(os.Stdin)
+	fmt.Fprintf(os.Stderr, "\n")
+	return value, err
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=radius [CONFIG K=V...]
+
+  The RADIUS auth method allows users to authenticate using a RADIUS
+  server.
+
+  Authenticate as "sally":
+
+      $ vault login -method=radius username=sally
+      Password (will be hidden):
+
+  If the RADIUS server challenges the login, for instance for a one-time
+  password, the CLI prompts for the response with the message of the server.
+
+Configuration:
+
+  mount=<string>
+      Path where the RADIUS credential method is mounted. This is usually
+      provided via the -path flag in the "vault login" command, but it can be
+      specified here as well. If specified here, it takes precedence over the
+      value for -path. The default value is "radius".
+
+  password=<string>
+      Password to use for authentication. If not provided, the CLI will prompt
+      for this on stdin.
+
+  username=<string>
+      Username to use for authentication.
+`
+
+	return strings.TrimSpace(help)
+}
@@ -5,6 +5,7 @@ package radius
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net"
 	"strconv"
@@ -43,7 +44,12 @@ func pathLogin(b *backend) *framework.Path {
 
 			"password": {
 				Type:        framework.TypeString,
-				Description: "Password for this user.",
+				Description: "Password for this user, or the response to a challenge.",
+			},
+
+			"challenge_state": {
+				Type:        framework.TypeString,
+				Description: "State returned along with a challenge of the authentication server, when answering it.",
 			},
 		},
 
@@ -102,18 +108,28 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		}
 	}
 
-	if password == "" {
+	var state []byte
+	if challengeState := d.Get("challenge_state").(string); challengeState != "" {
+		state, err = base64.StdEncoding.DecodeString(challengeState)
+		if err != nil {
+			return logical.ErrorResponse("invalid challenge_state"), nil
+		}
+	}
+
+	// Challenges such as push notifications may be answered without a
+	// password
+	if password == "" && state == nil {
 		return logical.ErrorResponse("password cannot be empty"), nil
 	}
 
-	policies, resp, err := b.RadiusLogin(ctx, req, username, password)
+	policies, resp, err := b.RadiusLogin(ctx, req, username, password, state)
 	// Handle an internal error
 	if err != nil {
 		return nil, err
 	}
 	if resp != nil {
-		// Handle a logical error
-		if resp.IsError() {
+		// Handle a logical error, or a challenge to be answered by the client
+		if resp.IsError() || isChallenge(resp) {
 			return resp, nil
 		}
 	}
@@ -123,9 +139,6 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 			"username": username,
 			"policies": strings.Join(policies, ","),
 		},
-		InternalData: map[string]interface{}{
-			"password": password,
-		},
 		DisplayName: username,
 		Alias: &logical.Alias{
 			Name: username,
@@ -133,6 +146,16 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	}
 	cfg.PopulateTokenAuth(auth)
 
+	if state == nil {
+		auth.InternalData = map[string]interface{}{
+			"password": password,
+		}
+	} else {
+		// The password answered a challenge, which can't be answered again
+		// on renewal
+		auth.Renewable = false
+	}
+
 	resp.Auth = auth
 	if policies != nil {
 		resp.Auth.Policies = append(resp.Auth.Policies, policies...)
@@ -151,15 +174,21 @@ func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *f
 	}
 
 	username := req.Auth.Metadata["username"]
-	password := req.Auth.InternalData["password"].(string)
+	password, ok := req.Auth.InternalData["password"].(string)
+	if !ok {
+		return nil, fmt.Errorf("token was issued after a challenge and cannot be renewed")
+	}
 
 	var resp *logical.Response
 	var loginPolicies []string
 
-	loginPolicies, resp, err = b.RadiusLogin(ctx, req, username, password)
+	loginPolicies, resp, err = b.RadiusLogin(ctx, req, username, password, nil)
 	if err != nil || (resp != nil && resp.IsError()) {
 		return resp, err
 	}
+	if isChallenge(resp) {
+		return nil, fmt.Errorf("authentication server requested a challenge, not renewing")
+	}
 	finalPolicies := cfg.TokenPolicies
 	if loginPolicies != nil {
 		finalPolicies = append(finalPolicies, loginPolicies...)
@@ -175,7 +204,11 @@ func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *f
 	return &logical.Response{Auth: req.Auth}, nil
 }
 
-func (b *backend) RadiusLogin(ctx context.Context, req *logical.Request, username string, password string) ([]string, *logical.Response, error) {
+// RadiusLogin sends an Access-Request for the user. If state is set, the
+// password answers the Access-Challenge the state was returned with. An
+// Access-Challenge is returned as a response holding the challenge, see
+// isChallenge.
+func (b *backend) RadiusLogin(ctx context.Context, req *logical.Request, username string, password string, state []byte) ([]string, *logical.Response, error) {
 	cfg, err := b.Config(ctx, req)
 	if err != nil {
 		return nil, nil, err
@@ -192,6 +225,9 @@ func (b *backend) RadiusLogin(ctx context.Context, req *logical.Request, usernam
 	if cfg.NasIdentifier != "" {
 		NASIdentifier_AddString(packet, cfg.NasIdentifier)
 	}
+	if state != nil {
+		State_Set(packet, state)
+	}
 	packet.Add(5, radius.NewInteger(uint32(cfg.NasPort)))
 
 	client := radius.Client{
@@ -205,6 +241,13 @@ func (b *backend) RadiusLogin(ctx context.Context, req *logical.Request, usernam
 	if err != nil {
 		return nil, logical.ErrorResponse(err.Error()), nil
 	}
+	if received.Code == radius.CodeAccessChallenge {
+		// A challenge can only be answered along with its state
+		if len(State_Get(received)) == 0 {
+			return nil, logical.ErrorResponse("the authentication server sent a challenge without a state"), nil
+		}
+		return nil, challengeResponse(received), nil
+	}
 	if received.Code != radius.CodeAccessAccept {
 		return nil, logical.ErrorResponse("access denied by the authentication server"), nil
 	}
@@ -223,6 +266,27 @@ func (b *backend) RadiusLogin(ctx context.Context, req *logical.Request, usernam
 	return policies, &logical.Response{}, nil
 }
 
+// challengeResponse returns the challenge of an Access-Challenge packet, to be
+// answered by logging in again with the response as password and the
+// challenge_state.
+func challengeResponse(received *radius.Packet) *logical.Response {
+	replyMessages, _ := ReplyMessage_GetStrings(received)
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"challenge_state": base64.StdEncoding.EncodeToString(State_Get(received)),
+			"reply_message":   strings.Join(replyMessages, "\n"),
+		},
+	}
+}
+
+func isChallenge(resp *logical.Response) bool {
+	if resp == nil || resp.Data == nil {
+		return false
+	}
+	_, ok := resp.Data["challenge_state"]
+	return ok
+}
+
 const pathLoginSyn = `
 Log in with a username and password.
 `
@@ -230,4 +294,9 @@ Log in with a username and password.
 const pathLoginDesc = `
 This endpoint authenticates using a username and password. Please be sure to
 read the note on escaping from the path-help for the 'config' endpoint.
+
+If the authentication server answers with an Access-Challenge, no token is
+returned. Instead, the response holds the "reply_message" of the server and a
+"challenge_state". The challenge is answered by logging in again with the
+response as password, along with the "challenge_state".
 `
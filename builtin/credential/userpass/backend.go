@@ -32,6 +32,7 @@ func Backend() *backend {
 		},
 
 		Paths: []*framework.Path{
+			pathConfig(&b),
 			pathUsers(&b),
 			pathUsersList(&b),
 			pathUserPolicies(&b),
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/tokenutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -417,3 +418,86 @@ func TestBackend_UserUpgrade(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestBackend_PasswordRehash(t *testing.T) {
+	s := &logical.InmemStorage{}
+
+	config := logical.TestBackendConfig()
+	config.StorageView = s
+
+	ctx := context.Background()
+
+	b := Backend()
+	if err := b.Setup(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.setUser(ctx, s, "foo", &UserEntry{PasswordHash: bcryptHash}); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(password string) *logical.Response {
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "login/foo",
+			Storage:    s,
+			Data:       map[string]interface{}{"password": password},
+			Connection: &logical.Connection{},
+		})
+		if err != nil && err != logical.ErrInvalidCredentials {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	storedHash := func() []byte {
+		user, err := b.user(ctx, s, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.PasswordHash
+	}
+
+	// A failed login must not touch the stored hash.
+	if resp := login("wrong"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected login with the wrong password to fail: %#v", resp)
+	}
+	if !reflect.DeepEqual(storedHash(), bcryptHash) {
+		t.Fatal("password was re-hashed after a failed login")
+	}
+
+	if resp := login("password"); resp == nil || resp.Auth == nil {
+		t.Fatalf("expected login to succeed: %#v", resp)
+	}
+	argonHash := storedHash()
+	if !isArgon2idHash(argonHash) {
+		t.Fatalf("expected password to be re-hashed with argon2id, got %q", argonHash)
+	}
+	if resp := login("password"); resp == nil || resp.Auth == nil {
+		t.Fatalf("expected login with re-hashed password to succeed: %#v", resp)
+	}
+
+	// Raising the configured parameters re-hashes on the next login.
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   s,
+		Data:      map[string]interface{}{"argon2id_time": 2},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("failed to update config: resp: %#v, err: %v", resp, err)
+	}
+	if resp := login("password"); resp == nil || resp.Auth == nil {
+		t.Fatalf("expected login to succeed: %#v", resp)
+	}
+	params, _, _, err := parseArgon2idHash(storedHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Time != 2 {
+		t.Fatalf("expected password to be re-hashed with time=2, got %d", params.Time)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package userpass
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	hashAlgorithmArgon2id = "argon2id"
+	hashAlgorithmBcrypt   = "bcrypt"
+
+	argon2idPrefix    = "$argon2id$"
+	argon2idSaltLen   = 16
+	argon2idKeyLength = 32
+)
+
+var errInvalidArgon2idHash = errors.New("invalid argon2id password hash")
+
+// argon2idParams are the cost parameters of an argon2id hash. Memory is
+// given in KiB.
+type argon2idParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// hashPassword hashes the password with the algorithm and parameters of the
+// given configuration.
+func hashPassword(cfg *passwordConfig, password []byte) ([]byte, error) {
+	if cfg.HashAlgorithm == hashAlgorithmBcrypt {
+		return bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	}
+
+	salt := make([]byte, argon2idSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := cfg.argon2idParams()
+	key := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, argon2idKeyLength)
+
+	// Hashes are stored in the PHC string format, so that the parameters
+	// used for each password are kept alongside it.
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))), nil
+}
+
+// comparePasswordHash returns nil if password matches the given argon2id or
+// bcrypt hash.
+func comparePasswordHash(hash, password []byte) error {
+	if !isArgon2idHash(hash) {
+		return bcrypt.CompareHashAndPassword(hash, password)
+	}
+
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+	computed := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// passwordNeedsRehash reports whether a stored hash was created with a
+// different algorithm or weaker parameters than currently configured.
+func passwordNeedsRehash(cfg *passwordConfig, hash []byte) bool {
+	if cfg.HashAlgorithm == hashAlgorithmBcrypt {
+		return isArgon2idHash(hash)
+	}
+	if !isArgon2idHash(hash) {
+		return true
+	}
+
+	params, _, _, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	want := cfg.argon2idParams()
+	return params.Time < want.Time || params.Memory < want.Memory || params.Threads < want.Threads
+}
+
+func isArgon2idHash(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(argon2idPrefix))
+}
+
+func parseArgon2idHash(hash []byte) (*argon2idParams, []byte, []byte, error) {
+	// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	var params argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	return &params, salt, key, nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package userpass
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultArgon2idTime    = 1
+	defaultArgon2idMemory  = 64 * 1024
+	defaultArgon2idThreads = 4
+
+	// Bounds accepted for the configured argon2id parameters.
+	minArgon2idMemory = 8 * 1024
+	maxArgon2idMemory = 4 * 1024 * 1024
+	maxArgon2idTime   = 64
+)
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixUserpass,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"password_hash_algorithm": {
+				Type:          framework.TypeString,
+				Default:       hashAlgorithmArgon2id,
+				AllowedValues: []interface{}{hashAlgorithmArgon2id, hashAlgorithmBcrypt},
+				Description:   `The algorithm used to hash passwords. Either "argon2id" or "bcrypt".`,
+			},
+			"argon2id_time": {
+				Type:        framework.TypeInt,
+				Default:     defaultArgon2idTime,
+				Description: `The number of passes over memory used by argon2id.`,
+			},
+			"argon2id_memory": {
+				Type:        framework.TypeInt,
+				Default:     defaultArgon2idMemory,
+				Description: `The amount of memory in KiB used by argon2id.`,
+			},
+			"argon2id_threads": {
+				Type:        framework.TypeInt,
+				Default:     defaultArgon2idThreads,
+				Description: `The degree of parallelism used by argon2id.`,
+			},
+			"rehash_on_login": {
+				Type:    framework.TypeBool,
+				Default: true,
+				Description: `If set, passwords stored with a different algorithm or weaker parameters
+than configured are re-hashed on the next successful login.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "configure",
+				},
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathConfigRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "configuration",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	cfg, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if algRaw, ok := data.GetOk("password_hash_algorithm"); ok {
+		alg := algRaw.(string)
+		if alg != hashAlgorithmArgon2id && alg != hashAlgorithmBcrypt {
+			return logical.ErrorResponse("unsupported password_hash_algorithm %q", alg), nil
+		}
+		cfg.HashAlgorithm = alg
+	}
+	if timeRaw, ok := data.GetOk("argon2id_time"); ok {
+		cfg.Argon2idTime = timeRaw.(int)
+	}
+	if memoryRaw, ok := data.GetOk("argon2id_memory"); ok {
+		cfg.Argon2idMemory = memoryRaw.(int)
+	}
+	if threadsRaw, ok := data.GetOk("argon2id_threads"); ok {
+		cfg.Argon2idThreads = threadsRaw.(int)
+	}
+	if rehashRaw, ok := data.GetOk("rehash_on_login"); ok {
+		cfg.RehashOnLogin = rehashRaw.(bool)
+	}
+
+	if cfg.Argon2idTime < 1 || cfg.Argon2idTime > maxArgon2idTime {
+		return logical.ErrorResponse("argon2id_time must be between 1 and %d", maxArgon2idTime), nil
+	}
+	if cfg.Argon2idMemory < minArgon2idMemory || cfg.Argon2idMemory > maxArgon2idMemory {
+		return logical.ErrorResponse("argon2id_memory must be between %d and %d", minArgon2idMemory, maxArgon2idMemory), nil
+	}
+	if cfg.Argon2idThreads < 1 || cfg.Argon2idThreads > 255 {
+		return logical.ErrorResponse("argon2id_threads must be between 1 and 255"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config", cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	cfg, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"password_hash_algorithm": cfg.HashAlgorithm,
+			"argon2id_time":           cfg.Argon2idTime,
+			"argon2id_memory":         cfg.Argon2idMemory,
+			"argon2id_threads":        cfg.Argon2idThreads,
+			"rehash_on_login":         cfg.RehashOnLogin,
+		},
+	}, nil
+}
+
+// config returns the password hashing configuration of this backend, or the
+// defaults if it has not been configured.
+func (b *backend) config(ctx context.Context, s logical.Storage) (*passwordConfig, error) {
+	cfg := &passwordConfig{
+		HashAlgorithm:   hashAlgorithmArgon2id,
+		Argon2idTime:    defaultArgon2idTime,
+		Argon2idMemory:  defaultArgon2idMemory,
+		Argon2idThreads: defaultArgon2idThreads,
+		RehashOnLogin:   true,
+	}
+
+	entry, err := s.Get(ctx, "config")
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(cfg); err != nil {
+			return nil, fmt.Errorf("error reading configuration: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+type passwordConfig struct {
+	HashAlgorithm   string `json:"password_hash_algorithm"`
+	Argon2idTime    int    `json:"argon2id_time"`
+	Argon2idMemory  int    `json:"argon2id_memory"`
+	Argon2idThreads int    `json:"argon2id_threads"`
+	RehashOnLogin   bool   `json:"rehash_on_login"`
+}
+
+func (c *passwordConfig) argon2idParams() argon2idParams {
+	return argon2idParams{
+		Time:    uint32(c.Argon2idTime),
+		Memory:  uint32(c.Argon2idMemory),
+		Threads: uint8(c.Argon2idThreads),
+	}
+}
+
+const pathConfigHelpSyn = `
+Configure how user passwords are hashed.
+`
+
+const pathConfigHelpDesc = `
+This endpoint configures the algorithm and parameters used to hash user
+passwords. Changes apply to passwords set afterwards; when rehash_on_login is
+enabled, existing passwords which were hashed with a different algorithm or
+with weaker parameters than configured are re-hashed on the user's next
+successful login.
+`
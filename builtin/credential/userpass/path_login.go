@@ -13,7 +13,6 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/cidrutil"
 	"github.com/hashicorp/vault/sdk/helper/policyutil"
 	"github.com/hashicorp/vault/sdk/logical"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func pathLogin(b *backend) *framework.Path {
@@ -97,7 +96,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	passwordBytes := []byte(password)
 	switch {
 	case !legacyPassword:
-		if err := bcrypt.CompareHashAndPassword(userPassword, passwordBytes); err != nil {
+		if err := comparePasswordHash(userPassword, passwordBytes); err != nil {
 			// The failed login info of existing users alone are tracked as only
 			// existing user's failed login information is stored in storage for optimization
 			if user == nil || userError != nil {
@@ -124,6 +123,8 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		return logical.ErrorResponse("invalid username or password"), nil
 	}
 
+	b.rehashPasswordIfNeeded(ctx, req, username, user, passwordBytes, legacyPassword)
+
 	// Check for a CIDR match.
 	if len(user.TokenBoundCIDRs) > 0 {
 		if req.Connection == nil {
@@ -151,6 +152,35 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	}, nil
 }
 
+// rehashPasswordIfNeeded migrates the user's stored password to the
+// configured hashing algorithm and parameters after a successful login.
+// Failures are logged rather than failing the login.
+func (b *backend) rehashPasswordIfNeeded(ctx context.Context, req *logical.Request, username string, user *UserEntry, password []byte, legacyPassword bool) {
+	cfg, err := b.config(ctx, req.Storage)
+	if err != nil {
+		b.Logger().Warn("unable to read configuration, not re-hashing password", "error", err)
+		return
+	}
+	if !cfg.RehashOnLogin || (!legacyPassword && !passwordNeedsRehash(cfg, user.PasswordHash)) {
+		return
+	}
+
+	hash, err := hashPassword(cfg, password)
+	if err != nil {
+		b.Logger().Warn("unable to re-hash password", "username", username, "error", err)
+		return
+	}
+	user.PasswordHash = hash
+	user.Password = ""
+	if err := b.setUser(ctx, req.Storage, username, user); err != nil {
+		// Standbys cannot write to storage; the password will be
+		// re-hashed on a later login handled by the active node.
+		if err != logical.ErrReadOnly {
+			b.Logger().Warn("unable to store re-hashed password", "username", username, "error", err)
+		}
+	}
+}
+
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	// Get the user
 	user, err := b.user(ctx, req.Storage, req.Auth.Metadata["username"])
@@ -218,7 +218,7 @@ func (b *backend) userCreateUpdate(ctx context.Context, req *logical.Request, d
 	}
 
 	if _, ok := d.GetOk("password"); ok {
-		userErr, intErr := b.updateUserPassword(req, d, userEntry)
+		userErr, intErr := b.updateUserPassword(ctx, req, d, userEntry)
 		if intErr != nil {
 			return nil, intErr
 		}
@@ -264,8 +264,8 @@ type UserEntry struct {
 	// PasswordHash, but is retained for backwards compatibility.
 	Password string
 
-	// PasswordHash is a bcrypt hash of the password. This is
-	// used instead of the actual password in Vault 0.2+.
+	// PasswordHash is an argon2id or bcrypt hash of the password. This
+	// is used instead of the actual password in Vault 0.2+.
 	PasswordHash []byte
 
 	Policies []string
@@ -118,50 +118,7 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 			OperationPrefix: operationPrefixDatabase,
 		},
 
-		Fields: map[string]*framework.FieldSchema{
-			"name": {
-				Type:        framework.TypeString,
-				Description: "Name of this database connection",
-			},
-
-			"plugin_name": {
-				Type: framework.TypeString,
-				Description: `The name of a builtin or previously registered
-				plugin known to vault. This endpoint will create an instance of
-				that plugin type.`,
-			},
-
-			"plugin_version": {
-				Type:        framework.TypeString,
-				Description: `The version of the plugin to use.`,
-			},
-
-			"verify_connection": {
-				Type:    framework.TypeBool,
-				Default: true,
-				Description: `If true, the connection details are verified by
-				actually connecting to the database. Defaults to true.`,
-			},
-
-			"allowed_roles": {
-				Type: framework.TypeCommaStringSlice,
-				Description: `Comma separated string or array of the role names
-				allowed to get creds from this database connection. If empty no
-				roles are allowed. If "*" all roles are allowed.`,
-			},
-
-			"root_rotation_statements": {
-				Type: framework.TypeStringSlice,
-				Description: `Specifies the database statements to be executed
-				to rotate the root user's credentials. See the plugin's API 
-				page for more information on support and formatting for this 
-				parameter.`,
-			},
-			"password_policy": {
-				Type:        framework.TypeString,
-				Description: `Password policy to use when generating passwords.`,
-			},
-		},
+		Fields: connectionConfigFields(),
 
 		ExistenceCheck: b.connectionExistenceCheck(),
 
@@ -201,6 +158,55 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 	}
 }
 
+// connectionConfigFields returns the fields of the connection configuration,
+// shared by the config and test-connection paths.
+func connectionConfigFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of this database connection",
+		},
+
+		"plugin_name": {
+			Type: framework.TypeString,
+			Description: `The name of a builtin or previously registered
+			plugin known to vault. This endpoint will create an instance of
+			that plugin type.`,
+		},
+
+		"plugin_version": {
+			Type:        framework.TypeString,
+			Description: `The version of the plugin to use.`,
+		},
+
+		"verify_connection": {
+			Type:    framework.TypeBool,
+			Default: true,
+			Description: `If true, the connection details are verified by
+			actually connecting to the database. Defaults to true.`,
+		},
+
+		"allowed_roles": {
+			Type: framework.TypeCommaStringSlice,
+			Description: `Comma separated string or array of the role names
+			allowed to get creds from this database connection. If empty no
+			roles are allowed. If "*" all roles are allowed.`,
+		},
+
+		"root_rotation_statements": {
+			Type: framework.TypeStringSlice,
+			Description: `Specifies the database statements to be executed
+			to rotate the root user's credentials. See the plugin's API 
+			page for more information on support and formatting for this 
+			parameter.`,
+		},
+		"password_policy": {
+			Type:        framework.TypeString,
+			Description: `Password policy to use when generating passwords.`,
+		},
+	}
+}
+
 func (b *databaseBackend) connectionExistenceCheck() framework.ExistenceFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
 		name := data.Get("name").(string)
@@ -322,127 +328,16 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			return logical.ErrorResponse(respErrEmptyName), nil
 		}
 
-		// Baseline
-		config := &DatabaseConfig{}
-
-		entry, err := req.Storage.Get(ctx, fmt.Sprintf("config/%s", name))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read connection configuration: %w", err)
-		}
-		if entry != nil {
-			if err := entry.DecodeJSON(config); err != nil {
-				return nil, err
-			}
-		}
-
-		if pluginNameRaw, ok := data.GetOk("plugin_name"); ok {
-			config.PluginName = pluginNameRaw.(string)
-		} else if req.Operation == logical.CreateOperation {
-			config.PluginName = data.Get("plugin_name").(string)
-		}
-		if config.PluginName == "" {
-			return logical.ErrorResponse(respErrEmptyPluginName), nil
-		}
-
-		if pluginVersionRaw, ok := data.GetOk("plugin_version"); ok {
-			config.PluginVersion = pluginVersionRaw.(string)
-		}
-
-		var builtinShadowed bool
-		if unversionedPlugin, err := b.System().LookupPlugin(ctx, config.PluginName, consts.PluginTypeDatabase); err == nil && !unversionedPlugin.Builtin {
-			builtinShadowed = true
-		}
-		switch {
-		case config.PluginVersion != "":
-			semanticVersion, err := version.NewVersion(config.PluginVersion)
-			if err != nil {
-				return logical.ErrorResponse("version %q is not a valid semantic version: %s", config.PluginVersion, err), nil
-			}
-
-			// Canonicalize the version.
-			config.PluginVersion = "v" + semanticVersion.String()
-
-			if config.PluginVersion == versions.GetBuiltinVersion(consts.PluginTypeDatabase, config.PluginName) {
-				if builtinShadowed {
-					return logical.ErrorResponse("database plugin %q, version %s not found, as it is"+
-						" overridden by an unversioned plugin of the same name. Omit `plugin_version` to use the unversioned plugin", config.PluginName, config.PluginVersion), nil
-				}
-
-				config.PluginVersion = ""
-			}
-		case builtinShadowed:
-			// We'll select the unversioned plugin that's been registered.
-		case req.Operation == logical.CreateOperation:
-			// No version provided and no unversioned plugin of that name available.
-			// Pin to the current latest version if any versioned plugins are registered.
-			plugins, err := b.System().ListVersionedPlugins(ctx, consts.PluginTypeDatabase)
-			if err != nil {
-				return nil, err
-			}
-
-			var versionedCandidates []pluginutil.VersionedPlugin
-			for _, plugin := range plugins {
-				if !plugin.Builtin && plugin.Name == config.PluginName && plugin.Version != "" {
-					versionedCandidates = append(versionedCandidates, plugin)
-				}
-			}
-
-			if len(versionedCandidates) != 0 {
-				// Sort in reverse order.
-				sort.SliceStable(versionedCandidates, func(i, j int) bool {
-					return versionedCandidates[i].SemanticVersion.GreaterThan(versionedCandidates[j].SemanticVersion)
-				})
-
-				config.PluginVersion = "v" + versionedCandidates[0].SemanticVersion.String()
-				b.logger.Debug(fmt.Sprintf("pinning %q database plugin version %q from candidates %v", config.PluginName, config.PluginVersion, versionedCandidates))
-			}
-		}
-
-		if allowedRolesRaw, ok := data.GetOk("allowed_roles"); ok {
-			config.AllowedRoles = allowedRolesRaw.([]string)
-		} else if req.Operation == logical.CreateOperation {
-			config.AllowedRoles = data.Get("allowed_roles").([]string)
-		}
-
-		if rootRotationStatementsRaw, ok := data.GetOk("root_rotation_statements"); ok {
-			config.RootCredentialsRotateStatements = rootRotationStatementsRaw.([]string)
-		} else if req.Operation == logical.CreateOperation {
-			config.RootCredentialsRotateStatements = data.Get("root_rotation_statements").([]string)
+		config, resp, err := b.buildConnectionConfig(ctx, req, data)
+		if resp != nil || err != nil {
+			return resp, err
 		}
 
-		if passwordPolicyRaw, ok := data.GetOk("password_policy"); ok {
-			config.PasswordPolicy = passwordPolicyRaw.(string)
-		}
-
-		// Remove these entries from the data before we store it keyed under
-		// ConnectionDetails.
-		delete(data.Raw, "name")
-		delete(data.Raw, "plugin_name")
-		delete(data.Raw, "plugin_version")
-		delete(data.Raw, "allowed_roles")
-		delete(data.Raw, "verify_connection")
-		delete(data.Raw, "root_rotation_statements")
-		delete(data.Raw, "password_policy")
-
 		id, err := uuid.GenerateUUID()
 		if err != nil {
 			return nil, err
 		}
 
-		// If this is an update, take any new values, overwrite what was there
-		// before, and pass that in as the "new" set of values to the plugin,
-		// then save what results
-		if req.Operation == logical.CreateOperation {
-			config.ConnectionDetails = data.Raw
-		} else {
-			if config.ConnectionDetails == nil {
-				config.ConnectionDetails = make(map[string]interface{})
-			}
-			for k, v := range data.Raw {
-				config.ConnectionDetails[k] = v
-			}
-		}
-
 		// Create a database plugin and initialize it.
 		dbw, err := newDatabaseWrapper(ctx, config.PluginName, config.PluginVersion, b.System(), b.logger)
 		if err != nil {
@@ -482,7 +377,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			return nil, err
 		}
 
-		resp := &logical.Response{}
+		resp = &logical.Response{}
 
 		// This is a simple test to check for passwords in the connection_url parameter. If one exists,
 		// warn the user to use templated url string
@@ -508,6 +403,132 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 	}
 }
 
+// buildConnectionConfig returns the connection configuration resulting from
+// applying the request data to the stored configuration, if any. An error
+// response is returned if the request data is invalid.
+func (b *databaseBackend) buildConnectionConfig(ctx context.Context, req *logical.Request, data *framework.FieldData) (*DatabaseConfig, *logical.Response, error) {
+	name := data.Get("name").(string)
+
+	// Baseline
+	config := &DatabaseConfig{}
+
+	entry, err := req.Storage.Get(ctx, fmt.Sprintf("config/%s", name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read connection configuration: %w", err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, nil, err
+		}
+	}
+	create := entry == nil
+
+	if pluginNameRaw, ok := data.GetOk("plugin_name"); ok {
+		config.PluginName = pluginNameRaw.(string)
+	} else if create {
+		config.PluginName = data.Get("plugin_name").(string)
+	}
+	if config.PluginName == "" {
+		return nil, logical.ErrorResponse(respErrEmptyPluginName), nil
+	}
+
+	if pluginVersionRaw, ok := data.GetOk("plugin_version"); ok {
+		config.PluginVersion = pluginVersionRaw.(string)
+	}
+
+	var builtinShadowed bool
+	if unversionedPlugin, err := b.System().LookupPlugin(ctx, config.PluginName, consts.PluginTypeDatabase); err == nil && !unversionedPlugin.Builtin {
+		builtinShadowed = true
+	}
+	switch {
+	case config.PluginVersion != "":
+		semanticVersion, err := version.NewVersion(config.PluginVersion)
+		if err != nil {
+			return nil, logical.ErrorResponse("version %q is not a valid semantic version: %s", config.PluginVersion, err), nil
+		}
+
+		// Canonicalize the version.
+		config.PluginVersion = "v" + semanticVersion.String()
+
+		if config.PluginVersion == versions.GetBuiltinVersion(consts.PluginTypeDatabase, config.PluginName) {
+			if builtinShadowed {
+				return nil, logical.ErrorResponse("database plugin %q, version %s not found, as it is"+
+					" overridden by an unversioned plugin of the same name. Omit `plugin_version` to use the unversioned plugin", config.PluginName, config.PluginVersion), nil
+			}
+
+			config.PluginVersion = ""
+		}
+	case builtinShadowed:
+		// We'll select the unversioned plugin that's been registered.
+	case create:
+		// No version provided and no unversioned plugin of that name available.
+		// Pin to the current latest version if any versioned plugins are registered.
+		plugins, err := b.System().ListVersionedPlugins(ctx, consts.PluginTypeDatabase)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var versionedCandidates []pluginutil.VersionedPlugin
+		for _, plugin := range plugins {
+			if !plugin.Builtin && plugin.Name == config.PluginName && plugin.Version != "" {
+				versionedCandidates = append(versionedCandidates, plugin)
+			}
+		}
+
+		if len(versionedCandidates) != 0 {
+			// Sort in reverse order.
+			sort.SliceStable(versionedCandidates, func(i, j int) bool {
+				return versionedCandidates[i].SemanticVersion.GreaterThan(versionedCandidates[j].SemanticVersion)
+			})
+
+			config.PluginVersion = "v" + versionedCandidates[0].SemanticVersion.String()
+			b.logger.Debug(fmt.Sprintf("pinning %q database plugin version %q from candidates %v", config.PluginName, config.PluginVersion, versionedCandidates))
+		}
+	}
+
+	if allowedRolesRaw, ok := data.GetOk("allowed_roles"); ok {
+		config.AllowedRoles = allowedRolesRaw.([]string)
+	} else if create {
+		config.AllowedRoles = data.Get("allowed_roles").([]string)
+	}
+
+	if rootRotationStatementsRaw, ok := data.GetOk("root_rotation_statements"); ok {
+		config.RootCredentialsRotateStatements = rootRotationStatementsRaw.([]string)
+	} else if create {
+		config.RootCredentialsRotateStatements = data.Get("root_rotation_statements").([]string)
+	}
+
+	if passwordPolicyRaw, ok := data.GetOk("password_policy"); ok {
+		config.PasswordPolicy = passwordPolicyRaw.(string)
+	}
+
+	// Remove these entries from the data before we store it keyed under
+	// ConnectionDetails.
+	delete(data.Raw, "name")
+	delete(data.Raw, "plugin_name")
+	delete(data.Raw, "plugin_version")
+	delete(data.Raw, "allowed_roles")
+	delete(data.Raw, "verify_connection")
+	delete(data.Raw, "root_rotation_statements")
+	delete(data.Raw, "password_policy")
+
+	// If this is an update, take any new values, overwrite what was there
+	// before, and pass that in as the "new" set of values to the plugin,
+	// then save what results
+	if create {
+		config.ConnectionDetails = data.Raw
+	} else {
+		if config.ConnectionDetails == nil {
+			config.ConnectionDetails = make(map[string]interface{})
+		}
+		for k, v := range data.Raw {
+			config.ConnectionDetails[k] = v
+		}
+	}
+
+	return config, nil, nil
+}
+
 func storeConfig(ctx context.Context, storage logical.Storage, name string, config *DatabaseConfig) error {
 	entry, err := logical.StorageEntryJSON(fmt.Sprintf("config/%s", name), config)
 	if err != nil {
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// sensitiveConnectionDetails are the connection details which are never
+// returned, see connectionReadHandler.
+var sensitiveConnectionDetails = []string{"password", "private_key", "service_account_json"}
+
+// pathTestConnection configures a path to verify connection details without
+// storing them.
+func pathTestConnection(b *databaseBackend) *framework.Path {
+	fields := connectionConfigFields()
+	delete(fields, "verify_connection")
+
+	return &framework.Path{
+		Pattern: fmt.Sprintf("test-connection/%s", framework.GenericNameRegex("name")),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixDatabase,
+			OperationVerb:   "test",
+			OperationSuffix: "connection",
+		},
+
+		Fields: fields,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTestConnectionWrite(),
+		},
+
+		HelpSynopsis:    pathTestConnectionHelpSyn,
+		HelpDescription: pathTestConnectionHelpDesc,
+	}
+}
+
+// pathTestConnectionWrite verifies the connection details resulting from
+// applying the request data to the stored configuration, if any, by
+// initializing a new plugin instance which is closed right after. Neither the
+// configuration nor the plugin instance are kept.
+func (b *databaseBackend) pathTestConnectionWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, resp, err := b.buildConnectionConfig(ctx, req, data)
+		if resp != nil || err != nil {
+			return resp, err
+		}
+
+		dbw, err := newDatabaseWrapper(ctx, config.PluginName, config.PluginVersion, b.System(), b.logger)
+		if err != nil {
+			return logical.ErrorResponse("error creating database object: %s", sanitizeConnectionError(err, config.ConnectionDetails)), nil
+		}
+		defer dbw.Close()
+
+		initReq := v5.InitializeRequest{
+			Config:           config.ConnectionDetails,
+			VerifyConnection: true,
+		}
+		if _, err := dbw.Initialize(ctx, initReq); err != nil {
+			return logical.ErrorResponse("connection verification failed: %s", sanitizeConnectionError(err, config.ConnectionDetails)), nil
+		}
+
+		b.Logger().Debug("verified database connection", "name", name, "plugin_name", config.PluginName)
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"plugin_name":    config.PluginName,
+				"plugin_version": config.PluginVersion,
+				"verified":       true,
+			},
+		}, nil
+	}
+}
+
+// sanitizeConnectionError returns the message of an error returned by a
+// plugin with the credentials of the connection details redacted, since
+// drivers may include them in their errors.
+func sanitizeConnectionError(err error, details map[string]interface{}) string {
+	msg := err.Error()
+
+	var secrets []string
+	for _, key := range sensitiveConnectionDetails {
+		if value, ok := details[key].(string); ok && value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+	if connURL, ok := details["connection_url"].(string); ok && connURL != "" {
+		if p, err := url.Parse(connURL); err == nil {
+			if password, ok := p.User.Password(); ok && password != "" {
+				msg = strings.ReplaceAll(msg, connURL, p.Redacted())
+				secrets = append(secrets, password)
+			}
+		}
+	}
+
+	for _, secret := range secrets {
+		msg = strings.ReplaceAll(msg, secret, "[redacted]")
+	}
+	return msg
+}
+
+const pathTestConnectionHelpSyn = `
+Verify connection details to a database without storing them.
+`
+
+const pathTestConnectionHelpDesc = `
+This path verifies connection details by running the plugin and connecting to
+the database, like writing the configuration with "verify_connection" set, but
+without storing the configuration or affecting the active connection. It
+accepts the same parameters as the "config/:name" path. If a configuration is
+stored under the name, the given parameters are applied on top of it, so
+changes can be checked before they are written.
+
+Credentials in the connection details are redacted from the returned errors.
+`
@@ -620,6 +620,70 @@ func TestBackend_max_token_name_length(t *testing.T) {
 	}
 }
 
+func TestBackend_renderNames(t *testing.T) {
+	names, err := renderNames([]string{"readonly", "{{namespace}}-{{job}}"}, "prod", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"readonly", "prod-web"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	if _, err := renderNames([]string{"{{job}}-policy"}, "prod", ""); err == nil {
+		t.Fatal("expected an error for a missing job")
+	}
+	if _, err := renderNames([]string{"{{group}}"}, "prod", "web"); err == nil {
+		t.Fatal("expected an error for an unknown template")
+	}
+}
+
+// TestBackend_CredsCreate_AllowedNamespacesJobs ensures templated roles
+// require allow-lists, and that credentials are refused for the namespaces and
+// jobs they don't allow.
+func TestBackend_CredsCreate_AllowedNamespacesJobs(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roleReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "role/templated",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"policies": []string{"{{namespace}}-{{job}}"},
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), roleReq)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error writing a templated role without allow-lists: resp:%#v err:%v", resp, err)
+	}
+
+	roleReq.Data["allowed_namespaces"] = []string{"team-*"}
+	roleReq.Data["allowed_jobs"] = []string{"web"}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("failed to write role: resp:%#v err:%v", resp, err)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"namespace": "default", "job": "web"},
+		{"namespace": "team-a", "job": "api"},
+	} {
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "creds/templated",
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected %v to be refused: resp:%#v err:%v", data, resp, err)
+		}
+	}
+}
+
 const caCert = `-----BEGIN CERTIFICATE-----
 MIIF7zCCA9egAwIBAgIINVVQic4bju8wDQYJKoZIhvcNAQELBQAwaDELMAkGA1UE
 BhMCVVMxFDASBgNVBAoMC1Vuc3BlY2lmaWVkMR8wHQYDVQQLDBZjYS0zODQzMDY2
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -32,10 +33,23 @@ func pathCredsCreate(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Name of the role",
 			},
+			"namespace": {
+				Type:        framework.TypeString,
+				Description: "Nomad namespace used to render the {{namespace}} template in the role's policies and roles. Must be allowed by the role's allowed_namespaces.",
+			},
+			"job": {
+				Type:        framework.TypeString,
+				Description: "Nomad job ID used to render the {{job}} template in the role's policies and roles. Must be allowed by the role's allowed_jobs.",
+			},
+			"identity_token": {
+				Type:        framework.TypeString,
+				Description: "Workload identity token exchanged for a Nomad token. Required if the role uses an auth method.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.ReadOperation: b.pathTokenRead,
+			logical.ReadOperation:   b.pathTokenRead,
+			logical.UpdateOperation: b.pathTokenRead,
 		},
 	}
 }
@@ -57,6 +71,18 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 		return logical.ErrorResponse(fmt.Sprintf("role %q not found", name)), nil
 	}
 
+	// The namespace and job are chosen by the caller, so they are checked
+	// against the role's allow-lists before being rendered in the policies
+	// and roles of the token.
+	namespace := d.Get("namespace").(string)
+	if namespace != "" && !strutil.StrListContainsGlob(role.AllowedNamespaces, namespace) {
+		return logical.ErrorResponse("namespace %q is not allowed by role %q", namespace, name), nil
+	}
+	job := d.Get("job").(string)
+	if job != "" && !strutil.StrListContainsGlob(role.AllowedJobs, job) {
+		return logical.ErrorResponse("job %q is not allowed by role %q", job, name), nil
+	}
+
 	// Determine if we have a lease configuration
 	leaseConfig, err := b.LeaseConfig(ctx, req.Storage)
 	if err != nil {
@@ -72,8 +98,46 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 		return nil, err
 	}
 
+	policies, err := renderNames(role.Policies, namespace, job)
+	if err != nil {
+		return logical.ErrorResponse("error rendering policies: %s", err), nil
+	}
+	roleNames, err := renderNames(role.Roles, namespace, job)
+	if err != nil {
+		return logical.ErrorResponse("error rendering roles: %s", err), nil
+	}
+
+	var token *api.ACLToken
+	if role.AuthMethod != "" {
+		token, err = b.loginToken(c, role, d.Get("identity_token").(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	} else {
+		token, err = b.createToken(c, role, name, req.DisplayName, tokenNameLength, policies, roleNames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Use the helper to create the secret
+	resp := b.Secret(SecretTokenType).Response(map[string]interface{}{
+		"secret_id":   token.SecretID,
+		"accessor_id": token.AccessorID,
+	}, map[string]interface{}{
+		"accessor_id": token.AccessorID,
+	})
+	resp.Secret.TTL = leaseConfig.TTL
+	resp.Secret.MaxTTL = leaseConfig.MaxTTL
+
+	return resp, nil
+}
+
+// createToken creates a new token using the configured access token, which
+// must be allowed to manage Nomad ACL tokens.
+func (b *backend) createToken(c *api.Client, role *roleConfig, name, displayName string, tokenNameLength int, policies, roleNames []string) (*api.ACLToken, error) {
 	// Generate a name for the token
-	tokenName := fmt.Sprintf("vault-%s-%s-%d", name, req.DisplayName, time.Now().UnixNano())
+	tokenName := fmt.Sprintf("vault-%s-%s-%d", name, displayName, time.Now().UnixNano())
 
 	// Note: if the given role name is sufficiently long, the UnixNano() portion
 	// of the pseudo randomized token name is the part that gets trimmed off,
@@ -82,26 +146,39 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 		tokenName = tokenName[:tokenNameLength]
 	}
 
+	var roleLinks []*api.ACLTokenRoleLink
+	for _, roleName := range roleNames {
+		roleLinks = append(roleLinks, &api.ACLTokenRoleLink{Name: roleName})
+	}
+
 	// Create it
 	token, _, err := c.ACLTokens().Create(&api.ACLToken{
 		Name:     tokenName,
 		Type:     role.TokenType,
-		Policies: role.Policies,
+		Policies: policies,
+		Roles:    roleLinks,
 		Global:   role.Global,
 	}, nil)
 	if err != nil {
 		return nil, err
 	}
+	return token, nil
+}
 
-	// Use the helper to create the secret
-	resp := b.Secret(SecretTokenType).Response(map[string]interface{}{
-		"secret_id":   token.SecretID,
-		"accessor_id": token.AccessorID,
-	}, map[string]interface{}{
-		"accessor_id": token.AccessorID,
-	})
-	resp.Secret.TTL = leaseConfig.TTL
-	resp.Secret.MaxTTL = leaseConfig.MaxTTL
+// loginToken derives a token by exchanging the given workload identity token
+// through the role's Nomad ACL auth method. The policies and roles attached to
+// the resulting token are determined by the auth method's binding rules.
+func (b *backend) loginToken(c *api.Client, role *roleConfig, identityToken string) (*api.ACLToken, error) {
+	if identityToken == "" {
+		return nil, fmt.Errorf("identity_token is required when the role uses an auth method")
+	}
 
-	return resp, nil
+	token, _, err := c.ACLAuth().Login(&api.ACLLoginRequest{
+		AuthMethodName: role.AuthMethod,
+		LoginToken:     identityToken,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error logging in with auth method %q: %w", role.AuthMethod, err)
+	}
+	return token, nil
 }
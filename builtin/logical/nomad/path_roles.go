@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -43,8 +44,37 @@ func pathRoles(b *backend) *framework.Path {
 			},
 
 			"policies": {
-				Type:        framework.TypeCommaStringSlice,
-				Description: "Comma-separated string or list of policies as previously created in Nomad. Required for 'client' token.",
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated string or list of policies as previously created in Nomad.
+May contain the {{namespace}} and {{job}} templates. Required for 'client'
+token unless "roles" or "auth_method" is set.`,
+			},
+
+			"roles": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated string or list of ACL roles as previously created in Nomad.
+May contain the {{namespace}} and {{job}} templates.`,
+			},
+
+			"allowed_namespaces": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated string or list of the Nomad namespaces, which may
+contain globs, credentials can be requested for. Required if the policies or
+roles contain the {{namespace}} template.`,
+			},
+
+			"allowed_jobs": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated string or list of the Nomad job IDs, which may
+contain globs, credentials can be requested for. Required if the policies or
+roles contain the {{job}} template.`,
+			},
+
+			"auth_method": {
+				Type: framework.TypeString,
+				Description: `Name of a Nomad ACL auth method. If set, tokens are derived by
+exchanging a workload identity token with Nomad instead of being created
+with the configured access token.`,
 			},
 
 			"global": {
@@ -127,9 +157,13 @@ func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *fr
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"type":     role.TokenType,
-			"global":   role.Global,
-			"policies": role.Policies,
+			"type":               role.TokenType,
+			"global":             role.Global,
+			"policies":           role.Policies,
+			"roles":              role.Roles,
+			"auth_method":        role.AuthMethod,
+			"allowed_namespaces": role.AllowedNamespaces,
+			"allowed_jobs":       role.AllowedJobs,
 		},
 	}
 	return resp, nil
@@ -150,18 +184,48 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 	if ok {
 		role.Policies = policies.([]string)
 	}
+	roles, ok := d.GetOk("roles")
+	if ok {
+		role.Roles = roles.([]string)
+	}
+	authMethod, ok := d.GetOk("auth_method")
+	if ok {
+		role.AuthMethod = authMethod.(string)
+	}
+	allowedNamespaces, ok := d.GetOk("allowed_namespaces")
+	if ok {
+		role.AllowedNamespaces = allowedNamespaces.([]string)
+	}
+	allowedJobs, ok := d.GetOk("allowed_jobs")
+	if ok {
+		role.AllowedJobs = allowedJobs.([]string)
+	}
+
+	names := append(append([]string{}, role.Policies...), role.Roles...)
+	if usesTemplate(names, "{{namespace}}") && len(role.AllowedNamespaces) == 0 {
+		return logical.ErrorResponse(
+			"allowed_namespaces cannot be empty when policies or roles use the {{namespace}} template"), nil
+	}
+	if usesTemplate(names, "{{job}}") && len(role.AllowedJobs) == 0 {
+		return logical.ErrorResponse(
+			"allowed_jobs cannot be empty when policies or roles use the {{job}} template"), nil
+	}
 
 	role.TokenType = d.Get("type").(string)
 	switch role.TokenType {
 	case "client":
-		if len(role.Policies) == 0 {
+		if len(role.Policies) == 0 && len(role.Roles) == 0 && role.AuthMethod == "" {
 			return logical.ErrorResponse(
-				"policies cannot be empty when using client tokens"), nil
+				"policies or roles cannot be empty when using client tokens without an auth method"), nil
 		}
 	case "management":
-		if len(role.Policies) != 0 {
+		if len(role.Policies) != 0 || len(role.Roles) != 0 {
+			return logical.ErrorResponse(
+				"policies and roles should be empty when using management tokens"), nil
+		}
+		if role.AuthMethod != "" {
 			return logical.ErrorResponse(
-				"policies should be empty when using management tokens"), nil
+				"auth_method cannot be used with management tokens"), nil
 		}
 	default:
 		return logical.ErrorResponse(
@@ -194,7 +258,46 @@ func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *
 }
 
 type roleConfig struct {
-	Policies  []string `json:"policies"`
-	TokenType string   `json:"type"`
-	Global    bool     `json:"global"`
+	Policies          []string `json:"policies"`
+	Roles             []string `json:"roles"`
+	AuthMethod        string   `json:"auth_method"`
+	AllowedNamespaces []string `json:"allowed_namespaces"`
+	AllowedJobs       []string `json:"allowed_jobs"`
+	TokenType         string   `json:"type"`
+	Global            bool     `json:"global"`
+}
+
+func usesTemplate(names []string, template string) bool {
+	for _, name := range names {
+		if strings.Contains(name, template) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderNames expands the {{namespace}} and {{job}} templates in the given
+// policy or role names. An error is returned if a name references a template
+// for which no value was provided.
+func renderNames(names []string, namespace, job string) ([]string, error) {
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	replacer := strings.NewReplacer("{{namespace}}", namespace, "{{job}}", job)
+	rendered := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.Contains(name, "{{namespace}}") && namespace == "" {
+			return nil, fmt.Errorf("%q requires a namespace", name)
+		}
+		if strings.Contains(name, "{{job}}") && job == "" {
+			return nil, fmt.Errorf("%q requires a job", name)
+		}
+		name = replacer.Replace(name)
+		if strings.Contains(name, "{{") {
+			return nil, fmt.Errorf("%q contains an unknown template", name)
+		}
+		rendered = append(rendered, name)
+	}
+	return rendered, nil
 }
@@ -114,6 +114,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"unified-crl",
 				"unified-ocsp",   // Unified OCSP POST
 				"unified-ocsp/*", // Unified OCSP GET
+				"spiffe/bundle",
 
 				// ACME paths are added below
 			},
@@ -125,7 +126,9 @@ func Backend(conf *logical.BackendConfig) *backend {
 				clusterConfigPath,
 				"crls/",
 				"certs/",
+				certMetadataPath,
 				acmePathPrefix,
+				externalIssuerRequestPrefix,
 			},
 
 			Root: []string{
@@ -206,6 +209,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathFetchValidRaw(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathSearchCerts(&b),
 
 			// OCSP APIs
 			buildPathOcspGet(&b),
@@ -219,6 +223,20 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathAcmeConfig(&b),
 			pathAcmeEabList(&b),
 			pathAcmeEabDelete(&b),
+
+			// SPIFFE
+			pathConfigSpiffe(&b),
+			pathSpiffeSignIntermediate(&b),
+			pathIssuerSpiffeSignIntermediate(&b),
+			pathSpiffeBundle(&b),
+
+			// External issuers
+			pathListExternalIssuerProfiles(&b),
+			pathExternalIssuerProfile(&b),
+			pathExternalIssuerSign(&b),
+			pathListExternalIssuerRequests(&b),
+			pathExternalIssuerRequest(&b),
+			pathExternalIssuerStatus(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -294,6 +312,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 	b.acmeState = NewACMEState()
 
+	b.certStoreQueue = newCertStoreQueue(&b)
+
+	b.externalIssuerLimiters = make(map[string]*externalIssuerLimiter)
+
 	b.SetupEnt()
 	return &b
 }
@@ -326,12 +348,21 @@ type backend struct {
 	pkiStorageVersion atomic.Value
 	crlBuilder        *crlBuilder
 
+	// serials pre-allocates the serial numbers of issued certificates, and
+	// certStoreQueue stores the certificates of roles with store_async set.
+	serials        serialAllocator
+	certStoreQueue *certStoreQueue
+
 	// Write lock around issuers and keys.
 	issuersLock sync.RWMutex
 
 	// Context around ACME operations
 	acmeState       *acmeState
 	acmeAccountLock sync.RWMutex // (Write) Locked on Tidy, (Read) Locked on Account Creation
+
+	// Rate limiters of the external issuer profiles on this node
+	externalIssuerLimitersLock sync.Mutex
+	externalIssuerLimiters     map[string]*externalIssuerLimiter
 }
 
 type roleOperation func(ctx context.Context, req *logical.Request, data *framework.FieldData, role *roleEntry) (*logical.Response, error)
@@ -433,6 +464,9 @@ func (b *backend) cleanup(ctx context.Context) {
 
 	b.acmeState.Shutdown(b)
 
+	// Store the certificates still queued before the backend goes away
+	b.certStoreQueue.stop()
+
 	b.cleanupEnt(sc)
 }
 
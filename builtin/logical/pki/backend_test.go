@@ -3691,6 +3691,7 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"key_bits":                           json.Number("2048"),
 		"max_ttl":                            json.Number("0"),
 		"no_store":                           false,
+		"store_async":                        false,
 		"organization":                       []interface{}{},
 		"province":                           []interface{}{},
 		"street_address":                     []interface{}{},
@@ -6771,6 +6772,7 @@ func TestProperAuthing(t *testing.T) {
 		"certs/revoked/":                         shouldBeAuthed,
 		"certs/revocation-queue/":                shouldBeAuthed,
 		"certs/unified-revoked/":                 shouldBeAuthed,
+		"certs/search":                           shouldBeAuthed,
 		"config/acme":                            shouldBeAuthed,
 		"config/auto-tidy":                       shouldBeAuthed,
 		"config/ca":                              shouldBeAuthed,
@@ -6866,6 +6868,11 @@ func TestProperAuthing(t *testing.T) {
 		"unified-ocsp/dGVzdAo=":                  shouldBeUnauthedReadList,
 		"eab/":                                   shouldBeAuthed,
 		"eab/" + eabKid:                          shouldBeAuthed,
+
+		"config/spiffe":                           shouldBeAuthed,
+		"spiffe/bundle":                           shouldBeUnauthedReadList,
+		"spiffe/sign-intermediate":                shouldBeAuthed,
+		"issuer/default/spiffe/sign-intermediate": shouldBeAuthed,
 	}
 
 	entPaths := getEntProperAuthingPaths(serial)
@@ -313,6 +313,7 @@ func buildSignVerbatimRole(data *framework.FieldData, role *roleEntry) *roleEntr
 			entry.NotBeforeDuration = role.NotBeforeDuration
 		}
 		entry.NoStore = role.NoStore
+		entry.StoreAsync = role.StoreAsync
 		entry.Issuer = role.Issuer
 	}
 
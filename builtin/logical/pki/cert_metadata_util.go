@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/ryanuber/go-glob"
+)
+
+// certMetadataPath holds a small JSON document per stored certificate, keyed
+// by the same normalized serial as certs/. It lets certs/search filter on
+// subject, SANs, role and expiry without parsing every stored certificate.
+const certMetadataPath = "certs-metadata/"
+
+type certMetadata struct {
+	SerialNumber   string    `json:"serial_number"`
+	CommonName     string    `json:"common_name"`
+	DNSNames       []string  `json:"dns_names,omitempty"`
+	IPAddresses    []string  `json:"ip_addresses,omitempty"`
+	EmailAddresses []string  `json:"email_addresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty"`
+	Role           string    `json:"role,omitempty"`
+	IssuerID       issuerID  `json:"issuer_id,omitempty"`
+	NotBefore      time.Time `json:"not_before"`
+	NotAfter       time.Time `json:"not_after"`
+}
+
+func newCertMetadata(cert *x509.Certificate, role string, issuer issuerID) *certMetadata {
+	meta := &certMetadata{
+		SerialNumber:   denormalizeSerial(normalizeSerialFromBigInt(cert.SerialNumber)),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Role:           role,
+		IssuerID:       issuer,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+	}
+	for _, ip := range cert.IPAddresses {
+		meta.IPAddresses = append(meta.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		meta.URIs = append(meta.URIs, uri.String())
+	}
+	return meta
+}
+
+func (m *certMetadata) sans() []string {
+	var sans []string
+	sans = append(sans, m.DNSNames...)
+	sans = append(sans, m.IPAddresses...)
+	sans = append(sans, m.EmailAddresses...)
+	sans = append(sans, m.URIs...)
+	return sans
+}
+
+func (m *certMetadata) toResponseData() map[string]interface{} {
+	return map[string]interface{}{
+		"serial_number":   m.SerialNumber,
+		"common_name":     m.CommonName,
+		"dns_names":       m.DNSNames,
+		"ip_addresses":    m.IPAddresses,
+		"email_addresses": m.EmailAddresses,
+		"uris":            m.URIs,
+		"role":            m.Role,
+		"issuer_id":       m.IssuerID,
+		"not_before":      m.NotBefore.Format(time.RFC3339),
+		"not_after":       m.NotAfter.Format(time.RFC3339),
+	}
+}
+
+// certMetadataFilter describes the criteria used by certs/search. Empty
+// values match everything.
+type certMetadataFilter struct {
+	CommonName     string
+	SAN            string
+	Role           string
+	IssuerID       issuerID
+	ExpiresBefore  time.Time
+	IncludeExpired bool
+}
+
+func (f *certMetadataFilter) matches(m *certMetadata, now time.Time) bool {
+	if !f.IncludeExpired && now.After(m.NotAfter) {
+		return false
+	}
+	if !f.ExpiresBefore.IsZero() && !m.NotAfter.Before(f.ExpiresBefore) {
+		return false
+	}
+	if f.CommonName != "" && !glob.Glob(strings.ToLower(f.CommonName), strings.ToLower(m.CommonName)) {
+		return false
+	}
+	if f.Role != "" && f.Role != m.Role {
+		return false
+	}
+	if f.IssuerID != "" && f.IssuerID != m.IssuerID {
+		return false
+	}
+	if f.SAN != "" {
+		pattern := strings.ToLower(f.SAN)
+		found := false
+		for _, san := range m.sans() {
+			if glob.Glob(pattern, strings.ToLower(san)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCertMetadata(ctx context.Context, s logical.Storage, meta *certMetadata) error {
+	entry, err := logical.StorageEntryJSON(certMetadataPath+normalizeSerial(meta.SerialNumber), meta)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return fmt.Errorf("unable to store certificate metadata: %w", err)
+	}
+	return nil
+}
+
+// fetchCertMetadata returns the indexed metadata of the certificate with the
+// given normalized serial. Certificates stored before the index existed have
+// no metadata entry; for those the metadata is derived from the certificate
+// itself, without a role or issuer.
+func fetchCertMetadata(ctx context.Context, s logical.Storage, serial string) (*certMetadata, error) {
+	entry, err := s.Get(ctx, certMetadataPath+serial)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching metadata for certificate %q: %w", serial, err)
+	}
+	if entry != nil {
+		var meta certMetadata
+		if err := entry.DecodeJSON(&meta); err != nil {
+			return nil, fmt.Errorf("error decoding metadata for certificate %q: %w", serial, err)
+		}
+		return &meta, nil
+	}
+
+	certEntry, err := s.Get(ctx, "certs/"+serial)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching certificate %q: %w", serial, err)
+	}
+	if certEntry == nil || len(certEntry.Value) == 0 {
+		return nil, nil
+	}
+
+	cert, err := x509.ParseCertificate(certEntry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse stored certificate with serial %q: %w", serial, err)
+	}
+	return newCertMetadata(cert, "", ""), nil
+}
+
+func deleteCertMetadata(ctx context.Context, s logical.Storage, serial string) error {
+	if err := s.Delete(ctx, certMetadataPath+serial); err != nil {
+		return fmt.Errorf("error deleting metadata for certificate %q: %w", serial, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+const (
+	// certStoreQueueSize is the number of issued certificates which can wait
+	// to be stored in the background. Certificates issued while the queue is
+	// full are stored before responding, as if store_async wasn't set.
+	certStoreQueueSize = 4096
+
+	// certStoreBatchSize is the maximum number of certificates stored in a
+	// single storage transaction.
+	certStoreBatchSize = 32
+)
+
+// pendingCert is an issued certificate to be stored, along with its metadata
+// index entry.
+type pendingCert struct {
+	serial    string
+	certBytes []byte
+	meta      *certMetadata
+
+	// certsCounted is the value of backend.certsCounted before the
+	// certificate was stored, see ifCountEnabledIncrementTotalCertificatesCount
+	certsCounted bool
+}
+
+// storeCert stores the issued certificate and its metadata.
+func (b *backend) storeCert(ctx context.Context, s logical.Storage, cert *pendingCert) error {
+	key := "certs/" + cert.serial
+	err := s.Put(ctx, &logical.StorageEntry{
+		Key:   key,
+		Value: cert.certBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store certificate locally: %w", err)
+	}
+	b.ifCountEnabledIncrementTotalCertificatesCount(cert.certsCounted, key)
+
+	return writeCertMetadata(ctx, s, cert.meta)
+}
+
+// certStoreQueue stores issued certificates in the background, for roles with
+// store_async set, so that issuance doesn't wait for storage. Certificates
+// queued together are stored in a single storage transaction, along with
+// their metadata index entries.
+type certStoreQueue struct {
+	b *backend
+
+	// lock guards the closing of queue: enqueue holds it for reading
+	lock    sync.RWMutex
+	stopped bool
+	queue   chan *pendingCert
+
+	startOnce sync.Once
+	doneCh    chan struct{}
+}
+
+func newCertStoreQueue(b *backend) *certStoreQueue {
+	return &certStoreQueue{
+		b:      b,
+		queue:  make(chan *pendingCert, certStoreQueueSize),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// enqueue queues the certificate to be stored in the background. It returns
+// false when the certificate can't be queued, either because the queue is
+// full or because it was stopped, in which case the caller must store it.
+func (q *certStoreQueue) enqueue(cert *pendingCert) bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	if q.stopped {
+		return false
+	}
+
+	q.startOnce.Do(func() {
+		go q.run()
+	})
+
+	select {
+	case q.queue <- cert:
+		return true
+	default:
+		metrics.IncrCounter([]string{"secrets", "pki", "store_async", "queue_full"}, 1)
+		return false
+	}
+}
+
+// run stores the queued certificates, in batches, until the queue is stopped
+// and empty.
+func (q *certStoreQueue) run() {
+	defer close(q.doneCh)
+
+	for cert := range q.queue {
+		batch := []*pendingCert{cert}
+	BATCH:
+		for len(batch) < certStoreBatchSize {
+			select {
+			case cert, ok := <-q.queue:
+				if !ok {
+					break BATCH
+				}
+				batch = append(batch, cert)
+			default:
+				break BATCH
+			}
+		}
+
+		q.store(batch)
+	}
+}
+
+// store stores a batch of certificates in a single transaction. If the
+// transaction fails, the certificates are stored one by one, so that a single
+// failure doesn't lose the whole batch.
+func (q *certStoreQueue) store(batch []*pendingCert) {
+	ctx := context.Background()
+	metrics.AddSample([]string{"secrets", "pki", "store_async", "batch_size"}, float32(len(batch)))
+
+	txns := make([]*logical.TxnEntry, 0, 2*len(batch))
+	for _, cert := range batch {
+		metaEntry, err := logical.StorageEntryJSON(certMetadataPath+cert.serial, cert.meta)
+		if err != nil {
+			txns = nil
+			break
+		}
+		txns = append(txns,
+			&logical.TxnEntry{
+				Operation: physical.PutOperation,
+				Entry:     &logical.StorageEntry{Key: "certs/" + cert.serial, Value: cert.certBytes},
+			},
+			&logical.TxnEntry{
+				Operation: physical.PutOperation,
+				Entry:     metaEntry,
+			},
+		)
+	}
+
+	if txns != nil {
+		err := logical.StorageTransaction(ctx, q.b.storage, txns)
+		if err == nil {
+			for _, cert := range batch {
+				q.b.ifCountEnabledIncrementTotalCertificatesCount(cert.certsCounted, "certs/"+cert.serial)
+			}
+			return
+		}
+		q.b.Logger().Warn("failed to store batch of issued certificates, storing them one by one", "error", err)
+	}
+
+	for _, cert := range batch {
+		if err := q.b.storeCert(ctx, q.b.storage, cert); err != nil {
+			metrics.IncrCounter([]string{"secrets", "pki", "store_async", "failure"}, 1)
+			q.b.Logger().Error("failed to store issued certificate", "serial_number", denormalizeSerial(cert.serial), "error", err)
+		}
+	}
+}
+
+// stop stores the queued certificates and waits for them to be stored.
+// Certificates enqueued afterwards are refused.
+func (q *certStoreQueue) stop() {
+	q.lock.Lock()
+	if !q.stopped {
+		q.stopped = true
+		close(q.queue)
+	}
+	q.lock.Unlock()
+
+	q.startOnce.Do(func() {
+		// The queue was never used
+		close(q.doneCh)
+	})
+	<-q.doneCh
+}
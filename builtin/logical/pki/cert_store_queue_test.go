@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPKI_StoreAsync ensures certificates issued against a role with
+// store_async set are stored, along with their metadata, at the latest when
+// the backend is cleaned up.
+func TestPKI_StoreAsync(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"ttl":         "40h",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root")
+
+	_, err = CBWrite(b, s, "roles/mesh", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"store_async":      true,
+	})
+	require.NoError(t, err)
+
+	resp, err = CBRead(b, s, "roles/mesh")
+	requireSuccessNonNilResponse(t, resp, err, "failed reading role")
+	require.Equal(t, true, resp.Data["store_async"])
+
+	var serials []string
+	for i := 0; i < 2*certStoreBatchSize; i++ {
+		resp, err := CBWrite(b, s, "issue/mesh", map[string]interface{}{
+			"common_name": "svc.example.com",
+		})
+		requireSuccessNonNilResponse(t, resp, err, "failed issuing certificate")
+		serials = append(serials, resp.Data["serial_number"].(string))
+	}
+
+	b.Cleanup(context.Background())
+
+	for _, serial := range serials {
+		resp, err := CBRead(b, s, "cert/"+serial)
+		requireSuccessNonNilResponse(t, resp, err, "failed reading certificate %s", serial)
+
+		meta, err := fetchCertMetadata(context.Background(), s, normalizeSerial(serial))
+		require.NoError(t, err)
+		require.Equal(t, "mesh", meta.Role)
+	}
+
+	// Once the queue is stopped, certificates are stored before responding
+	resp, err = CBWrite(b, s, "issue/mesh", map[string]interface{}{
+		"common_name": "svc.example.com",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing certificate")
+	resp, err = CBRead(b, s, "cert/"+resp.Data["serial_number"].(string))
+	requireSuccessNonNilResponse(t, resp, err, "failed reading certificate")
+}
+
+// TestSerialAllocator ensures pre-allocated serial numbers are unique and in
+// the range of those generated by certutil.
+func TestSerialAllocator(t *testing.T) {
+	t.Parallel()
+
+	var a serialAllocator
+	max := new(big.Int).Lsh(big.NewInt(1), 159)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 2*serialBlockSize+1; i++ {
+		serial, err := a.next()
+		require.NoError(t, err)
+		require.Equal(t, 1, max.Cmp(serial))
+		require.Equal(t, 1, serial.Sign())
+
+		_, ok := seen[serial.String()]
+		require.False(t, ok, "serial number %s allocated twice", serial)
+		seen[serial.String()] = struct{}{}
+	}
+	require.Len(t, a.serials, serialBlockSize-1)
+}
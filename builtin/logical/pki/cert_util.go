@@ -1513,8 +1513,14 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 		}
 	}
 
+	serialNumber, err := b.serials.next()
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: err.Error()}
+	}
+
 	creation := &certutil.CreationBundle{
 		Params: &certutil.CreationParameters{
+			SerialNumber:                  serialNumber,
 			Subject:                       subject,
 			DNSNames:                      strutil.RemoveDuplicates(dnsNames, false),
 			EmailAddresses:                strutil.RemoveDuplicates(emailAddresses, false),
@@ -278,6 +278,11 @@ func (b *backend) acmeFinalizeOrderHandler(ac *acmeContext, r *logical.Request,
 		if err != nil {
 			return nil, err
 		}
+
+		err = writeCertMetadata(ac.sc.Context, ac.sc.Storage, newCertMetadata(signedCertBundle.Certificate, ac.role.Name, issuerId))
+		if err != nil {
+			return nil, err
+		}
 	}
 	hyphenSerialNumber := normalizeSerialFromBigInt(signedCertBundle.Certificate.SerialNumber)
 
@@ -492,9 +497,9 @@ func storeCertificate(sc *storageContext, signedCertBundle *certutil.ParsedCertB
 	return nil
 }
 
-func maybeAugmentReqDataWithSuitableCN(ac *acmeContext, csr *x509.CertificateRequest, data *framework.FieldData) {
+func maybeAugmentReqDataWithSuitableCN(role *roleEntry, csr *x509.CertificateRequest, data *framework.FieldData) {
 	// Role doesn't require a CN, so we don't care.
-	if !ac.role.RequireCN {
+	if !role.RequireCN {
 		return
 	}
 
@@ -538,7 +543,7 @@ func issueCertFromCsr(ac *acmeContext, csr *x509.CertificateRequest) (*certutil.
 	// XXX: Usability hack: by default, minimalist roles have require_cn=true,
 	// but some ACME clients do not provision one in the certificate as modern
 	// (TLS) clients are mostly verifying against server's DNS SANs.
-	maybeAugmentReqDataWithSuitableCN(ac, csr, data)
+	maybeAugmentReqDataWithSuitableCN(ac.role, csr, data)
 
 	signingBundle, issuerId, err := ac.sc.fetchCAInfoWithIssuer(ac.issuer.ID.String(), IssuanceUsage)
 	if err != nil {
@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	storageSpiffeConfig      = "config/spiffe"
+	pathConfigSpiffeHelpSyn  = "Configuration of the SPIFFE endpoints"
+	pathConfigSpiffeHelpDesc = "Here we configure:\n\ntrust_domains=\"\", the SPIFFE trust domains whose intermediate CAs may be signed by spiffe/sign-intermediate; by default, no trust domain is allowed,\nbundle_refresh_hint=\"5m\", how often the consumers of spiffe/bundle should refresh it"
+)
+
+// spiffeTrustDomainRegex matches the trust domain names allowed by the
+// SPIFFE ID specification.
+var spiffeTrustDomainRegex = regexp.MustCompile(`^[a-z0-9._-]+$`)
+
+type spiffeConfigEntry struct {
+	TrustDomains      []string      `json:"trust_domains"`
+	BundleRefreshHint time.Duration `json:"bundle_refresh_hint"`
+}
+
+var defaultSpiffeConfig = spiffeConfigEntry{
+	TrustDomains:      []string{},
+	BundleRefreshHint: 5 * time.Minute,
+}
+
+func (sc *storageContext) getSpiffeConfig() (*spiffeConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, storageSpiffeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping spiffeConfigEntry
+	if entry == nil {
+		mapping = defaultSpiffeConfig
+		return &mapping, nil
+	}
+
+	if err := entry.DecodeJSON(&mapping); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode SPIFFE configuration: %v", err)}
+	}
+
+	return &mapping, nil
+}
+
+func (sc *storageContext) setSpiffeConfig(entry *spiffeConfigEntry) error {
+	json, err := logical.StorageEntryJSON(storageSpiffeConfig, entry)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathConfigSpiffe(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/spiffe",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"trust_domains": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `the SPIFFE trust domains whose intermediate CAs may be signed by spiffe/sign-intermediate, such as "example.org"; by default, no trust domain is allowed`,
+			},
+			"bundle_refresh_hint": {
+				Type:        framework.TypeDurationSecond,
+				Description: `how often the consumers of the trust bundle published at spiffe/bundle should refresh it, defaults to 5 minutes`,
+				Default:     "5m",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "spiffe-configuration",
+				},
+				Callback: b.pathSpiffeConfigRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      spiffeConfigResponseFields,
+					}},
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "spiffe",
+				},
+				Callback: b.pathSpiffeConfigWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      spiffeConfigResponseFields,
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigSpiffeHelpSyn,
+		HelpDescription: pathConfigSpiffeHelpDesc,
+	}
+}
+
+var spiffeConfigResponseFields = map[string]*framework.FieldSchema{
+	"trust_domains": {
+		Type:        framework.TypeCommaStringSlice,
+		Description: `the SPIFFE trust domains whose intermediate CAs may be signed`,
+		Required:    true,
+	},
+	"bundle_refresh_hint": {
+		Type:        framework.TypeInt64,
+		Description: `how often, in seconds, the consumers of the trust bundle should refresh it`,
+		Required:    true,
+	},
+}
+
+func (b *backend) pathSpiffeConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getSpiffeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return genResponseFromSpiffeConfig(config), nil
+}
+
+func genResponseFromSpiffeConfig(config *spiffeConfigEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"trust_domains":       config.TrustDomains,
+			"bundle_refresh_hint": int64(config.BundleRefreshHint.Seconds()),
+		},
+	}
+}
+
+func (b *backend) pathSpiffeConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getSpiffeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if trustDomainsRaw, ok := d.GetOk("trust_domains"); ok {
+		config.TrustDomains = []string{}
+		for _, trustDomain := range trustDomainsRaw.([]string) {
+			trustDomain = strings.TrimPrefix(strings.TrimSpace(trustDomain), "spiffe://")
+			if !spiffeTrustDomainRegex.MatchString(trustDomain) {
+				return logical.ErrorResponse("invalid SPIFFE trust domain %q: trust domains contain only lowercase letters, digits, dots, dashes and underscores", trustDomain), nil
+			}
+			config.TrustDomains = append(config.TrustDomains, trustDomain)
+		}
+	}
+
+	if refreshHintRaw, ok := d.GetOk("bundle_refresh_hint"); ok {
+		config.BundleRefreshHint = time.Duration(refreshHintRaw.(int)) * time.Second
+		if config.BundleRefreshHint <= 0 {
+			return logical.ErrorResponse("bundle_refresh_hint must be positive"), nil
+		}
+	}
+
+	if err := sc.setSpiffeConfig(config); err != nil {
+		return nil, err
+	}
+
+	return genResponseFromSpiffeConfig(config), nil
+}
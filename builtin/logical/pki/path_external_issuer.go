@@ -0,0 +1,1019 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/time/rate"
+)
+
+const (
+	externalIssuerProfilePrefix = "external-issuer/profile/"
+	externalIssuerRequestPrefix = "external-issuer/request/"
+
+	externalIssuerStateIssued = "issued"
+	externalIssuerStateDenied = "denied"
+	externalIssuerStateFailed = "failed"
+
+	pathExternalIssuerProfileHelpSyn  = `Manage the profiles used by external issuers, such as cert-manager, to sign certificates.`
+	pathExternalIssuerProfileHelpDesc = `An external issuer profile ties the certificate requests of an external
+issuer, such as the cert-manager Vault issuer of a Kubernetes cluster, to a
+role and an issuer of this mount.
+
+The profile's sign endpoint only takes a CSR: its common name and Subject
+Alternative Names are used verbatim, and are validated against the role. The
+profile can require each request to carry its approval, and limits how many
+certificates may be signed in a given interval.`
+
+	pathExternalIssuerSignHelpSyn  = `Sign the CSR of a certificate request of an external issuer.`
+	pathExternalIssuerSignHelpDesc = `This path signs the CSR of a certificate request of an external issuer, such
+as a cert-manager CertificateRequest, with the role and the issuer of the
+profile. The common name and the Subject Alternative Names of the CSR are used
+verbatim, and must be allowed by the role.
+
+Each request is identified by its request_id, such as the UID of the
+CertificateRequest. Its outcome is recorded and can be read from the request
+status endpoint of the profile; signing a request which was already issued
+returns the certificate which was issued for it.
+
+When the profile requires approval, the request must be approved, by one of the
+allowed approvers if any.`
+
+	pathExternalIssuerRequestHelpSyn  = `Fetch the status of the certificate requests of an external issuer.`
+	pathExternalIssuerRequestHelpDesc = `This path returns the outcome of a certificate request signed, denied or
+failed on the sign endpoint of the profile, along with its approval details
+and, once issued, its certificate.`
+
+	pathExternalIssuerStatusHelpSyn  = `Fetch the readiness of an external issuer profile.`
+	pathExternalIssuerStatusHelpDesc = `This path reports whether the certificate requests of the profile can be
+signed: its role must exist, and its issuer must exist, be allowed to issue
+certificates and be neither revoked nor expired. It also returns the CA chain
+of the issuer, which external issuers publish as the CA of the certificates,
+and the remaining rate limit of the profile on this node.`
+)
+
+type externalIssuerProfileEntry struct {
+	Name              string        `json:"name"`
+	Role              string        `json:"role"`
+	IssuerRef         string        `json:"issuer_ref"`
+	RequireApproval   bool          `json:"require_approval"`
+	AllowedApprovers  []string      `json:"allowed_approvers"`
+	RateLimit         int           `json:"rate_limit"`
+	RateLimitInterval time.Duration `json:"rate_limit_interval"`
+}
+
+// externalIssuerRequestEntry records the outcome of a certificate request
+// of an external issuer.
+type externalIssuerRequestEntry struct {
+	RequestID        string    `json:"request_id"`
+	RequestName      string    `json:"request_name"`
+	RequestNamespace string    `json:"request_namespace"`
+	State            string    `json:"state"`
+	Reason           string    `json:"reason"`
+	Approved         bool      `json:"approved"`
+	Approver         string    `json:"approver"`
+	ApprovalReason   string    `json:"approval_reason"`
+	IssuerID         issuerID  `json:"issuer_id"`
+	SerialNumber     string    `json:"serial_number"`
+	Certificate      string    `json:"certificate"`
+	IssuingCA        string    `json:"issuing_ca"`
+	CAChain          []string  `json:"ca_chain"`
+	Expiration       int64     `json:"expiration"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// externalIssuerLimiter rate limits the signing requests of a profile on
+// this node; it is recreated when the rate limit of the profile changes.
+type externalIssuerLimiter struct {
+	limit    int
+	interval time.Duration
+	limiter  *rate.Limiter
+}
+
+func (sc *storageContext) getExternalIssuerProfile(name string) (*externalIssuerProfileEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, externalIssuerProfilePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var profile externalIssuerProfileEntry
+	if err := entry.DecodeJSON(&profile); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode external issuer profile %q: %v", name, err)}
+	}
+
+	return &profile, nil
+}
+
+func (sc *storageContext) setExternalIssuerProfile(profile *externalIssuerProfileEntry) error {
+	json, err := logical.StorageEntryJSON(externalIssuerProfilePrefix+profile.Name, profile)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func (sc *storageContext) getExternalIssuerRequest(profile, id string) (*externalIssuerRequestEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, externalIssuerRequestPrefix+profile+"/"+id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var request externalIssuerRequestEntry
+	if err := entry.DecodeJSON(&request); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode external issuer request %q: %v", id, err)}
+	}
+
+	return &request, nil
+}
+
+func (sc *storageContext) setExternalIssuerRequest(profile string, request *externalIssuerRequestEntry) error {
+	request.UpdatedAt = time.Now()
+	json, err := logical.StorageEntryJSON(externalIssuerRequestPrefix+profile+"/"+request.RequestID, request)
+	if err != nil {
+		return fmt.Errorf("failed creating storage entry: %w", err)
+	}
+
+	if err := sc.Storage.Put(sc.Context, json); err != nil {
+		return fmt.Errorf("failed writing storage entry: %w", err)
+	}
+
+	return nil
+}
+
+func pathListExternalIssuerProfiles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "external-issuer-profiles",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerProfileList,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"keys": {
+								Type:        framework.TypeStringSlice,
+								Description: `List of external issuer profiles`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathExternalIssuerProfileHelpSyn,
+		HelpDescription: pathExternalIssuerProfileHelpDesc,
+	}
+}
+
+func pathExternalIssuerProfile(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "external-issuer-profile",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the external issuer profile`,
+				Required:    true,
+			},
+			"role": {
+				Type:        framework.TypeString,
+				Description: `The role validating the CSRs signed with the profile`,
+			},
+			issuerRefParam: {
+				Type:        framework.TypeString,
+				Description: `Reference to the issuer signing the CSRs; either "default" for the configured default issuer, an identifier or the name assigned to the issuer`,
+				Default:     defaultRef,
+			},
+			"require_approval": {
+				Type:        framework.TypeBool,
+				Description: `Whether the sign requests must be approved; defaults to true`,
+				Default:     true,
+			},
+			"allowed_approvers": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The approvers allowed to approve the sign requests, such as "cert-manager.io"; by default, any approver is allowed`,
+			},
+			"rate_limit": {
+				Type:        framework.TypeInt,
+				Description: `The maximum number of certificates signed with the profile on each node per rate_limit_interval; 0, the default, disables the rate limit`,
+			},
+			"rate_limit_interval": {
+				Type:        framework.TypeDurationSecond,
+				Description: `The interval of the rate limit; defaults to 1 minute`,
+				Default:     "1m",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerProfileRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      externalIssuerProfileResponseFields,
+					}},
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerProfileWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      externalIssuerProfileResponseFields,
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerProfileDelete,
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "No Content",
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		ExistenceCheck: b.pathExternalIssuerProfileExistenceCheck,
+
+		HelpSynopsis:    pathExternalIssuerProfileHelpSyn,
+		HelpDescription: pathExternalIssuerProfileHelpDesc,
+	}
+}
+
+var externalIssuerProfileResponseFields = map[string]*framework.FieldSchema{
+	"name": {
+		Type:        framework.TypeString,
+		Description: `Name of the external issuer profile`,
+		Required:    true,
+	},
+	"role": {
+		Type:        framework.TypeString,
+		Description: `The role validating the CSRs signed with the profile`,
+		Required:    true,
+	},
+	issuerRefParam: {
+		Type:        framework.TypeString,
+		Description: `Reference to the issuer signing the CSRs`,
+		Required:    true,
+	},
+	"require_approval": {
+		Type:        framework.TypeBool,
+		Description: `Whether the sign requests must be approved`,
+		Required:    true,
+	},
+	"allowed_approvers": {
+		Type:        framework.TypeCommaStringSlice,
+		Description: `The approvers allowed to approve the sign requests`,
+		Required:    true,
+	},
+	"rate_limit": {
+		Type:        framework.TypeInt,
+		Description: `The maximum number of certificates signed on each node per rate_limit_interval`,
+		Required:    true,
+	},
+	"rate_limit_interval": {
+		Type:        framework.TypeInt64,
+		Description: `The interval of the rate limit, in seconds`,
+		Required:    true,
+	},
+}
+
+func pathExternalIssuerSign(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/" + framework.GenericNameRegex("name") + "/sign",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "sign",
+			OperationSuffix: "with-external-issuer-profile",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the external issuer profile`,
+				Required:    true,
+			},
+			"csr": {
+				Type:        framework.TypeString,
+				Description: `PEM-format CSR to be signed; its common name and Subject Alternative Names must be allowed by the role of the profile`,
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: `The requested Time To Live for the certificate, such as the duration of the CertificateRequest; capped by the role and the mount`,
+			},
+			"request_id": {
+				Type:        framework.TypeString,
+				Description: `The unique identifier of the certificate request, such as the UID of the cert-manager CertificateRequest`,
+				Required:    true,
+			},
+			"request_name": {
+				Type:        framework.TypeString,
+				Description: `The name of the certificate request, recorded for auditing`,
+			},
+			"request_namespace": {
+				Type:        framework.TypeString,
+				Description: `The Kubernetes namespace of the certificate request, recorded for auditing`,
+			},
+			"approved": {
+				Type:        framework.TypeBool,
+				Description: `Whether the certificate request was approved; a request which was explicitly not approved is always denied`,
+			},
+			"approver": {
+				Type:        framework.TypeString,
+				Description: `The approver of the certificate request, such as "cert-manager.io"`,
+			},
+			"approval_reason": {
+				Type:        framework.TypeString,
+				Description: `The reason given by the approver`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerSign,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"expiration": {
+								Type:        framework.TypeInt64,
+								Description: `Expiration Time`,
+								Required:    true,
+							},
+							"serial_number": {
+								Type:        framework.TypeString,
+								Description: `Serial Number`,
+								Required:    true,
+							},
+							"certificate": {
+								Type:        framework.TypeString,
+								Description: `Certificate`,
+								Required:    true,
+							},
+							"issuing_ca": {
+								Type:        framework.TypeString,
+								Description: `Issuing CA`,
+								Required:    true,
+							},
+							"ca_chain": {
+								Type:        framework.TypeStringSlice,
+								Description: `CA Chain`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathExternalIssuerSignHelpSyn,
+		HelpDescription: pathExternalIssuerSignHelpDesc,
+	}
+}
+
+func pathListExternalIssuerRequests(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/" + framework.GenericNameRegex("name") + "/request/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "external-issuer-requests",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the external issuer profile`,
+				Required:    true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerRequestList,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"keys": {
+								Type:        framework.TypeStringSlice,
+								Description: `List of the identifiers of the certificate requests`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathExternalIssuerRequestHelpSyn,
+		HelpDescription: pathExternalIssuerRequestHelpDesc,
+	}
+}
+
+func pathExternalIssuerRequest(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/" + framework.GenericNameRegex("name") + "/request/" + framework.GenericNameRegex("request_id"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "external-issuer-request",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the external issuer profile`,
+				Required:    true,
+			},
+			"request_id": {
+				Type:        framework.TypeString,
+				Description: `The unique identifier of the certificate request`,
+				Required:    true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerRequestRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"request_id": {
+								Type:        framework.TypeString,
+								Description: `The unique identifier of the certificate request`,
+								Required:    true,
+							},
+							"state": {
+								Type:        framework.TypeString,
+								Description: `The outcome of the request: issued, denied or failed`,
+								Required:    true,
+							},
+							"reason": {
+								Type:        framework.TypeString,
+								Description: `Why the request was denied or failed`,
+								Required:    false,
+							},
+							"serial_number": {
+								Type:        framework.TypeString,
+								Description: `Serial Number of the issued certificate`,
+								Required:    false,
+							},
+							"certificate": {
+								Type:        framework.TypeString,
+								Description: `The issued certificate`,
+								Required:    false,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathExternalIssuerRequestHelpSyn,
+		HelpDescription: pathExternalIssuerRequestHelpDesc,
+	}
+}
+
+func pathExternalIssuerStatus(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "external-issuer/profile/" + framework.GenericNameRegex("name") + "/status",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "external-issuer-profile-status",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the external issuer profile`,
+				Required:    true,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathExternalIssuerStatusRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"ready": {
+								Type:        framework.TypeBool,
+								Description: `Whether the certificate requests of the profile can be signed`,
+								Required:    true,
+							},
+							"problems": {
+								Type:        framework.TypeStringSlice,
+								Description: `Why the certificate requests of the profile can't be signed`,
+								Required:    true,
+							},
+							"issuer_id": {
+								Type:        framework.TypeString,
+								Description: `Issuer Id`,
+								Required:    false,
+							},
+							"ca_chain": {
+								Type:        framework.TypeStringSlice,
+								Description: `CA Chain of the issuer`,
+								Required:    false,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathExternalIssuerStatusHelpSyn,
+		HelpDescription: pathExternalIssuerStatusHelpDesc,
+	}
+}
+
+func (b *backend) pathExternalIssuerProfileExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	profile, err := sc.getExternalIssuerProfile(data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+
+	return profile != nil, nil
+}
+
+func (b *backend) pathExternalIssuerProfileList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, externalIssuerProfilePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathExternalIssuerProfileRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	profile, err := sc.getExternalIssuerProfile(data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	return genResponseFromExternalIssuerProfile(profile), nil
+}
+
+func (b *backend) pathExternalIssuerProfileWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	name := data.Get("name").(string)
+	profile, err := sc.getExternalIssuerProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &externalIssuerProfileEntry{
+			Name:              name,
+			IssuerRef:         defaultRef,
+			RequireApproval:   true,
+			AllowedApprovers:  []string{},
+			RateLimitInterval: time.Minute,
+		}
+	}
+
+	if roleRaw, ok := data.GetOk("role"); ok {
+		profile.Role = roleRaw.(string)
+	}
+	if issuerRefRaw, ok := data.GetOk(issuerRefParam); ok {
+		profile.IssuerRef = issuerRefRaw.(string)
+	}
+	if requireApprovalRaw, ok := data.GetOk("require_approval"); ok {
+		profile.RequireApproval = requireApprovalRaw.(bool)
+	}
+	if allowedApproversRaw, ok := data.GetOk("allowed_approvers"); ok {
+		profile.AllowedApprovers = strutil.RemoveDuplicates(allowedApproversRaw.([]string), false)
+	}
+	if rateLimitRaw, ok := data.GetOk("rate_limit"); ok {
+		profile.RateLimit = rateLimitRaw.(int)
+	}
+	if intervalRaw, ok := data.GetOk("rate_limit_interval"); ok {
+		profile.RateLimitInterval = time.Duration(intervalRaw.(int)) * time.Second
+	}
+
+	if profile.Role == "" {
+		return logical.ErrorResponse(`"role" is required`), nil
+	}
+	role, err := b.getRole(ctx, req.Storage, profile.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role: %s", profile.Role), nil
+	}
+	if profile.IssuerRef == "" {
+		return logical.ErrorResponse(`"issuer_ref" must not be empty`), nil
+	}
+	if profile.RateLimit < 0 {
+		return logical.ErrorResponse(`"rate_limit" must not be negative`), nil
+	}
+	if profile.RateLimitInterval <= 0 {
+		return logical.ErrorResponse(`"rate_limit_interval" must be positive`), nil
+	}
+
+	resp := genResponseFromExternalIssuerProfile(profile)
+	if _, err := sc.resolveIssuerReference(profile.IssuerRef); err != nil {
+		resp.AddWarning(fmt.Sprintf("unable to resolve the issuer %q, the certificate requests of the profile will fail until it exists: %v", profile.IssuerRef, err))
+	}
+
+	if err := sc.setExternalIssuerProfile(profile); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathExternalIssuerProfileDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	// The records of the requests of the profile are removed with it
+	requests, err := req.Storage.List(ctx, externalIssuerRequestPrefix+name+"/")
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range requests {
+		if err := req.Storage.Delete(ctx, externalIssuerRequestPrefix+name+"/"+id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := req.Storage.Delete(ctx, externalIssuerProfilePrefix+name); err != nil {
+		return nil, err
+	}
+
+	b.externalIssuerLimitersLock.Lock()
+	delete(b.externalIssuerLimiters, name)
+	b.externalIssuerLimitersLock.Unlock()
+
+	return nil, nil
+}
+
+func genResponseFromExternalIssuerProfile(profile *externalIssuerProfileEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":                profile.Name,
+			"role":                profile.Role,
+			issuerRefParam:        profile.IssuerRef,
+			"require_approval":    profile.RequireApproval,
+			"allowed_approvers":   profile.AllowedApprovers,
+			"rate_limit":          profile.RateLimit,
+			"rate_limit_interval": int64(profile.RateLimitInterval.Seconds()),
+		},
+	}
+}
+
+// externalIssuerLimiter returns the rate limiter of the profile, or nil when
+// the profile has no rate limit.
+func (b *backend) externalIssuerLimiter(profile *externalIssuerProfileEntry) *rate.Limiter {
+	b.externalIssuerLimitersLock.Lock()
+	defer b.externalIssuerLimitersLock.Unlock()
+
+	if profile.RateLimit == 0 {
+		delete(b.externalIssuerLimiters, profile.Name)
+		return nil
+	}
+
+	limiter, ok := b.externalIssuerLimiters[profile.Name]
+	if !ok || limiter.limit != profile.RateLimit || limiter.interval != profile.RateLimitInterval {
+		limiter = &externalIssuerLimiter{
+			limit:    profile.RateLimit,
+			interval: profile.RateLimitInterval,
+			limiter:  rate.NewLimiter(rate.Every(profile.RateLimitInterval/time.Duration(profile.RateLimit)), profile.RateLimit),
+		}
+		b.externalIssuerLimiters[profile.Name] = limiter
+	}
+
+	return limiter.limiter
+}
+
+func (b *backend) pathExternalIssuerSign(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	name := data.Get("name").(string)
+	profile, err := sc.getExternalIssuerProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return logical.ErrorResponse("unknown external issuer profile: %s", name), nil
+	}
+
+	id := data.Get("request_id").(string)
+	if id == "" {
+		return logical.ErrorResponse(`"request_id" is required`), nil
+	}
+	if strings.Contains(id, "/") {
+		return logical.ErrorResponse(`"request_id" must not contain "/"`), nil
+	}
+
+	// Retried requests get the certificate which was already issued for them
+	request, err := sc.getExternalIssuerRequest(name, id)
+	if err != nil {
+		return nil, err
+	}
+	if request != nil && request.State == externalIssuerStateIssued {
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"expiration":    request.Expiration,
+				"serial_number": request.SerialNumber,
+				"certificate":   request.Certificate,
+				"issuing_ca":    request.IssuingCA,
+				"ca_chain":      request.CAChain,
+			},
+		}
+		resp.AddWarning(fmt.Sprintf("the certificate request %q was already issued", id))
+		return resp, nil
+	}
+
+	request = &externalIssuerRequestEntry{
+		RequestID:        id,
+		RequestName:      data.Get("request_name").(string),
+		RequestNamespace: data.Get("request_namespace").(string),
+		Approver:         data.Get("approver").(string),
+		ApprovalReason:   data.Get("approval_reason").(string),
+	}
+	approvedRaw, approvalSet := data.GetOk("approved")
+	if approvalSet {
+		request.Approved = approvedRaw.(bool)
+	}
+
+	labels := []metrics.Label{{Name: "profile", Value: name}, {Name: "role", Value: profile.Role}}
+	if ns, err := namespace.FromContext(ctx); err == nil {
+		labels = append(labels, metricsutil.NamespaceLabel(ns))
+	}
+
+	if reason := externalIssuerDenialReason(profile, request, approvalSet); reason != "" {
+		request.State = externalIssuerStateDenied
+		request.Reason = reason
+		if err := sc.setExternalIssuerRequest(name, request); err != nil {
+			return nil, err
+		}
+		metrics.IncrCounterWithLabels(metricsKey(req, "external-issuer", externalIssuerStateDenied), 1, labels)
+		return logical.ErrorResponse("certificate request %q denied: %s", id, reason), logical.ErrPermissionDenied
+	}
+
+	if limiter := b.externalIssuerLimiter(profile); limiter != nil && !limiter.Allow() {
+		metrics.IncrCounterWithLabels(metricsKey(req, "external-issuer", "rate_limited"), 1, labels)
+		return logical.ErrorResponse("external issuer profile %q exceeded its rate limit of %d certificates per %s", name, profile.RateLimit, profile.RateLimitInterval), logical.ErrRateLimitQuotaExceeded
+	}
+
+	role, err := b.getRole(ctx, req.Storage, profile.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown role: %s", profile.Role), nil
+	}
+
+	issuerId, err := sc.resolveIssuerReference(profile.IssuerRef)
+	if err != nil {
+		return logical.ErrorResponse("unable to resolve the issuer %q of the profile: %v", profile.IssuerRef, err), nil
+	}
+	labels = append(labels, metrics.Label{Name: "issuer", Value: string(issuerId)})
+
+	// The common name and SANs of the CSR are taken verbatim, but the role
+	// still validates them, along with the other values of the certificate.
+	signRole := *role
+	signRole.UseCSRCommonName = true
+	signRole.UseCSRSANs = true
+
+	csrString := data.Get("csr").(string)
+	raw := map[string]interface{}{
+		"csr":          csrString,
+		issuerRefParam: string(issuerId),
+		"format":       "pem",
+	}
+	if ttl, ok := data.GetOk("ttl"); ok {
+		raw["ttl"] = ttl
+	}
+	signData := &framework.FieldData{
+		Raw:    raw,
+		Schema: buildPathSign(b, "", nil).Fields,
+	}
+
+	// As with ACME, CSRs with only SANs get one of them as their common name
+	// when the role requires one; invalid CSRs are rejected when signing.
+	if pemBlock, _ := pem.Decode([]byte(csrString)); pemBlock != nil {
+		if csr, err := x509.ParseCertificateRequest(pemBlock.Bytes); err == nil {
+			maybeAugmentReqDataWithSuitableCN(&signRole, csr, signData)
+		}
+	}
+
+	start := time.Now()
+	defer metrics.MeasureSinceWithLabels(metricsKey(req, "external-issuer", "sign"), start, labels)
+
+	resp, err := b.pathIssueSignCert(ctx, req, signData, &signRole, true, false)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); !ok {
+			return nil, err
+		}
+		resp, err = logical.ErrorResponse(err.Error()), nil
+	}
+	if resp.IsError() {
+		request.State = externalIssuerStateFailed
+		request.Reason = resp.Error().Error()
+		if err := sc.setExternalIssuerRequest(name, request); err != nil {
+			return nil, err
+		}
+		metrics.IncrCounterWithLabels(metricsKey(req, "external-issuer", externalIssuerStateFailed), 1, labels)
+		return resp, nil
+	}
+
+	request.State = externalIssuerStateIssued
+	request.IssuerID = issuerId
+	request.SerialNumber = resp.Data["serial_number"].(string)
+	request.Certificate = resp.Data["certificate"].(string)
+	request.IssuingCA, _ = resp.Data["issuing_ca"].(string)
+	request.CAChain, _ = resp.Data["ca_chain"].([]string)
+	request.Expiration = resp.Data["expiration"].(int64)
+	if err := sc.setExternalIssuerRequest(name, request); err != nil {
+		return nil, err
+	}
+	metrics.IncrCounterWithLabels(metricsKey(req, "external-issuer", externalIssuerStateIssued), 1, labels)
+
+	return resp, nil
+}
+
+// externalIssuerDenialReason returns why the profile denies the request, or
+// an empty string when the request may be signed.
+func externalIssuerDenialReason(profile *externalIssuerProfileEntry, request *externalIssuerRequestEntry, approvalSet bool) string {
+	if approvalSet && !request.Approved {
+		return "the request was not approved"
+	}
+	if !profile.RequireApproval {
+		return ""
+	}
+	if !request.Approved {
+		return "the profile requires the request to be approved"
+	}
+	if request.Approver == "" {
+		return "the profile requires the approver of the request"
+	}
+	if len(profile.AllowedApprovers) > 0 && !strutil.StrListContains(profile.AllowedApprovers, request.Approver) {
+		return fmt.Sprintf("%q is not an allowed approver", request.Approver)
+	}
+	return ""
+}
+
+func (b *backend) pathExternalIssuerRequestList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, externalIssuerRequestPrefix+data.Get("name").(string)+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathExternalIssuerRequestRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	request, err := sc.getExternalIssuerRequest(data.Get("name").(string), data.Get("request_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, nil
+	}
+
+	respData := map[string]interface{}{
+		"request_id":        request.RequestID,
+		"request_name":      request.RequestName,
+		"request_namespace": request.RequestNamespace,
+		"state":             request.State,
+		"reason":            request.Reason,
+		"approved":          request.Approved,
+		"approver":          request.Approver,
+		"approval_reason":   request.ApprovalReason,
+		"updated_at":        request.UpdatedAt.Format(time.RFC3339),
+	}
+	if request.State == externalIssuerStateIssued {
+		respData["issuer_id"] = request.IssuerID
+		respData["serial_number"] = request.SerialNumber
+		respData["certificate"] = request.Certificate
+		respData["issuing_ca"] = request.IssuingCA
+		respData["ca_chain"] = request.CAChain
+		respData["expiration"] = request.Expiration
+	}
+
+	return &logical.Response{Data: respData}, nil
+}
+
+func (b *backend) pathExternalIssuerStatusRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	name := data.Get("name").(string)
+	profile, err := sc.getExternalIssuerProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	problems := []string{}
+	respData := map[string]interface{}{
+		"name":       profile.Name,
+		"role":       profile.Role,
+		"rate_limit": profile.RateLimit,
+	}
+
+	role, err := b.getRole(ctx, req.Storage, profile.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		problems = append(problems, fmt.Sprintf("the role %q does not exist", profile.Role))
+	}
+
+	issuerId, err := sc.resolveIssuerReference(profile.IssuerRef)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("unable to resolve the issuer %q: %v", profile.IssuerRef, err))
+	} else {
+		issuer, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := issuer.GetCertificate()
+		if err != nil {
+			return nil, err
+		}
+
+		respData["issuer_id"] = issuer.ID
+		respData["issuer_name"] = issuer.Name
+		respData["issuer_expiration"] = cert.NotAfter.Format(time.RFC3339)
+		respData["ca_chain"] = issuer.CAChain
+
+		if issuer.Revoked {
+			problems = append(problems, "the issuer is revoked")
+		}
+		if time.Now().After(cert.NotAfter) {
+			problems = append(problems, "the issuer is expired")
+		}
+		if err := issuer.EnsureUsage(IssuanceUsage); err != nil {
+			problems = append(problems, fmt.Sprintf("the issuer may not issue certificates: %v", err))
+		}
+	}
+
+	if limiter := b.externalIssuerLimiter(profile); limiter != nil {
+		tokens := int(limiter.Tokens())
+		if tokens < 0 {
+			tokens = 0
+		}
+		respData["rate_limit_remaining"] = tokens
+	}
+
+	respData["ready"] = len(problems) == 0
+	respData["problems"] = problems
+
+	return &logical.Response{Data: respData}, nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// externalIssuerCSR returns the PEM-encoded CSR of a leaf certificate with
+// the DNS SANs and no common name, the way cert-manager requests it.
+func externalIssuerCSR(t *testing.T, dnsNames ...string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{Organization: []string{"cert-manager"}},
+		DNSNames: dnsNames,
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func TestExternalIssuerProfile(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	_, err := CBWrite(b, s, "external-issuer/profile/cluster", map[string]interface{}{
+		"role": "unknown",
+	})
+	require.Error(t, err, "expected an error for an unknown role")
+
+	resp, err := CBWrite(b, s, "roles/k8s", map[string]interface{}{
+		"allowed_domains":  "svc.cluster.local",
+		"allow_subdomains": true,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// The issuer may be created after the profile
+	resp, err = CBWrite(b, s, "external-issuer/profile/cluster", map[string]interface{}{
+		"role":              "k8s",
+		"allowed_approvers": "cert-manager.io",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.NotEmpty(t, resp.Warnings)
+	require.Equal(t, "default", resp.Data["issuer_ref"])
+	require.Equal(t, true, resp.Data["require_approval"])
+	require.Equal(t, []string{"cert-manager.io"}, resp.Data["allowed_approvers"])
+	require.Equal(t, int64(60), resp.Data["rate_limit_interval"])
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/status")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, false, resp.Data["ready"])
+	require.Len(t, resp.Data["problems"], 1)
+
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"issuer_name": "root",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/status")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, true, resp.Data["ready"])
+	require.Empty(t, resp.Data["problems"])
+	require.Equal(t, "root", resp.Data["issuer_name"])
+	require.Len(t, resp.Data["ca_chain"], 1)
+
+	resp, err = CBList(b, s, "external-issuer/profile")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{"cluster"}, resp.Data["keys"])
+
+	resp, err = CBDelete(b, s, "external-issuer/profile/cluster")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestExternalIssuerSign(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"ttl":         "8760h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	root := parseCert(t, resp.Data["certificate"].(string))
+
+	resp, err = CBWrite(b, s, "roles/k8s", map[string]interface{}{
+		"allowed_domains":  "svc.cluster.local",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"max_ttl":          "720h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "external-issuer/profile/cluster", map[string]interface{}{
+		"role":                "k8s",
+		"allowed_approvers":   "cert-manager.io",
+		"rate_limit":          2,
+		"rate_limit_interval": "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	csr := externalIssuerCSR(t, "web.default.svc.cluster.local")
+
+	// The requests must be approved by an allowed approver
+	for _, data := range []map[string]interface{}{
+		{},
+		{"approved": true},
+		{"approved": true, "approver": "someone"},
+		{"approved": false, "approver": "cert-manager.io"},
+	} {
+		data["csr"] = csr
+		data["request_id"] = "denied"
+		_, err = CBWrite(b, s, "external-issuer/profile/cluster/sign", data)
+		require.ErrorContains(t, err, "denied", data)
+	}
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/request/denied")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, externalIssuerStateDenied, resp.Data["state"])
+	require.Equal(t, "the request was not approved", resp.Data["reason"])
+
+	// The SANs of the CSR are used, and one of them as its common name
+	approved := map[string]interface{}{
+		"csr":               csr,
+		"ttl":               "24h",
+		"request_id":        "0d8e7c4e",
+		"request_name":      "web-1",
+		"request_namespace": "default",
+		"approved":          true,
+		"approver":          "cert-manager.io",
+	}
+	resp, err = CBWrite(b, s, "external-issuer/profile/cluster/sign", approved)
+	requireSuccessNonNilResponse(t, resp, err)
+	requireFieldsSetInResp(t, resp, "certificate", "issuing_ca", "ca_chain", "serial_number", "expiration")
+	serial := resp.Data["serial_number"].(string)
+
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	requireSignedBy(t, cert, root)
+	require.Equal(t, "web.default.svc.cluster.local", cert.Subject.CommonName)
+	require.Equal(t, []string{"web.default.svc.cluster.local"}, cert.DNSNames)
+
+	// Retrying the request returns the same certificate
+	resp, err = CBWrite(b, s, "external-issuer/profile/cluster/sign", approved)
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, serial, resp.Data["serial_number"])
+	require.NotEmpty(t, resp.Warnings)
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/request/0d8e7c4e")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, externalIssuerStateIssued, resp.Data["state"])
+	require.Equal(t, serial, resp.Data["serial_number"])
+	require.Equal(t, "web-1", resp.Data["request_name"])
+	require.Equal(t, "cert-manager.io", resp.Data["approver"])
+
+	// The SANs are still validated by the role
+	approved["request_id"] = "5a1f2b9d"
+	approved["csr"] = externalIssuerCSR(t, "example.com")
+	_, err = CBWrite(b, s, "external-issuer/profile/cluster/sign", approved)
+	require.Error(t, err, "expected an error for a SAN the role doesn't allow")
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/request/5a1f2b9d")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, externalIssuerStateFailed, resp.Data["state"])
+
+	resp, err = CBList(b, s, "external-issuer/profile/cluster/request")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.ElementsMatch(t, []string{"0d8e7c4e", "5a1f2b9d", "denied"}, resp.Data["keys"])
+
+	// Both signing attempts used the rate limit of the profile
+	approved["request_id"] = "b7c3e1aa"
+	approved["csr"] = csr
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "external-issuer/profile/cluster/sign",
+		Data:      approved,
+		Storage:   s,
+	})
+	require.ErrorIs(t, err, logical.ErrRateLimitQuotaExceeded)
+	require.True(t, resp.IsError())
+
+	resp, err = CBRead(b, s, "external-issuer/profile/cluster/status")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 0, resp.Data["rate_limit_remaining"])
+}
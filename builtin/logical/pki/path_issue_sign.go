@@ -347,7 +347,7 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 
 	var caErr error
 	sc := b.makeStorageContext(ctx, req.Storage)
-	signingBundle, caErr := sc.fetchCAInfo(issuerName, IssuanceUsage)
+	signingBundle, signingIssuerId, caErr := sc.fetchCAInfoWithIssuer(issuerName, IssuanceUsage)
 	if caErr != nil {
 		switch caErr.(type) {
 		case errutil.UserError:
@@ -399,16 +399,19 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	if !role.NoStore {
-		key := "certs/" + normalizeSerial(cb.SerialNumber)
-		certsCounted := b.certsCounted.Load()
-		err = req.Storage.Put(ctx, &logical.StorageEntry{
-			Key:   key,
-			Value: parsedBundle.CertificateBytes,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("unable to store certificate locally: %w", err)
+		cert := &pendingCert{
+			serial:       normalizeSerial(cb.SerialNumber),
+			certBytes:    parsedBundle.CertificateBytes,
+			meta:         newCertMetadata(parsedBundle.Certificate, role.Name, signingIssuerId),
+			certsCounted: b.certsCounted.Load(),
+		}
+
+		// Certificates which can't be queued are stored right away
+		if !role.StoreAsync || !b.certStoreQueue.enqueue(cert) {
+			if err := b.storeCert(ctx, req.Storage, cert); err != nil {
+				return nil, err
+			}
 		}
-		b.ifCountEnabledIncrementTotalCertificatesCount(certsCounted, key)
 	}
 
 	if useCSR {
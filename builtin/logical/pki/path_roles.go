@@ -344,6 +344,16 @@ non-sensitive, or extremely short-lived. This option implies a value of "false"
 for "generate_lease".`,
 		},
 
+		"store_async": {
+			Type: framework.TypeBool,
+			Description: `
+If set, certificates issued/signed against this role are stored in the
+background after the response is returned, in batches, which can improve
+performance when issuing large numbers of certificates. However, certificates
+may be lost if Vault fails before they are stored, and may not be found right
+after their issuance. Ignored if "no_store" is set.`,
+		},
+
 		"require_cn": {
 			Type:        framework.TypeBool,
 			Description: `If set to false, makes the 'common_name' field optional while generating a certificate.`,
@@ -749,6 +759,16 @@ non-sensitive, or extremely short-lived. This option implies a value of "false"
 for "generate_lease".`,
 			},
 
+			"store_async": {
+				Type: framework.TypeBool,
+				Description: `
+If set, certificates issued/signed against this role are stored in the
+background after the response is returned, in batches, which can improve
+performance when issuing large numbers of certificates. However, certificates
+may be lost if Vault fails before they are stored, and may not be found right
+after their issuance. Ignored if "no_store" is set.`,
+			},
+
 			"require_cn": {
 				Type:        framework.TypeBool,
 				Default:     true,
@@ -1086,6 +1106,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		PostalCode:                    data.Get("postal_code").([]string),
 		GenerateLease:                 new(bool),
 		NoStore:                       data.Get("no_store").(bool),
+		StoreAsync:                    data.Get("store_async").(bool),
 		RequireCN:                     data.Get("require_cn").(bool),
 		CNValidations:                 data.Get("cn_validations").([]string),
 		AllowedSerialNumbers:          data.Get("allowed_serial_numbers").([]string),
@@ -1286,6 +1307,7 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		PostalCode:                    getWithExplicitDefault(data, "postal_code", oldEntry.PostalCode).([]string),
 		GenerateLease:                 new(bool),
 		NoStore:                       getWithExplicitDefault(data, "no_store", oldEntry.NoStore).(bool),
+		StoreAsync:                    getWithExplicitDefault(data, "store_async", oldEntry.StoreAsync).(bool),
 		RequireCN:                     getWithExplicitDefault(data, "require_cn", oldEntry.RequireCN).(bool),
 		CNValidations:                 getWithExplicitDefault(data, "cn_validations", oldEntry.CNValidations).([]string),
 		AllowedSerialNumbers:          getWithExplicitDefault(data, "allowed_serial_numbers", oldEntry.AllowedSerialNumbers).([]string),
@@ -1488,6 +1510,7 @@ type roleEntry struct {
 	PostalCode                    []string      `json:"postal_code"`
 	GenerateLease                 *bool         `json:"generate_lease,omitempty"`
 	NoStore                       bool          `json:"no_store"`
+	StoreAsync                    bool          `json:"store_async"`
 	RequireCN                     bool          `json:"require_cn"`
 	CNValidations                 []string      `json:"cn_validations"`
 	AllowedOtherSANs              []string      `json:"allowed_other_sans"`
@@ -1542,6 +1565,7 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"street_address":                     r.StreetAddress,
 		"postal_code":                        r.PostalCode,
 		"no_store":                           r.NoStore,
+		"store_async":                        r.StoreAsync,
 		"allowed_other_sans":                 r.AllowedOtherSANs,
 		"allowed_serial_numbers":             r.AllowedSerialNumbers,
 		"allowed_user_ids":                   r.AllowedUserIDs,
@@ -923,6 +923,11 @@ func TestPki_RolePatch(t *testing.T) {
 			Before:  true,
 			Patched: false,
 		},
+		{
+			Field:   "store_async",
+			Before:  false,
+			Patched: true,
+		},
 		{
 			Field:   "require_cn",
 			Before:  false,
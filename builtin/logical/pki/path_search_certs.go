@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const defaultCertSearchLimit = 100
+
+func pathSearchCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/search",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "search",
+			OperationSuffix: "certs",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"common_name": {
+				Type: framework.TypeString,
+				Description: `Only return certificates whose common name matches
+this value. Supports shell-style globs, e.g. "*.example.com".`,
+			},
+			"san": {
+				Type: framework.TypeString,
+				Description: `Only return certificates with a DNS, IP, email or URI
+SAN matching this value. Supports shell-style globs.`,
+			},
+			"role": {
+				Type:        framework.TypeString,
+				Description: `Only return certificates issued by this role.`,
+			},
+			"issuer_ref": {
+				Type:        framework.TypeString,
+				Description: `Only return certificates issued by this issuer.`,
+			},
+			"expires_within": {
+				Type: framework.TypeDurationSecond,
+				Description: `Only return certificates expiring within this
+duration from now, e.g. "720h".`,
+			},
+			"include_expired": {
+				Type:        framework.TypeBool,
+				Description: `Whether to include certificates which have already expired.`,
+				Default:     false,
+			},
+			"after": {
+				Type: framework.TypeString,
+				Description: `Serial number after which to start returning
+results. Use the next_after value of the previous response to page through
+results.`,
+			},
+			"limit": {
+				Type:        framework.TypeInt,
+				Description: `Maximum number of certificates to return.`,
+				Default:     defaultCertSearchLimit,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathSearchCerts,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"keys": {
+								Type:        framework.TypeStringSlice,
+								Description: `Serial numbers of the matching certificates`,
+								Required:    true,
+							},
+							"key_info": {
+								Type:        framework.TypeMap,
+								Description: `Metadata of the matching certificates, keyed by serial number`,
+								Required:    true,
+							},
+							"next_after": {
+								Type:        framework.TypeString,
+								Description: `Value of the after parameter to fetch the next page, if there are more results`,
+								Required:    false,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathSearchCertsHelpSyn,
+		HelpDescription: pathSearchCertsHelpDesc,
+	}
+}
+
+func (b *backend) pathSearchCerts(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	limit := data.Get("limit").(int)
+	if limit <= 0 {
+		return logical.ErrorResponse("limit must be greater than zero"), nil
+	}
+
+	now := time.Now()
+	filter := &certMetadataFilter{
+		CommonName:     data.Get("common_name").(string),
+		SAN:            data.Get("san").(string),
+		Role:           data.Get("role").(string),
+		IncludeExpired: data.Get("include_expired").(bool),
+	}
+	if expiresWithin := data.Get("expires_within").(int); expiresWithin > 0 {
+		filter.ExpiresBefore = now.Add(time.Duration(expiresWithin) * time.Second)
+	}
+	if issuerRef := data.Get("issuer_ref").(string); issuerRef != "" {
+		sc := b.makeStorageContext(ctx, req.Storage)
+		issuerId, err := sc.resolveIssuerReference(issuerRef)
+		if err != nil {
+			return logical.ErrorResponse("unable to resolve issuer reference %q: %v", issuerRef, err), nil
+		}
+		filter.IssuerID = issuerId
+	}
+
+	serials, err := req.Storage.List(ctx, "certs/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(serials)
+
+	after := normalizeSerial(data.Get("after").(string))
+	if after != "" {
+		start := sort.SearchStrings(serials, after)
+		if start < len(serials) && serials[start] == after {
+			start++
+		}
+		serials = serials[start:]
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	var nextAfter string
+	for _, serial := range serials {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		meta, err := fetchCertMetadata(ctx, req.Storage, serial)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil || !filter.matches(meta, now) {
+			continue
+		}
+
+		if len(keys) == limit {
+			nextAfter = keys[len(keys)-1]
+			break
+		}
+
+		key := denormalizeSerial(serial)
+		keys = append(keys, key)
+		keyInfo[key] = meta.toResponseData()
+	}
+
+	resp := logical.ListResponseWithInfo(keys, keyInfo)
+	if nextAfter != "" {
+		resp.Data["next_after"] = nextAfter
+	}
+	return resp, nil
+}
+
+const pathSearchCertsHelpSyn = `
+Search the stored certificates by subject, SAN, role, issuer and expiry.
+`
+
+const pathSearchCertsHelpDesc = `
+This endpoint returns the serial numbers and metadata of the stored
+certificates matching all of the given filters. By default expired
+certificates are not returned.
+
+Results are ordered by serial number and limited to "limit" entries; when more
+results are available, the response contains a "next_after" value which can be
+passed as the "after" parameter to fetch the next page.
+
+Certificates issued with "no_store" set on their role are not stored and hence
+cannot be found through this endpoint.
+`
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPKI_SearchCerts(t *testing.T) {
+	t.Parallel()
+
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"ttl":         "40h",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root")
+
+	for _, role := range []string{"web", "db"} {
+		_, err = CBWrite(b, s, "roles/"+role, map[string]interface{}{
+			"allowed_domains":  "example.com",
+			"allow_subdomains": true,
+			"key_type":         "ec",
+			"max_ttl":          "30h",
+		})
+		require.NoError(t, err)
+	}
+
+	issue := func(role, cn, ttl string) string {
+		resp, err := CBWrite(b, s, "issue/"+role, map[string]interface{}{
+			"common_name": cn,
+			"alt_names":   "alt-" + cn,
+			"ttl":         ttl,
+		})
+		requireSuccessNonNilResponse(t, resp, err, "failed issuing %s", cn)
+		return resp.Data["serial_number"].(string)
+	}
+	web1 := issue("web", "a.example.com", "2h")
+	web2 := issue("web", "b.example.com", "20h")
+	db1 := issue("db", "db.example.com", "2h")
+
+	search := func(data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation:  logical.ReadOperation,
+			Path:       "certs/search",
+			Storage:    s,
+			Data:       data,
+			MountPoint: "pki/",
+		})
+		requireSuccessNonNilResponse(t, resp, err, "failed searching certs")
+		schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("certs/search"), logical.ReadOperation), resp, true)
+		return resp
+	}
+
+	resp = search(map[string]interface{}{"role": "web"})
+	require.ElementsMatch(t, []string{web1, web2}, resp.Data["keys"])
+
+	resp = search(map[string]interface{}{"common_name": "db.*"})
+	require.Equal(t, []string{db1}, resp.Data["keys"])
+	keyInfo := resp.Data["key_info"].(map[string]interface{})[db1].(map[string]interface{})
+	require.Equal(t, "db", keyInfo["role"])
+	require.Contains(t, keyInfo["dns_names"], "alt-db.example.com")
+
+	resp = search(map[string]interface{}{"san": "alt-b.*"})
+	require.Equal(t, []string{web2}, resp.Data["keys"])
+
+	resp = search(map[string]interface{}{"expires_within": "5h"})
+	require.ElementsMatch(t, []string{web1, db1}, resp.Data["keys"])
+
+	// Page through all leaf certificates and the root one at a time.
+	var seen []string
+	after := ""
+	for {
+		resp = search(map[string]interface{}{"limit": 1, "after": after})
+		seen = append(seen, resp.Data["keys"].([]string)...)
+		next, ok := resp.Data["next_after"]
+		if !ok {
+			break
+		}
+		after = next.(string)
+	}
+	require.Len(t, seen, 4)
+	require.Subset(t, seen, []string{web1, web2, db1})
+}
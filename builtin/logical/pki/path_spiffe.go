@@ -0,0 +1,332 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	pathSpiffeSignIntermediateHelpSyn  = `Issue an intermediate CA certificate for a SPIFFE trust domain, based on the provided CSR.`
+	pathSpiffeSignIntermediateHelpDesc = `This path signs the CSR of the intermediate CA of a SPIFFE trust domain,
+such as the one of a SPIRE server using Vault as its UpstreamAuthority.
+
+The CSR must have a single URI Subject Alternative Name, the SPIFFE ID of a
+trust domain allowed by config/spiffe, such as "spiffe://example.org", and no
+other Subject Alternative Name. The other values of the CSR, including its
+extensions, are not copied into the issued certificate.`
+
+	pathSpiffeBundleHelpSyn  = `Fetch the trust bundle of the issuers, in the SPIFFE bundle format.`
+	pathSpiffeBundleHelpDesc = `This unauthenticated path returns the root certificates of the issuers of
+this mount as a JWK Set, in the format of the SPIFFE trust bundles, so that the
+SPIFFE workloads and federated trust domains can trust the identities issued
+by the intermediate CAs signed with spiffe/sign-intermediate.
+
+The roots are the last certificates of the CA chains of the issuers which are
+not revoked. The refresh hint is set by config/spiffe.`
+)
+
+func pathSpiffeSignIntermediate(b *backend) *framework.Path {
+	pattern := "spiffe/sign-intermediate"
+
+	displayAttrs := &framework.DisplayAttributes{
+		OperationPrefix: operationPrefixPKI,
+		OperationVerb:   "sign",
+		OperationSuffix: "spiffe-intermediate",
+	}
+
+	return buildPathSpiffeSignIntermediate(b, pattern, displayAttrs)
+}
+
+func pathIssuerSpiffeSignIntermediate(b *backend) *framework.Path {
+	pattern := "issuer/" + framework.GenericNameRegex(issuerRefParam) + "/spiffe/sign-intermediate"
+
+	displayAttrs := &framework.DisplayAttributes{
+		OperationPrefix: operationPrefixPKIIssuer,
+		OperationVerb:   "sign",
+		OperationSuffix: "spiffe-intermediate",
+	}
+
+	return buildPathSpiffeSignIntermediate(b, pattern, displayAttrs)
+}
+
+func buildPathSpiffeSignIntermediate(b *backend, pattern string, displayAttrs *framework.DisplayAttributes) *framework.Path {
+	fields := addIssuerRefField(map[string]*framework.FieldSchema{})
+
+	fields["csr"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Default:     "",
+		Description: `PEM-format CSR to be signed, with the SPIFFE ID of the trust domain as its only URI Subject Alternative Name.`,
+	}
+
+	fields["ttl"] = &framework.FieldSchema{
+		Type: framework.TypeDurationSecond,
+		Description: `The requested Time To Live for the certificate;
+sets the expiration date. If not specified
+the role default, backend default, or system
+default TTL is used, in that order. Cannot
+be larger than the mount max TTL. Note:
+this only has an effect when generating
+a CA cert or signing a CA cert, not when
+generating a CSR for an intermediate CA.`,
+	}
+
+	fields["format"] = &framework.FieldSchema{
+		Type:    framework.TypeString,
+		Default: "pem",
+		Description: `Format for returned data. Can be "pem", "der",
+or "pem_bundle". If "pem_bundle", the issuing cert
+will be appended to the certificate pem. If "der",
+the value will be base64 encoded. Defaults to "pem".`,
+		AllowedValues: []interface{}{"pem", "der", "pem_bundle"},
+		DisplayAttrs: &framework.DisplayAttributes{
+			Value: "pem",
+		},
+	}
+
+	return &framework.Path{
+		Pattern:      pattern,
+		DisplayAttrs: displayAttrs,
+		Fields:       fields,
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSpiffeSignIntermediate,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"expiration": {
+								Type:        framework.TypeInt64,
+								Description: `Expiration Time`,
+								Required:    true,
+							},
+							"serial_number": {
+								Type:        framework.TypeString,
+								Description: `Serial Number`,
+								Required:    true,
+							},
+							"certificate": {
+								Type:        framework.TypeString,
+								Description: `Certificate`,
+								Required:    true,
+							},
+							"issuing_ca": {
+								Type:        framework.TypeString,
+								Description: `Issuing CA`,
+								Required:    true,
+							},
+							"ca_chain": {
+								Type:        framework.TypeStringSlice,
+								Description: `CA Chain`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathSpiffeSignIntermediateHelpSyn,
+		HelpDescription: pathSpiffeSignIntermediateHelpDesc,
+	}
+}
+
+func (b *backend) pathSpiffeSignIntermediate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getSpiffeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	csrString := data.Get("csr").(string)
+	if csrString == "" {
+		return logical.ErrorResponse(`"csr" is empty`), nil
+	}
+	pemBlock, _ := pem.Decode([]byte(csrString))
+	if pemBlock == nil {
+		return logical.ErrorResponse("csr contains no data"), nil
+	}
+	csr, err := x509.ParseCertificateRequest(pemBlock.Bytes)
+	if err != nil {
+		return logical.ErrorResponse("certificate request could not be parsed: %v", err), nil
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return logical.ErrorResponse("request signature invalid: %v", err), nil
+	}
+
+	spiffeID, err := validateSpiffeCSR(csr, config)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// The intermediate is signed by the regular flow, with the SPIFFE ID as
+	// its only SAN and none of the values of the CSR but its common name.
+	raw := map[string]interface{}{
+		"csr":                  csrString,
+		issuerRefParam:         data.Get(issuerRefParam).(string),
+		"format":               data.Get("format").(string),
+		"uri_sans":             spiffeID,
+		"exclude_cn_from_sans": true,
+	}
+	if ttl, ok := data.GetOk("ttl"); ok {
+		raw["ttl"] = ttl
+	}
+	signData := &framework.FieldData{
+		Raw:    raw,
+		Schema: buildPathIssuerSignIntermediateRaw(b, "", nil).Fields,
+	}
+
+	return b.pathIssuerSignIntermediate(ctx, req, signData)
+}
+
+// validateSpiffeCSR ensures the only SAN of the CSR is the SPIFFE ID of a
+// trust domain allowed by the configuration, and returns it.
+func validateSpiffeCSR(csr *x509.CertificateRequest, config *spiffeConfigEntry) (string, error) {
+	if len(csr.DNSNames) > 0 || len(csr.EmailAddresses) > 0 || len(csr.IPAddresses) > 0 {
+		return "", fmt.Errorf("the CSR must not have DNS, email or IP Subject Alternative Names")
+	}
+	if len(csr.URIs) != 1 {
+		return "", fmt.Errorf("the CSR must have exactly one URI Subject Alternative Name, the SPIFFE ID of the trust domain")
+	}
+
+	id := csr.URIs[0]
+	if id.Scheme != "spiffe" || id.Opaque != "" || id.User != nil || id.Port() != "" ||
+		id.RawQuery != "" || id.Fragment != "" || (id.Path != "" && id.Path != "/") {
+		return "", fmt.Errorf("%q is not the SPIFFE ID of a trust domain, such as spiffe://example.org", id.String())
+	}
+
+	trustDomain := id.Hostname()
+	if !spiffeTrustDomainRegex.MatchString(trustDomain) {
+		return "", fmt.Errorf("invalid SPIFFE trust domain %q", trustDomain)
+	}
+	if !strutil.StrListContains(config.TrustDomains, trustDomain) {
+		return "", fmt.Errorf("the SPIFFE trust domain %q is not allowed by config/spiffe", trustDomain)
+	}
+
+	spiffeID := url.URL{Scheme: "spiffe", Host: trustDomain}
+	return spiffeID.String(), nil
+}
+
+func pathSpiffeBundle(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "spiffe/bundle",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationSuffix: "spiffe-bundle",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathSpiffeBundleRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathSpiffeBundleHelpSyn,
+		HelpDescription: pathSpiffeBundleHelpDesc,
+	}
+}
+
+// spiffeBundle is a SPIFFE trust bundle, in its JWK Set format.
+type spiffeBundle struct {
+	Keys        []jose.JSONWebKey `json:"keys"`
+	RefreshHint int64             `json:"spiffe_refresh_hint,omitempty"`
+}
+
+func (b *backend) pathSpiffeBundleRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getSpiffeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := sc.fetchSpiffeRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := spiffeBundle{
+		Keys:        []jose.JSONWebKey{},
+		RefreshHint: int64(config.BundleRefreshHint.Seconds()),
+	}
+	for _, root := range roots {
+		bundle.Keys = append(bundle.Keys, jose.JSONWebKey{
+			Key:          root.PublicKey,
+			Certificates: []*x509.Certificate{root},
+			Use:          "x509-svid",
+		})
+	}
+
+	rawBody, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding response: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/json",
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     rawBody,
+		},
+	}, nil
+}
+
+// fetchSpiffeRoots returns the last certificates of the CA chains of the
+// issuers which are not revoked, without duplicates.
+func (sc *storageContext) fetchSpiffeRoots() ([]*x509.Certificate, error) {
+	issuers, err := sc.listIssuers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issuers: %w", err)
+	}
+
+	var roots []*x509.Certificate
+	for _, issuerId := range issuers {
+		issuer, err := sc.fetchIssuerById(issuerId)
+		if err != nil {
+			return nil, err
+		}
+		if issuer.Revoked {
+			continue
+		}
+
+		rootPem := issuer.Certificate
+		if len(issuer.CAChain) > 0 {
+			rootPem = issuer.CAChain[len(issuer.CAChain)-1]
+		}
+		root, err := parseCertificateFromBytes([]byte(rootPem))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the CA chain of issuer %v: %w", issuerId, err)
+		}
+
+		duplicate := false
+		for _, known := range roots {
+			if bytes.Equal(known.Raw, root.Raw) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			roots = append(roots, root)
+		}
+	}
+
+	return roots, nil
+}
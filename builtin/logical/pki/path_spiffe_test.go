@@ -0,0 +1,195 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// spiffeCSR returns the PEM-encoded CSR of a new intermediate CA with the
+// URI SANs and the DNS SANs, the way SPIRE requests it.
+func spiffeCSR(t *testing.T, uris []string, dnsNames []string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{
+			Country:      []string{"US"},
+			Organization: []string{"SPIFFE"},
+		},
+		DNSNames: dnsNames,
+	}
+	for _, uri := range uris {
+		parsed, err := url.Parse(uri)
+		require.NoError(t, err)
+		template.URIs = append(template.URIs, parsed)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func TestSpiffeConfig(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/spiffe")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Empty(t, resp.Data["trust_domains"])
+	require.Equal(t, int64(300), resp.Data["bundle_refresh_hint"])
+
+	resp, err = CBWrite(b, s, "config/spiffe", map[string]interface{}{
+		"trust_domains":       "example.org,spiffe://prod.example.org",
+		"bundle_refresh_hint": "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{"example.org", "prod.example.org"}, resp.Data["trust_domains"])
+	require.Equal(t, int64(3600), resp.Data["bundle_refresh_hint"])
+
+	_, err = CBWrite(b, s, "config/spiffe", map[string]interface{}{
+		"trust_domains": "Example.org",
+	})
+	require.Error(t, err, "expected an error for an invalid trust domain")
+
+	_, err = CBWrite(b, s, "config/spiffe", map[string]interface{}{
+		"bundle_refresh_hint": 0,
+	})
+	require.Error(t, err, "expected an error for a zero refresh hint")
+
+	resp, err = CBRead(b, s, "config/spiffe")
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, []string{"example.org", "prod.example.org"}, resp.Data["trust_domains"])
+}
+
+func TestSpiffeSignIntermediate(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+		"issuer_name": "root",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	root := parseCert(t, resp.Data["certificate"].(string))
+
+	// No trust domain is allowed by default
+	csr := spiffeCSR(t, []string{"spiffe://example.org"}, nil)
+	_, err = CBWrite(b, s, "spiffe/sign-intermediate", map[string]interface{}{
+		"csr": csr,
+	})
+	require.Error(t, err, "expected an error for a trust domain which isn't allowed")
+
+	resp, err = CBWrite(b, s, "config/spiffe", map[string]interface{}{
+		"trust_domains": "example.org",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	for _, path := range []string{"spiffe/sign-intermediate", "issuer/root/spiffe/sign-intermediate"} {
+		resp, err = CBWrite(b, s, path, map[string]interface{}{
+			"csr": csr,
+			"ttl": "24h",
+		})
+		requireSuccessNonNilResponse(t, resp, err, path)
+		requireFieldsSetInResp(t, resp, "certificate", "issuing_ca", "ca_chain", "serial_number", "expiration")
+
+		cert := parseCert(t, resp.Data["certificate"].(string))
+		requireSignedBy(t, cert, root)
+		require.True(t, cert.IsCA)
+		require.Empty(t, cert.DNSNames)
+		require.Len(t, cert.URIs, 1)
+		require.Equal(t, "spiffe://example.org", cert.URIs[0].String())
+		// The not before date is backdated by 30 seconds by default
+		require.Equal(t, 24*time.Hour+30*time.Second, cert.NotAfter.Sub(cert.NotBefore))
+	}
+
+	for name, csr := range map[string]string{
+		"other trust domain": spiffeCSR(t, []string{"spiffe://other.org"}, nil),
+		"workload ID":        spiffeCSR(t, []string{"spiffe://example.org/workload"}, nil),
+		"other scheme":       spiffeCSR(t, []string{"https://example.org"}, nil),
+		"no URI SAN":         spiffeCSR(t, nil, nil),
+		"two URI SANs":       spiffeCSR(t, []string{"spiffe://example.org", "spiffe://example.org"}, nil),
+		"DNS SAN":            spiffeCSR(t, []string{"spiffe://example.org"}, []string{"example.org"}),
+		"not a CSR":          "not a CSR",
+	} {
+		_, err = CBWrite(b, s, "spiffe/sign-intermediate", map[string]interface{}{
+			"csr": csr,
+		})
+		require.Error(t, err, "expected an error for a CSR with %s", name)
+	}
+}
+
+func TestSpiffeBundle(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	readBundle := func() (jose.JSONWebKeySet, int64) {
+		resp, err := CBRead(b, s, "spiffe/bundle")
+		requireSuccessNonNilResponse(t, resp, err)
+		require.Equal(t, "application/json", resp.Data[logical.HTTPContentType])
+
+		var bundle struct {
+			jose.JSONWebKeySet
+			RefreshHint int64 `json:"spiffe_refresh_hint"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &bundle))
+		return bundle.JSONWebKeySet, bundle.RefreshHint
+	}
+
+	keys, refreshHint := readBundle()
+	require.Empty(t, keys.Keys)
+	require.Equal(t, int64(300), refreshHint)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "Root X1",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	root := parseCert(t, resp.Data["certificate"].(string))
+
+	// An intermediate issuer of the root only adds the root once
+	resp, err = CBWrite(b, s, "intermediate/generate/internal", map[string]interface{}{
+		"common_name": "Intermediate X1",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	resp, err = CBWrite(b, s, "root/sign-intermediate", map[string]interface{}{
+		"csr":    resp.Data["csr"],
+		"format": "pem_bundle",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	resp, err = CBWrite(b, s, "issuers/import/bundle", map[string]interface{}{
+		"pem_bundle": resp.Data["certificate"],
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "config/spiffe", map[string]interface{}{
+		"bundle_refresh_hint": "10m",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	keys, refreshHint = readBundle()
+	require.Equal(t, int64(600), refreshHint)
+	require.Len(t, keys.Keys, 1)
+	require.Equal(t, "x509-svid", keys.Keys[0].Use)
+	require.Len(t, keys.Keys[0].Certificates, 1)
+	require.Equal(t, root.Raw, keys.Keys[0].Certificates[0].Raw)
+	requireMatchingPublicKeys(t, root, keys.Keys[0].Key)
+}
@@ -1001,6 +1001,9 @@ func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, log
 			if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 				return fmt.Errorf("error deleting serial %q from storage: %w", serial, err)
 			}
+			if err := deleteCertMetadata(ctx, req.Storage, serial); err != nil {
+				return err
+			}
 			b.tidyStatusIncCertStoreCount()
 		}
 	}
@@ -1112,6 +1115,9 @@ func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Reques
 				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 					return fmt.Errorf("error deleting serial %q from store when tidying revoked: %w", serial, err)
 				}
+				if err := deleteCertMetadata(ctx, req.Storage, serial); err != nil {
+					return err
+				}
 				rebuildCRL = true
 				storeCert = false
 				b.tidyStatusIncRevokedCertCount()
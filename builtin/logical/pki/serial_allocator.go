@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bufio"
+	"crypto/rand"
+	"math/big"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+)
+
+// serialBlockSize is the number of serial numbers generated at once by the
+// serialAllocator.
+const serialBlockSize = 256
+
+// serialAllocator hands out the random serial numbers of issued certificates,
+// which it pre-allocates in blocks: a block is generated from a single read of
+// the random source, instead of one read per certificate. Serial numbers are
+// as random as those generated by certutil.GenerateSerialNumber, so no
+// coordination between nodes is needed.
+type serialAllocator struct {
+	lock    sync.Mutex
+	serials []*big.Int
+}
+
+// next returns an unused serial number, generating a new block of them when
+// the current one is exhausted.
+func (a *serialAllocator) next() (*big.Int, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(a.serials) == 0 {
+		// Each serial number takes 20 bytes, more when a value out of range is
+		// rejected, which is rare.
+		r := bufio.NewReaderSize(rand.Reader, serialBlockSize*21)
+		serials := make([]*big.Int, 0, serialBlockSize)
+		for i := 0; i < serialBlockSize; i++ {
+			serial, err := certutil.GenerateSerialNumberWithRandomSource(r)
+			if err != nil {
+				return nil, err
+			}
+			serials = append(serials, serial)
+		}
+		a.serials = serials
+	}
+
+	serial := a.serials[len(a.serials)-1]
+	a.serials[len(a.serials)-1] = nil
+	a.serials = a.serials[:len(a.serials)-1]
+	return serial, nil
+}
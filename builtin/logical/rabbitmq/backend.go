@@ -40,6 +40,7 @@ func Backend() *backend {
 		Paths: []*framework.Path{
 			pathConfigConnection(&b),
 			pathConfigLease(&b),
+			pathRotateRoot(&b),
 			pathListRoles(&b),
 			pathCreds(&b),
 			pathRoles(&b),
@@ -85,6 +85,15 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 		return nil, fmt.Errorf("failed to generate username: %w", err)
 	}
 
+	tags, err := renderTags(role.Tags, TagMetadata{
+		DisplayName: req.DisplayName,
+		RoleName:    name,
+		Username:    username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render tags: %w", err)
+	}
+
 	password, err := b.generatePassword(ctx, config.PasswordPolicy)
 	if err != nil {
 		return nil, err
@@ -102,7 +111,7 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 	// Register the generated credentials in the backend, with the RabbitMQ server
 	resp, err := client.PutUser(username, rabbithole.UserSettings{
 		Password: password,
-		Tags:     []string{role.Tags},
+		Tags:     tags,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new user with the generated credentials")
@@ -162,3 +162,16 @@ func TestBackend_RoleCreate_CustomUsernameTemplate(t *testing.T) {
 
 	require.Regexp(t, `^foo-token$`, username)
 }
+
+func TestBackend_RoleCreate_RenderTags(t *testing.T) {
+	tags, err := renderTags("management, {{ .RoleName }}-{{ .DisplayName }},,", TagMetadata{
+		DisplayName: "token",
+		RoleName:    "foo",
+		Username:    "token-1234",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"management", "foo-token"}, tags)
+
+	_, err = renderTags("{{ .Unknown", TagMetadata{})
+	require.Error(t, err)
+}
@@ -6,10 +6,12 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/template"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -42,7 +44,7 @@ func pathRoles(b *backend) *framework.Path {
 			},
 			"tags": {
 				Type:        framework.TypeString,
-				Description: "Comma-separated list of tags for this role.",
+				Description: "Comma-separated list of tags for this role. Each tag may be a template.",
 			},
 			"vhosts": {
 				Type:        framework.TypeString,
@@ -137,6 +139,10 @@ func (b *backend) pathRoleUpdate(ctx context.Context, req *logical.Request, d *f
 		return logical.ErrorResponse("both tags and vhosts not specified"), nil
 	}
 
+	if _, err := renderTags(tags, TagMetadata{}); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid tags: %s", err)), nil
+	}
+
 	var vhosts map[string]vhostPermission
 	if len(rawVHosts) > 0 {
 		if err := jsonutil.DecodeJSON([]byte(rawVHosts), &vhosts); err != nil {
@@ -178,6 +184,41 @@ type roleEntry struct {
 	VHostTopics map[string]map[string]vhostTopicPermission `json:"vhost_topics" structs:"vhost_topics" mapstructure:"vhost_topics"`
 }
 
+// TagMetadata is the data available to the templates in a role's tags
+type TagMetadata struct {
+	DisplayName string
+	RoleName    string
+	Username    string
+}
+
+// renderTags splits the comma-separated tags of a role and renders each of
+// them as a template using the given metadata. Tags rendering to an empty
+// string are dropped.
+func renderTags(rawTags string, metadata TagMetadata) ([]string, error) {
+	var tags []string
+	for _, rawTag := range strings.Split(rawTags, ",") {
+		rawTag = strings.TrimSpace(rawTag)
+		if rawTag == "" {
+			continue
+		}
+
+		tmpl, err := template.NewTemplate(template.Template(rawTag))
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize tag template %q: %w", rawTag, err)
+		}
+		tag, err := tmpl.Generate(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render tag template %q: %w", rawTag, err)
+		}
+
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
 // Structure representing the permissions of a vhost
 type vhostPermission struct {
 	Configure string `json:"configure" structs:"configure" mapstructure:"configure"`
@@ -199,7 +240,9 @@ const pathRoleHelpDesc = `
 This path lets you manage the roles that can be created with this backend.
 
 The "tags" parameter customizes the tags used to create the role.
-This is a comma separated list of strings. The "vhosts" parameter customizes
+This is a comma separated list of strings. Each tag may be a template with
+access to .DisplayName, .RoleName and .Username, for example
+"management,team-{{ .DisplayName }}". The "vhosts" parameter customizes
 the virtual hosts that this user will be associated with. This is a JSON object
 passed as a string in the form:
 {
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+)
+
+func pathRotateRoot(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/rotate-root",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixRabbitMQ,
+			OperationVerb:   "rotate",
+			OperationSuffix: "root-credentials",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.pathRotateRootUpdate,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathRotateRootHelpSyn,
+		HelpDescription: pathRotateRootHelpDesc,
+	}
+}
+
+func (b *backend) pathRotateRootUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read configuration: %w", err)
+	}
+	if config.URI == "" || config.Username == "" {
+		return logical.ErrorResponse("cannot call config/rotate-root before config/connection is configured"), nil
+	}
+
+	client, err := b.Client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return logical.ErrorResponse("failed to get the client"), nil
+	}
+
+	// Keep the existing tags of the management user, otherwise updating the
+	// password would strip its administrator privileges.
+	user, err := client.GetUser(config.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error reading user %s: %w", config.Username, err)
+	}
+
+	password, err := b.generatePassword(ctx, config.PasswordPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.PutUser(config.Username, rabbithole.UserSettings{
+		Password: password,
+		Tags:     user.Tags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating password for user %s: %w", config.Username, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			b.Logger().Error(fmt.Sprintf("unable to close response body: %s", err))
+		}
+	}()
+	if !isIn200s(resp.StatusCode) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error updating password for user %s - %d: %s", config.Username, resp.StatusCode, body)
+	}
+
+	config.Password = password
+	if err := writeConfig(ctx, req.Storage, config); err != nil {
+		return nil, fmt.Errorf("error saving new configuration: %w", err)
+	}
+
+	// Reset the client connection so the new password is used
+	b.resetClient(ctx)
+
+	return nil, nil
+}
+
+const pathRotateRootHelpSyn = `
+Request to rotate the RabbitMQ credentials used by Vault.
+`
+
+const pathRotateRootHelpDesc = `
+This path generates a new password for the RabbitMQ management user configured
+via the "config/connection" endpoint and stores it in Vault. The previous
+password is no longer valid once this request completes. If a password policy
+is configured it is used to generate the new password.
+`
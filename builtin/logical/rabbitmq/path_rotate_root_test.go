@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_RotateRoot(t *testing.T) {
+	cleanup, connectionURI := prepareRabbitMQTestContainer(t)
+	defer cleanup()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	require.NoError(t, b.Setup(context.Background(), config))
+
+	// Use a dedicated administrator so the guest user stays usable for
+	// other tests sharing the container.
+	rmqc, err := rabbithole.NewClient(connectionURI, "guest", "guest")
+	require.NoError(t, err)
+	_, err = rmqc.PutUser("vault-admin", rabbithole.UserSettings{
+		Password: "initial",
+		Tags:     rabbithole.UserTags{"administrator"},
+	})
+	require.NoError(t, err)
+	_, err = rmqc.UpdatePermissionsIn("/", "vault-admin", rabbithole.Permissions{
+		Configure: ".*",
+		Write:     ".*",
+		Read:      ".*",
+	})
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/connection",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"connection_uri": connectionURI,
+			"username":       "vault-admin",
+			"password":       "initial",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr:%s", resp, err)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/rotate-root",
+		Storage:   config.StorageView,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr:%s", resp, err)
+	}
+
+	newConfig, err := readConfig(context.Background(), config.StorageView)
+	require.NoError(t, err)
+	require.NotEqual(t, "initial", newConfig.Password)
+
+	// The new password must work and the administrator tag must be kept
+	client, err := rabbithole.NewClient(connectionURI, "vault-admin", newConfig.Password)
+	require.NoError(t, err)
+	user, err := client.GetUser("vault-admin")
+	require.NoError(t, err)
+	require.Equal(t, rabbithole.UserTags{"administrator"}, user.Tags)
+}
@@ -107,6 +107,16 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 		return nil, err
 	}
 
+	if useCache {
+		storedCache, err := getCacheConfigFromStorage(ctx, conf.StorageView)
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving cache configuration from storage: %w", err)
+		}
+		if err := b.lm.SetDerivedKeyCacheSize(storedCache.DerivedKeysSize); err != nil {
+			return nil, err
+		}
+	}
+
 	return &b, nil
 }
 
@@ -122,19 +132,27 @@ type backend struct {
 }
 
 func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error) {
-	size := 0
-	entry, err := s.Get(ctx, "config/cache")
+	storedCache, err := getCacheConfigFromStorage(ctx, s)
 	if err != nil {
 		return 0, err
 	}
+	return storedCache.Size, nil
+}
+
+// getCacheConfigFromStorage returns the stored cache configuration, or the
+// default one if none is stored.
+func getCacheConfigFromStorage(ctx context.Context, s logical.Storage) (*configCache, error) {
+	storedCache := &configCache{}
+	entry, err := s.Get(ctx, "config/cache")
+	if err != nil {
+		return nil, err
+	}
 	if entry != nil {
-		var storedCache configCache
-		if err := entry.DecodeJSON(&storedCache); err != nil {
-			return 0, err
+		if err := entry.DecodeJSON(storedCache); err != nil {
+			return nil, err
 		}
-		size = storedCache.Size
 	}
-	return size, nil
+	return storedCache, nil
 }
 
 // Update cache size and get policy
@@ -144,13 +162,17 @@ func (b *backend) GetPolicy(ctx context.Context, polReq keysutil.PolicyRequest,
 	if b.lm.GetUseCache() && b.cacheSizeChanged {
 		var err error
 		currentCacheSize := b.lm.GetCacheSize()
-		storedCacheSize, err := GetCacheSizeFromStorage(ctx, polReq.Storage)
+		storedCache, err := getCacheConfigFromStorage(ctx, polReq.Storage)
 		if err != nil {
 			b.configMutex.RUnlock()
 			return nil, false, err
 		}
-		if currentCacheSize != storedCacheSize {
-			err = b.lm.InitCache(storedCacheSize)
+		if err := b.lm.SetDerivedKeyCacheSize(storedCache.DerivedKeysSize); err != nil {
+			b.configMutex.RUnlock()
+			return nil, false, err
+		}
+		if currentCacheSize != storedCache.Size {
+			err = b.lm.InitCache(storedCache.Size)
 			if err != nil {
 				b.configMutex.RUnlock()
 				return nil, false, err
@@ -179,7 +201,7 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 	case strings.HasPrefix(key, "policy/"):
 		name := strings.TrimPrefix(key, "policy/")
 		b.lm.InvalidatePolicy(name)
-	case strings.HasPrefix(key, "cache-config/"):
+	case key == "config/cache":
 		// Acquire the lock to set the flag to indicate that cache size needs to be refreshed from storage
 		b.configMutex.Lock()
 		defer b.configMutex.Unlock()
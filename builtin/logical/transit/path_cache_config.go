@@ -26,6 +26,11 @@ func (b *backend) pathCacheConfig() *framework.Path {
 				Default:     0,
 				Description: `Size of cache, use 0 for an unlimited cache size, defaults to 0`,
 			},
+			"derived_keys_size": {
+				Type:        framework.TypeInt,
+				Required:    false,
+				Description: `Size of the cache of derived keys of each cached key, use 0 to disable caching of derived keys, defaults to 0`,
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -59,9 +64,25 @@ func (b *backend) pathCacheConfigWrite(ctx context.Context, req *logical.Request
 		return logical.ErrorResponse("size must be 0 or a value greater or equal to %d", minCacheSize), logical.ErrInvalidRequest
 	}
 
+	storedCache, err := getCacheConfigFromStorage(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// get target size of the derived keys cache, keeping the stored one if
+	// not given
+	derivedKeysSize := storedCache.DerivedKeysSize
+	if derivedKeysSizeRaw, ok := d.GetOk("derived_keys_size"); ok {
+		derivedKeysSize = derivedKeysSizeRaw.(int)
+	}
+	if derivedKeysSize < 0 {
+		return logical.ErrorResponse("derived_keys_size must be greater or equal to 0"), logical.ErrInvalidRequest
+	}
+
 	// store cache size
 	entry, err := logical.StorageEntryJSON("config/cache", &configCache{
-		Size: cacheSize,
+		Size:            cacheSize,
+		DerivedKeysSize: derivedKeysSize,
 	})
 	if err != nil {
 		return nil, err
@@ -70,6 +91,10 @@ func (b *backend) pathCacheConfigWrite(ctx context.Context, req *logical.Request
 		return nil, err
 	}
 
+	err = b.lm.SetDerivedKeyCacheSize(derivedKeysSize)
+	if err != nil {
+		return nil, err
+	}
 	err = b.lm.InitCache(cacheSize)
 	if err != nil {
 		return nil, err
@@ -77,13 +102,15 @@ func (b *backend) pathCacheConfigWrite(ctx context.Context, req *logical.Request
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"size": cacheSize,
+			"size":              cacheSize,
+			"derived_keys_size": derivedKeysSize,
 		},
 	}, nil
 }
 
 type configCache struct {
-	Size int `json:"size"`
+	Size            int `json:"size"`
+	DerivedKeysSize int `json:"derived_keys_size"`
 }
 
 func (b *backend) pathCacheConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -96,13 +123,17 @@ func (b *backend) pathCacheConfigRead(ctx context.Context, req *logical.Request,
 
 	// Compare current and stored cache sizes. If they are different warn the user.
 	currentCacheSize := b.lm.GetCacheSize()
-	storedCacheSize, err := GetCacheSizeFromStorage(ctx, req.Storage)
+	storedCache, err := getCacheConfigFromStorage(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
 
-	if currentCacheSize != storedCacheSize {
-		err = b.lm.InitCache(storedCacheSize)
+	err = b.lm.SetDerivedKeyCacheSize(storedCache.DerivedKeysSize)
+	if err != nil {
+		return nil, err
+	}
+	if currentCacheSize != storedCache.Size {
+		err = b.lm.InitCache(storedCache.Size)
 		if err != nil {
 			return nil, err
 		}
@@ -110,7 +141,8 @@ func (b *backend) pathCacheConfigRead(ctx context.Context, req *logical.Request,
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"size": storedCacheSize,
+			"size":              storedCache.Size,
+			"derived_keys_size": storedCache.DerivedKeysSize,
 		},
 	}
 
@@ -121,4 +153,6 @@ const pathCacheConfigHelpSyn = `Configure caching strategy`
 
 const pathCacheConfigHelpDesc = `
 This path is used to configure and query the cache size of the active cache, a size of 0 means unlimited.
+The size of the cache of derived keys kept for each cached key of derived and convergent modes can also be
+configured, a size of 0 disables caching of derived keys.
 `
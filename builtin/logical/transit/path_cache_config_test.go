@@ -95,7 +95,7 @@ func TestTransit_CacheConfig(t *testing.T) {
 	// Change cache size to targetCacheSize 12345 and validate that cache size is updated
 	doReq(b1, writeReq)
 	validateResponse(doReq(b1, readReq), targetCacheSize, false)
-	b1.invalidate(context.Background(), "cache-config/")
+	b1.invalidate(context.Background(), "config/cache")
 
 	// Change the cache size to 1000 to mock the scenario where
 	// current cache size and stored cache size are different and
@@ -121,3 +121,109 @@ func TestTransit_CacheConfig(t *testing.T) {
 	b4, storage := createBackendWithSysView(t)
 	doErrReq(b4, writeSmallCacheSizeReq)
 }
+
+func TestTransit_CacheConfig_DerivedKeys(t *testing.T) {
+	b1, storage := createBackendWithSysView(t)
+
+	doReq := func(b *backend, req *logical.Request) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+		}
+		return resp
+	}
+
+	readReq := &logical.Request{
+		Storage:   storage,
+		Operation: logical.ReadOperation,
+		Path:      "cache-config",
+	}
+
+	resp := doReq(b1, readReq)
+	if resp.Data["derived_keys_size"] != 0 {
+		t.Fatalf("expected derived keys cache to be disabled, got %#v", resp.Data)
+	}
+
+	// Negative sizes are rejected
+	resp, err := b1.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "cache-config",
+		Data: map[string]interface{}{
+			"derived_keys_size": -1,
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error")
+	}
+
+	doReq(b1, &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "cache-config",
+		Data: map[string]interface{}{
+			"derived_keys_size": 100,
+		},
+	})
+	if b1.lm.GetDerivedKeyCacheSize() != 100 {
+		t.Fatalf("bad derived key cache size: %d", b1.lm.GetDerivedKeyCacheSize())
+	}
+
+	// Updating the size alone keeps the derived key cache size
+	doReq(b1, &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "cache-config",
+		Data: map[string]interface{}{
+			"size": targetCacheSize,
+		},
+	})
+	resp = doReq(b1, readReq)
+	if resp.Data["size"] != targetCacheSize || resp.Data["derived_keys_size"] != 100 {
+		t.Fatalf("bad cache config: %#v", resp.Data)
+	}
+
+	// Encrypting with a derived key fills its cache
+	doReq(b1, &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/derived",
+		Data: map[string]interface{}{
+			"derived": true,
+		},
+	})
+	doReq(b1, &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "encrypt/derived",
+		Data: map[string]interface{}{
+			"plaintext": "dGhlIHF1aWNrIGJyb3duIGZveA==",
+			"context":   "dGVzdA==",
+		},
+	})
+
+	// b2 should spin up with the configured derived key cache size
+	b2 := createBackendWithSysViewWithStorage(t, storage)
+	if b2.lm.GetDerivedKeyCacheSize() != 100 {
+		t.Fatalf("bad derived key cache size: %d", b2.lm.GetDerivedKeyCacheSize())
+	}
+
+	// Changes made on another node are picked up once invalidated
+	doReq(b2, &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "cache-config",
+		Data: map[string]interface{}{
+			"derived_keys_size": 0,
+		},
+	})
+	b1.invalidate(context.Background(), "config/cache")
+	doReq(b1, &logical.Request{
+		Storage:   storage,
+		Operation: logical.ReadOperation,
+		Path:      "keys/derived",
+	})
+	if b1.lm.GetDerivedKeyCacheSize() != 0 {
+		t.Fatalf("bad derived key cache size: %d", b1.lm.GetDerivedKeyCacheSize())
+	}
+}
@@ -34,8 +34,13 @@ import (
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/awscreds"
+	"github.com/hashicorp/vault/command/agent/awskms"
 	agentConfig "github.com/hashicorp/vault/command/agent/config"
 	"github.com/hashicorp/vault/command/agent/exec"
+	"github.com/hashicorp/vault/command/agent/kmip"
+	"github.com/hashicorp/vault/command/agent/secretsapi"
+	"github.com/hashicorp/vault/command/agent/systemdcreds"
 	"github.com/hashicorp/vault/command/agent/template"
 	"github.com/hashicorp/vault/command/agentproxyshared"
 	"github.com/hashicorp/vault/command/agentproxyshared/auth"
@@ -284,6 +289,7 @@ func (c *AgentCommand) Run(args []string) int {
 	inmemMetrics, _, prometheusEnabled, err := configutil.SetupTelemetry(&configutil.SetupTelemetryOpts{
 		Config:      config.Telemetry,
 		Ui:          c.UI,
+		Logger:      c.logger,
 		ServiceName: "vault",
 		DisplayName: "Vault",
 		UserAgent:   useragent.AgentString(),
@@ -514,6 +520,144 @@ func (c *AgentCommand) Run(args []string) int {
 		}
 	}
 
+	// The AWS credentials server receives the auto-auth token as a sink, and
+	// is served on the listeners
+	var awsCredentialsServer *awscreds.Server
+	if config.AWSCredentials != nil {
+		awsCredentialsLogger := c.logger.Named("awscreds")
+		awsCredentialsServer, err = awscreds.NewServer(&awscreds.ServerConfig{
+			Logger:    awsCredentialsLogger,
+			Client:    proxyClient,
+			Config:    config.AWSCredentials,
+			Namespace: templateNamespace,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating AWS credentials server: %v", err))
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: awsCredentialsLogger,
+			Sink:   awsCredentialsServer,
+		})
+	}
+
+	// The AWS KMS server receives the auto-auth token as a sink when there is
+	// one, and is served on the listeners
+	var awsKMSServer *awskms.Server
+	if config.AWSKMS != nil {
+		awsKMSLogger := c.logger.Named("awskms")
+		awsKMSServer, err = awskms.NewServer(&awskms.ServerConfig{
+			Logger:    awsKMSLogger,
+			Client:    proxyClient,
+			Config:    config.AWSKMS,
+			Namespace: templateNamespace,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating AWS KMS server: %v", err))
+			return 1
+		}
+		if config.AutoAuth != nil {
+			sinks = append(sinks, &sink.SinkConfig{
+				Logger: awsKMSLogger,
+				Sink:   awsKMSServer,
+			})
+		}
+	}
+
+	// The secrets API server also receives the auto-auth token as a sink, and
+	// is served on its own unix socket
+	var secretsAPIServer *secretsapi.Server
+	var secretsAPIListener net.Listener
+	if config.SecretsAPI != nil {
+		secretsAPILogger := c.logger.Named("secretsapi")
+		secretsAPIServer, err = secretsapi.NewServer(&secretsapi.ServerConfig{
+			Logger:    secretsAPILogger,
+			Client:    proxyClient,
+			Config:    config.SecretsAPI,
+			Namespace: templateNamespace,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating secrets API server: %v", err))
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: secretsAPILogger,
+			Sink:   secretsAPIServer,
+		})
+
+		secretsAPIListener, err = secretsapi.Listen(config.SecretsAPI.Address)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error starting secrets API listener: %v", err))
+			return 1
+		}
+		defer secretsAPIListener.Close()
+
+		info["secrets api address"] = "unix://" + config.SecretsAPI.Address
+		infoKeys = append(infoKeys, "secrets api address")
+	}
+
+	// The systemd credentials server also receives the auto-auth token as a
+	// sink, and is served on its own unix socket
+	var systemdCredentialsServer *systemdcreds.Server
+	var systemdCredentialsListener net.Listener
+	if config.SystemdCredentials != nil {
+		systemdCredentialsLogger := c.logger.Named("systemdcreds")
+		systemdCredentialsServer, err = systemdcreds.NewServer(&systemdcreds.ServerConfig{
+			Logger: systemdCredentialsLogger,
+			Config: config.SystemdCredentials,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating systemd credentials server: %v", err))
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: systemdCredentialsLogger,
+			Sink:   systemdCredentialsServer,
+		})
+
+		systemdCredentialsListener, err = systemdcreds.Listen(config.SystemdCredentials.Address)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error starting systemd credentials listener: %v", err))
+			return 1
+		}
+		defer systemdCredentialsListener.Close()
+
+		info["systemd credentials address"] = "unix://" + config.SystemdCredentials.Address
+		infoKeys = append(infoKeys, "systemd credentials address")
+	}
+
+	// The KMIP server also receives the auto-auth token as a sink, and is
+	// served on its own TLS listener
+	var kmipServer *kmip.Server
+	var kmipListener net.Listener
+	if config.KMIP != nil {
+		kmipLogger := c.logger.Named("kmip")
+		kmipServer, err = kmip.NewServer(&kmip.ServerConfig{
+			Logger:    kmipLogger,
+			Client:    proxyClient,
+			Config:    config.KMIP,
+			Namespace: templateNamespace,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating KMIP server: %v", err))
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: kmipLogger,
+			Sink:   kmipServer,
+		})
+
+		kmipListener, err = kmip.Listen(config.KMIP)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error starting KMIP listener: %v", err))
+			return 1
+		}
+		defer kmipListener.Close()
+
+		info["kmip address"] = config.KMIP.Address
+		infoKeys = append(infoKeys, "kmip address")
+	}
+
 	var listeners []net.Listener
 
 	// If there are templates, add an in-process listener
@@ -591,6 +735,23 @@ func (c *AgentCommand) Run(args []string) int {
 		if "metrics_only" != lnConfig.Role {
 			mux.Handle(consts.AgentPathCacheClear, leaseCache.HandleCacheClear(ctx))
 			mux.Handle(consts.AgentPathQuit, c.handleQuit(quitEnabled))
+			if awsCredentialsServer != nil {
+				awsCredentialsHandler := awsCredentialsServer.Handler()
+				if lnConfig.RequireRequestHeader {
+					awsCredentialsHandler = verifyRequestHeader(awsCredentialsHandler)
+				}
+				mux.Handle(consts.AgentPathAWSCredentials, awsCredentialsHandler)
+				mux.Handle("/latest/", awsCredentialsHandler)
+			}
+			if awsKMSServer != nil {
+				// The SDKs add a trailing slash to endpoints with a path
+				awsKMSHandler := awsKMSServer.Handler()
+				if lnConfig.RequireRequestHeader {
+					awsKMSHandler = verifyRequestHeader(awsKMSHandler)
+				}
+				mux.Handle(consts.AgentPathAWSKMS, awsKMSHandler)
+				mux.Handle(consts.AgentPathAWSKMS+"/", awsKMSHandler)
+			}
 			mux.Handle("/", muxHandler)
 		}
 
@@ -678,6 +839,9 @@ func (c *AgentCommand) Run(args []string) int {
 		}
 	}, func(error) {})
 
+	// Closed once the templates have been rendered, if there are any
+	var templatesRenderedCh <-chan struct{}
+
 	// Start auto-auth and sink servers
 	if method != nil {
 		enableTemplateTokenCh := len(config.Templates) > 0
@@ -729,6 +893,10 @@ func (c *AgentCommand) Run(args []string) int {
 			ExitAfterAuth: config.ExitAfterAuth,
 		})
 
+		if len(config.Templates) > 0 {
+			templatesRenderedCh = ts.RenderedCh
+		}
+
 		es, err := exec.NewServer(&exec.ServerConfig{
 			AgentConfig: c.config,
 			Namespace:   templateNamespace,
@@ -809,6 +977,31 @@ func (c *AgentCommand) Run(args []string) int {
 
 	}
 
+	if secretsAPIServer != nil {
+		g.Add(func() error {
+			return secretsAPIServer.Run(ctx, secretsAPIListener)
+		}, func(error) {
+			cancelFunc()
+			secretsAPIServer.Stop()
+		})
+	}
+
+	if systemdCredentialsServer != nil {
+		g.Add(func() error {
+			return systemdCredentialsServer.Run(ctx, systemdCredentialsListener)
+		}, func(error) {
+			cancelFunc()
+		})
+	}
+
+	if kmipServer != nil {
+		g.Add(func() error {
+			return kmipServer.Run(ctx, kmipListener)
+		}, func(error) {
+			cancelFunc()
+		})
+	}
+
 	// Server configuration output
 	padding := 24
 	sort.Strings(infoKeys)
@@ -832,8 +1025,26 @@ func (c *AgentCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Notify systemd that the server is ready (if applicable)
-	c.notifySystemd(systemd.SdNotifyReady)
+	// Notify systemd that the server is ready (if applicable). When serving
+	// systemd credentials, wait until they are available, so that the units
+	// ordered after the agent can load them.
+	if systemdCredentialsServer != nil {
+		go func() {
+			for _, ch := range []<-chan struct{}{systemdCredentialsServer.ReadyCh(), templatesRenderedCh} {
+				if ch == nil {
+					continue
+				}
+				select {
+				case <-ch:
+				case <-ctx.Done():
+					return
+				}
+			}
+			c.notifySystemd(systemd.SdNotifyReady)
+		}()
+	} else {
+		c.notifySystemd(systemd.SdNotifyReady)
+	}
 
 	defer func() {
 		if err := c.removePidFile(config.PidFile); err != nil {
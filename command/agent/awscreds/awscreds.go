@@ -0,0 +1,400 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package awscreds serves credentials of the AWS secrets engine to the AWS
+// SDKs, emulating the ECS container credentials endpoint and the credentials
+// of the EC2 instance metadata service, so that applications pick up
+// credentials issued by Vault without code changes.
+package awscreds
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+const (
+	// imdsTokenPath is the path of the IMDSv2 session tokens.
+	imdsTokenPath = "/latest/api/token"
+
+	// imdsCredentialsPath is the path listing the role of the instance
+	// profile, under which its credentials are served.
+	imdsCredentialsPath = "/latest/meta-data/iam/security-credentials/"
+
+	imdsTokenHeader    = "X-aws-ec2-metadata-token"
+	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+
+	// imdsMaxTokenTTL is the maximum lifetime of IMDSv2 session tokens, as
+	// enforced by EC2.
+	imdsMaxTokenTTL = 6 * time.Hour
+
+	// refreshWindow is how long before their expiration credentials are
+	// replaced, so that the SDKs aren't handed credentials about to expire.
+	refreshWindow = 5 * time.Minute
+)
+
+var _ sink.Sink = (*Server)(nil)
+
+type ServerConfig struct {
+	Logger hclog.Logger
+	Client *api.Client
+	Config *config.AWSCredentials
+
+	Namespace string
+}
+
+// Server serves the credentials of the AWS secrets engine path of the config,
+// read with the auto-auth token it receives as a sink.
+type Server struct {
+	config *ServerConfig
+	logger hclog.Logger
+	role   string
+
+	lock       sync.Mutex
+	token      string
+	imdsTokens map[string]time.Time
+
+	// credentialsLock serializes the reads of credentials, so concurrent
+	// requests share the same ones.
+	credentialsLock sync.Mutex
+	credentials     *credentials
+}
+
+// credentials are the AWS credentials read from Vault.
+type credentials struct {
+	accessKey     string
+	secretKey     string
+	securityToken string
+	arn           string
+	issued        time.Time
+	expiration    time.Time
+}
+
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+	if conf.Client == nil {
+		return nil, errors.New("nil client provided")
+	}
+	if conf.Config == nil || conf.Config.Path == "" {
+		return nil, errors.New("no credentials path provided")
+	}
+
+	return &Server{
+		config:     conf,
+		logger:     conf.Logger,
+		role:       path.Base(conf.Config.Path),
+		imdsTokens: make(map[string]time.Time),
+	}, nil
+}
+
+// WriteToken stores the auto-auth token used to read the credentials.
+func (s *Server) WriteToken(token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.token = token
+	return nil
+}
+
+// Handler returns the handler of the credentials endpoints, to be registered
+// at consts.AgentPathAWSCredentials and under /latest/ on the listeners.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(consts.AgentPathAWSCredentials, s.handleContainerCredentials)
+	mux.HandleFunc(imdsTokenPath, s.handleIMDSToken)
+	mux.HandleFunc(imdsCredentialsPath, s.handleIMDSCredentials)
+	return mux
+}
+
+// handleContainerCredentials serves the credentials in the format of the ECS
+// container credentials endpoint, which the SDKs call when
+// AWS_CONTAINER_CREDENTIALS_FULL_URI is set.
+func (s *Server) handleContainerCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	authToken, err := s.authToken()
+	if err != nil {
+		s.logger.Error("failed to read the authorization token", "error", err)
+		http.Error(w, "failed to read the authorization token", http.StatusInternalServerError)
+		return
+	}
+	if authToken == "" {
+		s.logger.Error("the authorization token is empty")
+		http.Error(w, "no authorization token configured", http.StatusInternalServerError)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(authToken)) != 1 {
+		http.Error(w, "invalid authorization token", http.StatusUnauthorized)
+		return
+	}
+
+	creds, ok := s.serveCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"AccessKeyId":     creds.accessKey,
+		"SecretAccessKey": creds.secretKey,
+		"Token":           creds.securityToken,
+		"RoleArn":         creds.arn,
+		"Expiration":      creds.expiration.UTC().Format(time.RFC3339),
+	})
+}
+
+// handleIMDSToken issues IMDSv2 session tokens. Like EC2, requests forwarded
+// by a proxy are refused, so the tokens can't be obtained through server-side
+// request forgery. The SDKs can't send an authorization token to the instance
+// metadata service, so the tokens are only issued to local clients.
+func (s *Server) handleIMDSToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("X-Forwarded-For") != "" || !isLocal(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	seconds, err := strconv.Atoi(r.Header.Get(imdsTokenTTLHeader))
+	ttl := time.Duration(seconds) * time.Second
+	if err != nil || ttl <= 0 || ttl > imdsMaxTokenTTL {
+		http.Error(w, fmt.Sprintf("%s must be between 1 and %d", imdsTokenTTLHeader, int(imdsMaxTokenTTL.Seconds())), http.StatusBadRequest)
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		s.logger.Error("failed to generate metadata token", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	s.lock.Lock()
+	for t, expiration := range s.imdsTokens {
+		if now.After(expiration) {
+			delete(s.imdsTokens, t)
+		}
+	}
+	s.imdsTokens[token] = now.Add(ttl)
+	s.lock.Unlock()
+
+	w.Header().Set(imdsTokenTTLHeader, strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(token))
+}
+
+// handleIMDSCredentials lists the role of the instance profile, and serves its
+// credentials in the format of the instance metadata service. Only IMDSv2 is
+// supported: the requests must carry a session token.
+func (s *Server) handleIMDSCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLocal(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !s.validIMDSToken(r.Header.Get(imdsTokenHeader)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, imdsCredentialsPath) {
+	case "":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(s.role))
+		return
+	case s.role:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	creds, ok := s.serveCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"Code":            "Success",
+		"LastUpdated":     creds.issued.UTC().Format(time.RFC3339),
+		"Type":            "AWS-HMAC",
+		"AccessKeyId":     creds.accessKey,
+		"SecretAccessKey": creds.secretKey,
+		"Token":           creds.securityToken,
+		"Expiration":      creds.expiration.UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) validIMDSToken(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	expiration, ok := s.imdsTokens[token]
+	return ok && time.Now().Before(expiration)
+}
+
+// isLocal returns whether the request comes from a loopback address or a unix
+// socket.
+func isLocal(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// Connections over unix sockets have no remote address
+		return r.RemoteAddr == "" || r.RemoteAddr == "@"
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authToken returns the authorization token the container credentials
+// requests must carry.
+func (s *Server) authToken() (string, error) {
+	if s.config.Config.AuthTokenFile == "" {
+		return s.config.Config.AuthToken, nil
+	}
+
+	token, err := os.ReadFile(s.config.Config.AuthTokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// serveCredentials returns the credentials to serve, writing an error to the
+// response when they can't be read.
+func (s *Server) serveCredentials(w http.ResponseWriter, r *http.Request) (*credentials, bool) {
+	creds, err := s.getCredentials(r.Context())
+	switch {
+	case errors.Is(err, errNoToken):
+		http.Error(w, "the agent has not authenticated yet", http.StatusServiceUnavailable)
+		return nil, false
+	case err != nil:
+		s.logger.Error("failed to read AWS credentials", "path", s.config.Config.Path, "error", err)
+		http.Error(w, "failed to read AWS credentials", http.StatusBadGateway)
+		return nil, false
+	}
+	return creds, true
+}
+
+var errNoToken = errors.New("no auto-auth token")
+
+// getCredentials returns the cached credentials, or reads new ones when they
+// are about to expire.
+func (s *Server) getCredentials(ctx context.Context) (*credentials, error) {
+	s.credentialsLock.Lock()
+	defer s.credentialsLock.Unlock()
+
+	if s.credentials != nil && time.Until(s.credentials.expiration) > refreshWindow {
+		return s.credentials, nil
+	}
+
+	s.lock.Lock()
+	token := s.token
+	s.lock.Unlock()
+	if token == "" {
+		return nil, errNoToken
+	}
+
+	creds, err := s.readCredentials(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Debug("read AWS credentials", "path", s.config.Config.Path, "expiration", creds.expiration)
+	s.credentials = creds
+	return creds, nil
+}
+
+func (s *Server) readCredentials(ctx context.Context, token string) (*credentials, error) {
+	client, err := s.config.Client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	if s.config.Namespace != "" {
+		client.SetNamespace(s.config.Namespace)
+	}
+
+	data := make(map[string][]string)
+	if s.config.Config.RoleARN != "" {
+		data["role_arn"] = []string{s.config.Config.RoleARN}
+	}
+	if s.config.Config.RoleSessionName != "" {
+		data["role_session_name"] = []string{s.config.Config.RoleSessionName}
+	}
+	if s.config.Config.TTL > 0 {
+		data["ttl"] = []string{strconv.Itoa(int(s.config.Config.TTL.Seconds()))}
+	}
+
+	secret, err := client.Logical().ReadWithDataWithContext(ctx, s.config.Config.Path, data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no credentials at %q", s.config.Config.Path)
+	}
+
+	creds := &credentials{
+		issued: time.Now(),
+	}
+	for field, target := range map[string]*string{
+		"access_key":     &creds.accessKey,
+		"secret_key":     &creds.secretKey,
+		"security_token": &creds.securityToken,
+		"arn":            &creds.arn,
+	} {
+		if v, ok := secret.Data[field].(string); ok {
+			*target = v
+		}
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return nil, fmt.Errorf("no AWS credentials at %q", s.config.Config.Path)
+	}
+
+	// STS credentials report their lifetime in the data, others only have a
+	// lease.
+	ttl := time.Duration(secret.LeaseDuration) * time.Second
+	if raw, ok := secret.Data["ttl"].(json.Number); ok {
+		if seconds, err := raw.Int64(); err == nil && seconds > 0 {
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+	creds.expiration = creds.issued.Add(ttl)
+
+	return creds, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
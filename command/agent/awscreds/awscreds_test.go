@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package awscreds
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer returns a credentials server backed by a fake Vault serving STS
+// credentials with the given TTL at aws/sts/my-role, and the number of reads
+// of the credentials.
+func testServer(t *testing.T, ttl time.Duration, conf *config.AWSCredentials) (*Server, *int32) {
+	t.Helper()
+
+	reads := new(int32)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/aws/sts/my-role" || r.Header.Get(consts.AuthHeaderName) != "auto-auth-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("role_arn") != conf.RoleARN {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := atomic.AddInt32(reads, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_duration": int(ttl.Seconds()),
+			"data": map[string]interface{}{
+				"access_key":     "AKIA" + string(rune('0'+n)),
+				"secret_key":     "secret",
+				"security_token": "session",
+				"arn":            "arn:aws:sts::123456789012:assumed-role/my-role/vault",
+				"ttl":            int(ttl.Seconds()),
+			},
+		})
+	}))
+	t.Cleanup(vault.Close)
+
+	client, err := api.NewClient(&api.Config{Address: vault.URL})
+	require.NoError(t, err)
+
+	conf.Path = "aws/sts/my-role"
+	s, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Client: client,
+		Config: conf,
+	})
+	require.NoError(t, err)
+	return s, reads
+}
+
+func testRequest(t *testing.T, h http.Handler, method, path string, headers map[string]string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "127.0.0.1:41234"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return w.Code, string(body)
+}
+
+// TestServer_ContainerCredentials ensures the credentials are served in the
+// format of the ECS container credentials endpoint once the agent has
+// authenticated, to the requests carrying the authorization token, and are
+// cached until they are about to expire.
+func TestServer_ContainerCredentials(t *testing.T) {
+	s, reads := testServer(t, time.Hour, &config.AWSCredentials{
+		RoleARN:   "arn:aws:iam::123456789012:role/my-role",
+		AuthToken: "secret-token",
+	})
+	h := s.Handler()
+	auth := map[string]string{"Authorization": "secret-token"}
+
+	code, _ := testRequest(t, h, http.MethodGet, consts.AgentPathAWSCredentials, auth)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+
+	code, _ = testRequest(t, h, http.MethodGet, consts.AgentPathAWSCredentials, map[string]string{"Authorization": "wrong"})
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = testRequest(t, h, http.MethodGet, consts.AgentPathAWSCredentials, nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	for i := 0; i < 2; i++ {
+		code, body := testRequest(t, h, http.MethodGet, consts.AgentPathAWSCredentials, auth)
+		require.Equal(t, http.StatusOK, code)
+
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		require.Equal(t, "AKIA1", resp["AccessKeyId"])
+		require.Equal(t, "secret", resp["SecretAccessKey"])
+		require.Equal(t, "session", resp["Token"])
+		expiration, err := time.Parse(time.RFC3339, resp["Expiration"])
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), expiration, time.Minute)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(reads))
+}
+
+// TestServer_ContainerCredentials_Refresh ensures credentials about to expire
+// are replaced.
+func TestServer_ContainerCredentials_Refresh(t *testing.T) {
+	s, reads := testServer(t, time.Minute, &config.AWSCredentials{AuthToken: "secret-token"})
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	h := s.Handler()
+
+	for i := 0; i < 2; i++ {
+		code, _ := testRequest(t, h, http.MethodGet, consts.AgentPathAWSCredentials, map[string]string{"Authorization": "secret-token"})
+		require.Equal(t, http.StatusOK, code)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(reads))
+}
+
+// TestServer_IMDS ensures the credentials are served in the format of the
+// instance metadata service to the requests carrying an IMDSv2 session token.
+func TestServer_IMDS(t *testing.T) {
+	s, _ := testServer(t, time.Hour, &config.AWSCredentials{})
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	h := s.Handler()
+
+	code, _ := testRequest(t, h, http.MethodGet, imdsCredentialsPath, nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = testRequest(t, h, http.MethodGet, imdsCredentialsPath, map[string]string{imdsTokenHeader: "forged"})
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = testRequest(t, h, http.MethodPut, imdsTokenPath, nil)
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = testRequest(t, h, http.MethodPut, imdsTokenPath, map[string]string{imdsTokenTTLHeader: "60", "X-Forwarded-For": "10.0.0.1"})
+	require.Equal(t, http.StatusForbidden, code)
+
+	code, token := testRequest(t, h, http.MethodPut, imdsTokenPath, map[string]string{imdsTokenTTLHeader: "60"})
+	require.Equal(t, http.StatusOK, code)
+	headers := map[string]string{imdsTokenHeader: token}
+
+	code, role := testRequest(t, h, http.MethodGet, imdsCredentialsPath, headers)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "my-role", role)
+
+	code, _ = testRequest(t, h, http.MethodGet, imdsCredentialsPath+"other-role", headers)
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, body := testRequest(t, h, http.MethodGet, imdsCredentialsPath+role, headers)
+	require.Equal(t, http.StatusOK, code)
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Equal(t, "Success", resp["Code"])
+	require.Equal(t, "AWS-HMAC", resp["Type"])
+	require.Equal(t, "AKIA1", resp["AccessKeyId"])
+	require.Equal(t, "session", resp["Token"])
+}
+
+// TestServer_IMDS_Remote ensures the instance metadata endpoints refuse
+// clients which aren't local, as they can't carry an authorization token.
+func TestServer_IMDS_Remote(t *testing.T) {
+	s, reads := testServer(t, time.Hour, &config.AWSCredentials{})
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodPut, imdsTokenPath, nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	req.Header.Set(imdsTokenTTLHeader, "60")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	code, token := testRequest(t, h, http.MethodPut, imdsTokenPath, map[string]string{imdsTokenTTLHeader: "60"})
+	require.Equal(t, http.StatusOK, code)
+
+	req = httptest.NewRequest(http.MethodGet, imdsCredentialsPath+"my-role", nil)
+	req.RemoteAddr = "10.0.0.1:41234"
+	req.Header.Set(imdsTokenHeader, token)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, int32(0), atomic.LoadInt32(reads))
+}
@@ -0,0 +1,476 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package awskms serves the Encrypt, Decrypt and GenerateDataKey operations
+// of the AWS KMS API with the keys of a transit mount, so that applications
+// written against the AWS SDKs can use keys kept in Vault by pointing the
+// endpoint of their KMS client at the agent.
+//
+// The requests are performed with the Vault token of their X-Vault-Token
+// header or, without one, with the auto-auth token the server receives as a
+// sink. Those requests must be signed with the configured access key, and are
+// refused when there is none. The key IDs are the names of the transit keys, and the ciphertext blobs
+// are the transit ciphertexts prefixed by the name of their key.
+package awskms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+const (
+	// targetPrefix is the prefix of the X-Amz-Target header of the KMS
+	// operations.
+	targetPrefix = "TrentService."
+
+	// maxRequestSize bounds the size of the requests. KMS accepts at most
+	// 4 KiB of plaintext and 6 KiB of ciphertext.
+	maxRequestSize = 64 * 1024
+
+	// maxDataKeySize is the maximum size of the data keys, as in KMS.
+	maxDataKeySize = 1024
+
+	contentType = "application/x-amz-json-1.1"
+
+	algorithmSymmetricDefault = "SYMMETRIC_DEFAULT"
+)
+
+var _ sink.Sink = (*Server)(nil)
+
+type ServerConfig struct {
+	Logger hclog.Logger
+	Client *api.Client
+	Config *config.AWSKMS
+
+	Namespace string
+}
+
+// Server serves the KMS operations with the keys of the transit mount of the
+// config.
+type Server struct {
+	config *ServerConfig
+	logger hclog.Logger
+
+	lock  sync.RWMutex
+	token string
+}
+
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+	if conf.Client == nil {
+		return nil, errors.New("nil client provided")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("nil config provided")
+	}
+
+	return &Server{
+		config: conf,
+		logger: conf.Logger,
+	}, nil
+}
+
+// WriteToken stores the auto-auth token used by the requests without a Vault
+// token.
+func (s *Server) WriteToken(token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.token = token
+	return nil
+}
+
+// Handler returns the handler of the KMS operations, to be registered at
+// consts.AgentPathAWSKMS on the listeners.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(s.handle)
+}
+
+// kmsError is an error in the format of the KMS API.
+type kmsError struct {
+	status  int
+	errType string
+	message string
+}
+
+func (e *kmsError) Error() string {
+	return e.errType + ": " + e.message
+}
+
+func newError(status int, errType string, format string, args ...interface{}) *kmsError {
+	return &kmsError{
+		status:  status,
+		errType: errType,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		writeError(w, newError(http.StatusBadRequest, "ValidationException", "failed to read the request: %s", err))
+		return
+	}
+
+	client, kerr := s.client(r, body)
+	if kerr != nil {
+		writeError(w, kerr)
+		return
+	}
+
+	var resp interface{}
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+	switch operation {
+	case "Encrypt":
+		resp, kerr = s.encrypt(r.Context(), client, body)
+	case "Decrypt":
+		resp, kerr = s.decrypt(r.Context(), client, body)
+	case "GenerateDataKey":
+		resp, kerr = s.generateDataKey(r.Context(), client, body, true)
+	case "GenerateDataKeyWithoutPlaintext":
+		resp, kerr = s.generateDataKey(r.Context(), client, body, false)
+	default:
+		kerr = newError(http.StatusBadRequest, "UnknownOperationException", "operation %q is not supported", operation)
+	}
+	if kerr != nil {
+		if kerr.status >= http.StatusInternalServerError {
+			s.logger.Error("KMS operation failed", "operation", operation, "error", kerr)
+		} else {
+			s.logger.Debug("KMS operation failed", "operation", operation, "error", kerr)
+		}
+		writeError(w, kerr)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, kerr *kmsError) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Amzn-ErrorType", kerr.errType)
+	w.WriteHeader(kerr.status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"__type":  kerr.errType,
+		"message": kerr.message,
+	})
+}
+
+// client returns a client of transit with the Vault token of the request, or
+// with the auto-auth token once the signature of the request is verified.
+func (s *Server) client(r *http.Request, body []byte) (*api.Client, *kmsError) {
+	token := r.Header.Get(consts.AuthHeaderName)
+	if token == "" {
+		if s.config.Config.AccessKeyID == "" {
+			return nil, newError(http.StatusForbidden, "MissingAuthenticationTokenException", "the request has no Vault token and no access key is configured to verify its signature")
+		}
+		err := verifySignature(r, body, s.config.Config.AccessKeyID, s.config.Config.SecretAccessKey, time.Now())
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "InvalidSignatureException", "%s", err)
+		}
+
+		s.lock.RLock()
+		token = s.token
+		s.lock.RUnlock()
+		if token == "" {
+			return nil, newError(http.StatusServiceUnavailable, "DependencyTimeoutException", "the request has no Vault token and the agent has not authenticated yet")
+		}
+	}
+
+	client, err := s.config.Client.CloneWithHeaders()
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, "KMSInternalException", "%s", err)
+	}
+	client.SetToken(token)
+	if namespace := r.Header.Get(consts.NamespaceHeaderName); namespace != "" {
+		client.SetNamespace(namespace)
+	} else if s.config.Namespace != "" {
+		client.SetNamespace(s.config.Namespace)
+	}
+	return client, nil
+}
+
+type encryptRequest struct {
+	KeyID               string            `json:"KeyId"`
+	Plaintext           []byte            `json:"Plaintext"`
+	EncryptionContext   map[string]string `json:"EncryptionContext"`
+	EncryptionAlgorithm string            `json:"EncryptionAlgorithm"`
+}
+
+type encryptResponse struct {
+	CiphertextBlob      []byte `json:"CiphertextBlob"`
+	KeyID               string `json:"KeyId"`
+	EncryptionAlgorithm string `json:"EncryptionAlgorithm"`
+}
+
+type decryptRequest struct {
+	CiphertextBlob      []byte            `json:"CiphertextBlob"`
+	KeyID               string            `json:"KeyId"`
+	EncryptionContext   map[string]string `json:"EncryptionContext"`
+	EncryptionAlgorithm string            `json:"EncryptionAlgorithm"`
+}
+
+type decryptResponse struct {
+	KeyID               string `json:"KeyId"`
+	Plaintext           []byte `json:"Plaintext"`
+	EncryptionAlgorithm string `json:"EncryptionAlgorithm"`
+}
+
+type generateDataKeyRequest struct {
+	KeyID             string            `json:"KeyId"`
+	KeySpec           string            `json:"KeySpec"`
+	NumberOfBytes     int               `json:"NumberOfBytes"`
+	EncryptionContext map[string]string `json:"EncryptionContext"`
+}
+
+type generateDataKeyResponse struct {
+	CiphertextBlob []byte `json:"CiphertextBlob"`
+	Plaintext      []byte `json:"Plaintext,omitempty"`
+	KeyID          string `json:"KeyId"`
+}
+
+func decode(body []byte, v interface{}) *kmsError {
+	if err := json.Unmarshal(body, v); err != nil {
+		return newError(http.StatusBadRequest, "SerializationException", "invalid request: %s", err)
+	}
+	return nil
+}
+
+func validateAlgorithm(algorithm string) *kmsError {
+	if algorithm != "" && algorithm != algorithmSymmetricDefault {
+		return newError(http.StatusBadRequest, "ValidationException", "only the %s encryption algorithm is supported", algorithmSymmetricDefault)
+	}
+	return nil
+}
+
+func (s *Server) encrypt(ctx context.Context, client *api.Client, body []byte) (interface{}, *kmsError) {
+	var req encryptRequest
+	if kerr := decode(body, &req); kerr != nil {
+		return nil, kerr
+	}
+	if kerr := validateAlgorithm(req.EncryptionAlgorithm); kerr != nil {
+		return nil, kerr
+	}
+	if len(req.Plaintext) == 0 || len(req.Plaintext) > 4096 {
+		return nil, newError(http.StatusBadRequest, "ValidationException", "the plaintext must be between 1 and 4096 bytes")
+	}
+
+	name, blob, kerr := s.encryptWithKey(ctx, client, req.KeyID, req.Plaintext, req.EncryptionContext)
+	if kerr != nil {
+		return nil, kerr
+	}
+	return &encryptResponse{
+		CiphertextBlob:      blob,
+		KeyID:               name,
+		EncryptionAlgorithm: algorithmSymmetricDefault,
+	}, nil
+}
+
+func (s *Server) decrypt(ctx context.Context, client *api.Client, body []byte) (interface{}, *kmsError) {
+	var req decryptRequest
+	if kerr := decode(body, &req); kerr != nil {
+		return nil, kerr
+	}
+	if kerr := validateAlgorithm(req.EncryptionAlgorithm); kerr != nil {
+		return nil, kerr
+	}
+
+	// The blobs name their key, which the request may name too
+	blob := string(req.CiphertextBlob)
+	i := strings.LastIndex(blob, ":vault:v")
+	if i <= 0 {
+		return nil, newError(http.StatusBadRequest, "InvalidCiphertextException", "the ciphertext blob is invalid")
+	}
+	name, ciphertext := blob[:i], blob[i+1:]
+	if req.KeyID != "" {
+		requested, kerr := keyName(req.KeyID)
+		if kerr != nil {
+			return nil, kerr
+		}
+		if requested != name {
+			return nil, newError(http.StatusBadRequest, "IncorrectKeyException", "the ciphertext blob was not encrypted with key %q", requested)
+		}
+	}
+	if kerr := s.checkKey(ctx, client, name); kerr != nil {
+		return nil, kerr
+	}
+
+	data := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	if aad := associatedData(req.EncryptionContext); aad != "" {
+		data["associated_data"] = aad
+	}
+	secret, err := client.Logical().WriteWithContext(ctx, s.config.Config.Mount+"/decrypt/"+name, data)
+	if err != nil {
+		return nil, transitError(err, "InvalidCiphertextException")
+	}
+	var encoded string
+	if secret != nil && secret.Data != nil {
+		encoded, _ = secret.Data["plaintext"].(string)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, "KMSInternalException", "transit returned an invalid plaintext")
+	}
+
+	return &decryptResponse{
+		KeyID:               name,
+		Plaintext:           plaintext,
+		EncryptionAlgorithm: algorithmSymmetricDefault,
+	}, nil
+}
+
+// generateDataKey returns a random data key encrypted with the key, and in
+// plaintext if withPlaintext is set.
+func (s *Server) generateDataKey(ctx context.Context, client *api.Client, body []byte, withPlaintext bool) (interface{}, *kmsError) {
+	var req generateDataKeyRequest
+	if kerr := decode(body, &req); kerr != nil {
+		return nil, kerr
+	}
+
+	size := req.NumberOfBytes
+	switch {
+	case req.KeySpec != "" && size != 0:
+		return nil, newError(http.StatusBadRequest, "ValidationException", "only one of KeySpec and NumberOfBytes can be specified")
+	case req.KeySpec == "AES_128":
+		size = 16
+	case req.KeySpec == "AES_256":
+		size = 32
+	case req.KeySpec != "":
+		return nil, newError(http.StatusBadRequest, "ValidationException", "invalid KeySpec %q", req.KeySpec)
+	case size < 1 || size > maxDataKeySize:
+		return nil, newError(http.StatusBadRequest, "ValidationException", "NumberOfBytes must be between 1 and %d", maxDataKeySize)
+	}
+
+	dataKey := make([]byte, size)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, newError(http.StatusInternalServerError, "KMSInternalException", "failed to generate the data key: %s", err)
+	}
+
+	name, blob, kerr := s.encryptWithKey(ctx, client, req.KeyID, dataKey, req.EncryptionContext)
+	if kerr != nil {
+		return nil, kerr
+	}
+	resp := &generateDataKeyResponse{
+		CiphertextBlob: blob,
+		KeyID:          name,
+	}
+	if withPlaintext {
+		resp.Plaintext = dataKey
+	}
+	return resp, nil
+}
+
+// encryptWithKey encrypts the plaintext with the key of the ID, and returns
+// the name of the key and the ciphertext blob.
+func (s *Server) encryptWithKey(ctx context.Context, client *api.Client, keyID string, plaintext []byte, encryptionContext map[string]string) (string, []byte, *kmsError) {
+	name, kerr := keyName(keyID)
+	if kerr != nil {
+		return "", nil, kerr
+	}
+	if kerr := s.checkKey(ctx, client, name); kerr != nil {
+		return "", nil, kerr
+	}
+
+	data := map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	}
+	if aad := associatedData(encryptionContext); aad != "" {
+		data["associated_data"] = aad
+	}
+	secret, err := client.Logical().WriteWithContext(ctx, s.config.Config.Mount+"/encrypt/"+name, data)
+	if err != nil {
+		return "", nil, transitError(err, "ValidationException")
+	}
+	var ciphertext string
+	if secret != nil && secret.Data != nil {
+		ciphertext, _ = secret.Data["ciphertext"].(string)
+	}
+	if ciphertext == "" {
+		return "", nil, newError(http.StatusInternalServerError, "KMSInternalException", "transit returned no ciphertext")
+	}
+
+	return name, []byte(name + ":" + ciphertext), nil
+}
+
+// keyName returns the name of the transit key of a key ID, which can be the
+// name, an alias or an ARN.
+func keyName(keyID string) (string, *kmsError) {
+	name := keyID
+	if strings.HasPrefix(name, "arn:") {
+		name = name[strings.LastIndex(name, ":")+1:]
+		name = strings.TrimPrefix(name, "key/")
+	}
+	name = strings.TrimPrefix(name, "alias/")
+	if name == "" || strings.Contains(name, "/") {
+		return "", newError(http.StatusBadRequest, "NotFoundException", "key %q not found", keyID)
+	}
+	return name, nil
+}
+
+// checkKey ensures the key exists and supports encryption, as transit would
+// create a missing key on encryption.
+func (s *Server) checkKey(ctx context.Context, client *api.Client, name string) *kmsError {
+	secret, err := client.Logical().ReadWithContext(ctx, s.config.Config.Mount+"/keys/"+name)
+	if err != nil {
+		return transitError(err, "ValidationException")
+	}
+	if secret == nil || secret.Data == nil {
+		return newError(http.StatusBadRequest, "NotFoundException", "key %q not found", name)
+	}
+	if supported, _ := secret.Data["supports_encryption"].(bool); !supported {
+		return newError(http.StatusBadRequest, "InvalidKeyUsageException", "key %q does not support encryption", name)
+	}
+	return nil
+}
+
+// associatedData returns the encryption context serialized as the associated
+// data of transit, or "" if it is empty. The keys of the context are sorted,
+// so its order doesn't matter.
+func associatedData(encryptionContext map[string]string) string {
+	if len(encryptionContext) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(encryptionContext)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// transitError returns the KMS error for an error of a transit request: the
+// invalid error type for requests rejected because of their data.
+func transitError(err error, invalid string) *kmsError {
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusBadRequest:
+			return newError(http.StatusBadRequest, invalid, "%s", strings.Join(respErr.Errors, "; "))
+		case http.StatusForbidden:
+			return newError(http.StatusBadRequest, "AccessDeniedException", "permission denied")
+		case http.StatusNotFound:
+			return newError(http.StatusBadRequest, "NotFoundException", "not found")
+		}
+	}
+	return newError(http.StatusInternalServerError, "KMSInternalException", "transit request failed: %s", err)
+}
@@ -0,0 +1,251 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package awskms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/hashicorp/go-hclog"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	"github.com/hashicorp/vault/command/agent/config"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer serves the KMS operations with the transit mount of a test
+// cluster, which has the AES key "app" and the signing key "signing". It
+// returns the server, the client of the cluster with the root token, and the
+// endpoint of the KMS operations.
+func testServer(t *testing.T, conf *config.AWSKMS) (*Server, *vaultapi.Client, string) {
+	t.Helper()
+
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	cluster.Start()
+	t.Cleanup(cluster.Cleanup)
+
+	client := cluster.Cores[0].Client
+	require.NoError(t, client.Sys().Mount("transit-kms", &vaultapi.MountInput{Type: "transit"}))
+	for name, keyType := range map[string]string{"app": "aes256-gcm96", "signing": "ed25519"} {
+		_, err := client.Logical().Write("transit-kms/keys/"+name, map[string]interface{}{
+			"type": keyType,
+		})
+		require.NoError(t, err)
+	}
+
+	conf.Mount = "transit-kms"
+	s, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Client: client,
+		Config: conf,
+	})
+	require.NoError(t, err)
+
+	// The handler is registered the way the agent does
+	mux := http.NewServeMux()
+	mux.Handle(consts.AgentPathAWSKMS, s.Handler())
+	mux.Handle(consts.AgentPathAWSKMS+"/", s.Handler())
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return s, client, srv.URL + consts.AgentPathAWSKMS
+}
+
+// testKMS returns a KMS client of the AWS SDK for the endpoint, signing the
+// requests with the access key, and adding the Vault token to them if set.
+func testKMS(t *testing.T, endpoint, accessKeyID, secretAccessKey, vaultToken string) *kms.KMS {
+	t.Helper()
+
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials(accessKeyID, secretAccessKey, ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+
+	client := kms.New(sess)
+	if vaultToken != "" {
+		client.Handlers.Build.PushBack(func(r *request.Request) {
+			r.HTTPRequest.Header.Set(consts.AuthHeaderName, vaultToken)
+		})
+	}
+	return client
+}
+
+func requireErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var awsErr awserr.Error
+	require.ErrorAs(t, err, &awsErr)
+	require.Equal(t, code, awsErr.Code(), awsErr.Message())
+}
+
+// TestServer_SDK ensures the operations are served to the AWS SDK, with the
+// signed requests performed with the auto-auth token.
+func TestServer_SDK(t *testing.T) {
+	s, client, endpoint := testServer(t, &config.AWSKMS{
+		AccessKeyID:     "AKIAVAULTAGENT",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, s.WriteToken(client.Token()))
+	svc := testKMS(t, endpoint, "AKIAVAULTAGENT", "secret", "")
+
+	encryptionContext := map[string]*string{
+		"tenant": aws.String("a"),
+		"app":    aws.String("billing"),
+	}
+	encrypted, err := svc.Encrypt(&kms.EncryptInput{
+		KeyId:             aws.String("alias/app"),
+		Plaintext:         []byte("secret data"),
+		EncryptionContext: encryptionContext,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "app", aws.StringValue(encrypted.KeyId))
+
+	decrypted, err := svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob:    encrypted.CiphertextBlob,
+		EncryptionContext: encryptionContext,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret data"), decrypted.Plaintext)
+	require.Equal(t, "app", aws.StringValue(decrypted.KeyId))
+
+	// The encryption context authenticates the ciphertext
+	_, err = svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: encrypted.CiphertextBlob,
+	})
+	requireErrorCode(t, err, "InvalidCiphertextException")
+
+	_, err = svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob:    encrypted.CiphertextBlob,
+		EncryptionContext: encryptionContext,
+		KeyId:             aws.String("signing"),
+	})
+	requireErrorCode(t, err, "IncorrectKeyException")
+
+	dataKey, err := svc.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:   aws.String("arn:aws:kms:us-east-1:123456789012:key/app"),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	require.NoError(t, err)
+	require.Len(t, dataKey.Plaintext, 32)
+
+	decrypted, err = svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: dataKey.CiphertextBlob,
+	})
+	require.NoError(t, err)
+	require.Equal(t, dataKey.Plaintext, decrypted.Plaintext)
+
+	withoutPlaintext, err := svc.GenerateDataKeyWithoutPlaintext(&kms.GenerateDataKeyWithoutPlaintextInput{
+		KeyId:         aws.String("app"),
+		NumberOfBytes: aws.Int64(64),
+	})
+	require.NoError(t, err)
+	decrypted, err = svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: withoutPlaintext.CiphertextBlob,
+	})
+	require.NoError(t, err)
+	require.Len(t, decrypted.Plaintext, 64)
+
+	// Missing keys are not created by the encryption
+	_, err = svc.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String("missing"),
+		Plaintext: []byte("secret data"),
+	})
+	requireErrorCode(t, err, "NotFoundException")
+
+	_, err = svc.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String("signing"),
+		Plaintext: []byte("secret data"),
+	})
+	requireErrorCode(t, err, "InvalidKeyUsageException")
+
+	_, err = svc.ListKeys(&kms.ListKeysInput{})
+	requireErrorCode(t, err, "UnknownOperationException")
+}
+
+// TestServer_Signature ensures the requests without a Vault token must be
+// signed with the access key, and wait for the auto-auth token.
+func TestServer_Signature(t *testing.T) {
+	s, client, endpoint := testServer(t, &config.AWSKMS{
+		AccessKeyID:     "AKIAVAULTAGENT",
+		SecretAccessKey: "secret",
+	})
+
+	input := &kms.EncryptInput{
+		KeyId:     aws.String("app"),
+		Plaintext: []byte("secret data"),
+	}
+	_, err := testKMS(t, endpoint, "AKIAVAULTAGENT", "secret", "").Encrypt(input)
+	requireErrorCode(t, err, "DependencyTimeoutException")
+
+	require.NoError(t, s.WriteToken(client.Token()))
+	_, err = testKMS(t, endpoint, "AKIAVAULTAGENT", "wrong", "").Encrypt(input)
+	requireErrorCode(t, err, "InvalidSignatureException")
+	_, err = testKMS(t, endpoint, "AKIAOTHER", "secret", "").Encrypt(input)
+	requireErrorCode(t, err, "InvalidSignatureException")
+
+	_, err = testKMS(t, endpoint, "AKIAVAULTAGENT", "secret", "").Encrypt(input)
+	require.NoError(t, err)
+}
+
+// TestServer_VaultToken ensures the requests with a Vault token are performed
+// with it, whatever their signature.
+func TestServer_VaultToken(t *testing.T) {
+	s, client, endpoint := testServer(t, &config.AWSKMS{})
+
+	require.NoError(t, client.Sys().PutPolicy("kms-encrypt", `
+path "transit-kms/keys/app" {
+  capabilities = ["read"]
+}
+
+path "transit-kms/encrypt/app" {
+  capabilities = ["update"]
+}
+`))
+	secret, err := client.Auth().Token().Create(&vaultapi.TokenCreateRequest{
+		Policies: []string{"kms-encrypt"},
+	})
+	require.NoError(t, err)
+	svc := testKMS(t, endpoint, "AKIAANY", "any", secret.Auth.ClientToken)
+
+	encrypted, err := svc.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String("app"),
+		Plaintext: []byte("secret data"),
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: encrypted.CiphertextBlob,
+	})
+	requireErrorCode(t, err, "AccessDeniedException")
+
+	// Without a Vault token nor an access key to verify the signature with,
+	// the requests are refused even once there is an auto-auth token
+	require.NoError(t, s.WriteToken(client.Token()))
+	_, err = testKMS(t, endpoint, "AKIAANY", "any", "").Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String("app"),
+		Plaintext: []byte("secret data"),
+	})
+	requireErrorCode(t, err, "MissingAuthenticationTokenException")
+}
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package awskms
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	sigV4Service   = "kms"
+	amzDateFormat  = "20060102T150405Z"
+
+	// maxClockSkew bounds the difference between the time of a signature and
+	// the time of the agent, as AWS does.
+	maxClockSkew = 15 * time.Minute
+)
+
+// verifySignature verifies the SigV4 signature of a request to KMS made with
+// the access key.
+func verifySignature(r *http.Request, body []byte, accessKeyID, secretAccessKey string, now time.Time) error {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, sigV4Algorithm+" ") {
+		return errors.New("the request is not signed with SigV4")
+	}
+	params := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, sigV4Algorithm+" "), ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[k] = v
+		}
+	}
+	if params["Credential"] == "" || params["SignedHeaders"] == "" || params["Signature"] == "" {
+		return errors.New("incomplete signature")
+	}
+
+	// The credential is access-key/date/region/service/aws4_request
+	credential := strings.Split(params["Credential"], "/")
+	if len(credential) != 5 || credential[3] != sigV4Service || credential[4] != "aws4_request" {
+		return errors.New("invalid credential scope")
+	}
+	if subtle.ConstantTimeCompare([]byte(credential[0]), []byte(accessKeyID)) != 1 {
+		return errors.New("unknown access key")
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	date, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		return errors.New("invalid X-Amz-Date header")
+	}
+	if skew := now.Sub(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return errors.New("the signature has expired")
+	}
+	if credential[1] != amzDate[:8] {
+		return errors.New("the date of the credential scope does not match X-Amz-Date")
+	}
+
+	signedHeaders := strings.Split(params["SignedHeaders"], ";")
+	hostSigned := false
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		var values []string
+		if name == "host" {
+			hostSigned = true
+			values = []string{r.Host}
+		} else {
+			values = r.Header.Values(name)
+		}
+		for i, v := range values {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", name, strings.Join(values, ","))
+	}
+	if !hostSigned {
+		return errors.New("the host header must be signed")
+	}
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		escape(r.URL.EscapedPath(), false),
+		canonicalQuery(r.URL.Query()),
+		headers.String(),
+		params["SignedHeaders"],
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join(credential[1:], "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range credential[1:] {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if !hmac.Equal([]byte(signature), []byte(params["Signature"])) {
+		return errors.New("the signature does not match")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the query parameters sorted and escaped.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for k, values := range query {
+		for _, v := range values {
+			params = append(params, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// escape escapes the characters which are not unreserved, and the slashes if
+// escapeSlash is set, the way SigV4 does.
+func escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~',
+			c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
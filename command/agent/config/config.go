@@ -51,6 +51,11 @@ type Config struct {
 	DisableKeepAlivesAutoAuth   bool                       `hcl:"-"`
 	Exec                        *ExecConfig                `hcl:"exec,optional"`
 	EnvTemplates                []*ctconfig.TemplateConfig `hcl:"env_template,optional"`
+	AWSCredentials              *AWSCredentials            `hcl:"aws_credentials"`
+	SecretsAPI                  *SecretsAPI                `hcl:"secrets_api"`
+	SystemdCredentials          *SystemdCredentials        `hcl:"systemd_credentials"`
+	KMIP                        *KMIP                      `hcl:"kmip"`
+	AWSKMS                      *AWSKMS                    `hcl:"aws_kms"`
 }
 
 const (
@@ -122,6 +127,75 @@ type Cache struct {
 	InProcDialer        transportDialer                 `hcl:"-"`
 }
 
+// AWSCredentials contains the configuration of the AWS credentials endpoints,
+// serving credentials from the AWS secrets engine to the AWS SDKs the way the
+// ECS container credentials and the EC2 instance metadata services do
+type AWSCredentials struct {
+	Path            string        `hcl:"path"`
+	RoleARN         string        `hcl:"role_arn"`
+	RoleSessionName string        `hcl:"role_session_name"`
+	TTLRaw          interface{}   `hcl:"ttl"`
+	TTL             time.Duration `hcl:"-"`
+	AuthToken       string        `hcl:"auth_token"`
+	AuthTokenFile   string        `hcl:"auth_token_file"`
+}
+
+// SecretsAPI contains the configuration of the gRPC API serving secrets to
+// the applications connecting to its unix socket
+type SecretsAPI struct {
+	Address            string              `hcl:"address"`
+	RefreshIntervalRaw interface{}         `hcl:"refresh_interval"`
+	RefreshInterval    time.Duration       `hcl:"-"`
+	Secrets            []*SecretsAPISecret `hcl:"-"`
+}
+
+// SecretsAPISecret is a secret served by the secrets API, to the processes
+// running with one of the allowed user or group IDs
+type SecretsAPISecret struct {
+	Name        string `hcl:"-"`
+	Path        string `hcl:"path"`
+	AllowedUIDs []int  `hcl:"allowed_uids"`
+	AllowedGIDs []int  `hcl:"allowed_gids"`
+}
+
+// SystemdCredentials contains the configuration of the server of the
+// credentials systemd units load with LoadCredential=, from the files
+// templates render in its directory or from the auto-auth token
+type SystemdCredentials struct {
+	Address     string               `hcl:"address"`
+	Directory   string               `hcl:"directory"`
+	Credentials []*SystemdCredential `hcl:"-"`
+}
+
+// SystemdCredential is a credential served to the allowed units, or to any
+// unit if none are
+type SystemdCredential struct {
+	Name          string   `hcl:"-"`
+	AutoAuthToken bool     `hcl:"auto_auth_token"`
+	AllowedUnits  []string `hcl:"allowed_units"`
+}
+
+// KMIP contains the configuration of the KMIP server giving the clients
+// authenticated by their TLS certificates access to the AES keys of a
+// transit mount
+type KMIP struct {
+	Address         string `hcl:"address"`
+	Mount           string `hcl:"mount"`
+	TLSCertFile     string `hcl:"tls_cert_file"`
+	TLSKeyFile      string `hcl:"tls_key_file"`
+	TLSClientCAFile string `hcl:"tls_client_ca_file"`
+}
+
+// AWSKMS contains the configuration of the endpoint serving the Encrypt,
+// Decrypt and GenerateDataKey operations of the AWS KMS API with the keys of
+// a transit mount. The requests without a Vault token must be signed with the
+// access key, which is required along with auto_auth
+type AWSKMS struct {
+	Mount           string `hcl:"mount"`
+	AccessKeyID     string `hcl:"access_key_id"`
+	SecretAccessKey string `hcl:"secret_access_key"`
+}
+
 // AutoAuth is the configured authentication method and sinks
 type AutoAuth struct {
 	Method *Method `hcl:"-"`
@@ -277,6 +351,31 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.Exec = c2.Exec
 	}
 
+	result.AWSCredentials = c.AWSCredentials
+	if c2.AWSCredentials != nil {
+		result.AWSCredentials = c2.AWSCredentials
+	}
+
+	result.SecretsAPI = c.SecretsAPI
+	if c2.SecretsAPI != nil {
+		result.SecretsAPI = c2.SecretsAPI
+	}
+
+	result.SystemdCredentials = c.SystemdCredentials
+	if c2.SystemdCredentials != nil {
+		result.SystemdCredentials = c2.SystemdCredentials
+	}
+
+	result.KMIP = c.KMIP
+	if c2.KMIP != nil {
+		result.KMIP = c2.KMIP
+	}
+
+	result.AWSKMS = c.AWSKMS
+	if c2.AWSKMS != nil {
+		result.AWSKMS = c2.AWSKMS
+	}
+
 	for _, envTmpl := range c.EnvTemplates {
 		result.EnvTemplates = append(result.EnvTemplates, envTmpl)
 	}
@@ -342,12 +441,71 @@ func (c *Config) ValidateConfig() error {
 		}
 	}
 
+	if c.AWSCredentials != nil {
+		if !c.IsDefaultListerDefined() {
+			return fmt.Errorf("configuring aws_credentials requires at least 1 listener to be defined")
+		}
+		if c.AutoAuth == nil {
+			return fmt.Errorf("aws_credentials requires auto_auth to be configured")
+		}
+		if c.AutoAuth.Method != nil && c.AutoAuth.Method.WrapTTL > 0 {
+			return fmt.Errorf("aws_credentials requires auto_auth not to use wrapping")
+		}
+	}
+
+	if c.SecretsAPI != nil {
+		if c.AutoAuth == nil {
+			return fmt.Errorf("secrets_api requires auto_auth to be configured")
+		}
+		if c.AutoAuth.Method != nil && c.AutoAuth.Method.WrapTTL > 0 {
+			return fmt.Errorf("secrets_api requires auto_auth not to use wrapping")
+		}
+	}
+
+	if c.SystemdCredentials != nil {
+		if c.AutoAuth == nil {
+			return fmt.Errorf("systemd_credentials requires auto_auth to be configured")
+		}
+		if c.AutoAuth.Method != nil && c.AutoAuth.Method.WrapTTL > 0 {
+			return fmt.Errorf("systemd_credentials requires auto_auth not to use wrapping")
+		}
+	}
+
+	if c.KMIP != nil {
+		if c.AutoAuth == nil {
+			return fmt.Errorf("kmip requires auto_auth to be configured")
+		}
+		if c.AutoAuth.Method != nil && c.AutoAuth.Method.WrapTTL > 0 {
+			return fmt.Errorf("kmip requires auto_auth not to use wrapping")
+		}
+	}
+
+	if c.AWSKMS != nil {
+		if !c.IsDefaultListerDefined() {
+			return fmt.Errorf("configuring aws_kms requires at least 1 listener to be defined")
+		}
+		if c.AWSKMS.AccessKeyID != "" && c.AutoAuth == nil {
+			return fmt.Errorf("aws_kms.access_key_id requires auto_auth to be configured")
+		}
+		if c.AWSKMS.AccessKeyID == "" && c.AutoAuth != nil {
+			return fmt.Errorf("aws_kms requires access_key_id and secret_access_key when auto_auth is configured")
+		}
+		if c.AutoAuth != nil && c.AutoAuth.Method != nil && c.AutoAuth.Method.WrapTTL > 0 {
+			return fmt.Errorf("aws_kms requires auto_auth not to use wrapping")
+		}
+	}
+
 	if c.AutoAuth != nil {
 		if len(c.AutoAuth.Sinks) == 0 &&
 			(c.APIProxy == nil || !c.APIProxy.UseAutoAuthToken) &&
+			c.AWSCredentials == nil &&
+			c.SecretsAPI == nil &&
+			c.SystemdCredentials == nil &&
+			c.KMIP == nil &&
+			c.AWSKMS == nil &&
 			len(c.Templates) == 0 &&
 			len(c.EnvTemplates) == 0 {
-			return fmt.Errorf("auto_auth requires at least one sink or at least one template or api_proxy.use_auto_auth_token=true")
+			return fmt.Errorf("auto_auth requires at least one sink or at least one template or api_proxy.use_auto_auth_token=true or aws_credentials or secrets_api or systemd_credentials or kmip or aws_kms")
 		}
 	}
 
@@ -648,6 +806,26 @@ func LoadConfigFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("error parsing 'env_template': %w", err)
 	}
 
+	if err := parseAWSCredentials(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'aws_credentials': %w", err)
+	}
+
+	if err := parseSecretsAPI(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'secrets_api': %w", err)
+	}
+
+	if err := parseSystemdCredentials(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'systemd_credentials': %w", err)
+	}
+
+	if err := parseKMIP(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'kmip': %w", err)
+	}
+
+	if err := parseAWSKMS(result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'aws_kms': %w", err)
+	}
+
 	if result.Cache != nil && result.APIProxy == nil && (result.Cache.UseAutoAuthToken || result.Cache.ForceAutoAuthToken) {
 		result.APIProxy = &APIProxy{
 			UseAutoAuthToken:   result.Cache.UseAutoAuthToken,
@@ -830,6 +1008,267 @@ func parseAPIProxy(result *Config, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseAWSCredentials(result *Config, list *ast.ObjectList) error {
+	name := "aws_credentials"
+
+	awsCredentialsList := list.Filter(name)
+	if len(awsCredentialsList.Items) == 0 {
+		return nil
+	}
+
+	if len(awsCredentialsList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := awsCredentialsList.Items[0]
+
+	var awsCredentials AWSCredentials
+	err := hcl.DecodeObject(&awsCredentials, item.Val)
+	if err != nil {
+		return err
+	}
+
+	if awsCredentials.Path == "" {
+		return errors.New("'path' is required")
+	}
+	awsCredentials.Path = strings.Trim(awsCredentials.Path, "/")
+
+	switch {
+	case awsCredentials.AuthToken != "" && awsCredentials.AuthTokenFile != "":
+		return errors.New("only one of 'auth_token' and 'auth_token_file' can be specified")
+	case awsCredentials.AuthToken == "" && awsCredentials.AuthTokenFile == "":
+		return errors.New("one of 'auth_token' and 'auth_token_file' is required")
+	}
+
+	if awsCredentials.TTLRaw != nil {
+		if awsCredentials.TTL, err = parseutil.ParseDurationSecond(awsCredentials.TTLRaw); err != nil {
+			return err
+		}
+		awsCredentials.TTLRaw = nil
+	}
+
+	result.AWSCredentials = &awsCredentials
+
+	return nil
+}
+
+func parseSecretsAPI(result *Config, list *ast.ObjectList) error {
+	name := "secrets_api"
+
+	secretsAPIList := list.Filter(name)
+	if len(secretsAPIList.Items) == 0 {
+		return nil
+	}
+
+	if len(secretsAPIList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := secretsAPIList.Items[0]
+
+	var secretsAPI SecretsAPI
+	err := hcl.DecodeObject(&secretsAPI, item.Val)
+	if err != nil {
+		return err
+	}
+
+	if secretsAPI.Address == "" {
+		return errors.New("'address' is required")
+	}
+
+	secretsAPI.RefreshInterval = 5 * time.Minute
+	if secretsAPI.RefreshIntervalRaw != nil {
+		if secretsAPI.RefreshInterval, err = parseutil.ParseDurationSecond(secretsAPI.RefreshIntervalRaw); err != nil {
+			return err
+		}
+		if secretsAPI.RefreshInterval <= 0 {
+			return errors.New("'refresh_interval' must be positive")
+		}
+		secretsAPI.RefreshIntervalRaw = nil
+	}
+
+	subs, ok := item.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("could not parse %q as an object", name)
+	}
+
+	names := make(map[string]struct{})
+	for _, secretItem := range subs.List.Filter("secret").Items {
+		if len(secretItem.Keys) != 1 {
+			return errors.New("expected one and only one name for each 'secret'")
+		}
+
+		var secret SecretsAPISecret
+		if err := hcl.DecodeObject(&secret, secretItem.Val); err != nil {
+			return err
+		}
+		secret.Name = secretItem.Keys[0].Token.Value().(string)
+
+		if _, exists := names[secret.Name]; exists {
+			return fmt.Errorf("duplicate secret name: %q", secret.Name)
+		}
+		names[secret.Name] = struct{}{}
+
+		if secret.Path == "" {
+			return fmt.Errorf("secret %q: 'path' is required", secret.Name)
+		}
+		secret.Path = strings.Trim(secret.Path, "/")
+
+		secretsAPI.Secrets = append(secretsAPI.Secrets, &secret)
+	}
+
+	if len(secretsAPI.Secrets) == 0 {
+		return errors.New("at least one 'secret' is required")
+	}
+
+	result.SecretsAPI = &secretsAPI
+
+	return nil
+}
+
+func parseSystemdCredentials(result *Config, list *ast.ObjectList) error {
+	name := "systemd_credentials"
+
+	systemdCredentialsList := list.Filter(name)
+	if len(systemdCredentialsList.Items) == 0 {
+		return nil
+	}
+
+	if len(systemdCredentialsList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := systemdCredentialsList.Items[0]
+
+	var systemdCredentials SystemdCredentials
+	err := hcl.DecodeObject(&systemdCredentials, item.Val)
+	if err != nil {
+		return err
+	}
+
+	if systemdCredentials.Address == "" {
+		return errors.New("'address' is required")
+	}
+
+	subs, ok := item.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("could not parse %q as an object", name)
+	}
+
+	names := make(map[string]struct{})
+	for _, credentialItem := range subs.List.Filter("credential").Items {
+		if len(credentialItem.Keys) != 1 {
+			return errors.New("expected one and only one name for each 'credential'")
+		}
+
+		var credential SystemdCredential
+		if err := hcl.DecodeObject(&credential, credentialItem.Val); err != nil {
+			return err
+		}
+		credential.Name = credentialItem.Keys[0].Token.Value().(string)
+
+		// The names are file names in the directory, and in the credentials
+		// directory of the units
+		if credential.Name == "" || credential.Name == "." || credential.Name == ".." || strings.Contains(credential.Name, "/") {
+			return fmt.Errorf("invalid credential name: %q", credential.Name)
+		}
+		if _, exists := names[credential.Name]; exists {
+			return fmt.Errorf("duplicate credential name: %q", credential.Name)
+		}
+		names[credential.Name] = struct{}{}
+
+		if !credential.AutoAuthToken && systemdCredentials.Directory == "" {
+			return fmt.Errorf("credential %q: 'directory' is required for credentials rendered by templates", credential.Name)
+		}
+
+		systemdCredentials.Credentials = append(systemdCredentials.Credentials, &credential)
+	}
+
+	if len(systemdCredentials.Credentials) == 0 {
+		return errors.New("at least one 'credential' is required")
+	}
+
+	result.SystemdCredentials = &systemdCredentials
+
+	return nil
+}
+
+func parseKMIP(result *Config, list *ast.ObjectList) error {
+	name := "kmip"
+
+	kmipList := list.Filter(name)
+	if len(kmipList.Items) == 0 {
+		return nil
+	}
+
+	if len(kmipList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := kmipList.Items[0]
+
+	var kmip KMIP
+	err := hcl.DecodeObject(&kmip, item.Val)
+	if err != nil {
+		return err
+	}
+
+	if kmip.Address == "" {
+		// The port registered for KMIP over TLS
+		kmip.Address = "0.0.0.0:5696"
+	}
+	if kmip.Mount == "" {
+		kmip.Mount = "transit"
+	}
+	kmip.Mount = strings.Trim(kmip.Mount, "/")
+
+	// The clients are authenticated by their certificates
+	if kmip.TLSCertFile == "" || kmip.TLSKeyFile == "" {
+		return errors.New("'tls_cert_file' and 'tls_key_file' are required")
+	}
+	if kmip.TLSClientCAFile == "" {
+		return errors.New("'tls_client_ca_file' is required")
+	}
+
+	result.KMIP = &kmip
+
+	return nil
+}
+
+func parseAWSKMS(result *Config, list *ast.ObjectList) error {
+	name := "aws_kms"
+
+	awsKMSList := list.Filter(name)
+	if len(awsKMSList.Items) == 0 {
+		return nil
+	}
+
+	if len(awsKMSList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := awsKMSList.Items[0]
+
+	var awsKMS AWSKMS
+	err := hcl.DecodeObject(&awsKMS, item.Val)
+	if err != nil {
+		return err
+	}
+
+	if awsKMS.Mount == "" {
+		awsKMS.Mount = "transit"
+	}
+	awsKMS.Mount = strings.Trim(awsKMS.Mount, "/")
+
+	if (awsKMS.AccessKeyID == "") != (awsKMS.SecretAccessKey == "") {
+		return errors.New("'access_key_id' and 'secret_access_key' must be specified together")
+	}
+
+	result.AWSKMS = &awsKMS
+
+	return nil
+}
+
 func parseCache(result *Config, list *ast.ObjectList) error {
 	name := "cache"
 
@@ -2328,3 +2328,205 @@ func TestLoadConfigFile_Bad_EnvTemplates_DisalowedFields(t *testing.T) {
 		t.Fatal("expected an error from ValidateConfig: disallowed fields specified in env_template")
 	}
 }
+
+// TestLoadConfigFile_AWSCredentials ensures the aws_credentials stanza is
+// parsed, and is enough for auto_auth to be used without sinks
+func TestLoadConfigFile_AWSCredentials(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-aws-credentials.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("validation error: %s", err)
+	}
+
+	expected := &AWSCredentials{
+		Path:            "aws/sts/my-role",
+		RoleARN:         "arn:aws:iam::123456789012:role/my-role",
+		RoleSessionName: "my-app",
+		TTL:             30 * time.Minute,
+		AuthTokenFile:   "/run/secrets/aws-credentials-token",
+	}
+	if diff := deep.Equal(config.AWSCredentials, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// TestLoadConfigFile_Bad_AWSCredentials_NoListeners ensures that
+// ValidateConfig errors when aws_credentials has no listener to be served on
+func TestLoadConfigFile_Bad_AWSCredentials_NoListeners(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/bad-config-aws-credentials-no-listeners.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected an error from ValidateConfig: aws_credentials requires a listener")
+	}
+}
+
+// TestLoadConfigFile_Bad_AWSCredentials_NoAuthToken ensures that the config
+// fails to load when aws_credentials has no authorization token
+func TestLoadConfigFile_Bad_AWSCredentials_NoAuthToken(t *testing.T) {
+	_, err := LoadConfigFile("./test-fixtures/bad-config-aws-credentials-no-auth-token.hcl")
+	if err == nil {
+		t.Fatal("expected an error loading the config: aws_credentials requires an authorization token")
+	}
+}
+
+// TestLoadConfigFile_SecretsAPI ensures the secrets_api stanza and its secrets
+// are parsed, and are enough for auto_auth to be used without sinks
+func TestLoadConfigFile_SecretsAPI(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-secrets-api.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("validation error: %s", err)
+	}
+
+	expected := &SecretsAPI{
+		Address:         "/var/run/vault/agent.sock",
+		RefreshInterval: time.Minute,
+		Secrets: []*SecretsAPISecret{
+			{
+				Name:        "db",
+				Path:        "database/creds/app",
+				AllowedUIDs: []int{1000, 1001},
+			},
+			{
+				Name:        "api-key",
+				Path:        "secret/data/api",
+				AllowedGIDs: []int{2000},
+			},
+		},
+	}
+	if diff := deep.Equal(config.SecretsAPI, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// TestLoadConfigFile_Bad_SecretsAPI_DuplicateSecret ensures that the secrets
+// of the secrets API must have unique names
+func TestLoadConfigFile_Bad_SecretsAPI_DuplicateSecret(t *testing.T) {
+	_, err := LoadConfigFile("./test-fixtures/bad-config-secrets-api-duplicate-secret.hcl")
+	if err == nil {
+		t.Fatal("LoadConfigFile should return an error for this config")
+	}
+}
+
+// TestLoadConfigFile_SystemdCredentials ensures the systemd_credentials stanza
+// and its credentials are parsed
+func TestLoadConfigFile_SystemdCredentials(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-systemd-credentials.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("validation error: %s", err)
+	}
+
+	expected := &SystemdCredentials{
+		Address:   "/run/vault-agent/credentials.sock",
+		Directory: "/run/vault-agent/credentials",
+		Credentials: []*SystemdCredential{
+			{
+				Name:         "db-password",
+				AllowedUnits: []string{"app.service"},
+			},
+			{
+				Name:          "vault-token",
+				AutoAuthToken: true,
+			},
+		},
+	}
+	if diff := deep.Equal(config.SystemdCredentials, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// TestLoadConfigFile_Bad_SystemdCredentials_NoDirectory ensures the directory
+// is required by the credentials rendered by templates
+func TestLoadConfigFile_Bad_SystemdCredentials_NoDirectory(t *testing.T) {
+	_, err := LoadConfigFile("./test-fixtures/bad-config-systemd-credentials-no-directory.hcl")
+	if err == nil {
+		t.Fatal("LoadConfigFile should return an error for this config")
+	}
+}
+
+func TestLoadConfigFile_KMIP(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-kmip.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("validation error: %s", err)
+	}
+
+	expected := &KMIP{
+		Address:         "0.0.0.0:5696",
+		Mount:           "transit-kmip",
+		TLSCertFile:     "/etc/vault-agent/kmip.crt",
+		TLSKeyFile:      "/etc/vault-agent/kmip.key",
+		TLSClientCAFile: "/etc/vault-agent/kmip-clients.crt",
+	}
+	if diff := deep.Equal(config.KMIP, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// TestLoadConfigFile_Bad_KMIP_NoClientCA ensures the CA of the client
+// certificates is required, as they authenticate the clients
+func TestLoadConfigFile_Bad_KMIP_NoClientCA(t *testing.T) {
+	_, err := LoadConfigFile("./test-fixtures/bad-config-kmip-no-client-ca.hcl")
+	if err == nil {
+		t.Fatal("LoadConfigFile should return an error for this config")
+	}
+}
+
+// TestLoadConfigFile_AWSKMS ensures the aws_kms stanza is parsed
+func TestLoadConfigFile_AWSKMS(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/config-aws-kms.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		t.Fatalf("validation error: %s", err)
+	}
+
+	expected := &AWSKMS{
+		Mount:           "transit-kms",
+		AccessKeyID:     "AKIAVAULTAGENT",
+		SecretAccessKey: "agent-secret-access-key",
+	}
+	if diff := deep.Equal(config.AWSKMS, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// TestLoadConfigFile_Bad_AWSKMS_NoSecretAccessKey ensures the access key is
+// configured with its secret
+func TestLoadConfigFile_Bad_AWSKMS_NoSecretAccessKey(t *testing.T) {
+	_, err := LoadConfigFile("./test-fixtures/bad-config-aws-kms-no-secret-access-key.hcl")
+	if err == nil {
+		t.Fatal("LoadConfigFile should return an error for this config")
+	}
+}
+
+// TestLoadConfigFile_Bad_AWSKMS_NoAccessKey ensures that ValidateConfig errors
+// when aws_kms would let unsigned requests use the auto-auth token
+func TestLoadConfigFile_Bad_AWSKMS_NoAccessKey(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/bad-config-aws-kms-no-access-key.hcl")
+	if err != nil {
+		t.Fatalf("error loading config file: %s", err)
+	}
+
+	if err := config.ValidateConfig(); err == nil {
+		t.Fatal("expected an error from ValidateConfig: aws_kms requires an access key with auto_auth")
+	}
+}
@@ -0,0 +1,22 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "kubernetes"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+aws_credentials {
+	path = "aws/sts/my-role"
+	auth_token = "secret-token"
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+	role = "metrics_only"
+}
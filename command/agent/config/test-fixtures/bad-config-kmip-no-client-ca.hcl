@@ -0,0 +1,17 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "approle"
+		config = {
+			role_id_file_path = "/etc/vault/role-id"
+		}
+	}
+}
+
+kmip {
+	address = "127.0.0.1:5696"
+	tls_cert_file = "/etc/vault-agent/kmip.crt"
+	tls_key_file = "/etc/vault-agent/kmip.key"
+}
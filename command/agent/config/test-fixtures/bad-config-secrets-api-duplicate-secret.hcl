@@ -0,0 +1,23 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "kubernetes"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+secrets_api {
+	address = "/var/run/vault/agent.sock"
+
+	secret "db" {
+		path = "database/creds/app"
+	}
+
+	secret "db" {
+		path = "database/creds/other"
+	}
+}
@@ -0,0 +1,17 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "approle"
+		config = {
+			role_id_file_path = "/etc/vault/role-id"
+		}
+	}
+}
+
+systemd_credentials {
+	address = "/run/vault-agent/credentials.sock"
+
+	credential "db-password" {}
+}
@@ -0,0 +1,24 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "kubernetes"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+aws_credentials {
+	path = "/aws/sts/my-role/"
+	role_arn = "arn:aws:iam::123456789012:role/my-role"
+	role_session_name = "my-app"
+	ttl = "30m"
+	auth_token_file = "/run/secrets/aws-credentials-token"
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+}
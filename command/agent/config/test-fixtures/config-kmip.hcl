@@ -0,0 +1,18 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "approle"
+		config = {
+			role_id_file_path = "/etc/vault/role-id"
+		}
+	}
+}
+
+kmip {
+	mount = "/transit-kmip/"
+	tls_cert_file = "/etc/vault-agent/kmip.crt"
+	tls_key_file = "/etc/vault-agent/kmip.key"
+	tls_client_ca_file = "/etc/vault-agent/kmip-clients.crt"
+}
@@ -0,0 +1,26 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "kubernetes"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+secrets_api {
+	address = "/var/run/vault/agent.sock"
+	refresh_interval = "1m"
+
+	secret "db" {
+		path = "/database/creds/app/"
+		allowed_uids = [1000, 1001]
+	}
+
+	secret "api-key" {
+		path = "secret/data/api"
+		allowed_gids = [2000]
+	}
+}
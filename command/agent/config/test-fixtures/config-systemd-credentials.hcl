@@ -0,0 +1,29 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+auto_auth {
+	method {
+		type = "approle"
+		config = {
+			role_id_file_path = "/etc/vault/role-id"
+		}
+	}
+}
+
+systemd_credentials {
+	address = "/run/vault-agent/credentials.sock"
+	directory = "/run/vault-agent/credentials"
+
+	credential "db-password" {
+		allowed_units = ["app.service"]
+	}
+
+	credential "vault-token" {
+		auto_auth_token = true
+	}
+}
+
+template {
+	contents = "{{ with secret \"secret/data/db\" }}{{ .Data.data.password }}{{ end }}"
+	destination = "/run/vault-agent/credentials/db-password"
+}
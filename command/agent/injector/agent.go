@@ -0,0 +1,413 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package injector
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// AnnotationPrefix prefixes the annotations configuring the injection.
+	AnnotationPrefix = "vault.hashicorp.com/"
+
+	// AnnotationAgentInject enables the injection of the agent into a pod
+	// when set to true, and AnnotationAgentInjectStatus marks the pods the
+	// agent was injected into, so that they aren't injected again.
+	AnnotationAgentInject       = AnnotationPrefix + "agent-inject"
+	AnnotationAgentInjectStatus = AnnotationPrefix + "agent-inject-status"
+
+	// AnnotationAgentInjectSecret, AnnotationAgentInjectTemplate,
+	// AnnotationAgentInjectFile and AnnotationAgentInjectCommand prefix the
+	// annotations configuring a rendered secret, suffixed with its name:
+	// the path of the secret, the template rendering it, the name of the
+	// file it is rendered to and the command run after it is rendered.
+	AnnotationAgentInjectSecret   = AnnotationPrefix + "agent-inject-secret-"
+	AnnotationAgentInjectTemplate = AnnotationPrefix + "agent-inject-template-"
+	AnnotationAgentInjectFile     = AnnotationPrefix + "agent-inject-file-"
+	AnnotationAgentInjectCommand  = AnnotationPrefix + "agent-inject-command-"
+
+	// AnnotationAgentInjectContainers lists the containers the secrets are
+	// mounted into, defaulting to all the containers of the pod.
+	AnnotationAgentInjectContainers = AnnotationPrefix + "agent-inject-containers"
+
+	AnnotationAgentImage           = AnnotationPrefix + "agent-image"
+	AnnotationAgentPrePopulate     = AnnotationPrefix + "agent-pre-populate"
+	AnnotationAgentPrePopulateOnly = AnnotationPrefix + "agent-pre-populate-only"
+	AnnotationAgentInitFirst       = AnnotationPrefix + "agent-init-first"
+	AnnotationAgentLimitsCPU       = AnnotationPrefix + "agent-limits-cpu"
+	AnnotationAgentLimitsMem       = AnnotationPrefix + "agent-limits-mem"
+	AnnotationAgentRequestsCPU     = AnnotationPrefix + "agent-requests-cpu"
+	AnnotationAgentRequestsMem     = AnnotationPrefix + "agent-requests-mem"
+
+	AnnotationVaultRole                          = AnnotationPrefix + "role"
+	AnnotationVaultAuthPath                      = AnnotationPrefix + "auth-path"
+	AnnotationVaultNamespace                     = AnnotationPrefix + "namespace"
+	AnnotationVaultService                       = AnnotationPrefix + "service"
+	AnnotationVaultCACert                        = AnnotationPrefix + "ca-cert"
+	AnnotationVaultTLSSkipVerify                 = AnnotationPrefix + "tls-skip-verify"
+	AnnotationVaultLogLevel                      = AnnotationPrefix + "log-level"
+	AnnotationVaultSecretVolPath                 = AnnotationPrefix + "secret-volume-path"
+	AnnotationTemplateStaticSecretRenderInterval = AnnotationPrefix + "template-static-secret-render-interval"
+
+	// injectStatusInjected is the value of AnnotationAgentInjectStatus once
+	// the agent was injected.
+	injectStatusInjected = "injected"
+
+	defaultSecretVolumePath = "/vault/secrets"
+	defaultLimitsCPU        = "500m"
+	defaultLimitsMem        = "128Mi"
+	defaultRequestsCPU      = "250m"
+	defaultRequestsMem      = "64Mi"
+
+	// agentHome is the home directory of the vault user of the agent image,
+	// where the agent configuration and token are written.
+	agentHome = "/home/vault"
+
+	// serviceAccountTokenPath is where Kubernetes mounts the token of the
+	// service account of the pod, which the agent authenticates with.
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	secretsVolumeName     = "vault-secrets"
+	initHomeVolumeName    = "vault-agent-home-init"
+	sidecarHomeVolumeName = "vault-agent-home"
+
+	initContainerName    = "vault-agent-init"
+	sidecarContainerName = "vault-agent"
+
+	// defaultTemplate renders all the keys of a secret, one per line.
+	defaultTemplate = `{{ with secret "%s" }}{{ range $k, $v := .Data }}{{ $k }}: {{ $v }}
+{{ end }}{{ end }}`
+)
+
+// agentSecret is a secret the agent renders into the secrets volume.
+type agentSecret struct {
+	Name     string
+	Path     string
+	Template string
+	File     string
+	Command  string
+}
+
+// agent is the agent injected into a pod, as configured by its annotations
+// and the defaults of the injector.
+type agent struct {
+	Image            string
+	Role             string
+	AuthPath         string
+	Namespace        string
+	Address          string
+	CACert           string
+	TLSSkipVerify    bool
+	LogLevel         string
+	SecretVolumePath string
+	StaticInterval   string
+
+	PrePopulate     bool
+	PrePopulateOnly bool
+	InitFirst       bool
+
+	// Containers are the names of the containers the secrets are mounted
+	// into, all of them when empty.
+	Containers []string
+	Secrets    []*agentSecret
+
+	Resources corev1.ResourceRequirements
+
+	// TokenMount is the mount of the service account token of the pod,
+	// copied into the agent containers.
+	TokenMount *corev1.VolumeMount
+}
+
+// newAgent returns the agent to inject into a pod from its annotations,
+// falling back to the configuration of the injector.
+func newAgent(pod *corev1.Pod, config *Config) (*agent, error) {
+	annotations := pod.Annotations
+
+	a := &agent{
+		Image:            annotationOr(annotations, AnnotationAgentImage, config.AgentImage),
+		Role:             annotations[AnnotationVaultRole],
+		AuthPath:         annotationOr(annotations, AnnotationVaultAuthPath, config.AuthPath),
+		Namespace:        annotationOr(annotations, AnnotationVaultNamespace, config.Namespace),
+		Address:          annotationOr(annotations, AnnotationVaultService, config.VaultAddress),
+		CACert:           annotationOr(annotations, AnnotationVaultCACert, config.CACert),
+		LogLevel:         annotationOr(annotations, AnnotationVaultLogLevel, config.LogLevel),
+		SecretVolumePath: annotationOr(annotations, AnnotationVaultSecretVolPath, defaultSecretVolumePath),
+		StaticInterval:   annotations[AnnotationTemplateStaticSecretRenderInterval],
+	}
+	switch {
+	case a.Image == "":
+		return nil, fmt.Errorf("no agent image configured, set the %s annotation", AnnotationAgentImage)
+	case a.Role == "":
+		return nil, fmt.Errorf("the %s annotation is required", AnnotationVaultRole)
+	case a.Address == "":
+		return nil, fmt.Errorf("no Vault address configured, set the %s annotation", AnnotationVaultService)
+	}
+
+	var err error
+	bools := []struct {
+		annotation string
+		target     *bool
+		def        bool
+	}{
+		{AnnotationVaultTLSSkipVerify, &a.TLSSkipVerify, config.TLSSkipVerify},
+		{AnnotationAgentPrePopulate, &a.PrePopulate, true},
+		{AnnotationAgentPrePopulateOnly, &a.PrePopulateOnly, false},
+		{AnnotationAgentInitFirst, &a.InitFirst, false},
+	}
+	for _, b := range bools {
+		if *b.target, err = annotationBool(annotations, b.annotation, b.def); err != nil {
+			return nil, err
+		}
+	}
+	if a.PrePopulateOnly && !a.PrePopulate {
+		return nil, fmt.Errorf("%s requires %s", AnnotationAgentPrePopulateOnly, AnnotationAgentPrePopulate)
+	}
+
+	if containers := annotations[AnnotationAgentInjectContainers]; containers != "" {
+		for _, name := range strings.Split(containers, ",") {
+			a.Containers = append(a.Containers, strings.TrimSpace(name))
+		}
+	}
+
+	if a.Secrets, err = agentSecrets(annotations); err != nil {
+		return nil, err
+	}
+	if a.Resources, err = agentResources(annotations); err != nil {
+		return nil, err
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, mount := range container.VolumeMounts {
+			if mount.MountPath == serviceAccountTokenPath {
+				m := mount
+				a.TokenMount = &m
+				break
+			}
+		}
+		if a.TokenMount != nil {
+			break
+		}
+	}
+	if a.TokenMount == nil {
+		return nil, fmt.Errorf("no service account token is mounted at %s, automountServiceAccountToken must be enabled", serviceAccountTokenPath)
+	}
+
+	return a, nil
+}
+
+// agentSecrets returns the secrets to render, sorted by name.
+func agentSecrets(annotations map[string]string) ([]*agentSecret, error) {
+	var secrets []*agentSecret
+	for key, value := range annotations {
+		name, ok := strings.CutPrefix(key, AnnotationAgentInjectSecret)
+		if !ok {
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid secret name %q in annotation %s", name, key)
+		}
+
+		secret := &agentSecret{
+			Name:     name,
+			Path:     value,
+			Template: annotations[AnnotationAgentInjectTemplate+name],
+			File:     annotationOr(annotations, AnnotationAgentInjectFile+name, name),
+			Command:  annotations[AnnotationAgentInjectCommand+name],
+		}
+		if secret.Template == "" {
+			secret.Template = fmt.Sprintf(defaultTemplate, secret.Path)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+func agentResources(annotations map[string]string) (corev1.ResourceRequirements, error) {
+	resources := corev1.ResourceRequirements{
+		Limits:   corev1.ResourceList{},
+		Requests: corev1.ResourceList{},
+	}
+	for _, r := range []struct {
+		list       corev1.ResourceList
+		name       corev1.ResourceName
+		annotation string
+		def        string
+	}{
+		{resources.Limits, corev1.ResourceCPU, AnnotationAgentLimitsCPU, defaultLimitsCPU},
+		{resources.Limits, corev1.ResourceMemory, AnnotationAgentLimitsMem, defaultLimitsMem},
+		{resources.Requests, corev1.ResourceCPU, AnnotationAgentRequestsCPU, defaultRequestsCPU},
+		{resources.Requests, corev1.ResourceMemory, AnnotationAgentRequestsMem, defaultRequestsMem},
+	} {
+		quantity, err := resource.ParseQuantity(annotationOr(annotations, r.annotation, r.def))
+		if err != nil {
+			return resources, fmt.Errorf("invalid %s annotation: %w", r.annotation, err)
+		}
+		r.list[r.name] = quantity
+	}
+	return resources, nil
+}
+
+// config returns the agent configuration, as JSON. The init container exits
+// once the secrets are rendered, while the sidecar keeps them up to date.
+func (a *agent) config(init bool) ([]byte, error) {
+	vault := map[string]interface{}{
+		"address": a.Address,
+	}
+	if a.CACert != "" {
+		vault["ca_cert"] = a.CACert
+	}
+	if a.TLSSkipVerify {
+		vault["tls_skip_verify"] = true
+	}
+
+	method := map[string]interface{}{
+		"type":       "kubernetes",
+		"mount_path": a.AuthPath,
+		"config": map[string]interface{}{
+			"role":       a.Role,
+			"token_path": path.Join(serviceAccountTokenPath, "token"),
+		},
+	}
+	if a.Namespace != "" {
+		method["namespace"] = a.Namespace
+	}
+
+	templates := make([]map[string]interface{}, 0, len(a.Secrets))
+	for _, secret := range a.Secrets {
+		template := map[string]interface{}{
+			"destination":     path.Join(a.SecretVolumePath, secret.File),
+			"contents":        secret.Template,
+			"left_delimiter":  "{{",
+			"right_delimiter": "}}",
+		}
+		if secret.Command != "" && !init {
+			template["command"] = secret.Command
+		}
+		templates = append(templates, template)
+	}
+
+	templateConfig := map[string]interface{}{
+		"exit_on_retry_failure": true,
+	}
+	if a.StaticInterval != "" {
+		templateConfig["static_secret_render_interval"] = a.StaticInterval
+	}
+
+	config := map[string]interface{}{
+		"exit_after_auth": init,
+		"pid_file":        path.Join(agentHome, ".pid"),
+		"vault":           vault,
+		"auto_auth": map[string]interface{}{
+			"method": method,
+			"sink": []map[string]interface{}{{
+				"type": "file",
+				"config": map[string]interface{}{
+					"path": path.Join(agentHome, ".vault-token"),
+				},
+			}},
+		},
+		"template":        templates,
+		"template_config": templateConfig,
+	}
+	return json.Marshal(config)
+}
+
+// container returns the init or sidecar container running the agent. The
+// agent configuration is passed in the environment, and written to the
+// home volume of the container before starting the agent.
+func (a *agent) container(init bool) (corev1.Container, error) {
+	config, err := a.config(init)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+
+	name, home := sidecarContainerName, sidecarHomeVolumeName
+	if init {
+		name, home = initContainerName, initHomeVolumeName
+	}
+
+	configPath := path.Join(agentHome, "config.json")
+	env := []corev1.EnvVar{
+		{Name: "VAULT_CONFIG", Value: base64.StdEncoding.EncodeToString(config)},
+	}
+	if a.LogLevel != "" {
+		env = append(env, corev1.EnvVar{Name: "VAULT_LOG_LEVEL", Value: a.LogLevel})
+	}
+
+	uid, gid := int64(100), int64(1000)
+	return corev1.Container{
+		Name:    name,
+		Image:   a.Image,
+		Command: []string{"/bin/sh", "-ec"},
+		Args: []string{
+			fmt.Sprintf("echo ${VAULT_CONFIG?} | base64 -d > %s && vault agent -config=%s", configPath, configPath),
+		},
+		Env:       env,
+		Resources: a.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: home, MountPath: agentHome},
+			{Name: secretsVolumeName, MountPath: a.SecretVolumePath},
+			*a.TokenMount,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser:                &uid,
+			RunAsGroup:               &gid,
+			RunAsNonRoot:             boolPtr(true),
+			ReadOnlyRootFilesystem:   boolPtr(true),
+			AllowPrivilegeEscalation: boolPtr(false),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+	}, nil
+}
+
+// mountsSecrets returns whether the secrets are mounted into the container.
+func (a *agent) mountsSecrets(container string) bool {
+	if len(a.Containers) == 0 {
+		return true
+	}
+	for _, name := range a.Containers {
+		if name == container {
+			return true
+		}
+	}
+	return false
+}
+
+func annotationOr(annotations map[string]string, key, def string) string {
+	if value, ok := annotations[key]; ok && value != "" {
+		return value
+	}
+	return def
+}
+
+func annotationBool(annotations map[string]string, key string, def bool) (bool, error) {
+	value, ok := annotations[key]
+	if !ok || value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation %q: %w", key, value, err)
+	}
+	return b, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+/*
+Package injector implements a Kubernetes mutating admission webhook which
+injects Vault Agent into pods, as configured by their annotations. Pods
+annotated with vault.hashicorp.com/agent-inject: "true" get an init container
+rendering their secrets before they start, and a sidecar keeping the secrets
+up to date, both sharing an in-memory volume with the containers of the pod.
+*/
+package injector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxRequestSize bounds the size of the admission reviews, which embed the
+// pod being admitted.
+const maxRequestSize = 8 << 20
+
+// skippedNamespaces are the Kubernetes system namespaces, whose pods are
+// never injected.
+var skippedNamespaces = map[string]bool{
+	metav1.NamespaceSystem: true,
+	metav1.NamespacePublic: true,
+}
+
+// Config holds the defaults of the injected agents, which the annotations
+// of the pods can override.
+type Config struct {
+	// AgentImage is the image of the agent containers.
+	AgentImage string
+
+	// VaultAddress is the address of the Vault server the agents connect
+	// to, reachable from the pods.
+	VaultAddress string
+
+	// AuthPath is the mount path of the Kubernetes auth method the agents
+	// authenticate with.
+	AuthPath string
+
+	// Namespace is the Vault namespace the agents authenticate in.
+	Namespace string
+
+	// CACert is the path of the CA certificate of Vault in the agent
+	// containers, and TLSSkipVerify disables the verification of the
+	// certificate of Vault.
+	CACert        string
+	TLSSkipVerify bool
+
+	// LogLevel is the log level of the agents.
+	LogLevel string
+}
+
+// Handler is the HTTP handler of the webhook, answering the admission
+// reviews of pods with the patch injecting the agent.
+type Handler struct {
+	config *Config
+	logger hclog.Logger
+}
+
+// NewHandler returns the handler of the webhook injecting agents configured
+// with the given defaults.
+func NewHandler(config *Config, logger hclog.Logger) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "invalid content type, expected application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.admit(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		h.logger.Error("error writing admission response", "error", err)
+	}
+}
+
+// admit returns the response to an admission request, patching the pods to
+// inject and allowing everything else as is. Pods which can't be injected
+// are refused, as they would start without their secrets.
+func (h *Handler) admit(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	allowed := &admissionv1.AdmissionResponse{Allowed: true}
+
+	if req.Kind.Kind != "Pod" || req.Operation != admissionv1.Create || skippedNamespaces[req.Namespace] {
+		return allowed
+	}
+
+	var pod corev1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return deny(fmt.Errorf("error decoding pod: %w", err))
+	}
+
+	inject, err := annotationBool(pod.Annotations, AnnotationAgentInject, false)
+	if err != nil {
+		return deny(err)
+	}
+	if !inject || pod.Annotations[AnnotationAgentInjectStatus] == injectStatusInjected {
+		return allowed
+	}
+
+	logger := h.logger.With("namespace", req.Namespace, "pod", podName(&pod))
+	patch, err := h.patch(&pod)
+	if err != nil {
+		logger.Warn("refusing pod which can't be injected", "error", err)
+		return deny(err)
+	}
+
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return deny(fmt.Errorf("error encoding patch: %w", err))
+	}
+
+	logger.Info("injecting agent")
+	patchType := admissionv1.PatchTypeJSONPatch
+	allowed.Patch = raw
+	allowed.PatchType = &patchType
+	return allowed
+}
+
+// patch returns the JSON patch injecting the agent into a pod.
+func (h *Handler) patch(pod *corev1.Pod) ([]patchOperation, error) {
+	a, err := newAgent(pod, h.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var p patcher
+	p.addVolumes(pod.Spec.Volumes, []corev1.Volume{
+		memoryVolume(secretsVolumeName),
+		memoryVolume(initHomeVolumeName),
+		memoryVolume(sidecarHomeVolumeName),
+	})
+
+	for i, container := range pod.Spec.Containers {
+		if a.mountsSecrets(container.Name) {
+			p.addVolumeMount("/spec/containers", i, container.VolumeMounts, corev1.VolumeMount{
+				Name:      secretsVolumeName,
+				MountPath: a.SecretVolumePath,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	if a.PrePopulate {
+		init, err := a.container(true)
+		if err != nil {
+			return nil, err
+		}
+		p.addContainer("/spec/initContainers", pod.Spec.InitContainers, init, a.InitFirst)
+	}
+	if !a.PrePopulateOnly {
+		sidecar, err := a.container(false)
+		if err != nil {
+			return nil, err
+		}
+		p.addContainer("/spec/containers", pod.Spec.Containers, sidecar, false)
+	}
+
+	p.addAnnotation(pod.Annotations, AnnotationAgentInjectStatus, injectStatusInjected)
+	return p.ops, nil
+}
+
+func deny(err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		},
+	}
+}
+
+// podName returns the name of a pod, which is only generated after
+// admission for the pods of controllers.
+func podName(pod *corev1.Pod) string {
+	if pod.Name != "" {
+		return pod.Name
+	}
+	return strings.TrimSuffix(pod.GenerateName, "-") + "-<generated>"
+}
+
+func memoryVolume(name string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium: corev1.StorageMediumMemory,
+			},
+		},
+	}
+}
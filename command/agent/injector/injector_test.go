@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package injector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var testConfig = &Config{
+	AgentImage:   "hashicorp/vault:1.15.0",
+	VaultAddress: "https://vault.vault.svc:8200",
+	AuthPath:     "auth/kubernetes",
+}
+
+// testPod returns a pod with an app container which has the token of its
+// service account mounted, and the given annotations.
+func testPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-app",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "my-app:latest",
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "kube-api-access-abcde",
+					MountPath: serviceAccountTokenPath,
+					ReadOnly:  true,
+				}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "kube-api-access-abcde",
+			}},
+		},
+	}
+}
+
+// testAdmit sends the admission review of the creation of a pod to the
+// webhook, and returns its response.
+func testAdmit(t *testing.T, pod *corev1.Pod) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "1234",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace: pod.Namespace,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewHandler(testConfig, hclog.NewNullLogger()).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var review admissionv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+	require.NotNil(t, review.Response)
+	require.Equal(t, "1234", string(review.Response.UID))
+	return review.Response
+}
+
+// testPatch applies the patch of an admission response to a pod.
+func testPatch(t *testing.T, pod *corev1.Pod, resp *admissionv1.AdmissionResponse) *corev1.Pod {
+	t.Helper()
+
+	require.True(t, resp.Allowed)
+	require.NotNil(t, resp.PatchType)
+	require.Equal(t, admissionv1.PatchTypeJSONPatch, *resp.PatchType)
+
+	patch, err := jsonpatch.DecodePatch(resp.Patch)
+	require.NoError(t, err)
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	patched, err := patch.Apply(raw)
+	require.NoError(t, err)
+
+	var result corev1.Pod
+	require.NoError(t, json.Unmarshal(patched, &result))
+	return &result
+}
+
+// testAgentConfig loads the agent configuration of a container.
+func testAgentConfig(t *testing.T, container corev1.Container) *config.Config {
+	t.Helper()
+
+	require.Equal(t, "VAULT_CONFIG", container.Env[0].Name)
+	raw, err := base64.StdEncoding.DecodeString(container.Env[0].Value)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+	c, err := config.LoadConfigFile(path)
+	require.NoError(t, err)
+	return c
+}
+
+// TestHandler_Inject ensures annotated pods get an init container and a
+// sidecar rendering their secrets into a volume shared with the containers
+// of the pod, with a valid agent configuration.
+func TestHandler_Inject(t *testing.T) {
+	pod := testPod(map[string]string{
+		AnnotationAgentInject:                     "true",
+		AnnotationVaultRole:                       "my-app",
+		AnnotationAgentInjectSecret + "db-creds":  "database/creds/my-app",
+		AnnotationAgentInjectSecret + "api-key":   "secret/data/api",
+		AnnotationAgentInjectTemplate + "api-key": `{{ with secret "secret/data/api" }}{{ .Data.data.key }}{{ end }}`,
+		AnnotationAgentInjectFile + "api-key":     "api.txt",
+		AnnotationAgentInjectCommand + "api-key":  "kill -HUP 1",
+		AnnotationAgentLimitsMem:                  "256Mi",
+	})
+
+	patched := testPatch(t, pod, testAdmit(t, pod))
+	require.Equal(t, injectStatusInjected, patched.Annotations[AnnotationAgentInjectStatus])
+
+	volumes := make([]string, 0, len(patched.Spec.Volumes))
+	for _, volume := range patched.Spec.Volumes {
+		volumes = append(volumes, volume.Name)
+	}
+	require.Equal(t, []string{"kube-api-access-abcde", secretsVolumeName, initHomeVolumeName, sidecarHomeVolumeName}, volumes)
+
+	require.Len(t, patched.Spec.InitContainers, 1)
+	require.Len(t, patched.Spec.Containers, 2)
+	app, sidecar, init := patched.Spec.Containers[0], patched.Spec.Containers[1], patched.Spec.InitContainers[0]
+	require.Equal(t, corev1.VolumeMount{Name: secretsVolumeName, MountPath: defaultSecretVolumePath, ReadOnly: true}, app.VolumeMounts[1])
+	require.Equal(t, sidecarContainerName, sidecar.Name)
+	require.Equal(t, initContainerName, init.Name)
+	require.Equal(t, "hashicorp/vault:1.15.0", sidecar.Image)
+	require.Equal(t, "256Mi", sidecar.Resources.Limits.Memory().String())
+	require.Contains(t, sidecar.VolumeMounts, app.VolumeMounts[0])
+
+	initConfig := testAgentConfig(t, init)
+	require.True(t, initConfig.ExitAfterAuth)
+	require.Nil(t, initConfig.Templates[0].Command)
+
+	sidecarConfig := testAgentConfig(t, sidecar)
+	require.False(t, sidecarConfig.ExitAfterAuth)
+	require.Equal(t, "https://vault.vault.svc:8200", sidecarConfig.Vault.Address)
+	require.Equal(t, "kubernetes", sidecarConfig.AutoAuth.Method.Type)
+	require.Equal(t, "auth/kubernetes", sidecarConfig.AutoAuth.Method.MountPath)
+	require.Equal(t, "my-app", sidecarConfig.AutoAuth.Method.Config["role"])
+	require.Len(t, sidecarConfig.Templates, 2)
+	require.Equal(t, "/vault/secrets/api.txt", *sidecarConfig.Templates[0].Destination)
+	require.Equal(t, `{{ with secret "secret/data/api" }}{{ .Data.data.key }}{{ end }}`, *sidecarConfig.Templates[0].Contents)
+	require.Equal(t, []string{"kill -HUP 1"}, []string(sidecarConfig.Templates[0].Command))
+	require.Equal(t, "/vault/secrets/db-creds", *sidecarConfig.Templates[1].Destination)
+	require.Contains(t, *sidecarConfig.Templates[1].Contents, `secret "database/creds/my-app"`)
+}
+
+// TestHandler_InjectOptions ensures the annotations choose which agent
+// containers are injected, where, and which containers mount the secrets.
+func TestHandler_InjectOptions(t *testing.T) {
+	pod := testPod(map[string]string{
+		AnnotationAgentInject:           "true",
+		AnnotationVaultRole:             "my-app",
+		AnnotationAgentPrePopulateOnly:  "true",
+		AnnotationAgentInitFirst:        "true",
+		AnnotationAgentInjectContainers: "other",
+		AnnotationVaultSecretVolPath:    "/etc/secrets",
+	})
+	pod.Spec.InitContainers = []corev1.Container{{Name: "migrate"}}
+
+	patched := testPatch(t, pod, testAdmit(t, pod))
+	require.Len(t, patched.Spec.Containers, 1)
+	require.Len(t, patched.Spec.Containers[0].VolumeMounts, 1)
+	require.Equal(t, []string{initContainerName, "migrate"}, []string{patched.Spec.InitContainers[0].Name, patched.Spec.InitContainers[1].Name})
+	require.Equal(t, "/etc/secrets", patched.Spec.InitContainers[0].VolumeMounts[1].MountPath)
+}
+
+// TestHandler_Skip ensures the pods which aren't annotated, were already
+// injected or are in system namespaces are admitted as is, and that the pods
+// which can't be injected are refused.
+func TestHandler_Skip(t *testing.T) {
+	for name, pod := range map[string]*corev1.Pod{
+		"not annotated": testPod(nil),
+		"disabled":      testPod(map[string]string{AnnotationAgentInject: "false"}),
+		"injected": testPod(map[string]string{
+			AnnotationAgentInject:       "true",
+			AnnotationVaultRole:         "my-app",
+			AnnotationAgentInjectStatus: injectStatusInjected,
+		}),
+	} {
+		resp := testAdmit(t, pod)
+		require.True(t, resp.Allowed, name)
+		require.Nil(t, resp.Patch, name)
+	}
+
+	system := testPod(map[string]string{AnnotationAgentInject: "true", AnnotationVaultRole: "my-app"})
+	system.Namespace = metav1.NamespaceSystem
+	resp := testAdmit(t, system)
+	require.True(t, resp.Allowed)
+	require.Nil(t, resp.Patch)
+
+	noToken := testPod(map[string]string{AnnotationAgentInject: "true", AnnotationVaultRole: "my-app"})
+	noToken.Spec.Containers[0].VolumeMounts = nil
+	for name, pod := range map[string]*corev1.Pod{
+		"missing role":       testPod(map[string]string{AnnotationAgentInject: "true"}),
+		"invalid bool":       testPod(map[string]string{AnnotationAgentInject: "yes please"}),
+		"invalid resources":  testPod(map[string]string{AnnotationAgentInject: "true", AnnotationVaultRole: "my-app", AnnotationAgentLimitsCPU: "lots"}),
+		"invalid secret":     testPod(map[string]string{AnnotationAgentInject: "true", AnnotationVaultRole: "my-app", AnnotationAgentInjectSecret + "..": "secret/foo"}),
+		"no service account": noToken,
+	} {
+		resp := testAdmit(t, pod)
+		require.False(t, resp.Allowed, name)
+		require.NotEmpty(t, resp.Result.Message, name)
+	}
+}
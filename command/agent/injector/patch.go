@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package injector
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// patchOperation is an RFC 6902 JSON patch operation.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// patcher accumulates the operations of a JSON patch. Arrays and maps are
+// added whole when the pod doesn't have them yet, as JSON patch can't add
+// elements to missing ones.
+type patcher struct {
+	ops []patchOperation
+}
+
+func (p *patcher) add(path string, value interface{}) {
+	p.ops = append(p.ops, patchOperation{Op: "add", Path: path, Value: value})
+}
+
+func (p *patcher) addVolumes(existing, volumes []corev1.Volume) {
+	if len(existing) == 0 {
+		p.add("/spec/volumes", volumes)
+		return
+	}
+	for _, volume := range volumes {
+		p.add("/spec/volumes/-", volume)
+	}
+}
+
+func (p *patcher) addVolumeMount(containersPath string, index int, existing []corev1.VolumeMount, mount corev1.VolumeMount) {
+	path := fmt.Sprintf("%s/%d/volumeMounts", containersPath, index)
+	if len(existing) == 0 {
+		p.add(path, []corev1.VolumeMount{mount})
+		return
+	}
+	p.add(path+"/-", mount)
+}
+
+// addContainer appends a container to the containers at the path, or
+// inserts it first.
+func (p *patcher) addContainer(path string, existing []corev1.Container, container corev1.Container, first bool) {
+	switch {
+	case len(existing) == 0:
+		p.add(path, []corev1.Container{container})
+	case first:
+		p.add(path+"/0", container)
+	default:
+		p.add(path+"/-", container)
+	}
+}
+
+func (p *patcher) addAnnotation(existing map[string]string, key, value string) {
+	if len(existing) == 0 {
+		p.add("/metadata/annotations", map[string]string{key: value})
+		return
+	}
+	p.add("/metadata/annotations/"+escapePointer(key), value)
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
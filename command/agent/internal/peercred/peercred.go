@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package peercred reads the credentials of the process at the other end of
+// a unix socket connection, as reported by the kernel, to authorize the
+// local callers of the agent.
+package peercred
+
+// Credentials are the credentials of the peer of a connection. GID is the
+// primary group of the peer.
+type Credentials struct {
+	UID int
+	GID int
+	PID int
+}
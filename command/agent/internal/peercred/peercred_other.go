@@ -0,0 +1,16 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !linux
+
+package peercred
+
+import (
+	"errors"
+	"net"
+)
+
+// Get returns an error, as peer credentials are only supported on Linux.
+func Get(*net.UnixConn) (*Credentials, error) {
+	return nil, errors.New("peer credentials are only supported on Linux")
+}
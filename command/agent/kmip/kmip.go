@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package kmip serves the AES keys of a transit mount over a subset of the
+// KMIP 1.x protocol, so that storage arrays and databases which only support
+// external key management with KMIP can use keys kept in Vault.
+//
+// The clients are authenticated by their TLS certificates, and the server
+// accesses transit with the auto-auth token it receives as a sink. The keys
+// are identified by their names. The supported operations are Discover
+// Versions, Locate, Get, which returns the latest version of exportable keys,
+// and Encrypt and Decrypt, whose ciphertexts are those of transit.
+package kmip
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+)
+
+// idleTimeout bounds the time a connection waits for a request.
+const idleTimeout = 5 * time.Minute
+
+var _ sink.Sink = (*Server)(nil)
+
+type ServerConfig struct {
+	Logger hclog.Logger
+	Client *api.Client
+	Config *config.KMIP
+
+	Namespace string
+}
+
+// Server serves the keys of the transit mount of the config with the
+// auto-auth token it receives as a sink.
+type Server struct {
+	config *ServerConfig
+	logger hclog.Logger
+
+	lock  sync.RWMutex
+	token string
+}
+
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+	if conf.Client == nil {
+		return nil, errors.New("nil client provided")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("nil config provided")
+	}
+
+	return &Server{
+		config: conf,
+		logger: conf.Logger,
+	}, nil
+}
+
+// Listen listens on the address of the config with TLS, requiring the
+// clients to present a certificate signed by the client CA.
+func Listen(conf *config.KMIP) (net.Listener, error) {
+	cert, err := tls.LoadX509KeyPair(conf.TLSCertFile, conf.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	pem, err := os.ReadFile(conf.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA file: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("the client CA file contains no certificates")
+	}
+
+	return tls.Listen("tcp", conf.Address, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	})
+}
+
+// WriteToken stores the auto-auth token used to access transit.
+func (s *Server) WriteToken(token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.token = token
+	return nil
+}
+
+// client returns a client of transit with the auto-auth token.
+func (s *Server) client() (*api.Client, error) {
+	s.lock.RLock()
+	token := s.token
+	s.lock.RUnlock()
+
+	if token == "" {
+		return nil, errors.New("the agent has not authenticated yet")
+	}
+
+	client, err := s.config.Client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	if s.config.Namespace != "" {
+		client.SetNamespace(s.config.Namespace)
+	}
+	return client, nil
+}
+
+// Run serves the KMIP connections of the listener until the context is
+// canceled.
+func (s *Server) Run(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error accepting KMIP connection: %w", err)
+		}
+		go s.serve(ctx, conn)
+	}
+}
+
+// serve answers the requests of a connection until the client closes it,
+// sends an invalid message, or the context is canceled.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	client := conn.RemoteAddr().String()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn.SetDeadline(time.Now().Add(idleTimeout))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			s.logger.Warn("KMIP TLS handshake failed", "client", client, "error", err)
+			return
+		}
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			client = certs[0].Subject.CommonName
+		}
+	}
+
+	for {
+		conn.SetDeadline(time.Now().Add(idleTimeout))
+		request, err := readMessage(conn)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Debug("closing KMIP connection", "client", client, "error", err)
+			}
+			return
+		}
+
+		response := s.handle(ctx, client, request)
+		if _, err := conn.Write(response.encode()); err != nil {
+			s.logger.Debug("failed to write KMIP response", "client", client, "error", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kmip
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	"github.com/hashicorp/vault/command/agent/config"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate returns a certificate for the common name signed by the
+// parent, or self-signed if the parent is nil.
+func testCertificate(t *testing.T, name string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	issuer, signer := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+// writePEM writes the certificate, and its key if key is set, in a file of
+// the directory.
+func writePEM(t *testing.T, dir, name string, cert tls.Certificate, key bool) string {
+	t.Helper()
+
+	var b []byte
+	if key {
+		der, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+		require.NoError(t, err)
+		b = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	} else {
+		b = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	}
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+	return path
+}
+
+// testServer runs a server of a transit mount of a test cluster, with the
+// keys "exportable" and "internal", and an RSA key. It returns the server,
+// the root token of the cluster and a TLS config for the clients.
+func testServer(t *testing.T) (*Server, string, *tls.Config) {
+	t.Helper()
+
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	cluster.Start()
+	t.Cleanup(cluster.Cleanup)
+
+	client := cluster.Cores[0].Client
+	require.NoError(t, client.Sys().Mount("transit-kmip", &api.MountInput{Type: "transit"}))
+	keys := map[string]map[string]interface{}{
+		"exportable": {"type": "aes128-gcm96", "exportable": true},
+		"internal":   {"type": "aes256-gcm96"},
+		"rsa":        {"type": "rsa-2048"},
+	}
+	for name, data := range keys {
+		_, err := client.Logical().Write("transit-kmip/keys/"+name, data)
+		require.NoError(t, err)
+	}
+
+	dir := t.TempDir()
+	ca := testCertificate(t, "ca", nil)
+	serverCert := testCertificate(t, "127.0.0.1", &ca)
+	clientCert := testCertificate(t, "array", &ca)
+
+	s, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Client: client,
+		Config: &config.KMIP{
+			Address:         "127.0.0.1:0",
+			Mount:           "transit-kmip",
+			TLSCertFile:     writePEM(t, dir, "server.pem", serverCert, false),
+			TLSKeyFile:      writePEM(t, dir, "server-key.pem", serverCert, true),
+			TLSClientCAFile: writePEM(t, dir, "ca.pem", ca, false),
+		},
+	})
+	require.NoError(t, err)
+
+	ln, err := Listen(s.config.Config)
+	require.NoError(t, err)
+	s.config.Config.Address = ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	return s, cluster.RootToken, &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      roots,
+	}
+}
+
+// testRequest sends a request message with the batch items and returns the
+// batch items of the response.
+func testRequest(t *testing.T, s *Server, tlsConfig *tls.Config, minor int32, items ...*item) []*item {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", s.config.Config.Address, tlsConfig)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	request := structure(tagRequestMessage, append([]*item{
+		structure(tagRequestHeader,
+			structure(tagProtocolVersion,
+				integer(tagProtocolVersionMajor, 1),
+				integer(tagProtocolVersionMinor, minor),
+			),
+			integer(tagBatchCount, int32(len(items))),
+		),
+	}, items...)...)
+	_, err = conn.Write(request.encode())
+	require.NoError(t, err)
+
+	response, err := readMessage(conn)
+	require.NoError(t, err)
+	require.Equal(t, tagResponseMessage, response.Tag)
+	batchItems := response.all(tagBatchItem)
+	require.Len(t, batchItems, len(items))
+	return batchItems
+}
+
+func batchItem(operation uint32, payload ...*item) *item {
+	return structure(tagBatchItem,
+		enumeration(tagOperation, operation),
+		structure(tagRequestPayload, payload...),
+	)
+}
+
+// requireSuccess requires the batch item to be successful, and returns its
+// payload.
+func requireSuccess(t *testing.T, result *item) *item {
+	t.Helper()
+
+	status, _ := result.get(tagResultStatus).enum()
+	require.Equal(t, resultStatusSuccess, status, result.get(tagResultMessage).text())
+	return result.get(tagResponsePayload)
+}
+
+func requireFailure(t *testing.T, result *item, reason uint32) {
+	t.Helper()
+
+	status, _ := result.get(tagResultStatus).enum()
+	require.Equal(t, resultStatusOperationFailed, status)
+	actual, _ := result.get(tagResultReason).enum()
+	require.Equal(t, reason, actual, result.get(tagResultMessage).text())
+}
+
+func nameAttribute(name string) *item {
+	return structure(tagAttribute,
+		textString(tagAttributeName, "Name"),
+		structure(tagAttributeValue,
+			textString(tagNameValue, name),
+			enumeration(tagNameType, nameTypeUninterpretedTextString),
+		),
+	)
+}
+
+func TestServer_NotAuthenticated(t *testing.T) {
+	s, _, tlsConfig := testServer(t)
+
+	results := testRequest(t, s, tlsConfig, 4,
+		batchItem(operationDiscoverVersions),
+		batchItem(operationLocate),
+	)
+	requireSuccess(t, results[0])
+	requireFailure(t, results[1], reasonPermissionDenied)
+}
+
+func TestServer_ClientCertificate(t *testing.T) {
+	s, token, tlsConfig := testServer(t)
+	require.NoError(t, s.WriteToken(token))
+
+	// depending on the TLS version, the missing certificate fails the
+	// handshake or the first read
+	conn, err := tls.Dial("tcp", s.config.Config.Address, &tls.Config{RootCAs: tlsConfig.RootCAs})
+	if err == nil {
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		_, err = conn.Write(structure(tagRequestMessage).encode())
+		if err == nil {
+			_, err = readMessage(conn)
+		}
+	}
+	require.Error(t, err)
+}
+
+func TestServer_DiscoverVersions(t *testing.T) {
+	s, _, tlsConfig := testServer(t)
+
+	versions := func(payload *item) []int64 {
+		var minors []int64
+		for _, version := range payload.all(tagProtocolVersion) {
+			require.Equal(t, int64(1), version.get(tagProtocolVersionMajor).Int)
+			minors = append(minors, version.get(tagProtocolVersionMinor).Int)
+		}
+		return minors
+	}
+
+	results := testRequest(t, s, tlsConfig, 1,
+		batchItem(operationDiscoverVersions),
+		batchItem(operationDiscoverVersions,
+			structure(tagProtocolVersion, integer(tagProtocolVersionMajor, 2), integer(tagProtocolVersionMinor, 0)),
+			structure(tagProtocolVersion, integer(tagProtocolVersionMajor, 1), integer(tagProtocolVersionMinor, 2)),
+		),
+	)
+	require.Equal(t, []int64{4, 3, 2, 1, 0}, versions(requireSuccess(t, results[0])))
+	require.Equal(t, []int64{2}, versions(requireSuccess(t, results[1])))
+}
+
+func TestServer_Locate(t *testing.T) {
+	s, token, tlsConfig := testServer(t)
+	require.NoError(t, s.WriteToken(token))
+
+	identifiers := func(payload *item) []string {
+		var ids []string
+		for _, id := range payload.all(tagUniqueIdentifier) {
+			ids = append(ids, id.text())
+		}
+		return ids
+	}
+
+	results := testRequest(t, s, tlsConfig, 4,
+		batchItem(operationLocate),
+		batchItem(operationLocate,
+			structure(tagAttribute,
+				textString(tagAttributeName, "Cryptographic Length"),
+				integer(tagAttributeValue, 256),
+			),
+			structure(tagAttribute,
+				textString(tagAttributeName, "Object Type"),
+				enumeration(tagAttributeValue, objectTypeSymmetricKey),
+			),
+		),
+		batchItem(operationLocate, nameAttribute("exportable")),
+		batchItem(operationLocate, nameAttribute("rsa")),
+		batchItem(operationLocate, integer(tagMaximumItems, 1)),
+		batchItem(operationLocate,
+			structure(tagAttribute,
+				textString(tagAttributeName, "x-custom"),
+				textString(tagAttributeValue, "value"),
+			),
+		),
+	)
+	require.ElementsMatch(t, []string{"exportable", "internal"}, identifiers(requireSuccess(t, results[0])))
+	require.Equal(t, []string{"internal"}, identifiers(requireSuccess(t, results[1])))
+	require.Equal(t, []string{"exportable"}, identifiers(requireSuccess(t, results[2])))
+	require.Empty(t, identifiers(requireSuccess(t, results[3])))
+	require.Len(t, identifiers(requireSuccess(t, results[4])), 1)
+	require.Empty(t, identifiers(requireSuccess(t, results[5])))
+}
+
+func TestServer_Get(t *testing.T) {
+	s, token, tlsConfig := testServer(t)
+	require.NoError(t, s.WriteToken(token))
+
+	secret, err := s.config.Client.Logical().Read("transit-kmip/export/encryption-key/exportable/latest")
+	require.NoError(t, err)
+	var expected []byte
+	for _, v := range secret.Data["keys"].(map[string]interface{}) {
+		expected, err = base64.StdEncoding.DecodeString(v.(string))
+		require.NoError(t, err)
+	}
+
+	// the get uses the identifier located by the previous item
+	results := testRequest(t, s, tlsConfig, 0,
+		batchItem(operationLocate, nameAttribute("exportable")),
+		batchItem(operationGet),
+		batchItem(operationGet, textString(tagUniqueIdentifier, "internal")),
+		batchItem(operationGet, textString(tagUniqueIdentifier, "rsa")),
+		batchItem(operationGet, textString(tagUniqueIdentifier, "missing")),
+		batchItem(operationGet, textString(tagUniqueIdentifier, "exportable"), enumeration(tagKeyFormatType, 0x03)),
+		batchItem(operationGet),
+	)
+	requireSuccess(t, results[0])
+
+	payload := requireSuccess(t, results[1])
+	require.Equal(t, "exportable", payload.get(tagUniqueIdentifier).text())
+	objectType, _ := payload.get(tagObjectType).enum()
+	require.Equal(t, objectTypeSymmetricKey, objectType)
+	keyBlock := payload.get(tagSymmetricKey).get(tagKeyBlock)
+	require.Equal(t, expected, keyBlock.get(tagKeyValue).get(tagKeyMaterial).Bytes)
+	require.Equal(t, int64(128), keyBlock.get(tagCryptographicLength).Int)
+
+	requireFailure(t, results[2], reasonPermissionDenied)
+	requireFailure(t, results[3], reasonItemNotFound)
+	requireFailure(t, results[4], reasonItemNotFound)
+	requireFailure(t, results[5], reasonKeyFormatTypeNotSupported)
+	requireFailure(t, results[6], reasonMissingData)
+}
+
+func TestServer_EncryptDecrypt(t *testing.T) {
+	s, token, tlsConfig := testServer(t)
+	require.NoError(t, s.WriteToken(token))
+
+	results := testRequest(t, s, tlsConfig, 2,
+		batchItem(operationEncrypt,
+			textString(tagUniqueIdentifier, "internal"),
+			byteString(tagData, []byte("secret data")),
+		),
+	)
+	ciphertext := requireSuccess(t, results[0]).get(tagData).Bytes
+	require.Contains(t, string(ciphertext), "vault:v1:")
+
+	results = testRequest(t, s, tlsConfig, 4,
+		batchItem(operationDecrypt,
+			textString(tagUniqueIdentifier, "internal"),
+			byteString(tagData, ciphertext),
+		),
+		batchItem(operationDecrypt,
+			textString(tagUniqueIdentifier, "exportable"),
+			byteString(tagData, ciphertext),
+		),
+		batchItem(operationEncrypt,
+			textString(tagUniqueIdentifier, "internal"),
+			byteString(tagData, []byte("secret data")),
+			byteString(tagIVCounterNonce, make([]byte, 12)),
+		),
+		batchItem(operationEncrypt,
+			textString(tagUniqueIdentifier, "rsa"),
+			byteString(tagData, []byte("secret data")),
+		),
+	)
+	require.Equal(t, []byte("secret data"), requireSuccess(t, results[0]).get(tagData).Bytes)
+	requireFailure(t, results[1], reasonCryptographicFailure)
+	requireFailure(t, results[2], reasonFeatureNotSupported)
+	requireFailure(t, results[3], reasonItemNotFound)
+
+	// encrypt and decrypt are not part of KMIP 1.1
+	results = testRequest(t, s, tlsConfig, 1,
+		batchItem(operationEncrypt,
+			textString(tagUniqueIdentifier, "internal"),
+			byteString(tagData, []byte("secret data")),
+		),
+	)
+	requireFailure(t, results[0], reasonOperationNotSupported)
+}
+
+func TestServer_InvalidRequests(t *testing.T) {
+	s, token, tlsConfig := testServer(t)
+	require.NoError(t, s.WriteToken(token))
+
+	results := testRequest(t, s, tlsConfig, 4, batchItem(operationGet+0x40))
+	requireFailure(t, results[0], reasonOperationNotSupported)
+
+	conn, err := tls.Dial("tcp", s.config.Config.Address, tlsConfig)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	request := structure(tagRequestMessage,
+		structure(tagRequestHeader,
+			structure(tagProtocolVersion,
+				integer(tagProtocolVersionMajor, 2),
+				integer(tagProtocolVersionMinor, 0),
+			),
+			integer(tagBatchCount, 1),
+		),
+		batchItem(operationDiscoverVersions),
+	)
+	_, err = conn.Write(request.encode())
+	require.NoError(t, err)
+	response, err := readMessage(conn)
+	require.NoError(t, err)
+	requireFailure(t, response.get(tagBatchItem), reasonInvalidMessage)
+
+	// the connection is closed after a message which cannot be decoded
+	_, err = conn.Write([]byte{0x42, 0x00, 0x78, 0x02, 0x00, 0x00, 0x00, 0x04})
+	require.NoError(t, err)
+	_, err = readMessage(conn)
+	require.Error(t, err)
+}
@@ -0,0 +1,465 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kmip
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// maxMinorVersion is the latest minor version of KMIP 1.x supported.
+const maxMinorVersion = 4
+
+// keySizes are the sizes in bits of the transit key types served.
+var keySizes = map[string]int32{
+	"aes128-gcm96": 128,
+	"aes256-gcm96": 256,
+}
+
+// failure is the failure of a batch item.
+type failure struct {
+	reason  uint32
+	message string
+}
+
+func (f *failure) Error() string {
+	return f.message
+}
+
+func fail(reason uint32, format string, args ...interface{}) *failure {
+	return &failure{
+		reason:  reason,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// batch is the state shared by the items of a request.
+type batch struct {
+	client *api.Client
+	minor  int32
+
+	// placeholder is the unique identifier used by the items which do not
+	// have one, set by the previous items.
+	placeholder string
+}
+
+// handle performs the operations of a request message and returns the
+// response message.
+func (s *Server) handle(ctx context.Context, clientName string, request *item) *item {
+	header := request.get(tagRequestHeader)
+	if request.Tag != tagRequestMessage || header == nil {
+		return response(0, structure(tagBatchItem, failed(fail(reasonInvalidMessage, "invalid request message"))...))
+	}
+
+	var major, minor int32
+	if version := header.get(tagProtocolVersion); version != nil {
+		if i := version.get(tagProtocolVersionMajor); i != nil {
+			major = int32(i.Int)
+		}
+		if i := version.get(tagProtocolVersionMinor); i != nil {
+			minor = int32(i.Int)
+		}
+	}
+	if major != 1 {
+		return response(0, structure(tagBatchItem, failed(fail(reasonInvalidMessage, "unsupported protocol version %d.%d", major, minor))...))
+	}
+	if minor > maxMinorVersion {
+		minor = maxMinorVersion
+	}
+
+	b := &batch{minor: minor}
+	var err error
+	b.client, err = s.client()
+
+	var items []*item
+	for _, request := range request.all(tagBatchItem) {
+		operation, _ := request.get(tagOperation).enum()
+		result := structure(tagBatchItem, enumeration(tagOperation, operation))
+		if id := request.get(tagUniqueBatchItemID); id != nil {
+			result.Items = append(result.Items, id)
+		}
+
+		var payload *item
+		var f *failure
+		if err != nil && operation != operationDiscoverVersions {
+			f = fail(reasonPermissionDenied, "%s", err)
+		} else {
+			payload, f = s.perform(ctx, b, operation, request.get(tagRequestPayload))
+		}
+
+		if f != nil {
+			s.logger.Debug("KMIP operation failed", "client", clientName, "operation", operation, "error", f.message)
+			b.placeholder = ""
+			result.Items = append(result.Items, failed(f)...)
+		} else {
+			s.logger.Trace("KMIP operation performed", "client", clientName, "operation", operation)
+			result.Items = append(result.Items, enumeration(tagResultStatus, resultStatusSuccess), payload)
+		}
+		items = append(items, result)
+	}
+
+	return response(minor, items...)
+}
+
+// response returns a response message with the batch items.
+func response(minor int32, items ...*item) *item {
+	header := structure(tagResponseHeader,
+		structure(tagProtocolVersion,
+			integer(tagProtocolVersionMajor, 1),
+			integer(tagProtocolVersionMinor, minor),
+		),
+		dateTime(tagTimeStamp, time.Now()),
+		integer(tagBatchCount, int32(len(items))),
+	)
+	return structure(tagResponseMessage, append([]*item{header}, items...)...)
+}
+
+// failed returns the result items of a failure.
+func failed(f *failure) []*item {
+	return []*item{
+		enumeration(tagResultStatus, resultStatusOperationFailed),
+		enumeration(tagResultReason, f.reason),
+		textString(tagResultMessage, f.message),
+	}
+}
+
+// perform performs an operation and returns its response payload.
+func (s *Server) perform(ctx context.Context, b *batch, operation uint32, payload *item) (*item, *failure) {
+	if payload == nil {
+		payload = structure(tagRequestPayload)
+	}
+
+	switch operation {
+	case operationDiscoverVersions:
+		return discoverVersions(payload), nil
+	case operationLocate:
+		return s.locate(ctx, b, payload)
+	case operationGet:
+		return s.get(ctx, b, payload)
+	case operationEncrypt, operationDecrypt:
+		if b.minor < 2 {
+			return nil, fail(reasonOperationNotSupported, "encrypt and decrypt require KMIP 1.2 or later")
+		}
+		if operation == operationEncrypt {
+			return s.encrypt(ctx, b, payload)
+		}
+		return s.decrypt(ctx, b, payload)
+	default:
+		return nil, fail(reasonOperationNotSupported, "operation 0x%02X is not supported", operation)
+	}
+}
+
+// discoverVersions returns the supported protocol versions requested, or all
+// of them if none is.
+func discoverVersions(payload *item) *item {
+	requested := map[int32]bool{}
+	for _, version := range payload.all(tagProtocolVersion) {
+		major, minor := version.get(tagProtocolVersionMajor), version.get(tagProtocolVersionMinor)
+		if major != nil && minor != nil && major.Int == 1 {
+			requested[int32(minor.Int)] = true
+		}
+	}
+
+	result := structure(tagResponsePayload)
+	for minor := int32(maxMinorVersion); minor >= 0; minor-- {
+		if len(payload.Items) > 0 && !requested[minor] {
+			continue
+		}
+		result.Items = append(result.Items, structure(tagProtocolVersion,
+			integer(tagProtocolVersionMajor, 1),
+			integer(tagProtocolVersionMinor, minor),
+		))
+	}
+	return result
+}
+
+// key is a transit key served as a symmetric key.
+type key struct {
+	name       string
+	size       int32
+	exportable bool
+}
+
+// readKey reads a transit key, failing if it is not served.
+func (s *Server) readKey(ctx context.Context, client *api.Client, name string) (*key, *failure) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fail(reasonItemNotFound, "key %q not found", name)
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, s.config.Config.Mount+"/keys/"+name)
+	if err != nil {
+		return nil, transitFailure(err, reasonInvalidField)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fail(reasonItemNotFound, "key %q not found", name)
+	}
+
+	keyType, _ := secret.Data["type"].(string)
+	size, ok := keySizes[keyType]
+	if derived, _ := secret.Data["derived"].(bool); !ok || derived {
+		return nil, fail(reasonItemNotFound, "key %q is not a symmetric key", name)
+	}
+	exportable, _ := secret.Data["exportable"].(bool)
+
+	return &key{
+		name:       name,
+		size:       size,
+		exportable: exportable,
+	}, nil
+}
+
+// locate returns the identifiers of the keys matching the attributes of the
+// request.
+func (s *Server) locate(ctx context.Context, b *batch, payload *item) (*item, *failure) {
+	var maxItems int64
+	if i := payload.get(tagMaximumItems); i != nil {
+		maxItems = i.Int
+	}
+
+	secret, err := b.client.Logical().ListWithContext(ctx, s.config.Config.Mount+"/keys")
+	if err != nil {
+		return nil, transitFailure(err, reasonInvalidField)
+	}
+
+	var names []interface{}
+	if secret != nil && secret.Data != nil {
+		names, _ = secret.Data["keys"].([]interface{})
+	}
+
+	result := structure(tagResponsePayload)
+	for _, n := range names {
+		if maxItems > 0 && int64(len(result.Items)) >= maxItems {
+			break
+		}
+		name, _ := n.(string)
+		k, f := s.readKey(ctx, b.client, name)
+		if f != nil {
+			if f.reason == reasonItemNotFound {
+				continue
+			}
+			return nil, f
+		}
+		if matches(k, payload.all(tagAttribute)) {
+			result.Items = append(result.Items, textString(tagUniqueIdentifier, k.name))
+		}
+	}
+
+	b.placeholder = ""
+	if len(result.Items) > 0 {
+		b.placeholder = result.Items[0].text()
+	}
+	return result, nil
+}
+
+// matches returns whether the key has all the attributes. The attributes
+// not known by the server never match.
+func matches(k *key, attributes []*item) bool {
+	for _, attribute := range attributes {
+		value := attribute.get(tagAttributeValue)
+		if value == nil {
+			return false
+		}
+
+		switch attribute.get(tagAttributeName).text() {
+		case "Unique Identifier":
+			if value.text() != k.name {
+				return false
+			}
+		case "Name":
+			if value.get(tagNameValue).text() != k.name {
+				return false
+			}
+		case "Object Type":
+			if v, ok := value.enum(); !ok || v != objectTypeSymmetricKey {
+				return false
+			}
+		case "Cryptographic Algorithm":
+			if v, ok := value.enum(); !ok || v != algorithmAES {
+				return false
+			}
+		case "State":
+			if v, ok := value.enum(); !ok || v != stateActive {
+				return false
+			}
+		case "Cryptographic Length":
+			if value.Type != typeInteger || int32(value.Int) != k.size {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueIdentifier returns the unique identifier of the request, or the
+// placeholder if it has none.
+func (b *batch) uniqueIdentifier(payload *item) (string, *failure) {
+	if id := payload.get(tagUniqueIdentifier); id != nil {
+		return id.text(), nil
+	}
+	if b.placeholder == "" {
+		return "", fail(reasonMissingData, "the unique identifier is required")
+	}
+	return b.placeholder, nil
+}
+
+// get returns the material of the latest version of an exportable key.
+func (s *Server) get(ctx context.Context, b *batch, payload *item) (*item, *failure) {
+	if payload.get(tagKeyWrappingSpecification) != nil {
+		return nil, fail(reasonFeatureNotSupported, "key wrapping is not supported")
+	}
+	if format := payload.get(tagKeyFormatType); format != nil {
+		if v, ok := format.enum(); !ok || v != keyFormatTypeRaw {
+			return nil, fail(reasonKeyFormatTypeNotSupported, "only the raw key format is supported")
+		}
+	}
+
+	name, f := b.uniqueIdentifier(payload)
+	if f != nil {
+		return nil, f
+	}
+	k, f := s.readKey(ctx, b.client, name)
+	if f != nil {
+		return nil, f
+	}
+	if !k.exportable {
+		return nil, fail(reasonPermissionDenied, "key %q is not exportable", name)
+	}
+
+	secret, err := b.client.Logical().ReadWithContext(ctx, s.config.Config.Mount+"/export/encryption-key/"+name+"/latest")
+	if err != nil {
+		return nil, transitFailure(err, reasonInvalidField)
+	}
+	var encoded string
+	if secret != nil && secret.Data != nil {
+		keys, _ := secret.Data["keys"].(map[string]interface{})
+		for _, v := range keys {
+			encoded, _ = v.(string)
+		}
+	}
+	material, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(material) == 0 {
+		return nil, fail(reasonGeneralFailure, "transit returned no key material for key %q", name)
+	}
+
+	b.placeholder = name
+	return structure(tagResponsePayload,
+		enumeration(tagObjectType, objectTypeSymmetricKey),
+		textString(tagUniqueIdentifier, name),
+		structure(tagSymmetricKey,
+			structure(tagKeyBlock,
+				enumeration(tagKeyFormatType, keyFormatTypeRaw),
+				structure(tagKeyValue,
+					byteString(tagKeyMaterial, material),
+				),
+				enumeration(tagCryptographicAlgorithm, algorithmAES),
+				integer(tagCryptographicLength, k.size),
+			),
+		),
+	), nil
+}
+
+// cryptoRequest returns the key name and the data of an encrypt or decrypt
+// request. The key is read first, as transit would create a missing key on
+// encryption.
+func (s *Server) cryptoRequest(ctx context.Context, b *batch, payload *item) (string, []byte, *failure) {
+	if payload.get(tagIVCounterNonce) != nil {
+		return "", nil, fail(reasonFeatureNotSupported, "IVs chosen by the client are not supported")
+	}
+	name, f := b.uniqueIdentifier(payload)
+	if f != nil {
+		return "", nil, f
+	}
+	data := payload.get(tagData)
+	if data == nil || data.Type != typeByteString {
+		return "", nil, fail(reasonMissingData, "the data is required")
+	}
+	if _, f := s.readKey(ctx, b.client, name); f != nil {
+		return "", nil, f
+	}
+	return name, data.Bytes, nil
+}
+
+// encrypt encrypts the data with transit, and returns the ciphertext of
+// transit as the data.
+func (s *Server) encrypt(ctx context.Context, b *batch, payload *item) (*item, *failure) {
+	name, plaintext, f := s.cryptoRequest(ctx, b, payload)
+	if f != nil {
+		return nil, f
+	}
+
+	secret, err := b.client.Logical().WriteWithContext(ctx, s.config.Config.Mount+"/encrypt/"+name, map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	})
+	if err != nil {
+		return nil, transitFailure(err, reasonInvalidField)
+	}
+	var ciphertext string
+	if secret != nil && secret.Data != nil {
+		ciphertext, _ = secret.Data["ciphertext"].(string)
+	}
+	if ciphertext == "" {
+		return nil, fail(reasonGeneralFailure, "transit returned no ciphertext")
+	}
+
+	b.placeholder = name
+	return structure(tagResponsePayload,
+		textString(tagUniqueIdentifier, name),
+		byteString(tagData, []byte(ciphertext)),
+	), nil
+}
+
+// decrypt decrypts a ciphertext of transit.
+func (s *Server) decrypt(ctx context.Context, b *batch, payload *item) (*item, *failure) {
+	name, ciphertext, f := s.cryptoRequest(ctx, b, payload)
+	if f != nil {
+		return nil, f
+	}
+
+	secret, err := b.client.Logical().WriteWithContext(ctx, s.config.Config.Mount+"/decrypt/"+name, map[string]interface{}{
+		"ciphertext": string(ciphertext),
+	})
+	if err != nil {
+		return nil, transitFailure(err, reasonCryptographicFailure)
+	}
+	var encoded string
+	if secret != nil && secret.Data != nil {
+		encoded, _ = secret.Data["plaintext"].(string)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fail(reasonGeneralFailure, "transit returned an invalid plaintext")
+	}
+
+	b.placeholder = name
+	return structure(tagResponsePayload,
+		textString(tagUniqueIdentifier, name),
+		byteString(tagData, plaintext),
+	), nil
+}
+
+// transitFailure returns the failure for an error of a transit request: the
+// invalid reason for requests rejected because of their data.
+func transitFailure(err error, invalid uint32) *failure {
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusBadRequest:
+			return fail(invalid, "%s", strings.Join(respErr.Errors, "; "))
+		case http.StatusForbidden:
+			return fail(reasonPermissionDenied, "permission denied")
+		case http.StatusNotFound:
+			return fail(reasonItemNotFound, "item not found")
+		}
+	}
+	return fail(reasonGeneralFailure, "transit request failed: %s", err)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kmip
+
+import "fmt"
+
+// tag is the tag of a TTLV item.
+type tag uint32
+
+func (t tag) String() string {
+	return fmt.Sprintf("0x%06X", uint32(t))
+}
+
+const (
+	tagAttribute                tag = 0x420008
+	tagAttributeIndex           tag = 0x420009
+	tagAttributeName            tag = 0x42000A
+	tagAttributeValue           tag = 0x42000B
+	tagBatchCount               tag = 0x42000D
+	tagBatchItem                tag = 0x42000F
+	tagCryptographicAlgorithm   tag = 0x420028
+	tagCryptographicLength      tag = 0x42002A
+	tagCryptographicParameters  tag = 0x42002B
+	tagIVCounterNonce           tag = 0x42003D
+	tagKeyBlock                 tag = 0x420040
+	tagKeyFormatType            tag = 0x420042
+	tagKeyMaterial              tag = 0x420043
+	tagKeyValue                 tag = 0x420045
+	tagKeyWrappingSpecification tag = 0x420047
+	tagMaximumItems             tag = 0x42004F
+	tagName                     tag = 0x420053
+	tagNameType                 tag = 0x420054
+	tagNameValue                tag = 0x420055
+	tagObjectType               tag = 0x420057
+	tagOperation                tag = 0x42005C
+	tagProtocolVersion          tag = 0x420069
+	tagProtocolVersionMajor     tag = 0x42006A
+	tagProtocolVersionMinor     tag = 0x42006B
+	tagRequestHeader            tag = 0x420077
+	tagRequestMessage           tag = 0x420078
+	tagRequestPayload           tag = 0x420079
+	tagResponseHeader           tag = 0x42007A
+	tagResponseMessage          tag = 0x42007B
+	tagResponsePayload          tag = 0x42007C
+	tagResultMessage            tag = 0x42007D
+	tagResultReason             tag = 0x42007E
+	tagResultStatus             tag = 0x42007F
+	tagSymmetricKey             tag = 0x42008F
+	tagTimeStamp                tag = 0x420092
+	tagUniqueBatchItemID        tag = 0x420093
+	tagUniqueIdentifier         tag = 0x420094
+	tagData                     tag = 0x4200C2
+)
+
+// The values of the enumerations used by the server.
+const (
+	operationLocate           uint32 = 0x08
+	operationGet              uint32 = 0x0A
+	operationDiscoverVersions uint32 = 0x1E
+	operationEncrypt          uint32 = 0x1F
+	operationDecrypt          uint32 = 0x20
+
+	resultStatusSuccess         uint32 = 0x00
+	resultStatusOperationFailed uint32 = 0x01
+
+	reasonItemNotFound              uint32 = 0x01
+	reasonInvalidMessage            uint32 = 0x04
+	reasonOperationNotSupported     uint32 = 0x05
+	reasonMissingData               uint32 = 0x06
+	reasonInvalidField              uint32 = 0x07
+	reasonFeatureNotSupported       uint32 = 0x08
+	reasonCryptographicFailure      uint32 = 0x0A
+	reasonPermissionDenied          uint32 = 0x0C
+	reasonKeyFormatTypeNotSupported uint32 = 0x10
+	reasonGeneralFailure            uint32 = 0x100
+
+	objectTypeSymmetricKey uint32 = 0x02
+
+	algorithmAES uint32 = 0x03
+
+	keyFormatTypeRaw uint32 = 0x01
+
+	stateActive uint32 = 0x02
+
+	nameTypeUninterpretedTextString uint32 = 0x01
+)
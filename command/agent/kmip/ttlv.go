@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kmip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// maxMessageSize bounds the size of the request messages.
+	maxMessageSize = 1 << 20
+
+	// maxDepth bounds the nesting of the structures of the request messages.
+	maxDepth = 16
+)
+
+// itemType is the type of a TTLV item.
+type itemType byte
+
+const (
+	typeStructure   itemType = 0x01
+	typeInteger     itemType = 0x02
+	typeLongInteger itemType = 0x03
+	typeBigInteger  itemType = 0x04
+	typeEnumeration itemType = 0x05
+	typeBoolean     itemType = 0x06
+	typeTextString  itemType = 0x07
+	typeByteString  itemType = 0x08
+	typeDateTime    itemType = 0x09
+	typeInterval    itemType = 0x0A
+)
+
+// item is a TTLV item of a KMIP message: a tag, a type and a value. The
+// values of the structures are their items, the values of the numeric,
+// boolean and date-time types are in Int, and the values of the text, byte
+// string and big integer types are in Bytes.
+type item struct {
+	Tag   tag
+	Type  itemType
+	Items []*item
+	Int   int64
+	Bytes []byte
+}
+
+func structure(t tag, items ...*item) *item {
+	return &item{Tag: t, Type: typeStructure, Items: items}
+}
+
+func integer(t tag, v int32) *item {
+	return &item{Tag: t, Type: typeInteger, Int: int64(v)}
+}
+
+func enumeration(t tag, v uint32) *item {
+	return &item{Tag: t, Type: typeEnumeration, Int: int64(v)}
+}
+
+func textString(t tag, s string) *item {
+	return &item{Tag: t, Type: typeTextString, Bytes: []byte(s)}
+}
+
+func byteString(t tag, b []byte) *item {
+	return &item{Tag: t, Type: typeByteString, Bytes: b}
+}
+
+func dateTime(t tag, v time.Time) *item {
+	return &item{Tag: t, Type: typeDateTime, Int: v.Unix()}
+}
+
+// get returns the first item of the structure with the tag, or nil.
+func (i *item) get(t tag) *item {
+	for _, child := range i.Items {
+		if child.Tag == t {
+			return child
+		}
+	}
+	return nil
+}
+
+// all returns the items of the structure with the tag.
+func (i *item) all(t tag) []*item {
+	var items []*item
+	for _, child := range i.Items {
+		if child.Tag == t {
+			items = append(items, child)
+		}
+	}
+	return items
+}
+
+// text returns the value of a text string item, or "" for other items.
+func (i *item) text() string {
+	if i == nil || i.Type != typeTextString {
+		return ""
+	}
+	return string(i.Bytes)
+}
+
+// enum returns the value of an enumeration item, and whether it is one.
+func (i *item) enum() (uint32, bool) {
+	if i == nil || i.Type != typeEnumeration {
+		return 0, false
+	}
+	return uint32(i.Int), true
+}
+
+// fixedSizes are the sizes of the values of the types with a fixed size.
+var fixedSizes = map[itemType]int{
+	typeInteger:     4,
+	typeLongInteger: 8,
+	typeEnumeration: 4,
+	typeBoolean:     8,
+	typeDateTime:    8,
+	typeInterval:    4,
+}
+
+// readMessage reads a TTLV message.
+func readMessage(r io.Reader) (*item, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if itemType(header[3]) != typeStructure {
+		return nil, errors.New("the message is not a structure")
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length > maxMessageSize || length%8 != 0 {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+
+	b := make([]byte, 8+length)
+	copy(b, header[:])
+	if _, err := io.ReadFull(r, b[8:]); err != nil {
+		return nil, err
+	}
+
+	items, err := decode(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+// decode decodes the TTLV items of the bytes.
+func decode(b []byte, depth int) ([]*item, error) {
+	if depth > maxDepth {
+		return nil, errors.New("the structures are nested too deeply")
+	}
+
+	var items []*item
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return nil, errors.New("truncated item")
+		}
+		i := &item{
+			Tag:  tag(uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])),
+			Type: itemType(b[3]),
+		}
+		length := int(binary.BigEndian.Uint32(b[4:8]))
+		padded := (length + 7) &^ 7
+		if padded > len(b)-8 {
+			return nil, fmt.Errorf("item %s is truncated", i.Tag)
+		}
+		value := b[8 : 8+length]
+
+		if size, ok := fixedSizes[i.Type]; ok && length != size {
+			return nil, fmt.Errorf("item %s has an invalid length %d", i.Tag, length)
+		}
+		switch i.Type {
+		case typeStructure:
+			children, err := decode(value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			i.Items = children
+		case typeInteger:
+			i.Int = int64(int32(binary.BigEndian.Uint32(value)))
+		case typeEnumeration, typeInterval:
+			i.Int = int64(binary.BigEndian.Uint32(value))
+		case typeLongInteger, typeBoolean, typeDateTime:
+			i.Int = int64(binary.BigEndian.Uint64(value))
+		case typeBigInteger:
+			if length%8 != 0 {
+				return nil, fmt.Errorf("item %s has an invalid length %d", i.Tag, length)
+			}
+			i.Bytes = append([]byte(nil), value...)
+		case typeTextString, typeByteString:
+			i.Bytes = append([]byte(nil), value...)
+		default:
+			return nil, fmt.Errorf("item %s has an invalid type %d", i.Tag, i.Type)
+		}
+
+		items = append(items, i)
+		b = b[8+padded:]
+	}
+	return items, nil
+}
+
+// encode returns the TTLV encoding of the item.
+func (i *item) encode() []byte {
+	var value []byte
+	switch i.Type {
+	case typeStructure:
+		for _, child := range i.Items {
+			value = append(value, child.encode()...)
+		}
+	case typeInteger, typeEnumeration, typeInterval:
+		value = binary.BigEndian.AppendUint32(nil, uint32(i.Int))
+	case typeLongInteger, typeBoolean, typeDateTime:
+		value = binary.BigEndian.AppendUint64(nil, uint64(i.Int))
+	default:
+		value = i.Bytes
+	}
+
+	b := make([]byte, 8, 8+len(value)+7)
+	b[0], b[1], b[2] = byte(i.Tag>>16), byte(i.Tag>>8), byte(i.Tag)
+	b[3] = byte(i.Type)
+	binary.BigEndian.PutUint32(b[4:], uint32(len(value)))
+	b = append(b, value...)
+	for len(b)%8 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package kmip
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncode ensures the items are encoded as in the examples of the KMIP
+// specification.
+func TestEncode(t *testing.T) {
+	tests := map[string]struct {
+		item     *item
+		expected string
+	}{
+		"integer": {
+			item:     integer(tagCryptographicLength, 8),
+			expected: "42002A02000000040000000800000000",
+		},
+		"enumeration": {
+			item:     enumeration(tagObjectType, 255),
+			expected: "4200570500000004000000FF00000000",
+		},
+		"text string": {
+			item:     textString(tagNameValue, "Hello World"),
+			expected: "420055070000000B48656C6C6F20576F726C640000000000",
+		},
+		"byte string": {
+			item:     byteString(tagData, []byte{1, 2, 3}),
+			expected: "4200C208000000030102030000000000",
+		},
+		"date-time": {
+			item:     dateTime(tagTimeStamp, time.Date(2008, 3, 14, 11, 56, 40, 0, time.UTC)),
+			expected: "42009209000000080000000047DA67F8",
+		},
+		"structure": {
+			item:     structure(tagProtocolVersion, integer(tagProtocolVersionMajor, 1), integer(tagProtocolVersionMinor, 4)),
+			expected: "420069010000002042006A0200000004000000010000000042006B02000000040000000400000000",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected, err := hex.DecodeString(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, expected, tc.item.encode())
+		})
+	}
+}
+
+// TestReadMessage ensures the messages are decoded to the items they were
+// encoded from.
+func TestReadMessage(t *testing.T) {
+	message := structure(tagRequestMessage,
+		structure(tagRequestHeader,
+			structure(tagProtocolVersion,
+				integer(tagProtocolVersionMajor, 1),
+				integer(tagProtocolVersionMinor, 2),
+			),
+			integer(tagBatchCount, -1),
+		),
+		structure(tagBatchItem,
+			enumeration(tagOperation, operationEncrypt),
+			structure(tagRequestPayload,
+				textString(tagUniqueIdentifier, "key"),
+				byteString(tagData, []byte("plaintext")),
+			),
+		),
+		dateTime(tagTimeStamp, time.Unix(1700000000, 0)),
+	)
+
+	decoded, err := readMessage(bytes.NewReader(message.encode()))
+	require.NoError(t, err)
+	require.Equal(t, message, decoded)
+}
+
+// TestReadMessage_Invalid ensures the invalid messages are rejected.
+func TestReadMessage_Invalid(t *testing.T) {
+	nested := integer(tagBatchCount, 1)
+	for i := 0; i <= maxDepth+1; i++ {
+		nested = structure(tagRequestMessage, nested)
+	}
+
+	tests := map[string]string{
+		"not a structure":    "42000D02000000040000000100000000",
+		"too large":          "4200780100200000",
+		"unaligned length":   "4200780100000004",
+		"truncated message":  "420078010000001042000D0200000004",
+		"truncated item":     "420078010000000842000D0200000004",
+		"invalid length":     "420078010000001042000D02000000080000000100000000",
+		"invalid type":       "420078010000001042000D0B000000040000000100000000",
+		"nested too deeply":  hex.EncodeToString(nested.encode()),
+		"invalid big length": "420078010000001042000D04000000040000000100000000",
+	}
+
+	for name, message := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(message)
+			require.NoError(t, err)
+			_, err = readMessage(bytes.NewReader(b))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsapi
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/hashicorp/vault/command/agent/internal/peercred"
+	"google.golang.org/grpc/credentials"
+)
+
+// peerCredentials are the credentials of the process at the other end of a
+// unix socket connection, as reported by the kernel.
+type peerCredentials struct {
+	credentials.CommonAuthInfo
+	peercred.Credentials
+}
+
+func (*peerCredentials) AuthType() string {
+	return "peercred"
+}
+
+// peerCredentialsTransport are the transport credentials of the server,
+// attaching the credentials of the peer to the connections.
+type peerCredentialsTransport struct{}
+
+var _ credentials.TransportCredentials = peerCredentialsTransport{}
+
+func (peerCredentialsTransport) ClientHandshake(context.Context, string, net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("peer credentials are only supported by the server")
+}
+
+func (peerCredentialsTransport) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, errors.New("peer credentials are only supported on unix sockets")
+	}
+
+	creds, err := peercred.Get(unixConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, &peerCredentials{
+		// The connection doesn't leave the host, like the ones of the local
+		// credentials of gRPC on unix sockets.
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		Credentials:    *creds,
+	}, nil
+}
+
+func (peerCredentialsTransport) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (t peerCredentialsTransport) Clone() credentials.TransportCredentials {
+	return t
+}
+
+func (peerCredentialsTransport) OverrideServerName(string) error {
+	return nil
+}
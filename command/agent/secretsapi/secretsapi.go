@@ -0,0 +1,373 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package secretsapi serves the secrets Vault Agent keeps up to date to the
+// applications on the same host, over a gRPC API on a unix socket. The
+// applications can get the current version of a secret, or watch it to be
+// notified of its new versions, instead of re-reading files rendered by
+// templates. Callers are authorized by the user and group IDs of their
+// process, from the credentials of their connection to the socket.
+package secretsapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	pb "github.com/hashicorp/vault/command/agent/secretsapi/proto"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// minBackoff and maxBackoff bound the delay before reading a secret
+	// again after an error.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+var (
+	_ sink.Sink               = (*Server)(nil)
+	_ pb.SecretsServiceServer = (*Server)(nil)
+)
+
+type ServerConfig struct {
+	Logger hclog.Logger
+	Client *api.Client
+	Config *config.SecretsAPI
+
+	Namespace string
+}
+
+// Server reads the secrets of the config with the auto-auth token it receives
+// as a sink, and serves them on the secrets API.
+type Server struct {
+	pb.UnimplementedSecretsServiceServer
+
+	config     *ServerConfig
+	logger     hclog.Logger
+	grpcServer *grpc.Server
+
+	// tokenCh is closed when the first auto-auth token is received.
+	tokenCh   chan struct{}
+	tokenOnce sync.Once
+
+	lock    sync.RWMutex
+	token   string
+	secrets map[string]*secret
+}
+
+// secret is the state of a secret served by the API.
+type secret struct {
+	config  *config.SecretsAPISecret
+	current *pb.Secret
+
+	// updatedCh is closed when a new version of the secret is read, and
+	// replaced by a new channel.
+	updatedCh chan struct{}
+}
+
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+	if conf.Client == nil {
+		return nil, errors.New("nil client provided")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("nil config provided")
+	}
+
+	s := &Server{
+		config:  conf,
+		logger:  conf.Logger,
+		tokenCh: make(chan struct{}),
+		secrets: make(map[string]*secret, len(conf.Config.Secrets)),
+	}
+	for _, sc := range conf.Config.Secrets {
+		s.secrets[sc.Name] = &secret{
+			config:    sc,
+			updatedCh: make(chan struct{}),
+		}
+	}
+
+	s.grpcServer = grpc.NewServer(grpc.Creds(peerCredentialsTransport{}))
+	pb.RegisterSecretsServiceServer(s.grpcServer, s)
+
+	return s, nil
+}
+
+// Listen listens on the unix socket at the address, replacing a socket left
+// over by a previous run. Any local process can connect to the socket, as
+// the callers are authorized by their credentials.
+func Listen(address string) (net.Listener, error) {
+	if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove existing socket: %w", err)
+	}
+
+	ln, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0o666); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set the permissions of the socket: %w", err)
+	}
+	return ln, nil
+}
+
+// WriteToken stores the auto-auth token used to read the secrets.
+func (s *Server) WriteToken(token string) error {
+	s.lock.Lock()
+	s.token = token
+	s.lock.Unlock()
+
+	s.tokenOnce.Do(func() {
+		close(s.tokenCh)
+	})
+	return nil
+}
+
+// Run serves the API on the listener and keeps the secrets up to date, until
+// the context is canceled.
+func (s *Server) Run(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.grpcServer.Serve(ln)
+	}()
+
+	var wg sync.WaitGroup
+	for _, sec := range s.secrets {
+		wg.Add(1)
+		go func(sec *secret) {
+			defer wg.Done()
+			s.refresh(ctx, sec)
+		}(sec)
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return nil
+	case err := <-errCh:
+		return fmt.Errorf("error serving the secrets API: %w", err)
+	}
+}
+
+// Stop stops serving the API, closing the connections of the callers.
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+// refresh reads a secret once the agent has authenticated, then again before
+// its lease expires, or at the refresh interval for secrets without leases.
+func (s *Server) refresh(ctx context.Context, sec *secret) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-s.tokenCh:
+	}
+
+	backoff := minBackoff
+	for {
+		next, err := s.read(ctx, sec)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			s.logger.Error("failed to read secret", "name", sec.config.Name, "path", sec.config.Path, "error", err)
+			next = backoff
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		default:
+			backoff = minBackoff
+		}
+
+		timer := time.NewTimer(next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// read reads a secret, storing it as a new version if it changed, and returns
+// when to read it again.
+func (s *Server) read(ctx context.Context, sec *secret) (time.Duration, error) {
+	s.lock.RLock()
+	token := s.token
+	s.lock.RUnlock()
+
+	client, err := s.config.Client.CloneWithHeaders()
+	if err != nil {
+		return 0, err
+	}
+	client.SetToken(token)
+	if s.config.Namespace != "" {
+		client.SetNamespace(s.config.Namespace)
+	}
+
+	resp, err := client.Logical().ReadWithContext(ctx, sec.config.Path)
+	if err != nil {
+		return 0, err
+	}
+	if resp == nil {
+		return 0, fmt.Errorf("no secret at %q", sec.config.Path)
+	}
+
+	data, err := toStruct(resp.Data)
+	if err != nil {
+		return 0, err
+	}
+
+	next := s.config.Config.RefreshInterval
+	if resp.LeaseDuration > 0 {
+		next = time.Duration(resp.LeaseDuration) * time.Second * 2 / 3
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := sec.current
+	if current != nil &&
+		proto.Equal(current.Data, data) &&
+		current.LeaseDuration == int64(resp.LeaseDuration) &&
+		current.Renewable == resp.Renewable {
+		return next, nil
+	}
+
+	var version uint64 = 1
+	if current != nil {
+		version = current.Version + 1
+	}
+	sec.current = &pb.Secret{
+		Name:          sec.config.Name,
+		Path:          sec.config.Path,
+		Data:          data,
+		LeaseDuration: int64(resp.LeaseDuration),
+		Renewable:     resp.Renewable,
+		Version:       version,
+		UpdateTime:    timestamppb.Now(),
+	}
+	close(sec.updatedCh)
+	sec.updatedCh = make(chan struct{})
+
+	s.logger.Debug("read new version of secret", "name", sec.config.Name, "version", version)
+	return next, nil
+}
+
+// toStruct converts the data of a response, which holds JSON numbers, to a
+// struct.
+func toStruct(data map[string]interface{}) (*structpb.Struct, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &structpb.Struct{}
+	if err := protojson.Unmarshal(raw, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSecret returns the current version of a secret.
+func (s *Server) GetSecret(ctx context.Context, req *pb.GetSecretRequest) (*pb.GetSecretResponse, error) {
+	sec, err := s.authorize(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	current := sec.current
+	s.lock.RUnlock()
+
+	if current == nil {
+		return nil, status.Errorf(codes.Unavailable, "secret %q has not been read yet", req.Name)
+	}
+	return &pb.GetSecretResponse{Secret: current}, nil
+}
+
+// WatchSecret sends the current version of a secret, then its new versions.
+func (s *Server) WatchSecret(req *pb.WatchSecretRequest, stream pb.SecretsService_WatchSecretServer) error {
+	ctx := stream.Context()
+	sec, err := s.authorize(ctx, req.Name)
+	if err != nil {
+		return err
+	}
+
+	var sent uint64
+	for {
+		s.lock.RLock()
+		current, updatedCh := sec.current, sec.updatedCh
+		s.lock.RUnlock()
+
+		if current != nil && current.Version != sent {
+			if err := stream.Send(&pb.WatchSecretResponse{Secret: current}); err != nil {
+				return err
+			}
+			sent = current.Version
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-updatedCh:
+		}
+	}
+}
+
+// authorize returns the secret of the given name, if the caller is allowed to
+// read it. Secrets without allowed user or group IDs can only be read by the
+// processes running as the user of the agent.
+func (s *Server) authorize(ctx context.Context, name string) (*secret, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no peer credentials")
+	}
+	creds, ok := p.AuthInfo.(*peerCredentials)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no peer credentials")
+	}
+
+	sec, ok := s.secrets[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown secret %q", name)
+	}
+
+	allowed := slices.Contains(sec.config.AllowedUIDs, creds.UID) || slices.Contains(sec.config.AllowedGIDs, creds.GID)
+	if len(sec.config.AllowedUIDs) == 0 && len(sec.config.AllowedGIDs) == 0 {
+		allowed = creds.UID == os.Getuid()
+	}
+	if !allowed {
+		s.logger.Warn("denied access to secret", "name", name, "uid", creds.UID, "gid", creds.GID, "pid", creds.PID)
+		return nil, status.Errorf(codes.PermissionDenied, "not allowed to read secret %q", name)
+	}
+	return sec, nil
+}
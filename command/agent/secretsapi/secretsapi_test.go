@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build linux
+
+package secretsapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+	pb "github.com/hashicorp/vault/command/agent/secretsapi/proto"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// testServer runs a secrets API server backed by a fake Vault, serving at
+// secret/data/app a KV secret whose password is the value of the counter, and
+// returns a client of the API.
+func testServer(t *testing.T, secrets []*config.SecretsAPISecret, password *int32) (*Server, pb.SecretsServiceClient) {
+	t.Helper()
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/app" || r.Header.Get(consts.AuthHeaderName) != "auto-auth-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"password": atomic.LoadInt32(password),
+				},
+			},
+		})
+	}))
+	t.Cleanup(vault.Close)
+
+	client, err := api.NewClient(&api.Config{Address: vault.URL})
+	require.NoError(t, err)
+
+	s, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Client: client,
+		Config: &config.SecretsAPI{
+			Address:         filepath.Join(t.TempDir(), "agent.sock"),
+			RefreshInterval: 50 * time.Millisecond,
+			Secrets:         secrets,
+		},
+	})
+	require.NoError(t, err)
+
+	ln, err := Listen(s.config.Config.Address)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	conn, err := grpc.Dial("unix://"+s.config.Config.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return s, pb.NewSecretsServiceClient(conn)
+}
+
+// TestServer_GetSecret ensures secrets are served once the agent has
+// authenticated, to the callers they are allowed to.
+func TestServer_GetSecret(t *testing.T) {
+	password := new(int32)
+	s, client := testServer(t, []*config.SecretsAPISecret{
+		{Name: "app", Path: "secret/data/app"},
+		{Name: "allowed", Path: "secret/data/app", AllowedUIDs: []int{os.Getuid()}},
+		{Name: "allowed-group", Path: "secret/data/app", AllowedGIDs: []int{os.Getgid()}},
+		{Name: "other-user", Path: "secret/data/app", AllowedUIDs: []int{os.Getuid() + 1}},
+	}, password)
+	ctx := context.Background()
+
+	_, err := client.GetSecret(ctx, &pb.GetSecretRequest{Name: "app"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	require.Eventually(t, func() bool {
+		_, err := client.GetSecret(ctx, &pb.GetSecretRequest{Name: "app"})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, name := range []string{"app", "allowed", "allowed-group"} {
+		resp, err := client.GetSecret(ctx, &pb.GetSecretRequest{Name: name})
+		require.NoError(t, err, name)
+		require.Equal(t, name, resp.Secret.Name)
+		require.Equal(t, "secret/data/app", resp.Secret.Path)
+		require.Equal(t, uint64(1), resp.Secret.Version)
+		require.Equal(t, float64(0), resp.Secret.Data.AsMap()["data"].(map[string]interface{})["password"])
+	}
+
+	_, err = client.GetSecret(ctx, &pb.GetSecretRequest{Name: "other-user"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.GetSecret(ctx, &pb.GetSecretRequest{Name: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestServer_WatchSecret ensures watchers get the current version of a
+// secret, then its new versions only when it changes.
+func TestServer_WatchSecret(t *testing.T) {
+	password := new(int32)
+	s, client := testServer(t, []*config.SecretsAPISecret{
+		{Name: "app", Path: "secret/data/app"},
+	}, password)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.WatchSecret(ctx, &pb.WatchSecretRequest{Name: "app"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), resp.Secret.Version)
+
+	// Let the secret be read again without changes
+	time.Sleep(200 * time.Millisecond)
+	atomic.StoreInt32(password, 42)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), resp.Secret.Version)
+	require.Equal(t, float64(42), resp.Secret.Data.AsMap()["data"].(map[string]interface{})["password"])
+
+	stream, err = client.WatchSecret(ctx, &pb.WatchSecretRequest{Name: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package systemdcreds serves credentials to the systemd units which load
+// them with LoadCredential=, pointing at the unix socket of the server. The
+// credentials are the files templates render in a directory only the agent
+// can read, or the auto-auth token, and systemd places them in the protected
+// credentials directory of the units instead of world-readable paths.
+//
+// systemd binds the connections to an abstract address encoding the unit and
+// the credential it loads, and reads the credential until the server closes
+// the connection.
+package systemdcreds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/internal/peercred"
+	"github.com/hashicorp/vault/command/agentproxyshared/sink"
+	"golang.org/x/exp/slices"
+)
+
+// writeTimeout bounds the time to write a credential to systemd.
+const writeTimeout = 10 * time.Second
+
+var _ sink.Sink = (*Server)(nil)
+
+type ServerConfig struct {
+	Logger hclog.Logger
+	Config *config.SystemdCredentials
+}
+
+// Server serves the credentials of the config.
+type Server struct {
+	config      *ServerConfig
+	logger      hclog.Logger
+	credentials map[string]*config.SystemdCredential
+
+	// tokenCh is closed when the first auto-auth token is received.
+	tokenCh   chan struct{}
+	tokenOnce sync.Once
+
+	lock  sync.RWMutex
+	token string
+}
+
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("nil config provided")
+	}
+
+	if conf.Config.Directory != "" {
+		if err := os.MkdirAll(conf.Config.Directory, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create the credentials directory: %w", err)
+		}
+		info, err := os.Stat(conf.Config.Directory)
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			conf.Logger.Warn("the credentials directory can be accessed by other users", "directory", conf.Config.Directory, "mode", info.Mode().Perm())
+		}
+	}
+
+	s := &Server{
+		config:      conf,
+		logger:      conf.Logger,
+		credentials: make(map[string]*config.SystemdCredential, len(conf.Config.Credentials)),
+		tokenCh:     make(chan struct{}),
+	}
+	for _, credential := range conf.Config.Credentials {
+		s.credentials[credential.Name] = credential
+	}
+	return s, nil
+}
+
+// Listen listens on the unix socket at the address, replacing a socket left
+// over by a previous run. Only the user of the agent can connect to the
+// socket, besides root which systemd runs as.
+func Listen(address string) (net.Listener, error) {
+	if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove existing socket: %w", err)
+	}
+
+	ln, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set the permissions of the socket: %w", err)
+	}
+	return ln, nil
+}
+
+// WriteToken stores the auto-auth token served by the credentials of the
+// auto_auth_token type.
+func (s *Server) WriteToken(token string) error {
+	s.lock.Lock()
+	s.token = token
+	s.lock.Unlock()
+
+	s.tokenOnce.Do(func() {
+		close(s.tokenCh)
+	})
+	return nil
+}
+
+// ReadyCh returns a channel closed once the credentials of the auto-auth
+// token can be served, so the agent can notify systemd it is ready to start
+// the units loading them.
+func (s *Server) ReadyCh() <-chan struct{} {
+	for _, credential := range s.credentials {
+		if credential.AutoAuthToken {
+			return s.tokenCh
+		}
+	}
+
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// Run serves the credentials on the listener until the context is canceled.
+func (s *Server) Run(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error accepting systemd credentials connection: %w", err)
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve writes the credential requested by the connection, or closes it
+// without data, which systemd reports as a failure to load the credential.
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	unit, credential, err := s.authorize(conn)
+	if err != nil {
+		s.logger.Warn("refused credential request", "error", err)
+		return
+	}
+
+	data, err := s.read(credential)
+	if err != nil {
+		s.logger.Error("failed to read credential", "unit", unit, "credential", credential.Name, "error", err)
+		return
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := conn.Write(data); err != nil {
+		s.logger.Error("failed to write credential", "unit", unit, "credential", credential.Name, "error", err)
+		return
+	}
+	s.logger.Debug("served credential", "unit", unit, "credential", credential.Name)
+}
+
+// authorize returns the unit requesting a credential, and the credential, if
+// the request comes from systemd and the unit is allowed to load it.
+func (s *Server) authorize(conn net.Conn) (string, *config.SystemdCredential, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", nil, errors.New("not a unix socket connection")
+	}
+
+	creds, err := peercred.Get(unixConn)
+	if err != nil {
+		return "", nil, err
+	}
+	if creds.UID != 0 && creds.UID != os.Getuid() {
+		return "", nil, fmt.Errorf("connection from uid %d, which is neither root nor the user of the agent", creds.UID)
+	}
+
+	unit, name, err := parsePeerAddress(conn.RemoteAddr().String())
+	if err != nil {
+		return "", nil, err
+	}
+
+	credential, ok := s.credentials[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unit %q requested unknown credential %q", unit, name)
+	}
+	if len(credential.AllowedUnits) > 0 && !slices.Contains(credential.AllowedUnits, unit) {
+		return "", nil, fmt.Errorf("unit %q is not allowed to load credential %q", unit, name)
+	}
+	return unit, credential, nil
+}
+
+// parsePeerAddress returns the unit and the credential of the abstract
+// address systemd binds its connections to, which is formatted as
+// "@<random>/unit/<unit>/<credential>".
+func parsePeerAddress(addr string) (string, string, error) {
+	parts := strings.Split(addr, "/")
+	if len(parts) != 4 || !strings.HasPrefix(parts[0], "@") || parts[1] != "unit" || parts[2] == "" || parts[3] == "" {
+		return "", "", fmt.Errorf("peer address %q is not the one of a systemd credential request", addr)
+	}
+	return parts[2], parts[3], nil
+}
+
+func (s *Server) read(credential *config.SystemdCredential) ([]byte, error) {
+	if credential.AutoAuthToken {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+
+		if s.token == "" {
+			return nil, errors.New("the agent has not authenticated yet")
+		}
+		return []byte(s.token), nil
+	}
+
+	return os.ReadFile(filepath.Join(s.config.Config.Directory, credential.Name))
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build linux
+
+package systemdcreds
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer runs a server of the credentials, and returns it with the
+// address of its socket.
+func testServer(t *testing.T, credentials []*config.SystemdCredential) (*Server, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	s, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Config: &config.SystemdCredentials{
+			Address:     filepath.Join(dir, "credentials.sock"),
+			Directory:   filepath.Join(dir, "credentials"),
+			Credentials: credentials,
+		},
+	})
+	require.NoError(t, err)
+
+	info, err := os.Stat(s.config.Config.Directory)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	ln, err := Listen(s.config.Config.Address)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Run(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	return s, s.config.Config.Address
+}
+
+// testLoad loads a credential the way systemd does, binding the connection
+// to an abstract address naming the unit and the credential, if any.
+func testLoad(t *testing.T, address, bind string) string {
+	t.Helper()
+
+	var laddr *net.UnixAddr
+	if bind != "" {
+		laddr = &net.UnixAddr{Name: bind, Net: "unix"}
+	}
+	conn, err := net.DialUnix("unix", laddr, &net.UnixAddr{Name: address, Net: "unix"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestServer_Load ensures the credentials rendered in the directory are
+// served to the allowed units only.
+func TestServer_Load(t *testing.T) {
+	s, address := testServer(t, []*config.SystemdCredential{
+		{Name: "db-password", AllowedUnits: []string{"app.service"}},
+		{Name: "api-key"},
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(s.config.Config.Directory, "db-password"), []byte("hunter2"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(s.config.Config.Directory, "api-key"), []byte("key"), 0o600))
+
+	require.Equal(t, "hunter2", testLoad(t, address, "@4f1c2a/unit/app.service/db-password"))
+	require.Equal(t, "key", testLoad(t, address, "@4f1c2b/unit/other.service/api-key"))
+
+	require.Empty(t, testLoad(t, address, "@4f1c2c/unit/other.service/db-password"))
+	require.Empty(t, testLoad(t, address, "@4f1c2d/unit/app.service/unknown"))
+	require.Empty(t, testLoad(t, address, ""))
+}
+
+// TestServer_LoadToken ensures the auto-auth token is served once the agent
+// has authenticated, and that the server is only ready then.
+func TestServer_LoadToken(t *testing.T) {
+	s, address := testServer(t, []*config.SystemdCredential{
+		{Name: "vault-token", AutoAuthToken: true},
+	})
+
+	require.Empty(t, testLoad(t, address, "@4f1c2a/unit/app.service/vault-token"))
+	select {
+	case <-s.ReadyCh():
+		t.Fatal("server ready before the agent authenticated")
+	default:
+	}
+
+	require.NoError(t, s.WriteToken("auto-auth-token"))
+	<-s.ReadyCh()
+	require.Equal(t, "auto-auth-token", testLoad(t, address, "@4f1c2a/unit/app.service/vault-token"))
+}
+
+func TestParsePeerAddress(t *testing.T) {
+	unit, credential, err := parsePeerAddress("@9a8b7c6d5e4f/unit/app.service/db-password")
+	require.NoError(t, err)
+	require.Equal(t, "app.service", unit)
+	require.Equal(t, "db-password", credential)
+
+	for _, addr := range []string{"", "@", "/run/app.sock", "@1234/unit/app.service", "@1234/user/app.service/db-password"} {
+		_, _, err := parsePeerAddress(addr)
+		require.Error(t, err, addr)
+	}
+}
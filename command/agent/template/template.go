@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"go.uber.org/atomic"
 
@@ -67,6 +68,10 @@ type Server struct {
 	DoneCh  chan struct{}
 	stopped *atomic.Bool
 
+	// RenderedCh is closed once all the templates have been rendered.
+	RenderedCh   chan struct{}
+	renderedOnce sync.Once
+
 	logger        hclog.Logger
 	exitAfterAuth bool
 }
@@ -75,6 +80,7 @@ type Server struct {
 func NewServer(conf *ServerConfig) *Server {
 	ts := Server{
 		DoneCh:        make(chan struct{}),
+		RenderedCh:    make(chan struct{}),
 		stopped:       atomic.NewBool(false),
 		runnerStarted: atomic.NewBool(false),
 
@@ -219,6 +225,12 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 				}
 			}
 
+			if doneRendering {
+				ts.renderedOnce.Do(func() {
+					close(ts.RenderedCh)
+				})
+			}
+
 			if doneRendering && ts.exitAfterAuth {
 				// if we want to exit after auth, go ahead and shut down the runner and
 				// return. The deferred closing of the DoneCh will allow agent to
@@ -408,6 +408,12 @@ func TestServerRun(t *testing.T) {
 				}
 			}
 
+			select {
+			case <-server.RenderedCh:
+			default:
+				t.Fatal("RenderedCh not closed after the templates were rendered")
+			}
+
 			// verify test file exists and has the content we're looking for
 			var fileCount int
 			var errs []string
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	paths "path"
+	"path/filepath"
 	"sort"
 	"strings"
 	"unicode"
@@ -19,6 +20,7 @@ import (
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/go-homedir"
 	"github.com/posener/complete"
+	"github.com/zclconf/go-cty/cty"
 )
 
 var (
@@ -29,9 +31,42 @@ var (
 type AgentGenerateConfigCommand struct {
 	*BaseCommand
 
-	flagType  string
-	flagPaths []string
-	flagExec  string
+	flagType          string
+	flagPaths         []string
+	flagExec          string
+	flagDestination   string
+	flagAuthMethod    string
+	flagAuthMountPath string
+	flagAuthConfig    []string
+	flagCache         bool
+	flagInteractive   bool
+}
+
+const (
+	generateConfigTypeEnvTemplate  = "env-template"
+	generateConfigTypeFileTemplate = "file-template"
+)
+
+// agentAuthMethodConfigKeys are the auto_auth methods supported by the
+// generator along with the configuration keys each of them requires.
+var agentAuthMethodConfigKeys = map[string][]string{
+	"approle":    {"role_id_file_path", "secret_id_file_path"},
+	"aws":        {"type", "role"},
+	"azure":      {"role", "resource"},
+	"cert":       {},
+	"gcp":        {"type", "role"},
+	"jwt":        {"role", "path"},
+	"kubernetes": {"role"},
+	"token_file": {"token_file_path"},
+}
+
+func agentAuthMethods() []string {
+	methods := make([]string, 0, len(agentAuthMethodConfigKeys))
+	for method := range agentAuthMethodConfigKeys {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
 }
 
 func (c *AgentGenerateConfigCommand) Synopsis() string {
@@ -44,26 +79,34 @@ Usage: vault agent generate-config [options] [path/to/config.hcl]
 
   Generates a simple Vault Agent configuration file from the given parameters.
 
-  Currently, the only supported configuration type is 'env-template', which
-  helps you generate a configuration file with environment variable templates
-  for running Vault Agent in process supervisor mode.
+  Two configuration types are supported. 'env-template' generates a
+  configuration file with environment variable templates for running Vault
+  Agent in process supervisor mode. 'file-template' generates a configuration
+  file with templates rendering the secrets to files under the '-destination'
+  directory.
 
   For every specified secret -path, the command will attempt to generate one or
-  multiple 'env_template' entries based on the JSON key(s) stored in the
-  specified secret. If the secret -path ends with '/*', the command will
-  attempt to recurse through the secrets tree rooted at the given path,
-  generating 'env_template' entries for each encountered secret. Currently,
-  only kv-v1 and kv-v2 paths are supported.
+  multiple template entries based on the JSON key(s) stored in the specified
+  secret. If the secret -path ends with '/*', the command will attempt to
+  recurse through the secrets tree rooted at the given path, generating
+  template entries for each encountered secret. Currently, only kv-v1 and kv-v2
+  paths are supported.
 
   The command specified in the '-exec' option will be used to generate an
-  'exec' entry, which will tell Vault Agent which child process to run.
-
-  In addition to env_template entries, the command generates an 'auto_auth'
-  section with 'token_file' authentication method. While this method is very
-  convenient for local testing, it should NOT be used in production. Please
-  see https://developer.hashicorp.com/vault/docs/agent-and-proxy/autoauth/methods
+  'exec' entry, which will tell Vault Agent which child process to run. It is
+  only used with the 'env-template' type.
+
+  In addition to the templates, the command generates an 'auto_auth' section
+  for the '-auth-method' authentication method, configured with the
+  '-auth-config' values. The default 'token_file' method is very convenient
+  for local testing, but it should NOT be used in production. Please see
+  https://developer.hashicorp.com/vault/docs/agent-and-proxy/autoauth/methods
   for a list of production-ready auto_auth methods that you can use instead.
 
+  With the '-interactive' flag, the command prompts for the authentication
+  method, its required configuration and the secret paths when they are not
+  given as flags.
+
   By default, the file will be generated in the local directory as 'agent.hcl'
   unless a path is specified as an argument.
 
@@ -81,6 +124,16 @@ Usage: vault agent generate-config [options] [path/to/config.hcl]
                     -path="secret/bar" \
                     -path="secret/my-app/*"
 
+  Generate a file template configuration using AppRole authentication and an
+  API proxy with caching:
+
+      $ vault agent generate-config -type="file-template" \
+                    -auth-method="approle" \
+                    -auth-config="role_id_file_path=/etc/vault/role-id" \
+                    -auth-config="secret_id_file_path=/etc/vault/secret-id" \
+                    -cache \
+                    -path="secret/my-app/*"
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -96,9 +149,10 @@ func (c *AgentGenerateConfigCommand) Flags() *FlagSets {
 	f.StringVar(&StringVar{
 		Name:   "type",
 		Target: &c.flagType,
-		Usage:  "Type of configuration file to generate; currently, only 'env-template' is supported.",
+		Usage:  "Type of configuration file to generate; either 'env-template' or 'file-template'.",
 		Completion: complete.PredictSet(
-			"env-template",
+			generateConfigTypeEnvTemplate,
+			generateConfigTypeFileTemplate,
 		),
 	})
 
@@ -116,6 +170,48 @@ func (c *AgentGenerateConfigCommand) Flags() *FlagSets {
 		Usage:   "The command to execute in agent process supervisor mode.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "destination",
+		Target:     &c.flagDestination,
+		Default:    "secrets",
+		Completion: complete.PredictDirs(""),
+		Usage:      "Directory in which the secrets are rendered with the 'file-template' type.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "auth-method",
+		Target:     &c.flagAuthMethod,
+		Completion: complete.PredictSet(agentAuthMethods()...),
+		Usage: "The auto_auth method used by the agent, one of " +
+			strings.Join(agentAuthMethods(), ", ") + ". The default is 'token_file'.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "auth-mount",
+		Target: &c.flagAuthMountPath,
+		Usage:  "Mount path of the auth method (e.g. auth/my-approle), if it is not mounted at its default path.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "auth-config",
+		Target: &c.flagAuthConfig,
+		Usage:  "Configuration of the auth method, in the form key=value. This can be specified multiple times.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "cache",
+		Target:  &c.flagCache,
+		Default: false,
+		Usage:   "Generate a 'cache' section and an API proxy listening on 127.0.0.1:8100 which uses the auto_auth token.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "interactive",
+		Target:  &c.flagInteractive,
+		Default: false,
+		Usage:   "Prompt for the auth method, its configuration and the secret paths when they are not given as flags.",
+	})
+
 	return set
 }
 
@@ -143,22 +239,51 @@ func (c *AgentGenerateConfigCommand) Run(args []string) int {
 	}
 
 	if c.flagType == "" {
-		c.UI.Error(`Please specify a -type flag; currently only -type="env-template" is supported.`)
+		c.UI.Error(`Please specify a -type flag; either -type="env-template" or -type="file-template".`)
+		return 1
+	}
+
+	if c.flagType != generateConfigTypeEnvTemplate && c.flagType != generateConfigTypeFileTemplate {
+		c.UI.Error(fmt.Sprintf(`%q is not a supported configuration type; either -type="env-template" or -type="file-template" is supported.`, c.flagType))
 		return 1
 	}
 
-	if c.flagType != "env-template" {
-		c.UI.Error(fmt.Sprintf(`%q is not a supported configuration type; currently only -type="env-template" is supported.`, c.flagType))
+	authMethod, authConfig, err := c.authMethodConfig()
+	if err != nil {
+		c.UI.Error(err.Error())
 		return 1
 	}
 
+	paths := c.flagPaths
+	if len(paths) == 0 && c.flagInteractive {
+		answer, err := c.UI.Ask("Secret paths (comma-separated):")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading secret paths: %v", err))
+			return 1
+		}
+		for _, p := range strings.Split(answer, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
-	config, err := generateConfiguration(context.Background(), client, c.flagExec, c.flagPaths)
+	config, err := generateConfiguration(context.Background(), client, &generateConfigOptions{
+		Type:          c.flagType,
+		Exec:          c.flagExec,
+		Paths:         paths,
+		Destination:   c.flagDestination,
+		AuthMethod:    authMethod,
+		AuthMountPath: c.flagAuthMountPath,
+		AuthConfig:    authConfig,
+		Cache:         c.flagCache,
+	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error: %v", err))
 		return 2
@@ -189,25 +314,100 @@ func (c *AgentGenerateConfigCommand) Run(args []string) int {
 
 	c.UI.Info(fmt.Sprintf("Successfully generated %q configuration file!", configPath))
 
-	c.UI.Warn("Warning: the generated file uses 'token_file' authentication method, which is not suitable for production environments.")
+	if authMethod == "token_file" {
+		c.UI.Warn("Warning: the generated file uses 'token_file' authentication method, which is not suitable for production environments.")
+	}
 
 	return 0
 }
 
-func generateConfiguration(ctx context.Context, client *api.Client, flagExec string, flagPaths []string) (io.WriterTo, error) {
-	var execCommand []string
-	if flagExec != "" {
-		execCommand = strings.Split(flagExec, " ")
-	} else {
-		execCommand = []string{"env"}
+// authMethodConfig returns the auto_auth method and its configuration from the
+// flags, prompting for the missing values in interactive mode.
+func (c *AgentGenerateConfigCommand) authMethodConfig() (string, map[string]string, error) {
+	method := c.flagAuthMethod
+	if method == "" && c.flagInteractive {
+		answer, err := c.UI.Ask(fmt.Sprintf("Auth method (%s) [token_file]:", strings.Join(agentAuthMethods(), ", ")))
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading auth method: %w", err)
+		}
+		method = strings.TrimSpace(answer)
+	}
+	if method == "" {
+		method = "token_file"
 	}
 
-	tokenPath, err := homedir.Expand("~/.vault-token")
-	if err != nil {
-		return nil, fmt.Errorf("could not expand home directory: %w", err)
+	required, ok := agentAuthMethodConfigKeys[method]
+	if !ok {
+		return "", nil, fmt.Errorf("%q is not a supported auth method; supported methods are %s", method, strings.Join(agentAuthMethods(), ", "))
+	}
+
+	config := make(map[string]string, len(c.flagAuthConfig))
+	for _, kv := range c.flagAuthConfig {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return "", nil, fmt.Errorf("invalid -auth-config %q, must be in the form key=value", kv)
+		}
+		config[strings.TrimSpace(k)] = v
+	}
+
+	if method == "token_file" && config["token_file_path"] == "" {
+		tokenPath, err := homedir.Expand("~/.vault-token")
+		if err != nil {
+			return "", nil, fmt.Errorf("could not expand home directory: %w", err)
+		}
+		config["token_file_path"] = tokenPath
+	}
+
+	var missing []string
+	for _, key := range required {
+		if config[key] != "" {
+			continue
+		}
+		if c.flagInteractive {
+			answer, err := c.UI.Ask(fmt.Sprintf("Value of %q for the %s auth method:", key, method))
+			if err != nil {
+				return "", nil, fmt.Errorf("error reading %q: %w", key, err)
+			}
+			if answer = strings.TrimSpace(answer); answer != "" {
+				config[key] = answer
+				continue
+			}
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("the %s auth method requires the -auth-config values %s", method, strings.Join(missing, ", "))
+	}
+
+	return method, config, nil
+}
+
+// generateConfigOptions are the parameters of a generated agent configuration.
+type generateConfigOptions struct {
+	Type          string
+	Exec          string
+	Paths         []string
+	Destination   string
+	AuthMethod    string
+	AuthMountPath string
+	AuthConfig    map[string]string
+	Cache         bool
+}
+
+func generateConfiguration(ctx context.Context, client *api.Client, opts *generateConfigOptions) (io.WriterTo, error) {
+	authMethod, authConfig := opts.AuthMethod, opts.AuthConfig
+	if authMethod == "" {
+		tokenPath, err := homedir.Expand("~/.vault-token")
+		if err != nil {
+			return nil, fmt.Errorf("could not expand home directory: %w", err)
+		}
+		authMethod = "token_file"
+		authConfig = map[string]string{
+			"token_file_path": tokenPath,
+		}
 	}
 
-	templates, err := constructTemplates(ctx, client, flagPaths)
+	secrets, err := readGeneratedSecrets(ctx, client, opts.Paths)
 	if err != nil {
 		return nil, fmt.Errorf("could not generate templates: %w", err)
 	}
@@ -215,10 +415,8 @@ func generateConfiguration(ctx context.Context, client *api.Client, flagExec str
 	config := generatedConfig{
 		AutoAuth: generatedConfigAutoAuth{
 			Method: generatedConfigAutoAuthMethod{
-				Type: "token_file",
-				Config: generatedConfigAutoAuthMethodConfig{
-					TokenFilePath: tokenPath,
-				},
+				Type:   authMethod,
+				Config: authConfig,
 			},
 		},
 		TemplateConfig: generatedConfigTemplateConfig{
@@ -228,24 +426,142 @@ func generateConfiguration(ctx context.Context, client *api.Client, flagExec str
 		Vault: generatedConfigVault{
 			Address: client.Address(),
 		},
-		Exec: generatedConfigExec{
+	}
+	if opts.AuthMountPath != "" {
+		config.AutoAuth.Method.MountPath = &opts.AuthMountPath
+	}
+
+	switch opts.Type {
+	case "", generateConfigTypeEnvTemplate:
+		execCommand := []string{"env"}
+		if opts.Exec != "" {
+			execCommand = strings.Split(opts.Exec, " ")
+		}
+		config.EnvTemplates = envTemplatesFromSecrets(secrets)
+		config.Exec = &generatedConfigExec{
 			Command:                execCommand,
 			RestartOnSecretChanges: "always",
 			RestartStopSignal:      "SIGTERM",
-		},
-		EnvTemplates: templates,
+		}
+	case generateConfigTypeFileTemplate:
+		destination := opts.Destination
+		if destination == "" {
+			destination = "secrets"
+		}
+		config.Templates = fileTemplatesFromSecrets(secrets, destination)
+	default:
+		return nil, fmt.Errorf("%q is not a supported configuration type", opts.Type)
+	}
+
+	if opts.Cache {
+		config.Cache = &generatedConfigCache{}
+		config.APIProxy = &generatedConfigAPIProxy{
+			UseAutoAuthToken: true,
+		}
+		config.Listeners = []generatedConfigListener{
+			{
+				Type:       "tcp",
+				Address:    "127.0.0.1:8100",
+				TLSDisable: true,
+			},
+		}
 	}
 
 	contents := hclwrite.NewEmptyFile()
 
 	gohcl.EncodeIntoBody(&config, contents.Body())
 
+	// The method configuration has arbitrary keys, so it is added by hand as
+	// a block rather than encoded as a map attribute.
+	method := contents.Body().FirstMatchingBlock("auto_auth", nil).Body().FirstMatchingBlock("method", nil).Body()
+	if len(authConfig) > 0 {
+		method.AppendNewline()
+		methodConfig := method.AppendNewBlock("config", nil).Body()
+		keys := make([]string, 0, len(authConfig))
+		for key := range authConfig {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			methodConfig.SetAttributeValue(key, cty.StringVal(authConfig[key]))
+		}
+	}
+
 	return contents, nil
 }
 
+// generatedSecret is a secret read while generating a configuration, along
+// with its sorted field names.
+type generatedSecret struct {
+	path      string
+	mountPath string
+	v2        bool
+	fields    []string
+}
+
 func constructTemplates(ctx context.Context, client *api.Client, paths []string) ([]generatedConfigEnvTemplate, error) {
+	secrets, err := readGeneratedSecrets(ctx, client, paths)
+	if err != nil {
+		return nil, err
+	}
+	return envTemplatesFromSecrets(secrets), nil
+}
+
+func envTemplatesFromSecrets(secrets []generatedSecret) []generatedConfigEnvTemplate {
 	var templates []generatedConfigEnvTemplate
 
+	for _, secret := range secrets {
+		for _, field := range secret.fields {
+			templates = append(templates, generatedConfigEnvTemplate{
+				Name:              constructDefaultEnvironmentKey(secret.path, field),
+				Contents:          fmt.Sprintf(`{{ with secret "%s" }}{{ %s.%s }}{{ end }}`, secret.path, secret.dataContents(), field),
+				ErrorOnMissingKey: true,
+			})
+		}
+	}
+
+	return templates
+}
+
+// fileTemplatesFromSecrets returns a template rendering each secret to a file
+// of KEY=value lines under the destination directory, at the path of the
+// secret within its mount.
+func fileTemplatesFromSecrets(secrets []generatedSecret, destination string) []generatedConfigTemplate {
+	var templates []generatedConfigTemplate
+
+	for _, secret := range secrets {
+		rel := strings.TrimPrefix(secret.path, secret.mountPath)
+		if secret.v2 {
+			rel = strings.TrimPrefix(rel, "data/")
+		}
+
+		var contents strings.Builder
+		fmt.Fprintf(&contents, `{{ with secret "%s" }}`, secret.path)
+		for _, field := range secret.fields {
+			fmt.Fprintf(&contents, "%s={{ %s.%s }}\n", constructDefaultEnvironmentKey(secret.path, field), secret.dataContents(), field)
+		}
+		contents.WriteString("{{ end }}")
+
+		templates = append(templates, generatedConfigTemplate{
+			Contents:          contents.String(),
+			Destination:       filepath.Join(destination, filepath.FromSlash(rel)),
+			ErrorOnMissingKey: true,
+		})
+	}
+
+	return templates
+}
+
+func (s *generatedSecret) dataContents() string {
+	if s.v2 {
+		return ".Data.data"
+	}
+	return ".Data"
+}
+
+func readGeneratedSecrets(ctx context.Context, client *api.Client, paths []string) ([]generatedSecret, error) {
+	var secrets []generatedSecret
+
 	for _, path := range paths {
 		path = sanitizePath(path)
 
@@ -257,11 +573,11 @@ func constructTemplates(ctx context.Context, client *api.Client, paths []string)
 		switch {
 		case strings.HasSuffix(path, "/*"):
 			// this path contains a tail wildcard, attempt to walk the tree
-			t, err := constructTemplatesFromTree(ctx, client, path[:len(path)-2], mountPath, v2)
+			s, err := readGeneratedSecretsFromTree(ctx, client, path[:len(path)-2], mountPath, v2)
 			if err != nil {
 				return nil, fmt.Errorf("could not traverse sercet at %q: %w", path, err)
 			}
-			templates = append(templates, t...)
+			secrets = append(secrets, s...)
 
 		case strings.Contains(path, "*"):
 			// don't allow any other wildcards
@@ -269,19 +585,19 @@ func constructTemplates(ctx context.Context, client *api.Client, paths []string)
 
 		default:
 			// regular secret path
-			t, err := constructTemplatesFromSecret(ctx, client, path, mountPath, v2)
+			s, err := readGeneratedSecret(ctx, client, path, mountPath, v2)
 			if err != nil {
 				return nil, fmt.Errorf("could not read secret at %q: %v", path, err)
 			}
-			templates = append(templates, t...)
+			secrets = append(secrets, s)
 		}
 	}
 
-	return templates, nil
+	return secrets, nil
 }
 
-func constructTemplatesFromTree(ctx context.Context, client *api.Client, path, mountPath string, v2 bool) ([]generatedConfigEnvTemplate, error) {
-	var templates []generatedConfigEnvTemplate
+func readGeneratedSecretsFromTree(ctx context.Context, client *api.Client, path, mountPath string, v2 bool) ([]generatedSecret, error) {
+	var secrets []generatedSecret
 
 	if v2 {
 		metadataPath := strings.Replace(
@@ -309,11 +625,11 @@ func constructTemplatesFromTree(ctx context.Context, client *api.Client, path, m
 			1,
 		)
 
-		t, err := constructTemplatesFromSecret(ctx, client, dataPath, mountPath, v2)
+		s, err := readGeneratedSecret(ctx, client, dataPath, mountPath, v2)
 		if err != nil {
 			return err
 		}
-		templates = append(templates, t...)
+		secrets = append(secrets, s)
 
 		return nil
 	})
@@ -321,29 +637,27 @@ func constructTemplatesFromTree(ctx context.Context, client *api.Client, path, m
 		return nil, err
 	}
 
-	return templates, nil
+	return secrets, nil
 }
 
-func constructTemplatesFromSecret(ctx context.Context, client *api.Client, path, mountPath string, v2 bool) ([]generatedConfigEnvTemplate, error) {
-	var templates []generatedConfigEnvTemplate
-
+func readGeneratedSecret(ctx context.Context, client *api.Client, path, mountPath string, v2 bool) (generatedSecret, error) {
 	if v2 {
 		path = addPrefixToKVPath(path, mountPath, "data", true)
 	}
 
 	resp, err := client.Logical().ReadWithContext(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("error querying: %w", err)
+		return generatedSecret{}, fmt.Errorf("error querying: %w", err)
 	}
 	if resp == nil {
-		return nil, fmt.Errorf("secret not found")
+		return generatedSecret{}, fmt.Errorf("secret not found")
 	}
 
 	var data map[string]interface{}
 	if v2 {
 		internal, ok := resp.Data["data"]
 		if !ok {
-			return nil, fmt.Errorf("secret.Data not found")
+			return generatedSecret{}, fmt.Errorf("secret.Data not found")
 		}
 		data = internal.(map[string]interface{})
 	} else {
@@ -359,22 +673,12 @@ func constructTemplatesFromSecret(ctx context.Context, client *api.Client, path,
 	// sort for a deterministic output
 	sort.Strings(fields)
 
-	var dataContents string
-	if v2 {
-		dataContents = ".Data.data"
-	} else {
-		dataContents = ".Data"
-	}
-
-	for _, field := range fields {
-		templates = append(templates, generatedConfigEnvTemplate{
-			Name:              constructDefaultEnvironmentKey(path, field),
-			Contents:          fmt.Sprintf(`{{ with secret "%s" }}{{ %s.%s }}{{ end }}`, path, dataContents, field),
-			ErrorOnMissingKey: true,
-		})
-	}
-
-	return templates, nil
+	return generatedSecret{
+		path:      path,
+		mountPath: mountPath,
+		v2:        v2,
+		fields:    fields,
+	}, nil
 }
 
 func constructDefaultEnvironmentKey(path string, field string) string {
@@ -404,7 +708,11 @@ type generatedConfig struct {
 	TemplateConfig generatedConfigTemplateConfig `hcl:"template_config,block"`
 	Vault          generatedConfigVault          `hcl:"vault,block"`
 	EnvTemplates   []generatedConfigEnvTemplate  `hcl:"env_template,block"`
-	Exec           generatedConfigExec           `hcl:"exec,block"`
+	Exec           *generatedConfigExec          `hcl:"exec,block"`
+	Templates      []generatedConfigTemplate     `hcl:"template,block"`
+	Cache          *generatedConfigCache         `hcl:"cache,block"`
+	APIProxy       *generatedConfigAPIProxy      `hcl:"api_proxy,block"`
+	Listeners      []generatedConfigListener     `hcl:"listener,block"`
 }
 
 type generatedConfigTemplateConfig struct {
@@ -424,6 +732,12 @@ type generatedConfigEnvTemplate struct {
 	ErrorOnMissingKey bool   `hcl:"error_on_missing_key"`
 }
 
+type generatedConfigTemplate struct {
+	Contents          string `hcl:"contents,attr"`
+	Destination       string `hcl:"destination"`
+	ErrorOnMissingKey bool   `hcl:"error_on_missing_key"`
+}
+
 type generatedConfigVault struct {
 	Address string `hcl:"address"`
 }
@@ -433,10 +747,21 @@ type generatedConfigAutoAuth struct {
 }
 
 type generatedConfigAutoAuthMethod struct {
-	Type   string                              `hcl:"type"`
-	Config generatedConfigAutoAuthMethodConfig `hcl:"config,block"`
+	Type      string  `hcl:"type"`
+	MountPath *string `hcl:"mount_path"`
+
+	// Config is encoded as a "config" block by generateConfiguration.
+	Config map[string]string
+}
+
+type generatedConfigCache struct{}
+
+type generatedConfigAPIProxy struct {
+	UseAutoAuthToken bool `hcl:"use_auto_auth_token"`
 }
 
-type generatedConfigAutoAuthMethodConfig struct {
-	TokenFilePath string `hcl:"token_file_path"`
+type generatedConfigListener struct {
+	Type       string `hcl:"type,label"`
+	Address    string `hcl:"address"`
+	TLSDisable bool   `hcl:"tls_disable"`
 }
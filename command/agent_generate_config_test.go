@@ -8,8 +8,12 @@ import (
 	"context"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
+
+	"github.com/mitchellh/cli"
 )
 
 // TestConstructTemplates tests the construcTemplates helper function
@@ -253,7 +257,10 @@ exec \{
 		t.Run(name, func(t *testing.T) {
 			var config bytes.Buffer
 
-			c, err := generateConfiguration(ctx, client, tc.flagExec, tc.flagPaths)
+			c, err := generateConfiguration(ctx, client, &generateConfigOptions{
+				Exec:  tc.flagExec,
+				Paths: tc.flagPaths,
+			})
 			c.WriteTo(&config)
 
 			if tc.expectedError {
@@ -272,3 +279,195 @@ exec \{
 		})
 	}
 }
+
+// TestGenerateConfiguration_Options tests the generateConfiguration helper
+// function with file templates, other auth methods and caching
+func TestGenerateConfiguration_Options(t *testing.T) {
+	ctx, cancelContextFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelContextFunc()
+
+	client, closer := testVaultServerWithSecrets(ctx, t)
+	defer closer()
+
+	cases := map[string]struct {
+		opts          *generateConfigOptions
+		expected      *regexp.Regexp
+		notExpected   []string
+		expectedError bool
+	}{
+		"file-template-approle-cache": {
+			opts: &generateConfigOptions{
+				Type:          generateConfigTypeFileTemplate,
+				Paths:         []string{"kv-v2/app-1/nested/*"},
+				Destination:   "/run/secrets",
+				AuthMethod:    "approle",
+				AuthMountPath: "auth/my-approle",
+				AuthConfig: map[string]string{
+					"secret_id_file_path": "/etc/vault/secret-id",
+					"role_id_file_path":   "/etc/vault/role-id",
+				},
+				Cache: true,
+			},
+			expected: regexp.MustCompile(`
+auto_auth \{
+
+  method \{
+    type       = "approle"
+    mount_path = "auth/my-approle"
+
+    config \{
+      role_id_file_path   = "/etc/vault/role-id"
+      secret_id_file_path = "/etc/vault/secret-id"
+    }
+  }
+}
+
+template_config \{
+  static_secret_render_interval = "5m"
+  exit_on_retry_failure         = true
+}
+
+vault \{
+  address = "https://127.0.0.1:[0-9]{5}"
+}
+
+template \{
+  contents             = "\{\{ with secret \\"kv-v2/data/app-1/nested/baz\\" }}BAZ_PASSWORD=\{\{ .Data.data.password }}\\nBAZ_USER=\{\{ .Data.data.user }}\\n\{\{ end }}"
+  destination          = "/run/secrets/app-1/nested/baz"
+  error_on_missing_key = true
+}
+
+cache \{
+}
+
+api_proxy \{
+  use_auto_auth_token = true
+}
+
+listener "tcp" \{
+  address     = "127.0.0.1:8100"
+  tls_disable = true
+}
+`),
+			notExpected: []string{"env_template", "exec"},
+		},
+
+		"unsupported-type": {
+			opts: &generateConfigOptions{
+				Type:  "bogus",
+				Paths: []string{"kv-v1/foo"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			var config bytes.Buffer
+
+			c, err := generateConfiguration(ctx, client, tc.opts)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatal("an error was expected but the test succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.WriteTo(&config)
+
+			if !tc.expected.MatchString(config.String()) {
+				t.Fatalf("unexpected output; want: %v, got: %v", tc.expected.String(), config.String())
+			}
+			for _, s := range tc.notExpected {
+				if strings.Contains(config.String(), s) {
+					t.Fatalf("unexpected %q in output: %v", s, config.String())
+				}
+			}
+		})
+	}
+}
+
+// TestAgentGenerateConfigCommand_AuthMethodConfig tests how the auth method
+// configuration is read from the flags and the prompts
+func TestAgentGenerateConfigCommand_AuthMethodConfig(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		flagAuthMethod  string
+		flagAuthConfig  []string
+		flagInteractive bool
+		input           string
+		expectedMethod  string
+		expectedConfig  map[string]string
+		expectedError   string
+	}{
+		"flags": {
+			flagAuthMethod: "kubernetes",
+			flagAuthConfig: []string{"role=my-role", "token_path=/var/run/token"},
+			expectedMethod: "kubernetes",
+			expectedConfig: map[string]string{"role": "my-role", "token_path": "/var/run/token"},
+		},
+		"missing-config": {
+			flagAuthMethod: "approle",
+			flagAuthConfig: []string{"role_id_file_path=/etc/vault/role-id"},
+			expectedError:  "secret_id_file_path",
+		},
+		"invalid-config": {
+			flagAuthMethod: "kubernetes",
+			flagAuthConfig: []string{"role"},
+			expectedError:  "key=value",
+		},
+		"unsupported-method": {
+			flagAuthMethod: "bogus",
+			expectedError:  "not a supported auth method",
+		},
+		"interactive": {
+			flagInteractive: true,
+			input:           "approle\n/etc/vault/role-id\n/etc/vault/secret-id\n",
+			expectedMethod:  "approle",
+			expectedConfig: map[string]string{
+				"role_id_file_path":   "/etc/vault/role-id",
+				"secret_id_file_path": "/etc/vault/secret-id",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ui := cli.NewMockUi()
+			// read one byte at a time so that each prompt only consumes its line
+			ui.InputReader = iotest.OneByteReader(strings.NewReader(tc.input))
+			cmd := &AgentGenerateConfigCommand{
+				BaseCommand:     &BaseCommand{UI: ui},
+				flagAuthMethod:  tc.flagAuthMethod,
+				flagAuthConfig:  tc.flagAuthConfig,
+				flagInteractive: tc.flagInteractive,
+			}
+
+			method, config, err := cmd.authMethodConfig()
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != tc.expectedMethod {
+				t.Fatalf("expected method %q, got %q", tc.expectedMethod, method)
+			}
+			if !reflect.DeepEqual(tc.expectedConfig, config) {
+				t.Fatalf("expected config %v, got %v", tc.expectedConfig, config)
+			}
+		})
+	}
+}
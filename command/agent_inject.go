@@ -0,0 +1,297 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/reloadutil"
+	"github.com/hashicorp/vault/command/agent/injector"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/version"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AgentInjectCommand)(nil)
+	_ cli.CommandAutocomplete = (*AgentInjectCommand)(nil)
+)
+
+type AgentInjectCommand struct {
+	*BaseCommand
+
+	ShutdownCh chan struct{}
+	SighupCh   chan struct{}
+
+	flagListen        string
+	flagTLSCertFile   string
+	flagTLSKeyFile    string
+	flagAgentImage    string
+	flagVaultAddress  string
+	flagAuthPath      string
+	flagNamespace     string
+	flagCACert        string
+	flagTLSSkipVerify bool
+	flagAgentLogLevel string
+	flagLogLevel      string
+	flagLogFormat     string
+}
+
+func (c *AgentInjectCommand) Synopsis() string {
+	return "Run the Kubernetes webhook injecting Vault Agent into pods"
+}
+
+func (c *AgentInjectCommand) Help() string {
+	helpText := `
+Usage: vault agent inject [options]
+
+  Runs a Kubernetes mutating admission webhook which injects Vault Agent into
+  the pods annotated with vault.hashicorp.com/agent-inject: "true". The
+  injected pods get an init container rendering their secrets before the
+  containers of the pod start, and a sidecar keeping the secrets up to date,
+  in an in-memory volume mounted at /vault/secrets. The agents authenticate
+  with the Kubernetes auth method, using the service account of the pod.
+
+  The webhook is served over TLS at /mutate, and must be registered with a
+  MutatingWebhookConfiguration for the creation of pods. The certificate is
+  reloaded when the command receives SIGHUP. The flags set the defaults of
+  the injected agents, which the annotations of the pods can override.
+
+  Run the webhook, injecting agents connecting to Vault in the cluster:
+
+      $ vault agent inject -tls-cert-file=/etc/webhook/tls.crt \
+                    -tls-key-file=/etc/webhook/tls.key \
+                    -vault-address=https://vault.vault.svc:8200
+
+  Annotate a pod to render a secret to /vault/secrets/db-creds:
+
+      vault.hashicorp.com/agent-inject: "true"
+      vault.hashicorp.com/role: "my-app"
+      vault.hashicorp.com/agent-inject-secret-db-creds: "database/creds/my-app"
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AgentInjectCommand) Flags() *FlagSets {
+	set := NewFlagSets(c.UI)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "listen",
+		Target:  &c.flagListen,
+		Default: ":8080",
+		Usage:   "Address the webhook listens on.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "tls-cert-file",
+		Target:     &c.flagTLSCertFile,
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to the PEM-encoded TLS certificate of the webhook.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "tls-key-file",
+		Target:     &c.flagTLSKeyFile,
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to the PEM-encoded TLS private key of the webhook.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "agent-image",
+		Target:  &c.flagAgentImage,
+		Default: "hashicorp/vault:" + version.GetVersion().Version,
+		Usage:   "Image of the injected agent containers.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "vault-address",
+		Target: &c.flagVaultAddress,
+		EnvVar: "AGENT_INJECT_VAULT_ADDR",
+		Usage:  "Address of the Vault server the injected agents connect to, reachable from the pods.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "vault-auth-path",
+		Target:  &c.flagAuthPath,
+		Default: "auth/kubernetes",
+		Usage:   "Mount path of the Kubernetes auth method the injected agents authenticate with.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "vault-namespace",
+		Target: &c.flagNamespace,
+		Usage:  "Vault namespace the injected agents authenticate in.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "vault-ca-cert",
+		Target: &c.flagCACert,
+		Usage:  "Path of the CA certificate of Vault in the injected agent containers.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "vault-tls-skip-verify",
+		Target:  &c.flagTLSSkipVerify,
+		Default: false,
+		Usage:   "Disable the verification of the certificate of Vault by the injected agents.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "agent-log-level",
+		Target:  &c.flagAgentLogLevel,
+		Default: "info",
+		Usage:   "Log level of the injected agents.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "error"),
+		Usage:      "Log level of the webhook.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-format",
+		Target:     &c.flagLogFormat,
+		Default:    "standard",
+		Completion: complete.PredictSet("standard", "json"),
+		Usage:      "Log format of the webhook, either 'standard' or 'json'.",
+	})
+
+	return set
+}
+
+func (c *AgentInjectCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AgentInjectCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AgentInjectCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if len(f.Args()) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(f.Args())))
+		return 1
+	}
+
+	if c.flagTLSCertFile == "" || c.flagTLSKeyFile == "" {
+		c.UI.Error("Both -tls-cert-file and -tls-key-file are required, as Kubernetes only calls webhooks over TLS")
+		return 1
+	}
+	if c.flagVaultAddress == "" {
+		c.UI.Error("The -vault-address flag is required")
+		return 1
+	}
+
+	logLevel, err := logging.ParseLogLevel(c.flagLogLevel)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	logFormat, err := logging.ParseLogFormat(c.flagLogFormat)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	logger, err := logging.Setup(&logging.LogConfig{
+		Name:      "agent-injector",
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+	}, os.Stdout)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error setting up logging: %v", err))
+		return 1
+	}
+
+	certGetter := reloadutil.NewCertificateGetter(c.flagTLSCertFile, c.flagTLSKeyFile, "")
+	if err := certGetter.Reload(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading TLS certificate: %v", err))
+		return 1
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/mutate", injector.NewHandler(&injector.Config{
+		AgentImage:    c.flagAgentImage,
+		VaultAddress:  c.flagVaultAddress,
+		AuthPath:      c.flagAuthPath,
+		Namespace:     c.flagNamespace,
+		CACert:        c.flagCACert,
+		TLSSkipVerify: c.flagTLSSkipVerify,
+		LogLevel:      c.flagAgentLogLevel,
+	}, logger))
+	mux.HandleFunc("/health/ready", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ln, err := net.Listen("tcp", c.flagListen)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listening on %q: %v", c.flagListen, err))
+		return 1
+	}
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certGetter.GetCertificate,
+		},
+		ErrorLog: logger.StandardLogger(nil),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ServeTLS(ln, "", "")
+	}()
+
+	c.UI.Output(fmt.Sprintf("==> Vault Agent injector listening on %s", ln.Addr()))
+
+	for {
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, http.ErrServerClosed) {
+				c.UI.Error(fmt.Sprintf("Error serving webhook: %v", err))
+				return 1
+			}
+			return 0
+		case <-c.SighupCh:
+			c.UI.Output("==> Vault Agent injector certificate reload triggered")
+			if err := certGetter.Reload(); err != nil {
+				c.UI.Error(fmt.Sprintf("Error reloading TLS certificate: %v", err))
+			}
+		case <-c.ShutdownCh:
+			c.UI.Output("==> Vault Agent injector shutdown triggered")
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := server.Shutdown(ctx)
+			cancel()
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error shutting down webhook: %v", err))
+				return 1
+			}
+			return 0
+		}
+	}
+}
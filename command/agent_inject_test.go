@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func testAgentInjectCommand(tb testing.TB) (*cli.MockUi, *AgentInjectCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AgentInjectCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+		SighupCh:   make(chan struct{}),
+	}
+}
+
+// testAgentInjectCert writes a self-signed certificate and its key to the
+// directory, returning their paths.
+func testAgentInjectCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func TestAgentInjectCommand_Run(t *testing.T) {
+	t.Run("validations", func(t *testing.T) {
+		cases := []struct {
+			name string
+			args []string
+			out  string
+		}{
+			{"too many args", []string{"foo"}, "Too many arguments"},
+			{"no tls", []string{"-vault-address=https://vault:8200"}, "-tls-cert-file and -tls-key-file are required"},
+			{"no address", []string{"-tls-cert-file=tls.crt", "-tls-key-file=tls.key"}, "-vault-address flag is required"},
+			{"invalid log format", []string{"-tls-cert-file=tls.crt", "-tls-key-file=tls.key", "-vault-address=https://vault:8200", "-log-format=xml"}, "unknown log format"},
+			{"missing cert", []string{"-tls-cert-file=tls.crt", "-tls-key-file=tls.key", "-vault-address=https://vault:8200"}, "Error loading TLS certificate"},
+		}
+
+		for _, tc := range cases {
+			ui, cmd := testAgentInjectCommand(t)
+			code := cmd.Run(tc.args)
+			require.Equal(t, 1, code, tc.name)
+			require.Contains(t, ui.ErrorWriter.String(), tc.out, tc.name)
+		}
+	})
+
+	t.Run("serves webhook", func(t *testing.T) {
+		certPath, keyPath := testAgentInjectCert(t, t.TempDir())
+		ui, cmd := testAgentInjectCommand(t)
+
+		codeCh := make(chan int, 1)
+		go func() {
+			codeCh <- cmd.Run([]string{
+				"-listen=127.0.0.1:0",
+				"-tls-cert-file=" + certPath,
+				"-tls-key-file=" + keyPath,
+				"-vault-address=https://vault:8200",
+				"-log-level=error",
+			})
+		}()
+
+		var addr string
+		listening := regexp.MustCompile(`listening on (\S+)`)
+		corehelpers.RetryUntil(t, 5*time.Second, func() error {
+			match := listening.FindStringSubmatch(ui.OutputWriter.String())
+			if match == nil {
+				return fmt.Errorf("webhook not listening yet")
+			}
+			addr = match[1]
+			return nil
+		})
+
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+		resp, err := client.Get("https://" + addr + "/health/ready")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, err = client.Post("https://"+addr+"/mutate", "application/json", strings.NewReader(`{
+			"apiVersion": "admission.k8s.io/v1",
+			"kind": "AdmissionReview",
+			"request": {"uid": "1234", "kind": {"kind": "ConfigMap"}, "operation": "CREATE"}
+		}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		close(cmd.ShutdownCh)
+		select {
+		case code := <-codeCh:
+			require.Equal(t, 0, code, ui.ErrorWriter.String())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the webhook to shut down")
+		}
+	})
+}
@@ -0,0 +1,284 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AuditTailCommand)(nil)
+	_ cli.CommandAutocomplete = (*AuditTailCommand)(nil)
+)
+
+type AuditTailCommand struct {
+	*BaseCommand
+
+	flagPaths      []string
+	flagOperations []string
+	flagType       string
+	flagErrorsOnly bool
+
+	// ShutdownCh is used to capture interrupt signal and end streaming
+	ShutdownCh chan struct{}
+}
+
+// auditTailEntry holds the fields of an audit entry used to filter and
+// summarize it.
+type auditTailEntry struct {
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Dropped int    `json:"dropped"`
+	Error   string `json:"error"`
+	Auth    *struct {
+		DisplayName string   `json:"display_name"`
+		Policies    []string `json:"policies"`
+	} `json:"auth"`
+	Request *struct {
+		Operation     string `json:"operation"`
+		Path          string `json:"path"`
+		RemoteAddress string `json:"remote_address"`
+		Namespace     *struct {
+			Path string `json:"path"`
+		} `json:"namespace"`
+	} `json:"request"`
+}
+
+func (c *AuditTailCommand) Synopsis() string {
+	return "Stream the audit entries of a Vault server"
+}
+
+func (c *AuditTailCommand) Help() string {
+	helpText := `
+Usage: vault audit tail [options]
+
+  Streams the audit entries of the requests handled by a Vault server as they
+  happen, whether or not an audit device is enabled. This is meant for quick
+  interactive debugging, such as finding out why a request was denied, and is
+  not a replacement for an audit device.
+
+  Sensitive values are HMAC-ed with a salt which only lives as long as the
+  server process, so they cannot be compared with the hashes of the audit
+  devices. Request headers are not included. Only the requests handled by the
+  node the command is connected to are streamed. This requires sudo capability
+  on "sys/monitor/audit".
+
+  The entries are filtered by the command before they are printed. In the
+  default table format, each entry is summarized on one line. With
+  -format=json, the entries are printed as they are streamed, one JSON object
+  per line.
+
+  Follow the denied requests on the "secret/" mount:
+
+      $ vault audit tail -path="secret/*" -errors-only
+
+  Follow the write requests as JSON:
+
+      $ vault audit tail -format=json -operation=create -operation=update
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditTailCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Filter Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "path",
+		Target: &c.flagPaths,
+		Usage: "Only print the entries of requests whose path matches the given " +
+			"pattern, which may start or end with \"*\" as a wildcard. This can " +
+			"be specified multiple times.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "operation",
+		Target:     &c.flagOperations,
+		Completion: complete.PredictSet("create", "read", "update", "patch", "delete", "list"),
+		Usage: "Only print the entries of requests with the given operation. " +
+			"This can be specified multiple times.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "type",
+		Target:     &c.flagType,
+		Default:    "all",
+		Completion: complete.PredictSet("all", "request", "response"),
+		Usage:      `Only print entries of the given type, "request" or "response".`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "errors-only",
+		Target:  &c.flagErrorsOnly,
+		Default: false,
+		Usage:   "Only print the entries of requests which failed, e.g. were denied.",
+	})
+
+	return set
+}
+
+func (c *AuditTailCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AuditTailCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditTailCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if args = f.Args(); len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	c.flagType = strings.ToLower(c.flagType)
+	switch c.flagType {
+	case "all", "request", "response":
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid type %q, must be one of \"all\", \"request\" or \"response\"", c.flagType))
+		return 1
+	}
+
+	format := Format(c.UI)
+	switch format {
+	case "table", "json":
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid format %q, must be one of \"table\" or \"json\"", format))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// Remove the default 60 second timeout so we can stream indefinitely
+	client.SetClientTimeout(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entryCh, err := client.Sys().MonitorAudit(ctx)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error following audit entries: %s", err))
+		return 2
+	}
+
+	for {
+		select {
+		case raw, ok := <-entryCh:
+			if !ok {
+				return 0
+			}
+
+			var entry auditTailEntry
+			if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+				c.UI.Warn(fmt.Sprintf("Skipping malformed audit entry: %s", err))
+				continue
+			}
+
+			if entry.Type == "dropped" {
+				c.UI.Warn(fmt.Sprintf("Dropped %d audit entries which could not be streamed fast enough", entry.Dropped))
+				continue
+			}
+
+			if !c.matches(&entry) {
+				continue
+			}
+
+			if format == "json" {
+				c.UI.Output(raw)
+			} else {
+				c.UI.Output(entry.summary())
+			}
+		case <-c.ShutdownCh:
+			return 0
+		}
+	}
+}
+
+// matches reports whether the entry passes the filters of the command.
+func (c *AuditTailCommand) matches(entry *auditTailEntry) bool {
+	if c.flagType != "all" && entry.Type != c.flagType {
+		return false
+	}
+
+	if c.flagErrorsOnly && entry.Error == "" {
+		return false
+	}
+
+	var operation, path string
+	if entry.Request != nil {
+		operation, path = entry.Request.Operation, entry.Request.Path
+	}
+
+	if len(c.flagOperations) > 0 && !strutil.StrListContains(c.flagOperations, operation) {
+		return false
+	}
+
+	if len(c.flagPaths) > 0 {
+		matched := false
+		for _, pattern := range c.flagPaths {
+			if strutil.GlobbedStringsMatch(pattern, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// summary returns a one line description of the entry.
+func (e *auditTailEntry) summary() string {
+	parts := []string{e.Time, e.Type}
+
+	if e.Request != nil {
+		path := e.Request.Path
+		if e.Request.Namespace != nil && e.Request.Namespace.Path != "" {
+			path = e.Request.Namespace.Path + path
+		}
+		parts = append(parts, e.Request.Operation, path)
+		if e.Request.RemoteAddress != "" {
+			parts = append(parts, "remote_address="+e.Request.RemoteAddress)
+		}
+	}
+
+	if e.Auth != nil {
+		if e.Auth.DisplayName != "" {
+			parts = append(parts, "display_name="+e.Auth.DisplayName)
+		}
+		if len(e.Auth.Policies) > 0 {
+			parts = append(parts, "policies="+strings.Join(e.Auth.Policies, ","))
+		}
+	}
+
+	if e.Error != "" {
+		// Errors are often multierrors spanning several lines
+		parts = append(parts, fmt.Sprintf("error=%q", strings.Join(strings.Fields(e.Error), " ")))
+	}
+
+	return strings.Join(parts, " ")
+}
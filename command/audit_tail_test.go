@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testAuditTailCommand(tb testing.TB) (*cli.MockUi, *AuditTailCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditTailCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+func TestAuditTailCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_type",
+			[]string{"-type", "nope"},
+			"Invalid type",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testAuditTailCommand(t)
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("denied_requests", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().PutPolicy("reader", `path "secret/allowed" { capabilities = ["read"] }`); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: []string{"reader"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		limited, err := client.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		limited.SetToken(secret.Auth.ClientToken)
+
+		ui, cmd := testAuditTailCommand(t)
+		cmd.client = client
+
+		codeCh := make(chan int)
+		go func() {
+			codeCh <- cmd.Run([]string{
+				"-path", "secret/*",
+				"-errors-only",
+			})
+		}()
+
+		// The tail only streams the entries of the requests made once it is
+		// followed, so keep making requests until they show up.
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(ui.OutputWriter.String(), "secret/denied") {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for the denied request, got %q", ui.OutputWriter.String())
+			}
+			if _, err := limited.Logical().Write("secret/denied", map[string]interface{}{"password": "s3cr3t"}); err == nil {
+				t.Fatal("expected the request to be denied")
+			}
+			if _, err := limited.Logical().Read("secret/allowed"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := limited.Logical().Read("cubbyhole/denied"); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		close(cmd.ShutdownCh)
+		if code := <-codeCh; code != 0 {
+			t.Fatalf("expected %d to be 0: %s", code, ui.ErrorWriter.String())
+		}
+
+		out := ui.OutputWriter.String()
+		for _, expected := range []string{"create secret/denied", "permission denied", "policies=default,reader"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected %q to contain %q", out, expected)
+			}
+		}
+		for _, unexpected := range []string{"secret/allowed", "cubbyhole/denied", "s3cr3t"} {
+			if strings.Contains(out, unexpected) {
+				t.Errorf("expected %q not to contain %q", out, unexpected)
+			}
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditTailCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/command/token"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/mattn/go-isatty"
@@ -59,6 +60,8 @@ type BaseCommand struct {
 
 	flagFormat           string
 	flagField            string
+	flagColumns          []string
+	flagJSONPath         string
 	flagDetailed         bool
 	flagOutputCurlString bool
 	flagOutputPolicy     bool
@@ -133,12 +136,22 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 	// Set the wrapping function
 	client.SetWrappingLookupFunc(c.DefaultWrappingLookupFunc)
 
+	// Resolve the namespace first, since tokens may be stored per namespace.
+	// flagNS takes precedence over flagNamespace. After resolution, point both
+	// flags to the same value to be able to use them interchangeably anywhere.
+	if c.flagNS != notSetValue {
+		c.flagNamespace = c.flagNS
+	}
+	if c.flagNamespace != notSetValue {
+		client.SetNamespace(namespace.Canonicalize(c.flagNamespace))
+	}
+
 	// Get the token if it came in from the environment
 	token := client.Token()
 
 	// If we don't have a token, check the token helper
 	if token == "" {
-		helper, err := c.TokenHelper()
+		helper, err := c.tokenHelperFor(client)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get token helper")
 		}
@@ -155,14 +168,6 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 
 	client.SetMFACreds(c.flagMFA)
 
-	// flagNS takes precedence over flagNamespace. After resolution, point both
-	// flags to the same value to be able to use them interchangeably anywhere.
-	if c.flagNS != notSetValue {
-		c.flagNamespace = c.flagNS
-	}
-	if c.flagNamespace != notSetValue {
-		client.SetNamespace(namespace.Canonicalize(c.flagNamespace))
-	}
 	if c.flagPolicyOverride {
 		client.SetPolicyOverride(c.flagPolicyOverride)
 	}
@@ -201,11 +206,23 @@ func (c *BaseCommand) SetTokenHelper(th token.TokenHelper) {
 
 // TokenHelper returns the token helper attached to the command.
 func (c *BaseCommand) TokenHelper() (token.TokenHelper, error) {
+	return c.tokenHelperFor(c.client)
+}
+
+// tokenHelperFor returns the token helper attached to the command, or the
+// configured token helper for the address and namespace of the client. If
+// the client is nil, the address of the command is used.
+func (c *BaseCommand) tokenHelperFor(client *api.Client) (token.TokenHelper, error) {
 	if c.tokenHelper != nil {
 		return c.tokenHelper, nil
 	}
 
-	helper, err := DefaultTokenHelper()
+	address, ns := c.flagAddress, os.Getenv(api.EnvVaultNamespace)
+	if client != nil {
+		address, ns = client.Address(), client.Namespace()
+	}
+
+	helper, err := config.TokenHelperFor(address, ns)
 	if err != nil {
 		return nil, err
 	}
@@ -298,6 +315,7 @@ const (
 	FlagSetOutputField
 	FlagSetOutputFormat
 	FlagSetOutputDetailed
+	FlagSetOutputSelect
 )
 
 // flagSet creates the flags for this command. The result is cached on the
@@ -499,7 +517,7 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 
 		}
 
-		if bit&(FlagSetOutputField|FlagSetOutputFormat|FlagSetOutputDetailed) != 0 {
+		if bit&(FlagSetOutputField|FlagSetOutputFormat|FlagSetOutputDetailed|FlagSetOutputSelect) != 0 {
 			outputSet := set.NewFlagSet("Output Options")
 
 			if bit&FlagSetOutputField != 0 {
@@ -528,6 +546,30 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 				})
 			}
 
+			if bit&FlagSetOutputSelect != 0 {
+				outputSet.StringSliceVar(&StringSliceVar{
+					Name:       "columns",
+					Target:     &c.flagColumns,
+					Completion: complete.PredictAnything,
+					Usage: "Print only the given fields as the columns of a table, " +
+						"with one row per entry of a list. Fields are comma-separated " +
+						"and may name nested fields with dots, e.g. \"metadata.version\". " +
+						"This can be specified multiple times, and is only supported " +
+						"with the table format.",
+				})
+
+				outputSet.StringVar(&StringVar{
+					Name:       "jsonpath",
+					Target:     &c.flagJSONPath,
+					Default:    "",
+					Completion: complete.PredictAnything,
+					Usage: "Print only the values matched by the given JSONPath " +
+						"expression in the JSON output of the response, e.g. " +
+						"\"$.data.keys[0]\" or \"$.data.key_info.*.name\". With the " +
+						"table format, each value is printed on its own line.",
+				})
+			}
+
 			if bit&FlagSetOutputDetailed != 0 {
 				outputSet.BoolVar(&BoolVar{
 					Name:    "detailed",
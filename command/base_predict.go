@@ -10,12 +10,18 @@ import (
 	"sync"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/mitchellh/mapstructure"
 	"github.com/posener/complete"
 )
 
 type Predict struct {
 	client     *api.Client
 	clientOnce sync.Once
+
+	// cache holds the recent LIST results, shared by the completions of
+	// successive invocations. It is nil when the client is set by tests.
+	cache *predictCache
 }
 
 func NewPredict() *Predict {
@@ -45,6 +51,7 @@ func (p *Predict) Client() *api.Client {
 			}
 
 			p.client = client
+			p.cache = newPredictCache()
 		}
 	})
 	return p.client
@@ -247,6 +254,7 @@ func (p *Predict) vaultPaths(includeFiles bool) complete.PredictFunc {
 		if client == nil {
 			return nil
 		}
+		p.setNamespaceFromArgs(args.All)
 
 		path := args.Last
 
@@ -451,18 +459,25 @@ func (p *Predict) policies() []string {
 
 // mountInfos returns a map with mount paths as keys and MountOutputs as values
 // for the Vault server which the client is configured to communicate with.
-// Returns error if server communication fails.
+// The mounts are read from sys/internal/ui/mounts, which lists the mounts the
+// token has access to even if it can't read sys/mounts. Returns error if server
+// communication fails.
 func (p *Predict) mountInfos() (map[string]*api.MountOutput, error) {
 	client := p.Client()
 	if client == nil {
 		return nil, nil
 	}
 
-	mounts, err := client.Sys().ListMounts()
-	if err != nil {
-		return nil, err
+	secret, err := client.Logical().Read("sys/internal/ui/mounts")
+	if err != nil || secret == nil || secret.Data == nil {
+		// Fall back to sys/mounts for servers without the internal endpoint
+		return client.Sys().ListMounts()
 	}
 
+	mounts := make(map[string]*api.MountOutput)
+	if err := mapstructure.WeakDecode(secret.Data["secret"], &mounts); err != nil {
+		return nil, err
+	}
 	return mounts, nil
 }
 
@@ -513,14 +528,19 @@ func (p *Predict) namespaces() []string {
 	return list
 }
 
-// listPaths returns a list of paths (HTTP LIST) for the given path. This
-// function returns an empty list of any errors occur.
+// listPaths returns a list of paths (HTTP LIST) for the given path. Recent
+// results are reused from the cache. This function returns an empty list of
+// any errors occur.
 func (p *Predict) listPaths(path string) []string {
 	client := p.Client()
 	if client == nil {
 		return nil
 	}
 
+	if list, ok := p.cache.get(client, path); ok {
+		return list
+	}
+
 	secret, err := client.Logical().List(path)
 	if err != nil || secret == nil || secret.Data == nil {
 		return nil
@@ -538,16 +558,65 @@ func (p *Predict) listPaths(path string) []string {
 		}
 	}
 	sort.Strings(list)
+	p.cache.put(client, path, list)
 	return list
 }
 
+// setNamespaceFromArgs makes the client use the namespace given by the
+// -namespace or -ns flags of the command being completed, if any, since the
+// flags are not parsed before completing.
+func (p *Predict) setNamespaceFromArgs(args []string) {
+	var ns string
+	for i, arg := range args {
+		name, hasValue, ok := namespaceFlag(arg)
+		if !ok {
+			continue
+		}
+		_, value, _ := strings.Cut(arg, "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				continue
+			}
+			value = args[i+1]
+		}
+		// -ns takes precedence over -namespace
+		if ns == "" || name == "ns" {
+			ns = value
+		}
+	}
+
+	if ns != "" {
+		p.client.SetNamespace(namespace.Canonicalize(ns))
+	}
+}
+
+// namespaceFlag reports whether the argument is the -namespace or -ns flag,
+// returning the name of the flag and whether its value is in the argument.
+func namespaceFlag(arg string) (string, bool, bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", false, false
+	}
+	name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	if name != "namespace" && name != "ns" {
+		return "", false, false
+	}
+	return name, hasValue, true
+}
+
 // hasPathArg determines if the args have already accepted a path.
 func (p *Predict) hasPathArg(args []string) bool {
 	var nonFlags []string
-	for _, a := range args {
-		if !strings.HasPrefix(a, "-") {
-			nonFlags = append(nonFlags, a)
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		// Skip the value of a namespace flag given as a separate argument
+		if i > 0 {
+			if _, hasValue, ok := namespaceFlag(args[i-1]); ok && !hasValue {
+				continue
+			}
 		}
+		nonFlags = append(nonFlags, a)
 	}
 
 	return len(nonFlags) > 2
@@ -565,6 +634,7 @@ func (p *Predict) filterFunc(f func() []string) complete.Predictor {
 		if client == nil {
 			return nil
 		}
+		p.setNamespaceFromArgs(args.All)
 
 		return p.filter(f(), args.Last)
 	})
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// predictCacheTTL is how long LIST results are reused by the predictor. Each
+// completion runs a new process, so the results are kept on disk to avoid
+// listing the same path again while the user presses tab repeatedly.
+const predictCacheTTL = 30 * time.Second
+
+// predictCache is an on-disk cache of recent LIST results. The entries are
+// keyed by a hash of the address, namespace and token of the client along with
+// the path, so results are never shared between tokens or namespaces, and the
+// token itself is not stored. A nil cache caches nothing.
+type predictCache struct {
+	path    string
+	entries map[string]*predictCacheEntry
+}
+
+type predictCacheEntry struct {
+	Time time.Time `json:"time"`
+	Keys []string  `json:"keys"`
+}
+
+// newPredictCache returns the cache in the user cache directory, or nil if
+// there is no such directory.
+func newPredictCache() *predictCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return &predictCache{
+		path: filepath.Join(dir, "vault", "autocomplete.json"),
+	}
+}
+
+func (c *predictCache) key(client *api.Client, path string) string {
+	sum := sha256.Sum256([]byte(client.Address() + "\x00" + client.Namespace() + "\x00" + client.Token() + "\x00" + path))
+	return hex.EncodeToString(sum[:])
+}
+
+// load reads the entries from disk once, dropping the expired ones. Errors
+// are ignored since the cache is only an optimization.
+func (c *predictCache) load() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = make(map[string]*predictCacheEntry)
+
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var entries map[string]*predictCacheEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return
+	}
+	for key, entry := range entries {
+		if entry != nil && time.Since(entry.Time) < predictCacheTTL {
+			c.entries[key] = entry
+		}
+	}
+}
+
+// get returns the recent result of listing the path with the client, if any.
+func (c *predictCache) get(client *api.Client, path string) ([]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.load()
+
+	entry, ok := c.entries[c.key(client, path)]
+	if !ok {
+		return nil, false
+	}
+	return entry.Keys, true
+}
+
+// put records the result of listing the path with the client.
+func (c *predictCache) put(client *api.Client, path string, keys []string) {
+	if c == nil {
+		return
+	}
+	c.load()
+
+	c.entries[c.key(client, path)] = &predictCacheEntry{
+		Time: time.Now(),
+		Keys: keys,
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path, b, 0o600)
+}
@@ -4,8 +4,10 @@
 package command
 
 import (
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/api"
@@ -727,6 +729,11 @@ func TestPredict_HasPathArg(t *testing.T) {
 			[]string{"foo", "bar", "baz"},
 			true,
 		},
+		{
+			"namespace_value",
+			[]string{"read", "-ns", "ns1", "secret/"},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -741,3 +748,129 @@ func TestPredict_HasPathArg(t *testing.T) {
 		})
 	}
 }
+
+func TestPredict_MountsRestrictedToken(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("team-a", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sys().Mount("team-b", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := createTokenForPolicy(t, client, `path "team-a/*" { capabilities = ["list"] }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restricted, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted.SetToken(auth.ClientToken)
+
+	p := NewPredict()
+	p.client = restricted
+
+	// The token can't read sys/mounts, but sees the mounts it has access to
+	act := p.filter(p.mounts(), "team")
+	if exp := []string{"team-a/"}; !reflect.DeepEqual(act, exp) {
+		t.Errorf("expected %q to be %q", act, exp)
+	}
+}
+
+func TestPredict_SetNamespaceFromArgs(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{
+			"none",
+			[]string{"read", "secret/"},
+			"",
+		},
+		{
+			"namespace_equals",
+			[]string{"read", "-namespace=ns1", "secret/"},
+			"ns1/",
+		},
+		{
+			"ns_separate",
+			[]string{"read", "-ns", "ns1/ns2", "secret/"},
+			"ns1/ns2/",
+		},
+		{
+			"ns_precedence",
+			[]string{"read", "-ns=ns2", "-namespace=ns1", "secret/"},
+			"ns2/",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, err := api.NewClient(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.ClearNamespace()
+
+			p := NewPredict()
+			p.client = client
+			p.setNamespaceFromArgs(tc.args)
+			if act := client.Namespace(); act != tc.exp {
+				t.Errorf("expected %q to be %q", act, tc.exp)
+			}
+		})
+	}
+}
+
+func TestPredict_Cache(t *testing.T) {
+	t.Parallel()
+
+	client, err := api.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("token-a")
+
+	path := filepath.Join(t.TempDir(), "autocomplete.json")
+	cache := &predictCache{path: path}
+	cache.put(client, "secret/", []string{"foo", "bar/"})
+
+	// A new process reads the entries back from disk
+	cache = &predictCache{path: path}
+	if act, ok := cache.get(client, "secret/"); !ok || !reflect.DeepEqual(act, []string{"foo", "bar/"}) {
+		t.Errorf("expected cached keys, got %q", act)
+	}
+
+	// Results are not shared between tokens
+	client.SetToken("token-b")
+	if _, ok := cache.get(client, "secret/"); ok {
+		t.Error("expected no cached keys for another token")
+	}
+
+	// Expired entries are dropped
+	client.SetToken("token-a")
+	cache.entries[cache.key(client, "secret/")].Time = time.Now().Add(-2 * predictCacheTTL)
+	cache.put(client, "other/", nil)
+	cache = &predictCache{path: path}
+	if _, ok := cache.get(client, "secret/"); ok {
+		t.Error("expected expired keys to be dropped")
+	}
+
+	// A nil cache caches nothing
+	var nilCache *predictCache
+	nilCache.put(client, "secret/", []string{"foo"})
+	if _, ok := nilCache.get(client, "secret/"); ok {
+		t.Error("expected nil cache to be empty")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	_ "github.com/hashicorp/vault/helper/builtinplugins"
 
 	auditFile "github.com/hashicorp/vault/builtin/audit/file"
+	auditOTLP "github.com/hashicorp/vault/builtin/audit/otlp"
 	auditSocket "github.com/hashicorp/vault/builtin/audit/socket"
 	auditSyslog "github.com/hashicorp/vault/builtin/audit/syslog"
 
@@ -38,6 +39,7 @@ import (
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
+	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
 	credToken "github.com/hashicorp/vault/builtin/credential/token"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 
@@ -166,6 +168,7 @@ const (
 var (
 	auditBackends = map[string]audit.Factory{
 		"file":   auditFile.Factory,
+		"otlp":   auditOTLP.Factory,
 		"socket": auditSocket.Factory,
 		"syslog": auditSyslog.Factory,
 	}
@@ -232,10 +235,8 @@ var (
 		"oidc":     &credOIDC.CLIHandler{},
 		"okta":     &credOkta.CLIHandler{},
 		"pcf":      &credCF.CLIHandler{}, // Deprecated.
-		"radius": &credUserpass.CLIHandler{
-			DefaultMount: "radius",
-		},
-		"token": &credToken.CLIHandler{},
+		"radius":   &credRadius.CLIHandler{},
+		"token":    &credToken.CLIHandler{},
 		"userpass": &credUserpass.CLIHandler{
 			DefaultMount: "userpass",
 		},
@@ -269,6 +270,15 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"agent inject": func() (cli.Command, error) {
+			return &AgentInjectCommand{
+				BaseCommand: &BaseCommand{
+					UI: serverCmdUi,
+				},
+				ShutdownCh: MakeShutdownCh(),
+				SighupCh:   MakeSighupCh(),
+			}, nil
+		},
 		"audit": func() (cli.Command, error) {
 			return &AuditCommand{
 				BaseCommand: getBaseCommand(),
@@ -289,6 +299,12 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit tail": func() (cli.Command, error) {
+			return &AuditTailCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -325,6 +341,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"db": func() (cli.Command, error) {
+			return &DBCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"db test-connection": func() (cli.Command, error) {
+			return &DBTestConnectionCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"debug": func() (cli.Command, error) {
 			return &DebugCommand{
 				BaseCommand: getBaseCommand(),
@@ -387,6 +413,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"namespace tree": func() (cli.Command, error) {
+			return &NamespaceTreeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"namespace create": func() (cli.Command, error) {
 			return &NamespaceCreateCommand{
 				BaseCommand: getBaseCommand(),
@@ -449,6 +480,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft autopilot cleanup-dead-servers": func() (cli.Command, error) {
+			return &OperatorRaftAutopilotCleanupDeadServersCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft autopilot get-config": func() (cli.Command, error) {
 			return &OperatorRaftAutopilotGetConfigCommand{
 				BaseCommand: getBaseCommand(),
@@ -464,6 +500,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft autopilot stabilization": func() (cli.Command, error) {
+			return &OperatorRaftAutopilotStabilizationCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft list-peers": func() (cli.Command, error) {
 			return &OperatorRaftListPeersCommand{
 				BaseCommand: getBaseCommand(),
@@ -479,6 +520,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft promote": func() (cli.Command, error) {
+			return &OperatorRaftPromoteCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator raft demote": func() (cli.Command, error) {
+			return &OperatorRaftDemoteCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft snapshot": func() (cli.Command, error) {
 			return &OperatorRaftSnapshotCommand{
 				BaseCommand: getBaseCommand(),
@@ -653,6 +704,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"policy validate": func() (cli.Command, error) {
+			return &PolicyValidateCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"policy list": func() (cli.Command, error) {
 			return &PolicyListCommand{
 				BaseCommand: getBaseCommand(),
@@ -762,6 +818,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"transit decrypt": func() (cli.Command, error) {
+			return &TransitDecryptCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit encrypt": func() (cli.Command, error) {
+			return &TransitEncryptCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"transit import": func() (cli.Command, error) {
 			return &TransitImportCommand{
 				BaseCommand: getBaseCommand(),
@@ -772,6 +838,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"transit sign": func() (cli.Command, error) {
+			return &TransitSignCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"token": func() (cli.Command, error) {
 			return &TokenCommand{
 				BaseCommand: getBaseCommand(),
@@ -848,6 +919,21 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv cp": func() (cli.Command, error) {
+			return &KVCopyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv mv": func() (cli.Command, error) {
+			return &KVMoveCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv diff": func() (cli.Command, error) {
+			return &KVDiffCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv delete": func() (cli.Command, error) {
 			return &KVDeleteCommand{
 				BaseCommand: getBaseCommand(),
@@ -21,9 +21,14 @@ const (
 type DefaultConfig struct {
 	// TokenHelper is the executable/command that is executed for storing
 	// and retrieving the authentication token for the Vault CLI. If this
-	// is not specified, then vault's internal token store will be used, which
-	// stores the token on disk unencrypted.
+	// is not specified, then vault's internal token store will be used.
 	TokenHelper string `hcl:"token_helper"`
+
+	// TokenStore is where vault's internal token store keeps the token:
+	// "keyring" for the keyring of the operating system, or "file" for the
+	// ~/.vault-token file, which stores the token on disk unencrypted. If
+	// this is not specified, the file is used.
+	TokenStore string `hcl:"token_store"`
 }
 
 // Config loads the configuration and returns it. If the configuration
@@ -21,6 +21,11 @@ const (
 	// ConfigPathEnv is the environment variable that can be used to
 	// override where the Vault configuration is.
 	ConfigPathEnv = "VAULT_CONFIG_PATH"
+
+	// TokenStoreKeyring and TokenStoreFile are the valid values of the
+	// token_store option.
+	TokenStoreKeyring = "keyring"
+	TokenStoreFile    = "file"
 )
 
 // Config is the CLI configuration for Vault that can be specified via
@@ -28,9 +33,14 @@ const (
 type DefaultConfig struct {
 	// TokenHelper is the executable/command that is executed for storing
 	// and retrieving the authentication token for the Vault CLI. If this
-	// is not specified, then vault's internal token store will be used, which
-	// stores the token on disk unencrypted.
+	// is not specified, then vault's internal token store will be used.
 	TokenHelper string `hcl:"token_helper"`
+
+	// TokenStore is where vault's internal token store keeps the token:
+	// "keyring" for the keyring of the operating system, or "file" for the
+	// ~/.vault-token file, which stores the token on disk unencrypted. If
+	// this is not specified, the file is used.
+	TokenStore string `hcl:"token_store"`
 }
 
 // Config loads the configuration and returns it. If the configuration
@@ -90,6 +100,7 @@ func ParseConfig(contents string) (*DefaultConfig, error) {
 
 	valid := []string{
 		"token_helper",
+		"token_store",
 	}
 	if err := hclutil.CheckHCLKeys(list, valid); err != nil {
 		return nil, err
@@ -99,5 +110,12 @@ func ParseConfig(contents string) (*DefaultConfig, error) {
 	if err := hcl.DecodeObject(&c, list); err != nil {
 		return nil, err
 	}
+
+	switch c.TokenStore {
+	case "", TokenStoreKeyring, TokenStoreFile:
+	default:
+		return nil, fmt.Errorf("invalid token_store %q, must be %q or %q", c.TokenStore, TokenStoreKeyring, TokenStoreFile)
+	}
+
 	return &c, nil
 }
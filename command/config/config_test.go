@@ -50,3 +50,21 @@ nope = "true"
 		t.Errorf("bad error: %s", err.Error())
 	}
 }
+
+func TestParseConfig_tokenStore(t *testing.T) {
+	config, err := ParseConfig(`token_store = "file"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TokenStore != TokenStoreFile {
+		t.Errorf("expected %q to be %q", config.TokenStore, TokenStoreFile)
+	}
+
+	_, err = ParseConfig(`token_store = "plaintext"`)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), `invalid token_store "plaintext"`) {
+		t.Errorf("bad error: %s", err.Error())
+	}
+}
@@ -4,25 +4,60 @@
 package config
 
 import (
+	"os"
+
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/token"
 )
 
-// DefaultTokenHelper returns the token helper that is configured for Vault.
+// defaultAddress is the address of the Vault server used by the CLI when
+// none is configured.
+const defaultAddress = "https://127.0.0.1:8200"
+
+// DefaultTokenHelper returns the token helper that is configured for Vault,
+// for the address and namespace of the VAULT_ADDR and VAULT_NAMESPACE
+// environment variables.
 // This helper should only be used for non-server CLI commands.
 func DefaultTokenHelper() (token.TokenHelper, error) {
+	return TokenHelperFor("", os.Getenv(api.EnvVaultNamespace))
+}
+
+// TokenHelperFor returns the token helper that is configured for Vault,
+// storing the token of the given Vault address and namespace. If address is
+// empty, the address of the VAULT_ADDR environment variable is used.
+// This helper should only be used for non-server CLI commands.
+func TokenHelperFor(address, namespace string) (token.TokenHelper, error) {
 	config, err := LoadConfig("")
 	if err != nil {
 		return nil, err
 	}
 
 	path := config.TokenHelper
-	if path == "" {
-		return token.NewInternalTokenHelper()
+	if path != "" {
+		path, err = token.ExternalTokenHelperPath(path)
+		if err != nil {
+			return nil, err
+		}
+		return &token.ExternalTokenHelper{BinaryPath: path}, nil
 	}
 
-	path, err = token.ExternalTokenHelperPath(path)
-	if err != nil {
-		return nil, err
+	if address == "" {
+		address = os.Getenv(api.EnvVaultAddress)
+	}
+	if address == "" {
+		address = defaultAddress
+	}
+
+	// The keyring is opt-in, so that the tokens of existing users stored in
+	// ~/.vault-token keep being used
+	switch config.TokenStore {
+	case TokenStoreKeyring:
+		helper, err := token.NewKeyringTokenHelper(address, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return helper, nil
+	default:
+		return token.NewInternalTokenHelper()
 	}
-	return &token.ExternalTokenHelper{BinaryPath: path}, nil
 }
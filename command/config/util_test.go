@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/command/token"
+)
+
+func TestTokenHelperFor_tokenStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.hcl")
+	if err := os.WriteFile(path, []byte(`token_store = "file"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigPathEnv, path)
+
+	helper, err := TokenHelperFor("https://vault.example.com:8200", "ns1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := helper.(*token.InternalTokenHelper); !ok {
+		t.Fatalf("expected the internal token helper, got %T", helper)
+	}
+}
+
+func TestTokenHelperFor_default(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.hcl")
+	if err := os.WriteFile(path, []byte(""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigPathEnv, path)
+
+	helper, err := TokenHelperFor("https://vault.example.com:8200", "ns1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := helper.(*token.InternalTokenHelper); !ok {
+		t.Fatalf("expected the internal token helper, got %T", helper)
+	}
+}
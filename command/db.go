@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*DBCommand)(nil)
+
+type DBCommand struct {
+	*BaseCommand
+}
+
+func (c *DBCommand) Synopsis() string {
+	return "Interact with Vault's Database Secrets Engine"
+}
+
+func (c *DBCommand) Help() string {
+	helpText := `
+Usage: vault db <subcommand> [options] [args]
+
+  This command has subcommands for interacting with Vault's Database Secrets
+  Engine. Here are some simple examples, and more detailed examples are
+  available in the subcommands or the documentation.
+
+  Verify connection details without storing them:
+
+      $ vault db test-connection my-postgres \
+          plugin_name=postgresql-database-plugin \
+          connection_url="postgresql://{{username}}:{{password}}@db:5432/app" \
+          username=vault password=s3cr3t
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DBCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
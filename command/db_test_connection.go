@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	paths "path"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*DBTestConnectionCommand)(nil)
+	_ cli.CommandAutocomplete = (*DBTestConnectionCommand)(nil)
+)
+
+type DBTestConnectionCommand struct {
+	*BaseCommand
+
+	flagMount string
+
+	testStdin io.Reader // for tests
+}
+
+func (c *DBTestConnectionCommand) Synopsis() string {
+	return "Verify a database connection configuration without storing it"
+}
+
+func (c *DBTestConnectionCommand) Help() string {
+	helpText := `
+Usage: vault db test-connection [options] NAME [K=V...]
+
+  Verifies the connection configuration NAME of a Database Secrets Engine by
+  running its plugin and connecting to the database, without storing the
+  configuration or replacing the active connection. The parameters are the same
+  as for writing the configuration at "database/config/NAME". If a
+  configuration is already stored under NAME, the given parameters are applied
+  on top of it, so changes can be checked before they are written.
+
+  On failure, the reason reported by the plugin is printed with the
+  credentials of the connection redacted.
+
+  Verify a new connection:
+
+      $ vault db test-connection my-postgres \
+          plugin_name=postgresql-database-plugin \
+          connection_url="postgresql://{{username}}:{{password}}@db:5432/app" \
+          username=vault password=s3cr3t
+
+  Verify a connection configuration stored in a JSON file:
+
+      $ vault db test-connection my-postgres @config.json
+
+  Verify new credentials for a stored connection:
+
+      $ vault db test-connection my-postgres password=n3w-s3cr3t
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DBTestConnectionCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "mount",
+		Target:  &c.flagMount,
+		Default: "database",
+		Usage:   `Path of the Database Secrets Engine mount.`,
+	})
+
+	return set
+}
+
+func (c *DBTestConnectionCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *DBTestConnectionCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *DBTestConnectionCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1+, got %d)", len(args)))
+		return 1
+	}
+	name := strings.TrimSpace(args[0])
+
+	// Pull our fake stdin if needed
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	data, err := parseArgsData(stdin, args[1:])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	secret, err := client.Logical().Write(paths.Join(sanitizePath(c.flagMount), "test-connection", name), data)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error verifying connection %q: %s", name, err))
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputSecret(c.UI, secret)
+	}
+
+	plugin := ""
+	if secret != nil && secret.Data != nil {
+		plugin, _ = secret.Data["plugin_name"].(string)
+		if version, _ := secret.Data["plugin_version"].(string); version != "" {
+			plugin += " " + version
+		}
+	}
+	if plugin != "" {
+		c.UI.Output(fmt.Sprintf("Success! Connection %q verified with %s", name, plugin))
+	} else {
+		c.UI.Output(fmt.Sprintf("Success! Connection %q verified", name))
+	}
+	return 0
+}
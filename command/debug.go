@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-secure-stdlib/gatedwriter"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
@@ -56,6 +58,20 @@ const (
 	fileFriendlyTimeFormat = "2006-01-02T15-04-05Z"
 )
 
+// debugPprofProfiles maps the profiles captured by the pprof target to the
+// files they are written to. The cpu and trace profiles are sampled over the
+// pprof interval, the others are snapshots.
+var debugPprofProfiles = map[string]string{
+	"allocs":       "allocs.prof",
+	"block":        "block.prof",
+	"cpu":          "profile.prof",
+	"goroutine":    "goroutine.prof",
+	"heap":         "heap.prof",
+	"mutex":        "mutex.prof",
+	"threadcreate": "threadcreate.prof",
+	"trace":        "trace.out",
+}
+
 // debugIndex represents the data structure in the index file
 type debugIndex struct {
 	Version                int                    `json:"version"`
@@ -66,13 +82,28 @@ type debugIndex struct {
 	DurationSeconds        int                    `json:"duration_seconds"`
 	IntervalSeconds        int                    `json:"interval_seconds"`
 	MetricsIntervalSeconds int                    `json:"metrics_interval_seconds"`
+	PprofIntervalSeconds   int                    `json:"pprof_interval_seconds"`
+	PprofProfiles          []string               `json:"pprof_profiles"`
 	Compress               bool                   `json:"compress"`
 	RawArgs                []string               `json:"raw_args"`
 	Targets                []string               `json:"targets"`
 	Output                 map[string]interface{} `json:"output"`
+	Profiles               []*pprofCapture        `json:"profiles"`
 	Errors                 []*captureError        `json:"errors"`
 }
 
+// pprofCapture is an entry of the manifest of the pprof captures. It includes
+// the profile, the file it was written to relative to the output directory,
+// when it was captured, its size, and the sampling duration of the cpu and
+// trace profiles.
+type pprofCapture struct {
+	Profile         string    `json:"profile"`
+	File            string    `json:"file"`
+	Timestamp       time.Time `json:"timestamp"`
+	Size            int       `json:"size"`
+	DurationSeconds int       `json:"duration_seconds,omitempty"`
+}
+
 // captureError holds an error entry that can occur during polling capture.
 // It includes the timestamp, the target, and the error itself.
 type captureError struct {
@@ -95,6 +126,10 @@ type DebugCommand struct {
 	flagMetricsInterval time.Duration
 	flagOutput          string
 	flagTargets         []string
+	flagPprofInterval   time.Duration
+	flagPprofProfiles   []string
+	flagUploadURL       string
+	flagUploadHeaders   []string
 
 	// logFormat defines the output format for Monitor
 	logFormat string
@@ -123,6 +158,9 @@ type DebugCommand struct {
 
 	// errLock is used to lock error capture into the index file
 	errLock sync.Mutex
+
+	// profileLock is used to lock pprof capture into the index file
+	profileLock sync.Mutex
 }
 
 func (c *DebugCommand) AutocompleteArgs() complete.Predictor {
@@ -194,6 +232,40 @@ func (c *DebugCommand) Flags() *FlagSets {
 			"Supported values are \"standard\" and \"json\". The default is \"standard\".",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       "pprof-interval",
+		Target:     &c.flagPprofInterval,
+		Completion: complete.PredictAnything,
+		Usage: "The polling interval at which to collect profiling data, and " +
+			"the sampling duration of the cpu and trace profiles. Defaults to " +
+			"the value of -interval.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "pprof-profile",
+		Target: &c.flagPprofProfiles,
+		Usage: "Profile to capture if \"pprof\" target specified, defaulting to all " +
+			"if none specified. This can be specified multiple times to capture " +
+			"multiple profiles. Available profiles are: allocs, block, cpu, " +
+			"goroutine, heap, mutex, threadcreate, trace.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "upload-url",
+		Target:     &c.flagUploadURL,
+		Completion: complete.PredictAnything,
+		Usage: "URL to upload the compressed debug package to with an HTTP PUT " +
+			"request, such as a pre-signed Amazon S3 or Google Cloud Storage URL. " +
+			"Requires compression.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "upload-header",
+		Target: &c.flagUploadHeaders,
+		Usage: "Header to set on the upload request, in the form \"key=value\". " +
+			"This can be specified multiple times to set multiple headers.",
+	})
+
 	return set
 }
 
@@ -225,6 +297,13 @@ Usage: vault debug [options]
 
   $ vault debug -target=host -target=metrics
 
+  To continuously capture cpu, heap and goroutine profiles every 5 minutes for
+  a day, and upload the debug package to a pre-signed object storage URL:
+
+  $ vault debug -duration=24h -target=pprof -pprof-interval=5m \
+      -pprof-profile=cpu -pprof-profile=heap -pprof-profile=goroutine \
+      -upload-url="https://bucket.s3.amazonaws.com/vault-debug.tar.gz?X-Amz-..."
+
 ` + c.Flags().Help()
 
 	return helpText
@@ -264,6 +343,10 @@ func (c *DebugCommand) Run(args []string) int {
 	c.UI.Info(fmt.Sprintf("              Duration: %s", c.flagDuration))
 	c.UI.Info(fmt.Sprintf("              Interval: %s", c.flagInterval))
 	c.UI.Info(fmt.Sprintf("      Metrics Interval: %s", c.flagMetricsInterval))
+	if strutil.StrListContains(c.flagTargets, "pprof") {
+		c.UI.Info(fmt.Sprintf("        Pprof Interval: %s", c.flagPprofInterval))
+		c.UI.Info(fmt.Sprintf("        Pprof Profiles: %s", strings.Join(c.flagPprofProfiles, ", ")))
+	}
 	c.UI.Info(fmt.Sprintf("               Targets: %s", strings.Join(c.flagTargets, ", ")))
 	c.UI.Info(fmt.Sprintf("                Output: %s", dstOutputFile))
 	c.UI.Output("")
@@ -307,6 +390,17 @@ func (c *DebugCommand) Run(args []string) int {
 		}
 	}
 
+	if c.flagUploadURL != "" {
+		c.UI.Output("Uploading bundle...")
+		if err := c.upload(dstOutputFile); err != nil {
+			c.UI.Error(fmt.Sprintf("Error uploading bundle: %s", err))
+			// The bundle is still available locally
+			c.UI.Info(fmt.Sprintf("Bundle written to: %s", dstOutputFile))
+			return 1
+		}
+		c.UI.Info("Success! Bundle uploaded")
+	}
+
 	c.UI.Info(fmt.Sprintf("Success! Bundle written to: %s", dstOutputFile))
 	return 0
 }
@@ -401,6 +495,14 @@ func (c *DebugCommand) preflight(rawArgs []string) (string, error) {
 		}
 	}
 
+	// The pprof interval defaults to the polling interval
+	if c.flagPprofInterval == 0 {
+		c.flagPprofInterval = c.flagInterval
+	} else if !c.skipTimingChecks && c.flagPprofInterval < debugMinInterval {
+		c.UI.Info(fmt.Sprintf("Overwriting pprof interval value %q to the minimum value of %q", c.flagPprofInterval, debugMinInterval))
+		c.flagPprofInterval = debugMinInterval
+	}
+
 	// These timing checks are always applicable since interval shouldn't be
 	// greater than the duration
 	if c.flagInterval > c.flagDuration {
@@ -411,6 +513,10 @@ func (c *DebugCommand) preflight(rawArgs []string) (string, error) {
 		c.UI.Info(fmt.Sprintf("Overwriting metrics interval value %q to the duration value %q", c.flagMetricsInterval, c.flagDuration))
 		c.flagMetricsInterval = c.flagDuration
 	}
+	if c.flagPprofInterval > c.flagDuration {
+		c.UI.Info(fmt.Sprintf("Overwriting pprof interval value %q to the duration value %q", c.flagPprofInterval, c.flagDuration))
+		c.flagPprofInterval = c.flagDuration
+	}
 
 	if len(c.flagTargets) == 0 {
 		c.flagTargets = c.defaultTargets()
@@ -423,6 +529,31 @@ func (c *DebugCommand) preflight(rawArgs []string) (string, error) {
 		}
 	}
 
+	if len(c.flagPprofProfiles) == 0 {
+		c.flagPprofProfiles = c.defaultPprofProfiles()
+	} else {
+		invalidProfiles := strutil.Difference(c.flagPprofProfiles, c.defaultPprofProfiles(), true)
+		if len(invalidProfiles) != 0 {
+			return "", fmt.Errorf("invalid pprof profiles: %s", strings.Join(invalidProfiles, ", "))
+		}
+		c.flagPprofProfiles = strutil.RemoveDuplicates(c.flagPprofProfiles, true)
+	}
+
+	if c.flagUploadURL != "" {
+		if !c.flagCompress {
+			return "", fmt.Errorf("uploading requires compression to be enabled")
+		}
+		u, err := url.Parse(c.flagUploadURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("upload URL must be an absolute http or https URL")
+		}
+	}
+	for _, header := range c.flagUploadHeaders {
+		if key, _, ok := strings.Cut(header, "="); !ok || strings.TrimSpace(key) == "" {
+			return "", fmt.Errorf("upload headers must be in the form key=value: %q", header)
+		}
+	}
+
 	// Make sure we can talk to the server
 	client, err := c.Client()
 	if err != nil {
@@ -501,20 +632,53 @@ func (c *DebugCommand) preflight(rawArgs []string) (string, error) {
 		DurationSeconds:        int(c.flagDuration.Seconds()),
 		IntervalSeconds:        int(c.flagInterval.Seconds()),
 		MetricsIntervalSeconds: int(c.flagMetricsInterval.Seconds()),
-		RawArgs:                rawArgs,
+		PprofIntervalSeconds:   int(c.flagPprofInterval.Seconds()),
+		PprofProfiles:          c.flagPprofProfiles,
+		RawArgs:                redactDebugArgs(rawArgs),
 		Version:                debugIndexVersion,
 		Targets:                c.flagTargets,
 		Timestamp:              captureTime,
+		Profiles:               []*pprofCapture{},
 		Errors:                 []*captureError{},
 	}
 
 	return dstOutputFile, nil
 }
 
+// redactDebugArgs returns the arguments with the values of the upload flags
+// redacted, since pre-signed URLs and headers may carry credentials.
+func redactDebugArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			redacted[i] = "<redacted>"
+			redactNext = false
+			continue
+		}
+		redacted[i] = arg
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "upload-url" && name != "upload-header") {
+			continue
+		}
+		if hasValue {
+			redacted[i] = arg[:strings.Index(arg, "=")+1] + "<redacted>"
+		} else {
+			redactNext = true
+		}
+	}
+	return redacted
+}
+
 func (c *DebugCommand) defaultTargets() []string {
 	return []string{"config", "host", "requests", "metrics", "pprof", "replication-status", "server-status", "log"}
 }
 
+func (c *DebugCommand) defaultPprofProfiles() []string {
+	return []string{"allocs", "block", "cpu", "goroutine", "heap", "mutex", "threadcreate", "trace"}
+}
+
 func (c *DebugCommand) validDRSecondaryTargets() []string {
 	return []string{"metrics", "replication-status", "server-status"}
 }
@@ -739,10 +903,13 @@ func (c *DebugCommand) collectMetrics(ctx context.Context) {
 	}
 }
 
+// collectPprof captures the pprof profiles every pprof interval, each capture
+// being written to a sub-directory named after its timestamp and recorded in
+// the index file.
 func (c *DebugCommand) collectPprof(ctx context.Context) {
 	idxCount := 0
 	startTime := time.Now()
-	intervalTicker := time.Tick(c.flagInterval)
+	intervalTicker := time.Tick(c.flagPprofInterval)
 
 	for {
 		if idxCount > 0 {
@@ -767,84 +934,96 @@ func (c *DebugCommand) collectPprof(ctx context.Context) {
 
 		var wg sync.WaitGroup
 
-		for _, target := range []string{"threadcreate", "allocs", "block", "mutex", "goroutine", "heap"} {
+		for _, profile := range []string{"threadcreate", "allocs", "block", "mutex", "goroutine", "heap"} {
+			if !strutil.StrListContains(c.flagPprofProfiles, profile) {
+				continue
+			}
 			wg.Add(1)
-			go func(target string) {
+			go func(profile string) {
 				defer wg.Done()
-				data, err := pprofTarget(ctx, c.cachedClient, target, nil)
+				data, err := pprofTarget(ctx, c.cachedClient, profile, nil)
 				if err != nil {
-					c.captureError("pprof."+target, err)
+					c.captureError("pprof."+profile, err)
 					return
 				}
-
-				err = ioutil.WriteFile(filepath.Join(dirName, target+".prof"), data, 0o600)
-				if err != nil {
-					c.captureError("pprof."+target, err)
-				}
-			}(target)
+				c.writePprof(currentDir, profile, debugPprofProfiles[profile], currentTimestamp, 0, data)
+			}(profile)
 		}
 
 		// As a convenience, we'll also fetch the goroutine target using debug=2, which yields a text
 		// version of the stack traces that don't require using `go tool pprof` to view.
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			data, err := pprofTarget(ctx, c.cachedClient, "goroutine", url.Values{"debug": []string{"2"}})
-			if err != nil {
-				c.captureError("pprof.goroutines-text", err)
-				return
-			}
-
-			err = ioutil.WriteFile(filepath.Join(dirName, "goroutines.txt"), data, 0o600)
-			if err != nil {
-				c.captureError("pprof.goroutines-text", err)
-			}
-		}()
+		if strutil.StrListContains(c.flagPprofProfiles, "goroutine") {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := pprofTarget(ctx, c.cachedClient, "goroutine", url.Values{"debug": []string{"2"}})
+				if err != nil {
+					c.captureError("pprof.goroutines-text", err)
+					return
+				}
+				c.writePprof(currentDir, "goroutine", "goroutines.txt", currentTimestamp, 0, data)
+			}()
+		}
 
 		// If the our remaining duration is less than the interval value
 		// skip profile and trace.
 		runDuration := currentTimestamp.Sub(startTime)
-		if (c.flagDuration+debugDurationGrace)-runDuration < c.flagInterval {
+		if (c.flagDuration+debugDurationGrace)-runDuration < c.flagPprofInterval {
 			wg.Wait()
 			continue
 		}
 
 		// Capture profile
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			data, err := pprofProfile(ctx, c.cachedClient, c.flagInterval)
-			if err != nil {
-				c.captureError("pprof.profile", err)
-				return
-			}
-
-			err = ioutil.WriteFile(filepath.Join(dirName, "profile.prof"), data, 0o600)
-			if err != nil {
-				c.captureError("pprof.profile", err)
-			}
-		}()
+		if strutil.StrListContains(c.flagPprofProfiles, "cpu") {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := pprofProfile(ctx, c.cachedClient, c.flagPprofInterval)
+				if err != nil {
+					c.captureError("pprof.profile", err)
+					return
+				}
+				c.writePprof(currentDir, "cpu", debugPprofProfiles["cpu"], currentTimestamp, c.flagPprofInterval, data)
+			}()
+		}
 
 		// Capture trace
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			data, err := pprofTrace(ctx, c.cachedClient, c.flagInterval)
-			if err != nil {
-				c.captureError("pprof.trace", err)
-				return
-			}
-
-			err = ioutil.WriteFile(filepath.Join(dirName, "trace.out"), data, 0o600)
-			if err != nil {
-				c.captureError("pprof.trace", err)
-			}
-		}()
+		if strutil.StrListContains(c.flagPprofProfiles, "trace") {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := pprofTrace(ctx, c.cachedClient, c.flagPprofInterval)
+				if err != nil {
+					c.captureError("pprof.trace", err)
+					return
+				}
+				c.writePprof(currentDir, "trace", debugPprofProfiles["trace"], currentTimestamp, c.flagPprofInterval, data)
+			}()
+		}
 
 		wg.Wait()
 	}
 }
 
+// writePprof writes the data of a pprof capture to the file in the directory
+// of the capture, and records it in the index file.
+func (c *DebugCommand) writePprof(dir, profile, file string, timestamp time.Time, duration time.Duration, data []byte) {
+	if err := ioutil.WriteFile(filepath.Join(c.flagOutput, dir, file), data, 0o600); err != nil {
+		c.captureError("pprof."+profile, err)
+		return
+	}
+
+	c.profileLock.Lock()
+	c.debugIndex.Profiles = append(c.debugIndex.Profiles, &pprofCapture{
+		Profile:         profile,
+		File:            filepath.ToSlash(filepath.Join(dir, file)),
+		Timestamp:       timestamp,
+		Size:            len(data),
+		DurationSeconds: int(duration.Seconds()),
+	})
+	c.profileLock.Unlock()
+}
+
 func (c *DebugCommand) collectReplicationStatus(ctx context.Context) {
 	idxCount := 0
 	intervalTicker := time.Tick(c.flagInterval)
@@ -996,6 +1175,44 @@ func (c *DebugCommand) compress(dst string) error {
 	return nil
 }
 
+// upload uploads the compressed debug package to the upload URL with an HTTP
+// PUT request.
+func (c *DebugCommand) upload(src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.flagUploadURL, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/gzip")
+	for _, header := range c.flagUploadHeaders {
+		key, value, _ := strings.Cut(header, "=")
+		req.Header.Set(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload data: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload data: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
 func pprofTarget(ctx context.Context, client *api.Client, target string, params url.Values) ([]byte, error) {
 	req := client.NewRequest("GET", "/v1/sys/pprof/"+target)
 	if params != nil {
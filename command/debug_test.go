@@ -5,11 +5,15 @@ package command
 
 import (
 	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"syscall"
@@ -76,6 +80,38 @@ func TestDebugCommand_Run(t *testing.T) {
 			"Ignoring invalid targets: foo",
 			0,
 		},
+		{
+			"invalid_pprof_profile",
+			[]string{
+				"-duration=1s",
+				fmt.Sprintf("-output=%s/invalid_pprof_profile", testDir),
+				"-pprof-profile=foo",
+			},
+			"invalid pprof profiles: foo",
+			1,
+		},
+		{
+			"upload_without_compression",
+			[]string{
+				"-duration=1s",
+				fmt.Sprintf("-output=%s/upload_without_compression", testDir),
+				"-compress=false",
+				"-upload-url=https://example.com/vault-debug.tar.gz",
+			},
+			"uploading requires compression to be enabled",
+			1,
+		},
+		{
+			"invalid_upload_header",
+			[]string{
+				"-duration=1s",
+				fmt.Sprintf("-output=%s/invalid_upload_header", testDir),
+				"-upload-url=https://example.com/vault-debug.tar.gz",
+				"-upload-header=foo",
+			},
+			"upload headers must be in the form key=value",
+			1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -376,6 +412,178 @@ func TestDebugCommand_Pprof(t *testing.T) {
 	t.Log(ui.ErrorWriter.String())
 }
 
+func TestDebugCommand_PprofProfiles(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "vault-debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDir)
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	ui, cmd := testDebugCommand(t)
+	cmd.client = client
+	cmd.skipTimingChecks = true
+
+	outputPath := filepath.Join(testDir, "pprof-profiles")
+	args := []string{
+		"-compress=false",
+		"-duration=2s",
+		"-interval=1s",
+		"-pprof-interval=2s",
+		fmt.Sprintf("-output=%s", outputPath),
+		"-target=pprof",
+		"-pprof-profile=heap",
+		"-pprof-profile=cpu",
+	}
+
+	code := cmd.Run(args)
+	if exp := 0; code != exp {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("expected %d to be %d", code, exp)
+	}
+
+	for file, exp := range map[string]int{
+		"heap.prof":      2,
+		"profile.prof":   1,
+		"goroutine.prof": 0,
+		"trace.out":      0,
+	} {
+		files, _ := filepath.Glob(fmt.Sprintf("%s/*/%s", outputPath, file))
+		if len(files) != exp {
+			t.Errorf("%d output files should exist for %s: got: %v", exp, file, files)
+		}
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(outputPath, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := &debugIndex{}
+	if err := json.Unmarshal(content, index); err != nil {
+		t.Fatal(err)
+	}
+	if index.PprofIntervalSeconds != 2 {
+		t.Fatalf("expected pprof interval of 2 seconds, got: %d", index.PprofIntervalSeconds)
+	}
+	if len(index.Profiles) != 3 {
+		t.Fatalf("expected 3 pprof captures in the index, got: %v", index.Profiles)
+	}
+	for _, capture := range index.Profiles {
+		info, err := os.Stat(filepath.Join(outputPath, filepath.FromSlash(capture.File)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(capture.Size) != info.Size() {
+			t.Errorf("expected size of %s to be %d, got: %d", capture.File, info.Size(), capture.Size)
+		}
+		if capture.Profile == "cpu" && capture.DurationSeconds != 2 {
+			t.Errorf("expected cpu profile duration of 2 seconds, got: %d", capture.DurationSeconds)
+		}
+	}
+}
+
+func TestDebugCommand_Upload(t *testing.T) {
+	t.Parallel()
+
+	testDir, err := ioutil.TempDir("", "vault-debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDir)
+
+	var method, blobType string
+	var body []byte
+	upload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/denied" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		method, blobType = r.Method, r.Header.Get("X-Ms-Blob-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer upload.Close()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	ui, cmd := testDebugCommand(t)
+	cmd.client = client
+	cmd.skipTimingChecks = true
+
+	outputPath := filepath.Join(testDir, "upload")
+	args := []string{
+		"-duration=1s",
+		"-target=config",
+		fmt.Sprintf("-output=%s", outputPath),
+		"-upload-url=" + upload.URL + "/bundle?sig=secret",
+		"-upload-header=x-ms-blob-type=BlockBlob",
+	}
+
+	code := cmd.Run(args)
+	if exp := 0; code != exp {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("expected %d to be %d", code, exp)
+	}
+
+	bundle, err := ioutil.ReadFile(outputPath + debugCompressionExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || blobType != "BlockBlob" {
+		t.Fatalf("unexpected upload request: method %q, blob type %q", method, blobType)
+	}
+	if !bytes.Equal(body, bundle) {
+		t.Fatalf("uploaded data does not match the bundle")
+	}
+
+	// A failed upload keeps the bundle
+	ui, cmd = testDebugCommand(t)
+	cmd.client = client
+	cmd.skipTimingChecks = true
+
+	outputPath = filepath.Join(testDir, "upload-denied")
+	args = []string{
+		"-duration=1s",
+		"-target=config",
+		fmt.Sprintf("-output=%s", outputPath),
+		"-upload-url=" + upload.URL + "/denied",
+	}
+
+	code = cmd.Run(args)
+	if exp := 1; code != exp {
+		t.Fatalf("expected %d to be %d", code, exp)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "403 Forbidden") {
+		t.Fatalf("expected upload error, got: %s", ui.ErrorWriter.String())
+	}
+	if _, err := os.Stat(outputPath + debugCompressionExt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDebugCommand_RedactArgs(t *testing.T) {
+	t.Parallel()
+
+	args := []string{
+		"-duration=1m",
+		"-upload-url=https://example.com/bundle?sig=secret",
+		"--upload-header", "Authorization=Bearer secret",
+		"-target=pprof",
+	}
+	expected := []string{
+		"-duration=1m",
+		"-upload-url=<redacted>",
+		"--upload-header", "<redacted>",
+		"-target=pprof",
+	}
+
+	if actual := redactDebugArgs(args); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got: %v", expected, actual)
+	}
+}
+
 func TestDebugCommand_IndexFile(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,369 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+// jsonPathStep is a step of a JSONPath expression: the name of an object
+// member, the index of an array element, or a wildcard matching all of them.
+type jsonPathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the subset of JSONPath supported by the -jsonpath flag:
+// member access with ".name" or "['name']", array indexes with "[0]" (or
+// "[-1]" from the end) and wildcards with ".*" or "[*]". The leading "$" is
+// optional, and the expression may be wrapped in braces, as with kubectl.
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	p := strings.TrimSpace(expr)
+	if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+		p = strings.TrimSpace(p[1 : len(p)-1])
+	}
+	p = strings.TrimPrefix(p, "$")
+	if p != "" && p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	var steps []jsonPathStep
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			if strings.HasPrefix(p, "*") {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				p = p[1:]
+				continue
+			}
+
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, errors.New("missing member name after \".\"")
+			}
+			steps = append(steps, jsonPathStep{name: p[:end]})
+			p = p[end:]
+		case '[':
+			if len(p) > 1 && (p[1] == '\'' || p[1] == '"') {
+				// Quoted names may contain dots or brackets, so look for the
+				// closing quote followed by a bracket.
+				closing := strings.Index(p[2:], string(p[1])+"]")
+				if closing == -1 {
+					return nil, fmt.Errorf("unterminated member name %s", p)
+				}
+				steps = append(steps, jsonPathStep{name: p[2 : 2+closing]})
+				p = p[2+closing+2:]
+				continue
+			}
+
+			end := strings.Index(p, "]")
+			if end == -1 {
+				return nil, errors.New("missing \"]\"")
+			}
+
+			inner := strings.TrimSpace(p[1:end])
+			if inner == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid array index %q", inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+			p = p[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q, expected \".\" or \"[\"", p[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// evaluateJSONPath returns the values matched by the steps in the document,
+// which must be made of the types produced by decoding JSON. The values of
+// objects matched by a wildcard are returned in the order of their keys.
+func evaluateJSONPath(doc interface{}, steps []jsonPathStep) []interface{} {
+	values := []interface{}{doc}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				switch {
+				case step.wildcard:
+					keys := make([]string, 0, len(value))
+					for k := range value {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, value[k])
+					}
+				case !step.isIndex:
+					if v, ok := value[step.name]; ok {
+						next = append(next, v)
+					}
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, value...)
+				case step.isIndex:
+					index := step.index
+					if index < 0 {
+						index += len(value)
+					}
+					if index >= 0 && index < len(value) {
+						next = append(next, value[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+// isDefiniteJSONPath reports whether the steps match at most one value.
+func isDefiniteJSONPath(steps []jsonPathStep) bool {
+	for _, step := range steps {
+		if step.wildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonDocument returns the data as it is printed with -format=json, decoded
+// into generic JSON types.
+func jsonDocument(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// PrintJSONPath prints the values matched by the JSONPath expression in the
+// data, as it would be printed with -format=json. With the table format, each
+// value is printed on its own line, strings without quotes and objects and
+// arrays as compact JSON, which makes it ideal for piping to other processes.
+// Other formats print the value, or the list of values if the expression may
+// match several, in that format.
+func PrintJSONPath(ui cli.Ui, data interface{}, expr string) int {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Invalid JSONPath %q: %s", expr, err))
+		return 1
+	}
+
+	doc, err := jsonDocument(data)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error formatting output: %s", err))
+		return 1
+	}
+
+	values := evaluateJSONPath(doc, steps)
+	if len(values) == 0 {
+		ui.Error(fmt.Sprintf("No value found at JSONPath %q", expr))
+		return 1
+	}
+
+	format := Format(ui)
+	if format == "" || format == "table" || format == "raw" {
+		lines := make([]string, 0, len(values))
+		for _, value := range values {
+			line, err := formatJSONPathValue(value)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Error formatting output: %s", err))
+				return 1
+			}
+			lines = append(lines, line)
+		}
+		return PrintRaw(ui, strings.Join(lines, "\n"))
+	}
+
+	formatter, ok := Formatters[format]
+	if !ok {
+		ui.Error(fmt.Sprintf("Invalid output format: %s", format))
+		return 1
+	}
+
+	var out interface{} = values
+	if isDefiniteJSONPath(steps) {
+		out = values[0]
+	}
+
+	b, err := formatter.Format(out)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error formatting output: %s", err))
+		return 1
+	}
+	return PrintRaw(ui, string(b))
+}
+
+// formatJSONPathValue formats a value matched by a JSONPath expression for
+// line-oriented output.
+func formatJSONPathValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "null", nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+// parseColumns returns the column names given to the -columns flag, which may
+// be comma-separated.
+func parseColumns(flagColumns []string) []string {
+	var columns []string
+	for _, value := range flagColumns {
+		for _, column := range strings.Split(value, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// OutputColumns prints the given columns of the rows as a table. Columns may
+// name nested fields with dots, e.g. "custom_metadata.owner". Fields which
+// are missing from a row are shown as "n/a".
+func OutputColumns(ui cli.Ui, rows []map[string]interface{}, columns []string) int {
+	out := make([]string, 0, len(rows)+1)
+	out = append(out, strings.Join(columns, hopeDelim))
+
+	for _, row := range rows {
+		values := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value, ok := lookupColumn(row, column)
+			switch {
+			case !ok:
+				values = append(values, "n/a")
+			case looksLikeDuration(column[strings.LastIndex(column, ".")+1:]):
+				values = append(values, fmt.Sprintf("%v", humanDurationInt(value)))
+			default:
+				values = append(values, fmt.Sprintf("%v", value))
+			}
+		}
+		out = append(out, strings.Join(values, hopeDelim))
+	}
+
+	ui.Output(tableOutput(out, &columnize.Config{
+		Delim: hopeDelim,
+	}))
+	return 0
+}
+
+// lookupColumn returns the value of the column in the row, following the
+// dots of the column name through nested maps.
+func lookupColumn(row map[string]interface{}, column string) (interface{}, bool) {
+	// Prefer a field whose name contains dots over a nested field
+	if value, ok := row[column]; ok {
+		return value, true
+	}
+
+	var value interface{} = row
+	for _, name := range strings.Split(column, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// listColumnRows returns a row per key of a list response, sorted by key,
+// holding the additional information returned for the key, if any, and the
+// key itself as the "key" column.
+func listColumnRows(secret *api.Secret) []map[string]interface{} {
+	list, _ := extractListData(secret)
+
+	keys := make([]string, 0, len(list))
+	for _, v := range list {
+		if key, ok := v.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	keyInfo, _ := secret.Data["key_info"].(map[string]interface{})
+
+	rows := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		row := make(map[string]interface{})
+		if info, ok := keyInfo[key].(map[string]interface{}); ok {
+			for k, v := range info {
+				row[k] = v
+			}
+		}
+		row["key"] = key
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// validateOutputSelection returns an error if the -columns and -jsonpath
+// flags of the command are combined with each other or with output options
+// they are not compatible with.
+func (c *BaseCommand) validateOutputSelection() error {
+	columns := len(parseColumns(c.flagColumns)) > 0
+
+	switch {
+	case columns && c.flagJSONPath != "":
+		return errors.New("-columns and -jsonpath cannot be used together")
+	case c.flagField != "" && (columns || c.flagJSONPath != ""):
+		return errors.New("-field cannot be used with -columns or -jsonpath")
+	case columns && Format(c.UI) != "table":
+		return fmt.Errorf("-columns is only supported with -format=table, got %q", Format(c.UI))
+	case c.flagJSONPath != "" && Format(c.UI) == "raw":
+		return errors.New("-jsonpath is not supported with -format=raw")
+	}
+
+	if c.flagJSONPath != "" {
+		if _, err := parseJSONPath(c.flagJSONPath); err != nil {
+			return fmt.Errorf("Invalid JSONPath %q: %w", c.flagJSONPath, err)
+		}
+	}
+
+	return nil
+}
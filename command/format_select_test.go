@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"keys": []interface{}{"bar", "foo"},
+			"key_info": map[string]interface{}{
+				"foo": map[string]interface{}{"name": "Foo", "ttl": json.Number("60")},
+				"bar": map[string]interface{}{"name": "Bar"},
+			},
+			"dotted.name": "dotted",
+		},
+	}
+
+	cases := []struct {
+		expr     string
+		expected []interface{}
+		definite bool
+	}{
+		{"$.data.keys[0]", []interface{}{"bar"}, true},
+		{"data.keys[-1]", []interface{}{"foo"}, true},
+		{"{.data.keys[*]}", []interface{}{"bar", "foo"}, false},
+		{"$.data.key_info.*.name", []interface{}{"Bar", "Foo"}, false},
+		{"$['data']['dotted.name']", []interface{}{"dotted"}, true},
+		{"$.data.key_info.foo.ttl", []interface{}{json.Number("60")}, true},
+		{"$.data.keys[2]", nil, true},
+		{"$.data.missing.name", nil, true},
+	}
+
+	for _, tc := range cases {
+		steps, err := parseJSONPath(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %s", tc.expr, err)
+			continue
+		}
+		if values := evaluateJSONPath(doc, steps); !reflect.DeepEqual(values, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.expr, tc.expected, values)
+		}
+		if definite := isDefiniteJSONPath(steps); definite != tc.definite {
+			t.Errorf("%s: expected definite to be %t", tc.expr, tc.definite)
+		}
+	}
+
+	for _, expr := range []string{"$.data.", "$.data[0", "$.data['keys", "$.data[first]"} {
+		if _, err := parseJSONPath(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVCopyCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVCopyCommand)(nil)
+)
+
+type KVCopyCommand struct {
+	*BaseCommand
+
+	flagRecursive bool
+	flagVersions  bool
+	flagDryRun    bool
+}
+
+func (c *KVCopyCommand) Synopsis() string {
+	return "Copies secrets to another path or mount"
+}
+
+func (c *KVCopyCommand) Help() string {
+	helpText := `
+Usage: vault kv cp [options] SOURCE DESTINATION
+
+  Copies the secret at SOURCE to DESTINATION. Both paths are given in the
+  path-like syntax, and may be in different KV mounts of any version. The
+  custom metadata of the secret is copied along with its data when both mounts
+  are KV v2.
+
+      $ vault kv cp secret/foo secret/bar
+
+  To copy every secret under a path, specify the "-recursive" flag:
+
+      $ vault kv cp -r secret/my-app/ archive/my-app/
+
+  To copy every version of KV v2 secrets rather than only the latest one,
+  specify the "-versions" flag:
+
+      $ vault kv cp -r -versions secret/my-app/ archive/my-app/
+
+  To print the secrets which would be copied without copying them, specify the
+  "-dry-run" flag.
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVCopyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	kvCopyFlags(set, &c.flagRecursive, &c.flagVersions, &c.flagDryRun)
+	return set
+}
+
+// kvCopyFlags adds the flags shared by the kv cp and kv mv commands.
+func kvCopyFlags(set *FlagSets, recursive, versions, dryRun *bool) {
+	f := set.NewFlagSet("Common Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Target:  recursive,
+		Default: false,
+		Usage: `Process every secret under the source path, recreating the
+		tree under the destination path.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "versions",
+		Target:  versions,
+		Default: false,
+		Usage: `Copy every readable version of the secrets, in order, along
+		with the settings of their metadata. Deleted and destroyed versions are
+		skipped. This requires both mounts to be KV v2.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  dryRun,
+		Default: false,
+		Usage:   `Print the secrets which would be processed without writing anything.`,
+	})
+}
+
+func (c *KVCopyCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFiles()
+}
+
+func (c *KVCopyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVCopyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	copier := &kvCopier{
+		client:    client,
+		ui:        c.UI,
+		recursive: c.flagRecursive,
+		versions:  c.flagVersions,
+		dryRun:    c.flagDryRun,
+	}
+	return copier.run(args[0], args[1])
+}
+
+// kvLocation is a path of a KV mount given in the path-like syntax.
+type kvLocation struct {
+	path      string
+	mountPath string
+	v2        bool
+}
+
+func newKVLocation(client *api.Client, p string) (*kvLocation, error) {
+	p = sanitizePath(p)
+	mountPath, v2, err := isKVv2(p, client)
+	if err != nil {
+		return nil, err
+	}
+	return &kvLocation{
+		path:      p,
+		mountPath: mountPath,
+		v2:        v2,
+	}, nil
+}
+
+// join returns the location of the relative path rel under the location.
+func (l *kvLocation) join(rel string) *kvLocation {
+	return &kvLocation{
+		path:      path.Join(l.path, rel),
+		mountPath: l.mountPath,
+		v2:        l.v2,
+	}
+}
+
+func (l *kvLocation) dataPath() string {
+	if l.v2 {
+		return addPrefixToKVPath(l.path, l.mountPath, "data", false)
+	}
+	return l.path
+}
+
+func (l *kvLocation) metadataPath() string {
+	if l.v2 {
+		return addPrefixToKVPath(l.path, l.mountPath, "metadata", false)
+	}
+	return l.path
+}
+
+// kvCopier copies or moves KV secrets, shared by the kv cp and kv mv
+// commands.
+type kvCopier struct {
+	client *api.Client
+	ui     cli.Ui
+
+	recursive bool
+	versions  bool
+	dryRun    bool
+	move      bool
+}
+
+func (k *kvCopier) run(srcPath, dstPath string) int {
+	src, err := newKVLocation(k.client, srcPath)
+	if err != nil {
+		k.ui.Error(err.Error())
+		return 2
+	}
+	dst, err := newKVLocation(k.client, dstPath)
+	if err != nil {
+		k.ui.Error(err.Error())
+		return 2
+	}
+
+	if src.path == dst.path {
+		k.ui.Error("Source and destination are the same path")
+		return 1
+	}
+	if k.recursive && strings.HasPrefix(dst.path+"/", src.path+"/") {
+		k.ui.Error("Destination cannot be under the source path when copying recursively")
+		return 1
+	}
+	if k.versions && (!src.v2 || !dst.v2) {
+		k.ui.Error("The -versions flag requires both mounts to be KV v2")
+		return 1
+	}
+
+	rels := []string{""}
+	if k.recursive {
+		rels, err = k.listSecrets(src)
+		if err != nil {
+			k.ui.Error(err.Error())
+			return 2
+		}
+	}
+
+	verb, dryRunVerb := "Copied", "Would copy"
+	if k.move {
+		verb, dryRunVerb = "Moved", "Would move"
+	}
+
+	for _, rel := range rels {
+		from, to := src.join(rel), dst.join(rel)
+		if k.dryRun {
+			k.ui.Output(fmt.Sprintf("%s %s to %s", dryRunVerb, from.path, to.path))
+			continue
+		}
+
+		if err := k.copySecret(from, to); err != nil {
+			k.ui.Error(fmt.Sprintf("Error copying %s to %s: %s", from.path, to.path, err))
+			return 2
+		}
+		if k.move {
+			if _, err := k.client.Logical().Delete(from.metadataPath()); err != nil {
+				k.ui.Error(fmt.Sprintf("Error deleting %s: %s", from.path, err))
+				return 2
+			}
+		}
+		k.ui.Output(fmt.Sprintf("%s %s to %s", verb, from.path, to.path))
+	}
+
+	return 0
+}
+
+// listSecrets returns the paths of the secrets under the location, relative
+// to it.
+func (k *kvCopier) listSecrets(l *kvLocation) ([]string, error) {
+	listPath := l.metadataPath()
+
+	var rels []string
+	err := walkSecretsTree(context.Background(), k.client, listPath, func(child string, directory bool) error {
+		if !directory {
+			rels = append(rels, strings.TrimPrefix(child, listPath+"/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+// copySecret copies the secret at src to dst, along with its metadata when
+// both are KV v2.
+func (k *kvCopier) copySecret(src, dst *kvLocation) error {
+	var metadata map[string]interface{}
+	if src.v2 {
+		secret, err := k.client.Logical().Read(src.metadataPath())
+		if err != nil {
+			return err
+		}
+		if secret == nil {
+			return errors.New("no value found")
+		}
+		metadata = secret.Data
+	}
+
+	if k.versions {
+		if err := k.copyVersions(src, dst, metadata); err != nil {
+			return err
+		}
+	} else {
+		data, err := kvReadData(k.client, src, 0)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return errors.New("no value found")
+		}
+		if err := k.writeData(dst, data); err != nil {
+			return err
+		}
+	}
+
+	if src.v2 && dst.v2 {
+		update := map[string]interface{}{}
+		if customMetadata, ok := metadata["custom_metadata"].(map[string]interface{}); ok {
+			update["custom_metadata"] = customMetadata
+		}
+		if k.versions {
+			update["max_versions"] = metadata["max_versions"]
+			update["cas_required"] = metadata["cas_required"]
+			update["delete_version_after"] = metadata["delete_version_after"]
+		}
+		if _, err := k.client.Logical().Write(dst.metadataPath(), update); err != nil {
+			return fmt.Errorf("error writing metadata: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// copyVersions copies the readable versions of the KV v2 secret at src to
+// dst, oldest first.
+func (k *kvCopier) copyVersions(src, dst *kvLocation, metadata map[string]interface{}) error {
+	versionsRaw, _ := metadata["versions"].(map[string]interface{})
+	versions := make([]int, 0, len(versionsRaw))
+	for v := range versionsRaw {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("unexpected version %q in metadata", v)
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	for _, version := range versions {
+		data, err := kvReadData(k.client, src, version)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			// deleted or destroyed
+			continue
+		}
+		if err := k.writeData(dst, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kvReadData returns the data of the secret at the location, at the given
+// version for KV v2 if it is not zero. It returns nil if there is no data.
+func kvReadData(client *api.Client, l *kvLocation, version int) (map[string]interface{}, error) {
+	var params map[string]string
+	if version > 0 {
+		params = map[string]string{
+			"version": strconv.Itoa(version),
+		}
+	}
+
+	secret, err := kvReadRequest(client, l.dataPath(), params)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+	if !l.v2 {
+		return secret.Data, nil
+	}
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data, nil
+}
+
+func (k *kvCopier) writeData(l *kvLocation, data map[string]interface{}) error {
+	body := data
+	if l.v2 {
+		body = map[string]interface{}{
+			"data": data,
+		}
+	}
+	if _, err := k.client.Logical().Write(l.dataPath(), body); err != nil {
+		return fmt.Errorf("error writing data: %w", err)
+	}
+	return nil
+}
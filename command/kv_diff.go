@@ -0,0 +1,328 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVDiffCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVDiffCommand)(nil)
+)
+
+const (
+	kvDiffRedactNone = "none"
+	kvDiffRedactHash = "hash"
+	kvDiffRedactAll  = "all"
+)
+
+type KVDiffCommand struct {
+	*BaseCommand
+
+	flagUnified bool
+	flagRedact  string
+}
+
+func (c *KVDiffCommand) Synopsis() string {
+	return "Compares secrets across versions or paths"
+}
+
+func (c *KVDiffCommand) Help() string {
+	helpText := `
+Usage: vault kv diff [options] FROM TO
+
+  Compares the data of two secrets key by key. Each secret is given in the
+  path-like syntax, optionally followed by "@" and a version for KV v2 secrets.
+  The latest version is used when no version is given.
+
+  Compare two versions of a secret:
+
+      $ vault kv diff secret/app@3 secret/app@5
+
+  Compare secrets at different paths, possibly in different mounts:
+
+      $ vault kv diff secret/app archive/app
+
+  To print the differences as a unified diff, specify the "-unified" flag. To
+  keep the values out of the output, specify the "-redact" flag.
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVDiffCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "unified",
+		Target:  &c.flagUnified,
+		Default: false,
+		Usage: `Print the differences as a unified diff of the key=value pairs,
+		including the unchanged keys as context.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "redact",
+		Target:     &c.flagRedact,
+		Default:    kvDiffRedactNone,
+		Completion: complete.PredictSet(kvDiffRedactNone, kvDiffRedactHash, kvDiffRedactAll),
+		Usage: `How values are printed. "none" prints them as they are, "hash"
+		prints a short SHA-256 hash of them which still allows telling whether
+		they changed, and "all" hides them entirely.`,
+	})
+
+	return set
+}
+
+func (c *KVDiffCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFiles()
+}
+
+func (c *KVDiffCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVDiffCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	switch c.flagRedact {
+	case kvDiffRedactNone, kvDiffRedactHash, kvDiffRedactAll:
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid value %q for -redact, must be one of %q, %q or %q",
+			c.flagRedact, kvDiffRedactNone, kvDiffRedactHash, kvDiffRedactAll))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	sides := make([]map[string]interface{}, 2)
+	for i, arg := range args {
+		p, version, err := parseKVVersionedPath(arg)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		l, err := newKVLocation(client, p)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		if version > 0 && !l.v2 {
+			c.UI.Error(fmt.Sprintf("Versions can only be given for KV v2 secrets: %s", arg))
+			return 1
+		}
+
+		data, err := kvReadData(client, l, version)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading %s: %s", arg, err))
+			return 2
+		}
+		if data == nil {
+			c.UI.Error(fmt.Sprintf("No value found at %s", arg))
+			return 2
+		}
+		sides[i] = data
+	}
+
+	changes, unchanged := diffKVData(sides[0], sides[1])
+
+	if c.flagUnified {
+		c.UI.Output(c.unifiedDiff(args[0], args[1], sides[0], changes, unchanged))
+		return 0
+	}
+
+	if c.flagFormat != "table" {
+		out := make([]map[string]interface{}, 0, len(changes))
+		for _, change := range changes {
+			entry := map[string]interface{}{
+				"key":    change.Key,
+				"change": change.Change,
+			}
+			if change.Change != kvDiffAdded {
+				entry["old"] = c.redact(change.Old)
+			}
+			if change.Change != kvDiffRemoved {
+				entry["new"] = c.redact(change.New)
+			}
+			out = append(out, entry)
+		}
+		return OutputData(c.UI, map[string]interface{}{
+			"from":    args[0],
+			"to":      args[1],
+			"changes": out,
+		})
+	}
+
+	if len(changes) == 0 {
+		c.UI.Output("No differences")
+		return 0
+	}
+
+	rows := []string{"Key | Change | Old | New"}
+	for _, change := range changes {
+		oldValue, newValue := "n/a", "n/a"
+		if change.Change != kvDiffAdded {
+			oldValue = c.formatValue(change.Old)
+		}
+		if change.Change != kvDiffRemoved {
+			newValue = c.formatValue(change.New)
+		}
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s", change.Key, change.Change, oldValue, newValue))
+	}
+	c.UI.Output(tableOutput(rows, nil))
+	return 0
+}
+
+func (c *KVDiffCommand) unifiedDiff(from, to string, oldData map[string]interface{}, changes []kvDiffChange, unchanged []string) string {
+	lines := []string{"--- " + from, "+++ " + to}
+
+	keys := append([]string{}, unchanged...)
+	byKey := make(map[string]kvDiffChange, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+		byKey[change.Key] = change
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		change, ok := byKey[key]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("  %s=%s", key, c.formatValue(oldData[key])))
+			continue
+		}
+		if change.Change != kvDiffAdded {
+			lines = append(lines, fmt.Sprintf("- %s=%s", key, c.formatValue(change.Old)))
+		}
+		if change.Change != kvDiffRemoved {
+			lines = append(lines, fmt.Sprintf("+ %s=%s", key, c.formatValue(change.New)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redact returns the value as it should be output.
+func (c *KVDiffCommand) redact(value interface{}) interface{} {
+	switch c.flagRedact {
+	case kvDiffRedactAll:
+		return "<redacted>"
+	case kvDiffRedactHash:
+		encoded, _ := json.Marshal(value)
+		sum := sha256.Sum256(encoded)
+		return "sha256:" + hex.EncodeToString(sum[:])[:12]
+	default:
+		return value
+	}
+}
+
+// formatValue returns the value as it should be printed in text output,
+// encoding maps and lists as JSON.
+func (c *KVDiffCommand) formatValue(value interface{}) string {
+	value = c.redact(value)
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(value)
+		if err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+const (
+	kvDiffAdded   = "added"
+	kvDiffRemoved = "removed"
+	kvDiffChanged = "changed"
+)
+
+// kvDiffChange is a key whose value differs between two secrets.
+type kvDiffChange struct {
+	Key    string
+	Change string
+	Old    interface{}
+	New    interface{}
+}
+
+// diffKVData compares the data of two secrets, returning the changed keys
+// and the unchanged ones, both sorted.
+func diffKVData(oldData, newData map[string]interface{}) ([]kvDiffChange, []string) {
+	keys := make([]string, 0, len(oldData)+len(newData))
+	for key := range oldData {
+		keys = append(keys, key)
+	}
+	for key := range newData {
+		if _, ok := oldData[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []kvDiffChange
+	var unchanged []string
+	for _, key := range keys {
+		oldValue, inOld := oldData[key]
+		newValue, inNew := newData[key]
+		switch {
+		case !inOld:
+			changes = append(changes, kvDiffChange{Key: key, Change: kvDiffAdded, New: newValue})
+		case !inNew:
+			changes = append(changes, kvDiffChange{Key: key, Change: kvDiffRemoved, Old: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, kvDiffChange{Key: key, Change: kvDiffChanged, Old: oldValue, New: newValue})
+		default:
+			unchanged = append(unchanged, key)
+		}
+	}
+	return changes, unchanged
+}
+
+// parseKVVersionedPath splits a "path@version" argument into the path and
+// the version, which is zero if none is given.
+func parseKVVersionedPath(arg string) (string, int, error) {
+	idx := strings.LastIndex(arg, "@")
+	if idx == -1 {
+		return arg, 0, nil
+	}
+
+	version, err := strconv.Atoi(arg[idx+1:])
+	if err != nil || version <= 0 {
+		return "", 0, fmt.Errorf("invalid version in %q: must be a positive integer", arg)
+	}
+	return arg[:idx], version, nil
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -56,7 +57,7 @@ Usage: vault kv get [options] KEY
 }
 
 func (c *KVGetCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat | FlagSetOutputSelect)
 
 	// Common Options
 	f := set.NewFlagSet("Common Options")
@@ -108,6 +109,11 @@ func (c *KVGetCommand) Run(args []string) int {
 		return 1
 	}
 
+	if err := c.validateOutputSelection(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -205,6 +211,14 @@ func (c *KVGetCommand) Run(args []string) int {
 		}
 	}
 
+	if c.flagJSONPath != "" {
+		return PrintJSONPath(c.UI, secret, c.flagJSONPath)
+	}
+
+	if columns := parseColumns(c.flagColumns); len(columns) > 0 {
+		return OutputColumns(c.UI, []map[string]interface{}{kvColumnRow(secret, v2)}, columns)
+	}
+
 	// If we have wrap info print the secret normally.
 	if secret.WrapInfo != nil || c.flagFormat != "table" {
 		return OutputSecret(c.UI, secret)
@@ -241,3 +255,23 @@ func (c *KVGetCommand) Run(args []string) int {
 
 	return 0
 }
+
+// kvColumnRow returns the fields of the secret available to -columns. For KV
+// v2 secrets, these are the fields of the secret data, along with the
+// "metadata" of the version unless the data has a field of that name.
+func kvColumnRow(secret *api.Secret, v2 bool) map[string]interface{} {
+	if !v2 {
+		return secret.Data
+	}
+
+	row := make(map[string]interface{})
+	if data, ok := secret.Data["data"].(map[string]interface{}); ok {
+		for k, v := range data {
+			row[k] = v
+		}
+	}
+	if _, ok := row["metadata"]; !ok {
+		row["metadata"] = secret.Data["metadata"]
+	}
+	return row
+}
@@ -45,7 +45,7 @@ Usage: vault kv list [options] PATH
 }
 
 func (c *KVListCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat | FlagSetOutputSelect)
 
 	// Common Options
 	f := set.NewFlagSet("Common Options")
@@ -93,6 +93,11 @@ func (c *KVListCommand) Run(args []string) int {
 		return 1
 	}
 
+	if err := c.validateOutputSelection(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -175,5 +180,13 @@ func (c *KVListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagJSONPath != "" {
+		return PrintJSONPath(c.UI, secret, c.flagJSONPath)
+	}
+
+	if columns := parseColumns(c.flagColumns); len(columns) > 0 {
+		return OutputColumns(c.UI, listColumnRows(secret), columns)
+	}
+
 	return OutputList(c.UI, secret)
 }
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVMoveCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVMoveCommand)(nil)
+)
+
+type KVMoveCommand struct {
+	*BaseCommand
+
+	flagRecursive bool
+	flagVersions  bool
+	flagDryRun    bool
+}
+
+func (c *KVMoveCommand) Synopsis() string {
+	return "Moves secrets to another path or mount"
+}
+
+func (c *KVMoveCommand) Help() string {
+	helpText := `
+Usage: vault kv mv [options] SOURCE DESTINATION
+
+  Moves the secret at SOURCE to DESTINATION. The secret is copied like with
+  "vault kv cp", then deleted from SOURCE along with all its versions and
+  metadata. Both paths are given in the path-like syntax, and may be in
+  different KV mounts of any version.
+
+      $ vault kv mv secret/foo secret/bar
+
+  To move every secret under a path, specify the "-recursive" flag:
+
+      $ vault kv mv -r secret/my-app/ secret/apps/my-app/
+
+  Unless the "-versions" flag is specified, only the latest version of KV v2
+  secrets is kept. To print the secrets which would be moved without moving
+  them, specify the "-dry-run" flag.
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVMoveCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	kvCopyFlags(set, &c.flagRecursive, &c.flagVersions, &c.flagDryRun)
+	return set
+}
+
+func (c *KVMoveCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFiles()
+}
+
+func (c *KVMoveCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVMoveCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	copier := &kvCopier{
+		client:    client,
+		ui:        c.UI,
+		recursive: c.flagRecursive,
+		versions:  c.flagVersions,
+		dryRun:    c.flagDryRun,
+		move:      true,
+	}
+	return copier.run(args[0], args[1])
+}
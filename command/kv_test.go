@@ -1544,3 +1544,271 @@ func createTokenForPolicy(t *testing.T, client *api.Client, policy string) (*api
 
 	return secret.Auth, err
 }
+
+func testKVCopyCommand(tb testing.TB) (*cli.MockUi, *KVCopyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVCopyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVMoveCommand(tb testing.TB) (*cli.MockUi, *KVMoveCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVMoveCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// TestKVCopyCommand runs tests for `vault kv cp` and `vault kv mv`
+func TestKVCopyCommand(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	for _, mount := range []string{"kv/", "archive/"} {
+		if err := client.Sys().Mount(mount, &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Sys().Mount("kv1/", &api.MountInput{
+		Type: "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		path := fmt.Sprintf("my-app/secret-%d", i)
+		for version := 1; version <= 2; version++ {
+			if _, err := client.KVv2("kv/").Put(ctx, path, map[string]interface{}{
+				"version": version,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := client.KVv2("kv/").PutMetadata(ctx, path, api.KVMetadataPutInput{
+			CustomMetadata: map[string]interface{}{"owner": "team-a"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.KVv2("kv/").Put(ctx, "my-app/nested/secret", map[string]interface{}{
+		"foo": "bar",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cmd interface{ Run([]string) int }, ui *cli.MockUi, args ...string) (int, string) {
+		code := cmd.Run(args)
+		return code, ui.OutputWriter.String() + ui.ErrorWriter.String()
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		ui, cmd := testKVCopyCommand(t)
+		cmd.client = client
+		if code, combined := run(cmd, ui, "kv/foo"); code != 1 || !strings.Contains(combined, "Not enough arguments") {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+
+		ui, cmd = testKVCopyCommand(t)
+		cmd.client = client
+		if code, combined := run(cmd, ui, "-r", "kv/my-app", "kv/my-app/copy"); code != 1 || !strings.Contains(combined, "under the source path") {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+
+		ui, cmd = testKVCopyCommand(t)
+		cmd.client = client
+		if code, combined := run(cmd, ui, "-versions", "kv/my-app/secret-0", "kv1/secret-0"); code != 1 || !strings.Contains(combined, "KV v2") {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		ui, cmd := testKVCopyCommand(t)
+		cmd.client = client
+		code, combined := run(cmd, ui, "-r", "-dry-run", "kv/my-app", "archive/my-app")
+		if code != 0 {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+		if !strings.Contains(combined, "Would copy kv/my-app/nested/secret to archive/my-app/nested/secret") {
+			t.Fatalf("unexpected output: %s", combined)
+		}
+		if secret, err := client.KVv2("archive/").Get(ctx, "my-app/secret-0"); err == nil || secret != nil {
+			t.Fatalf("expected nothing to be copied: %v", secret)
+		}
+	})
+
+	t.Run("copy_recursive_versions", func(t *testing.T) {
+		ui, cmd := testKVCopyCommand(t)
+		cmd.client = client
+		code, combined := run(cmd, ui, "-r", "-versions", "kv/my-app", "archive/my-app")
+		if code != 0 {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+
+		for i := 0; i < 2; i++ {
+			path := fmt.Sprintf("my-app/secret-%d", i)
+			metadata, err := client.KVv2("archive/").GetMetadata(ctx, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.CurrentVersion != 2 || metadata.CustomMetadata["owner"] != "team-a" {
+				t.Fatalf("unexpected metadata: %#v", metadata)
+			}
+		}
+		if _, err := client.KVv2("archive/").Get(ctx, "my-app/nested/secret"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("move_across_versions", func(t *testing.T) {
+		ui, cmd := testKVMoveCommand(t)
+		cmd.client = client
+		code, combined := run(cmd, ui, "kv/my-app/secret-0", "kv1/secret-0")
+		if code != 0 {
+			t.Fatalf("unexpected result %d: %s", code, combined)
+		}
+
+		secret, err := client.KVv1("kv1/").Get(ctx, "secret-0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(secret.Data["version"]) != "2" {
+			t.Fatalf("unexpected data: %v", secret.Data)
+		}
+		if _, err := client.KVv2("kv/").GetMetadata(ctx, "my-app/secret-0"); err == nil {
+			t.Fatal("expected the source to be deleted")
+		}
+	})
+}
+
+func testKVDiffCommand(tb testing.TB) (*cli.MockUi, *KVDiffCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVDiffCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// TestKVDiffCommand runs tests for `vault kv diff`
+func TestKVDiffCommand(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sys().Mount("kv1/", &api.MountInput{
+		Type: "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	ctx := context.Background()
+	for _, data := range []map[string]interface{}{
+		{"user": "admin", "password": "one", "old": "gone"},
+		{"user": "admin", "password": "two", "new": "here"},
+	} {
+		if _, err := client.KVv2("kv/").Put(ctx, "app", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.KVv1("kv1/").Put(ctx, "app", map[string]interface{}{
+		"user": "admin", "password": "two", "new": "here",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		args       []string
+		env        string
+		outStrings []string
+		code       int
+	}{
+		{
+			name:       "not_enough_args",
+			args:       []string{"kv/app"},
+			outStrings: []string{"Not enough arguments"},
+			code:       1,
+		},
+		{
+			name:       "invalid_version",
+			args:       []string{"kv/app@first", "kv/app"},
+			outStrings: []string{"invalid version"},
+			code:       1,
+		},
+		{
+			name:       "v1_version",
+			args:       []string{"kv1/app@1", "kv/app"},
+			outStrings: []string{"Versions can only be given for KV v2 secrets"},
+			code:       1,
+		},
+		{
+			name:       "versions",
+			args:       []string{"kv/app@1", "kv/app@2"},
+			outStrings: []string{"new         added", "old         removed", "password    changed    one"},
+			code:       0,
+		},
+		{
+			name:       "paths",
+			args:       []string{"kv/app", "kv1/app"},
+			outStrings: []string{"No differences"},
+			code:       0,
+		},
+		{
+			name: "unified_redacted",
+			args: []string{"-unified", "-redact=all", "kv/app@1", "kv/app@2"},
+			outStrings: []string{
+				"--- kv/app@1\n+++ kv/app@2\n+ new=<redacted>\n- old=<redacted>\n- password=<redacted>\n+ password=<redacted>\n  user=<redacted>",
+			},
+			code: 0,
+		},
+		{
+			name:       "json_hashed",
+			args:       []string{"-redact=hash", "kv/app@1", "kv/app@2"},
+			env:        "json",
+			outStrings: []string{`"change": "changed"`, `"old": "sha256:`},
+			code:       0,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv(EnvVaultFormat, tc.env)
+			}
+			ui, cmd := testKVDiffCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(tc.args)
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, combined)
+			}
+			for _, str := range tc.outStrings {
+				if !strings.Contains(combined, str) {
+					t.Errorf("expected %q to contain %q", combined, str)
+				}
+			}
+		})
+	}
+}
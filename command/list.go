@@ -45,7 +45,7 @@ Usage: vault list [options] PATH
 }
 
 func (c *ListCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat | FlagSetOutputDetailed)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat | FlagSetOutputDetailed | FlagSetOutputSelect)
 	return set
 }
 
@@ -75,6 +75,11 @@ func (c *ListCommand) Run(args []string) int {
 		return 1
 	}
 
+	if err := c.validateOutputSelection(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -116,5 +121,13 @@ func (c *ListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagJSONPath != "" {
+		return PrintJSONPath(c.UI, secret, c.flagJSONPath)
+	}
+
+	if columns := parseColumns(c.flagColumns); len(columns) > 0 {
+		return OutputColumns(c.UI, listColumnRows(secret), columns)
+	}
+
 	return OutputList(c.UI, secret)
 }
@@ -60,6 +60,30 @@ func TestListCommand_Run(t *testing.T) {
 			"bar\nbaz\nfoo",
 			0,
 		},
+		{
+			"jsonpath",
+			[]string{"-jsonpath", "$.data.keys[*]", "secret/list"},
+			"bar\nbaz\nfoo",
+			0,
+		},
+		{
+			"jsonpath_index",
+			[]string{"-jsonpath", "{.data.keys[-1]}", "secret/list"},
+			"foo",
+			0,
+		},
+		{
+			"columns",
+			[]string{"-columns", "key,version", "secret/list"},
+			"key    version\n---    -------\nbar    n/a\nbaz    n/a\nfoo    n/a",
+			0,
+		},
+		{
+			"invalid_jsonpath",
+			[]string{"-jsonpath", "$.data.keys[first]", "secret/list"},
+			"Invalid JSONPath",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -23,6 +24,8 @@ type MonitorCommand struct {
 
 	logLevel  string
 	logFormat string
+	modules   []string
+	rateLimit int
 
 	// ShutdownCh is used to capture interrupt signal and end streaming
 	ShutdownCh chan struct{}
@@ -41,6 +44,11 @@ Usage: vault monitor [options]
 	the server may be logging at the INFO level, but with the monitor command
 	you can set -log-level=DEBUG.
 
+	Stream the debug logs of the expiration manager and Raft storage, at most
+	100 log messages per second:
+
+	    $ vault monitor -log-level=debug -modules=expiration,raft -rate-limit=100
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -66,6 +74,19 @@ func (c *MonitorCommand) Flags() *FlagSets {
 		Completion: complete.PredictSet("standard", "json"),
 		Usage:      "Output format of logs. Supported values are \"standard\" and \"json\".",
 	})
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "modules",
+		Target: &c.modules,
+		Usage: "If passed, the subsystems to monitor logs of, such as \"expiration\"," +
+			" \"raft\" or \"plugin\". This can be specified multiple times.",
+	})
+	f.IntVar(&IntVar{
+		Name:    "rate-limit",
+		Target:  &c.rateLimit,
+		Default: 0,
+		Usage: "If passed, the maximum number of log messages streamed per second." +
+			" Log messages over the limit are dropped.",
+	})
 
 	return set
 }
@@ -99,6 +120,11 @@ func (c *MonitorCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.rateLimit < 0 {
+		c.UI.Error("Rate limit must not be negative")
+		return 1
+	}
+
 	c.logFormat = strings.ToLower(c.logFormat)
 	validFormats := []string{"standard", "json"}
 	if !strutil.StrListContains(validFormats, c.logFormat) {
@@ -118,7 +144,12 @@ func (c *MonitorCommand) Run(args []string) int {
 	var logCh chan string
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	logCh, err = client.Sys().Monitor(ctx, c.logLevel, c.logFormat)
+	logCh, err = client.Sys().MonitorWithInput(ctx, &api.MonitorInput{
+		LogLevel:  c.logLevel,
+		LogFormat: c.logFormat,
+		Modules:   c.modules,
+		RateLimit: c.rateLimit,
+	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error starting monitor: %s", err))
 		return 1
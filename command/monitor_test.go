@@ -57,6 +57,24 @@ func TestMonitorCommand_Run(t *testing.T) {
 			"haha is an unknown log level",
 			1,
 		},
+		{
+			"valid_modules",
+			[]string{
+				"-log-level=debug",
+				"-modules=expiration,raft",
+				"-rate-limit=100",
+			},
+			"",
+			0,
+		},
+		{
+			"negative_rate_limit",
+			[]string{
+				"-rate-limit=-1",
+			},
+			"Rate limit must not be negative",
+			1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -31,6 +31,10 @@ Usage: vault namespace <subcommand> [options] [args]
 
       $ vault namespace list
 
+  Print the hierarchy of namespaces below the current namespace:
+
+      $ vault namespace tree
+
   Look up an existing namespace:
 
       $ vault namespace lookup
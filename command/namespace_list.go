@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,6 +19,8 @@ var (
 
 type NamespaceListCommand struct {
 	*BaseCommand
+
+	flagRecursive bool
 }
 
 func (c *NamespaceListCommand) Synopsis() string {
@@ -34,6 +37,10 @@ Usage: vault namespace list [options]
 
       $ vault namespace list
 
+  List all descendant namespaces, relative to the current namespace:
+
+      $ vault namespace list -recursive
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -51,6 +58,14 @@ func (c *NamespaceListCommand) Flags() *FlagSets {
 		Usage:   "Print detailed information such as namespace ID.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: "List all descendant namespaces instead of the child namespaces " +
+			"only. The paths are relative to the current namespace.",
+	})
+
 	return set
 }
 
@@ -76,12 +91,21 @@ func (c *NamespaceListCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagRecursive && c.flagDetailed {
+		c.UI.Error("The -detailed flag cannot be used with -recursive")
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagRecursive {
+		return c.listRecursive(client)
+	}
+
 	secret, err := client.Logical().List("sys/namespaces")
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error listing namespaces: %s", err))
@@ -121,3 +145,28 @@ func (c *NamespaceListCommand) Run(args []string) int {
 
 	return OutputList(c.UI, secret)
 }
+
+// listRecursive lists the descendants of the current namespace.
+func (c *NamespaceListCommand) listRecursive(client *api.Client) int {
+	tree := walkNamespaces(client, namespaceTreeRoot(client), 0, &namespaceTreeOptions{})
+	if errs := tree.errors(); len(errs) > 0 {
+		c.UI.Error(fmt.Sprintf("Error listing namespaces: %s", strings.Join(errs, "; ")))
+		return 2
+	}
+
+	descendants := tree.descendants()
+	if len(descendants) == 0 {
+		if Format(c.UI) != "table" {
+			OutputData(c.UI, []interface{}{})
+			return 2
+		}
+		c.UI.Error("No namespaces found")
+		return 2
+	}
+
+	keys := make([]interface{}, 0, len(descendants))
+	for _, path := range descendants {
+		keys = append(keys, path)
+	}
+	return OutputList(c.UI, keys)
+}
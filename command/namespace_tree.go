@@ -0,0 +1,357 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*NamespaceTreeCommand)(nil)
+	_ cli.CommandAutocomplete = (*NamespaceTreeCommand)(nil)
+)
+
+type NamespaceTreeCommand struct {
+	*BaseCommand
+
+	flagDepth    int
+	flagMounts   bool
+	flagPolicies bool
+}
+
+// namespaceTreeNode is a namespace of a namespace hierarchy, along with the
+// details requested about it.
+type namespaceTreeNode struct {
+	Path     string               `json:"path"`
+	Mounts   map[string]string    `json:"mounts,omitempty"`
+	Auth     map[string]string    `json:"auth,omitempty"`
+	Policies []string             `json:"policies,omitempty"`
+	Errors   []string             `json:"errors,omitempty"`
+	Children []*namespaceTreeNode `json:"children,omitempty"`
+}
+
+// namespaceTreeOptions are the details fetched by walkNamespaces for each
+// namespace of the hierarchy.
+type namespaceTreeOptions struct {
+	// maxDepth is the number of levels of descendants walked, or zero to walk
+	// them all.
+	maxDepth int
+	mounts   bool
+	policies bool
+}
+
+func (c *NamespaceTreeCommand) Synopsis() string {
+	return "Print a namespace hierarchy"
+}
+
+func (c *NamespaceTreeCommand) Help() string {
+	helpText := `
+Usage: vault namespace tree [options] [PATH]
+
+  Prints the hierarchy of namespaces below PATH, relative to the current
+  namespace, or below the current namespace if no PATH is given. Optionally,
+  the secrets engines, auth methods and ACL policies of every namespace of the
+  hierarchy are included, so that a whole subtree can be reviewed in one
+  command. Namespaces which cannot be listed or read are reported and the
+  command exits with a non-zero status.
+
+  Print the hierarchy below the current namespace:
+
+      $ vault namespace tree
+
+  Print two levels of the hierarchy below "engineering/":
+
+      $ vault namespace tree -depth=2 engineering/
+
+  Print the mounts and policies of every namespace below "engineering/" as
+  JSON:
+
+      $ vault namespace tree -mounts -policies -format=json engineering/
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceTreeCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.IntVar(&IntVar{
+		Name:    "depth",
+		Target:  &c.flagDepth,
+		Default: 0,
+		Usage: "Number of levels of descendant namespaces to print. The whole " +
+			"hierarchy is printed by default.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "mounts",
+		Target:  &c.flagMounts,
+		Default: false,
+		Usage:   "Include the secrets engines and auth methods of every namespace.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "policies",
+		Target:  &c.flagPolicies,
+		Default: false,
+		Usage:   "Include the ACL policies of every namespace.",
+	})
+
+	return set
+}
+
+func (c *NamespaceTreeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *NamespaceTreeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *NamespaceTreeCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 1 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0-1, got %d)", len(args)))
+		return 1
+	}
+
+	if c.flagDepth < 0 {
+		c.UI.Error("Depth must not be negative")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	root := namespaceTreeRoot(client)
+	if len(args) == 1 && sanitizePath(args[0]) != "" {
+		root += ensureTrailingSlash(sanitizePath(args[0]))
+	}
+
+	tree := walkNamespaces(client, root, 0, &namespaceTreeOptions{
+		maxDepth: c.flagDepth,
+		mounts:   c.flagMounts,
+		policies: c.flagPolicies,
+	})
+
+	code := 0
+	if len(tree.errors()) > 0 {
+		code = 2
+	}
+
+	if Format(c.UI) != "table" {
+		if ret := OutputData(c.UI, tree); ret != 0 {
+			return ret
+		}
+		return code
+	}
+
+	c.UI.Output(strings.TrimSuffix(tree.render(), "\n"))
+	return code
+}
+
+// namespaceTreeRoot returns the full path of the current namespace of the
+// client, with a trailing slash unless it is the root namespace.
+func namespaceTreeRoot(client *api.Client) string {
+	root := sanitizePath(client.Namespace())
+	if root == "" {
+		return ""
+	}
+	return ensureTrailingSlash(root)
+}
+
+// walkNamespaces returns the hierarchy of namespaces below the namespace with
+// the given full path, found at the given depth of the walk. Errors are
+// recorded in the namespace they concern, so that the rest of the hierarchy
+// is still walked.
+func walkNamespaces(client *api.Client, path string, depth int, opts *namespaceTreeOptions) *namespaceTreeNode {
+	node := &namespaceTreeNode{Path: path}
+	nsClient := client.WithNamespace(path)
+
+	if opts.mounts {
+		mounts, err := nsClient.Sys().ListMounts()
+		if err != nil {
+			node.Errors = append(node.Errors, fmt.Sprintf("error listing secrets engines: %s", err))
+		} else {
+			node.Mounts = make(map[string]string, len(mounts))
+			for mountPath, mount := range mounts {
+				node.Mounts[mountPath] = mount.Type
+			}
+		}
+
+		auths, err := nsClient.Sys().ListAuth()
+		if err != nil {
+			node.Errors = append(node.Errors, fmt.Sprintf("error listing auth methods: %s", err))
+		} else {
+			node.Auth = make(map[string]string, len(auths))
+			for authPath, auth := range auths {
+				node.Auth[authPath] = auth.Type
+			}
+		}
+	}
+
+	if opts.policies {
+		policies, err := nsClient.Sys().ListPolicies()
+		if err != nil {
+			node.Errors = append(node.Errors, fmt.Sprintf("error listing policies: %s", err))
+		} else {
+			sort.Strings(policies)
+			node.Policies = policies
+		}
+	}
+
+	if opts.maxDepth > 0 && depth >= opts.maxDepth {
+		return node
+	}
+
+	children, err := listChildNamespaces(nsClient)
+	if err != nil {
+		node.Errors = append(node.Errors, fmt.Sprintf("error listing namespaces: %s", err))
+		return node
+	}
+
+	for _, child := range children {
+		node.Children = append(node.Children, walkNamespaces(client, path+child, depth+1, opts))
+	}
+
+	return node
+}
+
+// listChildNamespaces returns the sorted names of the child namespaces of the
+// namespace of the client, with a trailing slash.
+func listChildNamespaces(client *api.Client) ([]string, error) {
+	secret, err := client.Logical().List("sys/namespaces")
+	if err != nil {
+		return nil, err
+	}
+
+	keys, _ := extractListData(secret)
+	children := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if name, ok := key.(string); ok && name != "" {
+			children = append(children, ensureTrailingSlash(name))
+		}
+	}
+	sort.Strings(children)
+
+	return children, nil
+}
+
+// errors returns the errors recorded in the tree, prefixed by the path of the
+// namespace they concern.
+func (n *namespaceTreeNode) errors() []string {
+	var errs []string
+	for _, err := range n.Errors {
+		name := n.Path
+		if name == "" {
+			name = "root"
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+	}
+	for _, child := range n.Children {
+		errs = append(errs, child.errors()...)
+	}
+	return errs
+}
+
+// descendants returns the paths of the descendants of the namespace,
+// relative to it, in depth-first order.
+func (n *namespaceTreeNode) descendants() []string {
+	var paths []string
+	for _, child := range n.Children {
+		paths = append(paths, strings.TrimPrefix(child.Path, n.Path))
+		for _, path := range child.descendants() {
+			paths = append(paths, strings.TrimPrefix(child.Path, n.Path)+path)
+		}
+	}
+	return paths
+}
+
+// render draws the tree, one line per namespace and detail.
+func (n *namespaceTreeNode) render() string {
+	var b strings.Builder
+
+	name := n.Path
+	if name == "" {
+		name = "root"
+	}
+	b.WriteString(name + "\n")
+	n.renderChildren(&b, "")
+
+	return b.String()
+}
+
+func (n *namespaceTreeNode) renderChildren(b *strings.Builder, prefix string) {
+	var lines []string
+	if n.Mounts != nil {
+		lines = append(lines, "secrets engines: "+formatNamespaceMounts(n.Mounts))
+	}
+	if n.Auth != nil {
+		lines = append(lines, "auth methods: "+formatNamespaceMounts(n.Auth))
+	}
+	if n.Policies != nil {
+		lines = append(lines, "policies: "+strings.Join(n.Policies, ", "))
+	}
+	for _, err := range n.Errors {
+		lines = append(lines, strings.Join(strings.Fields(err), " "))
+	}
+
+	count := len(lines) + len(n.Children)
+	branch := func(i int) (string, string) {
+		if i == count-1 {
+			return prefix + "└── ", prefix + "    "
+		}
+		return prefix + "├── ", prefix + "│   "
+	}
+
+	for i, line := range lines {
+		first, _ := branch(i)
+		b.WriteString(first + line + "\n")
+	}
+
+	for i, child := range n.Children {
+		first, rest := branch(len(lines) + i)
+		b.WriteString(first + strings.TrimPrefix(child.Path, n.Path) + "\n")
+		child.renderChildren(b, rest)
+	}
+}
+
+// formatNamespaceMounts formats mounts as their sorted paths followed by their
+// types.
+func formatNamespaceMounts(mounts map[string]string) string {
+	if len(mounts) == 0 {
+		return "none"
+	}
+
+	paths := make([]string, 0, len(mounts))
+	for path := range mounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	formatted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		formatted = append(formatted, fmt.Sprintf("%s (%s)", path, mounts[path]))
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testNamespaceTreeCommand(tb testing.TB) (*cli.MockUi, *NamespaceTreeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &NamespaceTreeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestNamespaceTreeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"negative_depth",
+			[]string{"-depth", "-1"},
+			"Depth must not be negative",
+			1,
+		},
+		{
+			"mounts_and_policies",
+			[]string{"-mounts", "-policies"},
+			"root\n├── secrets engines: cubbyhole/ (cubbyhole), identity/ (identity), secret/ (kv), sys/ (system)\n" +
+				"├── auth methods: token/ (token)\n└── policies: default, root",
+			0,
+		},
+	}
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui, cmd := testNamespaceTreeCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, ui.ErrorWriter.String())
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testNamespaceTreeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestNamespaceTree_Render(t *testing.T) {
+	t.Parallel()
+
+	tree := &namespaceTreeNode{
+		Path:     "eng/",
+		Policies: []string{"default"},
+		Children: []*namespaceTreeNode{
+			{
+				Path:   "eng/team-a/",
+				Mounts: map[string]string{"kv/": "kv", "pki/": "pki"},
+				Children: []*namespaceTreeNode{
+					{Path: "eng/team-a/app/"},
+				},
+			},
+			{
+				Path:   "eng/team-b/",
+				Errors: []string{"error listing namespaces: permission denied"},
+			},
+		},
+	}
+
+	expected := `eng/
+├── policies: default
+├── team-a/
+│   ├── secrets engines: kv/ (kv), pki/ (pki)
+│   └── app/
+└── team-b/
+    └── error listing namespaces: permission denied
+`
+	if rendered := tree.render(); rendered != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+
+	if descendants, expected := tree.descendants(), []string{"team-a/", "team-a/app/", "team-b/"}; !reflect.DeepEqual(descendants, expected) {
+		t.Errorf("expected descendants %q, got %q", expected, descendants)
+	}
+
+	if errs, expected := tree.errors(), []string{"eng/team-b/: error listing namespaces: permission denied"}; !reflect.DeepEqual(errs, expected) {
+		t.Errorf("expected errors %q, got %q", expected, errs)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	log "github.com/hashicorp/go-hclog"
@@ -20,9 +21,9 @@ import (
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/physical"
-	"github.com/hashicorp/vault/vault"
 	"github.com/mitchellh/cli"
 	"github.com/pkg/errors"
 	"github.com/posener/complete"
@@ -36,17 +37,65 @@ var (
 
 var errAbort = errors.New("Migration aborted")
 
+// migrationCheckpointPath is where the checkpoint of a migration is stored in
+// the destination storage.
+const migrationCheckpointPath = "core/migration-checkpoint"
+
+// migrationCheckpointInterval is how often the progress of a migration is
+// reported and checkpointed.
+var migrationCheckpointInterval = 10 * time.Second
+
+// migrationCheckpoint is stored in the destination storage while an offline
+// migration is running, so an interrupted migration resumes after the
+// HighWaterMark instead of copying all keys again. Copied counts the keys
+// copied across all runs.
+type migrationCheckpoint struct {
+	Start         time.Time `json:"start"`
+	HighWaterMark string    `json:"high_water_mark"`
+	Copied        int64     `json:"copied"`
+}
+
+func getMigrationCheckpoint(ctx context.Context, b physical.Backend) (*migrationCheckpoint, error) {
+	entry, err := b.Get(ctx, migrationCheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var checkpoint migrationCheckpoint
+	if err := jsonutil.DecodeJSON(entry.Value, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func putMigrationCheckpoint(ctx context.Context, b physical.Backend, checkpoint *migrationCheckpoint) error {
+	enc, err := jsonutil.EncodeJSON(checkpoint)
+	if err != nil {
+		return err
+	}
+	return b.Put(ctx, &physical.Entry{
+		Key:   migrationCheckpointPath,
+		Value: enc,
+	})
+}
+
 type OperatorMigrateCommand struct {
 	*BaseCommand
 
-	PhysicalBackends map[string]physical.Factory
-	flagConfig       string
-	flagLogLevel     string
-	flagStart        string
-	flagReset        bool
-	flagMaxParallel  int
-	logger           log.Logger
-	ShutdownCh       chan struct{}
+	PhysicalBackends     map[string]physical.Factory
+	flagConfig           string
+	flagLogLevel         string
+	flagStart            string
+	flagReset            bool
+	flagResume           bool
+	flagMaxParallel      int
+	flagOnline           bool
+	flagCutoverThreshold int
+	logger               log.Logger
+	ShutdownCh           chan struct{}
 }
 
 type migratorConfig struct {
@@ -71,6 +120,17 @@ Usage: vault operator migrate [options]
 
       $ vault operator migrate -config=migrate.hcl
 
+  Migrate while Vault servers keep running on the source storage, only
+  rejecting writes during a brief cutover at the end:
+
+      $ vault operator migrate -config=migrate.hcl -online
+
+  Progress is checkpointed in the destination storage, so running the same
+  migration again after it was interrupted resumes where it stopped. Start
+  over by copying all keys instead:
+
+      $ vault operator migrate -config=migrate.hcl -resume=false
+
   For more information, please see the documentation.
 
 ` + c.Flags().Help()
@@ -104,6 +164,14 @@ func (c *OperatorMigrateCommand) Flags() *FlagSets {
 		Usage:  "Reset the migration lock. No migration will occur.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "resume",
+		Target:  &c.flagResume,
+		Default: true,
+		Usage: "Resume an interrupted migration from the checkpoint in the destination " +
+			"storage. If false, the checkpoint is discarded and all keys are copied again.",
+	})
+
 	f.IntVar(&IntVar{
 		Name:    "max-parallel",
 		Default: 10,
@@ -112,6 +180,22 @@ func (c *OperatorMigrateCommand) Flags() *FlagSets {
 			"This can speed up the migration process on slow backends but uses more resources.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:   "online",
+		Target: &c.flagOnline,
+		Usage: "Migrate while Vault servers keep running on the source storage. Servers " +
+			"journal their writes while the keys are copied, and reject writes during a " +
+			"brief cutover once the journal is drained. Not supported with a raft source.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "cutover-threshold",
+		Default: 100,
+		Target:  &c.flagCutoverThreshold,
+		Usage: "Number of journaled writes below which an online migration stops catching " +
+			"up and starts the cutover. Only used with -online.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "log-level",
 		Target:     &c.flagLogLevel,
@@ -172,9 +256,13 @@ func (c *OperatorMigrateCommand) Run(args []string) int {
 		return 2
 	}
 
-	if c.flagReset {
+	switch {
+	case c.flagReset:
 		c.UI.Output("Success! Migration lock reset (if it was set).")
-	} else {
+	case c.flagOnline:
+		c.UI.Output("Success! All of the keys have been migrated. Servers using the " +
+			"source storage now reject writes; restart them with the destination storage.")
+	default:
 		c.UI.Output("Success! All of the keys have been migrated.")
 	}
 
@@ -193,9 +281,16 @@ func (c *OperatorMigrateCommand) migrate(config *migratorConfig) error {
 		if err := SetStorageMigration(from, false); err != nil {
 			return fmt.Errorf("error resetting migration lock: %w", err)
 		}
+		if err := resetOnlineMigration(context.Background(), from); err != nil {
+			return fmt.Errorf("error resetting online migration: %w", err)
+		}
 		return nil
 	}
 
+	if c.flagOnline && config.StorageSource.Type == "raft" {
+		return errors.New("online migration is not supported with a raft source")
+	}
+
 	to, err := c.createDestinationBackend(config.StorageDestination.Type, config.StorageDestination.Config, config)
 	if err != nil {
 		return fmt.Errorf("error mounting 'storage_destination': %w", err)
@@ -210,11 +305,14 @@ func (c *OperatorMigrateCommand) migrate(config *migratorConfig) error {
 		return fmt.Errorf("storage migration in progress (started: %s)", migrationStatus.Start.Format(time.RFC3339))
 	}
 
-	switch config.StorageSource.Type {
-	case "raft":
+	switch {
+	case config.StorageSource.Type == "raft":
 		// Raft storage cannot be written to when shutdown. Also the boltDB file
 		// already uses file locking to ensure two processes are not accessing
 		// it.
+	case c.flagOnline:
+		// Servers keep running until the cutover, which sets the lock once
+		// the migration completes
 	default:
 		if err := SetStorageMigration(from, true); err != nil {
 			return fmt.Errorf("error setting migration lock: %w", err)
@@ -227,6 +325,10 @@ func (c *OperatorMigrateCommand) migrate(config *migratorConfig) error {
 
 	doneCh := make(chan error)
 	go func() {
+		if c.flagOnline {
+			doneCh <- c.migrateOnline(ctx, from, to, c.flagMaxParallel)
+			return
+		}
 		doneCh <- c.migrateAll(ctx, from, to, c.flagMaxParallel)
 	}()
 
@@ -242,13 +344,29 @@ func (c *OperatorMigrateCommand) migrate(config *migratorConfig) error {
 	}
 }
 
-// migrateAll copies all keys in lexicographic order.
+// migrateAll copies all keys in lexicographic order, checkpointing the
+// progress in the destination so an interrupted migration can be resumed.
 func (c *OperatorMigrateCommand) migrateAll(ctx context.Context, from physical.Backend, to physical.Backend, maxParallel int) error {
-	return dfsScan(ctx, from, maxParallel, func(ctx context.Context, path string) error {
-		if path < c.flagStart || path == storageMigrationLock || path == vault.CoreLockPath {
-			return nil
+	checkpoint, err := getMigrationCheckpoint(ctx, to)
+	if err != nil {
+		return fmt.Errorf("error reading migration checkpoint: %w", err)
+	}
+
+	start := c.flagStart
+	switch {
+	case checkpoint == nil || !c.flagResume:
+		checkpoint = &migrationCheckpoint{
+			Start: time.Now(),
+		}
+	default:
+		c.logger.Info("resuming migration", "high_water_mark", checkpoint.HighWaterMark, "copied", checkpoint.Copied)
+		if checkpoint.HighWaterMark > start {
+			start = checkpoint.HighWaterMark
 		}
+	}
 
+	previouslyCopied := checkpoint.Copied
+	err = c.copyKeys(ctx, from, maxParallel, start, func(ctx context.Context, path string) error {
 		entry, err := from.Get(ctx, path)
 		if err != nil {
 			return fmt.Errorf("error reading entry: %w", err)
@@ -261,9 +379,82 @@ func (c *OperatorMigrateCommand) migrateAll(ctx context.Context, from physical.B
 		if err := to.Put(ctx, entry); err != nil {
 			return fmt.Errorf("error writing entry: %w", err)
 		}
-		c.logger.Info("copied key", "path", path)
 		return nil
+	}, func(ctx context.Context, mark string, copied int64) {
+		if mark == "" || mark <= checkpoint.HighWaterMark {
+			return
+		}
+		checkpoint.HighWaterMark = mark
+		checkpoint.Copied = previouslyCopied + copied
+		if err := putMigrationCheckpoint(ctx, to, checkpoint); err != nil {
+			c.logger.Warn("failed to record migration checkpoint", "error", err)
+		}
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := to.Delete(ctx, migrationCheckpointPath); err != nil {
+		return fmt.Errorf("error removing migration checkpoint: %w", err)
+	}
+	return nil
+}
+
+// copyKeys copies the keys at or after start with up to maxParallel workers
+// using copyKey. Every checkpoint interval, and once more when the copy stops,
+// it reports the progress and calls checkpoint with the high-water mark and
+// the number of keys copied.
+func (c *OperatorMigrateCommand) copyKeys(ctx context.Context, from physical.Backend, maxParallel int, start string, copyKey func(ctx context.Context, path string) error, checkpoint func(ctx context.Context, mark string, copied int64)) error {
+	progress := newMigrationProgress()
+	var copied atomic.Int64
+	began := time.Now()
+
+	report := func(ctx context.Context) {
+		n := copied.Load()
+		mark := progress.highWaterMark()
+		c.logger.Info("migration progress", "copied", n,
+			"keys_per_second", math.Round(float64(n)/time.Since(began).Seconds()),
+			"high_water_mark", mark)
+		checkpoint(ctx, mark, n)
+	}
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		ticker := time.NewTicker(migrationCheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				report(ctx)
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	err := dfsScanWithProgress(ctx, from, maxParallel, progress, func(ctx context.Context, path string) error {
+		if path < start || isMigrationKey(path) {
+			progress.completed(path)
+			return nil
+		}
+		// Keys which failed to copy are never completed, so the high-water
+		// mark stays before them
+		if err := copyKey(ctx, path); err != nil {
+			return err
+		}
+		progress.completed(path)
+		copied.Add(1)
+		c.logger.Debug("copied key", "path", path)
+		return nil
+	})
+
+	close(stopCh)
+	<-doneCh
+	// Record the progress made even if the copy was interrupted
+	report(context.Background())
+	return err
 }
 
 func (c *OperatorMigrateCommand) newBackend(kind string, conf map[string]string) (physical.Backend, error) {
@@ -384,6 +575,12 @@ func parseStorage(result *migratorConfig, list *ast.ObjectList, name string) err
 // dfsScan will invoke cb with every key from source.
 // Keys will be traversed in lexicographic, depth-first order.
 func dfsScan(ctx context.Context, source physical.Backend, maxParallel int, cb func(ctx context.Context, path string) error) error {
+	return dfsScanWithProgress(ctx, source, maxParallel, nil, cb)
+}
+
+// dfsScanWithProgress is dfsScan, additionally recording the order keys are
+// dispatched in to progress, if set.
+func dfsScanWithProgress(ctx context.Context, source physical.Backend, maxParallel int, progress *migrationProgress, cb func(ctx context.Context, path string) error) error {
 	dfs := []string{""}
 
 	eg, ctx := errgroup.WithContext(ctx)
@@ -414,6 +611,9 @@ func dfsScan(ctx context.Context, source physical.Backend, maxParallel int, cb f
 			}
 		} else {
 			// Pooling
+			if progress != nil {
+				progress.dispatched(key)
+			}
 			eg.Go(func() error {
 				return cb(ctx, key)
 			})
@@ -0,0 +1,431 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault"
+)
+
+const (
+	onlineMigrationPrefix        = "core/migration-online/"
+	onlineMigrationStatusPath    = onlineMigrationPrefix + "status"
+	onlineMigrationJournalPrefix = onlineMigrationPrefix + "journal/"
+
+	// During the journaling phase servers record the keys they write in the
+	// journal, which the migrator replays onto the destination.
+	onlineMigrationPhaseJournaling = "journaling"
+	// During the cutover phase servers reject writes while the migrator
+	// drains the journal for the last time.
+	onlineMigrationPhaseCutover = "cutover"
+	// Once complete, servers keep rejecting writes until they are restarted
+	// with the destination storage.
+	onlineMigrationPhaseComplete = "complete"
+)
+
+// onlineMigrationPollInterval is how long servers cache the online migration
+// status. The migrator waits twice as long after changing phases so every
+// server is guaranteed to have seen the change.
+var onlineMigrationPollInterval = 5 * time.Second
+
+var errOnlineMigrationCutover = errors.New("storage migration cutover in progress; writes are disabled")
+
+// onlineMigrationStatus is stored in the source storage while an online
+// migration is running. HighWaterMark is the key up to which, in lexicographic
+// order, the initial copy has completed.
+type onlineMigrationStatus struct {
+	Phase         string    `json:"phase"`
+	Start         time.Time `json:"start"`
+	HighWaterMark string    `json:"high_water_mark"`
+}
+
+func getOnlineMigrationStatus(ctx context.Context, b physical.Backend) (*onlineMigrationStatus, error) {
+	entry, err := b.Get(ctx, onlineMigrationStatusPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var status onlineMigrationStatus
+	if err := jsonutil.DecodeJSON(entry.Value, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func putOnlineMigrationStatus(ctx context.Context, b physical.Backend, status *onlineMigrationStatus) error {
+	enc, err := jsonutil.EncodeJSON(status)
+	if err != nil {
+		return err
+	}
+	return b.Put(ctx, &physical.Entry{
+		Key:   onlineMigrationStatusPath,
+		Value: enc,
+	})
+}
+
+// isMigrationKey reports whether the key holds migration or lock state that
+// must neither be copied nor journaled.
+func isMigrationKey(key string) bool {
+	return strings.HasPrefix(key, storageMigrationLock) || key == vault.CoreLockPath
+}
+
+// onlineMigrationBackend wraps the storage of a server so that, while an
+// online migration is running, the keys it writes are journaled for the
+// migrator to copy, and writes are rejected during cutover.
+type onlineMigrationBackend struct {
+	physical.Backend
+	logger log.Logger
+
+	l         sync.Mutex
+	phase     string
+	lastCheck time.Time
+}
+
+// transactionalOnlineMigrationBackend is the transactional variant of
+// onlineMigrationBackend.
+type transactionalOnlineMigrationBackend struct {
+	*onlineMigrationBackend
+	txn physical.Transactional
+}
+
+var (
+	_ physical.Backend       = (*onlineMigrationBackend)(nil)
+	_ physical.Transactional = (*transactionalOnlineMigrationBackend)(nil)
+)
+
+func newOnlineMigrationBackend(b physical.Backend, logger log.Logger) physical.Backend {
+	ob := &onlineMigrationBackend{
+		Backend: b,
+		logger:  logger,
+	}
+	if txn, ok := b.(physical.Transactional); ok {
+		return &transactionalOnlineMigrationBackend{
+			onlineMigrationBackend: ob,
+			txn:                    txn,
+		}
+	}
+	return ob
+}
+
+// currentPhase returns the phase of the online migration, if any, re-reading
+// it from storage at most once per poll interval.
+func (b *onlineMigrationBackend) currentPhase(ctx context.Context) string {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if time.Since(b.lastCheck) < onlineMigrationPollInterval {
+		return b.phase
+	}
+
+	status, err := getOnlineMigrationStatus(ctx, b.Backend)
+	if err != nil {
+		// Keep the last known phase and retry on the next write
+		b.logger.Warn("failed to read online storage migration status", "error", err)
+		return b.phase
+	}
+
+	phase := ""
+	if status != nil {
+		phase = status.Phase
+	}
+	if phase != b.phase {
+		b.logger.Info("online storage migration phase changed", "phase", phase)
+	}
+	b.phase = phase
+	b.lastCheck = time.Now()
+	return phase
+}
+
+// journal records the given keys before they are written.
+func (b *onlineMigrationBackend) journal(ctx context.Context, keys ...string) error {
+	switch b.currentPhase(ctx) {
+	case "":
+		return nil
+	case onlineMigrationPhaseCutover, onlineMigrationPhaseComplete:
+		return errOnlineMigrationCutover
+	}
+
+	for _, key := range keys {
+		if isMigrationKey(key) {
+			continue
+		}
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return err
+		}
+		if err := b.Backend.Put(ctx, &physical.Entry{
+			Key:   fmt.Sprintf("%s%020d-%s", onlineMigrationJournalPrefix, time.Now().UnixNano(), id),
+			Value: []byte(key),
+		}); err != nil {
+			return fmt.Errorf("failed to journal write for storage migration: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *onlineMigrationBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	if err := b.journal(ctx, entry.Key); err != nil {
+		return err
+	}
+	return b.Backend.Put(ctx, entry)
+}
+
+func (b *onlineMigrationBackend) Delete(ctx context.Context, key string) error {
+	if err := b.journal(ctx, key); err != nil {
+		return err
+	}
+	return b.Backend.Delete(ctx, key)
+}
+
+func (b *transactionalOnlineMigrationBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	var keys []string
+	for _, txn := range txns {
+		if txn.Operation != physical.GetOperation {
+			keys = append(keys, txn.Entry.Key)
+		}
+	}
+	if err := b.journal(ctx, keys...); err != nil {
+		return err
+	}
+	return b.txn.Transaction(ctx, txns)
+}
+
+// migrationProgress tracks the high-water mark of a parallel copy: the
+// greatest key such that it and every key dispatched before it were copied.
+type migrationProgress struct {
+	l       sync.Mutex
+	pending []string
+	done    map[string]struct{}
+	mark    string
+}
+
+func newMigrationProgress() *migrationProgress {
+	return &migrationProgress{
+		done: make(map[string]struct{}),
+	}
+}
+
+func (p *migrationProgress) dispatched(key string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.pending = append(p.pending, key)
+}
+
+func (p *migrationProgress) completed(key string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.done[key] = struct{}{}
+	for len(p.pending) > 0 {
+		if _, ok := p.done[p.pending[0]]; !ok {
+			break
+		}
+		p.mark = p.pending[0]
+		delete(p.done, p.pending[0])
+		p.pending = p.pending[1:]
+	}
+}
+
+func (p *migrationProgress) highWaterMark() string {
+	p.l.Lock()
+	defer p.l.Unlock()
+	return p.mark
+}
+
+// migrateOnline copies all keys while servers keep running on the source
+// storage, replays the writes they journaled meanwhile, and finally cuts over
+// by briefly rejecting writes to drain the journal.
+func (c *OperatorMigrateCommand) migrateOnline(ctx context.Context, from physical.Backend, to physical.Backend, maxParallel int) error {
+	status, err := getOnlineMigrationStatus(ctx, from)
+	if err != nil {
+		return fmt.Errorf("error reading online migration status: %w", err)
+	}
+
+	switch {
+	case status == nil:
+		status = &onlineMigrationStatus{
+			Phase: onlineMigrationPhaseJournaling,
+			Start: time.Now(),
+		}
+		if err := putOnlineMigrationStatus(ctx, from, status); err != nil {
+			return fmt.Errorf("error starting online migration: %w", err)
+		}
+		c.logger.Info("started write journaling, waiting for servers to observe it")
+		if err := c.waitForServers(ctx); err != nil {
+			return err
+		}
+	case status.Phase == onlineMigrationPhaseComplete:
+		return errors.New("online migration already completed; use -reset to clear it")
+	default:
+		c.logger.Info("resuming online migration", "phase", status.Phase, "high_water_mark", status.HighWaterMark)
+	}
+
+	if status.Phase == onlineMigrationPhaseJournaling {
+		if err := c.copyOnline(ctx, from, to, maxParallel, status); err != nil {
+			return err
+		}
+
+		// Catch up with the writes made during the copy until few enough are
+		// left to drain them with writes disabled
+		for {
+			n, err := c.replayJournal(ctx, from, to, onlineMigrationPollInterval)
+			if err != nil {
+				return err
+			}
+			c.logger.Info("replayed journaled writes", "count", n)
+			if n < c.flagCutoverThreshold {
+				break
+			}
+		}
+
+		status.Phase = onlineMigrationPhaseCutover
+		if err := putOnlineMigrationStatus(ctx, from, status); err != nil {
+			return fmt.Errorf("error starting cutover: %w", err)
+		}
+		c.logger.Info("started cutover, waiting for servers to stop writing")
+		if err := c.waitForServers(ctx); err != nil {
+			return err
+		}
+	}
+
+	n, err := c.replayJournal(ctx, from, to, 0)
+	if err != nil {
+		return err
+	}
+	c.logger.Info("replayed journaled writes", "count", n)
+
+	// Servers must not be restarted against the source storage from now on
+	if err := SetStorageMigration(from, true); err != nil {
+		return fmt.Errorf("error setting migration lock: %w", err)
+	}
+	status.Phase = onlineMigrationPhaseComplete
+	if err := putOnlineMigrationStatus(ctx, from, status); err != nil {
+		return fmt.Errorf("error completing online migration: %w", err)
+	}
+	return nil
+}
+
+// waitForServers waits until every server has re-read the online migration
+// status.
+func (c *OperatorMigrateCommand) waitForServers(ctx context.Context) error {
+	select {
+	case <-time.After(2 * onlineMigrationPollInterval):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// copyOnline copies all keys from the high-water mark onwards, checkpointing
+// the mark so an interrupted migration can be resumed.
+func (c *OperatorMigrateCommand) copyOnline(ctx context.Context, from physical.Backend, to physical.Backend, maxParallel int, status *onlineMigrationStatus) error {
+	start := c.flagStart
+	if status.HighWaterMark > start {
+		start = status.HighWaterMark
+	}
+
+	return c.copyKeys(ctx, from, maxParallel, start, func(ctx context.Context, path string) error {
+		return copyMigrationKey(ctx, from, to, path)
+	}, func(ctx context.Context, mark string, _ int64) {
+		if mark == "" || mark <= status.HighWaterMark {
+			return
+		}
+		status.HighWaterMark = mark
+		if err := putOnlineMigrationStatus(ctx, from, status); err != nil {
+			c.logger.Warn("failed to record migration high-water mark", "error", err)
+		}
+	})
+}
+
+// replayJournal copies the keys recorded in the journal at least settle ago,
+// and returns how many journal entries were replayed.
+func (c *OperatorMigrateCommand) replayJournal(ctx context.Context, from physical.Backend, to physical.Backend, settle time.Duration) (int, error) {
+	ids, err := from.List(ctx, onlineMigrationJournalPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("error listing journal: %w", err)
+	}
+	sort.Strings(ids)
+
+	// Entries are journaled before the write they record, so only replay
+	// those old enough for the write to have completed
+	cutoff := time.Now().Add(-settle).UnixNano()
+	copied := make(map[string]struct{})
+	var n int
+	for _, id := range ids {
+		ts, _, _ := strings.Cut(id, "-")
+		if nanos, err := strconv.ParseInt(ts, 10, 64); err == nil && nanos > cutoff {
+			break
+		}
+
+		entry, err := from.Get(ctx, onlineMigrationJournalPrefix+id)
+		if err != nil {
+			return n, fmt.Errorf("error reading journal: %w", err)
+		}
+		if entry != nil {
+			path := string(entry.Value)
+			if _, ok := copied[path]; !ok {
+				if err := copyMigrationKey(ctx, from, to, path); err != nil {
+					return n, err
+				}
+				copied[path] = struct{}{}
+			}
+		}
+
+		if err := from.Delete(ctx, onlineMigrationJournalPrefix+id); err != nil {
+			return n, fmt.Errorf("error deleting journal entry: %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// copyMigrationKey makes the destination value of the key match the source,
+// deleting it if it no longer exists.
+func copyMigrationKey(ctx context.Context, from physical.Backend, to physical.Backend, path string) error {
+	entry, err := from.Get(ctx, path)
+	if err != nil {
+		return fmt.Errorf("error reading entry: %w", err)
+	}
+
+	if entry == nil {
+		if err := to.Delete(ctx, path); err != nil {
+			return fmt.Errorf("error deleting entry: %w", err)
+		}
+		return nil
+	}
+
+	if err := to.Put(ctx, entry); err != nil {
+		return fmt.Errorf("error writing entry: %w", err)
+	}
+	return nil
+}
+
+// resetOnlineMigration clears the status and journal of an online migration.
+func resetOnlineMigration(ctx context.Context, b physical.Backend) error {
+	ids, err := b.List(ctx, onlineMigrationJournalPrefix)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := b.Delete(ctx, onlineMigrationJournalPrefix+id); err != nil {
+			return err
+		}
+	}
+	return b.Delete(ctx, onlineMigrationStatusPath)
+}
@@ -6,6 +6,7 @@ package command
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -185,6 +186,166 @@ func TestMigration(t *testing.T) {
 		}
 	})
 
+	t.Run("Resume", func(t *testing.T) {
+		data := generateData()
+		from, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storeData(from, data); err != nil {
+			t.Fatal(err)
+		}
+		inmem, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		to := &failingPutter{Backend: inmem, remaining: 200}
+
+		cmd := OperatorMigrateCommand{
+			logger:     log.NewNullLogger(),
+			flagResume: true,
+		}
+		if err := cmd.migrateAll(context.Background(), from, to, 10); err == nil {
+			t.Fatal("expected migration to fail")
+		}
+
+		checkpoint, err := getMigrationCheckpoint(context.Background(), to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint == nil || checkpoint.HighWaterMark == "" || checkpoint.Copied == 0 || checkpoint.Copied > 200 {
+			t.Fatalf("unexpected checkpoint: %#v", checkpoint)
+		}
+
+		// Resuming only copies the keys after the high-water mark
+		to.reset(-1)
+		if err := cmd.migrateAll(context.Background(), from, to, 10); err != nil {
+			t.Fatal(err)
+		}
+		if err := compareStoredData(to, data, ""); err != nil {
+			t.Fatal(err)
+		}
+		if to.puts >= len(data)-4 {
+			t.Fatalf("expected resumed migration to copy fewer keys, copied %d", to.puts)
+		}
+		if checkpoint, err := getMigrationCheckpoint(context.Background(), to); err != nil || checkpoint != nil {
+			t.Fatalf("expected checkpoint to be removed, got: %v, %v", checkpoint, err)
+		}
+
+		// Without resuming all keys are copied again
+		to.reset(-1)
+		if err := putMigrationCheckpoint(context.Background(), to, &migrationCheckpoint{HighWaterMark: "zzz"}); err != nil {
+			t.Fatal(err)
+		}
+		cmd.flagResume = false
+		if err := cmd.migrateAll(context.Background(), from, to, 10); err != nil {
+			t.Fatal(err)
+		}
+		if to.puts != len(data)-4 {
+			t.Fatalf("expected all %d keys to be copied, copied %d", len(data)-4, to.puts)
+		}
+	})
+
+	t.Run("Online", func(t *testing.T) {
+		defer func(interval time.Duration) {
+			onlineMigrationPollInterval = interval
+		}(onlineMigrationPollInterval)
+		onlineMigrationPollInterval = 50 * time.Millisecond
+
+		data := generateData()
+		from, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storeData(from, data); err != nil {
+			t.Fatal(err)
+		}
+		to, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Keep writing through the storage of a running server until the
+		// cutover rejects writes
+		server := newOnlineMigrationBackend(from, log.NewNullLogger())
+		var existing []string
+		for k := range data {
+			existing = append(existing, k)
+		}
+		writerErrCh := make(chan error, 1)
+		go func() {
+			for i := 0; ; i++ {
+				err := server.Put(context.Background(), &physical.Entry{
+					Key:   fmt.Sprintf("online/%d", i%50),
+					Value: []byte(fmt.Sprintf("%d", i)),
+				})
+				if err == nil && i < len(existing) {
+					err = server.Delete(context.Background(), existing[i])
+				}
+				if err != nil {
+					if err == errOnlineMigrationCutover {
+						err = nil
+					}
+					writerErrCh <- err
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		cmd := OperatorMigrateCommand{
+			logger:               log.NewNullLogger(),
+			flagCutoverThreshold: 10,
+		}
+		if err := cmd.migrateOnline(context.Background(), from, to, 10); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-writerErrCh; err != nil {
+			t.Fatal(err)
+		}
+
+		// The destination matches the source, apart from migration state
+		readAll := func(b physical.Backend) map[string]string {
+			result := make(map[string]string)
+			dfsScan(context.Background(), b, 1, func(ctx context.Context, path string) error {
+				if isMigrationKey(path) {
+					return nil
+				}
+				entry, err := b.Get(ctx, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				result[path] = string(entry.Value)
+				return nil
+			})
+			return result
+		}
+		if diff := deep.Equal(readAll(to), readAll(from)); diff != nil {
+			t.Fatal(diff)
+		}
+
+		journal, err := from.List(context.Background(), onlineMigrationJournalPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(journal) != 0 {
+			t.Fatalf("expected journal to be drained, got %d entries", len(journal))
+		}
+
+		status, err := CheckStorageMigration(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status == nil {
+			t.Fatal("expected migration lock to be set")
+		}
+
+		time.Sleep(onlineMigrationPollInterval)
+		if err := server.Put(context.Background(), &physical.Entry{Key: "after"}); err != errOnlineMigrationCutover {
+			t.Fatalf("expected writes to be rejected, got: %v", err)
+		}
+	})
+
 	t.Run("Config parsing", func(t *testing.T) {
 		cmd := new(OperatorMigrateCommand)
 		cfgName := filepath.Join(t.TempDir(), "migrator")
@@ -339,6 +500,37 @@ func (l randomLister) Delete(ctx context.Context, path string) error {
 	return l.b.Delete(ctx, path)
 }
 
+// failingPutter wraps a physical backend, failing writes of keys once a
+// number of them succeeded. Checkpoints are always written.
+type failingPutter struct {
+	physical.Backend
+
+	l         sync.Mutex
+	remaining int
+	puts      int
+}
+
+func (p *failingPutter) Put(ctx context.Context, entry *physical.Entry) error {
+	if entry.Key != migrationCheckpointPath {
+		p.l.Lock()
+		if p.remaining == 0 {
+			p.l.Unlock()
+			return errors.New("storage unavailable")
+		}
+		p.remaining--
+		p.puts++
+		p.l.Unlock()
+	}
+	return p.Backend.Put(ctx, entry)
+}
+
+// reset allows the given number of writes, or unlimited writes if negative.
+func (p *failingPutter) reset(remaining int) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.remaining, p.puts = remaining, 0
+}
+
 // generateData creates a map of 500 random keys and values
 func generateData() map[string][]byte {
 	result := make(map[string][]byte)
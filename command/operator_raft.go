@@ -43,6 +43,10 @@ Usage: vault operator raft <subcommand> [options] [args]
 
       $ vault operator raft snapshot save out.snap
 
+  Displays the stabilization status of the servers as seen by autopilot:
+
+      $ vault operator raft autopilot stabilization
+
   Please see the individual subcommand help for detailed usage information.
 `
 
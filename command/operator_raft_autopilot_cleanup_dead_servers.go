@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorRaftAutopilotCleanupDeadServersCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorRaftAutopilotCleanupDeadServersCommand)(nil)
+)
+
+type OperatorRaftAutopilotCleanupDeadServersCommand struct {
+	*BaseCommand
+	flagDRToken string
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) Synopsis() string {
+	return "Triggers the removal of dead servers by autopilot under integrated storage"
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) Help() string {
+	helpText := `
+Usage: vault operator raft autopilot cleanup-dead-servers [options]
+
+  Triggers an immediate removal of the failed and stale servers from the raft
+  cluster by autopilot, instead of waiting for its next periodic cleanup. The
+  removal follows the autopilot configuration: servers are only considered
+  failed after the dead server last contact threshold, and the minimum quorum
+  is respected. Dead server cleanup must be enabled with:
+
+      $ vault operator raft autopilot set-config -cleanup-dead-servers=true
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "dr-token",
+		Target:     &c.flagDRToken,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "DR operation token used to authorize this request (if a DR secondary node).",
+	})
+
+	return set
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorRaftAutopilotCleanupDeadServersCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch len(args) {
+	case 0:
+	default:
+		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	switch {
+	case c.flagDRToken != "":
+		err = client.Sys().RaftAutopilotCleanupDeadServersWithDRToken(c.flagDRToken)
+	default:
+		err = client.Sys().RaftAutopilotCleanupDeadServers()
+	}
+
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error triggering the cleanup of dead servers: %s", err))
+		return 2
+	}
+
+	c.UI.Output("Success! Triggered the cleanup of dead servers. Check the result with \"vault operator raft autopilot state\".")
+	return 0
+}
@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorRaftAutopilotStabilizationCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorRaftAutopilotStabilizationCommand)(nil)
+)
+
+type OperatorRaftAutopilotStabilizationCommand struct {
+	*BaseCommand
+	flagDRToken string
+}
+
+// autopilotServerStabilization is the stabilization status of a server of
+// the raft cluster.
+type autopilotServerStabilization struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	Status      string `json:"status"`
+	Healthy     bool   `json:"healthy"`
+	StableSince string `json:"stable_since"`
+	Stable      bool   `json:"stable"`
+
+	// StableIn is the time left until a healthy server is considered stable
+	// by autopilot.
+	StableIn string `json:"stable_in,omitempty"`
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) Synopsis() string {
+	return "Displays the stabilization status of the servers under integrated storage"
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) Help() string {
+	helpText := `
+Usage: vault operator raft autopilot stabilization [options]
+
+  Displays whether each server of the raft cluster has been healthy for the
+  server stabilization time of the autopilot configuration, which autopilot
+  requires before promoting a server to voter, and how long is left until it
+  is.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "dr-token",
+		Target:     &c.flagDRToken,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "DR operation token used to authorize this request (if a DR secondary node).",
+	})
+
+	return set
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorRaftAutopilotStabilizationCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch len(args) {
+	case 0:
+	default:
+		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var state *api.AutopilotState
+	var config *api.AutopilotConfig
+	switch {
+	case c.flagDRToken != "":
+		state, err = client.Sys().RaftAutopilotStateWithDRToken(c.flagDRToken)
+		if err == nil {
+			config, err = client.Sys().RaftAutopilotConfigurationWithDRToken(c.flagDRToken)
+		}
+	default:
+		state, err = client.Sys().RaftAutopilotState()
+		if err == nil {
+			config, err = client.Sys().RaftAutopilotConfiguration()
+		}
+	}
+
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error checking autopilot state: %s", err))
+		return 2
+	}
+
+	if state == nil || config == nil {
+		c.UI.Error("Autopilot is not running")
+		return 2
+	}
+
+	servers := autopilotStabilization(state, config.ServerStabilizationTime, time.Now())
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, servers)
+	}
+
+	out := []string{"Node | Address | Status | Healthy | Stable Since | Stable"}
+	for _, server := range servers {
+		stable := "true"
+		switch {
+		case !server.Healthy:
+			stable = "false (unhealthy)"
+		case !server.Stable:
+			stable = fmt.Sprintf("in %s", server.StableIn)
+		}
+		out = append(out, fmt.Sprintf("%s | %s | %s | %t | %s | %s",
+			server.Name, server.Address, server.Status, server.Healthy, server.StableSince, stable))
+	}
+
+	c.UI.Output(fmt.Sprintf("Server stabilization time: %s\n", config.ServerStabilizationTime))
+	c.UI.Output(tableOutput(out, nil))
+	return 0
+}
+
+// autopilotStabilization returns the stabilization status of the servers of
+// the state at the given time, sorted by name.
+func autopilotStabilization(state *api.AutopilotState, stabilizationTime time.Duration, now time.Time) []*autopilotServerStabilization {
+	servers := make([]*autopilotServerStabilization, 0, len(state.Servers))
+	for _, server := range state.Servers {
+		s := &autopilotServerStabilization{
+			ID:          server.ID,
+			Name:        server.Name,
+			Address:     server.Address,
+			Status:      server.Status,
+			Healthy:     server.Healthy,
+			StableSince: server.StableSince,
+		}
+
+		if server.Healthy {
+			s.Stable = true
+			if since, err := time.Parse(time.RFC3339Nano, server.StableSince); err == nil {
+				if left := since.Add(stabilizationTime).Sub(now); left > 0 {
+					s.Stable = false
+					s.StableIn = left.Round(time.Second).String()
+				}
+			}
+		}
+
+		servers = append(servers, s)
+	}
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name < servers[j].Name
+	})
+
+	return servers
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestAutopilotStabilization(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 8, 29, 16, 0, 0, 0, time.UTC)
+	state := &api.AutopilotState{
+		Servers: map[string]*api.AutopilotServer{
+			"raft2": {
+				Name:        "raft2",
+				Healthy:     true,
+				StableSince: now.Add(-4 * time.Second).Format(time.RFC3339Nano),
+			},
+			"raft1": {
+				Name:        "raft1",
+				Healthy:     true,
+				StableSince: now.Add(-time.Minute).Format(time.RFC3339Nano),
+			},
+			"raft3": {
+				Name:        "raft3",
+				Healthy:     false,
+				StableSince: now.Add(-time.Minute).Format(time.RFC3339Nano),
+			},
+		},
+	}
+
+	servers := autopilotStabilization(state, 10*time.Second, now)
+
+	expected := []struct {
+		name     string
+		stable   bool
+		stableIn string
+	}{
+		{"raft1", true, ""},
+		{"raft2", false, "6s"},
+		{"raft3", false, ""},
+	}
+	if len(servers) != len(expected) {
+		t.Fatalf("expected %d servers, got %d", len(expected), len(servers))
+	}
+	for i, e := range expected {
+		if s := servers[i]; s.Name != e.name || s.Stable != e.stable || s.StableIn != e.stableIn {
+			t.Errorf("expected %s to have stable=%t stable_in=%q, got %#v", e.name, e.stable, e.stableIn, s)
+		}
+	}
+}
@@ -4,10 +4,13 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,7 +21,9 @@ var (
 )
 
 type OperatorRaftSnapshotRestoreCommand struct {
-	flagForce bool
+	flagForce     bool
+	flagChunkSize string
+	flagRetries   int
 	*BaseCommand
 }
 
@@ -34,6 +39,11 @@ Usage: vault operator raft snapshot restore <snapshot_file>
 
 	  $ vault operator raft snapshot restore raft.snap
 
+  Upload a large snapshot in chunks of 64MiB, resuming the upload from the data
+  received by Vault when a chunk fails:
+
+	  $ vault operator raft snapshot restore -chunk-size=64MiB raft.snap
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -51,6 +61,21 @@ func (c *OperatorRaftSnapshotRestoreCommand) Flags() *FlagSets {
 		Usage:   "This bypasses checks ensuring the Autounseal or shamir keys are consistent with the snapshot data.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:   "chunk-size",
+		Target: &c.flagChunkSize,
+		Usage: "Upload the snapshot in chunks of this size, such as \"64MiB\", " +
+			"verifying its SHA256 sum once it is uploaded. A chunk which fails " +
+			"is resumed from the data received by Vault.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "retries",
+		Target:  &c.flagRetries,
+		Default: 5,
+		Usage:   "Number of times in a row a chunk is retried when uploading in chunks.",
+	})
+
 	return set
 }
 
@@ -99,11 +124,48 @@ func (c *OperatorRaftSnapshotRestoreCommand) Run(args []string) int {
 		return 2
 	}
 
-	err = client.Sys().RaftSnapshotRestore(snapReader, c.flagForce)
+	if c.flagChunkSize == "" {
+		err = client.Sys().RaftSnapshotRestore(snapReader, c.flagForce)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error installing the snapshot: %s", err))
+			return 2
+		}
+
+		return 0
+	}
+
+	chunkSize, err := parseutil.ParseCapacityString(c.flagChunkSize)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error installing the snapshot: %s", err))
+		c.UI.Error(fmt.Sprintf("Error parsing chunk size: %s", err))
+		return 1
+	}
+
+	ctx := context.Background()
+	status, err := client.Sys().RaftSnapshotRestoreInChunksWithContext(ctx, snapReader, c.flagForce, int64(chunkSize), c.flagRetries)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error uploading the snapshot: %s", err))
+		return 2
+	}
+
+	// Wait for Vault to verify the snapshot; the status can't be read while
+	// the snapshot is installed.
+	for status.State == "verifying" {
+		time.Sleep(time.Second)
+		status, err = client.Sys().RaftSnapshotUploadStatusWithContext(ctx, status.UploadID)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading the snapshot upload status: %s", err))
+			return 2
+		}
+		if status == nil {
+			c.UI.Error("Error reading the snapshot upload status: the upload was discarded")
+			return 2
+		}
+	}
+	if status.State == "failed" {
+		c.UI.Error(fmt.Sprintf("Error installing the snapshot: %s", status.Error))
 		return 2
 	}
 
+	c.UI.Output(fmt.Sprintf("Snapshot uploaded and verified; installing it (upload ID %s)", status.UploadID))
 	return 0
 }
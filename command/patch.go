@@ -7,9 +7,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -23,7 +25,9 @@ var (
 type PatchCommand struct {
 	*BaseCommand
 
-	flagForce bool
+	flagForce  bool
+	flagMethod string
+	flagRemove []string
 
 	testStdin io.Reader // for tests
 }
@@ -44,7 +48,9 @@ Usage: vault patch [options] PATH [DATA K=V...]
   it is loaded from a file. If the value is "-", Vault will read the value from
   stdin.
 
-  Unlike write, patch will only modify specified fields.
+  Unlike write, patch will only modify specified fields. The data is sent as
+  a JSON merge patch (RFC 7386) with an HTTP PATCH request: nested objects are
+  merged and fields set to null are removed.
 
   Persist data in the generic secrets engine without modifying any other fields:
 
@@ -59,6 +65,16 @@ Usage: vault patch [options] PATH [DATA K=V...]
 
       $ echo "example.com" | vault patch pki/roles/example allowed_domains=-
 
+  Remove a field:
+
+      $ vault patch -remove=allowed_domains pki/roles/example
+
+  For endpoints which do not support HTTP PATCH, the data can instead be read,
+  updated locally and written back. Note that this is not atomic, and that
+  it writes back every field of the read response:
+
+      $ vault patch -method=rw auth/userpass/users/alice token_ttl=1h
+
   For a full list of examples and paths, please see the documentation that
   corresponds to the secret engines in use.
 
@@ -82,6 +98,26 @@ func (c *PatchCommand) Flags() *FlagSets {
 			"allows writing to keys that do not need or expect data.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "method",
+		Target:     &c.flagMethod,
+		Default:    "patch",
+		Completion: complete.PredictSet("patch", "rw", "auto"),
+		Usage: `Specifies which method of patching to use. If set to "patch",
+		an HTTP PATCH request is issued. If set to "rw", the data is read,
+		updated locally and written back. If set to "auto", an HTTP PATCH
+		request is issued, falling back to "rw" if the endpoint does not support
+		HTTP PATCH.`,
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "remove",
+		Target:     &c.flagRemove,
+		Completion: complete.PredictAnything,
+		Usage: "Field to remove, by setting it to null in the merge patch. " +
+			"This can be specified multiple times.",
+	})
+
 	return set
 }
 
@@ -108,11 +144,18 @@ func (c *PatchCommand) Run(args []string) int {
 	case len(args) < 1:
 		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
 		return 1
-	case len(args) == 1 && !c.flagForce:
+	case len(args) == 1 && !c.flagForce && len(c.flagRemove) == 0:
 		c.UI.Error("Must supply data or use -force")
 		return 1
 	}
 
+	switch c.flagMethod {
+	case "patch", "rw", "auto":
+	default:
+		c.UI.Error(fmt.Sprintf("Unsupported method provided to -method flag: %s", c.flagMethod))
+		return 1
+	}
+
 	// Pull our fake stdin if needed
 	stdin := (io.Reader)(os.Stdin)
 	if c.testStdin != nil {
@@ -127,12 +170,74 @@ func (c *PatchCommand) Run(args []string) int {
 		return 1
 	}
 
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	for _, key := range c.flagRemove {
+		// A null in a JSON merge patch payload will remove the associated key
+		data[key] = nil
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagMethod == "rw" {
+		return c.readThenWrite(client, path, data)
+	}
+
 	secret, err := client.Logical().JSONMergePatch(context.Background(), path, data)
+	if re, ok := err.(*api.ResponseError); ok && re.StatusCode == http.StatusMethodNotAllowed && c.flagMethod == "auto" {
+		return c.readThenWrite(client, path, data)
+	}
+	return handleWriteSecretOutput(c.BaseCommand, path, secret, err)
+}
+
+// readThenWrite patches the data at the path by reading it, merging the patch
+// into it and writing it back.
+func (c *PatchCommand) readThenWrite(client *api.Client, path string, patch map[string]interface{}) int {
+	// Note that we don't want to see curl output for the read request.
+	curOutputCurl := client.OutputCurlString()
+	client.SetOutputCurlString(false)
+	outputPolicy := client.OutputPolicy()
+	client.SetOutputPolicy(false)
+	secret, err := client.Logical().Read(path)
+	client.SetOutputCurlString(curOutputCurl)
+	client.SetOutputPolicy(outputPolicy)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error doing pre-read at %s: %s", path, err))
+		return 2
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error(fmt.Sprintf("No value found at %s; patch only works on existing data", path))
+		return 2
+	}
+
+	secret, err = client.Logical().Write(path, jsonMergePatch(secret.Data, patch))
 	return handleWriteSecretOutput(c.BaseCommand, path, secret, err)
 }
+
+// jsonMergePatch applies the patch to the target as a JSON merge patch, as
+// defined by RFC 7386: objects are merged recursively, nulls remove fields
+// and any other value replaces the field. The target is modified in place.
+func jsonMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(target, k)
+		case map[string]interface{}:
+			existing, _ := target[k].(map[string]interface{})
+			target[k] = jsonMergePatch(existing, v)
+		default:
+			target[k] = v
+		}
+	}
+
+	return target
+}
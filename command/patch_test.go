@@ -5,6 +5,7 @@ package command
 
 import (
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -173,6 +174,62 @@ func TestPatchCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("methods", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		// The KV v1 secrets engine does not support HTTP PATCH
+		if _, err := client.Logical().Write("secret/patch/foo", map[string]interface{}{
+			"foo": "bar",
+			"baz": "qux",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testPatchCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"secret/patch/foo", "foo=updated"}); code != 2 {
+			t.Fatalf("expected the patch to fail, got %d: %s", code, ui.OutputWriter.String())
+		}
+
+		for _, args := range [][]string{
+			{"-method=rw", "secret/patch/foo", "foo=updated"},
+			{"-method=auto", "-remove=baz", "secret/patch/foo", "new=value"},
+		} {
+			ui, cmd := testPatchCommand(t)
+			cmd.client = client
+			if code := cmd.Run(args); code != 0 {
+				t.Fatalf("%v: expected %d to be 0: %s", args, code, ui.ErrorWriter.String())
+			}
+		}
+
+		secret, err := client.Logical().Read("secret/patch/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]interface{}{"foo": "updated", "new": "value"}
+		if !reflect.DeepEqual(secret.Data, expected) {
+			t.Errorf("expected %v, got %v", expected, secret.Data)
+		}
+
+		ui, cmd = testPatchCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"-method=rw", "secret/patch/missing", "foo=bar"}); code != 2 {
+			t.Errorf("expected %d to be 2", code)
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), "No value found at secret/patch/missing") {
+			t.Errorf("unexpected error: %s", ui.ErrorWriter.String())
+		}
+
+		ui, cmd = testPatchCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"-method=put", "secret/patch/foo", "foo=bar"}); code != 1 {
+			t.Errorf("expected %d to be 1", code)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -203,3 +260,28 @@ func TestPatchCommand_Run(t *testing.T) {
 		assertNoTabs(t, cmd)
 	})
 }
+
+func TestJSONMergePatch(t *testing.T) {
+	t.Parallel()
+
+	target := map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": "e", "f": "g"},
+		"h": []interface{}{"i"},
+	}
+	patch := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"f": nil, "x": "y"},
+		"h": nil,
+		"n": map[string]interface{}{"o": nil, "p": "q"},
+	}
+	expected := map[string]interface{}{
+		"a": "z",
+		"c": map[string]interface{}{"d": "e", "x": "y"},
+		"n": map[string]interface{}{"p": "q"},
+	}
+
+	if merged := jsonMergePatch(target, patch); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
@@ -20,13 +20,14 @@ var (
 type PluginRegisterCommand struct {
 	*BaseCommand
 
-	flagArgs     []string
-	flagCommand  string
-	flagSHA256   string
-	flagVersion  string
-	flagOCIImage string
-	flagRuntime  string
-	flagEnv      []string
+	flagArgs        []string
+	flagCommand     string
+	flagSHA256      string
+	flagVersion     string
+	flagOCIImage    string
+	flagOCIArtifact string
+	flagRuntime     string
+	flagEnv         []string
 }
 
 func (c *PluginRegisterCommand) Synopsis() string {
@@ -53,6 +54,13 @@ Usage: vault plugin register [options] TYPE NAME
           -args=--with-glibc,--with-cgo \
           auth my-custom-plugin
 
+  Pull a signed plugin from an OCI registry and register it:
+
+      $ vault plugin register \
+          -oci_artifact=registry.example.com/vault/my-custom-plugin:v1.0.0 \
+          -version=v1.0.0 \
+          auth my-custom-plugin
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -83,7 +91,8 @@ func (c *PluginRegisterCommand) Flags() *FlagSets {
 		Name:       "sha256",
 		Target:     &c.flagSHA256,
 		Completion: complete.PredictAnything,
-		Usage:      "SHA256 of the plugin binary or the oci_image provided. This is required for all plugins.",
+		Usage: "SHA256 of the plugin binary or the oci_image provided. This is required for all plugins " +
+			"except those pulled with oci_artifact.",
 	})
 
 	f.StringVar(&StringVar{
@@ -101,6 +110,15 @@ func (c *PluginRegisterCommand) Flags() *FlagSets {
 			"container's entrypoint, args, and environment variables (append-only) respectively.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "oci_artifact",
+		Target:     &c.flagOCIArtifact,
+		Completion: complete.PredictAnything,
+		Usage: "OCI artifact holding the plugin binary, such as registry.example.com/vault/my-plugin:v1.0.0. " +
+			"Vault pulls it into the plugin directory after verifying its signature against the plugin " +
+			"trust roots, and registers its SHA256.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "runtime",
 		Target:     &c.flagRuntime,
@@ -144,7 +162,7 @@ func (c *PluginRegisterCommand) Run(args []string) int {
 	case len(args) > 2:
 		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1 or 2, got %d)", len(args)))
 		return 1
-	case c.flagSHA256 == "":
+	case c.flagSHA256 == "" && c.flagOCIArtifact == "":
 		c.UI.Error("SHA256 is required for all plugins, please provide -sha256")
 		return 1
 
@@ -176,15 +194,16 @@ func (c *PluginRegisterCommand) Run(args []string) int {
 	}
 
 	if err := client.Sys().RegisterPlugin(&api.RegisterPluginInput{
-		Name:     pluginName,
-		Type:     pluginType,
-		Args:     c.flagArgs,
-		Command:  command,
-		SHA256:   c.flagSHA256,
-		Version:  c.flagVersion,
-		OCIImage: c.flagOCIImage,
-		Runtime:  c.flagRuntime,
-		Env:      c.flagEnv,
+		Name:        pluginName,
+		Type:        pluginType,
+		Args:        c.flagArgs,
+		Command:     command,
+		SHA256:      c.flagSHA256,
+		Version:     c.flagVersion,
+		OCIImage:    c.flagOCIImage,
+		OCIArtifact: c.flagOCIArtifact,
+		Runtime:     c.flagRuntime,
+		Env:         c.flagEnv,
 	}); err != nil {
 		c.UI.Error(fmt.Sprintf("Error registering plugin %s: %s", pluginName, err))
 		return 2
@@ -125,12 +125,14 @@ func (c *PluginRuntimeInfoCommand) Run(args []string) int {
 	}
 
 	data := map[string]interface{}{
-		"name":          resp.Name,
-		"type":          resp.Type,
-		"oci_runtime":   resp.OCIRuntime,
-		"cgroup_parent": resp.CgroupParent,
-		"cpu_nanos":     resp.CPU,
-		"memory_bytes":  resp.Memory,
+		"name":            resp.Name,
+		"type":            resp.Type,
+		"oci_runtime":     resp.OCIRuntime,
+		"cgroup_parent":   resp.CgroupParent,
+		"cpu_nanos":       resp.CPU,
+		"memory_bytes":    resp.Memory,
+		"disable_network": resp.DisableNetwork,
+		"networks":        resp.Networks,
 	}
 
 	if c.flagField != "" {
@@ -21,11 +21,13 @@ var (
 type PluginRuntimeRegisterCommand struct {
 	*BaseCommand
 
-	flagType         string
-	flagOCIRuntime   string
-	flagCgroupParent string
-	flagCPUNanos     int64
-	flagMemoryBytes  int64
+	flagType           string
+	flagOCIRuntime     string
+	flagCgroupParent   string
+	flagCPUNanos       int64
+	flagMemoryBytes    int64
+	flagDisableNetwork bool
+	flagNetworks       []string
 }
 
 func (c *PluginRuntimeRegisterCommand) Synopsis() string {
@@ -88,6 +90,21 @@ func (c *PluginRuntimeRegisterCommand) Flags() *FlagSets {
 		Usage:      "Memory limit to set per container in bytes. Defaults to no limit.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "disable_network",
+		Target:  &c.flagDisableNetwork,
+		Default: false,
+		Usage:   "Run each container without a network stack. Cannot be combined with -networks.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "networks",
+		Target:     &c.flagNetworks,
+		Completion: complete.PredictAnything,
+		Usage: "Comma-separated list of container networks to attach each container to " +
+			"instead of the default network. This can be specified multiple times.",
+	})
+
 	return set
 }
 
@@ -145,12 +162,14 @@ func (c *PluginRuntimeRegisterCommand) Run(args []string) int {
 	cgroupParent := strings.TrimSpace(c.flagCgroupParent)
 
 	if err := client.Sys().RegisterPluginRuntime(context.Background(), &api.RegisterPluginRuntimeInput{
-		Name:         runtimeName,
-		Type:         runtimeType,
-		OCIRuntime:   ociRuntime,
-		CgroupParent: cgroupParent,
-		CPU:          c.flagCPUNanos,
-		Memory:       c.flagMemoryBytes,
+		Name:           runtimeName,
+		Type:           runtimeType,
+		OCIRuntime:     ociRuntime,
+		CgroupParent:   cgroupParent,
+		CPU:            c.flagCPUNanos,
+		Memory:         c.flagMemoryBytes,
+		DisableNetwork: c.flagDisableNetwork,
+		Networks:       c.flagNetworks,
 	}); err != nil {
 		c.UI.Error(fmt.Sprintf("Error registering plugin runtime %s: %s", runtimeName, err))
 		return 2
@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PolicyValidateCommand)(nil)
+	_ cli.CommandAutocomplete = (*PolicyValidateCommand)(nil)
+)
+
+type PolicyValidateCommand struct {
+	*BaseCommand
+
+	flagPaths  []string
+	flagStrict bool
+
+	testStdin io.Reader // for tests
+}
+
+func (c *PolicyValidateCommand) Synopsis() string {
+	return "Validates a policy on disk"
+}
+
+func (c *PolicyValidateCommand) Help() string {
+	helpText := `
+Usage: vault policy validate [options] PATH
+
+  Validates a local policy file without uploading it. The policy is parsed like
+  Vault parses it on upload, so syntax errors and unknown capabilities are
+  reported. Suspicious rules, such as globs in the middle of a path or sudo
+  granted on globs, are reported as warnings. If PATH is "-", the policy is
+  read from stdin.
+
+  Validate the local file "my-policy.hcl":
+
+      $ vault policy validate my-policy.hcl
+
+  Show the capabilities the policy grants on sample paths:
+
+      $ vault policy validate -path=secret/data/app -path=sys/mounts my-policy.hcl
+
+  This command does not contact the Vault server.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PolicyValidateCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "path",
+		Target: &c.flagPaths,
+		Usage: `Sample request path to simulate against the policy, printing
+		the capabilities the policy grants on it. This can be specified
+		multiple times.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "strict",
+		Target:  &c.flagStrict,
+		Default: false,
+		Usage:   `Exit with an error when warnings are reported.`,
+	})
+
+	return set
+}
+
+func (c *PolicyValidateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.hcl")
+}
+
+func (c *PolicyValidateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PolicyValidateCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	// Get the policy contents, either from stdin of a file
+	var reader io.Reader
+	path := strings.TrimSpace(args[0])
+	if path == "-" {
+		reader = os.Stdin
+		if c.testStdin != nil {
+			reader = c.testStdin
+		}
+	} else {
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to expand path: %s", err))
+			return 1
+		}
+		file, err := os.Open(expanded)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error opening policy file: %s", err))
+			return 1
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading policy: %s", err))
+		return 1
+	}
+
+	// We always use the root namespace here, since the policy is not bound to
+	// a namespace until it is uploaded.
+	policy, err := vault.ParseACLPolicy(namespace.RootNamespace, buf.String())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Invalid policy: %s", err))
+		return 1
+	}
+
+	warnings := lintACLPolicy(policy)
+	if len(c.flagPaths) > 0 && policy.Templated {
+		warnings = append(warnings, "The policy is templated: templated paths are simulated literally, without identity information")
+	}
+
+	if len(c.flagPaths) > 0 {
+		ctx := namespace.RootContext(nil)
+		acl, err := vault.NewACL(ctx, []*vault.Policy{policy})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error simulating policy: %s", err))
+			return 1
+		}
+
+		rows := []string{"Path | Capabilities"}
+		for _, p := range c.flagPaths {
+			p = strings.TrimPrefix(strings.TrimSpace(p), "/")
+			rows = append(rows, fmt.Sprintf("%s | %s", p, strings.Join(acl.Capabilities(ctx, p), ", ")))
+		}
+		c.UI.Output(tableOutput(rows, nil))
+		c.UI.Output("")
+	}
+
+	for _, warning := range warnings {
+		c.UI.Warn(fmt.Sprintf("WARNING! %s", warning))
+	}
+	if len(warnings) > 0 && c.flagStrict {
+		c.UI.Error(fmt.Sprintf("Policy has %d warning(s)", len(warnings)))
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Policy is valid: %s", path))
+	return 0
+}
+
+// lintACLPolicy returns warnings about the rules of the policy which parse
+// but are likely mistakes.
+func lintACLPolicy(policy *vault.Policy) []string {
+	var warnings []string
+
+	seen := make(map[string]bool, len(policy.Paths))
+	for _, pr := range policy.Paths {
+		// Recover the path as it was written
+		path := pr.Path
+		if pr.IsPrefix {
+			path += "*"
+		}
+
+		if seen[path] {
+			warnings = append(warnings, fmt.Sprintf("Path %q is defined more than once; its capabilities are merged", path))
+		}
+		seen[path] = true
+
+		if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+			warnings = append(warnings, fmt.Sprintf("Path %q uses '*' before its end, where it only matches a literal '*'; use '+' to match a path segment", path))
+		}
+
+		if path == "*" || path == "+" || strings.HasPrefix(path, "+/*") {
+			warnings = append(warnings, fmt.Sprintf("Path %q matches every path", path))
+		}
+
+		if len(pr.Capabilities) == 0 {
+			warnings = append(warnings, fmt.Sprintf("Path %q has no capabilities and grants nothing", path))
+		}
+
+		for _, capability := range pr.Capabilities {
+			if capability == vault.SudoCapability && (pr.IsPrefix || pr.HasSegmentWildcards) {
+				warnings = append(warnings, fmt.Sprintf("Path %q grants sudo on a glob, which gives root-protected access to every matching path", path))
+			}
+		}
+	}
+
+	return warnings
+}
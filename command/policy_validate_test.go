@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testPolicyValidateCommand(tb testing.TB) (*cli.MockUi, *PolicyValidateCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PolicyValidateCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPolicyValidateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		args   []string
+		policy string
+		out    []string
+		code   int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"",
+			[]string{"Not enough arguments"},
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"",
+			[]string{"Too many arguments"},
+			1,
+		},
+		{
+			"invalid_syntax",
+			[]string{"-"},
+			`path "secret/*" { capabilities = ["read"]`,
+			[]string{"Invalid policy"},
+			1,
+		},
+		{
+			"unknown_capability",
+			[]string{"-"},
+			`path "secret/*" { capabilities = ["raed"] }`,
+			[]string{`invalid capability "raed"`},
+			1,
+		},
+		{
+			"valid",
+			[]string{"-"},
+			`path "secret/data/+/config" { capabilities = ["read"] }`,
+			[]string{"Success! Policy is valid"},
+			0,
+		},
+		{
+			"suspicious_globs",
+			[]string{"-"},
+			`
+path "secret/*/config" { capabilities = ["read"] }
+path "sys/*" { capabilities = ["read", "sudo"] }
+path "*" { capabilities = [] }
+path "*" { capabilities = ["list"] }
+`,
+			[]string{
+				`Path "secret/*/config" uses '*' before its end`,
+				`Path "sys/*" grants sudo on a glob`,
+				`Path "*" matches every path`,
+				`Path "*" has no capabilities`,
+				`Path "*" is defined more than once`,
+				"Success! Policy is valid",
+			},
+			0,
+		},
+		{
+			"strict",
+			[]string{"-strict", "-"},
+			`path "secret/*/config" { capabilities = ["read"] }`,
+			[]string{"Policy has 1 warning(s)"},
+			2,
+		},
+		{
+			"simulation",
+			[]string{"-path=secret/data/app", "-path=/secret/metadata/app", "-path=sys/mounts", "-"},
+			`
+path "secret/data/*" { capabilities = ["read", "update"] }
+path "secret/metadata/+" { capabilities = ["list"] }
+`,
+			[]string{
+				"secret/data/app        read, update",
+				"secret/metadata/app    list",
+				"sys/mounts             deny",
+			},
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testPolicyValidateCommand(t)
+			cmd.testStdin = strings.NewReader(tc.policy)
+
+			code := cmd.Run(tc.args)
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, combined)
+			}
+			for _, out := range tc.out {
+				if !strings.Contains(combined, out) {
+					t.Errorf("expected %q to contain %q", combined, out)
+				}
+			}
+		})
+	}
+}
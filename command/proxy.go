@@ -267,6 +267,7 @@ func (c *ProxyCommand) Run(args []string) int {
 	inmemMetrics, _, prometheusEnabled, err := configutil.SetupTelemetry(&configutil.SetupTelemetryOpts{
 		Config:      config.Telemetry,
 		Ui:          c.UI,
+		Logger:      c.logger,
 		ServiceName: "vault",
 		DisplayName: "Vault",
 		UserAgent:   useragent.ProxyString(),
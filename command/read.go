@@ -49,7 +49,7 @@ Usage: vault read [options] PATH
 }
 
 func (c *ReadCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat | FlagSetOutputSelect)
 }
 
 func (c *ReadCommand) AutocompleteArgs() complete.Predictor {
@@ -75,6 +75,11 @@ func (c *ReadCommand) Run(args []string) int {
 		return 1
 	}
 
+	if err := c.validateOutputSelection(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -114,6 +119,14 @@ func (c *ReadCommand) Run(args []string) int {
 			return PrintRawField(c.UI, secret, c.flagField)
 		}
 
+		if c.flagJSONPath != "" {
+			return PrintJSONPath(c.UI, secret, c.flagJSONPath)
+		}
+
+		if columns := parseColumns(c.flagColumns); len(columns) > 0 {
+			return OutputColumns(c.UI, []map[string]interface{}{secret.Data}, columns)
+		}
+
 		return OutputSecret(c.UI, secret)
 	}
 
@@ -72,6 +72,43 @@ func TestReadCommand_Run(t *testing.T) {
 			"not present in secret",
 			1,
 		},
+		{
+			"jsonpath",
+			[]string{
+				"-jsonpath", "$.data.foo",
+				"secret/read/foo",
+			},
+			"bar",
+			0,
+		},
+		{
+			"jsonpath_not_found",
+			[]string{
+				"-jsonpath", "$.data.not-a-real-field",
+				"secret/read/foo",
+			},
+			"No value found at JSONPath",
+			1,
+		},
+		{
+			"columns",
+			[]string{
+				"-columns", "foo,not-a-real-field",
+				"secret/read/foo",
+			},
+			"bar    n/a",
+			0,
+		},
+		{
+			"columns_and_jsonpath",
+			[]string{
+				"-columns", "foo",
+				"-jsonpath", "$.data.foo",
+				"secret/read/foo",
+			},
+			"cannot be used together",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -70,6 +70,7 @@ import (
 	"github.com/sasha-s/go-deadlock"
 	"go.uber.org/atomic"
 	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/http2"
 	"google.golang.org/grpc/grpclog"
 )
 
@@ -119,6 +120,8 @@ type ServerCommand struct {
 
 	reloadFuncsLock   *sync.RWMutex
 	reloadFuncs       *map[string][]reloadutil.ReloadFunc
+	listenersLock     sync.Mutex
+	listeners         []*serverListener
 	startedCh         chan (struct{}) // for tests
 	reloadedCh        chan (struct{}) // for tests
 	licenseReloadedCh chan (error)    // for tests
@@ -896,6 +899,16 @@ func (c *ServerCommand) InitListeners(config *server.Config, disableClustering b
 			(*c.reloadFuncs)["listener|"+lnConfig.Type] = relSlice
 		}
 
+		c.listenersLock.Lock()
+		c.listeners = append(c.listeners, &serverListener{
+			Listener: listenerutil.Listener{
+				Listener: ln,
+				Config:   lnConfig,
+			},
+			reloadFunc: reloadFunc,
+		})
+		c.listenersLock.Unlock()
+
 		if !disableClustering && lnConfig.Type == "tcp" {
 			addr := lnConfig.ClusterAddress
 			if addr != "" {
@@ -921,14 +934,8 @@ func (c *ServerCommand) InitListeners(config *server.Config, disableClustering b
 			props["cluster address"] = addr
 		}
 
-		if lnConfig.MaxRequestSize == 0 {
-			lnConfig.MaxRequestSize = vaulthttp.DefaultMaxRequestSize
-		}
+		setListenerDefaults(lnConfig)
 		props["max_request_size"] = fmt.Sprintf("%d", lnConfig.MaxRequestSize)
-
-		if lnConfig.MaxRequestDuration == 0 {
-			lnConfig.MaxRequestDuration = vault.DefaultMaxRequestDuration
-		}
 		props["max_request_duration"] = lnConfig.MaxRequestDuration.String()
 
 		if lnConfig.ChrootNamespace != "" {
@@ -1201,6 +1208,7 @@ func (c *ServerCommand) Run(args []string) int {
 	inmemMetrics, metricSink, prometheusEnabled, err := configutil.SetupTelemetry(&configutil.SetupTelemetryOpts{
 		Config:      config.Telemetry,
 		Ui:          c.UI,
+		Logger:      c.logger,
 		ServiceName: "vault",
 		DisplayName: "Vault",
 		UserAgent:   useragent.String(),
@@ -1212,6 +1220,27 @@ func (c *ServerCommand) Run(args []string) int {
 	}
 	metricsHelper := metricsutil.NewMetricsHelper(inmemMetrics, prometheusEnabled)
 
+	shutdownTracing, err := configutil.SetupTracing(&configutil.SetupTracingOpts{
+		Config:         config.Telemetry,
+		Logger:         c.logger,
+		ServiceName:    "vault",
+		ServiceVersion: version.GetVersion().VersionNumber(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing tracing: %s", err))
+		return 1
+	}
+	if shutdownTracing != nil {
+		defer func() {
+			// Export the spans of the last requests before exiting
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				c.logger.Warn("failed to export the remaining spans", "error", err)
+			}
+		}()
+	}
+
 	// Initialize the storage backend
 	var backend physical.Backend
 	if !c.flagDev || config.Storage != nil {
@@ -1458,12 +1487,9 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Make sure we close all listeners from this point on
-	listenerCloseFunc := func() {
-		for _, ln := range lns {
-			ln.Listener.Close()
-		}
-	}
+	// Make sure we close all listeners from this point on, including those
+	// added on reload
+	listenerCloseFunc := c.closeListeners
 
 	defer c.cleanupGuard.Do(listenerCloseFunc)
 
@@ -1714,6 +1740,11 @@ func (c *ServerCommand) Run(args []string) int {
 
 			core.SetConfig(config)
 
+			// Add, remove and reconfigure listeners
+			if err := c.reloadListeners(core, config); err != nil {
+				c.logger.Error("error(s) were encountered reconfiguring listeners", "error", err)
+			}
+
 			// reloading custom response headers to make sure we have
 			// the most up to date headers after reloading the config file
 			if err = core.ReloadCustomResponseHeaders(); err != nil {
@@ -2209,6 +2240,7 @@ func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info m
 	}
 
 	// Set the token
+	c.SetAddress(testCluster.Cores[0].Client.Address())
 	tokenHelper, err := c.TokenHelper()
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error getting token helper: %s", err))
@@ -3043,6 +3075,7 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		Logger:                         c.logger,
 		DetectDeadlocks:                config.DetectDeadlocks,
 		ImpreciseLeaseRoleTracking:     config.ImpreciseLeaseRoleTracking,
+		LazyIdentityLoading:            config.LazyIdentityLoading,
 		DisableSentinelTrace:           config.DisableSentinelTrace,
 		DisableCache:                   config.DisableCache,
 		DisableMlock:                   config.DisableMlock,
@@ -3093,6 +3126,12 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 			}
 		}
 	}
+
+	// Journal writes while 'vault operator migrate -online' copies the
+	// storage. Raft cannot be the source of an online migration.
+	if !c.flagDev && config.Storage.Type != storageTypeRaft {
+		coreConfig.Physical = newOnlineMigrationBackend(coreConfig.Physical, c.logger.Named("storage.migration"))
+	}
 	return *coreConfig
 }
 
@@ -3107,6 +3146,14 @@ func runListeners(c *ServerCommand, coreConfig *vault.CoreConfig, config *server
 
 func initDevCore(c *ServerCommand, coreConfig *vault.CoreConfig, config *server.Config, core *vault.Core, certDir string, clusterJSON *testcluster.ClusterJson) error {
 	if c.flagDev && !c.flagDevSkipInit {
+		protocol := "http://"
+		if c.flagDevTLS {
+			protocol = "https://"
+		}
+		endpointURL := protocol + config.Listeners[0].Address
+
+		// Store the root token for the address of the dev server
+		c.SetAddress(endpointURL)
 
 		init, err := c.enableDev(core, coreConfig)
 		if err != nil {
@@ -3163,12 +3210,6 @@ func initDevCore(c *ServerCommand, coreConfig *vault.CoreConfig, config *server.
 					c.UI.Warn("You may need to set the following environment variables:")
 					c.UI.Warn("")
 
-					protocol := "http://"
-					if c.flagDevTLS {
-						protocol = "https://"
-					}
-
-					endpointURL := protocol + config.Listeners[0].Address
 					if runtime.GOOS == "windows" {
 						c.UI.Warn("PowerShell:")
 						c.UI.Warn(fmt.Sprintf("    $env:VAULT_ADDR=\"%s\"", endpointURL))
@@ -3248,54 +3289,80 @@ func startHttpServers(c *ServerCommand, core *vault.Core, config *server.Config,
 			return fmt.Errorf("Found nil listener config after parsing")
 		}
 
-		if err := config2.IsValidListener(ln.Config); err != nil {
+		server, err := newHttpServer(c, core, config, ln.Config)
+		if err != nil {
 			return err
 		}
 
-		handler := vaulthttp.Handler.Handler(&vault.HandlerProperties{
-			Core:                  core,
-			ListenerConfig:        ln.Config,
-			DisablePrintableCheck: config.DisablePrintableCheck,
-			RecoveryMode:          c.flagRecovery,
-		})
-
-		if len(ln.Config.XForwardedForAuthorizedAddrs) > 0 {
-			handler = vaulthttp.WrapForwardedForHandler(handler, ln.Config)
-		}
-
-		// server defaults
-		server := &http.Server{
-			Handler:           handler,
-			ReadHeaderTimeout: 10 * time.Second,
-			ReadTimeout:       30 * time.Second,
-			IdleTimeout:       5 * time.Minute,
-			ErrorLog:          c.logger.StandardLogger(nil),
-		}
-
-		// override server defaults with config values for read/write/idle timeouts if configured
-		if ln.Config.HTTPReadHeaderTimeout > 0 {
-			server.ReadHeaderTimeout = ln.Config.HTTPReadHeaderTimeout
-		}
-		if ln.Config.HTTPReadTimeout > 0 {
-			server.ReadTimeout = ln.Config.HTTPReadTimeout
-		}
-		if ln.Config.HTTPWriteTimeout > 0 {
-			server.WriteTimeout = ln.Config.HTTPWriteTimeout
-		}
-		if ln.Config.HTTPIdleTimeout > 0 {
-			server.IdleTimeout = ln.Config.HTTPIdleTimeout
-		}
-
 		// server config tests can exit now
 		if c.flagTestServerConfig {
 			continue
 		}
 
+		c.setListenerServer(ln.Listener, server)
 		go server.Serve(ln.Listener)
 	}
 	return nil
 }
 
+// newHttpServer returns the HTTP server of a listener.
+func newHttpServer(c *ServerCommand, core *vault.Core, config *server.Config, lnConfig *configutil.Listener) (*http.Server, error) {
+	if err := config2.IsValidListener(lnConfig); err != nil {
+		return nil, err
+	}
+
+	handler := vaulthttp.Handler.Handler(&vault.HandlerProperties{
+		Core:                  core,
+		ListenerConfig:        lnConfig,
+		DisablePrintableCheck: config.DisablePrintableCheck,
+		RecoveryMode:          c.flagRecovery,
+	})
+
+	if len(lnConfig.XForwardedForAuthorizedAddrs) > 0 {
+		handler = vaulthttp.WrapForwardedForHandler(handler, lnConfig)
+	}
+
+	if lnConfig.ConnectionRateLimit > 0 {
+		handler = vaulthttp.WrapConnectionRateLimitHandler(handler)
+	}
+
+	// server defaults
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       5 * time.Minute,
+		ErrorLog:          c.logger.StandardLogger(nil),
+		ConnContext:       vaulthttp.ConnectionRateLimitConnContext(lnConfig),
+	}
+
+	// override server defaults with config values for read/write/idle timeouts if configured
+	if lnConfig.HTTPReadHeaderTimeout > 0 {
+		server.ReadHeaderTimeout = lnConfig.HTTPReadHeaderTimeout
+	}
+	if lnConfig.HTTPReadTimeout > 0 {
+		server.ReadTimeout = lnConfig.HTTPReadTimeout
+	}
+	if lnConfig.HTTPWriteTimeout > 0 {
+		server.WriteTimeout = lnConfig.HTTPWriteTimeout
+	}
+	if lnConfig.HTTPIdleTimeout > 0 {
+		server.IdleTimeout = lnConfig.HTTPIdleTimeout
+	}
+
+	// HTTP/2 is configured last, as it picks up the timeouts of the server
+	if lnConfig.HTTP2MaxConcurrentStreams > 0 {
+		err := http2.ConfigureServer(server, &http2.Server{
+			MaxConcurrentStreams: uint32(lnConfig.HTTP2MaxConcurrentStreams),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error configuring HTTP/2: %w", err)
+		}
+	}
+
+	return server, nil
+}
+
 func SetStorageMigration(b physical.Backend, active bool) error {
 	if !active {
 		return b.Delete(context.Background(), storageMigrationLock)
@@ -32,6 +32,9 @@ const (
 	VaultDevCAFilename   = "vault-ca.pem"
 	VaultDevCertFilename = "vault-cert.pem"
 	VaultDevKeyFilename  = "vault-key.pem"
+
+	// EnvVaultEnableSealHABeta allows configuring more than one enabled seal.
+	EnvVaultEnableSealHABeta = "VAULT_ENABLE_SEAL_HA_BETA"
 )
 
 var (
@@ -114,6 +117,8 @@ type Config struct {
 
 	ImpreciseLeaseRoleTracking bool `hcl:"imprecise_lease_role_tracking"`
 
+	LazyIdentityLoading bool `hcl:"lazy_identity_loading"`
+
 	EnableResponseHeaderRaftNodeID    bool        `hcl:"-"`
 	EnableResponseHeaderRaftNodeIDRaw interface{} `hcl:"enable_response_header_raft_node_id"`
 
@@ -419,6 +424,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ImpreciseLeaseRoleTracking = c2.ImpreciseLeaseRoleTracking
 	}
 
+	result.LazyIdentityLoading = c.LazyIdentityLoading
+	if c2.LazyIdentityLoading {
+		result.LazyIdentityLoading = c2.LazyIdentityLoading
+	}
+
 	result.EnableResponseHeaderRaftNodeID = c.EnableResponseHeaderRaftNodeID
 	if c2.EnableResponseHeaderRaftNodeID {
 		result.EnableResponseHeaderRaftNodeID = c2.EnableResponseHeaderRaftNodeID
@@ -1153,6 +1163,8 @@ func (c *Config) Sanitized() map[string]interface{} {
 		"detect_deadlocks": c.DetectDeadlocks,
 
 		"imprecise_lease_role_tracking": c.ImpreciseLeaseRoleTracking,
+
+		"lazy_identity_loading": c.LazyIdentityLoading,
 	}
 	for k, v := range sharedResult {
 		result[k] = v
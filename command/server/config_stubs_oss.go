@@ -2,8 +2,25 @@
 
 package server
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
 //go:generate go run github.com/hashicorp/vault/tools/stubmaker
 
+// IsSealHABetaEnabled returns whether Seal High Availability is enabled, which
+// allows the root key material to be wrapped by more than one seal.
 func IsSealHABetaEnabled() (bool, error) {
-	return false, nil
+	v := os.Getenv(EnvVaultEnableSealHABeta)
+	if v == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("error parsing the environment variable %s: %w", EnvVaultEnableSealHABeta, err)
+	}
+	return enabled, nil
 }
@@ -5,6 +5,7 @@ package server
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -74,3 +75,80 @@ func TestRollbackMountPointMetricsConfig(t *testing.T) {
 		})
 	}
 }
+
+// TestNamespaceMountPointLabelsConfig verifies that the namespace and mount
+// point label options are parsed correctly, and are disabled by default.
+func TestNamespaceMountPointLabelsConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfigFile("./test-fixtures/telemetry/namespace_mount_point_labels.hcl")
+	require.NoError(t, err)
+	require.True(t, config.Telemetry.NamespaceLabels)
+	require.True(t, config.Telemetry.MountPointLabels)
+	require.Equal(t, []string{"root", "tenant-a"}, config.Telemetry.NamespaceLabelsAllowlist)
+	require.Equal(t, []string{"secret/", "auth/userpass/"}, config.Telemetry.MountPointLabelsAllowlist)
+
+	config, err = LoadConfigFile("./test-fixtures/telemetry/valid_prefix_filter.hcl")
+	require.NoError(t, err)
+	require.False(t, config.Telemetry.NamespaceLabels)
+	require.False(t, config.Telemetry.MountPointLabels)
+}
+
+// TestPrometheusHistogramBucketsConfig verifies that the Prometheus histogram
+// buckets and summary quantiles are parsed correctly.
+func TestPrometheusHistogramBucketsConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfigFile("./test-fixtures/telemetry/prometheus_histogram_buckets.hcl")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]float64{
+		"vault.core.handle_request": {0.1, 0.5, 1, 5, 10, 50},
+		"vault.raft-storage":        {0.01, 0.05, 0.1, 0.5, 1},
+	}, config.Telemetry.PrometheusHistogramBuckets)
+	require.Equal(t, []float64{0.5, 0.9, 0.99, 0.999}, config.Telemetry.PrometheusSummaryQuantiles)
+}
+
+// TestPrometheusRemoteWriteConfig verifies that the Prometheus remote write
+// options are parsed correctly.
+func TestPrometheusRemoteWriteConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfigFile("./test-fixtures/telemetry/prometheus_remote_write.hcl")
+	require.NoError(t, err)
+	require.Equal(t, "https://prometheus.example.com/api/v1/write", config.Telemetry.PrometheusRemoteWriteURL)
+	require.Equal(t, 15*time.Second, config.Telemetry.PrometheusRemoteWriteInterval)
+	require.Equal(t, map[string]string{"instance": "vault-1", "job": "vault"}, config.Telemetry.PrometheusRemoteWriteLabels)
+	require.Equal(t, "/etc/vault/prometheus-token", config.Telemetry.PrometheusRemoteWriteBearerTokenFile)
+	require.Equal(t, "/etc/vault/prometheus-ca.pem", config.Telemetry.PrometheusRemoteWriteTLSCACert)
+	require.Equal(t, "/etc/vault/prometheus-client.pem", config.Telemetry.PrometheusRemoteWriteTLSClientCert)
+	require.Equal(t, "/etc/vault/prometheus-client-key.pem", config.Telemetry.PrometheusRemoteWriteTLSClientKey)
+}
+
+// TestTracingConfig verifies that the tracing options are parsed correctly,
+// and that the sample ratio must be between 0 and 1.
+func TestTracingConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := LoadConfigFile("./test-fixtures/telemetry/tracing.hcl")
+	require.NoError(t, err)
+	require.Equal(t, "otel-collector.example.com:4317", config.Telemetry.TracingOTLPEndpoint)
+	require.Equal(t, "/etc/vault/otel-ca.pem", config.Telemetry.TracingOTLPTLSCACert)
+	require.Equal(t, map[string]string{"authorization": "Bearer otel-token"}, config.Telemetry.TracingOTLPHeaders)
+	require.Equal(t, 0.25, config.Telemetry.TracingSampleRatio)
+
+	for ratio, valid := range map[string]bool{
+		`1`:      true,
+		`0`:      true,
+		`"0.5"`:  true,
+		`1.5`:    false,
+		`-0.1`:   false,
+		`"half"`: false,
+	} {
+		_, err := ParseConfig(`telemetry { tracing_sample_ratio = `+ratio+` }`, "")
+		if valid {
+			require.NoError(t, err, ratio)
+		} else {
+			require.Error(t, err, ratio)
+		}
+	}
+}
@@ -86,6 +86,7 @@ func testLoadConfigFile_topLevel(t *testing.T, entropy *configutil.Entropy) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     30 * time.Second,
+				TracingSampleRatio:          configutil.TracingDefaultSampleRatio,
 				UsageGaugePeriod:            5 * time.Minute,
 				MaximumGaugeCardinality:     125,
 				LeaseMetricsEpsilon:         time.Hour,
@@ -219,6 +220,7 @@ func testLoadConfigFile_json2(t *testing.T, entropy *configutil.Entropy) {
 				CirconusBrokerID:                   "0",
 				CirconusBrokerSelectTag:            "dc:sfo",
 				PrometheusRetentionTime:            30 * time.Second,
+				TracingSampleRatio:                 configutil.TracingDefaultSampleRatio,
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
@@ -410,6 +412,7 @@ func testLoadConfigFile(t *testing.T) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				TracingSampleRatio:          configutil.TracingDefaultSampleRatio,
 				MetricsPrefix:               "myprefix",
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  168,
@@ -634,6 +637,7 @@ func testLoadConfigFile_json(t *testing.T) {
 				CirconusBrokerID:                   "",
 				CirconusBrokerSelectTag:            "",
 				PrometheusRetentionTime:            configutil.PrometheusDefaultRetentionTime,
+				TracingSampleRatio:                 configutil.TracingDefaultSampleRatio,
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
@@ -707,6 +711,7 @@ func testLoadConfigDir(t *testing.T) {
 				UsageGaugePeriod:            5 * time.Minute,
 				MaximumGaugeCardinality:     100,
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				TracingSampleRatio:          configutil.TracingDefaultSampleRatio,
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  168,
 				LeaseMetricsNameSpaceLabels: false,
@@ -837,6 +842,13 @@ func testConfig_Sanitized(t *testing.T) {
 			"dogstatsd_addr":                         "",
 			"dogstatsd_tags":                         []string(nil),
 			"prometheus_retention_time":              24 * time.Hour,
+			"prometheus_histogram_buckets":           map[string][]float64(nil),
+			"prometheus_summary_quantiles":           []float64(nil),
+			"prometheus_remote_write_url":            "",
+			"prometheus_remote_write_interval":       time.Duration(0),
+			"prometheus_remote_write_labels":         map[string]string(nil),
+			"tracing_otlp_endpoint":                  "",
+			"tracing_sample_ratio":                   1.0,
 			"stackdriver_location":                   "",
 			"stackdriver_namespace":                  "",
 			"stackdriver_project_id":                 "",
@@ -847,9 +859,14 @@ func testConfig_Sanitized(t *testing.T) {
 			"num_lease_metrics_buckets":              168,
 			"add_lease_metrics_namespace_labels":     false,
 			"add_mount_point_rollback_metrics":       false,
+			"add_namespace_labels":                   false,
+			"add_mount_point_labels":                 false,
+			"namespace_labels_allowlist":             []string(nil),
+			"mount_point_labels_allowlist":           []string(nil),
 		},
 		"administrative_namespace_path": "admin/",
 		"imprecise_lease_role_tracking": false,
+		"lazy_identity_loading":         false,
 	}
 
 	addExpectedEntSanitizedConfig(expected, []string{"http"})
@@ -1171,6 +1188,7 @@ func testLoadConfigFileLeaseMetrics(t *testing.T) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				TracingSampleRatio:          configutil.TracingDefaultSampleRatio,
 				MetricsPrefix:               "myprefix",
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  2,
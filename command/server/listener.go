@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/mitchellh/cli"
+	"golang.org/x/net/netutil"
 )
 
 // ListenerFactory is the factory function to create a listener.
@@ -56,3 +57,14 @@ func listenerWrapProxy(ln net.Listener, l *configutil.Listener) (net.Listener, e
 
 	return newLn, nil
 }
+
+// listenerWrapLimit limits the number of connections the listener accepts
+// at once to the configured maximum, if any. Connections beyond the limit
+// wait in the backlog of the socket until others are closed.
+func listenerWrapLimit(ln net.Listener, l *configutil.Listener) net.Listener {
+	if l.MaxConnections <= 0 {
+		return ln
+	}
+
+	return netutil.LimitListener(ln, int(l.MaxConnections))
+}
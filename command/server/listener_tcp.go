@@ -43,6 +43,8 @@ func tcpListenerFactory(l *configutil.Listener, _ io.Writer, ui cli.Ui) (net.Lis
 		return nil, nil, nil, err
 	}
 
+	ln = listenerWrapLimit(ln, l)
+
 	props := map[string]string{"addr": addr}
 
 	// X-Forwarded-For props
@@ -464,3 +464,54 @@ func TestTCPListener_proxyProtocol(t *testing.T) {
 		})
 	}
 }
+
+func TestTCPListener_maxConnections(t *testing.T) {
+	ln, _, _, err := tcpListenerFactory(&configutil.Listener{
+		Address:        "127.0.0.1:0",
+		TLSDisable:     true,
+		MaxConnections: 1,
+	}, nil, cli.NewMockUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	client1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client1.Close()
+	server1, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second connection isn't accepted until the first one is closed
+	client2, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client2.Close()
+
+	acceptCh := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			acceptCh <- conn
+		}
+	}()
+
+	select {
+	case <-acceptCh:
+		t.Fatal("expected the second connection not to be accepted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	server1.Close()
+	select {
+	case conn := <-acceptCh:
+		conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the second connection to be accepted")
+	}
+}
@@ -35,5 +35,7 @@ func unixListenerFactory(l *configutil.Listener, _ io.Writer, ui cli.Ui) (net.Li
 		return nil, nil, nil, err
 	}
 
+	ln = listenerWrapLimit(ln, l)
+
 	return ln, map[string]string{}, nil, nil
 }
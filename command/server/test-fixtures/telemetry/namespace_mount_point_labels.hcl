@@ -0,0 +1,12 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+disable_mlock = true
+ui            = true
+
+telemetry {
+  add_namespace_labels         = true
+  add_mount_point_labels       = true
+  namespace_labels_allowlist   = ["root", "tenant-a"]
+  mount_point_labels_allowlist = ["secret/", "auth/userpass/"]
+}
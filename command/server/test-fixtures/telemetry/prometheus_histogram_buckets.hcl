@@ -0,0 +1,17 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+disable_mlock = true
+ui            = true
+
+telemetry {
+  prometheus_retention_time = "30s"
+  disable_hostname          = true
+
+  prometheus_histogram_buckets {
+    "vault.core.handle_request" = [0.1, 0.5, 1, 5, 10, 50]
+    "vault.raft-storage"        = [0.01, 0.05, 0.1, 0.5, 1]
+  }
+
+  prometheus_summary_quantiles = [0.5, 0.9, 0.99, 0.999]
+}
@@ -0,0 +1,21 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+disable_mlock = true
+ui            = true
+
+telemetry {
+  disable_hostname = true
+
+  prometheus_remote_write_url               = "https://prometheus.example.com/api/v1/write"
+  prometheus_remote_write_interval          = "15s"
+  prometheus_remote_write_bearer_token_file = "/etc/vault/prometheus-token"
+  prometheus_remote_write_tls_ca_cert       = "/etc/vault/prometheus-ca.pem"
+  prometheus_remote_write_tls_client_cert   = "/etc/vault/prometheus-client.pem"
+  prometheus_remote_write_tls_client_key    = "/etc/vault/prometheus-client-key.pem"
+
+  prometheus_remote_write_labels {
+    instance = "vault-1"
+    job      = "vault"
+  }
+}
@@ -0,0 +1,15 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+disable_mlock = true
+ui            = true
+
+telemetry {
+  tracing_otlp_endpoint    = "otel-collector.example.com:4317"
+  tracing_otlp_tls_ca_cert = "/etc/vault/otel-ca.pem"
+  tracing_sample_ratio     = 0.25
+
+  tracing_otlp_headers {
+    authorization = "Bearer otel-token"
+  }
+}
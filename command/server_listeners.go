@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/reloadutil"
+	"github.com/hashicorp/vault/command/server"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/internalshared/listenerutil"
+	"github.com/hashicorp/vault/vault"
+)
+
+// listenerShutdownTimeout is how long the requests in flight on a listener
+// which is removed or reconfigured on reload are given to complete.
+var listenerShutdownTimeout = 30 * time.Second
+
+// serverListener is a listener of the server, along with the HTTP server
+// serving it and the function reloading its certificates.
+type serverListener struct {
+	listenerutil.Listener
+	server     *http.Server
+	reloadFunc reloadutil.ReloadFunc
+}
+
+// listenerID identifies a listener across reloads by its type and address.
+func listenerID(l *configutil.Listener) string {
+	addr := l.Address
+	if addr == "" && l.Type == "tcp" {
+		addr = "127.0.0.1:8200"
+	}
+	return l.Type + "|" + addr
+}
+
+// setListenerDefaults sets the defaults of the listener configuration which
+// are not set when parsing it.
+func setListenerDefaults(l *configutil.Listener) {
+	if l.MaxRequestSize == 0 {
+		l.MaxRequestSize = vaulthttp.DefaultMaxRequestSize
+	}
+	if l.MaxRequestDuration == 0 {
+		l.MaxRequestDuration = vault.DefaultMaxRequestDuration
+	}
+	if !l.TLSDisable {
+		if l.TLSMinVersion == "" {
+			l.TLSMinVersion = "tls12"
+		}
+		if l.TLSMaxVersion == "" {
+			l.TLSMaxVersion = "tls13"
+		}
+	}
+}
+
+// setListenerServer records the HTTP server serving the listener.
+func (c *ServerCommand) setListenerServer(ln net.Listener, server *http.Server) {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	for _, l := range c.listeners {
+		if l.Listener.Listener == ln {
+			l.server = server
+			return
+		}
+	}
+}
+
+// closeListeners closes all of the listeners of the server.
+func (c *ServerCommand) closeListeners() {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	for _, l := range c.listeners {
+		l.Listener.Listener.Close()
+	}
+}
+
+// startListener creates a listener from its configuration and serves it.
+func (c *ServerCommand) startListener(core *vault.Core, config *server.Config, lnConfig *configutil.Listener) (*serverListener, error) {
+	setListenerDefaults(lnConfig)
+
+	httpServer, err := newHttpServer(c, core, config, lnConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ln, _, reloadFunc, err := server.NewListener(lnConfig, c.logGate, c.UI)
+	if err != nil {
+		return nil, err
+	}
+
+	go httpServer.Serve(ln)
+	return &serverListener{
+		Listener: listenerutil.Listener{
+			Listener: ln,
+			Config:   lnConfig,
+		},
+		server:     httpServer,
+		reloadFunc: reloadFunc,
+	}, nil
+}
+
+// stopListener stops accepting connections on the listener, and gives the
+// requests in flight on it some time to complete.
+func (c *ServerCommand) stopListener(l *serverListener) {
+	l.Listener.Listener.Close()
+	if l.server == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), listenerShutdownTimeout)
+		defer cancel()
+		l.server.Shutdown(ctx)
+	}()
+}
+
+// reloadListeners reconciles the listeners of the server with the given
+// configuration: listeners which are no longer configured are stopped, new
+// ones are started, and those whose configuration changed are rebound with
+// the new configuration. Listeners are identified by their type and address.
+// A listener which fails to be rebound is restored with its previous
+// configuration.
+func (c *ServerCommand) reloadListeners(core *vault.Core, config *server.Config) error {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	current := make(map[string]*serverListener, len(c.listeners))
+	for _, l := range c.listeners {
+		current[listenerID(l.Config)] = l
+	}
+
+	var errs *multierror.Error
+	listeners := make([]*serverListener, 0, len(config.Listeners))
+	configured := make(map[string]struct{}, len(config.Listeners))
+	for _, lnConfig := range config.Listeners {
+		id := listenerID(lnConfig)
+		if _, ok := configured[id]; ok {
+			errs = multierror.Append(errs, fmt.Errorf("duplicate listener of type %s at %q", lnConfig.Type, lnConfig.Address))
+			continue
+		}
+		configured[id] = struct{}{}
+
+		setListenerDefaults(lnConfig)
+		existing, ok := current[id]
+		if ok && reflect.DeepEqual(existing.Config, lnConfig) {
+			listeners = append(listeners, existing)
+			continue
+		}
+
+		if ok {
+			// The listener has to be closed before it is rebound, so make
+			// sure its TLS configuration is valid first
+			if _, _, err := listenerutil.TLSConfig(lnConfig, map[string]string{}, c.UI); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("error reconfiguring listener of type %s at %q: %w", lnConfig.Type, lnConfig.Address, err))
+				listeners = append(listeners, existing)
+				continue
+			}
+			c.stopListener(existing)
+		}
+
+		l, err := c.startListener(core, config, lnConfig)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("error starting listener of type %s at %q: %w", lnConfig.Type, lnConfig.Address, err))
+			if ok {
+				l, err = c.startListener(core, config, existing.Config)
+				if err != nil {
+					errs = multierror.Append(errs, fmt.Errorf("error restoring listener of type %s at %q: %w", lnConfig.Type, lnConfig.Address, err))
+					continue
+				}
+				listeners = append(listeners, l)
+			}
+			continue
+		}
+
+		if ok {
+			c.logger.Info("reconfigured listener", "type", lnConfig.Type, "address", l.Listener.Listener.Addr())
+		} else {
+			c.logger.Info("added listener", "type", lnConfig.Type, "address", l.Listener.Listener.Addr())
+		}
+		listeners = append(listeners, l)
+	}
+
+	for id, l := range current {
+		if _, ok := configured[id]; ok {
+			continue
+		}
+		c.stopListener(l)
+		c.logger.Info("removed listener", "type", l.Config.Type, "address", l.Listener.Listener.Addr())
+	}
+	c.listeners = listeners
+
+	// Replace the certificate reload functions of the listeners
+	c.reloadFuncsLock.Lock()
+	for k := range *c.reloadFuncs {
+		if strings.HasPrefix(k, "listener|") {
+			delete(*c.reloadFuncs, k)
+		}
+	}
+	for _, l := range c.listeners {
+		if l.reloadFunc != nil {
+			(*c.reloadFuncs)["listener|"+l.Config.Type] = append((*c.reloadFuncs)["listener|"+l.Config.Type], l.reloadFunc)
+		}
+	}
+	c.reloadFuncsLock.Unlock()
+
+	return errs.ErrorOrNil()
+}
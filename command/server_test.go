@@ -15,7 +15,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -58,6 +61,15 @@ const (
 	badListenerWriteTimeout      = `http_write_timeout = "56lbs"`
 	badListenerIdleTimeout       = `http_idle_timeout = "78gophers"`
 
+	goodListenerConnectionLimits = `max_connections = 1000
+			http2_max_concurrent_streams = 100
+			connection_rate_limit = 50
+			connection_rate_limit_burst = 100`
+
+	badListenerMaxConnections = `max_connections = -1`
+	badListenerHTTP2Streams   = `http2_max_concurrent_streams = "many"`
+	badListenerRateLimit      = `connection_rate_limit = -5`
+
 	inmemHCL = `
 backend "inmem_ha" {
   advertise_addr       = "http://127.0.0.1:8200"
@@ -207,6 +219,117 @@ func TestServer_ReloadListener(t *testing.T) {
 	wg.Wait()
 }
 
+// TestServer_ReloadListenerConfig ensures listeners are added, removed and
+// reconfigured on reload, and that unchanged listeners are kept.
+func TestServer_ReloadListenerConfig(t *testing.T) {
+	t.Parallel()
+
+	freePort := func() int {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+		return ln.Addr().(*net.TCPAddr).Port
+	}
+	port1, port2 := freePort(), freePort()
+	ui, cmd := testServerCommand(t)
+
+	listenerHCL := func(port int, extras string) string {
+		return fmt.Sprintf(`
+listener "tcp" {
+  address     = "127.0.0.1:%d"
+  tls_disable = true
+  %s
+}
+`, port, extras)
+	}
+	configPath := filepath.Join(t.TempDir(), "reload.hcl")
+	writeConfig := func(listeners ...string) {
+		hcl := "backend \"inmem\" {}\ndisable_mlock = true\n" + strings.Join(listeners, "")
+		require.NoError(t, os.WriteFile(configPath, []byte(hcl), 0o600))
+	}
+	reload := func() {
+		cmd.SighupCh <- struct{}{}
+		select {
+		case <-cmd.reloadedCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	listeners := func() map[int]*serverListener {
+		cmd.listenersLock.Lock()
+		defer cmd.listenersLock.Unlock()
+		result := make(map[int]*serverListener)
+		for _, l := range cmd.listeners {
+			result[l.Listener.Listener.Addr().(*net.TCPAddr).Port] = l
+		}
+		return result
+	}
+	serving := func(port int) bool {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/v1/sys/health", port))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}
+
+	writeConfig(listenerHCL(port1, ""))
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if code := cmd.Run([]string{"-config", configPath}); code != 0 {
+			t.Errorf("got a non-zero exit status: %s", ui.ErrorWriter.String()+ui.OutputWriter.String())
+		}
+	}()
+	defer func() {
+		cmd.ShutdownCh <- struct{}{}
+		wg.Wait()
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	require.True(t, serving(port1))
+	initial := listeners()[port1]
+	require.NotNil(t, initial)
+
+	// An unchanged listener is kept
+	reload()
+	require.Same(t, initial, listeners()[port1])
+
+	// A listener is added and another one is reconfigured
+	writeConfig(listenerHCL(port1, "max_request_size = 1024"), listenerHCL(port2, ""))
+	reload()
+	current := listeners()
+	require.Len(t, current, 2)
+	require.NotSame(t, initial, current[port1])
+	require.Equal(t, int64(1024), current[port1].Config.MaxRequestSize)
+	require.True(t, serving(port1))
+	require.True(t, serving(port2))
+
+	// An invalid listener configuration keeps the previous listener
+	writeConfig(fmt.Sprintf(`
+listener "tcp" {
+  address       = "127.0.0.1:%d"
+  tls_cert_file = "/nonexistent/cert.pem"
+  tls_key_file  = "/nonexistent/key.pem"
+}
+`, port1), listenerHCL(port2, ""))
+	reload()
+	require.Same(t, current[port1], listeners()[port1])
+	require.True(t, serving(port1))
+
+	// A listener is removed
+	writeConfig(listenerHCL(port2, ""))
+	reload()
+	require.Len(t, listeners(), 1)
+	require.False(t, serving(port1))
+	require.True(t, serving(port2))
+}
+
 func TestServer(t *testing.T) {
 	t.Parallel()
 
@@ -273,6 +396,34 @@ func TestServer(t *testing.T) {
 			1,
 			[]string{"-test-server-config"},
 		},
+		{
+			"good_listener_connection_limits_config",
+			testBaseHCL(t, goodListenerConnectionLimits) + inmemHCL,
+			"",
+			0,
+			[]string{"-test-server-config"},
+		},
+		{
+			"bad_listener_max_connections_config",
+			testBaseHCL(t, badListenerMaxConnections) + inmemHCL,
+			"max_connections cannot be negative",
+			1,
+			[]string{"-test-server-config"},
+		},
+		{
+			"bad_listener_http2_max_concurrent_streams_config",
+			testBaseHCL(t, badListenerHTTP2Streams) + inmemHCL,
+			"error parsing http2_max_concurrent_streams",
+			1,
+			[]string{"-test-server-config"},
+		},
+		{
+			"bad_listener_connection_rate_limit_config",
+			testBaseHCL(t, badListenerRateLimit) + inmemHCL,
+			"connection_rate_limit cannot be negative",
+			1,
+			[]string{"-test-server-config"},
+		},
 		{
 			"environment_variables_logged",
 			testBaseHCL(t, "") + inmemHCL,
@@ -45,6 +45,11 @@ type SSHCommand struct {
 	flagHostKeyMountPoint string
 	flagHostKeyHostnames  string
 	flagValidPrincipals   string
+
+	// SSH certificate agent options
+	flagSignOnly   bool
+	flagProxy      bool
+	flagAddToAgent bool
 }
 
 func (c *SSHCommand) Synopsis() string {
@@ -78,6 +83,17 @@ Usage: vault ssh [options] username@ip [ssh options]
           -host-key-hostnames=example.com \
           user@example.com
 
+  Obtain a signed certificate next to the public key, reusing the cached one
+  until it expires, without connecting. This is suitable for "Match exec" in
+  ssh_config, after which ssh picks up the certificate by itself:
+
+      Match host *.example.com exec "vault ssh -mode=ca -role=my-role -sign-only %r@%h"
+
+  Obtain a signed certificate, load it into ssh-agent and connect the standard
+  input and output to the host, for use as a ProxyCommand in ssh_config:
+
+      ProxyCommand vault ssh -mode=ca -role=my-role -proxy -add-to-agent %r@%h -p %p
+
   For the full list of options and arguments, please see the documentation.
 
 ` + c.Flags().Help()
@@ -208,6 +224,34 @@ func (c *SSHCommand) Flags() *FlagSets {
 			"user certificate. This is specified as a comma-separated list of values.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "sign-only",
+		Target:  &c.flagSignOnly,
+		Default: false,
+		Usage: "Write a certificate signed for the user to the file ssh reads " +
+			"the certificate of -public-key-path from (e.g. ~/.ssh/id_rsa-cert.pub) " +
+			"and exit without connecting. A cached certificate is reused until it " +
+			"expires. Nothing is printed on success, so this can be used in " +
+			"ssh_config \"Match exec\".",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "proxy",
+		Target:  &c.flagProxy,
+		Default: false,
+		Usage: "Like -sign-only, but then connect the standard input and output " +
+			"to the host, for use as an ssh_config \"ProxyCommand\".",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "add-to-agent",
+		Target:  &c.flagAddToAgent,
+		Default: false,
+		Usage: "With -sign-only or -proxy, load the private key and its signed " +
+			"certificate into the ssh-agent at SSH_AUTH_SOCK until the " +
+			"certificate expires.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "ssh-executable",
 		Target:     &c.flagSSHExecutable,
@@ -274,6 +318,10 @@ func (c *SSHCommand) Run(args []string) int {
 		username = u.Username
 	}
 
+	if c.flagSignOnly || c.flagProxy {
+		return c.handleSignedCert(username, hostname, port)
+	}
+
 	ip, err := c.resolveHostname(hostname)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error resolving the ssh hostname: %q", err))
@@ -373,31 +421,8 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 		return 1
 	}
 
-	sshClient := c.client.SSHWithMountPoint(c.flagMountPoint)
-
-	principals := username
-	if c.flagValidPrincipals != "" {
-		principals = c.flagValidPrincipals
-	}
-
 	// Attempt to sign the public key
-	secret, err := sshClient.SignKey(c.flagRole, map[string]interface{}{
-		// WARNING: publicKey is []byte, which is b64 encoded on JSON upload. We
-		// have to convert it to a string. SV lost many hours to this...
-		"public_key":       string(publicKey),
-		"valid_principals": principals,
-		"cert_type":        "user",
-
-		// TODO: let the user configure these. In the interim, if users want to
-		// customize these values, they can produce the key themselves.
-		"extensions": map[string]string{
-			"permit-X11-forwarding":   "",
-			"permit-agent-forwarding": "",
-			"permit-port-forwarding":  "",
-			"permit-pty":              "",
-			"permit-user-rc":          "",
-		},
-	})
+	secret, err := c.signPublicKey(publicKey, username)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("failed to sign public key %s: %s",
 			c.flagPublicKeyPath, err))
@@ -511,6 +536,35 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 	return 0
 }
 
+// signPublicKey signs the given public key as a user certificate for the
+// username, or the -valid-principals if given.
+func (c *SSHCommand) signPublicKey(publicKey []byte, username string) (*api.Secret, error) {
+	sshClient := c.client.SSHWithMountPoint(c.flagMountPoint)
+
+	principals := username
+	if c.flagValidPrincipals != "" {
+		principals = c.flagValidPrincipals
+	}
+
+	return sshClient.SignKey(c.flagRole, map[string]interface{}{
+		// WARNING: publicKey is []byte, which is b64 encoded on JSON upload. We
+		// have to convert it to a string. SV lost many hours to this...
+		"public_key":       string(publicKey),
+		"valid_principals": principals,
+		"cert_type":        "user",
+
+		// TODO: let the user configure these. In the interim, if users want to
+		// customize these values, they can produce the key themselves.
+		"extensions": map[string]string{
+			"permit-X11-forwarding":   "",
+			"permit-agent-forwarding": "",
+			"permit-port-forwarding":  "",
+			"permit-pty":              "",
+			"permit-user-rc":          "",
+		},
+	})
+}
+
 // handleTypeOTP is used to handle SSH logins using the "otp" key type.
 func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string) int {
 	secret, cred, err := c.generateCredential(username, ip)
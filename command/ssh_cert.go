@@ -0,0 +1,254 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/ssh"
+	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshCertRenewBefore is how long before its expiry a cached certificate is
+// replaced by a new one, so that it does not expire during the handshake.
+const sshCertRenewBefore = time.Minute
+
+// handleSignedCert makes sure a valid certificate signed for the user is
+// stored next to the public key, optionally loads it into ssh-agent, and
+// proxies the connection to the host with -proxy. It prints nothing but
+// errors, since with -proxy the standard output is the SSH connection.
+func (c *SSHCommand) handleSignedCert(username, hostname, port string) int {
+	if !strings.EqualFold(c.flagMode, ssh.KeyTypeCA) {
+		c.UI.Error("The -sign-only and -proxy flags require -mode=ca")
+		return 1
+	}
+	if c.flagRole == "" {
+		c.UI.Error("The -sign-only and -proxy flags require a -role")
+		return 1
+	}
+
+	publicKey, err := os.ReadFile(c.flagPublicKeyPath)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("failed to read public key %s: %s",
+			c.flagPublicKeyPath, err))
+		return 1
+	}
+
+	principals := []string{username}
+	if c.flagValidPrincipals != "" {
+		principals = strings.Split(c.flagValidPrincipals, ",")
+	}
+
+	certPath := sshCertPath(c.flagPublicKeyPath)
+	cert, err := readSSHCert(certPath)
+	if err != nil || !sshCertValid(cert, publicKey, principals, time.Now()) {
+		if _, err := c.Client(); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		secret, err := c.signPublicKey(publicKey, username)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("failed to sign public key %s: %s",
+				c.flagPublicKeyPath, err))
+			return 2
+		}
+		if secret == nil || secret.Data == nil {
+			c.UI.Error("missing signed key")
+			return 2
+		}
+		key, ok := secret.Data["signed_key"].(string)
+		if !ok || key == "" {
+			c.UI.Error("signed key is empty")
+			return 2
+		}
+
+		cert, err = parseSSHCert([]byte(key))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("failed to parse signed key: %s", err))
+			return 2
+		}
+		if err := writeSSHCert(certPath, []byte(key)); err != nil {
+			c.UI.Error(fmt.Sprintf("failed to write signed key to %s: %s", certPath, err))
+			return 1
+		}
+	}
+
+	if c.flagAddToAgent {
+		if err := addSSHCertToAgent(c.flagPrivateKeyPath, cert); err != nil {
+			c.UI.Error(fmt.Sprintf("failed to add signed key to ssh-agent: %s", err))
+			return 1
+		}
+	}
+
+	if c.flagProxy {
+		if port == "" {
+			port = "22"
+		}
+		if err := proxySSHConnection(net.JoinHostPort(hostname, port), os.Stdin, os.Stdout); err != nil {
+			c.UI.Error(fmt.Sprintf("failed to proxy the connection: %s", err))
+			return 2
+		}
+	}
+
+	return 0
+}
+
+// sshCertPath returns the path ssh loads the certificate of the given public
+// key from, e.g. ~/.ssh/id_rsa-cert.pub for ~/.ssh/id_rsa.pub.
+func sshCertPath(publicKeyPath string) string {
+	return strings.TrimSuffix(publicKeyPath, ".pub") + "-cert.pub"
+}
+
+func readSSHCert(path string) (*cryptossh.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSSHCert(data)
+}
+
+func parseSSHCert(data []byte) (*cryptossh.Certificate, error) {
+	key, _, _, _, err := cryptossh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*cryptossh.Certificate)
+	if !ok {
+		return nil, errors.New("not a certificate")
+	}
+	return cert, nil
+}
+
+// sshCertValid reports whether the cached certificate can still be used: it
+// must certify the given public key for every principal, and must not expire
+// within sshCertRenewBefore.
+func sshCertValid(cert *cryptossh.Certificate, publicKey []byte, principals []string, now time.Time) bool {
+	if cert == nil || cert.CertType != cryptossh.UserCert {
+		return false
+	}
+
+	key, _, _, _, err := cryptossh.ParseAuthorizedKey(publicKey)
+	if err != nil || !bytes.Equal(key.Marshal(), cert.Key.Marshal()) {
+		return false
+	}
+
+	if uint64(now.Unix()) < cert.ValidAfter {
+		return false
+	}
+	if cert.ValidBefore != cryptossh.CertTimeInfinity &&
+		uint64(now.Add(sshCertRenewBefore).Unix()) >= cert.ValidBefore {
+		return false
+	}
+
+	for _, principal := range principals {
+		found := false
+		for _, valid := range cert.ValidPrincipals {
+			if strings.TrimSpace(principal) == valid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// writeSSHCert replaces the certificate at path atomically, so that a
+// concurrent ssh never reads a partial certificate.
+func writeSSHCert(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// addSSHCertToAgent loads the private key along with its certificate into
+// the ssh-agent at SSH_AUTH_SOCK, until the certificate expires.
+func addSSHCertToAgent(privateKeyPath string, cert *cryptossh.Certificate) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return errors.New("SSH_AUTH_SOCK is not set")
+	}
+
+	data, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read private key %s: %w", privateKeyPath, err)
+	}
+	privateKey, err := cryptossh.ParseRawPrivateKey(data)
+	if err != nil {
+		var passphraseErr *cryptossh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return fmt.Errorf("private key %s is protected by a passphrase", privateKeyPath)
+		}
+		return fmt.Errorf("failed to parse private key %s: %w", privateKeyPath, err)
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var lifetime uint32
+	if cert.ValidBefore != cryptossh.CertTimeInfinity {
+		remaining := time.Until(time.Unix(int64(cert.ValidBefore), 0))
+		if remaining <= 0 {
+			return errors.New("certificate has expired")
+		}
+		lifetime = uint32(remaining.Seconds())
+	}
+
+	return agent.NewClient(conn).Add(agent.AddedKey{
+		PrivateKey:   privateKey,
+		Certificate:  cert,
+		Comment:      cert.KeyId,
+		LifetimeSecs: lifetime,
+	})
+}
+
+// proxySSHConnection connects in and out to the address until the remote end
+// closes the connection.
+func proxySSHConnection(address string, in io.Reader, out io.Writer) error {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		io.Copy(conn, in)
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.CloseWrite()
+		}
+	}()
+
+	_, err = io.Copy(out, conn)
+	return err
+}
@@ -4,10 +4,17 @@
 package command
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
+	cryptossh "golang.org/x/crypto/ssh"
 )
 
 func testSSHCommand(tb testing.TB) (*cli.MockUi, *SSHCommand) {
@@ -233,3 +240,176 @@ func TestSSHCommandOmitFlagWarning(t *testing.T) {
 		t.Fatalf("ssh command displayed flag warnings")
 	}
 }
+
+func testSSHPublicKey(tb testing.TB) []byte {
+	tb.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sshPub, err := cryptossh.NewPublicKey(pub)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return cryptossh.MarshalAuthorizedKey(sshPub)
+}
+
+func TestSSHCommand_SignOnly(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("ssh", &api.MountInput{
+		Type: "ssh",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("ssh/config/ca", map[string]interface{}{
+		"generate_signing_key": true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("ssh/roles/my-role", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"allowed_extensions":      "*",
+		"ttl":                     "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	publicKeyPath := filepath.Join(dir, "id_ed25519.pub")
+	if err := os.WriteFile(publicKeyPath, testSSHPublicKey(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(dir, "id_ed25519-cert.pub")
+
+	run := func() {
+		t.Helper()
+
+		ui, cmd := testSSHCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-mode", "ca",
+			"-role", "my-role",
+			"-public-key-path", publicKeyPath,
+			"-sign-only",
+			"alice@example.com",
+		})
+		if code != 0 {
+			t.Fatalf("expected 0, got %d: %s", code, ui.ErrorWriter.String())
+		}
+		if out := ui.OutputWriter.String(); out != "" {
+			t.Fatalf("expected no output, got %q", out)
+		}
+	}
+
+	run()
+	first, err := readSSHCert(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.ValidPrincipals) != 1 || first.ValidPrincipals[0] != "alice" {
+		t.Fatalf("unexpected principals %v", first.ValidPrincipals)
+	}
+
+	// The cached certificate is reused
+	run()
+	second, err := readSSHCert(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Serial != second.Serial {
+		t.Fatalf("expected the cached certificate to be reused, got serials %d and %d", first.Serial, second.Serial)
+	}
+
+	// A new certificate is signed when the key changes
+	if err := os.WriteFile(publicKeyPath, testSSHPublicKey(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run()
+	third, err := readSSHCert(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Serial == first.Serial {
+		t.Fatal("expected a new certificate for the new key")
+	}
+}
+
+func TestSSHCertValid(t *testing.T) {
+	t.Parallel()
+
+	publicKey := testSSHPublicKey(t)
+	key, _, _, _, err := cryptossh.ParseAuthorizedKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	newCert := func(validBefore time.Time, principals ...string) *cryptossh.Certificate {
+		return &cryptossh.Certificate{
+			Key:             key,
+			CertType:        cryptossh.UserCert,
+			ValidPrincipals: principals,
+			ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+			ValidBefore:     uint64(validBefore.Unix()),
+		}
+	}
+
+	cases := map[string]struct {
+		cert       *cryptossh.Certificate
+		publicKey  []byte
+		principals []string
+		expected   bool
+	}{
+		"valid": {
+			cert:       newCert(now.Add(time.Hour), "alice", "bob"),
+			publicKey:  publicKey,
+			principals: []string{"alice", "bob"},
+			expected:   true,
+		},
+		"expiring": {
+			cert:       newCert(now.Add(30*time.Second), "alice"),
+			publicKey:  publicKey,
+			principals: []string{"alice"},
+			expected:   false,
+		},
+		"missing-principal": {
+			cert:       newCert(now.Add(time.Hour), "alice"),
+			publicKey:  publicKey,
+			principals: []string{"alice", "bob"},
+			expected:   false,
+		},
+		"other-key": {
+			cert:       newCert(now.Add(time.Hour), "alice"),
+			publicKey:  testSSHPublicKey(t),
+			principals: []string{"alice"},
+			expected:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := sshCertValid(tc.cert, tc.publicKey, tc.principals, now); got != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSSHCertPath(t *testing.T) {
+	t.Parallel()
+
+	if got := sshCertPath("/home/alice/.ssh/id_rsa.pub"); got != "/home/alice/.ssh/id_rsa-cert.pub" {
+		t.Fatalf("unexpected certificate path %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package token
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/keyring"
+)
+
+// keyringServiceName is the name of the service the tokens are stored under
+// in the keyring.
+const keyringServiceName = "vault"
+
+// ErrNoKeyring is returned by NewKeyringTokenHelper when the operating
+// system has no keyring available, e.g. on a headless Linux host without a
+// Secret Service.
+var ErrNoKeyring = errors.New("no keyring is available on this system")
+
+var _ TokenHelper = (*KeyringTokenHelper)(nil)
+
+// KeyringTokenHelper fulfills the TokenHelper interface by storing the token
+// encrypted in the keyring of the operating system: the Keychain on macOS,
+// the Credential Manager on Windows and the Secret Service (e.g. GNOME
+// Keyring) on Linux. Tokens are stored per Vault address and namespace, so
+// that logging in to one does not replace the token of another.
+type KeyringTokenHelper struct {
+	address   string
+	namespace string
+	ring      keyring.Keyring
+}
+
+// NewKeyringTokenHelper returns a token helper storing the token of the given
+// Vault address and namespace in the keyring of the operating system. It
+// returns ErrNoKeyring if no keyring is available.
+func NewKeyringTokenHelper(address, namespace string) (*KeyringTokenHelper, error) {
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName: keyringServiceName,
+		AllowedBackends: []keyring.BackendType{
+			keyring.KeychainBackend,
+			keyring.WinCredBackend,
+			keyring.SecretServiceBackend,
+		},
+		KeychainTrustApplication:       true,
+		KeychainAccessibleWhenUnlocked: true,
+		// Use the default collection, which is unlocked on login, rather
+		// than creating a dedicated one the user would be prompted for.
+		LibSecretCollectionName: "login",
+		WinCredPrefix:           keyringServiceName,
+	})
+	if errors.Is(err, keyring.ErrNoAvailImpl) {
+		return nil, ErrNoKeyring
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening keyring: %w", err)
+	}
+
+	return newKeyringTokenHelper(ring, address, namespace), nil
+}
+
+func newKeyringTokenHelper(ring keyring.Keyring, address, namespace string) *KeyringTokenHelper {
+	return &KeyringTokenHelper{
+		address:   strings.TrimSuffix(address, "/"),
+		namespace: strings.Trim(namespace, "/"),
+		ring:      ring,
+	}
+}
+
+// key returns the key the token is stored under in the keyring.
+func (h *KeyringTokenHelper) key() string {
+	if h.namespace == "" {
+		return h.address
+	}
+	return h.address + "#" + h.namespace + "/"
+}
+
+// Path returns the key of the token in the keyring.
+func (h *KeyringTokenHelper) Path() string {
+	return "keyring:" + h.key()
+}
+
+// Get gets the value of the stored token, if any
+func (h *KeyringTokenHelper) Get() (string, error) {
+	item, err := h.ring.Get(h.key())
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(item.Data)), nil
+}
+
+// Store stores the value of the token in the keyring, replacing the token
+// previously stored for the same address and namespace.
+func (h *KeyringTokenHelper) Store(input string) error {
+	label := fmt.Sprintf("Vault token for %s", h.address)
+	if h.namespace != "" {
+		label += fmt.Sprintf(" (namespace %s/)", h.namespace)
+	}
+
+	return h.ring.Set(keyring.Item{
+		Key:                       h.key(),
+		Data:                      []byte(input),
+		Label:                     label,
+		Description:               "Vault token",
+		KeychainNotSynchronizable: true,
+	})
+}
+
+// Erase erases the value of the token
+func (h *KeyringTokenHelper) Erase() error {
+	if err := h.ring.Remove(h.key()); err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package token
+
+import (
+	"testing"
+
+	"github.com/99designs/keyring"
+)
+
+// TestKeyringTokenHelper re-uses the existing Test function to ensure proper
+// behavior of the keyring token helper
+func TestKeyringTokenHelper(t *testing.T) {
+	Test(t, newKeyringTokenHelper(keyring.NewArrayKeyring(nil), "https://127.0.0.1:8200", ""))
+}
+
+// TestKeyringTokenHelper_Scope verifies that the tokens of different addresses
+// and namespaces do not replace each other.
+func TestKeyringTokenHelper_Scope(t *testing.T) {
+	ring := keyring.NewArrayKeyring(nil)
+
+	helpers := map[string]*KeyringTokenHelper{
+		"root":   newKeyringTokenHelper(ring, "https://vault.example.com:8200/", ""),
+		"ns1":    newKeyringTokenHelper(ring, "https://vault.example.com:8200", "/ns1/"),
+		"other":  newKeyringTokenHelper(ring, "https://127.0.0.1:8200", ""),
+		"nested": newKeyringTokenHelper(ring, "https://vault.example.com:8200", "ns1/child"),
+	}
+	for token, helper := range helpers {
+		if err := helper.Store(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for token, helper := range helpers {
+		stored, err := helper.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != token {
+			t.Errorf("expected %q to be stored at %s, got %q", token, helper.Path(), stored)
+		}
+	}
+
+	if path, expected := helpers["ns1"].Path(), "keyring:https://vault.example.com:8200#ns1/"; path != expected {
+		t.Errorf("expected path %q, got %q", expected, path)
+	}
+
+	// Storing the same scope again replaces the token
+	same := newKeyringTokenHelper(ring, "https://vault.example.com:8200", "ns1")
+	if err := same.Store("replaced"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := helpers["ns1"].Get(); err != nil || stored != "replaced" {
+		t.Fatalf("expected the token to be replaced, got %q: %v", stored, err)
+	}
+	if stored, err := helpers["root"].Get(); err != nil || stored != "root" {
+		t.Fatalf("expected the root namespace token to be kept, got %q: %v", stored, err)
+	}
+}
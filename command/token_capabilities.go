@@ -4,10 +4,14 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -17,8 +21,19 @@ var (
 	_ cli.CommandAutocomplete = (*TokenCapabilitiesCommand)(nil)
 )
 
+// tokenCapabilitiesOrder is the order of the columns of the capabilities
+// matrix. Capabilities not listed here are appended in lexical order.
+var tokenCapabilitiesOrder = []string{
+	"create", "read", "update", "patch", "delete", "list", "sudo", "root", "subscribe", "deny",
+}
+
 type TokenCapabilitiesCommand struct {
 	*BaseCommand
+
+	flagToken     string
+	flagPathsFile string
+
+	testStdin io.Reader // for tests
 }
 
 func (c *TokenCapabilitiesCommand) Synopsis() string {
@@ -28,12 +43,18 @@ func (c *TokenCapabilitiesCommand) Synopsis() string {
 func (c *TokenCapabilitiesCommand) Help() string {
 	helpText := `
 Usage: vault token capabilities [options] [TOKEN] PATH
+       vault token capabilities [options] -token=TOKEN PATH...
+       vault token capabilities [options] -paths-file=FILE [PATH...]
 
   Fetches the capabilities of a token for a given path. If a TOKEN is provided
   as an argument, the "/sys/capabilities" endpoint and permission is used. If
   no TOKEN is provided, the "/sys/capabilities-self" endpoint and permission
   is used with the locally authenticated token.
 
+  When the -token or -paths-file flag is given, every argument is a path and
+  the capabilities of all paths are fetched in a single request. The result is
+  printed as a matrix with one row per path and one column per capability.
+
   List capabilities for the local token on the "secret/foo" path:
 
       $ vault token capabilities secret/foo
@@ -42,6 +63,15 @@ Usage: vault token capabilities [options] [TOKEN] PATH
 
       $ vault token capabilities 96ddf4bc-d217-f3ba-f9bd-017055595017 cubbyhole/foo
 
+  List capabilities for a token on several paths:
+
+      $ vault token capabilities -token=96ddf4bc-d217-f3ba-f9bd-017055595017 \
+          secret/foo secret/bar sys/mounts
+
+  List capabilities for the local token on the paths listed in a file, as JSON:
+
+      $ vault token capabilities -format=json -paths-file=paths.txt
+
   For a full list of examples, please see the documentation.
 
 ` + c.Flags().Help()
@@ -50,7 +80,31 @@ Usage: vault token capabilities [options] [TOKEN] PATH
 }
 
 func (c *TokenCapabilitiesCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "token",
+		Target:  &c.flagToken,
+		Default: "",
+		Usage: "Token whose capabilities are fetched. When set, every argument " +
+			"is treated as a path. If unset, the locally authenticated token is " +
+			"used.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "paths-file",
+		Target:     &c.flagPathsFile,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a file with one path per line whose capabilities are " +
+			"fetched in addition to the paths given as arguments. Empty lines " +
+			"and lines starting with \"#\" are ignored. If the value is \"-\", " +
+			"the paths are read from stdin.",
+	})
+
+	return set
 }
 
 func (c *TokenCapabilitiesCommand) AutocompleteArgs() complete.Predictor {
@@ -69,9 +123,13 @@ func (c *TokenCapabilitiesCommand) Run(args []string) int {
 		return 1
 	}
 
+	args = f.Args()
+	if c.flagToken != "" || c.flagPathsFile != "" {
+		return c.runPaths(args)
+	}
+
 	token := ""
 	path := ""
-	args = f.Args()
 	switch len(args) {
 	case 0:
 		c.UI.Error("Not enough arguments (expected 1-2, got 0)")
@@ -115,3 +173,159 @@ func (c *TokenCapabilitiesCommand) Run(args []string) int {
 		return OutputData(c.UI, capabilities)
 	}
 }
+
+// runPaths fetches the capabilities of the token given by -token, or the
+// locally authenticated token, on every path given as an argument or listed
+// in -paths-file, and prints them as a matrix.
+func (c *TokenCapabilitiesCommand) runPaths(args []string) int {
+	paths := args
+	if c.flagPathsFile != "" {
+		filePaths, err := c.readPathsFile()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading paths file: %s", err))
+			return 1
+		}
+		paths = append(paths, filePaths...)
+	}
+	if len(paths) == 0 {
+		c.UI.Error("Not enough arguments (expected at least 1 path, got 0)")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	capabilities, err := tokenCapabilities(client, c.flagToken, paths)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing capabilities: %s", err))
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		c.UI.Output(tableOutput(capabilitiesMatrix(paths, capabilities), nil))
+		return 0
+	default:
+		return OutputData(c.UI, capabilities)
+	}
+}
+
+// readPathsFile reads the paths listed in -paths-file, one per line.
+func (c *TokenCapabilitiesCommand) readPathsFile() ([]string, error) {
+	var r io.Reader
+	if c.flagPathsFile == "-" {
+		r = os.Stdin
+		if c.testStdin != nil {
+			r = c.testStdin
+		}
+	} else {
+		f, err := os.Open(c.flagPathsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// tokenCapabilities fetches the capabilities of token on all paths in a
+// single request. An empty token, or the token of the client, is looked up
+// through the "sys/capabilities-self" endpoint.
+func tokenCapabilities(client *api.Client, token string, paths []string) (map[string][]string, error) {
+	reqPath := "sys/capabilities"
+	if token == "" || token == client.Token() {
+		reqPath = "sys/capabilities-self"
+	}
+
+	secret, err := client.Logical().Write(reqPath, map[string]interface{}{
+		"token": token,
+		"paths": paths,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("data from server response is empty")
+	}
+
+	capabilities := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		raw, ok := secret.Data[path].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no capabilities returned for path %q", path)
+		}
+
+		pathCapabilities := make([]string, 0, len(raw))
+		for _, capability := range raw {
+			if s, ok := capability.(string); ok {
+				pathCapabilities = append(pathCapabilities, s)
+			}
+		}
+		capabilities[path] = pathCapabilities
+	}
+
+	return capabilities, nil
+}
+
+// capabilitiesMatrix returns the rows of a table with one row per path, in
+// the given order, and one column per capability held on any of the paths.
+func capabilitiesMatrix(paths []string, capabilities map[string][]string) []string {
+	seen := make(map[string]bool)
+	for _, pathCapabilities := range capabilities {
+		for _, capability := range pathCapabilities {
+			seen[capability] = true
+		}
+	}
+
+	var columns []string
+	for _, capability := range tokenCapabilitiesOrder {
+		if seen[capability] {
+			columns = append(columns, capability)
+			delete(seen, capability)
+		}
+	}
+	var extra []string
+	for capability := range seen {
+		extra = append(extra, capability)
+	}
+	sort.Strings(extra)
+	columns = append(columns, extra...)
+
+	out := []string{"Path | " + strings.Join(columns, " | ")}
+	for _, path := range paths {
+		held := make(map[string]bool)
+		for _, capability := range capabilities[path] {
+			held[capability] = true
+		}
+
+		row := []string{path}
+		for _, capability := range columns {
+			if held[capability] {
+				row = append(row, "x")
+			} else {
+				row = append(row, "-")
+			}
+		}
+		out = append(out, strings.Join(row, " | "))
+	}
+
+	return out
+}
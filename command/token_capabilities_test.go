@@ -4,6 +4,8 @@
 package command
 
 import (
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -185,6 +187,131 @@ func TestTokenCapabilitiesCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("token_paths", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		policy := `
+path "secret/foo" { capabilities = ["read", "list"] }
+path "secret/bar" { capabilities = ["create", "update"] }
+`
+		if err := client.Sys().PutPolicy("policy", policy); err != nil {
+			t.Fatal(err)
+		}
+
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: []string{"policy"},
+			TTL:      "30m",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := secret.Auth.ClientToken
+
+		ui, cmd := testTokenCapabilitiesCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-token", token,
+			"secret/foo", "secret/bar", "secret/zip",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("expected a header, an underline and 3 rows, got %q", lines)
+		}
+		for i, expected := range [][]string{
+			{"Path", "create", "read", "update", "list", "deny"},
+			nil,
+			{"secret/foo", "-", "x", "-", "x", "-"},
+			{"secret/bar", "x", "-", "x", "-", "-"},
+			{"secret/zip", "-", "-", "-", "-", "x"},
+		} {
+			if expected == nil {
+				continue
+			}
+			if fields := strings.Fields(lines[i]); !reflect.DeepEqual(fields, expected) {
+				t.Errorf("expected row %d to be %q, got %q", i, expected, fields)
+			}
+		}
+	})
+
+	t.Run("paths_file_stdin", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		policy := `path "secret/foo" { capabilities = ["read"] }`
+		if err := client.Sys().PutPolicy("policy", policy); err != nil {
+			t.Fatal(err)
+		}
+
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: []string{"policy"},
+			TTL:      "30m",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.SetToken(secret.Auth.ClientToken)
+
+		ui, cmd := testTokenCapabilitiesCommand(t)
+		cmd.client = client
+		cmd.testStdin = strings.NewReader("# paths to check\nsecret/foo\n\nsecret/bar\n")
+
+		code := cmd.Run([]string{
+			"-paths-file", "-",
+			"sys/mounts",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("expected a header, an underline and 3 rows, got %q", lines)
+		}
+		for i, expected := range [][]string{
+			{"Path", "read", "deny"},
+			nil,
+			{"sys/mounts", "-", "x"},
+			{"secret/foo", "x", "-"},
+			{"secret/bar", "-", "x"},
+		} {
+			if expected == nil {
+				continue
+			}
+			if fields := strings.Fields(lines[i]); !reflect.DeepEqual(fields, expected) {
+				t.Errorf("expected row %d to be %q, got %q", i, expected, fields)
+			}
+		}
+	})
+
+	t.Run("paths_file_missing", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testTokenCapabilitiesCommand(t)
+
+		code := cmd.Run([]string{
+			"-paths-file", filepath.Join(t.TempDir(), "nope.txt"),
+		})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error reading paths file"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
 	t.Run("no_tabs", func(t *testing.T) {
 		t.Parallel()
 
@@ -31,6 +31,15 @@ Usage: vault transit <subcommand> [options] [args]
 
   $ vault transit import transit/keys/newly-imported @path/to/key type=rsa-2048
 
+  To encrypt and decrypt a file of any size with a Transit key:
+
+  $ vault transit encrypt my-key backup.tar backup.tar.enc
+  $ vault transit decrypt backup.tar.enc backup.tar
+
+  To sign a file with a Transit key:
+
+  $ vault transit sign my-key release.tar.gz
+
   Please see the individual subcommand help for detailed usage information.
 `
 
@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitDecryptCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitDecryptCommand)(nil)
+)
+
+type TransitDecryptCommand struct {
+	*BaseCommand
+
+	flagMount   string
+	flagKey     string
+	flagContext string
+
+	testStdin  io.Reader // for tests
+	testStdout io.Writer // for tests
+}
+
+func (c *TransitDecryptCommand) Synopsis() string {
+	return "Decrypt a file encrypted with a Transit key"
+}
+
+func (c *TransitDecryptCommand) Help() string {
+	helpText := `
+Usage: vault transit decrypt [options] INPUT OUTPUT
+
+  Decrypts the file INPUT, encrypted by "vault transit encrypt", and writes the
+  result to OUTPUT. The data key of the file is decrypted with the Transit key
+  which generated it, and the file is then streamed through AES-256-GCM. Every
+  chunk is authenticated, and truncated or modified files are rejected. If
+  INPUT or OUTPUT is "-", stdin or stdout is used. An output file is removed
+  when decryption fails, but data already written to stdout cannot be.
+
+      $ vault transit decrypt backup.tar.enc backup.tar
+
+  The mount and the key recorded in the file are used, unless they are
+  overridden with the "-mount" and "-key" flags.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitDecryptCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:   "mount",
+		Target: &c.flagMount,
+		Usage: `Path of the Transit secrets engine mount, if it differs from the
+		mount recorded in the file.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "key",
+		Target: &c.flagKey,
+		Usage:  `Name of the Transit key, if it differs from the key recorded in the file.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "context",
+		Target: &c.flagContext,
+		Usage:  `Base64 encoded context for key derivation, as given to encrypt.`,
+	})
+
+	return set
+}
+
+func (c *TransitDecryptCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitDecryptCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitDecryptCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+	inputPath, outputPath := args[0], args[1]
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	input, err := openTransitInput(inputPath, c.testStdin)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening input: %s", err))
+		return 1
+	}
+	defer input.Close()
+
+	r := bufio.NewReader(input)
+	header, headerLine, err := readTransitStreamHeader(r)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading input: %s", err))
+		return 1
+	}
+
+	mount, key := header.Mount, header.Key
+	if c.flagMount != "" {
+		mount = sanitizePath(c.flagMount)
+	}
+	if c.flagKey != "" {
+		key = c.flagKey
+	}
+
+	dataKey, err := transitDecryptDataKey(client, mount, key, header.Ciphertext, c.flagContext)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	defer zeroBytes(dataKey)
+
+	output, err := createTransitOutput(outputPath, c.testStdout)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating output: %s", err))
+		return 1
+	}
+
+	if err := transitOpenStream(output, r, dataKey, header, headerLine); err != nil {
+		output.abort()
+		c.UI.Error(fmt.Sprintf("Error decrypting: %s", err))
+		return 2
+	}
+	if err := output.commit(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing output: %s", err))
+		return 2
+	}
+
+	if outputPath != "-" {
+		c.UI.Output(fmt.Sprintf("Success! Decrypted %s to %s", inputPath, outputPath))
+	}
+	return 0
+}
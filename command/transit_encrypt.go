@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitEncryptCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitEncryptCommand)(nil)
+)
+
+type TransitEncryptCommand struct {
+	*BaseCommand
+
+	flagMount     string
+	flagContext   string
+	flagChunkSize int
+
+	testStdin  io.Reader // for tests
+	testStdout io.Writer // for tests
+}
+
+func (c *TransitEncryptCommand) Synopsis() string {
+	return "Encrypt a file with a Transit key"
+}
+
+func (c *TransitEncryptCommand) Help() string {
+	helpText := `
+Usage: vault transit encrypt [options] KEY INPUT OUTPUT
+
+  Encrypts the file INPUT with envelope encryption and writes the result to
+  OUTPUT. A data key is generated with the Transit key KEY, and the file is
+  streamed through AES-256-GCM in chunks under that data key, so files of any
+  size can be encrypted without sending them to Vault. Only the data key,
+  encrypted by Transit, is stored alongside the encrypted chunks. If INPUT or
+  OUTPUT is "-", stdin or stdout is used.
+
+  Encrypt a file with the key "my-key" of the "transit" mount:
+
+      $ vault transit encrypt my-key backup.tar backup.tar.enc
+
+  Decrypt it with "vault transit decrypt":
+
+      $ vault transit decrypt backup.tar.enc backup.tar
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitEncryptCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "mount",
+		Target:  &c.flagMount,
+		Default: "transit",
+		Usage:   `Path of the Transit secrets engine mount.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "context",
+		Target: &c.flagContext,
+		Usage: `Base64 encoded context for key derivation, required if the key
+		has derivation enabled. The same context must be given to decrypt.`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "chunk-size",
+		Target:  &c.flagChunkSize,
+		Default: transitStreamDefaultChunkSize,
+		Usage:   `Size in bytes of the chunks the file is encrypted in.`,
+	})
+
+	return set
+}
+
+func (c *TransitEncryptCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitEncryptCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitEncryptCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 3:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 3, got %d)", len(args)))
+		return 1
+	case len(args) > 3:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 3, got %d)", len(args)))
+		return 1
+	}
+	key, inputPath, outputPath := args[0], args[1], args[2]
+
+	if c.flagChunkSize <= 0 || c.flagChunkSize > transitStreamMaxChunkSize {
+		c.UI.Error(fmt.Sprintf("Chunk size must be between 1 and %d bytes", transitStreamMaxChunkSize))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	input, err := openTransitInput(inputPath, c.testStdin)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening input: %s", err))
+		return 1
+	}
+	defer input.Close()
+
+	mount := sanitizePath(c.flagMount)
+	dataKey, ciphertext, err := transitDataKey(client, mount, key, c.flagContext)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	defer zeroBytes(dataKey)
+
+	output, err := createTransitOutput(outputPath, c.testStdout)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating output: %s", err))
+		return 1
+	}
+
+	header := &transitStreamHeader{
+		Version:    transitStreamVersion,
+		Mount:      mount,
+		Key:        key,
+		Ciphertext: ciphertext,
+		ChunkSize:  c.flagChunkSize,
+	}
+	if err := transitSealStream(output, input, dataKey, header); err != nil {
+		output.abort()
+		c.UI.Error(fmt.Sprintf("Error encrypting: %s", err))
+		return 2
+	}
+	if err := output.commit(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing output: %s", err))
+		return 2
+	}
+
+	if outputPath != "-" {
+		c.UI.Output(fmt.Sprintf("Success! Encrypted %s to %s", inputPath, outputPath))
+	}
+	return 0
+}
@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func testTransitEncryptCommand(tb testing.TB) (*cli.MockUi, *TransitEncryptCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitEncryptCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testTransitDecryptCommand(tb testing.TB) (*cli.MockUi, *TransitDecryptCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitDecryptCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testTransitServer(t *testing.T) (*api.Client, func()) {
+	t.Helper()
+
+	client, closer := testVaultServer(t)
+
+	if err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		t.Fatalf("transit mount error: %#v", err)
+	}
+	if _, err := client.Logical().Write("transit/keys/my-key", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("transit/keys/derived", map[string]interface{}{
+		"derived": true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return client, closer
+}
+
+// Validate the `vault transit encrypt` and `vault transit decrypt` commands
+// round trip files of various sizes.
+func TestTransitEncryptDecrypt(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testTransitServer(t)
+	defer closer()
+
+	dir := t.TempDir()
+	context := base64.StdEncoding.EncodeToString([]byte("my-context"))
+
+	cases := []struct {
+		name        string
+		size        int
+		encryptArgs []string
+		decryptArgs []string
+	}{
+		{"empty", 0, nil, nil},
+		{"one-chunk", 100, nil, nil},
+		{"exact-chunks", 4096, []string{"-chunk-size=1024"}, nil},
+		{"partial-chunk", 4000, []string{"-chunk-size=1024"}, nil},
+		{"derived", 3000, []string{"-chunk-size=1024", "-context=" + context}, []string{"-context=" + context}},
+	}
+
+	for _, tc := range cases {
+		plaintext := make([]byte, tc.size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		input := filepath.Join(dir, tc.name)
+		encrypted := input + ".enc"
+		decrypted := input + ".dec"
+		require.NoError(t, os.WriteFile(input, plaintext, 0o600))
+
+		key := "my-key"
+		if tc.name == "derived" {
+			key = "derived"
+		}
+
+		ui, cmd := testTransitEncryptCommand(t)
+		cmd.client = client
+		code := cmd.Run(append(tc.encryptArgs, key, input, encrypted))
+		require.Equal(t, 0, code, "%s: %s", tc.name, ui.ErrorWriter.String())
+
+		contents, err := os.ReadFile(encrypted)
+		require.NoError(t, err)
+		if tc.size > 0 {
+			require.False(t, bytes.Contains(contents, plaintext), "%s: plaintext found in encrypted file", tc.name)
+		}
+
+		ui, dcmd := testTransitDecryptCommand(t)
+		dcmd.client = client
+		code = dcmd.Run(append(tc.decryptArgs, encrypted, decrypted))
+		require.Equal(t, 0, code, "%s: %s", tc.name, ui.ErrorWriter.String())
+
+		result, err := os.ReadFile(decrypted)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(plaintext, result), "%s: decrypted file differs", tc.name)
+	}
+}
+
+// Validate the `vault transit decrypt` command rejects modified and truncated
+// files, without leaving partial output behind.
+func TestTransitDecrypt_Tampered(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testTransitServer(t)
+	defer closer()
+
+	plaintext := make([]byte, 5000)
+	_, err := rand.Read(plaintext)
+	require.NoError(t, err)
+
+	var encrypted bytes.Buffer
+	ui, cmd := testTransitEncryptCommand(t)
+	cmd.client = client
+	cmd.testStdin = bytes.NewReader(plaintext)
+	cmd.testStdout = &encrypted
+	code := cmd.Run([]string{"-chunk-size=1024", "my-key", "-", "-"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	headerEnd := bytes.IndexByte(encrypted.Bytes(), '\n') + 1
+
+	flipped := append([]byte{}, encrypted.Bytes()...)
+	flipped[len(flipped)-10] ^= 0xff
+
+	// drop the final chunk
+	truncated := encrypted.Bytes()[:headerEnd+2*(4+1024+16)]
+
+	reordered := append([]byte{}, encrypted.Bytes()[:headerEnd]...)
+	chunk := 4 + 1024 + 16
+	reordered = append(reordered, encrypted.Bytes()[headerEnd+chunk:headerEnd+2*chunk]...)
+	reordered = append(reordered, encrypted.Bytes()[headerEnd:headerEnd+chunk]...)
+	reordered = append(reordered, encrypted.Bytes()[headerEnd+2*chunk:]...)
+
+	headerChanged := bytes.Replace(encrypted.Bytes(), []byte(`"chunk_size":1024`), []byte(`"chunk_size":1025`), 1)
+
+	cases := map[string][]byte{
+		"flipped":        flipped,
+		"truncated":      truncated,
+		"reordered":      reordered,
+		"header-changed": headerChanged,
+		"extra-data":     append(append([]byte{}, encrypted.Bytes()...), 0),
+		"not-encrypted":  []byte("hello\n"),
+	}
+
+	dir := t.TempDir()
+	for name, contents := range cases {
+		input := filepath.Join(dir, name)
+		output := input + ".dec"
+		require.NoError(t, os.WriteFile(input, contents, 0o600))
+
+		ui, dcmd := testTransitDecryptCommand(t)
+		dcmd.client = client
+		code := dcmd.Run([]string{input, output})
+		require.NotEqual(t, 0, code, "%s: expected failure", name)
+		require.NotEmpty(t, strings.TrimSpace(ui.ErrorWriter.String()), name)
+
+		_, err := os.Stat(output)
+		require.True(t, os.IsNotExist(err), "%s: expected no output file", name)
+	}
+}
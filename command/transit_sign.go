@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"io"
+	paths "path"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitSignCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitSignCommand)(nil)
+)
+
+// transitSignHashes are the hash algorithms the file can be hashed with
+// locally before it is signed.
+var transitSignHashes = map[string]crypto.Hash{
+	"sha2-224": crypto.SHA224,
+	"sha2-256": crypto.SHA256,
+	"sha2-384": crypto.SHA384,
+	"sha2-512": crypto.SHA512,
+	"sha3-224": crypto.SHA3_224,
+	"sha3-256": crypto.SHA3_256,
+	"sha3-384": crypto.SHA3_384,
+	"sha3-512": crypto.SHA3_512,
+}
+
+type TransitSignCommand struct {
+	*BaseCommand
+
+	flagMount              string
+	flagHashAlgorithm      string
+	flagSignatureAlgorithm string
+	flagKeyVersion         int
+
+	testStdin io.Reader // for tests
+}
+
+func (c *TransitSignCommand) Synopsis() string {
+	return "Sign a file with a Transit key"
+}
+
+func (c *TransitSignCommand) Help() string {
+	helpText := `
+Usage: vault transit sign [options] KEY INPUT
+
+  Signs the file INPUT with the Transit key KEY. The file is hashed locally
+  while it is streamed, and only its digest is sent to Vault, which signs it
+  as prehashed input. If INPUT is "-", stdin is used. The signature can be
+  verified with the Transit verify endpoint using the same digest, or with any
+  tool for the key type.
+
+  Sign a file with the key "my-key" of the "transit" mount:
+
+      $ vault transit sign my-key release.tar.gz
+
+  Print only the signature:
+
+      $ vault transit sign -field=signature my-key release.tar.gz
+
+  Ed25519 keys sign the whole input rather than a digest, and are not
+  supported by this command.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitSignCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "mount",
+		Target:  &c.flagMount,
+		Default: "transit",
+		Usage:   `Path of the Transit secrets engine mount.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "hash-algorithm",
+		Target:     &c.flagHashAlgorithm,
+		Default:    "sha2-256",
+		Completion: complete.PredictSet(transitSignHashNames()...),
+		Usage:      `Hash algorithm the file is hashed with before it is signed.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "signature-algorithm",
+		Target:     &c.flagSignatureAlgorithm,
+		Completion: complete.PredictSet("pss", "pkcs1v15"),
+		Usage:      `Signature algorithm to use with RSA keys, either "pss" or "pkcs1v15".`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:   "key-version",
+		Target: &c.flagKeyVersion,
+		Usage:  `Version of the key to sign with. The latest version is used by default.`,
+	})
+
+	return set
+}
+
+func transitSignHashNames() []string {
+	return []string{
+		"sha2-224", "sha2-256", "sha2-384", "sha2-512",
+		"sha3-224", "sha3-256", "sha3-384", "sha3-512",
+	}
+}
+
+func (c *TransitSignCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitSignCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitSignCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+	key, inputPath := args[0], args[1]
+
+	hash, ok := transitSignHashes[c.flagHashAlgorithm]
+	if !ok || !hash.Available() {
+		c.UI.Error(fmt.Sprintf("Unsupported hash algorithm %q, must be one of %s",
+			c.flagHashAlgorithm, strings.Join(transitSignHashNames(), ", ")))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	input, err := openTransitInput(inputPath, c.testStdin)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening input: %s", err))
+		return 1
+	}
+	defer input.Close()
+
+	h := hash.New()
+	if _, err := io.Copy(h, input); err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading input: %s", err))
+		return 1
+	}
+
+	data := map[string]interface{}{
+		"input":          base64.StdEncoding.EncodeToString(h.Sum(nil)),
+		"prehashed":      true,
+		"hash_algorithm": c.flagHashAlgorithm,
+	}
+	if c.flagSignatureAlgorithm != "" {
+		data["signature_algorithm"] = c.flagSignatureAlgorithm
+	}
+	if c.flagKeyVersion > 0 {
+		data["key_version"] = c.flagKeyVersion
+	}
+
+	secret, err := client.Logical().Write(paths.Join(sanitizePath(c.flagMount), "sign", key), data)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error signing %s: %s", inputPath, err))
+		return 2
+	}
+	if secret == nil {
+		c.UI.Error(fmt.Sprintf("No signature returned for %s", inputPath))
+		return 2
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, secret, c.flagField)
+	}
+	return OutputSecret(c.UI, secret)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+// Validate the `vault transit sign` command signs the digest of a file.
+func TestTransitSign(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		t.Fatalf("transit mount error: %#v", err)
+	}
+	if _, err := client.Logical().Write("transit/keys/signer", map[string]interface{}{
+		"type": "ecdsa-p256",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := bytes.Repeat([]byte("some file contents\n"), 10000)
+
+	ui := cli.NewMockUi()
+	cmd := &TransitSignCommand{
+		BaseCommand: &BaseCommand{
+			UI:     ui,
+			client: client,
+		},
+		testStdin: bytes.NewReader(input),
+	}
+	code := cmd.Run([]string{"-field=signature", "-hash-algorithm=sha2-512", "signer", "-"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	signature := strings.TrimSpace(ui.OutputWriter.String())
+	require.True(t, strings.HasPrefix(signature, "vault:v1:"), signature)
+
+	digest := sha512.Sum512(input)
+	secret, err := client.Logical().Write("transit/verify/signer", map[string]interface{}{
+		"input":          base64.StdEncoding.EncodeToString(digest[:]),
+		"prehashed":      true,
+		"hash_algorithm": "sha2-512",
+		"signature":      signature,
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, secret.Data["valid"])
+
+	ui = cli.NewMockUi()
+	cmd = &TransitSignCommand{
+		BaseCommand: &BaseCommand{
+			UI:     ui,
+			client: client,
+		},
+		testStdin: bytes.NewReader(input),
+	}
+	code = cmd.Run([]string{"-hash-algorithm=md5", "signer", "-"})
+	require.Equal(t, 1, code)
+}
@@ -0,0 +1,335 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	paths "path"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Files encrypted by vault transit encrypt are made of a header line, holding
+// a JSON encoded transitStreamHeader, followed by the chunks of the file. Each
+// chunk is sealed with AES-256-GCM under a data key generated by Transit,
+// whose Transit ciphertext is stored in the header, and is written as its
+// big-endian uint32 length followed by the sealed bytes. The nonce of a chunk
+// is its index, with the last byte set on the final chunk so that truncated
+// files are detected, and the header line is authenticated with every chunk.
+const (
+	transitStreamVersion      = 1
+	transitStreamMaxHeader    = 64 * 1024
+	transitStreamMaxChunkSize = 64 * 1024 * 1024
+
+	transitStreamDefaultChunkSize = 1024 * 1024
+)
+
+type transitStreamHeader struct {
+	Version    int    `json:"version"`
+	Mount      string `json:"mount"`
+	Key        string `json:"key"`
+	Ciphertext string `json:"ciphertext"`
+	ChunkSize  int    `json:"chunk_size"`
+}
+
+// transitDataKey generates a new data key with the Transit key, returning its
+// plaintext and its Transit ciphertext.
+func transitDataKey(client *api.Client, mount, key, context string) ([]byte, string, error) {
+	data := map[string]interface{}{
+		"bits": 256,
+	}
+	if context != "" {
+		data["context"] = context
+	}
+
+	secret, err := client.Logical().Write(paths.Join(mount, "datakey", "plaintext", key), data)
+	if err != nil {
+		return nil, "", fmt.Errorf("error generating data key: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, "", errors.New("error generating data key: no data returned")
+	}
+
+	ciphertext, _ := secret.Data["ciphertext"].(string)
+	encoded, _ := secret.Data["plaintext"].(string)
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || ciphertext == "" || len(plaintext) != 32 {
+		return nil, "", errors.New("error generating data key: invalid response")
+	}
+	return plaintext, ciphertext, nil
+}
+
+// transitDecryptDataKey decrypts the data key of an encrypted file with the
+// Transit key.
+func transitDecryptDataKey(client *api.Client, mount, key, ciphertext, context string) ([]byte, error) {
+	data := map[string]interface{}{
+		"ciphertext": ciphertext,
+	}
+	if context != "" {
+		data["context"] = context
+	}
+
+	secret, err := client.Logical().Write(paths.Join(mount, "decrypt", key), data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data key: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("error decrypting data key: no data returned")
+	}
+
+	encoded, _ := secret.Data["plaintext"].(string)
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(plaintext) != 32 {
+		return nil, errors.New("error decrypting data key: invalid response")
+	}
+	return plaintext, nil
+}
+
+func transitStreamAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func transitStreamNonce(aead cipher.AEAD, index uint64, final bool) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce, index)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// transitSealStream writes the header and the chunks of src sealed with the
+// data key to dst.
+func transitSealStream(dst io.Writer, src io.Reader, dataKey []byte, header *transitStreamHeader) error {
+	if header.ChunkSize <= 0 || header.ChunkSize > transitStreamMaxChunkSize {
+		return fmt.Errorf("chunk size must be between 1 and %d bytes", transitStreamMaxChunkSize)
+	}
+
+	aead, err := transitStreamAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	headerLine, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	headerLine = append(headerLine, '\n')
+	if _, err := dst.Write(headerLine); err != nil {
+		return err
+	}
+
+	var index uint64
+	seal := func(chunk []byte, final bool) error {
+		sealed := aead.Seal(nil, transitStreamNonce(aead, index, final), chunk, headerLine)
+		index++
+
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+		if _, err := dst.Write(length[:]); err != nil {
+			return err
+		}
+		_, err := dst.Write(sealed)
+		return err
+	}
+
+	// Read one chunk ahead, since the final chunk has to be known when it is
+	// sealed.
+	cur, next := make([]byte, header.ChunkSize), make([]byte, header.ChunkSize)
+	n, err := io.ReadFull(src, cur)
+	for {
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			return seal(cur[:n], true)
+		case err != nil:
+			return err
+		}
+
+		nextN, nextErr := io.ReadFull(src, next)
+		if nextErr == io.EOF {
+			return seal(cur[:n], true)
+		}
+		if err := seal(cur[:n], false); err != nil {
+			return err
+		}
+		cur, next = next, cur
+		n, err = nextN, nextErr
+	}
+}
+
+// readTransitStreamHeader reads the header line of an encrypted file,
+// returning the decoded header along with the raw line.
+func readTransitStreamHeader(r *bufio.Reader) (*transitStreamHeader, []byte, error) {
+	var line []byte
+	for {
+		part, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading header: %w", err)
+		}
+		line = append(line, part...)
+		if len(line) > transitStreamMaxHeader {
+			return nil, nil, errors.New("header is too long")
+		}
+		if !isPrefix {
+			break
+		}
+	}
+
+	var header transitStreamHeader
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&header); err != nil {
+		return nil, nil, fmt.Errorf("input is not a file encrypted by vault transit encrypt: %w", err)
+	}
+	if header.Version != transitStreamVersion {
+		return nil, nil, fmt.Errorf("unsupported format version %d", header.Version)
+	}
+	if header.ChunkSize <= 0 || header.ChunkSize > transitStreamMaxChunkSize {
+		return nil, nil, fmt.Errorf("invalid chunk size %d", header.ChunkSize)
+	}
+	if header.Ciphertext == "" {
+		return nil, nil, errors.New("header is missing the data key")
+	}
+
+	return &header, append(line, '\n'), nil
+}
+
+// transitOpenStream writes the chunks read from r, opened with the data key,
+// to dst. It fails if any chunk was tampered with or the file is truncated.
+func transitOpenStream(dst io.Writer, r io.Reader, dataKey []byte, header *transitStreamHeader, headerLine []byte) error {
+	aead, err := transitStreamAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	maxSealed := header.ChunkSize + aead.Overhead()
+	sealed := make([]byte, maxSealed)
+
+	var index uint64
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return errors.New("encrypted file is truncated")
+			}
+			return err
+		}
+		n := int(binary.BigEndian.Uint32(length[:]))
+		if n > maxSealed || n < aead.Overhead() {
+			return fmt.Errorf("invalid length of chunk %d", index)
+		}
+		if _, err := io.ReadFull(r, sealed[:n]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return errors.New("encrypted file is truncated")
+			}
+			return err
+		}
+
+		final := false
+		chunk, err := aead.Open(nil, transitStreamNonce(aead, index, false), sealed[:n], headerLine)
+		if err != nil {
+			chunk, err = aead.Open(nil, transitStreamNonce(aead, index, true), sealed[:n], headerLine)
+			if err != nil {
+				return fmt.Errorf("failed to authenticate chunk %d", index)
+			}
+			final = true
+		}
+		index++
+
+		if _, err := dst.Write(chunk); err != nil {
+			return err
+		}
+
+		if final {
+			var extra [1]byte
+			if n, _ := r.Read(extra[:]); n > 0 {
+				return errors.New("unexpected data after the final chunk")
+			}
+			return nil
+		}
+	}
+}
+
+// openTransitInput opens the input file of the transit commands, which is
+// stdin if path is "-".
+func openTransitInput(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == "-" {
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(path)
+}
+
+// transitOutput is the output file of the transit commands, which is stdout
+// if its path is "-". A file is removed unless it is committed, so that no
+// partial output is left behind on errors.
+type transitOutput struct {
+	io.Writer
+
+	file *os.File
+	buf  *bufio.Writer
+}
+
+func createTransitOutput(path string, stdout io.Writer) (*transitOutput, error) {
+	if path == "-" {
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		buf := bufio.NewWriter(stdout)
+		return &transitOutput{Writer: buf, buf: buf}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	return &transitOutput{Writer: buf, file: file, buf: buf}, nil
+}
+
+func (o *transitOutput) commit() error {
+	if err := o.buf.Flush(); err != nil {
+		o.abort()
+		return err
+	}
+	if o.file == nil {
+		return nil
+	}
+	if err := o.file.Close(); err != nil {
+		os.Remove(o.file.Name())
+		return err
+	}
+	return nil
+}
+
+func (o *transitOutput) abort() {
+	if o.file == nil {
+		return
+	}
+	o.file.Close()
+	os.Remove(o.file.Name())
+}
+
+// zeroBytes overwrites a key once it is no longer needed.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -61,3 +61,7 @@ func newTestLogger(name string) log.Logger {
 func GetNumWorkers(j *JobManager) int {
 	return j.workerPool.numWorkers
 }
+
+func GetMaxWorkersPerQueue(j *JobManager) int {
+	return j.maxWorkersPerQueue
+}
@@ -45,6 +45,20 @@ type JobManager struct {
 	// track queues by index for round robin worker assignment
 	queuesIndex       []string
 	lastQueueAccessed int
+
+	// maxWorkersPerQueue bounds the number of workers assigned to a single
+	// queue, on top of the fair share of the worker pool. 0 means no bound.
+	maxWorkersPerQueue int
+
+	// agingThreshold is the time after which the oldest job of a queue is
+	// assigned a worker ahead of the round robin order. 0 disables aging.
+	agingThreshold time.Duration
+}
+
+// queuedJob is a job waiting in a queue, along with the time it was added
+type queuedJob struct {
+	job      Job
+	enqueued time.Time
 }
 
 // NewJobManager creates a job manager, with an optional name
@@ -81,6 +95,34 @@ func NewJobManager(name string, numWorkers int, l log.Logger, metricSink *metric
 	return &j
 }
 
+// SetMaxWorkersPerQueue bounds the number of workers which may process jobs
+// from a single queue at the same time, so that a queue whose jobs hang can't
+// hold on to the whole worker pool. 0 removes the bound. It must be called
+// before the job manager is started.
+func (j *JobManager) SetMaxWorkersPerQueue(n int) {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	if n < 0 {
+		n = 0
+	}
+	j.maxWorkersPerQueue = n
+}
+
+// SetAgingThreshold sets the time after which a waiting job is given
+// priority over the round robin order, so that jobs of a queue which is
+// repeatedly skipped are not starved. 0 disables aging. It must be called
+// before the job manager is started.
+func (j *JobManager) SetAgingThreshold(d time.Duration) {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	if d < 0 {
+		d = 0
+	}
+	j.agingThreshold = d
+}
+
 // Start starts the job manager
 // note: a given job manager cannot be restarted after it has been stopped
 func (j *JobManager) Start() {
@@ -106,8 +148,12 @@ func (j *JobManager) AddJob(job Job, queueID string) {
 	if len(j.queues) == 0 {
 		defer func() {
 			// newWork must be buffered to avoid deadlocks if work is added
-			// before the job manager is started
-			j.newWork <- struct{}{}
+			// before the job manager is started. if a wake-up is already
+			// pending, there is no need for another one.
+			select {
+			case j.newWork <- struct{}{}:
+			default:
+			}
 		}()
 	}
 	defer j.l.Unlock()
@@ -116,7 +162,7 @@ func (j *JobManager) AddJob(job Job, queueID string) {
 		j.addQueue(queueID)
 	}
 
-	j.queues[queueID].PushBack(job)
+	j.queues[queueID].PushBack(&queuedJob{job: job, enqueued: time.Now()})
 	j.totalJobs++
 
 	if j.metricSink != nil {
@@ -191,7 +237,7 @@ func (j *JobManager) getNextJob() (Job, string) {
 		j.removeLastQueueAccessed()
 	}
 
-	return jobRaw.(Job), queueID
+	return jobRaw.(*queuedJob).job, queueID
 }
 
 // returns the next queue to assign work from, and a bool if there is a queue
@@ -199,6 +245,14 @@ func (j *JobManager) getNextJob() (Job, string) {
 // j.lastQueueAccessed will be updated to that queue.
 // note: this must be called with j.l held
 func (j *JobManager) getNextQueue() (string, bool) {
+	if queueIdx, ok := j.agedQueueIndex(); ok {
+		j.lastQueueAccessed = queueIdx
+		if j.metricSink != nil {
+			j.metricSink.IncrCounterWithLabels([]string{j.name, "job_manager", "aged_jobs"}, 1, []metrics.Label{{Name: "queue_id", Value: j.queuesIndex[queueIdx]}})
+		}
+		return j.queuesIndex[queueIdx], true
+	}
+
 	var nextQueue string
 	var canAssignWorker bool
 
@@ -221,6 +275,36 @@ func (j *JobManager) getNextQueue() (string, bool) {
 	return nextQueue, canAssignWorker
 }
 
+// returns the index of the eligible queue whose oldest job has waited the
+// longest, if that job has waited longer than the aging threshold.
+// note: this must be called with j.l held (at least for read).
+func (j *JobManager) agedQueueIndex() (int, bool) {
+	if j.agingThreshold == 0 {
+		return -1, false
+	}
+
+	agedIdx := -1
+	var oldest time.Time
+	cutoff := time.Now().Add(-j.agingThreshold)
+	for idx, queueID := range j.queuesIndex {
+		front := j.queues[queueID].Front()
+		if front == nil {
+			continue
+		}
+
+		enqueued := front.Value.(*queuedJob).enqueued
+		if enqueued.After(cutoff) || j.queueWorkersSaturated(queueID) {
+			continue
+		}
+		if agedIdx == -1 || enqueued.Before(oldest) {
+			agedIdx = idx
+			oldest = enqueued
+		}
+	}
+
+	return agedIdx, agedIdx != -1
+}
+
 // get the index of the next queue in round-robin order
 // note: this must be called with j.l held
 func (j *JobManager) nextQueueIndex(currentIdx int) int {
@@ -233,11 +317,14 @@ func (j *JobManager) nextQueueIndex(currentIdx int) int {
 func (j *JobManager) queueWorkersSaturated(queueID string) bool {
 	numActiveQueues := float64(len(j.queues))
 	numTotalWorkers := float64(j.workerPool.numWorkers)
-	maxWorkersPerQueue := math.Ceil(0.9 * numTotalWorkers / numActiveQueues)
+	maxWorkersPerQueue := int(math.Ceil(0.9 * numTotalWorkers / numActiveQueues))
+	if j.maxWorkersPerQueue > 0 && j.maxWorkersPerQueue < maxWorkersPerQueue {
+		maxWorkersPerQueue = j.maxWorkersPerQueue
+	}
 
 	numWorkersPerQueue := j.workerCount
 
-	return numWorkersPerQueue[queueID] >= int(maxWorkersPerQueue)
+	return numWorkersPerQueue[queueID] >= maxWorkersPerQueue
 }
 
 // increment the worker count for this queue
@@ -260,6 +347,14 @@ func (j *JobManager) decrementWorkerCount(queueID string) {
 	if !queueExists && j.workerCount[queueID] < 1 {
 		delete(j.workerCount, queueID)
 	}
+
+	// wake up the assignment loop, which may be waiting for a queue to have
+	// a worker freed up. if a wake-up is already pending, there is no need
+	// for another one.
+	select {
+	case j.newWork <- struct{}{}:
+	default:
+	}
 }
 
 // assignWork continually loops checks for new jobs and dispatches them to the
@@ -286,10 +381,13 @@ func (j *JobManager) assignWork() {
 
 				job, queueID := j.getNextJob()
 				if job != nil {
+					// count the worker before dispatching, rather than once a
+					// worker picks up the job, so that the next call to
+					// getNextJob can't assign more workers to the queue than
+					// it is allowed
+					j.incrementWorkerCount(queueID)
 					j.workerPool.dispatch(job,
-						func() {
-							j.incrementWorkerCount(queueID)
-						},
+						nil,
 						func() {
 							j.decrementWorkerCount(queueID)
 						})
@@ -747,3 +747,118 @@ func TestFairshare_queueWorkersSaturated(t *testing.T) {
 		j.l.RUnlock()
 	}
 }
+
+func TestFairshare_queueWorkersSaturated_maxWorkersPerQueue(t *testing.T) {
+	j := NewJobManager("test-job-mgr", 20, nil, nil)
+	j.SetMaxWorkersPerQueue(3)
+
+	job := newDefaultTestJob(t, "job-0")
+	j.AddJob(&job, "a")
+	j.AddJob(&job, "b")
+
+	// the fair share would allow 9 workers per queue, but the bound is lower
+	for i := 0; i < 3; i++ {
+		j.l.RLock()
+		saturated := j.queueWorkersSaturated("a")
+		j.l.RUnlock()
+		if saturated {
+			t.Fatalf("queue 'a' falsely saturated: %#v", j.GetWorkerCounts())
+		}
+
+		j.incrementWorkerCount("a")
+	}
+
+	j.l.RLock()
+	defer j.l.RUnlock()
+	if !j.queueWorkersSaturated("a") {
+		t.Fatalf("queue 'a' falsely unsaturated: %#v", j.workerCount)
+	}
+	if j.queueWorkersSaturated("b") {
+		t.Fatalf("queue 'b' falsely saturated: %#v", j.workerCount)
+	}
+}
+
+func TestFairshare_getNextQueue_aging(t *testing.T) {
+	j := NewJobManager("test-job-mgr", 18, nil, nil)
+	j.SetAgingThreshold(time.Minute)
+
+	for _, queueID := range []string{"a", "b", "c"} {
+		job := newDefaultTestJob(t, fmt.Sprintf("job-%s", queueID))
+		j.AddJob(&job, queueID)
+	}
+
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	// no job has waited long enough to be aged, so queues are round robined
+	if queueID, _ := j.getNextQueue(); queueID != "a" {
+		t.Fatalf("expected queue %q, got %q", "a", queueID)
+	}
+
+	// age the job of queue "c", which is then picked ahead of queue "b"
+	j.queues["c"].Front().Value.(*queuedJob).enqueued = time.Now().Add(-2 * time.Minute)
+	if queueID, _ := j.getNextQueue(); queueID != "c" {
+		t.Fatalf("expected aged queue %q, got %q", "c", queueID)
+	}
+
+	// saturated queues are skipped even if their jobs are aged
+	j.workerCount["c"] = 6
+	if queueID, _ := j.getNextQueue(); queueID != "a" {
+		t.Fatalf("expected queue %q, got %q", "a", queueID)
+	}
+}
+
+func TestFairshare_EndToEnd_maxWorkersPerQueue(t *testing.T) {
+	j := NewJobManager("test-job-mgr", 10, nil, nil)
+	j.SetMaxWorkersPerQueue(2)
+	defer j.Stop()
+
+	// jobs of the "hung" queue block until the test ends
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		j.AddJob(&testJob{
+			id: fmt.Sprintf("hung-%d", i),
+			ex: func(string) error {
+				<-unblock
+				return nil
+			},
+			onFail: func(error) {},
+		}, "hung")
+	}
+
+	wg.Add(5)
+	for i := 0; i < 5; i++ {
+		j.AddJob(&testJob{
+			id: fmt.Sprintf("ok-%d", i),
+			ex: func(string) error {
+				wg.Done()
+				return nil
+			},
+			onFail: func(error) {},
+		}, "ok")
+	}
+
+	j.Start()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("jobs of the healthy queue were blocked by the hung queue")
+	}
+
+	j.l.RLock()
+	workers := j.workerCount["hung"]
+	j.l.RUnlock()
+	if workers > 2 {
+		t.Fatalf("expected at most 2 workers on the hung queue, got %d", workers)
+	}
+}
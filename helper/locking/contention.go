@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package locking
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentionDetector reports the attempts at acquiring a MonitoredRWMutex
+// which have been waiting for longer than Timeout, which usually indicates a
+// deadlock or heavy contention of the lock.
+type ContentionDetector struct {
+	// Timeout is how long an attempt at acquiring the lock may wait before
+	// being reported.
+	Timeout time.Duration
+
+	// OnContention is called with the name of the lock, how long the attempt
+	// has been waiting and the stack traces of all goroutines when an attempt
+	// exceeds the timeout. It is called at most once per attempt, from its own
+	// goroutine.
+	OnContention func(name string, waited time.Duration, stacks []byte)
+}
+
+// MonitoredRWMutex wraps a RWMutex so that waiting for it can be reported by a
+// ContentionDetector. The detector can be set and removed at any time, which
+// allows the detection to be toggled without restarting. Without a detector
+// the lock behaves like the lock it wraps.
+type MonitoredRWMutex struct {
+	RWMutex
+
+	name     string
+	detector atomic.Pointer[ContentionDetector]
+}
+
+// NewMonitoredRWMutex returns a MonitoredRWMutex named name wrapping lock.
+func NewMonitoredRWMutex(name string, lock RWMutex) *MonitoredRWMutex {
+	return &MonitoredRWMutex{
+		RWMutex: lock,
+		name:    name,
+	}
+}
+
+// Name returns the name the lock is reported with.
+func (m *MonitoredRWMutex) Name() string {
+	return m.name
+}
+
+// SetDetector sets the detector reporting the contention of the lock, or
+// disables the detection when detector is nil. Attempts which are already
+// waiting keep the detector they started with.
+func (m *MonitoredRWMutex) SetDetector(detector *ContentionDetector) {
+	m.detector.Store(detector)
+}
+
+// Detector returns the detector reporting the contention of the lock, if any.
+func (m *MonitoredRWMutex) Detector() *ContentionDetector {
+	return m.detector.Load()
+}
+
+func (m *MonitoredRWMutex) Lock() {
+	m.acquire(m.RWMutex.Lock)
+}
+
+func (m *MonitoredRWMutex) RLock() {
+	m.acquire(m.RWMutex.RLock)
+}
+
+func (m *MonitoredRWMutex) RLocker() sync.Locker {
+	return monitoredRLocker{m}
+}
+
+func (m *MonitoredRWMutex) acquire(lock func()) {
+	detector := m.detector.Load()
+	if detector == nil || detector.Timeout <= 0 || detector.OnContention == nil {
+		lock()
+		return
+	}
+
+	start := time.Now()
+	timer := time.AfterFunc(detector.Timeout, func() {
+		detector.OnContention(m.name, time.Since(start), goroutineStacks())
+	})
+	lock()
+	timer.Stop()
+}
+
+type monitoredRLocker struct {
+	m *MonitoredRWMutex
+}
+
+func (r monitoredRLocker) Lock()   { r.m.RLock() }
+func (r monitoredRLocker) Unlock() { r.m.RUnlock() }
+
+// goroutineStacks returns the stack traces of all goroutines, growing the
+// buffer until they fit.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package metricsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/hashicorp/go-hclog"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// PrometheusRemoteWriteDefaultInterval is the default interval at which
+// metrics are pushed to a Prometheus remote write endpoint.
+const PrometheusRemoteWriteDefaultInterval = 30 * time.Second
+
+// prometheusRemoteWriteVersion is the version of the remote write protocol
+// the requests are made with.
+const prometheusRemoteWriteVersion = "0.1.0"
+
+// PrometheusRemoteWriterOpts holds the options of a PrometheusRemoteWriter.
+type PrometheusRemoteWriterOpts struct {
+	// URL is the URL of the remote write endpoint.
+	URL string
+
+	// Interval is the interval at which metrics are pushed, which defaults
+	// to PrometheusRemoteWriteDefaultInterval.
+	Interval time.Duration
+
+	// Gatherer is the gatherer the pushed metrics are collected from, which
+	// defaults to prometheus.DefaultGatherer.
+	Gatherer prometheus.Gatherer
+
+	// Labels are added to every pushed series, unless the series has a label
+	// with the same name. Since pushed series have neither the instance nor
+	// the job labels scrapes add, these labels should identify the node.
+	Labels map[string]string
+
+	// BearerToken is sent in the Authorization header of the requests.
+	BearerToken string
+
+	// BearerTokenFile is a file the bearer token is read from before each
+	// request, so that the token can be rotated.
+	BearerTokenFile string
+
+	// TLSConfig is the TLS configuration of the requests, holding the client
+	// certificate for mutual TLS.
+	TLSConfig *tls.Config
+
+	// UserAgent is the User-Agent header of the requests.
+	UserAgent string
+
+	// Logger logs the failures of the periodic pushes.
+	Logger hclog.Logger
+}
+
+// PrometheusRemoteWriter periodically pushes the metrics of a Prometheus
+// gatherer to an endpoint implementing the Prometheus remote write protocol,
+// for environments where the metrics can't be scraped.
+type PrometheusRemoteWriter struct {
+	opts   PrometheusRemoteWriterOpts
+	client *http.Client
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPrometheusRemoteWriter creates a PrometheusRemoteWriter, which pushes
+// metrics once started.
+func NewPrometheusRemoteWriter(opts PrometheusRemoteWriterOpts) (*PrometheusRemoteWriter, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote write URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid remote write URL %q: an http or https URL is required", opts.URL)
+	}
+	if opts.BearerToken != "" && opts.BearerTokenFile != "" {
+		return nil, errors.New("only one of the bearer token and the bearer token file can be specified")
+	}
+	if opts.Interval < 0 {
+		return nil, errors.New("the remote write interval must not be negative")
+	}
+
+	if opts.Interval == 0 {
+		opts.Interval = PrometheusRemoteWriteDefaultInterval
+	}
+	if opts.Gatherer == nil {
+		opts.Gatherer = prometheus.DefaultGatherer
+	}
+	if opts.Logger == nil {
+		opts.Logger = hclog.NewNullLogger()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = opts.TLSConfig
+
+	return &PrometheusRemoteWriter{
+		opts: opts,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Interval,
+		},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}, nil
+}
+
+// Start pushes the metrics at every interval, until Stop is called.
+func (w *PrometheusRemoteWriter) Start() {
+	go func() {
+		defer close(w.doneCh)
+
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stopCh:
+				return
+			case <-ticker.C:
+				if err := w.Push(context.Background()); err != nil {
+					w.opts.Logger.Warn("failed to push metrics to the Prometheus remote write endpoint", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the pushes started by Start.
+func (w *PrometheusRemoteWriter) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
+	<-w.doneCh
+}
+
+// Push pushes the metrics of the gatherer once.
+func (w *PrometheusRemoteWriter) Push(ctx context.Context) error {
+	families, err := w.opts.Gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	body := encodePrometheusWriteRequest(families, w.opts.Labels, time.Now().UnixMilli())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(snappy.Encode(nil, body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", prometheusRemoteWriteVersion)
+	if w.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", w.opts.UserAgent)
+	}
+
+	token := w.opts.BearerToken
+	if w.opts.BearerTokenFile != "" {
+		raw, err := os.ReadFile(w.opts.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the bearer token file: %w", err)
+		}
+		token = strings.TrimSpace(string(raw))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// prometheusLabel is a label of a remote write series.
+type prometheusLabel struct {
+	name  string
+	value string
+}
+
+// encodePrometheusWriteRequest encodes the metric families as the series of
+// a remote write WriteRequest protobuf message, the way a Prometheus server
+// would store the samples of a scrape.
+func encodePrometheusWriteRequest(families []*dto.MetricFamily, extraLabels map[string]string, timestamp int64) []byte {
+	var b []byte
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.Metric {
+			labels := make([]prometheusLabel, 0, len(m.Label)+len(extraLabels))
+			for _, label := range m.Label {
+				labels = append(labels, prometheusLabel{label.GetName(), label.GetValue()})
+			}
+			for k, v := range extraLabels {
+				if !hasPrometheusLabel(labels, k) {
+					labels = append(labels, prometheusLabel{k, v})
+				}
+			}
+
+			ts := timestamp
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			series := func(suffix string, value float64, extra ...prometheusLabel) {
+				seriesLabels := make([]prometheusLabel, 0, len(labels)+len(extra)+1)
+				seriesLabels = append(append(seriesLabels, labels...), extra...)
+				b = appendPrometheusSeries(b, name+suffix, seriesLabels, value, ts)
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				series("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				series("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				series("", m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				for _, q := range summary.Quantile {
+					series("", q.GetValue(), prometheusLabel{"quantile", formatPrometheusFloat(q.GetQuantile())})
+				}
+				series("_sum", summary.GetSampleSum())
+				series("_count", float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := m.GetHistogram()
+				infSeen := false
+				for _, bucket := range histogram.Bucket {
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						infSeen = true
+					}
+					series("_bucket", float64(bucket.GetCumulativeCount()), prometheusLabel{"le", formatPrometheusFloat(bucket.GetUpperBound())})
+				}
+				if !infSeen {
+					series("_bucket", float64(histogram.GetSampleCount()), prometheusLabel{"le", "+Inf"})
+				}
+				series("_sum", histogram.GetSampleSum())
+				series("_count", float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+	return b
+}
+
+func hasPrometheusLabel(labels []prometheusLabel, name string) bool {
+	for _, label := range labels {
+		if label.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatPrometheusFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// appendPrometheusSeries appends a TimeSeries with a single sample to the
+// encoded WriteRequest, sorting the labels by name as the protocol requires.
+func appendPrometheusSeries(b []byte, name string, labels []prometheusLabel, value float64, timestamp int64) []byte {
+	labels = append(labels, prometheusLabel{"__name__", name})
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	var series []byte
+	for _, label := range labels {
+		var l []byte
+		l = protowire.AppendTag(l, 1, protowire.BytesType)
+		l = protowire.AppendString(l, label.name)
+		l = protowire.AppendTag(l, 2, protowire.BytesType)
+		l = protowire.AppendString(l, label.value)
+
+		series = protowire.AppendTag(series, 1, protowire.BytesType)
+		series = protowire.AppendBytes(series, l)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+
+	series = protowire.AppendTag(series, 2, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, series)
+}
@@ -0,0 +1,266 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package metricsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteRequest is a request received by a test remote write endpoint,
+// with its samples keyed by series, such as `name{label="value"}`.
+type remoteWriteRequest struct {
+	header  http.Header
+	samples map[string]float64
+}
+
+// decodeRemoteWriteRequest decodes the samples of a snappy-compressed
+// WriteRequest, ensuring the labels of each series are sorted.
+func decodeRemoteWriteRequest(t *testing.T, body []byte) map[string]float64 {
+	t.Helper()
+
+	raw, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+
+	// fields returns the fields of a message, all of which are of the
+	// length-delimited type but the samples' values and timestamps.
+	fields := func(b []byte) map[protowire.Number][][]byte {
+		m := make(map[protowire.Number][][]byte)
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			var v []byte
+			switch typ {
+			case protowire.BytesType:
+				v, n = protowire.ConsumeBytes(b)
+			case protowire.Fixed64Type:
+				n = protowire.ConsumeFieldValue(num, typ, b)
+				v = b[:n]
+			case protowire.VarintType:
+				n = protowire.ConsumeFieldValue(num, typ, b)
+				v = b[:n]
+			}
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			m[num] = append(m[num], v)
+		}
+		return m
+	}
+
+	samples := make(map[string]float64)
+	for _, series := range fields(raw)[1] {
+		seriesFields := fields(series)
+
+		var name string
+		var labels, names []string
+		for _, label := range seriesFields[1] {
+			labelFields := fields(label)
+			labelName, labelValue := string(labelFields[1][0]), string(labelFields[2][0])
+			names = append(names, labelName)
+			if labelName == "__name__" {
+				name = labelValue
+				continue
+			}
+			labels = append(labels, labelName+`="`+labelValue+`"`)
+		}
+		require.True(t, sort.StringsAreSorted(names), "labels are not sorted: %v", names)
+
+		require.Len(t, seriesFields[2], 1)
+		sample := fields(seriesFields[2][0])
+		value, _ := protowire.ConsumeFixed64(sample[1][0])
+		timestamp, _ := protowire.ConsumeVarint(sample[2][0])
+		require.InDelta(t, time.Now().UnixMilli(), int64(timestamp), float64(time.Minute.Milliseconds()))
+
+		samples[name+"{"+strings.Join(labels, ",")+"}"] = math.Float64frombits(value)
+	}
+	return samples
+}
+
+// testRemoteWriteEndpoint returns a TLS endpoint requiring client
+// certificates, which sends the requests it receives to the returned channel,
+// along with the TLS configuration of its clients.
+func testRemoteWriteEndpoint(t *testing.T) (*httptest.Server, *tls.Config, <-chan remoteWriteRequest) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vault"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	clientCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	requests := make(chan remoteWriteRequest, 10)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer remote-write-token" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		select {
+		case requests <- remoteWriteRequest{
+			header:  r.Header,
+			samples: decodeRemoteWriteRequest(t, body),
+		}:
+		default:
+			// The requests which aren't awaited by the test are dropped
+		}
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srv.Certificate())
+	return srv, &tls.Config{
+		RootCAs: rootCAs,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+		}},
+	}, requests
+}
+
+// TestPrometheusRemoteWriter_Push ensures the metric families are pushed as
+// series over mutual TLS, with the bearer token, and the configured labels
+// unless the series have them.
+func TestPrometheusRemoteWriter_Push(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "vault_core_check_token", Help: "h"}, []string{"namespace"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "vault_core_active", Help: "h", ConstLabels: prometheus.Labels{"cluster": "primary"}})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "vault_core_handle_request", Help: "h", Buckets: []float64{0.5, 1}})
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "vault_barrier_get", Help: "h", Objectives: map[float64]float64{0.5: 0.05}})
+	reg.MustRegister(counter, gauge, histogram, summary)
+
+	counter.WithLabelValues("root").Add(3)
+	gauge.Set(1)
+	histogram.Observe(0.2)
+	histogram.Observe(2)
+	summary.Observe(4)
+
+	srv, tlsConfig, requests := testRemoteWriteEndpoint(t)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("remote-write-token\n"), 0o600))
+
+	w, err := NewPrometheusRemoteWriter(PrometheusRemoteWriterOpts{
+		URL:             srv.URL + "/api/v1/write",
+		Gatherer:        reg,
+		Labels:          map[string]string{"instance": "vault-1", "cluster": "default"},
+		BearerTokenFile: tokenFile,
+		TLSConfig:       tlsConfig,
+		UserAgent:       "Vault",
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Push(context.Background()))
+
+	req := <-requests
+	require.Equal(t, "snappy", req.header.Get("Content-Encoding"))
+	require.Equal(t, "application/x-protobuf", req.header.Get("Content-Type"))
+	require.Equal(t, "0.1.0", req.header.Get("X-Prometheus-Remote-Write-Version"))
+	require.Equal(t, "Vault", req.header.Get("User-Agent"))
+	require.Equal(t, map[string]float64{
+		`vault_core_check_token{cluster="default",instance="vault-1",namespace="root"}`:    3,
+		`vault_core_active{cluster="primary",instance="vault-1"}`:                          1,
+		`vault_core_handle_request_bucket{cluster="default",instance="vault-1",le="0.5"}`:  1,
+		`vault_core_handle_request_bucket{cluster="default",instance="vault-1",le="1"}`:    1,
+		`vault_core_handle_request_bucket{cluster="default",instance="vault-1",le="+Inf"}`: 2,
+		`vault_core_handle_request_sum{cluster="default",instance="vault-1"}`:              2.2,
+		`vault_core_handle_request_count{cluster="default",instance="vault-1"}`:            2,
+		`vault_barrier_get{cluster="default",instance="vault-1",quantile="0.5"}`:           4,
+		`vault_barrier_get_sum{cluster="default",instance="vault-1"}`:                      4,
+		`vault_barrier_get_count{cluster="default",instance="vault-1"}`:                    1,
+	}, req.samples)
+
+	// The token is read from the file before each push
+	require.NoError(t, os.WriteFile(tokenFile, []byte("rotated-token"), 0o600))
+	err = w.Push(context.Background())
+	require.ErrorContains(t, err, "401")
+
+	// Without the client certificate, the handshake fails
+	w, err = NewPrometheusRemoteWriter(PrometheusRemoteWriterOpts{
+		URL:         srv.URL + "/api/v1/write",
+		Gatherer:    reg,
+		BearerToken: "remote-write-token",
+		TLSConfig:   &tls.Config{RootCAs: tlsConfig.RootCAs},
+	})
+	require.NoError(t, err)
+	require.Error(t, w.Push(context.Background()))
+}
+
+// TestPrometheusRemoteWriter_Start ensures the metrics are pushed at every
+// interval until the writer is stopped.
+func TestPrometheusRemoteWriter_Start(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "vault_core_active", Help: "h"})
+	reg.MustRegister(gauge)
+
+	srv, tlsConfig, requests := testRemoteWriteEndpoint(t)
+	w, err := NewPrometheusRemoteWriter(PrometheusRemoteWriterOpts{
+		URL:         srv.URL,
+		Interval:    10 * time.Millisecond,
+		Gatherer:    reg,
+		BearerToken: "remote-write-token",
+		TLSConfig:   tlsConfig,
+	})
+	require.NoError(t, err)
+
+	w.Start()
+	req := <-requests
+	require.Equal(t, map[string]float64{"vault_core_active{}": 0}, req.samples)
+
+	gauge.Set(1)
+	require.Eventually(t, func() bool {
+		req := <-requests
+		return req.samples["vault_core_active{}"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	w.Stop()
+}
+
+func TestNewPrometheusRemoteWriter_Invalid(t *testing.T) {
+	for name, opts := range map[string]PrometheusRemoteWriterOpts{
+		"no URL":         {},
+		"not http":       {URL: "unix:///var/run/prometheus.sock"},
+		"no host":        {URL: "https:///api/v1/write"},
+		"two tokens":     {URL: "https://prometheus:9090/api/v1/write", BearerToken: "t", BearerTokenFile: "/token"},
+		"negative delay": {URL: "https://prometheus:9090/api/v1/write", Interval: -time.Second},
+	} {
+		_, err := NewPrometheusRemoteWriter(opts)
+		require.Error(t, err, name)
+	}
+}
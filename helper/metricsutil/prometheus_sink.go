@@ -0,0 +1,262 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package metricsutil
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	gmprometheus "github.com/armon/go-metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusSummaryMaxAge is the duration over which summary quantiles are
+// calculated, matching the summaries of the go-metrics Prometheus sink.
+const prometheusSummaryMaxAge = 10 * time.Second
+
+// prometheusNameReplacer replaces the characters go-metrics replaces when
+// flattening metric keys into Prometheus metric names.
+var prometheusNameReplacer = strings.NewReplacer(" ", "_", ".", "_", "=", "_", "-", "_", "/", "_")
+
+// PrometheusSinkOpts holds the options of a PrometheusSink.
+type PrometheusSinkOpts struct {
+	// Expiration is the duration after which metrics which are not updated
+	// are no longer reported. A zero value disables expiration.
+	Expiration time.Duration
+
+	// Registerer is the registerer the sink registers with, which defaults
+	// to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// HistogramBuckets maps metric names, or prefixes of metric names, to the
+	// upper bounds of the buckets of the histograms the matching samples are
+	// reported as. Names may use either dots or underscores as separators.
+	HistogramBuckets map[string][]float64
+
+	// SummaryQuantiles overrides the quantiles of the summaries the other
+	// samples are reported as.
+	SummaryQuantiles []float64
+}
+
+// PrometheusSink is a metrics sink which wraps the go-metrics Prometheus sink,
+// reporting samples as histograms with configurable buckets, or as summaries
+// with configurable quantiles, instead of as summaries with fixed quantiles.
+type PrometheusSink struct {
+	sink       *gmprometheus.PrometheusSink
+	expiration time.Duration
+	buckets    map[string][]float64
+	objectives map[float64]float64
+
+	// observers holds the histograms and summaries of the samples handled
+	// by this sink, keyed by metric name and labels.
+	observers sync.Map
+}
+
+var (
+	_ metrics.MetricSink   = (*PrometheusSink)(nil)
+	_ prometheus.Collector = (*PrometheusSink)(nil)
+)
+
+// prometheusObserver is a histogram or summary along with the time it was
+// last updated, so that it can be expired.
+type prometheusObserver struct {
+	prometheus.Observer
+	collector prometheus.Collector
+	updatedAt atomic.Int64
+}
+
+// NewPrometheusSink creates a PrometheusSink along with the go-metrics
+// Prometheus sink it wraps, registering both.
+func NewPrometheusSink(opts PrometheusSinkOpts) (*PrometheusSink, error) {
+	if err := ValidatePrometheusHistogramBuckets(opts.HistogramBuckets); err != nil {
+		return nil, err
+	}
+	if err := ValidatePrometheusSummaryQuantiles(opts.SummaryQuantiles); err != nil {
+		return nil, err
+	}
+
+	reg := opts.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	sink, err := gmprometheus.NewPrometheusSinkFrom(gmprometheus.PrometheusOpts{
+		Expiration: opts.Expiration,
+		Registerer: reg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PrometheusSink{
+		sink:       sink,
+		expiration: opts.Expiration,
+		buckets:    make(map[string][]float64, len(opts.HistogramBuckets)),
+	}
+	for name, buckets := range opts.HistogramBuckets {
+		p.buckets[prometheusNameReplacer.Replace(name)] = buckets
+	}
+	if len(opts.SummaryQuantiles) > 0 {
+		p.objectives = make(map[float64]float64, len(opts.SummaryQuantiles))
+		for _, q := range opts.SummaryQuantiles {
+			// The allowed error matches the go-metrics summaries, for
+			// instance 0.01 for the 0.9 quantile.
+			p.objectives[q] = math.Min(q, 1-q) / 10
+		}
+	}
+
+	return p, reg.Register(p)
+}
+
+// ValidatePrometheusHistogramBuckets ensures the buckets of each histogram are
+// in increasing order.
+func ValidatePrometheusHistogramBuckets(buckets map[string][]float64) error {
+	for name, b := range buckets {
+		if len(b) == 0 {
+			return fmt.Errorf("no buckets specified for histogram %q", name)
+		}
+		if !sort.IsSorted(sort.Float64Slice(b)) {
+			return fmt.Errorf("buckets of histogram %q must be in increasing order", name)
+		}
+		for i := 1; i < len(b); i++ {
+			if b[i] == b[i-1] {
+				return fmt.Errorf("duplicate bucket %v for histogram %q", b[i], name)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePrometheusSummaryQuantiles ensures the quantiles are between 0 and 1.
+func ValidatePrometheusSummaryQuantiles(quantiles []float64) error {
+	for _, q := range quantiles {
+		if q <= 0 || q >= 1 {
+			return fmt.Errorf("invalid summary quantile %v: quantiles must be between 0 and 1", q)
+		}
+	}
+	return nil
+}
+
+func (p *PrometheusSink) SetGauge(key []string, val float32) {
+	p.sink.SetGauge(key, val)
+}
+
+func (p *PrometheusSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	p.sink.SetGaugeWithLabels(key, val, labels)
+}
+
+func (p *PrometheusSink) EmitKey(key []string, val float32) {
+	p.sink.EmitKey(key, val)
+}
+
+func (p *PrometheusSink) IncrCounter(key []string, val float32) {
+	p.sink.IncrCounter(key, val)
+}
+
+func (p *PrometheusSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	p.sink.IncrCounterWithLabels(key, val, labels)
+}
+
+func (p *PrometheusSink) AddSample(key []string, val float32) {
+	p.AddSampleWithLabels(key, val, nil)
+}
+
+// AddSampleWithLabels records the sample in a histogram when its name matches
+// configured buckets, or in a summary with the configured quantiles. Other
+// samples are handled by the wrapped sink.
+func (p *PrometheusSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	name := prometheusNameReplacer.Replace(strings.Join(key, "_"))
+	hash := name
+	for _, label := range labels {
+		hash += ";" + label.Name + "=" + label.Value
+	}
+
+	if o, ok := p.observers.Load(hash); ok {
+		o.(*prometheusObserver).observe(val)
+		return
+	}
+
+	constLabels := make(prometheus.Labels, len(labels))
+	for _, label := range labels {
+		constLabels[label.Name] = label.Value
+	}
+
+	var collector prometheus.Collector
+	switch buckets := p.histogramBuckets(name); {
+	case buckets != nil:
+		collector = prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        name,
+			Help:        name,
+			ConstLabels: constLabels,
+			Buckets:     buckets,
+		})
+	case p.objectives != nil:
+		collector = prometheus.NewSummary(prometheus.SummaryOpts{
+			Name:        name,
+			Help:        name,
+			ConstLabels: constLabels,
+			MaxAge:      prometheusSummaryMaxAge,
+			Objectives:  p.objectives,
+		})
+	default:
+		p.sink.AddSampleWithLabels(key, val, labels)
+		return
+	}
+
+	o, _ := p.observers.LoadOrStore(hash, &prometheusObserver{
+		Observer:  collector.(prometheus.Observer),
+		collector: collector,
+	})
+	o.(*prometheusObserver).observe(val)
+}
+
+// histogramBuckets returns the buckets configured for the longest prefix of
+// the metric name, or nil if there is none.
+func (p *PrometheusSink) histogramBuckets(name string) []float64 {
+	var match string
+	var buckets []float64
+	for prefix, b := range p.buckets {
+		if name != prefix && !strings.HasPrefix(name, prefix+"_") {
+			continue
+		}
+		if buckets == nil || len(prefix) > len(match) {
+			match, buckets = prefix, b
+		}
+	}
+	return buckets
+}
+
+func (o *prometheusObserver) observe(val float32) {
+	o.Observe(float64(val))
+	o.updatedAt.Store(time.Now().UnixNano())
+}
+
+// Describe sends no descriptors, making the sink an unchecked collector, as
+// metrics are added during the lifetime of the sink.
+func (p *PrometheusSink) Describe(_ chan<- *prometheus.Desc) {}
+
+// Collect collects the histograms and summaries of the sink, removing those
+// which have not been updated within the expiration duration.
+func (p *PrometheusSink) Collect(c chan<- prometheus.Metric) {
+	p.collectAtTime(c, time.Now())
+}
+
+func (p *PrometheusSink) collectAtTime(c chan<- prometheus.Metric, t time.Time) {
+	p.observers.Range(func(k, v interface{}) bool {
+		o := v.(*prometheusObserver)
+		updatedAt := time.Unix(0, o.updatedAt.Load())
+		if p.expiration != 0 && updatedAt.Add(p.expiration).Before(t) {
+			p.observers.Delete(k)
+			return true
+		}
+		o.collector.Collect(c)
+		return true
+	})
+}
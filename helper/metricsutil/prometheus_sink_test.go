@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package metricsutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func gatherMetricFamilies(t *testing.T, reg *prometheus.Registry) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	m := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		m[family.GetName()] = family
+	}
+	return m
+}
+
+// TestPrometheusSink_Histogram ensures samples matching configured buckets are
+// reported as histograms, and others as summaries with configured quantiles.
+func TestPrometheusSink_Histogram(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	sink, err := NewPrometheusSink(PrometheusSinkOpts{
+		Registerer: reg,
+		HistogramBuckets: map[string][]float64{
+			"vault.core":                {1, 10},
+			"vault.core.handle_request": {0.1, 0.5, 1},
+			"vault_raft-storage":        {0.01, 0.1},
+		},
+		SummaryQuantiles: []float64{0.5, 0.999},
+	})
+	require.NoError(t, err)
+
+	sink.AddSampleWithLabels([]string{"vault", "core", "handle_request"}, 0.2, []metrics.Label{{Name: "namespace", Value: "root"}})
+	sink.AddSample([]string{"vault", "core", "handle_login_request"}, 5)
+	sink.AddSample([]string{"vault", "raft-storage", "get"}, 0.05)
+	sink.AddSample([]string{"vault", "barrier", "get"}, 2)
+	sink.IncrCounter([]string{"vault", "core", "check_token"}, 1)
+
+	families := gatherMetricFamilies(t, reg)
+
+	handleRequest := families["vault_core_handle_request"]
+	require.NotNil(t, handleRequest)
+	require.Equal(t, dto.MetricType_HISTOGRAM, handleRequest.GetType())
+	require.Len(t, handleRequest.Metric, 1)
+	require.Equal(t, "namespace", handleRequest.Metric[0].Label[0].GetName())
+	var upperBounds []float64
+	for _, bucket := range handleRequest.Metric[0].GetHistogram().Bucket {
+		upperBounds = append(upperBounds, bucket.GetUpperBound())
+	}
+	require.Equal(t, []float64{0.1, 0.5, 1}, upperBounds)
+
+	require.Equal(t, dto.MetricType_HISTOGRAM, families["vault_core_handle_login_request"].GetType())
+	require.Len(t, families["vault_core_handle_login_request"].Metric[0].GetHistogram().Bucket, 2)
+	require.Equal(t, dto.MetricType_HISTOGRAM, families["vault_raft_storage_get"].GetType())
+
+	barrier := families["vault_barrier_get"]
+	require.Equal(t, dto.MetricType_SUMMARY, barrier.GetType())
+	var quantiles []float64
+	for _, q := range barrier.Metric[0].GetSummary().Quantile {
+		quantiles = append(quantiles, q.GetQuantile())
+	}
+	require.Equal(t, []float64{0.5, 0.999}, quantiles)
+
+	require.Equal(t, dto.MetricType_COUNTER, families["vault_core_check_token"].GetType())
+}
+
+// TestPrometheusSink_Default ensures samples are handled by the wrapped sink
+// when no quantiles are configured.
+func TestPrometheusSink_Default(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	sink, err := NewPrometheusSink(PrometheusSinkOpts{
+		Registerer:       reg,
+		HistogramBuckets: map[string][]float64{"vault.core.handle_request": {1, 10}},
+	})
+	require.NoError(t, err)
+
+	sink.AddSample([]string{"vault", "barrier", "get"}, 2)
+
+	families := gatherMetricFamilies(t, reg)
+	barrier := families["vault_barrier_get"]
+	require.Equal(t, dto.MetricType_SUMMARY, barrier.GetType())
+	require.Len(t, barrier.Metric[0].GetSummary().Quantile, 3)
+}
+
+// TestPrometheusSink_Expiration ensures metrics which have not been updated
+// within the expiration duration are no longer collected.
+func TestPrometheusSink_Expiration(t *testing.T) {
+	sink, err := NewPrometheusSink(PrometheusSinkOpts{
+		Expiration:       time.Minute,
+		Registerer:       prometheus.NewRegistry(),
+		HistogramBuckets: map[string][]float64{"vault.core.handle_request": {1, 10}},
+	})
+	require.NoError(t, err)
+
+	sink.AddSample([]string{"vault", "core", "handle_request"}, 2)
+
+	collect := func(at time.Time) int {
+		c := make(chan prometheus.Metric, 10)
+		sink.collectAtTime(c, at)
+		close(c)
+		return len(c)
+	}
+	require.Equal(t, 1, collect(time.Now()))
+	require.Equal(t, 0, collect(time.Now().Add(2*time.Minute)))
+	require.Equal(t, 0, collect(time.Now()))
+}
+
+// TestNewPrometheusSink_Validation ensures invalid buckets and quantiles are
+// rejected.
+func TestNewPrometheusSink_Validation(t *testing.T) {
+	tests := map[string]PrometheusSinkOpts{
+		"empty-buckets":     {HistogramBuckets: map[string][]float64{"vault.core": {}}},
+		"unsorted-buckets":  {HistogramBuckets: map[string][]float64{"vault.core": {10, 1}}},
+		"duplicate-buckets": {HistogramBuckets: map[string][]float64{"vault.core": {1, 1}}},
+		"invalid-quantile":  {SummaryQuantiles: []float64{0.5, 1}},
+		"zero-quantile":     {SummaryQuantiles: []float64{0}},
+		"negative-quantile": {SummaryQuantiles: []float64{-0.5}},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts.Registerer = prometheus.NewRegistry()
+			_, err := NewPrometheusSink(opts)
+			require.Error(t, err)
+		})
+	}
+}
@@ -41,6 +41,71 @@ type TelemetryConstConfig struct {
 	NumLeaseMetricsTimeBuckets       int
 	LeaseMetricsNameSpaceLabels      bool
 	RollbackMetricsIncludeMountPoint bool
+
+	// NamespaceLabels and MountPointLabels add the namespace and mount point
+	// labels to the request and lease metrics.
+	NamespaceLabels  bool
+	MountPointLabels bool
+
+	// NamespaceLabelAllowlist and MountPointLabelAllowlist limit the values
+	// of the namespace and mount point labels, to control their cardinality.
+	// Values which are not allowed are reported as OtherLabelValue.
+	NamespaceLabelAllowlist  []string
+	MountPointLabelAllowlist []string
+}
+
+// OtherLabelValue is the value of namespace and mount point labels which are
+// not in the configured allowlist.
+const OtherLabelValue = "other"
+
+// NamespaceLabel creates a metrics label for the given namespace, limited to
+// the namespace allowlist.
+func (c TelemetryConstConfig) NamespaceLabel(ns *namespace.Namespace) Label {
+	label := NamespaceLabel(ns)
+	if !labelValueAllowed(c.NamespaceLabelAllowlist, label.Value) {
+		label.Value = OtherLabelValue
+	}
+	return label
+}
+
+// MountPointLabel creates a metrics label for the given mount point, which
+// should not include the namespace path, limited to the mount point allowlist.
+func (c TelemetryConstConfig) MountPointLabel(mountPoint string) Label {
+	label := Label{"mount_point", mountPoint}
+	if !labelValueAllowed(c.MountPointLabelAllowlist, mountPoint) {
+		label.Value = OtherLabelValue
+	}
+	return label
+}
+
+// RequestLabels returns the namespace and mount point labels configured to be
+// added to the request and lease metrics.
+func (c TelemetryConstConfig) RequestLabels(ns *namespace.Namespace, mountPoint string) []Label {
+	var labels []Label
+	if c.NamespaceLabels {
+		labels = append(labels, c.NamespaceLabel(ns))
+	}
+	if c.MountPointLabels {
+		labels = append(labels, c.MountPointLabel(mountPoint))
+	}
+	return labels
+}
+
+// labelValueAllowed reports whether the label value is in the allowlist,
+// ignoring leading and trailing slashes. Every value is allowed when the
+// allowlist is empty.
+func labelValueAllowed(allowlist []string, value string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	value = strings.Trim(value, "/")
+	for _, allowed := range allowlist {
+		if strings.Trim(allowed, "/") == value {
+			return true
+		}
+	}
+	return false
 }
 
 type Metrics interface {
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/stretchr/testify/require"
 )
 
 func isLabelPresent(toFind Label, ls []Label) bool {
@@ -111,3 +113,24 @@ func TestClusterLabelPresent(t *testing.T) {
 		t.Error("Sample label", s.Labels, "does not include", clusterLabel)
 	}
 }
+
+// TestTelemetryConstConfig_RequestLabels verifies the namespace and mount point
+// labels are only added when configured, and are limited to the allowlists.
+func TestTelemetryConstConfig_RequestLabels(t *testing.T) {
+	tenant := &namespace.Namespace{ID: "tenant", Path: "tenant-a/"}
+	other := &namespace.Namespace{ID: "other", Path: "tenant-b/"}
+
+	c := TelemetryConstConfig{}
+	require.Empty(t, c.RequestLabels(tenant, "secret/"))
+
+	c.NamespaceLabels = true
+	c.MountPointLabels = true
+	require.Equal(t, []Label{{"namespace", "tenant-a"}, {"mount_point", "secret/"}}, c.RequestLabels(tenant, "secret/"))
+	require.Equal(t, []Label{{"namespace", "root"}, {"mount_point", ""}}, c.RequestLabels(namespace.RootNamespace, ""))
+
+	c.NamespaceLabelAllowlist = []string{"root", "tenant-a/"}
+	c.MountPointLabelAllowlist = []string{"/secret"}
+	require.Equal(t, []Label{{"namespace", "tenant-a"}, {"mount_point", "secret/"}}, c.RequestLabels(tenant, "secret/"))
+	require.Equal(t, []Label{{"namespace", OtherLabelValue}, {"mount_point", OtherLabelValue}}, c.RequestLabels(other, "kv/"))
+	require.Equal(t, Label{"namespace", "root"}, c.NamespaceLabel(nil))
+}
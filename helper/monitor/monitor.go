@@ -5,6 +5,8 @@ package monitor
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/hashicorp/go-hclog"
@@ -24,6 +26,47 @@ type Monitor interface {
 	Stop()
 }
 
+// Filter restricts the logs streamed by a Monitor.
+type Filter struct {
+	// Modules restricts the logs to those of the loggers whose name has one
+	// of the modules as a dot-separated component. For instance, the module
+	// raft matches the storage.raft logger.
+	Modules []string
+
+	// RateLimit is the maximum number of logs streamed per second. Logs over
+	// the rate limit are dropped. A zero value disables the rate limit.
+	RateLimit int
+}
+
+// matches returns whether the logger name has one of the modules as a
+// component.
+func (f *Filter) matches(name string) bool {
+	if len(f.Modules) == 0 {
+		return true
+	}
+	for _, component := range strings.Split(name, ".") {
+		for _, module := range f.Modules {
+			if component == module {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filteredSink is a SinkAdapter which only accepts the logs of the loggers
+// matching a filter.
+type filteredSink struct {
+	log.SinkAdapter
+	filter *Filter
+}
+
+func (s *filteredSink) Accept(name string, level log.Level, msg string, args ...interface{}) {
+	if s.filter.matches(name) {
+		s.SinkAdapter.Accept(name, level, msg, args...)
+	}
+}
+
 // monitor implements the Monitor interface. Note that this
 // struct is not threadsafe.
 type monitor struct {
@@ -43,6 +86,16 @@ type monitor struct {
 	droppedCount *atomic.Uint32
 	bufSize      int
 
+	// rateLimit is the maximum number of messages sent per second, and
+	// rateLimitedCount the current count of messages that were dropped
+	// because of it. The messages sent during the current second are
+	// counted in windowCount, guarded by windowLock.
+	rateLimit        int
+	rateLimitedCount *atomic.Uint32
+	windowLock       sync.Mutex
+	windowStart      time.Time
+	windowCount      int
+
 	// dropCheckInterval is the amount of time we should
 	// wait to check for dropped messages. Defaults
 	// to 3 seconds
@@ -57,13 +110,22 @@ type monitor struct {
 // NewMonitor creates a new Monitor. Start must be called in order to actually start
 // streaming logs. buf is the buffer size of the channel that sends log messages.
 func NewMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (Monitor, error) {
-	return newMonitor(buf, logger, opts)
+	return newMonitor(buf, logger, opts, nil)
 }
 
-func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*monitor, error) {
+// NewFilteredMonitor creates a new Monitor which only streams the logs
+// matching the filter, up to its rate limit.
+func NewFilteredMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (Monitor, error) {
+	return newMonitor(buf, logger, opts, filter)
+}
+
+func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (*monitor, error) {
 	if buf <= 0 {
 		return nil, fmt.Errorf("buf must be greater than zero")
 	}
+	if filter != nil && filter.RateLimit < 0 {
+		return nil, fmt.Errorf("rate limit must not be negative")
+	}
 
 	sw := &monitor{
 		logger:            logger,
@@ -72,12 +134,19 @@ func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*
 		bufSize:           buf,
 		dropCheckInterval: 3 * time.Second,
 		droppedCount:      atomic.NewUint32(0),
+		rateLimitedCount:  atomic.NewUint32(0),
 		started:           atomic.NewBool(false),
 	}
 
 	opts.Output = sw
 	sink := log.NewSinkAdapter(opts)
 	sw.sink = sink
+	if filter != nil {
+		sw.rateLimit = filter.RateLimit
+		if len(filter.Modules) > 0 {
+			sw.sink = &filteredSink{SinkAdapter: sink, filter: filter}
+		}
+	}
 
 	return sw, nil
 }
@@ -128,6 +197,11 @@ func (d *monitor) Start() <-chan []byte {
 					logMessage = []byte(fmt.Sprintf("Monitor dropped %d logs during monitor request\n", dc))
 					d.droppedCount.Swap(0)
 				}
+
+				// Likewise for messages over the rate limit
+				if rc := d.rateLimitedCount.Swap(0); rc > 0 {
+					logMessage = append(logMessage, []byte(fmt.Sprintf("Monitor dropped %d logs over the rate limit of %d logs per second\n", rc, d.rateLimit))...)
+				}
 			case logMessage = <-d.logCh:
 			case <-d.doneCh:
 				return
@@ -156,6 +230,11 @@ func (d *monitor) Write(p []byte) (n int, err error) {
 	default:
 	}
 
+	if !d.allow(time.Now()) {
+		d.rateLimitedCount.Add(1)
+		return len(p), nil
+	}
+
 	bytes := make([]byte, len(p))
 	copy(bytes, p)
 
@@ -167,3 +246,24 @@ func (d *monitor) Write(p []byte) (n int, err error) {
 
 	return len(p), nil
 }
+
+// allow returns whether a message written at the given time is within the
+// rate limit, counting it if so.
+func (d *monitor) allow(now time.Time) bool {
+	if d.rateLimit == 0 {
+		return true
+	}
+
+	d.windowLock.Lock()
+	defer d.windowLock.Unlock()
+
+	if now.Sub(d.windowStart) >= time.Second {
+		d.windowStart = now
+		d.windowCount = 0
+	}
+	if d.windowCount >= d.rateLimit {
+		return false
+	}
+	d.windowCount++
+	return true
+}
@@ -112,7 +112,7 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 
 	m, _ := newMonitor(5, logger, &log.LoggerOptions{
 		Level: log.Debug,
-	})
+	}, nil)
 	m.dropCheckInterval = 5 * time.Millisecond
 
 	logCh := m.Start()
@@ -138,3 +138,102 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 		require.Fail(t, "expected to see warn dropped messages")
 	}
 }
+
+// Ensure only the logs of the filtered modules are streamed
+func TestMonitor_FilterModules(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	m, err := NewFilteredMonitor(512, logger, &log.LoggerOptions{
+		Level: log.Debug,
+	}, &Filter{
+		Modules: []string{"raft", "expiration"},
+	})
+	require.NoError(t, err)
+
+	logCh := m.Start()
+	defer m.Stop()
+
+	logger.Named("core").Debug("core log")
+	logger.Named("storage").Named("raft").Debug("raft log")
+	logger.Named("expiration").Named("job-manager").Debug("job manager log")
+	logger.Named("rafters").Debug("rafters log")
+
+	var logs []string
+	for len(logs) < 2 {
+		select {
+		case l := <-logCh:
+			logs = append(logs, string(l))
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected to receive from log channel")
+		}
+	}
+	require.Contains(t, logs[0], "storage.raft: raft log")
+	require.Contains(t, logs[1], "expiration.job-manager: job manager log")
+
+	select {
+	case l := <-logCh:
+		t.Fatalf("Unexpected log: %s", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// Ensure logs over the rate limit are dropped and reported
+func TestMonitor_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	m, err := newMonitor(512, logger, &log.LoggerOptions{
+		Level: log.Debug,
+	}, &Filter{
+		RateLimit: 5,
+	})
+	require.NoError(t, err)
+	m.dropCheckInterval = 5 * time.Millisecond
+
+	logCh := m.Start()
+	defer m.Stop()
+
+	for i := 0; i < 20; i++ {
+		logger.Debug(fmt.Sprintf("test message %d", i))
+	}
+
+	var received int
+	var dropped bool
+	for !dropped || received < 5 {
+		select {
+		case l := <-logCh:
+			if strings.Contains(string(l), "Monitor dropped") {
+				require.Contains(t, string(l), "Monitor dropped 15 logs over the rate limit of 5 logs per second")
+				dropped = true
+				continue
+			}
+			received++
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "expected to see rate limited messages")
+		}
+	}
+
+	select {
+	case l := <-logCh:
+		t.Fatalf("Unexpected log: %s", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMonitor_RateLimit_Negative(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	_, err := NewFilteredMonitor(512, logger, &log.LoggerOptions{}, &Filter{RateLimit: -1})
+	require.Error(t, err)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// EnvConfigPath is the environment variable of the path of the configuration
+// file of the library loaded by applications, which cannot be given options.
+const EnvConfigPath = "VAULT_PKCS11_CONFIG"
+
+// Config is the configuration of the provider
+type Config struct {
+	LogLevel string        `hcl:"log_level"`
+	Slots    []*SlotConfig `hcl:"-"`
+}
+
+// SlotConfig is the configuration of a slot, whose token holds the keys of a
+// transit mount
+type SlotConfig struct {
+	Label     string `hcl:"label"`
+	Mount     string `hcl:"mount"`
+	Namespace string `hcl:"namespace"`
+}
+
+// DefaultConfig returns the configuration used without a configuration file,
+// with a single slot for the keys of the transit mount at "transit".
+func DefaultConfig() *Config {
+	return &Config{
+		LogLevel: "warn",
+		Slots: []*SlotConfig{
+			{
+				Label: "transit",
+				Mount: "transit",
+			},
+		},
+	}
+}
+
+// LoadConfig loads the configuration at the path of the environment variable
+// EnvConfigPath, or the default configuration if it is not set.
+func LoadConfig() (*Config, error) {
+	path := os.Getenv(EnvConfigPath)
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	return LoadConfigFile(path)
+}
+
+// LoadConfigFile loads the configuration file at the path.
+func LoadConfigFile(path string) (*Config, error) {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(string(d))
+}
+
+// ParseConfig parses the HCL or JSON contents of a configuration file.
+func ParseConfig(d string) (*Config, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, errors.New("error parsing: file doesn't contain a root object")
+	}
+
+	var result Config
+	if err := hcl.DecodeObject(&result, list); err != nil {
+		return nil, err
+	}
+	if result.LogLevel == "" {
+		result.LogLevel = "warn"
+	}
+
+	if err := parseSlots(&result, list); err != nil {
+		return nil, fmt.Errorf("error parsing 'slot': %w", err)
+	}
+
+	return &result, nil
+}
+
+func parseSlots(result *Config, list *ast.ObjectList) error {
+	slotList := list.Filter("slot")
+	if len(slotList.Items) == 0 {
+		return errors.New("at least one 'slot' is required")
+	}
+
+	var errs *multierror.Error
+	labels := make(map[string]struct{})
+	for i, item := range slotList.Items {
+		var slot SlotConfig
+		if err := hcl.DecodeObject(&slot, item.Val); err != nil {
+			return err
+		}
+
+		if slot.Mount == "" {
+			errs = multierror.Append(errs, fmt.Errorf("slot %d: 'mount' is required", i))
+			continue
+		}
+		if slot.Label == "" {
+			slot.Label = slot.Mount
+		}
+
+		// Token labels are padded to 32 bytes
+		if len(slot.Label) > 32 {
+			errs = multierror.Append(errs, fmt.Errorf("slot %d: 'label' must be at most 32 bytes", i))
+			continue
+		}
+		if _, ok := labels[slot.Label]; ok {
+			errs = multierror.Append(errs, fmt.Errorf("slot %d: duplicate label %q", i, slot.Label))
+			continue
+		}
+		labels[slot.Label] = struct{}{}
+
+		result.Slots = append(result.Slots, &slot)
+	}
+
+	return errs.ErrorOrNil()
+}
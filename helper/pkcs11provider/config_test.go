@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseConfig(t *testing.T) {
+	conf, err := ParseConfig(`
+log_level = "debug"
+
+slot {
+  mount = "transit"
+}
+
+slot {
+  label     = "signing"
+  mount     = "transit-signing"
+  namespace = "ns1/"
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Config{
+		LogLevel: "debug",
+		Slots: []*SlotConfig{
+			{
+				Label: "transit",
+				Mount: "transit",
+			},
+			{
+				Label:     "signing",
+				Mount:     "transit-signing",
+				Namespace: "ns1/",
+			},
+		},
+	}
+	if diff := deep.Equal(conf, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestParseConfig_Bad(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"no slot": {
+			config: `log_level = "info"`,
+			err:    "at least one 'slot' is required",
+		},
+		"no mount": {
+			config: `slot { label = "transit" }`,
+			err:    "slot 0: 'mount' is required",
+		},
+		"long label": {
+			config: `slot { mount = "transit-with-a-name-longer-than-a-label" }`,
+			err:    "slot 0: 'label' must be at most 32 bytes",
+		},
+		"duplicate label": {
+			config: `
+slot { mount = "transit" }
+slot {
+  label = "transit"
+  mount = "transit-signing"
+}`,
+			err: `slot 1: duplicate label "transit"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig(tc.config)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q, got %q", tc.err, err)
+			}
+		})
+	}
+}
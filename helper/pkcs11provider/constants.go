@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+// The PKCS#11 constants used by the provider, from the PKCS#11 v2.40 and
+// v3.0 specifications. They keep the names of the specification so that the
+// code can be read alongside it.
+
+// Return values
+const (
+	CKR_OK                             = 0x00000000
+	CKR_HOST_MEMORY                    = 0x00000002
+	CKR_SLOT_ID_INVALID                = 0x00000003
+	CKR_GENERAL_ERROR                  = 0x00000005
+	CKR_FUNCTION_FAILED                = 0x00000006
+	CKR_ARGUMENTS_BAD                  = 0x00000007
+	CKR_CANT_LOCK                      = 0x0000000A
+	CKR_ATTRIBUTE_SENSITIVE            = 0x00000011
+	CKR_ATTRIBUTE_TYPE_INVALID         = 0x00000012
+	CKR_DATA_INVALID                   = 0x00000020
+	CKR_DATA_LEN_RANGE                 = 0x00000021
+	CKR_DEVICE_ERROR                   = 0x00000030
+	CKR_ENCRYPTED_DATA_INVALID         = 0x00000040
+	CKR_FUNCTION_NOT_PARALLEL          = 0x00000051
+	CKR_FUNCTION_NOT_SUPPORTED         = 0x00000054
+	CKR_KEY_HANDLE_INVALID             = 0x00000060
+	CKR_KEY_TYPE_INCONSISTENT          = 0x00000063
+	CKR_KEY_FUNCTION_NOT_PERMITTED     = 0x00000068
+	CKR_MECHANISM_INVALID              = 0x00000070
+	CKR_MECHANISM_PARAM_INVALID        = 0x00000071
+	CKR_OBJECT_HANDLE_INVALID          = 0x00000082
+	CKR_OPERATION_ACTIVE               = 0x00000090
+	CKR_OPERATION_NOT_INITIALIZED      = 0x00000091
+	CKR_PIN_INCORRECT                  = 0x000000A0
+	CKR_SESSION_HANDLE_INVALID         = 0x000000B3
+	CKR_SESSION_PARALLEL_NOT_SUPPORTED = 0x000000B4
+	CKR_SIGNATURE_INVALID              = 0x000000C0
+	CKR_SIGNATURE_LEN_RANGE            = 0x000000C1
+	CKR_USER_ALREADY_LOGGED_IN         = 0x00000100
+	CKR_USER_NOT_LOGGED_IN             = 0x00000101
+	CKR_USER_TYPE_INVALID              = 0x00000103
+	CKR_RANDOM_SEED_NOT_SUPPORTED      = 0x00000120
+	CKR_BUFFER_TOO_SMALL               = 0x00000150
+	CKR_CRYPTOKI_NOT_INITIALIZED       = 0x00000190
+	CKR_CRYPTOKI_ALREADY_INITIALIZED   = 0x00000191
+)
+
+// Object classes
+const (
+	CKO_CERTIFICATE = 0x00000001
+	CKO_PUBLIC_KEY  = 0x00000002
+	CKO_PRIVATE_KEY = 0x00000003
+	CKO_SECRET_KEY  = 0x00000004
+)
+
+// Key types
+const (
+	CKK_RSA        = 0x00000000
+	CKK_EC         = 0x00000003
+	CKK_AES        = 0x0000001F
+	CKK_CHACHA20   = 0x00000033
+	CKK_EC_EDWARDS = 0x00000040
+)
+
+// Certificate types
+const (
+	CKC_X_509 = 0x00000000
+)
+
+// Attributes
+const (
+	CKA_CLASS               = 0x00000000
+	CKA_TOKEN               = 0x00000001
+	CKA_PRIVATE             = 0x00000002
+	CKA_LABEL               = 0x00000003
+	CKA_VALUE               = 0x00000011
+	CKA_CERTIFICATE_TYPE    = 0x00000080
+	CKA_ISSUER              = 0x00000081
+	CKA_SERIAL_NUMBER       = 0x00000082
+	CKA_KEY_TYPE            = 0x00000100
+	CKA_SUBJECT             = 0x00000101
+	CKA_ID                  = 0x00000102
+	CKA_SENSITIVE           = 0x00000103
+	CKA_ENCRYPT             = 0x00000104
+	CKA_DECRYPT             = 0x00000105
+	CKA_WRAP                = 0x00000106
+	CKA_UNWRAP              = 0x00000107
+	CKA_SIGN                = 0x00000108
+	CKA_SIGN_RECOVER        = 0x00000109
+	CKA_VERIFY              = 0x0000010A
+	CKA_VERIFY_RECOVER      = 0x0000010B
+	CKA_DERIVE              = 0x0000010C
+	CKA_MODULUS             = 0x00000120
+	CKA_MODULUS_BITS        = 0x00000121
+	CKA_PUBLIC_EXPONENT     = 0x00000122
+	CKA_PUBLIC_KEY_INFO     = 0x00000129
+	CKA_VALUE_LEN           = 0x00000161
+	CKA_EXTRACTABLE         = 0x00000162
+	CKA_LOCAL               = 0x00000163
+	CKA_NEVER_EXTRACTABLE   = 0x00000164
+	CKA_ALWAYS_SENSITIVE    = 0x00000165
+	CKA_MODIFIABLE          = 0x00000170
+	CKA_EC_PARAMS           = 0x00000180
+	CKA_EC_POINT            = 0x00000181
+	CKA_ALWAYS_AUTHENTICATE = 0x00000202
+)
+
+// Mechanisms
+const (
+	CKM_RSA_PKCS            = 0x00000001
+	CKM_RSA_PKCS_PSS        = 0x0000000D
+	CKM_SHA256_RSA_PKCS     = 0x00000040
+	CKM_SHA384_RSA_PKCS     = 0x00000041
+	CKM_SHA512_RSA_PKCS     = 0x00000042
+	CKM_SHA256_RSA_PKCS_PSS = 0x00000043
+	CKM_SHA384_RSA_PKCS_PSS = 0x00000044
+	CKM_SHA512_RSA_PKCS_PSS = 0x00000045
+	CKM_SHA256              = 0x00000250
+	CKM_SHA384              = 0x00000260
+	CKM_SHA512              = 0x00000270
+	CKM_ECDSA               = 0x00001041
+	CKM_ECDSA_SHA256        = 0x00001044
+	CKM_ECDSA_SHA384        = 0x00001045
+	CKM_ECDSA_SHA512        = 0x00001046
+	CKM_EDDSA               = 0x00001057
+	CKM_VENDOR_DEFINED      = 0x80000000
+
+	// CKM_VAULT_TRANSIT encrypts and decrypts data with transit, whose
+	// ciphertexts are the ciphertexts returned by transit, such as
+	// "vault:v1:...". It is supported by all the keys transit can encrypt
+	// with.
+	CKM_VAULT_TRANSIT = CKM_VENDOR_DEFINED | 0x5654
+)
+
+// Mask generation functions of the RSA PSS parameters
+const (
+	CKG_MGF1_SHA256 = 0x00000002
+	CKG_MGF1_SHA384 = 0x00000003
+	CKG_MGF1_SHA512 = 0x00000004
+)
+
+// Flags
+const (
+	// Slot flags
+	CKF_TOKEN_PRESENT = 0x00000001
+
+	// Token flags
+	CKF_RNG                  = 0x00000001
+	CKF_LOGIN_REQUIRED       = 0x00000004
+	CKF_USER_PIN_INITIALIZED = 0x00000008
+	CKF_TOKEN_INITIALIZED    = 0x00000400
+
+	// Session flags
+	CKF_RW_SESSION     = 0x00000002
+	CKF_SERIAL_SESSION = 0x00000004
+
+	// Mechanism flags
+	CKF_ENCRYPT = 0x00000100
+	CKF_DECRYPT = 0x00000200
+	CKF_SIGN    = 0x00000800
+	CKF_VERIFY  = 0x00002000
+
+	// Initialization flags
+	CKF_OS_LOCKING_OK = 0x00000002
+)
+
+// Session states
+const (
+	CKS_RO_PUBLIC_SESSION = 0
+	CKS_RO_USER_FUNCTIONS = 1
+	CKS_RW_PUBLIC_SESSION = 2
+	CKS_RW_USER_FUNCTIONS = 3
+)
+
+// User types
+const (
+	CKU_SO   = 0
+	CKU_USER = 1
+)
@@ -0,0 +1,16 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// libvault-pkcs11 is a PKCS#11 library backed by the transit secrets engine,
+// built as a shared library with:
+//
+//	go build -buildmode=c-shared -o libvault-pkcs11.so ./helper/pkcs11provider/libvault-pkcs11
+//
+// It reads the address of Vault and its token from the same environment
+// variables as the Vault CLI, such as VAULT_ADDR and VAULT_TOKEN, and the
+// slots of its tokens from the configuration file at the path of
+// VAULT_PKCS11_CONFIG.
+package main
+
+// main is required by the c-shared build mode, and never called.
+func main() {}
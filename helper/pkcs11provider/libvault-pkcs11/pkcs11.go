@@ -0,0 +1,643 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build cgo && !windows
+
+package main
+
+// #include "vault_pkcs11.h"
+import "C"
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/hashicorp/go-hclog"
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pkcs11provider"
+	"github.com/hashicorp/vault/version"
+)
+
+var (
+	lock     sync.RWMutex
+	provider *pkcs11provider.Provider
+)
+
+// rv returns the return value of an error of the provider.
+func rv(err error) C.CK_RV {
+	if err == nil {
+		return pkcs11provider.CKR_OK
+	}
+	var pErr pkcs11provider.Error
+	if errors.As(err, &pErr) {
+		return C.CK_RV(pErr)
+	}
+	return pkcs11provider.CKR_GENERAL_ERROR
+}
+
+// current returns the provider of the library once initialized.
+func current() (*pkcs11provider.Provider, C.CK_RV) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if provider == nil {
+		return nil, pkcs11provider.CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+	return provider, pkcs11provider.CKR_OK
+}
+
+// pad sets a fixed-size string of a PKCS#11 structure, padded with spaces.
+func pad(dst unsafe.Pointer, size int, s string) {
+	b := unsafe.Slice((*byte)(dst), size)
+	n := copy(b, s)
+	for i := n; i < size; i++ {
+		b[i] = ' '
+	}
+}
+
+// goBytes returns a copy of a buffer given by the application.
+func goBytes(p unsafe.Pointer, n C.CK_ULONG) []byte {
+	if p == nil || n == 0 {
+		return []byte{}
+	}
+	return C.GoBytes(p, C.int(n))
+}
+
+// setOutput returns the result of an operation in the buffer of the
+// application, or its length if the buffer is NULL.
+func setOutput(result []byte, err error, out C.CK_BYTE_PTR, outLen C.CK_ULONG_PTR) C.CK_RV {
+	if err != nil && err != pkcs11provider.Error(pkcs11provider.CKR_BUFFER_TOO_SMALL) {
+		return rv(err)
+	}
+	*outLen = C.CK_ULONG(len(result))
+	if err != nil {
+		return rv(err)
+	}
+	if out != nil {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(out)), len(result)), result)
+	}
+	return pkcs11provider.CKR_OK
+}
+
+// capacity returns the capacity of the output buffer of the application,
+// following the conventions of the provider.
+func capacity(out C.CK_BYTE_PTR, outLen C.CK_ULONG_PTR) int {
+	if out == nil {
+		return -1
+	}
+	return int(*outLen)
+}
+
+func mechanism(m C.CK_MECHANISM_PTR) *pkcs11provider.Mechanism {
+	if m == nil {
+		return nil
+	}
+	return &pkcs11provider.Mechanism{
+		Type:      uint(m.mechanism),
+		Parameter: goBytes(unsafe.Pointer(m.pParameter), m.ulParameterLen),
+	}
+}
+
+func template(attrs C.CK_ATTRIBUTE_PTR, count C.CK_ULONG) []*pkcs11provider.Attribute {
+	if attrs == nil || count == 0 {
+		return nil
+	}
+	var template []*pkcs11provider.Attribute
+	for _, attr := range unsafe.Slice((*C.CK_ATTRIBUTE)(attrs), count) {
+		template = append(template, &pkcs11provider.Attribute{
+			Type:  uint(attr._type),
+			Value: goBytes(unsafe.Pointer(attr.pValue), attr.ulValueLen),
+		})
+	}
+	return template
+}
+
+//export C_Initialize
+func C_Initialize(pInitArgs C.CK_VOID_PTR) C.CK_RV {
+	if pInitArgs != nil {
+		args := (*C.CK_C_INITIALIZE_ARGS)(pInitArgs)
+		if args.pReserved != nil {
+			return pkcs11provider.CKR_ARGUMENTS_BAD
+		}
+		// The library uses the locking of Go, which is that of the OS
+		if args.CreateMutex != nil && args.flags&pkcs11provider.CKF_OS_LOCKING_OK == 0 {
+			return pkcs11provider.CKR_CANT_LOCK
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if provider != nil {
+		return pkcs11provider.CKR_CRYPTOKI_ALREADY_INITIALIZED
+	}
+
+	conf, err := pkcs11provider.LoadConfig()
+	if err != nil {
+		hclog.New(&hclog.LoggerOptions{Name: "vault-pkcs11", Output: os.Stderr}).Error("failed to load the configuration", "error", err)
+		return pkcs11provider.CKR_GENERAL_ERROR
+	}
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:   "vault-pkcs11",
+		Level:  hclog.LevelFromString(conf.LogLevel),
+		Output: os.Stderr,
+	})
+
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		logger.Error("failed to create the Vault client", "error", err)
+		return pkcs11provider.CKR_GENERAL_ERROR
+	}
+
+	p, err := pkcs11provider.NewProvider(conf, client, logger)
+	if err != nil {
+		logger.Error("failed to create the provider", "error", err)
+		return pkcs11provider.CKR_GENERAL_ERROR
+	}
+	provider = p
+	return pkcs11provider.CKR_OK
+}
+
+//export C_Finalize
+func C_Finalize(pReserved C.CK_VOID_PTR) C.CK_RV {
+	if pReserved != nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if provider == nil {
+		return pkcs11provider.CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+	provider = nil
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetInfo
+func C_GetInfo(pInfo C.CK_INFO_PTR) C.CK_RV {
+	if _, r := current(); r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	pInfo.cryptokiVersion = C.CK_VERSION{major: 2, minor: 40}
+	pad(unsafe.Pointer(&pInfo.manufacturerID[0]), len(pInfo.manufacturerID), pkcs11provider.ManufacturerID)
+	pInfo.flags = 0
+	pad(unsafe.Pointer(&pInfo.libraryDescription[0]), len(pInfo.libraryDescription), pkcs11provider.LibraryDescription)
+	pInfo.libraryVersion = C.CK_VERSION{}
+	if v, err := goversion.NewVersion(version.Version); err == nil {
+		segments := v.Segments()
+		pInfo.libraryVersion = C.CK_VERSION{major: C.CK_BYTE(segments[0]), minor: C.CK_BYTE(segments[1])}
+	}
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetSlotList
+func C_GetSlotList(tokenPresent C.CK_BBOOL, pSlotList C.CK_SLOT_ID_PTR, pulCount C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulCount == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	// All the slots have a token
+	slots := p.SlotList()
+	if pSlotList != nil {
+		if int(*pulCount) < len(slots) {
+			*pulCount = C.CK_ULONG(len(slots))
+			return pkcs11provider.CKR_BUFFER_TOO_SMALL
+		}
+		list := unsafe.Slice((*C.CK_SLOT_ID)(pSlotList), len(slots))
+		for i, id := range slots {
+			list[i] = C.CK_SLOT_ID(id)
+		}
+	}
+	*pulCount = C.CK_ULONG(len(slots))
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetSlotInfo
+func C_GetSlotInfo(slotID C.CK_SLOT_ID, pInfo C.CK_SLOT_INFO_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	info, err := p.SlotInfo(uint(slotID))
+	if err != nil {
+		return rv(err)
+	}
+	pad(unsafe.Pointer(&pInfo.slotDescription[0]), len(pInfo.slotDescription), info.Description)
+	pad(unsafe.Pointer(&pInfo.manufacturerID[0]), len(pInfo.manufacturerID), pkcs11provider.ManufacturerID)
+	pInfo.flags = C.CK_FLAGS(info.Flags)
+	pInfo.hardwareVersion = C.CK_VERSION{}
+	pInfo.firmwareVersion = C.CK_VERSION{}
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetTokenInfo
+func C_GetTokenInfo(slotID C.CK_SLOT_ID, pInfo C.CK_TOKEN_INFO_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	info, err := p.TokenInfo(uint(slotID))
+	if err != nil {
+		return rv(err)
+	}
+	pad(unsafe.Pointer(&pInfo.label[0]), len(pInfo.label), info.Label)
+	pad(unsafe.Pointer(&pInfo.manufacturerID[0]), len(pInfo.manufacturerID), pkcs11provider.ManufacturerID)
+	pad(unsafe.Pointer(&pInfo.model[0]), len(pInfo.model), info.Model)
+	pad(unsafe.Pointer(&pInfo.serialNumber[0]), len(pInfo.serialNumber), info.SerialNumber)
+	pInfo.flags = C.CK_FLAGS(info.Flags)
+	// The session counts are not limited, which is CK_EFFECTIVELY_INFINITE
+	pInfo.ulMaxSessionCount = 0
+	pInfo.ulSessionCount = C.CK_ULONG(info.SessionCount)
+	pInfo.ulMaxRwSessionCount = 0
+	pInfo.ulRwSessionCount = C.CK_ULONG(info.RWSessionCount)
+	pInfo.ulMaxPinLen = C.CK_ULONG(info.MaxPinLen)
+	pInfo.ulMinPinLen = C.CK_ULONG(info.MinPinLen)
+	pInfo.ulTotalPublicMemory = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+	pInfo.ulFreePublicMemory = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+	pInfo.ulTotalPrivateMemory = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+	pInfo.ulFreePrivateMemory = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+	pInfo.hardwareVersion = C.CK_VERSION{}
+	pInfo.firmwareVersion = C.CK_VERSION{}
+	pad(unsafe.Pointer(&pInfo.utcTime[0]), len(pInfo.utcTime), "")
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetMechanismList
+func C_GetMechanismList(slotID C.CK_SLOT_ID, pMechanismList C.CK_MECHANISM_TYPE_PTR, pulCount C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulCount == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	mechanisms, err := p.MechanismList(uint(slotID))
+	if err != nil {
+		return rv(err)
+	}
+	if pMechanismList != nil {
+		if int(*pulCount) < len(mechanisms) {
+			*pulCount = C.CK_ULONG(len(mechanisms))
+			return pkcs11provider.CKR_BUFFER_TOO_SMALL
+		}
+		list := unsafe.Slice((*C.CK_MECHANISM_TYPE)(pMechanismList), len(mechanisms))
+		for i, m := range mechanisms {
+			list[i] = C.CK_MECHANISM_TYPE(m)
+		}
+	}
+	*pulCount = C.CK_ULONG(len(mechanisms))
+	return pkcs11provider.CKR_OK
+}
+
+//export C_GetMechanismInfo
+func C_GetMechanismInfo(slotID C.CK_SLOT_ID, mechanismType C.CK_MECHANISM_TYPE, pInfo C.CK_MECHANISM_INFO_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	info, err := p.MechanismInfo(uint(slotID), uint(mechanismType))
+	if err != nil {
+		return rv(err)
+	}
+	pInfo.ulMinKeySize = C.CK_ULONG(info.MinKeySize)
+	pInfo.ulMaxKeySize = C.CK_ULONG(info.MaxKeySize)
+	pInfo.flags = C.CK_FLAGS(info.Flags)
+	return pkcs11provider.CKR_OK
+}
+
+//export C_OpenSession
+func C_OpenSession(slotID C.CK_SLOT_ID, flags C.CK_FLAGS, pApplication C.CK_VOID_PTR, notify C.CK_NOTIFY, phSession C.CK_SESSION_HANDLE_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if phSession == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	// The provider makes no callbacks, so the notification callback is not
+	// used.
+	handle, err := p.OpenSession(uint(slotID), uint(flags))
+	if err != nil {
+		return rv(err)
+	}
+	*phSession = C.CK_SESSION_HANDLE(handle)
+	return pkcs11provider.CKR_OK
+}
+
+//export C_CloseSession
+func C_CloseSession(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.CloseSession(uint(hSession)))
+}
+
+//export C_CloseAllSessions
+func C_CloseAllSessions(slotID C.CK_SLOT_ID) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.CloseAllSessions(uint(slotID)))
+}
+
+//export C_GetSessionInfo
+func C_GetSessionInfo(hSession C.CK_SESSION_HANDLE, pInfo C.CK_SESSION_INFO_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	info, err := p.SessionInfo(uint(hSession))
+	if err != nil {
+		return rv(err)
+	}
+	pInfo.slotID = C.CK_SLOT_ID(info.SlotID)
+	pInfo.state = C.CK_STATE(info.State)
+	pInfo.flags = C.CK_FLAGS(info.Flags)
+	pInfo.ulDeviceError = 0
+	return pkcs11provider.CKR_OK
+}
+
+//export C_Login
+func C_Login(hSession C.CK_SESSION_HANDLE, userType C.CK_USER_TYPE, pPin C.CK_UTF8CHAR_PTR, ulPinLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	// There is no protected authentication path, so a PIN is required
+	if pPin == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	return rv(p.Login(uint(hSession), uint(userType), string(goBytes(unsafe.Pointer(pPin), ulPinLen))))
+}
+
+//export C_Logout
+func C_Logout(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.Logout(uint(hSession)))
+}
+
+//export C_GetAttributeValue
+func C_GetAttributeValue(hSession C.CK_SESSION_HANDLE, hObject C.CK_OBJECT_HANDLE, pTemplate C.CK_ATTRIBUTE_PTR, ulCount C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pTemplate == nil && ulCount != 0 {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	attrs := unsafe.Slice((*C.CK_ATTRIBUTE)(pTemplate), ulCount)
+	template := make([]*pkcs11provider.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		template = append(template, &pkcs11provider.Attribute{Type: uint(attr._type)})
+	}
+
+	err := p.GetAttributeValue(uint(hSession), uint(hObject), template)
+	var pErr pkcs11provider.Error
+	if err != nil && !errors.As(err, &pErr) {
+		return rv(err)
+	}
+	if pErr != 0 && pErr != pkcs11provider.CKR_ATTRIBUTE_SENSITIVE && pErr != pkcs11provider.CKR_ATTRIBUTE_TYPE_INVALID {
+		return rv(err)
+	}
+
+	for i, attr := range template {
+		switch {
+		case attr.Value == nil:
+			attrs[i].ulValueLen = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+		case attrs[i].pValue == nil:
+			attrs[i].ulValueLen = C.CK_ULONG(len(attr.Value))
+		case int(attrs[i].ulValueLen) < len(attr.Value):
+			attrs[i].ulValueLen = C.CK_ULONG(pkcs11provider.UnavailableInformation)
+			if err == nil {
+				err = pkcs11provider.Error(pkcs11provider.CKR_BUFFER_TOO_SMALL)
+			}
+		default:
+			copy(unsafe.Slice((*byte)(attrs[i].pValue), len(attr.Value)), attr.Value)
+			attrs[i].ulValueLen = C.CK_ULONG(len(attr.Value))
+		}
+	}
+	return rv(err)
+}
+
+//export C_FindObjectsInit
+func C_FindObjectsInit(hSession C.CK_SESSION_HANDLE, pTemplate C.CK_ATTRIBUTE_PTR, ulCount C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pTemplate == nil && ulCount != 0 {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	return rv(p.FindObjectsInit(uint(hSession), template(pTemplate, ulCount)))
+}
+
+//export C_FindObjects
+func C_FindObjects(hSession C.CK_SESSION_HANDLE, phObject C.CK_OBJECT_HANDLE_PTR, ulMaxObjectCount C.CK_ULONG, pulObjectCount C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if (phObject == nil && ulMaxObjectCount != 0) || pulObjectCount == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	handles, err := p.FindObjects(uint(hSession), int(ulMaxObjectCount))
+	if err != nil {
+		return rv(err)
+	}
+	if len(handles) > 0 {
+		objects := unsafe.Slice((*C.CK_OBJECT_HANDLE)(phObject), len(handles))
+		for i, h := range handles {
+			objects[i] = C.CK_OBJECT_HANDLE(h)
+		}
+	}
+	*pulObjectCount = C.CK_ULONG(len(handles))
+	return pkcs11provider.CKR_OK
+}
+
+//export C_FindObjectsFinal
+func C_FindObjectsFinal(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.FindObjectsFinal(uint(hSession)))
+}
+
+//export C_EncryptInit
+func C_EncryptInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.EncryptInit(uint(hSession), mechanism(pMechanism), uint(hKey)))
+}
+
+//export C_Encrypt
+func C_Encrypt(hSession C.CK_SESSION_HANDLE, pData C.CK_BYTE_PTR, ulDataLen C.CK_ULONG, pEncryptedData C.CK_BYTE_PTR, pulEncryptedDataLen C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulEncryptedDataLen == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	result, err := p.Encrypt(uint(hSession), goBytes(unsafe.Pointer(pData), ulDataLen), capacity(pEncryptedData, pulEncryptedDataLen))
+	return setOutput(result, err, pEncryptedData, pulEncryptedDataLen)
+}
+
+//export C_DecryptInit
+func C_DecryptInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.DecryptInit(uint(hSession), mechanism(pMechanism), uint(hKey)))
+}
+
+//export C_Decrypt
+func C_Decrypt(hSession C.CK_SESSION_HANDLE, pEncryptedData C.CK_BYTE_PTR, ulEncryptedDataLen C.CK_ULONG, pData C.CK_BYTE_PTR, pulDataLen C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulDataLen == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	result, err := p.Decrypt(uint(hSession), goBytes(unsafe.Pointer(pEncryptedData), ulEncryptedDataLen), capacity(pData, pulDataLen))
+	return setOutput(result, err, pData, pulDataLen)
+}
+
+//export C_SignInit
+func C_SignInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.SignInit(uint(hSession), mechanism(pMechanism), uint(hKey)))
+}
+
+//export C_Sign
+func C_Sign(hSession C.CK_SESSION_HANDLE, pData C.CK_BYTE_PTR, ulDataLen C.CK_ULONG, pSignature C.CK_BYTE_PTR, pulSignatureLen C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulSignatureLen == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	result, err := p.Sign(uint(hSession), goBytes(unsafe.Pointer(pData), ulDataLen), capacity(pSignature, pulSignatureLen))
+	return setOutput(result, err, pSignature, pulSignatureLen)
+}
+
+//export C_SignUpdate
+func C_SignUpdate(hSession C.CK_SESSION_HANDLE, pPart C.CK_BYTE_PTR, ulPartLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.SignUpdate(uint(hSession), goBytes(unsafe.Pointer(pPart), ulPartLen)))
+}
+
+//export C_SignFinal
+func C_SignFinal(hSession C.CK_SESSION_HANDLE, pSignature C.CK_BYTE_PTR, pulSignatureLen C.CK_ULONG_PTR) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pulSignatureLen == nil {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+	result, err := p.SignFinal(uint(hSession), capacity(pSignature, pulSignatureLen))
+	return setOutput(result, err, pSignature, pulSignatureLen)
+}
+
+//export C_VerifyInit
+func C_VerifyInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.VerifyInit(uint(hSession), mechanism(pMechanism), uint(hKey)))
+}
+
+//export C_Verify
+func C_Verify(hSession C.CK_SESSION_HANDLE, pData C.CK_BYTE_PTR, ulDataLen C.CK_ULONG, pSignature C.CK_BYTE_PTR, ulSignatureLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.Verify(uint(hSession), goBytes(unsafe.Pointer(pData), ulDataLen), goBytes(unsafe.Pointer(pSignature), ulSignatureLen)))
+}
+
+//export C_VerifyUpdate
+func C_VerifyUpdate(hSession C.CK_SESSION_HANDLE, pPart C.CK_BYTE_PTR, ulPartLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.VerifyUpdate(uint(hSession), goBytes(unsafe.Pointer(pPart), ulPartLen)))
+}
+
+//export C_VerifyFinal
+func C_VerifyFinal(hSession C.CK_SESSION_HANDLE, pSignature C.CK_BYTE_PTR, ulSignatureLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	return rv(p.VerifyFinal(uint(hSession), goBytes(unsafe.Pointer(pSignature), ulSignatureLen)))
+}
+
+//export C_GenerateRandom
+func C_GenerateRandom(hSession C.CK_SESSION_HANDLE, pRandomData C.CK_BYTE_PTR, ulRandomLen C.CK_ULONG) C.CK_RV {
+	p, r := current()
+	if r != pkcs11provider.CKR_OK {
+		return r
+	}
+	if pRandomData == nil && ulRandomLen != 0 {
+		return pkcs11provider.CKR_ARGUMENTS_BAD
+	}
+
+	random, err := p.GenerateRandom(uint(hSession), int(ulRandomLen))
+	if err != nil {
+		return rv(err)
+	}
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(pRandomData)), len(random)), random)
+	return pkcs11provider.CKR_OK
+}
@@ -0,0 +1,334 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"unsafe"
+)
+
+// Attribute is an attribute of a template or of an object. The values of the
+// attributes of the CK_ULONG type, such as CKA_CLASS, have the size and byte
+// order of a uint, which matches the unsigned long of CK_ULONG on the
+// platforms the library is built for.
+type Attribute struct {
+	Type  uint
+	Value []byte
+}
+
+var (
+	oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidNamedCurveP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+	oidEd25519        = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// transitKey is the latest version of a transit key.
+type transitKey struct {
+	name          string
+	keyType       string
+	latestVersion int
+	publicKey     crypto.PublicKey
+	certificate   *x509.Certificate
+}
+
+// objectID identifies the object of a key across reloads of the keys, so
+// that it keeps its handle.
+type objectID struct {
+	slot  uint
+	name  string
+	class uint
+}
+
+// object is a PKCS#11 object of a transit key: its private or public key, its
+// certificate, or the secret key of a symmetric key.
+type object struct {
+	handle     uint
+	slot       uint
+	class      uint
+	key        *transitKey
+	attributes map[uint][]byte
+}
+
+// parseKey parses the response of transit to the read of a key. It returns
+// nil for the keys without objects, such as HMAC keys and derived keys which
+// need a context for each operation.
+func parseKey(name string, data map[string]interface{}) (*transitKey, error) {
+	if derived, _ := data["derived"].(bool); derived {
+		return nil, nil
+	}
+
+	keyType, _ := data["type"].(string)
+	switch keyType {
+	case "aes128-gcm96", "aes256-gcm96", "chacha20-poly1305",
+		"rsa-2048", "rsa-3072", "rsa-4096",
+		"ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ed25519":
+	default:
+		return nil, nil
+	}
+
+	latest, ok := data["latest_version"].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("key %q has no latest version", name)
+	}
+	latestVersion, err := latest.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("key %q has an invalid latest version: %w", name, err)
+	}
+
+	key := &transitKey{
+		name:          name,
+		keyType:       keyType,
+		latestVersion: int(latestVersion),
+	}
+	if key.symmetric() {
+		return key, nil
+	}
+
+	versions, _ := data["keys"].(map[string]interface{})
+	version, _ := versions[latest.String()].(map[string]interface{})
+	publicKey, _ := version["public_key"].(string)
+	if publicKey == "" {
+		return nil, fmt.Errorf("key %q has no public key", name)
+	}
+
+	if keyType == "ed25519" {
+		raw, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q has an invalid public key", name)
+		}
+		key.publicKey = ed25519.PublicKey(raw)
+	} else {
+		block, _ := pem.Decode([]byte(publicKey))
+		if block == nil {
+			return nil, fmt.Errorf("key %q has an invalid public key", name)
+		}
+		key.publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q has an invalid public key: %w", name, err)
+		}
+	}
+
+	if chain, _ := version["certificate_chain"].(string); chain != "" {
+		// The leaf certificate is the first of the chain
+		block, _ := pem.Decode([]byte(chain))
+		if block == nil {
+			return nil, fmt.Errorf("key %q has an invalid certificate chain", name)
+		}
+		key.certificate, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q has an invalid certificate: %w", name, err)
+		}
+	}
+
+	return key, nil
+}
+
+func (k *transitKey) symmetric() bool {
+	switch k.keyType {
+	case "aes128-gcm96", "aes256-gcm96", "chacha20-poly1305":
+		return true
+	}
+	return false
+}
+
+// classes returns the classes of the objects of the key.
+func (k *transitKey) classes() []uint {
+	if k.symmetric() {
+		return []uint{CKO_SECRET_KEY}
+	}
+	classes := []uint{CKO_PRIVATE_KEY, CKO_PUBLIC_KEY}
+	if k.certificate != nil {
+		classes = append(classes, CKO_CERTIFICATE)
+	}
+	return classes
+}
+
+// newObject returns the object of the class of the key.
+func newObject(handle, slot, class uint, key *transitKey) (*object, error) {
+	o := &object{
+		handle: handle,
+		slot:   slot,
+		class:  class,
+		key:    key,
+		attributes: map[uint][]byte{
+			CKA_CLASS:      ulongBytes(class),
+			CKA_TOKEN:      boolBytes(true),
+			CKA_PRIVATE:    boolBytes(class != CKO_PUBLIC_KEY && class != CKO_CERTIFICATE),
+			CKA_MODIFIABLE: boolBytes(false),
+			CKA_LABEL:      []byte(key.name),
+			CKA_ID:         []byte(key.name),
+		},
+	}
+
+	if class == CKO_CERTIFICATE {
+		o.attributes[CKA_CERTIFICATE_TYPE] = ulongBytes(CKC_X_509)
+		o.attributes[CKA_SUBJECT] = key.certificate.RawSubject
+		o.attributes[CKA_ISSUER] = key.certificate.RawIssuer
+		serial, err := asn1.Marshal(key.certificate.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		o.attributes[CKA_SERIAL_NUMBER] = serial
+		o.attributes[CKA_VALUE] = key.certificate.Raw
+		return o, nil
+	}
+
+	o.attributes[CKA_LOCAL] = boolBytes(true)
+	o.attributes[CKA_DERIVE] = boolBytes(false)
+	o.attributes[CKA_WRAP] = boolBytes(false)
+	o.attributes[CKA_UNWRAP] = boolBytes(false)
+	if class != CKO_PUBLIC_KEY {
+		o.attributes[CKA_SENSITIVE] = boolBytes(true)
+		o.attributes[CKA_ALWAYS_SENSITIVE] = boolBytes(true)
+		o.attributes[CKA_EXTRACTABLE] = boolBytes(false)
+		o.attributes[CKA_NEVER_EXTRACTABLE] = boolBytes(true)
+	}
+
+	switch pub := key.publicKey.(type) {
+	case nil:
+		o.attributes[CKA_ENCRYPT] = boolBytes(true)
+		o.attributes[CKA_DECRYPT] = boolBytes(true)
+		o.attributes[CKA_SIGN] = boolBytes(false)
+		o.attributes[CKA_VERIFY] = boolBytes(false)
+		switch key.keyType {
+		case "aes128-gcm96":
+			o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_AES)
+			o.attributes[CKA_VALUE_LEN] = ulongBytes(16)
+		case "aes256-gcm96":
+			o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_AES)
+			o.attributes[CKA_VALUE_LEN] = ulongBytes(32)
+		case "chacha20-poly1305":
+			o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_CHACHA20)
+			o.attributes[CKA_VALUE_LEN] = ulongBytes(32)
+		}
+		return o, nil
+
+	case *rsa.PublicKey:
+		o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_RSA)
+		o.attributes[CKA_MODULUS] = pub.N.Bytes()
+		o.attributes[CKA_PUBLIC_EXPONENT] = big.NewInt(int64(pub.E)).Bytes()
+		if class == CKO_PUBLIC_KEY {
+			o.attributes[CKA_MODULUS_BITS] = ulongBytes(uint(pub.N.BitLen()))
+		}
+
+	case *ecdsa.PublicKey:
+		o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_EC)
+		var oid asn1.ObjectIdentifier
+		switch pub.Curve {
+		case elliptic.P256():
+			oid = oidNamedCurveP256
+		case elliptic.P384():
+			oid = oidNamedCurveP384
+		case elliptic.P521():
+			oid = oidNamedCurveP521
+		default:
+			return nil, fmt.Errorf("key %q has an unsupported curve", key.name)
+		}
+		params, err := asn1.Marshal(oid)
+		if err != nil {
+			return nil, err
+		}
+		o.attributes[CKA_EC_PARAMS] = params
+		if class == CKO_PUBLIC_KEY {
+			ecdhKey, err := pub.ECDH()
+			if err != nil {
+				return nil, err
+			}
+			point, err := asn1.Marshal(ecdhKey.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			o.attributes[CKA_EC_POINT] = point
+		}
+
+	case ed25519.PublicKey:
+		o.attributes[CKA_KEY_TYPE] = ulongBytes(CKK_EC_EDWARDS)
+		params, err := asn1.Marshal(oidEd25519)
+		if err != nil {
+			return nil, err
+		}
+		o.attributes[CKA_EC_PARAMS] = params
+		if class == CKO_PUBLIC_KEY {
+			point, err := asn1.Marshal([]byte(pub))
+			if err != nil {
+				return nil, err
+			}
+			o.attributes[CKA_EC_POINT] = point
+		}
+
+	default:
+		return nil, errors.New("unsupported public key")
+	}
+
+	_, isRSA := key.publicKey.(*rsa.PublicKey)
+	if class == CKO_PRIVATE_KEY {
+		o.attributes[CKA_SIGN] = boolBytes(true)
+		o.attributes[CKA_SIGN_RECOVER] = boolBytes(false)
+		o.attributes[CKA_DECRYPT] = boolBytes(isRSA)
+		o.attributes[CKA_ALWAYS_AUTHENTICATE] = boolBytes(false)
+	} else {
+		o.attributes[CKA_VERIFY] = boolBytes(true)
+		o.attributes[CKA_VERIFY_RECOVER] = boolBytes(false)
+		o.attributes[CKA_ENCRYPT] = boolBytes(isRSA)
+		info, err := x509.MarshalPKIXPublicKey(key.publicKey)
+		if err != nil {
+			return nil, err
+		}
+		o.attributes[CKA_PUBLIC_KEY_INFO] = info
+	}
+
+	return o, nil
+}
+
+// matches returns whether the object has all the attributes of the template.
+func (o *object) matches(template []*Attribute) bool {
+	for _, attr := range template {
+		value, ok := o.attributes[attr.Type]
+		if !ok || !bytes.Equal(value, attr.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasFlag returns whether a boolean attribute of the object is true.
+func (o *object) hasFlag(attr uint) bool {
+	return bytes.Equal(o.attributes[attr], boolBytes(true))
+}
+
+func boolBytes(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// ulongBytes returns the value of a CK_ULONG attribute.
+func ulongBytes(v uint) []byte {
+	return append([]byte(nil), unsafe.Slice((*byte)(unsafe.Pointer(&v)), unsafe.Sizeof(v))...)
+}
+
+// bytesULong returns the value of a CK_ULONG attribute or parameter.
+func bytesULong(b []byte) (uint, bool) {
+	var v uint
+	if len(b) != int(unsafe.Sizeof(v)) {
+		return 0, false
+	}
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(&v)), unsafe.Sizeof(v)), b)
+	return v, true
+}
@@ -0,0 +1,537 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// mechanismInfos are the mechanisms supported by the tokens. The key sizes of
+// the asymmetric keys are in bits, and of the symmetric keys in bytes.
+var mechanismInfos = map[uint]MechanismInfo{
+	CKM_RSA_PKCS:            {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_RSA_PKCS_PSS:        {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA256_RSA_PKCS:     {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA384_RSA_PKCS:     {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA512_RSA_PKCS:     {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA256_RSA_PKCS_PSS: {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA384_RSA_PKCS_PSS: {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_SHA512_RSA_PKCS_PSS: {MinKeySize: 2048, MaxKeySize: 4096, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_ECDSA:               {MinKeySize: 256, MaxKeySize: 521, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_ECDSA_SHA256:        {MinKeySize: 256, MaxKeySize: 521, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_ECDSA_SHA384:        {MinKeySize: 256, MaxKeySize: 521, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_ECDSA_SHA512:        {MinKeySize: 256, MaxKeySize: 521, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_EDDSA:               {MinKeySize: 255, MaxKeySize: 255, Flags: CKF_SIGN | CKF_VERIFY},
+	CKM_VAULT_TRANSIT:       {MinKeySize: 16, MaxKeySize: 512, Flags: CKF_ENCRYPT | CKF_DECRYPT},
+}
+
+// hashAlgorithms are the transit hash algorithms of the hashing mechanisms,
+// and of the hashes of the RSA PSS parameters.
+var hashAlgorithms = map[uint]string{
+	CKM_SHA256_RSA_PKCS: "sha2-256",
+	CKM_SHA384_RSA_PKCS: "sha2-384",
+	CKM_SHA512_RSA_PKCS: "sha2-512",
+	CKM_ECDSA_SHA256:    "sha2-256",
+	CKM_ECDSA_SHA384:    "sha2-384",
+	CKM_ECDSA_SHA512:    "sha2-512",
+	CKM_SHA256:          "sha2-256",
+	CKM_SHA384:          "sha2-384",
+	CKM_SHA512:          "sha2-512",
+}
+
+// pssHashes are the hashes of the RSA PSS mechanisms hashing the data, which
+// must be the hashes of their parameters.
+var pssHashes = map[uint]uint{
+	CKM_SHA256_RSA_PKCS_PSS: CKM_SHA256,
+	CKM_SHA384_RSA_PKCS_PSS: CKM_SHA384,
+	CKM_SHA512_RSA_PKCS_PSS: CKM_SHA512,
+}
+
+// pssMGFs are the mask generation functions allowed with the hashes of the
+// RSA PSS parameters, as transit uses MGF1 with the hash of the signature.
+var pssMGFs = map[uint]uint{
+	CKM_SHA256: CKG_MGF1_SHA256,
+	CKM_SHA384: CKG_MGF1_SHA384,
+	CKM_SHA512: CKG_MGF1_SHA512,
+}
+
+// hashSizes are the sizes of the hashes signed by CKM_RSA_PKCS_PSS.
+var hashSizes = map[uint]int{
+	CKM_SHA256: 32,
+	CKM_SHA384: 48,
+	CKM_SHA512: 64,
+}
+
+// operation is a signature, verification, encryption or decryption in
+// progress in a session.
+type operation struct {
+	mechanism *Mechanism
+	object    *object
+
+	// params are the parameters of the transit requests of the operation,
+	// and inputLen the length of its data, if the mechanism requires one.
+	params   map[string]interface{}
+	inputLen int
+
+	// multipart is set once data is given in parts, and data holds it.
+	multipart bool
+	data      []byte
+
+	// result is the output of the operation, kept until it is returned in a
+	// buffer large enough.
+	result []byte
+}
+
+// multipartMechanism returns whether the mechanism hashes the data, so that it
+// can be given in parts.
+func multipartMechanism(mechanism uint) bool {
+	switch mechanism {
+	case CKM_RSA_PKCS, CKM_RSA_PKCS_PSS, CKM_ECDSA, CKM_EDDSA, CKM_VAULT_TRANSIT:
+		return false
+	}
+	return true
+}
+
+// newOperation checks the mechanism of an operation is supported by the key,
+// and the key allows the operation by its attribute, and returns the
+// operation with the parameters of its transit requests.
+func newOperation(m *Mechanism, o *object, attr uint) (*operation, error) {
+	if _, ok := mechanismInfos[m.Type]; !ok {
+		return nil, Error(CKR_MECHANISM_INVALID)
+	}
+	if !o.hasFlag(attr) {
+		return nil, Error(CKR_KEY_FUNCTION_NOT_PERMITTED)
+	}
+
+	params := make(map[string]interface{})
+	var inputLen int
+	switch m.Type {
+	case CKM_VAULT_TRANSIT:
+		// Supported by all the keys which allow encryption or decryption
+
+	case CKM_RSA_PKCS, CKM_SHA256_RSA_PKCS, CKM_SHA384_RSA_PKCS, CKM_SHA512_RSA_PKCS:
+		if _, ok := o.key.publicKey.(*rsa.PublicKey); !ok {
+			return nil, Error(CKR_KEY_TYPE_INCONSISTENT)
+		}
+		params["signature_algorithm"] = "pkcs1v15"
+		if m.Type == CKM_RSA_PKCS {
+			// The data is the DigestInfo of the hash to sign
+			params["hash_algorithm"] = "none"
+			params["prehashed"] = true
+		} else {
+			params["hash_algorithm"] = hashAlgorithms[m.Type]
+		}
+
+	case CKM_RSA_PKCS_PSS, CKM_SHA256_RSA_PKCS_PSS, CKM_SHA384_RSA_PKCS_PSS, CKM_SHA512_RSA_PKCS_PSS:
+		if _, ok := o.key.publicKey.(*rsa.PublicKey); !ok {
+			return nil, Error(CKR_KEY_TYPE_INCONSISTENT)
+		}
+		hash, mgf, saltLength, err := pssParameters(m.Parameter)
+		if err != nil {
+			return nil, err
+		}
+		if expected, ok := pssHashes[m.Type]; (ok && hash != expected) || pssMGFs[hash] != mgf || mgf == 0 {
+			return nil, Error(CKR_MECHANISM_PARAM_INVALID)
+		}
+		if m.Type == CKM_RSA_PKCS_PSS {
+			// The data is the hash to sign
+			params["prehashed"] = true
+			inputLen = hashSizes[hash]
+		}
+		params["signature_algorithm"] = "pss"
+		params["hash_algorithm"] = hashAlgorithms[hash]
+		params["salt_length"] = fmt.Sprintf("%d", saltLength)
+
+	case CKM_ECDSA, CKM_ECDSA_SHA256, CKM_ECDSA_SHA384, CKM_ECDSA_SHA512:
+		if _, ok := o.key.publicKey.(*ecdsa.PublicKey); !ok {
+			return nil, Error(CKR_KEY_TYPE_INCONSISTENT)
+		}
+		// PKCS#11 ECDSA signatures are the concatenation of r and s
+		params["marshaling_algorithm"] = "jws"
+		if m.Type == CKM_ECDSA {
+			params["prehashed"] = true
+		} else {
+			params["hash_algorithm"] = hashAlgorithms[m.Type]
+		}
+
+	case CKM_EDDSA:
+		if _, ok := o.key.publicKey.(ed25519.PublicKey); !ok {
+			return nil, Error(CKR_KEY_TYPE_INCONSISTENT)
+		}
+		// Only pure Ed25519, without parameters, is supported
+		if len(m.Parameter) != 0 {
+			return nil, Error(CKR_MECHANISM_PARAM_INVALID)
+		}
+	}
+
+	return &operation{
+		mechanism: m,
+		object:    o,
+		params:    params,
+		inputLen:  inputLen,
+	}, nil
+}
+
+// pssParameters parses the CK_RSA_PKCS_PSS_PARAMS of the RSA PSS mechanisms,
+// which are made of three CK_ULONGs.
+func pssParameters(parameter []byte) (hash, mgf, saltLength uint, err error) {
+	size := len(ulongBytes(0))
+	if len(parameter) != 3*size {
+		return 0, 0, 0, Error(CKR_MECHANISM_PARAM_INVALID)
+	}
+	hash, _ = bytesULong(parameter[:size])
+	mgf, _ = bytesULong(parameter[size : 2*size])
+	saltLength, _ = bytesULong(parameter[2*size:])
+	return hash, mgf, saltLength, nil
+}
+
+// output returns the result of an operation following the conventions of
+// PKCS#11 for output buffers: a negative capacity queries the length of the
+// result, and a capacity smaller than the result returns
+// CKR_BUFFER_TOO_SMALL, keeping the operation active in both cases. The
+// operation ends once the result is returned.
+func output(op **operation, capacity int) ([]byte, error) {
+	result := (*op).result
+	switch {
+	case capacity < 0:
+		return result, nil
+	case capacity < len(result):
+		return result, Error(CKR_BUFFER_TOO_SMALL)
+	}
+	*op = nil
+	return result, nil
+}
+
+// SignInit starts a signature with a private key.
+func (p *Provider) SignInit(handle uint, m *Mechanism, key uint) error {
+	return p.operationInit(handle, m, key, CKA_SIGN, func(sess *session) **operation { return &sess.sign })
+}
+
+// VerifyInit starts a verification with a public key.
+func (p *Provider) VerifyInit(handle uint, m *Mechanism, key uint) error {
+	if m != nil && m.Type == CKM_VAULT_TRANSIT {
+		return Error(CKR_MECHANISM_INVALID)
+	}
+	return p.operationInit(handle, m, key, CKA_VERIFY, func(sess *session) **operation { return &sess.verify })
+}
+
+// EncryptInit starts an encryption with a secret or public key.
+func (p *Provider) EncryptInit(handle uint, m *Mechanism, key uint) error {
+	if m != nil && m.Type != CKM_VAULT_TRANSIT {
+		return Error(CKR_MECHANISM_INVALID)
+	}
+	return p.operationInit(handle, m, key, CKA_ENCRYPT, func(sess *session) **operation { return &sess.encrypt })
+}
+
+// DecryptInit starts a decryption with a secret or private key.
+func (p *Provider) DecryptInit(handle uint, m *Mechanism, key uint) error {
+	if m != nil && m.Type != CKM_VAULT_TRANSIT {
+		return Error(CKR_MECHANISM_INVALID)
+	}
+	return p.operationInit(handle, m, key, CKA_DECRYPT, func(sess *session) **operation { return &sess.decrypt })
+}
+
+func (p *Provider) operationInit(handle uint, m *Mechanism, key uint, attr uint, field func(*session) **operation) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	op := field(sess)
+	if *op != nil {
+		return Error(CKR_OPERATION_ACTIVE)
+	}
+	if m == nil {
+		return Error(CKR_ARGUMENTS_BAD)
+	}
+
+	o, err := p.object(sess, key)
+	if err != nil {
+		return Error(CKR_KEY_HANDLE_INVALID)
+	}
+
+	*op, err = newOperation(m, o, attr)
+	return err
+}
+
+// update adds a part of the data of an operation of a session.
+func (p *Provider) update(handle uint, part []byte, field func(*session) **operation) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	op := field(sess)
+	if *op == nil {
+		return Error(CKR_OPERATION_NOT_INITIALIZED)
+	}
+	if !multipartMechanism((*op).mechanism.Type) {
+		*op = nil
+		return Error(CKR_FUNCTION_NOT_SUPPORTED)
+	}
+
+	(*op).multipart = true
+	(*op).data = append((*op).data, part...)
+	return nil
+}
+
+// run runs an operation of a session with the data, unless it already has a
+// result, and returns its output for the capacity. Any error other than
+// CKR_BUFFER_TOO_SMALL ends the operation.
+func (p *Provider) run(handle uint, data []byte, final bool, capacity int, field func(*session) **operation, fn func(context.Context, *session, *operation, []byte) ([]byte, error)) ([]byte, error) {
+	sess, err := p.session(handle)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.lock.Unlock()
+
+	op := field(sess)
+	if *op == nil {
+		return nil, Error(CKR_OPERATION_NOT_INITIALIZED)
+	}
+
+	if (*op).result == nil {
+		if final {
+			data = (*op).data
+		} else if (*op).multipart {
+			// The single-part function cannot finish a multi-part operation
+			*op = nil
+			return nil, Error(CKR_OPERATION_ACTIVE)
+		}
+
+		if (*op).inputLen != 0 && len(data) != (*op).inputLen {
+			*op = nil
+			return nil, Error(CKR_DATA_LEN_RANGE)
+		}
+
+		result, err := fn(context.Background(), sess, *op, data)
+		if err != nil {
+			*op = nil
+			return nil, err
+		}
+		(*op).result = result
+	}
+
+	return output(op, capacity)
+}
+
+// Sign signs the data, returning the signature following the conventions of
+// output buffers.
+func (p *Provider) Sign(handle uint, data []byte, capacity int) ([]byte, error) {
+	return p.run(handle, data, false, capacity, func(sess *session) **operation { return &sess.sign }, p.sign)
+}
+
+// SignUpdate adds a part of the data to sign.
+func (p *Provider) SignUpdate(handle uint, part []byte) error {
+	return p.update(handle, part, func(sess *session) **operation { return &sess.sign })
+}
+
+// SignFinal signs the parts of the data, returning the signature following
+// the conventions of output buffers.
+func (p *Provider) SignFinal(handle uint, capacity int) ([]byte, error) {
+	return p.run(handle, nil, true, capacity, func(sess *session) **operation { return &sess.sign }, p.sign)
+}
+
+// Verify verifies the signature of the data.
+func (p *Provider) Verify(handle uint, data, signature []byte) error {
+	_, err := p.run(handle, data, false, 0, func(sess *session) **operation { return &sess.verify }, p.verifier(signature))
+	return err
+}
+
+// VerifyUpdate adds a part of the data to verify.
+func (p *Provider) VerifyUpdate(handle uint, part []byte) error {
+	return p.update(handle, part, func(sess *session) **operation { return &sess.verify })
+}
+
+// VerifyFinal verifies the signature of the parts of the data.
+func (p *Provider) VerifyFinal(handle uint, signature []byte) error {
+	_, err := p.run(handle, nil, true, 0, func(sess *session) **operation { return &sess.verify }, p.verifier(signature))
+	return err
+}
+
+// Encrypt encrypts the data, returning the ciphertext following the
+// conventions of output buffers.
+func (p *Provider) Encrypt(handle uint, data []byte, capacity int) ([]byte, error) {
+	return p.run(handle, data, false, capacity, func(sess *session) **operation { return &sess.encrypt }, p.encrypt)
+}
+
+// Decrypt decrypts the data, returning the plaintext following the
+// conventions of output buffers.
+func (p *Provider) Decrypt(handle uint, data []byte, capacity int) ([]byte, error) {
+	return p.run(handle, data, false, capacity, func(sess *session) **operation { return &sess.decrypt }, p.decrypt)
+}
+
+// transitPath returns the path of a transit endpoint for the key of an
+// operation.
+func transitPath(sess *session, endpoint string, op *operation) string {
+	return fmt.Sprintf("%s/%s/%s", strings.Trim(sess.slot.config.Mount, "/"), endpoint, op.object.key.name)
+}
+
+func (p *Provider) sign(ctx context.Context, sess *session, op *operation, data []byte) ([]byte, error) {
+	client, err := p.sessionClient(sess)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(data),
+	}
+	for k, v := range op.params {
+		body[k] = v
+	}
+
+	resp, err := client.Logical().WriteWithContext(ctx, transitPath(sess, "sign", op), body)
+	if err != nil {
+		return nil, p.transitError(err, "failed to sign with transit", CKR_DATA_INVALID)
+	}
+	if resp == nil {
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+
+	signature, _ := resp.Data["signature"].(string)
+	parts := strings.SplitN(signature, ":", 3)
+	if len(parts) != 3 {
+		p.logger.Error("invalid signature returned by transit", "signature", signature)
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+
+	encoding := base64.StdEncoding
+	if op.params["marshaling_algorithm"] == "jws" {
+		encoding = base64.RawURLEncoding
+	}
+	raw, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		p.logger.Error("invalid signature returned by transit", "error", err)
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+	return raw, nil
+}
+
+// verifier returns the function verifying the signature, whose result is
+// empty as verifications have no output.
+func (p *Provider) verifier(signature []byte) func(context.Context, *session, *operation, []byte) ([]byte, error) {
+	return func(ctx context.Context, sess *session, op *operation, data []byte) ([]byte, error) {
+		client, err := p.sessionClient(sess)
+		if err != nil {
+			return nil, err
+		}
+
+		encoding := base64.StdEncoding
+		if op.params["marshaling_algorithm"] == "jws" {
+			encoding = base64.RawURLEncoding
+		}
+		body := map[string]interface{}{
+			"input":     base64.StdEncoding.EncodeToString(data),
+			"signature": fmt.Sprintf("vault:v%d:%s", op.object.key.latestVersion, encoding.EncodeToString(signature)),
+		}
+		for k, v := range op.params {
+			body[k] = v
+		}
+
+		resp, err := client.Logical().WriteWithContext(ctx, transitPath(sess, "verify", op), body)
+		if err != nil {
+			return nil, p.transitError(err, "failed to verify with transit", CKR_SIGNATURE_INVALID)
+		}
+		if resp == nil {
+			return nil, Error(CKR_DEVICE_ERROR)
+		}
+		if valid, _ := resp.Data["valid"].(bool); !valid {
+			return nil, Error(CKR_SIGNATURE_INVALID)
+		}
+		return []byte{}, nil
+	}
+}
+
+func (p *Provider) encrypt(ctx context.Context, sess *session, op *operation, data []byte) ([]byte, error) {
+	client, err := p.sessionClient(sess)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Logical().WriteWithContext(ctx, transitPath(sess, "encrypt", op), map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		return nil, p.transitError(err, "failed to encrypt with transit", CKR_DATA_INVALID)
+	}
+	if resp == nil {
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+
+	ciphertext, _ := resp.Data["ciphertext"].(string)
+	if ciphertext == "" {
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+	return []byte(ciphertext), nil
+}
+
+func (p *Provider) decrypt(ctx context.Context, sess *session, op *operation, data []byte) ([]byte, error) {
+	client, err := p.sessionClient(sess)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Logical().WriteWithContext(ctx, transitPath(sess, "decrypt", op), map[string]interface{}{
+		"ciphertext": string(data),
+	})
+	if err != nil {
+		return nil, p.transitError(err, "failed to decrypt with transit", CKR_ENCRYPTED_DATA_INVALID)
+	}
+	if resp == nil {
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+
+	plaintext, _ := resp.Data["plaintext"].(string)
+	raw, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		p.logger.Error("invalid plaintext returned by transit", "error", err)
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+	return raw, nil
+}
+
+// GenerateRandom returns random bytes generated by transit.
+func (p *Provider) GenerateRandom(handle uint, n int) ([]byte, error) {
+	sess, err := p.session(handle)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.lock.Unlock()
+
+	if n == 0 {
+		return []byte{}, nil
+	}
+
+	client, err := p.sessionClient(sess)
+	if err != nil {
+		return nil, err
+	}
+
+	mount := strings.Trim(sess.slot.config.Mount, "/")
+	resp, err := client.Logical().WriteWithContext(context.Background(), fmt.Sprintf("%s/random/%d", mount, n), map[string]interface{}{
+		"format": "base64",
+	})
+	if err != nil {
+		return nil, p.transitError(err, "failed to generate random bytes with transit", CKR_ARGUMENTS_BAD)
+	}
+	if resp == nil {
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+
+	random, _ := resp.Data["random_bytes"].(string)
+	raw, err := base64.StdEncoding.DecodeString(random)
+	if err != nil || len(raw) != n {
+		p.logger.Error("invalid random bytes returned by transit", "error", err)
+		return nil, Error(CKR_DEVICE_ERROR)
+	}
+	return raw, nil
+}
@@ -0,0 +1,676 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package pkcs11provider implements a PKCS#11 provider backed by the transit
+// secrets engine, so that applications which can only use keys through
+// PKCS#11 can sign, verify, encrypt and decrypt with the keys of transit.
+//
+// Each slot of the provider holds a token with the keys of a transit mount.
+// Their objects are the private and public keys and the certificates of the
+// asymmetric keys, and the secret keys of the symmetric keys, labeled by the
+// name of the key. The keys never leave Vault: the operations with the
+// objects are transit requests, authenticated by the Vault token configured
+// in the environment or given as the PIN of the user.
+//
+// The methods of the provider follow the PKCS#11 functions of the same name,
+// and return their errors as Error values. The libvault-pkcs11 library
+// exposes them to applications through the PKCS#11 C interface.
+package pkcs11provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	// ManufacturerID is the manufacturer of the library and of the tokens
+	ManufacturerID = "HashiCorp"
+
+	// LibraryDescription is the description of the library
+	LibraryDescription = "Vault transit PKCS#11 provider"
+
+	// UnavailableInformation is CK_UNAVAILABLE_INFORMATION, for the values
+	// the provider doesn't know
+	UnavailableInformation = ^uint(0)
+
+	tokenModel = "Vault transit"
+	maxPinLen  = 1024
+)
+
+// Error is a PKCS#11 return value other than CKR_OK.
+type Error uint
+
+func (e Error) Error() string {
+	return fmt.Sprintf("pkcs11: error 0x%08X", uint(e))
+}
+
+// SlotInfo is the information of a slot.
+type SlotInfo struct {
+	Description string
+	Flags       uint
+}
+
+// TokenInfo is the information of the token of a slot.
+type TokenInfo struct {
+	Label          string
+	Model          string
+	SerialNumber   string
+	Flags          uint
+	SessionCount   uint
+	RWSessionCount uint
+	MaxPinLen      uint
+	MinPinLen      uint
+}
+
+// MechanismInfo is the information of a mechanism.
+type MechanismInfo struct {
+	MinKeySize uint
+	MaxKeySize uint
+	Flags      uint
+}
+
+// SessionInfo is the information of a session.
+type SessionInfo struct {
+	SlotID uint
+	State  uint
+	Flags  uint
+}
+
+// Mechanism is the mechanism of an operation, with its parameter.
+type Mechanism struct {
+	Type      uint
+	Parameter []byte
+}
+
+// Provider implements the PKCS#11 functions with transit.
+type Provider struct {
+	logger hclog.Logger
+	client *api.Client
+	slots  []*slot
+
+	lock        sync.Mutex
+	sessions    map[uint]*session
+	nextSession uint
+	objects     map[uint]*object
+	handles     map[objectID]uint
+	nextObject  uint
+}
+
+// slot is a slot of the provider, whose token holds the keys of a transit
+// mount.
+type slot struct {
+	id     uint
+	config *SlotConfig
+
+	// token is the Vault token used by the sessions of the slot, which is
+	// the PIN of the user once logged in.
+	token    string
+	loggedIn bool
+}
+
+// session is an open session with the token of a slot. Its operations are
+// serialized by its lock, as PKCS#11 doesn't allow concurrent operations on a
+// session.
+type session struct {
+	lock   sync.Mutex
+	handle uint
+	slot   *slot
+	flags  uint
+
+	find    []uint
+	finding bool
+	sign    *operation
+	verify  *operation
+	encrypt *operation
+	decrypt *operation
+}
+
+// NewProvider returns a provider with the slots of the configuration, using
+// the Vault token of the client, if any, until users log in.
+func NewProvider(conf *Config, client *api.Client, logger hclog.Logger) (*Provider, error) {
+	if conf == nil {
+		return nil, errors.New("nil config provided")
+	}
+	if client == nil {
+		return nil, errors.New("nil client provided")
+	}
+	if logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	p := &Provider{
+		logger:      logger,
+		client:      client,
+		sessions:    make(map[uint]*session),
+		nextSession: 1,
+		objects:     make(map[uint]*object),
+		handles:     make(map[objectID]uint),
+		nextObject:  1,
+	}
+	for i, sc := range conf.Slots {
+		p.slots = append(p.slots, &slot{
+			id:     uint(i),
+			config: sc,
+			token:  client.Token(),
+		})
+	}
+	return p, nil
+}
+
+// SlotList returns the IDs of the slots, which always have a token.
+func (p *Provider) SlotList() []uint {
+	ids := make([]uint, 0, len(p.slots))
+	for _, s := range p.slots {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+func (p *Provider) slot(id uint) (*slot, error) {
+	if id >= uint(len(p.slots)) {
+		return nil, Error(CKR_SLOT_ID_INVALID)
+	}
+	return p.slots[id], nil
+}
+
+// SlotInfo returns the information of a slot.
+func (p *Provider) SlotInfo(id uint) (*SlotInfo, error) {
+	s, err := p.slot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Vault transit mount %s", s.config.Mount)
+	if s.config.Namespace != "" {
+		description = fmt.Sprintf("Vault transit mount %s/%s", strings.Trim(s.config.Namespace, "/"), s.config.Mount)
+	}
+	return &SlotInfo{
+		Description: description,
+		Flags:       CKF_TOKEN_PRESENT,
+	}, nil
+}
+
+// TokenInfo returns the information of the token of a slot.
+func (p *Provider) TokenInfo(id uint) (*TokenInfo, error) {
+	s, err := p.slot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	info := &TokenInfo{
+		Label:        s.config.Label,
+		Model:        tokenModel,
+		SerialNumber: fmt.Sprintf("%d", s.id),
+		Flags:        CKF_RNG | CKF_TOKEN_INITIALIZED | CKF_USER_PIN_INITIALIZED,
+		MaxPinLen:    maxPinLen,
+		MinPinLen:    1,
+	}
+	if p.client.Token() == "" {
+		info.Flags |= CKF_LOGIN_REQUIRED
+	}
+	for _, sess := range p.sessions {
+		if sess.slot == s {
+			info.SessionCount++
+			if sess.flags&CKF_RW_SESSION != 0 {
+				info.RWSessionCount++
+			}
+		}
+	}
+	return info, nil
+}
+
+// MechanismList returns the mechanisms supported by the token of a slot.
+func (p *Provider) MechanismList(id uint) ([]uint, error) {
+	if _, err := p.slot(id); err != nil {
+		return nil, err
+	}
+
+	mechanisms := make([]uint, 0, len(mechanismInfos))
+	for m := range mechanismInfos {
+		mechanisms = append(mechanisms, m)
+	}
+	sort.Slice(mechanisms, func(i, j int) bool { return mechanisms[i] < mechanisms[j] })
+	return mechanisms, nil
+}
+
+// MechanismInfo returns the information of a mechanism supported by the token
+// of a slot.
+func (p *Provider) MechanismInfo(id, mechanism uint) (*MechanismInfo, error) {
+	if _, err := p.slot(id); err != nil {
+		return nil, err
+	}
+
+	info, ok := mechanismInfos[mechanism]
+	if !ok {
+		return nil, Error(CKR_MECHANISM_INVALID)
+	}
+	return &info, nil
+}
+
+// OpenSession opens a session with the token of a slot.
+func (p *Provider) OpenSession(id, flags uint) (uint, error) {
+	s, err := p.slot(id)
+	if err != nil {
+		return 0, err
+	}
+	if flags&CKF_SERIAL_SESSION == 0 {
+		return 0, Error(CKR_SESSION_PARALLEL_NOT_SUPPORTED)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	handle := p.nextSession
+	p.nextSession++
+	p.sessions[handle] = &session{
+		handle: handle,
+		slot:   s,
+		flags:  flags & (CKF_RW_SESSION | CKF_SERIAL_SESSION),
+	}
+	return handle, nil
+}
+
+// CloseSession closes a session.
+func (p *Provider) CloseSession(handle uint) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sess, ok := p.sessions[handle]
+	if !ok {
+		return Error(CKR_SESSION_HANDLE_INVALID)
+	}
+	p.closeSession(sess)
+	return nil
+}
+
+// CloseAllSessions closes the sessions with the token of a slot.
+func (p *Provider) CloseAllSessions(id uint) error {
+	s, err := p.slot(id)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, sess := range p.sessions {
+		if sess.slot == s {
+			p.closeSession(sess)
+		}
+	}
+	return nil
+}
+
+// closeSession closes a session, logging the user out of the token when it
+// was its last session, as required by PKCS#11. It is called with the lock
+// held.
+func (p *Provider) closeSession(sess *session) {
+	delete(p.sessions, sess.handle)
+	for _, other := range p.sessions {
+		if other.slot == sess.slot {
+			return
+		}
+	}
+	p.logout(sess.slot)
+}
+
+// session returns the session of the handle, locked, to be unlocked by the
+// caller.
+func (p *Provider) session(handle uint) (*session, error) {
+	p.lock.Lock()
+	sess, ok := p.sessions[handle]
+	p.lock.Unlock()
+	if !ok {
+		return nil, Error(CKR_SESSION_HANDLE_INVALID)
+	}
+
+	sess.lock.Lock()
+	return sess, nil
+}
+
+// SessionInfo returns the information of a session.
+func (p *Provider) SessionInfo(handle uint) (*SessionInfo, error) {
+	sess, err := p.session(handle)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.lock.Unlock()
+
+	p.lock.Lock()
+	authenticated := sess.slot.token != ""
+	p.lock.Unlock()
+
+	var state uint
+	switch {
+	case sess.flags&CKF_RW_SESSION != 0 && authenticated:
+		state = CKS_RW_USER_FUNCTIONS
+	case sess.flags&CKF_RW_SESSION != 0:
+		state = CKS_RW_PUBLIC_SESSION
+	case authenticated:
+		state = CKS_RO_USER_FUNCTIONS
+	default:
+		state = CKS_RO_PUBLIC_SESSION
+	}
+
+	return &SessionInfo{
+		SlotID: sess.slot.id,
+		State:  state,
+		Flags:  sess.flags,
+	}, nil
+}
+
+// Login logs the user in the token of the session, with the PIN as Vault
+// token.
+func (p *Provider) Login(handle, userType uint, pin string) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	// There is no security officer, as tokens and PINs are managed in Vault
+	if userType != CKU_USER {
+		return Error(CKR_USER_TYPE_INVALID)
+	}
+
+	p.lock.Lock()
+	loggedIn := sess.slot.loggedIn
+	p.lock.Unlock()
+	if loggedIn {
+		return Error(CKR_USER_ALREADY_LOGGED_IN)
+	}
+	if pin == "" {
+		return Error(CKR_PIN_INCORRECT)
+	}
+
+	client, err := p.slotClient(sess.slot, pin)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Auth().Token().LookupSelf(); err != nil {
+		p.logger.Warn("failed to log in with the Vault token given as PIN", "slot", sess.slot.id, "error", err)
+		return Error(CKR_PIN_INCORRECT)
+	}
+
+	p.lock.Lock()
+	sess.slot.token = pin
+	sess.slot.loggedIn = true
+	p.lock.Unlock()
+	return nil
+}
+
+// Logout logs the user out of the token of the session.
+func (p *Provider) Logout(handle uint) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !sess.slot.loggedIn {
+		return Error(CKR_USER_NOT_LOGGED_IN)
+	}
+	p.logout(sess.slot)
+	return nil
+}
+
+// logout logs the user out of the token of a slot, which then uses the token
+// of the client again. It is called with the lock held.
+func (p *Provider) logout(s *slot) {
+	s.token = p.client.Token()
+	s.loggedIn = false
+}
+
+// slotClient returns a client of the transit mount of a slot, with the token.
+func (p *Provider) slotClient(s *slot, token string) (*api.Client, error) {
+	client, err := p.client.CloneWithHeaders()
+	if err != nil {
+		p.logger.Error("failed to create a Vault client", "error", err)
+		return nil, Error(CKR_GENERAL_ERROR)
+	}
+	client.SetToken(token)
+	if s.config.Namespace != "" {
+		client.SetNamespace(s.config.Namespace)
+	}
+	return client, nil
+}
+
+// sessionClient returns a client of the transit mount of the slot of a
+// session, with its Vault token.
+func (p *Provider) sessionClient(sess *session) (*api.Client, error) {
+	p.lock.Lock()
+	token := sess.slot.token
+	p.lock.Unlock()
+
+	if token == "" {
+		return nil, Error(CKR_USER_NOT_LOGGED_IN)
+	}
+	return p.slotClient(sess.slot, token)
+}
+
+// loadObjects reads the keys of the transit mount of the slot of a session,
+// and updates their objects, which keep their handles.
+func (p *Provider) loadObjects(ctx context.Context, sess *session) error {
+	client, err := p.sessionClient(sess)
+	if err != nil {
+		return err
+	}
+
+	mount := strings.Trim(sess.slot.config.Mount, "/")
+	list, err := client.Logical().ListWithContext(ctx, mount+"/keys")
+	if err != nil {
+		return p.transitError(err, "failed to list transit keys", CKR_DEVICE_ERROR)
+	}
+
+	var keys []*transitKey
+	if list != nil {
+		names, _ := list.Data["keys"].([]interface{})
+		for _, rawName := range names {
+			name, _ := rawName.(string)
+			if name == "" {
+				continue
+			}
+
+			resp, err := client.Logical().ReadWithContext(ctx, mount+"/keys/"+name)
+			if err != nil {
+				return p.transitError(err, "failed to read transit key", CKR_DEVICE_ERROR)
+			}
+			if resp == nil {
+				// Deleted after the list
+				continue
+			}
+
+			key, err := parseKey(name, resp.Data)
+			if err != nil {
+				p.logger.Warn("ignoring transit key", "slot", sess.slot.id, "error", err)
+				continue
+			}
+			if key != nil {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	current := make(map[uint]struct{})
+	for _, key := range keys {
+		for _, class := range key.classes() {
+			id := objectID{slot: sess.slot.id, name: key.name, class: class}
+			handle, ok := p.handles[id]
+			if !ok {
+				handle = p.nextObject
+				p.nextObject++
+				p.handles[id] = handle
+			}
+
+			o, err := newObject(handle, sess.slot.id, class, key)
+			if err != nil {
+				p.logger.Warn("ignoring transit key", "slot", sess.slot.id, "key", key.name, "error", err)
+				continue
+			}
+			p.objects[handle] = o
+			current[handle] = struct{}{}
+		}
+	}
+
+	// Remove the objects of the deleted keys
+	for handle, o := range p.objects {
+		if _, ok := current[handle]; !ok && o.slot == sess.slot.id {
+			delete(p.objects, handle)
+		}
+	}
+
+	return nil
+}
+
+// object returns an object visible by a session. It is called without the
+// lock held.
+func (p *Provider) object(sess *session, handle uint) (*object, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	o, ok := p.objects[handle]
+	if !ok || o.slot != sess.slot.id {
+		return nil, Error(CKR_OBJECT_HANDLE_INVALID)
+	}
+	return o, nil
+}
+
+// FindObjectsInit starts searching the objects of the token of the session
+// matching the template, reading the keys of its transit mount.
+func (p *Provider) FindObjectsInit(handle uint, template []*Attribute) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	if sess.finding {
+		return Error(CKR_OPERATION_ACTIVE)
+	}
+
+	// Without a Vault token, there are no visible objects
+	var found []uint
+	switch err := p.loadObjects(context.Background(), sess); err {
+	case nil:
+		p.lock.Lock()
+		for handle, o := range p.objects {
+			if o.slot == sess.slot.id && o.matches(template) {
+				found = append(found, handle)
+			}
+		}
+		p.lock.Unlock()
+	case Error(CKR_USER_NOT_LOGGED_IN):
+	default:
+		return err
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	sess.find = found
+	sess.finding = true
+	return nil
+}
+
+// FindObjects returns up to maxCount handles of the objects found.
+func (p *Provider) FindObjects(handle uint, maxCount int) ([]uint, error) {
+	sess, err := p.session(handle)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.lock.Unlock()
+
+	if !sess.finding {
+		return nil, Error(CKR_OPERATION_NOT_INITIALIZED)
+	}
+
+	n := len(sess.find)
+	if maxCount < n {
+		n = maxCount
+	}
+	found := sess.find[:n]
+	sess.find = sess.find[n:]
+	return found, nil
+}
+
+// FindObjectsFinal ends the search of objects.
+func (p *Provider) FindObjectsFinal(handle uint) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	if !sess.finding {
+		return Error(CKR_OPERATION_NOT_INITIALIZED)
+	}
+	sess.find = nil
+	sess.finding = false
+	return nil
+}
+
+// GetAttributeValue sets the values of the attributes of an object. The
+// values of the attributes the object doesn't have, or which are sensitive,
+// are set to nil, and the error reports them.
+func (p *Provider) GetAttributeValue(handle, objectHandle uint, attributes []*Attribute) error {
+	sess, err := p.session(handle)
+	if err != nil {
+		return err
+	}
+	defer sess.lock.Unlock()
+
+	o, err := p.object(sess, objectHandle)
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, attr := range attributes {
+		value, ok := o.attributes[attr.Type]
+		switch {
+		case ok:
+			attr.Value = value
+		case attr.Type == CKA_VALUE && o.class != CKO_PUBLIC_KEY:
+			attr.Value = nil
+			result = Error(CKR_ATTRIBUTE_SENSITIVE)
+		default:
+			attr.Value = nil
+			if result == nil {
+				result = Error(CKR_ATTRIBUTE_TYPE_INVALID)
+			}
+		}
+	}
+	return result
+}
+
+// transitError logs an error of a transit request, and returns the PKCS#11
+// error for it: the invalid error for requests rejected because of their
+// data, or CKR_DEVICE_ERROR.
+func (p *Provider) transitError(err error, msg string, invalid uint) error {
+	p.logger.Error(msg, "error", err)
+
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case 400:
+			return Error(invalid)
+		case 403:
+			return Error(CKR_USER_NOT_LOGGED_IN)
+		}
+	}
+	return Error(CKR_DEVICE_ERROR)
+}
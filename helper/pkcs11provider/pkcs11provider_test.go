@@ -0,0 +1,437 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pkcs11provider
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// testProvider returns a provider for a test cluster whose transit mount has
+// keys of each type, named after their type, and the root token of the
+// cluster. The client of the provider has no token unless withToken is set.
+func testProvider(t *testing.T, withToken bool) (*Provider, string) {
+	t.Helper()
+
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	cluster.Start()
+	t.Cleanup(cluster.Cleanup)
+
+	client := cluster.Cores[0].Client
+	if err := client.Sys().Mount("transit", &api.MountInput{Type: "transit"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, keyType := range []string{"rsa-2048", "ecdsa-p256", "ed25519", "aes256-gcm96", "hmac"} {
+		data := map[string]interface{}{
+			"type": keyType,
+		}
+		if keyType == "hmac" {
+			data["key_size"] = 32
+		}
+		if _, err := client.Logical().Write("transit/keys/"+keyType, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	providerClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	providerClient.ClearToken()
+	if withToken {
+		providerClient.SetToken(cluster.RootToken)
+	}
+
+	p, err := NewProvider(DefaultConfig(), providerClient, hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, cluster.RootToken
+}
+
+func testSession(t *testing.T, p *Provider) uint {
+	t.Helper()
+
+	handle, err := p.OpenSession(0, CKF_SERIAL_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.CloseSession(handle) })
+	return handle
+}
+
+func findObjects(t *testing.T, p *Provider, handle uint, template []*Attribute) []uint {
+	t.Helper()
+
+	if err := p.FindObjectsInit(handle, template); err != nil {
+		t.Fatal(err)
+	}
+	found, err := p.FindObjects(handle, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.FindObjectsFinal(handle); err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func findObject(t *testing.T, p *Provider, handle uint, label string, class uint) uint {
+	t.Helper()
+
+	found := findObjects(t, p, handle, []*Attribute{
+		{Type: CKA_LABEL, Value: []byte(label)},
+		{Type: CKA_CLASS, Value: ulongBytes(class)},
+	})
+	if len(found) != 1 {
+		t.Fatalf("expected a single object, found %d", len(found))
+	}
+	return found[0]
+}
+
+func TestProvider_Login(t *testing.T) {
+	p, rootToken := testProvider(t, false)
+	handle := testSession(t, p)
+
+	info, err := p.TokenInfo(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Flags&CKF_LOGIN_REQUIRED == 0 {
+		t.Fatal("expected the token to require a login")
+	}
+	if info.SessionCount != 1 {
+		t.Fatalf("expected 1 session, got %d", info.SessionCount)
+	}
+
+	// Without a Vault token, no objects are visible
+	if found := findObjects(t, p, handle, nil); len(found) != 0 {
+		t.Fatalf("expected no objects before logging in, found %d", len(found))
+	}
+
+	if err := p.Login(handle, CKU_SO, rootToken); err != Error(CKR_USER_TYPE_INVALID) {
+		t.Fatalf("expected CKR_USER_TYPE_INVALID, got %v", err)
+	}
+	if err := p.Login(handle, CKU_USER, "invalid"); err != Error(CKR_PIN_INCORRECT) {
+		t.Fatalf("expected CKR_PIN_INCORRECT, got %v", err)
+	}
+	if err := p.Login(handle, CKU_USER, rootToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Login(handle, CKU_USER, rootToken); err != Error(CKR_USER_ALREADY_LOGGED_IN) {
+		t.Fatalf("expected CKR_USER_ALREADY_LOGGED_IN, got %v", err)
+	}
+
+	sessionInfo, err := p.SessionInfo(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionInfo.State != CKS_RO_USER_FUNCTIONS {
+		t.Fatalf("expected the session to be logged in, got state %d", sessionInfo.State)
+	}
+
+	// The HMAC key has no objects, and the other keys have their private
+	// and public keys, or their secret key
+	if found := findObjects(t, p, handle, nil); len(found) != 7 {
+		t.Fatalf("expected 7 objects, found %d", len(found))
+	}
+	findObject(t, p, handle, "aes256-gcm96", CKO_SECRET_KEY)
+
+	if err := p.Logout(handle); err != nil {
+		t.Fatal(err)
+	}
+	if found := findObjects(t, p, handle, nil); len(found) != 0 {
+		t.Fatalf("expected no objects after logging out, found %d", len(found))
+	}
+}
+
+func TestProvider_GetAttributeValue(t *testing.T) {
+	p, _ := testProvider(t, true)
+	handle := testSession(t, p)
+
+	public := findObject(t, p, handle, "ecdsa-p256", CKO_PUBLIC_KEY)
+	attrs := []*Attribute{
+		{Type: CKA_KEY_TYPE},
+		{Type: CKA_ID},
+		{Type: CKA_PUBLIC_KEY_INFO},
+		{Type: CKA_EC_POINT},
+	}
+	if err := p.GetAttributeValue(handle, public, attrs); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(attrs[0].Value, ulongBytes(CKK_EC)) {
+		t.Fatalf("unexpected key type %v", attrs[0].Value)
+	}
+	if string(attrs[1].Value) != "ecdsa-p256" {
+		t.Fatalf("unexpected ID %q", attrs[1].Value)
+	}
+	pub, err := x509.ParsePKIXPublicKey(attrs[2].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhKey, err := pub.(*ecdsa.PublicKey).ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var point []byte
+	if _, err := asn1.Unmarshal(attrs[3].Value, &point); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(point, ecdhKey.Bytes()) {
+		t.Fatal("the EC point doesn't match the public key")
+	}
+
+	private := findObject(t, p, handle, "ecdsa-p256", CKO_PRIVATE_KEY)
+	attrs = []*Attribute{
+		{Type: CKA_SENSITIVE},
+		{Type: CKA_VALUE},
+	}
+	if err := p.GetAttributeValue(handle, private, attrs); err != Error(CKR_ATTRIBUTE_SENSITIVE) {
+		t.Fatalf("expected CKR_ATTRIBUTE_SENSITIVE, got %v", err)
+	}
+	if !bytes.Equal(attrs[0].Value, boolBytes(true)) || attrs[1].Value != nil {
+		t.Fatalf("unexpected values %v", attrs)
+	}
+}
+
+func TestProvider_Sign(t *testing.T) {
+	p, _ := testProvider(t, true)
+	handle := testSession(t, p)
+
+	data := []byte("data to sign")
+	digest := sha256.Sum256(data)
+	digestInfo := append([]byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}, digest[:]...)
+	pssParams := append(append(ulongBytes(CKM_SHA256), ulongBytes(CKG_MGF1_SHA256)...), ulongBytes(32)...)
+
+	publicKey := func(t *testing.T, label string) crypto.PublicKey {
+		attrs := []*Attribute{{Type: CKA_PUBLIC_KEY_INFO}}
+		if err := p.GetAttributeValue(handle, findObject(t, p, handle, label, CKO_PUBLIC_KEY), attrs); err != nil {
+			t.Fatal(err)
+		}
+		pub, err := x509.ParsePKIXPublicKey(attrs[0].Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pub
+	}
+
+	tests := map[string]struct {
+		label     string
+		mechanism *Mechanism
+		data      []byte
+		verify    func(pub crypto.PublicKey, signature []byte) error
+	}{
+		"rsa pkcs1v15": {
+			label:     "rsa-2048",
+			mechanism: &Mechanism{Type: CKM_SHA256_RSA_PKCS},
+			data:      data,
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				return rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], signature)
+			},
+		},
+		"rsa pkcs1v15 digest info": {
+			label:     "rsa-2048",
+			mechanism: &Mechanism{Type: CKM_RSA_PKCS},
+			data:      digestInfo,
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				return rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), 0, digestInfo, signature)
+			},
+		},
+		"rsa pss": {
+			label:     "rsa-2048",
+			mechanism: &Mechanism{Type: CKM_SHA256_RSA_PKCS_PSS, Parameter: pssParams},
+			data:      data,
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				return rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], signature, &rsa.PSSOptions{SaltLength: 32})
+			},
+		},
+		"rsa pss prehashed": {
+			label:     "rsa-2048",
+			mechanism: &Mechanism{Type: CKM_RSA_PKCS_PSS, Parameter: pssParams},
+			data:      digest[:],
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				return rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA256, digest[:], signature, &rsa.PSSOptions{SaltLength: 32})
+			},
+		},
+		"ecdsa": {
+			label:     "ecdsa-p256",
+			mechanism: &Mechanism{Type: CKM_ECDSA_SHA256},
+			data:      data,
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				if len(signature) != 64 {
+					return Error(CKR_SIGNATURE_LEN_RANGE)
+				}
+				r := new(big.Int).SetBytes(signature[:32])
+				s := new(big.Int).SetBytes(signature[32:])
+				if !ecdsa.Verify(pub.(*ecdsa.PublicKey), digest[:], r, s) {
+					return Error(CKR_SIGNATURE_INVALID)
+				}
+				return nil
+			},
+		},
+		"ed25519": {
+			label:     "ed25519",
+			mechanism: &Mechanism{Type: CKM_EDDSA},
+			data:      data,
+			verify: func(pub crypto.PublicKey, signature []byte) error {
+				if !ed25519.Verify(pub.(ed25519.PublicKey), data, signature) {
+					return Error(CKR_SIGNATURE_INVALID)
+				}
+				return nil
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			private := findObject(t, p, handle, tc.label, CKO_PRIVATE_KEY)
+			public := findObject(t, p, handle, tc.label, CKO_PUBLIC_KEY)
+
+			if err := p.SignInit(handle, tc.mechanism, private); err != nil {
+				t.Fatal(err)
+			}
+			signature, err := p.Sign(handle, tc.data, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Sign(handle, tc.data, len(signature)-1); err != Error(CKR_BUFFER_TOO_SMALL) {
+				t.Fatalf("expected CKR_BUFFER_TOO_SMALL, got %v", err)
+			}
+			returned, err := p.Sign(handle, tc.data, len(signature))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(signature, returned) {
+				t.Fatal("the signature changed after querying its length")
+			}
+
+			if err := tc.verify(publicKey(t, tc.label), signature); err != nil {
+				t.Fatalf("failed to verify the signature: %v", err)
+			}
+
+			if err := p.VerifyInit(handle, tc.mechanism, public); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Verify(handle, tc.data, signature); err != nil {
+				t.Fatal(err)
+			}
+
+			tampered := append([]byte(nil), signature...)
+			tampered[0] ^= 0xff
+			if err := p.VerifyInit(handle, tc.mechanism, public); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Verify(handle, tc.data, tampered); err != Error(CKR_SIGNATURE_INVALID) {
+				t.Fatalf("expected CKR_SIGNATURE_INVALID, got %v", err)
+			}
+		})
+	}
+
+	t.Run("multi-part", func(t *testing.T) {
+		private := findObject(t, p, handle, "rsa-2048", CKO_PRIVATE_KEY)
+		if err := p.SignInit(handle, &Mechanism{Type: CKM_SHA256_RSA_PKCS}, private); err != nil {
+			t.Fatal(err)
+		}
+		for _, part := range [][]byte{data[:4], data[4:]} {
+			if err := p.SignUpdate(handle, part); err != nil {
+				t.Fatal(err)
+			}
+		}
+		signature, err := p.SignFinal(handle, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey(t, "rsa-2048").(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("inconsistent key", func(t *testing.T) {
+		private := findObject(t, p, handle, "ed25519", CKO_PRIVATE_KEY)
+		if err := p.SignInit(handle, &Mechanism{Type: CKM_ECDSA_SHA256}, private); err != Error(CKR_KEY_TYPE_INCONSISTENT) {
+			t.Fatalf("expected CKR_KEY_TYPE_INCONSISTENT, got %v", err)
+		}
+	})
+}
+
+func TestProvider_Encrypt(t *testing.T) {
+	p, _ := testProvider(t, true)
+	handle := testSession(t, p)
+
+	key := findObject(t, p, handle, "aes256-gcm96", CKO_SECRET_KEY)
+	mechanism := &Mechanism{Type: CKM_VAULT_TRANSIT}
+	plaintext := []byte("data to encrypt")
+
+	if err := p.EncryptInit(handle, mechanism, key); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := p.Encrypt(handle, plaintext, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(ciphertext), "vault:v1:") {
+		t.Fatalf("unexpected ciphertext %q", ciphertext)
+	}
+
+	if err := p.DecryptInit(handle, mechanism, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Decrypt(handle, ciphertext, 1); err != Error(CKR_BUFFER_TOO_SMALL) {
+		t.Fatalf("expected CKR_BUFFER_TOO_SMALL, got %v", err)
+	}
+	decrypted, err := p.Decrypt(handle, ciphertext, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("expected %q, got %q", plaintext, decrypted)
+	}
+
+	if err := p.DecryptInit(handle, mechanism, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Decrypt(handle, []byte("vault:v1:invalid"), 1024); err != Error(CKR_ENCRYPTED_DATA_INVALID) {
+		t.Fatalf("expected CKR_ENCRYPTED_DATA_INVALID, got %v", err)
+	}
+
+	if err := p.EncryptInit(handle, &Mechanism{Type: CKM_RSA_PKCS}, key); err != Error(CKR_MECHANISM_INVALID) {
+		t.Fatalf("expected CKR_MECHANISM_INVALID, got %v", err)
+	}
+}
+
+func TestProvider_GenerateRandom(t *testing.T) {
+	p, _ := testProvider(t, true)
+	handle := testSession(t, p)
+
+	random, err := p.GenerateRandom(handle, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random) != 48 {
+		t.Fatalf("expected 48 bytes, got %d", len(random))
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pluginoci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxManifestSize bounds the size of the manifests read from registries
+	maxManifestSize = 4 << 20
+
+	// MaxArtifactSize bounds the size of the plugin binaries pulled from
+	// registries
+	MaxArtifactSize = 1 << 30
+
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// ErrNotFound is returned when a manifest or blob does not exist in the
+// registry.
+var ErrNotFound = errors.New("not found in registry")
+
+// Client pulls plugin artifacts from OCI registries using the distribution
+// API. The zero value is ready to use and pulls anonymously over HTTPS.
+type Client struct {
+	// HTTPClient is the client used to reach registries, which defaults to a
+	// client with a clean transport
+	HTTPClient *http.Client
+
+	// PlainHTTP reaches registries over HTTP instead of HTTPS, which is only
+	// meant for tests and registries on the loopback interface
+	PlainHTTP bool
+
+	// Username and Password are the credentials used to authenticate to
+	// registries, if any
+	Username string
+	Password string
+
+	tokensLock sync.Mutex
+	tokens     map[string]string
+}
+
+// Artifact describes a plugin artifact resolved in a registry. A plugin
+// artifact has a single layer, which is the plugin binary.
+type Artifact struct {
+	// ManifestDigest is the digest of the manifest of the artifact, which is
+	// what signatures are made over
+	ManifestDigest digest.Digest
+
+	// Binary is the descriptor of the layer holding the plugin binary. The
+	// encoded part of its digest is the SHA-256 of the binary.
+	Binary ocispec.Descriptor
+}
+
+// Resolve fetches the manifest of the artifact, and returns its digest and
+// the descriptor of its binary.
+func (c *Client) Resolve(ctx context.Context, ref *Reference) (*Artifact, error) {
+	manifest, dgst, err := c.fetchManifest(ctx, ref.Registry, ref.Repository, ref.reference())
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest != "" && ref.Digest != dgst {
+		return nil, fmt.Errorf("digest of the manifest of %s is %s", ref, dgst)
+	}
+
+	if len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("artifact %s must have exactly one layer holding the plugin binary, found %d", ref, len(manifest.Layers))
+	}
+	binary := manifest.Layers[0]
+	if binary.Digest.Algorithm() != digest.SHA256 {
+		return nil, fmt.Errorf("plugin binary of %s must be addressed by a sha256 digest, got %s", ref, binary.Digest.Algorithm())
+	}
+	if binary.Size <= 0 || binary.Size > MaxArtifactSize {
+		return nil, fmt.Errorf("plugin binary of %s has invalid size %d", ref, binary.Size)
+	}
+
+	return &Artifact{
+		ManifestDigest: dgst,
+		Binary:         binary,
+	}, nil
+}
+
+// FetchBinary writes the plugin binary of the artifact to w, verifying its
+// size and digest. On error, a partial binary may have been written.
+func (c *Client) FetchBinary(ctx context.Context, ref *Reference, artifact *Artifact, w io.Writer) error {
+	return c.fetchBlob(ctx, ref.Registry, ref.Repository, artifact.Binary, w)
+}
+
+func (c *Client) fetchManifest(ctx context.Context, registry, repository, reference string) (*ocispec.Manifest, digest.Digest, error) {
+	resp, err := c.get(ctx, registry, repository, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference),
+		strings.Join([]string{ocispec.MediaTypeImageManifest, mediaTypeDockerManifest}, ", "))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if len(body) > maxManifestSize {
+		return nil, "", fmt.Errorf("manifest of %s/%s:%s exceeds %d bytes", registry, repository, reference, maxManifestSize)
+	}
+
+	manifest := new(ocispec.Manifest)
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return manifest, digest.FromBytes(body), nil
+}
+
+func (c *Client) fetchBlob(ctx context.Context, registry, repository string, desc ocispec.Descriptor, w io.Writer) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid blob digest: %w", err)
+	}
+
+	resp, err := c.get(ctx, registry, repository, fmt.Sprintf("/v2/%s/blobs/%s", repository, desc.Digest), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	verifier := desc.Digest.Verifier()
+	n, err := io.Copy(io.MultiWriter(w, verifier), io.LimitReader(resp.Body, desc.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", desc.Digest, err)
+	}
+	if n != desc.Size {
+		return fmt.Errorf("blob %s has size %d, expected %d", desc.Digest, n, desc.Size)
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("blob %s does not match its digest", desc.Digest)
+	}
+	return nil
+}
+
+// get sends a GET request to the registry, authenticating with the challenge
+// of the registry if it is rejected as unauthorized.
+func (c *Client) get(ctx context.Context, registry, repository, path, accept string) (*http.Response, error) {
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s%s", scheme, registry, path)
+	scope := fmt.Sprintf("repository:%s:pull", repository)
+
+	do := func(authorize func(*http.Request)) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if authorize != nil {
+			authorize(req)
+		}
+		return c.httpClient().Do(req)
+	}
+
+	token := c.token(registry, scope)
+	var authorize func(*http.Request)
+	if token != "" {
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+	resp, err := do(authorize)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		authorize, err = c.authenticate(ctx, registry, scope, challenge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = do(authorize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s/%s: %w", registry, strings.TrimPrefix(path, "/v2/"), ErrNotFound)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %q from registry %s", resp.Status, registry)
+	}
+}
+
+// authenticate answers the challenge of a registry, returning a func which
+// authorizes the requests to the registry.
+func (c *Client) authenticate(ctx context.Context, registry, scope, challenge string) (func(*http.Request), error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.Username == "" {
+			return nil, fmt.Errorf("registry %s requires credentials", registry)
+		}
+		return func(req *http.Request) { req.SetBasicAuth(c.Username, c.Password) }, nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, params, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to registry %s: %w", registry, err)
+		}
+		c.tokensLock.Lock()
+		if c.tokens == nil {
+			c.tokens = make(map[string]string)
+		}
+		c.tokens[registry+"/"+scope] = token
+		c.tokensLock.Unlock()
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, nil
+	default:
+		return nil, fmt.Errorf("registry %s rejected the request with unsupported challenge %q", registry, challenge)
+	}
+}
+
+// fetchToken fetches a bearer token from the realm of a challenge, as in the
+// token authentication of the distribution API.
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, scope string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid realm %q in challenge", params["realm"])
+	}
+	if realm.Scheme != "https" && !c.PlainHTTP {
+		return "", fmt.Errorf("refusing to fetch token from realm %q without TLS", params["realm"])
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if s := params["scope"]; s != "" {
+		scope = s
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q from token realm", resp.Status)
+	}
+
+	var out struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if out.Token == "" {
+		out.Token = out.AccessToken
+	}
+	if out.Token == "" {
+		return "", errors.New("token realm returned no token")
+	}
+	return out.Token, nil
+}
+
+func (c *Client) token(registry, scope string) string {
+	c.tokensLock.Lock()
+	defer c.tokensLock.Unlock()
+	return c.tokens[registry+"/"+scope]
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return cleanhttp.DefaultClient()
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.example.com/token",service="registry".
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			var ok bool
+			value, rest, ok = strings.Cut(rest[1:], `"`)
+			if !ok {
+				rest = ""
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			params[key] = value
+		}
+	}
+	return scheme, params
+}
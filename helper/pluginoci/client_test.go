@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pluginoci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry is an in-memory registry serving the pull endpoints of the
+// distribution API, optionally behind token authentication.
+type testRegistry struct {
+	manifests map[string][]byte
+	blobs     map[digest.Digest][]byte
+	token     string
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[digest.Digest][]byte),
+	}
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+	if r.token != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test"`, req.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if i := strings.Index(req.URL.Path, "/manifests/"); i != -1 {
+		body, ok := r.manifests[req.URL.Path[i+len("/manifests/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Write(body)
+		return
+	}
+	if i := strings.Index(req.URL.Path, "/blobs/"); i != -1 {
+		body, ok := r.blobs[digest.Digest(req.URL.Path[i+len("/blobs/"):])]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// pushManifest stores a manifest with the layers under the tag, and returns
+// its digest.
+func (r *testRegistry) pushManifest(t *testing.T, tag string, layers []ocispec.Descriptor) digest.Digest {
+	t.Helper()
+	body, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: "application/vnd.oci.empty.v1+json",
+			Digest:    digest.FromString("{}"),
+			Size:      2,
+		},
+		Layers: layers,
+	})
+	require.NoError(t, err)
+	dgst := digest.FromBytes(body)
+	r.manifests[tag] = body
+	r.manifests[dgst.String()] = body
+	return dgst
+}
+
+func (r *testRegistry) pushBlob(content []byte, annotations map[string]string) ocispec.Descriptor {
+	dgst := digest.FromBytes(content)
+	r.blobs[dgst] = content
+	return ocispec.Descriptor{
+		MediaType:   "application/octet-stream",
+		Digest:      dgst,
+		Size:        int64(len(content)),
+		Annotations: annotations,
+	}
+}
+
+// sign pushes a cosign signature of the manifest made with the signer.
+func (r *testRegistry) sign(t *testing.T, manifestDigest digest.Digest, sign func([]byte) []byte) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example"},"image":{"docker-manifest-digest":%q},"type":%q},"optional":null}`,
+		manifestDigest, simpleSigningType)
+	layer := r.pushBlob([]byte(payload), map[string]string{
+		annotationSignature: base64.StdEncoding.EncodeToString(sign([]byte(payload))),
+	})
+	r.pushManifest(t, strings.Replace(manifestDigest.String(), ":", "-", 1)+".sig", []ocispec.Descriptor{layer})
+}
+
+func ecdsaSigner(t *testing.T) (crypto.PublicKey, string, func([]byte) []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return &key.PublicKey, pemKey, func(payload []byte) []byte {
+		hash := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		require.NoError(t, err)
+		return sig
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	for ref, expected := range map[string]*Reference{
+		"registry.example.com/vault/my-plugin:v1.0.0": {Registry: "registry.example.com", Repository: "vault/my-plugin", Tag: "v1.0.0"},
+		"localhost:5000/my-plugin":                    {Registry: "localhost:5000", Repository: "my-plugin", Tag: "latest"},
+		"ghcr.io/org/plugin@sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824": {
+			Registry: "ghcr.io", Repository: "org/plugin", Digest: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+	} {
+		actual, err := ParseReference(ref)
+		require.NoError(t, err, ref)
+		require.Equal(t, expected, actual)
+		require.Equal(t, ref, strings.Replace(actual.String(), ":latest", "", 1))
+	}
+
+	for _, ref := range []string{
+		"my-plugin:v1.0.0",
+		"vault/my-plugin",
+		"registry.example.com/My-Plugin",
+		"registry.example.com/plugin@sha256:abc",
+		"registry.example.com/plugin:",
+	} {
+		_, err := ParseReference(ref)
+		require.Error(t, err, ref)
+	}
+}
+
+func TestClient_ResolveAndFetch(t *testing.T) {
+	for name, token := range map[string]string{
+		"anonymous": "",
+		"token":     "test-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry := newTestRegistry()
+			registry.token = token
+			srv := httptest.NewServer(registry)
+			defer srv.Close()
+
+			binary := []byte("#!/bin/sh\necho plugin\n")
+			manifestDigest := registry.pushManifest(t, "v1.0.0", []ocispec.Descriptor{registry.pushBlob(binary, nil)})
+
+			client := &Client{PlainHTTP: true}
+			ref, err := ParseReference(strings.TrimPrefix(srv.URL, "http://") + "/vault/plugin:v1.0.0")
+			require.NoError(t, err)
+
+			artifact, err := client.Resolve(context.Background(), ref)
+			require.NoError(t, err)
+			require.Equal(t, manifestDigest, artifact.ManifestDigest)
+			require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(binary)), artifact.Binary.Digest.Encoded())
+
+			var buf bytes.Buffer
+			require.NoError(t, client.FetchBinary(context.Background(), ref, artifact, &buf))
+			require.Equal(t, binary, buf.Bytes())
+
+			// Pinning the digest of another manifest fails
+			ref.Tag, ref.Digest = "", digest.FromString("other")
+			registry.manifests[ref.Digest.String()] = registry.manifests["v1.0.0"]
+			_, err = client.Resolve(context.Background(), ref)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestClient_FetchBinaryTampered(t *testing.T) {
+	registry := newTestRegistry()
+	srv := httptest.NewServer(registry)
+	defer srv.Close()
+
+	desc := registry.pushBlob([]byte("plugin"), nil)
+	registry.pushManifest(t, "v1.0.0", []ocispec.Descriptor{desc})
+	registry.blobs[desc.Digest] = []byte("evil!!")
+
+	client := &Client{PlainHTTP: true}
+	ref, err := ParseReference(strings.TrimPrefix(srv.URL, "http://") + "/vault/plugin:v1.0.0")
+	require.NoError(t, err)
+	artifact, err := client.Resolve(context.Background(), ref)
+	require.NoError(t, err)
+	err = client.FetchBinary(context.Background(), ref, artifact, &bytes.Buffer{})
+	require.ErrorContains(t, err, "does not match its digest")
+}
+
+func TestClient_VerifySignature(t *testing.T) {
+	registry := newTestRegistry()
+	srv := httptest.NewServer(registry)
+	defer srv.Close()
+
+	client := &Client{PlainHTTP: true}
+	ref, err := ParseReference(strings.TrimPrefix(srv.URL, "http://") + "/vault/plugin:v1.0.0")
+	require.NoError(t, err)
+	registry.pushManifest(t, "v1.0.0", []ocispec.Descriptor{registry.pushBlob([]byte("plugin"), nil)})
+	artifact, err := client.Resolve(context.Background(), ref)
+	require.NoError(t, err)
+
+	pub, pemKey, sign := ecdsaSigner(t)
+	parsed, err := ParsePublicKey(pemKey)
+	require.NoError(t, err)
+	require.Equal(t, pub, parsed)
+	otherPub, _, _ := ecdsaSigner(t)
+
+	// Unsigned artifacts are rejected
+	err = client.VerifySignature(context.Background(), ref, artifact, []crypto.PublicKey{pub})
+	require.True(t, errors.Is(err, ErrNoSignature), err)
+
+	registry.sign(t, artifact.ManifestDigest, sign)
+	require.NoError(t, client.VerifySignature(context.Background(), ref, artifact, []crypto.PublicKey{otherPub, pub}))
+	require.Error(t, client.VerifySignature(context.Background(), ref, artifact, []crypto.PublicKey{otherPub}))
+
+	// A signature of another artifact doesn't verify this one
+	other := &Artifact{ManifestDigest: digest.FromString("other")}
+	sigTag := strings.Replace(artifact.ManifestDigest.String(), ":", "-", 1) + ".sig"
+	registry.manifests[strings.Replace(other.ManifestDigest.String(), ":", "-", 1)+".sig"] = registry.manifests[sigTag]
+	err = client.VerifySignature(context.Background(), ref, other, []crypto.PublicKey{pub})
+	require.ErrorContains(t, err, "signed payload is for manifest")
+
+	// Ed25519 keys are supported
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	registry.sign(t, artifact.ManifestDigest, func(payload []byte) []byte {
+		return ed25519.Sign(edKey, payload)
+	})
+	require.NoError(t, client.VerifySignature(context.Background(), ref, artifact, []crypto.PublicKey{edPub}))
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pluginoci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const defaultTag = "latest"
+
+var (
+	repositoryRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegex        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// Reference is a reference to an artifact in an OCI registry, such as
+// registry.example.com/vault/my-plugin:v1.0.0. Either Tag or Digest is set.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     digest.Digest
+}
+
+// ParseReference parses a reference of the form registry/repository[:tag] or
+// registry/repository@digest. Unlike container engines, the registry is never
+// implied, so that the source of plugins is always explicit.
+func ParseReference(s string) (*Reference, error) {
+	registry, rest, ok := strings.Cut(s, "/")
+	if !ok || registry == "" || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		return nil, fmt.Errorf("reference %q must start with the host of its registry", s)
+	}
+
+	ref := &Reference{Registry: registry}
+	if repository, dgst, ok := strings.Cut(rest, "@"); ok {
+		d, err := digest.Parse(dgst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest in reference %q: %w", s, err)
+		}
+		ref.Repository, ref.Digest = repository, d
+	} else if i := strings.LastIndex(rest, ":"); i != -1 {
+		ref.Repository, ref.Tag = rest[:i], rest[i+1:]
+		if !tagRegex.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("invalid tag in reference %q", s)
+		}
+	} else {
+		ref.Repository, ref.Tag = rest, defaultTag
+	}
+
+	if !repositoryRegex.MatchString(ref.Repository) {
+		return nil, fmt.Errorf("invalid repository in reference %q", s)
+	}
+	return ref, nil
+}
+
+// reference returns the tag or digest identifying the manifest of the
+// artifact in its repository.
+func (r *Reference) reference() string {
+	if r.Digest != "" {
+		return r.Digest.String()
+	}
+	return r.Tag
+}
+
+func (r *Reference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pluginoci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// annotationSignature is the annotation of the layers of cosign signature
+	// manifests holding the base64 signature of the layer
+	annotationSignature = "dev.cosignproject.cosign/signature"
+
+	// simpleSigningType is the type of the payloads signed by cosign
+	simpleSigningType = "cosign container image signature"
+
+	maxSignaturePayloadSize = 1 << 20
+)
+
+// ErrNoSignature is returned when no signature of an artifact can be found.
+var ErrNoSignature = errors.New("no signature found")
+
+// simpleSigning is the payload signed by cosign, in the simple signing
+// format of container signatures.
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// ParsePublicKey parses a PEM encoded ECDSA, RSA or Ed25519 public key, as
+// generated by cosign generate-key-pair.
+func ParsePublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// VerifySignature verifies that the artifact is signed by one of the keys,
+// using the signatures which cosign stores alongside artifacts under the
+// sha256-<digest>.sig tag.
+func (c *Client) VerifySignature(ctx context.Context, ref *Reference, artifact *Artifact, keys []crypto.PublicKey) error {
+	if len(keys) == 0 {
+		return errors.New("no keys to verify signatures with")
+	}
+
+	sigTag := strings.Replace(artifact.ManifestDigest.String(), ":", "-", 1) + ".sig"
+	manifest, _, err := c.fetchManifest(ctx, ref.Registry, ref.Repository, sigTag)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w for %s", ErrNoSignature, ref)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures of %s: %w", ref, err)
+	}
+
+	var errs *multierror.Error
+	for _, layer := range manifest.Layers {
+		sigB64, ok := layer.Annotations[annotationSignature]
+		if !ok {
+			continue
+		}
+		if layer.Size <= 0 || layer.Size > maxSignaturePayloadSize {
+			errs = multierror.Append(errs, fmt.Errorf("signature payload %s has invalid size %d", layer.Digest, layer.Size))
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(sigB64)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to decode signature of %s: %w", layer.Digest, err))
+			continue
+		}
+
+		var payload bytes.Buffer
+		if err := c.fetchBlob(ctx, ref.Registry, ref.Repository, layer, &payload); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+
+		if err := verifyPayload(payload.Bytes(), sig, artifact.ManifestDigest, keys); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("signature %s: %w", layer.Digest, err))
+			continue
+		}
+		return nil
+	}
+
+	if errs.ErrorOrNil() == nil {
+		return fmt.Errorf("%w for %s", ErrNoSignature, ref)
+	}
+	return fmt.Errorf("no valid signature found for %s: %w", ref, errs)
+}
+
+// verifyPayload verifies that the signature of the payload was made by one
+// of the keys, and that the payload is about the manifest digest.
+func verifyPayload(payload, sig []byte, manifestDigest digest.Digest, keys []crypto.PublicKey) error {
+	verified := false
+	for _, key := range keys {
+		if verifyWithKey(key, payload, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return errors.New("signature does not verify with any trusted key")
+	}
+
+	var ss simpleSigning
+	if err := json.Unmarshal(payload, &ss); err != nil {
+		return fmt.Errorf("failed to decode signed payload: %w", err)
+	}
+	if ss.Critical.Type != simpleSigningType {
+		return fmt.Errorf("unexpected signed payload type %q", ss.Critical.Type)
+	}
+	if ss.Critical.Image.DockerManifestDigest != manifestDigest.String() {
+		return fmt.Errorf("signed payload is for manifest %q", ss.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+func verifyWithKey(key crypto.PublicKey, payload, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(payload)
+		return ecdsa.VerifyASN1(k, hash[:], sig)
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(payload)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	default:
+		return false
+	}
+}
@@ -225,6 +225,67 @@ func randomRunes(rng io.Reader, charset []rune, length int) (candidate []rune, e
 	return runes, nil
 }
 
+// Check whether the candidate string could have been generated, which is the case if it has the specified length,
+// only contains characters from the charset and passes all the rules. The reasons it fails are returned as a
+// multierror.
+func (g *StringGenerator) Check(candidate string) error {
+	err := g.validateConfig()
+	if err != nil {
+		return err
+	}
+
+	g.charsetLock.RLock()
+	charset := g.charset
+	g.charsetLock.RUnlock()
+
+	merr := &multierror.Error{}
+	value := []rune(candidate)
+	if len(value) != g.Length {
+		merr = multierror.Append(merr, fmt.Errorf("length is %d but %d is specified", len(value), g.Length))
+	}
+	for _, r := range value {
+		if !charIn(r, charset) {
+			merr = multierror.Append(merr, fmt.Errorf("character %q is not in the charset", r))
+			break
+		}
+	}
+	for _, rule := range g.Rules {
+		if rule.Pass(value) {
+			continue
+		}
+		if charsetRule, ok := rule.(CharsetRule); ok {
+			merr = multierror.Append(merr, fmt.Errorf("requires at least %d characters from charset %q", charsetRule.MinChars, string(charsetRule.Charset)))
+			continue
+		}
+		merr = multierror.Append(merr, fmt.Errorf("failed %s rule", rule.Type()))
+	}
+	return merr.ErrorOrNil()
+}
+
+// Charset returns the characters the generated strings are chosen from, which is the combined charset of the rules.
+func (g *StringGenerator) Charset() []rune {
+	g.charsetLock.RLock()
+	defer g.charsetLock.RUnlock()
+	if len(g.charset) > 0 {
+		return g.charset
+	}
+	return getChars(g.Rules)
+}
+
+// Entropy estimates the entropy, in bits, of the generated strings. This is the entropy of strings of the specified
+// length with characters chosen uniformly from the charset, so it is an upper bound: strings failing the rules are
+// never generated, which slightly lowers the actual entropy.
+func (g *StringGenerator) Entropy() (float64, error) {
+	err := g.validateConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	g.charsetLock.RLock()
+	defer g.charsetLock.RUnlock()
+	return float64(g.Length) * math.Log2(float64(len(g.charset))), nil
+}
+
 // validateConfig of the generator to ensure that we can successfully generate a string.
 func (g *StringGenerator) validateConfig() (err error) {
 	merr := &multierror.Error{}
@@ -15,6 +15,8 @@ import (
 	"sort"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
 )
 
 func TestStringGenerator_Generate_successful(t *testing.T) {
@@ -830,3 +832,108 @@ type charCounts []charCount
 func (s charCounts) Len() int           { return len(s) }
 func (s charCounts) Less(i, j int) bool { return s[i].r < s[j].r }
 func (s charCounts) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func TestStringGenerator_Check(t *testing.T) {
+	generator := &StringGenerator{
+		Length: 8,
+		Rules: []Rule{
+			CharsetRule{
+				Charset:  LowercaseRuneset,
+				MinChars: 1,
+			},
+			CharsetRule{
+				Charset:  NumericRuneset,
+				MinChars: 2,
+			},
+		},
+	}
+
+	type testCase struct {
+		candidate  string
+		expectErrs int
+	}
+
+	tests := map[string]testCase{
+		"valid": {
+			candidate:  "abcdef12",
+			expectErrs: 0,
+		},
+		"too short": {
+			candidate:  "abcde12",
+			expectErrs: 1,
+		},
+		"character not in charset": {
+			candidate:  "abcdE12!",
+			expectErrs: 1,
+		},
+		"failed rule": {
+			candidate:  "abcdefg1",
+			expectErrs: 1,
+		},
+		"multiple failures": {
+			candidate:  "ABC",
+			expectErrs: 4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := generator.Check(test.candidate)
+			if test.expectErrs == 0 {
+				if err != nil {
+					t.Fatalf("no error expected, got: %s", err)
+				}
+				return
+			}
+
+			merr, ok := err.(*multierror.Error)
+			if !ok {
+				t.Fatalf("multierror expected, got: %v", err)
+			}
+			if len(merr.Errors) != test.expectErrs {
+				t.Fatalf("expected %d errors, got: %s", test.expectErrs, err)
+			}
+		})
+	}
+
+	// Generated strings always pass
+	for i := 0; i < 100; i++ {
+		str, err := generator.Generate(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		if err := generator.Check(str); err != nil {
+			t.Fatalf("generated string %q failed the check: %s", str, err)
+		}
+	}
+}
+
+func TestStringGenerator_Entropy(t *testing.T) {
+	generator := &StringGenerator{
+		Length: 10,
+		Rules: []Rule{
+			CharsetRule{
+				Charset:  LowercaseRuneset,
+				MinChars: 1,
+			},
+			CharsetRule{
+				Charset:  []rune("abcdef"),
+				MinChars: 1,
+			},
+		},
+	}
+
+	entropy, err := generator.Entropy()
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	expected := 10 * math.Log2(26)
+	if math.Abs(entropy-expected) > 0.0001 {
+		t.Fatalf("expected entropy of %f, got %f", expected, entropy)
+	}
+
+	_, err = (&StringGenerator{Length: 10}).Entropy()
+	if err == nil {
+		t.Fatalf("err expected, got nil")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/compressutil"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
 )
 
 const (
@@ -232,40 +233,51 @@ func (s *StoragePacker) DeleteMultipleItems(ctx context.Context, logger hclog.Lo
 
 func (s *StoragePacker) putBucket(ctx context.Context, bucket *Bucket) error {
 	defer metrics.MeasureSince([]string{"storage_packer", "put_bucket"}, time.Now())
+
+	entry, err := s.bucketStorageEntry(bucket)
+	if err != nil {
+		return err
+	}
+
+	// Store the compressed value
+	err = s.view.Put(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to persist packed storage entry: %w", err)
+	}
+
+	return nil
+}
+
+// bucketStorageEntry returns the compressed storage entry of the bucket
+func (s *StoragePacker) bucketStorageEntry(bucket *Bucket) (*logical.StorageEntry, error) {
 	if bucket == nil {
-		return fmt.Errorf("nil bucket entry")
+		return nil, fmt.Errorf("nil bucket entry")
 	}
 
 	if bucket.Key == "" {
-		return fmt.Errorf("missing key")
+		return nil, fmt.Errorf("missing key")
 	}
 
 	if !strings.HasPrefix(bucket.Key, s.viewPrefix) {
-		return fmt.Errorf("incorrect prefix; bucket entry key should have %q prefix", s.viewPrefix)
+		return nil, fmt.Errorf("incorrect prefix; bucket entry key should have %q prefix", s.viewPrefix)
 	}
 
 	marshaledBucket, err := proto.Marshal(bucket)
 	if err != nil {
-		return fmt.Errorf("failed to marshal bucket: %w", err)
+		return nil, fmt.Errorf("failed to marshal bucket: %w", err)
 	}
 
 	compressedBucket, err := compressutil.Compress(marshaledBucket, &compressutil.CompressionConfig{
 		Type: compressutil.CompressionTypeSnappy,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to compress packed bucket: %w", err)
+		return nil, fmt.Errorf("failed to compress packed bucket: %w", err)
 	}
 
-	// Store the compressed value
-	err = s.view.Put(ctx, &logical.StorageEntry{
+	return &logical.StorageEntry{
 		Key:   bucket.Key,
 		Value: compressedBucket,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to persist packed storage entry: %w", err)
-	}
-
-	return nil
+	}, nil
 }
 
 // GetItem fetches the storage entry for a given key from its corresponding
@@ -302,12 +314,49 @@ func (s *StoragePacker) GetItem(itemID string) (*Item, error) {
 func (s *StoragePacker) PutItem(ctx context.Context, item *Item) error {
 	defer metrics.MeasureSince([]string{"storage_packer", "put_item"}, time.Now())
 
+	bucket, unlock, err := s.upsertItem(ctx, item)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.putBucket(ctx, bucket)
+}
+
+// PutItemTxn prepares the storage of the given item in its respective bucket
+// as part of a storage transaction on the view of the storage packer: it
+// returns the write of the updated bucket rather than performing it. The
+// bucket stays locked, for reads too, until the returned function is called,
+// which must be done once the transaction has been committed, or abandoned.
+func (s *StoragePacker) PutItemTxn(ctx context.Context, item *Item) (*logical.TxnEntry, func(), error) {
+	defer metrics.MeasureSince([]string{"storage_packer", "put_item_txn"}, time.Now())
+
+	bucket, unlock, err := s.upsertItem(ctx, item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry, err := s.bucketStorageEntry(bucket)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	return &logical.TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     entry,
+	}, unlock, nil
+}
+
+// upsertItem returns the bucket of the given item, updated with the item. On
+// success, the bucket is locked until the returned function is called.
+func (s *StoragePacker) upsertItem(ctx context.Context, item *Item) (*Bucket, func(), error) {
 	if item == nil {
-		return fmt.Errorf("nil item")
+		return nil, nil, fmt.Errorf("nil item")
 	}
 
 	if item.ID == "" {
-		return fmt.Errorf("missing ID in item")
+		return nil, nil, fmt.Errorf("missing ID in item")
 	}
 
 	var err error
@@ -322,12 +371,12 @@ func (s *StoragePacker) PutItem(ctx context.Context, item *Item) error {
 	// even to read the entry.
 	lock := locksutil.LockForKey(s.storageLocks, bucketKey)
 	lock.Lock()
-	defer lock.Unlock()
 
 	// Check if there is an existing bucket for a given key
 	storageEntry, err := s.view.Get(ctx, bucketKey)
 	if err != nil {
-		return fmt.Errorf("failed to read packed storage bucket entry: %w", err)
+		lock.Unlock()
+		return nil, nil, fmt.Errorf("failed to read packed storage bucket entry: %w", err)
 	}
 
 	if storageEntry == nil {
@@ -339,7 +388,8 @@ func (s *StoragePacker) PutItem(ctx context.Context, item *Item) error {
 	} else {
 		uncompressedData, notCompressed, err := compressutil.Decompress(storageEntry.Value)
 		if err != nil {
-			return fmt.Errorf("failed to decompress packed storage entry: %w", err)
+			lock.Unlock()
+			return nil, nil, fmt.Errorf("failed to decompress packed storage entry: %w", err)
 		}
 		if notCompressed {
 			uncompressedData = storageEntry.Value
@@ -347,16 +397,18 @@ func (s *StoragePacker) PutItem(ctx context.Context, item *Item) error {
 
 		err = proto.Unmarshal(uncompressedData, bucket)
 		if err != nil {
-			return fmt.Errorf("failed to decode packed storage entry: %w", err)
+			lock.Unlock()
+			return nil, nil, fmt.Errorf("failed to decode packed storage entry: %w", err)
 		}
 
 		err = bucket.upsert(item)
 		if err != nil {
-			return fmt.Errorf("failed to update entry in packed storage entry: %w", err)
+			lock.Unlock()
+			return nil, nil, fmt.Errorf("failed to update entry in packed storage entry: %w", err)
 		}
 	}
 
-	return s.putBucket(ctx, bucket)
+	return bucket, lock.Unlock, nil
 }
 
 // NewStoragePacker creates a new storage packer for a given view
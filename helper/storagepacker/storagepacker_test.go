@@ -293,3 +293,57 @@ func TestStoragePacker_DeleteMultiple_ALL(t *testing.T) {
 		}
 	}
 }
+
+func TestStoragePacker_PutItemTxn(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	entityPacker, err := NewStoragePacker(storage, log.New(&log.LoggerOptions{Name: "storagepackertest"}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliasPacker, err := NewStoragePacker(storage, log.New(&log.LoggerOptions{Name: "storagepackertest"}), "packer/local-aliases/buckets/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	entityTxn, unlockEntity, err := entityPacker.PutItemTxn(ctx, &Item{ID: "item1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliasTxn, unlockAlias, err := aliasPacker.PutItemTxn(ctx, &Item{ID: "item1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is written until the transaction is committed
+	keys, err := storage.List(ctx, "packer/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected no bucket before the transaction is committed, got %v", keys)
+	}
+
+	err = logical.StorageTransaction(ctx, storage, []*logical.TxnEntry{entityTxn, aliasTxn})
+	unlockEntity()
+	unlockAlias()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, packer := range []*StoragePacker{entityPacker, aliasPacker} {
+		fetchedItem, err := packer.GetItem("item1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fetchedItem == nil || fetchedItem.ID != "item1" {
+			t.Fatalf("bad: item; expected %q, got %#v", "item1", fetchedItem)
+		}
+	}
+
+	// The bucket is unlocked, so the item can be updated again
+	if err := entityPacker.PutItem(ctx, &Item{ID: "item2"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -436,6 +436,12 @@ func (n *NoopAudit) Invalidate(_ context.Context) {
 	n.salt = nil
 }
 
+// HasFiltering reports whether the backend filters the entries it logs, which
+// the NoopAudit backend never does.
+func (n *NoopAudit) HasFiltering() bool {
+	return false
+}
+
 // RegisterNodesAndPipeline registers the nodes and a pipeline as required by
 // the audit.Backend interface.
 func (b *NoopAudit) RegisterNodesAndPipeline(broker *eventlogger.Broker, name string) error {
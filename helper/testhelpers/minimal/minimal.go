@@ -7,6 +7,7 @@ import (
 	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/audit"
 	auditFile "github.com/hashicorp/vault/builtin/audit/file"
+	auditOTLP "github.com/hashicorp/vault/builtin/audit/otlp"
 	auditSocket "github.com/hashicorp/vault/builtin/audit/socket"
 	auditSyslog "github.com/hashicorp/vault/builtin/audit/syslog"
 	logicalDb "github.com/hashicorp/vault/builtin/logical/database"
@@ -65,6 +66,7 @@ func NewTestSoloCluster(t testing.T, config *vault.CoreConfig) *vault.TestCluste
 	if mycfg.AuditBackends == nil {
 		mycfg.AuditBackends = map[string]audit.Factory{
 			"file":   auditFile.Factory,
+			"otlp":   auditOTLP.Factory,
 			"socket": auditSocket.Factory,
 			"syslog": auditSyslog.Factory,
 		}
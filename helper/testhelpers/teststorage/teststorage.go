@@ -14,6 +14,7 @@ import (
 	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/audit"
 	auditFile "github.com/hashicorp/vault/builtin/audit/file"
+	auditOTLP "github.com/hashicorp/vault/builtin/audit/otlp"
 	auditSocket "github.com/hashicorp/vault/builtin/audit/socket"
 	auditSyslog "github.com/hashicorp/vault/builtin/audit/syslog"
 	logicalDb "github.com/hashicorp/vault/builtin/logical/database"
@@ -267,6 +268,7 @@ func ClusterSetup(conf *vault.CoreConfig, opts *vault.TestClusterOptions, setup
 	if localConf.AuditBackends == nil {
 		localConf.AuditBackends = map[string]audit.Factory{
 			"file":   auditFile.Factory,
+			"otlp":   auditOTLP.Factory,
 			"socket": auditSocket.Factory,
 			"syslog": auditSyslog.Factory,
 			"noop":   corehelpers.NoopAuditFactory(nil),
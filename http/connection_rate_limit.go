@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"golang.org/x/time/rate"
+)
+
+// connRateLimiterKey is the context key of the rate limiter of a connection
+type connRateLimiterKey struct{}
+
+// ConnectionRateLimitConnContext returns the function attaching a rate
+// limiter to each connection of the HTTP server of the listener, to be set as
+// the ConnContext of the server, or nil if the listener has no connection
+// rate limit. The requests of all the streams of HTTP/2 connections share the
+// rate limiter of their connection.
+func ConnectionRateLimitConnContext(l *configutil.Listener) func(context.Context, net.Conn) context.Context {
+	if l.ConnectionRateLimit <= 0 {
+		return nil
+	}
+
+	limit := rate.Limit(l.ConnectionRateLimit)
+	burst := int(l.ConnectionRateLimitBurst)
+	if burst <= 0 {
+		burst = int(l.ConnectionRateLimit)
+	}
+
+	return func(ctx context.Context, _ net.Conn) context.Context {
+		return context.WithValue(ctx, connRateLimiterKey{}, rate.NewLimiter(limit, burst))
+	}
+}
+
+// WrapConnectionRateLimitHandler rejects the requests exceeding the rate limit
+// of their connection, see ConnectionRateLimitConnContext, before any other
+// processing, so that a single client can't exhaust the server before rate
+// limit quotas apply.
+func WrapConnectionRateLimitHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter, ok := r.Context().Value(connRateLimiterKey{}).(*rate.Limiter)
+		if ok && !limiter.Allow() {
+			metrics.IncrCounter([]string{"core", "connection_rate_limited"}, 1)
+			w.Header().Set("Retry-After", "1")
+			respondError(w, http.StatusTooManyRequests, errors.New("request rate limit of the connection exceeded"))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/internalshared/configutil"
+)
+
+func TestConnectionRateLimit(t *testing.T) {
+	if ConnectionRateLimitConnContext(&configutil.Listener{}) != nil {
+		t.Fatal("expected no connection rate limit")
+	}
+
+	l := &configutil.Listener{
+		ConnectionRateLimit:      1,
+		ConnectionRateLimitBurst: 3,
+	}
+
+	ts := httptest.NewUnstartedServer(WrapConnectionRateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	ts.Config.ConnContext = ConnectionRateLimitConnContext(l)
+	ts.Start()
+	defer ts.Close()
+
+	get := func(client *http.Client) int {
+		t.Helper()
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The burst of requests of a connection is allowed, not more
+	client := cleanhttp.DefaultPooledClient()
+	for i := 0; i < 3; i++ {
+		if status := get(client); status != http.StatusNoContent {
+			t.Fatalf("request %d: expected status %d, got %d", i, http.StatusNoContent, status)
+		}
+	}
+	if status := get(client); status != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, status)
+	}
+
+	// Other connections have limits of their own
+	if status := get(cleanhttp.DefaultClient()); status != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, status)
+	}
+}
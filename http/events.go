@@ -139,7 +139,12 @@ func (sub *eventSubscriber) handleEventsSubscribeWebsocket() {
 				}
 				messageType = websocket.MessageText
 			} else {
-				messageBytes, err = proto.Marshal(message.Payload.(*logical.EventReceived))
+				// The event is serialized once for all the subscribers sharing its filter
+				var ok bool
+				messageBytes, ok = message.Format(eventbus.ProtobufFormat)
+				if !ok {
+					messageBytes, err = proto.Marshal(message.Payload.(*logical.EventReceived))
+				}
 				messageType = websocket.MessageBinary
 			}
 			if err != nil {
@@ -4,13 +4,32 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/vault"
 )
 
 //go:generate go run github.com/hashicorp/vault/tools/stubmaker
 
+// adjustRequest resolves the namespace of the request from the namespace
+// header and the path, which may both name namespaces. The namespace is set in
+// the context of the returned request, and the header is folded into its path
+// so that a forwarded request is resolved the same way by the active node.
 func adjustRequest(c *vault.Core, listener *configutil.Listener, r *http.Request) (*http.Request, int, error) {
+	nsHeader := r.Header.Get(consts.NamespaceHeaderName)
+	path := namespace.Canonicalize(nsHeader) + strings.TrimPrefix(r.URL.Path, "/v1/")
+
+	ns := c.NamespaceByPathPrefix(path)
+	if nsHeader == "" && ns.ID == namespace.RootNamespaceID {
+		return r, 0, nil
+	}
+
+	r = r.Clone(namespace.ContextWithNamespace(r.Context(), ns))
+	r.Header.Del(consts.NamespaceHeaderName)
+	r.URL.Path = "/v1/" + path
+	r.URL.RawPath = ""
 	return r, 0, nil
 }
@@ -44,6 +44,14 @@ func (b *bufferedReader) Close() error {
 
 const MergePatchContentTypeHeader = "application/merge-patch+json"
 
+// isSCIMPatch returns whether the PATCH request is a SCIM patch of the
+// identity store, which identity providers send with the SCIM media type or
+// as plain JSON rather than as a JSON merge patch.
+func isSCIMPatch(path, contentType string) bool {
+	return strings.HasPrefix(path, "identity/scim/") &&
+		(contentType == vault.SCIMContentType || contentType == "application/json")
+}
+
 func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.Request) (*logical.Request, io.ReadCloser, int, error) {
 	ns, err := namespace.FromContext(r.Context())
 	if err != nil {
@@ -92,7 +100,7 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 			responseWriter = w
 		case path == "sys/internal/counters/activity/export":
 			responseWriter = w
-		case path == "sys/monitor":
+		case path == "sys/monitor", path == "sys/monitor/audit":
 			passHTTPReq = true
 			responseWriter = w
 		}
@@ -109,7 +117,8 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 		// is der encoded) we don't want to parse it. Instead, we will simply
 		// add the HTTP request to the logical request object for later consumption.
 		contentType := r.Header.Get("Content-Type")
-		if path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" || isOcspRequest(contentType) {
+		if path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" ||
+			strings.HasPrefix(path, "sys/storage/raft/snapshot-upload/") || isOcspRequest(contentType) {
 			passHTTPReq = true
 			origBody = r.Body
 		} else {
@@ -158,7 +167,7 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 			return nil, nil, status, err
 		}
 
-		if contentType != MergePatchContentTypeHeader {
+		if contentType != MergePatchContentTypeHeader && !isSCIMPatch(path, contentType) {
 			return nil, nil, http.StatusUnsupportedMediaType, fmt.Errorf("PATCH requires Content-Type of %s, provided %s", MergePatchContentTypeHeader, contentType)
 		}
 
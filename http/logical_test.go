@@ -480,6 +480,53 @@ func TestLogical_RespondWithStatusCode(t *testing.T) {
 	}
 }
 
+// TestLogical_SCIMPatch ensures the SCIM patch operations are accepted with
+// the content types sent by the identity providers, which other PATCH
+// requests don't accept.
+func TestLogical_SCIMPatch(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/identity/scim/v2/Users", map[string]interface{}{
+		"userName": "alice",
+	})
+	testResponseStatus(t, resp, http.StatusCreated)
+	if contentType := resp.Header.Get("Content-Type"); contentType != vault.SCIMContentType {
+		t.Fatalf("bad content type: %q", contentType)
+	}
+	var user map[string]interface{}
+	testResponseBody(t, resp, &user)
+
+	patch := map[string]interface{}{
+		"Operations": []interface{}{
+			map[string]interface{}{"op": "replace", "path": "active", "value": false},
+		},
+	}
+	for _, contentType := range []string{vault.SCIMContentType, "application/json"} {
+		body, err := json.Marshal(patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("PATCH", addr+"/v1/identity/scim/v2/Users/"+user["id"].(string), bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testResponseStatus(t, resp, http.StatusOK)
+	}
+
+	// Other PATCH requests still require a JSON merge patch
+	resp = testHttpData(t, "PATCH", token, addr+"/v1/identity/entity/id/"+user["id"].(string), "", map[string]interface{}{}, false, 0)
+	testResponseStatus(t, resp, http.StatusUnsupportedMediaType)
+}
+
 func TestLogical_Audit_invalidWrappingToken(t *testing.T) {
 	t.Setenv("VAULT_AUDIT_DISABLE_EVENTLOGGER", "true")
 
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package http
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNamespaces_HeaderAndPath ensures a namespace can be named by the
+// namespace header, by the request path, or by both.
+func TestNamespaces_HeaderAndPath(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+	client.SetToken(token)
+
+	_, err = client.Logical().Write("sys/namespaces/ns1", nil)
+	require.NoError(t, err)
+	_, err = client.Logical().Write("ns1/sys/namespaces/ns2", nil)
+	require.NoError(t, err)
+
+	nsClient := client.WithNamespace("ns1")
+	require.NoError(t, nsClient.Sys().Mount("secret", &api.MountInput{Type: "kv"}))
+	_, err = nsClient.Logical().Write("secret/foo", map[string]interface{}{"value": "ns1"})
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Read("ns1/secret/foo")
+	require.NoError(t, err)
+	require.Equal(t, "ns1", secret.Data["value"])
+
+	// The header and the path are combined
+	secret, err = nsClient.Logical().Read("ns2/sys/mounts/sys")
+	require.NoError(t, err)
+	require.NotNil(t, secret)
+	secret, err = client.WithNamespace("ns1/ns2").Logical().Read("secret/foo")
+	require.NoError(t, err)
+	require.Nil(t, secret)
+
+	// The secret isn't visible from the root namespace
+	secret, err = client.Logical().Read("secret/foo")
+	require.NoError(t, err)
+	require.Nil(t, secret)
+}
@@ -176,6 +176,7 @@ func TestSysConfigState_Sanitized(t *testing.T) {
 				"storage":                       tc.expectedStorageOutput,
 				"administrative_namespace_path": "",
 				"imprecise_lease_role_tracking": false,
+				"lazy_identity_loading":         false,
 			}
 
 			if tc.expectedHAStorageOutput != nil {
@@ -134,6 +134,26 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		perfStandbyCode = code
 	}
 
+	unhealthyCode := http.StatusServiceUnavailable
+	if code, found, ok := fetchStatusCode(r, "unhealthycode"); !ok {
+		return http.StatusBadRequest, nil, nil
+	} else if found {
+		unhealthyCode = code
+	}
+
+	thresholds, err := parseHealthThresholds(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	detailStr, detail := r.URL.Query()["detail"]
+	if detail {
+		detail, err = parseutil.ParseBool(detailStr[0])
+		if err != nil {
+			return http.StatusBadRequest, nil, fmt.Errorf("bad value for detail parameter: %w", err)
+		}
+	}
+
 	ctx := context.Background()
 
 	// Check system status
@@ -151,8 +171,10 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		return http.StatusInternalServerError, nil, err
 	}
 
-	// Determine the status code
+	// Determine the status code. Serving nodes may still be reported as
+	// unhealthy by the thresholds of their subsystems.
 	code := activeCode
+	serving := false
 	switch {
 	case !init:
 		code = uninitCode
@@ -163,10 +185,26 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 	case perfStandby:
 		if !perfStandbyOK {
 			code = perfStandbyCode
+		} else {
+			serving = true
 		}
 	case standby:
 		if !standbyOK {
 			code = standbyCode
+		} else {
+			serving = true
+		}
+	default:
+		serving = true
+	}
+
+	var subsystems *vault.HealthDetail
+	var unhealthyReasons []string
+	if init && !sealed && (detail || thresholds.enabled()) {
+		subsystems = core.HealthDetail(ctx)
+		unhealthyReasons = thresholds.check(subsystems)
+		if serving && len(unhealthyReasons) > 0 {
+			code = unhealthyCode
 		}
 	}
 
@@ -196,6 +234,10 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		Version:                    version.GetVersion().VersionNumber(),
 		ClusterName:                clusterName,
 		ClusterID:                  clusterID,
+		UnhealthyReasons:           unhealthyReasons,
+	}
+	if detail {
+		body.Subsystems = subsystems
 	}
 
 	licenseState, err := vault.LicenseSummary(core)
@@ -220,6 +262,79 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 	return code, body, nil
 }
 
+// healthThresholds are the limits of the subsystems of a node beyond which it
+// is reported as unhealthy. Negative limits are disabled.
+type healthThresholds struct {
+	maxStorageLatency    time.Duration
+	maxExpirationBacklog int
+	maxAuditFailures     int
+	maxExitedPlugins     int
+}
+
+func parseHealthThresholds(r *http.Request) (*healthThresholds, error) {
+	t := &healthThresholds{
+		maxStorageLatency:    -1,
+		maxExpirationBacklog: -1,
+		maxAuditFailures:     -1,
+		maxExitedPlugins:     -1,
+	}
+
+	query := r.URL.Query()
+	if raw, ok := query["maxstoragelatency"]; ok {
+		latency, err := parseutil.ParseDurationSecond(raw[0])
+		if err != nil || latency < 0 {
+			return nil, fmt.Errorf("bad value for maxstoragelatency parameter: %q", raw[0])
+		}
+		t.maxStorageLatency = latency
+	}
+	for param, limit := range map[string]*int{
+		"maxexpirationbacklog": &t.maxExpirationBacklog,
+		"maxauditfailures":     &t.maxAuditFailures,
+		"maxexitedplugins":     &t.maxExitedPlugins,
+	} {
+		if raw, ok := query[param]; ok {
+			v, err := strconv.Atoi(raw[0])
+			if err != nil || v < 0 {
+				return nil, fmt.Errorf("bad value for %s parameter: %q", param, raw[0])
+			}
+			*limit = v
+		}
+	}
+	return t, nil
+}
+
+func (t *healthThresholds) enabled() bool {
+	return t.maxStorageLatency >= 0 || t.maxExpirationBacklog >= 0 || t.maxAuditFailures >= 0 || t.maxExitedPlugins >= 0
+}
+
+// check returns the reasons why the subsystems are unhealthy. Subsystems
+// which don't run on the node are not checked.
+func (t *healthThresholds) check(detail *vault.HealthDetail) []string {
+	if detail == nil {
+		return nil
+	}
+
+	var reasons []string
+	if t.maxStorageLatency >= 0 && detail.Storage != nil {
+		switch {
+		case detail.Storage.Error != "":
+			reasons = append(reasons, fmt.Sprintf("storage read failed: %s", detail.Storage.Error))
+		case detail.Storage.Latency > t.maxStorageLatency:
+			reasons = append(reasons, fmt.Sprintf("storage latency %s exceeds %s", detail.Storage.Latency.Round(time.Millisecond), t.maxStorageLatency))
+		}
+	}
+	if t.maxExpirationBacklog >= 0 && detail.Expiration != nil && detail.Expiration.PendingRevocations > t.maxExpirationBacklog {
+		reasons = append(reasons, fmt.Sprintf("%d leases pending revocation exceed %d", detail.Expiration.PendingRevocations, t.maxExpirationBacklog))
+	}
+	if t.maxAuditFailures >= 0 && detail.Audit != nil && detail.Audit.ConsecutiveFailures > uint64(t.maxAuditFailures) {
+		reasons = append(reasons, fmt.Sprintf("%d consecutive audit failures exceed %d", detail.Audit.ConsecutiveFailures, t.maxAuditFailures))
+	}
+	if t.maxExitedPlugins >= 0 && detail.Plugins != nil && len(detail.Plugins.Exited) > t.maxExitedPlugins {
+		reasons = append(reasons, fmt.Sprintf("exited plugin processes %v exceed %d", detail.Plugins.Exited, t.maxExitedPlugins))
+	}
+	return reasons
+}
+
 type HealthResponseLicense struct {
 	State      string `json:"state"`
 	ExpiryTime string `json:"expiry_time"`
@@ -239,4 +354,6 @@ type HealthResponse struct {
 	ClusterID                  string                 `json:"cluster_id,omitempty"`
 	LastWAL                    uint64                 `json:"last_wal,omitempty"`
 	License                    *HealthResponseLicense `json:"license,omitempty"`
+	Subsystems                 *vault.HealthDetail    `json:"subsystems,omitempty"`
+	UnhealthyReasons           []string               `json:"unhealthy_reasons,omitempty"`
 }
@@ -279,3 +279,67 @@ func TestSysHealth_head(t *testing.T) {
 		}
 	}
 }
+
+func TestSysHealth_detail(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	resp, err := http.Get(addr + "/v1/sys/health?detail=true")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	subsystems, ok := actual["subsystems"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected subsystems, got: %#v", actual)
+	}
+	for _, name := range []string{"storage", "expiration", "audit", "plugins"} {
+		if _, ok := subsystems[name]; !ok {
+			t.Fatalf("expected %s in subsystems, got: %#v", name, subsystems)
+		}
+	}
+	if _, ok := actual["unhealthy_reasons"]; ok {
+		t.Fatalf("expected no unhealthy reasons, got: %#v", actual)
+	}
+
+	// Thresholds without detail flip readiness but don't return the detail
+	resp, err = http.Get(addr + "/v1/sys/health?maxstoragelatency=0s&unhealthycode=299")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	actual = map[string]interface{}{}
+	testResponseStatus(t, resp, 299)
+	testResponseBody(t, resp, &actual)
+	if _, ok := actual["subsystems"]; ok {
+		t.Fatalf("expected no subsystems, got: %#v", actual)
+	}
+	if reasons, ok := actual["unhealthy_reasons"].([]interface{}); !ok || len(reasons) != 1 {
+		t.Fatalf("expected an unhealthy reason, got: %#v", actual)
+	}
+
+	testData := []struct {
+		uri  string
+		code int
+	}{
+		{"?maxstoragelatency=1m&maxexpirationbacklog=100&maxauditfailures=0&maxexitedplugins=0", 200},
+		{"?maxstoragelatency=0s", 503},
+		{"?maxstoragelatency=0s&activecode=299", 503},
+		{"?maxstoragelatency=notaduration", 400},
+		{"?maxauditfailures=-1", 400},
+		{"?unhealthycode=notacode", 400},
+		{"?detail=notabool", 400},
+	}
+	for _, tt := range testData {
+		resp, err := http.Get(addr + "/v1/sys/health" + tt.uri)
+		if err != nil {
+			t.Fatalf("err on %v: %s", tt.uri, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Fatalf("GET %v expected code %d, got %d", tt.uri, tt.code, resp.StatusCode)
+		}
+	}
+}
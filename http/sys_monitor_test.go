@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	"github.com/hashicorp/vault/vault"
 )
@@ -125,3 +127,69 @@ func TestSysMonitorStreamingLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestSysMonitorModules(t *testing.T) {
+	t.Parallel()
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	stopCh := testhelpers.GenerateDebugLogs(t, client)
+	defer close(stopCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logCh, err := client.Sys().MonitorWithInput(ctx, &api.MonitorInput{
+		LogLevel:  "DEBUG",
+		LogFormat: "json",
+		Modules:   []string{"plugin"},
+		RateLimit: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type jsonlog struct {
+		Module string `json:"@module"`
+	}
+
+	timeCh := time.After(5 * time.Second)
+	for count := 0; count < 3; {
+		select {
+		case log := <-logCh:
+			jsonLog := &jsonlog{}
+			if err := json.Unmarshal([]byte(log), jsonLog); err != nil {
+				// Dropped log notices are not JSON
+				continue
+			}
+			components := strings.Split(jsonLog.Module, ".")
+			if !strutil.StrListContains(components, "secrets") && !strutil.StrListContains(components, "auth") {
+				t.Fatalf("Expected only logs of secrets engines and auth methods, got %s", log)
+			}
+			count++
+		case <-timeCh:
+			t.Fatal("Failed to get plugin logs after 5 seconds")
+		}
+	}
+}
+
+func TestSysMonitorNegativeRateLimit(t *testing.T) {
+	t.Parallel()
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	request := client.NewRequest("GET", "/v1/sys/monitor")
+	request.Params.Add("rate_limit", "-1")
+	_, err := client.RawRequest(request)
+	if err == nil || !strings.Contains(err.Error(), "rate_limit must not be negative") {
+		t.Fatalf("expected an error about the negative rate limit, got %v", err)
+	}
+}
@@ -387,3 +387,118 @@ func TestHTTP_Wrapping(t *testing.T) {
 		t.Fatalf("expected 403 response, actual: %d", respError.StatusCode)
 	}
 }
+
+// TestHTTP_WrappingTokens ensures the outstanding wrapping tokens can be
+// listed along with their creator and revoked in bulk.
+func TestHTTP_WrappingTokens(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{}, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+		NumCores:    1,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	client.SetToken(cluster.RootToken)
+
+	rootInfo, err := client.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootAccessor := rootInfo.Data["accessor"].(string)
+
+	child, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"default"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	childClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	childClient.SetToken(child.Auth.ClientToken)
+
+	wrap := func(c *api.Client) *api.SecretWrapInfo {
+		c.SetWrappingLookupFunc(func(operation, path string) string {
+			return "5m"
+		})
+		defer c.SetWrappingLookupFunc(nil)
+		secret, err := c.Logical().Write("sys/wrapping/wrap", map[string]interface{}{
+			"zip": "zap",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret.WrapInfo
+	}
+	rootWrap := wrap(client)
+	childWrap := wrap(childClient)
+	unwrappedWrap := wrap(client)
+
+	if _, err := client.Logical().Unwrap(unwrappedWrap.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wrapping tokens can only be listed with sudo
+	if _, err := childClient.Logical().List("sys/wrapping/tokens"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	secret, err := client.Logical().List("sys/wrapping/tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := secret.Data["keys"].([]interface{})
+	if len(keys) != 2 || keys[0] != rootWrap.Accessor || keys[1] != childWrap.Accessor {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	info := secret.Data["key_info"].(map[string]interface{})[childWrap.Accessor].(map[string]interface{})
+	if info["creator_accessor"] != child.Auth.Accessor {
+		t.Fatalf("unexpected creator accessor: %v", info["creator_accessor"])
+	}
+	if info["creation_path"] != "sys/wrapping/wrap" || info["has_response"] != true {
+		t.Fatalf("unexpected info: %v", info)
+	}
+
+	r := client.NewRequest("LIST", "/v1/sys/wrapping/tokens")
+	r.Params.Set("creator_accessor", rootAccessor)
+	resp, err := client.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	secret, err = api.ParseSecret(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := secret.Data["keys"].([]interface{}); len(keys) != 1 || keys[0] != rootWrap.Accessor {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	// Revoking requires selecting the tokens
+	if _, err := client.Logical().Write("sys/wrapping/tokens/revoke", nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	secret, err = client.Logical().Write("sys/wrapping/tokens/revoke", map[string]interface{}{
+		"creator_accessor": child.Auth.Accessor,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := secret.Data["revoked_count"].(json.Number).Int64(); count != 1 {
+		t.Fatalf("unexpected revoked count: %v", secret.Data["revoked_count"])
+	}
+	if _, err := client.Logical().Unwrap(childWrap.Token); err == nil {
+		t.Fatal("expected revoked wrapping token to fail to unwrap")
+	}
+
+	secret, err = client.Logical().List("sys/wrapping/tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := secret.Data["keys"].([]interface{}); len(keys) != 1 || keys[0] != rootWrap.Accessor {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+}
@@ -29,7 +29,7 @@ var (
 
 	additionalRoutes = func(mux *http.ServeMux, core *vault.Core) {}
 
-	nonVotersAllowed = false
+	nonVotersAllowed = true
 
 	adjustResponse = func(core *vault.Core, w http.ResponseWriter, req *logical.Request) {}
 )
@@ -85,6 +85,20 @@ func rateLimitQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler
 			quotaReq.Role = role
 		}
 
+		// Only look up the entity of the client token if a rate limit quota
+		// groups requests by entity.
+		if token, _ := getTokenFromReq(r); token != "" {
+			requiresResolveEntity, err := core.ResolveEntityForQuotas(r.Context(), quotaReq)
+			if err != nil {
+				core.Logger().Error("failed to lookup quotas", "path", path, "error", err)
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if requiresResolveEntity {
+				quotaReq.EntityID = core.DetermineEntityFromToken(r.Context(), token)
+			}
+		}
+
 		quotaResp, err := core.ApplyRateLimitQuota(r.Context(), quotaReq)
 		if err != nil {
 			core.Logger().Error("failed to apply quota", "path", path, "error", err)
@@ -4,6 +4,7 @@
 package event
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"os"
@@ -28,6 +29,10 @@ type options struct {
 	withSocketType  string
 	withMaxDuration time.Duration
 	withFileMode    *os.FileMode
+	withAddress     string
+	withNetwork     string
+	withTLSConfig   *tls.Config
+	withQueueSize   int
 }
 
 // getDefaultOptions returns Options with their default values.
@@ -41,6 +46,7 @@ func getDefaultOptions() options {
 		withSocketType:  "tcp",
 		withMaxDuration: 2 * time.Second,
 		withFileMode:    &fileMode,
+		withNetwork:     "tcp",
 	}
 }
 
@@ -189,3 +195,69 @@ func WithFileMode(mode string) Option {
 		return nil
 	}
 }
+
+// WithAddress provides an Option to represent the address of a remote syslog
+// server for a syslog sink.
+func WithAddress(address string) Option {
+	return func(o *options) error {
+		o.withAddress = strings.TrimSpace(address)
+
+		return nil
+	}
+}
+
+// WithNetwork provides an Option to represent the network a remote syslog
+// server is reached over: udp, tcp or tls.
+// Supplying an empty string or whitespace will prevent this Option from being
+// applied, but it will not return an error in those circumstances.
+func WithNetwork(network string) Option {
+	return func(o *options) error {
+		network = strings.ToLower(strings.TrimSpace(network))
+
+		switch network {
+		case "":
+		case "udp", "tcp", "tls":
+			o.withNetwork = network
+		default:
+			return fmt.Errorf("unsupported network %q: expected udp, tcp or tls", network)
+		}
+
+		return nil
+	}
+}
+
+// WithTLSConfig provides an Option to represent the TLS configuration of the
+// connections to a remote syslog server over tls.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) error {
+		o.withTLSConfig = tlsConfig
+
+		return nil
+	}
+}
+
+// WithQueueSize provides an Option to represent the number of messages queued
+// in memory for a remote syslog server, which are then written in the
+// background. A size of 0 disables the queue.
+// Supplying an empty string or whitespace will prevent this Option from being
+// applied, but it will not return an error in those circumstances.
+func WithQueueSize(size string) Option {
+	return func(o *options) error {
+		size = strings.TrimSpace(size)
+		if size == "" {
+			return nil
+		}
+
+		parsed, err := strconv.Atoi(size)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse queue size: %w", err)
+		case parsed < 0:
+			return errors.New("queue size cannot be negative")
+		default:
+			o.withQueueSize = parsed
+		}
+
+		return nil
+	}
+}
@@ -106,6 +106,8 @@ func TestOptions_Default(t *testing.T) {
 	require.Equal(t, "AUTH", opts.withFacility)
 	require.Equal(t, "vault", opts.withTag)
 	require.Equal(t, 2*time.Second, opts.withMaxDuration)
+	require.Equal(t, "tcp", opts.withNetwork)
+	require.Zero(t, opts.withQueueSize)
 }
 
 // TestOptions_Opts exercises getOpts with various Option values.
@@ -423,3 +425,101 @@ func TestOptions_WithFileMode(t *testing.T) {
 		})
 	}
 }
+
+// TestOptions_WithNetwork exercises WithNetwork Option to ensure it performs as expected.
+func TestOptions_WithNetwork(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        string
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty": {
+			Value:         "",
+			ExpectedValue: "",
+		},
+		"whitespace": {
+			Value:         "    ",
+			ExpectedValue: "",
+		},
+		"udp": {
+			Value:         "udp",
+			ExpectedValue: "udp",
+		},
+		"spacey-upper-tls": {
+			Value:         "  TLS  ",
+			ExpectedValue: "tls",
+		},
+		"bad-value": {
+			Value:                "unix",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unsupported network \"unix\": expected udp, tcp or tls",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithNetwork(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withNetwork)
+			}
+		})
+	}
+}
+
+// TestOptions_WithQueueSize exercises WithQueueSize Option to ensure it performs as expected.
+func TestOptions_WithQueueSize(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty": {
+			Value: "",
+		},
+		"spacey-value": {
+			Value:         "   1000   ",
+			ExpectedValue: 1000,
+		},
+		"negative": {
+			Value:                "-1",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "queue size cannot be negative",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse queue size: strconv.Atoi: parsing \"juan\": invalid syntax",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithQueueSize(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withQueueSize)
+			}
+		})
+	}
+}
@@ -12,16 +12,22 @@ import (
 	"github.com/hashicorp/eventlogger"
 )
 
-var _ eventlogger.Node = (*SyslogSink)(nil)
+var (
+	_ eventlogger.Node   = (*SyslogSink)(nil)
+	_ eventlogger.Closer = (*SyslogSink)(nil)
+)
 
-// SyslogSink is a sink node which handles writing events to syslog.
+// SyslogSink is a sink node which handles writing events to syslog, either the
+// local one or a remote syslog server.
 type SyslogSink struct {
 	requiredFormat string
 	logger         gsyslog.Syslogger
 }
 
-// NewSyslogSink should be used to create a new SyslogSink.
-// Accepted options: WithFacility and WithTag.
+// NewSyslogSink should be used to create a new SyslogSink, which writes to the
+// remote syslog server of WithAddress, if any, using a RemoteSyslogger.
+// Accepted options: WithFacility, WithTag, WithAddress, WithNetwork,
+// WithTLSConfig, WithMaxDuration and WithQueueSize.
 func NewSyslogSink(format string, opt ...Option) (*SyslogSink, error) {
 	const op = "event.NewSyslogSink"
 
@@ -30,7 +36,12 @@ func NewSyslogSink(format string, opt ...Option) (*SyslogSink, error) {
 		return nil, fmt.Errorf("%s: error applying options: %w", op, err)
 	}
 
-	logger, err := gsyslog.NewLogger(gsyslog.LOG_INFO, opts.withFacility, opts.withTag)
+	var logger gsyslog.Syslogger
+	if opts.withAddress != "" {
+		logger, err = NewRemoteSyslogger(opts.withAddress, opt...)
+	} else {
+		logger, err = gsyslog.NewLogger(gsyslog.LOG_INFO, opts.withFacility, opts.withTag)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: error creating syslogger: %w", op, err)
 	}
@@ -66,6 +77,12 @@ func (s *SyslogSink) Process(ctx context.Context, e *eventlogger.Event) (*eventl
 	return nil, nil
 }
 
+// Close closes the syslogger, writing the messages queued for a remote syslog
+// server.
+func (s *SyslogSink) Close(_ context.Context) error {
+	return s.logger.Close()
+}
+
 // Reopen is a no-op for a syslog sink.
 func (_ *SyslogSink) Reopen() error {
 	return nil
@@ -0,0 +1,396 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	gsyslog "github.com/hashicorp/go-syslog"
+)
+
+const (
+	// remoteSyslogMinBackoff and remoteSyslogMaxBackoff bound the delay
+	// between the attempts of the background writer of a RemoteSyslogger to
+	// write a queued message.
+	remoteSyslogMinBackoff = 100 * time.Millisecond
+	remoteSyslogMaxBackoff = 30 * time.Second
+
+	// remoteSyslogTimestampFormat is the RFC 5424 timestamp format, with a
+	// microsecond precision.
+	remoteSyslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// ErrRemoteSyslogClosed is returned when writing to a closed RemoteSyslogger.
+var ErrRemoteSyslogClosed = errors.New("remote syslog closed")
+
+// syslogFacilities maps the names of the syslog facilities to their codes.
+var syslogFacilities = map[string]int{
+	"KERN":     0,
+	"USER":     1,
+	"MAIL":     2,
+	"DAEMON":   3,
+	"AUTH":     4,
+	"SYSLOG":   5,
+	"LPR":      6,
+	"NEWS":     7,
+	"UUCP":     8,
+	"CRON":     9,
+	"AUTHPRIV": 10,
+	"FTP":      11,
+	"LOCAL0":   16,
+	"LOCAL1":   17,
+	"LOCAL2":   18,
+	"LOCAL3":   19,
+	"LOCAL4":   20,
+	"LOCAL5":   21,
+	"LOCAL6":   22,
+	"LOCAL7":   23,
+}
+
+var _ gsyslog.Syslogger = (*RemoteSyslogger)(nil)
+
+// RemoteSyslogger writes messages to a remote syslog server in the RFC 5424
+// format, either over UDP, one message per datagram, or over TCP or TLS
+// (RFC 5425), with the octet-counting framing, so that messages may contain
+// newlines. A failed write is retried once on a new connection.
+//
+// When its queue is enabled, messages are acknowledged once queued in memory,
+// and a background writer writes them in order, reconnecting with an
+// exponential backoff while the server is unavailable. A message which doesn't
+// fit in the queue results in an error, as if it failed to be written.
+type RemoteSyslogger struct {
+	network     string
+	address     string
+	tlsConfig   *tls.Config
+	maxDuration time.Duration
+	facility    int
+	tag         string
+	hostname    string
+	pid         int
+
+	connLock   sync.Mutex
+	connection net.Conn
+
+	queue   chan []byte
+	pending []byte
+
+	// closeLock prevents messages from being queued once closed
+	closeLock sync.RWMutex
+	startOnce sync.Once
+	closeOnce sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewRemoteSyslogger should be used to create a RemoteSyslogger, which
+// connects to the server on its first write.
+// Accepted options: WithNetwork, WithFacility, WithTag, WithTLSConfig,
+// WithMaxDuration and WithQueueSize.
+func NewRemoteSyslogger(address string, opt ...Option) (*RemoteSyslogger, error) {
+	const op = "event.NewRemoteSyslogger"
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error applying options: %w", op, err)
+	}
+
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("%s: address is required: %w", op, ErrInvalidParameter)
+	}
+
+	facility, ok := syslogFacilities[strings.ToUpper(opts.withFacility)]
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid syslog facility %q: %w", op, opts.withFacility, ErrInvalidParameter)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	tag := opts.withTag
+	if tag == "" {
+		tag = "-"
+	}
+
+	s := &RemoteSyslogger{
+		network:     opts.withNetwork,
+		address:     address,
+		tlsConfig:   opts.withTLSConfig,
+		maxDuration: opts.withMaxDuration,
+		facility:    facility,
+		tag:         tag,
+		hostname:    hostname,
+		pid:         os.Getpid(),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+	if opts.withQueueSize > 0 {
+		s.queue = make(chan []byte, opts.withQueueSize)
+	}
+
+	return s, nil
+}
+
+// Write writes the message at the informational level.
+func (s *RemoteSyslogger) Write(b []byte) (int, error) {
+	if err := s.WriteLevel(gsyslog.LOG_INFO, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// WriteLevel writes the message at the given level, or queues it when the
+// queue is enabled.
+func (s *RemoteSyslogger) WriteLevel(p gsyslog.Priority, b []byte) error {
+	const op = "event.(RemoteSyslogger).WriteLevel"
+
+	s.closeLock.RLock()
+	defer s.closeLock.RUnlock()
+
+	select {
+	case <-s.stopCh:
+		return fmt.Errorf("%s: %w", op, ErrRemoteSyslogClosed)
+	default:
+	}
+
+	msg := s.frame(s.format(p, b, time.Now()))
+
+	if s.queue == nil {
+		s.connLock.Lock()
+		defer s.connLock.Unlock()
+
+		if err := s.writeWithRetry(msg); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+
+	select {
+	case s.queue <- msg:
+		return nil
+	default:
+		return fmt.Errorf("%s: the queue of %d messages is full", op, cap(s.queue))
+	}
+}
+
+// Close stops the background writer, makes a last attempt to write the
+// queued messages, and closes the connection.
+func (s *RemoteSyslogger) Close() error {
+	const op = "event.(RemoteSyslogger).Close"
+
+	s.closeLock.Lock()
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.closeLock.Unlock()
+	s.startOnce.Do(func() {
+		// The writer was never started
+		close(s.doneCh)
+	})
+	<-s.doneCh
+
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+
+	// Once a write fails, the remaining messages are dropped rather than
+	// waiting for each of them to time out.
+	var dropped int
+	if s.pending != nil {
+		if err := s.writeWithRetry(s.pending); err != nil {
+			dropped++
+		}
+		s.pending = nil
+	}
+	for len(s.queue) > 0 {
+		msg := <-s.queue
+		if dropped > 0 {
+			dropped++
+			continue
+		}
+		if err := s.writeWithRetry(msg); err != nil {
+			dropped++
+		}
+	}
+
+	var err error
+	if dropped > 0 {
+		err = multierror.Append(err, fmt.Errorf("%s: unable to write %d queued messages", op, dropped))
+	}
+	if disconnErr := s.disconnect(); disconnErr != nil {
+		err = multierror.Append(err, fmt.Errorf("%s: %w", op, disconnErr))
+	}
+
+	return err
+}
+
+// run writes the queued messages until the syslogger is closed.
+func (s *RemoteSyslogger) run() {
+	defer close(s.doneCh)
+
+	for {
+		var msg []byte
+		select {
+		case <-s.stopCh:
+			return
+		case msg = <-s.queue:
+		}
+
+		backoff := remoteSyslogMinBackoff
+		for {
+			s.connLock.Lock()
+			err := s.writeWithRetry(msg)
+			s.connLock.Unlock()
+			if err == nil {
+				break
+			}
+
+			select {
+			case <-s.stopCh:
+				// Close makes a last attempt to write it
+				s.pending = msg
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > remoteSyslogMaxBackoff {
+				backoff = remoteSyslogMaxBackoff
+			}
+		}
+	}
+}
+
+// format formats the message as an RFC 5424 message, without structured data.
+func (s *RemoteSyslogger) format(p gsyslog.Priority, b []byte, now time.Time) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(s.facility<<3 | int(p)))
+	buf.WriteString(">1 ")
+	buf.WriteString(now.UTC().Format(remoteSyslogTimestampFormat))
+	buf.WriteByte(' ')
+	buf.WriteString(s.hostname)
+	buf.WriteByte(' ')
+	buf.WriteString(s.tag)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(s.pid))
+	buf.WriteString(" - - ")
+	buf.Write(bytes.TrimRight(b, "\n"))
+	return buf.Bytes()
+}
+
+// frame prefixes the message with its length over TCP and TLS, as required by
+// the octet-counting framing.
+func (s *RemoteSyslogger) frame(msg []byte) []byte {
+	if s.network == "udp" {
+		return msg
+	}
+	framed := strconv.AppendInt(nil, int64(len(msg)), 10)
+	framed = append(framed, ' ')
+	return append(framed, msg...)
+}
+
+// writeWithRetry writes the framed message, retrying once on a new
+// connection. The caller must hold connLock.
+func (s *RemoteSyslogger) writeWithRetry(msg []byte) error {
+	err := s.write(msg)
+	if err == nil {
+		return nil
+	}
+
+	if reconErr := s.reconnect(); reconErr != nil {
+		return multierror.Append(err, reconErr)
+	}
+	return s.write(msg)
+}
+
+// connect establishes a connection to the server, unless already connected.
+func (s *RemoteSyslogger) connect() error {
+	const op = "event.(RemoteSyslogger).connect"
+
+	if s.connection != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.maxDuration)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	switch s.network {
+	case "tls":
+		dialer := &tls.Dialer{Config: s.tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", s.address)
+	default:
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, s.network, s.address)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: error connecting to %q address %q: %w", op, s.network, s.address, err)
+	}
+
+	s.connection = conn
+
+	return nil
+}
+
+// disconnect closes and clears the connection, if any.
+func (s *RemoteSyslogger) disconnect() error {
+	const op = "event.(RemoteSyslogger).disconnect"
+
+	if s.connection == nil {
+		return nil
+	}
+
+	err := s.connection.Close()
+	s.connection = nil
+	if err != nil {
+		return fmt.Errorf("%s: error closing connection: %w", op, err)
+	}
+
+	return nil
+}
+
+// reconnect replaces the connection with a new one.
+func (s *RemoteSyslogger) reconnect() error {
+	// The connection is replaced even if it can't be closed cleanly
+	_ = s.disconnect()
+
+	return s.connect()
+}
+
+// write writes the framed message on the connection, connecting first if
+// needed.
+func (s *RemoteSyslogger) write(msg []byte) error {
+	const op = "event.(RemoteSyslogger).write"
+
+	if err := s.connect(); err != nil {
+		return fmt.Errorf("%s: connection error: %w", op, err)
+	}
+
+	if err := s.connection.SetWriteDeadline(time.Now().Add(s.maxDuration)); err != nil {
+		return fmt.Errorf("%s: unable to set write deadline: %w", op, err)
+	}
+
+	if _, err := s.connection.Write(msg); err != nil {
+		return fmt.Errorf("%s: unable to write to syslog server: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// remoteSyslogHeader matches the header of the RFC 5424 messages written with
+// the AUTH facility, at the informational level, and the vault tag.
+var remoteSyslogHeader = regexp.MustCompile(`^<38>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z \S+ vault \d+ - - `)
+
+// testSyslogServer serves the syslog protocol with the octet-counting framing
+// on the listener, sending the messages it receives to the returned channel.
+func testSyslogServer(t *testing.T, l net.Listener) <-chan string {
+	t.Helper()
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					length, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, err := strconv.Atoi(length[:len(length)-1])
+					if err != nil {
+						return
+					}
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+				}
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+	})
+
+	return messages
+}
+
+// requireRemoteSyslogMessage ensures the message is an RFC 5424 message with
+// the expected content.
+func requireRemoteSyslogMessage(t *testing.T, expected, msg string) {
+	t.Helper()
+
+	header := remoteSyslogHeader.FindString(msg)
+	require.NotEmpty(t, header, "unexpected message %q", msg)
+	require.Equal(t, expected, msg[len(header):])
+}
+
+// TestRemoteSyslogger_TCP ensures the messages are written with the
+// octet-counting framing, so that they may contain newlines, but without
+// their trailing newlines.
+func TestRemoteSyslogger_TCP(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	messages := testSyslogServer(t, l)
+
+	s, err := NewRemoteSyslogger(l.Addr().String())
+	require.NoError(t, err)
+
+	_, err = s.Write([]byte("{\"type\":\"request\"}\n"))
+	require.NoError(t, err)
+	_, err = s.Write([]byte("first line\nsecond line"))
+	require.NoError(t, err)
+
+	requireRemoteSyslogMessage(t, `{"type":"request"}`, <-messages)
+	requireRemoteSyslogMessage(t, "first line\nsecond line", <-messages)
+
+	require.NoError(t, s.Close())
+	_, err = s.Write([]byte("closed"))
+	require.ErrorIs(t, err, ErrRemoteSyslogClosed)
+}
+
+// TestRemoteSyslogger_TLS ensures the messages are written over TLS, with the
+// certificate of the server verified.
+func TestRemoteSyslogger_TLS(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "syslog"},
+		DNSNames:     []string{"syslog.example.com"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	require.NoError(t, err)
+	messages := testSyslogServer(t, l)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(cert)
+	s, err := NewRemoteSyslogger(l.Addr().String(),
+		WithNetwork("tls"),
+		WithTLSConfig(&tls.Config{RootCAs: rootCAs, ServerName: "syslog.example.com"}),
+	)
+	require.NoError(t, err)
+	_, err = s.Write([]byte("over TLS"))
+	require.NoError(t, err)
+	requireRemoteSyslogMessage(t, "over TLS", <-messages)
+	require.NoError(t, s.Close())
+
+	// The certificate of the server isn't trusted by default
+	s, err = NewRemoteSyslogger(l.Addr().String(), WithNetwork("tls"))
+	require.NoError(t, err)
+	_, err = s.Write([]byte("over TLS"))
+	require.Error(t, err)
+	require.NoError(t, s.Close())
+}
+
+// TestRemoteSyslogger_UDP ensures the messages are written one per datagram,
+// without framing.
+func TestRemoteSyslogger_UDP(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s, err := NewRemoteSyslogger(conn.LocalAddr().String(), WithNetwork("udp"), WithFacility("local0"))
+	require.NoError(t, err)
+	_, err = s.Write([]byte("over UDP"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Regexp(t, `^<134>1 \S+ \S+ vault \d+ - - over UDP$`, string(buf[:n]))
+	require.NoError(t, s.Close())
+}
+
+// TestRemoteSyslogger_Queue ensures the queued messages are written in order
+// once the server is available, and that messages don't fit in a full queue.
+func TestRemoteSyslogger_Queue(t *testing.T) {
+	t.Parallel()
+
+	// Reserve an address, which the server listens on later
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	s, err := NewRemoteSyslogger(address, WithQueueSize("10"), WithMaxDuration("100ms"))
+	require.NoError(t, err)
+	for _, msg := range []string{"one", "two", "three"} {
+		_, err = s.Write([]byte(msg))
+		require.NoError(t, err)
+	}
+
+	l, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	messages := testSyslogServer(t, l)
+	for _, expected := range []string{"one", "two", "three"} {
+		select {
+		case msg := <-messages:
+			requireRemoteSyslogMessage(t, expected, msg)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for message %q", expected)
+		}
+	}
+	require.NoError(t, s.Close())
+
+	// Without a server, the queue fills up, and the queued messages are
+	// dropped on close
+	s, err = NewRemoteSyslogger(address, WithQueueSize("1"), WithMaxDuration("100ms"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	require.Eventually(t, func() bool {
+		_, err := s.Write([]byte("dropped"))
+		return err != nil
+	}, 5*time.Second, time.Millisecond)
+	require.ErrorContains(t, s.Close(), "unable to write")
+}
+
+// TestRemoteSyslogger_Unavailable ensures writes fail when the server is
+// unavailable and the queue is disabled.
+func TestRemoteSyslogger_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	s, err := NewRemoteSyslogger(address, WithMaxDuration("100ms"))
+	require.NoError(t, err)
+	_, err = s.Write([]byte("unavailable"))
+	require.Error(t, err)
+	require.NoError(t, s.Close())
+}
+
+func TestNewRemoteSyslogger_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRemoteSyslogger("")
+	require.ErrorIs(t, err, ErrInvalidParameter)
+	_, err = NewRemoteSyslogger("127.0.0.1:6514", WithFacility("juan"))
+	require.ErrorIs(t, err, ErrInvalidParameter)
+	_, err = NewRemoteSyslogger("127.0.0.1:6514", WithNetwork("unix"))
+	require.Error(t, err)
+}
@@ -264,6 +264,13 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			"dogstatsd_addr":                         c.Telemetry.DogStatsDAddr,
 			"dogstatsd_tags":                         c.Telemetry.DogStatsDTags,
 			"prometheus_retention_time":              c.Telemetry.PrometheusRetentionTime,
+			"prometheus_histogram_buckets":           c.Telemetry.PrometheusHistogramBuckets,
+			"prometheus_summary_quantiles":           c.Telemetry.PrometheusSummaryQuantiles,
+			"prometheus_remote_write_url":            c.Telemetry.PrometheusRemoteWriteURL,
+			"prometheus_remote_write_interval":       c.Telemetry.PrometheusRemoteWriteInterval,
+			"prometheus_remote_write_labels":         c.Telemetry.PrometheusRemoteWriteLabels,
+			"tracing_otlp_endpoint":                  c.Telemetry.TracingOTLPEndpoint,
+			"tracing_sample_ratio":                   c.Telemetry.TracingSampleRatio,
 			"stackdriver_project_id":                 c.Telemetry.StackdriverProjectID,
 			"stackdriver_location":                   c.Telemetry.StackdriverLocation,
 			"stackdriver_namespace":                  c.Telemetry.StackdriverNamespace,
@@ -272,6 +279,10 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			"num_lease_metrics_buckets":              c.Telemetry.NumLeaseMetricsTimeBuckets,
 			"add_lease_metrics_namespace_labels":     c.Telemetry.LeaseMetricsNameSpaceLabels,
 			"add_mount_point_rollback_metrics":       c.Telemetry.RollbackMetricsIncludeMountPoint,
+			"add_namespace_labels":                   c.Telemetry.NamespaceLabels,
+			"add_mount_point_labels":                 c.Telemetry.MountPointLabels,
+			"namespace_labels_allowlist":             c.Telemetry.NamespaceLabelsAllowlist,
+			"mount_point_labels_allowlist":           c.Telemetry.MountPointLabelsAllowlist,
 		}
 		result["telemetry"] = sanitizedTelemetry
 	}
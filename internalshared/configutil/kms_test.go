@@ -6,7 +6,11 @@ package configutil
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/go-kms-wrapping/wrappers/alicloudkms/v2"
+	"github.com/hashicorp/go-kms-wrapping/wrappers/ocikms/v2"
 )
 
 func Test_getEnvConfig(t *testing.T) {
@@ -100,3 +104,89 @@ func Test_getEnvConfig(t *testing.T) {
 		})
 	}
 }
+
+// TestConfigureWrapper_CloudKMSValidation ensures the OCI KMS and AliCloud KMS
+// seals reject incomplete configurations before contacting the KMS, like the
+// other cloud seals.
+func TestConfigureWrapper_CloudKMSValidation(t *testing.T) {
+	for _, env := range []string{
+		ocikms.EnvOciKmsWrapperKeyId, ocikms.EnvVaultOciKmsSealKeyId,
+		ocikms.EnvOciKmsWrapperCryptoEndpoint, ocikms.EnvVaultOciKmsSealCryptoEndpoint,
+		ocikms.EnvOciKmsWrapperManagementEndpoint, ocikms.EnvVaultOciKmsSealManagementEndpoint,
+		alicloudkms.EnvAliCloudKmsWrapperKeyId, alicloudkms.EnvVaultAliCloudKmsSealKeyId,
+	} {
+		t.Setenv(env, "")
+	}
+
+	tests := []struct {
+		name    string
+		kms     *KMS
+		wantErr string
+	}{
+		{
+			"OCI KMS without key_id",
+			&KMS{
+				Type: "ocikms",
+				Config: map[string]string{
+					"crypto_endpoint":     "https://crypto.example.com",
+					"management_endpoint": "https://management.example.com",
+				},
+			},
+			"'key_id' not found",
+		},
+		{
+			"OCI KMS without crypto_endpoint",
+			&KMS{
+				Type: "ocikms",
+				Config: map[string]string{
+					"key_id":              "ocid1.key.oc1.test",
+					"management_endpoint": "https://management.example.com",
+				},
+			},
+			"'crypto_endpoint' not found",
+		},
+		{
+			"OCI KMS without management_endpoint",
+			&KMS{
+				Type: "ocikms",
+				Config: map[string]string{
+					"key_id":          "ocid1.key.oc1.test",
+					"crypto_endpoint": "https://crypto.example.com",
+				},
+			},
+			"'management_endpoint' not found",
+		},
+		{
+			"OCI KMS with invalid auth_type_api_key",
+			&KMS{
+				Type: "ocikms",
+				Config: map[string]string{
+					"key_id":            "ocid1.key.oc1.test",
+					"auth_type_api_key": "maybe",
+				},
+			},
+			"failed parsing auth_type_api_key",
+		},
+		{
+			"AliCloud KMS without kms_key_id",
+			&KMS{
+				Type: "alicloudkms",
+				Config: map[string]string{
+					"region": "us-east-1",
+				},
+			},
+			"key id not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConfigureWrapper(tt.kms, nil, nil, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package configutil
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/textproto"
 	"regexp"
 	"strings"
@@ -82,6 +83,15 @@ type Listener struct {
 	HTTPIdleTimeout          time.Duration `hcl:"-"`
 	HTTPIdleTimeoutRaw       interface{}   `hcl:"http_idle_timeout"`
 
+	MaxConnections               int64       `hcl:"-"`
+	MaxConnectionsRaw            interface{} `hcl:"max_connections"`
+	HTTP2MaxConcurrentStreams    int64       `hcl:"-"`
+	HTTP2MaxConcurrentStreamsRaw interface{} `hcl:"http2_max_concurrent_streams"`
+	ConnectionRateLimit          int64       `hcl:"-"`
+	ConnectionRateLimitRaw       interface{} `hcl:"connection_rate_limit"`
+	ConnectionRateLimitBurst     int64       `hcl:"-"`
+	ConnectionRateLimitBurstRaw  interface{} `hcl:"connection_rate_limit_burst"`
+
 	ProxyProtocolBehavior           string                        `hcl:"proxy_protocol_behavior"`
 	ProxyProtocolAuthorizedAddrs    []*sockaddr.SockAddrMarshaler `hcl:"-"`
 	ProxyProtocolAuthorizedAddrsRaw interface{}                   `hcl:"proxy_protocol_authorized_addrs,alias:ProxyProtocolAuthorizedAddrs"`
@@ -304,6 +314,59 @@ func ParseListeners(result *SharedConfig, list *ast.ObjectList) error {
 			}
 		}
 
+		// Connection limits
+		{
+			if l.MaxConnectionsRaw != nil {
+				if l.MaxConnections, err = parseutil.ParseInt(l.MaxConnectionsRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("error parsing max_connections: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				if l.MaxConnections < 0 {
+					return multierror.Prefix(errors.New("max_connections cannot be negative"), fmt.Sprintf("listeners.%d", i))
+				}
+
+				l.MaxConnectionsRaw = nil
+			}
+
+			if l.HTTP2MaxConcurrentStreamsRaw != nil {
+				if l.HTTP2MaxConcurrentStreams, err = parseutil.ParseInt(l.HTTP2MaxConcurrentStreamsRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("error parsing http2_max_concurrent_streams: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				if l.HTTP2MaxConcurrentStreams < 0 || l.HTTP2MaxConcurrentStreams > math.MaxUint32 {
+					return multierror.Prefix(fmt.Errorf("http2_max_concurrent_streams must be between 0 and %d", uint32(math.MaxUint32)), fmt.Sprintf("listeners.%d", i))
+				}
+
+				l.HTTP2MaxConcurrentStreamsRaw = nil
+			}
+
+			if l.ConnectionRateLimitRaw != nil {
+				if l.ConnectionRateLimit, err = parseutil.ParseInt(l.ConnectionRateLimitRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("error parsing connection_rate_limit: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				if l.ConnectionRateLimit < 0 {
+					return multierror.Prefix(errors.New("connection_rate_limit cannot be negative"), fmt.Sprintf("listeners.%d", i))
+				}
+
+				l.ConnectionRateLimitRaw = nil
+			}
+
+			if l.ConnectionRateLimitBurstRaw != nil {
+				if l.ConnectionRateLimitBurst, err = parseutil.ParseInt(l.ConnectionRateLimitBurstRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("error parsing connection_rate_limit_burst: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				if l.ConnectionRateLimitBurst < 0 {
+					return multierror.Prefix(errors.New("connection_rate_limit_burst cannot be negative"), fmt.Sprintf("listeners.%d", i))
+				}
+
+				l.ConnectionRateLimitBurstRaw = nil
+			}
+
+			// The burst defaults to the rate, so that a second worth of
+			// requests may be made at once
+			if l.ConnectionRateLimit > 0 && l.ConnectionRateLimitBurst == 0 {
+				l.ConnectionRateLimitBurst = l.ConnectionRateLimit
+			}
+		}
+
 		// Proxy Protocol config
 		{
 			if l.ProxyProtocolAuthorizedAddrsRaw != nil {
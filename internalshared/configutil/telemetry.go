@@ -5,8 +5,11 @@ package configutil
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
@@ -18,6 +21,7 @@ import (
 	"github.com/armon/go-metrics/prometheus"
 	stackdriver "github.com/google/go-metrics-stackdriver"
 	stackdrivervault "github.com/google/go-metrics-stackdriver/vault"
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
@@ -32,6 +36,7 @@ const (
 	MaximumGaugeCardinalityDefault    = 500
 	LeaseMetricsEpsilonDefault        = time.Hour
 	NumLeaseMetricsTimeBucketsDefault = 168
+	TracingDefaultSampleRatio         = 1.0
 )
 
 // Telemetry is the telemetry configuration for the server
@@ -134,6 +139,75 @@ type Telemetry struct {
 	// Default: 24h
 	PrometheusRetentionTime    time.Duration `hcl:"-"`
 	PrometheusRetentionTimeRaw interface{}   `hcl:"prometheus_retention_time"`
+	// PrometheusHistogramBuckets maps metric names, or prefixes of metric names,
+	// to the bucket boundaries of the histograms the matching samples are
+	// reported as, instead of summaries.
+	// Default: none
+	PrometheusHistogramBuckets map[string][]float64 `hcl:"prometheus_histogram_buckets"`
+	// PrometheusSummaryQuantiles are the quantiles reported by the summaries
+	// of the other samples.
+	// Default: [0.5, 0.9, 0.99]
+	PrometheusSummaryQuantiles []float64 `hcl:"prometheus_summary_quantiles"`
+
+	// Prometheus remote write:
+	// PrometheusRemoteWriteURL is the URL of a Prometheus remote write
+	// endpoint the metrics of the Prometheus sink are pushed to, for
+	// environments where they can't be scraped.
+	// Default: none
+	PrometheusRemoteWriteURL string `hcl:"prometheus_remote_write_url"`
+	// PrometheusRemoteWriteInterval is the interval at which metrics are pushed.
+	// Default: 30s
+	PrometheusRemoteWriteInterval    time.Duration `hcl:"-"`
+	PrometheusRemoteWriteIntervalRaw interface{}   `hcl:"prometheus_remote_write_interval"`
+	// PrometheusRemoteWriteLabels are added to the pushed series which don't
+	// have them, and should identify the node, as scrapes would.
+	// Default: none
+	PrometheusRemoteWriteLabels map[string]string `hcl:"prometheus_remote_write_labels"`
+	// PrometheusRemoteWriteBearerToken is the bearer token the pushes are
+	// authenticated with.
+	// Default: none
+	PrometheusRemoteWriteBearerToken string `hcl:"prometheus_remote_write_bearer_token"`
+	// PrometheusRemoteWriteBearerTokenFile is a file the bearer token is read
+	// from before each push.
+	// Default: none
+	PrometheusRemoteWriteBearerTokenFile string `hcl:"prometheus_remote_write_bearer_token_file"`
+	// PrometheusRemoteWriteTLSCACert is the PEM-encoded CA certificate file
+	// the certificate of the endpoint is verified with.
+	// Default: the system CA certificates
+	PrometheusRemoteWriteTLSCACert string `hcl:"prometheus_remote_write_tls_ca_cert"`
+	// PrometheusRemoteWriteTLSClientCert and PrometheusRemoteWriteTLSClientKey
+	// are the PEM-encoded certificate and key files the pushes are
+	// authenticated with, for mutual TLS.
+	// Default: none
+	PrometheusRemoteWriteTLSClientCert string `hcl:"prometheus_remote_write_tls_client_cert"`
+	PrometheusRemoteWriteTLSClientKey  string `hcl:"prometheus_remote_write_tls_client_key"`
+	// PrometheusRemoteWriteTLSServerName is the name the certificate of the
+	// endpoint is verified against.
+	// Default: the host of the URL
+	PrometheusRemoteWriteTLSServerName string `hcl:"prometheus_remote_write_tls_server_name"`
+
+	// Tracing:
+	// TracingOTLPEndpoint is the host:port of the OTLP gRPC endpoint, such as
+	// an OpenTelemetry collector, the spans of the requests are exported to.
+	// Default: none, which disables tracing
+	TracingOTLPEndpoint string `hcl:"tracing_otlp_endpoint"`
+	// TracingOTLPInsecure disables TLS for the connection to the endpoint.
+	// Default: false
+	TracingOTLPInsecure bool `hcl:"tracing_otlp_insecure"`
+	// TracingOTLPTLSCACert is the PEM-encoded CA certificate file the
+	// certificate of the endpoint is verified with.
+	// Default: the system CA certificates
+	TracingOTLPTLSCACert string `hcl:"tracing_otlp_tls_ca_cert"`
+	// TracingOTLPHeaders are sent with the exports, such as to authenticate
+	// them.
+	// Default: none
+	TracingOTLPHeaders map[string]string `hcl:"tracing_otlp_headers"`
+	// TracingSampleRatio is the ratio of the requests which are traced, when
+	// the caller doesn't propagate its own sampling decision in a traceparent
+	// header.
+	// Default: 1
+	TracingSampleRatio    float64     `hcl:"-"`
+	TracingSampleRatioRaw interface{} `hcl:"tracing_sample_ratio"`
 
 	// Stackdriver:
 	// StackdriverProjectID is the project to publish stackdriver metrics to.
@@ -166,6 +240,17 @@ type Telemetry struct {
 	// Whether or not telemetry should include the mount point in the rollback
 	// metrics
 	RollbackMetricsIncludeMountPoint bool `hcl:"add_mount_point_rollback_metrics"`
+
+	// Whether or not telemetry should add namespace and mount point labels to
+	// the request and lease metrics
+	NamespaceLabels  bool `hcl:"add_namespace_labels"`
+	MountPointLabels bool `hcl:"add_mount_point_labels"`
+
+	// NamespaceLabelsAllowlist and MountPointLabelsAllowlist limit the values
+	// of the namespace and mount point labels of request, lease and token
+	// metrics, other values being reported as "other"
+	NamespaceLabelsAllowlist  []string `hcl:"namespace_labels_allowlist"`
+	MountPointLabelsAllowlist []string `hcl:"mount_point_labels_allowlist"`
 }
 
 func (t *Telemetry) Validate(source string) []ConfigError {
@@ -202,6 +287,45 @@ func parseTelemetry(result *SharedConfig, list *ast.ObjectList) error {
 		result.Telemetry.PrometheusRetentionTime = PrometheusDefaultRetentionTime
 	}
 
+	if err := metricsutil.ValidatePrometheusHistogramBuckets(result.Telemetry.PrometheusHistogramBuckets); err != nil {
+		return multierror.Prefix(err, "telemetry.prometheus_histogram_buckets:")
+	}
+	if err := metricsutil.ValidatePrometheusSummaryQuantiles(result.Telemetry.PrometheusSummaryQuantiles); err != nil {
+		return multierror.Prefix(err, "telemetry.prometheus_summary_quantiles:")
+	}
+
+	if result.Telemetry.PrometheusRemoteWriteIntervalRaw != nil {
+		var err error
+		if result.Telemetry.PrometheusRemoteWriteInterval, err = parseutil.ParseDurationSecond(result.Telemetry.PrometheusRemoteWriteIntervalRaw); err != nil {
+			return err
+		}
+		result.Telemetry.PrometheusRemoteWriteIntervalRaw = nil
+	}
+	if result.Telemetry.PrometheusRemoteWriteURL != "" {
+		if result.Telemetry.PrometheusRetentionTime == 0 {
+			return errors.New("telemetry.prometheus_remote_write_url: the metrics of the Prometheus sink are pushed, but it is disabled by a zero prometheus_retention_time")
+		}
+		if result.Telemetry.PrometheusRemoteWriteBearerToken != "" && result.Telemetry.PrometheusRemoteWriteBearerTokenFile != "" {
+			return errors.New("telemetry: only one of prometheus_remote_write_bearer_token and prometheus_remote_write_bearer_token_file can be specified")
+		}
+		if (result.Telemetry.PrometheusRemoteWriteTLSClientCert == "") != (result.Telemetry.PrometheusRemoteWriteTLSClientKey == "") {
+			return errors.New("telemetry: prometheus_remote_write_tls_client_cert and prometheus_remote_write_tls_client_key must be specified together")
+		}
+	}
+
+	if result.Telemetry.TracingSampleRatioRaw != nil {
+		var err error
+		if result.Telemetry.TracingSampleRatio, err = parseSampleRatio(result.Telemetry.TracingSampleRatioRaw); err != nil {
+			return multierror.Prefix(err, "telemetry.tracing_sample_ratio:")
+		}
+		result.Telemetry.TracingSampleRatioRaw = nil
+	} else {
+		result.Telemetry.TracingSampleRatio = TracingDefaultSampleRatio
+	}
+	if result.Telemetry.TracingOTLPInsecure && result.Telemetry.TracingOTLPTLSCACert != "" {
+		return errors.New("telemetry: only one of tracing_otlp_insecure and tracing_otlp_tls_ca_cert can be specified")
+	}
+
 	if result.Telemetry.UsageGaugePeriodRaw != nil {
 		if result.Telemetry.UsageGaugePeriodRaw == "none" {
 			result.Telemetry.UsageGaugePeriod = 0
@@ -244,6 +368,7 @@ func parseTelemetry(result *SharedConfig, list *ast.ObjectList) error {
 type SetupTelemetryOpts struct {
 	Config      *Telemetry
 	Ui          cli.Ui
+	Logger      hclog.Logger
 	ServiceName string
 	DisplayName string
 	UserAgent   string
@@ -290,13 +415,53 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 			Expiration: opts.Config.PrometheusRetentionTime,
 		}
 
-		sink, err := prometheus.NewPrometheusSinkFrom(prometheusOpts)
+		var sink metrics.MetricSink
+		var err error
+		if len(opts.Config.PrometheusHistogramBuckets) > 0 || len(opts.Config.PrometheusSummaryQuantiles) > 0 {
+			sink, err = metricsutil.NewPrometheusSink(metricsutil.PrometheusSinkOpts{
+				Expiration:       opts.Config.PrometheusRetentionTime,
+				HistogramBuckets: opts.Config.PrometheusHistogramBuckets,
+				SummaryQuantiles: opts.Config.PrometheusSummaryQuantiles,
+			})
+		} else {
+			sink, err = prometheus.NewPrometheusSinkFrom(prometheusOpts)
+		}
 		if err != nil {
 			return nil, nil, false, err
 		}
 		fanout = append(fanout, sink)
 	}
 
+	// Configure the Prometheus remote write, which pushes the metrics of the
+	// Prometheus sink
+	if opts.Config.PrometheusRemoteWriteURL != "" {
+		if !prometheusEnabled {
+			return nil, nil, false, errors.New("the Prometheus remote write requires the Prometheus sink, which is disabled")
+		}
+		tlsConfig, err := prometheusRemoteWriteTLSConfig(opts.Config)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to configure the Prometheus remote write TLS: %w", err)
+		}
+		logger := opts.Logger
+		if logger != nil {
+			logger = logger.Named("telemetry")
+		}
+		writer, err := metricsutil.NewPrometheusRemoteWriter(metricsutil.PrometheusRemoteWriterOpts{
+			URL:             opts.Config.PrometheusRemoteWriteURL,
+			Interval:        opts.Config.PrometheusRemoteWriteInterval,
+			Labels:          opts.Config.PrometheusRemoteWriteLabels,
+			BearerToken:     opts.Config.PrometheusRemoteWriteBearerToken,
+			BearerTokenFile: opts.Config.PrometheusRemoteWriteBearerTokenFile,
+			TLSConfig:       tlsConfig,
+			UserAgent:       opts.UserAgent,
+			Logger:          logger,
+		})
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to start the Prometheus remote write: %w", err)
+		}
+		writer.Start()
+	}
+
 	if opts.Config.StatsiteAddr != "" {
 		sink, err := metrics.NewStatsiteSink(opts.Config.StatsiteAddr)
 		if err != nil {
@@ -407,6 +572,10 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 	wrapper.TelemetryConsts.LeaseMetricsNameSpaceLabels = opts.Config.LeaseMetricsNameSpaceLabels
 	wrapper.TelemetryConsts.NumLeaseMetricsTimeBuckets = opts.Config.NumLeaseMetricsTimeBuckets
 	wrapper.TelemetryConsts.RollbackMetricsIncludeMountPoint = opts.Config.RollbackMetricsIncludeMountPoint
+	wrapper.TelemetryConsts.NamespaceLabels = opts.Config.NamespaceLabels
+	wrapper.TelemetryConsts.MountPointLabels = opts.Config.MountPointLabels
+	wrapper.TelemetryConsts.NamespaceLabelAllowlist = opts.Config.NamespaceLabelsAllowlist
+	wrapper.TelemetryConsts.MountPointLabelAllowlist = opts.Config.MountPointLabelsAllowlist
 
 	// Parse the metric filters
 	telemetryAllowedPrefixes, telemetryBlockedPrefixes, err := parsePrefixFilter(opts.Config.PrefixFilter)
@@ -418,6 +587,37 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 	return inm, wrapper, prometheusEnabled, nil
 }
 
+// prometheusRemoteWriteTLSConfig returns the TLS configuration of the
+// Prometheus remote write, or nil when the defaults are used.
+func prometheusRemoteWriteTLSConfig(t *Telemetry) (*tls.Config, error) {
+	if t.PrometheusRemoteWriteTLSCACert == "" && t.PrometheusRemoteWriteTLSClientCert == "" && t.PrometheusRemoteWriteTLSServerName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.PrometheusRemoteWriteTLSServerName,
+	}
+	if t.PrometheusRemoteWriteTLSCACert != "" {
+		caPEM, err := os.ReadFile(t.PrometheusRemoteWriteTLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no CA certificate found in %q", t.PrometheusRemoteWriteTLSCACert)
+		}
+	}
+	if t.PrometheusRemoteWriteTLSClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(t.PrometheusRemoteWriteTLSClientCert, t.PrometheusRemoteWriteTLSClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
 func parsePrefixFilter(prefixFilters []string) ([]string, []string, error) {
 	var telemetryAllowedPrefixes, telemetryBlockedPrefixes []string
 
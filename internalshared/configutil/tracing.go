@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package configutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"google.golang.org/grpc/credentials"
+)
+
+type SetupTracingOpts struct {
+	Config         *Telemetry
+	Logger         hclog.Logger
+	ServiceName    string
+	ServiceVersion string
+}
+
+// SetupTracing registers the global OpenTelemetry tracer provider, which
+// exports the spans of the sampled requests to the OTLP endpoint of the
+// configuration, along with the W3C trace context propagator, so that the
+// traces of the callers are continued. The returned function flushes the
+// pending spans and stops the exports; it is nil when tracing isn't
+// configured.
+func SetupTracing(opts *SetupTracingOpts) (func(context.Context) error, error) {
+	if opts == nil {
+		return nil, errors.New("nil opts passed into SetupTracing")
+	}
+	if opts.Config == nil || opts.Config.TracingOTLPEndpoint == "" {
+		return nil, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(opts.Config.TracingOTLPEndpoint),
+	}
+	switch {
+	case opts.Config.TracingOTLPInsecure:
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	case opts.Config.TracingOTLPTLSCACert != "":
+		caPEM, err := os.ReadFile(opts.Config.TracingOTLPTLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no CA certificate found in %q", opts.Config.TracingOTLPTLSCACert)
+		}
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(&tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    rootCAs,
+		})))
+	}
+	if len(opts.Config.TracingOTLPHeaders) > 0 {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithHeaders(opts.Config.TracingOTLPHeaders))
+	}
+
+	// The connection is established in the background, so that an unavailable
+	// collector doesn't prevent the startup
+	exporter, err := otlptracegrpc.New(context.Background(), exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(opts.ServiceName),
+		semconv.ServiceVersionKey.String(opts.ServiceVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.Config.TracingSampleRatio))),
+	)
+
+	if opts.Logger != nil {
+		logger := opts.Logger.Named("tracing")
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			logger.Warn("failed to export spans", "error", err)
+		}))
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// parseSampleRatio parses a ratio between 0 and 1, from a number or a string.
+func parseSampleRatio(in interface{}) (float64, error) {
+	var ratio float64
+	switch v := in.(type) {
+	case int:
+		ratio = float64(v)
+	case int64:
+		ratio = float64(v)
+	case float64:
+		ratio = v
+	case string:
+		var err error
+		if ratio, err = strconv.ParseFloat(v, 64); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("could not parse %v as a ratio", in)
+	}
+
+	if ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("the ratio %v is not between 0 and 1", ratio)
+	}
+	return ratio, nil
+}
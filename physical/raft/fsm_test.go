@@ -11,6 +11,7 @@ import (
 	"os"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	"github.com/golang/protobuf/proto"
@@ -162,3 +163,44 @@ func TestFSM_List(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestFSM_GroupFsync(t *testing.T) {
+	fsm, dir := getFSM(t)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	fsm.enableGroupFsync(10 * time.Millisecond)
+	if !fsm.getDB().NoSync {
+		t.Fatal("expected the db file not to be synced on each write")
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := fsm.Put(ctx, &physical.Entry{Key: fmt.Sprintf("key-%d", i), Value: []byte("value")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if err := fsm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes made before closing the FSM are kept, and the FSM syncs on each
+	// write again unless group fsync is enabled
+	fsm, err := NewFSM(dir, "", hclog.NewNullLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsm.Close()
+
+	keys, err := fsm.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 10 {
+		t.Fatalf("expected 10 keys, got %d", len(keys))
+	}
+	if fsm.getDB().NoSync {
+		t.Fatal("expected the db file to be synced on each write")
+	}
+}
@@ -139,6 +139,15 @@ type RaftBackend struct {
 	// performance.
 	maxEntrySize uint64
 
+	// maxApplyEntries is the maximum number of log entries the raft library
+	// batches into a single write to the log store and apply to the FSM, and
+	// the maximum number of writes coalesced by applyBatcher.
+	maxApplyEntries int
+
+	// applyBatcher coalesces the writes of concurrent requests into single
+	// log entries. It is nil unless max_apply_delay is set.
+	applyBatcher *applyBatcher
+
 	// autopilot is the instance of raft-autopilot library implementation of the
 	// autopilot features. This will be instantiated in both leader and followers.
 	// However, only active node will have a "running" autopilot.
@@ -183,6 +192,12 @@ type RaftBackend struct {
 	// replicated to and can serve reads, but do not take part in leader elections.
 	nonVoter bool
 
+	// suffrageOverrides holds the suffrage operators assigned to peers via the
+	// promote and demote APIs, keyed by node ID. It takes precedence over the
+	// suffrage peers report in their heartbeats.
+	suffrageLock      sync.RWMutex
+	suffrageOverrides map[string]string
+
 	effectiveSDKVersion string
 	failGetInTxn        *uint32
 }
@@ -369,6 +384,21 @@ func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend
 		return nil, fmt.Errorf("failed to create fsm: %v", err)
 	}
 
+	if intervalRaw, ok := conf["fsm_group_fsync_interval"]; ok {
+		interval, err := parseutil.ParseDurationSecond(intervalRaw)
+		if err != nil {
+			return nil, fmt.Errorf("fsm_group_fsync_interval does not parse as a duration: %w", err)
+		}
+		if interval < 0 {
+			return nil, errors.New("fsm_group_fsync_interval must not be negative")
+		}
+		if interval > 0 {
+			logger.Warn("FSM group fsync is enabled, the FSM of this node may be lost or corrupted if the host crashes or loses power",
+				"fsm_group_fsync_interval", interval)
+			fsm.enableGroupFsync(interval)
+		}
+	}
+
 	if delayRaw, ok := conf["apply_delay"]; ok {
 		delay, err := parseutil.ParseDurationSecond(delayRaw)
 		if err != nil {
@@ -444,6 +474,43 @@ func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend
 		maxEntrySize = uint64(i)
 	}
 
+	maxApplyEntries := defaultMaxApplyEntries
+	if maxApplyEntriesCfg := conf["max_apply_entries"]; len(maxApplyEntriesCfg) != 0 {
+		i, err := strconv.Atoi(maxApplyEntriesCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse 'max_apply_entries': %w", err)
+		}
+		if i < 1 || i > maxMaxApplyEntries {
+			return nil, fmt.Errorf("'max_apply_entries' must be between 1 and %d", maxMaxApplyEntries)
+		}
+
+		maxApplyEntries = i
+	}
+
+	maxApplyBytes := defaultMaxApplyBytes
+	if maxApplyBytesCfg := conf["max_apply_bytes"]; len(maxApplyBytesCfg) != 0 {
+		i, err := strconv.Atoi(maxApplyBytesCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse 'max_apply_bytes': %w", err)
+		}
+		if i < 1 || uint64(i) > maxEntrySize {
+			return nil, fmt.Errorf("'max_apply_bytes' must be between 1 and the max entry size of %d", maxEntrySize)
+		}
+
+		maxApplyBytes = i
+	}
+
+	var maxApplyDelay time.Duration
+	if delayRaw, ok := conf["max_apply_delay"]; ok {
+		maxApplyDelay, err = parseutil.ParseDurationSecond(delayRaw)
+		if err != nil {
+			return nil, fmt.Errorf("max_apply_delay does not parse as a duration: %w", err)
+		}
+		if maxApplyDelay < 0 {
+			return nil, errors.New("max_apply_delay must not be negative")
+		}
+	}
+
 	var reconcileInterval time.Duration
 	if interval := conf["autopilot_reconcile_interval"]; interval != "" {
 		interval, err := parseutil.ParseDurationSecond(interval)
@@ -501,7 +568,7 @@ func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend
 		return nil, fmt.Errorf("setting %s to true is only valid if at least one retry_join stanza is specified", raftNonVoterConfigKey)
 	}
 
-	return &RaftBackend{
+	b := &RaftBackend{
 		logger:                     logger,
 		fsm:                        fsm,
 		raftInitCh:                 make(chan struct{}),
@@ -513,6 +580,7 @@ func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend
 		localID:                    localID,
 		permitPool:                 physical.NewPermitPool(physical.DefaultParallelOperations),
 		maxEntrySize:               maxEntrySize,
+		maxApplyEntries:            maxApplyEntries,
 		followerHeartbeatTicker:    time.NewTicker(time.Second),
 		autopilotReconcileInterval: reconcileInterval,
 		autopilotUpdateInterval:    updateInterval,
@@ -520,7 +588,13 @@ func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend
 		nonVoter:                   nonVoter,
 		upgradeVersion:             upgradeVersion,
 		failGetInTxn:               new(uint32),
-	}, nil
+	}
+
+	if maxApplyDelay > 0 {
+		b.applyBatcher = newApplyBatcher(b.applyLog, maxApplyEntries, maxApplyBytes, maxApplyDelay)
+	}
+
+	return b, nil
 }
 
 type snapshotStoreDelay struct {
@@ -824,7 +898,10 @@ func (b *RaftBackend) applyConfigSettings(config *raft.Config) error {
 	}
 
 	config.NoSnapshotRestoreOnStart = true
-	config.MaxAppendEntries = 64
+	config.MaxAppendEntries = defaultMaxApplyEntries
+	if b.maxApplyEntries > 0 {
+		config.MaxAppendEntries = b.maxApplyEntries
+	}
 
 	// Setting BatchApplyCh allows the raft library to enqueue up to
 	// MaxAppendEntries into each raft apply rather than relying on the
@@ -989,11 +1066,11 @@ func (b *RaftBackend) SetupCluster(ctx context.Context, opts SetupOpts) error {
 			return fmt.Errorf("raft recovery failed to parse peers.json: %w", err)
 		}
 
-		// Non-voting servers are only allowed in enterprise. If Suffrage is disabled,
-		// error out to indicate that it isn't allowed.
+		// Non-voting servers are only allowed when suffrage is supported. If it
+		// is disabled, error out to indicate that it isn't allowed.
 		for idx := range recoveryConfig.Servers {
 			if !nonVotersAllowed && recoveryConfig.Servers[idx].Suffrage == raft.Nonvoter {
-				return fmt.Errorf("raft recovery failed to parse configuration for node %q: setting `non_voter` is only supported in enterprise", recoveryConfig.Servers[idx].ID)
+				return fmt.Errorf("raft recovery failed to parse configuration for node %q: setting `non_voter` is not supported", recoveryConfig.Servers[idx].ID)
 			}
 		}
 
@@ -1468,7 +1545,7 @@ func (b *RaftBackend) Delete(ctx context.Context, path string) error {
 	defer b.permitPool.Release()
 
 	b.l.RLock()
-	err := b.applyLog(ctx, command)
+	err := b.applyWrite(ctx, command)
 	b.l.RUnlock()
 	return err
 }
@@ -1530,7 +1607,7 @@ func (b *RaftBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	defer b.permitPool.Release()
 
 	b.l.RLock()
-	err := b.applyLog(ctx, command)
+	err := b.applyWrite(ctx, command)
 	b.l.RUnlock()
 	return err
 }
@@ -1623,6 +1700,15 @@ func (b *RaftBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry
 // applyLog will take a given log command and apply it to the raft log. applyLog
 // doesn't return until the log has been applied to a quorum of servers and is
 // persisted to the local FSM. Caller should hold the backend's read lock.
+// applyWrite applies the command of a single write, coalesced with the writes
+// of concurrent requests if apply batching is enabled.
+func (b *RaftBackend) applyWrite(ctx context.Context, command *LogData) error {
+	if b.applyBatcher == nil {
+		return b.applyLog(ctx, command)
+	}
+	return b.applyBatcher.apply(ctx, command)
+}
+
 func (b *RaftBackend) applyLog(ctx context.Context, command *LogData) error {
 	if b.raft == nil {
 		return errors.New("raft storage is not initialized")
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package raft
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-raftchunking"
+)
+
+const (
+	// defaultMaxApplyEntries is the default maximum number of log entries the
+	// raft library batches into a single write to the log store and a single
+	// apply to the FSM, and the number of write operations coalesced into a
+	// single log entry when apply batching is enabled.
+	defaultMaxApplyEntries = 64
+
+	// maxMaxApplyEntries is the highest value the raft library accepts for
+	// the maximum number of entries batched together.
+	maxMaxApplyEntries = 1024
+)
+
+// defaultMaxApplyBytes is the default maximum size of the write operations
+// coalesced into a single log entry. It matches the size above which entries
+// are chunked, so batched entries are never chunked.
+var defaultMaxApplyBytes = raftchunking.ChunkSize
+
+// applyBatcher coalesces the single writes of concurrent requests into a
+// single raft log entry, so that they are committed with a single write, and
+// fsync, of the log store on each node and applied to the FSM in a single
+// transaction. Each write waits for at most maxDelay for others to join its
+// batch, less if the batch reaches maxEntries operations or maxBytes bytes.
+//
+// Coalescing writes trades their latency for throughput: it only helps
+// workloads with many concurrent writes, on disks whose fsyncs are fast
+// enough that waiting for more writes costs less than committing them apart.
+// A batch is applied as a whole, so all its writes fail if the apply fails,
+// e.g. because of a leadership change, as a single write would.
+type applyBatcher struct {
+	applyFunc  func(context.Context, *LogData) error
+	maxEntries int
+	maxBytes   int
+	maxDelay   time.Duration
+
+	l       sync.Mutex
+	pending *applyBatch
+}
+
+// applyBatch is a batch of write operations applied in a single log entry
+type applyBatch struct {
+	command *LogData
+	size    int
+	start   time.Time
+	timer   *time.Timer
+
+	// doneCh is closed once the batch is applied, err holding the result
+	doneCh chan struct{}
+	err    error
+}
+
+func newApplyBatcher(applyFunc func(context.Context, *LogData) error, maxEntries, maxBytes int, maxDelay time.Duration) *applyBatcher {
+	return &applyBatcher{
+		applyFunc:  applyFunc,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		maxDelay:   maxDelay,
+	}
+}
+
+// apply applies the command along with the commands of concurrent calls, and
+// returns once it is applied. Commands which don't fit in a batch on their
+// own are applied alone, right away.
+func (a *applyBatcher) apply(ctx context.Context, command *LogData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	size := proto.Size(command)
+	if size >= a.maxBytes || len(command.Operations) >= a.maxEntries {
+		return a.applyFunc(ctx, command)
+	}
+
+	a.l.Lock()
+	if p := a.pending; p != nil && (len(p.command.Operations)+len(command.Operations) > a.maxEntries || p.size+size > a.maxBytes) {
+		// The command doesn't fit in the pending batch, so apply the batch
+		// without waiting any longer and start a new one.
+		a.pending = nil
+		p.timer.Stop()
+		go a.flush(p)
+	}
+
+	batch := a.pending
+	if batch == nil {
+		batch = &applyBatch{
+			command: &LogData{},
+			start:   time.Now(),
+			doneCh:  make(chan struct{}),
+		}
+		batch.timer = time.AfterFunc(a.maxDelay, func() {
+			a.flushPending(batch)
+		})
+		a.pending = batch
+	}
+
+	batch.command.Operations = append(batch.command.Operations, command.Operations...)
+	batch.size += size
+
+	full := len(batch.command.Operations) >= a.maxEntries || batch.size >= a.maxBytes
+	if full {
+		a.pending = nil
+		batch.timer.Stop()
+	}
+	a.l.Unlock()
+
+	if full {
+		a.flush(batch)
+	}
+
+	<-batch.doneCh
+	return batch.err
+}
+
+// flushPending applies the batch once its delay elapsed, unless it was
+// already applied.
+func (a *applyBatcher) flushPending(batch *applyBatch) {
+	a.l.Lock()
+	if a.pending != batch {
+		a.l.Unlock()
+		return
+	}
+	a.pending = nil
+	a.l.Unlock()
+
+	a.flush(batch)
+}
+
+// flush applies the batch, which must not be pending anymore.
+func (a *applyBatcher) flush(batch *applyBatch) {
+	metrics.MeasureSince([]string{"raft-storage", "apply_batch", "delay"}, batch.start)
+	metrics.AddSample([]string{"raft-storage", "apply_batch", "entries"}, float32(len(batch.command.Operations)))
+	metrics.AddSample([]string{"raft-storage", "apply_batch", "size"}, float32(batch.size))
+
+	// Callers of apply hold the read lock of the backend while they wait for
+	// their batch to be applied, so the raft instance can't go away.
+	batch.err = a.applyFunc(context.Background(), batch.command)
+	close(batch.doneCh)
+}
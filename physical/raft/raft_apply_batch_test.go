@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+func putCommand(key string, value []byte) *LogData {
+	return &LogData{
+		Operations: []*LogOperation{
+			{
+				OpType: putOp,
+				Key:    key,
+				Value:  value,
+			},
+		},
+	}
+}
+
+func TestApplyBatcher(t *testing.T) {
+	var l sync.Mutex
+	var applied []*LogData
+	applyFunc := func(ctx context.Context, command *LogData) error {
+		l.Lock()
+		defer l.Unlock()
+		applied = append(applied, command)
+		return nil
+	}
+
+	a := newApplyBatcher(applyFunc, 10, 1024, time.Second)
+
+	// Writes are applied once the batch is full, without waiting
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := a.apply(context.Background(), putCommand(fmt.Sprintf("key-%d", i), []byte("value"))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("expected full batches to be applied right away")
+	}
+
+	seen := make(map[string]bool)
+	for _, command := range applied {
+		if len(command.Operations) > 10 {
+			t.Fatalf("expected at most 10 operations per batch, got %d", len(command.Operations))
+		}
+		for _, op := range command.Operations {
+			seen[op.Key] = true
+		}
+	}
+	if len(applied) != 3 || len(seen) != 30 {
+		t.Fatalf("expected 30 writes applied in 3 batches, got %d writes in %d batches", len(seen), len(applied))
+	}
+
+	// A lone write is applied once the delay elapses
+	applied = nil
+	a.maxDelay = 50 * time.Millisecond
+	start = time.Now()
+	if err := a.apply(context.Background(), putCommand("key", []byte("value"))); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 50*time.Millisecond || len(applied) != 1 {
+		t.Fatal("expected the write to be applied after the delay")
+	}
+
+	// Writes larger than the batch size are applied alone, right away
+	applied = nil
+	a.maxDelay = time.Minute
+	if err := a.apply(context.Background(), putCommand("key", make([]byte, 1024))); err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 {
+		t.Fatal("expected the write to be applied right away")
+	}
+
+	// Writes which don't fit in the pending batch flush it
+	applied = nil
+	a.maxDelay = time.Minute
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := a.apply(context.Background(), putCommand("small", []byte("value"))); err != nil {
+			t.Error(err)
+		}
+	}()
+	for {
+		a.l.Lock()
+		pending := a.pending != nil
+		a.l.Unlock()
+		if pending {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	largeDoneCh := make(chan struct{})
+	go func() {
+		defer close(largeDoneCh)
+		if err := a.apply(context.Background(), putCommand("large", make([]byte, 1000))); err != nil {
+			t.Error(err)
+		}
+	}()
+	wg.Wait()
+
+	// The large write waits in a batch of its own
+	a.l.Lock()
+	pending := a.pending
+	a.l.Unlock()
+	if pending == nil || len(pending.command.Operations) != 1 || pending.command.Operations[0].Key != "large" {
+		t.Fatal("expected the large write to be pending in a new batch")
+	}
+	a.flushPending(pending)
+	<-largeDoneCh
+
+	if len(applied) != 2 || applied[0].Operations[0].Key != "small" {
+		t.Fatalf("expected the batches to be applied in order, got %d batches", len(applied))
+	}
+}
+
+func TestApplyBatcher_Error(t *testing.T) {
+	applyErr := errors.New("not leader")
+	a := newApplyBatcher(func(ctx context.Context, command *LogData) error {
+		return applyErr
+	}, 5, 1024, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := a.apply(context.Background(), putCommand(fmt.Sprintf("key-%d", i), nil)); err != applyErr {
+				t.Errorf("expected the error of the batch, got %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRaft_Backend_ApplyBatching(t *testing.T) {
+	b, dir := GetRaft(t, true, true)
+	defer os.RemoveAll(dir)
+
+	b.applyBatcher = newApplyBatcher(b.applyLog, defaultMaxApplyEntries, defaultMaxApplyBytes, 5*time.Millisecond)
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseBackend_ListPrefix(t, b)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := b.Put(ctx, &physical.Entry{Key: fmt.Sprintf("batch/%d", i), Value: []byte(fmt.Sprintf("%d", i))}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 200; i++ {
+		entry, err := b.Get(ctx, fmt.Sprintf("batch/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil || string(entry.Value) != fmt.Sprintf("%d", i) {
+			t.Fatalf("bad entry for key %d: %#v", i, entry)
+		}
+	}
+}
+
+func TestRaft_ParseApplyBatching(t *testing.T) {
+	for name, tc := range map[string]struct {
+		conf        map[string]string
+		expectErr   bool
+		expectBatch bool
+	}{
+		"defaults":               {conf: map[string]string{}},
+		"valid":                  {conf: map[string]string{"max_apply_entries": "256", "max_apply_bytes": "65536", "max_apply_delay": "2ms"}, expectBatch: true},
+		"entries too large":      {conf: map[string]string{"max_apply_entries": "2048"}, expectErr: true},
+		"entries not a number":   {conf: map[string]string{"max_apply_entries": "many"}, expectErr: true},
+		"bytes over entry size":  {conf: map[string]string{"max_apply_bytes": "1000", "max_entry_size": "500"}, expectErr: true},
+		"negative delay":         {conf: map[string]string{"max_apply_delay": "-1s"}, expectErr: true},
+		"negative fsync":         {conf: map[string]string{"fsm_group_fsync_interval": "-1s"}, expectErr: true},
+		"fsync not a duration":   {conf: map[string]string{"fsm_group_fsync_interval": "often"}, expectErr: true},
+		"fsync with batching":    {conf: map[string]string{"fsm_group_fsync_interval": "100ms", "max_apply_delay": "1ms"}, expectBatch: true},
+		"zero delay, no batcher": {conf: map[string]string{"max_apply_delay": "0"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			raftDir, err := ioutil.TempDir("", "vault-raft-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(raftDir)
+
+			tc.conf["path"] = raftDir
+			tc.conf["node_id"] = "abc123"
+
+			backend, err := NewRaftBackend(tc.conf, hclog.NewNullLogger())
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := backend.(*RaftBackend)
+			defer b.fsm.Close()
+			if (b.applyBatcher != nil) != tc.expectBatch {
+				t.Fatalf("expected apply batching to be %t", tc.expectBatch)
+			}
+		})
+	}
+}
@@ -802,6 +802,29 @@ func (b *RaftBackend) GetAutopilotServerState(ctx context.Context) (*AutopilotSt
 	return autopilotToAPIState(apState)
 }
 
+// RemoveDeadServers triggers an immediate removal of the failed and stale
+// servers by autopilot, instead of waiting for its next periodic cleanup. It
+// fails if autopilot is not running or dead server cleanup is disabled.
+func (b *RaftBackend) RemoveDeadServers() error {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.raft == nil {
+		return errors.New("raft storage is not initialized")
+	}
+
+	if b.autopilot == nil {
+		return errors.New("autopilot is not running")
+	}
+
+	if b.autopilotConfig == nil || !b.autopilotConfig.CleanupDeadServers {
+		return errors.New("dead server cleanup is disabled in the autopilot configuration")
+	}
+
+	b.autopilot.RemoveDeadServers()
+	return nil
+}
+
 func (b *RaftBackend) DisableAutopilot() {
 	b.l.Lock()
 	b.disableAutopilot = true
@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+const (
+	suffrageVoter    = "voter"
+	suffrageNonVoter = "non-voter"
+
+	// nodeNonVoter is the autopilot node type of servers that should stay
+	// non-voters, such as read replicas or DR staging nodes.
+	nodeNonVoter autopilot.NodeType = "non-voter"
+)
+
+// SetSuffrageOverrides sets the suffrage operators assigned to peers through
+// the promote and demote APIs. An override takes precedence over the suffrage
+// a node asked for when it joined.
+func (b *RaftBackend) SetSuffrageOverrides(overrides map[string]string) {
+	b.suffrageLock.Lock()
+	defer b.suffrageLock.Unlock()
+
+	b.suffrageOverrides = make(map[string]string, len(overrides))
+	for id, suffrage := range overrides {
+		b.suffrageOverrides[id] = suffrage
+	}
+}
+
+// desiredSuffrage returns whether the given peer should be a voter or a
+// non-voter.
+func (b *RaftBackend) desiredSuffrage(id raft.ServerID) string {
+	b.suffrageLock.RLock()
+	suffrage, ok := b.suffrageOverrides[string(id)]
+	b.suffrageLock.RUnlock()
+	if ok {
+		return suffrage
+	}
+
+	if b.followerStates != nil {
+		b.followerStates.l.RLock()
+		defer b.followerStates.l.RUnlock()
+		if state, ok := b.followerStates.followers[string(id)]; ok && state.DesiredSuffrage != "" {
+			return state.DesiredSuffrage
+		}
+	}
+	return suffrageVoter
+}
+
+// PromotePeer makes the given non-voting peer a voter.
+func (b *RaftBackend) PromotePeer(ctx context.Context, peerID string) error {
+	server, err := b.peerServer(ctx, peerID)
+	if err != nil {
+		return err
+	}
+	if server.Suffrage == raft.Voter {
+		return nil
+	}
+
+	b.logger.Info("promoting server to voter", "id", peerID)
+	return b.raft.AddVoter(server.ID, server.Address, 0, 0).Error()
+}
+
+// DemotePeer makes the given voting peer a non-voter. The local node cannot be
+// demoted, as that would make it step down as leader.
+func (b *RaftBackend) DemotePeer(ctx context.Context, peerID string) error {
+	server, err := b.peerServer(ctx, peerID)
+	if err != nil {
+		return err
+	}
+	if server.Suffrage != raft.Voter {
+		return nil
+	}
+	if peerID == b.localID {
+		return errors.New("cannot demote the leader; step down first")
+	}
+
+	b.logger.Info("demoting server to non-voter", "id", peerID)
+	return b.raft.DemoteVoter(server.ID, 0, 0).Error()
+}
+
+// peerServer looks up the given peer in the current raft configuration.
+func (b *RaftBackend) peerServer(ctx context.Context, peerID string) (raft.Server, error) {
+	if err := ctx.Err(); err != nil {
+		return raft.Server{}, err
+	}
+
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.raft == nil {
+		return raft.Server{}, errors.New("raft storage is not initialized")
+	}
+
+	future := b.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return raft.Server{}, err
+	}
+	for _, server := range future.Configuration().Servers {
+		if string(server.ID) == peerID {
+			return server, nil
+		}
+	}
+	return raft.Server{}, fmt.Errorf("server %q is not a raft peer", peerID)
+}
+
+// suffragePromoter is an autopilot promoter that keeps servers which asked to
+// be non-voters, or were demoted by an operator, from being promoted. Other
+// servers are promoted once stable, as with autopilot's default promoter.
+type suffragePromoter struct {
+	*autopilot.StablePromoter
+	backend *RaftBackend
+}
+
+var _ autopilot.Promoter = (*suffragePromoter)(nil)
+
+func (p *suffragePromoter) GetNodeTypes(_ *autopilot.Config, s *autopilot.State) map[raft.ServerID]autopilot.NodeType {
+	types := make(map[raft.ServerID]autopilot.NodeType, len(s.Servers))
+	for id := range s.Servers {
+		types[id] = autopilot.NodeVoter
+		if p.backend.desiredSuffrage(id) == suffrageNonVoter {
+			types[id] = nodeNonVoter
+		}
+	}
+	return types
+}
+
+func (p *suffragePromoter) CalculatePromotionsAndDemotions(c *autopilot.Config, s *autopilot.State) autopilot.RaftChanges {
+	var changes autopilot.RaftChanges
+	for _, id := range p.StablePromoter.CalculatePromotionsAndDemotions(c, s).Promotions {
+		if p.backend.desiredSuffrage(id) != suffrageNonVoter {
+			changes.Promotions = append(changes.Promotions, id)
+		}
+	}
+
+	for id, server := range s.Servers {
+		if server.State == autopilot.RaftVoter && !server.Server.IsLeader && p.backend.desiredSuffrage(id) == suffrageNonVoter {
+			changes.Demotions = append(changes.Demotions, id)
+		}
+	}
+	return changes
+}
@@ -9,18 +9,50 @@ import (
 	"context"
 	"errors"
 
+	"github.com/hashicorp/raft"
 	autopilot "github.com/hashicorp/raft-autopilot"
 )
 
-const nonVotersAllowed = false
+const nonVotersAllowed = true
 
 func (b *RaftBackend) autopilotPromoter() autopilot.Promoter {
-	return autopilot.DefaultPromoter()
+	return &suffragePromoter{
+		StablePromoter: new(autopilot.StablePromoter),
+		backend:        b,
+	}
 }
 
-// AddNonVotingPeer adds a new server to the raft cluster
+// AddNonVotingPeer adds a new server to the raft cluster as a non-voter. The
+// server is never promoted by autopilot while it asks to remain a non-voter.
 func (b *RaftBackend) AddNonVotingPeer(ctx context.Context, peerID, clusterAddr string) error {
-	return errors.New("adding non voting peer is not allowed")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.disableAutopilot {
+		if b.raft == nil {
+			return errors.New("raft storage is not initialized")
+		}
+		b.logger.Trace("adding non-voting server to raft", "id", peerID)
+		future := b.raft.AddNonvoter(raft.ServerID(peerID), raft.ServerAddress(clusterAddr), 0, 0)
+		return future.Error()
+	}
+
+	if b.autopilot == nil {
+		return errors.New("raft storage autopilot is not initialized")
+	}
+
+	b.logger.Trace("adding non-voting server to raft via autopilot", "id", peerID)
+	return b.autopilot.AddServer(&autopilot.Server{
+		ID:          raft.ServerID(peerID),
+		Name:        peerID,
+		Address:     raft.ServerAddress(clusterAddr),
+		RaftVersion: raft.ProtocolVersionMax,
+		NodeType:    nodeNonVoter,
+	})
 }
 
 func autopilotToAPIServerEnterprise(_ *autopilot.Server, _ *AutopilotServer) error {
@@ -17,15 +17,19 @@ import (
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
-	"github.com/hashicorp/vault/sdk/helper/template"
 )
 
 const (
 	msSQLTypeName = "mssql"
 
 	defaultUserNameTemplate = `{{ printf "v-%s-%s-%s-%s" (.DisplayName | truncate 20) (.RoleName | truncate 20) (random 20) (unix_time) | truncate 128 }}`
+
+	// maxUsernameLength is the maximum length of the logins and users of
+	// SQL Server, which are sysname identifiers.
+	maxUsernameLength = 128
 )
 
 var _ dbplugin.Database = &MSSQL{}
@@ -34,7 +38,7 @@ var _ dbplugin.Database = &MSSQL{}
 type MSSQL struct {
 	*connutil.SQLConnectionProducer
 
-	usernameProducer template.StringTemplate
+	usernameProducer credsutil.UsernameTemplate
 
 	// A flag to let us know to skip cross DB queries and server login checks
 	containedDB bool
@@ -83,25 +87,12 @@ func (m *MSSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest)
 		return dbplugin.InitializeResponse{}, err
 	}
 
-	usernameTemplate, err := strutil.GetString(req.Config, "username_template")
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("failed to retrieve username_template: %w", err)
-	}
-	if usernameTemplate == "" {
-		usernameTemplate = defaultUserNameTemplate
-	}
-
-	up, err := template.NewTemplate(template.Template(usernameTemplate))
+	up, err := credsutil.UsernameTemplateFromConfig(req.Config, defaultUserNameTemplate, credsutil.UsernameMaxLength(maxUsernameLength))
 	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to initialize username template: %w", err)
+		return dbplugin.InitializeResponse{}, err
 	}
 	m.usernameProducer = up
 
-	_, err = m.usernameProducer.Generate(dbplugin.UsernameMetadata{})
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid username template - did you reference a field that isn't available? : %w", err)
-	}
-
 	if v, ok := req.Config["contained_db"]; ok {
 		containedDB, err := parseutil.ParseBool(v)
 		if err != nil {
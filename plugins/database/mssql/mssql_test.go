@@ -16,6 +16,8 @@ import (
 	mssqlhelper "github.com/hashicorp/vault/helper/testhelpers/mssql"
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/testhelpers"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
 	"github.com/stretchr/testify/assert"
 )
@@ -79,6 +81,14 @@ func TestInitialize(t *testing.T) {
 	}
 }
 
+func TestUsernameGeneration(t *testing.T) {
+	metadata := dbplugin.UsernameMetadata{
+		DisplayName: "token-abcdefghijklmnopqrstuvwxyz",
+		RoleName:    "myrole",
+	}
+	testhelpers.AssertUsernameTemplate(t, defaultUserNameTemplate, metadata, `^v-token-abcdefghijklmn-myrole-[a-zA-Z0-9]{20}-[0-9]{10}$`, credsutil.UsernameMaxLength(maxUsernameLength))
+}
+
 func TestNewUser(t *testing.T) {
 	cleanup, connURL := mssqlhelper.PrepareMSSQLTestContainer(t)
 	defer cleanup()
@@ -13,8 +13,8 @@ import (
 	stdmysql "github.com/go-sql-driver/mysql"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
-	"github.com/hashicorp/vault/sdk/helper/template"
 )
 
 const (
@@ -31,6 +31,10 @@ const (
 
 	DefaultUserNameTemplate       = `{{ printf "v-%s-%s-%s-%s" (.DisplayName | truncate 10) (.RoleName | truncate 10) (random 20) (unix_time) | truncate 32 }}`
 	DefaultLegacyUserNameTemplate = `{{ printf "v-%s-%s-%s" (.RoleName | truncate 4) (random 20) | truncate 16 }}`
+
+	// maxUsernameLength is the maximum length of the user names of MySQL 5.7
+	// and later; the older servers reject the names longer than 16 characters.
+	maxUsernameLength = 32
 )
 
 var _ dbplugin.Database = (*MySQL)(nil)
@@ -38,7 +42,7 @@ var _ dbplugin.Database = (*MySQL)(nil)
 type MySQL struct {
 	*mySQLConnectionProducer
 
-	usernameProducer        template.StringTemplate
+	usernameProducer        credsutil.UsernameTemplate
 	defaultUsernameTemplate string
 }
 
@@ -79,27 +83,12 @@ func (m *MySQL) getConnection(ctx context.Context) (*sql.DB, error) {
 }
 
 func (m *MySQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
-	usernameTemplate, err := strutil.GetString(req.Config, "username_template")
+	up, err := credsutil.UsernameTemplateFromConfig(req.Config, m.defaultUsernameTemplate, credsutil.UsernameMaxLength(maxUsernameLength))
 	if err != nil {
 		return dbplugin.InitializeResponse{}, err
 	}
-
-	if usernameTemplate == "" {
-		usernameTemplate = m.defaultUsernameTemplate
-	}
-
-	up, err := template.NewTemplate(template.Template(usernameTemplate))
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to initialize username template: %w", err)
-	}
-
 	m.usernameProducer = up
 
-	_, err = m.usernameProducer.Generate(dbplugin.UsernameMetadata{})
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid username template: %w", err)
-	}
-
 	err = m.mySQLConnectionProducer.Initialize(ctx, req.Config, req.VerifyConnection)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, err
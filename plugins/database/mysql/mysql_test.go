@@ -21,6 +21,7 @@ import (
 	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/testhelpers"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 )
 
@@ -245,6 +246,24 @@ func testInitialize(t *testing.T, rootPassword string) {
 	}
 }
 
+func TestMySQL_UsernameGeneration(t *testing.T) {
+	metadata := dbplugin.UsernameMetadata{
+		DisplayName: "token-abcdefghijklmnopqrstuvwxyz",
+		RoleName:    "myrole",
+	}
+	testhelpers.AssertUsernameTemplate(t, DefaultUserNameTemplate, metadata, `^v-token-abcd-myrole-[a-zA-Z0-9]{12}$`, credsutil.UsernameMaxLength(maxUsernameLength))
+	testhelpers.AssertUsernameTemplate(t, DefaultLegacyUserNameTemplate, metadata, `^v-myro-[a-zA-Z0-9]{9}$`, credsutil.UsernameMaxLength(maxUsernameLength))
+
+	// Custom templates can't generate usernames longer than MySQL supports
+	up, err := credsutil.NewUsernameTemplate(`{{ printf "v-%s-%s" .DisplayName (random 20) }}`, credsutil.UsernameMaxLength(maxUsernameLength))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := up.Generate(metadata); err == nil {
+		t.Fatalf("expected an error for a username longer than %d characters", maxUsernameLength)
+	}
+}
+
 func TestMySQL_NewUser_nonLegacy(t *testing.T) {
 	displayName := "token"
 	roleName := "testrole"
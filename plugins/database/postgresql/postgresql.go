@@ -15,9 +15,9 @@ import (
 	"github.com/hashicorp/vault/plugins/database/postgresql/scram"
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
-	"github.com/hashicorp/vault/sdk/helper/template"
 	"github.com/hashicorp/vault/sdk/logical"
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
@@ -34,6 +34,10 @@ ALTER ROLE "{{username}}" WITH PASSWORD '{{password}}';
 	expirationFormat = "2006-01-02 15:04:05-0700"
 
 	defaultUserNameTemplate = `{{ printf "v-%s-%s-%s-%s" (.DisplayName | truncate 8) (.RoleName | truncate 8) (random 20) (unix_time) | truncate 63 }}`
+
+	// maxUsernameLength is the maximum length of the identifiers of
+	// PostgreSQL, which silently truncates longer ones.
+	maxUsernameLength = 63
 )
 
 var (
@@ -79,7 +83,7 @@ func new() *PostgreSQL {
 type PostgreSQL struct {
 	*connutil.SQLConnectionProducer
 
-	usernameProducer       template.StringTemplate
+	usernameProducer       credsutil.UsernameTemplate
 	passwordAuthentication passwordAuthentication
 }
 
@@ -89,25 +93,12 @@ func (p *PostgreSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequ
 		return dbplugin.InitializeResponse{}, err
 	}
 
-	usernameTemplate, err := strutil.GetString(req.Config, "username_template")
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("failed to retrieve username_template: %w", err)
-	}
-	if usernameTemplate == "" {
-		usernameTemplate = defaultUserNameTemplate
-	}
-
-	up, err := template.NewTemplate(template.Template(usernameTemplate))
+	up, err := credsutil.UsernameTemplateFromConfig(req.Config, defaultUserNameTemplate, credsutil.UsernameMaxLength(maxUsernameLength))
 	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to initialize username template: %w", err)
+		return dbplugin.InitializeResponse{}, err
 	}
 	p.usernameProducer = up
 
-	_, err = p.usernameProducer.Generate(dbplugin.UsernameMetadata{})
-	if err != nil {
-		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid username template: %w", err)
-	}
-
 	passwordAuthenticationRaw, err := strutil.GetString(req.Config, "password_authentication")
 	if err != nil {
 		return dbplugin.InitializeResponse{}, fmt.Errorf("failed to retrieve password_authentication: %w", err)
@@ -1088,6 +1088,7 @@ func TestUsernameGeneration(t *testing.T) {
 				username, err := up.Generate(test.data)
 				require.NoError(t, err)
 				require.Regexp(t, test.expectedRegex, username)
+				require.LessOrEqual(t, len(username), maxUsernameLength)
 			}
 		})
 	}
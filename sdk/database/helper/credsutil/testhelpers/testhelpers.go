@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package testhelpers contains the test helpers of the credsutil package, kept
+// out of it so that the database plugins don't import the testing package.
+package testhelpers
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+)
+
+// AssertUsernameTemplate generates usernames from the template for the
+// metadata, and fails the test unless they all match the regular expression.
+// Several usernames are generated, as most templates have random parts.
+func AssertUsernameTemplate(t testing.TB, rawTemplate string, metadata dbplugin.UsernameMetadata, expectedRegex string, opts ...credsutil.UsernameTemplateOpt) {
+	t.Helper()
+
+	up, err := credsutil.NewUsernameTemplate(rawTemplate, opts...)
+	if err != nil {
+		t.Fatalf("unable to create the username template: %v", err)
+	}
+
+	re := regexp.MustCompile(expectedRegex)
+	for i := 0; i < 100; i++ {
+		username, err := up.Generate(metadata)
+		if err != nil {
+			t.Fatalf("unable to generate a username: %v", err)
+		}
+		if !re.MatchString(username) {
+			t.Fatalf("username %q doesn't match %q", username, expectedRegex)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package credsutil
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/template"
+)
+
+// UsernameTemplateKey is the key of the username template in the connection
+// configuration of the database plugins.
+const UsernameTemplateKey = "username_template"
+
+// UsernameTemplate generates the usernames of the database users from a
+// template, such as:
+//
+//	{{ printf "v-%s-%s-%s-%s" (.DisplayName | truncate 8) (.RoleName | truncate 8) (random 20) (unix_time) | truncate 63 }}
+//
+// The templates use the syntax and the functions of sdk/helper/template, and
+// get the dbplugin.UsernameMetadata of the request as their data. Usernames
+// longer than the maximum length of the database are rejected rather than
+// truncated, as truncating could cut off their random part.
+type UsernameTemplate struct {
+	template  template.StringTemplate
+	maxLength int
+}
+
+type UsernameTemplateOpt func(*UsernameTemplate)
+
+// UsernameMaxLength sets the maximum length of the usernames supported by the
+// database; generating a longer username fails. Zero, the default, doesn't
+// limit the length of the usernames.
+func UsernameMaxLength(maxLength int) UsernameTemplateOpt {
+	return func(t *UsernameTemplate) {
+		t.maxLength = maxLength
+	}
+}
+
+// NewUsernameTemplate parses the template and validates it by generating a
+// username for an empty dbplugin.UsernameMetadata, so that templates
+// referencing unknown fields are rejected when the plugin is initialized
+// rather than when creating users. The username generated for the empty
+// metadata may itself be empty, as with a template such as {{ .RoleName }}.
+func NewUsernameTemplate(rawTemplate string, opts ...UsernameTemplateOpt) (UsernameTemplate, error) {
+	var t UsernameTemplate
+	for _, opt := range opts {
+		opt(&t)
+	}
+	if t.maxLength < 0 {
+		return UsernameTemplate{}, fmt.Errorf("max length must not be negative but was %d", t.maxLength)
+	}
+
+	up, err := template.NewTemplate(template.Template(rawTemplate))
+	if err != nil {
+		return UsernameTemplate{}, fmt.Errorf("unable to initialize username template: %w", err)
+	}
+	t.template = up
+
+	if _, err := t.generate(dbplugin.UsernameMetadata{}); err != nil {
+		return UsernameTemplate{}, fmt.Errorf("invalid username template - did you reference a field that isn't available? : %w", err)
+	}
+
+	return t, nil
+}
+
+// UsernameTemplateFromConfig returns the username template set by the
+// username_template key of the connection configuration of a database
+// plugin, or the default template of the plugin when it isn't set.
+func UsernameTemplateFromConfig(config map[string]interface{}, defaultTemplate string, opts ...UsernameTemplateOpt) (UsernameTemplate, error) {
+	rawTemplate, err := strutil.GetString(config, UsernameTemplateKey)
+	if err != nil {
+		return UsernameTemplate{}, fmt.Errorf("failed to retrieve %s: %w", UsernameTemplateKey, err)
+	}
+	if rawTemplate == "" {
+		rawTemplate = defaultTemplate
+	}
+
+	return NewUsernameTemplate(rawTemplate, opts...)
+}
+
+// Generate returns a username for the metadata of the request.
+func (t UsernameTemplate) Generate(metadata dbplugin.UsernameMetadata) (string, error) {
+	username, err := t.generate(metadata)
+	if err != nil {
+		return "", err
+	}
+	if username == "" {
+		return "", fmt.Errorf("the username template generated an empty username")
+	}
+
+	return username, nil
+}
+
+func (t UsernameTemplate) generate(metadata dbplugin.UsernameMetadata) (string, error) {
+	username, err := t.template.Generate(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	if t.maxLength > 0 && len(username) > t.maxLength {
+		return "", fmt.Errorf("the username template generated a username of %d characters, longer than the maximum of %d", len(username), t.maxLength)
+	}
+
+	return username, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package credsutil_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/testhelpers"
+)
+
+func TestUsernameTemplate(t *testing.T) {
+	metadata := dbplugin.UsernameMetadata{
+		DisplayName: "token-abcdefghijklmnopqrstuvwxyz",
+		RoleName:    "myrole",
+	}
+
+	type testCase struct {
+		template string
+		opts     []credsutil.UsernameTemplateOpt
+		regex    string
+	}
+	tests := map[string]testCase{
+		"no max length": {
+			template: `{{ printf "v-%s-%s-%s" .DisplayName .RoleName (random 20) }}`,
+			regex:    `^v-token-abcdefghijklmnopqrstuvwxyz-myrole-[a-zA-Z0-9]{20}$`,
+		},
+		"shorter than the max length": {
+			template: `{{ printf "v-%s-%s" (.RoleName | truncate 4) (random 5) }}`,
+			opts:     []credsutil.UsernameTemplateOpt{credsutil.UsernameMaxLength(16)},
+			regex:    `^v-myro-[a-zA-Z0-9]{5}$`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testhelpers.AssertUsernameTemplate(t, test.template, metadata, test.regex, test.opts...)
+		})
+	}
+}
+
+// TestUsernameTemplate_tooLong ensures usernames longer than the max length
+// are rejected rather than truncated.
+func TestUsernameTemplate_tooLong(t *testing.T) {
+	up, err := credsutil.NewUsernameTemplate(`{{ printf "v-%s-%s" .DisplayName (random 5) }}`, credsutil.UsernameMaxLength(16))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := up.Generate(dbplugin.UsernameMetadata{DisplayName: "token-abcdefghij"}); err == nil {
+		t.Fatalf("expected an error for a username longer than the max length")
+	}
+
+	if _, err := credsutil.NewUsernameTemplate(`v-{{ random 20 }}`, credsutil.UsernameMaxLength(16)); err == nil {
+		t.Fatalf("expected an error for a template always longer than the max length")
+	}
+}
+
+// TestUsernameTemplate_metadataOnly ensures templates made only of metadata
+// fields, which generate an empty username for empty metadata, are accepted.
+func TestUsernameTemplate_metadataOnly(t *testing.T) {
+	for _, tmpl := range []string{`{{ .RoleName }}`, `{{ .DisplayName | truncate 10 }}`} {
+		up, err := credsutil.NewUsernameTemplate(tmpl, credsutil.UsernameMaxLength(16))
+		if err != nil {
+			t.Fatalf("unexpected error for template %q: %v", tmpl, err)
+		}
+
+		username, err := up.Generate(dbplugin.UsernameMetadata{DisplayName: "token", RoleName: "token"})
+		if err != nil {
+			t.Fatalf("unexpected error generating a username from template %q: %v", tmpl, err)
+		}
+		if username != "token" {
+			t.Fatalf("expected username %q from template %q, got %q", "token", tmpl, username)
+		}
+
+		if _, err := up.Generate(dbplugin.UsernameMetadata{}); err == nil {
+			t.Fatalf("expected an error for an empty username from template %q", tmpl)
+		}
+	}
+}
+
+func TestUsernameTemplate_invalid(t *testing.T) {
+	tests := map[string]string{
+		"bad syntax":    `{{ .DisplayName`,
+		"unknown field": `{{ .FieldThatDoesNotExist }}`,
+	}
+
+	for name, rawTemplate := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := credsutil.NewUsernameTemplate(rawTemplate); err == nil {
+				t.Fatalf("expected an error for template %q", rawTemplate)
+			}
+		})
+	}
+
+	if _, err := credsutil.NewUsernameTemplate(`v-{{ random 20 }}`, credsutil.UsernameMaxLength(-1)); err == nil {
+		t.Fatalf("expected an error for a negative max length")
+	}
+}
+
+func TestUsernameTemplateFromConfig(t *testing.T) {
+	metadata := dbplugin.UsernameMetadata{DisplayName: "token", RoleName: "myrole"}
+
+	up, err := credsutil.UsernameTemplateFromConfig(map[string]interface{}{}, `default-{{ .RoleName }}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	username, err := up.Generate(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "default-myrole" {
+		t.Fatalf("expected the default template to be used, got %q", username)
+	}
+
+	up, err = credsutil.UsernameTemplateFromConfig(map[string]interface{}{
+		credsutil.UsernameTemplateKey: `custom-{{ .DisplayName }}`,
+	}, `default-{{ .RoleName }}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	username, err = up.Generate(metadata)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "custom-token" {
+		t.Fatalf("expected the configured template to be used, got %q", username)
+	}
+
+	_, err = credsutil.UsernameTemplateFromConfig(map[string]interface{}{
+		credsutil.UsernameTemplateKey: 42,
+	}, `default-{{ .RoleName }}`)
+	if err == nil {
+		t.Fatalf("expected an error for a template which isn't a string")
+	}
+}
@@ -785,6 +785,12 @@ type FieldSchema struct {
 	// DisplayAttrs provides hints for UI and documentation generators. They
 	// will be included in OpenAPI output if set.
 	DisplayAttrs *DisplayAttributes
+
+	// Fields optionally describes the properties of the object held by a
+	// TypeMap field, or of each element of a TypeSlice field. Like Required,
+	// it is only used by openapi, so that the generated schemas (notably the
+	// response schemas) are typed rather than arbitrary objects.
+	Fields map[string]*FieldSchema
 }
 
 // DefaultOrZero returns the default value if it is set, or otherwise
@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+const (
+	// ListAfterField is the field of paginated list operations holding the
+	// key the listing starts after.
+	ListAfterField = "after"
+
+	// ListLimitField is the field of paginated list operations holding the
+	// maximum number of keys to return.
+	ListLimitField = "limit"
+
+	// ListNextAfterField is the field of the responses to paginated list
+	// operations holding the value of ListAfterField listing the next page.
+	// It is only set when there are more keys to list.
+	ListNextAfterField = "next_after"
+)
+
+// AddListPaginationFields adds the fields of paginated list operations to the
+// given fields, and returns them. Paths adding them should get the pagination
+// of the request with GetListPagination in their list handler, and respond
+// with its ListResponse or PaginateResponse.
+func AddListPaginationFields(fields map[string]*FieldSchema) map[string]*FieldSchema {
+	if fields == nil {
+		fields = make(map[string]*FieldSchema)
+	}
+
+	fields[ListAfterField] = &FieldSchema{
+		Type:        TypeString,
+		Description: "Optional entry to begin listing after, for pagination. The entry doesn't need to exist.",
+		Query:       true,
+	}
+	fields[ListLimitField] = &FieldSchema{
+		Type:        TypeInt,
+		Description: "Optional number of entries to return, for pagination. Defaults to all the entries.",
+		Query:       true,
+	}
+
+	return fields
+}
+
+// ListPagination holds the pagination of a list operation: the keys sorting
+// after After, in lexicographic order, are returned, at most Limit of them if
+// Limit is positive.
+type ListPagination struct {
+	After string
+	Limit int
+}
+
+// GetListPagination returns the pagination of a list operation on a path with
+// the fields of AddListPaginationFields.
+func GetListPagination(d *FieldData) (*ListPagination, error) {
+	p := &ListPagination{}
+
+	if after, ok := d.GetOk(ListAfterField); ok {
+		p.After = after.(string)
+	}
+	if limit, ok := d.GetOk(ListLimitField); ok {
+		p.Limit = limit.(int)
+		if p.Limit <= 0 {
+			return nil, fmt.Errorf("%q must be a positive integer", ListLimitField)
+		}
+	}
+
+	return p, nil
+}
+
+// List lists the page of the keys under the prefix in the storage. See
+// logical.ListPage.
+func (p *ListPagination) List(ctx context.Context, s logical.Storage, prefix string) ([]string, error) {
+	return logical.ListPage(ctx, s, prefix, p.After, p.Limit)
+}
+
+// Paginate returns the page of the given keys, which are sorted in place.
+func (p *ListPagination) Paginate(keys []string) []string {
+	return physical.PaginateKeys(keys, p.After, p.Limit)
+}
+
+// ListResponse lists the page of the keys under the prefix in the storage, and
+// returns it as the response of the list operation, with the marker of the
+// next page if there are more keys to list.
+func (p *ListPagination) ListResponse(ctx context.Context, s logical.Storage, prefix string) (*logical.Response, error) {
+	if p.Limit <= 0 {
+		keys, err := p.List(ctx, s, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return logical.ListResponse(keys), nil
+	}
+
+	// One more key is listed to know whether there is a next page
+	keys, err := logical.ListPage(ctx, s, prefix, p.After, p.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	return p.pageResponse(keys, nil), nil
+}
+
+// PaginateResponse returns the page of the given keys, which are sorted in
+// place, as the response of the list operation, with the marker of the next
+// page if there are more keys to list.
+func (p *ListPagination) PaginateResponse(keys []string) *logical.Response {
+	return p.PaginateResponseWithInfo(keys, nil)
+}
+
+// PaginateResponseWithInfo is PaginateResponse for the list operations also
+// returning information about each key, see logical.ListResponseWithInfo.
+func (p *ListPagination) PaginateResponseWithInfo(keys []string, keyInfo map[string]interface{}) *logical.Response {
+	if p.Limit <= 0 {
+		return logical.ListResponseWithInfo(p.Paginate(keys), keyInfo)
+	}
+
+	return p.pageResponse(physical.PaginateKeys(keys, p.After, p.Limit+1), keyInfo)
+}
+
+// pageResponse returns the response for the page, given with the first key of
+// the next page if any.
+func (p *ListPagination) pageResponse(keys []string, keyInfo map[string]interface{}) *logical.Response {
+	more := len(keys) > p.Limit
+	if more {
+		keys = keys[:p.Limit]
+	}
+
+	var resp *logical.Response
+	if keyInfo != nil {
+		resp = logical.ListResponseWithInfo(keys, keyInfo)
+	} else {
+		resp = logical.ListResponse(keys)
+	}
+	if more {
+		resp.Data[ListNextAfterField] = keys[len(keys)-1]
+	}
+	return resp
+}
+
+// ListPages lists the keys under the prefix in the storage page by page,
+// calling the given function with each page of at most pageSize keys, in
+// lexicographic order, until all the keys are listed or the function returns
+// an error, which is then returned. It lets backends walk large prefixes
+// without holding all their keys in memory when the storage supports
+// pagination.
+func ListPages(ctx context.Context, s logical.Storage, prefix string, pageSize int, f func(keys []string) error) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("page size must be a positive integer")
+	}
+
+	var after string
+	for {
+		keys, err := logical.ListPage(ctx, s, prefix, after, pageSize)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := f(keys); err != nil {
+			return err
+		}
+		if len(keys) < pageSize {
+			return nil
+		}
+		after = keys[len(keys)-1]
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestGetListPagination(t *testing.T) {
+	cases := map[string]struct {
+		raw      map[string]interface{}
+		expected *ListPagination
+		err      bool
+	}{
+		"none": {
+			raw:      map[string]interface{}{},
+			expected: &ListPagination{},
+		},
+		"after and limit": {
+			raw:      map[string]interface{}{"after": "foo", "limit": "2"},
+			expected: &ListPagination{After: "foo", Limit: 2},
+		},
+		"zero limit": {
+			raw: map[string]interface{}{"limit": 0},
+			err: true,
+		},
+		"negative limit": {
+			raw: map[string]interface{}{"limit": -1},
+			err: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := &FieldData{
+				Raw:    tc.raw,
+				Schema: AddListPaginationFields(nil),
+			}
+
+			p, err := GetListPagination(d)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %#v", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p, tc.expected) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, p)
+			}
+		})
+	}
+}
+
+func TestListPagination_List(t *testing.T) {
+	ctx := context.Background()
+	s := &logical.InmemStorage{}
+	for _, key := range []string{"prefix/c", "prefix/a", "prefix/b/c", "prefix/d"} {
+		if err := s.Put(ctx, &logical.StorageEntry{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &ListPagination{After: "a", Limit: 2}
+
+	keys, err := p.List(ctx, s, "prefix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"b/", "c"}; !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+
+	keys = p.Paginate([]string{"d", "c", "b/", "a"})
+	if expected := []string{"b/", "c"}; !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestListPagination_ListResponse(t *testing.T) {
+	ctx := context.Background()
+	s := &logical.InmemStorage{}
+	for _, key := range []string{"prefix/c", "prefix/a", "prefix/b/c", "prefix/d"} {
+		if err := s.Put(ctx, &logical.StorageEntry{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string]struct {
+		pagination *ListPagination
+		keys       []string
+		nextAfter  interface{}
+	}{
+		"all": {
+			pagination: &ListPagination{},
+			keys:       []string{"a", "b/", "c", "d"},
+		},
+		"first page": {
+			pagination: &ListPagination{Limit: 2},
+			keys:       []string{"a", "b/"},
+			nextAfter:  "b/",
+		},
+		"last page": {
+			pagination: &ListPagination{After: "b/", Limit: 2},
+			keys:       []string{"c", "d"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := tc.pagination.ListResponse(ctx, s, "prefix/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp.Data["keys"], tc.keys) {
+				t.Fatalf("expected %v, got %v", tc.keys, resp.Data["keys"])
+			}
+			if resp.Data[ListNextAfterField] != tc.nextAfter {
+				t.Fatalf("expected next page after %v, got %v", tc.nextAfter, resp.Data[ListNextAfterField])
+			}
+
+			resp = tc.pagination.PaginateResponseWithInfo([]string{"d", "c", "b/", "a"}, map[string]interface{}{
+				"a": "info", "c": "info",
+			})
+			if !reflect.DeepEqual(resp.Data["keys"], tc.keys) {
+				t.Fatalf("expected %v, got %v", tc.keys, resp.Data["keys"])
+			}
+			if resp.Data[ListNextAfterField] != tc.nextAfter {
+				t.Fatalf("expected next page after %v, got %v", tc.nextAfter, resp.Data[ListNextAfterField])
+			}
+			for key := range resp.Data["key_info"].(map[string]interface{}) {
+				if !strutil.StrListContains(tc.keys, key) {
+					t.Fatalf("unexpected info for %q", key)
+				}
+			}
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	ctx := context.Background()
+	s := &logical.InmemStorage{}
+	for _, key := range []string{"prefix/c", "prefix/a", "prefix/b/c", "prefix/d", "prefix/e"} {
+		if err := s.Put(ctx, &logical.StorageEntry{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var pages [][]string
+	err := ListPages(ctx, s, "prefix/", 2, func(keys []string) error {
+		pages = append(pages, keys)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]string{{"a", "b/"}, {"c", "d"}, {"e"}}; !reflect.DeepEqual(pages, expected) {
+		t.Fatalf("expected %v, got %v", expected, pages)
+	}
+
+	stop := errors.New("stop")
+	pages = nil
+	err = ListPages(ctx, s, "prefix/", 2, func(keys []string) error {
+		pages = append(pages, keys)
+		return stop
+	})
+	if err != stop || len(pages) != 1 {
+		t.Fatalf("expected the listing to stop after the first page, got %v and %v", err, pages)
+	}
+
+	if err := ListPages(ctx, s, "prefix/", 0, func([]string) error { return nil }); err == nil {
+		t.Fatal("expected an error for a zero page size")
+	}
+}
@@ -526,27 +526,21 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 					}
 
 					for name, field := range resp.Fields {
-						openapiField := convertType(field.Type)
-						p := OASSchema{
-							Type:         openapiField.baseType,
-							Description:  cleanString(field.Description),
-							Format:       openapiField.format,
-							Pattern:      openapiField.pattern,
-							Enum:         field.AllowedValues,
-							Default:      field.Default,
-							Deprecated:   field.Deprecated,
-							DisplayAttrs: withoutOperationHints(field.DisplayAttrs),
-						}
-						if openapiField.baseType == "array" {
-							p.Items = &OASSchema{
-								Type: openapiField.items,
-							}
-						}
-						responseSchema.Properties[name] = &p
+						addFieldToOASSchema(responseSchema, name, field)
 					}
 
+					// As for the request bodies, keep the ordering deterministic.
+					sort.Strings(responseSchema.Required)
+
 					if len(resp.Fields) != 0 {
 						responseName := hyphenatedToTitleCase(operationID) + "Response"
+
+						// Operations may declare typed responses for several status
+						// codes, in which case all but the 200 response are suffixed
+						// with their status code, so that their schemas don't collide.
+						if code != 200 && responseCodesWithFields(props.Responses) > 1 {
+							responseName += strconv.Itoa(code)
+						}
 						doc.Components.Schemas[responseName] = responseSchema
 						content = OASContent{
 							"application/json": &OASMediaTypeObject{
@@ -644,12 +638,20 @@ func documentPath(p *Path, backend *Backend, requestResponsePrefix string, doc *
 }
 
 func addFieldToOASSchema(s *OASSchema, name string, field *FieldSchema) {
-	openapiField := convertType(field.Type)
 	if field.Required {
 		s.Required = append(s.Required, name)
 	}
 
-	p := OASSchema{
+	s.Properties[name] = convertField(field)
+}
+
+// convertField returns the schema of a field. The properties declared by the
+// Fields of TypeMap and TypeSlice fields are converted recursively, into the
+// properties of the object or of the array items respectively.
+func convertField(field *FieldSchema) *OASSchema {
+	openapiField := convertType(field.Type)
+
+	p := &OASSchema{
 		Type:         openapiField.baseType,
 		Description:  cleanString(field.Description),
 		Format:       openapiField.format,
@@ -665,7 +667,38 @@ func addFieldToOASSchema(s *OASSchema, name string, field *FieldSchema) {
 		}
 	}
 
-	s.Properties[name] = &p
+	if len(field.Fields) > 0 {
+		// The properties belong to the array items for slices, and to the
+		// field itself for maps.
+		s := p
+		if p.Items != nil {
+			s = p.Items
+		}
+		if s.Type == "object" {
+			s.Properties = make(map[string]*OASSchema)
+			for name, f := range field.Fields {
+				addFieldToOASSchema(s, name, f)
+			}
+			sort.Strings(s.Required)
+		}
+	}
+
+	return p
+}
+
+// responseCodesWithFields returns the number of status codes for which
+// responses with fields are declared.
+func responseCodesWithFields(responses map[int][]Response) int {
+	var count int
+	for _, rs := range responses {
+		for _, r := range rs {
+			if len(r.Fields) != 0 {
+				count++
+				break
+			}
+		}
+	}
+	return count
 }
 
 // specialPathMatch checks whether the given path matches one of the special
@@ -584,6 +584,68 @@ func TestOpenAPI_Paths(t *testing.T) {
 
 		testPath(t, p, sp, expected("responses"))
 	})
+
+	t.Run("Responses - Typed", func(t *testing.T) {
+		p := &Path{
+			Pattern:         "foo",
+			HelpSynopsis:    "Synopsis",
+			HelpDescription: "Description",
+			Operations: map[logical.Operation]OperationHandler{
+				logical.UpdateOperation: &PathOperation{
+					Summary: "Create stuff",
+					Responses: map[int][]Response{
+						200: {{
+							Description: "OK",
+							Fields: map[string]*FieldSchema{
+								"name": {
+									Type:        TypeString,
+									Description: "the name",
+									Required:    true,
+								},
+								"owner": {
+									Type:        TypeMap,
+									Description: "the owner",
+									Fields: map[string]*FieldSchema{
+										"id": {
+											Type:     TypeString,
+											Required: true,
+										},
+										"email": {
+											Type: TypeString,
+										},
+									},
+								},
+								"versions": {
+									Type:        TypeSlice,
+									Description: "the versions",
+									Fields: map[string]*FieldSchema{
+										"version": {
+											Type:     TypeInt,
+											Required: true,
+										},
+										"created_time": {
+											Type: TypeTime,
+										},
+									},
+								},
+							},
+						}},
+						202: {{
+							Description: "Accepted",
+							Fields: map[string]*FieldSchema{
+								"request_id": {
+									Type:     TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+		}
+
+		testPath(t, p, &logical.Paths{}, expected("responses_typed"))
+	})
 }
 
 func TestOpenAPI_CustomDecoder(t *testing.T) {
@@ -412,6 +412,15 @@ func GenerateSerialNumberWithRandomSource(randReader io.Reader) (*big.Int, error
 	return generateSerialNumber(randReader)
 }
 
+// creationSerialNumber returns the serial number of the creation parameters,
+// or generates a random one if they have none.
+func creationSerialNumber(data *CreationBundle) (*big.Int, error) {
+	if data.Params.SerialNumber != nil {
+		return data.Params.SerialNumber, nil
+	}
+	return GenerateSerialNumber()
+}
+
 func generateSerialNumber(randReader io.Reader) (*big.Int, error) {
 	serial, err := rand.Int(randReader, (&big.Int{}).Exp(big.NewInt(2), big.NewInt(159), nil))
 	if err != nil {
@@ -880,7 +889,7 @@ func createCertificate(data *CreationBundle, randReader io.Reader, privateKeyGen
 	var err error
 	result := &ParsedCertBundle{}
 
-	serialNumber, err := GenerateSerialNumber()
+	serialNumber, err := creationSerialNumber(data)
 	if err != nil {
 		return nil, err
 	}
@@ -1179,7 +1188,7 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 
 	result := &ParsedCertBundle{}
 
-	serialNumber, err := GenerateSerialNumber()
+	serialNumber, err := creationSerialNumber(data)
 	if err != nil {
 		return nil, err
 	}
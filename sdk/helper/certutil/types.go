@@ -804,6 +804,9 @@ type CreationParameters struct {
 
 	// The explicit SKID to use; especially useful for cross-signing.
 	SKID []byte
+
+	// The serial number to use; a random one is generated when nil.
+	SerialNumber *big.Int
 }
 
 type CreationBundle struct {
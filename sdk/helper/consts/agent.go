@@ -13,3 +13,11 @@ const AgentPathMetrics = "/agent/v1/metrics"
 
 // AgentPathQuit is the path that the agent will use to trigger stopping it.
 const AgentPathQuit = "/agent/v1/quit"
+
+// AgentPathAWSCredentials is the path the agent will use to serve AWS
+// credentials in the format of the ECS container credentials endpoint.
+const AgentPathAWSCredentials = "/agent/v1/aws-credentials"
+
+// AgentPathAWSKMS is the path the agent will use to serve the operations of
+// the AWS KMS API backed by transit.
+const AgentPathAWSKMS = "/agent/v1/aws-kms"
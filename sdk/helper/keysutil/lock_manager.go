@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
@@ -74,6 +75,10 @@ type LockManager struct {
 	useCache bool
 	cache    Cache
 	keyLocks []*locksutil.LockEntry
+
+	// derivedKeyCacheSize is the size of the cache of derived keys of each
+	// cached policy, 0 if derived keys are not cached
+	derivedKeyCacheSize int
 }
 
 func NewLockManager(useCache bool, cacheSize int) (*LockManager, error) {
@@ -137,6 +142,47 @@ func (lm *LockManager) InitCache(cacheSize int) error {
 	return nil
 }
 
+// GetDerivedKeyCacheSize returns the size of the cache of derived keys of
+// each cached policy, 0 if derived keys are not cached.
+func (lm *LockManager) GetDerivedKeyCacheSize() int {
+	if !lm.useCache {
+		return 0
+	}
+	return lm.derivedKeyCacheSize
+}
+
+// SetDerivedKeyCacheSize sets the size of the cache of derived keys of each
+// cached policy, which saves deriving the keys of derived and convergent
+// policies again for each request using the same context. A size of 0
+// disables the cache. As derived key caches are set up when policies are
+// cached, the policy cache is reset if the size changes.
+func (lm *LockManager) SetDerivedKeyCacheSize(size int) error {
+	if !lm.useCache {
+		return nil
+	}
+	if size < 0 {
+		return errors.New("derived key cache size must be greater or equal to zero")
+	}
+	if size == lm.derivedKeyCacheSize {
+		return nil
+	}
+
+	lm.derivedKeyCacheSize = size
+	return lm.InitCache(lm.GetCacheSize())
+}
+
+// storeInCache stores the policy in the cache, with a cache of derived keys
+// if enabled. Callers must check that the cache is in use.
+func (lm *LockManager) storeInCache(name string, p *Policy) {
+	if lm.derivedKeyCacheSize > 0 && p.Derived && p.derivedKeys == nil {
+		derivedKeys, err := NewTransitLRU(lm.derivedKeyCacheSize)
+		if err == nil {
+			p.derivedKeys = derivedKeys
+		}
+	}
+	lm.cache.Store(name, p)
+}
+
 // RestorePolicy acquires an exclusive lock on the policy name and restores the
 // given policy along with the archive.
 func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storage, name, backup string, force bool) error {
@@ -234,7 +280,7 @@ func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storag
 
 	// Update the cache to contain the restored policy
 	if lm.useCache {
-		lm.cache.Store(name, keyData.Policy)
+		lm.storeInCache(name, keyData.Policy)
 	}
 	return nil
 }
@@ -293,6 +339,11 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 	// Check if it's in our cache. If so, return right away.
 	if lm.useCache {
 		pRaw, ok = lm.cache.Load(req.Name)
+		if ok {
+			metrics.IncrCounter([]string{"secrets", "transit", "key_cache", "hit"}, 1)
+		} else {
+			metrics.IncrCounter([]string{"secrets", "transit", "key_cache", "miss"}, 1)
+		}
 	}
 	if ok {
 		p = pRaw.(*Policy)
@@ -438,7 +489,7 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 		}
 
 		if lm.useCache {
-			lm.cache.Store(req.Name, p)
+			lm.storeInCache(req.Name, p)
 		} else {
 			p.l = &lock.RWMutex
 			p.writeLocked = true
@@ -459,7 +510,7 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 	}
 
 	if lm.useCache {
-		lm.cache.Store(req.Name, p)
+		lm.storeInCache(req.Name, p)
 	} else {
 		p.l = &lock.RWMutex
 		p.writeLocked = true
@@ -520,7 +571,7 @@ func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key
 	}
 
 	if lm.useCache {
-		lm.cache.Store(req.Name, p)
+		lm.storeInCache(req.Name, p)
 	}
 
 	return nil
@@ -36,6 +36,7 @@ import (
 	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/hkdf"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
@@ -389,6 +390,9 @@ type Policy struct {
 	// that may write data, e.g. if one request rotates and that request is
 	// served after a delete.
 	deleted uint32
+	// derivedKeys caches the keys derived from the key versions, if enabled
+	// by the lock manager, see LockManager.SetDerivedKeyCacheSize.
+	derivedKeys *TransitLRU
 
 	Name    string      `json:"name"`
 	Key     []byte      `json:"key,omitempty"`      // DEPRECATED
@@ -622,6 +626,11 @@ func (p *Policy) Persist(ctx context.Context, storage logical.Storage) (retErr e
 		return errors.New("key has been deleted, not persisting")
 	}
 
+	// Key versions may have changed, so derive keys anew
+	if p.derivedKeys != nil {
+		defer p.derivedKeys.Purge()
+	}
+
 	// Other functions will take care of restoring other values; this is just
 	// responsible for archiving and keys since the archive function can modify
 	// keys. At the moment one of the other functions calling persist will also
@@ -831,6 +840,40 @@ func (p *Policy) DeriveKey(context, salt []byte, ver int, numBytes int) ([]byte,
 		return nil, err
 	}
 
+	if p.derivedKeys == nil {
+		return p.deriveKey(keyEntry, context, salt, numBytes)
+	}
+
+	cacheKey := derivedKeyCacheKey{
+		version:  ver,
+		numBytes: numBytes,
+		context:  string(context),
+		salt:     string(salt),
+	}
+	if derived, ok := p.derivedKeys.Load(cacheKey); ok {
+		metrics.IncrCounter([]string{"secrets", "transit", "derived_key_cache", "hit"}, 1)
+		return append([]byte(nil), derived.([]byte)...), nil
+	}
+	metrics.IncrCounter([]string{"secrets", "transit", "derived_key_cache", "miss"}, 1)
+
+	derived, err := p.deriveKey(keyEntry, context, salt, numBytes)
+	if err != nil {
+		return nil, err
+	}
+	p.derivedKeys.Store(cacheKey, append([]byte(nil), derived...))
+	return derived, nil
+}
+
+// derivedKeyCacheKey identifies a derived key in the cache of the policy
+type derivedKeyCacheKey struct {
+	version  int
+	numBytes int
+	context  string
+	salt     string
+}
+
+// deriveKey derives a key from the key entry, see DeriveKey
+func (p *Policy) deriveKey(keyEntry KeyEntry, context, salt []byte, numBytes int) ([]byte, error) {
 	switch p.KDF {
 	case Kdf_hmac_sha256_counter:
 		prf := kdf.HMACSHA256PRF
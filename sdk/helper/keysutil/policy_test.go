@@ -1067,3 +1067,89 @@ func isUnsupportedGoHashType(hashType HashType, err error) bool {
 
 	return false
 }
+
+func Test_DerivedKeyCache(t *testing.T) {
+	ctx := context.Background()
+	lm, _ := NewLockManager(true, 0)
+	if err := lm.SetDerivedKeyCacheSize(10); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &logical.InmemStorage{}
+	p, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:  true,
+		Storage: storage,
+		KeyType: KeyType_AES256_GCM96,
+		Name:    "test",
+		Derived: true,
+	}, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.derivedKeys == nil {
+		t.Fatal("expected a derived key cache")
+	}
+
+	context1, context2 := []byte("context1"), []byte("context2")
+	key1, err := p.GetKey(context1, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.derivedKeys.Size() != 10 {
+		t.Fatalf("bad derived key cache size: %d", p.derivedKeys.Size())
+	}
+	if _, ok := p.derivedKeys.Load(derivedKeyCacheKey{version: 1, numBytes: 32, context: string(context1)}); !ok {
+		t.Fatal("expected the derived key to be cached")
+	}
+
+	// Cached keys must be returned as copies
+	key1[0] ^= 0xff
+	key1Again, err := p.GetKey(context1, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1[0] ^= 0xff
+	if !bytes.Equal(key1, key1Again) {
+		t.Fatal("expected the same derived key")
+	}
+
+	key2, err := p.GetKey(context2, 1, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key1, key2) {
+		t.Fatal("expected different derived keys for different contexts")
+	}
+
+	// Derived keys must match those derived without the cache
+	key1Uncached, err := p.deriveKey(p.Keys["1"], context1, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key1, key1Uncached) {
+		t.Fatal("expected the cached derived key to match the uncached one")
+	}
+
+	// Persisting the policy purges the cache
+	if err := p.Rotate(ctx, storage, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.derivedKeys.Load(derivedKeyCacheKey{version: 1, numBytes: 32, context: string(context1)}); ok {
+		t.Fatal("expected the derived key cache to be purged")
+	}
+
+	// Changing the size resets the policy cache
+	if err := lm.SetDerivedKeyCacheSize(0); err != nil {
+		t.Fatal(err)
+	}
+	p, _, err = lm.GetPolicy(ctx, PolicyRequest{
+		Storage: storage,
+		Name:    "test",
+	}, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.derivedKeys != nil {
+		t.Fatal("expected no derived key cache")
+	}
+}
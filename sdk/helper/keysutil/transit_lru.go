@@ -30,3 +30,7 @@ func (c *TransitLRU) Store(key, value interface{}) {
 func (c *TransitLRU) Size() int {
 	return c.size
 }
+
+func (c *TransitLRU) Purge() {
+	c.lru.Purge()
+}
@@ -13,4 +13,12 @@ type PluginRuntimeConfig struct {
 	CgroupParent string                   `json:"cgroup_parent" structs:"cgroup_parent"`
 	CPU          int64                    `json:"cpu" structs:"cpu"`
 	Memory       int64                    `json:"memory" structs:"memory"`
+
+	// DisableNetwork runs the containers without a network stack, for plugins
+	// which need no network access beyond their connection to Vault
+	DisableNetwork bool `json:"disable_network" structs:"disable_network"`
+
+	// Networks are the container networks to attach the containers to instead
+	// of the default one, which allows limiting the hosts they can reach
+	Networks []string `json:"networks" structs:"networks"`
 }
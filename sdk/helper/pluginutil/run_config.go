@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/docker/docker/api/types/network"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/go-secure-stdlib/plugincontainer"
@@ -188,6 +189,13 @@ func (rc runConfig) containerConfig(ctx context.Context, env []string) (*pluginc
 		if rc.runtimeConfig.OCIRuntime != "" {
 			cfg.Runtime = rc.runtimeConfig.OCIRuntime
 		}
+		cfg.DisableNetwork = rc.runtimeConfig.DisableNetwork
+		if len(rc.runtimeConfig.Networks) > 0 {
+			cfg.EndpointsConfig = make(map[string]*network.EndpointSettings, len(rc.runtimeConfig.Networks))
+			for _, name := range rc.runtimeConfig.Networks {
+				cfg.EndpointsConfig[name] = &network.EndpointSettings{}
+			}
+		}
 	}
 
 	return cfg, nil
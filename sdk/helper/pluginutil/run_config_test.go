@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types/network"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/go-secure-stdlib/plugincontainer"
@@ -547,6 +548,83 @@ func TestContainerConfig(t *testing.T) {
 				Memory:       2000,
 			},
 		},
+		"image set, with network disabled": {
+			rc: runConfig{
+				sha256:   dummySHA,
+				image:    "some-image",
+				imageTag: "0.1.0",
+				runtimeConfig: &pluginruntimeutil.PluginRuntimeConfig{
+					DisableNetwork: true,
+				},
+				PluginClientConfig: PluginClientConfig{
+					Logger:     hclog.NewNullLogger(),
+					AutoMTLS:   true,
+					Name:       "some-plugin",
+					PluginType: consts.PluginTypeCredential,
+					Version:    "v0.1.0",
+				},
+			},
+			expected: plugincontainer.Config{
+				Image:  "some-image",
+				Tag:    "0.1.0",
+				SHA256: "abc123",
+				Env: []string{
+					fmt.Sprintf("%s=%s", PluginVaultVersionEnv, "dummyversion"),
+					fmt.Sprintf("%s=%t", PluginMetadataModeEnv, false),
+					fmt.Sprintf("%s=%t", PluginAutoMTLSEnv, true),
+				},
+				Labels: map[string]string{
+					labelVaultPID:           myPID,
+					labelVaultClusterID:     "1234",
+					labelVaultPluginName:    "some-plugin",
+					labelVaultPluginType:    "auth",
+					labelVaultPluginVersion: "v0.1.0",
+				},
+				Runtime:        consts.DefaultContainerPluginOCIRuntime,
+				GroupAdd:       os.Getgid(),
+				DisableNetwork: true,
+			},
+		},
+		"image set, with networks": {
+			rc: runConfig{
+				sha256:   dummySHA,
+				image:    "some-image",
+				imageTag: "0.1.0",
+				runtimeConfig: &pluginruntimeutil.PluginRuntimeConfig{
+					Networks: []string{"db-net", "egress-net"},
+				},
+				PluginClientConfig: PluginClientConfig{
+					Logger:     hclog.NewNullLogger(),
+					AutoMTLS:   true,
+					Name:       "some-plugin",
+					PluginType: consts.PluginTypeCredential,
+					Version:    "v0.1.0",
+				},
+			},
+			expected: plugincontainer.Config{
+				Image:  "some-image",
+				Tag:    "0.1.0",
+				SHA256: "abc123",
+				Env: []string{
+					fmt.Sprintf("%s=%s", PluginVaultVersionEnv, "dummyversion"),
+					fmt.Sprintf("%s=%t", PluginMetadataModeEnv, false),
+					fmt.Sprintf("%s=%t", PluginAutoMTLSEnv, true),
+				},
+				Labels: map[string]string{
+					labelVaultPID:           myPID,
+					labelVaultClusterID:     "1234",
+					labelVaultPluginName:    "some-plugin",
+					labelVaultPluginType:    "auth",
+					labelVaultPluginVersion: "v0.1.0",
+				},
+				Runtime:  consts.DefaultContainerPluginOCIRuntime,
+				GroupAdd: os.Getgid(),
+				EndpointsConfig: map[string]*network.EndpointSettings{
+					"db-net":     {},
+					"egress-net": {},
+				},
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			mockWrapper := new(mockRunnerUtil)
@@ -44,6 +44,32 @@ func (s *LogicalStorage) List(ctx context.Context, prefix string) ([]string, err
 	return s.underlying.List(ctx, prefix)
 }
 
+// ListPage lists a page of the keys under the prefix, without listing all of
+// them if the underlying backend supports it.
+func (s *LogicalStorage) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, s.underlying, prefix, after, limit)
+}
+
+// Transaction performs the writes in a single transaction if the underlying
+// backend supports transactions, and one after the other otherwise.
+func (s *LogicalStorage) Transaction(ctx context.Context, txns []*TxnEntry) error {
+	txnBackend, ok := s.underlying.(physical.Transactional)
+	if !ok {
+		for _, txn := range txns {
+			if err := applyTxnEntry(ctx, s, txn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pTxns, err := physicalTxnEntries(txns)
+	if err != nil {
+		return err
+	}
+	return txnBackend.Transaction(ctx, pTxns)
+}
+
 func (s *LogicalStorage) Underlying() physical.Backend {
 	return s.underlying
 }
@@ -172,6 +172,10 @@ type Request struct {
 	// mountClass is used internally to propagate the mount class of the mounted plugin to audit logging
 	mountClass string
 
+	// admissionAnnotations is used internally to propagate the annotations set
+	// by the admission handlers of the request to audit logging
+	admissionAnnotations map[string]string
+
 	// WrapInfo contains requested response wrapping parameters
 	WrapInfo *RequestWrapInfo `json:"wrap_info" structs:"wrap_info" mapstructure:"wrap_info" sentinel:""`
 
@@ -334,6 +338,14 @@ func (r *Request) SetMountClass(mountClass string) {
 	r.mountClass = mountClass
 }
 
+func (r *Request) AdmissionAnnotations() map[string]string {
+	return r.admissionAnnotations
+}
+
+func (r *Request) SetAdmissionAnnotations(annotations map[string]string) {
+	r.admissionAnnotations = annotations
+}
+
 func (r *Request) LastRemoteWAL() uint64 {
 	return r.lastRemoteWAL
 }
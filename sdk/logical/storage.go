@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/physical"
 )
 
 // ErrReadOnly is returned when a backend does not support
@@ -36,6 +37,105 @@ type Storage interface {
 	Delete(context.Context, string) error
 }
 
+// PaginatedStorage is an optional interface for storage which can list a
+// page of the keys under a prefix, without listing all of them when the
+// underlying physical backend supports it.
+type PaginatedStorage interface {
+	Storage
+	ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error)
+}
+
+// ListPage lists the keys under the prefix which sort after the given key, in
+// lexicographic order. At most limit keys are returned, or all of them if
+// limit is not positive. If the storage doesn't implement PaginatedStorage,
+// all the keys are listed and the page is taken from them.
+func ListPage(ctx context.Context, s Storage, prefix string, after string, limit int) ([]string, error) {
+	if paginated, ok := s.(PaginatedStorage); ok {
+		return paginated.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := s.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return physical.PaginateKeys(keys, after, limit), nil
+}
+
+// TxnEntry is a write performed as part of a storage transaction. Only
+// physical.PutOperation and physical.DeleteOperation are supported; the Value
+// of the entry is ignored for deletions.
+type TxnEntry struct {
+	Operation physical.Operation
+	Entry     *StorageEntry
+}
+
+// TransactionalStorage is an optional interface for storage which can
+// perform several writes atomically, in a single round trip to the underlying
+// physical backend, when that backend supports transactions.
+type TransactionalStorage interface {
+	Storage
+	Transaction(context.Context, []*TxnEntry) error
+}
+
+// StorageTransaction performs the writes as a single transaction if the
+// storage implements TransactionalStorage. Otherwise, the writes are performed
+// one after the other, in order, stopping at the first error.
+func StorageTransaction(ctx context.Context, s Storage, txns []*TxnEntry) error {
+	if txnStorage, ok := s.(TransactionalStorage); ok {
+		return txnStorage.Transaction(ctx, txns)
+	}
+
+	for _, txn := range txns {
+		if err := applyTxnEntry(ctx, s, txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTxnEntry performs a single write of a transaction on the storage.
+func applyTxnEntry(ctx context.Context, s Storage, txn *TxnEntry) error {
+	if txn == nil || txn.Entry == nil {
+		return errors.New("cannot write nil entry")
+	}
+
+	switch txn.Operation {
+	case physical.PutOperation:
+		return s.Put(ctx, txn.Entry)
+	case physical.DeleteOperation:
+		return s.Delete(ctx, txn.Entry.Key)
+	default:
+		return fmt.Errorf("unsupported transaction operation %q", txn.Operation)
+	}
+}
+
+// physicalTxnEntries converts the writes of a transaction to the entries of a
+// physical transaction.
+func physicalTxnEntries(txns []*TxnEntry) ([]*physical.TxnEntry, error) {
+	pTxns := make([]*physical.TxnEntry, 0, len(txns))
+	for _, txn := range txns {
+		if txn == nil || txn.Entry == nil {
+			return nil, errors.New("cannot write nil entry")
+		}
+
+		switch txn.Operation {
+		case physical.PutOperation, physical.DeleteOperation:
+		default:
+			return nil, fmt.Errorf("unsupported transaction operation %q", txn.Operation)
+		}
+
+		pTxns = append(pTxns, &physical.TxnEntry{
+			Operation: txn.Operation,
+			Entry: &physical.Entry{
+				Key:      txn.Entry.Key,
+				Value:    txn.Entry.Value,
+				SealWrap: txn.Entry.SealWrap,
+			},
+		})
+	}
+	return pTxns, nil
+}
+
 // StorageEntry is the entry for an item in a Storage implementation.
 type StorageEntry struct {
 	Key      string
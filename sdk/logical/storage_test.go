@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/go-test/deep"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
 )
 
 var keyList = []string{
@@ -102,3 +104,80 @@ func prepKeyStorage(t *testing.T) Storage {
 
 	return s
 }
+
+func TestStorageTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	backend, err := inmem.NewTransactionalInmem(map[string]string{"max_value_size": "8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewLogicalStorage(backend)
+	view := NewStorageView(s, "prefix/")
+
+	if err := s.Put(ctx, &StorageEntry{Key: "prefix/old", Value: []byte("old")}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = StorageTransaction(ctx, view, []*TxnEntry{
+		{Operation: physical.PutOperation, Entry: &StorageEntry{Key: "a", Value: []byte("a")}},
+		{Operation: physical.DeleteOperation, Entry: &StorageEntry{Key: "old"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := s.List(ctx, "prefix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(keys, []string{"a"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// A failed write rolls back the whole transaction
+	err = StorageTransaction(ctx, view, []*TxnEntry{
+		{Operation: physical.PutOperation, Entry: &StorageEntry{Key: "b", Value: []byte("b")}},
+		{Operation: physical.PutOperation, Entry: &StorageEntry{Key: "c", Value: []byte("too large for the backend")}},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	entry, err := view.Get(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Fatalf("expected the transaction to be rolled back, got %#v", entry)
+	}
+}
+
+func TestStorageTransaction_NotTransactional(t *testing.T) {
+	ctx := context.Background()
+	s := new(InmemStorage)
+
+	err := StorageTransaction(ctx, NewStorageView(s, "prefix/"), []*TxnEntry{
+		{Operation: physical.PutOperation, Entry: &StorageEntry{Key: "a", Value: []byte("a")}},
+		{Operation: physical.PutOperation, Entry: &StorageEntry{Key: "b", Value: []byte("b")}},
+		{Operation: physical.DeleteOperation, Entry: &StorageEntry{Key: "a"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := s.List(ctx, "prefix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(keys, []string{"b"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	err = StorageTransaction(ctx, s, []*TxnEntry{
+		{Operation: physical.GetOperation, Entry: &StorageEntry{Key: "b"}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported operation")
+	}
+}
@@ -84,6 +84,39 @@ func (s *StorageView) Delete(ctx context.Context, key string) error {
 	return s.storage.Delete(ctx, expandedKey)
 }
 
+// ListPage lists a page of the keys under the prefix, see ListPage.
+func (s *StorageView) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	if err := s.SanityCheck(prefix); err != nil {
+		return nil, err
+	}
+	return ListPage(ctx, s.storage, s.ExpandKey(prefix), after, limit)
+}
+
+// Transaction performs the writes atomically if the underlying storage
+// supports transactions, see StorageTransaction.
+func (s *StorageView) Transaction(ctx context.Context, txns []*TxnEntry) error {
+	nested := make([]*TxnEntry, 0, len(txns))
+	for _, txn := range txns {
+		if txn == nil || txn.Entry == nil {
+			return errors.New("cannot write nil entry")
+		}
+		if err := s.SanityCheck(txn.Entry.Key); err != nil {
+			return err
+		}
+
+		nested = append(nested, &TxnEntry{
+			Operation: txn.Operation,
+			Entry: &StorageEntry{
+				Key:      s.ExpandKey(txn.Entry.Key),
+				Value:    txn.Entry.Value,
+				SealWrap: txn.Entry.SealWrap,
+			},
+		})
+	}
+
+	return StorageTransaction(ctx, s.storage, nested)
+}
+
 func (s *StorageView) Prefix() string {
 	return s.prefix
 }
@@ -225,6 +225,11 @@ func (c *Cache) List(ctx context.Context, prefix string) ([]string, error) {
 	return c.backend.List(ctx, prefix)
 }
 
+// ListPage passes through to the underlying backend, like List.
+func (c *Cache) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return ListPage(ctx, c.backend, prefix, after, limit)
+}
+
 func (c *TransactionalCache) Locks() []*locksutil.LockEntry {
 	return c.locks
 }
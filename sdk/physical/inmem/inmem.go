@@ -24,6 +24,7 @@ var (
 	_ physical.HABackend     = (*InmemHABackend)(nil)
 	_ physical.HABackend     = (*TransactionalInmemHABackend)(nil)
 	_ physical.Lock          = (*InmemLock)(nil)
+	_ physical.Paginated     = (*InmemBackend)(nil)
 	_ physical.Transactional = (*TransactionalInmemBackend)(nil)
 	_ physical.Transactional = (*TransactionalInmemHABackend)(nil)
 )
@@ -252,28 +253,49 @@ func (i *InmemBackend) List(ctx context.Context, prefix string) ([]string, error
 }
 
 func (i *InmemBackend) ListInternal(ctx context.Context, prefix string) ([]string, error) {
+	return i.listPageInternal(ctx, prefix, "", -1)
+}
+
+// ListPage is used to list a page of the keys under a prefix, without
+// collecting the keys which are not part of the page.
+func (i *InmemBackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	i.permitPool.Acquire()
+	defer i.permitPool.Release()
+
+	i.RLock()
+	defer i.RUnlock()
+
+	return i.listPageInternal(ctx, prefix, after, limit)
+}
+
+func (i *InmemBackend) listPageInternal(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
 	if i.logOps {
-		i.logger.Trace("list", "prefix", prefix)
+		i.logger.Trace("list", "prefix", prefix, "after", after, "limit", limit)
 	}
 	if atomic.LoadUint32(i.failList) != 0 {
 		return nil, ListDisabledError
 	}
 
+	// The tree is walked in lexicographic order, so the keys are found in
+	// order, and the walk can stop once the page is full.
 	var out []string
 	seen := make(map[string]interface{})
 	walkFn := func(s string, v interface{}) bool {
 		trimmed := strings.TrimPrefix(s, prefix)
 		sep := strings.Index(trimmed, "/")
-		if sep == -1 {
-			out = append(out, trimmed)
-		} else {
+		if sep != -1 {
 			trimmed = trimmed[:sep+1]
-			if _, ok := seen[trimmed]; !ok {
-				out = append(out, trimmed)
-				seen[trimmed] = struct{}{}
+			if _, ok := seen[trimmed]; ok {
+				return false
 			}
+			seen[trimmed] = struct{}{}
+		}
+		if after != "" && trimmed <= after {
+			return false
 		}
-		return false
+
+		out = append(out, trimmed)
+		return limit > 0 && len(out) >= limit
 	}
 	i.root.WalkPrefix(prefix, walkFn)
 
@@ -4,6 +4,8 @@
 package inmem
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
 	log "github.com/hashicorp/go-hclog"
@@ -21,3 +23,52 @@ func TestInmem(t *testing.T) {
 	physical.ExerciseBackend(t, inm)
 	physical.ExerciseBackend_ListPrefix(t, inm)
 }
+
+func TestInmem_ListPage(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, key := range []string{"foo/a", "foo/b/c", "foo/b/d", "foo/b-c", "foo/c", "foo/d/e", "foo0", "bar"} {
+		if err := inm.Put(ctx, &physical.Entry{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := inm.List(ctx, "foo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		after    string
+		limit    int
+		expected []string
+	}{
+		{"", 0, []string{"a", "b-c", "b/", "c", "d/"}},
+		{"", 2, []string{"a", "b-c"}},
+		{"b-c", 2, []string{"b/", "c"}},
+		{"b/", 0, []string{"c", "d/"}},
+		{"bb", 1, []string{"c"}},
+		{"d/", 2, nil},
+	}
+	for _, tc := range cases {
+		keys, err := inm.(physical.Paginated).ListPage(ctx, "foo/", tc.after, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(keys, tc.expected) {
+			t.Fatalf("after %q, limit %d: expected %v, got %v", tc.after, tc.limit, tc.expected, keys)
+		}
+
+		// Listing all the keys and paginating them gives the same page
+		paginated := physical.PaginateKeys(append([]string(nil), all...), tc.after, tc.limit)
+		if len(paginated) != 0 && !reflect.DeepEqual(keys, paginated) {
+			t.Fatalf("after %q, limit %d: expected %v, got %v", tc.after, tc.limit, paginated, keys)
+		}
+	}
+}
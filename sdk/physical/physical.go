@@ -5,6 +5,7 @@ package physical
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	log "github.com/hashicorp/go-hclog"
@@ -48,6 +49,47 @@ type Backend interface {
 	List(ctx context.Context, prefix string) ([]string, error)
 }
 
+// Paginated is an optional interface for backends which can list a page of
+// the keys under a prefix without listing all of them.
+type Paginated interface {
+	// ListPage is used to list the keys under a given prefix, up to the next
+	// prefix, which sort after the given key, in lexicographic order. At most
+	// limit keys are returned, or all of them if limit is not positive.
+	ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error)
+}
+
+// ListPage lists a page of the keys under the prefix, see Paginated. If the
+// backend doesn't implement Paginated, all the keys are listed and the page is
+// taken from them.
+func ListPage(ctx context.Context, b Backend, prefix string, after string, limit int) ([]string, error) {
+	if paginated, ok := b.(Paginated); ok {
+		return paginated.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return PaginateKeys(keys, after, limit), nil
+}
+
+// PaginateKeys sorts the keys and returns those which sort after the given
+// key, at most limit of them if limit is positive.
+func PaginateKeys(keys []string, after string, limit int) []string {
+	sort.Strings(keys)
+
+	start := sort.SearchStrings(keys, after)
+	if start < len(keys) && keys[start] == after {
+		start++
+	}
+	keys = keys[start:]
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 // HABackend is an extensions to the standard physical
 // backend to support high-availability. Vault only expects to
 // use mutual exclusion to allow multiple instances to act as a
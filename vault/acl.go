@@ -751,6 +751,12 @@ func (c *Core) performPolicyChecks(ctx context.Context, acl *ACL, te *logical.To
 
 	c.performEntPolicyChecks(ctx, acl, te, req, inEntity, opts, ret)
 
+	// Evaluate the external OPA policy last, so that it is only queried for
+	// requests the other policies allow.
+	if ret.Allowed && !opts.Unauth {
+		c.performOPAPolicyCheck(ctx, te, req, inEntity, ret)
+	}
+
 	return ret
 }
 
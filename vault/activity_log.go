@@ -2873,6 +2873,7 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 	a.logger.Info("starting activity log export", "start_time", startTime, "end_time", endTime, "format", format)
 
 	dedupedIds := make(map[string]struct{})
+	namespaces := make(map[string]*namespace.Namespace)
 
 	walkEntities := func(l *activity.EntityActivityLog, startTime time.Time, hll *hyperloglog.Sketch) error {
 		for _, e := range l.Clients {
@@ -2881,7 +2882,11 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 			}
 
 			dedupedIds[e.ClientID] = struct{}{}
-			err := encoder.Encode(e)
+			record, err := a.exportRecord(ctx, e, namespaces)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(record)
 			if err != nil {
 				return err
 			}
@@ -2911,8 +2916,84 @@ func (a *ActivityLog) writeExport(ctx context.Context, rw http.ResponseWriter, f
 	return nil
 }
 
+// activityExportRecord is a client in the activity export, along with the
+// details of its namespace, mount and entity.
+type activityExportRecord struct {
+	ClientID        string `json:"client_id,omitempty"`
+	NamespaceID     string `json:"namespace_id,omitempty"`
+	Timestamp       int64  `json:"timestamp,omitempty"`
+	NonEntity       bool   `json:"non_entity,omitempty"`
+	MountAccessor   string `json:"mount_accessor,omitempty"`
+	ClientType      string `json:"client_type,omitempty"`
+	NamespacePath   string `json:"namespace_path,omitempty"`
+	MountPath       string `json:"mount_path,omitempty"`
+	MountType       string `json:"mount_type,omitempty"`
+	EntityName      string `json:"entity_name,omitempty"`
+	EntityAliasName string `json:"entity_alias_name,omitempty"`
+}
+
+// exportRecord resolves the namespace, mount and entity details of a client.
+// Details of namespaces, mounts and entities which no longer exist are left
+// empty. The namespaces map caches namespace lookups across clients.
+func (a *ActivityLog) exportRecord(ctx context.Context, e *activity.EntityRecord, namespaces map[string]*namespace.Namespace) (*activityExportRecord, error) {
+	record := &activityExportRecord{
+		ClientID:      e.ClientID,
+		NamespaceID:   e.NamespaceID,
+		Timestamp:     e.Timestamp,
+		NonEntity:     e.NonEntity,
+		MountAccessor: e.MountAccessor,
+		ClientType:    e.ClientType,
+	}
+
+	// Records written before the client type was tracked are either entities
+	// or non-entity tokens
+	if record.ClientType == "" {
+		record.ClientType = entityActivityType
+		if e.NonEntity {
+			record.ClientType = nonEntityTokenActivityType
+		}
+	}
+
+	ns, ok := namespaces[e.NamespaceID]
+	if !ok {
+		var err error
+		ns, err = NamespaceByID(ctx, e.NamespaceID, a.core)
+		if err != nil {
+			// The namespace has been deleted
+			ns = nil
+		}
+		namespaces[e.NamespaceID] = ns
+	}
+	if ns != nil {
+		record.NamespacePath = ns.Path
+	}
+
+	if mount := a.core.router.ValidateMountByAccessor(e.MountAccessor); mount != nil {
+		record.MountPath = mount.MountPath
+		record.MountType = mount.MountType
+	}
+
+	if record.ClientType == entityActivityType && a.core.identityStore != nil {
+		entity, err := a.core.identityStore.MemDBEntityByID(e.ClientID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil {
+			record.EntityName = entity.Name
+			for _, alias := range entity.Aliases {
+				if alias.MountAccessor == e.MountAccessor {
+					record.EntityAliasName = alias.Name
+					break
+				}
+			}
+		}
+	}
+
+	return record, nil
+}
+
 type encoder interface {
-	Encode(*activity.EntityRecord) error
+	Encode(*activityExportRecord) error
 	Flush()
 	Error() error
 }
@@ -2929,7 +3010,7 @@ func newJSONEncoder(w io.Writer) *jsonEncoder {
 	}
 }
 
-func (j *jsonEncoder) Encode(er *activity.EntityRecord) error {
+func (j *jsonEncoder) Encode(er *activityExportRecord) error {
 	return j.e.Encode(er)
 }
 
@@ -2954,6 +3035,12 @@ func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
 		"timestamp",
 		"non_entity",
 		"mount_accessor",
+		"client_type",
+		"namespace_path",
+		"mount_path",
+		"mount_type",
+		"entity_name",
+		"entity_alias_name",
 	})
 	if err != nil {
 		return nil, err
@@ -2966,12 +3053,18 @@ func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
 
 // Encode converts an export bundle into a set of strings and writes them to the
 // csv writer.
-func (c *csvEncoder) Encode(e *activity.EntityRecord) error {
+func (c *csvEncoder) Encode(e *activityExportRecord) error {
 	return c.Writer.Write([]string{
 		e.ClientID,
 		e.NamespaceID,
 		fmt.Sprintf("%d", e.Timestamp),
 		fmt.Sprintf("%t", e.NonEntity),
 		e.MountAccessor,
+		e.ClientType,
+		e.NamespacePath,
+		e.MountPath,
+		e.MountType,
+		e.EntityName,
+		e.EntityAliasName,
 	})
 }
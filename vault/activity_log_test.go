@@ -2033,6 +2033,58 @@ func TestActivityLog_Export(t *testing.T) {
 	}
 }
 
+// TestActivityLog_ExportRecord verifies that exported clients include the
+// details of their namespace, mount and entity, and that the details of
+// deleted namespaces, mounts and entities are left empty.
+func TestActivityLog_ExportRecord(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	is, ghAccessor, _, core := testIdentityStoreWithGithubUserpassAuth(ctx, t)
+	a := core.activityLog
+
+	entity, _, err := is.CreateOrFetchEntity(ctx, &logical.Alias{
+		MountType:     "github",
+		MountAccessor: ghAccessor,
+		Name:          "githubuser",
+	})
+	require.NoError(t, err)
+
+	namespaces := make(map[string]*namespace.Namespace)
+	record, err := a.exportRecord(ctx, &activity.EntityRecord{
+		ClientID:      entity.ID,
+		NamespaceID:   namespace.RootNamespaceID,
+		Timestamp:     1,
+		MountAccessor: ghAccessor,
+	}, namespaces)
+	require.NoError(t, err)
+	require.Equal(t, &activityExportRecord{
+		ClientID:        entity.ID,
+		NamespaceID:     namespace.RootNamespaceID,
+		Timestamp:       1,
+		MountAccessor:   ghAccessor,
+		ClientType:      entityActivityType,
+		MountPath:       "auth/github/",
+		MountType:       "github",
+		EntityName:      entity.Name,
+		EntityAliasName: "githubuser",
+	}, record)
+
+	record, err = a.exportRecord(ctx, &activity.EntityRecord{
+		ClientID:      "client",
+		NamespaceID:   "deleted",
+		NonEntity:     true,
+		MountAccessor: "auth_deleted",
+	}, namespaces)
+	require.NoError(t, err)
+	require.Equal(t, &activityExportRecord{
+		ClientID:      "client",
+		NamespaceID:   "deleted",
+		NonEntity:     true,
+		MountAccessor: "auth_deleted",
+		ClientType:    nonEntityTokenActivityType,
+	}, record)
+	require.Contains(t, namespaces, "deleted")
+}
+
 type fakeResponseWriter struct {
 	buffer  *bytes.Buffer
 	headers http.Header
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 
@@ -45,6 +46,11 @@ const (
 	// NOTE: this is an undocumented and temporary feature flag, it should not
 	// be relied on to remain part of Vault for any subsequent releases.
 	featureFlagDisableEventLogger = "VAULT_AUDIT_DISABLE_EVENTLOGGER"
+
+	// auditFlushTimeout bounds the time spent flushing the entries buffered by
+	// asynchronous audit backends when sealing. The entries which could not be
+	// flushed are kept in their spool, and written after the next unseal.
+	auditFlushTimeout = 30 * time.Second
 )
 
 // loadAuditFailed if loading audit tables encounters an error
@@ -68,6 +74,23 @@ func (c *Core) generateAuditTestProbe() (*logical.LogInput, error) {
 	}, nil
 }
 
+// isAuditFallback reports whether the audit entry is configured as the
+// fallback device, which only logs entries no other device succeeded in
+// logging.
+func isAuditFallback(entry *MountEntry) (bool, error) {
+	raw, ok := entry.Options["fallback"]
+	if !ok {
+		return false, nil
+	}
+
+	fallback, err := parseutil.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse fallback option: %w", err)
+	}
+
+	return fallback, nil
+}
+
 // enableAudit is used to enable a new audit backend
 func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage bool) error {
 	// Ensure we end the path in a slash
@@ -84,6 +107,11 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 	c.auditLock.Lock()
 	defer c.auditLock.Unlock()
 
+	fallback, err := isAuditFallback(entry)
+	if err != nil {
+		return err
+	}
+
 	// Look for matching name
 	for _, ent := range c.audit.Entries {
 		switch {
@@ -94,6 +122,12 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 		case strings.HasPrefix(entry.Path, ent.Path):
 			return fmt.Errorf("path already in use")
 		}
+
+		if fallback {
+			if entFallback, _ := isAuditFallback(ent); entFallback {
+				return fmt.Errorf("fallback audit device already enabled at %q", ent.Path)
+			}
+		}
 	}
 
 	// Generate a new UUID and view
@@ -164,7 +198,11 @@ func (c *Core) enableAudit(ctx context.Context, entry *MountEntry, updateStorage
 	c.audit = newTable
 
 	// Register the backend
-	c.auditBroker.Register(entry.Path, backend, entry.Local)
+	if fallback {
+		c.auditBroker.RegisterFallback(entry.Path, backend, entry.Local)
+	} else {
+		c.auditBroker.Register(entry.Path, backend, entry.Local)
+	}
 	if c.logger.IsInfo() {
 		c.logger.Info("enabled audit backend", "path", entry.Path, "type", entry.Type)
 	}
@@ -401,6 +439,7 @@ func (c *Core) setupAudits(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	broker.tail = c.auditTail
 
 	c.auditLock.Lock()
 	defer c.auditLock.Unlock()
@@ -434,7 +473,14 @@ func (c *Core) setupAudits(ctx context.Context) error {
 		}
 
 		// Mount the backend
-		broker.Register(entry.Path, backend, entry.Local)
+		if fallback, _ := isAuditFallback(entry); fallback {
+			if err := broker.RegisterFallback(entry.Path, backend, entry.Local); err != nil {
+				c.logger.Error("failed to register fallback audit entry", "path", entry.Path, "error", err)
+				continue
+			}
+		} else {
+			broker.Register(entry.Path, backend, entry.Local)
+		}
 
 		successCount++
 	}
@@ -460,9 +506,17 @@ func (c *Core) teardownAudits() error {
 		}
 	}
 
+	var err error
+	if c.auditBroker != nil {
+		// Asynchronous backends flush their buffered entries when closed
+		ctx, cancel := context.WithTimeout(context.Background(), auditFlushTimeout)
+		defer cancel()
+		err = c.auditBroker.Close(ctx)
+	}
+
 	c.audit = nil
 	c.auditBroker = nil
-	return nil
+	return err
 }
 
 // removeAuditReloadFunc removes the reload func from the working set. The
@@ -500,12 +554,20 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 		return nil, fmt.Errorf("unable to parse feature flag: %q: %w", featureFlagDisableEventLogger, err)
 	}
 
+	// The cluster name is only informational, so a failure to look it up is
+	// not an error
+	var clusterName string
+	if cluster, err := c.Cluster(ctx); err == nil {
+		clusterName = cluster.Name
+	}
+
 	be, err := f(
 		ctx, &audit.BackendConfig{
-			SaltView:   view,
-			SaltConfig: saltConfig,
-			Config:     conf,
-			MountPath:  entry.Path,
+			SaltView:    view,
+			SaltConfig:  saltConfig,
+			Config:      conf,
+			MountPath:   entry.Path,
+			ClusterName: clusterName,
 		},
 		!disableEventLogger,
 		c.auditedHeaders)
@@ -545,6 +607,12 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 				auditLogger.Debug("socket backend options", "path", entry.Path, "address", entry.Options["address"], "socket type", entry.Options["socket_type"])
 			}
 		}
+	case "otlp":
+		if auditLogger.IsDebug() {
+			if entry.Options != nil {
+				auditLogger.Debug("otlp backend options", "path", entry.Path, "endpoint", entry.Options["endpoint"])
+			}
+		}
 	case "syslog":
 		if auditLogger.IsDebug() {
 			if entry.Options != nil {
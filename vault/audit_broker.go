@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/vault/internal/observability/event"
@@ -21,8 +22,9 @@ import (
 )
 
 type backendEntry struct {
-	backend audit.Backend
-	local   bool
+	backend  audit.Backend
+	local    bool
+	fallback bool
 }
 
 // AuditBroker is used to provide a single ingest interface to auditable
@@ -33,11 +35,26 @@ type AuditBroker struct {
 	logger   log.Logger
 
 	broker *eventlogger.Broker
+
+	// fallbackBroker holds the pipeline of the fallback backend, which only
+	// logs entries that no other backend succeeded in logging.
+	fallbackBroker *eventlogger.Broker
+	fallbackName   string
+
+	// consecutiveFailures counts the entries which no backend succeeded in
+	// logging since the last one which was logged, and lastFailure is the time
+	// of the last of them in unix nanoseconds. They are reported by sys/health.
+	consecutiveFailures atomic.Uint64
+	lastFailure         atomic.Int64
+
+	// tail receives a copy of every entry for the followers of
+	// sys/monitor/audit, independently of the registered backends.
+	tail *auditTail
 }
 
 // NewAuditBroker creates a new audit broker
 func NewAuditBroker(log log.Logger, useEventLogger bool) (*AuditBroker, error) {
-	var eventBroker *eventlogger.Broker
+	var eventBroker, fallbackBroker *eventlogger.Broker
 	var err error
 
 	if useEventLogger {
@@ -45,12 +62,18 @@ func NewAuditBroker(log log.Logger, useEventLogger bool) (*AuditBroker, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error creating event broker for audit events: %w", err)
 		}
+
+		fallbackBroker, err = eventlogger.NewBroker(eventlogger.WithNodeRegistrationPolicy(eventlogger.DenyOverwrite), eventlogger.WithPipelineRegistrationPolicy(eventlogger.DenyOverwrite))
+		if err != nil {
+			return nil, fmt.Errorf("error creating fallback event broker for audit events: %w", err)
+		}
 	}
 
 	b := &AuditBroker{
-		backends: make(map[string]backendEntry),
-		logger:   log,
-		broker:   eventBroker,
+		backends:       make(map[string]backendEntry),
+		logger:         log,
+		broker:         eventBroker,
+		fallbackBroker: fallbackBroker,
 	}
 	return b, nil
 }
@@ -66,7 +89,7 @@ func (a *AuditBroker) Register(name string, b audit.Backend, local bool) error {
 	}
 
 	if a.broker != nil {
-		err := a.broker.SetSuccessThresholdSinks(eventlogger.EventType(event.AuditType.String()), 1)
+		err := a.setSuccessThresholdSinks()
 		if err != nil {
 			return err
 		}
@@ -80,6 +103,44 @@ func (a *AuditBroker) Register(name string, b audit.Backend, local bool) error {
 	return nil
 }
 
+// RegisterFallback is used to add the fallback audit backend to the broker.
+// The fallback backend only logs the entries which no other backend succeeded
+// in logging, and there can only be one.
+func (a *AuditBroker) RegisterFallback(name string, b audit.Backend, local bool) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.fallbackName != "" {
+		return fmt.Errorf("fallback audit backend already registered at %q", a.fallbackName)
+	}
+
+	a.backends[name] = backendEntry{
+		backend:  b,
+		local:    local,
+		fallback: true,
+	}
+	a.fallbackName = name
+
+	if a.fallbackBroker != nil {
+		threshold := 1
+		if b.HasFiltering() {
+			threshold = 0
+		}
+
+		err := a.fallbackBroker.SetSuccessThresholdSinks(eventlogger.EventType(event.AuditType.String()), threshold)
+		if err != nil {
+			return err
+		}
+
+		err = b.RegisterNodesAndPipeline(a.fallbackBroker, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Deregister is used to remove an audit backend from the broker
 func (a *AuditBroker) Deregister(ctx context.Context, name string) error {
 	a.Lock()
@@ -88,11 +149,18 @@ func (a *AuditBroker) Deregister(ctx context.Context, name string) error {
 	// Remove the Backend from the map first, so that if an error occurs while
 	// removing the pipeline and nodes, we can quickly exit this method with
 	// the error.
+	be, ok := a.backends[name]
 	delete(a.backends, name)
 
-	if a.broker != nil {
-		if len(a.backends) == 0 {
-			err := a.broker.SetSuccessThresholdSinks(eventlogger.EventType(event.AuditType.String()), 0)
+	eventBroker := a.broker
+	if ok && be.fallback {
+		a.fallbackName = ""
+		eventBroker = a.fallbackBroker
+	}
+
+	if eventBroker != nil {
+		if eventBroker == a.broker {
+			err := a.setSuccessThresholdSinks()
 			if err != nil {
 				return err
 			}
@@ -102,7 +170,7 @@ func (a *AuditBroker) Deregister(ctx context.Context, name string) error {
 		// RemovePipelineAndNodes encountered the error while evaluating
 		// pre-conditions (false) or once it started removing the pipeline and
 		// the nodes (true). This code doesn't care either way.
-		_, err := a.broker.RemovePipelineAndNodes(ctx, eventlogger.EventType(event.AuditType.String()), eventlogger.PipelineID(name))
+		_, err := eventBroker.RemovePipelineAndNodes(ctx, eventlogger.EventType(event.AuditType.String()), eventlogger.PipelineID(name))
 		if err != nil {
 			return err
 		}
@@ -111,6 +179,51 @@ func (a *AuditBroker) Deregister(ctx context.Context, name string) error {
 	return nil
 }
 
+// Close removes the pipelines of all the backends, which closes their nodes,
+// so that asynchronous backends flush the entries they buffered before the
+// broker is discarded.
+func (a *AuditBroker) Close(ctx context.Context) error {
+	a.Lock()
+	defer a.Unlock()
+
+	var retErr *multierror.Error
+	for name, be := range a.backends {
+		eventBroker := a.broker
+		if be.fallback {
+			eventBroker = a.fallbackBroker
+		}
+		if eventBroker == nil {
+			continue
+		}
+
+		_, err := eventBroker.RemovePipelineAndNodes(ctx, eventlogger.EventType(event.AuditType.String()), eventlogger.PipelineID(name))
+		if err != nil {
+			retErr = multierror.Append(retErr, fmt.Errorf("failed to close audit backend %q: %w", name, err))
+		}
+	}
+
+	a.backends = make(map[string]backendEntry)
+	a.fallbackName = ""
+
+	return retErr.ErrorOrNil()
+}
+
+// setSuccessThresholdSinks requires audit entries to be logged by at least one
+// sink when any backend logs every entry. Backends with a filter may filter out
+// an entry, so they alone cannot be required to log it.
+// It should be called with the write lock held.
+func (a *AuditBroker) setSuccessThresholdSinks() error {
+	threshold := 0
+	for _, be := range a.backends {
+		if !be.fallback && !be.backend.HasFiltering() {
+			threshold = 1
+			break
+		}
+	}
+
+	return a.broker.SetSuccessThresholdSinks(eventlogger.EventType(event.AuditType.String()), threshold)
+}
+
 // IsRegistered is used to check if a given audit backend is registered
 func (a *AuditBroker) IsRegistered(name string) bool {
 	a.RLock()
@@ -175,6 +288,7 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 			failure = 1.0
 		}
 		metrics.IncrCounter([]string{"audit", "log_request_failure"}, failure)
+		a.recordResult(ret)
 	}()
 
 	headers := in.Request.Headers
@@ -182,16 +296,16 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 		in.Request.Headers = headers
 	}()
 
+	a.tail.publishRequest(ctx, in)
+
 	// Old behavior (no events)
 	if a.broker == nil {
-		// Ensure at least one backend logs
-		anyLogged := false
-		for name, be := range a.backends {
+		logged := func(name string, be backendEntry) bool {
 			in.Request.Headers = nil
 			transHeaders, thErr := headersConfig.ApplyConfig(ctx, headers, be.backend)
 			if thErr != nil {
 				a.logger.Error("backend failed to include headers", "backend", name, "error", thErr)
-				continue
+				return false
 			}
 			in.Request.Headers = transHeaders
 
@@ -200,10 +314,22 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 			metrics.MeasureSince([]string{"audit", name, "log_request"}, start)
 			if lrErr != nil {
 				a.logger.Error("backend failed to log request", "backend", name, "error", lrErr)
-			} else {
+				return false
+			}
+			return true
+		}
+
+		// Ensure at least one backend logs
+		anyLogged := false
+		for name, be := range a.backends {
+			if !be.fallback && logged(name, be) {
 				anyLogged = true
 			}
 		}
+		if !anyLogged && a.fallbackName != "" {
+			anyLogged = logged(a.fallbackName, a.backends[a.fallbackName])
+			measureAuditFallback(anyLogged)
+		}
 		if !anyLogged && len(a.backends) > 0 {
 			retErr = multierror.Append(retErr, fmt.Errorf("no audit backend succeeded in logging the request"))
 		}
@@ -216,9 +342,32 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 
 			e.Data = in
 
-			_, err = a.broker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
-			if err != nil {
-				retErr = multierror.Append(retErr, err)
+			primaries := len(a.backends)
+			if a.fallbackName != "" {
+				primaries--
+			}
+
+			var sendErr error
+			if primaries > 0 {
+				_, sendErr = a.broker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
+			}
+
+			// Only use the fallback backend when no other backend logged the request
+			if a.fallbackName != "" && (primaries == 0 || sendErr != nil) {
+				_, fallbackErr := a.fallbackBroker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
+				measureAuditFallback(fallbackErr == nil)
+				if fallbackErr != nil {
+					sendErr = multierror.Append(sendErr, fallbackErr)
+				} else {
+					if sendErr != nil {
+						a.logger.Warn("audit request logged by fallback backend", "backend", a.fallbackName, "error", sendErr)
+					}
+					sendErr = nil
+				}
+			}
+
+			if sendErr != nil {
+				retErr = multierror.Append(retErr, sendErr)
 			}
 		}
 	}
@@ -226,6 +375,26 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 	return retErr.ErrorOrNil()
 }
 
+// recordResult tracks the consecutive failures to log entries.
+func (a *AuditBroker) recordResult(err error) {
+	if err == nil {
+		a.consecutiveFailures.Store(0)
+		return
+	}
+	a.consecutiveFailures.Add(1)
+	a.lastFailure.Store(time.Now().UnixNano())
+}
+
+// failures returns the number of consecutive entries which failed to be
+// logged, and the time of the last failure if any.
+func (a *AuditBroker) failures() (uint64, time.Time) {
+	var last time.Time
+	if nanos := a.lastFailure.Load(); nanos != 0 {
+		last = time.Unix(0, nanos)
+	}
+	return a.consecutiveFailures.Load(), last
+}
+
 // LogResponse is used to ensure all the audit backends have an opportunity to
 // log the given response and that *at least one* succeeds.
 func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, headersConfig *AuditedHeadersConfig) (ret error) {
@@ -257,6 +426,7 @@ func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, hea
 			failure = 1.0
 		}
 		metrics.IncrCounter([]string{"audit", "log_response_failure"}, failure)
+		a.recordResult(ret)
 	}()
 
 	headers := in.Request.Headers
@@ -264,15 +434,16 @@ func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, hea
 		in.Request.Headers = headers
 	}()
 
+	a.tail.publishResponse(ctx, in)
+
 	// Ensure at least one backend logs
 	if a.broker == nil {
-		anyLogged := false
-		for name, be := range a.backends {
+		logged := func(name string, be backendEntry) bool {
 			in.Request.Headers = nil
 			transHeaders, thErr := headersConfig.ApplyConfig(ctx, headers, be.backend)
 			if thErr != nil {
 				a.logger.Error("backend failed to include headers", "backend", name, "error", thErr)
-				continue
+				return false
 			}
 			in.Request.Headers = transHeaders
 
@@ -281,10 +452,21 @@ func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, hea
 			metrics.MeasureSince([]string{"audit", name, "log_response"}, start)
 			if lrErr != nil {
 				a.logger.Error("backend failed to log response", "backend", name, "error", lrErr)
-			} else {
+				return false
+			}
+			return true
+		}
+
+		anyLogged := false
+		for name, be := range a.backends {
+			if !be.fallback && logged(name, be) {
 				anyLogged = true
 			}
 		}
+		if !anyLogged && a.fallbackName != "" {
+			anyLogged = logged(a.fallbackName, a.backends[a.fallbackName])
+			measureAuditFallback(anyLogged)
+		}
 		if !anyLogged && len(a.backends) > 0 {
 			retErr = multierror.Append(retErr, fmt.Errorf("no audit backend succeeded in logging the response"))
 		}
@@ -297,9 +479,32 @@ func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, hea
 
 			e.Data = in
 
-			_, err = a.broker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
-			if err != nil {
-				retErr = multierror.Append(retErr, err)
+			primaries := len(a.backends)
+			if a.fallbackName != "" {
+				primaries--
+			}
+
+			var sendErr error
+			if primaries > 0 {
+				_, sendErr = a.broker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
+			}
+
+			// Only use the fallback backend when no other backend logged the response
+			if a.fallbackName != "" && (primaries == 0 || sendErr != nil) {
+				_, fallbackErr := a.fallbackBroker.Send(ctx, eventlogger.EventType(event.AuditType.String()), e)
+				measureAuditFallback(fallbackErr == nil)
+				if fallbackErr != nil {
+					sendErr = multierror.Append(sendErr, fallbackErr)
+				} else {
+					if sendErr != nil {
+						a.logger.Warn("audit response logged by fallback backend", "backend", a.fallbackName, "error", sendErr)
+					}
+					sendErr = nil
+				}
+			}
+
+			if sendErr != nil {
+				retErr = multierror.Append(retErr, sendErr)
 			}
 		}
 	}
@@ -316,3 +521,13 @@ func (a *AuditBroker) Invalidate(ctx context.Context, key string) {
 		be.backend.Invalidate(ctx)
 	}
 }
+
+// measureAuditFallback records whether the fallback backend succeeded in
+// logging an entry no other backend logged.
+func measureAuditFallback(success bool) {
+	if success {
+		metrics.IncrCounter([]string{"audit", "fallback", "success"}, 1)
+	} else {
+		metrics.IncrCounter([]string{"audit", "fallback", "miss"}, 1)
+	}
+}
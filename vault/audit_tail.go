@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// auditTailBufferSize is the number of entries buffered for each follower of
+// the audit tail, after which entries are dropped.
+const auditTailBufferSize = 256
+
+// auditTail fans out the audit entries of the requests handled by this node
+// to the clients following them through sys/monitor/audit. The entries are
+// HMAC-redacted with a salt which only lives as long as the process, and they
+// are only formatted while the tail is followed.
+type auditTail struct {
+	l         sync.RWMutex
+	followers map[*auditTailFollower]struct{}
+	formatter *audit.EntryFormatter
+	logger    log.Logger
+}
+
+// auditTailFollower receives the entries of the audit tail.
+type auditTailFollower struct {
+	entries chan []byte
+
+	// dropped counts the entries which did not fit in the buffer since the
+	// follower last caught up.
+	dropped atomic.Uint64
+}
+
+// auditTailSalter provides the non-persistent salt of the audit tail.
+type auditTailSalter struct {
+	salt *salt.Salt
+}
+
+func (s *auditTailSalter) Salt(context.Context) (*salt.Salt, error) {
+	return s.salt, nil
+}
+
+func newAuditTail(logger log.Logger) (*auditTail, error) {
+	config, err := audit.NewFormatterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error creating audit tail formatter config: %w", err)
+	}
+
+	formatter, err := audit.NewEntryFormatter(config, &auditTailSalter{salt: salt.NewNonpersistentSalt()})
+	if err != nil {
+		return nil, fmt.Errorf("error creating audit tail formatter: %w", err)
+	}
+
+	return &auditTail{
+		followers: make(map[*auditTailFollower]struct{}),
+		formatter: formatter,
+		logger:    logger,
+	}, nil
+}
+
+// follow registers a new follower of the tail. The returned function must be
+// called once the follower stops reading entries.
+func (t *auditTail) follow() (*auditTailFollower, func()) {
+	f := &auditTailFollower{
+		entries: make(chan []byte, auditTailBufferSize),
+	}
+
+	t.l.Lock()
+	t.followers[f] = struct{}{}
+	t.l.Unlock()
+
+	return f, func() {
+		t.l.Lock()
+		delete(t.followers, f)
+		t.l.Unlock()
+	}
+}
+
+// following reports whether anyone follows the tail.
+func (t *auditTail) following() bool {
+	if t == nil {
+		return false
+	}
+
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	return len(t.followers) > 0
+}
+
+// publishRequest sends the request entry of in to the followers of the tail.
+func (t *auditTail) publishRequest(ctx context.Context, in *logical.LogInput) {
+	if !t.following() {
+		return
+	}
+
+	t.publish(ctx, in, func(in *logical.LogInput) (interface{}, error) {
+		return t.formatter.FormatRequest(ctx, in)
+	})
+}
+
+// publishResponse sends the response entry of in to the followers of the
+// tail.
+func (t *auditTail) publishResponse(ctx context.Context, in *logical.LogInput) {
+	if !t.following() {
+		return
+	}
+
+	t.publish(ctx, in, func(in *logical.LogInput) (interface{}, error) {
+		return t.formatter.FormatResponse(ctx, in)
+	})
+}
+
+func (t *auditTail) publish(ctx context.Context, in *logical.LogInput, format func(*logical.LogInput) (interface{}, error)) {
+	if in == nil || in.Request == nil {
+		return
+	}
+
+	// Headers are left out entirely, as which of them may be audited and
+	// whether they are HMAC-ed is configured per audit device.
+	headers := in.Request.Headers
+	in.Request.Headers = nil
+	entry, err := format(in)
+	in.Request.Headers = headers
+	if err != nil {
+		t.logger.Debug("failed to format audit tail entry", "request_path", in.Request.Path, "error", err)
+		return
+	}
+
+	encoded, err := jsonutil.EncodeJSON(entry)
+	if err != nil {
+		t.logger.Debug("failed to encode audit tail entry", "request_path", in.Request.Path, "error", err)
+		return
+	}
+
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	for f := range t.followers {
+		select {
+		case f.entries <- encoded:
+		default:
+			f.dropped.Add(1)
+		}
+	}
+}
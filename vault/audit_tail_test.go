@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// TestAuditTail_Publish verifies that the entries are only formatted while
+// the tail is followed, that their sensitive values are HMAC-ed and that the
+// entries which do not fit in the buffer of a follower are counted.
+func TestAuditTail_Publish(t *testing.T) {
+	tail, err := newAuditTail(logging.NewVaultLogger(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := namespace.RootContext(context.Background())
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+			Data: map[string]interface{}{
+				"password": "s3cr3t",
+			},
+			Headers: map[string][]string{
+				"Authorization": {"Bearer s3cr3t"},
+			},
+		},
+	}
+
+	// Nothing is formatted without followers
+	if tail.following() {
+		t.Fatal("expected the tail not to be followed")
+	}
+	tail.publishRequest(ctx, in)
+
+	follower, stop := tail.follow()
+	tail.publishRequest(ctx, in)
+
+	var entry struct {
+		Type    string `json:"type"`
+		Request struct {
+			Path    string                 `json:"path"`
+			Data    map[string]interface{} `json:"data"`
+			Headers map[string][]string    `json:"headers"`
+		} `json:"request"`
+	}
+	select {
+	case raw := <-follower.entries:
+		if strings.Contains(string(raw), "s3cr3t") {
+			t.Fatalf("expected the entry to be redacted: %s", raw)
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatal("expected an entry")
+	}
+
+	if entry.Type != "request" || entry.Request.Path != "secret/foo" {
+		t.Fatalf("unexpected entry: %#v", entry)
+	}
+	if password, _ := entry.Request.Data["password"].(string); !strings.HasPrefix(password, "hmac-sha256:") {
+		t.Fatalf("expected the password to be HMAC-ed, got %q", password)
+	}
+	if entry.Request.Headers != nil {
+		t.Fatalf("expected no headers, got %v", entry.Request.Headers)
+	}
+	if in.Request.Headers == nil {
+		t.Fatal("expected the headers of the request to be restored")
+	}
+
+	for i := 0; i < auditTailBufferSize+3; i++ {
+		tail.publishResponse(ctx, in)
+	}
+	if dropped := follower.dropped.Load(); dropped != 3 {
+		t.Fatalf("expected 3 dropped entries, got %d", dropped)
+	}
+
+	stop()
+	if tail.following() {
+		t.Fatal("expected the tail not to be followed after stopping")
+	}
+}
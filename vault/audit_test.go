@@ -428,6 +428,97 @@ func TestAuditBroker_LogRequest(t *testing.T) {
 	}
 }
 
+// TestAuditBroker_LogRequest_Fallback ensures the fallback backend only logs
+// requests when no other backend succeeded in logging them.
+func TestAuditBroker_LogRequest_Fallback(t *testing.T) {
+	l := logging.NewVaultLogger(log.Trace)
+	b, err := NewAuditBroker(l, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := corehelpers.TestNoopAudit(t, nil)
+	fallback := corehelpers.TestNoopAudit(t, nil)
+	b.Register("foo", primary, false)
+	if err := b.RegisterFallback("bar", fallback, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterFallback("baz", corehelpers.TestNoopAudit(t, nil), false); err == nil {
+		t.Fatal("expected registering a second fallback backend to fail")
+	}
+
+	headersConf := &AuditedHeadersConfig{
+		Headers: make(map[string]*auditedHeaderSettings),
+	}
+	logInput := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "sys/mounts",
+		},
+	}
+	ctx := namespace.RootContext(context.Background())
+
+	if err := b.LogRequest(ctx, logInput, headersConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(primary.Req) != 1 || len(fallback.Req) != 0 {
+		t.Fatalf("expected only the primary backend to log the request, got %d and %d", len(primary.Req), len(fallback.Req))
+	}
+
+	// The fallback backend logs the request the primary backend failed to log
+	primary.ReqErr = fmt.Errorf("failed")
+	if err := b.LogRequest(ctx, logInput, headersConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(fallback.Req) != 1 {
+		t.Fatalf("expected the fallback backend to log the request, got %d", len(fallback.Req))
+	}
+
+	fallback.ReqErr = fmt.Errorf("failed")
+	if err := b.LogRequest(ctx, logInput, headersConf); !errwrap.Contains(err, "no audit backend succeeded in logging the request") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Once deregistered, another fallback backend can be registered
+	if err := b.Deregister(ctx, "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterFallback("baz", corehelpers.TestNoopAudit(t, nil), false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAuditBroker_Close ensures closing the broker removes the pipelines of
+// all its backends.
+func TestAuditBroker_Close(t *testing.T) {
+	l := logging.NewVaultLogger(log.Trace)
+	b, err := NewAuditBroker(l, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Register("foo", corehelpers.TestNoopAudit(t, nil), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterFallback("bar", corehelpers.TestNoopAudit(t, nil), false); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := namespace.RootContext(context.Background())
+	if err := b.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.IsRegistered("foo") || b.IsRegistered("bar") {
+		t.Fatal("expected no backend to be registered once the broker is closed")
+	}
+
+	// The pipelines were removed, so they can be registered again
+	if err := b.Register("foo", corehelpers.TestNoopAudit(t, nil), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterFallback("bar", corehelpers.TestNoopAudit(t, nil), false); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestAuditBroker_LogResponse(t *testing.T) {
 	l := logging.NewVaultLogger(log.Trace)
 	b, err := NewAuditBroker(l, false)
@@ -58,6 +58,11 @@ var (
 
 // enableCredential is used to enable a new credential backend
 func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
+	// The token auth mounts of namespaces are only created with the namespace
+	if entry.Type == nsTokenMountType {
+		return fmt.Errorf("%s credential backend cannot be instantiated", nsTokenMountType)
+	}
+
 	// Enable credential internally
 	if err := c.enableCredentialInternal(ctx, entry, MountTableUpdateStorage); err != nil {
 		return err
@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLocalConfig(t *testing.T) *Config {
+	t.Helper()
+	return &Config{
+		Interval:      time.Hour,
+		Retain:        2,
+		PathPrefix:    t.TempDir(),
+		FilePrefix:    DefaultFilePrefix,
+		StorageType:   StorageTypeLocal,
+		LocalMaxSpace: 1024,
+	}
+}
+
+func testSnapshotFile(t *testing.T, contents string) (*os.File, int64) {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	return f, int64(len(contents))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cases := map[string]struct {
+		modify func(*Config)
+		err    string
+	}{
+		"valid":        {modify: func(*Config) {}},
+		"no interval":  {modify: func(c *Config) { c.Interval = 0 }, err: "interval"},
+		"no retain":    {modify: func(c *Config) { c.Retain = 0 }, err: "retain"},
+		"bad type":     {modify: func(c *Config) { c.StorageType = "ftp" }, err: "storage_type"},
+		"no max space": {modify: func(c *Config) { c.LocalMaxSpace = 0 }, err: "local_max_space"},
+		"slash prefix": {modify: func(c *Config) { c.FilePrefix = "a/b" }, err: "file_prefix"},
+		"no s3 region": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+			},
+			err: "aws_s3_region",
+		},
+		"kms and sse": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeAWSS3
+				c.AWSS3Bucket = "bucket"
+				c.AWSS3Region = "us-east-1"
+				c.AWSS3EnableKMS = true
+				c.AWSS3ServerSideEncryption = true
+			},
+			err: "mutually exclusive",
+		},
+		"gcs prefix without slash": {
+			modify: func(c *Config) {
+				c.StorageType = StorageTypeGoogleGCS
+				c.GoogleGCSBucket = "bucket"
+				c.PathPrefix = "snapshots"
+			},
+			err: "must end with a slash",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := testLocalConfig(t)
+			tc.modify(config)
+			err := config.Validate()
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestLocalTarget_PutPrune(t *testing.T) {
+	ctx := context.Background()
+	config := testLocalConfig(t)
+	target, err := NewTarget(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unrelated files in the directory are left alone
+	if err := os.WriteFile(filepath.Join(config.PathPrefix, "other.snap"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := config.SnapshotName(start.Add(time.Duration(i) * time.Second))
+		f, size := testSnapshotFile(t, "snapshot")
+		url, err := target.Put(ctx, name, f, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if url != "file://"+filepath.ToSlash(filepath.Join(config.PathPrefix, name)) {
+			t.Fatalf("bad url: %s", url)
+		}
+		if err := Prune(ctx, config, target); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	snapshots, err := Snapshots(ctx, config, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0] != names[1] || snapshots[1] != names[2] {
+		t.Fatalf("expected the two newest snapshots %v, got %v", names[1:], snapshots)
+	}
+	if _, err := os.Stat(filepath.Join(config.PathPrefix, "other.snap")); err != nil {
+		t.Fatalf("unrelated file was removed: %v", err)
+	}
+}
+
+func TestLocalTarget_MaxSpace(t *testing.T) {
+	ctx := context.Background()
+	config := testLocalConfig(t)
+	config.LocalMaxSpace = 10
+	target, err := NewTarget(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, size := testSnapshotFile(t, "12345678")
+	if _, err := target.Put(ctx, config.SnapshotName(time.Now()), f, size); err != nil {
+		t.Fatal(err)
+	}
+
+	f, size = testSnapshotFile(t, "12345678")
+	_, err = target.Put(ctx, config.SnapshotName(time.Now().Add(time.Second)), f, size)
+	if err == nil || !strings.Contains(err.Error(), "local_max_space") {
+		t.Fatalf("expected local_max_space error, got %v", err)
+	}
+
+	snapshots, err := Snapshots(ctx, config, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("expected a single snapshot, got %v", snapshots)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// azureTarget writes snapshots to an Azure blob container.
+type azureTarget struct {
+	config    *Config
+	container azblob.ContainerURL
+}
+
+var _ Target = (*azureTarget)(nil)
+
+func newAzureTarget(config *Config) (*azureTarget, error) {
+	var containerURL *url.URL
+	var err error
+	if config.AzureEndpoint != "" {
+		// Endpoints such as Azurite's include the account in the path
+		containerURL, err = url.Parse(strings.TrimSuffix(config.AzureEndpoint, "/") + "/" + config.AzureContainerName)
+	} else {
+		environmentName := config.AzureBlobEnvironment
+		if environmentName == "" {
+			environmentName = azure.PublicCloud.Name
+		}
+		var environment azure.Environment
+		environment, err = azure.EnvironmentFromName(environmentName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up Azure environment descriptor for name %q: %w", environmentName, err)
+		}
+		containerURL, err = url.Parse(fmt.Sprintf("https://%s.blob.%s/%s", config.AzureAccountName, environment.StorageEndpointSuffix, config.AzureContainerName))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build container URL: %w", err)
+	}
+
+	var credential azblob.Credential = azblob.NewAnonymousCredential()
+	if config.AzureAccountKey != "" {
+		credential, err = azblob.NewSharedKeyCredential(config.AzureAccountName, config.AzureAccountKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+		}
+	}
+
+	return &azureTarget{
+		config:    config,
+		container: azblob.NewContainerURL(*containerURL, azblob.NewPipeline(credential, azblob.PipelineOptions{})),
+	}, nil
+}
+
+func (t *azureTarget) Put(ctx context.Context, name string, file *os.File, _ int64) (string, error) {
+	blobURL := t.container.NewBlockBlobURL(t.config.PathPrefix + name)
+	if _, err := azblob.UploadFileToBlockBlob(ctx, file, blobURL, azblob.UploadToBlockBlobOptions{}); err != nil {
+		return "", err
+	}
+	u := blobURL.URL()
+	return u.String(), nil
+}
+
+func (t *azureTarget) List(ctx context.Context) ([]string, error) {
+	var names []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := t.container.ListBlobsHierarchySegment(ctx, marker, "/", azblob.ListBlobsSegmentOptions{
+			Prefix: t.config.PathPrefix,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range resp.Segment.BlobItems {
+			names = append(names, strings.TrimPrefix(blob.Name, t.config.PathPrefix))
+		}
+		marker = resp.NextMarker
+	}
+	return names, nil
+}
+
+func (t *azureTarget) Delete(ctx context.Context, name string) error {
+	blobURL := t.container.NewBlobURL(t.config.PathPrefix + name)
+	_, err := blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	var e azblob.StorageError
+	if errors.As(err, &e) && e.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+		return nil
+	}
+	return err
+}
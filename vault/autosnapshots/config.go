@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StorageTypeLocal     = "local"
+	StorageTypeAWSS3     = "aws-s3"
+	StorageTypeGoogleGCS = "google-gcs"
+	StorageTypeAzureBlob = "azure-blob"
+
+	// DefaultFilePrefix is the file or object name prefix used when none is
+	// configured.
+	DefaultFilePrefix = "vault-snapshot"
+
+	// snapshotExtension is appended to every snapshot file or object name.
+	snapshotExtension = ".snap"
+)
+
+// Config is a named automated snapshot configuration. It controls how often
+// snapshots are taken, where they are written and how many are retained.
+type Config struct {
+	Interval    time.Duration `json:"interval"`
+	Retain      int           `json:"retain"`
+	PathPrefix  string        `json:"path_prefix"`
+	FilePrefix  string        `json:"file_prefix"`
+	StorageType string        `json:"storage_type"`
+
+	LocalMaxSpace int64 `json:"local_max_space,omitempty"`
+
+	AWSS3Bucket               string `json:"aws_s3_bucket,omitempty"`
+	AWSS3Region               string `json:"aws_s3_region,omitempty"`
+	AWSAccessKeyID            string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey        string `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken           string `json:"aws_session_token,omitempty"`
+	AWSS3Endpoint             string `json:"aws_s3_endpoint,omitempty"`
+	AWSS3DisableTLS           bool   `json:"aws_s3_disable_tls,omitempty"`
+	AWSS3ForcePathStyle       bool   `json:"aws_s3_force_path_style,omitempty"`
+	AWSS3EnableKMS            bool   `json:"aws_s3_enable_kms,omitempty"`
+	AWSS3ServerSideEncryption bool   `json:"aws_s3_server_side_encryption,omitempty"`
+	AWSS3KMSKey               string `json:"aws_s3_kms_key,omitempty"`
+
+	GoogleGCSBucket         string `json:"google_gcs_bucket,omitempty"`
+	GoogleServiceAccountKey string `json:"google_service_account_key,omitempty"`
+	GoogleEndpoint          string `json:"google_endpoint,omitempty"`
+	GoogleDisableTLS        bool   `json:"google_disable_tls,omitempty"`
+
+	AzureContainerName   string `json:"azure_container_name,omitempty"`
+	AzureAccountName     string `json:"azure_account_name,omitempty"`
+	AzureAccountKey      string `json:"azure_account_key,omitempty"`
+	AzureBlobEnvironment string `json:"azure_blob_environment,omitempty"`
+	AzureEndpoint        string `json:"azure_endpoint,omitempty"`
+}
+
+// Validate checks that the configuration is complete for its storage type.
+func (c *Config) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("interval must be greater than zero")
+	}
+	if c.Retain < 1 {
+		return errors.New("retain must be at least 1")
+	}
+	if c.FilePrefix == "" {
+		return errors.New("file_prefix cannot be empty")
+	}
+	if strings.Contains(c.FilePrefix, "/") {
+		return errors.New("file_prefix cannot contain a slash")
+	}
+
+	switch c.StorageType {
+	case StorageTypeLocal:
+		if c.PathPrefix == "" {
+			return errors.New("path_prefix is required")
+		}
+		if c.LocalMaxSpace <= 0 {
+			return errors.New("local_max_space must be greater than zero")
+		}
+	case StorageTypeAWSS3:
+		if c.AWSS3Bucket == "" {
+			return errors.New("aws_s3_bucket is required")
+		}
+		if c.AWSS3Region == "" {
+			return errors.New("aws_s3_region is required")
+		}
+		if c.AWSS3EnableKMS && c.AWSS3ServerSideEncryption {
+			return errors.New("aws_s3_enable_kms and aws_s3_server_side_encryption are mutually exclusive")
+		}
+		if c.AWSS3KMSKey != "" && !c.AWSS3EnableKMS {
+			return errors.New("aws_s3_kms_key requires aws_s3_enable_kms")
+		}
+	case StorageTypeGoogleGCS:
+		if c.GoogleGCSBucket == "" {
+			return errors.New("google_gcs_bucket is required")
+		}
+		if c.PathPrefix != "" && !strings.HasSuffix(c.PathPrefix, "/") {
+			return errors.New("path_prefix must end with a slash")
+		}
+	case StorageTypeAzureBlob:
+		if c.AzureContainerName == "" {
+			return errors.New("azure_container_name is required")
+		}
+		if c.PathPrefix != "" && !strings.HasSuffix(c.PathPrefix, "/") {
+			return errors.New("path_prefix must end with a slash")
+		}
+	default:
+		return fmt.Errorf("invalid storage_type %q", c.StorageType)
+	}
+
+	return nil
+}
+
+// SnapshotName returns the file or object name, relative to the path prefix,
+// of a snapshot taken at the given time.
+func (c *Config) SnapshotName(t time.Time) string {
+	return c.FilePrefix + "-" + strconv.FormatInt(t.UnixNano(), 10) + snapshotExtension
+}
+
+// isSnapshotName returns whether name, relative to the path prefix, is a
+// snapshot written for this configuration.
+func (c *Config) isSnapshotName(name string) bool {
+	ts, ok := strings.CutPrefix(name, c.FilePrefix+"-")
+	if !ok {
+		return false
+	}
+	ts, ok = strings.CutSuffix(ts, snapshotExtension)
+	if !ok {
+		return false
+	}
+	_, err := strconv.ParseInt(ts, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/hashicorp/vault/helper/useragent"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// gcsTarget writes snapshots to a Google Cloud Storage bucket.
+type gcsTarget struct {
+	config *Config
+	bucket *storage.BucketHandle
+}
+
+var _ Target = (*gcsTarget)(nil)
+
+func newGCSTarget(ctx context.Context, config *Config) (*gcsTarget, error) {
+	opts := []option.ClientOption{option.WithUserAgent(useragent.String())}
+	if config.GoogleServiceAccountKey != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(config.GoogleServiceAccountKey)))
+	}
+	if config.GoogleEndpoint != "" {
+		endpoint := config.GoogleEndpoint
+		if !strings.Contains(endpoint, "://") {
+			scheme := "https://"
+			if config.GoogleDisableTLS {
+				scheme = "http://"
+			}
+			endpoint = scheme + endpoint
+		}
+		opts = append(opts, option.WithEndpoint(strings.TrimSuffix(endpoint, "/")+"/storage/v1/"))
+		if config.GoogleServiceAccountKey == "" {
+			opts = append(opts, option.WithoutAuthentication())
+		}
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &gcsTarget{
+		config: config,
+		bucket: client.Bucket(config.GoogleGCSBucket),
+	}, nil
+}
+
+func (t *gcsTarget) Put(ctx context.Context, name string, file *os.File, _ int64) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	object := t.config.PathPrefix + name
+	w := t.bucket.Object(object).NewWriter(ctx)
+	if _, err := io.Copy(w, file); err != nil {
+		// Cancelling the context aborts the upload
+		cancel()
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s/%s", t.config.GoogleGCSBucket, object), nil
+}
+
+func (t *gcsTarget) List(ctx context.Context) ([]string, error) {
+	it := t.bucket.Objects(ctx, &storage.Query{
+		Prefix:    t.config.PathPrefix,
+		Delimiter: "/",
+	})
+
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Prefixes of nested objects are returned with an empty name
+		if attrs.Name == "" {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(attrs.Name, t.config.PathPrefix))
+	}
+	return names, nil
+}
+
+func (t *gcsTarget) Delete(ctx context.Context, name string) error {
+	err := t.bucket.Object(t.config.PathPrefix + name).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
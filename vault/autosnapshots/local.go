@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// localTarget writes snapshots to a directory on the active node.
+type localTarget struct {
+	config *Config
+	dir    string
+}
+
+var _ Target = (*localTarget)(nil)
+
+func newLocalTarget(config *Config) *localTarget {
+	return &localTarget{
+		config: config,
+		dir:    config.PathPrefix,
+	}
+}
+
+func (t *localTarget) Put(ctx context.Context, name string, file *os.File, size int64) (string, error) {
+	if err := os.MkdirAll(t.dir, 0o700); err != nil {
+		return "", err
+	}
+
+	snapshots, err := Snapshots(ctx, t.config, t)
+	if err != nil {
+		return "", err
+	}
+	used := size
+	for _, existing := range snapshots {
+		info, err := os.Stat(filepath.Join(t.dir, existing))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		used += info.Size()
+	}
+	if used > t.config.LocalMaxSpace {
+		return "", fmt.Errorf("snapshot of %d bytes would exceed local_max_space of %d bytes", size, t.config.LocalMaxSpace)
+	}
+
+	path := filepath.Join(t.dir, name)
+
+	// Write to a temporary file first so a partial snapshot is never picked
+	// up by the retention policy
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, file); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return "file://" + filepath.ToSlash(path), nil
+}
+
+func (t *localTarget) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (t *localTarget) Delete(_ context.Context, name string) error {
+	err := os.Remove(filepath.Join(t.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
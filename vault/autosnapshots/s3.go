@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+)
+
+// s3Target writes snapshots to an S3 bucket, or any S3 compatible store.
+type s3Target struct {
+	config   *Config
+	prefix   string
+	client   *s3.S3
+	uploader *s3manager.Uploader
+}
+
+var _ Target = (*s3Target)(nil)
+
+func newS3Target(config *Config) (*s3Target, error) {
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    config.AWSAccessKeyID,
+		SecretKey:    config.AWSSecretAccessKey,
+		SessionToken: config.AWSSessionToken,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials:      creds,
+		HTTPClient:       cleanhttp.DefaultClient(),
+		Region:           aws.String(config.AWSS3Region),
+		S3ForcePathStyle: aws.Bool(config.AWSS3ForcePathStyle),
+		DisableSSL:       aws.Bool(config.AWSS3DisableTLS),
+	}
+	if config.AWSS3Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.AWSS3Endpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The trailing slash is optional for S3 path prefixes
+	prefix := config.PathPrefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &s3Target{
+		config:   config,
+		prefix:   prefix,
+		client:   s3.New(sess),
+		uploader: s3manager.NewUploader(sess),
+	}, nil
+}
+
+func (t *s3Target) Put(ctx context.Context, name string, file *os.File, _ int64) (string, error) {
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(t.config.AWSS3Bucket),
+		Key:    aws.String(t.prefix + name),
+		Body:   file,
+	}
+	switch {
+	case t.config.AWSS3EnableKMS:
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		if t.config.AWSS3KMSKey != "" {
+			input.SSEKMSKeyId = aws.String(t.config.AWSS3KMSKey)
+		}
+	case t.config.AWSS3ServerSideEncryption:
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAes256)
+	}
+
+	if _, err := t.uploader.UploadWithContext(ctx, input); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("s3://%s/%s", t.config.AWSS3Bucket, t.prefix+name), nil
+}
+
+func (t *s3Target) List(ctx context.Context) ([]string, error) {
+	var names []string
+	err := t.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(t.config.AWSS3Bucket),
+		Prefix:    aws.String(t.prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			names = append(names, strings.TrimPrefix(aws.StringValue(object.Key), t.prefix))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (t *s3Target) Delete(ctx context.Context, name string) error {
+	_, err := t.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(t.config.AWSS3Bucket),
+		Key:    aws.String(t.prefix + name),
+	})
+	return err
+}
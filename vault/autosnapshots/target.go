@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package autosnapshots
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Target is a destination snapshots are written to. Names passed to and
+// returned by a Target are relative to the configured path prefix.
+type Target interface {
+	// Put writes the snapshot held in file under the given name and returns
+	// a URL describing where it was written.
+	Put(ctx context.Context, name string, file *os.File, size int64) (string, error)
+
+	// List returns the names of all objects under the path prefix.
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the named snapshot.
+	Delete(ctx context.Context, name string) error
+}
+
+// NewTarget returns the Target for the configuration's storage type.
+func NewTarget(ctx context.Context, config *Config) (Target, error) {
+	switch config.StorageType {
+	case StorageTypeLocal:
+		return newLocalTarget(config), nil
+	case StorageTypeAWSS3:
+		return newS3Target(config)
+	case StorageTypeGoogleGCS:
+		return newGCSTarget(ctx, config)
+	case StorageTypeAzureBlob:
+		return newAzureTarget(config)
+	default:
+		return nil, fmt.Errorf("invalid storage_type %q", config.StorageType)
+	}
+}
+
+// Snapshots returns the names of the snapshots written for the configuration,
+// oldest first.
+func Snapshots(ctx context.Context, config *Config, target Target) ([]string, error) {
+	names, err := target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []string
+	for _, name := range names {
+		if config.isSnapshotName(name) {
+			snapshots = append(snapshots, name)
+		}
+	}
+
+	// Names only differ in their fixed width nanosecond timestamp, so they
+	// sort chronologically
+	sort.Strings(snapshots)
+	return snapshots, nil
+}
+
+// Prune deletes the oldest snapshots written for the configuration until no
+// more than config.Retain remain.
+func Prune(ctx context.Context, config *Config, target Target) error {
+	snapshots, err := Snapshots(ctx, config, target)
+	if err != nil {
+		return fmt.Errorf("error listing snapshots: %w", err)
+	}
+	if len(snapshots) <= config.Retain {
+		return nil
+	}
+
+	for _, name := range snapshots[:len(snapshots)-config.Retain] {
+		if err := target.Delete(ctx, name); err != nil {
+			return fmt.Errorf("error deleting snapshot %q: %w", name, err)
+		}
+	}
+	return nil
+}
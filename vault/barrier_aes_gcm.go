@@ -782,6 +782,8 @@ func (b *AESGCMBarrier) updateRootKeyCommon(key []byte) (*Keyring, error) {
 // Put is used to insert or update an entry
 func (b *AESGCMBarrier) Put(ctx context.Context, entry *logical.StorageEntry) error {
 	defer metrics.MeasureSince([]string{"barrier", "put"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.put")
+	defer span.End()
 	b.l.RLock()
 	if b.sealed {
 		b.l.RUnlock()
@@ -818,6 +820,8 @@ func (b *AESGCMBarrier) Get(ctx context.Context, key string) (*logical.StorageEn
 
 func (b *AESGCMBarrier) lockSwitchedGet(ctx context.Context, key string, getLock bool) (*logical.StorageEntry, error) {
 	defer metrics.MeasureSince([]string{"barrier", "get"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.get")
+	defer span.End()
 	if getLock {
 		b.l.RLock()
 	}
@@ -884,6 +888,8 @@ func (b *AESGCMBarrier) lockSwitchedGet(ctx context.Context, key string, getLock
 // Delete is used to permanently delete an entry
 func (b *AESGCMBarrier) Delete(ctx context.Context, key string) error {
 	defer metrics.MeasureSince([]string{"barrier", "delete"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.delete")
+	defer span.End()
 	b.l.RLock()
 	sealed := b.sealed
 	b.l.RUnlock()
@@ -894,10 +900,108 @@ func (b *AESGCMBarrier) Delete(ctx context.Context, key string) error {
 	return b.backend.Delete(ctx, key)
 }
 
+// Transaction is used to encrypt and write several entries atomically. If the
+// physical backend does not support transactions, the writes are performed
+// one after the other.
+func (b *AESGCMBarrier) Transaction(ctx context.Context, txns []*logical.TxnEntry) error {
+	defer metrics.MeasureSince([]string{"barrier", "transaction"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.transaction")
+	defer span.End()
+	b.l.RLock()
+	if b.sealed {
+		b.l.RUnlock()
+		return ErrBarrierSealed
+	}
+
+	term := b.keyring.ActiveTerm()
+	primary, err := b.aeadForTerm(term)
+	b.l.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	pTxns, err := b.encryptTxnEntries(term, primary, txns)
+	if err != nil {
+		return err
+	}
+
+	txnBackend, ok := b.backend.(physical.Transactional)
+	if !ok {
+		for _, txn := range pTxns {
+			switch txn.Operation {
+			case physical.PutOperation:
+				err = b.backend.Put(ctx, txn.Entry)
+			case physical.DeleteOperation:
+				err = b.backend.Delete(ctx, txn.Entry.Key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return txnBackend.Transaction(ctx, pTxns)
+}
+
+// encryptTxnEntries converts the writes of a transaction to the entries of a
+// physical transaction, encrypting the values which are put. The nonces of
+// all the values are read from the random source at once, rather than one
+// read per value.
+func (b *AESGCMBarrier) encryptTxnEntries(term uint32, gcm cipher.AEAD, txns []*logical.TxnEntry) ([]*physical.TxnEntry, error) {
+	var puts int
+	for _, txn := range txns {
+		if txn == nil || txn.Entry == nil {
+			return nil, errors.New("cannot write nil entry")
+		}
+
+		switch txn.Operation {
+		case physical.PutOperation:
+			puts++
+		case physical.DeleteOperation:
+		default:
+			return nil, fmt.Errorf("unsupported transaction operation %q", txn.Operation)
+		}
+	}
+
+	nonces := make([]byte, puts*gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonces); err != nil {
+		return nil, fmt.Errorf("unable to read enough random bytes to fill gcm nonces: %w", err)
+	}
+
+	pTxns := make([]*physical.TxnEntry, 0, len(txns))
+	for _, txn := range txns {
+		pe := &physical.Entry{
+			Key:      txn.Entry.Key,
+			SealWrap: txn.Entry.SealWrap,
+		}
+		if txn.Operation == physical.PutOperation {
+			nonce := nonces[:gcm.NonceSize()]
+			nonces = nonces[gcm.NonceSize():]
+
+			var err error
+			pe.Value, err = b.encryptWithNonce(txn.Entry.Key, term, gcm, nonce, txn.Entry.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		pTxns = append(pTxns, &physical.TxnEntry{
+			Operation: txn.Operation,
+			Entry:     pe,
+		})
+	}
+
+	b.trackEncryptions(term, int64(puts))
+	return pTxns, nil
+}
+
 // List is used ot list all the keys under a given
 // prefix, up to the next prefix.
 func (b *AESGCMBarrier) List(ctx context.Context, prefix string) ([]string, error) {
 	defer metrics.MeasureSince([]string{"barrier", "list"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.list")
+	defer span.End()
 	b.l.RLock()
 	sealed := b.sealed
 	b.l.RUnlock()
@@ -908,6 +1012,22 @@ func (b *AESGCMBarrier) List(ctx context.Context, prefix string) ([]string, erro
 	return b.backend.List(ctx, prefix)
 }
 
+// ListPage is used to list a page of the keys in the barrier. See
+// logical.ListPage.
+func (b *AESGCMBarrier) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	defer metrics.MeasureSince([]string{"barrier", "list"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.barrier.list")
+	defer span.End()
+	b.l.RLock()
+	sealed := b.sealed
+	b.l.RUnlock()
+	if sealed {
+		return nil, ErrBarrierSealed
+	}
+
+	return physical.ListPage(ctx, b.backend, prefix, after, limit)
+}
+
 // aeadForTerm returns the AES-GCM AEAD for the given term
 func (b *AESGCMBarrier) aeadForTerm(term uint32) (cipher.AEAD, error) {
 	// Check for the keyring
@@ -961,6 +1081,17 @@ func (b *AESGCMBarrier) aeadFromKey(key []byte) (cipher.AEAD, error) {
 
 // encrypt is used to encrypt a value
 func (b *AESGCMBarrier) encrypt(path string, term uint32, gcm cipher.AEAD, plain []byte) ([]byte, error) {
+	return b.encryptWithNonce(path, term, gcm, nil, plain)
+}
+
+// encryptWithNonce is used to encrypt a value with the given nonce, which
+// must be random and never reused. If the nonce is nil, a random one is
+// generated.
+func (b *AESGCMBarrier) encryptWithNonce(path string, term uint32, gcm cipher.AEAD, nonce []byte, plain []byte) ([]byte, error) {
+	if nonce != nil && len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+
 	// Allocate the output buffer with room for term, version byte,
 	// nonce, GCM tag and the plaintext
 
@@ -979,15 +1110,19 @@ func (b *AESGCMBarrier) encrypt(path string, term uint32, gcm cipher.AEAD, plain
 	// Set the version byte
 	out[4] = b.currentAESGCMVersionByte
 
-	// Generate a random nonce
-	nonce := out[5 : 5+gcm.NonceSize()]
-	n, err := rand.Read(nonce)
-	if err != nil {
-		return nil, err
-	}
-	if n != len(nonce) {
-		return nil, errors.New("unable to read enough random bytes to fill gcm nonce")
+	// Generate a random nonce, unless one was given
+	if nonce != nil {
+		copy(out[5:], nonce)
+	} else {
+		n, err := rand.Read(out[5:size])
+		if err != nil {
+			return nil, err
+		}
+		if n != gcm.NonceSize() {
+			return nil, errors.New("unable to read enough random bytes to fill gcm nonce")
+		}
 	}
+	nonce = out[5:size]
 
 	// Seal the output
 	switch b.currentAESGCMVersionByte {
@@ -1006,13 +1141,23 @@ func (b *AESGCMBarrier) encrypt(path string, term uint32, gcm cipher.AEAD, plain
 	return out, nil
 }
 
+// termLabels caches the metric labels of the key terms, as they are needed
+// for every encryption.
+var termLabels sync.Map
+
 func termLabel(term uint32) []metrics.Label {
-	return []metrics.Label{
+	if labels, ok := termLabels.Load(term); ok {
+		return labels.([]metrics.Label)
+	}
+
+	labels := []metrics.Label{
 		{
 			Name:  "term",
 			Value: strconv.FormatUint(uint64(term), 10),
 		},
 	}
+	termLabels.Store(term, labels)
+	return labels
 }
 
 // decrypt is used to decrypt a value using the keyring
@@ -1023,7 +1168,10 @@ func (b *AESGCMBarrier) decrypt(path string, gcm cipher.AEAD, cipher []byte) ([]
 	// Capture the parts
 	nonce := cipher[5 : 5+gcm.NonceSize()]
 	raw := cipher[5+gcm.NonceSize():]
-	out := make([]byte, 0, len(raw)-gcm.NonceSize())
+	if len(raw) < gcm.Overhead() {
+		return nil, fmt.Errorf("invalid cipher length")
+	}
+	out := make([]byte, 0, len(raw)-gcm.Overhead())
 
 	// Attempt to open
 	switch cipher[4] {
@@ -1143,14 +1291,22 @@ func (b *AESGCMBarrier) encryptTracked(path string, term uint32, gcm cipher.AEAD
 	if err != nil {
 		return nil, err
 	}
-	// Increment the local encryption count, and track metrics
-	b.UnaccountedEncryptions.Add(1)
-	b.totalLocalEncryptions.Add(1)
-	metrics.IncrCounterWithLabels(barrierEncryptsMetric, 1, termLabel(term))
+	b.trackEncryptions(term, 1)
 
 	return ct, nil
 }
 
+// trackEncryptions increments the local encryption count, and tracks metrics
+func (b *AESGCMBarrier) trackEncryptions(term uint32, encryptions int64) {
+	if encryptions == 0 {
+		return
+	}
+
+	b.UnaccountedEncryptions.Add(encryptions)
+	b.totalLocalEncryptions.Add(encryptions)
+	metrics.IncrCounterWithLabels(barrierEncryptsMetric, float32(encryptions), termLabel(term))
+}
+
 // UnaccountedEncryptions returns the number of encryptions made on the local instance only for the current key term
 func (b *AESGCMBarrier) TotalLocalEncryptions() int64 {
 	return b.totalLocalEncryptions.Load()
@@ -8,6 +8,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -264,6 +265,76 @@ func TestAESGCMBarrier_Confidential(t *testing.T) {
 	}
 }
 
+func TestAESGCMBarrier_Transaction(t *testing.T) {
+	for name, newBackend := range map[string]physical.Factory{
+		"transactional":     inmem.NewTransactionalInmem,
+		"not transactional": inmem.NewInmem,
+	} {
+		t.Run(name, func(t *testing.T) {
+			inm, err := newBackend(nil, logger)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			b, err := NewAESGCMBarrier(inm)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			// Initialize and unseal
+			key, _ := b.GenerateKey(rand.Reader)
+			b.Initialize(context.Background(), key, nil, rand.Reader)
+			b.Unseal(context.Background(), key)
+
+			err = b.Put(context.Background(), &logical.StorageEntry{Key: "old", Value: []byte("old")})
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			view := NewBarrierView(b, "logical/")
+			err = logical.StorageTransaction(context.Background(), view, []*logical.TxnEntry{
+				{Operation: physical.PutOperation, Entry: &logical.StorageEntry{Key: "foo", Value: []byte("foo")}},
+				{Operation: physical.PutOperation, Entry: &logical.StorageEntry{Key: "bar", Value: []byte("bar")}},
+			})
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			err = logical.StorageTransaction(context.Background(), b, []*logical.TxnEntry{
+				{Operation: physical.DeleteOperation, Entry: &logical.StorageEntry{Key: "old"}},
+			})
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			for _, key := range []string{"foo", "bar"} {
+				entry, err := view.Get(context.Background(), key)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if entry == nil || string(entry.Value) != key {
+					t.Fatalf("bad: %#v", entry)
+				}
+
+				// The physical entry must be encrypted
+				pe, err := inm.Get(context.Background(), "logical/"+key)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if pe == nil || bytes.Equal(pe.Value, []byte(key)) {
+					t.Fatalf("bad: %#v", pe)
+				}
+			}
+
+			entry, err := b.Get(context.Background(), "old")
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if entry != nil {
+				t.Fatalf("bad: %#v", entry)
+			}
+		})
+	}
+}
+
 // Verify data sent through cannot be tampered with
 func TestAESGCMBarrier_Integrity(t *testing.T) {
 	inm, err := inmem.NewInmem(nil, logger)
@@ -482,6 +553,68 @@ func TestEncrypt_Unique(t *testing.T) {
 	}
 }
 
+func TestEncrypt_TxnEntriesUnique(t *testing.T) {
+	_, barrier, _ := mockBarrier(t)
+	b := barrier.(*AESGCMBarrier)
+
+	term := b.keyring.ActiveTerm()
+	primary, _ := b.aeadForTerm(term)
+	encryptions := b.TotalLocalEncryptions()
+
+	pTxns, err := b.encryptTxnEntries(term, primary, []*logical.TxnEntry{
+		{Operation: physical.PutOperation, Entry: &logical.StorageEntry{Key: "test", Value: []byte("test")}},
+		{Operation: physical.DeleteOperation, Entry: &logical.StorageEntry{Key: "other"}},
+		{Operation: physical.PutOperation, Entry: &logical.StorageEntry{Key: "test", Value: []byte("test")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pTxns) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(pTxns))
+	}
+	if pTxns[1].Operation != physical.DeleteOperation || pTxns[1].Entry.Key != "other" || pTxns[1].Entry.Value != nil {
+		t.Fatalf("bad: %#v", pTxns[1].Entry)
+	}
+
+	first, second := pTxns[0].Entry.Value, pTxns[2].Entry.Value
+	if bytes.Equal(first, second) {
+		t.Fatalf("improper random seeding detected")
+	}
+	for _, value := range [][]byte{first, second} {
+		plain, err := b.decrypt("test", primary, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plain) != "test" {
+			t.Fatalf("bad: %q", plain)
+		}
+	}
+
+	if total := b.TotalLocalEncryptions(); total != encryptions+2 {
+		t.Fatalf("expected %d encryptions, got %d", encryptions+2, total)
+	}
+}
+
+func TestDecrypt_ShortCiphertext(t *testing.T) {
+	_, barrier, _ := mockBarrier(t)
+	b := barrier.(*AESGCMBarrier)
+
+	ciphertext, err := b.Encrypt(context.Background(), "test", []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncate the ciphertext into the GCM tag
+	_, err = b.Decrypt(context.Background(), "test", ciphertext[:len(ciphertext)-8])
+	if err == nil {
+		t.Fatal("expected an error decrypting a truncated ciphertext")
+	}
+	_, err = b.Decrypt(context.Background(), "test", ciphertext[:5+12+3])
+	if err == nil {
+		t.Fatal("expected an error decrypting a truncated ciphertext")
+	}
+}
+
 func TestInitialize_KeyLength(t *testing.T) {
 	inm, err := inmem.NewInmem(nil, logger)
 	if err != nil {
@@ -694,3 +827,77 @@ func TestBarrier_LegacyRotate(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func BenchmarkAESGCMBarrier_Put(b *testing.B) {
+	_, barrier, _ := mockBarrier(b)
+	entry := &logical.StorageEntry{Key: "test", Value: make([]byte, 256)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := barrier.Put(context.Background(), entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAESGCMBarrier_Get(b *testing.B) {
+	_, barrier, _ := mockBarrier(b)
+	entry := &logical.StorageEntry{Key: "test", Value: make([]byte, 256)}
+	if err := barrier.Put(context.Background(), entry); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := barrier.Get(context.Background(), "test"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAESGCMBarrier_Transaction(b *testing.B) {
+	inm, err := inmem.NewTransactionalInmem(nil, logger)
+	if err != nil {
+		b.Fatal(err)
+	}
+	barrier, err := NewAESGCMBarrier(inm)
+	if err != nil {
+		b.Fatal(err)
+	}
+	key, _ := barrier.GenerateKey(rand.Reader)
+	barrier.Initialize(context.Background(), key, nil, rand.Reader)
+	barrier.Unseal(context.Background(), key)
+
+	txns := make([]*logical.TxnEntry, 10)
+	for i := range txns {
+		txns[i] = &logical.TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     &logical.StorageEntry{Key: fmt.Sprintf("test%d", i), Value: make([]byte, 256)},
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := barrier.Transaction(context.Background(), txns); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAESGCMBarrier_EncryptParallel(b *testing.B) {
+	_, barrier, _ := mockBarrier(b)
+	plaintext := make([]byte, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := barrier.Encrypt(context.Background(), "test", plaintext); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
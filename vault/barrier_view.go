@@ -93,6 +93,31 @@ func (v *BarrierView) Delete(ctx context.Context, key string) error {
 	return v.storage.Delete(ctx, key)
 }
 
+// ListPage lists a page of the keys under the prefix, without listing all of
+// them if the physical backend supports it.
+func (v *BarrierView) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return v.storage.ListPage(ctx, prefix, after, limit)
+}
+
+// Transaction performs the writes atomically if the underlying barrier and
+// physical backend support transactions. Like Put and Delete, it checks
+// read-only errors.
+func (v *BarrierView) Transaction(ctx context.Context, txns []*logical.TxnEntry) error {
+	roErr := v.getReadOnlyErr()
+	if roErr != nil {
+		for _, txn := range txns {
+			if txn == nil || txn.Entry == nil {
+				return errors.New("cannot write nil entry")
+			}
+			if runICheck(v, v.storage.ExpandKey(txn.Entry.Key), roErr) {
+				return roErr
+			}
+		}
+	}
+
+	return v.storage.Transaction(ctx, txns)
+}
+
 // SubView constructs a nested sub-view using the given prefix
 func (v *BarrierView) SubView(prefix string) *BarrierView {
 	return &BarrierView{
@@ -307,6 +307,10 @@ type Core struct {
 	migrationInfo     *migrationInformation
 	sealMigrationDone *uint32
 
+	// sealRewrap re-wraps the seal wrapped values on request, e.g. after the
+	// key of a seal was rotated
+	sealRewrap *sealRewrapper
+
 	// barrier is the security barrier wrapping the physical backend
 	barrier SecurityBarrier
 
@@ -388,6 +392,10 @@ type Core struct {
 	// out into the configured audit backends
 	auditBroker *AuditBroker
 
+	// auditTail streams the audit entries of this node to the followers of
+	// sys/monitor/audit
+	auditTail *auditTail
+
 	// auditedHeaders is used to configure which http headers
 	// can be output in the audit logs
 	auditedHeaders *AuditedHeadersConfig
@@ -520,6 +528,27 @@ type Core struct {
 	// CORS Information
 	corsConfig *CORSConfig
 
+	// opaEvaluator evaluates requests against the policies of an OPA server
+	// when configured, guarded by opaLock
+	opaEvaluator *opaEvaluator
+	opaLock      sync.RWMutex
+
+	// admissionHandlers and admissionWebhooks inspect authorized requests
+	// before they are routed, guarded by admissionLock
+	admissionHandlers []namedAdmissionHandler
+	admissionWebhooks map[string]*admissionWebhook
+	admissionLock     sync.RWMutex
+
+	// namespaces are the namespaces other than root keyed by ID, guarded by
+	// namespacesLock
+	namespaces     map[string]*namespace.Namespace
+	namespacesLock sync.RWMutex
+
+	// monitoredLocks are the locks watched by the deadlock detection, which
+	// can be toggled at runtime and reports to deadlockLogger
+	monitoredLocks []*locking.MonitoredRWMutex
+	deadlockLogger log.Logger
+
 	// replicationState keeps the current replication state cached for quick
 	// lookup; activeNodeReplicationState stores the active value on standbys
 	replicationState           *uint32
@@ -550,6 +579,11 @@ type Core struct {
 	// pluginRuntimeCatalog is used to manage plugin runtime configurations
 	pluginRuntimeCatalog *PluginRuntimeCatalog
 
+	// pluginOCIHTTPClient is the client used to pull plugins from OCI
+	// registries, which is only set by tests
+	pluginOCIHTTPClient *http.Client
+	pluginOCIPlainHTTP  bool
+
 	// The userFailedLoginInfo map has user failed login information.
 	// It has user information (alias-name and mount accessor) as a key
 	// and login counter, last failed login time as value
@@ -610,6 +644,16 @@ type Core struct {
 	raftFollowerStates *raft.FollowerStates
 	// Stop channel for raft TLS rotations
 	raftTLSRotationStopCh chan struct{}
+	// Runs the automated raft snapshot configs on the active node
+	raftAutoSnapshots *raftAutoSnapshotManager
+	// Tracks the raft snapshots uploaded in chunks to be restored
+	raftSnapshotUploads raftSnapshotUploads
+	// Runs the scheduled root credential rotations on the active node
+	rotationManager *rotationManager
+	// Syncs KV v2 secrets to external secret stores on the active node
+	secretsSync *secretsSyncManager
+	// Serializes updates to the persisted raft suffrage overrides
+	raftSuffrageLock sync.Mutex
 	// Stores the pending peers we are waiting to give answers
 	pendingRaftPeers *sync.Map
 
@@ -702,6 +746,10 @@ type Core struct {
 
 	// If any role based quota (LCQ or RLQ) is enabled, don't track lease counts by role
 	impreciseLeaseRoleTracking bool
+
+	// lazyIdentityLoading loads identity entities in the background after
+	// unseal, see loadIdentityStoreArtifactsLazily
+	lazyIdentityLoading bool
 }
 
 // c.stateLock needs to be held in read mode before calling this function.
@@ -766,6 +814,10 @@ type CoreConfig struct {
 	// If any role based quota (LCQ or RLQ) is enabled, don't track lease counts by role
 	ImpreciseLeaseRoleTracking bool
 
+	// LazyIdentityLoading loads identity entities in the background after
+	// unseal, instead of before unseal completes
+	LazyIdentityLoading bool
+
 	// Disables the trace display for Sentinel checks
 	DisableSentinelTrace bool
 
@@ -966,6 +1018,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 	} else {
 		stateLock = &locking.SyncRWMutex{}
 	}
+	monitoredStateLock := locking.NewMonitoredRWMutex("statelock", stateLock)
 
 	effectiveSDKVersion := conf.EffectiveSDKVersion
 	if effectiveSDKVersion == "" {
@@ -985,7 +1038,8 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		clusterListener:      new(atomic.Value),
 		customListenerHeader: new(atomic.Value),
 		seal:                 conf.Seal,
-		stateLock:            stateLock,
+		stateLock:            monitoredStateLock,
+		monitoredLocks:       []*locking.MonitoredRWMutex{monitoredStateLock},
 		router:               NewRouter(),
 		sealed:               new(uint32),
 		sealMigrationDone:    new(uint32),
@@ -1045,6 +1099,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		rollbackMountPathMetrics:       conf.MetricSink.TelemetryConsts.RollbackMetricsIncludeMountPoint,
 		numRollbackWorkers:             conf.NumRollbackWorkers,
 		impreciseLeaseRoleTracking:     conf.ImpreciseLeaseRoleTracking,
+		lazyIdentityLoading:            conf.LazyIdentityLoading,
 	}
 
 	c.standbyStopCh.Store(make(chan struct{}))
@@ -1054,6 +1109,8 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 	c.shutdownDoneCh.Store(make(chan struct{}))
 
 	c.router.logger = c.logger.Named("router")
+	c.deadlockLogger = c.logger.Named("deadlock")
+	c.sealRewrap = newSealRewrapper(c)
 	c.router.rollbackMetricsMountName = c.rollbackMountPathMetrics
 
 	c.inFlightReqData = &InFlightRequests{
@@ -1298,6 +1355,11 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	c.events = events
 	c.events.Start()
 
+	c.auditTail, err = newAuditTail(conf.Logger.Named("audit").Named("tail"))
+	if err != nil {
+		return nil, err
+	}
+
 	// Make sure we're keeping track of the subloggers added above. We haven't
 	// yet registered core to the server command's SubloggerAdder, so any new
 	// subloggers will be in conf.AllLoggers.
@@ -2307,6 +2369,9 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 	if err := c.setupPluginCatalog(ctx); err != nil {
 		return err
 	}
+	if err := c.loadNamespaces(ctx); err != nil {
+		return err
+	}
 	if err := c.loadMounts(ctx); err != nil {
 		return err
 	}
@@ -2328,6 +2393,12 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 	if err := c.loadCORSConfig(ctx); err != nil {
 		return err
 	}
+	if err := c.loadOPAConfig(ctx); err != nil {
+		return err
+	}
+	if err := c.loadAdmissionWebhooks(ctx); err != nil {
+		return err
+	}
 	if err := c.loadCredentials(ctx); err != nil {
 		return err
 	}
@@ -2382,6 +2453,12 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 		if err := c.setupActivityLog(ctx, &wg); err != nil {
 			return err
 		}
+		if err := c.setupRotationManager(c.activeContext); err != nil {
+			return err
+		}
+		if err := c.setupSecretsSync(c.activeContext); err != nil {
+			return err
+		}
 	} else {
 		var err error
 		disableEventLogger, err := parseutil.ParseBool(os.Getenv(featureFlagDisableEventLogger))
@@ -2392,6 +2469,7 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 		if err != nil {
 			return err
 		}
+		c.auditBroker.tail = c.auditTail
 	}
 
 	if !c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary | consts.ReplicationDRSecondary) {
@@ -2593,6 +2671,7 @@ func (c *Core) preSeal() error {
 	c.stopForwarding()
 
 	c.stopRaftActiveNode()
+	c.sealRewrap.stop()
 
 	c.clusterParamsLock.Lock()
 	if err := stopReplication(c); err != nil {
@@ -2607,6 +2686,8 @@ func (c *Core) preSeal() error {
 		result = multierror.Append(result, fmt.Errorf("error stopping expiration: %w", err))
 	}
 	c.stopActivityLog()
+	c.stopRotationManager()
+	c.stopSecretsSync()
 	// Clean up the censusAgent on seal
 	if err := c.teardownCensusAgent(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error tearing down reporting agent: %w", err))
@@ -3989,6 +4070,29 @@ func (c *Core) ResolveRoleForQuotas(ctx context.Context, req *quotas.Request) (b
 	return c.quotaManager.QueryResolveRoleQuotas(req)
 }
 
+// ResolveEntityForQuotas looks for a rate limit quota grouping requests by
+// entity, which requires resolving the entity of the client token early in the
+// RateLimitQuotaWrapping handler.
+func (c *Core) ResolveEntityForQuotas(ctx context.Context, req *quotas.Request) (bool, error) {
+	if c.quotaManager == nil {
+		return false, nil
+	}
+	return c.quotaManager.QueryResolveEntityQuotas(req)
+}
+
+// DetermineEntityFromToken returns the identifier of the entity of the given
+// token, if any, to apply to a quota.
+func (c *Core) DetermineEntityFromToken(ctx context.Context, token string) string {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	te, err := c.LookupToken(ctx, token)
+	if err != nil || te == nil {
+		return ""
+	}
+	return te.EntityID
+}
+
 // aliasNameFromLoginRequest will determine the aliasName from the login Request
 func (c *Core) aliasNameFromLoginRequest(ctx context.Context, req *logical.Request) (string, error) {
 	c.authLock.RLock()
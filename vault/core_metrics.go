@@ -147,6 +147,11 @@ func (c *Core) metricsLoop(stopCh chan struct{}) {
 			// Capture the total number of in-flight requests
 			c.inFlightReqGaugeMetric()
 
+			// Capture the external plugin processes and connections
+			if c.pluginCatalog != nil {
+				c.pluginCatalog.emitMetrics(c.metricSink)
+			}
+
 			// Refresh gauge metrics that are looped
 			c.cachedGaugeMetricsEmitter()
 		case <-writeTimer:
@@ -632,3 +637,22 @@ func (c *Core) configuredPoliciesGaugeCollector(ctx context.Context) ([]metricsu
 
 	return values, nil
 }
+
+// requestMetricLabels returns the namespace and mount point labels configured
+// for the metrics of a request to the given mount entry, which may be nil.
+func (c *Core) requestMetricLabels(ctx context.Context, entry *MountEntry) []metrics.Label {
+	if c.metricSink == nil {
+		return nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	var mountPoint string
+	if entry != nil {
+		mountPoint = entry.Path
+	}
+	return c.metricSink.TelemetryConsts.RequestLabels(ns, mountPoint)
+}
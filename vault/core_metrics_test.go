@@ -441,3 +441,23 @@ func TestCoreMetrics_AvailablePolicies(t *testing.T) {
 		})
 	}
 }
+
+// TestCoreMetrics_RequestMetricLabels verifies the namespace and mount point
+// labels of request metrics are only added when configured.
+func TestCoreMetrics_RequestMetricLabels(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	entry := core.router.MatchingMountEntry(ctx, "secret/foo")
+
+	if labels := core.requestMetricLabels(ctx, entry); len(labels) != 0 {
+		t.Fatalf("expected no labels, got %v", labels)
+	}
+
+	core.metricSink.TelemetryConsts.NamespaceLabels = true
+	core.metricSink.TelemetryConsts.MountPointLabels = true
+	assert.Equal(t, []metrics.Label{{"namespace", "root"}, {"mount_point", "secret/"}}, core.requestMetricLabels(ctx, entry))
+	assert.Equal(t, []metrics.Label{{"namespace", "root"}, {"mount_point", ""}}, core.requestMetricLabels(ctx, nil))
+
+	core.metricSink.TelemetryConsts.MountPointLabelAllowlist = []string{"sys/"}
+	assert.Equal(t, []metrics.Label{{"namespace", "root"}, {"mount_point", "other"}}, core.requestMetricLabels(ctx, entry))
+}
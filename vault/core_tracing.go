@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of the lifecycle of the requests, which are
+// exported when tracing is configured in the telemetry stanza and are no-ops
+// otherwise, since the global tracer provider is then the no-op one.
+var tracer = otel.Tracer("github.com/hashicorp/vault/vault")
+
+// The attributes of the spans
+const (
+	spanAttrOperation      = attribute.Key("vault.operation")
+	spanAttrNamespace      = attribute.Key("vault.namespace")
+	spanAttrMountPoint     = attribute.Key("vault.mount_point")
+	spanAttrMountType      = attribute.Key("vault.mount_type")
+	spanAttrExternalPlugin = attribute.Key("vault.plugin.external")
+	spanAttrPluginVersion  = attribute.Key("vault.plugin.version")
+	spanAttrAllowed        = attribute.Key("vault.policy.allowed")
+)
+
+// noopSpan is returned for the operations which aren't part of a sampled
+// request.
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// startRequestSpan starts the span of an operation of a request, such as a
+// storage operation, as a child of the span of the request. No span is
+// started outside of a sampled request, so that the background operations of
+// the core, such as the rollbacks and the storage operations of the plugins,
+// don't start traces of their own.
+func startRequestSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, noopSpan
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span, recording the error of the operation if any.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testSpanRecorder     *tracetest.SpanRecorder
+	testSpanRecorderOnce sync.Once
+)
+
+// testTracedRequestSpans registers a global tracer provider recording the
+// spans of the traces continued from a sampled parent only, so that the other
+// tests don't record theirs, and returns the recorded spans of the trace of
+// the returned parent.
+func testTracedRequestSpans(t *testing.T) (trace.SpanContext, func() map[string]sdktrace.ReadOnlySpan) {
+	t.Helper()
+
+	// The tracer of the core is bound to the first registered provider
+	testSpanRecorderOnce.Do(func() {
+		testSpanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+			sdktrace.WithSpanProcessor(testSpanRecorder),
+		))
+	})
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	_, err := rand.Read(traceID[:])
+	require.NoError(t, err)
+	_, err = rand.Read(spanID[:])
+	require.NoError(t, err)
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+
+	return parent, func() map[string]sdktrace.ReadOnlySpan {
+		spans := make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range testSpanRecorder.Ended() {
+			if span.SpanContext().TraceID() == traceID {
+				spans[span.Name()] = span
+			}
+		}
+		return spans
+	}
+}
+
+// TestCore_HandleRequest_Tracing ensures the spans of the lifecycle of a
+// request continue the trace of the caller, attributing the time spent to
+// the token and policy checks, the backend and the storage.
+func TestCore_HandleRequest_Tracing(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	parent, spans := testTracedRequestSpans(t)
+
+	ctx := trace.ContextWithRemoteSpanContext(namespace.RootContext(nil), parent)
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.Data["foo"] = "bar"
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	recorded := spans()
+	for _, name := range []string{
+		"vault.request",
+		"vault.core.check_token",
+		"vault.core.fetch_acl",
+		"vault.core.policy_checks",
+		"vault.backend.handle_request",
+		"vault.barrier.put",
+	} {
+		require.Contains(t, recorded, name)
+	}
+
+	request := recorded["vault.request"]
+	require.Equal(t, parent.SpanID(), request.Parent().SpanID())
+	require.Equal(t, trace.SpanKindServer, request.SpanKind())
+	require.Contains(t, request.Attributes(), spanAttrOperation.String("update"))
+	require.Contains(t, request.Attributes(), spanAttrMountPoint.String("secret/"))
+
+	checkToken := recorded["vault.core.check_token"]
+	require.Equal(t, request.SpanContext().SpanID(), checkToken.Parent().SpanID())
+	require.Equal(t, checkToken.SpanContext().SpanID(), recorded["vault.core.fetch_acl"].Parent().SpanID())
+	policyChecks := recorded["vault.core.policy_checks"]
+	require.Equal(t, checkToken.SpanContext().SpanID(), policyChecks.Parent().SpanID())
+	require.Contains(t, policyChecks.Attributes(), spanAttrAllowed.Bool(true))
+
+	backend := recorded["vault.backend.handle_request"]
+	require.Equal(t, request.SpanContext().SpanID(), backend.Parent().SpanID())
+	require.Contains(t, backend.Attributes(), spanAttrMountType.String("kv"))
+	require.Contains(t, backend.Attributes(), spanAttrExternalPlugin.Bool(false))
+	require.Equal(t, backend.SpanContext().SpanID(), recorded["vault.barrier.put"].Parent().SpanID())
+
+	// A request without a sampled parent isn't traced
+	before := len(testSpanRecorder.Ended())
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	_, err = c.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Len(t, testSpanRecorder.Ended(), before)
+}
@@ -7,7 +7,9 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -65,11 +67,15 @@ func (c *Core) setupReplicationResolverHandler() error {
 func NewPolicyMFABackend(core *Core, logger hclog.Logger) *PolicyMFABackend { return nil }
 
 func (c *Core) barrierViewForNamespace(namespaceId string) (*BarrierView, error) {
-	if namespaceId != namespace.RootNamespaceID {
-		return nil, fmt.Errorf("failed to find barrier view for non-root namespace")
+	ns, err := namespaceByID(context.Background(), namespaceId, c)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, fmt.Errorf("failed to find barrier view for namespace %q", namespaceId)
 	}
 
-	return c.systemBarrierView, nil
+	return c.namespaceSystemView(ns), nil
 }
 
 func (c *Core) UndoLogsEnabled() bool            { return false }
@@ -83,9 +89,26 @@ func (c *Core) BuildTotal() *uint32    { return nil }
 func (c *Core) teardownReplicationResolverHandler() {}
 func createSecondaries(*Core, *CoreConfig)          {}
 
-func addExtraLogicalBackends(*Core, map[string]logical.Factory, string) {}
+func addExtraLogicalBackends(c *Core, backends map[string]logical.Factory, _ string) {
+	systemFactory := backends[systemMountType]
+	backends[nsSystemMountType] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
+		b, err := systemFactory(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		return namespaceSystemBackend(b)
+	}
+	backends[mountTypeNSCubbyhole] = CubbyholeBackendFactory
+}
 
-func addExtraCredentialBackends(*Core, map[string]logical.Factory) {}
+func addExtraCredentialBackends(c *Core, backends map[string]logical.Factory) {
+	backends[nsTokenMountType] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		if c.tokenStore == nil {
+			return nil, errors.New("token store is not set up")
+		}
+		return namespaceTokenStore{TokenStore: c.tokenStore}, nil
+	}
+}
 
 func preUnsealInternal(context.Context, *Core) error { return nil }
 
@@ -162,22 +185,96 @@ func (c *Core) perfStandbyClusterHandler() (*replication.Cluster, chan struct{},
 
 func (c *Core) initSealsForMigration() {}
 
-func (c *Core) postSealMigration(ctx context.Context) error { return nil }
+// postSealMigration re-wraps the root key material and the recovery key with
+// every configured seal once the seal generation changed. Without seal
+// wrapping there are no other values to re-wrap.
+func (c *Core) postSealMigration(ctx context.Context) error {
+	autoSeal, ok := c.seal.(*autoSeal)
+	if !ok {
+		return nil
+	}
+
+	sealGenInfo := autoSeal.GetAccess().GetSealGenerationInfo()
+	if sealGenInfo == nil || sealGenInfo.IsRewrapped() {
+		return nil
+	}
+
+	// Unhealthy seals are skipped when encrypting, so re-wrapping now would
+	// leave them out. The re-wrap is retried once all seals are healthy again.
+	if !autoSeal.GetAccess().AllSealWrappersHealthy() {
+		return errors.New("cannot re-wrap seal keys while a seal is unhealthy")
+	}
+	if err := autoSeal.UpgradeKeys(ctx); err != nil {
+		return fmt.Errorf("failed to re-wrap seal keys: %w", err)
+	}
 
-func (c *Core) applyLeaseCountQuota(_ context.Context, in *quotas.Request) (*quotas.Response, error) {
-	return &quotas.Response{Allowed: true}, nil
+	sealGenInfo.SetRewrapped(true)
+	if err := c.SetPhysicalSealGenInfo(ctx, sealGenInfo); err != nil {
+		return err
+	}
+	c.logger.Info("re-wrapped seal keys with all configured seals", "generation", sealGenInfo.Generation)
+	return nil
+}
+
+func (c *Core) applyLeaseCountQuota(ctx context.Context, in *quotas.Request) (*quotas.Response, error) {
+	if c.quotaManager == nil {
+		return &quotas.Response{Allowed: true}, nil
+	}
+
+	in.Type = quotas.TypeLeaseCount
+	resp, err := c.quotaManager.ApplyQuota(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
 }
 
 func (c *Core) ackLeaseQuota(access quotas.Access, leaseGenerated bool) error {
+	c.quotaManager.AckLeaseCountQuota(access)
 	return nil
 }
 
+// quotaLeaseWalker walks the leases counted by lease count quotas, which are
+// the leases pending expiration.
 func (c *Core) quotaLeaseWalker(ctx context.Context, callback func(request *quotas.Request) bool) error {
+	if c.expiration == nil {
+		return nil
+	}
+
+	c.expiration.pending.Range(func(key, value interface{}) bool {
+		pending := value.(pendingInfo)
+		if pending.cachedLeaseInfo == nil {
+			return true
+		}
+		return callback(c.leaseQuotaRequest(ctx, key.(string), pending.cachedLeaseInfo.LoginRole))
+	})
 	return nil
 }
 
 func (c *Core) quotasHandleLeases(ctx context.Context, action quotas.LeaseAction, leases []*quotas.QuotaLeaseInformation) error {
-	return nil
+	if c.quotaManager == nil {
+		return nil
+	}
+
+	reqs := make([]*quotas.Request, 0, len(leases))
+	for _, lease := range leases {
+		reqs = append(reqs, c.leaseQuotaRequest(ctx, lease.LeaseId, lease.Role))
+	}
+	return c.quotaManager.HandleLeaseActions(action, reqs)
+}
+
+// leaseQuotaRequest describes the request that created the given lease, for
+// looking up the lease count quota it counts towards. Lease IDs are made of the
+// request path followed by a random identifier.
+func (c *Core) leaseQuotaRequest(ctx context.Context, leaseID, role string) *quotas.Request {
+	reqPath := path.Dir(leaseID)
+	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	return &quotas.Request{
+		Path:          reqPath,
+		MountPath:     c.router.MatchingMount(ctx, reqPath),
+		NamespacePath: namespace.RootNamespace.Path,
+		Role:          role,
+	}
 }
 
 func (c *Core) namespaceByPath(path string) *namespace.Namespace {
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/helper/locking"
+)
+
+// DefaultDeadlockDetectionTimeout is how long an attempt at acquiring a
+// monitored lock may wait before it is reported, matching the timeout of the
+// detect_deadlocks configuration.
+const DefaultDeadlockDetectionTimeout = 30 * time.Second
+
+// MonitoredLocks returns the names of the locks watched by the deadlock
+// detection.
+func (c *Core) MonitoredLocks() []string {
+	names := make([]string, 0, len(c.monitoredLocks))
+	for _, lock := range c.monitoredLocks {
+		names = append(names, lock.Name())
+	}
+	return names
+}
+
+// DeadlockDetectionTimeout returns how long an attempt at acquiring a
+// monitored lock may wait before it is reported, or zero if the deadlock
+// detection is disabled.
+func (c *Core) DeadlockDetectionTimeout() time.Duration {
+	if len(c.monitoredLocks) == 0 {
+		return 0
+	}
+	detector := c.monitoredLocks[0].Detector()
+	if detector == nil {
+		return 0
+	}
+	return detector.Timeout
+}
+
+// SetDeadlockDetection enables the deadlock detection of the monitored locks
+// with the given timeout, or disables it if the timeout is zero. The attempts
+// exceeding the timeout are logged with the stack traces of all goroutines,
+// which makes them visible through sys/monitor.
+func (c *Core) SetDeadlockDetection(timeout time.Duration) {
+	var detector *locking.ContentionDetector
+	if timeout > 0 {
+		detector = c.newContentionDetector(timeout)
+	}
+	for _, lock := range c.monitoredLocks {
+		lock.SetDetector(detector)
+	}
+
+	if detector == nil {
+		c.deadlockLogger.Info("deadlock detection disabled")
+	} else {
+		c.deadlockLogger.Info("deadlock detection enabled", "locks", c.MonitoredLocks(), "timeout", timeout)
+	}
+}
+
+// newContentionDetector returns a detector logging the attempts which exceed
+// the timeout. Since every waiter of a deadlocked lock is reported, the stack
+// traces are only logged once per timeout to keep the logs readable.
+func (c *Core) newContentionDetector(timeout time.Duration) *locking.ContentionDetector {
+	var lastStacks atomic.Int64
+	return &locking.ContentionDetector{
+		Timeout: timeout,
+		OnContention: func(name string, waited time.Duration, stacks []byte) {
+			now := time.Now()
+			last := lastStacks.Load()
+			if now.Sub(time.Unix(0, last)) < timeout || !lastStacks.CompareAndSwap(last, now.UnixNano()) {
+				c.deadlockLogger.Error("POTENTIAL DEADLOCK: lock attempt exceeded the timeout", "lock", name, "waited", waited)
+				return
+			}
+			c.deadlockLogger.Error("POTENTIAL DEADLOCK: lock attempt exceeded the timeout", "lock", name, "waited", waited, "goroutines", string(stacks))
+		},
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/monitor"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemBackend_DeadlockDetection ensures the deadlock detection can be
+// toggled through sys/config/deadlock-detection.
+func TestSystemBackend_DeadlockDetection(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "config/deadlock-detection")
+	req.ClientToken = root
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["enabled"])
+	require.Equal(t, int64(30), resp.Data["timeout"])
+	require.Equal(t, []string{"statelock"}, resp.Data["locks"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/deadlock-detection")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{"timeout": "10s"}
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.Equal(t, 10*time.Second, c.DeadlockDetectionTimeout())
+
+	req = logical.TestRequest(t, logical.ReadOperation, "config/deadlock-detection")
+	req.ClientToken = root
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["enabled"])
+	require.Equal(t, int64(10), resp.Data["timeout"])
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "config/deadlock-detection")
+	req.ClientToken = root
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, c.DeadlockDetectionTimeout())
+}
+
+// TestCore_DeadlockDetection ensures attempts at acquiring the state lock
+// which exceed the timeout are streamed to the deadlock monitor module along
+// with the stack traces of the goroutines.
+func TestCore_DeadlockDetection(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	mon, err := monitor.NewFilteredMonitor(512, c.Logger().(log.InterceptLogger), &log.LoggerOptions{
+		Level: log.Error,
+	}, &monitor.Filter{
+		Modules: []string{"deadlock"},
+	})
+	require.NoError(t, err)
+	logCh := mon.Start()
+	defer mon.Stop()
+
+	c.SetDeadlockDetection(50 * time.Millisecond)
+	defer c.SetDeadlockDetection(0)
+
+	c.stateLock.Lock()
+	acquired := make(chan struct{})
+	go func() {
+		c.stateLock.RLock()
+		c.stateLock.RUnlock()
+		close(acquired)
+	}()
+
+	select {
+	case l := <-logCh:
+		require.Contains(t, string(l), "POTENTIAL DEADLOCK")
+		require.Contains(t, string(l), "lock=statelock")
+		require.True(t, strings.Contains(string(l), "goroutine"), "expected stack traces in %q", l)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock attempt to be reported")
+	}
+
+	c.stateLock.Unlock()
+	<-acquired
+}
@@ -9,12 +9,14 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/eventlogger/formatter_filters/cloudevents"
 	"github.com/hashicorp/go-bexpr"
@@ -32,6 +34,15 @@ const (
 	// based on what each subscriber is interested in.
 	eventTypeAll   = "*"
 	defaultTimeout = 60 * time.Second
+
+	// ProtobufFormat is the format of events holding their payload serialized
+	// as protobuf, so that subscribers sharing a filter don't serialize each
+	// event again.
+	ProtobufFormat = "protobuf"
+
+	// subscriptionQueueSize is the number of events which can wait to be
+	// delivered to a subscriber. A subscriber falling behind further is closed.
+	subscriptionQueueSize = 256
 )
 
 var (
@@ -54,6 +65,11 @@ type EventBus struct {
 	started         atomic.Bool
 	formatterNodeID eventlogger.NodeID
 	timeout         time.Duration
+
+	// groups holds the subscription groups by their key, see
+	// subscriptionGroupKey
+	groupsLock sync.Mutex
+	groups     map[string]*subscriptionGroup
 }
 
 type pluginEventBus struct {
@@ -62,21 +78,34 @@ type pluginEventBus struct {
 	pluginInfo *logical.EventPluginInfo
 }
 
-type asyncChanNode struct {
-	// TODO: add bounded deque buffer of *EventReceived
+// subscriptionGroup is the pipeline shared by the subscriptions with the same
+// namespace patterns, event type pattern and bexpr filter: events are filtered,
+// formatted and serialized once per group, whatever the number of its
+// subscriptions, which only have their own queue for delivery.
+type subscriptionGroup struct {
+	key        string
+	pipelineID eventlogger.PipelineID
+
+	lock          sync.RWMutex
+	subscriptions map[*subscription]struct{}
+}
+
+// subscription is a single subscriber of a subscription group, to which events
+// are delivered through its queue, in order.
+type subscription struct {
 	ctx    context.Context
-	ch     chan *eventlogger.Event
-	logger hclog.Logger
-
-	// used to close the connection
-	closeOnce      sync.Once
-	cancelFunc     context.CancelFunc
-	pipelineID     eventlogger.PipelineID
-	removePipeline func(ctx context.Context, t eventlogger.EventType, id eventlogger.PipelineID) (bool, error)
+	cancel context.CancelFunc
+	bus    *EventBus
+	group  *subscriptionGroup
+
+	queue chan *eventlogger.Event
+	ch    chan *eventlogger.Event
+
+	closeOnce sync.Once
 }
 
 var (
-	_ eventlogger.Node    = (*asyncChanNode)(nil)
+	_ eventlogger.Node    = (*subscriptionGroup)(nil)
 	_ logical.EventSender = (*pluginEventBus)(nil)
 )
 
@@ -128,9 +157,10 @@ func (bus *EventBus) SendEventInternal(ctx context.Context, ns *namespace.Namesp
 		PluginInfo: pluginInfo,
 	}
 
-	// We can't easily know when the SendEvent is complete, so we can't call the cancel function.
-	// But, it is called automatically after bus.timeout, so there won't be any leak as long as bus.timeout is not too long.
-	ctx, _ = context.WithTimeout(ctx, bus.timeout)
+	// Subscription groups only queue the event for delivery, so the send is
+	// complete once the broker returns.
+	ctx, cancel := context.WithTimeout(ctx, bus.timeout)
+	defer cancel()
 	_, err := bus.broker.Send(ctx, eventTypeAll, eventReceived)
 	if err != nil {
 		// if no listeners for this event type are registered, that's okay, the event
@@ -194,6 +224,7 @@ func NewEventBus(logger hclog.Logger) (*EventBus, error) {
 		broker:          broker,
 		formatterNodeID: formatterNodeID,
 		timeout:         defaultTimeout,
+		groups:          make(map[string]*subscriptionGroup),
 	}, nil
 }
 
@@ -204,44 +235,95 @@ func (bus *EventBus) Subscribe(ctx context.Context, ns *namespace.Namespace, pat
 }
 
 // SubscribeMultipleNamespaces subscribes to events in the given namespace matching the event type
-// pattern and after applying the optional go-bexpr filter.
+// pattern and after applying the optional go-bexpr filter. Subscriptions with the same namespace
+// patterns, event type pattern and filter share the evaluation of the filter and the serialization
+// of the events.
 func (bus *EventBus) SubscribeMultipleNamespaces(ctx context.Context, namespacePathPatterns []string, pattern string, bexprFilter string) (<-chan *eventlogger.Event, context.CancelFunc, error) {
 	// subscriptions are still stored even if the bus has not been started
+	bus.groupsLock.Lock()
+	defer bus.groupsLock.Unlock()
+
+	key := subscriptionGroupKey(namespacePathPatterns, pattern, bexprFilter)
+	group, ok := bus.groups[key]
+	if !ok {
+		var err error
+		group, err = bus.newSubscriptionGroup(key, namespacePathPatterns, pattern, bexprFilter)
+		if err != nil {
+			return nil, nil, err
+		}
+		bus.groups[key] = group
+		metrics.SetGauge([]string{"events", "subscription_groups"}, float32(len(bus.groups)))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &subscription{
+		ctx:    ctx,
+		cancel: cancel,
+		bus:    bus,
+		group:  group,
+		queue:  make(chan *eventlogger.Event, subscriptionQueueSize),
+		ch:     make(chan *eventlogger.Event),
+	}
+
+	group.lock.Lock()
+	group.subscriptions[sub] = struct{}{}
+	group.lock.Unlock()
+
+	addSubscriptions(1)
+	go sub.run()
+
+	return sub.ch, sub.close, nil
+}
+
+// subscriptionGroupKey returns the key of the subscription group of the
+// subscriptions with the given namespace patterns, event type pattern and
+// bexpr filter. The order of the namespace patterns doesn't matter.
+func subscriptionGroupKey(namespacePathPatterns []string, pattern string, bexprFilter string) string {
+	namespaces := slices.Clone(namespacePathPatterns)
+	slices.Sort(namespaces)
+	return fmt.Sprintf("%q|%q|%q", namespaces, pattern, bexprFilter)
+}
+
+// newSubscriptionGroup registers the pipeline of a new subscription group. It
+// must be called with the lock of the groups held.
+func (bus *EventBus) newSubscriptionGroup(key string, namespacePathPatterns []string, pattern string, bexprFilter string) (*subscriptionGroup, error) {
 	pipelineID, err := uuid.GenerateUUID()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	err = bus.broker.RegisterNode(bus.formatterNodeID, cloudEventsFormatterFilter)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	filterNodeID, err := uuid.GenerateUUID()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	filterNode, err := newFilterNode(namespacePathPatterns, pattern, bexprFilter)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	err = bus.broker.RegisterNode(eventlogger.NodeID(filterNodeID), filterNode)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	sinkNodeID, err := uuid.GenerateUUID()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	asyncNode := newAsyncNode(ctx, bus.logger, bus.broker)
-	err = bus.broker.RegisterNode(eventlogger.NodeID(sinkNodeID), asyncNode)
+	group := &subscriptionGroup{
+		key:           key,
+		pipelineID:    eventlogger.PipelineID(pipelineID),
+		subscriptions: make(map[*subscription]struct{}),
+	}
+	err = bus.broker.RegisterNode(eventlogger.NodeID(sinkNodeID), group)
 	if err != nil {
-		defer cancel()
-		return nil, nil, err
+		return nil, err
 	}
 
 	nodes := []eventlogger.NodeID{eventlogger.NodeID(filterNodeID), bus.formatterNodeID, eventlogger.NodeID(sinkNodeID)}
@@ -253,16 +335,40 @@ func (bus *EventBus) SubscribeMultipleNamespaces(ctx context.Context, namespaceP
 	}
 	err = bus.broker.RegisterPipeline(pipeline)
 	if err != nil {
-		defer cancel()
-		return nil, nil, err
+		return nil, err
 	}
 
-	addSubscriptions(1)
-	// add info needed to cancel the subscription
-	asyncNode.pipelineID = eventlogger.PipelineID(pipelineID)
-	asyncNode.cancelFunc = cancel
-	// Capture context in a closure for the cancel func
-	return asyncNode.ch, func() { asyncNode.Close(ctx) }, nil
+	return group, nil
+}
+
+// removeSubscription removes the subscription from its group, and the group
+// along with its pipeline once it has no subscriptions left.
+func (bus *EventBus) removeSubscription(ctx context.Context, sub *subscription) {
+	bus.groupsLock.Lock()
+	defer bus.groupsLock.Unlock()
+
+	group := sub.group
+	group.lock.Lock()
+	delete(group.subscriptions, sub)
+	empty := len(group.subscriptions) == 0
+	group.lock.Unlock()
+
+	if !empty || bus.groups[group.key] != group {
+		return
+	}
+
+	delete(bus.groups, group.key)
+	metrics.SetGauge([]string{"events", "subscription_groups"}, float32(len(bus.groups)))
+
+	removed, err := bus.broker.RemovePipelineAndNodes(ctx, eventTypeAll, group.pipelineID)
+	switch {
+	case err != nil && removed:
+		msg := fmt.Sprintf("Error removing nodes referenced by pipeline %q", group.pipelineID)
+		bus.logger.Warn(msg, err)
+	case err != nil:
+		msg := fmt.Sprintf("Error removing pipeline %q", group.pipelineID)
+		bus.logger.Warn(msg, err)
+	}
 }
 
 // SetSendTimeout sets the timeout of sending events. If the events are not accepted by the
@@ -312,60 +418,81 @@ func newFilterNode(namespacePatterns []string, pattern string, bexprFilter strin
 	}, nil
 }
 
-func newAsyncNode(ctx context.Context, logger hclog.Logger, broker *eventlogger.Broker) *asyncChanNode {
-	return &asyncChanNode{
-		ctx:            ctx,
-		ch:             make(chan *eventlogger.Event),
-		logger:         logger,
-		removePipeline: broker.RemovePipelineAndNodes,
+// Process serializes the event as protobuf, unless another group already did,
+// and queues it for delivery to each subscription of the group.
+func (group *subscriptionGroup) Process(_ context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	if _, ok := e.Format(ProtobufFormat); !ok {
+		if eventRecv, ok := e.Payload.(*logical.EventReceived); ok {
+			if serialized, err := proto.Marshal(eventRecv); err == nil {
+				e.FormattedAs(ProtobufFormat, serialized)
+			}
+		}
 	}
-}
 
-// Close tells the bus to stop sending us events.
-func (node *asyncChanNode) Close(ctx context.Context) {
-	node.closeOnce.Do(func() {
-		defer node.cancelFunc()
-		removed, err := node.removePipeline(ctx, eventTypeAll, node.pipelineID)
-
-		switch {
-		case err != nil && removed:
-			msg := fmt.Sprintf("Error removing nodes referenced by pipeline %q", node.pipelineID)
-			node.logger.Warn(msg, err)
-		case err != nil:
-			msg := fmt.Sprintf("Error removing pipeline %q", node.pipelineID)
-			node.logger.Warn(msg, err)
-		}
-		addSubscriptions(-1)
-	})
-}
+	group.lock.RLock()
+	defer group.lock.RUnlock()
 
-func (node *asyncChanNode) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
-	// sends to the channel async in another goroutine
-	go func() {
-		var timeout bool
-		select {
-		case node.ch <- e:
-		case <-ctx.Done():
-			timeout = errors.Is(ctx.Err(), context.DeadlineExceeded)
-		case <-node.ctx.Done():
-			timeout = errors.Is(node.ctx.Err(), context.DeadlineExceeded)
-		}
-		if timeout {
-			node.logger.Info("Subscriber took too long to process event, closing", "ID", e.Payload.(*logical.EventReceived).Event.Id)
-			node.Close(ctx)
-		}
-	}()
+	for sub := range group.subscriptions {
+		sub.enqueue(e)
+	}
 	return e, nil
 }
 
-func (node *asyncChanNode) Reopen() error {
+func (group *subscriptionGroup) Reopen() error {
 	return nil
 }
 
-func (node *asyncChanNode) Type() eventlogger.NodeType {
+func (group *subscriptionGroup) Type() eventlogger.NodeType {
 	return eventlogger.NodeTypeSink
 }
 
+// enqueue queues the event for delivery, closing the subscription if its
+// queue is full.
+func (sub *subscription) enqueue(e *eventlogger.Event) {
+	select {
+	case sub.queue <- e:
+	case <-sub.ctx.Done():
+	default:
+		sub.bus.logger.Info("Subscriber fell too far behind, closing", "ID", e.Payload.(*logical.EventReceived).Event.Id)
+		// The lock of the group is held by the caller
+		go sub.close()
+	}
+}
+
+// run delivers the queued events to the subscriber until the subscription is
+// closed, closing it if the subscriber takes too long to receive an event.
+func (sub *subscription) run() {
+	defer sub.close()
+
+	for {
+		select {
+		case <-sub.ctx.Done():
+			return
+		case e := <-sub.queue:
+			timer := time.NewTimer(sub.bus.timeout)
+			select {
+			case sub.ch <- e:
+				timer.Stop()
+			case <-sub.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				sub.bus.logger.Info("Subscriber took too long to process event, closing", "ID", e.Payload.(*logical.EventReceived).Event.Id)
+				return
+			}
+		}
+	}
+}
+
+// close tells the bus to stop sending us events.
+func (sub *subscription) close() {
+	sub.closeOnce.Do(func() {
+		defer sub.cancel()
+		sub.bus.removeSubscription(context.Background(), sub)
+		addSubscriptions(-1)
+	})
+}
+
 func addSubscriptions(delta int64) {
 	metrics.SetGauge([]string{"events", "subscriptions"}, float32(subscriptions.Add(delta)))
 }
@@ -653,3 +653,82 @@ func TestPipelineCleanedUp(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+// TestSubscriptionGroups ensures subscriptions with the same filter share a
+// single pipeline, which is removed along with its last subscription, and that
+// events are serialized once for all of them.
+func TestSubscriptionGroups(t *testing.T) {
+	bus, err := NewEventBus(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Start()
+	ctx := context.Background()
+
+	eventType := logical.EventType("someType")
+
+	var channels []<-chan *eventlogger.Event
+	var cancels []context.CancelFunc
+	for i := 0; i < 10; i++ {
+		ch, cancel, err := bus.SubscribeMultipleNamespaces(ctx, []string{"", "ns1"}, string(eventType), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		channels = append(channels, ch)
+		cancels = append(cancels, cancel)
+	}
+	// The order of the namespace patterns doesn't matter
+	ch, cancel, err := bus.SubscribeMultipleNamespaces(ctx, []string{"ns1", ""}, string(eventType), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels = append(channels, ch)
+	cancels = append(cancels, cancel)
+
+	_, otherCancel, err := bus.SubscribeMultipleNamespaces(ctx, []string{""}, "otherType", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus.groupsLock.Lock()
+	groups := len(bus.groups)
+	bus.groupsLock.Unlock()
+	assert.Equal(t, 2, groups)
+
+	event, err := logical.NewEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bus.SendEventInternal(ctx, namespace.RootNamespace, nil, eventType, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var serialized []byte
+	for _, ch := range channels {
+		select {
+		case message := <-ch:
+			assert.Equal(t, event.Id, message.Payload.(*logical.EventReceived).Event.Id)
+			formatted, ok := message.Format(ProtobufFormat)
+			assert.True(t, ok)
+			if serialized == nil {
+				serialized = formatted
+			}
+			// All the subscribers receive the same serialized payload
+			assert.Same(t, &serialized[0], &formatted[0])
+		case <-time.After(1 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	otherCancel()
+
+	bus.groupsLock.Lock()
+	groups = len(bus.groups)
+	bus.groupsLock.Unlock()
+	assert.Equal(t, 0, groups)
+	assert.False(t, bus.broker.IsAnyPipelineRegistered(eventTypeAll))
+}
@@ -70,6 +70,17 @@ const (
 
 	fairshareWorkersOverrideVar = "VAULT_LEASE_REVOCATION_WORKERS"
 
+	// numExpirationWorkersPerMountDivisor bounds the workers revoking leases
+	// of a single mount to a fraction of all workers by default, so that a
+	// slow or hung backend can't hold on to the whole worker pool
+	numExpirationWorkersPerMountDivisor = 2
+
+	fairshareWorkersPerMountOverrideVar = "VAULT_LEASE_REVOCATION_WORKERS_PER_MOUNT"
+
+	// revocationAgingThreshold is the time after which a pending revocation
+	// is given priority over the revocations of other mounts
+	revocationAgingThreshold = 30 * time.Second
+
 	// limit irrevocable error messages to 240 characters to be respectful of
 	// storage/memory
 	maxIrrevocableErrorLength = 240
@@ -156,6 +167,19 @@ type ExpirationManager struct {
 
 	jobManager      *fairshare.JobManager
 	revokeRetryBase time.Duration
+
+	// revokeConfig holds the configured retry policy of revocations, if any
+	revokeConfig atomic.Pointer[leaseRevocationConfig]
+
+	// revokePrefixJobs holds the asynchronous revoke prefix jobs by ID
+	revokePrefixJobs     map[string]*revokePrefixJob
+	revokePrefixJobsLock sync.RWMutex
+
+	// irrevocableCleanupResetCh signals the irrevocable lease cleanup job
+	// that its interval changed, and irrevocableCleanupReport holds the
+	// outcome of its last run
+	irrevocableCleanupResetCh chan struct{}
+	irrevocableCleanupReport  atomic.Pointer[irrevocableCleanupReport]
 }
 
 type ExpireLeaseStrategy func(context.Context, *ExpirationManager, string, *namespace.Namespace)
@@ -249,11 +273,12 @@ func (r *revocationJob) OnFailure(err error) {
 
 	pending := pendingRaw.(pendingInfo)
 	pending.revokesAttempted++
-	newTimer := r.revokeExponentialBackoff(pending.revokesAttempted)
+	conf := r.m.revocationConfig()
+	newTimer := r.revokeExponentialBackoff(conf, pending.revokesAttempted)
 
-	if pending.revokesAttempted >= maxRevokeAttempts || errIsUnrecoverable(err) {
+	if int(pending.revokesAttempted) >= conf.MaxAttempts || errIsUnrecoverable(err) {
 		reason := "unrecoverable error"
-		if pending.revokesAttempted >= maxRevokeAttempts {
+		if int(pending.revokesAttempted) >= conf.MaxAttempts {
 			reason = "lease has consumed all retry attempts"
 			err = fmt.Errorf("%v: %w", outOfRetriesMessage, err)
 		}
@@ -269,6 +294,7 @@ func (r *revocationJob) OnFailure(err error) {
 			return
 		}
 
+		le.RevokeAttempts = int(pending.revokesAttempted)
 		r.m.pendingLock.Lock()
 		r.m.markLeaseIrrevocable(r.nsCtx, le, err)
 		r.m.pendingLock.Unlock()
@@ -298,13 +324,16 @@ func expireLeaseStrategyFairsharing(ctx context.Context, m *ExpirationManager, l
 	m.jobManager.AddJob(job, mountAccessor)
 }
 
-func (r *revocationJob) revokeExponentialBackoff(attempt uint8) time.Duration {
-	exp := (1 << attempt) * r.m.revokeRetryBase
+func (r *revocationJob) revokeExponentialBackoff(conf *leaseRevocationConfig, attempt uint8) time.Duration {
+	exp := (1 << attempt) * conf.RetryBase
 	randomDelta := 0.5 * float64(exp)
 
 	// Allow backoff time to be a random value between exp +/- (0.5*exp)
-	backoffTime := (float64(exp) - randomDelta) + (rand.Float64() * (2 * randomDelta))
-	return time.Duration(backoffTime)
+	backoffTime := time.Duration((float64(exp) - randomDelta) + (rand.Float64() * (2 * randomDelta)))
+	if conf.MaxRetryDelay != 0 && backoffTime > conf.MaxRetryDelay {
+		backoffTime = conf.MaxRetryDelay
+	}
+	return backoffTime
 }
 
 func getNumExpirationWorkers(c *Core, l log.Logger) int {
@@ -325,11 +354,37 @@ func getNumExpirationWorkers(c *Core, l log.Logger) int {
 	return numWorkers
 }
 
+// getNumExpirationWorkersPerMount returns the maximum number of workers which
+// may revoke leases of a single mount at the same time
+func getNumExpirationWorkersPerMount(numWorkers int, l log.Logger) int {
+	perMount := numWorkers / numExpirationWorkersPerMountDivisor
+	if perMount < 1 {
+		perMount = 1
+	}
+
+	workerOverride := os.Getenv(fairshareWorkersPerMountOverrideVar)
+	if workerOverride != "" {
+		i, err := strconv.Atoi(workerOverride)
+		if err != nil {
+			l.Warn("vault lease revocation workers per mount override must be an integer", "value", workerOverride)
+		} else if i < 1 || i > numWorkers {
+			l.Warn("vault lease revocation workers per mount override out of range", "value", i, "max", numWorkers)
+		} else {
+			perMount = i
+		}
+	}
+
+	return perMount
+}
+
 // NewExpirationManager creates a new ExpirationManager that is backed
 // using a given view, and uses the provided router for revocation.
 func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, logger log.Logger) *ExpirationManager {
 	managerLogger := logger.Named("job-manager")
-	jobManager := fairshare.NewJobManager("expire", getNumExpirationWorkers(c, logger), managerLogger, c.metricSink)
+	numWorkers := getNumExpirationWorkers(c, logger)
+	jobManager := fairshare.NewJobManager("expire", numWorkers, managerLogger, c.metricSink)
+	jobManager.SetMaxWorkersPerQueue(getNumExpirationWorkersPerMount(numWorkers, logger))
+	jobManager.SetAgingThreshold(revocationAgingThreshold)
 	jobManager.Start()
 
 	exp := &ExpirationManager{
@@ -363,6 +418,9 @@ func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, log
 
 		jobManager:      jobManager,
 		revokeRetryBase: c.expirationRevokeRetryBase,
+
+		revokePrefixJobs:          make(map[string]*revokePrefixJob),
+		irrevocableCleanupResetCh: make(chan struct{}, 1),
 	}
 	exp.expireFunc.Store(&e)
 	if exp.revokeRetryBase == 0 {
@@ -396,6 +454,10 @@ func (c *Core) setupExpiration(e ExpireLeaseStrategy) error {
 	// Link the token store to this
 	c.tokenStore.SetExpirationManager(mgr)
 
+	if err := mgr.loadRevocationConfig(c.activeContext); err != nil {
+		return err
+	}
+
 	// Restore the existing state
 	c.logger.Info("restoring leases")
 	errorFunc := func() {
@@ -406,19 +468,7 @@ func (c *Core) setupExpiration(e ExpireLeaseStrategy) error {
 	}
 	go c.expiration.Restore(errorFunc)
 
-	quit := c.expiration.quitCh
-	go func() {
-		t := time.NewTimer(24 * time.Hour)
-		for {
-			select {
-			case <-quit:
-				return
-			case <-t.C:
-				c.expiration.attemptIrrevocableLeasesRevoke()
-				t.Reset(24 * time.Hour)
-			}
-		}
-	}()
+	go c.expiration.runIrrevocableCleanup()
 
 	return nil
 }
@@ -896,7 +946,7 @@ func (m *ExpirationManager) Stop() error {
 
 // Revoke is used to revoke a secret named by the given LeaseID
 func (m *ExpirationManager) Revoke(ctx context.Context, leaseID string) error {
-	defer metrics.MeasureSince([]string{"expire", "revoke"}, time.Now())
+	defer metrics.MeasureSinceWithLabels([]string{"expire", "revoke"}, time.Now(), m.leaseIDMetricLabels(ctx, leaseID))
 
 	return m.revokeCommon(ctx, leaseID, false, false)
 }
@@ -935,39 +985,6 @@ func (m *ExpirationManager) lazyRevokeInternal(ctx context.Context, leaseID stri
 	return nil
 }
 
-// should be run on a schedule. something like once a day, maybe once a week
-func (m *ExpirationManager) attemptIrrevocableLeasesRevoke() {
-	m.irrevocable.Range(func(k, v interface{}) bool {
-		leaseID := k.(string)
-		le := v.(*leaseEntry)
-
-		if le.ExpireTime.Add(time.Hour).Before(time.Now()) {
-			// if we get an error (or no namespace) note it, but continue attempting
-			// to revoke other leases
-			leaseNS, err := m.getNamespaceFromLeaseID(m.core.activeContext, leaseID)
-			if err != nil {
-				m.logger.Debug("could not get lease namespace from ID", "error", err)
-				return true
-			}
-			if leaseNS == nil {
-				m.logger.Debug("could not get lease namespace from ID: nil namespace")
-				return true
-			}
-
-			ctxWithNS := namespace.ContextWithNamespace(m.core.activeContext, leaseNS)
-			ctxWithNSAndTimeout, _ := context.WithTimeout(ctxWithNS, time.Minute)
-			if err := m.revokeCommon(ctxWithNSAndTimeout, leaseID, false, false); err != nil {
-				// on failure, force some delay to mitigate resource spike while
-				// this is running. if revocations succeed, we are okay with
-				// the higher resource consumption.
-				time.Sleep(10 * time.Millisecond)
-			}
-		}
-
-		return true
-	})
-}
-
 // revokeCommon does the heavy lifting. If force is true, we ignore a problem
 // during revocation and still remove entries/index/lease timers
 func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, force, skipToken bool) error {
@@ -1195,7 +1212,10 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 // Renew is used to renew a secret using the given leaseID
 // and a renew interval. The increment may be ignored.
 func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment time.Duration) (*logical.Response, error) {
-	defer metrics.MeasureSince([]string{"expire", "renew"}, time.Now())
+	var labels []metrics.Label
+	defer func(start time.Time) {
+		metrics.MeasureSinceWithLabels([]string{"expire", "renew"}, start, labels)
+	}(time.Now())
 
 	// Acquire lock for this lease
 	leaseLock := m.lockForLeaseID(leaseID)
@@ -1207,6 +1227,7 @@ func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment
 	if err != nil {
 		return nil, err
 	}
+	labels = m.leaseMetricLabels(le)
 
 	// Check if the lease is renewable
 	if _, err := le.renewable(); err != nil {
@@ -1759,6 +1780,8 @@ func (m *ExpirationManager) inMemoryLeaseInfo(le *leaseEntry) *leaseEntry {
 	}
 	if le.isIrrevocable() {
 		ret.RevokeErr = le.RevokeErr
+		ret.RevokeAttempts = le.RevokeAttempts
+		ret.IrrevocableTime = le.IrrevocableTime
 	}
 	ret.LoginRole = le.LoginRole
 	return ret
@@ -2560,6 +2583,7 @@ func (m *ExpirationManager) markLeaseIrrevocable(ctx context.Context, le *leaseE
 	}
 
 	le.RevokeErr = errStr
+	le.IrrevocableTime = time.Now()
 	m.persistEntry(ctx, le)
 
 	m.irrevocable.Store(le.LeaseID, m.inMemoryLeaseInfo(le))
@@ -2652,10 +2676,12 @@ func (m *ExpirationManager) getIrrevocableLeaseCounts(ctx context.Context, inclu
 }
 
 type leaseResponse struct {
-	LeaseID    string `json:"lease_id"`
-	MountID    string `json:"mount_id"`
-	ErrMsg     string `json:"error"`
-	expireTime time.Time
+	LeaseID         string    `json:"lease_id"`
+	MountID         string    `json:"mount_id"`
+	ErrMsg          string    `json:"error"`
+	RevokeAttempts  int       `json:"revoke_attempts"`
+	IrrevocableTime time.Time `json:"irrevocable_time"`
+	expireTime      time.Time
 }
 
 // returns a warning string, if applicable
@@ -2699,10 +2725,12 @@ func (m *ExpirationManager) listIrrevocableLeases(ctx context.Context, includeCh
 
 		numMatchingLeases++
 		matchingLeases = append(matchingLeases, &leaseResponse{
-			LeaseID:    leaseID,
-			MountID:    mountAccessor,
-			ErrMsg:     leaseInfo.RevokeErr,
-			expireTime: leaseInfo.ExpireTime,
+			LeaseID:         leaseID,
+			MountID:         mountAccessor,
+			ErrMsg:          leaseInfo.RevokeErr,
+			RevokeAttempts:  leaseInfo.RevokeAttempts,
+			IrrevocableTime: leaseInfo.IrrevocableTime,
+			expireTime:      leaseInfo.ExpireTime,
 		})
 
 		return true
@@ -2756,6 +2784,11 @@ type leaseEntry struct {
 	// RevokeErr will be set, thus marking this leaseEntry as irrevocable. From
 	// there, it must be manually removed (force revoked).
 	RevokeErr string `json:"revokeErr"`
+
+	// RevokeAttempts is the number of failed revocation attempts of an
+	// irrevocable lease, and IrrevocableTime when it was marked irrevocable.
+	RevokeAttempts  int       `json:"revoke_attempts,omitempty"`
+	IrrevocableTime time.Time `json:"irrevocable_time,omitempty"`
 }
 
 // encode is used to JSON encode the lease entry
@@ -2821,3 +2854,37 @@ func decodeLeaseEntry(buf []byte) (*leaseEntry, error) {
 	out := new(leaseEntry)
 	return out, jsonutil.DecodeJSON(buf, out)
 }
+
+// leaseMetricLabels returns the namespace and mount point labels configured
+// for the metrics of the lease.
+func (m *ExpirationManager) leaseMetricLabels(le *leaseEntry) []metrics.Label {
+	if le == nil || le.namespace == nil || !m.leaseMetricLabelsEnabled() {
+		return nil
+	}
+
+	nsCtx := namespace.ContextWithNamespace(m.quitContext, le.namespace)
+	return m.core.requestMetricLabels(nsCtx, m.router.MatchingMountEntry(nsCtx, le.Path))
+}
+
+// leaseIDMetricLabels returns the metric labels of the lease with the given
+// ID. The lease is only loaded when such labels are configured.
+func (m *ExpirationManager) leaseIDMetricLabels(ctx context.Context, leaseID string) []metrics.Label {
+	if !m.leaseMetricLabelsEnabled() {
+		return nil
+	}
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return nil
+	}
+	return m.leaseMetricLabels(le)
+}
+
+func (m *ExpirationManager) leaseMetricLabelsEnabled() bool {
+	if m.core == nil || m.core.metricSink == nil {
+		return false
+	}
+
+	telemetryConsts := m.core.metricSink.TelemetryConsts
+	return telemetryConsts.NamespaceLabels || telemetryConsts.MountPointLabels
+}
@@ -0,0 +1,327 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+// maxIrrevocableMountErrors is the number of distinct revocation errors
+// reported for each mount by the irrevocable lease summaries.
+const maxIrrevocableMountErrors = 5
+
+// irrevocableCleanupReport is the outcome of a run of the irrevocable lease
+// cleanup job.
+type irrevocableCleanupReport struct {
+	StartTime    time.Time
+	EndTime      time.Time
+	Retried      int
+	Revoked      int
+	ForceExpired int
+	Failed       int
+
+	// Mounts holds the outcome of the run for each mount, by accessor
+	Mounts map[string]*irrevocableCleanupMountReport
+}
+
+type irrevocableCleanupMountReport struct {
+	Revoked      int `json:"revoked"`
+	ForceExpired int `json:"force_expired"`
+	Failed       int `json:"failed"`
+}
+
+func (r *irrevocableCleanupReport) mount(accessor string) *irrevocableCleanupMountReport {
+	report, ok := r.Mounts[accessor]
+	if !ok {
+		report = &irrevocableCleanupMountReport{}
+		r.Mounts[accessor] = report
+	}
+	return report
+}
+
+// data returns the report as the data of a response.
+func (r *irrevocableCleanupReport) data() map[string]interface{} {
+	mounts := make(map[string]interface{}, len(r.Mounts))
+	for accessor, report := range r.Mounts {
+		mounts[accessor] = map[string]interface{}{
+			"revoked":       report.Revoked,
+			"force_expired": report.ForceExpired,
+			"failed":        report.Failed,
+		}
+	}
+	return map[string]interface{}{
+		"start_time":    r.StartTime.Format(time.RFC3339),
+		"end_time":      r.EndTime.Format(time.RFC3339),
+		"retried":       r.Retried,
+		"revoked":       r.Revoked,
+		"force_expired": r.ForceExpired,
+		"failed":        r.Failed,
+		"mounts":        mounts,
+	}
+}
+
+// runIrrevocableCleanup periodically retries the revocation of the
+// irrevocable leases until the expiration manager is stopped. The interval is
+// re-read from the revocation configuration whenever it changes.
+func (m *ExpirationManager) runIrrevocableCleanup() {
+	t := time.NewTimer(m.revocationConfig().cleanupInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.quitCh:
+			return
+		case <-m.irrevocableCleanupResetCh:
+			t.Stop()
+			t = time.NewTimer(m.revocationConfig().cleanupInterval())
+		case <-t.C:
+			m.attemptIrrevocableLeasesRevoke()
+			t.Reset(m.revocationConfig().cleanupInterval())
+		}
+	}
+}
+
+// resetIrrevocableCleanup makes the cleanup job pick up a new interval.
+func (m *ExpirationManager) resetIrrevocableCleanup() {
+	select {
+	case m.irrevocableCleanupResetCh <- struct{}{}:
+	default:
+	}
+}
+
+// attemptIrrevocableLeasesRevoke retries the revocation of the leases which
+// have been irrevocable for more than an hour. Leases which have been
+// irrevocable for longer than the configured irrevocable_force_expire_after
+// are removed ignoring the errors of their backends. The outcome is recorded
+// as the last cleanup report.
+func (m *ExpirationManager) attemptIrrevocableLeasesRevoke() {
+	conf := m.revocationConfig()
+	report := &irrevocableCleanupReport{
+		StartTime: time.Now(),
+		Mounts:    make(map[string]*irrevocableCleanupMountReport),
+	}
+
+	m.irrevocable.Range(func(k, v interface{}) bool {
+		select {
+		case <-m.quitCh:
+			return false
+		default:
+		}
+
+		leaseID := k.(string)
+		le := v.(*leaseEntry)
+
+		if !le.ExpireTime.Add(time.Hour).Before(time.Now()) {
+			return true
+		}
+
+		// if we get an error (or no namespace) note it, but continue attempting
+		// to revoke other leases
+		leaseNS, err := m.getNamespaceFromLeaseID(m.core.activeContext, leaseID)
+		if err != nil {
+			m.logger.Debug("could not get lease namespace from ID", "error", err)
+			return true
+		}
+		if leaseNS == nil {
+			m.logger.Debug("could not get lease namespace from ID: nil namespace")
+			return true
+		}
+
+		force := conf.IrrevocableForceExpireAfter > 0 && time.Since(le.IrrevocableTime) > conf.IrrevocableForceExpireAfter
+
+		ctxWithNS := namespace.ContextWithNamespace(m.core.activeContext, leaseNS)
+		ctxWithNSAndTimeout, cancel := context.WithTimeout(ctxWithNS, time.Minute)
+		m.coreStateLock.RLock()
+		mountReport := report.mount(m.getLeaseMountAccessor(ctxWithNS, leaseID))
+		err = m.revokeCommon(ctxWithNSAndTimeout, leaseID, force, false)
+		m.coreStateLock.RUnlock()
+		cancel()
+
+		report.Retried++
+		switch {
+		case err != nil:
+			report.Failed++
+			mountReport.Failed++
+			// on failure, force some delay to mitigate resource spike while
+			// this is running. if revocations succeed, we are okay with
+			// the higher resource consumption.
+			time.Sleep(10 * time.Millisecond)
+		case force:
+			m.logger.Info("force expired irrevocable lease", "lease_id", leaseID, "revoke_error", le.RevokeErr)
+			report.ForceExpired++
+			mountReport.ForceExpired++
+		default:
+			report.Revoked++
+			mountReport.Revoked++
+		}
+
+		return true
+	})
+
+	report.EndTime = time.Now()
+	m.irrevocableCleanupReport.Store(report)
+	if report.Retried > 0 {
+		m.logger.Info("irrevocable lease cleanup completed", "retried", report.Retried, "revoked", report.Revoked,
+			"force_expired", report.ForceExpired, "failed", report.Failed)
+	}
+}
+
+// irrevocableLeaseIDs returns the IDs of the irrevocable leases of the
+// namespace of the context, restricted to those of the mount with the
+// accessor if it is set. The caller must hold the state lock for reading.
+func (m *ExpirationManager) irrevocableLeaseIDs(ctx context.Context, mountAccessor string) ([]string, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var leaseIDs []string
+	m.irrevocable.Range(func(k, v interface{}) bool {
+		leaseID := k.(string)
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+		if err != nil || leaseNS.ID != requestNS.ID {
+			return true
+		}
+		if mountAccessor != "" && m.getLeaseMountAccessor(ctx, leaseID) != mountAccessor {
+			return true
+		}
+		leaseIDs = append(leaseIDs, leaseID)
+		return true
+	})
+	sort.Strings(leaseIDs)
+	return leaseIDs, nil
+}
+
+// StartIrrevocableLeasesJob starts a job retrying the revocation of the
+// irrevocable leases of the namespace of the context in the background, or
+// removing them ignoring the errors of their backends if force is set, and
+// returns its ID. The job is restricted to the leases of the mount with the
+// accessor if it is set. The caller must hold the state lock for reading.
+func (m *ExpirationManager) StartIrrevocableLeasesJob(ctx context.Context, mountAccessor string, force bool) (string, error) {
+	leaseIDs, err := m.irrevocableLeaseIDs(ctx, mountAccessor)
+	if err != nil {
+		return "", err
+	}
+
+	var prefix string
+	if mountAccessor != "" {
+		if entry := m.core.router.MatchingMountByAccessor(mountAccessor); entry != nil {
+			prefix = entry.APIPath()
+		}
+	}
+
+	operation := revokePrefixJobOperationRetryIrrevocable
+	if force {
+		operation = revokePrefixJobOperationExpireIrrevocable
+	}
+	return m.startRevokePrefixJob(ctx, operation, prefix, force, func(context.Context) ([]string, error) {
+		return leaseIDs, nil
+	})
+}
+
+// irrevocableMountSummary describes the irrevocable leases of a mount.
+type irrevocableMountSummary struct {
+	Path                  string
+	Type                  string
+	NamespacePath         string
+	LeaseCount            int
+	OldestIrrevocableTime time.Time
+	Errors                map[string]int
+}
+
+// getIrrevocableLeasesByMount returns the summaries of the irrevocable leases
+// of the namespace of the context, and of its children if
+// includeChildNamespaces is set, grouped by the accessor of their mount.
+func (m *ExpirationManager) getIrrevocableLeasesByMount(ctx context.Context, includeChildNamespaces bool) (map[string]interface{}, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*irrevocableMountSummary)
+	m.irrevocable.Range(func(k, v interface{}) bool {
+		leaseID := k.(string)
+		le := v.(*leaseEntry)
+
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+		if err != nil {
+			m.logger.Warn("could not get lease namespace from ID", "error", err)
+			return true
+		}
+		if leaseNS.ID != requestNS.ID && !(includeChildNamespaces && leaseNS.HasParent(requestNS)) {
+			return true
+		}
+
+		accessor := "mount-accessor-not-found"
+		summary := &irrevocableMountSummary{NamespacePath: leaseNS.Path}
+		if entry := m.core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, leaseNS), leaseID); entry != nil {
+			accessor = entry.Accessor
+			summary.Path = entry.Path
+			summary.Type = entry.Type
+		}
+		if existing, ok := summaries[accessor]; ok {
+			summary = existing
+		} else {
+			summary.Errors = make(map[string]int)
+			summaries[accessor] = summary
+		}
+
+		summary.LeaseCount++
+		if summary.OldestIrrevocableTime.IsZero() || le.IrrevocableTime.Before(summary.OldestIrrevocableTime) {
+			summary.OldestIrrevocableTime = le.IrrevocableTime
+		}
+		summary.Errors[le.RevokeErr]++
+		return true
+	})
+
+	mounts := make(map[string]interface{}, len(summaries))
+	leaseCount := 0
+	for accessor, summary := range summaries {
+		leaseCount += summary.LeaseCount
+		mounts[accessor] = map[string]interface{}{
+			"path":                    summary.Path,
+			"type":                    summary.Type,
+			"namespace_path":          summary.NamespacePath,
+			"lease_count":             summary.LeaseCount,
+			"oldest_irrevocable_time": summary.OldestIrrevocableTime.Format(time.RFC3339),
+			"errors":                  topIrrevocableErrors(summary.Errors),
+		}
+	}
+
+	return map[string]interface{}{
+		"lease_count": leaseCount,
+		"mounts":      mounts,
+	}, nil
+}
+
+// topIrrevocableErrors returns the most frequent revocation errors along with
+// the number of leases which failed with them.
+func topIrrevocableErrors(counts map[string]int) []map[string]interface{} {
+	errs := make([]string, 0, len(counts))
+	for err := range counts {
+		errs = append(errs, err)
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if counts[errs[i]] != counts[errs[j]] {
+			return counts[errs[i]] > counts[errs[j]]
+		}
+		return errs[i] < errs[j]
+	})
+	if len(errs) > maxIrrevocableMountErrors {
+		errs = errs[:maxIrrevocableMountErrors]
+	}
+
+	ret := make([]map[string]interface{}, 0, len(errs))
+	for _, err := range errs {
+		ret = append(ret, map[string]interface{}{
+			"error":       err,
+			"lease_count": counts[err],
+		})
+	}
+	return ret
+}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// registerIrrevocableLease registers a lease which can't be revoked since it
+// has no mount, and marks it irrevocable as if it expired two hours ago and
+// was marked irrevocable irrevocableFor ago.
+func registerIrrevocableLease(t *testing.T, ctx context.Context, exp *ExpirationManager, irrevocableFor time.Duration) string {
+	t.Helper()
+
+	leaseID := registerOneLease(t, ctx, exp)
+	le, err := exp.loadEntry(ctx, leaseID)
+	require.NoError(t, err)
+
+	exp.pendingLock.Lock()
+	defer exp.pendingLock.Unlock()
+	exp.markLeaseIrrevocable(ctx, le, errors.New("connection refused"))
+	info, ok := exp.irrevocable.Load(leaseID)
+	require.True(t, ok)
+	info.(*leaseEntry).ExpireTime = time.Now().Add(-2 * time.Hour)
+	info.(*leaseEntry).IrrevocableTime = time.Now().Add(-irrevocableFor)
+	return leaseID
+}
+
+// waitForRevokePrefixJob waits for the job started by the response to finish,
+// and returns its status.
+func waitForRevokePrefixJob(t *testing.T, ctx context.Context, exp *ExpirationManager, resp *logical.Response) map[string]interface{} {
+	t.Helper()
+
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+	var body struct {
+		Data struct {
+			JobID string `json:"job_id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Data[logical.HTTPRawBody].(string)), &body))
+	jobID := body.Data.JobID
+
+	var status map[string]interface{}
+	require.Eventually(t, func() bool {
+		job, err := exp.revokePrefixJob(ctx, jobID)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		status = job.status()
+		return status["status"] != revokePrefixJobStatusRunning
+	}, 10*time.Second, 10*time.Millisecond)
+	return status
+}
+
+// TestExpiration_IrrevocableCleanup ensures the cleanup job retries the
+// revocation of irrevocable leases, removes those which exceed
+// irrevocable_force_expire_after, and reports the outcome by mount.
+func TestExpiration_IrrevocableCleanup(t *testing.T) {
+	exp := mockExpiration(t)
+	ctx := namespace.RootContext(nil)
+	b := exp.core.systemBackend
+
+	req := logical.TestRequest(t, logical.ReadOperation, "leases/irrevocable/cleanup")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/config/revocation")
+	req.Data["irrevocable_force_expire_after"] = "24h"
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/config/revocation")
+	req.Data["irrevocable_cleanup_interval"] = "1s"
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	oldLeaseID := registerIrrevocableLease(t, ctx, exp, 48*time.Hour)
+	leaseID := registerIrrevocableLease(t, ctx, exp, time.Hour)
+
+	exp.attemptIrrevocableLeasesRevoke()
+
+	_, ok := exp.irrevocable.Load(oldLeaseID)
+	require.False(t, ok)
+	_, ok = exp.irrevocable.Load(leaseID)
+	require.True(t, ok)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "leases/irrevocable/cleanup")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 2, resp.Data["retried"])
+	require.Equal(t, 0, resp.Data["revoked"])
+	require.Equal(t, 1, resp.Data["force_expired"])
+	require.Equal(t, 1, resp.Data["failed"])
+	require.Equal(t, map[string]interface{}{
+		"mount-accessor-not-found": map[string]interface{}{
+			"revoked":       0,
+			"force_expired": 1,
+			"failed":        1,
+		},
+	}, resp.Data["mounts"])
+
+	req = logical.TestRequest(t, logical.ReadOperation, "leases/irrevocable/mounts")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Data["lease_count"])
+	mount := resp.Data["mounts"].(map[string]interface{})["mount-accessor-not-found"].(map[string]interface{})
+	require.Equal(t, 1, mount["lease_count"])
+	require.Equal(t, []map[string]interface{}{{
+		"error":       "connection refused",
+		"lease_count": 1,
+	}}, mount["errors"])
+}
+
+// TestExpiration_IrrevocableLeasesJobs ensures irrevocable leases can be
+// retried and removed in bulk by jobs.
+func TestExpiration_IrrevocableLeasesJobs(t *testing.T) {
+	exp := mockExpiration(t)
+	ctx := namespace.RootContext(nil)
+	b := exp.core.systemBackend
+
+	leaseIDs := []string{
+		registerIrrevocableLease(t, ctx, exp, time.Hour),
+		registerIrrevocableLease(t, ctx, exp, time.Hour),
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "leases/irrevocable/retry")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	status := waitForRevokePrefixJob(t, ctx, exp, resp)
+	require.Equal(t, revokePrefixJobOperationRetryIrrevocable, status["operation"])
+	require.Equal(t, revokePrefixJobStatusFailed, status["status"])
+	require.Equal(t, 2, status["total"])
+	require.Equal(t, 2, status["failed"])
+
+	// Removing the leases requires choosing the mounts
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/irrevocable/force-expire-bulk")
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data["mount_accessor"] = "nonexistent"
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data = map[string]interface{}{"all_mounts": true}
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	status = waitForRevokePrefixJob(t, ctx, exp, resp)
+	require.Equal(t, revokePrefixJobOperationExpireIrrevocable, status["operation"])
+	require.Equal(t, revokePrefixJobStatusCompleted, status["status"])
+	require.Equal(t, 2, status["revoked"])
+
+	for _, leaseID := range leaseIDs {
+		_, ok := exp.irrevocable.Load(leaseID)
+		require.False(t, ok)
+		le, err := exp.loadEntry(ctx, leaseID)
+		require.NoError(t, err)
+		require.Nil(t, le)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// leaseRevocationConfigPath is the path of the lease revocation
+	// configuration in the config/ view
+	leaseRevocationConfigPath = "lease-revocation"
+
+	// maxRevokeAttemptsLimit bounds the configurable number of revocation
+	// attempts, which are counted in a uint8 and double the backoff each time
+	maxRevokeAttemptsLimit = 20
+
+	// defaultIrrevocableCleanupInterval is how often the revocation of
+	// irrevocable leases is retried by default
+	defaultIrrevocableCleanupInterval = 24 * time.Hour
+
+	// minIrrevocableCleanupInterval bounds how often the revocation of
+	// irrevocable leases can be retried
+	minIrrevocableCleanupInterval = time.Minute
+)
+
+// leaseRevocationConfig is the retry policy of the revocation of expired
+// leases. Leases which fail to be revoked MaxAttempts times are marked
+// irrevocable.
+type leaseRevocationConfig struct {
+	MaxAttempts int `json:"max_attempts"`
+
+	// RetryBase is the baseline of the exponential backoff between attempts
+	RetryBase time.Duration `json:"retry_base"`
+
+	// MaxRetryDelay caps the backoff between attempts, if set
+	MaxRetryDelay time.Duration `json:"max_retry_delay"`
+
+	// IrrevocableCleanupInterval is how often the cleanup job retries the
+	// revocation of irrevocable leases, defaultIrrevocableCleanupInterval if
+	// zero
+	IrrevocableCleanupInterval time.Duration `json:"irrevocable_cleanup_interval"`
+
+	// IrrevocableForceExpireAfter is how long a lease may stay irrevocable
+	// before the cleanup job removes it ignoring the errors of its backend.
+	// Irrevocable leases are never removed by the job if zero.
+	IrrevocableForceExpireAfter time.Duration `json:"irrevocable_force_expire_after"`
+}
+
+func (c *leaseRevocationConfig) validate() error {
+	switch {
+	case c.MaxAttempts < 1 || c.MaxAttempts > maxRevokeAttemptsLimit:
+		return fmt.Errorf("max_attempts must be between 1 and %d", maxRevokeAttemptsLimit)
+	case c.RetryBase <= 0:
+		return errors.New("retry_base must be positive")
+	case c.MaxRetryDelay < 0:
+		return errors.New("max_retry_delay must not be negative")
+	case c.MaxRetryDelay != 0 && c.MaxRetryDelay < c.RetryBase:
+		return errors.New("max_retry_delay must not be less than retry_base")
+	case c.IrrevocableCleanupInterval != 0 && c.IrrevocableCleanupInterval < minIrrevocableCleanupInterval:
+		return fmt.Errorf("irrevocable_cleanup_interval must be at least %s", minIrrevocableCleanupInterval)
+	case c.IrrevocableForceExpireAfter < 0:
+		return errors.New("irrevocable_force_expire_after must not be negative")
+	}
+	return nil
+}
+
+// cleanupInterval returns how often the cleanup job retries the revocation of
+// irrevocable leases.
+func (c *leaseRevocationConfig) cleanupInterval() time.Duration {
+	if c.IrrevocableCleanupInterval == 0 {
+		return defaultIrrevocableCleanupInterval
+	}
+	return c.IrrevocableCleanupInterval
+}
+
+// defaultRevocationConfig returns the retry policy used when none is
+// configured.
+func (m *ExpirationManager) defaultRevocationConfig() *leaseRevocationConfig {
+	return &leaseRevocationConfig{
+		MaxAttempts: maxRevokeAttempts,
+		RetryBase:   m.revokeRetryBase,
+	}
+}
+
+// revocationConfig returns the current retry policy of revocations.
+func (m *ExpirationManager) revocationConfig() *leaseRevocationConfig {
+	if conf := m.revokeConfig.Load(); conf != nil {
+		return conf
+	}
+	return m.defaultRevocationConfig()
+}
+
+// setRevocationConfig validates, persists and applies the retry policy of
+// revocations.
+func (m *ExpirationManager) setRevocationConfig(ctx context.Context, conf *leaseRevocationConfig) error {
+	if err := conf.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(leaseRevocationConfigPath, conf)
+	if err != nil {
+		return fmt.Errorf("failed to create lease revocation config entry: %w", err)
+	}
+	if err := m.core.systemBarrierView.SubView("config/").Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save lease revocation config: %w", err)
+	}
+
+	m.revokeConfig.Store(conf)
+	m.resetIrrevocableCleanup()
+	return nil
+}
+
+// loadRevocationConfig loads the persisted retry policy of revocations.
+func (m *ExpirationManager) loadRevocationConfig(ctx context.Context) error {
+	out, err := m.core.systemBarrierView.SubView("config/").Get(ctx, leaseRevocationConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read lease revocation config: %w", err)
+	}
+	if out == nil {
+		return nil
+	}
+
+	conf := new(leaseRevocationConfig)
+	if err := out.DecodeJSON(conf); err != nil {
+		return fmt.Errorf("failed to decode lease revocation config: %w", err)
+	}
+	m.revokeConfig.Store(conf)
+	return nil
+}
+
+// ForceExpireIrrevocable removes an irrevocable lease of the namespace of
+// the context along with its secondary indexes, ignoring the errors of its
+// backend like revoke-force. It returns false if there is no such lease.
+func (m *ExpirationManager) ForceExpireIrrevocable(ctx context.Context, leaseID string) (bool, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+	if errors.Is(err, namespace.ErrNoNamespace) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if leaseNS.ID != ns.ID {
+		return false, nil
+	}
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return false, err
+	}
+	if le == nil {
+		return false, nil
+	}
+	if !le.isIrrevocable() {
+		return false, fmt.Errorf("lease %q is not irrevocable: %w", leaseID, logical.ErrInvalidRequest)
+	}
+
+	if err := m.revokeCommon(namespace.ContextWithNamespace(ctx, leaseNS), leaseID, true, false); err != nil {
+		return false, err
+	}
+	m.logger.Info("force expired irrevocable lease", "lease_id", leaseID, "revoke_error", le.RevokeErr)
+	return true, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpiration_RevocationConfig ensures the retry policy of revocations can
+// be read and updated, is validated, and is persisted.
+func TestExpiration_RevocationConfig(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	b := c.systemBackend
+
+	req := logical.TestRequest(t, logical.ReadOperation, "leases/config/revocation")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, maxRevokeAttempts, resp.Data["max_attempts"])
+	require.Equal(t, int64(0), resp.Data["max_retry_delay"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/config/revocation")
+	req.Data["max_attempts"] = 3
+	req.Data["retry_base"] = "30s"
+	req.Data["max_retry_delay"] = "10m"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// Unset parameters keep their value
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/config/revocation")
+	req.Data["max_attempts"] = 4
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "leases/config/revocation")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 4, resp.Data["max_attempts"])
+	require.Equal(t, int64(30), resp.Data["retry_base"])
+	require.Equal(t, int64(600), resp.Data["max_retry_delay"])
+
+	for _, data := range []map[string]interface{}{
+		{"max_attempts": 0},
+		{"max_attempts": maxRevokeAttemptsLimit + 1},
+		{"retry_base": 0},
+		{"max_retry_delay": "10s"},
+	} {
+		req = logical.TestRequest(t, logical.UpdateOperation, "leases/config/revocation")
+		req.Data = data
+		_, err = b.HandleRequest(ctx, req)
+		require.ErrorIs(t, err, logical.ErrInvalidRequest)
+	}
+
+	// The configuration is loaded on unseal
+	conf := c.expiration.revocationConfig()
+	c.expiration.revokeConfig.Store(nil)
+	require.NoError(t, c.expiration.loadRevocationConfig(ctx))
+	require.Equal(t, conf, c.expiration.revocationConfig())
+}
+
+// TestExpiration_RevocationRetryPolicy ensures leases are marked irrevocable
+// after the configured number of attempts, recording them, and that the
+// backoff between attempts is capped.
+func TestExpiration_RevocationRetryPolicy(t *testing.T) {
+	exp := mockExpiration(t)
+	ctx := namespace.RootContext(nil)
+	require.NoError(t, exp.setRevocationConfig(ctx, &leaseRevocationConfig{
+		MaxAttempts: 2,
+		RetryBase:   time.Hour,
+	}))
+
+	leaseID := registerOneLease(t, ctx, exp)
+	job, err := newRevocationJob(ctx, leaseID, namespace.RootNamespace, exp)
+	require.NoError(t, err)
+
+	job.OnFailure(errors.New("connection refused"))
+	le, err := exp.loadEntry(ctx, leaseID)
+	require.NoError(t, err)
+	require.False(t, le.isIrrevocable())
+
+	job.OnFailure(errors.New("connection refused"))
+	le, err = exp.loadEntry(ctx, leaseID)
+	require.NoError(t, err)
+	require.True(t, le.isIrrevocable())
+	require.Equal(t, 2, le.RevokeAttempts)
+	require.False(t, le.IrrevocableTime.IsZero())
+
+	out, _, err := exp.listIrrevocableLeases(ctx, false, false, MaxIrrevocableLeasesToReturn)
+	require.NoError(t, err)
+	leases := out["leases"].([]*leaseResponse)
+	require.Len(t, leases, 1)
+	require.Equal(t, 2, leases[0].RevokeAttempts)
+	require.Contains(t, leases[0].ErrMsg, "connection refused")
+
+	conf := &leaseRevocationConfig{
+		MaxAttempts:   6,
+		RetryBase:     10 * time.Second,
+		MaxRetryDelay: time.Minute,
+	}
+	require.Equal(t, time.Minute, job.revokeExponentialBackoff(conf, 5))
+	require.LessOrEqual(t, job.revokeExponentialBackoff(conf, 1), 30*time.Second)
+}
+
+// TestExpiration_ForceExpireIrrevocable ensures irrevocable leases can be
+// removed, and that other leases can't.
+func TestExpiration_ForceExpireIrrevocable(t *testing.T) {
+	exp := mockExpiration(t)
+	ctx := namespace.RootContext(nil)
+	b := exp.core.systemBackend
+
+	irrevocableID := registerOneLease(t, ctx, exp)
+	le, err := exp.loadEntry(ctx, irrevocableID)
+	require.NoError(t, err)
+	exp.pendingLock.Lock()
+	exp.markLeaseIrrevocable(ctx, le, errors.New("connection refused"))
+	exp.pendingLock.Unlock()
+
+	leaseID := registerOneLease(t, ctx, exp)
+	req := logical.TestRequest(t, logical.UpdateOperation, "leases/irrevocable/force-expire")
+	req.Data["lease_id"] = leaseID
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data["lease_id"] = "irrevocable/lease/nonexistent"
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data["lease_id"] = irrevocableID
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	le, err = exp.loadEntry(ctx, irrevocableID)
+	require.NoError(t, err)
+	require.Nil(t, le)
+	_, ok := exp.irrevocable.Load(irrevocableID)
+	require.False(t, ok)
+
+	le, err = exp.loadEntry(ctx, leaseID)
+	require.NoError(t, err)
+	require.NotNil(t, le)
+}
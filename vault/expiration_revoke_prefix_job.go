@@ -0,0 +1,331 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	revokePrefixJobStatusRunning   = "running"
+	revokePrefixJobStatusCompleted = "completed"
+	revokePrefixJobStatusFailed    = "failed"
+	revokePrefixJobStatusCanceled  = "canceled"
+
+	// The operations of the jobs, which either revoke the leases under a
+	// prefix, or retry or force the revocation of irrevocable leases
+	revokePrefixJobOperationRevokePrefix      = "revoke-prefix"
+	revokePrefixJobOperationRetryIrrevocable  = "retry-irrevocable"
+	revokePrefixJobOperationExpireIrrevocable = "force-expire-irrevocable"
+)
+
+var (
+	// revokePrefixJobWorkers is the number of leases of a revoke prefix job
+	// which are revoked concurrently.
+	revokePrefixJobWorkers = 10
+
+	// maxRevokePrefixJobs is the number of finished revoke prefix jobs which
+	// are retained.
+	maxRevokePrefixJobs = 100
+)
+
+// revokePrefixJob tracks the revocation of the leases under a prefix in the
+// background. Jobs are kept in memory by the active node, and are lost when
+// it is sealed or steps down. The bulk revocations of irrevocable leases are
+// tracked the same way, with the path of their mount as prefix.
+type revokePrefixJob struct {
+	l sync.Mutex
+
+	ID          string
+	Operation   string
+	Prefix      string
+	Force       bool
+	NamespaceID string
+	Status      string
+	Error       string
+	Total       int
+	Revoked     int
+	StartTime   time.Time
+	EndTime     time.Time
+
+	// FailedLeases maps the ID of the leases which failed to be revoked to
+	// the error returned when revoking them.
+	FailedLeases map[string]string
+}
+
+// status returns the progress of the job.
+func (j *revokePrefixJob) status() map[string]interface{} {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	var endTime string
+	if !j.EndTime.IsZero() {
+		endTime = j.EndTime.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"job_id":     j.ID,
+		"operation":  j.Operation,
+		"prefix":     j.Prefix,
+		"status":     j.Status,
+		"error":      j.Error,
+		"total":      j.Total,
+		"revoked":    j.Revoked,
+		"failed":     len(j.FailedLeases),
+		"pending":    j.Total - j.Revoked - len(j.FailedLeases),
+		"start_time": j.StartTime.Format(time.RFC3339),
+		"end_time":   endTime,
+	}
+}
+
+// failedLeases returns the sorted IDs of the leases which failed to be
+// revoked, along with their errors.
+func (j *revokePrefixJob) failedLeases() ([]string, map[string]interface{}) {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	keys := make([]string, 0, len(j.FailedLeases))
+	keyInfo := make(map[string]interface{}, len(j.FailedLeases))
+	for leaseID, err := range j.FailedLeases {
+		keys = append(keys, leaseID)
+		keyInfo[leaseID] = map[string]interface{}{
+			"error": err,
+		}
+	}
+	sort.Strings(keys)
+	return keys, keyInfo
+}
+
+func (j *revokePrefixJob) finish(status, err string) {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	j.Status = status
+	j.Error = err
+	j.EndTime = time.Now()
+}
+
+// RevokePrefixAsync starts a job revoking all the leases under the prefix in
+// the background, and returns its ID. The leases are revoked by a bounded
+// pool of workers, and leases which fail to be revoked are recorded on the job
+// rather than stopping it.
+func (m *ExpirationManager) RevokePrefixAsync(ctx context.Context, prefix string) (string, error) {
+	return m.startRevokePrefixJob(ctx, revokePrefixJobOperationRevokePrefix, prefix, false, func(ctx context.Context) ([]string, error) {
+		return m.revokePrefixJobLeases(ctx, prefix)
+	})
+}
+
+// startRevokePrefixJob starts a job of the operation revoking the leases
+// returned by leaseIDs in the background, ignoring the errors of their
+// backends if force is set, and returns its ID.
+func (m *ExpirationManager) startRevokePrefixJob(ctx context.Context, operation, prefix string, force bool, leaseIDs func(context.Context) ([]string, error)) (string, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	job := &revokePrefixJob{
+		ID:           id,
+		Operation:    operation,
+		Prefix:       prefix,
+		Force:        force,
+		NamespaceID:  ns.ID,
+		Status:       revokePrefixJobStatusRunning,
+		StartTime:    time.Now(),
+		FailedLeases: make(map[string]string),
+	}
+
+	m.revokePrefixJobsLock.Lock()
+	m.revokePrefixJobs[id] = job
+	m.pruneRevokePrefixJobsLocked()
+	m.revokePrefixJobsLock.Unlock()
+
+	go m.runRevokePrefixJob(namespace.ContextWithNamespace(m.quitContext, ns), job, leaseIDs)
+
+	return id, nil
+}
+
+func (m *ExpirationManager) runRevokePrefixJob(ctx context.Context, job *revokePrefixJob, listLeases func(context.Context) ([]string, error)) {
+	logger := m.logger.With("job_id", job.ID, "operation", job.Operation, "prefix", job.Prefix)
+	logger.Info("starting revoke prefix job")
+
+	leaseIDs, err := listLeases(ctx)
+	if err != nil {
+		logger.Error("revoke prefix job failed", "error", err)
+		job.finish(revokePrefixJobStatusFailed, err.Error())
+		return
+	}
+
+	job.l.Lock()
+	job.Total = len(leaseIDs)
+	job.l.Unlock()
+
+	leaseCh := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < revokePrefixJobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for leaseID := range leaseCh {
+				// Hold the state lock like the revocations of expired leases,
+				// since this isn't called from an API handler
+				m.coreStateLock.RLock()
+				err := m.revokeCommon(ctx, leaseID, job.Force, false)
+				m.coreStateLock.RUnlock()
+
+				job.l.Lock()
+				if err != nil {
+					job.FailedLeases[leaseID] = err.Error()
+				} else {
+					job.Revoked++
+				}
+				job.l.Unlock()
+
+				if err != nil {
+					logger.Error("failed to revoke lease", "lease_id", leaseID, "error", err)
+				}
+			}
+		}()
+	}
+
+	canceled := false
+	for _, leaseID := range leaseIDs {
+		select {
+		case <-m.quitCh:
+			canceled = true
+		case <-ctx.Done():
+			canceled = true
+		case leaseCh <- leaseID:
+		}
+		if canceled {
+			break
+		}
+	}
+	close(leaseCh)
+	wg.Wait()
+
+	job.l.Lock()
+	revoked, failed := job.Revoked, len(job.FailedLeases)
+	job.l.Unlock()
+
+	switch {
+	case canceled:
+		logger.Warn("revoke prefix job canceled", "revoked", revoked, "failed", failed)
+		job.finish(revokePrefixJobStatusCanceled, "revocation was canceled by the node shutting down or losing leadership")
+	case failed > 0:
+		logger.Error("revoke prefix job finished with failures", "revoked", revoked, "failed", failed)
+		job.finish(revokePrefixJobStatusFailed, fmt.Sprintf("failed to revoke %d of %d leases", failed, len(leaseIDs)))
+	default:
+		logger.Info("revoke prefix job completed", "revoked", revoked)
+		job.finish(revokePrefixJobStatusCompleted, "")
+	}
+
+	m.revokePrefixJobsLock.Lock()
+	m.pruneRevokePrefixJobsLocked()
+	m.revokePrefixJobsLock.Unlock()
+}
+
+// revokePrefixJobLeases returns the IDs of the leases under the prefix, which
+// is handled like in revokePrefixCommon.
+func (m *ExpirationManager) revokePrefixJobLeases(ctx context.Context, prefix string) ([]string, error) {
+	if m.inRestoreMode() {
+		m.restoreRequestLock.Lock()
+		defer m.restoreRequestLock.Unlock()
+	}
+
+	if !strings.HasSuffix(prefix, "/") {
+		le, err := m.loadEntry(ctx, prefix)
+		if err == nil && le != nil {
+			return []string{prefix}, nil
+		}
+		prefix = prefix + "/"
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := logical.CollectKeys(ctx, m.leaseView(ns).SubView(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for leases: %w", err)
+	}
+
+	leaseIDs := make([]string, 0, len(existing))
+	for _, suffix := range existing {
+		leaseIDs = append(leaseIDs, prefix+suffix)
+	}
+	return leaseIDs, nil
+}
+
+// pruneRevokePrefixJobsLocked removes the oldest finished jobs beyond
+// maxRevokePrefixJobs. The caller must hold revokePrefixJobsLock.
+func (m *ExpirationManager) pruneRevokePrefixJobsLocked() {
+	var finished []*revokePrefixJob
+	for _, job := range m.revokePrefixJobs {
+		job.l.Lock()
+		if job.Status != revokePrefixJobStatusRunning {
+			finished = append(finished, job)
+		}
+		job.l.Unlock()
+	}
+	if len(finished) <= maxRevokePrefixJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].StartTime.Before(finished[j].StartTime)
+	})
+	for _, job := range finished[:len(finished)-maxRevokePrefixJobs] {
+		delete(m.revokePrefixJobs, job.ID)
+	}
+}
+
+// revokePrefixJob returns the job with the ID in the namespace of the
+// context, or nil if there is none.
+func (m *ExpirationManager) revokePrefixJob(ctx context.Context, id string) (*revokePrefixJob, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.revokePrefixJobsLock.RLock()
+	defer m.revokePrefixJobsLock.RUnlock()
+
+	job, ok := m.revokePrefixJobs[id]
+	if !ok || job.NamespaceID != ns.ID {
+		return nil, nil
+	}
+	return job, nil
+}
+
+// listRevokePrefixJobs returns the jobs in the namespace of the context.
+func (m *ExpirationManager) listRevokePrefixJobs(ctx context.Context) ([]*revokePrefixJob, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.revokePrefixJobsLock.RLock()
+	defer m.revokePrefixJobsLock.RUnlock()
+
+	var jobs []*revokePrefixJob
+	for _, job := range m.revokePrefixJobs {
+		if job.NamespaceID == ns.ID {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
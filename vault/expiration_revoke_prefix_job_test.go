@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpiration_RevokePrefixAsync ensures revoke prefix requests with async
+// set return a job which revokes the leases in the background, records the
+// leases which fail to be revoked, and can be listed and read.
+func TestExpiration_RevokePrefixAsync(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	exp := c.expiration
+
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation == logical.RevokeOperation && req.Path == "fail" {
+				return nil, errors.New("connection refused")
+			}
+			return nil, nil
+		},
+	}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	err := c.router.Mount(noop, "prod/db/", &MountEntry{Path: "prod/db/", Type: "noop", UUID: "noop-uuid", Accessor: "noop-accessor", namespace: namespace.RootNamespace}, view)
+	require.NoError(t, err)
+
+	paths := []string{"prod/db/fail"}
+	for i := 0; i < 25; i++ {
+		paths = append(paths, "prod/db/creds/"+string(rune('a'+i)))
+	}
+	for _, path := range paths {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "foobar",
+		}
+		req.SetTokenEntry(&logical.TokenEntry{ID: "foobar", NamespaceID: "root"})
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: time.Hour,
+				},
+			},
+		}
+		_, err := exp.Register(ctx, req, resp, "")
+		require.NoError(t, err)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "leases/revoke-prefix/prod/db/")
+	req.Data["async"] = true
+	resp, err := c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+	jobs, err := exp.listRevokePrefixJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	jobID := jobs[0].ID
+	require.Contains(t, resp.Data[logical.HTTPRawBody], `"job_id":"`+jobID+`"`)
+
+	var status map[string]interface{}
+	require.Eventually(t, func() bool {
+		req = logical.TestRequest(t, logical.ReadOperation, "leases/revoke-prefix-jobs/"+jobID)
+		resp, err = c.systemBackend.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		status = resp.Data
+		return status["status"] != revokePrefixJobStatusRunning
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, revokePrefixJobStatusFailed, status["status"])
+	require.Equal(t, 26, status["total"])
+	require.Equal(t, 25, status["revoked"])
+	require.Equal(t, 1, status["failed"])
+	require.Equal(t, 0, status["pending"])
+	require.NotEmpty(t, status["end_time"])
+
+	req = logical.TestRequest(t, logical.ListOperation, "leases/revoke-prefix-jobs/"+jobID+"/failed")
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, resp.Data["keys"], 1)
+	failed := resp.Data["keys"].([]string)[0]
+	require.Contains(t, failed, "prod/db/fail/")
+	require.Contains(t, resp.Data["key_info"].(map[string]interface{})[failed].(map[string]interface{})["error"], "connection refused")
+
+	req = logical.TestRequest(t, logical.ListOperation, "leases/revoke-prefix-jobs")
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{jobID}, resp.Data["keys"])
+
+	// Jobs aren't visible from other namespaces
+	nsCtx := namespace.ContextWithNamespace(context.Background(), &namespace.Namespace{ID: "foo", Path: "foo/"})
+	job, err := exp.revokePrefixJob(nsCtx, jobID)
+	require.NoError(t, err)
+	require.Nil(t, job)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "leases/revoke-prefix-jobs/nonexistent")
+	resp, err = c.systemBackend.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+// TestExpiration_RevokePrefixJobsPruned ensures only the most recent finished
+// revoke prefix jobs are retained.
+func TestExpiration_RevokePrefixJobsPruned(t *testing.T) {
+	exp := mockExpiration(t)
+	ctx := namespace.RootContext(nil)
+
+	for i := 0; i < maxRevokePrefixJobs+10; i++ {
+		_, err := exp.RevokePrefixAsync(ctx, "prod/nonexistent/")
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		jobs, err := exp.listRevokePrefixJobs(ctx)
+		require.NoError(t, err)
+		for _, job := range jobs {
+			if job.status()["status"] == revokePrefixJobStatusRunning {
+				return false
+			}
+		}
+		return len(jobs) == maxRevokePrefixJobs
+	}, 10*time.Second, 10*time.Millisecond)
+}
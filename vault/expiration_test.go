@@ -2983,6 +2983,40 @@ func TestExpiration_FairsharingEnvVar(t *testing.T) {
 	}
 }
 
+func TestExpiration_FairsharingPerMountEnvVar(t *testing.T) {
+	testCases := []struct {
+		set      string
+		expected int
+	}{
+		{
+			set:      "",
+			expected: numExpirationWorkersTest / numExpirationWorkersPerMountDivisor,
+		},
+		{
+			set:      "5",
+			expected: 5,
+		},
+		{
+			set:      "0",
+			expected: numExpirationWorkersTest / numExpirationWorkersPerMountDivisor,
+		},
+		{
+			set:      "11",
+			expected: numExpirationWorkersTest / numExpirationWorkersPerMountDivisor,
+		},
+	}
+
+	defer os.Unsetenv(fairshareWorkersPerMountOverrideVar)
+	for _, tc := range testCases {
+		os.Setenv(fairshareWorkersPerMountOverrideVar, tc.set)
+		exp := mockExpiration(t)
+
+		if fairshare.GetMaxWorkersPerQueue(exp.jobManager) != tc.expected {
+			t.Errorf("bad workers per mount. expected %d, got %d", tc.expected, fairshare.GetMaxWorkersPerQueue(exp.jobManager))
+		}
+	}
+}
+
 // register one lease ID and return the leaseID
 func registerOneLease(t *testing.T, ctx context.Context, exp *ExpirationManager) string {
 	t.Helper()
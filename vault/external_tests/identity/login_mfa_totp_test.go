@@ -330,3 +330,164 @@ func TestLoginMfaGenerateTOTPTestAuditIncluded(t *testing.T) {
 		t.Fatalf("failed to destroy the MFA secret: %s", err)
 	}
 }
+
+func TestLoginMFA_TOTPLockoutAndRecoveryCodes(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+		LogicalBackends: map[string]logical.Factory{
+			"totp": totp.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	ctx := context.Background()
+
+	testhelpers.SetupTOTPMount(t, client)
+	mountAccessor := testhelpers.SetupUserpassMountAccessor(t, client)
+	_, entityID, _ := testhelpers.CreateEntityAndAlias(t, client, mountAccessor, "lockout-entity", "testuser")
+
+	err := client.Sys().PutPolicy("totp-recovery-codes", `
+path "identity/mfa/method/totp/recovery-codes" {
+	capabilities = ["update"]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("auth/userpass/users/testuser", map[string]interface{}{
+		"password":       "testpassword",
+		"token_policies": "totp-recovery-codes",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("identity/mfa/method/totp", map[string]interface{}{
+		"issuer":            "yCorp",
+		"lockout_threshold": 3,
+		"lockout_duration":  0,
+	})
+	if err == nil {
+		t.Fatal("expected an error setting lockout_threshold without lockout_duration")
+	}
+
+	methodID := testhelpers.SetupTOTPMethod(t, client, map[string]interface{}{
+		"issuer":                  "yCorp",
+		"period":                  30,
+		"max_validation_attempts": 10,
+		"lockout_threshold":       3,
+		"lockout_duration":        5,
+		"recovery_code_count":     2,
+	})
+
+	resp, err := client.Logical().Write("identity/mfa/method/totp/admin-generate", map[string]interface{}{
+		"entity_id": entityID,
+		"method_id": methodID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes := resp.Data["recovery_codes"].([]interface{})
+	if len(recoveryCodes) != 2 {
+		t.Fatalf("expected 2 recovery codes, got %v", resp.Data["recovery_codes"])
+	}
+	_, err = client.Logical().Write("totp/keys/lockout", map[string]interface{}{
+		"url": resp.Data["url"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testhelpers.SetupMFALoginEnforcement(t, client, map[string]interface{}{
+		"name":                "lockout",
+		"identity_entity_ids": []string{entityID},
+		"mfa_method_ids":      []string{methodID},
+	})
+
+	unauthClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unauthClient.ClearToken()
+	upMethod, err := upAuth.NewUserpassAuth("testuser", &upAuth.Password{FromString: "testpassword"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(mfaSecret *api.Secret, passcode string) (*api.Secret, error) {
+		return unauthClient.Auth().MFAValidate(ctx, mfaSecret, map[string]interface{}{
+			methodID: []string{passcode},
+		})
+	}
+
+	mfaSecret, err := unauthClient.Auth().MFALogin(ctx, upMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A recovery code can be used in place of a passcode, but only once
+	secret, err := validate(mfaSecret, recoveryCodes[0].(string))
+	if err != nil {
+		t.Fatalf("MFA validation with a recovery code failed: %v", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		t.Fatalf("MFA validation failed to return a ClientToken in secret: %v", secret)
+	}
+	userToken := secret.Auth.ClientToken
+
+	mfaSecret, err = unauthClient.Auth().MFALogin(ctx, upMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = validate(mfaSecret, recoveryCodes[0].(string)); err == nil {
+		t.Fatal("expected MFA validation with a used recovery code to fail")
+	}
+
+	// Consecutive failures lock the entity out, even with a valid passcode
+	for _, passcode := range []string{"111111", "222222"} {
+		if _, err = validate(mfaSecret, passcode); err == nil {
+			t.Fatal("MFA succeeded with an invalid passcode")
+		}
+	}
+	_, err = validate(mfaSecret, testhelpers.GetTOTPCodeFromEngine(t, client, "lockout"))
+	if err == nil || !strings.Contains(err.Error(), "locked out") {
+		t.Fatalf("expected a lockout error, got: %v", err)
+	}
+	_, err = validate(mfaSecret, recoveryCodes[1].(string))
+	if err == nil || !strings.Contains(err.Error(), "locked out") {
+		t.Fatalf("expected a lockout error, got: %v", err)
+	}
+
+	// The lockout is lifted after lockout_duration
+	time.Sleep(6 * time.Second)
+	if _, err = validate(mfaSecret, recoveryCodes[1].(string)); err != nil {
+		t.Fatalf("MFA validation failed after the lockout expired: %v", err)
+	}
+
+	// Recovery codes can be replaced by the entity
+	userClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userClient.SetToken(userToken)
+	resp, err = userClient.Logical().Write("identity/mfa/method/totp/recovery-codes", map[string]interface{}{
+		"method_id": methodID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCodes := resp.Data["recovery_codes"].([]interface{})
+	if len(newCodes) != 2 || newCodes[0] == recoveryCodes[0] {
+		t.Fatalf("unexpected recovery codes: %v", newCodes)
+	}
+	mfaSecret, err = unauthClient.Auth().MFALogin(ctx, upMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = validate(mfaSecret, strings.ToUpper(newCodes[1].(string))); err != nil {
+		t.Fatalf("MFA validation with a new recovery code failed: %v", err)
+	}
+}
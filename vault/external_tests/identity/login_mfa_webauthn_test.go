@@ -0,0 +1,339 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/hashicorp/vault/api"
+	upAuth "github.com/hashicorp/vault/api/auth/userpass"
+	"github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/testhelpers"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+const testWebAuthnOrigin = "https://vault.example.com"
+
+// softAuthenticator is a minimal WebAuthn authenticator, creating "none"
+// attestations and ES256 assertions.
+type softAuthenticator struct {
+	t       *testing.T
+	rpID    string
+	credID  []byte
+	key     *ecdsa.PrivateKey
+	counter uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credID := make([]byte, 16)
+	if _, err := rand.Read(credID); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{t: t, rpID: rpID, credID: credID, key: key}
+}
+
+func (a *softAuthenticator) clientData(ceremony string, options map[string]interface{}) []byte {
+	publicKey := options["publicKey"].(map[string]interface{})
+	clientData, err := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": publicKey["challenge"],
+		"origin":    testWebAuthnOrigin,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return clientData
+}
+
+func (a *softAuthenticator) authData(flags byte, attestedCredData []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	a.counter++
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	return append(data, attestedCredData...)
+}
+
+func (a *softAuthenticator) credential(response map[string]interface{}) string {
+	id := base64.RawURLEncoding.EncodeToString(a.credID)
+	raw, err := json.Marshal(map[string]interface{}{
+		"id":       id,
+		"rawId":    id,
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return string(raw)
+}
+
+// create answers the options of a registration, as navigator.credentials.create() would.
+func (a *softAuthenticator) create(options map[string]interface{}) string {
+	coseKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	attestedCredData := make([]byte, 16) // zero AAGUID
+	attestedCredData = binary.BigEndian.AppendUint16(attestedCredData, uint16(len(a.credID)))
+	attestedCredData = append(attestedCredData, a.credID...)
+	attestedCredData = append(attestedCredData, coseKey...)
+
+	// user present, user verified, attested credential data included
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, attestedCredData),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", options)),
+		"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+	})
+}
+
+// get answers the options of a login, as navigator.credentials.get() would.
+func (a *softAuthenticator) get(options map[string]interface{}) string {
+	clientData := a.clientData("webauthn.get", options)
+	authData := a.authData(0x05, nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+		"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+		"signature":         base64.RawURLEncoding.EncodeToString(signature),
+	})
+}
+
+func TestLoginMFA_WebAuthn(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	ctx := context.Background()
+
+	mountAccessor := testhelpers.SetupUserpassMountAccessor(t, client)
+	_, entityID, _ := testhelpers.CreateEntityAndAlias(t, client, mountAccessor, "webauthn-entity", "testuser")
+
+	err := client.Sys().PutPolicy("webauthn-register", `
+path "identity/mfa/method/webauthn/generate" {
+	capabilities = ["update"]
+}
+path "identity/mfa/method/webauthn/register" {
+	capabilities = ["update"]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("auth/userpass/users/testuser", map[string]interface{}{
+		"password":       "testpassword",
+		"token_policies": "webauthn-register",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid configurations are rejected
+	_, err = client.Logical().Write("identity/mfa/method/webauthn", map[string]interface{}{
+		"rp_id": "vault.example.com",
+	})
+	if err == nil {
+		t.Fatal("expected an error creating a method without rp_origins")
+	}
+	_, err = client.Logical().Write("identity/mfa/method/webauthn", map[string]interface{}{
+		"rp_id":           "vault.example.com",
+		"rp_origins":      testWebAuthnOrigin,
+		"allowed_aaguids": "cb69481e-8ff7-4039-93ec-0a2729a154a8",
+	})
+	if err == nil {
+		t.Fatal("expected an error setting allowed_aaguids without attestation")
+	}
+
+	resp, err := client.Logical().Write("identity/mfa/method/webauthn", map[string]interface{}{
+		"method_name":       "security-key",
+		"rp_id":             "vault.example.com",
+		"rp_origins":        testWebAuthnOrigin,
+		"user_verification": "required",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	methodID := resp.Data["method_id"].(string)
+
+	resp, err = client.Logical().Read("identity/mfa/method/webauthn/" + methodID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["rp_display_name"] != "vault.example.com" || resp.Data["attestation_conveyance"] != "none" {
+		t.Fatalf("unexpected method configuration: %#v", resp.Data)
+	}
+
+	// Register a credential with a token of the entity
+	userClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	upMethod, err := upAuth.NewUserpassAuth("testuser", &upAuth.Password{FromString: "testpassword"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := userClient.Auth().Login(ctx, upMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.EntityID != entityID {
+		t.Fatalf("expected token of entity %s, got %s", entityID, secret.Auth.EntityID)
+	}
+
+	authenticator := newSoftAuthenticator(t, "vault.example.com")
+	resp, err = userClient.Logical().Write("identity/mfa/method/webauthn/generate", map[string]interface{}{
+		"method_id": methodID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = userClient.Logical().Write("identity/mfa/method/webauthn/register", map[string]interface{}{
+		"method_id":  methodID,
+		"credential": authenticator.create(resp.Data["options"].(map[string]interface{})),
+		"name":       "yubikey",
+	})
+	if err != nil {
+		t.Fatalf("failed to register credential: %v", err)
+	}
+
+	// The registration options can only be answered once
+	_, err = userClient.Logical().Write("identity/mfa/method/webauthn/register", map[string]interface{}{
+		"method_id":  methodID,
+		"credential": authenticator.create(resp.Data["options"].(map[string]interface{})),
+	})
+	if err == nil {
+		t.Fatal("expected an error replaying a registration")
+	}
+
+	testhelpers.SetupMFALoginEnforcement(t, client, map[string]interface{}{
+		"auth_method_accessors": []string{mountAccessor},
+		"name":                  "webauthn-enforcement",
+		"mfa_method_ids":        []string{methodID},
+	})
+
+	unauthClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unauthClient.ClearToken()
+
+	login := func() *api.Secret {
+		t.Helper()
+		mfaSecret, err := unauthClient.Auth().MFALogin(ctx, upMethod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mfaSecret.Auth == nil || mfaSecret.Auth.MFARequirement == nil {
+			t.Fatalf("expected an MFA requirement, got %#v", mfaSecret)
+		}
+		return mfaSecret
+	}
+	challenge := func(mfaSecret *api.Secret) map[string]interface{} {
+		t.Helper()
+		resp, err := unauthClient.Logical().Write("sys/mfa/challenge", map[string]interface{}{
+			"mfa_request_id": mfaSecret.Auth.MFARequirement.MFARequestID,
+			"method_id":      methodID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data["options"].(map[string]interface{})
+	}
+
+	// Validation without a challenge fails
+	mfaSecret := login()
+	_, err = unauthClient.Auth().MFAValidate(ctx, mfaSecret, map[string]interface{}{
+		methodID: []string{authenticator.get(map[string]interface{}{"publicKey": map[string]interface{}{"challenge": "AAAA"}})},
+	})
+	if err == nil {
+		t.Fatal("expected MFA validation without a challenge to fail")
+	}
+
+	// A signed challenge satisfies the MFA requirement
+	options := challenge(mfaSecret)
+	publicKey := options["publicKey"].(map[string]interface{})
+	if publicKey["rpId"] != "vault.example.com" || publicKey["userVerification"] != "required" {
+		t.Fatalf("unexpected assertion options: %#v", publicKey)
+	}
+	assertion := authenticator.get(options)
+	secret, err = unauthClient.Auth().MFAValidate(ctx, mfaSecret, map[string]interface{}{
+		methodID: []string{assertion},
+	})
+	if err != nil {
+		t.Fatalf("MFA validation failed: %v", err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		t.Fatalf("MFA validation failed to return a ClientToken in secret: %v", secret)
+	}
+
+	// Replaying an assertion against a new challenge fails
+	mfaSecret = login()
+	challenge(mfaSecret)
+	_, err = unauthClient.Auth().MFAValidate(ctx, mfaSecret, map[string]interface{}{
+		methodID: []string{assertion},
+	})
+	if err == nil {
+		t.Fatal("expected MFA validation with a replayed assertion to fail")
+	}
+
+	// Once the credentials are destroyed, no challenge can be issued anymore
+	_, err = client.Logical().Write("identity/mfa/method/webauthn/admin-destroy", map[string]interface{}{
+		"method_id": methodID,
+		"entity_id": entityID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfaSecret = login()
+	_, err = unauthClient.Logical().Write("sys/mfa/challenge", map[string]interface{}{
+		"mfa_request_id": mfaSecret.Auth.MFARequirement.MFARequestID,
+		"method_id":      methodID,
+	})
+	if err == nil {
+		t.Fatal("expected an error issuing a challenge without registered credentials")
+	}
+}
@@ -542,3 +542,85 @@ func TestQuotas_RateLimitQuota(t *testing.T) {
 		t.Fatalf("unexpected number of failed requests: %d", numFail)
 	}
 }
+
+func TestQuotas_LeaseCountQuota_Mount(t *testing.T) {
+	conf, opts := teststorage.ClusterSetup(coreConfig, nil, nil)
+	opts.NoDefaultQuotas = true
+	cluster := vault.NewTestCluster(t, conf, opts)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	setupMounts(t, client)
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("sys/quotas/lease-count/lcq", map[string]interface{}{
+		"mount_accessor": mounts["pki/"].Accessor,
+		"max_leases":     2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func() error {
+		_, err := client.Logical().Write("pki/issue/test", map[string]interface{}{
+			"common_name": "foo.testvault.com",
+		})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := issue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := issue(); err == nil {
+		t.Fatal("expected the lease count quota to reject the request")
+	}
+
+	resp, err := client.Logical().Read("sys/quotas/lease-count/lcq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, "pki/", resp.Data["path"])
+	require.Equal(t, "reject", resp.Data["enforcement"])
+	require.Equal(t, "2", fmt.Sprint(resp.Data["lease_count"]))
+
+	// Alerting on the quota allows the requests again
+	_, err = client.Logical().Write("sys/quotas/lease-count/lcq", map[string]interface{}{
+		"path":        "pki/",
+		"max_leases":  2,
+		"enforcement": "alert",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issue(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoking the leases frees up room under the quota
+	_, err = client.Logical().Write("sys/quotas/lease-count/lcq", map[string]interface{}{
+		"path":       "pki/",
+		"max_leases": 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issue(); err == nil {
+		t.Fatal("expected the lease count quota to reject the request")
+	}
+	if err := client.Sys().RevokePrefix("pki/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := issue(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -210,6 +210,33 @@ func TestRaft_Autopilot_Configuration(t *testing.T) {
 	configCheckFunc(config)
 }
 
+// TestRaft_Autopilot_CleanupDeadServers verifies that the cleanup of dead
+// servers can only be triggered when it is enabled in the autopilot
+// configuration.
+func TestRaft_Autopilot_CleanupDeadServers(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		DisableFollowerJoins: true,
+		InmemCluster:         true,
+		EnableAutopilot:      true,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().RaftAutopilotCleanupDeadServers()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "dead server cleanup is disabled")
+
+	_, err = client.Logical().Write("sys/storage/raft/autopilot/configuration", map[string]interface{}{
+		"cleanup_dead_servers": true,
+		"min_quorum":           3,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Sys().RaftAutopilotCleanupDeadServers())
+}
+
 // TestRaft_Autopilot_Stabilization_Delay verifies that if a node takes a long
 // time to become ready, it doesn't get promoted to voter until then.
 func TestRaft_Autopilot_Stabilization_Delay(t *testing.T) {
@@ -419,6 +446,76 @@ func TestRaft_VotersStayVoters(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestRaft_Autopilot_NonVoters ensures that nodes joined as non-voters are not
+// promoted by autopilot, and that operators can promote and demote peers.
+func TestRaft_Autopilot_NonVoters(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		DisableFollowerJoins: true,
+		InmemCluster:         true,
+		EnableAutopilot:      true,
+		PhysicalFactoryConfig: map[string]interface{}{
+			"autopilot_reconcile_interval": "300ms",
+			"autopilot_update_interval":    "100ms",
+		},
+	})
+	defer cluster.Cleanup()
+	testhelpers.WaitForActiveNode(t, cluster)
+
+	client := cluster.Cores[0].Client
+	_, err := client.Logical().Write("sys/storage/raft/autopilot/configuration", map[string]interface{}{
+		"server_stabilization_time": "2s",
+	})
+	require.NoError(t, err)
+
+	joinAndUnseal(t, cluster.Cores[1], cluster, true, true)
+	joinAsVoterAndUnseal(t, cluster.Cores[2], cluster)
+
+	votersEqual := func(expected ...string) func() error {
+		return func() error {
+			state, err := client.Sys().RaftAutopilotState()
+			if err != nil {
+				return err
+			}
+			if !strutil.EquivalentSlices(state.Voters, expected) {
+				return fmt.Errorf("expected voters %v, got %v", expected, state.Voters)
+			}
+			return nil
+		}
+	}
+
+	// core-2 is promoted once stable, core-1 must stay a non-voter
+	testhelpers.RetryUntil(t, 30*time.Second, votersEqual("core-0", "core-2"))
+	time.Sleep(5 * time.Second)
+	require.NoError(t, votersEqual("core-0", "core-2")())
+
+	_, err = client.Logical().Write("sys/storage/raft/promote", map[string]interface{}{
+		"server_id": "core-1",
+	})
+	require.NoError(t, err)
+	testhelpers.RetryUntil(t, 10*time.Second, votersEqual("core-0", "core-1", "core-2"))
+
+	_, err = client.Logical().Write("sys/storage/raft/demote", map[string]interface{}{
+		"server_id": "core-2",
+	})
+	require.NoError(t, err)
+	testhelpers.RetryUntil(t, 10*time.Second, votersEqual("core-0", "core-1"))
+
+	// Autopilot must neither promote core-2 again nor demote core-1
+	time.Sleep(5 * time.Second)
+	require.NoError(t, votersEqual("core-0", "core-1")())
+
+	_, err = client.Logical().Write("sys/storage/raft/demote", map[string]interface{}{
+		"server_id": "core-0",
+	})
+	require.Error(t, err)
+
+	_, err = client.Logical().Write("sys/storage/raft/promote", map[string]interface{}{
+		"server_id": "core-5",
+	})
+	require.Error(t, err)
+}
+
 // TestRaft_Autopilot_DeadServerCleanup tests that dead servers are correctly
 // removed by Vault and autopilot when a node stops and a replacement node joins.
 // The expected behavior is that removing a node from a 3 node cluster wouldn't
@@ -7,11 +7,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -510,6 +512,148 @@ func TestRaft_SnapshotAPI(t *testing.T) {
 	}
 }
 
+// TestRaft_SnapshotAPI_Chunked verifies that a snapshot uploaded in chunks is
+// verified against its SHA256 sum and restored, reporting its progress.
+func TestRaft_SnapshotAPI_Chunked(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, nil)
+	defer cluster.Cleanup()
+
+	leaderClient := cluster.Cores[0].Client
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err := leaderClient.Logical().Write(fmt.Sprintf("secret/%d", i), map[string]interface{}{
+			"test": "data",
+		})
+		require.NoError(t, err)
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, leaderClient.Sys().RaftSnapshot(buf))
+	snap := buf.Bytes()
+
+	for i := 10; i < 20; i++ {
+		_, err := leaderClient.Logical().Write(fmt.Sprintf("secret/%d", i), map[string]interface{}{
+			"test": "data",
+		})
+		require.NoError(t, err)
+	}
+
+	// An upload whose SHA256 sum doesn't match is not restored
+	status, err := leaderClient.Sys().RaftSnapshotUploadStartWithContext(ctx, int64(len(snap)), strings.Repeat("00", sha256.Size), false)
+	require.NoError(t, err)
+	require.Equal(t, "uploading", status.State)
+	status, err = leaderClient.Sys().RaftSnapshotUploadChunkWithContext(ctx, status.UploadID, 0, bytes.NewReader(snap))
+	require.NoError(t, err)
+	require.EqualValues(t, len(snap), status.BytesReceived)
+	status = waitForSnapshotUpload(t, leaderClient, status.UploadID, "failed")
+	require.Contains(t, status.Error, "the SHA256 sum of the snapshot is")
+
+	status, err = leaderClient.Sys().RaftSnapshotRestoreInChunksWithContext(ctx, bytes.NewReader(snap), false, int64(len(snap)/3+1), 0)
+	require.NoError(t, err)
+	require.EqualValues(t, len(snap), status.BytesReceived)
+	status = waitForSnapshotUpload(t, leaderClient, status.UploadID, "restored")
+	require.EqualValues(t, len(snap), status.BytesVerified)
+	require.Equal(t, status.RestoreSize, status.BytesRestored)
+
+	secret, err := leaderClient.Logical().List("secret/")
+	require.NoError(t, err)
+	require.Len(t, secret.Data["keys"], 10)
+}
+
+func waitForSnapshotUpload(t *testing.T, client *api.Client, uploadID, state string) *api.RaftSnapshotUploadStatus {
+	t.Helper()
+
+	var status *api.RaftSnapshotUploadStatus
+	corehelpers.RetryUntil(t, 30*time.Second, func() error {
+		var err error
+		status, err = client.Sys().RaftSnapshotUploadStatusWithContext(context.Background(), uploadID)
+		switch {
+		case err != nil:
+			return err
+		case status == nil:
+			return errors.New("snapshot upload not found")
+		case status.State != state:
+			return fmt.Errorf("snapshot upload is %s", status.State)
+		}
+		return nil
+	})
+	return status
+}
+
+// TestRaft_SnapshotAuto verifies that an automated snapshot configuration
+// writes snapshots to a local directory, applies its retention policy and
+// reports its status.
+func TestRaft_SnapshotAuto(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, nil)
+	defer cluster.Cleanup()
+
+	leaderClient := cluster.Cores[0].Client
+	dir := t.TempDir()
+
+	_, err := leaderClient.Logical().Write("sys/storage/raft/snapshot-auto/config/test", map[string]interface{}{
+		"interval":     "1s",
+		"retain":       2,
+		"storage_type": "local",
+		"path_prefix":  dir,
+	})
+	require.Error(t, err, "expected local_max_space to be required")
+
+	_, err = leaderClient.Logical().Write("sys/storage/raft/snapshot-auto/config/test", map[string]interface{}{
+		"interval":        "1s",
+		"retain":          2,
+		"storage_type":    "local",
+		"path_prefix":     dir,
+		"local_max_space": 100 * 1024 * 1024,
+	})
+	require.NoError(t, err)
+
+	list, err := leaderClient.Logical().List("sys/storage/raft/snapshot-auto/config")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"test"}, list.Data["keys"])
+
+	config, err := leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/config/test")
+	require.NoError(t, err)
+	require.Equal(t, "vault-snapshot", config.Data["file_prefix"])
+
+	// Wait for enough snapshots for the retention policy to kick in
+	var firstURL string
+	corehelpers.RetryUntil(t, 30*time.Second, func() error {
+		status, err := leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/status/test")
+		if err != nil {
+			return err
+		}
+		if errStr := status.Data["last_snapshot_error"].(string); errStr != "" {
+			t.Fatalf("snapshot failed: %s", errStr)
+		}
+		url := status.Data["snapshot_url"].(string)
+		switch {
+		case url == "":
+			return errors.New("no snapshot taken yet")
+		case firstURL == "":
+			firstURL = url
+			return errors.New("waiting for more snapshots")
+		}
+		if _, err := os.Stat(strings.TrimPrefix(firstURL, "file://")); !os.IsNotExist(err) {
+			return errors.New("first snapshot not pruned yet")
+		}
+		return nil
+	})
+
+	_, err = leaderClient.Logical().Delete("sys/storage/raft/snapshot-auto/config/test")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(entries), 2)
+
+	status, err := leaderClient.Logical().Read("sys/storage/raft/snapshot-auto/status/test")
+	require.NoError(t, err)
+	require.Nil(t, status)
+}
+
 func TestRaft_SnapshotAPI_MidstreamFailure(t *testing.T) {
 	// defer goleak.VerifyNone(t)
 	t.Parallel()
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"time"
+)
+
+// healthStorageProbeTimeout bounds the storage read of the health detail
+const healthStorageProbeTimeout = 5 * time.Second
+
+// HealthDetail is the health of the subsystems of an unsealed node. The
+// subsystems which aren't running on the node, such as the expiration manager
+// of a standby, are nil.
+type HealthDetail struct {
+	Storage    *StorageHealth    `json:"storage"`
+	Expiration *ExpirationHealth `json:"expiration,omitempty"`
+	Audit      *AuditHealth      `json:"audit,omitempty"`
+	Plugins    *PluginsHealth    `json:"plugins,omitempty"`
+}
+
+// StorageHealth is the result of a read of the storage backend, bypassing
+// the cache.
+type StorageHealth struct {
+	Latency time.Duration `json:"-"`
+	Error   string        `json:"error,omitempty"`
+
+	LatencyMillis int64 `json:"latency_ms"`
+}
+
+// ExpirationHealth is the lease backlog of the expiration manager.
+type ExpirationHealth struct {
+	Leases            int `json:"leases"`
+	IrrevocableLeases int `json:"irrevocable_leases"`
+
+	// PendingRevocations is the number of expired leases queued for
+	// revocation
+	PendingRevocations int `json:"pending_revocations"`
+}
+
+// AuditHealth is the status of the audit devices as a whole, since entries
+// only fail to be logged when no device succeeds.
+type AuditHealth struct {
+	Devices             int    `json:"devices"`
+	ConsecutiveFailures uint64 `json:"consecutive_failures"`
+	LastFailureTime     string `json:"last_failure_time,omitempty"`
+}
+
+// PluginsHealth is the status of the external plugin processes.
+type PluginsHealth struct {
+	Running int `json:"running"`
+
+	// Exited are the names of the plugins whose process exited while still in
+	// use, until they are reloaded
+	Exited []string `json:"exited"`
+}
+
+// HealthDetail returns the health of the subsystems of the node, or nil if it
+// is sealed.
+func (c *Core) HealthDetail(ctx context.Context) *HealthDetail {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	if c.Sealed() {
+		return nil
+	}
+
+	detail := &HealthDetail{
+		Storage: c.probeStorage(ctx),
+	}
+
+	if c.expiration != nil {
+		m := c.expiration
+		m.pendingLock.RLock()
+		detail.Expiration = &ExpirationHealth{
+			Leases:            m.leaseCount,
+			IrrevocableLeases: m.irrevocableLeaseCount,
+		}
+		m.pendingLock.RUnlock()
+		detail.Expiration.PendingRevocations = m.jobManager.GetPendingJobCount()
+	}
+
+	if c.auditBroker != nil {
+		c.auditBroker.RLock()
+		devices := len(c.auditBroker.backends)
+		c.auditBroker.RUnlock()
+
+		failures, last := c.auditBroker.failures()
+		detail.Audit = &AuditHealth{
+			Devices:             devices,
+			ConsecutiveFailures: failures,
+		}
+		if !last.IsZero() {
+			detail.Audit.LastFailureTime = last.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if c.pluginCatalog != nil {
+		running, exited := c.pluginCatalog.processes()
+		detail.Plugins = &PluginsHealth{
+			Running: running,
+			Exited:  exited,
+		}
+	}
+
+	return detail
+}
+
+// probeStorage times a read of the keyring from the underlying storage, so
+// that the physical cache doesn't hide a slow or unavailable backend.
+func (c *Core) probeStorage(ctx context.Context) *StorageHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthStorageProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.underlyingPhysical.Get(ctx, keyringPath)
+	health := &StorageHealth{
+		Latency: time.Since(start),
+	}
+	health.LatencyMillis = health.Latency.Milliseconds()
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
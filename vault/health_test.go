@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCore_HealthDetail(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := context.Background()
+
+	detail := c.HealthDetail(ctx)
+	require.NotNil(t, detail)
+	require.NotNil(t, detail.Storage)
+	require.Empty(t, detail.Storage.Error)
+	require.NotNil(t, detail.Expiration)
+	require.Zero(t, detail.Expiration.PendingRevocations)
+	require.NotNil(t, detail.Audit)
+	require.Zero(t, detail.Audit.ConsecutiveFailures)
+	require.Empty(t, detail.Audit.LastFailureTime)
+	require.NotNil(t, detail.Plugins)
+	require.Empty(t, detail.Plugins.Exited)
+
+	// Failures are counted until an entry is logged again
+	c.auditBroker.recordResult(errors.New("no audit backend succeeded"))
+	c.auditBroker.recordResult(errors.New("no audit backend succeeded"))
+	detail = c.HealthDetail(ctx)
+	require.Equal(t, uint64(2), detail.Audit.ConsecutiveFailures)
+	require.NotEmpty(t, detail.Audit.LastFailureTime)
+
+	c.auditBroker.recordResult(nil)
+	detail = c.HealthDetail(ctx)
+	require.Zero(t, detail.Audit.ConsecutiveFailures)
+	require.NotEmpty(t, detail.Audit.LastFailureTime)
+
+	require.NoError(t, c.Seal(root))
+	require.Nil(t, c.HealthDetail(ctx))
+}
@@ -127,6 +127,16 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 	return iStore, nil
 }
 
+// HandleRequest handles requests to the identity backend. While entities are
+// loaded in the background, requests wait for them all to be loaded.
+func (i *IdentityStore) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if err := i.waitForLazyLoad(ctx); err != nil {
+		return nil, err
+	}
+
+	return i.Backend.HandleRequest(ctx, req)
+}
+
 func (i *IdentityStore) paths() []*framework.Path {
 	return framework.PathAppend(
 		entityPaths(i),
@@ -143,7 +153,10 @@ func (i *IdentityStore) paths() []*framework.Path {
 		mfaOktaPaths(i),
 		mfaDuoPaths(i),
 		mfaPingIDPaths(i),
+		mfaWebAuthnPaths(i),
+		mfaWebAuthnExtraPaths(i),
 		mfaLoginEnforcementPaths(i),
+		scimPaths(i),
 	)
 }
 
@@ -279,6 +292,23 @@ func mfaTOTPPaths(i *IdentityStore) []*framework.Path {
 				Type:        framework.TypeInt,
 				Description: `Max number of allowed validation attempts.`,
 			},
+			"rate_limit_period": {
+				Type:        framework.TypeDurationSecond,
+				Description: `The window in which at most max_validation_attempts validations are allowed per entity. Defaults to the period.`,
+			},
+			"lockout_threshold": {
+				Type:        framework.TypeInt,
+				Description: `The number of consecutive failed validations after which the entity is locked out of the method. Zero disables the lockout.`,
+			},
+			"lockout_duration": {
+				Type:        framework.TypeDurationSecond,
+				Default:     900,
+				Description: `How long an entity stays locked out after its last failed validation.`,
+			},
+			"recovery_code_count": {
+				Type:        framework.TypeInt,
+				Description: `The number of one-time recovery codes generated along with the secret. Recovery codes can be used in place of a passcode. Zero disables recovery codes.`,
+			},
 			"issuer": {
 				Type:        framework.TypeString,
 				Description: `The name of the key's issuing organization.`,
@@ -341,6 +371,28 @@ func mfaTOTPExtraPaths(i *IdentityStore) []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "mfa/method/totp/recovery-codes$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mfa",
+				OperationVerb:   "generate",
+				OperationSuffix: "totp-recovery-codes",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"method_id": {
+					Type:        framework.TypeString,
+					Description: `The unique identifier for this MFA method.`,
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                  i.handleLoginMFATOTPRecoveryCodesUpdate,
+					ForwardPerformanceStandby: true,
+					Summary:                   "Replace the TOTP recovery codes of the given method ID on the entity of the token.",
+				},
+			},
+		},
 		{
 			Pattern: "mfa/method/totp/admin-generate$",
 			DisplayAttrs: &framework.DisplayAttributes{
@@ -496,6 +548,130 @@ func mfaPingIDPaths(i *IdentityStore) []*framework.Path {
 	)
 }
 
+func mfaWebAuthnPaths(i *IdentityStore) []*framework.Path {
+	return makeMFAMethodPaths(
+		mfaMethodTypeWebAuthn,
+		mfaMethodTypeWebAuthn,
+		map[string]*framework.FieldSchema{
+			"method_name": {
+				Type:        framework.TypeString,
+				Description: `The unique name identifier for this MFA method.`,
+			},
+			"rp_id": {
+				Type:        framework.TypeString,
+				Description: `The relying party ID, i.e. the effective domain credentials are scoped to.`,
+			},
+			"rp_display_name": {
+				Type:        framework.TypeString,
+				Description: `The relying party name shown by authenticators. Defaults to the relying party ID.`,
+			},
+			"rp_origins": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The fully qualified origins, e.g. "https://vault.example.com", from which WebAuthn ceremonies are accepted.`,
+			},
+			"attestation_conveyance": {
+				Type:        framework.TypeString,
+				Default:     "none",
+				Description: `The attestation requested from authenticators on registration. Options include none, indirect, direct and enterprise.`,
+			},
+			"user_verification": {
+				Type:        framework.TypeString,
+				Default:     "preferred",
+				Description: `Whether the authenticator has to verify the user, e.g. through a PIN or biometrics. Options include required, preferred and discouraged.`,
+			},
+			"allowed_aaguids": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `If set, only authenticators whose attested AAGUID is in this list can be registered. Requires direct or enterprise attestation_conveyance.`,
+			},
+		},
+		i,
+	)
+}
+
+func mfaWebAuthnExtraPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mfa/method/webauthn/generate$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mfa",
+				OperationVerb:   "generate",
+				OperationSuffix: "webauthn-registration-options",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"method_id": {
+					Type:        framework.TypeString,
+					Description: `The unique identifier for this MFA method.`,
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                  i.handleLoginMFAGenerateUpdate,
+					Summary:                   "Start the registration of a WebAuthn credential for the given method ID on the requesting entity.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+		},
+		{
+			Pattern: "mfa/method/webauthn/register$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mfa",
+				OperationVerb:   "register",
+				OperationSuffix: "webauthn-credential",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"method_id": {
+					Type:        framework.TypeString,
+					Description: `The unique identifier for this MFA method.`,
+					Required:    true,
+				},
+				"credential": {
+					Type:        framework.TypeString,
+					Description: `The JSON encoded PublicKeyCredential returned by navigator.credentials.create().`,
+					Required:    true,
+				},
+				"name": {
+					Type:        framework.TypeString,
+					Description: `A name for the credential, to tell the authenticators of an entity apart.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                  i.handleLoginMFAWebAuthnRegisterUpdate,
+					Summary:                   "Complete the registration of a WebAuthn credential for the given method ID on the requesting entity.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+		},
+		{
+			Pattern: "mfa/method/webauthn/admin-destroy$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mfa",
+				OperationVerb:   "admin-destroy",
+				OperationSuffix: "webauthn-credentials",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"method_id": {
+					Type:        framework.TypeString,
+					Description: "The unique identifier for this MFA method.",
+					Required:    true,
+				},
+				"entity_id": {
+					Type:        framework.TypeString,
+					Description: "Identifier of the entity from which the WebAuthn credentials need to be removed.",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.handleLoginMFAAdminDestroyUpdate,
+					Summary:  "Destroys the WebAuthn credentials for the given MFA method ID on the given entity",
+				},
+			},
+		},
+	}
+}
+
 func mfaLoginEnforcementPaths(i *IdentityStore) []*framework.Path {
 	return []*framework.Path{
 		{
@@ -1154,6 +1330,11 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 		return nil, false, fmt.Errorf("empty alias name")
 	}
 
+	// Aliases can't be looked up before all entities are loaded
+	if err := i.waitForLazyLoad(ctx); err != nil {
+		return nil, false, err
+	}
+
 	mountValidationResp := i.router.ValidateMountByAccessor(alias.MountAccessor)
 	if mountValidationResp == nil {
 		return nil, false, fmt.Errorf("invalid mount accessor %q", alias.MountAccessor)
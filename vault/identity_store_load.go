@@ -0,0 +1,347 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/storagepacker"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// identityLazyLoad tracks the loading of entities into MemDB while it happens
+// in the background.
+type identityLazyLoad struct {
+	ctx context.Context
+
+	// doneCh is closed once all entities are loaded
+	doneCh chan struct{}
+
+	// buckets holds the entity buckets which are loaded, or being loaded
+	lock    sync.Mutex
+	buckets map[string]*entityBucketLoad
+}
+
+// entityBucketLoad is the loading of a single entity bucket into MemDB
+type entityBucketLoad struct {
+	doneCh chan struct{}
+	err    error
+}
+
+// loadIdentityStoreArtifactsLazily loads groups and OIDC clients into MemDB,
+// and starts loading entities in the background, so that unsealing doesn't
+// wait for all entities to be loaded. Until they are, entities looked up by
+// ID are loaded on demand, along with the other entities of their storage
+// bucket, while lookups which need all of them, such as logins or requests
+// to the identity backend, wait for the loading to complete.
+func (c *Core) loadIdentityStoreArtifactsLazily(ctx context.Context) error {
+	i := c.identityStore
+	i.startLazyLoad(ctx)
+
+	loadFunc := func(ctx context.Context) error {
+		if err := i.loadGroups(ctx); err != nil {
+			return err
+		}
+		return i.loadOIDCClients(ctx)
+	}
+
+	err := loadFunc(ctx)
+	if err != nil && errwrap.Contains(err, errDuplicateIdentityName.Error()) {
+		if err := i.enableCaseSensitiveNames(ctx); err != nil {
+			return err
+		}
+		err = loadFunc(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := i.loadEntitiesLazily(ctx)
+		if err != nil && errwrap.Contains(err, errDuplicateIdentityName.Error()) && !i.disableLowerCasedNames {
+			// Requests made until now may have seen entities of the MemDB
+			// instance which is about to be replaced, but nothing could have
+			// been written to it as writes wait for the loading to complete.
+			if err = i.enableCaseSensitiveNames(ctx); err == nil {
+				if err = loadFunc(ctx); err == nil {
+					err = i.loadEntitiesLazily(ctx)
+				}
+			}
+		}
+		if err == nil {
+			err = i.loadCachedEntitiesOfLocalAliases(ctx)
+		}
+		if err == nil {
+			err = i.pruneGroupMemberEntities(ctx)
+		}
+
+		switch {
+		case err == nil:
+			i.finishLazyLoad()
+		case ctx.Err() != nil:
+			// The core is being sealed
+		default:
+			c.logger.Error("failed to load identity entities", "error", err)
+			c.logger.Error("shutting down")
+			if err := c.Shutdown(); err != nil {
+				c.logger.Error("error shutting down core", "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// enableCaseSensitiveNames sets the identity store to operate on case
+// sensitive identity names, and swaps the MemDB instance by one which does.
+func (i *IdentityStore) enableCaseSensitiveNames(ctx context.Context) error {
+	i.logger.Warn("enabling case sensitive identity names")
+	i.disableLowerCasedNames = true
+
+	if l := i.lazyLoad.Load(); l != nil {
+		l.lock.Lock()
+		l.buckets = make(map[string]*entityBucketLoad)
+		l.lock.Unlock()
+	}
+
+	return i.resetDB(ctx)
+}
+
+// startLazyLoad marks entities as being loaded in the background
+func (i *IdentityStore) startLazyLoad(ctx context.Context) {
+	i.lazyLoad.Store(&identityLazyLoad{
+		ctx:     ctx,
+		doneCh:  make(chan struct{}),
+		buckets: make(map[string]*entityBucketLoad),
+	})
+}
+
+// finishLazyLoad marks all entities as loaded
+func (i *IdentityStore) finishLazyLoad() {
+	l := i.lazyLoad.Swap(nil)
+	if l != nil {
+		close(l.doneCh)
+		i.logger.Info("identity entities loaded")
+	}
+}
+
+// lazyLoading returns true while entities are being loaded in the background
+func (i *IdentityStore) lazyLoading() bool {
+	return i.lazyLoad.Load() != nil
+}
+
+// lazyLoadContext returns the context entities are loaded with, for lookups
+// which have no context of their own.
+func (i *IdentityStore) lazyLoadContext() context.Context {
+	if l := i.lazyLoad.Load(); l != nil {
+		return l.ctx
+	}
+	return context.Background()
+}
+
+// waitForLazyLoad waits until all entities are loaded, if they are being
+// loaded in the background.
+func (i *IdentityStore) waitForLazyLoad(ctx context.Context) error {
+	l := i.lazyLoad.Load()
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case <-l.doneCh:
+		return nil
+	case <-l.ctx.Done():
+		return fmt.Errorf("identity entities were not loaded: %w", l.ctx.Err())
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for identity entities to be loaded: %w", ctx.Err())
+	}
+}
+
+// loadEntityBucketOnDemand loads the bucket holding the entity with the given
+// ID into MemDB if entities are being loaded in the background and the bucket
+// isn't loaded yet.
+func (i *IdentityStore) loadEntityBucketOnDemand(entityID string) error {
+	l := i.lazyLoad.Load()
+	if l == nil {
+		return nil
+	}
+
+	_, err := i.loadLazyEntityBucket(l, i.entityPacker.BucketKey(entityID))
+	return err
+}
+
+// loadLazyEntityBucket loads the entity bucket into MemDB, unless it was
+// already loaded. If the bucket is being loaded, it waits for the loading to
+// complete. It returns the accessors of the mounts on which entities have
+// more than one alias, see loadEntitiesFromBucket.
+func (i *IdentityStore) loadLazyEntityBucket(l *identityLazyLoad, key string) ([]string, error) {
+	l.lock.Lock()
+	if load, ok := l.buckets[key]; ok {
+		l.lock.Unlock()
+		select {
+		case <-load.doneCh:
+			return nil, load.err
+		case <-l.ctx.Done():
+			return nil, l.ctx.Err()
+		}
+	}
+	load := &entityBucketLoad{doneCh: make(chan struct{})}
+	l.buckets[key] = load
+	l.lock.Unlock()
+
+	var accessors []string
+	bucket, err := i.entityPacker.GetBucket(l.ctx, key)
+	if err == nil && bucket != nil {
+		accessors, err = i.loadEntitiesFromBucket(l.ctx, bucket)
+	}
+	if err != nil {
+		// Let the next lookup try loading the bucket again
+		l.lock.Lock()
+		if l.buckets[key] == load {
+			delete(l.buckets, key)
+		}
+		l.lock.Unlock()
+	}
+
+	load.err = err
+	close(load.doneCh)
+	return accessors, err
+}
+
+// loadEntitiesLazily loads all the entity buckets which weren't loaded on
+// demand into MemDB.
+func (i *IdentityStore) loadEntitiesLazily(ctx context.Context) error {
+	l := i.lazyLoad.Load()
+	if l == nil {
+		return errors.New("entities are not being loaded lazily")
+	}
+
+	i.logger.Debug("loading entities in the background")
+	existing, err := i.entityPacker.View().List(ctx, storagepacker.StoragePackerBucketsPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to scan for entities: %w", err)
+	}
+	i.logger.Debug("entities collected", "num_existing", len(existing))
+
+	broker := make(chan string)
+	quit := make(chan struct{})
+
+	var errOnce sync.Once
+	var loadErr error
+
+	var duplicatedAccessorsLock sync.Mutex
+	duplicatedAccessors := make(map[string]struct{})
+
+	wg := &sync.WaitGroup{}
+	for j := 0; j < consts.ExpirationRestoreWorkerCount; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for key := range broker {
+				accessors, err := i.loadLazyEntityBucket(l, storagepacker.StoragePackerBucketsPrefix+key)
+				if err != nil {
+					errOnce.Do(func() {
+						loadErr = err
+						close(quit)
+					})
+					return
+				}
+
+				duplicatedAccessorsLock.Lock()
+				for _, accessor := range accessors {
+					duplicatedAccessors[accessor] = struct{}{}
+				}
+				duplicatedAccessorsLock.Unlock()
+			}
+		}()
+	}
+
+LOOP:
+	for j, key := range existing {
+		if j%500 == 0 {
+			i.logger.Debug("entities loading", "progress", j)
+		}
+
+		select {
+		case <-quit:
+			break LOOP
+		case broker <- key:
+		}
+	}
+	close(broker)
+
+	wg.Wait()
+	if loadErr != nil {
+		return loadErr
+	}
+
+	if len(duplicatedAccessors) > 0 {
+		duplicatedAccessorsList := make([]string, 0, len(duplicatedAccessors))
+		for accessor := range duplicatedAccessors {
+			duplicatedAccessorsList = append(duplicatedAccessorsList, accessor)
+		}
+		i.logger.Warn("One or more entities have multiple aliases on the same mount(s), remove duplicates to avoid ACL templating issues", "mount_accessors", duplicatedAccessorsList)
+	}
+
+	if i.logger.IsInfo() {
+		i.logger.Info("entities restored")
+	}
+
+	return nil
+}
+
+// pruneGroupMemberEntities removes the entities which don't exist anymore
+// from the members of the groups. This is done by loadGroups when entities
+// aren't loaded lazily.
+func (i *IdentityStore) pruneGroupMemberEntities(ctx context.Context) error {
+	txn := i.db.Txn(false)
+
+	iter, err := txn.Get(groupsTable, "id")
+	if err != nil {
+		return fmt.Errorf("failed to iterate over groups: %w", err)
+	}
+
+	var pruned []*identity.Group
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		group := raw.(*identity.Group)
+
+		var missing []string
+		for _, memberEntityID := range group.MemberEntityIDs {
+			entity, err := i.MemDBEntityByIDInTxn(txn, memberEntityID, false)
+			if err != nil {
+				return err
+			}
+			if entity == nil {
+				missing = append(missing, memberEntityID)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		group, err = group.Clone()
+		if err != nil {
+			return err
+		}
+		for _, memberEntityID := range missing {
+			group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, memberEntityID)
+		}
+		pruned = append(pruned, group)
+	}
+
+	for _, group := range pruned {
+		if err := i.UpsertGroup(ctx, group, true); err != nil {
+			return fmt.Errorf("failed to update group in memdb: %w", err)
+		}
+	}
+
+	return nil
+}
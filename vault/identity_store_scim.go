@@ -0,0 +1,1508 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	scimConfigStorageKey = "scim/config"
+	scimBasePath         = "scim/v2/"
+
+	// SCIMContentType is the media type of the SCIM requests and responses,
+	// as defined by RFC 7644.
+	SCIMContentType = "application/scim+json"
+
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// The metadata keys of the entities and groups holding the SCIM attributes
+	// which have no equivalent in the identity store
+	scimMetadataExternalID  = "scim_external_id"
+	scimMetadataDisplayName = "scim_display_name"
+	scimMetadataEmail       = "scim_email"
+
+	scimDefaultCount = 100
+	scimMaxCount     = 1000
+)
+
+// The SCIM error types, as defined by RFC 7644 section 3.12
+const (
+	scimErrInvalidFilter = "invalidFilter"
+	scimErrInvalidSyntax = "invalidSyntax"
+	scimErrInvalidPath   = "invalidPath"
+	scimErrInvalidValue  = "invalidValue"
+	scimErrNoTarget      = "noTarget"
+	scimErrMutability    = "mutability"
+	scimErrUniqueness    = "uniqueness"
+)
+
+// scimFilterRegex matches the filters supported by the list endpoints, which
+// are the equality filters used by the identity providers to find the
+// resources they provision, such as `userName eq "alice"`.
+var scimFilterRegex = regexp.MustCompile(`(?i)^\s*([a-z][a-z0-9.]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// scimMemberFilterRegex matches the paths of the patch operations removing a
+// member of a group, such as `members[value eq "<id>"]`.
+var scimMemberFilterRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+
+type scimConfig struct {
+	MountAccessor string `json:"mount_accessor"`
+}
+
+// scimBool is a boolean which may also be given as a string, as some identity
+// providers do in patch operations.
+type scimBool bool
+
+func (b *scimBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := parseSCIMBool(v)
+	if err != nil {
+		return err
+	}
+	*b = scimBool(parsed)
+	return nil
+}
+
+func parseSCIMBool(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("expected a boolean, got %v", v)
+	}
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimReference struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	UserName    string          `json:"userName"`
+	DisplayName string          `json:"displayName,omitempty"`
+	Active      *scimBool       `json:"active,omitempty"`
+	Emails      []scimEmail     `json:"emails,omitempty"`
+	Groups      []scimReference `json:"groups,omitempty"`
+	Meta        *scimMeta       `json:"meta,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []scimReference `json:"members,omitempty"`
+	Meta        *scimMeta       `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func scimPaths(i *IdentityStore) []*framework.Path {
+	listFields := map[string]*framework.FieldSchema{
+		"filter": {
+			Type:        framework.TypeString,
+			Description: `Equality filter on an attribute of the resources, such as 'userName eq "alice"'.`,
+			Query:       true,
+		},
+		"startIndex": {
+			Type:        framework.TypeInt,
+			Description: "1-based index of the first resource of the page.",
+			Default:     1,
+			Query:       true,
+		},
+		"count": {
+			Type:        framework.TypeInt,
+			Description: "Maximum number of resources of the page.",
+			Default:     scimDefaultCount,
+			Query:       true,
+		},
+	}
+	idFields := map[string]*framework.FieldSchema{
+		"id": {
+			Type:        framework.TypeString,
+			Description: "ID of the resource.",
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "scim/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth method on which the provisioned users are given an entity alias named after their userName.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMReadConfig,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUpdateConfig,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["config"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["config"][1]),
+		},
+		{
+			Pattern: scimBasePath + "ServiceProviderConfig$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+				OperationSuffix: "service-provider-configuration",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMServiceProviderConfig,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["service-provider-config"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["service-provider-config"][1]),
+		},
+		{
+			Pattern: scimBasePath + "Users$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+				OperationSuffix: "users",
+			},
+
+			Fields: listFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserCreate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "create",
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["users"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["users"][1]),
+		},
+		{
+			Pattern: scimBasePath + "Users/" + framework.GenericNameRegex("id"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+				OperationSuffix: "user",
+			},
+
+			Fields: idFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserReplace,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "replace",
+					},
+					ForwardPerformanceStandby: true,
+				},
+				logical.PatchOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserPatch,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "patch",
+					},
+					ForwardPerformanceStandby: true,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.pathSCIMUserDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["user"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["user"][1]),
+		},
+		{
+			Pattern: scimBasePath + "Groups$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+				OperationSuffix: "groups",
+			},
+
+			Fields: listFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupCreate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "create",
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["groups"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["groups"][1]),
+		},
+		{
+			Pattern: scimBasePath + "Groups/" + framework.GenericNameRegex("id"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "scim",
+				OperationSuffix: "group",
+			},
+
+			Fields: idFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupReplace,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "replace",
+					},
+					ForwardPerformanceStandby: true,
+				},
+				logical.PatchOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupPatch,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "patch",
+					},
+					ForwardPerformanceStandby: true,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.pathSCIMGroupDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["group"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["group"][1]),
+		},
+	}
+}
+
+func (i *IdentityStore) getSCIMConfig(ctx context.Context, s logical.Storage) (*scimConfig, error) {
+	var c scimConfig
+	entry, err := s.Get(ctx, scimConfigStorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	return &c, nil
+}
+
+func (i *IdentityStore) pathSCIMReadConfig(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, err := i.getSCIMConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"mount_accessor": c.MountAccessor,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathSCIMUpdateConfig(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := i.getSCIMConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if mountAccessorRaw, ok := d.GetOk("mount_accessor"); ok {
+		c.MountAccessor = mountAccessorRaw.(string)
+	}
+
+	if c.MountAccessor != "" {
+		mountEntry := i.router.MatchingMountByAccessor(c.MountAccessor)
+		if mountEntry == nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid mount accessor %q", c.MountAccessor)), nil
+		}
+		if mountEntry.NamespaceID != ns.ID {
+			return logical.ErrorResponse("mount accessor is not in the request namespace"), nil
+		}
+		if mountEntry.Local {
+			return logical.ErrorResponse("mount accessor of a local auth method is not supported"), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(scimConfigStorageKey, c)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (i *IdentityStore) pathSCIMServiceProviderConfig(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	supported := func(supported bool) map[string]interface{} {
+		return map[string]interface{}{"supported": supported}
+	}
+
+	return scimResponse(http.StatusOK, map[string]interface{}{
+		"schemas":        []string{scimSchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "Vault token",
+				"description": "Authentication with a Vault token given as a bearer token in the Authorization header.",
+				"primary":     true,
+			},
+		},
+		"meta": scimMeta{ResourceType: "ServiceProviderConfig"},
+	})
+}
+
+func (i *IdentityStore) pathSCIMUserList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	match, errResp := scimUserFilter(d.Get("filter").(string))
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	txn := i.db.Txn(false)
+	iter, err := txn.Get(entitiesTable, "namespace_id", ns.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch iterator for entities in memdb: %w", err)
+	}
+
+	var entities []*identity.Entity
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		entity := raw.(*identity.Entity)
+		if match(entity) {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(a, b int) bool {
+		return entities[a].Name < entities[b].Name
+	})
+
+	startIndex, count := scimPage(d)
+	list := scimListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: len(entities),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	for index := startIndex - 1; index < len(entities) && len(list.Resources) < count; index++ {
+		user, err := i.scimUserFromEntity(txn, ns, entities[index])
+		if err != nil {
+			return nil, err
+		}
+		list.Resources = append(list.Resources, user)
+	}
+	list.ItemsPerPage = len(list.Resources)
+
+	return scimResponse(http.StatusOK, list)
+}
+
+func (i *IdentityStore) pathSCIMUserRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := i.db.Txn(false)
+	entity, err := i.MemDBEntityByIDInTxn(txn, d.Get("id").(string), false)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || entity.NamespaceID != ns.ID {
+		return scimNotFound("User", d.Get("id").(string))
+	}
+
+	user, err := i.scimUserFromEntity(txn, ns, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return scimResponse(http.StatusOK, user)
+}
+
+func (i *IdentityStore) pathSCIMUserCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var user scimUser
+	if errResp := decodeSCIMRequest(req.Data, &user); errResp != nil {
+		return errResp, nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.scimUpsertUser(ctx, req, nil, &user)
+}
+
+func (i *IdentityStore) pathSCIMUserReplace(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var user scimUser
+	if errResp := decodeSCIMRequest(req.Data, &user); errResp != nil {
+		return errResp, nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, errResp, err := i.scimEntityByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	return i.scimUpsertUser(ctx, req, entity, &user)
+}
+
+func (i *IdentityStore) pathSCIMUserPatch(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var patch scimPatchRequest
+	if errResp := decodeSCIMRequest(req.Data, &patch); errResp != nil {
+		return errResp, nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, errResp, err := i.scimEntityByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	// The operations are applied to the current representation of the user,
+	// which then replaces the entity as a whole
+	user, err := i.scimUserFromEntity(i.db.Txn(false), ns, entity)
+	if err != nil {
+		return nil, err
+	}
+	for _, operation := range patch.Operations {
+		if errResp := applySCIMUserPatchOperation(user, operation); errResp != nil {
+			return errResp, nil
+		}
+	}
+
+	return i.scimUpsertUser(ctx, req, entity, user)
+}
+
+func (i *IdentityStore) pathSCIMUserDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	txn := i.db.Txn(true)
+	defer txn.Abort()
+
+	entity, err := i.MemDBEntityByIDInTxn(txn, d.Get("id").(string), true)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || entity.NamespaceID != ns.ID {
+		return scimNotFound("User", d.Get("id").(string))
+	}
+
+	if err := i.handleEntityDeleteCommon(ctx, txn, entity, true); err != nil {
+		return nil, err
+	}
+
+	txn.Commit()
+
+	return scimResponse(http.StatusNoContent, nil)
+}
+
+// scimEntityByID returns a clone of the entity of the request namespace with
+// the given ID, or a SCIM error response if there is none.
+func (i *IdentityStore) scimEntityByID(ctx context.Context, entityID string) (*identity.Entity, *logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entity, err := i.MemDBEntityByID(entityID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entity == nil || entity.NamespaceID != ns.ID {
+		resp, err := scimNotFound("User", entityID)
+		return nil, resp, err
+	}
+
+	return entity, nil, nil
+}
+
+// scimUpsertUser creates an entity for the user, or replaces the attributes
+// of the given entity with the ones of the user, keeping the alias of the
+// entity on the configured auth method in sync with its name. The caller must
+// hold the lock of the identity store.
+func (i *IdentityStore) scimUpsertUser(ctx context.Context, req *logical.Request, entity *identity.Entity, user *scimUser) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.UserName == "" {
+		return scimErrorResponse(http.StatusBadRequest, scimErrInvalidValue, "userName is required")
+	}
+
+	status := http.StatusOK
+	if entity == nil {
+		status = http.StatusCreated
+		entity = new(identity.Entity)
+	}
+
+	entityByName, err := i.MemDBEntityByName(ctx, user.UserName, false)
+	if err != nil {
+		return nil, err
+	}
+	if entityByName != nil && entityByName.ID != entity.ID {
+		return scimErrorResponse(http.StatusConflict, scimErrUniqueness, fmt.Sprintf("userName %q is already in use", user.UserName))
+	}
+
+	entity.Name = user.UserName
+	entity.Disabled = user.Active != nil && !bool(*user.Active)
+
+	var email string
+	for _, e := range user.Emails {
+		if email == "" || e.Primary {
+			email = e.Value
+		}
+	}
+	entity.Metadata = setSCIMMetadata(entity.Metadata, map[string]string{
+		scimMetadataExternalID:  user.ExternalID,
+		scimMetadataDisplayName: user.DisplayName,
+		scimMetadataEmail:       email,
+	})
+
+	if err := i.sanitizeEntity(ctx, entity); err != nil {
+		return nil, err
+	}
+
+	config, err := i.getSCIMConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.MountAccessor != "" {
+		if errResp, err := i.scimSyncAlias(ctx, entity, config.MountAccessor); errResp != nil || err != nil {
+			return errResp, err
+		}
+	}
+
+	if err := i.upsertEntity(ctx, entity, nil, true); err != nil {
+		return nil, err
+	}
+
+	resource, err := i.scimUserFromEntity(i.db.Txn(false), ns, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return scimResponse(status, resource)
+}
+
+// scimSyncAlias ensures the entity has an alias named after it on the auth
+// method with the given accessor, so that the user is mapped to the entity
+// when logging in with that auth method.
+func (i *IdentityStore) scimSyncAlias(ctx context.Context, entity *identity.Entity, mountAccessor string) (*logical.Response, error) {
+	if i.router.MatchingMountByAccessor(mountAccessor) == nil {
+		return nil, fmt.Errorf("auth method of the SCIM mount accessor %q not found", mountAccessor)
+	}
+
+	aliasByFactors, err := i.MemDBAliasByFactors(mountAccessor, entity.Name, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if aliasByFactors != nil && aliasByFactors.CanonicalID != entity.ID {
+		return scimErrorResponse(http.StatusConflict, scimErrUniqueness, fmt.Sprintf("alias %q already belongs to another entity", entity.Name))
+	}
+
+	for _, alias := range entity.Aliases {
+		if alias.MountAccessor != mountAccessor {
+			continue
+		}
+		if alias.Name != entity.Name {
+			alias.Name = entity.Name
+			alias.LastUpdateTime = ptypes.TimestampNow()
+		}
+		return nil, nil
+	}
+
+	alias := &identity.Alias{
+		MountAccessor: mountAccessor,
+		Name:          entity.Name,
+		CanonicalID:   entity.ID,
+	}
+	if err := i.sanitizeAlias(ctx, alias); err != nil {
+		return nil, err
+	}
+	entity.UpsertAlias(alias)
+
+	return nil, nil
+}
+
+// scimUserFromEntity returns the SCIM representation of the entity, with the
+// internal groups it's a direct member of.
+func (i *IdentityStore) scimUserFromEntity(txn *memdb.Txn, ns *namespace.Namespace, entity *identity.Entity) (*scimUser, error) {
+	active := scimBool(!entity.Disabled)
+	user := &scimUser{
+		Schemas:     []string{scimSchemaUser},
+		ID:          entity.ID,
+		ExternalID:  entity.Metadata[scimMetadataExternalID],
+		UserName:    entity.Name,
+		DisplayName: entity.Metadata[scimMetadataDisplayName],
+		Active:      &active,
+		Meta:        i.scimMeta(ns, "User", "Users/"+entity.ID, entity.CreationTime, entity.LastUpdateTime),
+	}
+	if email := entity.Metadata[scimMetadataEmail]; email != "" {
+		user.Emails = []scimEmail{{Value: email, Primary: true}}
+	}
+
+	groups, err := i.MemDBGroupsByMemberEntityIDInTxn(txn, entity.ID, false, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Type != groupTypeInternal {
+			continue
+		}
+		user.Groups = append(user.Groups, scimReference{
+			Value:   group.ID,
+			Display: group.Name,
+			Type:    "direct",
+		})
+	}
+
+	return user, nil
+}
+
+// applySCIMUserPatchOperation applies the patch operation to the user. The
+// attributes which the identity store doesn't hold are ignored, so that the
+// identity providers may send their whole set of attributes.
+func applySCIMUserPatchOperation(user *scimUser, operation scimPatchOperation) *logical.Response {
+	switch strings.ToLower(operation.Op) {
+	case "add", "replace":
+		if operation.Path != "" {
+			return setSCIMUserAttribute(user, operation.Path, operation.Value)
+		}
+		values, ok := operation.Value.(map[string]interface{})
+		if !ok {
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidValue, "value must be an object when the path is omitted")
+		}
+		for attr, value := range values {
+			if errResp := setSCIMUserAttribute(user, attr, value); errResp != nil {
+				return errResp
+			}
+		}
+	case "remove":
+		switch strings.ToLower(operation.Path) {
+		case "":
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrNoTarget, "path is required to remove an attribute")
+		case "username", "active", "id":
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrMutability, fmt.Sprintf("%s cannot be removed", operation.Path))
+		case "externalid":
+			user.ExternalID = ""
+		case "displayname":
+			user.DisplayName = ""
+		default:
+			if isSCIMEmailsPath(operation.Path) {
+				user.Emails = nil
+			}
+		}
+	default:
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidSyntax, fmt.Sprintf("unsupported patch operation %q", operation.Op))
+	}
+
+	return nil
+}
+
+func setSCIMUserAttribute(user *scimUser, attr string, value interface{}) *logical.Response {
+	invalid := func(err error) *logical.Response {
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidValue, fmt.Sprintf("invalid value of %s: %v", attr, err))
+	}
+
+	switch strings.ToLower(attr) {
+	case "id":
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrMutability, "id cannot be modified")
+	case "username":
+		s, ok := value.(string)
+		if !ok || s == "" {
+			return invalid(fmt.Errorf("expected a non-empty string"))
+		}
+		user.UserName = s
+	case "active":
+		b, err := parseSCIMBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+		active := scimBool(b)
+		user.Active = &active
+	case "externalid":
+		s, ok := value.(string)
+		if !ok {
+			return invalid(fmt.Errorf("expected a string"))
+		}
+		user.ExternalID = s
+	case "displayname":
+		s, ok := value.(string)
+		if !ok {
+			return invalid(fmt.Errorf("expected a string"))
+		}
+		user.DisplayName = s
+	case "emails":
+		var emails []scimEmail
+		if err := convertSCIMValue(value, &emails); err != nil {
+			return invalid(err)
+		}
+		user.Emails = emails
+	default:
+		// Paths such as `emails[type eq "work"].value` set the single email
+		// the identity store holds
+		if isSCIMEmailsPath(attr) && strings.HasSuffix(strings.ToLower(attr), ".value") {
+			s, ok := value.(string)
+			if !ok {
+				return invalid(fmt.Errorf("expected a string"))
+			}
+			user.Emails = []scimEmail{{Value: s, Primary: true}}
+		}
+	}
+
+	return nil
+}
+
+func isSCIMEmailsPath(path string) bool {
+	return strings.HasPrefix(strings.ToLower(path), "emails")
+}
+
+// scimUserFilter returns the function matching the entities selected by the
+// filter, which matches them all if empty.
+func scimUserFilter(filter string) (func(*identity.Entity) bool, *logical.Response) {
+	if filter == "" {
+		return func(*identity.Entity) bool { return true }, nil
+	}
+
+	attr, value, errResp := parseSCIMFilter(filter)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	switch attr {
+	case "id":
+		return func(e *identity.Entity) bool { return e.ID == value }, nil
+	case "username":
+		return func(e *identity.Entity) bool { return strings.EqualFold(e.Name, value) }, nil
+	case "externalid":
+		return func(e *identity.Entity) bool { return e.Metadata[scimMetadataExternalID] == value }, nil
+	case "displayname":
+		return func(e *identity.Entity) bool { return e.Metadata[scimMetadataDisplayName] == value }, nil
+	case "emails", "emails.value":
+		return func(e *identity.Entity) bool { return strings.EqualFold(e.Metadata[scimMetadataEmail], value) }, nil
+	default:
+		return nil, scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidFilter, fmt.Sprintf("filtering on %q is not supported", attr))
+	}
+}
+
+func (i *IdentityStore) pathSCIMGroupList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	match, errResp := scimGroupFilter(d.Get("filter").(string))
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	txn := i.db.Txn(false)
+	iter, err := txn.Get(groupsTable, "namespace_id", ns.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch iterator for groups in memdb: %w", err)
+	}
+
+	var groups []*identity.Group
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		group := raw.(*identity.Group)
+		if group.Type == groupTypeInternal && match(group) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		return groups[a].Name < groups[b].Name
+	})
+
+	startIndex, count := scimPage(d)
+	list := scimListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: len(groups),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	for index := startIndex - 1; index < len(groups) && len(list.Resources) < count; index++ {
+		group, err := i.scimGroupFromGroup(txn, ns, groups[index])
+		if err != nil {
+			return nil, err
+		}
+		list.Resources = append(list.Resources, group)
+	}
+	list.ItemsPerPage = len(list.Resources)
+
+	return scimResponse(http.StatusOK, list)
+}
+
+func (i *IdentityStore) pathSCIMGroupRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group, errResp, err := i.scimGroupByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	resource, err := i.scimGroupFromGroup(i.db.Txn(false), ns, group)
+	if err != nil {
+		return nil, err
+	}
+
+	return scimResponse(http.StatusOK, resource)
+}
+
+func (i *IdentityStore) pathSCIMGroupCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var group scimGroup
+	if errResp := decodeSCIMRequest(req.Data, &group); errResp != nil {
+		return errResp, nil
+	}
+
+	i.groupLock.Lock()
+	defer i.groupLock.Unlock()
+
+	return i.scimUpsertGroup(ctx, nil, &group)
+}
+
+func (i *IdentityStore) pathSCIMGroupReplace(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var resource scimGroup
+	if errResp := decodeSCIMRequest(req.Data, &resource); errResp != nil {
+		return errResp, nil
+	}
+
+	i.groupLock.Lock()
+	defer i.groupLock.Unlock()
+
+	group, errResp, err := i.scimGroupByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	return i.scimUpsertGroup(ctx, group, &resource)
+}
+
+func (i *IdentityStore) pathSCIMGroupPatch(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var patch scimPatchRequest
+	if errResp := decodeSCIMRequest(req.Data, &patch); errResp != nil {
+		return errResp, nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.groupLock.Lock()
+	defer i.groupLock.Unlock()
+
+	group, errResp, err := i.scimGroupByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	resource, err := i.scimGroupFromGroup(i.db.Txn(false), ns, group)
+	if err != nil {
+		return nil, err
+	}
+	for _, operation := range patch.Operations {
+		if errResp := applySCIMGroupPatchOperation(resource, operation); errResp != nil {
+			return errResp, nil
+		}
+	}
+
+	return i.scimUpsertGroup(ctx, group, resource)
+}
+
+func (i *IdentityStore) pathSCIMGroupDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	group, errResp, err := i.scimGroupByID(ctx, d.Get("id").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	resp, err := i.handleGroupDeleteCommon(ctx, group.ID, true)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	return scimResponse(http.StatusNoContent, nil)
+}
+
+// scimGroupByID returns a clone of the internal group of the request
+// namespace with the given ID, or a SCIM error response if there is none.
+// The external groups aren't exposed, as their members are given by the
+// auth methods.
+func (i *IdentityStore) scimGroupByID(ctx context.Context, groupID string) (*identity.Group, *logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group, err := i.MemDBGroupByID(groupID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if group == nil || group.NamespaceID != ns.ID || group.Type != groupTypeInternal {
+		resp, err := scimNotFound("Group", groupID)
+		return nil, resp, err
+	}
+
+	return group, nil, nil
+}
+
+// scimUpsertGroup creates an internal group for the SCIM group, or replaces
+// the name and members of the given group with the ones of the SCIM group.
+// The members are either users or groups. The caller must hold the group
+// lock of the identity store.
+func (i *IdentityStore) scimUpsertGroup(ctx context.Context, group *identity.Group, resource *scimGroup) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if resource.DisplayName == "" {
+		return scimErrorResponse(http.StatusBadRequest, scimErrInvalidValue, "displayName is required")
+	}
+
+	status := http.StatusOK
+	if group == nil {
+		status = http.StatusCreated
+		group = &identity.Group{Type: groupTypeInternal}
+	}
+
+	groupByName, err := i.MemDBGroupByName(ctx, resource.DisplayName, false)
+	if err != nil {
+		return nil, err
+	}
+	if groupByName != nil && groupByName.ID != group.ID {
+		return scimErrorResponse(http.StatusConflict, scimErrUniqueness, fmt.Sprintf("displayName %q is already in use", resource.DisplayName))
+	}
+
+	memberEntityIDs := []string{}
+	memberGroupIDs := []string{}
+	for _, member := range resource.Members {
+		entity, err := i.MemDBEntityByID(member.Value, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil && entity.NamespaceID == ns.ID {
+			memberEntityIDs = append(memberEntityIDs, entity.ID)
+			continue
+		}
+
+		memberGroup, err := i.MemDBGroupByID(member.Value, false)
+		if err != nil {
+			return nil, err
+		}
+		if memberGroup != nil && memberGroup.NamespaceID == ns.ID && memberGroup.Type == groupTypeInternal {
+			memberGroupIDs = append(memberGroupIDs, memberGroup.ID)
+			continue
+		}
+
+		return scimErrorResponse(http.StatusBadRequest, scimErrInvalidValue, fmt.Sprintf("member %q is neither a user nor a group", member.Value))
+	}
+
+	group.Name = resource.DisplayName
+	group.MemberEntityIDs = memberEntityIDs
+	group.Metadata = setSCIMMetadata(group.Metadata, map[string]string{
+		scimMetadataExternalID: resource.ExternalID,
+	})
+
+	if err := i.sanitizeAndUpsertGroup(ctx, group, nil, memberGroupIDs); err != nil {
+		if errStr := err.Error(); strings.HasPrefix(errStr, errCycleDetectedPrefix) {
+			return scimErrorResponse(http.StatusBadRequest, scimErrInvalidValue, errStr)
+		}
+		return nil, err
+	}
+
+	upserted, err := i.MemDBGroupByID(group.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	if upserted == nil {
+		return nil, fmt.Errorf("group %q not found after upsert", group.ID)
+	}
+
+	scimGroup, err := i.scimGroupFromGroup(i.db.Txn(false), ns, upserted)
+	if err != nil {
+		return nil, err
+	}
+
+	return scimResponse(status, scimGroup)
+}
+
+// scimGroupFromGroup returns the SCIM representation of the internal group,
+// with its member entities and groups.
+func (i *IdentityStore) scimGroupFromGroup(txn *memdb.Txn, ns *namespace.Namespace, group *identity.Group) (*scimGroup, error) {
+	resource := &scimGroup{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          group.ID,
+		ExternalID:  group.Metadata[scimMetadataExternalID],
+		DisplayName: group.Name,
+		Meta:        i.scimMeta(ns, "Group", "Groups/"+group.ID, group.CreationTime, group.LastUpdateTime),
+	}
+
+	for _, entityID := range group.MemberEntityIDs {
+		member := scimReference{
+			Value: entityID,
+			Type:  "User",
+		}
+		entity, err := i.MemDBEntityByIDInTxn(txn, entityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil {
+			member.Display = entity.Name
+		}
+		resource.Members = append(resource.Members, member)
+	}
+
+	memberGroups, err := i.MemDBGroupsByParentGroupIDInTxn(txn, group.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, memberGroup := range memberGroups {
+		resource.Members = append(resource.Members, scimReference{
+			Value:   memberGroup.ID,
+			Display: memberGroup.Name,
+			Type:    "Group",
+		})
+	}
+
+	return resource, nil
+}
+
+// applySCIMGroupPatchOperation applies the patch operation to the group. The
+// attributes which the identity store doesn't hold are ignored.
+func applySCIMGroupPatchOperation(group *scimGroup, operation scimPatchOperation) *logical.Response {
+	op := strings.ToLower(operation.Op)
+	switch op {
+	case "add", "replace":
+		if operation.Path != "" {
+			return setSCIMGroupAttribute(group, op, operation.Path, operation.Value)
+		}
+		values, ok := operation.Value.(map[string]interface{})
+		if !ok {
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidValue, "value must be an object when the path is omitted")
+		}
+		for attr, value := range values {
+			if errResp := setSCIMGroupAttribute(group, op, attr, value); errResp != nil {
+				return errResp
+			}
+		}
+	case "remove":
+		path := strings.ToLower(operation.Path)
+		switch {
+		case path == "":
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrNoTarget, "path is required to remove an attribute")
+		case path == "displayname", path == "id":
+			return scimErrorRawResponse(http.StatusBadRequest, scimErrMutability, fmt.Sprintf("%s cannot be removed", operation.Path))
+		case path == "externalid":
+			group.ExternalID = ""
+		case path == "members":
+			if operation.Value == nil {
+				group.Members = nil
+				break
+			}
+			var members []scimReference
+			if err := convertSCIMValue(operation.Value, &members); err != nil {
+				return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidValue, fmt.Sprintf("invalid value of members: %v", err))
+			}
+			for _, member := range members {
+				group.Members = removeSCIMMember(group.Members, member.Value)
+			}
+		default:
+			matches := scimMemberFilterRegex.FindStringSubmatch(operation.Path)
+			if matches == nil {
+				return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidPath, fmt.Sprintf("unsupported path %q", operation.Path))
+			}
+			var value string
+			if err := json.Unmarshal([]byte(matches[1]), &value); err != nil {
+				return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidPath, fmt.Sprintf("invalid path %q: %v", operation.Path, err))
+			}
+			group.Members = removeSCIMMember(group.Members, value)
+		}
+	default:
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidSyntax, fmt.Sprintf("unsupported patch operation %q", operation.Op))
+	}
+
+	return nil
+}
+
+func setSCIMGroupAttribute(group *scimGroup, op, attr string, value interface{}) *logical.Response {
+	invalid := func(err error) *logical.Response {
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidValue, fmt.Sprintf("invalid value of %s: %v", attr, err))
+	}
+
+	switch strings.ToLower(attr) {
+	case "id":
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrMutability, "id cannot be modified")
+	case "displayname":
+		s, ok := value.(string)
+		if !ok || s == "" {
+			return invalid(fmt.Errorf("expected a non-empty string"))
+		}
+		group.DisplayName = s
+	case "externalid":
+		s, ok := value.(string)
+		if !ok {
+			return invalid(fmt.Errorf("expected a string"))
+		}
+		group.ExternalID = s
+	case "members":
+		var members []scimReference
+		if err := convertSCIMValue(value, &members); err != nil {
+			return invalid(err)
+		}
+		if op == "replace" {
+			group.Members = nil
+		}
+		for _, member := range members {
+			group.Members = append(removeSCIMMember(group.Members, member.Value), member)
+		}
+	}
+
+	return nil
+}
+
+func removeSCIMMember(members []scimReference, value string) []scimReference {
+	var kept []scimReference
+	for _, member := range members {
+		if member.Value != value {
+			kept = append(kept, member)
+		}
+	}
+	return kept
+}
+
+// scimGroupFilter returns the function matching the groups selected by the
+// filter, which matches them all if empty.
+func scimGroupFilter(filter string) (func(*identity.Group) bool, *logical.Response) {
+	if filter == "" {
+		return func(*identity.Group) bool { return true }, nil
+	}
+
+	attr, value, errResp := parseSCIMFilter(filter)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	switch attr {
+	case "id":
+		return func(g *identity.Group) bool { return g.ID == value }, nil
+	case "displayname":
+		return func(g *identity.Group) bool { return strings.EqualFold(g.Name, value) }, nil
+	case "externalid":
+		return func(g *identity.Group) bool { return g.Metadata[scimMetadataExternalID] == value }, nil
+	case "members.value":
+		return func(g *identity.Group) bool { return strutil.StrListContains(g.MemberEntityIDs, value) }, nil
+	default:
+		return nil, scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidFilter, fmt.Sprintf("filtering on %q is not supported", attr))
+	}
+}
+
+// parseSCIMFilter returns the lowercased attribute and the value of an
+// equality filter.
+func parseSCIMFilter(filter string) (string, string, *logical.Response) {
+	matches := scimFilterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return "", "", scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidFilter, fmt.Sprintf("unsupported filter %q, only equality filters are supported", filter))
+	}
+
+	var value string
+	if err := json.Unmarshal([]byte(matches[2]), &value); err != nil {
+		return "", "", scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidFilter, fmt.Sprintf("invalid filter %q: %v", filter, err))
+	}
+
+	return strings.ToLower(matches[1]), value, nil
+}
+
+// scimPage returns the 1-based index of the first resource of the page and
+// the maximum number of resources of the page.
+func scimPage(d *framework.FieldData) (int, int) {
+	startIndex := d.Get("startIndex").(int)
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count := d.Get("count").(int)
+	switch {
+	case count < 0:
+		count = 0
+	case count > scimMaxCount:
+		count = scimMaxCount
+	}
+
+	return startIndex, count
+}
+
+func (i *IdentityStore) scimMeta(ns *namespace.Namespace, resourceType, path string, created, lastModified *timestamppb.Timestamp) *scimMeta {
+	meta := &scimMeta{
+		ResourceType: resourceType,
+	}
+	if created != nil {
+		meta.Created = ptypes.TimestampString(created)
+	}
+	if lastModified != nil {
+		meta.LastModified = ptypes.TimestampString(lastModified)
+	}
+	if i.redirectAddr != "" {
+		meta.Location = i.redirectAddr + "/v1/" + ns.Path + "identity/" + scimBasePath + path
+	}
+	return meta
+}
+
+// setSCIMMetadata sets the SCIM attributes in the metadata, removing the ones
+// which are empty.
+func setSCIMMetadata(metadata map[string]string, attrs map[string]string) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	for key, value := range attrs {
+		if value == "" {
+			delete(metadata, key)
+			continue
+		}
+		metadata[key] = value
+	}
+	return metadata
+}
+
+// convertSCIMValue converts the value of a patch operation to the given type.
+func convertSCIMValue(value, out interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// decodeSCIMRequest decodes the body of the request, returning a SCIM error
+// response if it's invalid.
+func decodeSCIMRequest(data map[string]interface{}, out interface{}) *logical.Response {
+	if err := convertSCIMValue(data, out); err != nil {
+		return scimErrorRawResponse(http.StatusBadRequest, scimErrInvalidSyntax, fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+// scimResponse returns a raw response with the JSON encoding of the value
+// as its body.
+func scimResponse(status int, v interface{}) (*logical.Response, error) {
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  status,
+			logical.HTTPContentType: SCIMContentType,
+		},
+	}
+	if status == http.StatusNoContent {
+		return resp, nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	resp.Data[logical.HTTPRawBody] = body
+
+	return resp, nil
+}
+
+func scimErrorResponse(status int, scimType, detail string) (*logical.Response, error) {
+	return scimResponse(status, scimError{
+		Schemas:  []string{scimSchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// scimErrorRawResponse is scimErrorResponse for the helpers returning the
+// error response alone, as encoding a scimError can't fail.
+func scimErrorRawResponse(status int, scimType, detail string) *logical.Response {
+	resp, _ := scimErrorResponse(status, scimType, detail)
+	return resp
+}
+
+func scimNotFound(resourceType, id string) (*logical.Response, error) {
+	return scimErrorResponse(http.StatusNotFound, "", fmt.Sprintf("%s %q not found", resourceType, id))
+}
+
+var scimHelp = map[string][2]string{
+	"config": {
+		"Configure the SCIM provisioning of the identity store.",
+		`The provisioned users are given an entity alias named after their userName
+on the auth method with the configured mount accessor, so that they are mapped
+to their entity when logging in with it.`,
+	},
+	"service-provider-config": {
+		"Read the SCIM features supported by the identity store.",
+		"",
+	},
+	"users": {
+		"List or provision SCIM users, which are entities of the identity store.",
+		"",
+	},
+	"user": {
+		"Read, replace, patch or deprovision a SCIM user.",
+		"",
+	},
+	"groups": {
+		"List or provision SCIM groups, which are internal groups of the identity store.",
+		"",
+	},
+	"group": {
+		"Read, replace, patch or deprovision a SCIM group.",
+		"",
+	},
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testSCIMRequest sends the SCIM request to the identity store, returning the
+// status and the decoded body of the response.
+func testSCIMRequest(t *testing.T, ctx context.Context, is *IdentityStore, op logical.Operation, path string, data map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Operation: op,
+		Path:      path,
+		Data:      data,
+		Storage:   is.view,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, SCIMContentType, resp.Data[logical.HTTPContentType])
+
+	status := resp.Data[logical.HTTPStatusCode].(int)
+	if status == http.StatusNoContent {
+		return status, nil
+	}
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &body))
+	return status, body
+}
+
+func TestIdentityStore_SCIM_Users(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	is, ghAccessor, _ := testIdentityStoreWithGithubAuth(ctx, t)
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "scim/config",
+		Data:      map[string]interface{}{"mount_accessor": ghAccessor},
+		Storage:   is.view,
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	status, user := testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+		"schemas":    []interface{}{scimSchemaUser},
+		"userName":   "alice@example.com",
+		"externalId": "00u1",
+		"active":     true,
+		"emails":     []interface{}{map[string]interface{}{"value": "alice@example.com", "primary": true}},
+	})
+	require.Equal(t, http.StatusCreated, status)
+	id := user["id"].(string)
+	require.Equal(t, "00u1", user["externalId"])
+	require.Equal(t, true, user["active"])
+
+	// The user is an entity with an alias on the configured auth method
+	entity, err := is.MemDBEntityByID(id, false)
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", entity.Name)
+	require.Equal(t, "00u1", entity.Metadata[scimMetadataExternalID])
+	require.Len(t, entity.Aliases, 1)
+	require.Equal(t, ghAccessor, entity.Aliases[0].MountAccessor)
+	require.Equal(t, "alice@example.com", entity.Aliases[0].Name)
+
+	// The userName is unique
+	status, scimErr := testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+		"userName": "alice@example.com",
+	})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, scimErrUniqueness, scimErr["scimType"])
+
+	// The identity providers look the users up by userName
+	status, list := testSCIMRequest(t, ctx, is, logical.ReadOperation, "scim/v2/Users", map[string]interface{}{
+		"filter": `userName eq "Alice@example.com"`,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, float64(1), list["totalResults"])
+	require.Equal(t, id, list["Resources"].([]interface{})[0].(map[string]interface{})["id"])
+
+	status, _ = testSCIMRequest(t, ctx, is, logical.ReadOperation, "scim/v2/Users", map[string]interface{}{
+		"filter": `name.givenName sw "A"`,
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	// Deactivating and renaming the user disables the entity and renames its
+	// alias, with the values given as strings as some identity providers do
+	status, user = testSCIMRequest(t, ctx, is, logical.PatchOperation, "scim/v2/Users/"+id, map[string]interface{}{
+		"schemas": []interface{}{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []interface{}{
+			map[string]interface{}{"op": "Replace", "path": "active", "value": "False"},
+			map[string]interface{}{"op": "replace", "value": map[string]interface{}{"userName": "alice@corp.example.com"}},
+			map[string]interface{}{"op": "replace", "path": "name.givenName", "value": "Alice"},
+		},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, false, user["active"])
+	require.Equal(t, "alice@corp.example.com", user["userName"])
+
+	entity, err = is.MemDBEntityByID(id, false)
+	require.NoError(t, err)
+	require.True(t, entity.Disabled)
+	require.Len(t, entity.Aliases, 1)
+	require.Equal(t, "alice@corp.example.com", entity.Aliases[0].Name)
+
+	// Replacing the user clears the attributes it omits
+	status, user = testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Users/"+id, map[string]interface{}{
+		"userName": "alice@corp.example.com",
+		"active":   true,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, user["externalId"])
+	require.Nil(t, user["emails"])
+
+	status, _ = testSCIMRequest(t, ctx, is, logical.DeleteOperation, "scim/v2/Users/"+id, nil)
+	require.Equal(t, http.StatusNoContent, status)
+	entity, err = is.MemDBEntityByID(id, false)
+	require.NoError(t, err)
+	require.Nil(t, entity)
+
+	status, _ = testSCIMRequest(t, ctx, is, logical.ReadOperation, "scim/v2/Users/"+id, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestIdentityStore_SCIM_Groups(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	is, _, _ := testIdentityStoreWithGithubAuth(ctx, t)
+
+	var userIDs []string
+	for _, userName := range []string{"alice", "bob"} {
+		status, user := testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+			"userName": userName,
+		})
+		require.Equal(t, http.StatusCreated, status)
+		userIDs = append(userIDs, user["id"].(string))
+	}
+
+	status, group := testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Groups", map[string]interface{}{
+		"schemas":     []interface{}{scimSchemaGroup},
+		"displayName": "engineering",
+		"members":     []interface{}{map[string]interface{}{"value": userIDs[0]}},
+	})
+	require.Equal(t, http.StatusCreated, status)
+	groupID := group["id"].(string)
+
+	status, subgroup := testSCIMRequest(t, ctx, is, logical.UpdateOperation, "scim/v2/Groups", map[string]interface{}{
+		"displayName": "platform",
+	})
+	require.Equal(t, http.StatusCreated, status)
+	subgroupID := subgroup["id"].(string)
+
+	// Users and groups are added as members, and users removed by filter
+	status, group = testSCIMRequest(t, ctx, is, logical.PatchOperation, "scim/v2/Groups/"+groupID, map[string]interface{}{
+		"Operations": []interface{}{
+			map[string]interface{}{"op": "add", "path": "members", "value": []interface{}{
+				map[string]interface{}{"value": userIDs[1]},
+				map[string]interface{}{"value": subgroupID},
+			}},
+			map[string]interface{}{"op": "remove", "path": `members[value eq "` + userIDs[0] + `"]`},
+		},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"value": userIDs[1], "display": "bob", "type": "User"},
+		map[string]interface{}{"value": subgroupID, "display": "platform", "type": "Group"},
+	}, group["members"])
+
+	memDBGroup, err := is.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{userIDs[1]}, memDBGroup.MemberEntityIDs)
+	memDBSubgroup, err := is.MemDBGroupByID(subgroupID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{groupID}, memDBSubgroup.ParentGroupIDs)
+
+	// The groups of a user are part of its representation
+	status, user := testSCIMRequest(t, ctx, is, logical.ReadOperation, "scim/v2/Users/"+userIDs[1], nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"value": groupID, "display": "engineering", "type": "direct"},
+	}, user["groups"])
+
+	status, scimErr := testSCIMRequest(t, ctx, is, logical.PatchOperation, "scim/v2/Groups/"+groupID, map[string]interface{}{
+		"Operations": []interface{}{
+			map[string]interface{}{"op": "add", "path": "members", "value": []interface{}{
+				map[string]interface{}{"value": "unknown"},
+			}},
+		},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, scimErrInvalidValue, scimErr["scimType"])
+
+	status, list := testSCIMRequest(t, ctx, is, logical.ReadOperation, "scim/v2/Groups", map[string]interface{}{
+		"startIndex": 2,
+		"count":      1,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, float64(2), list["totalResults"])
+	require.Equal(t, float64(1), list["itemsPerPage"])
+	require.Equal(t, subgroupID, list["Resources"].([]interface{})[0].(map[string]interface{})["id"])
+
+	status, _ = testSCIMRequest(t, ctx, is, logical.DeleteOperation, "scim/v2/Groups/"+groupID, nil)
+	require.Equal(t, http.StatusNoContent, status)
+	memDBGroup, err = is.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Nil(t, memDBGroup)
+}
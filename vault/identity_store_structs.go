@@ -7,6 +7,7 @@ import (
 	"context"
 	"regexp"
 	"sync"
+	"sync/atomic"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -93,6 +94,10 @@ type IdentityStore struct {
 	// operated case insensitively
 	disableLowerCasedNames bool
 
+	// lazyLoad is set while entities are loaded into MemDB in the
+	// background, see loadIdentityStoreArtifactsLazily
+	lazyLoad atomic.Pointer[identityLazyLoad]
+
 	router        *Router
 	redirectAddr  string
 	localNode     LocalNode
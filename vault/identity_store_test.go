@@ -5,6 +5,8 @@ package vault
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -913,3 +915,174 @@ func TestIdentityStore_DeleteCaseSensitivityKey(t *testing.T) {
 		t.Fatalf("bad: expected no entry for casesensitivity key")
 	}
 }
+
+func TestIdentityStore_LazyLoading(t *testing.T) {
+	err := AddTestCredentialBackend("github", credGithub.Factory)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	c, unsealKey, root := TestCoreUnsealedWithConfig(t, &CoreConfig{LazyIdentityLoading: true})
+	ctx := namespace.RootContext(nil)
+
+	meGH := &MountEntry{
+		Table:       credentialTableType,
+		Path:        "github/",
+		Type:        "github",
+		Description: "github auth",
+	}
+	if err := c.enableCredential(ctx, meGH); err != nil {
+		t.Fatal(err)
+	}
+
+	alias := &logical.Alias{
+		MountType:     "github",
+		MountAccessor: meGH.Accessor,
+		Name:          "githubuser",
+	}
+	entity, _, err := c.identityStore.CreateOrFetchEntity(ctx, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Persist a group with a member entity which doesn't exist anymore
+	group := &identity.Group{
+		ID:              "group1",
+		Name:            "group1",
+		MemberEntityIDs: []string{entity.ID, "missing"},
+		NamespaceID:     namespace.RootNamespaceID,
+		BucketKey:       c.identityStore.groupPacker.BucketKey("group1"),
+	}
+	groupAny, err := ptypes.MarshalAny(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.identityStore.groupPacker.PutItem(ctx, &storagepacker.Item{ID: group.ID, Message: groupAny}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Seal(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range unsealKey {
+		if _, err := c.Unseal(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Sealed() {
+		t.Fatal("still sealed")
+	}
+
+	// The entity can be looked up by ID whether or not it is loaded yet
+	fetched, err := c.identityStore.MemDBEntityByID(entity.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched == nil {
+		t.Fatal("expected the entity to be loaded on demand")
+	}
+
+	// Logins wait for all entities to be loaded
+	fetched, _, err = c.identityStore.CreateOrFetchEntity(ctx, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ID != entity.ID {
+		t.Fatalf("expected entity %q for the alias, got %q", entity.ID, fetched.ID)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := c.identityStore.waitForLazyLoad(waitCtx); err != nil {
+		t.Fatal(err)
+	}
+	if c.identityStore.lazyLoading() {
+		t.Fatal("expected entities to be loaded")
+	}
+
+	// The dangling member entity is pruned once all entities are loaded
+	fetchedGroup, err := c.identityStore.MemDBGroupByID("group1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetchedGroup == nil {
+		t.Fatal("expected the group to be loaded")
+	}
+	if !reflect.DeepEqual(fetchedGroup.MemberEntityIDs, []string{entity.ID}) {
+		t.Fatalf("bad: member entity IDs: %v", fetchedGroup.MemberEntityIDs)
+	}
+}
+
+func TestIdentityStore_LoadEntityBucketOnDemand(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	// Find two entity IDs stored in different buckets
+	ids := []string{"entity0"}
+	for j := 1; len(ids) < 2; j++ {
+		id := fmt.Sprintf("entity%d", j)
+		if is.entityPacker.BucketKey(id) != is.entityPacker.BucketKey(ids[0]) {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		entity := &identity.Entity{
+			ID:          id,
+			Name:        id,
+			NamespaceID: namespace.RootNamespaceID,
+			BucketKey:   is.entityPacker.BucketKey(id),
+		}
+		if err := is.upsertEntity(ctx, entity, nil, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Start over with an empty MemDB, as after unseal
+	if err := is.resetDB(ctx); err != nil {
+		t.Fatal(err)
+	}
+	is.startLazyLoad(ctx)
+	defer is.finishLazyLoad()
+
+	entity, err := is.MemDBEntityByID(ids[0], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity == nil {
+		t.Fatal("expected the entity to be loaded on demand")
+	}
+
+	// Only the bucket of the entity was loaded
+	entity, err = is.MemDBEntityByName(ctx, ids[1], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity != nil {
+		t.Fatalf("expected the entity of another bucket not to be loaded, got %#v", entity)
+	}
+
+	// Requests to the identity backend wait for all entities to be loaded
+	reqCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = is.HandleRequest(reqCtx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "entity/id",
+	})
+	if err == nil {
+		t.Fatal("expected the request to time out")
+	}
+
+	if err := is.loadEntitiesLazily(ctx); err != nil {
+		t.Fatal(err)
+	}
+	is.finishLazyLoad()
+
+	entity, err = is.MemDBEntityByName(ctx, ids[1], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity == nil {
+		t.Fatal("expected the entity to be loaded")
+	}
+}
@@ -37,6 +37,10 @@ func (c *Core) loadIdentityStoreArtifacts(ctx context.Context) error {
 		return nil
 	}
 
+	if c.lazyIdentityLoading {
+		return c.loadIdentityStoreArtifactsLazily(ctx)
+	}
+
 	loadFunc := func(context.Context) error {
 		if err := c.identityStore.loadEntities(ctx); err != nil {
 			return err
@@ -155,17 +159,20 @@ func (i *IdentityStore) loadGroups(ctx context.Context) error {
 			// Before pull#5786, entity memberships in groups were not getting
 			// updated when respective entities were deleted. This is here to
 			// check that the entity IDs in the group are indeed valid, and if
-			// not remove them.
+			// not remove them. When entities are loaded lazily, this is done
+			// once they are all loaded, see pruneGroupMemberEntities.
 			persist := false
-			for _, memberEntityID := range group.MemberEntityIDs {
-				entity, err := i.MemDBEntityByID(memberEntityID, false)
-				if err != nil {
-					txn.Abort()
-					return err
-				}
-				if entity == nil {
-					persist = true
-					group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, memberEntityID)
+			if !i.lazyLoading() {
+				for _, memberEntityID := range group.MemberEntityIDs {
+					entity, err := i.MemDBEntityByID(memberEntityID, false)
+					if err != nil {
+						txn.Abort()
+						return err
+					}
+					if entity == nil {
+						persist = true
+						group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, memberEntityID)
+					}
 				}
 			}
 
@@ -403,69 +410,12 @@ LOOP:
 				continue
 			}
 
-			for _, item := range bucket.Items {
-				entity, err := i.parseEntityFromBucketItem(ctx, item)
-				if err != nil {
-					return err
-				}
-				if entity == nil {
-					continue
-				}
-
-				ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
-				if err != nil {
-					return err
-				}
-				if ns == nil {
-					// Remove dangling entities
-					if !(i.localNode.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) || i.localNode.HAState() == consts.PerfStandby) {
-						// Entity's namespace doesn't exist anymore but the
-						// entity from the namespace still exists.
-						i.logger.Warn("deleting entity and its any existing aliases", "name", entity.Name, "namespace_id", entity.NamespaceID)
-						err = i.entityPacker.DeleteItem(ctx, entity.ID)
-						if err != nil {
-							return err
-						}
-					}
-					continue
-				}
-				nsCtx := namespace.ContextWithNamespace(ctx, ns)
-
-				// Ensure that there are no entities with duplicate names
-				entityByName, err := i.MemDBEntityByName(nsCtx, entity.Name, false)
-				if err != nil {
-					return nil
-				}
-				if entityByName != nil {
-					i.logger.Warn(errDuplicateIdentityName.Error(), "entity_name", entity.Name, "conflicting_entity_name", entityByName.Name, "action", "merge the duplicate entities into one")
-					if !i.disableLowerCasedNames {
-						return errDuplicateIdentityName
-					}
-				}
-
-				mountAccessors := getAccessorsOnDuplicateAliases(entity.Aliases)
-
-				for _, accessor := range mountAccessors {
-					if _, ok := duplicatedAccessors[accessor]; !ok {
-						duplicatedAccessors[accessor] = struct{}{}
-					}
-				}
-
-				localAliases, err := i.parseLocalAliases(entity.ID)
-				if err != nil {
-					return fmt.Errorf("failed to load local aliases from storage: %v", err)
-				}
-				if localAliases != nil {
-					for _, alias := range localAliases.Aliases {
-						entity.UpsertAlias(alias)
-					}
-				}
-
-				// Only update MemDB and don't hit the storage again
-				err = i.upsertEntity(nsCtx, entity, nil, false)
-				if err != nil {
-					return fmt.Errorf("failed to update entity in MemDB: %w", err)
-				}
+			mountAccessors, err := i.loadEntitiesFromBucket(ctx, bucket)
+			if err != nil {
+				return err
+			}
+			for _, accessor := range mountAccessors {
+				duplicatedAccessors[accessor] = struct{}{}
 			}
 		}
 	}
@@ -495,6 +445,73 @@ LOOP:
 	return nil
 }
 
+// loadEntitiesFromBucket loads the entities of the bucket, along with their
+// local aliases, into MemDB. It returns the accessors of the mounts on which
+// entities have more than one alias.
+func (i *IdentityStore) loadEntitiesFromBucket(ctx context.Context, bucket *storagepacker.Bucket) ([]string, error) {
+	var duplicatedAccessors []string
+	for _, item := range bucket.Items {
+		entity, err := i.parseEntityFromBucketItem(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			continue
+		}
+
+		ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
+		if err != nil {
+			return nil, err
+		}
+		if ns == nil {
+			// Remove dangling entities
+			if !(i.localNode.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) || i.localNode.HAState() == consts.PerfStandby) {
+				// Entity's namespace doesn't exist anymore but the
+				// entity from the namespace still exists.
+				i.logger.Warn("deleting entity and its any existing aliases", "name", entity.Name, "namespace_id", entity.NamespaceID)
+				err = i.entityPacker.DeleteItem(ctx, entity.ID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		nsCtx := namespace.ContextWithNamespace(ctx, ns)
+
+		// Ensure that there are no entities with duplicate names
+		entityByName, err := i.MemDBEntityByName(nsCtx, entity.Name, false)
+		if err != nil {
+			return nil, err
+		}
+		if entityByName != nil && entityByName.ID != entity.ID {
+			i.logger.Warn(errDuplicateIdentityName.Error(), "entity_name", entity.Name, "conflicting_entity_name", entityByName.Name, "action", "merge the duplicate entities into one")
+			if !i.disableLowerCasedNames {
+				return nil, errDuplicateIdentityName
+			}
+		}
+
+		duplicatedAccessors = append(duplicatedAccessors, getAccessorsOnDuplicateAliases(entity.Aliases)...)
+
+		localAliases, err := i.parseLocalAliases(entity.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load local aliases from storage: %v", err)
+		}
+		if localAliases != nil {
+			for _, alias := range localAliases.Aliases {
+				entity.UpsertAlias(alias)
+			}
+		}
+
+		// Only update MemDB and don't hit the storage again
+		err = i.upsertEntity(nsCtx, entity, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update entity in MemDB: %w", err)
+		}
+	}
+
+	return duplicatedAccessors, nil
+}
+
 // getAccessorsOnDuplicateAliases returns a list of accessors by checking aliases in
 // the passed in list which belong to the same accessor(s)
 func getAccessorsOnDuplicateAliases(aliases []*identity.Alias) []string {
@@ -790,18 +807,17 @@ func (i *IdentityStore) persistEntity(ctx context.Context, entity *identity.Enti
 	if err != nil {
 		return err
 	}
-	if err := i.entityPacker.PutItem(ctx, &storagepacker.Item{
+	entityItem := &storagepacker.Item{
 		ID:      entity.ID,
 		Message: marshaledEntity,
-	}); err != nil {
-		return err
 	}
 
 	if len(localAliases) == 0 {
-		return nil
+		return i.entityPacker.PutItem(ctx, entityItem)
 	}
 
-	// Store the local aliases separately.
+	// Store the local aliases separately, in the same storage transaction as
+	// the entity.
 	aliases := &identity.LocalAliases{
 		Aliases: localAliases,
 	}
@@ -810,12 +826,25 @@ func (i *IdentityStore) persistEntity(ctx context.Context, entity *identity.Enti
 	if err != nil {
 		return err
 	}
-	if err := i.localAliasPacker.PutItem(ctx, &storagepacker.Item{
+
+	entityTxn, unlockEntity, err := i.entityPacker.PutItemTxn(ctx, entityItem)
+	if err != nil {
+		return err
+	}
+	defer unlockEntity()
+
+	aliasesTxn, unlockAliases, err := i.localAliasPacker.PutItemTxn(ctx, &storagepacker.Item{
 		ID:      entity.ID,
 		Message: marshaledAliases,
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
+	defer unlockAliases()
+
+	if err := logical.StorageTransaction(ctx, i.view, []*logical.TxnEntry{entityTxn, aliasesTxn}); err != nil {
+		return fmt.Errorf("failed to persist packed storage entries: %w", err)
+	}
 
 	return nil
 }
@@ -1094,6 +1123,12 @@ func (i *IdentityStore) MemDBEntityByID(entityID string, clone bool) (*identity.
 		return nil, fmt.Errorf("missing entity id")
 	}
 
+	// Entities are lazily loaded, so make sure that the bucket holding the
+	// entity, if any, is loaded
+	if err := i.loadEntityBucketOnDemand(entityID); err != nil {
+		return nil, err
+	}
+
 	txn := i.db.Txn(false)
 
 	return i.MemDBEntityByIDInTxn(txn, entityID, clone)
@@ -1196,6 +1231,11 @@ func (i *IdentityStore) MemDBEntityByMergedEntityID(mergedEntityID string, clone
 		return nil, fmt.Errorf("missing merged entity id")
 	}
 
+	// The entity may be held in any bucket, so all of them must be loaded
+	if err := i.waitForLazyLoad(i.lazyLoadContext()); err != nil {
+		return nil, err
+	}
+
 	txn := i.db.Txn(false)
 
 	entityRaw, err := txn.First(entitiesTable, "merged_entity_ids", mergedEntityID)
@@ -4,6 +4,7 @@
 package vault
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -13,6 +14,7 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"math"
 	"math/rand"
 	"net/http"
 	"path"
@@ -111,17 +113,36 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"replication/dr/reindex",
 				"replication/performance/reindex",
 				"rotate",
+				"monitor/audit",
 				"config/cors",
+				"config/deadlock-detection",
+				"config/opa",
+				"config/admission-webhooks",
+				"config/admission-webhooks/*",
 				"config/auditing/*",
 				"config/ui/headers/*",
 				"plugins/catalog/*",
 				"plugins/runtimes/catalog/*",
+				"plugins/rollout/*",
+				"plugins/trust-roots",
+				"plugins/trust-roots/*",
 				"revoke-prefix/*",
 				"revoke-force/*",
 				"leases/revoke-prefix/*",
+				"leases/revoke-prefix-jobs",
+				"leases/revoke-prefix-jobs/*",
+				"leases/config/revocation",
+				"leases/irrevocable/force-expire",
+				"leases/irrevocable/force-expire-bulk",
+				"leases/irrevocable/retry",
+				"leases/irrevocable/mounts",
+				"leases/irrevocable/cleanup",
+				"wrapping/tokens",
+				"wrapping/tokens/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
 				"storage/raft/snapshot-auto/config/*",
+				"rotation/*",
 				"leases",
 				"internal/inspect/*",
 				// sys/seal and sys/step-down actually have their sudo requirement enforced through hardcoding
@@ -168,6 +189,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"rekey-recovery-key/update",
 				"rekey-recovery-key/verify",
 				"mfa/validate",
+				"mfa/challenge",
 			},
 
 			LocalStorage: []string{
@@ -189,12 +211,15 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsCatalogListPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsCatalogCRUDPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsReloadPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRolloutPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.pluginsTrustRootsPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRuntimesCatalogCRUDPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRuntimesCatalogListPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.auditPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.mountPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.authPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.lockedUserPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.namespacePaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.leasePaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.policyPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.wrappingPaths()...)
@@ -205,9 +230,12 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.remountPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.metricsPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.monitorPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.monitorAuditPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.rotationPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.secretsSyncPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.loginMFAPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.experimentPaths()...)
@@ -317,6 +345,97 @@ func (b *SystemBackend) handleCORSDelete(ctx context.Context, req *logical.Reque
 	return nil, b.Core.corsConfig.Disable(ctx)
 }
 
+// handleOPARead returns the current OPA policy evaluation configuration,
+// without the bearer token
+func (b *SystemBackend) handleOPARead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := b.Core.opaConfig()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"enabled": conf != nil,
+		},
+	}
+
+	if conf != nil {
+		resp.Data["address"] = conf.Address
+		resp.Data["decision_path"] = conf.DecisionPath
+		resp.Data["timeout"] = int64(conf.Timeout.Seconds())
+		resp.Data["fail_open"] = conf.FailOpen
+	}
+
+	return resp, nil
+}
+
+// handleOPAUpdate enables the evaluation of requests against the policies of
+// an OPA server, replacing any previous configuration
+func (b *SystemBackend) handleOPAUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := &OPAConfig{
+		Address:      d.Get("address").(string),
+		DecisionPath: d.Get("decision_path").(string),
+		BearerToken:  d.Get("bearer_token").(string),
+		Timeout:      time.Duration(d.Get("timeout").(int)) * time.Second,
+		FailOpen:     d.Get("fail_open").(bool),
+	}
+	if err := conf.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.setOPAConfig(ctx, conf)
+}
+
+// handleOPADelete disables the OPA policy evaluation.
+func (b *SystemBackend) handleOPADelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, b.Core.deleteOPAConfig(ctx)
+}
+
+// handleAdmissionWebhooksList returns the names of the admission webhooks
+func (b *SystemBackend) handleAdmissionWebhooksList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.listAdmissionWebhooks()), nil
+}
+
+// handleAdmissionWebhookRead returns the configuration of an admission
+// webhook, without the bearer token
+func (b *SystemBackend) handleAdmissionWebhookRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := b.Core.admissionWebhook(d.Get("name").(string))
+	if conf == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":       conf.Name,
+			"url":        conf.URL,
+			"paths":      conf.Paths,
+			"operations": conf.Operations,
+			"timeout":    int64(conf.Timeout.Seconds()),
+			"fail_open":  conf.FailOpen,
+		},
+	}, nil
+}
+
+// handleAdmissionWebhookUpdate adds or replaces an admission webhook
+func (b *SystemBackend) handleAdmissionWebhookUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := &AdmissionWebhookConfig{
+		Name:        d.Get("name").(string),
+		URL:         d.Get("url").(string),
+		Paths:       d.Get("paths").([]string),
+		Operations:  d.Get("operations").([]string),
+		BearerToken: d.Get("bearer_token").(string),
+		Timeout:     time.Duration(d.Get("timeout").(int)) * time.Second,
+		FailOpen:    d.Get("fail_open").(bool),
+	}
+	if err := conf.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.setAdmissionWebhook(ctx, conf)
+}
+
+// handleAdmissionWebhookDelete removes an admission webhook
+func (b *SystemBackend) handleAdmissionWebhookDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, b.Core.deleteAdmissionWebhook(ctx, d.Get("name").(string))
+}
+
 func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -337,6 +456,138 @@ func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Reque
 	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
 }
 
+// handleLeaseRevocationConfigRead returns the retry policy of the revocation
+// of expired leases
+func (b *SystemBackend) handleLeaseRevocationConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := b.Core.expiration.revocationConfig()
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"max_attempts":                   conf.MaxAttempts,
+			"retry_base":                     int64(conf.RetryBase.Seconds()),
+			"max_retry_delay":                int64(conf.MaxRetryDelay.Seconds()),
+			"irrevocable_cleanup_interval":   int64(conf.cleanupInterval().Seconds()),
+			"irrevocable_force_expire_after": int64(conf.IrrevocableForceExpireAfter.Seconds()),
+		},
+	}, nil
+}
+
+// handleLeaseRevocationConfigUpdate updates the retry policy of the
+// revocation of expired leases. Unset parameters keep their current value.
+func (b *SystemBackend) handleLeaseRevocationConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf := *b.Core.expiration.revocationConfig()
+	if maxAttempts, ok := d.GetOk("max_attempts"); ok {
+		conf.MaxAttempts = maxAttempts.(int)
+	}
+	if retryBase, ok := d.GetOk("retry_base"); ok {
+		conf.RetryBase = time.Duration(retryBase.(int)) * time.Second
+	}
+	if maxRetryDelay, ok := d.GetOk("max_retry_delay"); ok {
+		conf.MaxRetryDelay = time.Duration(maxRetryDelay.(int)) * time.Second
+	}
+	if interval, ok := d.GetOk("irrevocable_cleanup_interval"); ok {
+		conf.IrrevocableCleanupInterval = time.Duration(interval.(int)) * time.Second
+	}
+	if forceExpireAfter, ok := d.GetOk("irrevocable_force_expire_after"); ok {
+		conf.IrrevocableForceExpireAfter = time.Duration(forceExpireAfter.(int)) * time.Second
+	}
+	if err := conf.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.expiration.setRevocationConfig(ctx, &conf)
+}
+
+// handleForceExpireIrrevocableLease removes an irrevocable lease without
+// requiring its backend to revoke it
+func (b *SystemBackend) handleForceExpireIrrevocableLease(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	leaseID := d.Get("lease_id").(string)
+	if leaseID == "" {
+		return logical.ErrorResponse("lease_id must be specified"), logical.ErrInvalidRequest
+	}
+
+	found, err := b.Core.expiration.ForceExpireIrrevocable(ctx, leaseID)
+	switch {
+	case errors.Is(err, logical.ErrInvalidRequest):
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	case err != nil:
+		b.Backend.Logger().Error("force expiring irrevocable lease failed", "lease_id", leaseID, "error", err)
+		return handleErrorNoReadOnlyForward(err)
+	case !found:
+		return logical.ErrorResponse("irrevocable lease not found"), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+// handleIrrevocableLeasesRetry starts a job retrying the revocation of the
+// irrevocable leases of the namespace, or of one of its mounts
+func (b *SystemBackend) handleIrrevocableLeasesRetry(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.startIrrevocableLeasesJob(ctx, req, d, false)
+}
+
+// handleIrrevocableLeasesForceExpire starts a job removing the irrevocable
+// leases of one of the mounts of the namespace, or of all of them
+func (b *SystemBackend) handleIrrevocableLeasesForceExpire(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if d.Get("mount_accessor").(string) == "" && !d.Get("all_mounts").(bool) {
+		return logical.ErrorResponse("mount_accessor must be specified unless all_mounts is set"), logical.ErrInvalidRequest
+	}
+	return b.startIrrevocableLeasesJob(ctx, req, d, true)
+}
+
+func (b *SystemBackend) startIrrevocableLeasesJob(ctx context.Context, req *logical.Request, d *framework.FieldData, force bool) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mountAccessor := d.Get("mount_accessor").(string)
+	if mountAccessor != "" {
+		entry := b.Core.router.MatchingMountByAccessor(mountAccessor)
+		if entry == nil || entry.NamespaceID != ns.ID {
+			return logical.ErrorResponse("no mount found with accessor %q", mountAccessor), logical.ErrInvalidRequest
+		}
+	}
+
+	jobID, err := b.Core.expiration.StartIrrevocableLeasesJob(namespace.ContextWithNamespace(b.Core.activeContext, ns), mountAccessor, force)
+	if err != nil {
+		b.Backend.Logger().Error("starting irrevocable leases job failed", "mount_accessor", mountAccessor, "error", err)
+		return handleErrorNoReadOnlyForward(err)
+	}
+
+	return logical.RespondWithStatusCode(&logical.Response{
+		Data: map[string]interface{}{
+			"job_id": jobID,
+		},
+	}, req, http.StatusAccepted)
+}
+
+// handleIrrevocableLeasesByMount returns the irrevocable leases grouped by
+// mount
+func (b *SystemBackend) handleIrrevocableLeasesByMount(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	data, err := b.Core.expiration.getIrrevocableLeasesByMount(ctx, d.Get("include_child_namespaces").(bool))
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleIrrevocableLeasesCleanupRead returns the outcome of the last run of
+// the irrevocable lease cleanup job
+func (b *SystemBackend) handleIrrevocableLeasesCleanupRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	report := b.Core.expiration.irrevocableCleanupReport.Load()
+	if report == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: report.data(),
+	}, nil
+}
+
 func (b *SystemBackend) handleLeaseCount(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	typeRaw, ok := d.GetOk("type")
 	if !ok || strings.ToLower(typeRaw.(string)) != "irrevocable" {
@@ -531,21 +782,32 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 		return logical.ErrorResponse("version %q is not allowed because 'builtin' is a reserved metadata identifier", pluginVersion), nil
 	}
 
+	ociArtifact := d.Get("oci_artifact").(string)
 	sha256 := d.Get("sha256").(string)
 	if sha256 == "" {
 		sha256 = d.Get("sha_256").(string)
-		if sha256 == "" {
+		if sha256 == "" && ociArtifact == "" {
 			return logical.ErrorResponse("missing SHA-256 value"), nil
 		}
 	}
 
 	command := d.Get("command").(string)
 	ociImage := d.Get("oci_image").(string)
+	if ociArtifact != "" {
+		if ociImage != "" {
+			return logical.ErrorResponse("must not provide both oci_artifact and oci_image"), nil
+		}
+		// Artifacts are written into the plugin directory under the name of
+		// the plugin unless a command is given
+		if command == "" {
+			command = pluginName
+		}
+	}
 	if command == "" && ociImage == "" {
 		return logical.ErrorResponse("must provide at least one of command or oci_image"), nil
 	}
 
-	if ociImage == "" {
+	if ociImage == "" && ociArtifact == "" {
 		if err = b.Core.CheckPluginPerms(command); err != nil {
 			return nil, err
 		}
@@ -586,6 +848,21 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 		return logical.ErrorResponse("Could not decode SHA256 value from Hex %s: %s", sha256, err), err
 	}
 
+	var artifact *pluginArtifact
+	if ociArtifact != "" {
+		artifact, err = b.Core.pullPluginArtifact(ctx, ociArtifact, command, d.Get("oci_username").(string), d.Get("oci_password").(string))
+		if err != nil {
+			return logical.ErrorResponse("failed to pull plugin from %q: %s", ociArtifact, err), nil
+		}
+		if len(sha256Bytes) > 0 && !bytes.Equal(sha256Bytes, artifact.Sha256) {
+			return logical.ErrorResponse("SHA-256 of the plugin pulled from %q is %x", ociArtifact, artifact.Sha256), nil
+		}
+		if err = b.Core.CheckPluginPerms(command); err != nil {
+			return nil, err
+		}
+		sha256Bytes = artifact.Sha256
+	}
+
 	err = b.Core.pluginCatalog.Set(ctx, pluginutil.SetPluginInput{
 		Name:     pluginName,
 		Type:     pluginType,
@@ -604,6 +881,13 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 		return nil, err
 	}
 
+	if artifact != nil {
+		return &logical.Response{Data: map[string]interface{}{
+			"command":             artifact.Command,
+			"sha256":              hex.EncodeToString(artifact.Sha256),
+			"oci_manifest_digest": artifact.ManifestDigest,
+		}}, nil
+	}
 	return nil, nil
 }
 
@@ -775,6 +1059,233 @@ func (b *SystemBackend) handlePluginReloadUpdate(ctx context.Context, req *logic
 	return &r, nil
 }
 
+// handlePluginRolloutUpdate pins the mounts of a plugin to a version of the
+// plugin catalog, and reloads them
+func (b *SystemBackend) handlePluginRolloutUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	pluginName := d.Get("name").(string)
+	pluginType, err := consts.ParsePluginType(d.Get("type").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	version, resp, err := b.validateVersion(ctx, d.Get("version").(string), pluginName, pluginType)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	rollout, err := b.Core.rolloutPluginVersion(ctx, pluginType, pluginName, version, d.Get("mounts").([]string))
+	if errors.Is(err, ErrPluginNotFound) {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := make(map[string]interface{}, len(rollout.Mounts))
+	for path, previous := range rollout.Mounts {
+		mounts[path] = map[string]interface{}{
+			"previous_version": previous,
+		}
+	}
+	resp = &logical.Response{
+		Data: map[string]interface{}{
+			"version": version,
+			"mounts":  mounts,
+		},
+	}
+	if len(rollout.Errors) > 0 {
+		errs := make(map[string]interface{}, len(rollout.Errors))
+		for path, err := range rollout.Errors {
+			errs[path] = err
+		}
+		resp.Data["errors"] = errs
+		resp.AddWarning(fmt.Sprintf("%d mounts could not be rolled out to the version and keep running their previous version", len(errs)))
+	}
+	return resp, nil
+}
+
+func (b *SystemBackend) handlePluginTrustRootsList(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	names, err := b.Core.listPluginTrustRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+func (b *SystemBackend) handlePluginTrustRootUpdate(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse("missing trust root name"), nil
+	}
+	publicKey := d.Get("public_key").(string)
+	if publicKey == "" {
+		return logical.ErrorResponse("missing public_key"), nil
+	}
+
+	if err := b.Core.setPluginTrustRoot(ctx, &pluginTrustRoot{
+		Name:      name,
+		PublicKey: publicKey,
+	}); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handlePluginTrustRootRead(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	root, err := b.Core.pluginTrustRoot(ctx, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, nil
+	}
+	return &logical.Response{Data: map[string]interface{}{
+		"name":       root.Name,
+		"public_key": root.PublicKey,
+	}}, nil
+}
+
+func (b *SystemBackend) handlePluginTrustRootDelete(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.deletePluginTrustRoot(ctx, d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func namespaceResponseData(ns *namespace.Namespace) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              ns.ID,
+		"path":            ns.Path,
+		"custom_metadata": ns.CustomMetadata,
+	}
+}
+
+// namespaceCustomMetadata returns the custom_metadata parameter, whose values
+// must be strings.
+func namespaceCustomMetadata(d *framework.FieldData) (map[string]string, error) {
+	customMetadata := make(map[string]string)
+	for k, v := range d.Get("custom_metadata").(map[string]interface{}) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("custom_metadata value of %q must be a string", k))
+		}
+		customMetadata[k] = s
+	}
+	return customMetadata, nil
+}
+
+// childNamespace returns the child namespace of the namespace of the request
+// with the path parameter, or nil if there is none.
+func (b *SystemBackend) childNamespace(ctx context.Context, d *framework.FieldData) (*namespace.Namespace, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	child := b.Core.NamespaceByPath(ns.Path + d.Get("path").(string))
+	if child == nil || child.ID == ns.ID {
+		return nil, nil
+	}
+	return child, nil
+}
+
+func (b *SystemBackend) handleNamespacesList(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, child := range b.Core.childNamespaces(ns, false) {
+		key := ns.TrimmedPath(child.Path)
+		keys = append(keys, key)
+		keyInfo[key] = namespaceResponseData(child)
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleNamespaceExistenceCheck(ctx context.Context, _ *logical.Request, d *framework.FieldData) (bool, error) {
+	child, err := b.childNamespace(ctx, d)
+	if err != nil {
+		return false, err
+	}
+	return child != nil, nil
+}
+
+func (b *SystemBackend) handleNamespaceCreate(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	customMetadata, err := namespaceCustomMetadata(d)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := b.Core.createNamespace(ctx, d.Get("path").(string), customMetadata)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{Data: namespaceResponseData(ns)}, nil
+}
+
+func (b *SystemBackend) handleNamespaceUpdate(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	child, err := b.childNamespace(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil {
+		return nil, logical.CodedError(http.StatusNotFound, "namespace not found")
+	}
+	customMetadata, err := namespaceCustomMetadata(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the custom metadata by removing the keys which aren't set
+	patch := make(map[string]interface{})
+	for k := range child.CustomMetadata {
+		patch[k] = nil
+	}
+	for k, v := range customMetadata {
+		patch[k] = v
+	}
+	return b.patchNamespace(ctx, child, patch)
+}
+
+func (b *SystemBackend) handleNamespacePatch(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	child, err := b.childNamespace(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	if child == nil {
+		return nil, logical.CodedError(http.StatusNotFound, "namespace not found")
+	}
+	return b.patchNamespace(ctx, child, d.Get("custom_metadata").(map[string]interface{}))
+}
+
+func (b *SystemBackend) patchNamespace(ctx context.Context, ns *namespace.Namespace, customMetadata map[string]interface{}) (*logical.Response, error) {
+	patched, err := b.Core.patchNamespace(ctx, ns, customMetadata)
+	if err != nil {
+		return nil, err
+	}
+	if patched == nil {
+		return nil, logical.CodedError(http.StatusNotFound, "namespace not found")
+	}
+	return &logical.Response{Data: namespaceResponseData(patched)}, nil
+}
+
+func (b *SystemBackend) handleNamespaceRead(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	child, err := b.childNamespace(ctx, d)
+	if err != nil || child == nil {
+		return nil, err
+	}
+	return &logical.Response{Data: namespaceResponseData(child)}, nil
+}
+
+func (b *SystemBackend) handleNamespaceDelete(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.deleteNamespace(ctx, d.Get("path").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 func (b *SystemBackend) handlePluginRuntimeCatalogUpdate(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	runtimeName := d.Get("name").(string)
 	if runtimeName == "" {
@@ -803,14 +1314,21 @@ func (b *SystemBackend) handlePluginRuntimeCatalogUpdate(ctx context.Context, _
 		if memory < 0 {
 			return logical.ErrorResponse("runtime memory in bytes cannot be negative"), nil
 		}
+		disableNetwork := d.Get("disable_network").(bool)
+		networks := strutil.RemoveDuplicates(d.Get("networks").([]string), false)
+		if disableNetwork && len(networks) > 0 {
+			return logical.ErrorResponse("runtime networks cannot be set when the network is disabled"), nil
+		}
 		if err = b.Core.pluginRuntimeCatalog.Set(ctx,
 			&pluginruntimeutil.PluginRuntimeConfig{
-				Name:         runtimeName,
-				Type:         runtimeType,
-				OCIRuntime:   ociRuntime,
-				CgroupParent: cgroupParent,
-				CPU:          cpu,
-				Memory:       memory,
+				Name:           runtimeName,
+				Type:           runtimeType,
+				OCIRuntime:     ociRuntime,
+				CgroupParent:   cgroupParent,
+				CPU:            cpu,
+				Memory:         memory,
+				DisableNetwork: disableNetwork,
+				Networks:       networks,
 			}); err != nil {
 			return logical.ErrorResponse(err.Error()), nil
 		}
@@ -877,12 +1395,14 @@ func (b *SystemBackend) handlePluginRuntimeCatalogRead(ctx context.Context, _ *l
 	}
 
 	return &logical.Response{Data: map[string]interface{}{
-		"name":          conf.Name,
-		"type":          conf.Type.String(),
-		"oci_runtime":   conf.OCIRuntime,
-		"cgroup_parent": conf.CgroupParent,
-		"cpu_nanos":     conf.CPU,
-		"memory_bytes":  conf.Memory,
+		"name":            conf.Name,
+		"type":            conf.Type.String(),
+		"oci_runtime":     conf.OCIRuntime,
+		"cgroup_parent":   conf.CgroupParent,
+		"cpu_nanos":       conf.CPU,
+		"memory_bytes":    conf.Memory,
+		"disable_network": conf.DisableNetwork,
+		"networks":        conf.Networks,
 	}}, nil
 }
 
@@ -916,12 +1436,14 @@ func (b *SystemBackend) handlePluginRuntimeCatalogList(ctx context.Context, _ *l
 			})
 			for _, conf := range configs {
 				data = append(data, map[string]any{
-					"name":          conf.Name,
-					"type":          conf.Type.String(),
-					"oci_runtime":   conf.OCIRuntime,
-					"cgroup_parent": conf.CgroupParent,
-					"cpu_nanos":     conf.CPU,
-					"memory_bytes":  conf.Memory,
+					"name":            conf.Name,
+					"type":            conf.Type.String(),
+					"oci_runtime":     conf.OCIRuntime,
+					"cgroup_parent":   conf.CgroupParent,
+					"cpu_nanos":       conf.CPU,
+					"memory_bytes":    conf.Memory,
+					"disable_network": conf.DisableNetwork,
+					"networks":        conf.Networks,
 				})
 			}
 		}
@@ -2546,13 +3068,18 @@ func (b *SystemBackend) handleLeaseLookupList(ctx context.Context, req *logical.
 	if err != nil {
 		return nil, err
 	}
+	pagination, err := framework.GetListPagination(data)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	view := b.Core.expiration.leaseView(ns)
-	keys, err := view.List(ctx, prefix)
+	resp, err := pagination.ListResponse(ctx, view, prefix)
 	if err != nil {
 		b.Backend.Logger().Error("error listing leases", "prefix", prefix, "error", err)
 		return handleErrorNoReadOnlyForward(err)
 	}
-	return logical.ListResponse(keys), nil
+	return resp, nil
 }
 
 // handleRenew is used to renew a lease with a given LeaseID
@@ -2617,9 +3144,87 @@ func (b *SystemBackend) handleRevoke(ctx context.Context, req *logical.Request,
 
 // handleRevokePrefix is used to revoke a prefix with many LeaseIDs
 func (b *SystemBackend) handleRevokePrefix(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if data.Get("async").(bool) {
+		return b.handleRevokePrefixAsync(ctx, req, data)
+	}
 	return b.handleRevokePrefixCommon(ctx, req, data, false, data.Get("sync").(bool))
 }
 
+// handleRevokePrefixAsync starts a job revoking a prefix in the background
+func (b *SystemBackend) handleRevokePrefixAsync(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	prefix := data.Get("prefix").(string)
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobID, err := b.Core.expiration.RevokePrefixAsync(namespace.ContextWithNamespace(b.Core.activeContext, ns), prefix)
+	if err != nil {
+		b.Backend.Logger().Error("revoke prefix failed", "prefix", prefix, "error", err)
+		return handleErrorNoReadOnlyForward(err)
+	}
+
+	return logical.RespondWithStatusCode(&logical.Response{
+		Data: map[string]interface{}{
+			"job_id": jobID,
+		},
+	}, req, http.StatusAccepted)
+}
+
+// handleRevokePrefixJobsList lists the revoke prefix jobs of the namespace
+func (b *SystemBackend) handleRevokePrefixJobsList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	jobs, err := b.Core.expiration.listRevokePrefixJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(jobs))
+	keyInfo := make(map[string]interface{}, len(jobs))
+	for _, job := range jobs {
+		status := job.status()
+		keys = append(keys, job.ID)
+		keyInfo[job.ID] = map[string]interface{}{
+			"operation":  status["operation"],
+			"prefix":     status["prefix"],
+			"status":     status["status"],
+			"start_time": status["start_time"],
+		}
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleRevokePrefixJobRead returns the progress of a revoke prefix job
+func (b *SystemBackend) handleRevokePrefixJobRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	job, err := b.Core.expiration.revokePrefixJob(ctx, data.Get("job_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: job.status(),
+	}, nil
+}
+
+// handleRevokePrefixJobFailedList lists the leases a revoke prefix job failed
+// to revoke, along with their errors
+func (b *SystemBackend) handleRevokePrefixJobFailedList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	job, err := b.Core.expiration.revokePrefixJob(ctx, data.Get("job_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, nil
+	}
+
+	return logical.ListResponseWithInfo(job.failedLeases()), nil
+}
+
 // handleRevokeForce is used to revoke a prefix with many LeaseIDs, ignoring errors
 func (b *SystemBackend) handleRevokeForce(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	return b.handleRevokePrefixCommon(ctx, req, data, true, true)
@@ -3220,6 +3825,10 @@ func getPasswordPolicyKey(policyName string) string {
 const (
 	minPasswordLength = 4
 	maxPasswordLength = 100
+
+	// maxPasswordPolicyTestSamples is the maximum number of sample passwords
+	// generated when testing a password policy.
+	maxPasswordPolicyTestSamples = 100
 )
 
 // handlePoliciesPasswordList returns the list of password policies
@@ -3376,16 +3985,58 @@ func (*SystemBackend) handlePoliciesPasswordDelete(ctx context.Context, req *log
 			fmt.Sprintf("failed to delete password policy: %s", err))
 	}
 
-	return nil, nil
+	return nil, nil
+}
+
+// handlePoliciesPasswordGenerate generates a password from the specified password policy
+func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	policyName := data.Get("name").(string)
+	if policyName == "" {
+		return nil, logical.CodedError(http.StatusBadRequest, "missing policy name")
+	}
+
+	cfg, err := retrievePasswordPolicy(ctx, req.Storage, policyName)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError, "failed to retrieve password policy")
+	}
+	if cfg == nil {
+		return nil, logical.CodedError(http.StatusNotFound, "policy does not exist")
+	}
+
+	policy, err := random.ParsePolicy(cfg.HCLPolicy)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError,
+			"stored password policy configuration failed to parse")
+	}
+
+	password, err := policy.Generate(ctx, nil)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError,
+			fmt.Sprintf("failed to generate password from policy: %s", err))
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"password": password,
+		},
+	}
+	return resp, nil
 }
 
-// handlePoliciesPasswordGenerate generates a password from the specified password policy
-func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+// handlePoliciesPasswordTest generates sample passwords from the specified password policy, validates the provided
+// candidate passwords against it and estimates the entropy of its passwords
+func (*SystemBackend) handlePoliciesPasswordTest(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	policyName := data.Get("name").(string)
 	if policyName == "" {
 		return nil, logical.CodedError(http.StatusBadRequest, "missing policy name")
 	}
 
+	count := data.Get("count").(int)
+	if count < 0 || count > maxPasswordPolicyTestSamples {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("count must be between 0 and %d", maxPasswordPolicyTestSamples))
+	}
+
 	cfg, err := retrievePasswordPolicy(ctx, req.Storage, policyName)
 	if err != nil {
 		return nil, logical.CodedError(http.StatusInternalServerError, "failed to retrieve password policy")
@@ -3400,17 +4051,57 @@ func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *l
 			"stored password policy configuration failed to parse")
 	}
 
-	password, err := policy.Generate(ctx, nil)
+	samples := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		password, err := policy.Generate(ctx, nil)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError,
+				fmt.Sprintf("failed to generate password from policy: %s", err))
+		}
+		samples = append(samples, password)
+	}
+
+	entropy, err := policy.Entropy()
 	if err != nil {
 		return nil, logical.CodedError(http.StatusInternalServerError,
-			fmt.Sprintf("failed to generate password from policy: %s", err))
+			fmt.Sprintf("failed to estimate entropy of policy: %s", err))
+	}
+
+	// Report the results of the candidates in the order they were provided,
+	// without echoing the candidates themselves
+	var candidates []interface{}
+	for _, candidate := range data.Get("candidates").([]string) {
+		result := map[string]interface{}{
+			"valid":  true,
+			"errors": []string{},
+		}
+		if err := policy.Check(candidate); err != nil {
+			result["valid"] = false
+			var merr *multierror.Error
+			if errors.As(err, &merr) {
+				errs := make([]string, 0, len(merr.Errors))
+				for _, e := range merr.Errors {
+					errs = append(errs, e.Error())
+				}
+				result["errors"] = errs
+			} else {
+				result["errors"] = []string{err.Error()}
+			}
+		}
+		candidates = append(candidates, result)
 	}
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"password": password,
+			"samples":      samples,
+			"entropy_bits": math.Round(entropy*100) / 100,
+			"length":       policy.Length,
+			"charset_size": len(policy.Charset()),
 		},
 	}
+	if candidates != nil {
+		resp.Data["candidates"] = candidates
+	}
 	return resp, nil
 }
 
@@ -3719,6 +4410,30 @@ func (b *SystemBackend) handleRotate(ctx context.Context, _ *logical.Request, _
 	return nil, nil
 }
 
+// handleSealRewrapStatus reports the progress of the seal rewrap.
+func (b *SystemBackend) handleSealRewrapStatus(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: b.Core.sealRewrap.status(),
+	}, nil
+}
+
+// handleSealRewrapStart starts a seal rewrap in the background, or resumes a
+// paused one. If a rewrap is already running its status is returned.
+func (b *SystemBackend) handleSealRewrapStart(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if !b.Core.sealRewrap.start(b.Core.activeContext) {
+		return b.handleSealRewrapStatus(ctx, req, d)
+	}
+	return nil, nil
+}
+
+// handleSealRewrapPause pauses the running seal rewrap.
+func (b *SystemBackend) handleSealRewrapPause(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.sealRewrap.pause(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
 func (b *SystemBackend) handleWrappingPubkey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	x, _ := b.Core.wrappingJWTKey.X.MarshalText()
 	y, _ := b.Core.wrappingJWTKey.Y.MarshalText()
@@ -3944,6 +4659,14 @@ func (b *SystemBackend) handleInFlightRequestData(_ context.Context, req *logica
 	return resp, nil
 }
 
+// monitorModuleAliases maps the subsystems which can be monitored but have no
+// logger of their own to the names of their loggers.
+var monitorModuleAliases = map[string][]string{
+	// Secrets engines and auth methods log under secrets.<type>.<accessor>
+	// and auth.<type>.<accessor>
+	"plugin": {"secrets", "auth"},
+}
+
 func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	ll := data.Get("log_level").(string)
 	w := req.ResponseWriter
@@ -3965,26 +4688,37 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("unknown log format"), nil
 	}
 
-	flusher, ok := w.ResponseWriter.(http.Flusher)
+	flusher, ok := streamFlusher(w)
 	if !ok {
-		// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
-		// access the underlying functionality
-		nw, ok := w.ResponseWriter.(logical.WrappingResponseWriter)
-		if !ok {
-			return logical.ErrorResponse("streaming not supported"), nil
+		return logical.ErrorResponse("streaming not supported"), nil
+	}
+
+	rateLimit := data.Get("rate_limit").(int)
+	if rateLimit < 0 {
+		return logical.ErrorResponse("rate_limit must not be negative"), nil
+	}
+
+	var modules []string
+	for _, module := range data.Get("modules").([]string) {
+		module = strings.ToLower(strings.TrimSpace(module))
+		if aliases, ok := monitorModuleAliases[module]; ok {
+			modules = append(modules, aliases...)
+			continue
 		}
-		flusher, ok = nw.Wrapped().(http.Flusher)
-		if !ok {
-			return logical.ErrorResponse("streaming not supported"), nil
+		if module != "" {
+			modules = append(modules, module)
 		}
 	}
 
 	isJson := b.Core.LogFormat() == "json" || lf == "json"
 	logger := b.Core.Logger().(log.InterceptLogger)
 
-	mon, err := monitor.NewMonitor(512, logger, &log.LoggerOptions{
+	mon, err := monitor.NewFilteredMonitor(512, logger, &log.LoggerOptions{
 		Level:      logLevel,
 		JSONFormat: isJson,
+	}, &monitor.Filter{
+		Modules:   modules,
+		RateLimit: rateLimit,
 	})
 	if err != nil {
 		return nil, err
@@ -4042,6 +4776,83 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 	}
 }
 
+// streamFlusher returns the flusher of the response writer of a streaming
+// request.
+func streamFlusher(w *logical.HTTPResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if ok {
+		return flusher, true
+	}
+
+	// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
+	// access the underlying functionality
+	nw, ok := w.ResponseWriter.(logical.WrappingResponseWriter)
+	if !ok {
+		return nil, false
+	}
+	flusher, ok = nw.Wrapped().(http.Flusher)
+	return flusher, ok
+}
+
+// handleMonitorAudit streams the HMAC-redacted audit entries of the requests
+// handled by this node, one JSON object per line, until the connection is
+// closed or the core is sealed.
+func (b *SystemBackend) handleMonitorAudit(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	w := req.ResponseWriter
+	if w == nil {
+		return logical.ErrorResponse("streaming not supported"), nil
+	}
+
+	flusher, ok := streamFlusher(w)
+	if !ok {
+		return logical.ErrorResponse("streaming not supported"), nil
+	}
+
+	follower, stop := b.Core.auditTail.follow()
+	defer stop()
+
+	w.WriteHeader(http.StatusOK)
+
+	// 0 byte write is needed before the Flush call so that if we are using
+	// a gzip stream it will go ahead and write out the HTTP response header
+	if _, err := w.Write([]byte("")); err != nil {
+		return nil, fmt.Errorf("error seeding flusher: %w", err)
+	}
+
+	flusher.Flush()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if b.Core.Sealed() {
+				return nil, nil
+			}
+		case <-ctx.Done():
+			return nil, nil
+		case entry := <-follower.entries:
+			// Report the entries dropped because this follower fell behind
+			// before the next one, so that the client can tell.
+			if dropped := follower.dropped.Swap(0); dropped > 0 {
+				if _, err := fmt.Fprintf(w, "{\"type\":\"dropped\",\"dropped\":%d}\n", dropped); err != nil {
+					return nil, fmt.Errorf("error streaming audit entries: %w", err)
+				}
+			}
+
+			// We still return the error, but this will be ignored upstream
+			// due to the fact that we've already sent a response by
+			// writing the header and flushing the writer above.
+			if _, err := w.Write(entry); err != nil {
+				return nil, fmt.Errorf("error streaming audit entries: %w", err)
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
 // handleHostInfo collects and returns host-related information, which includes
 // system information, cpu, disk, and memory usage. Any capture-related errors
 // returned by the collection method will be returned as response warnings.
@@ -4172,6 +4983,66 @@ func (b *SystemBackend) handleWrappingLookup(ctx context.Context, req *logical.R
 	return resp, nil
 }
 
+// wrappingTokenFilterFromData returns the filter selecting the wrapping tokens
+// described by the request data.
+func wrappingTokenFilterFromData(data *framework.FieldData) *wrappingTokenFilter {
+	filter := &wrappingTokenFilter{
+		CreatorAccessor:    data.Get("creator_accessor").(string),
+		CreationPathPrefix: data.Get("creation_path_prefix").(string),
+	}
+	if accessors, ok := data.GetOk("accessors"); ok {
+		filter.Accessors = accessors.([]string)
+	}
+	return filter
+}
+
+// handleWrappingTokensList lists the outstanding wrapping tokens of the
+// namespace along with the information recorded in their cubbyholes.
+func (b *SystemBackend) handleWrappingTokensList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	infos, warnings, err := b.Core.listWrappingTokens(ctx, wrappingTokenFilterFromData(data))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(infos))
+	keyInfo := make(map[string]interface{}, len(infos))
+	for _, info := range infos {
+		keys = append(keys, info.Accessor)
+		keyInfo[info.Accessor] = info.data()
+	}
+
+	resp := logical.ListResponseWithInfo(keys, keyInfo)
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+	return resp, nil
+}
+
+// handleWrappingTokensRevoke revokes the outstanding wrapping tokens of the
+// namespace which match the request, destroying the responses they wrap.
+func (b *SystemBackend) handleWrappingTokensRevoke(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	filter := wrappingTokenFilterFromData(data)
+	if len(filter.Accessors) == 0 && filter.CreatorAccessor == "" && filter.CreationPathPrefix == "" && !data.Get("all").(bool) {
+		return logical.ErrorResponse("one of accessors, creator_accessor, creation_path_prefix or all must be set"), logical.ErrInvalidRequest
+	}
+
+	revoked, warnings, err := b.Core.revokeWrappingTokens(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"revoked":       revoked,
+			"revoked_count": len(revoked),
+		},
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+	return resp, nil
+}
+
 func (b *SystemBackend) handleWrappingRewrap(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	// If a third party is rewrapping (rather than the calling token being the
 	// wrapping token) we detect this so that we can revoke the original
@@ -5257,6 +6128,39 @@ func (b *SystemBackend) handleVersionHistoryList(ctx context.Context, req *logic
 	return logical.ListResponseWithInfo(respKeys, respKeyInfo), nil
 }
 
+func (b *SystemBackend) handleDeadlockDetectionRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	timeout := b.Core.DeadlockDetectionTimeout()
+	if timeout == 0 {
+		timeout = DefaultDeadlockDetectionTimeout
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled": b.Core.DeadlockDetectionTimeout() > 0,
+			"timeout": int64(timeout.Seconds()),
+			"locks":   b.Core.MonitoredLocks(),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleDeadlockDetectionUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if !d.Get("enabled").(bool) {
+		b.Core.SetDeadlockDetection(0)
+		return nil, nil
+	}
+
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Second
+	if timeout <= 0 {
+		return logical.ErrorResponse("timeout must be positive"), nil
+	}
+	b.Core.SetDeadlockDetection(timeout)
+	return nil, nil
+}
+
+func (b *SystemBackend) handleDeadlockDetectionDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.Core.SetDeadlockDetection(0)
+	return nil, nil
+}
+
 func (b *SystemBackend) handleLoggersRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	b.Core.allLoggersLock.RLock()
 	defer b.Core.allLoggersLock.RUnlock()
@@ -5488,6 +6392,44 @@ The path responds to the following HTTP methods.
         Sets the license for the server
 	`,
 	},
+	"config/opa": {
+		"Configures or returns the current configuration of the OPA policy evaluation.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the configuration of the OPA policy evaluation, without the bearer token.
+
+    POST /
+        Evaluates each authenticated request, other than those of root tokens,
+        against a decision of an OPA server, in addition to ACL policies.
+
+    DELETE /
+        Clears the configuration and disables the OPA policy evaluation.
+		`,
+	},
+
+	"config/admission-webhooks": {
+		"Configures the webhooks which admit or reject requests before they are routed.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        Returns the names of the admission webhooks.
+
+    GET /<name>
+        Returns the configuration of an admission webhook, without its bearer token.
+
+    POST /<name>
+        Posts the metadata of each authorized request it matches, other than
+        those of root tokens, to the webhook, which allows or rejects it and
+        may annotate its audit entries.
+
+    DELETE /<name>
+        Removes an admission webhook.
+		`,
+	},
+
 	"config/cors": {
 		"Configures or returns the current configuration of CORS settings.",
 		`
@@ -5503,6 +6445,25 @@ This path responds to the following HTTP methods.
         Clears the CORS configuration and disables acceptance of CORS requests.
 		`,
 	},
+	"config/deadlock-detection": {
+		"Configures the deadlock detection of the core locks on this node.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns whether the deadlock detection is enabled, its timeout and the monitored locks.
+
+    POST /
+        Enables or disables the deadlock detection. Attempts at acquiring a monitored lock
+        which wait for longer than the timeout are logged along with the stack traces of
+        all goroutines, and can be followed with sys/monitor using the "deadlock" module.
+
+    DELETE /
+        Disables the deadlock detection.
+
+The setting only applies to the node serving the request and is not persisted.
+		`,
+	},
 	"config/group-policy-application": {
 		"Configures how policies in groups should be applied, accepting 'within_namespace_hierarchy' (default) and 'any'," +
 			"which will allow policies to grant permissions in groups outside of those sharing a namespace hierarchy.",
@@ -5810,6 +6771,97 @@ all matching leases.
 		`,
 	},
 
+	"revoke-prefix-async": {
+		"Whether to revoke the leases in the background and return the ID of a job tracking the revocation",
+		"",
+	},
+
+	"revoke-prefix-jobs": {
+		"Track the asynchronous revocation of the leases under a prefix.",
+		`
+Revoke prefix requests with async set to true return the ID of a job which
+revokes the leases under the prefix in the background with a bounded pool of
+workers. Leases which fail to be revoked are recorded on the job rather than
+stopping it. These endpoints list the jobs of the namespace, return the
+progress of a job, and list the leases a job failed to revoke along with their
+errors. Jobs are kept in memory by the active node.
+		`,
+	},
+
+	"revoke-prefix-job-id": {
+		"The ID of the revoke prefix job.",
+		"",
+	},
+
+	"leases-config-revocation": {
+		"Configure the retry policy of the revocation of expired leases.",
+		`
+Expired leases which fail to be revoked are retried with an exponential
+backoff: the delay before attempt n is retry_base * 2^n, randomized by up to
+half of it and capped by max_retry_delay if set. Leases which fail to be
+revoked max_attempts times, or with an unrecoverable error, are marked
+irrevocable. Irrevocable leases can be listed with the type=irrevocable
+parameter of sys/leases, and removed with sys/leases/irrevocable/force-expire.
+
+The revocation of irrevocable leases is retried in the background every
+irrevocable_cleanup_interval. Leases which have been irrevocable for longer
+than irrevocable_force_expire_after, if set, are removed by this cleanup
+ignoring the errors of their backends.
+		`,
+	},
+
+	"leases-irrevocable-force-expire": {
+		"Remove an irrevocable lease.",
+		`
+Removes an irrevocable lease and its secondary indexes, ignoring the errors of
+its backend like revoke-force. This abdicates the responsibility for ensuring
+that the credentials of the lease are revoked, and should only be used once
+they have been revoked or cleaned up manually.
+		`,
+	},
+
+	"leases-irrevocable-force-expire-bulk": {
+		"Remove the irrevocable leases of a mount, or of all mounts, in the background.",
+		`
+Starts a job removing the irrevocable leases of the mount with the given
+accessor, or of all the mounts of the namespace if all_mounts is set, ignoring
+the errors of their backends. The progress of the job is read from
+sys/leases/revoke-prefix-jobs/<job_id>. Like force-expire, this abdicates the
+responsibility for ensuring that the credentials of the leases are revoked.
+		`,
+	},
+
+	"leases-irrevocable-retry": {
+		"Retry the revocation of irrevocable leases in the background.",
+		`
+Starts a job retrying the revocation of the irrevocable leases of the mount
+with the given accessor, or of all the mounts of the namespace. Leases which
+are revoked are removed, and the others stay irrevocable. The progress of the
+job and the leases which failed again are read from
+sys/leases/revoke-prefix-jobs/<job_id>.
+		`,
+	},
+
+	"leases-irrevocable-mounts": {
+		"Report the irrevocable leases grouped by mount.",
+		`
+Returns, for each mount with irrevocable leases, its path and type, the number
+of its irrevocable leases, when the oldest of them was marked irrevocable, and
+its most frequent revocation errors.
+		`,
+	},
+
+	"leases-irrevocable-cleanup": {
+		"Report the outcome of the last irrevocable lease cleanup.",
+		`
+Returns the number of irrevocable leases whose revocation was retried by the
+last run of the background cleanup, how many of them were revoked, removed
+because they exceeded irrevocable_force_expire_after, or failed again, both in
+total and for each mount. Nothing is returned until the cleanup has run on the
+active node.
+		`,
+	},
+
 	"revoke-prefix-path": {
 		`The path to revoke keys under. Example: "prod/aws/ops"`,
 		"",
@@ -6029,6 +7081,25 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"sealwrap-rewrap": {
+		"Re-wraps the values protected by the seal.",
+		`
+		Starts re-wrapping the root key, the recovery key and the seal wrapped
+		entries in storage with the current seal configuration, for example
+		after the key of a seal was rotated. The rewrap runs in the background
+		and resumes from where it left off if it was paused. Reading this path
+		reports the progress of the rewrap, overall and per mount.
+		`,
+	},
+
+	"sealwrap-rewrap-pause": {
+		"Pauses the running seal rewrap.",
+		`
+		Pauses the running seal rewrap. Starting the rewrap again resumes it
+		from where it left off.
+		`,
+	},
+
 	"rekey_backup": {
 		"Allows fetching or deleting the backup of the rotated unseal keys.",
 		"",
@@ -6083,6 +7154,21 @@ it.`,
 		`Returns the creation TTL and creation time of a response-wrapped token.`,
 	},
 
+	"wrapping-tokens": {
+		"Lists the outstanding response-wrapping tokens.",
+		`Lists the accessors of the response-wrapping tokens of the namespace which
+have not been unwrapped yet, along with their creation path, creation time,
+TTL and the accessor of the token which created them. The wrapped responses
+are never returned.`,
+	},
+
+	"wrapping-tokens-revoke": {
+		"Revokes outstanding response-wrapping tokens in bulk.",
+		`Revokes the response-wrapping tokens of the namespace selected by their
+accessors, the accessor of the token which created them or the prefix of
+their creation path, destroying the responses they wrap.`,
+	},
+
 	"rewrap": {
 		"Rotates a response-wrapped token.",
 		`Rotates a response-wrapped token; the output is a new token with the same
@@ -6169,10 +7255,55 @@ Each entry is of the form "key=value".`,
 Must already be present on the machine.`,
 		"",
 	},
+	"plugin-catalog_oci-artifact": {
+		`The reference of an OCI artifact holding the plugin binary, such as registry.example.com/vault/my-plugin:v1.0.0.
+Vault pulls the artifact into the plugin directory after verifying its signature against the plugin trust roots,
+and registers the SHA-256 of the binary.`,
+		"",
+	},
+	"plugin-catalog_oci-username": {
+		"The username to authenticate to the registry of oci_artifact with. It is not stored.",
+		"",
+	},
+	"plugin-catalog_oci-password": {
+		"The password to authenticate to the registry of oci_artifact with. It is not stored.",
+		"",
+	},
 	"plugin-catalog_runtime": {
 		`The Vault plugin runtime to use when running the plugin.`,
 		"",
 	},
+	"plugin-trust-roots": {
+		"Configures the public keys trusted to sign the plugins pulled from OCI registries.",
+		`
+Plugins registered with oci_artifact must be signed with cosign by the key of
+one of the trust roots.
+		`,
+	},
+	"plugin-trust-roots_name": {
+		"The name of the trust root.",
+		"",
+	},
+	"plugin-trust-roots_public-key": {
+		"The PEM encoded ECDSA, RSA or Ed25519 public key used to verify cosign signatures.",
+		"",
+	},
+	"namespaces": {
+		"Manages the child namespaces of the current namespace.",
+		`
+Namespaces isolate mounts, policies, tokens and leases. The mounts of a
+namespace are reached below its path, or by setting the X-Vault-Namespace
+header. Namespaces with child namespaces can't be deleted.
+		`,
+	},
+	"namespaces_path": {
+		"The name of the child namespace.",
+		"",
+	},
+	"namespaces_custom-metadata": {
+		"Arbitrary string keys and values describing the namespace.",
+		"",
+	},
 	"plugin-runtime-catalog": {
 		"Configures plugin runtimes",
 		`
@@ -6218,6 +7349,14 @@ This path responds to the following HTTP methods.
 		"Memory limit to set per container in bytes. Defaults to no limit.",
 		"",
 	},
+	"plugin-runtime-catalog_disable-network": {
+		"Whether to run each container without a network stack. Defaults to false.",
+		"",
+	},
+	"plugin-runtime-catalog_networks": {
+		"Container networks to attach each container to instead of the default network.",
+		"",
+	},
 	"leases": {
 		`View or list lease metadata.`,
 		`
@@ -6242,6 +7381,26 @@ This path responds to the following HTTP methods.
 		case that the plugin name is provided, all mounted paths that use that plugin
 		backend will be reloaded.`,
 	},
+	"plugin-rollout": {
+		"Pin the mounts of a plugin to a version of the plugin catalog.",
+		`
+Pins the mounts of the plugin in the namespace to a version registered in the
+plugin catalog and reloads them on this node, like tuning their plugin_version
+and reloading them one by one. Listing mounts only rolls out the version to
+these mounts, which allows staging an upgrade before rolling out the version
+to the remaining mounts, and rolling out a previous version rolls the mounts
+back. Mounts which fail to be reloaded with the version are restored to their
+previous version and reported in errors.
+		`,
+	},
+	"plugin-rollout-version": {
+		`The version to pin the mounts to. Defaults to the latest registered version.`,
+		"",
+	},
+	"plugin-rollout-mounts": {
+		`The paths of the mounts to roll out the version to. Defaults to all the mounts of the plugin in the namespace.`,
+		"",
+	},
 	"plugin-backend-reload-plugin": {
 		`The name of the plugin to reload, as registered in the plugin catalog.`,
 		"",
@@ -6300,6 +7459,17 @@ This path responds to the following HTTP methods.
 		"Export the metrics aggregated for telemetry purpose.",
 		"",
 	},
+	"monitor-audit": {
+		"Stream the audit entries of the requests handled by this node.",
+		`
+Streams the audit entries of the requests and responses handled by this node as
+they happen, one JSON object per line, whether or not an audit device is
+enabled. Sensitive values are HMAC-ed with a salt which only lives as long as
+the Vault process, so the hashes cannot be compared with those of the audit
+devices. Request headers are not included. Entries are dropped when the client
+does not keep up, which is reported by an object of type "dropped".
+		`,
+	},
 	"in-flight-req": {
 		"reports in-flight requests",
 		`
@@ -95,6 +95,236 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors"][1]),
 		},
 
+		{
+			Pattern: "config/opa$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "opa",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"address": {
+					Type:        framework.TypeString,
+					Description: "The address of the OPA server, such as http://127.0.0.1:8181.",
+				},
+				"decision_path": {
+					Type:        framework.TypeString,
+					Description: "The path of the decision evaluated for each request, such as vault/authz.",
+				},
+				"bearer_token": {
+					Type:        framework.TypeString,
+					Description: "The bearer token sent to the OPA server, if it requires authentication.",
+				},
+				"timeout": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultOPATimeout.Seconds()),
+					Description: "The timeout of each policy evaluation.",
+				},
+				"fail_open": {
+					Type:        framework.TypeBool,
+					Description: "Allow requests when the OPA server can't be reached or returns an error, instead of denying them.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleOPARead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the current OPA policy evaluation settings.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"enabled": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"address": {
+									Type: framework.TypeString,
+								},
+								"decision_path": {
+									Type: framework.TypeString,
+								},
+								"timeout": {
+									Type: framework.TypeDurationSecond,
+								},
+								"fail_open": {
+									Type: framework.TypeBool,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleOPAUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure the evaluation of requests against the policies of an OPA server.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleOPADelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Disable the OPA policy evaluation.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/opa"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config/opa"][1]),
+		},
+
+		{
+			Pattern: "config/admission-webhooks/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "admission-webhooks",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleAdmissionWebhooksList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "List the admission webhooks.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/admission-webhooks"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config/admission-webhooks"][1]),
+		},
+
+		{
+			Pattern: "config/admission-webhooks/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "admission-webhooks",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the admission webhook.",
+				},
+				"url": {
+					Type:        framework.TypeString,
+					Description: "The URL the admission requests are posted to.",
+				},
+				"paths": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The request paths, including their namespace, which are sent to the webhook. They may start or end with a * to match any prefix or suffix. All requests are sent when empty.",
+				},
+				"operations": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The operations of the requests which are sent to the webhook. All operations are sent when empty.",
+				},
+				"bearer_token": {
+					Type:        framework.TypeString,
+					Description: "The bearer token sent to the webhook, if it requires authentication.",
+				},
+				"timeout": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultAdmissionWebhookTimeout.Seconds()),
+					Description: "The timeout of each call to the webhook.",
+				},
+				"fail_open": {
+					Type:        framework.TypeBool,
+					Description: "Allow requests when the webhook can't be reached or returns an error, instead of rejecting them.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleAdmissionWebhookRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the configuration of an admission webhook, without its bearer token.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"url": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"paths": {
+									Type: framework.TypeCommaStringSlice,
+								},
+								"operations": {
+									Type: framework.TypeCommaStringSlice,
+								},
+								"timeout": {
+									Type: framework.TypeDurationSecond,
+								},
+								"fail_open": {
+									Type: framework.TypeBool,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleAdmissionWebhookUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure a webhook which admits or rejects the requests it matches.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleAdmissionWebhookDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "configuration",
+					},
+					Summary: "Remove an admission webhook.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/admission-webhooks"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config/admission-webhooks"][1]),
+		},
+
 		{
 			Pattern: "config/state/sanitized$",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -735,6 +965,78 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "config/deadlock-detection$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "deadlock-detection",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"enabled": {
+					Type:        framework.TypeBool,
+					Description: "Enables or disables the deadlock detection of the monitored locks.",
+					Default:     true,
+				},
+				"timeout": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long an attempt at acquiring a monitored lock may wait before it is reported as a potential deadlock.",
+					Default:     int(DefaultDeadlockDetectionTimeout.Seconds()),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleDeadlockDetectionRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationSuffix: "configuration",
+					},
+					Summary: "Return the deadlock detection settings of this node.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"enabled": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"timeout": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"locks": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleDeadlockDetectionUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Enable or disable the deadlock detection on this node.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleDeadlockDetectionDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "disable",
+					},
+					Summary: "Disable the deadlock detection on this node.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/deadlock-detection"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config/deadlock-detection"][1]),
+		},
 	}
 }
 
@@ -1835,40 +2137,149 @@ func (b *SystemBackend) sealPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["rotate"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["rotate"][1]),
 		},
-	}
-}
-
-func (b *SystemBackend) pluginsCatalogCRUDPath() *framework.Path {
-	return &framework.Path{
-		Pattern: "plugins/catalog(/(?P<type>auth|database|secret))?/(?P<name>.+)",
 
-		DisplayAttrs: &framework.DisplayAttributes{
-			OperationPrefix: "plugins-catalog",
-		},
+		{
+			Pattern: "sealwrap/rewrap$",
 
-		Fields: map[string]*framework.FieldSchema{
-			"name": {
-				Type:        framework.TypeString,
-				Description: strings.TrimSpace(sysHelp["plugin-catalog_name"][0]),
-			},
-			"type": {
-				Type:        framework.TypeString,
-				Description: strings.TrimSpace(sysHelp["plugin-catalog_type"][0]),
-			},
-			"sha256": {
-				Type:        framework.TypeString,
-				Description: strings.TrimSpace(sysHelp["plugin-catalog_sha-256"][0]),
-			},
-			"sha_256": {
-				Type:        framework.TypeString,
-				Description: strings.TrimSpace(sysHelp["plugin-catalog_sha-256"][0]),
-			},
-			"oci_image": {
-				Type:        framework.TypeString,
-				Description: strings.TrimSpace(sysHelp["plugin-catalog_oci-image"][0]),
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "seal-wrap",
 			},
-			"runtime": {
-				Type:        framework.TypeString,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSealRewrapStatus,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "rewrap-status",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"is_running": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"is_paused": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"entries": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+								"mounts": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+								"start_time": {
+									Type: framework.TypeString,
+								},
+								"end_time": {
+									Type: framework.TypeString,
+								},
+								"last_error": {
+									Type: framework.TypeString,
+								},
+							},
+						}},
+					},
+					Summary: "Report the progress of the seal rewrap.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSealRewrapStart,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "rewrap",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Start or resume a seal rewrap.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sealwrap-rewrap"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sealwrap-rewrap"][1]),
+		},
+
+		{
+			Pattern: "sealwrap/rewrap/pause$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "seal-wrap",
+				OperationVerb:   "pause",
+				OperationSuffix: "rewrap",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSealRewrapPause,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Pause the running seal rewrap.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sealwrap-rewrap-pause"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sealwrap-rewrap-pause"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) pluginsCatalogCRUDPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "plugins/catalog(/(?P<type>auth|database|secret))?/(?P<name>.+)",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "plugins-catalog",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_name"][0]),
+			},
+			"type": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_type"][0]),
+			},
+			"sha256": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_sha-256"][0]),
+			},
+			"sha_256": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_sha-256"][0]),
+			},
+			"oci_image": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_oci-image"][0]),
+			},
+			"oci_artifact": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_oci-artifact"][0]),
+			},
+			"oci_username": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_oci-username"][0]),
+			},
+			"oci_password": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_oci-password"][0]),
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+			"runtime": {
+				Type:        framework.TypeString,
 				Description: strings.TrimSpace(sysHelp["plugin-catalog_runtime"][0]),
 			},
 			"command": {
@@ -2110,6 +2521,175 @@ func (b *SystemBackend) pluginsReloadPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) pluginsRolloutPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "plugins/rollout/(?P<type>auth|secret)/(?P<name>.+)",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "plugins",
+			OperationVerb:   "rollout",
+			OperationSuffix: "version",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"type": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_type"][0]),
+			},
+			"name": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_name"][0]),
+			},
+			"version": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-rollout-version"][0]),
+			},
+			"mounts": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: strings.TrimSpace(sysHelp["plugin-rollout-mounts"][0]),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handlePluginRolloutUpdate,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"version": {
+								Type:     framework.TypeString,
+								Required: true,
+							},
+							"mounts": {
+								Type:     framework.TypeMap,
+								Required: true,
+							},
+							"errors": {
+								Type:     framework.TypeMap,
+								Required: false,
+							},
+						},
+					}},
+				},
+				Summary: "Pin the mounts of a plugin to a version of the plugin catalog and reload them.",
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-rollout"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["plugin-rollout"][1]),
+	}
+}
+
+func (b *SystemBackend) pluginsTrustRootsPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "plugins/trust-roots/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "plugins-trust-roots",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handlePluginTrustRootsList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "List the trust roots of the plugins pulled from OCI registries.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-trust-roots"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["plugin-trust-roots"][1]),
+		},
+		{
+			Pattern: "plugins/trust-roots/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "plugins-trust-roots",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["plugin-trust-roots_name"][0]),
+				},
+				"public_key": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["plugin-trust-roots_public-key"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handlePluginTrustRootUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "write",
+						OperationSuffix: "trust-root",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Add or update a trust root of the plugins pulled from OCI registries.",
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePluginTrustRootRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "trust-root",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"public_key": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Read a trust root of the plugins pulled from OCI registries.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handlePluginTrustRootDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "trust-root",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a trust root of the plugins pulled from OCI registries.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["plugin-trust-roots"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["plugin-trust-roots"][1]),
+		},
+	}
+}
+
 func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 	return &framework.Path{
 		Pattern: "plugins/runtimes/catalog/(?P<type>container)/" + framework.GenericNameRegex("name"),
@@ -2143,6 +2723,14 @@ func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 				Type:        framework.TypeInt64,
 				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_memory-bytes"][0]),
 			},
+			"disable_network": {
+				Type:        framework.TypeBool,
+				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_disable-network"][0]),
+			},
+			"networks": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_networks"][0]),
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -2212,6 +2800,16 @@ func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_memory-bytes"][0]),
 								Required:    true,
 							},
+							"disable_network": {
+								Type:        framework.TypeBool,
+								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_disable-network"][0]),
+								Required:    true,
+							},
+							"networks": {
+								Type:        framework.TypeStringSlice,
+								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_networks"][0]),
+								Required:    true,
+							},
 						},
 					}},
 				},
@@ -2879,12 +3477,12 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 				OperationVerb:   "look-up",
 			},
 
-			Fields: map[string]*framework.FieldSchema{
+			Fields: framework.AddListPaginationFields(map[string]*framework.FieldSchema{
 				"prefix": {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["leases-list-prefix"][0]),
 				},
-			},
+			}),
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ListOperation: &framework.PathOperation{
@@ -2898,6 +3496,11 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 									Description: "A list of lease ids",
 									Required:    false,
 								},
+								framework.ListNextAfterField: {
+									Type:        framework.TypeString,
+									Description: "The lease id to list the next page after, if there are more lease ids",
+									Required:    false,
+								},
 							},
 						}},
 					},
@@ -3104,6 +3707,11 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 					Default:     true,
 					Description: strings.TrimSpace(sysHelp["revoke-sync"][0]),
 				},
+				"async": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: strings.TrimSpace(sysHelp["revoke-prefix-async"][0]),
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -3114,12 +3722,468 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 							Description: "OK",
 						}},
 					},
-					Summary: "Revokes all secrets (via a lease ID prefix) or tokens (via the tokens' path property) generated under a given prefix immediately.",
+					Summary: "Revokes all secrets (via a lease ID prefix) or tokens (via the tokens' path property) generated under a given prefix immediately.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["revoke-prefix"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["revoke-prefix"][1]),
+		},
+
+		{
+			Pattern: "leases/revoke-prefix-jobs/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "list",
+				OperationSuffix: "revoke-prefix-jobs",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleRevokePrefixJobsList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: false,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "Lists the asynchronous revoke prefix jobs.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["revoke-prefix-jobs"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["revoke-prefix-jobs"][1]),
+		},
+
+		{
+			Pattern: "leases/revoke-prefix-jobs/(?P<job_id>[^/]+)$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "read",
+				OperationSuffix: "revoke-prefix-job",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"job_id": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["revoke-prefix-job-id"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRevokePrefixJobRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"job_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"operation": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"prefix": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"status": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"error": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"total": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"revoked": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"failed": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"pending": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"start_time": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"end_time": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Reads the progress of an asynchronous revoke prefix job.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["revoke-prefix-jobs"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["revoke-prefix-jobs"][1]),
+		},
+
+		{
+			Pattern: "leases/revoke-prefix-jobs/(?P<job_id>[^/]+)/failed/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "list",
+				OperationSuffix: "revoke-prefix-job-failed-leases",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"job_id": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["revoke-prefix-job-id"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleRevokePrefixJobFailedList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: false,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "Lists the leases an asynchronous revoke prefix job failed to revoke.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["revoke-prefix-jobs"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["revoke-prefix-jobs"][1]),
+		},
+
+		{
+			Pattern: "leases/config/revocation$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"max_attempts": {
+					Type:        framework.TypeInt,
+					Default:     maxRevokeAttempts,
+					Description: "The number of attempts to revoke an expired lease before it is marked irrevocable.",
+				},
+				"retry_base": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(revokeRetryBase.Seconds()),
+					Description: "The baseline of the exponential backoff between revocation attempts.",
+				},
+				"max_retry_delay": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The maximum backoff between revocation attempts. Unlimited if 0.",
+				},
+				"irrevocable_cleanup_interval": {
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultIrrevocableCleanupInterval.Seconds()),
+					Description: "How often the revocation of irrevocable leases is retried in the background.",
+				},
+				"irrevocable_force_expire_after": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long a lease may stay irrevocable before the background cleanup removes it, ignoring the errors of its backend. Irrevocable leases are never removed by the cleanup if 0.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseRevocationConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "revocation-configuration",
+					},
+					Summary: "Return the retry policy of the revocation of expired leases.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"max_attempts": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"retry_base": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"max_retry_delay": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"irrevocable_cleanup_interval": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+								"irrevocable_force_expire_after": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLeaseRevocationConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "configure",
+						OperationSuffix: "revocation",
+					},
+					Summary: "Configure the retry policy of the revocation of expired leases.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-config-revocation"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-config-revocation"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/force-expire$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "force-expire",
+				OperationSuffix: "irrevocable-lease",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"lease_id": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "The ID of the irrevocable lease.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleForceExpireIrrevocableLease,
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Removes an irrevocable lease, ignoring the errors of its backend.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable-force-expire"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable-force-expire"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/force-expire-bulk$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "force-expire",
+				OperationSuffix: "irrevocable-leases",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "The accessor of the mount whose irrevocable leases are removed.",
+				},
+				"all_mounts": {
+					Type:        framework.TypeBool,
+					Description: "Remove the irrevocable leases of all the mounts of the namespace.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeasesForceExpire,
+					Responses: map[int][]framework.Response{
+						http.StatusAccepted: {{
+							Description: "Accepted",
+							Fields: map[string]*framework.FieldSchema{
+								"job_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Starts a job removing irrevocable leases in bulk, ignoring the errors of their backends.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable-force-expire-bulk"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable-force-expire-bulk"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/retry$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "retry",
+				OperationSuffix: "irrevocable-leases",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "The accessor of the mount whose irrevocable leases are retried. The leases of all the mounts of the namespace are retried if not set.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeasesRetry,
+					Responses: map[int][]framework.Response{
+						http.StatusAccepted: {{
+							Description: "Accepted",
+							Fields: map[string]*framework.FieldSchema{
+								"job_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Starts a job retrying the revocation of irrevocable leases in bulk.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable-retry"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable-retry"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/mounts$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "read",
+				OperationSuffix: "irrevocable-leases-by-mount",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Set true if you want the leases of this namespace and its children.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeasesByMount,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of irrevocable leases",
+									Required:    true,
+								},
+								"mounts": {
+									Type:        framework.TypeMap,
+									Description: "Irrevocable leases of each mount, by mount accessor",
+									Required:    true,
+								},
+							},
+						}},
+					},
+					Summary: "Reports the irrevocable leases grouped by mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable-mounts"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable-mounts"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/cleanup$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "read",
+				OperationSuffix: "irrevocable-lease-cleanup",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeasesCleanupRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"start_time": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"end_time": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"retried": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"revoked": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"force_expired": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"failed": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"mounts": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Reports the outcome of the last run of the irrevocable lease cleanup.",
 				},
 			},
 
-			HelpSynopsis:    strings.TrimSpace(sysHelp["revoke-prefix"][0]),
-			HelpDescription: strings.TrimSpace(sysHelp["revoke-prefix"][1]),
+			HelpSynopsis:    strings.TrimSpace(sysHelp["leases-irrevocable-cleanup"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["leases-irrevocable-cleanup"][1]),
 		},
 
 		{
@@ -3383,6 +4447,16 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 				Query:       true,
 				Default:     "standard",
 			},
+			"modules": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Subsystems to view system logs of, such as \"expiration\", \"raft\" or \"plugin\". Logs of all subsystems are streamed by default.",
+				Query:       true,
+			},
+			"rate_limit": {
+				Type:        framework.TypeInt,
+				Description: "Maximum number of logs streamed per second. Logs over the limit are dropped. Unlimited by default.",
+				Query:       true,
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -3399,6 +4473,30 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) monitorAuditPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "monitor/audit",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "monitor",
+			OperationVerb:   "audit",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleMonitorAudit,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+		HelpSynopsis:    strings.TrimSpace(sysHelp["monitor-audit"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["monitor-audit"][1]),
+	}
+}
+
 func (b *SystemBackend) inFlightRequestPath() *framework.Path {
 	return &framework.Path{
 		Pattern: "in-flight-req",
@@ -4112,6 +5210,68 @@ func (b *SystemBackend) policyPaths() []*framework.Path {
 			HelpDescription: "Generate a password from an existing password policy.",
 		},
 
+		{
+			Pattern: "policies/password/(?P<name>.+)/test$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "policies",
+				OperationVerb:   "test",
+				OperationSuffix: "password-policy",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the password policy.",
+				},
+				"count": {
+					Type:        framework.TypeInt,
+					Default:     5,
+					Description: "The number of sample passwords to generate, up to 100.",
+				},
+				"candidates": {
+					Type:        framework.TypeStringSlice,
+					Description: "Passwords to validate against the password policy.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handlePoliciesPasswordTest,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"samples": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"entropy_bits": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+								"length": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"charset_size": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"candidates": {
+									Type: framework.TypeSlice,
+								},
+							},
+						}},
+					},
+					Summary: "Test an existing password policy by generating sample passwords and validating candidates.",
+				},
+			},
+
+			HelpSynopsis:    "Test an existing password policy.",
+			HelpDescription: "Generate sample passwords from an existing password policy, validate candidate passwords against it and estimate the entropy of its passwords.",
+		},
+
 		{
 			Pattern: "policies/password/(?P<name>.+)$",
 
@@ -4312,6 +5472,107 @@ func (b *SystemBackend) wrappingPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["wraplookup"][1]),
 		},
 
+		{
+			Pattern: "wrapping/tokens/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "wrapping",
+				OperationSuffix: "tokens",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"creator_accessor": {
+					Type:        framework.TypeString,
+					Description: "Only list the wrapping tokens created by the token with this accessor.",
+					Query:       true,
+				},
+				"creation_path_prefix": {
+					Type:        framework.TypeString,
+					Description: "Only list the wrapping tokens whose creation path starts with this prefix.",
+					Query:       true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleWrappingTokensList,
+					Summary:  "List the outstanding response-wrapping tokens.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["wrapping-tokens"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["wrapping-tokens"][1]),
+		},
+
+		{
+			Pattern: "wrapping/tokens/revoke$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "wrapping",
+				OperationVerb:   "revoke",
+				OperationSuffix: "tokens",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessors": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Accessors of the wrapping tokens to revoke.",
+				},
+				"creator_accessor": {
+					Type:        framework.TypeString,
+					Description: "Revoke the wrapping tokens created by the token with this accessor.",
+				},
+				"creation_path_prefix": {
+					Type:        framework.TypeString,
+					Description: "Revoke the wrapping tokens whose creation path starts with this prefix.",
+				},
+				"all": {
+					Type:        framework.TypeBool,
+					Description: "Revoke all the outstanding wrapping tokens of the namespace.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleWrappingTokensRevoke,
+					Summary:  "Revoke outstanding response-wrapping tokens in bulk.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"revoked": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"revoked_count": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["wrapping-tokens-revoke"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["wrapping-tokens-revoke"][1]),
+		},
+
 		{
 			Pattern: "wrapping/rewrap$",
 
@@ -4790,3 +6051,153 @@ func (b *SystemBackend) lockedUserPaths() []*framework.Path {
 		},
 	}
 }
+
+func (b *SystemBackend) namespacePaths() []*framework.Path {
+	namespaceResponseFields := map[string]*framework.FieldSchema{
+		"id": {
+			Type:     framework.TypeString,
+			Required: true,
+		},
+		"path": {
+			Type:     framework.TypeString,
+			Required: true,
+		},
+		"custom_metadata": {
+			Type:     framework.TypeMap,
+			Required: true,
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "namespaces/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "namespaces",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleNamespacesList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: false,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
+							},
+						}},
+					},
+					Summary: "List the child namespaces of the current namespace.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["namespaces"][1]),
+		},
+		{
+			Pattern: "namespaces/" + framework.GenericNameRegex("path") + "/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "namespaces",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["namespaces_path"][0]),
+				},
+				"custom_metadata": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["namespaces_custom-metadata"][0]),
+				},
+			},
+
+			ExistenceCheck: b.handleNamespaceExistenceCheck,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.handleNamespaceCreate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "create",
+						OperationSuffix: "namespace",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      namespaceResponseFields,
+						}},
+					},
+					Summary: "Create a child namespace of the current namespace.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleNamespaceUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "update",
+						OperationSuffix: "namespace",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      namespaceResponseFields,
+						}},
+					},
+					Summary: "Replace the custom metadata of a child namespace.",
+				},
+				logical.PatchOperation: &framework.PathOperation{
+					Callback: b.handleNamespacePatch,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "patch",
+						OperationSuffix: "namespace",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      namespaceResponseFields,
+						}},
+					},
+					Summary: "Patch the custom metadata of a child namespace.",
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleNamespaceRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "namespace",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      namespaceResponseFields,
+						}},
+					},
+					Summary: "Read a child namespace.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleNamespaceDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "namespace",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a child namespace, along with its mounts, policies, tokens and leases.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["namespaces"][1]),
+		},
+	}
+}
@@ -5,15 +5,20 @@ package vault
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-uuid"
+	raftlib "github.com/hashicorp/raft"
 	snapshot "github.com/hashicorp/raft-snapshot"
 	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -21,6 +26,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/autosnapshots"
 	"github.com/hashicorp/vault/vault/seal"
 	"github.com/mitchellh/mapstructure"
 )
@@ -104,6 +110,54 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-remove-peer"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-remove-peer"][1]),
 		},
+		{
+			Pattern: "storage/raft/promote",
+
+			Fields: map[string]*framework.FieldSchema{
+				"dr_operation_token": {
+					Type:        framework.TypeString,
+					Description: "DR operation token used to authorize this request (if a DR secondary node).",
+				},
+				"server_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the non-voting peer to promote.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.verifyDROperationTokenOnSecondary(b.handleRaftPromoteUpdate(), false),
+					Summary:  "Promote a non-voting peer to a voter.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-promote"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-promote"][1]),
+		},
+		{
+			Pattern: "storage/raft/demote",
+
+			Fields: map[string]*framework.FieldSchema{
+				"dr_operation_token": {
+					Type:        framework.TypeString,
+					Description: "DR operation token used to authorize this request (if a DR secondary node).",
+				},
+				"server_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the voting peer to demote.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.verifyDROperationTokenOnSecondary(b.handleRaftDemoteUpdate(), false),
+					Summary:  "Demote a voting peer to a non-voter.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-demote"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-demote"][1]),
+		},
 		{
 			Pattern: "storage/raft/configuration",
 
@@ -159,6 +213,65 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][1]),
 		},
+		{
+			Pattern: "storage/raft/snapshot-upload$",
+			Fields: map[string]*framework.FieldSchema{
+				"size": {
+					Type:        framework.TypeInt64,
+					Description: "Size of the snapshot in bytes.",
+					Required:    true,
+				},
+				"sha256": {
+					Type:        framework.TypeString,
+					Description: "Hex encoded SHA256 sum of the snapshot, verified once it is uploaded.",
+					Required:    true,
+				},
+				"force": {
+					Type:        framework.TypeBool,
+					Description: "Bypass the checks ensuring the current Autounseal or Shamir keys are consistent with the snapshot data.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotUploadStart(),
+					Summary:  "Starts uploading a snapshot in chunks, to be installed once it is fully uploaded.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-upload"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-upload"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-upload/" + framework.GenericNameRegex("upload_id"),
+			Fields: map[string]*framework.FieldSchema{
+				"upload_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the snapshot upload.",
+				},
+				"offset": {
+					Type:        framework.TypeInt64,
+					Description: "Offset of the chunk in the snapshot, which must be the number of bytes received so far. Given as a query parameter, the chunk being the request body.",
+					Query:       true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotUploadStatus(),
+					Summary:  "Returns the progress of a snapshot upload and restore.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotUploadChunk(makeSealer(b.logger, "snapshot_write")),
+					Summary:  "Appends a chunk to a snapshot upload, installing the snapshot once it is fully uploaded.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotUploadAbort(),
+					Summary:  "Aborts a snapshot upload.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-upload-id"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-upload-id"][1]),
+		},
 		{
 			Pattern: "storage/raft/autopilot/state",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -221,6 +334,191 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-autopilot-configuration"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-autopilot-configuration"][1]),
 		},
+		{
+			Pattern: "storage/raft/autopilot/cleanup-dead-servers",
+			Fields: map[string]*framework.FieldSchema{
+				"dr_operation_token": {
+					Type:        framework.TypeString,
+					Description: "DR operation token used to authorize this request (if a DR secondary node).",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.verifyDROperationTokenOnSecondary(b.handleStorageRaftAutopilotCleanupDeadServers(), false),
+					Summary:  "Triggers an immediate removal of the dead servers by autopilot.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-autopilot-cleanup-dead-servers"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-autopilot-cleanup-dead-servers"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/config/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigList(),
+					Summary:  "Lists the automated snapshot configurations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config-list"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config-list"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/config/" + framework.GenericNameRegex("name"),
+			Fields:  raftSnapshotAutoConfigFields(),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigRead(),
+					Summary:  "Reads an automated snapshot configuration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigUpdate(),
+					Summary:  "Creates or updates an automated snapshot configuration.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigDelete(),
+					Summary:  "Deletes an automated snapshot configuration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/status/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automated snapshot configuration.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:                  b.handleStorageRaftSnapshotAutoStatus(),
+					Summary:                   "Returns the status of an automated snapshot configuration.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][1]),
+		},
+	}
+}
+
+func raftSnapshotAutoConfigFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the automated snapshot configuration.",
+		},
+		"interval": {
+			Type:        framework.TypeDurationSecond,
+			Description: "Time between snapshots.",
+		},
+		"retain": {
+			Type:        framework.TypeInt,
+			Default:     1,
+			Description: "How many snapshots to keep. Older ones are deleted after a new snapshot is written.",
+		},
+		"path_prefix": {
+			Type:        framework.TypeString,
+			Description: "For storage_type=local, the directory to write snapshots to. For cloud storage types, the object name prefix to use.",
+		},
+		"file_prefix": {
+			Type:        framework.TypeString,
+			Default:     autosnapshots.DefaultFilePrefix,
+			Description: "Prefix of the snapshot file or object names within path_prefix.",
+		},
+		"storage_type": {
+			Type:        framework.TypeString,
+			Description: `Where to write snapshots: "local", "aws-s3", "google-gcs" or "azure-blob".`,
+		},
+		"local_max_space": {
+			Type:        framework.TypeInt,
+			Description: "For storage_type=local, the maximum space in bytes used by all snapshots of this configuration.",
+		},
+		"aws_s3_bucket": {
+			Type:        framework.TypeString,
+			Description: "S3 bucket to write snapshots to.",
+		},
+		"aws_s3_region": {
+			Type:        framework.TypeString,
+			Description: "AWS region the bucket is in.",
+		},
+		"aws_access_key_id": {
+			Type:        framework.TypeString,
+			Description: "AWS access key ID.",
+		},
+		"aws_secret_access_key": {
+			Type:        framework.TypeString,
+			Description: "AWS secret access key.",
+		},
+		"aws_session_token": {
+			Type:        framework.TypeString,
+			Description: "AWS session token.",
+		},
+		"aws_s3_endpoint": {
+			Type:        framework.TypeString,
+			Description: "S3 endpoint, for S3 compatible stores.",
+		},
+		"aws_s3_disable_tls": {
+			Type:        framework.TypeBool,
+			Description: "Disable TLS for the S3 endpoint. Only meant for testing.",
+		},
+		"aws_s3_force_path_style": {
+			Type:        framework.TypeBool,
+			Description: "Use path style bucket URLs.",
+		},
+		"aws_s3_enable_kms": {
+			Type:        framework.TypeBool,
+			Description: "Use KMS to encrypt snapshots.",
+		},
+		"aws_s3_server_side_encryption": {
+			Type:        framework.TypeBool,
+			Description: "Use AES256 server side encryption. Cannot be combined with aws_s3_enable_kms.",
+		},
+		"aws_s3_kms_key": {
+			Type:        framework.TypeString,
+			Description: "KMS key to encrypt snapshots with when aws_s3_enable_kms is set.",
+		},
+		"google_gcs_bucket": {
+			Type:        framework.TypeString,
+			Description: "GCS bucket to write snapshots to.",
+		},
+		"google_service_account_key": {
+			Type:        framework.TypeString,
+			Description: "Google service account key in JSON format.",
+		},
+		"google_endpoint": {
+			Type:        framework.TypeString,
+			Description: "GCS endpoint, for GCS compatible stores.",
+		},
+		"google_disable_tls": {
+			Type:        framework.TypeBool,
+			Description: "Disable TLS for the GCS endpoint. Only meant for testing.",
+		},
+		"azure_container_name": {
+			Type:        framework.TypeString,
+			Description: "Azure container to write snapshots to.",
+		},
+		"azure_account_name": {
+			Type:        framework.TypeString,
+			Description: "Azure storage account name.",
+		},
+		"azure_account_key": {
+			Type:        framework.TypeString,
+			Description: "Azure storage account key.",
+		},
+		"azure_blob_environment": {
+			Type:        framework.TypeString,
+			Description: "Azure environment, such as AzurePublicCloud.",
+		},
+		"azure_endpoint": {
+			Type:        framework.TypeString,
+			Description: "Azure blob storage endpoint, for Azure compatible stores.",
+		},
 	}
 }
 
@@ -262,6 +560,61 @@ func (b *SystemBackend) handleRaftRemovePeerUpdate() framework.OperationFunc {
 
 		b.Core.raftFollowerStates.Delete(serverID)
 
+		if err := b.Core.setRaftSuffrageOverride(ctx, raftBackend, serverID, ""); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleRaftPromoteUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		serverID := d.Get("server_id").(string)
+		if len(serverID) == 0 {
+			return logical.ErrorResponse("no server id provided"), logical.ErrInvalidRequest
+		}
+
+		raftBackend := b.Core.getRaftBackend()
+		if raftBackend == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		if err := raftBackend.PromotePeer(ctx, serverID); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		// Keep autopilot from demoting the peer again if it joined as a
+		// non-voter
+		if err := b.Core.setRaftSuffrageOverride(ctx, raftBackend, serverID, "voter"); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleRaftDemoteUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		serverID := d.Get("server_id").(string)
+		if len(serverID) == 0 {
+			return logical.ErrorResponse("no server id provided"), logical.ErrInvalidRequest
+		}
+
+		raftBackend := b.Core.getRaftBackend()
+		if raftBackend == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		if err := raftBackend.DemotePeer(ctx, serverID); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		// Keep autopilot from promoting the peer again once it is stable
+		if err := b.Core.setRaftSuffrageOverride(ctx, raftBackend, serverID, "non-voter"); err != nil {
+			return nil, err
+		}
+
 		return nil, nil
 	}
 }
@@ -421,6 +774,188 @@ func (b *SystemBackend) handleStorageRaftSnapshotRead(makeSealer func() snapshot
 	}
 }
 
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if b.Core.getRaftBackend() == nil || b.Core.isRaftHAOnly() {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		names, err := b.Core.barrier.List(ctx, raftAutoSnapshotConfigPrefix)
+		if err != nil {
+			return nil, err
+		}
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if b.Core.getRaftBackend() == nil || b.Core.isRaftHAOnly() {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		config, err := b.Core.raftAutoSnapshotConfig(ctx, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return nil, nil
+		}
+
+		data := map[string]interface{}{
+			"interval":     int64(config.Interval.Seconds()),
+			"retain":       config.Retain,
+			"path_prefix":  config.PathPrefix,
+			"file_prefix":  config.FilePrefix,
+			"storage_type": config.StorageType,
+		}
+
+		// Credentials are never returned
+		switch config.StorageType {
+		case autosnapshots.StorageTypeLocal:
+			data["local_max_space"] = config.LocalMaxSpace
+		case autosnapshots.StorageTypeAWSS3:
+			data["aws_s3_bucket"] = config.AWSS3Bucket
+			data["aws_s3_region"] = config.AWSS3Region
+			data["aws_access_key_id"] = config.AWSAccessKeyID
+			data["aws_s3_endpoint"] = config.AWSS3Endpoint
+			data["aws_s3_disable_tls"] = config.AWSS3DisableTLS
+			data["aws_s3_force_path_style"] = config.AWSS3ForcePathStyle
+			data["aws_s3_enable_kms"] = config.AWSS3EnableKMS
+			data["aws_s3_server_side_encryption"] = config.AWSS3ServerSideEncryption
+			data["aws_s3_kms_key"] = config.AWSS3KMSKey
+		case autosnapshots.StorageTypeGoogleGCS:
+			data["google_gcs_bucket"] = config.GoogleGCSBucket
+			data["google_endpoint"] = config.GoogleEndpoint
+			data["google_disable_tls"] = config.GoogleDisableTLS
+		case autosnapshots.StorageTypeAzureBlob:
+			data["azure_container_name"] = config.AzureContainerName
+			data["azure_account_name"] = config.AzureAccountName
+			data["azure_blob_environment"] = config.AzureBlobEnvironment
+			data["azure_endpoint"] = config.AzureEndpoint
+		}
+
+		return &logical.Response{Data: data}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if b.Core.getRaftBackend() == nil || b.Core.isRaftHAOnly() {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		config, err := b.Core.raftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = &autosnapshots.Config{
+				Retain:     d.Get("retain").(int),
+				FilePrefix: d.Get("file_prefix").(string),
+			}
+		}
+
+		if v, ok := d.GetOk("interval"); ok {
+			config.Interval = time.Duration(v.(int)) * time.Second
+		}
+		if v, ok := d.GetOk("retain"); ok {
+			config.Retain = v.(int)
+		}
+		if v, ok := d.GetOk("local_max_space"); ok {
+			config.LocalMaxSpace = int64(v.(int))
+		}
+		for field, dest := range map[string]*string{
+			"path_prefix":                &config.PathPrefix,
+			"file_prefix":                &config.FilePrefix,
+			"storage_type":               &config.StorageType,
+			"aws_s3_bucket":              &config.AWSS3Bucket,
+			"aws_s3_region":              &config.AWSS3Region,
+			"aws_access_key_id":          &config.AWSAccessKeyID,
+			"aws_secret_access_key":      &config.AWSSecretAccessKey,
+			"aws_session_token":          &config.AWSSessionToken,
+			"aws_s3_endpoint":            &config.AWSS3Endpoint,
+			"aws_s3_kms_key":             &config.AWSS3KMSKey,
+			"google_gcs_bucket":          &config.GoogleGCSBucket,
+			"google_service_account_key": &config.GoogleServiceAccountKey,
+			"google_endpoint":            &config.GoogleEndpoint,
+			"azure_container_name":       &config.AzureContainerName,
+			"azure_account_name":         &config.AzureAccountName,
+			"azure_account_key":          &config.AzureAccountKey,
+			"azure_blob_environment":     &config.AzureBlobEnvironment,
+			"azure_endpoint":             &config.AzureEndpoint,
+		} {
+			if v, ok := d.GetOk(field); ok {
+				*dest = v.(string)
+			}
+		}
+		for field, dest := range map[string]*bool{
+			"aws_s3_disable_tls":            &config.AWSS3DisableTLS,
+			"aws_s3_force_path_style":       &config.AWSS3ForcePathStyle,
+			"aws_s3_enable_kms":             &config.AWSS3EnableKMS,
+			"aws_s3_server_side_encryption": &config.AWSS3ServerSideEncryption,
+			"google_disable_tls":            &config.GoogleDisableTLS,
+		} {
+			if v, ok := d.GetOk(field); ok {
+				*dest = v.(bool)
+			}
+		}
+
+		if err := config.Validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		entry, err := logical.StorageEntryJSON(raftAutoSnapshotConfigPrefix+name, config)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Core.barrier.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		if m := b.Core.raftAutoSnapshots; m != nil {
+			m.reload(name, config)
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if b.Core.getRaftBackend() == nil || b.Core.isRaftHAOnly() {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		if err := b.Core.barrier.Delete(ctx, raftAutoSnapshotConfigPrefix+name); err != nil {
+			return nil, err
+		}
+
+		if m := b.Core.raftAutoSnapshots; m != nil {
+			m.reload(name, nil)
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoStatus() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		m := b.Core.raftAutoSnapshots
+		if m == nil {
+			return logical.ErrorResponse("automated snapshots are not running on this node"), logical.ErrInvalidRequest
+		}
+
+		status := m.status(d.Get("name").(string))
+		if status == nil {
+			return nil, nil
+		}
+		return status.statusResponse(), nil
+	}
+}
+
 func (b *SystemBackend) handleStorageRaftAutopilotState() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftBackend := b.Core.getRaftBackend()
@@ -449,6 +984,21 @@ func (b *SystemBackend) handleStorageRaftAutopilotState() framework.OperationFun
 	}
 }
 
+func (b *SystemBackend) handleStorageRaftAutopilotCleanupDeadServers() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftBackend := b.Core.getRaftBackend()
+		if raftBackend == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		if err := raftBackend.RemoveDeadServers(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return nil, nil
+	}
+}
+
 func (b *SystemBackend) handleStorageRaftAutopilotConfigRead() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftBackend := b.Core.getRaftBackend()
@@ -587,12 +1137,7 @@ func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer fu
 		switch {
 		case err == nil:
 		case strings.Contains(err.Error(), "failed to open the sealed hashes"):
-			switch b.Core.seal.BarrierSealConfigType() {
-			case SealConfigTypeShamir:
-				return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different set of unseal keys; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
-			default:
-				return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different autoseal key; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
-			}
+			return logical.ErrorResponse(b.raftSnapshotHashError() + "; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
 		case err != nil:
 			b.Core.logger.Error("raft snapshot restore: failed to write snapshot", "error", err)
 			return nil, err
@@ -600,83 +1145,260 @@ func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer fu
 
 		// We want to do this in a go routine so we can upgrade the lock and
 		// allow the client to disconnect.
-		go func() (retErr error) {
-			// Cleanup the temp file
-			defer cleanup()
-
-			// Grab statelock
-			l := newLockGrabber(b.Core.stateLock.Lock, b.Core.stateLock.Unlock, b.Core.standbyStopCh.Load().(chan struct{}))
-			go l.grab()
-			if stopped := l.lockOrStop(); stopped {
-				b.Core.logger.Error("not applying snapshot; shutting down")
-				return
-			}
-			defer b.Core.stateLock.Unlock()
-
-			// If we failed to restore the snapshot we should seal this node as
-			// it's in an unknown state
-			defer func() {
-				if retErr != nil {
-					if err := b.Core.sealInternalWithOptions(false, false, true); err != nil {
-						b.Core.logger.Error("failed to seal node", "error", err)
-					}
-				}
-			}()
-
-			ctx, ctxCancel := context.WithCancel(namespace.RootContext(nil))
-
-			// We are calling the callback function synchronously here while we
-			// have the lock. So set it to nil and restore the callback when we
-			// finish.
-			raftStorage.SetRestoreCallback(nil)
-			defer raftStorage.SetRestoreCallback(b.Core.raftSnapshotRestoreCallback(true, true))
-
-			// Do a preSeal to clear vault's in-memory caches and shut down any
-			// systems that might be holding the encryption access.
-			b.Core.logger.Info("shutting down prior to restoring snapshot")
-			if err := b.Core.preSeal(); err != nil {
-				b.Core.logger.Error("raft snapshot restore failed preSeal", "error", err)
-				return err
-			}
+		go b.applyRaftSnapshot(raftStorage, snapFile, cleanup, metadata)
 
-			b.Core.logger.Info("applying snapshot")
-			if err := raftStorage.RestoreSnapshot(ctx, metadata, snapFile); err != nil {
-				b.Core.logger.Error("error while restoring raft snapshot", "error", err)
-				return err
-			}
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotUploadStart() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		size := d.Get("size").(int64)
+		if size <= 0 {
+			return logical.ErrorResponse("size must be positive"), logical.ErrInvalidRequest
+		}
+		sum, err := hex.DecodeString(d.Get("sha256").(string))
+		if err != nil || len(sum) != sha256.Size {
+			return logical.ErrorResponse("sha256 must be a hex encoded SHA256 sum"), logical.ErrInvalidRequest
+		}
+
+		up, err := b.Core.raftSnapshotUploads.start(size, sum, d.Get("force").(bool))
+		switch {
+		case errors.Is(err, errRaftSnapshotUploadInProgress):
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		case err != nil:
+			return nil, err
+		}
+
+		return raftSnapshotUploadResponse(up), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotUploadStatus() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		up := b.Core.raftSnapshotUploads.get(d.Get("upload_id").(string))
+		if up == nil {
+			return nil, nil
+		}
+
+		return raftSnapshotUploadResponse(up), nil
+	}
+}
 
-			// Run invalidation logic synchronously here
-			callback := b.Core.raftSnapshotRestoreCallback(false, false)
-			if err := callback(ctx); err != nil {
-				return err
+func (b *SystemBackend) handleStorageRaftSnapshotUploadChunk(makeSealer func() snapshot.Sealer) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+			return nil, errors.New("no reader for request")
+		}
+
+		up := b.Core.raftSnapshotUploads.get(d.Get("upload_id").(string))
+		if up == nil {
+			return logical.ErrorResponse("unknown snapshot upload"), logical.ErrInvalidRequest
+		}
+
+		// The chunk being the request body, the offset is only found in the
+		// query parameters
+		offset, err := strconv.ParseInt(req.HTTPRequest.URL.Query().Get("offset"), 10, 64)
+		if err != nil {
+			return logical.ErrorResponse("offset must be set to the number of bytes received"), logical.ErrInvalidRequest
+		}
+
+		complete, err := up.write(offset, req.HTTPRequest.Body)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		if complete {
+			var sealer snapshot.Sealer
+			if !up.force {
+				sealer = makeSealer()
 			}
 
-			{
-				// If the snapshot was taken while another node was leader we
-				// need to reset the leader information to this node.
-				if err := b.Core.underlyingPhysical.Put(ctx, &physical.Entry{
-					Key:   CoreLockPath,
-					Value: []byte(b.Core.leaderUUID),
-				}); err != nil {
-					b.Core.logger.Error("cluster setup failed", "error", err)
-					return err
-				}
-				// re-advertise our cluster information
-				if err := b.Core.advertiseLeader(ctx, b.Core.leaderUUID, nil); err != nil {
-					b.Core.logger.Error("cluster setup failed", "error", err)
-					return err
-				}
+			// The snapshot is verified and restored in the background, the
+			// client following the progress through the upload status.
+			go b.restoreRaftSnapshotUpload(raftStorage, up, sealer)
+		}
+
+		return raftSnapshotUploadResponse(up), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotUploadAbort() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := b.Core.raftSnapshotUploads.abort(d.Get("upload_id").(string)); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return nil, nil
+	}
+}
+
+// restoreRaftSnapshotUpload verifies and restores a fully uploaded snapshot,
+// recording the outcome in its status.
+func (b *SystemBackend) restoreRaftSnapshotUpload(raftStorage *raft.RaftBackend, up *raftSnapshotUpload, sealer snapshot.Sealer) {
+	snapFile, cleanup, metadata, err := up.verify(raftStorage, sealer)
+	if err != nil {
+		if strings.Contains(err.Error(), "failed to open the sealed hashes") {
+			err = errors.New(b.raftSnapshotHashError() + "; use force to bypass this check")
+		}
+		b.Core.logger.Error("raft snapshot restore: failed to verify uploaded snapshot", "error", err)
+		up.fail(err)
+		return
+	}
+
+	// The snapshot was copied to the file it is restored from
+	up.discard()
+	up.restoreSize.Store(metadata.Size)
+	up.setState(raftSnapshotUploadStateRestoring)
+
+	// Requests are blocked while the snapshot is applied, so its progress is
+	// logged instead
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() {
+		ticker := time.NewTicker(raftSnapshotUploadProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				b.Core.logger.Info("applying uploaded snapshot", "bytes_restored", up.restored.Load(), "restore_size", metadata.Size)
 			}
-			if err := b.Core.postUnseal(ctx, ctxCancel, standardUnsealStrategy{}); err != nil {
-				b.Core.logger.Error("raft snapshot restore failed postUnseal", "error", err)
-				return err
+		}
+	}()
+
+	if err := b.applyRaftSnapshot(raftStorage, &raftSnapshotProgressReader{r: snapFile, n: &up.restored}, cleanup, metadata); err != nil {
+		up.fail(err)
+		return
+	}
+	up.setState(raftSnapshotUploadStateRestored)
+}
+
+func raftSnapshotUploadResponse(up *raftSnapshotUpload) *logical.Response {
+	status := up.status()
+
+	data := map[string]interface{}{
+		"upload_id":      status.ID,
+		"state":          status.State,
+		"size":           status.Size,
+		"force":          status.Force,
+		"bytes_received": status.BytesReceived,
+		"bytes_verified": status.BytesVerified,
+		"bytes_restored": status.BytesRestored,
+		"restore_size":   status.RestoreSize,
+		"start_time":     status.StartTime.Format(time.RFC3339Nano),
+		"update_time":    status.UpdateTime.Format(time.RFC3339Nano),
+	}
+	if status.Error != "" {
+		data["error"] = status.Error
+	}
+
+	return &logical.Response{Data: data}
+}
+
+// raftSnapshotHashError explains the failure to verify the sealed hashes of a
+// snapshot.
+func (b *SystemBackend) raftSnapshotHashError() string {
+	switch b.Core.seal.BarrierSealConfigType() {
+	case SealConfigTypeShamir:
+		return "could not verify hash file, possibly the snapshot is using a different set of unseal keys"
+	default:
+		return "could not verify hash file, possibly the snapshot is using a different autoseal key"
+	}
+}
+
+// applyRaftSnapshot restores the snapshot written to a temporary file, with
+// the stateLock write locked. The node is sealed if the restore fails, since
+// it is in an unknown state.
+func (b *SystemBackend) applyRaftSnapshot(raftStorage *raft.RaftBackend, snap io.Reader, cleanup func(), metadata raftlib.SnapshotMeta) (retErr error) {
+	// Cleanup the temp file
+	defer cleanup()
+
+	// Grab statelock
+	l := newLockGrabber(b.Core.stateLock.Lock, b.Core.stateLock.Unlock, b.Core.standbyStopCh.Load().(chan struct{}))
+	go l.grab()
+	if stopped := l.lockOrStop(); stopped {
+		b.Core.logger.Error("not applying snapshot; shutting down")
+		return errors.New("shutting down")
+	}
+	defer b.Core.stateLock.Unlock()
+
+	// If we failed to restore the snapshot we should seal this node as
+	// it's in an unknown state
+	defer func() {
+		if retErr != nil {
+			if err := b.Core.sealInternalWithOptions(false, false, true); err != nil {
+				b.Core.logger.Error("failed to seal node", "error", err)
 			}
+		}
+	}()
+
+	ctx, ctxCancel := context.WithCancel(namespace.RootContext(nil))
+	// On success, the context becomes the active context of the core, which
+	// is canceled when sealing; it is only canceled here on failure.
+	defer func() {
+		if retErr != nil {
+			ctxCancel()
+		}
+	}()
+
+	// We are calling the callback function synchronously here while we
+	// have the lock. So set it to nil and restore the callback when we
+	// finish.
+	raftStorage.SetRestoreCallback(nil)
+	defer raftStorage.SetRestoreCallback(b.Core.raftSnapshotRestoreCallback(true, true))
+
+	// Do a preSeal to clear vault's in-memory caches and shut down any
+	// systems that might be holding the encryption access.
+	b.Core.logger.Info("shutting down prior to restoring snapshot")
+	if err := b.Core.preSeal(); err != nil {
+		b.Core.logger.Error("raft snapshot restore failed preSeal", "error", err)
+		return err
+	}
+
+	b.Core.logger.Info("applying snapshot")
+	if err := raftStorage.RestoreSnapshot(ctx, metadata, snap); err != nil {
+		b.Core.logger.Error("error while restoring raft snapshot", "error", err)
+		return err
+	}
 
-			return nil
-		}()
+	// Run invalidation logic synchronously here
+	callback := b.Core.raftSnapshotRestoreCallback(false, false)
+	if err := callback(ctx); err != nil {
+		return err
+	}
 
-		return nil, nil
+	{
+		// If the snapshot was taken while another node was leader we
+		// need to reset the leader information to this node.
+		if err := b.Core.underlyingPhysical.Put(ctx, &physical.Entry{
+			Key:   CoreLockPath,
+			Value: []byte(b.Core.leaderUUID),
+		}); err != nil {
+			b.Core.logger.Error("cluster setup failed", "error", err)
+			return err
+		}
+		// re-advertise our cluster information
+		if err := b.Core.advertiseLeader(ctx, b.Core.leaderUUID, nil); err != nil {
+			b.Core.logger.Error("cluster setup failed", "error", err)
+			return err
+		}
+	}
+	if err := b.Core.postUnseal(ctx, ctxCancel, standardUnsealStrategy{}); err != nil {
+		b.Core.logger.Error("raft snapshot restore failed postUnseal", "error", err)
+		return err
 	}
+
+	return nil
 }
 
 var sysRaftHelp = map[string][2]string{
@@ -697,10 +1419,33 @@ var sysRaftHelp = map[string][2]string{
 		"Removes a peer from the raft cluster.",
 		"",
 	},
+	"raft-promote": {
+		"Promotes a non-voting peer to a voter.",
+		`The peer remains a voter even if it joined the cluster as a
+		non-voter.`,
+	},
+	"raft-demote": {
+		"Demotes a voting peer to a non-voter.",
+		`The peer keeps receiving the replication stream and can serve reads,
+		but no longer takes part in leader elections. The active node cannot
+		be demoted.`,
+	},
 	"raft-snapshot": {
 		"Restores and saves snapshots from the raft cluster.",
 		"",
 	},
+	"raft-snapshot-upload": {
+		"Starts uploading a snapshot in chunks.",
+		`The snapshot is uploaded in chunks to the returned upload ID, and is
+		installed once all of it was received and its SHA256 sum verified.`,
+	},
+	"raft-snapshot-upload-id": {
+		"Uploads a chunk of a snapshot, or returns the progress of the upload.",
+		`Each chunk is the body of a request, given the offset of the chunk as
+		a query parameter. A chunk which fails midway is kept up to the data
+		received, so that the upload resumes from the bytes_received of its
+		status.`,
+	},
 	"raft-snapshot-force": {
 		"Force restore a raft cluster snapshot",
 		"",
@@ -713,6 +1458,30 @@ var sysRaftHelp = map[string][2]string{
 		"Returns autopilot configuration.",
 		"",
 	},
+	"raft-autopilot-cleanup-dead-servers": {
+		"Triggers an immediate removal of the dead servers by autopilot.",
+		`
+Removes the failed and stale servers from the raft cluster right away, instead
+of waiting for the next periodic cleanup of autopilot. The removal follows the
+autopilot configuration: servers are only considered failed after
+dead_server_last_contact_threshold, and min_quorum is respected. Dead server
+cleanup must be enabled with cleanup_dead_servers.
+		`,
+	},
+	"raft-snapshot-auto-config-list": {
+		"Lists the automated snapshot configurations.",
+		"",
+	},
+	"raft-snapshot-auto-config": {
+		"Manages automated snapshot configurations.",
+		`Each configuration sets how often the active node takes a raft snapshot,
+where the snapshot is written (a local directory, S3, GCS or Azure blob
+storage) and how many snapshots are retained.`,
+	},
+	"raft-snapshot-auto-status": {
+		"Returns the status of an automated snapshot configuration.",
+		"",
+	},
 }
 
 func NewSealAccessSealer(access seal.Access, logger hclog.Logger, use string) snapshot.Sealer {
@@ -0,0 +1,380 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var errRotationManagerUnavailable = errors.New("root credential rotation is only available on the active node")
+
+// rotationJobResponseFields are the fields of a rotation job as returned by
+// the sys/rotation endpoints.
+var rotationJobResponseFields = map[string]*framework.FieldSchema{
+	"mount_accessor": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"mount_path": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"mount_type": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"endpoint": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"rotation_period": {
+		Type:     framework.TypeDurationSecond,
+		Required: true,
+	},
+	"rotation_schedule": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"last_attempt": {
+		Type: framework.TypeString,
+	},
+	"last_rotation": {
+		Type: framework.TypeString,
+	},
+	"next_rotation": {
+		Type: framework.TypeString,
+	},
+	"last_error": {
+		Type: framework.TypeString,
+	},
+	"consecutive_failures": {
+		Type:     framework.TypeInt,
+		Required: true,
+	},
+}
+
+// rotationPaths returns the paths which schedule the rotation of the root
+// credentials of mounts.
+func (b *SystemBackend) rotationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "rotation/jobs/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationVerb:   "list",
+				OperationSuffix: "jobs",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobsList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the mounts with scheduled root credential rotations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(rotationHelp["rotation-jobs-list"][0]),
+			HelpDescription: strings.TrimSpace(rotationHelp["rotation-jobs-list"][1]),
+		},
+		{
+			Pattern: "rotation/jobs/(?P<path>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "The path of the mount, such as aws/ or auth/aws/.",
+				},
+				"endpoint": {
+					Type:        framework.TypeString,
+					Description: "The root credential rotation endpoint of the mount, relative to the mount path. Defaults to the endpoint of known mount types.",
+				},
+				"rotation_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The time between rotations. Mutually exclusive with rotation_schedule.",
+				},
+				"rotation_schedule": {
+					Type:        framework.TypeString,
+					Description: "A cron style schedule of the rotations. Mutually exclusive with rotation_period.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "job",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      rotationJobResponseFields,
+						}},
+					},
+					Summary: "Read the root credential rotation schedule and status of a mount.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobWrite,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "write",
+						OperationSuffix: "job",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Schedule the root credential rotation of a mount.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "job",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Stop rotating the root credential of a mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(rotationHelp["rotation-jobs"][0]),
+			HelpDescription: strings.TrimSpace(rotationHelp["rotation-jobs"][1]),
+		},
+		{
+			Pattern: "rotation/rotate/(?P<path>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationVerb:   "rotate",
+				OperationSuffix: "root",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: "The path of the mount, such as aws/ or auth/aws/.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRotationRotate,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      rotationJobResponseFields,
+						}},
+					},
+					Summary: "Rotate the root credential of a mount now.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(rotationHelp["rotation-rotate"][0]),
+			HelpDescription: strings.TrimSpace(rotationHelp["rotation-rotate"][1]),
+		},
+	}
+}
+
+// rotationMount returns the mount at the path, relative to the namespace of
+// the request.
+func (b *SystemBackend) rotationMount(ctx context.Context, path string) (*MountEntry, error) {
+	path = sanitizePath(path)
+	entry := b.Core.router.MatchingMountEntry(ctx, path)
+	if entry == nil || rotationMountPath(entry) != path {
+		return nil, fmt.Errorf("no mount found at %q", path)
+	}
+	return entry, nil
+}
+
+func (b *SystemBackend) handleRotationJobsList(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.rotationManager
+	if m == nil {
+		return nil, errRotationManagerUnavailable
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for accessor, job := range m.listJobs() {
+		entry := b.Core.router.MatchingMountByAccessor(accessor)
+		if entry == nil || entry.Namespace().ID != ns.ID {
+			continue
+		}
+
+		mountPath := rotationMountPath(entry)
+		keys = append(keys, mountPath)
+		keyInfo[mountPath] = map[string]interface{}{
+			"mount_accessor": accessor,
+			"mount_type":     entry.Type,
+			"next_rotation":  formatRotationTime(job.nextRotation()),
+			"last_error":     job.LastError,
+		}
+	}
+
+	sort.Strings(keys)
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleRotationJobRead(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.rotationManager
+	if m == nil {
+		return nil, errRotationManagerUnavailable
+	}
+
+	entry, err := b.rotationMount(ctx, d.Get("path").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	job := m.job(entry.Accessor)
+	if job == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: job.responseData(entry),
+	}, nil
+}
+
+func (b *SystemBackend) handleRotationJobWrite(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.rotationManager
+	if m == nil {
+		return nil, errRotationManagerUnavailable
+	}
+
+	entry, err := b.rotationMount(ctx, d.Get("path").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	job := &rotationJob{
+		MountAccessor:    entry.Accessor,
+		Endpoint:         strings.Trim(d.Get("endpoint").(string), "/"),
+		RotationPeriod:   time.Duration(d.Get("rotation_period").(int)) * time.Second,
+		RotationSchedule: d.Get("rotation_schedule").(string),
+	}
+	if job.Endpoint == "" {
+		job.Endpoint = defaultRotationEndpoint(entry)
+		if job.Endpoint == "" {
+			return logical.ErrorResponse("endpoint is required for mounts of type %q", entry.Type), logical.ErrInvalidRequest
+		}
+	}
+	if err := job.validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if err := m.setJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleRotationJobDelete(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.rotationManager
+	if m == nil {
+		return nil, errRotationManagerUnavailable
+	}
+
+	entry, err := b.rotationMount(ctx, d.Get("path").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, m.deleteJob(ctx, entry.Accessor)
+}
+
+// handleRotationRotate rotates the root credential of a mount with a rotation
+// job immediately, which also resets its schedule.
+func (b *SystemBackend) handleRotationRotate(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.rotationManager
+	if m == nil {
+		return nil, errRotationManagerUnavailable
+	}
+
+	entry, err := b.rotationMount(ctx, d.Get("path").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if m.job(entry.Accessor) == nil {
+		return logical.ErrorResponse("no rotation job for mount %q", rotationMountPath(entry)), logical.ErrInvalidRequest
+	}
+
+	job, err := m.rotate(entry.Accessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate root credential: %w", err)
+	}
+
+	return &logical.Response{
+		Data: job.responseData(entry),
+	}, nil
+}
+
+var rotationHelp = map[string][2]string{
+	"rotation-jobs-list": {
+		"Lists the mounts with scheduled root credential rotations.",
+		`
+		Lists the paths of the mounts of the namespace which have a root
+		credential rotation job, along with when they are next rotated and the
+		error of their last rotation, if it failed.
+		`,
+	},
+	"rotation-jobs": {
+		"Schedules the rotation of the root credential of a mount.",
+		`
+		Schedules the rotation of the root credential of a secrets engine or
+		auth method by calling its root credential rotation endpoint, either
+		periodically with rotation_period or on a cron style rotation_schedule.
+		The endpoint defaults to the rotation endpoint of the aws, rabbitmq, ad
+		and ldap secrets engines and of the aws auth method, and can be set for
+		other mounts. Reading this path reports the outcome of the last
+		rotations. A failed rotation is retried, and a rotation/failure event
+		is sent for each failure.
+		`,
+	},
+	"rotation-rotate": {
+		"Rotates the root credential of a mount now.",
+		`
+		Rotates the root credential of a mount which has a rotation job now,
+		and reports the outcome. The next scheduled rotation is computed from
+		this rotation.
+		`,
+	},
+}
@@ -0,0 +1,886 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/secretsync"
+)
+
+// secretsSyncConnectionFields are the connection details accepted by each
+// destination type, mapped to the fields of the destination they set.
+var secretsSyncConnectionFields = map[string]map[string]func(*secretsync.Destination) *string{
+	secretsync.TypeAWSSecretsManager: {
+		"access_key_id":     func(d *secretsync.Destination) *string { return &d.AWSAccessKeyID },
+		"secret_access_key": func(d *secretsync.Destination) *string { return &d.AWSSecretAccessKey },
+		"region":            func(d *secretsync.Destination) *string { return &d.AWSRegion },
+		"endpoint":          func(d *secretsync.Destination) *string { return &d.AWSEndpoint },
+	},
+	secretsync.TypeAzureKeyVault: {
+		"key_vault_uri": func(d *secretsync.Destination) *string { return &d.AzureKeyVaultURI },
+		"tenant_id":     func(d *secretsync.Destination) *string { return &d.AzureTenantID },
+		"client_id":     func(d *secretsync.Destination) *string { return &d.AzureClientID },
+		"client_secret": func(d *secretsync.Destination) *string { return &d.AzureClientSecret },
+		"cloud":         func(d *secretsync.Destination) *string { return &d.AzureCloud },
+	},
+	secretsync.TypeGCPSecretManager: {
+		"credentials": func(d *secretsync.Destination) *string { return &d.GCPCredentials },
+		"project_id":  func(d *secretsync.Destination) *string { return &d.GCPProjectID },
+		"endpoint":    func(d *secretsync.Destination) *string { return &d.GCPEndpoint },
+	},
+	secretsync.TypeGitHubActions: {
+		"access_token":     func(d *secretsync.Destination) *string { return &d.GitHubAccessToken },
+		"repository_owner": func(d *secretsync.Destination) *string { return &d.GitHubOwner },
+		"repository_name":  func(d *secretsync.Destination) *string { return &d.GitHubRepository },
+		"api_url":          func(d *secretsync.Destination) *string { return &d.GitHubAPIURL },
+	},
+}
+
+var secretsSyncDestinationResponseFields = map[string]*framework.FieldSchema{
+	"type": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"name": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"connection_details": {
+		Type:     framework.TypeMap,
+		Required: true,
+	},
+	"secret_name_template": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"granularity": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+}
+
+var secretsSyncAssociationsResponseFields = map[string]*framework.FieldSchema{
+	"associated_secrets": {
+		Type:     framework.TypeMap,
+		Required: true,
+	},
+	"associations": {
+		Type:     framework.TypeSlice,
+		Required: true,
+	},
+	"store_name": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+	"store_type": {
+		Type:     framework.TypeString,
+		Required: true,
+	},
+}
+
+// secretsSyncPaths returns the paths which configure the destinations secrets
+// are synced to, and the secrets associated to them.
+func (b *SystemBackend) secretsSyncPaths() []*framework.Path {
+	destinationFields := map[string]*framework.FieldSchema{
+		"type": {
+			Type:        framework.TypeString,
+			Description: "The type of the destination: aws-sm, azure-kv, gcp-sm or gh.",
+		},
+		"name": {
+			Type:        framework.TypeString,
+			Description: "The name of the destination.",
+		},
+	}
+
+	configFields := map[string]*framework.FieldSchema{
+		"type": destinationFields["type"],
+		"name": destinationFields["name"],
+		"secret_name_template": {
+			Type:        framework.TypeString,
+			Description: "The template the names of the external secrets are generated with. Defaults to " + secretsync.DefaultSecretNameTemplate + ".",
+		},
+		"granularity": {
+			Type:        framework.TypeString,
+			Description: "Whether each secret is synced as one external secret holding its keys as JSON (secret-path), or each key is synced as its own external secret (secret-key).",
+			Default:     secretsync.GranularitySecretPath,
+		},
+		"access_key_id": {
+			Type:        framework.TypeString,
+			Description: "aws-sm: The access key ID. Defaults to the AWS credential provider chain.",
+		},
+		"secret_access_key": {
+			Type:        framework.TypeString,
+			Description: "aws-sm: The secret access key. Defaults to the AWS credential provider chain.",
+		},
+		"region": {
+			Type:        framework.TypeString,
+			Description: "aws-sm: The region of the secrets. Defaults to the AWS credential provider chain.",
+		},
+		"key_vault_uri": {
+			Type:        framework.TypeString,
+			Description: "azure-kv: The URI of the key vault.",
+		},
+		"tenant_id": {
+			Type:        framework.TypeString,
+			Description: "azure-kv: The tenant ID of the app registration.",
+		},
+		"client_id": {
+			Type:        framework.TypeString,
+			Description: "azure-kv: The client ID of the app registration, or of the managed identity without client_secret.",
+		},
+		"client_secret": {
+			Type:        framework.TypeString,
+			Description: "azure-kv: The client secret of the app registration. Defaults to the managed identity of the host.",
+		},
+		"cloud": {
+			Type:        framework.TypeString,
+			Description: "azure-kv: The Azure cloud of the key vault. Defaults to the public cloud.",
+		},
+		"credentials": {
+			Type:        framework.TypeString,
+			Description: "gcp-sm: The JSON credentials. Defaults to the application default credentials.",
+		},
+		"project_id": {
+			Type:        framework.TypeString,
+			Description: "gcp-sm: The project of the secrets. Defaults to the project of the credentials.",
+		},
+		"access_token": {
+			Type:        framework.TypeString,
+			Description: "gh: The access token, allowed to manage the Actions secrets of the repository.",
+		},
+		"repository_owner": {
+			Type:        framework.TypeString,
+			Description: "gh: The user or organization owning the repository.",
+		},
+		"repository_name": {
+			Type:        framework.TypeString,
+			Description: "gh: The name of the repository.",
+		},
+		"api_url": {
+			Type:        framework.TypeString,
+			Description: "gh: The URL of the GitHub API. Defaults to https://api.github.com.",
+		},
+		"endpoint": {
+			Type:        framework.TypeString,
+			Description: "aws-sm, gcp-sm: The endpoint of the secret store API, overriding the default one.",
+		},
+	}
+
+	associationFields := map[string]*framework.FieldSchema{
+		"type": destinationFields["type"],
+		"name": destinationFields["name"],
+		"mount": {
+			Type:        framework.TypeString,
+			Description: "The path of the KV v2 mount of the secrets.",
+			Required:    true,
+		},
+		"secret_name": {
+			Type:        framework.TypeString,
+			Description: "The path of the secret relative to the mount, or a glob pattern matching the paths of several secrets.",
+			Required:    true,
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "sync/destinations/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "list",
+				OperationSuffix: "destinations",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncDestinationsList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"key_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the destinations, grouped by type.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-destinations-list"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-destinations-list"][1]),
+		},
+		{
+			Pattern: "sync/destinations/(?P<type>[^/]+)/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "list",
+				OperationSuffix: "destinations-by-type",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"type": destinationFields["type"],
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncDestinationsListByType,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "List the destinations of a type.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-destinations-list"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-destinations-list"][1]),
+		},
+		{
+			Pattern: "sync/destinations/(?P<type>[^/]+)/(?P<name>[^/]+)$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+			},
+
+			Fields: configFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncDestinationRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "destination",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      secretsSyncDestinationResponseFields,
+						}},
+					},
+					Summary: "Read a destination, with its sensitive connection details masked.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncDestinationWrite,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "write",
+						OperationSuffix: "destination",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      secretsSyncDestinationResponseFields,
+						}},
+					},
+					Summary: "Create or update a destination.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncDestinationDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "delete",
+						OperationSuffix: "destination",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Delete a destination without associations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-destination"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-destination"][1]),
+		},
+		{
+			Pattern: "sync/destinations/(?P<type>[^/]+)/(?P<name>[^/]+)/associations$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "read",
+				OperationSuffix: "associations",
+			},
+
+			Fields: destinationFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncAssociationsRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      secretsSyncAssociationsResponseFields,
+						}},
+					},
+					Summary: "Read the associations of a destination and the sync status of their secrets.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-associations"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-associations"][1]),
+		},
+		{
+			Pattern: "sync/destinations/(?P<type>[^/]+)/(?P<name>[^/]+)/associations/set$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "set",
+				OperationSuffix: "association",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"type":        associationFields["type"],
+				"name":        associationFields["name"],
+				"mount":       associationFields["mount"],
+				"secret_name": associationFields["secret_name"],
+				"keys": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Glob patterns of the keys of the secrets to sync. Defaults to all keys.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncAssociationSet,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      secretsSyncAssociationsResponseFields,
+						}},
+					},
+					Summary: "Associate secrets to a destination, and sync them.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-associations-set"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-associations-set"][1]),
+		},
+		{
+			Pattern: "sync/destinations/(?P<type>[^/]+)/(?P<name>[^/]+)/associations/remove$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "remove",
+				OperationSuffix: "association",
+			},
+
+			Fields: associationFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncAssociationRemove,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      secretsSyncAssociationsResponseFields,
+						}},
+					},
+					Summary: "Remove an association from a destination, and its secrets from the destination.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-associations-remove"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-associations-remove"][1]),
+		},
+		{
+			Pattern: "sync/status$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets-sync",
+				OperationVerb:   "read",
+				OperationSuffix: "status",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSecretsSyncStatusRead,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"destinations": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+								"last_reconcile": {
+									Type: framework.TypeString,
+								},
+								"reconcile_interval": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Report the sync status of the secrets of all the destinations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(secretsSyncHelp["sync-status"][0]),
+			HelpDescription: strings.TrimSpace(secretsSyncHelp["sync-status"][1]),
+		},
+	}
+}
+
+// secretsSyncDestinationType returns the destination type of the request,
+// which must be supported.
+func secretsSyncDestinationType(d *framework.FieldData) (string, error) {
+	typ := d.Get("type").(string)
+	if !strutil.StrListContains(secretsync.Types, typ) {
+		return "", fmt.Errorf("invalid destination type %q, must be one of %s", typ, strings.Join(secretsync.Types, ", "))
+	}
+	return typ, nil
+}
+
+// secretsSyncKVMount returns the KV v2 mount at the path, relative to the
+// namespace of the request.
+func (b *SystemBackend) secretsSyncKVMount(ctx context.Context, path string) (*MountEntry, error) {
+	path = sanitizePath(path)
+	entry := b.Core.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.Table != mountTableType || entry.Path != path {
+		return nil, fmt.Errorf("no mount found at %q", path)
+	}
+	if entry.Type != "kv" || entry.Options["version"] != "2" {
+		return nil, fmt.Errorf("mount %q is not a KV v2 secrets engine", path)
+	}
+	return entry, nil
+}
+
+func secretsSyncDestinationResponse(config *secretsync.Destination) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type":                 config.Type,
+			"name":                 config.Name,
+			"connection_details":   config.ConnectionDetails(),
+			"secret_name_template": config.SecretNameTemplate,
+			"granularity":          config.Granularity,
+		},
+	}
+}
+
+// secretsSyncAssociationsResponse returns the associations of a destination
+// along with the sync status of the secrets they match. Associations with a
+// single secret which was never synced are reported as unsynced.
+func (b *SystemBackend) secretsSyncAssociationsResponse(ctx context.Context, m *secretsSyncManager, dest *secretsSyncDestination) (*logical.Response, error) {
+	statuses, err := m.listStatuses(ctx, dest.config)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]interface{}, len(statuses))
+	for _, status := range statuses {
+		secret := map[string]interface{}{
+			"accessor":    status.Accessor,
+			"secret_name": status.SecretName,
+			"association": status.Association,
+			"sync_status": status.SyncStatus,
+			"updated_at":  status.UpdatedAt.Format(time.RFC3339Nano),
+		}
+		if status.Error != "" {
+			secret["error"] = status.Error
+		}
+		secrets[status.Accessor+"/"+status.SecretName] = secret
+	}
+
+	m.l.RLock()
+	associations := dest.associations
+	m.l.RUnlock()
+
+	assocs := make([]map[string]interface{}, 0, len(associations))
+	for _, assoc := range associations {
+		mount := ""
+		if entry := b.Core.router.MatchingMountByAccessor(assoc.Accessor); entry != nil {
+			mount = entry.Path
+		}
+		assocs = append(assocs, map[string]interface{}{
+			"accessor":    assoc.Accessor,
+			"mount":       mount,
+			"secret_name": assoc.SecretName,
+			"keys":        assoc.Keys,
+			"created_at":  assoc.CreatedAt.Format(time.RFC3339Nano),
+		})
+
+		key := assoc.Accessor + "/" + assoc.SecretName
+		if _, ok := secrets[key]; ok || strings.Contains(assoc.SecretName, "*") {
+			continue
+		}
+		secrets[key] = map[string]interface{}{
+			"accessor":    assoc.Accessor,
+			"secret_name": assoc.SecretName,
+			"association": assoc.SecretName,
+			"sync_status": secretsSyncStatusUnsynced,
+			"updated_at":  assoc.CreatedAt.Format(time.RFC3339Nano),
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"associated_secrets": secrets,
+			"associations":       assocs,
+			"store_name":         dest.config.Name,
+			"store_type":         dest.config.Type,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleSecretsSyncDestinationsList(_ context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, dest := range m.listDestinations() {
+		names, ok := keyInfo[dest.config.Type].([]string)
+		if !ok {
+			keys = append(keys, dest.config.Type)
+		}
+		keyInfo[dest.config.Type] = append(names, dest.config.Name)
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleSecretsSyncDestinationsListByType(_ context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	var keys []string
+	for _, dest := range m.listDestinations() {
+		if dest.config.Type == typ {
+			keys = append(keys, dest.config.Name)
+		}
+	}
+	return logical.ListResponse(keys), nil
+}
+
+func (b *SystemBackend) handleSecretsSyncDestinationRead(_ context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	dest := m.destination(typ, d.Get("name").(string))
+	if dest == nil {
+		return nil, nil
+	}
+	return secretsSyncDestinationResponse(dest.config), nil
+}
+
+// handleSecretsSyncDestinationWrite creates or updates a destination. The
+// fields omitted from an update keep their value.
+func (b *SystemBackend) handleSecretsSyncDestinationWrite(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	name := d.Get("name").(string)
+
+	config := &secretsync.Destination{
+		Type:        typ,
+		Name:        name,
+		Granularity: d.Get("granularity").(string),
+	}
+	if existing := m.destination(typ, name); existing != nil {
+		copied := *existing.config
+		config = &copied
+	}
+
+	// Reject the connection details of the other destination types, which
+	// would be silently ignored
+	fields := secretsSyncConnectionFields[typ]
+	for key := range d.Raw {
+		switch key {
+		case "type", "name", "secret_name_template", "granularity":
+			continue
+		}
+		if _, ok := fields[key]; !ok {
+			return logical.ErrorResponse("%s is not a connection detail of %s destinations", key, typ), logical.ErrInvalidRequest
+		}
+	}
+	for key, field := range fields {
+		if value, ok := d.GetOk(key); ok {
+			*field(config) = value.(string)
+		}
+	}
+	if value, ok := d.GetOk("secret_name_template"); ok {
+		config.SecretNameTemplate = value.(string)
+	}
+	if value, ok := d.GetOk("granularity"); ok {
+		config.Granularity = value.(string)
+	}
+
+	if err := config.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if err := m.setDestination(ctx, config); err != nil {
+		return nil, err
+	}
+	return secretsSyncDestinationResponse(config), nil
+}
+
+func (b *SystemBackend) handleSecretsSyncDestinationDelete(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	dest := m.destination(typ, d.Get("name").(string))
+	if dest == nil {
+		return nil, nil
+	}
+	m.l.RLock()
+	associated := len(dest.associations) > 0
+	m.l.RUnlock()
+	if associated {
+		return logical.ErrorResponse("destination still has associations, remove them first"), logical.ErrInvalidRequest
+	}
+
+	return nil, m.deleteDestination(ctx, typ, dest.config.Name)
+}
+
+func (b *SystemBackend) handleSecretsSyncAssociationsRead(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	dest := m.destination(typ, d.Get("name").(string))
+	if dest == nil {
+		return nil, nil
+	}
+	return b.secretsSyncAssociationsResponse(ctx, m, dest)
+}
+
+func (b *SystemBackend) handleSecretsSyncAssociationSet(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	name := d.Get("name").(string)
+	dest := m.destination(typ, name)
+	if dest == nil {
+		return logical.ErrorResponse("destination %q not found", secretsSyncDestinationKey(typ, name)), logical.ErrInvalidRequest
+	}
+
+	entry, err := b.secretsSyncKVMount(ctx, d.Get("mount").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	secretName := strings.Trim(d.Get("secret_name").(string), "/")
+	if secretName == "" {
+		return logical.ErrorResponse("secret_name is required"), logical.ErrInvalidRequest
+	}
+
+	assoc := &secretsSyncAssociation{
+		Accessor:   entry.Accessor,
+		SecretName: secretName,
+		Keys:       d.Get("keys").([]string),
+	}
+	if err := m.setAssociation(ctx, typ, name, assoc); err != nil {
+		return nil, err
+	}
+	return b.secretsSyncAssociationsResponse(ctx, m, dest)
+}
+
+func (b *SystemBackend) handleSecretsSyncAssociationRemove(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	typ, err := secretsSyncDestinationType(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	name := d.Get("name").(string)
+	dest := m.destination(typ, name)
+	if dest == nil {
+		return logical.ErrorResponse("destination %q not found", secretsSyncDestinationKey(typ, name)), logical.ErrInvalidRequest
+	}
+
+	entry, err := b.secretsSyncKVMount(ctx, d.Get("mount").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	secretName := strings.Trim(d.Get("secret_name").(string), "/")
+	if err := m.removeAssociation(ctx, typ, name, entry.Accessor, secretName); err != nil {
+		return nil, err
+	}
+	return b.secretsSyncAssociationsResponse(ctx, m, dest)
+}
+
+// handleSecretsSyncStatusRead counts the secrets of each destination by sync
+// status.
+func (b *SystemBackend) handleSecretsSyncStatusRead(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.secretsSync
+	if m == nil {
+		return nil, errSecretsSyncUnavailable
+	}
+
+	destinations := make(map[string]interface{})
+	for _, dest := range m.listDestinations() {
+		statuses, err := m.listStatuses(ctx, dest.config)
+		if err != nil {
+			return nil, err
+		}
+
+		counts := map[string]int{
+			secretsSyncStatusSynced:   0,
+			secretsSyncStatusUnsynced: 0,
+			secretsSyncStatusFailed:   0,
+			secretsSyncStatusDrifted:  0,
+		}
+		var failed []string
+		for _, status := range statuses {
+			counts[status.SyncStatus]++
+			if status.SyncStatus == secretsSyncStatusFailed || status.SyncStatus == secretsSyncStatusDrifted {
+				failed = append(failed, status.Accessor+"/"+status.SecretName)
+			}
+		}
+		sort.Strings(failed)
+
+		m.l.RLock()
+		associations := len(dest.associations)
+		m.l.RUnlock()
+
+		destinations[secretsSyncDestinationKey(dest.config.Type, dest.config.Name)] = map[string]interface{}{
+			"associations":      associations,
+			"secrets":           len(statuses),
+			"status_counts":     counts,
+			"unhealthy_secrets": failed,
+		}
+	}
+
+	m.l.RLock()
+	lastReconcile := m.lastReconcile
+	m.l.RUnlock()
+
+	data := map[string]interface{}{
+		"destinations":       destinations,
+		"reconcile_interval": int64(secretsSyncReconcileInterval.Seconds()),
+	}
+	if !lastReconcile.IsZero() {
+		data["last_reconcile"] = lastReconcile.Format(time.RFC3339Nano)
+	}
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+var secretsSyncHelp = map[string][2]string{
+	"sync-destinations-list": {
+		"Lists the destinations secrets are synced to.",
+		`
+		Lists the names of the destinations KV v2 secrets are synced to,
+		grouped by destination type.
+		`,
+	},
+	"sync-destination": {
+		"Configures a destination secrets are synced to.",
+		`
+		Configures an external secret store KV v2 secrets are synced to: AWS
+		Secrets Manager (aws-sm), Azure Key Vault (azure-kv), GCP Secret Manager
+		(gcp-sm) or the Actions secrets of a GitHub repository (gh). The names
+		of the external secrets are generated with secret_name_template, and
+		normalized for the store. Secrets are synced as a single JSON object,
+		or key by key with the secret-key granularity. Updating a destination
+		syncs its secrets again. Destinations can only be deleted once their
+		associations are removed.
+		`,
+	},
+	"sync-associations": {
+		"Reports the associations of a destination.",
+		`
+		Reports the associations of a destination, and the sync status of the
+		secrets they match: SYNCED, UNSYNCED when the secret has no data,
+		FAILED along with the error of the last sync, or DRIFTED when the
+		external secret was modified or deleted outside of Vault.
+		`,
+	},
+	"sync-associations-set": {
+		"Associates secrets to a destination.",
+		`
+		Associates a KV v2 secret, or the secrets whose paths match a glob
+		pattern, to a destination, and syncs them. Secrets are synced again
+		when they are written, patched, deleted or destroyed, and periodically
+		checked for drift. The keys synced can be filtered with glob patterns.
+		Setting an existing association syncs its secrets again.
+		`,
+	},
+	"sync-associations-remove": {
+		"Removes an association from a destination.",
+		`
+		Removes an association from a destination, and deletes the external
+		secrets it synced, unless another association of the destination
+		matches them.
+		`,
+	},
+	"sync-status": {
+		"Reports the sync status of the secrets of all the destinations.",
+		`
+		Counts the secrets of each destination by sync status and lists the
+		ones which failed to sync or drifted, along with when the secrets were
+		last reconciled and checked for drift.
+		`,
+	},
+}
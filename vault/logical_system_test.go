@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -1367,6 +1368,56 @@ func TestSystemBackend_leases_list(t *testing.T) {
 	if !reflect.DeepEqual(expected, keys) {
 		t.Fatalf("exp: %#v, act: %#v", expected, keys)
 	}
+
+	// Listing page by page
+	req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/secret/foo")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var all []string
+	if err := mapstructure.WeakDecode(resp.Data["keys"], &all); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sort.Strings(all)
+
+	var paged []string
+	var after string
+	for {
+		req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/secret/foo")
+		req.Data["after"] = after
+		req.Data["limit"] = 2
+		resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		keys = []string{}
+		if err := mapstructure.WeakDecode(resp.Data["keys"], &keys); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(keys) > 2 {
+			t.Fatalf("Expected at most 2 secret leases, got %d: %#v", len(keys), keys)
+		}
+		paged = append(paged, keys...)
+		nextAfter, ok := resp.Data[framework.ListNextAfterField].(string)
+		if !ok {
+			break
+		}
+		if nextAfter != keys[len(keys)-1] {
+			t.Fatalf("Expected the next page after %q, got %q", keys[len(keys)-1], nextAfter)
+		}
+		after = nextAfter
+	}
+	if !reflect.DeepEqual(all, paged) {
+		t.Fatalf("exp: %#v, act: %#v", all, paged)
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "leases/lookup/secret/foo")
+	req.Data["limit"] = -1
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v, resp: %#v", err, resp)
+	}
 }
 
 func TestSystemBackend_renew(t *testing.T) {
@@ -4992,6 +5043,75 @@ func TestHandlePoliciesPasswordGenerate(t *testing.T) {
 	})
 }
 
+func TestHandlePoliciesPasswordTest(t *testing.T) {
+	fieldData := func(raw map[string]interface{}) *framework.FieldData {
+		return &framework.FieldData{
+			Raw: raw,
+			Schema: map[string]*framework.FieldSchema{
+				"name":       {Type: framework.TypeString},
+				"count":      {Type: framework.TypeInt, Default: 5},
+				"candidates": {Type: framework.TypeStringSlice},
+			},
+		}
+	}
+
+	storage := makeStorage(t, storageEntry(t, "testpolicy",
+		"length = 8\n"+
+			"rule \"charset\" {\n"+
+			"	charset=\"abcdefghij\"\n"+
+			"}\n"+
+			"rule \"charset\" {\n"+
+			"	charset=\"0123456789\"\n"+
+			"	min-chars=2\n"+
+			"}"))
+
+	t.Run("errors", func(t *testing.T) {
+		tests := map[string]*framework.FieldData{
+			"missing policy name":   fieldData(map[string]interface{}{}),
+			"policy does not exist": fieldData(map[string]interface{}{"name": "nonexistent"}),
+			"negative count":        fieldData(map[string]interface{}{"name": "testpolicy", "count": -1}),
+			"count too large":       fieldData(map[string]interface{}{"name": "testpolicy", "count": maxPasswordPolicyTestSamples + 1}),
+		}
+
+		for name, data := range tests {
+			t.Run(name, func(t *testing.T) {
+				b := &SystemBackend{}
+				resp, err := b.handlePoliciesPasswordTest(context.Background(), &logical.Request{Storage: storage}, data)
+				require.Error(t, err)
+				require.Nil(t, resp)
+			})
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		b := &SystemBackend{}
+		data := fieldData(map[string]interface{}{
+			"name":       "testpolicy",
+			"candidates": []string{"abcdef12", "abcdefg1", "ABC"},
+		})
+		resp, err := b.handlePoliciesPasswordTest(context.Background(), &logical.Request{Storage: storage}, data)
+		require.NoError(t, err)
+
+		samples := resp.Data["samples"].([]string)
+		require.Len(t, samples, 5)
+		for _, sample := range samples {
+			require.Len(t, sample, 8)
+		}
+		require.Equal(t, 8, resp.Data["length"])
+		require.Equal(t, 20, resp.Data["charset_size"])
+		require.Equal(t, 34.58, resp.Data["entropy_bits"])
+
+		candidates := resp.Data["candidates"].([]interface{})
+		require.Len(t, candidates, 3)
+		require.Equal(t, true, candidates[0].(map[string]interface{})["valid"])
+		require.Empty(t, candidates[0].(map[string]interface{})["errors"])
+		require.Equal(t, false, candidates[1].(map[string]interface{})["valid"])
+		require.Len(t, candidates[1].(map[string]interface{})["errors"], 1)
+		require.Equal(t, false, candidates[2].(map[string]interface{})["valid"])
+		require.Len(t, candidates[2].(map[string]interface{})["errors"], 3)
+	})
+}
+
 func assertTrue(t *testing.T, pass bool, f string, vals ...interface{}) {
 	t.Helper()
 	if !pass {
@@ -6067,6 +6187,7 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 		CgroupParent: "/cpulimit/",
 		CPU:          1,
 		Memory:       10000,
+		Networks:     []string{"plugin-net"},
 	}
 
 	// Register the plugin runtime
@@ -6076,6 +6197,7 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 		"cgroup_parent": conf.CgroupParent,
 		"cpu_nanos":     conf.CPU,
 		"memory_bytes":  conf.Memory,
+		"networks":      conf.Networks,
 	}
 
 	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
@@ -6110,12 +6232,14 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 	)
 
 	readExp := map[string]any{
-		"type":          conf.Type.String(),
-		"name":          conf.Name,
-		"oci_runtime":   conf.OCIRuntime,
-		"cgroup_parent": conf.CgroupParent,
-		"cpu_nanos":     conf.CPU,
-		"memory_bytes":  conf.Memory,
+		"type":            conf.Type.String(),
+		"name":            conf.Name,
+		"oci_runtime":     conf.OCIRuntime,
+		"cgroup_parent":   conf.CgroupParent,
+		"cpu_nanos":       conf.CPU,
+		"memory_bytes":    conf.Memory,
+		"disable_network": conf.DisableNetwork,
+		"networks":        conf.Networks,
 	}
 	if !reflect.DeepEqual(resp.Data, readExp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, readExp)
@@ -6332,6 +6456,41 @@ func TestGetSealBackendStatus(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_pluginRuntime_NetworkPolicy(t *testing.T) {
+	b := testSystemBackend(t)
+
+	// Networks cannot be attached to containers without a network
+	req := logical.TestRequest(t, logical.UpdateOperation, "plugins/runtimes/catalog/container/foo")
+	req.Data = map[string]interface{}{
+		"disable_network": true,
+		"networks":        "plugin-net",
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	req.Data = map[string]interface{}{
+		"disable_network": true,
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "plugins/runtimes/catalog/container/foo")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["disable_network"] != true {
+		t.Fatalf("expected the network to be disabled, got: %#v", resp.Data)
+	}
+}
+
 func TestSystemBackend_pluginRuntime_CannotDeleteRuntimeWithReferencingPlugins(t *testing.T) {
 	if runtime.GOOS != "linux" {
 		t.Skip("Currently plugincontainer only supports linux")
@@ -6,10 +6,15 @@ package vault
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image/png"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -58,10 +63,17 @@ const (
 	// configs
 	loginMFAConfigPrefix      = "login-mfa/method/"
 	mfaLoginEnforcementPrefix = "login-mfa/enforcement/"
+
+	// maxTOTPRecoveryCodes caps the number of recovery codes generated along
+	// with a TOTP key
+	maxTOTPRecoveryCodes = 50
 )
 
 type totpKey struct {
 	Key string `json:"key"`
+
+	// RecoveryCodes holds the hashes of the unused one-time recovery codes
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 // loginMfaPaths returns the API endpoints to configure the new style
@@ -103,6 +115,39 @@ func (b *SystemBackend) loginMFAPaths() []*framework.Path {
 				},
 			},
 		},
+		{
+			Pattern: "mfa/challenge",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mfa",
+				OperationVerb:   "challenge",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mfa_request_id": {
+					Type:        framework.TypeString,
+					Description: "ID for this MFA request",
+					Required:    true,
+				},
+				"method_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the WebAuthn MFA method to issue a challenge for",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.Core.loginMFABackend.handleMFALoginChallenge,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+						}},
+					},
+					Summary:                   "Issues a WebAuthn challenge for a login which is pending MFA validation",
+					ForwardPerformanceStandby: true,
+				},
+			},
+		},
 	}
 }
 
@@ -128,6 +173,12 @@ type MFABackend struct {
 
 type LoginMFABackend struct {
 	*MFABackend
+
+	// webAuthnLock serializes updates of the stored WebAuthn credentials
+	webAuthnLock sync.Mutex
+
+	// totpKeyLock serializes updates of the TOTP recovery codes
+	totpKeyLock sync.Mutex
 }
 
 func loginMFASchemaFuncs() []func() *memdb.TableSchema {
@@ -139,7 +190,7 @@ func loginMFASchemaFuncs() []func() *memdb.TableSchema {
 
 func NewLoginMFABackend(core *Core, logger hclog.Logger) *LoginMFABackend {
 	b := NewMFABackend(core, logger, memDBLoginMFAConfigsTable, loginMFASchemaFuncs())
-	return &LoginMFABackend{b}
+	return &LoginMFABackend{MFABackend: b}
 }
 
 func NewMFABackend(core *Core, logger hclog.Logger, prefix string, schemaFuncs []func() *memdb.TableSchema) *MFABackend {
@@ -359,6 +410,12 @@ func (i *IdentityStore) handleMFAMethodWriteCommon(ctx context.Context, req *log
 			return logical.ErrorResponse(err.Error()), nil
 		}
 
+	case mfaMethodTypeWebAuthn:
+		err = parseWebAuthnConfig(mConfig, d)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unrecognized type %q", methodType)), nil
 	}
@@ -403,62 +460,77 @@ func (i *IdentityStore) handleLoginMFAAdminGenerateUpdate(ctx context.Context, r
 }
 
 func (i *IdentityStore) handleLoginMFAGenerateCommon(ctx context.Context, req *logical.Request, methodID, entityID string) (*logical.Response, error) {
+	mConfig, resp, err := i.loginMFAMethodForEntity(ctx, methodID, entityID)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	switch mConfig.Type {
+	case mfaMethodTypeTOTP:
+		return i.mfaBackend.handleMFAGenerateTOTP(ctx, mConfig, entityID)
+	case mfaMethodTypeWebAuthn:
+		return i.mfaBackend.handleMFAGenerateWebAuthn(ctx, mConfig, entityID)
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("generate not available for MFA type %q", mConfig.Type)), nil
+	}
+}
+
+// loginMFAMethodForEntity returns the configuration of the given method ID
+// after checking that it can be used by the entity in the request namespace.
+// A non-nil response is returned in place of the configuration on user
+// errors.
+func (i *IdentityStore) loginMFAMethodForEntity(ctx context.Context, methodID, entityID string) (*mfa.Config, *logical.Response, error) {
 	if methodID == "" {
-		return logical.ErrorResponse("missing method ID"), nil
+		return nil, logical.ErrorResponse("missing method ID"), nil
 	}
 
 	if entityID == "" {
-		return logical.ErrorResponse("missing entityID"), nil
+		return nil, logical.ErrorResponse("missing entityID"), nil
 	}
 
 	mConfig, err := i.mfaBackend.MemDBMFAConfigByID(methodID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if mConfig == nil {
-		return logical.ErrorResponse(fmt.Sprintf("configuration for method ID %q does not exist", methodID)), nil
+		return nil, logical.ErrorResponse(fmt.Sprintf("configuration for method ID %q does not exist", methodID)), nil
 	}
 	if mConfig.ID == "" {
-		return nil, fmt.Errorf("configuration for method ID %q does not contain an identifier", methodID)
+		return nil, nil, fmt.Errorf("configuration for method ID %q does not contain an identifier", methodID)
 	}
 
 	entity, err := i.MemDBEntityByID(entityID, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find entity with ID %q: error: %w", entityID, err)
+		return nil, nil, fmt.Errorf("failed to find entity with ID %q: error: %w", entityID, err)
 	}
 
 	if entity == nil {
-		return logical.ErrorResponse("invalid entity ID"), nil
+		return nil, logical.ErrorResponse("invalid entity ID"), nil
 	}
 
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
-		return logical.ErrorResponse("failed to retrieve the namespace"), nil
+		return nil, logical.ErrorResponse("failed to retrieve the namespace"), nil
 	}
 	if ns.ID != entity.NamespaceID {
-		return logical.ErrorResponse("entity namespace ID does not match the current namespace ID"), nil
+		return nil, logical.ErrorResponse("entity namespace ID does not match the current namespace ID"), nil
 	}
 
 	entityNS, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
 	if err != nil {
-		return logical.ErrorResponse("entity namespace not found"), nil
+		return nil, logical.ErrorResponse("entity namespace not found"), nil
 	}
 
 	configNS, err := i.namespacer.NamespaceByID(ctx, mConfig.NamespaceID)
 	if err != nil {
-		return logical.ErrorResponse("methodID namespace not found"), nil
+		return nil, logical.ErrorResponse("methodID namespace not found"), nil
 	}
 
 	if configNS.ID != entityNS.ID && !entityNS.HasParent(configNS) {
-		return logical.ErrorResponse(fmt.Sprintf("entity namespace %s outside of the config namespace %s", entityNS.Path, configNS.Path)), nil
+		return nil, logical.ErrorResponse(fmt.Sprintf("entity namespace %s outside of the config namespace %s", entityNS.Path, configNS.Path)), nil
 	}
 
-	switch mConfig.Type {
-	case mfaMethodTypeTOTP:
-		return i.mfaBackend.handleMFAGenerateTOTP(ctx, mConfig, entityID)
-	default:
-		return logical.ErrorResponse(fmt.Sprintf("generate not available for MFA type %q", mConfig.Type)), nil
-	}
+	return mConfig, nil, nil
 }
 
 func (i *IdentityStore) handleLoginMFAAdminDestroyUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -497,8 +569,8 @@ func (i *IdentityStore) handleLoginMFAAdminDestroyUpdate(ctx context.Context, re
 		return nil, fmt.Errorf("configuration for method ID %q does not contain an identifier", methodID)
 	}
 
-	if mConfig.Type != mfaMethodTypeTOTP {
-		return nil, fmt.Errorf("method ID does not match TOTP type")
+	if mConfig.Type != mfaMethodTypeTOTP && mConfig.Type != mfaMethodTypeWebAuthn {
+		return nil, fmt.Errorf("method ID does not match TOTP or WebAuthn type")
 	}
 
 	ns, err := namespace.FromContext(ctx)
@@ -523,6 +595,20 @@ func (i *IdentityStore) handleLoginMFAAdminDestroyUpdate(ctx context.Context, re
 		return logical.ErrorResponse(fmt.Sprintf("entity namespace %s outside of the current namespace %s", entityNS.Path, ns.Path)), nil
 	}
 
+	if mConfig.Type == mfaMethodTypeWebAuthn {
+		i.mfaBackend.webAuthnLock.Lock()
+		defer i.mfaBackend.webAuthnLock.Unlock()
+		if err := i.mfaBackend.Core.deleteWebAuthnCredentials(ctx, mConfig.ID, entity.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete WebAuthn credentials: %w", err)
+		}
+		return nil, nil
+	}
+
+	// lifting any lockout, so that the entity can start over with a new secret
+	if i.mfaBackend.usedCodes != nil {
+		i.mfaBackend.usedCodes.Delete(totpLockoutKey(mConfig.ID, entity.ID))
+	}
+
 	// destroying the secret on the entity
 	if entity.MFASecrets != nil {
 		delete(entity.MFASecrets, mConfig.ID)
@@ -536,6 +622,58 @@ func (i *IdentityStore) handleLoginMFAAdminDestroyUpdate(ctx context.Context, re
 	return nil, nil
 }
 
+// handleLoginMFATOTPRecoveryCodesUpdate replaces the recovery codes of the
+// TOTP secret of the requesting entity with a new set.
+func (i *IdentityStore) handleLoginMFATOTPRecoveryCodesUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	mConfig, resp, err := i.loginMFAMethodForEntity(ctx, d.Get("method_id").(string), req.EntityID)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	totpConfig := mConfig.GetTOTPConfig()
+	if totpConfig == nil {
+		return logical.ErrorResponse("method ID does not match TOTP type"), nil
+	}
+	if totpConfig.RecoveryCodeCount == 0 {
+		return logical.ErrorResponse(fmt.Sprintf("recovery codes are not enabled for MFA method %q", mConfig.ID)), nil
+	}
+
+	entity, err := i.MemDBEntityByID(req.EntityID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entity with ID %q: error: %w", req.EntityID, err)
+	}
+	if entity == nil || entity.MFASecrets[mConfig.ID] == nil {
+		return logical.ErrorResponse(fmt.Sprintf("entity does not have a secret for MFA method %q", mConfig.ID)), nil
+	}
+
+	b := i.mfaBackend
+	b.totpKeyLock.Lock()
+	defer b.totpKeyLock.Unlock()
+
+	ks, err := b.Core.fetchTOTPKeyEntry(ctx, mConfig.ID, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching TOTP key: %w", err)
+	}
+	if ks == nil {
+		return logical.ErrorResponse(fmt.Sprintf("entity does not have a secret for MFA method %q", mConfig.ID)), nil
+	}
+
+	codes, hashes, err := generateTOTPRecoveryCodes(b.Core.secureRandomReader, int(totpConfig.RecoveryCodeCount))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP recovery codes: %w", err)
+	}
+	ks.RecoveryCodes = hashes
+	if err := b.Core.persistTOTPKeyEntry(ctx, mConfig.ID, entity.ID, ks); err != nil {
+		return nil, fmt.Errorf("failed to persist TOTP recovery codes: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"recovery_codes": codes,
+		},
+	}, nil
+}
+
 // loadMFAMethodConfigs loads MFA method configs for login MFA
 func (b *LoginMFABackend) loadMFAMethodConfigs(ctx context.Context, ns *namespace.Namespace) error {
 	b.mfaLogger.Trace("loading login MFA configurations")
@@ -1043,38 +1181,108 @@ func (b *LoginMFABackend) validateAuthEntriesForAccessorOrType(ctx context.Conte
 }
 
 func (c *Core) PersistTOTPKey(ctx context.Context, methodID, entityID, key string) error {
-	ks := &totpKey{
+	return c.persistTOTPKeyEntry(ctx, methodID, entityID, &totpKey{
 		Key: key,
-	}
+	})
+}
+
+func (c *Core) persistTOTPKeyEntry(ctx context.Context, methodID, entityID string, ks *totpKey) error {
 	val, err := jsonutil.EncodeJSON(ks)
 	if err != nil {
 		return err
 	}
-	if c.barrier.Put(ctx, &logical.StorageEntry{
+	return c.barrier.Put(ctx, &logical.StorageEntry{
 		Key:   fmt.Sprintf("%s%s/%s", mfaTOTPKeysPrefix, methodID, entityID),
 		Value: val,
-	}); err != nil {
-		return err
-	}
-	return nil
+	})
 }
 
 func (c *Core) fetchTOTPKey(ctx context.Context, methodID, entityID string) (string, error) {
+	ks, err := c.fetchTOTPKeyEntry(ctx, methodID, entityID)
+	if err != nil || ks == nil {
+		return "", err
+	}
+
+	return ks.Key, nil
+}
+
+func (c *Core) fetchTOTPKeyEntry(ctx context.Context, methodID, entityID string) (*totpKey, error) {
 	entry, err := c.barrier.Get(ctx, fmt.Sprintf("%s%s/%s", mfaTOTPKeysPrefix, methodID, entityID))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if entry == nil {
-		return "", nil
+		return nil, nil
 	}
 
 	ks := &totpKey{}
 	err = jsonutil.DecodeJSON(entry.Value, ks)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return ks.Key, nil
+	return ks, nil
+}
+
+// generateTOTPRecoveryCodes returns count one-time recovery codes, along with
+// the hashes to be stored in their place.
+func generateTOTPRecoveryCodes(rand io.Reader, count int) ([]string, []string, error) {
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+	for n := 0; n < count; n++ {
+		buf := make([]byte, 7)
+		if _, err := io.ReadFull(rand, buf); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))
+		code := encoded[:5] + "-" + encoded[5:10]
+		codes = append(codes, code)
+		hashes = append(hashes, hashTOTPRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashTOTPRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// isTOTPRecoveryCode tells recovery codes apart from TOTP passcodes, which
+// only consist of digits.
+func isTOTPRecoveryCode(passcode string) bool {
+	return strings.IndexFunc(passcode, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) != -1
+}
+
+// redeemTOTPRecoveryCode consumes the given recovery code of the entity,
+// returning false if it does not match any of the unused codes.
+func (c *Core) redeemTOTPRecoveryCode(ctx context.Context, methodID, entityID, code string) (bool, error) {
+	c.loginMFABackend.totpKeyLock.Lock()
+	defer c.loginMFABackend.totpKeyLock.Unlock()
+
+	ks, err := c.fetchTOTPKeyEntry(ctx, methodID, entityID)
+	if err != nil {
+		return false, err
+	}
+	if ks == nil {
+		return false, nil
+	}
+
+	hash := hashTOTPRecoveryCode(code)
+	for idx, stored := range ks.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) != 1 {
+			continue
+		}
+		ks.RecoveryCodes = append(ks.RecoveryCodes[:idx], ks.RecoveryCodes[idx+1:]...)
+		if err := c.persistTOTPKeyEntry(ctx, methodID, entityID, ks); err != nil {
+			return false, fmt.Errorf("failed to persist TOTP recovery codes: %w", err)
+		}
+		return true, nil
+	}
+
+	return false, nil
 }
 
 func (b *MFABackend) handleMFAGenerateTOTP(ctx context.Context, mConfig *mfa.Config, entityID string) (*logical.Response, error) {
@@ -1146,7 +1354,18 @@ func (b *MFABackend) handleMFAGenerateTOTP(ctx context.Context, mConfig *mfa.Con
 		totpB64Barcode = base64.StdEncoding.EncodeToString(buff.Bytes())
 	}
 
-	if err := b.Core.PersistTOTPKey(ctx, mConfig.ID, entity.ID, keyObject.Secret()); err != nil {
+	ks := &totpKey{
+		Key: keyObject.Secret(),
+	}
+	var recoveryCodes []string
+	if totpConfig.RecoveryCodeCount > 0 {
+		recoveryCodes, ks.RecoveryCodes, err = generateTOTPRecoveryCodes(b.Core.secureRandomReader, int(totpConfig.RecoveryCodeCount))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate TOTP recovery codes: %w", err)
+		}
+	}
+
+	if err := b.Core.persistTOTPKeyEntry(ctx, mConfig.ID, entity.ID, ks); err != nil {
 		return nil, errwrap.Wrapf("failed to persist totp key: {{err}}", err)
 	}
 
@@ -1170,11 +1389,16 @@ func (b *MFABackend) handleMFAGenerateTOTP(ctx context.Context, mConfig *mfa.Con
 		return nil, errwrap.Wrapf("failed to persist MFA secret in entity: {{err}}", err)
 	}
 
+	respData := map[string]interface{}{
+		"url":     totpURL,
+		"barcode": totpB64Barcode,
+	}
+	if len(recoveryCodes) > 0 {
+		respData["recovery_codes"] = recoveryCodes
+	}
+
 	return &logical.Response{
-		Data: map[string]interface{}{
-			"url":     totpURL,
-			"barcode": totpB64Barcode,
-		},
+		Data: respData,
 	}, nil
 }
 
@@ -1432,6 +1656,10 @@ func (b *MFABackend) mfaConfigToMap(mConfig *mfa.Config) (map[string]interface{}
 		respData["qr_size"] = totpConfig.QRSize
 		respData["algorithm"] = otplib.Algorithm(totpConfig.Algorithm).String()
 		respData["max_validation_attempts"] = totpConfig.MaxValidationAttempts
+		respData["rate_limit_period"] = totpConfig.RateLimitPeriod
+		respData["lockout_threshold"] = totpConfig.LockoutThreshold
+		respData["lockout_duration"] = totpConfig.LockoutDuration
+		respData["recovery_code_count"] = totpConfig.RecoveryCodeCount
 	case *mfa.Config_OktaConfig:
 		oktaConfig := mConfig.GetOktaConfig()
 		respData["org_name"] = oktaConfig.OrgName
@@ -1456,6 +1684,14 @@ func (b *MFABackend) mfaConfigToMap(mConfig *mfa.Config) (map[string]interface{}
 		respData["org_alias"] = pingConfig.OrgAlias
 		respData["admin_url"] = pingConfig.AdminURL
 		respData["authenticator_url"] = pingConfig.AuthenticatorURL
+	case *mfa.Config_WebauthnConfig:
+		webAuthnConfig := mConfig.GetWebauthnConfig()
+		respData["rp_id"] = webAuthnConfig.RelyingPartyID
+		respData["rp_display_name"] = webAuthnConfig.RelyingPartyName
+		respData["rp_origins"] = append([]string{}, webAuthnConfig.Origins...)
+		respData["attestation_conveyance"] = webAuthnConfig.AttestationConveyance
+		respData["user_verification"] = webAuthnConfig.UserVerification
+		respData["allowed_aaguids"] = append([]string{}, webAuthnConfig.AllowedAaguids...)
 	default:
 		return nil, fmt.Errorf("invalid method type %q was persisted, underlying type: %T", mConfig.Type, mConfig.Config)
 	}
@@ -1539,6 +1775,28 @@ func parseTOTPConfig(mConfig *mfa.Config, d *framework.FieldData) error {
 		maxValidationAttempt = defaultMaxTOTPValidateAttempts
 	}
 
+	rateLimitPeriod := d.Get("rate_limit_period").(int)
+	if rateLimitPeriod < 0 {
+		return fmt.Errorf("rate_limit_period cannot be negative")
+	}
+
+	lockoutThreshold := d.Get("lockout_threshold").(int)
+	if lockoutThreshold < 0 {
+		return fmt.Errorf("lockout_threshold cannot be negative")
+	}
+	lockoutDuration := d.Get("lockout_duration").(int)
+	if lockoutDuration < 0 {
+		return fmt.Errorf("lockout_duration cannot be negative")
+	}
+	if lockoutThreshold > 0 && lockoutDuration == 0 {
+		return fmt.Errorf("lockout_duration must be greater than zero when lockout_threshold is set")
+	}
+
+	recoveryCodeCount := d.Get("recovery_code_count").(int)
+	if recoveryCodeCount < 0 || recoveryCodeCount > maxTOTPRecoveryCodes {
+		return fmt.Errorf("recovery_code_count must be between 0 and %d", maxTOTPRecoveryCodes)
+	}
+
 	config := &mfa.TOTPConfig{
 		Issuer:                issuer,
 		Period:                uint32(period),
@@ -1548,6 +1806,10 @@ func parseTOTPConfig(mConfig *mfa.Config, d *framework.FieldData) error {
 		KeySize:               uint32(keySize),
 		QRSize:                int32(d.Get("qr_size").(int)),
 		MaxValidationAttempts: uint32(maxValidationAttempt),
+		RateLimitPeriod:       int64(rateLimitPeriod),
+		LockoutThreshold:      uint32(lockoutThreshold),
+		LockoutDuration:       int64(lockoutDuration),
+		RecoveryCodeCount:     uint32(recoveryCodeCount),
 	}
 	mConfig.Config = &mfa.Config_TOTPConfig{
 		TOTPConfig: config,
@@ -1682,6 +1944,12 @@ func (c *Core) validateLoginMFAInternal(ctx context.Context, methodID string, en
 		}
 	}
 
+	// WebAuthn assertions are JSON documents rather than passcodes, so they
+	// are handed over as is.
+	if mConfig.Type == mfaMethodTypeWebAuthn {
+		return c.validateWebAuthn(ctx, mfaCreds, mConfig, entity)
+	}
+
 	mfaFactors, err := parseMfaFactors(mfaCreds)
 	if err != nil {
 		return fmt.Errorf("failed to parse MFA factor, %w", err)
@@ -1698,7 +1966,7 @@ func (c *Core) validateLoginMFAInternal(ctx context.Context, methodID string, en
 			return fmt.Errorf("MFA secret for method name %q not present in entity %q", mConfig.Name, entity.ID)
 		}
 
-		return c.validateTOTP(ctx, mfaFactors, entityMFASecret, mConfig.ID, entity.ID, c.loginMFABackend.usedCodes, mConfig.GetTOTPConfig().MaxValidationAttempts)
+		return c.validateTOTP(ctx, mfaFactors, entityMFASecret, mConfig.ID, entity.ID, c.loginMFABackend.usedCodes, mConfig.GetTOTPConfig())
 
 	case mfaMethodTypeOkta:
 		return c.validateOkta(ctx, mConfig, finalUsername)
@@ -2317,7 +2585,7 @@ func (c *Core) validatePingID(ctx context.Context, mConfig *mfa.Config, username
 	return nil
 }
 
-func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMethodSecret *mfa.Secret, configID, entityID string, usedCodes *cache.Cache, maximumValidationAttempts uint32) error {
+func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMethodSecret *mfa.Secret, configID, entityID string, usedCodes *cache.Cache, totpConfig *mfa.TOTPConfig) error {
 	if mfaFactors == nil || mfaFactors.passcode == "" {
 		return fmt.Errorf("MFA credentials not supplied")
 	}
@@ -2328,6 +2596,21 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 		return fmt.Errorf("entity does not contain the TOTP secret")
 	}
 
+	// Enforcing the lockout after consecutive failed validations, which
+	// outlives the rate limit below
+	lockoutID := totpLockoutKey(configID, entityID)
+	if totpConfig.LockoutThreshold > 0 {
+		if numFailures, ok := usedCodes.Get(lockoutID); ok {
+			num, ok := numFailures.(uint32)
+			if !ok {
+				return fmt.Errorf("invalid counter type returned in TOTP usedCode cache")
+			}
+			if num >= totpConfig.LockoutThreshold {
+				return fmt.Errorf("TOTP validation is locked out after %d consecutive failed attempts", num)
+			}
+		}
+	}
+
 	usedName := fmt.Sprintf("%s_%s", configID, passcode)
 
 	_, ok := usedCodes.Get(usedName)
@@ -2338,6 +2621,9 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 	// The duration in which a passcode is stored in cache to enforce
 	// rate limit on failed totp passcode validation
 	passcodeTTL := time.Duration(int64(time.Second) * int64(totpSecret.Period))
+	if totpConfig.RateLimitPeriod > 0 {
+		passcodeTTL = time.Duration(totpConfig.RateLimitPeriod) * time.Second
+	}
 
 	// Enforcing rate limit per MethodID per EntityID
 	rateLimitID := fmt.Sprintf("%s_%s", configID, entityID)
@@ -2350,8 +2636,8 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 		if !ok {
 			return fmt.Errorf("invalid counter type returned in TOTP usedCode cache")
 		}
-		if num == maximumValidationAttempts {
-			return fmt.Errorf("maximum TOTP validation attempts %d exceeded the allowed attempts %d. Please try again in %v seconds", num+1, maximumValidationAttempts, passcodeTTL)
+		if num == totpConfig.MaxValidationAttempts {
+			return fmt.Errorf("maximum TOTP validation attempts %d exceeded the allowed attempts %d. Please try again in %v seconds", num+1, totpConfig.MaxValidationAttempts, passcodeTTL)
 		}
 		err := usedCodes.Increment(rateLimitID, 1)
 		if err != nil {
@@ -2359,6 +2645,33 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 		}
 	}
 
+	// validationFailed counts towards the lockout of the entity
+	validationFailed := func(err error) error {
+		if totpConfig.LockoutThreshold == 0 {
+			return err
+		}
+		var num uint32
+		if numFailures, ok := usedCodes.Get(lockoutID); ok {
+			num, _ = numFailures.(uint32)
+		}
+		usedCodes.Set(lockoutID, num+1, time.Duration(totpConfig.LockoutDuration)*time.Second)
+		return err
+	}
+
+	if isTOTPRecoveryCode(passcode) {
+		redeemed, err := c.redeemTOTPRecoveryCode(ctx, configID, entityID, passcode)
+		if err != nil {
+			return fmt.Errorf("failed to validate TOTP recovery code: %w", err)
+		}
+		if !redeemed {
+			return validationFailed(fmt.Errorf("failed to validate TOTP recovery code"))
+		}
+
+		usedCodes.Delete(rateLimitID)
+		usedCodes.Delete(lockoutID)
+		return nil
+	}
+
 	key, err := c.fetchTOTPKey(ctx, configID, entityID)
 	if err != nil {
 		return errwrap.Wrapf("error fetching TOTP key: {{err}}", err)
@@ -2381,7 +2694,7 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 	}
 
 	if !valid {
-		return fmt.Errorf("failed to validate TOTP passcode")
+		return validationFailed(fmt.Errorf("failed to validate TOTP passcode"))
 	}
 
 	// Take the key skew, add two for behind and in front, and multiply that by
@@ -2394,12 +2707,19 @@ func (c *Core) validateTOTP(ctx context.Context, mfaFactors *MFAFactor, entityMe
 		return fmt.Errorf("error adding code to used cache: %w", err)
 	}
 
-	// deleting the cache entry after a successful MFA validation
+	// deleting the cache entries after a successful MFA validation
 	usedCodes.Delete(rateLimitID)
+	usedCodes.Delete(lockoutID)
 
 	return nil
 }
 
+// totpLockoutKey is the key under which the consecutive failed TOTP
+// validations of an entity are counted in the used codes cache.
+func totpLockoutKey(configID, entityID string) string {
+	return fmt.Sprintf("%s_%s_lockout", configID, entityID)
+}
+
 func loginMFAConfigTableSchema() *memdb.TableSchema {
 	return &memdb.TableSchema{
 		Name: memDBLoginMFAConfigsTable,
@@ -2805,6 +3125,12 @@ func (b *LoginMFABackend) deleteMFAConfigByMethodID(ctx context.Context, configI
 		}
 	}
 
+	if mConfig.Type == mfaMethodTypeWebAuthn && mConfig.ID != "" {
+		if err := logical.ClearView(ctx, NewBarrierView(b.Core.barrier, fmt.Sprintf("%s%s/", mfaWebAuthnCredentialsPrefix, mConfig.ID))); err != nil {
+			b.mfaLogger.Warn("unable to clear WebAuthn credentials", "method", mConfig.Name, "error", err)
+		}
+	}
+
 	// Delete the config from MemDB
 	err = b.MemDBDeleteMFAConfigByIDInTxn(txn, configID)
 	if err != nil {
@@ -0,0 +1,577 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/identity/mfa"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	mfaMethodTypeWebAuthn = "webauthn"
+
+	mfaWebAuthnCredentialsPrefix = systemBarrierPrefix + "mfa/webauthncreds/"
+
+	// webAuthnCeremonyTimeout bounds how long a registration or login
+	// ceremony, started by generating the options for the client, can take.
+	webAuthnCeremonyTimeout = 5 * time.Minute
+)
+
+// webAuthnCredentials holds the credentials registered by an entity for a
+// WebAuthn MFA method. They are kept in the barrier, next to the TOTP keys,
+// rather than in the entity, as their signature counters change with every
+// successful validation.
+type webAuthnCredentials struct {
+	Credentials []*webAuthnCredential `json:"credentials"`
+}
+
+type webAuthnCredential struct {
+	Name         string              `json:"name"`
+	CreationTime time.Time           `json:"creation_time"`
+	Credential   webauthn.Credential `json:"credential"`
+}
+
+// webAuthnUser adapts an entity to the user expected by the webauthn library.
+type webAuthnUser struct {
+	entity      *identity.Entity
+	credentials []webauthn.Credential
+}
+
+func newWebAuthnUser(entity *identity.Entity, creds *webAuthnCredentials) *webAuthnUser {
+	user := &webAuthnUser{
+		entity: entity,
+	}
+	if creds != nil {
+		for _, cred := range creds.Credentials {
+			user.credentials = append(user.credentials, cred.Credential)
+		}
+	}
+	return user
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.entity.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.entity.Name
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.entity.Name
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) credentialDescriptors() []protocol.CredentialDescriptor {
+	descriptors := make([]protocol.CredentialDescriptor, 0, len(u.credentials))
+	for _, cred := range u.credentials {
+		descriptors = append(descriptors, cred.Descriptor())
+	}
+	return descriptors
+}
+
+func parseWebAuthnConfig(mConfig *mfa.Config, d *framework.FieldData) error {
+	if mConfig == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	if d == nil {
+		return fmt.Errorf("field data is nil")
+	}
+
+	rpID := d.Get("rp_id").(string)
+	if rpID == "" {
+		return fmt.Errorf("rp_id must be set")
+	}
+
+	rpOrigins := d.Get("rp_origins").([]string)
+	if len(rpOrigins) == 0 {
+		return fmt.Errorf("rp_origins must be set")
+	}
+
+	conveyance := d.Get("attestation_conveyance").(string)
+	switch protocol.ConveyancePreference(conveyance) {
+	case protocol.PreferNoAttestation, protocol.PreferIndirectAttestation, protocol.PreferDirectAttestation, protocol.PreferEnterpriseAttestation:
+	default:
+		return fmt.Errorf("attestation_conveyance must be one of none, indirect, direct or enterprise")
+	}
+
+	userVerification := d.Get("user_verification").(string)
+	switch protocol.UserVerificationRequirement(userVerification) {
+	case protocol.VerificationRequired, protocol.VerificationPreferred, protocol.VerificationDiscouraged:
+	default:
+		return fmt.Errorf("user_verification must be one of required, preferred or discouraged")
+	}
+
+	allowedAAGUIDs := d.Get("allowed_aaguids").([]string)
+	for i, aaguid := range allowedAAGUIDs {
+		if _, err := uuid.ParseUUID(aaguid); err != nil {
+			return fmt.Errorf("invalid AAGUID %q in allowed_aaguids", aaguid)
+		}
+		allowedAAGUIDs[i] = strings.ToLower(aaguid)
+	}
+	if len(allowedAAGUIDs) > 0 && conveyance != string(protocol.PreferDirectAttestation) && conveyance != string(protocol.PreferEnterpriseAttestation) {
+		return fmt.Errorf("allowed_aaguids requires attestation_conveyance to be direct or enterprise")
+	}
+
+	config := &mfa.WebAuthnConfig{
+		RelyingPartyID:        rpID,
+		RelyingPartyName:      d.Get("rp_display_name").(string),
+		Origins:               rpOrigins,
+		AttestationConveyance: conveyance,
+		UserVerification:      userVerification,
+		AllowedAaguids:        allowedAAGUIDs,
+	}
+	if config.RelyingPartyName == "" {
+		config.RelyingPartyName = config.RelyingPartyID
+	}
+
+	if _, err := newWebAuthn(config); err != nil {
+		return err
+	}
+
+	mConfig.Config = &mfa.Config_WebauthnConfig{
+		WebauthnConfig: config,
+	}
+
+	return nil
+}
+
+func newWebAuthn(config *mfa.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:                  config.RelyingPartyID,
+		RPDisplayName:         config.RelyingPartyName,
+		RPOrigins:             config.Origins,
+		AttestationPreference: protocol.ConveyancePreference(config.AttestationConveyance),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.UserVerificationRequirement(config.UserVerification),
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    webAuthnCeremonyTimeout,
+				TimeoutUVD: webAuthnCeremonyTimeout,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    webAuthnCeremonyTimeout,
+				TimeoutUVD: webAuthnCeremonyTimeout,
+			},
+		},
+	})
+}
+
+func webAuthnSessionKey(ceremony, methodID, entityID string) string {
+	return fmt.Sprintf("webauthn_%s_%s_%s", ceremony, methodID, entityID)
+}
+
+// takeWebAuthnSession returns the pending session of a ceremony and removes
+// it, so that every challenge can be answered at most once.
+func (b *MFABackend) takeWebAuthnSession(ceremony, methodID, entityID string) *webauthn.SessionData {
+	if b.usedCodes == nil {
+		return nil
+	}
+	key := webAuthnSessionKey(ceremony, methodID, entityID)
+	raw, ok := b.usedCodes.Get(key)
+	if !ok {
+		return nil
+	}
+	b.usedCodes.Delete(key)
+	session, ok := raw.(*webauthn.SessionData)
+	if !ok {
+		return nil
+	}
+	return session
+}
+
+func (b *MFABackend) putWebAuthnSession(ceremony, methodID, entityID string, session *webauthn.SessionData) error {
+	if b.usedCodes == nil {
+		return fmt.Errorf("login MFA is not set up")
+	}
+	b.usedCodes.Set(webAuthnSessionKey(ceremony, methodID, entityID), session, webAuthnCeremonyTimeout)
+	return nil
+}
+
+func (c *Core) persistWebAuthnCredentials(ctx context.Context, methodID, entityID string, creds *webAuthnCredentials) error {
+	val, err := jsonutil.EncodeJSON(creds)
+	if err != nil {
+		return err
+	}
+	return c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   fmt.Sprintf("%s%s/%s", mfaWebAuthnCredentialsPrefix, methodID, entityID),
+		Value: val,
+	})
+}
+
+func (c *Core) fetchWebAuthnCredentials(ctx context.Context, methodID, entityID string) (*webAuthnCredentials, error) {
+	entry, err := c.barrier.Get(ctx, fmt.Sprintf("%s%s/%s", mfaWebAuthnCredentialsPrefix, methodID, entityID))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	creds := &webAuthnCredentials{}
+	if err := jsonutil.DecodeJSON(entry.Value, creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (c *Core) deleteWebAuthnCredentials(ctx context.Context, methodID, entityID string) error {
+	return c.barrier.Delete(ctx, fmt.Sprintf("%s%s/%s", mfaWebAuthnCredentialsPrefix, methodID, entityID))
+}
+
+// webAuthnOptionsToMap converts the options handed to the browser into a
+// response data map, keeping the JSON layout expected by the WebAuthn API.
+func webAuthnOptionsToMap(options interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	var ret map[string]interface{}
+	if err := jsonutil.DecodeJSON(raw, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// handleMFAGenerateWebAuthn starts the registration of a new WebAuthn
+// credential for the entity, returning the options to pass to
+// navigator.credentials.create().
+func (b *MFABackend) handleMFAGenerateWebAuthn(ctx context.Context, mConfig *mfa.Config, entityID string) (*logical.Response, error) {
+	config := mConfig.GetWebauthnConfig()
+	if config == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown MFA config type %q", mConfig.Type)), nil
+	}
+
+	if b.Core.identityStore == nil {
+		return nil, fmt.Errorf("identity store not set up, cannot service webauthn mfa requests")
+	}
+
+	entity, err := b.Core.identityStore.MemDBEntityByID(entityID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entity with ID %q: %w", entityID, err)
+	}
+	if entity == nil {
+		return logical.ErrorResponse("invalid entity ID"), nil
+	}
+
+	wa, err := newWebAuthn(config)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := b.Core.fetchWebAuthnCredentials(ctx, mConfig.ID, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WebAuthn credentials: %w", err)
+	}
+	user := newWebAuthnUser(entity, creds)
+
+	// Excluding the registered credentials keeps an authenticator from being
+	// registered twice for the same method.
+	creation, session, err := wa.BeginRegistration(user, webauthn.WithExclusions(user.credentialDescriptors()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin WebAuthn registration: %w", err)
+	}
+	if err := b.putWebAuthnSession("registration", mConfig.ID, entity.ID, session); err != nil {
+		return nil, err
+	}
+
+	options, err := webAuthnOptionsToMap(creation)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"options": options,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) handleLoginMFAWebAuthnRegisterUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	methodID := d.Get("method_id").(string)
+	mConfig, resp, err := i.loginMFAMethodForEntity(ctx, methodID, req.EntityID)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	config := mConfig.GetWebauthnConfig()
+	if config == nil {
+		return logical.ErrorResponse("method ID does not match WebAuthn type"), nil
+	}
+
+	credential := d.Get("credential").(string)
+	if credential == "" {
+		return logical.ErrorResponse("missing credential"), nil
+	}
+
+	b := i.mfaBackend
+	session := b.takeWebAuthnSession("registration", mConfig.ID, req.EntityID)
+	if session == nil {
+		return logical.ErrorResponse("no pending WebAuthn registration; generate registration options first"), nil
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(strings.NewReader(credential))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid credential: %s", webAuthnErrorDetails(err))), nil
+	}
+
+	entity, err := i.MemDBEntityByID(req.EntityID, false)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse("invalid entity ID"), nil
+	}
+
+	wa, err := newWebAuthn(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b.webAuthnLock.Lock()
+	defer b.webAuthnLock.Unlock()
+
+	creds, err := b.Core.fetchWebAuthnCredentials(ctx, mConfig.ID, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WebAuthn credentials: %w", err)
+	}
+	if creds == nil {
+		creds = &webAuthnCredentials{}
+	}
+
+	cred, err := wa.CreateCredential(newWebAuthnUser(entity, creds), *session, parsed)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to verify credential: %s", webAuthnErrorDetails(err))), nil
+	}
+
+	if len(config.AllowedAaguids) > 0 {
+		if cred.AttestationType == "none" {
+			return logical.ErrorResponse("the authenticator did not provide an attestation"), nil
+		}
+		aaguid, err := uuid.FormatUUID(cred.Authenticator.AAGUID)
+		if err != nil || !strutil.StrListContains(config.AllowedAaguids, aaguid) {
+			return logical.ErrorResponse("the authenticator model is not allowed by this MFA method"), nil
+		}
+	}
+
+	for _, existing := range creds.Credentials {
+		if bytes.Equal(existing.Credential.ID, cred.ID) {
+			return logical.ErrorResponse("credential is already registered"), nil
+		}
+	}
+
+	name := d.Get("name").(string)
+	if name == "" {
+		name = fmt.Sprintf("credential-%d", len(creds.Credentials)+1)
+	}
+	creds.Credentials = append(creds.Credentials, &webAuthnCredential{
+		Name:         name,
+		CreationTime: time.Now().UTC(),
+		Credential:   *cred,
+	})
+
+	if err := b.Core.persistWebAuthnCredentials(ctx, mConfig.ID, entity.ID, creds); err != nil {
+		return nil, fmt.Errorf("failed to persist WebAuthn credential: %w", err)
+	}
+
+	return nil, nil
+}
+
+// handleMFALoginChallenge issues the WebAuthn assertion options for a login
+// which is pending MFA validation.
+func (b *LoginMFABackend) handleMFALoginChallenge(ctx context.Context, req *logical.Request, d *framework.FieldData) (retResp *logical.Response, retErr error) {
+	mfaReqID := d.Get("mfa_request_id").(string)
+	if mfaReqID == "" {
+		return logical.ErrorResponse("missing request ID"), nil
+	}
+
+	methodID := d.Get("method_id").(string)
+	if methodID == "" {
+		return logical.ErrorResponse("missing method ID"), nil
+	}
+
+	// The cached response is only borrowed here, it still has to be
+	// validated through mfa/validate afterwards.
+	cachedResponseAuth, err := b.Core.PopMFAResponseAuthByID(mfaReqID)
+	if err != nil || cachedResponseAuth == nil {
+		return logical.ErrorResponse("invalid request ID"), nil
+	}
+	defer func() {
+		if pushErr := b.Core.SaveMFAResponseAuth(cachedResponseAuth); pushErr != nil {
+			retErr = multierror.Append(retErr, pushErr)
+		}
+	}()
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != cachedResponseAuth.RequestNSID {
+		return nil, fmt.Errorf("original request was issued in a different namespace %v, current namespace is %v", cachedResponseAuth.RequestNSPath, ns.Path)
+	}
+
+	entity, _, err := b.Core.fetchEntityAndDerivedPolicies(ctx, ns, cachedResponseAuth.CachedAuth.EntityID, true)
+	if err != nil || entity == nil {
+		return nil, fmt.Errorf("entity not found: %v", err)
+	}
+
+	matchedMfaEnforcementList, err := b.Core.buildMFAEnforcementConfigList(ctx, entity, cachedResponseAuth.RequestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find MFAEnforcement configuration")
+	}
+	var enforced bool
+	for _, eConfig := range matchedMfaEnforcementList {
+		if strutil.StrListContains(eConfig.MFAMethodIDs, methodID) {
+			enforced = true
+			break
+		}
+	}
+	if !enforced {
+		return logical.ErrorResponse("method ID is not required by the login request"), nil
+	}
+
+	mConfig, err := b.MemDBMFAConfigByID(methodID)
+	if err != nil {
+		return nil, err
+	}
+	if mConfig == nil || mConfig.GetWebauthnConfig() == nil {
+		return logical.ErrorResponse("method ID does not match WebAuthn type"), nil
+	}
+	config := mConfig.GetWebauthnConfig()
+
+	creds, err := b.Core.fetchWebAuthnCredentials(ctx, mConfig.ID, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch WebAuthn credentials: %w", err)
+	}
+	if creds == nil || len(creds.Credentials) == 0 {
+		return logical.ErrorResponse(fmt.Sprintf("no WebAuthn credentials registered for method %q", mConfig.Name)), nil
+	}
+
+	wa, err := newWebAuthn(config)
+	if err != nil {
+		return nil, err
+	}
+	assertion, session, err := wa.BeginLogin(newWebAuthnUser(entity, creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin WebAuthn login: %w", err)
+	}
+	if err := b.putWebAuthnSession("login", mConfig.ID, entity.ID, session); err != nil {
+		return nil, err
+	}
+
+	options, err := webAuthnOptionsToMap(assertion)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"options": options,
+		},
+	}, nil
+}
+
+func (c *Core) validateWebAuthn(ctx context.Context, mfaCreds []string, mConfig *mfa.Config, entity *identity.Entity) error {
+	config := mConfig.GetWebauthnConfig()
+	if config == nil {
+		return fmt.Errorf("failed to get WebAuthn configuration for method %q", mConfig.Name)
+	}
+
+	var assertion string
+	for _, cred := range mfaCreds {
+		if cred == "" {
+			continue
+		}
+		if assertion != "" {
+			return fmt.Errorf("found multiple WebAuthn assertions for the same MFA method")
+		}
+		assertion = cred
+	}
+	if assertion == "" {
+		return fmt.Errorf("MFA credentials not supplied")
+	}
+
+	b := c.loginMFABackend
+	session := b.takeWebAuthnSession("login", mConfig.ID, entity.ID)
+	if session == nil {
+		return fmt.Errorf("no pending WebAuthn challenge for method %q", mConfig.Name)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(strings.NewReader(assertion))
+	if err != nil {
+		return fmt.Errorf("invalid WebAuthn assertion: %s", webAuthnErrorDetails(err))
+	}
+
+	wa, err := newWebAuthn(config)
+	if err != nil {
+		return err
+	}
+
+	b.webAuthnLock.Lock()
+	defer b.webAuthnLock.Unlock()
+
+	creds, err := c.fetchWebAuthnCredentials(ctx, mConfig.ID, entity.ID)
+	if err != nil {
+		return fmt.Errorf("error fetching WebAuthn credentials: %w", err)
+	}
+	if creds == nil || len(creds.Credentials) == 0 {
+		return fmt.Errorf("no WebAuthn credentials registered for method %q", mConfig.Name)
+	}
+
+	cred, err := wa.ValidateLogin(newWebAuthnUser(entity, creds), *session, parsed)
+	if err != nil {
+		return fmt.Errorf("failed to validate WebAuthn assertion: %s", webAuthnErrorDetails(err))
+	}
+
+	// A signature counter which did not increase indicates that the
+	// credential's private key may have been cloned.
+	if cred.Authenticator.CloneWarning {
+		return fmt.Errorf("WebAuthn signature counter did not increase, the authenticator may have been cloned")
+	}
+
+	for _, existing := range creds.Credentials {
+		if bytes.Equal(existing.Credential.ID, cred.ID) {
+			existing.Credential = *cred
+		}
+	}
+	if err := c.persistWebAuthnCredentials(ctx, mConfig.ID, entity.ID, creds); err != nil {
+		return fmt.Errorf("error updating WebAuthn credential: %w", err)
+	}
+
+	return nil
+}
+
+// webAuthnErrorDetails includes the debug information of protocol errors,
+// which carries the reason a verification failed.
+func webAuthnErrorDetails(err error) string {
+	if perr, ok := err.(*protocol.Error); ok && perr.DevInfo != "" {
+		return fmt.Sprintf("%s: %s", perr.Error(), perr.DevInfo)
+	}
+	return err.Error()
+}
@@ -109,6 +109,9 @@ var (
 		systemMountType,
 		"token",
 		identityMountType,
+		nsSystemMountType,
+		mountTypeNSCubbyhole,
+		nsTokenMountType,
 	}
 
 	// mountAliases maps old backend names to new backend names, allowing us
@@ -693,7 +696,7 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry, updateStora
 	// Check for the correct backend type
 	backendType := backend.Type()
 	if backendType != logical.TypeLogical {
-		if entry.Type != "kv" && entry.Type != "system" && entry.Type != "cubbyhole" {
+		if entry.Type != "kv" && entry.Type != "system" && entry.Type != "cubbyhole" && entry.Type != nsSystemMountType && entry.Type != mountTypeNSCubbyhole {
 			return fmt.Errorf(`unknown backend type: "%s"`, entry.Type)
 		}
 	}
@@ -1564,7 +1567,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			backendType := backend.Type()
 
 			if backendType != logical.TypeLogical {
-				if entry.Type != "kv" && entry.Type != "system" && entry.Type != "cubbyhole" {
+				if entry.Type != "kv" && entry.Type != "system" && entry.Type != "cubbyhole" && entry.Type != nsSystemMountType && entry.Type != mountTypeNSCubbyhole {
 					return fmt.Errorf(`unknown backend type: "%s"`, entry.Type)
 				}
 			}
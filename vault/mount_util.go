@@ -7,7 +7,9 @@ package vault
 
 import (
 	"context"
+	"fmt"
 	"path"
+	"strings"
 
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -32,26 +34,41 @@ func runFilteredPathsEvaluation(context.Context, *Core, bool) error           {
 
 // ViewPath returns storage prefix for the view
 func (e *MountEntry) ViewPath() string {
+	// The mounts of the namespaces other than root are kept under the storage
+	// of their namespace
+	var prefix string
+	if e.NamespaceID != "" && e.NamespaceID != namespace.RootNamespaceID {
+		prefix = namespaceBarrierPrefix + e.NamespaceID + "/"
+	}
+
 	switch e.Type {
-	case systemMountType:
-		return systemBarrierPrefix
-	case "token":
-		return path.Join(systemBarrierPrefix, tokenSubPath) + "/"
+	case systemMountType, nsSystemMountType:
+		return prefix + systemBarrierPrefix
+	case "token", nsTokenMountType:
+		return prefix + path.Join(systemBarrierPrefix, tokenSubPath) + "/"
 	}
 
 	switch e.Table {
 	case mountTableType:
-		return backendBarrierPrefix + e.UUID + "/"
+		return prefix + backendBarrierPrefix + e.UUID + "/"
 	case credentialTableType:
-		return credentialBarrierPrefix + e.UUID + "/"
+		return prefix + credentialBarrierPrefix + e.UUID + "/"
 	case auditTableType:
-		return auditBarrierPrefix + e.UUID + "/"
+		return prefix + auditBarrierPrefix + e.UUID + "/"
 	}
 
 	panic("invalid mount entry")
 }
 
-func verifyNamespace(*Core, *namespace.Namespace, *MountEntry) error { return nil }
+// verifyNamespace ensures that a mount in the namespace doesn't shadow one of
+// its child namespaces.
+func verifyNamespace(c *Core, ns *namespace.Namespace, entry *MountEntry) error {
+	name, _, _ := strings.Cut(entry.Path, "/")
+	if child := c.NamespaceByPath(ns.Path + name); child != nil && child.ID != ns.ID {
+		return logical.CodedError(409, fmt.Sprintf("path conflicts with namespace %q", child.Path))
+	}
+	return nil
+}
 
 // mountEntrySysView creates a logical.SystemView from global and
 // mount-specific entries; because this should be called when setting
@@ -0,0 +1,469 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/base62"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/versions"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// namespaceStoreSubPath is the path of the namespace entries, keyed by ID
+	namespaceStoreSubPath = "core/namespaces/"
+
+	// namespaceBarrierPrefix is the prefix of the storage of the namespaces
+	// other than root. The mounts of a namespace, and its policies, tokens
+	// and leases, live under namespaces/<id>/.
+	namespaceBarrierPrefix = "namespaces/"
+
+	// namespaceIDLength is the length of the random IDs of namespaces, which
+	// suffix the tokens and leases created in them
+	namespaceIDLength = 5
+
+	// nsSystemMountType and nsTokenMountType are the types of the sys/ and
+	// auth/token/ mounts of the namespaces other than root
+	nsSystemMountType = "ns_system"
+	nsTokenMountType  = "ns_token"
+)
+
+var (
+	// reservedNamespaceNames can't be used as the name of a namespace, since
+	// they would shadow the built-in paths of its parent
+	reservedNamespaceNames = []string{
+		namespace.RootNamespaceID,
+		"sys",
+		"audit",
+		"auth",
+		cubbyholeMountType,
+		identityMountType,
+	}
+
+	validNamespaceName = regexp.MustCompile(`^[\w-]+$`)
+
+	// namespaceSystemPathPrefixes are the prefixes of the patterns of the
+	// system backend which are served in namespaces other than root. The
+	// others manage the cluster as a whole, and are only served in the root
+	// namespace.
+	namespaceSystemPathPrefixes = []string{
+		"auth",
+		"capabilities",
+		"internal/specs/openapi",
+		"internal/ui/",
+		"leases",
+		"mounts",
+		"namespaces",
+		"policies/acl",
+		"policy",
+		"remount",
+		"renew",
+		"revoke",
+		"tools/",
+		"wrapping/",
+	}
+)
+
+// namespaceTokenStore serves the token auth mount of a namespace using the
+// token store of the root namespace, which the mount doesn't own.
+type namespaceTokenStore struct {
+	*TokenStore
+}
+
+func (namespaceTokenStore) Initialize(context.Context, *logical.InitializationRequest) error {
+	return nil
+}
+
+func (namespaceTokenStore) Cleanup(context.Context) {}
+
+// namespaceSystemView returns the view of the sys/ storage of the namespace,
+// under which its policies, tokens and leases are kept.
+func (c *Core) namespaceSystemView(ns *namespace.Namespace) *BarrierView {
+	if ns.ID == namespace.RootNamespaceID {
+		return c.systemBarrierView
+	}
+	return NewBarrierView(c.barrier, namespaceBarrierPrefix+ns.ID+"/"+systemBarrierPrefix)
+}
+
+// loadNamespaces loads the namespaces from storage. It must run before the
+// mount tables are loaded, since their entries refer to namespaces by ID.
+func (c *Core) loadNamespaces(ctx context.Context) error {
+	view := NewBarrierView(c.barrier, namespaceStoreSubPath)
+	ids, err := view.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	namespaces := make(map[string]*namespace.Namespace, len(ids))
+	for _, id := range ids {
+		entry, err := view.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to read namespace %q: %w", id, err)
+		}
+		if entry == nil {
+			continue
+		}
+
+		ns := new(namespace.Namespace)
+		if err := entry.DecodeJSON(ns); err != nil {
+			return fmt.Errorf("failed to decode namespace %q: %w", id, err)
+		}
+		if ns.CustomMetadata == nil {
+			ns.CustomMetadata = make(map[string]string)
+		}
+		namespaces[ns.ID] = ns
+	}
+
+	c.namespacesLock.Lock()
+	c.namespaces = namespaces
+	c.namespacesLock.Unlock()
+	return nil
+}
+
+func (c *Core) persistNamespace(ctx context.Context, ns *namespace.Namespace) error {
+	entry, err := logical.StorageEntryJSON(ns.ID, ns)
+	if err != nil {
+		return fmt.Errorf("failed to create namespace entry: %w", err)
+	}
+	if err := NewBarrierView(c.barrier, namespaceStoreSubPath).Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist namespace: %w", err)
+	}
+	return nil
+}
+
+// NamespaceByPath returns the namespace with the path, or nil if there is
+// none.
+func (c *Core) NamespaceByPath(path string) *namespace.Namespace {
+	path = namespace.Canonicalize(path)
+	if path == "" {
+		return namespace.RootNamespace
+	}
+
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+	for _, ns := range c.namespaces {
+		if ns.Path == path {
+			return ns
+		}
+	}
+	return nil
+}
+
+// NamespaceByPathPrefix returns the namespace with the longest path which is
+// a prefix of the request path, which is the root namespace if no other
+// namespace matches.
+func (c *Core) NamespaceByPathPrefix(path string) *namespace.Namespace {
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+
+	match := namespace.RootNamespace
+	for _, ns := range c.namespaces {
+		if strings.HasPrefix(path, ns.Path) && len(ns.Path) > len(match.Path) {
+			match = ns
+		}
+	}
+	return match
+}
+
+// childNamespaces returns the namespaces below the namespace, sorted by path.
+// Only the direct children are returned unless recursive is set.
+func (c *Core) childNamespaces(parent *namespace.Namespace, recursive bool) []*namespace.Namespace {
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+
+	var children []*namespace.Namespace
+	for _, ns := range c.namespaces {
+		if ns.ID == parent.ID || !strings.HasPrefix(ns.Path, parent.Path) {
+			continue
+		}
+		if !recursive && strings.Count(strings.TrimPrefix(ns.Path, parent.Path), "/") != 1 {
+			continue
+		}
+		children = append(children, ns)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Path < children[j].Path
+	})
+	return children
+}
+
+// createNamespace creates a child namespace of the namespace of the context
+// with its built-in mounts and default policies.
+func (c *Core) createNamespace(ctx context.Context, name string, customMetadata map[string]string) (*namespace.Namespace, error) {
+	parent, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSuffix(name, "/")
+	if !validNamespaceName.MatchString(name) {
+		return nil, logical.CodedError(400, fmt.Sprintf("invalid namespace name %q", name))
+	}
+	if strutil.StrListContains(reservedNamespaceNames, strings.ToLower(name)) {
+		return nil, logical.CodedError(400, fmt.Sprintf("%q is a reserved namespace name", name))
+	}
+	if conflict := c.router.MountConflict(ctx, name+"/"); conflict != "" {
+		return nil, logical.CodedError(409, fmt.Sprintf("namespace conflicts with existing mount at %s", conflict))
+	}
+
+	if customMetadata == nil {
+		customMetadata = make(map[string]string)
+	}
+	ns := &namespace.Namespace{
+		Path:           parent.Path + name + "/",
+		CustomMetadata: customMetadata,
+	}
+
+	c.namespacesLock.Lock()
+	for _, existing := range c.namespaces {
+		if existing.Path == ns.Path {
+			c.namespacesLock.Unlock()
+			return nil, logical.CodedError(409, fmt.Sprintf("namespace %q already exists", ns.Path))
+		}
+	}
+	for ns.ID == "" {
+		id, err := base62.Random(namespaceIDLength)
+		if err != nil {
+			c.namespacesLock.Unlock()
+			return nil, fmt.Errorf("failed to generate namespace ID: %w", err)
+		}
+		if _, ok := c.namespaces[id]; !ok && id != namespace.RootNamespaceID {
+			ns.ID = id
+		}
+	}
+	if err := c.persistNamespace(ctx, ns); err != nil {
+		c.namespacesLock.Unlock()
+		return nil, err
+	}
+	if c.namespaces == nil {
+		c.namespaces = make(map[string]*namespace.Namespace)
+	}
+	c.namespaces[ns.ID] = ns
+	c.namespacesLock.Unlock()
+
+	if err := c.setupNamespace(namespace.ContextWithNamespace(ctx, ns)); err != nil {
+		if removeErr := c.removeNamespace(ctx, ns); removeErr != nil {
+			c.logger.Error("failed to remove namespace after failed setup", "namespace", ns.Path, "error", removeErr)
+		}
+		return nil, fmt.Errorf("failed to set up namespace: %w", err)
+	}
+
+	c.logger.Info("created namespace", "namespace", ns.Path, "namespace_id", ns.ID)
+	return ns, nil
+}
+
+// setupNamespace mounts the sys/, cubbyhole/ and auth/token/ paths of the
+// namespace of the context and creates its default policies.
+func (c *Core) setupNamespace(ctx context.Context) error {
+	mounts := []*MountEntry{
+		{
+			Table:       mountTableType,
+			Path:        systemMountPath,
+			Type:        nsSystemMountType,
+			Description: "system endpoints used for control, policy and debugging",
+			SealWrap:    true,
+			Config: MountConfig{
+				PassthroughRequestHeaders: []string{"Accept"},
+			},
+			RunningVersion: versions.DefaultBuiltinVersion,
+		},
+		{
+			Table:          mountTableType,
+			Path:           cubbyholeMountPath,
+			Type:           mountTypeNSCubbyhole,
+			Description:    "per-token private secret storage",
+			Local:          true,
+			RunningVersion: versions.DefaultBuiltinVersion,
+		},
+	}
+	for _, entry := range mounts {
+		if err := c.mountInternal(ctx, entry, MountTableUpdateStorage); err != nil {
+			return fmt.Errorf("failed to mount %s: %w", entry.Path, err)
+		}
+	}
+
+	tokenAuth := &MountEntry{
+		Table:       credentialTableType,
+		Path:        "token/",
+		Type:        nsTokenMountType,
+		Description: "token based credentials",
+	}
+	if err := c.enableCredentialInternal(ctx, tokenAuth, MountTableUpdateStorage); err != nil {
+		return fmt.Errorf("failed to enable token auth: %w", err)
+	}
+
+	for name, policy := range map[string]string{
+		defaultPolicyName:          defaultPolicy,
+		responseWrappingPolicyName: responseWrappingPolicy,
+		controlGroupPolicyName:     controlGroupPolicy,
+	} {
+		if err := c.policyStore.loadACLPolicyInternal(ctx, name, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchNamespace sets the keys of the custom metadata of the namespace with a
+// string value, and removes those with a nil value.
+func (c *Core) patchNamespace(ctx context.Context, ns *namespace.Namespace, customMetadata map[string]interface{}) (*namespace.Namespace, error) {
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+
+	current, ok := c.namespaces[ns.ID]
+	if !ok {
+		return nil, nil
+	}
+
+	patched := &namespace.Namespace{
+		ID:             current.ID,
+		Path:           current.Path,
+		CustomMetadata: make(map[string]string, len(current.CustomMetadata)),
+	}
+	for k, v := range current.CustomMetadata {
+		patched.CustomMetadata[k] = v
+	}
+	for k, v := range customMetadata {
+		switch v := v.(type) {
+		case nil:
+			delete(patched.CustomMetadata, k)
+		case string:
+			patched.CustomMetadata[k] = v
+		default:
+			return nil, logical.CodedError(400, fmt.Sprintf("custom_metadata value of %q must be a string", k))
+		}
+	}
+
+	if err := c.persistNamespace(ctx, patched); err != nil {
+		return nil, err
+	}
+
+	// Namespaces are shared by the mount entries and contexts, so only the
+	// metadata is replaced
+	current.CustomMetadata = patched.CustomMetadata
+	return current, nil
+}
+
+// deleteNamespace deletes the child namespace of the namespace of the context
+// with the name, along with everything in it. Namespaces with children can't
+// be deleted.
+func (c *Core) deleteNamespace(ctx context.Context, name string) error {
+	parent, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	ns := c.NamespaceByPath(parent.Path + strings.TrimSuffix(name, "/"))
+	if ns == nil || ns.ID == namespace.RootNamespaceID {
+		return nil
+	}
+	if children := c.childNamespaces(ns, false); len(children) != 0 {
+		return logical.CodedError(400, fmt.Sprintf("namespace %q has child namespaces which must be deleted first", ns.Path))
+	}
+
+	return c.removeNamespace(ctx, ns)
+}
+
+// removeNamespace disables the auth methods and unmounts the secrets engines
+// of the namespace, which revokes their leases and tokens, then removes its
+// storage and the namespace itself.
+func (c *Core) removeNamespace(ctx context.Context, ns *namespace.Namespace) error {
+	nsCtx := namespace.ContextWithNamespace(ctx, ns)
+
+	// The token auth mount and the sys/ and cubbyhole/ mounts are removed
+	// last, since revoking the leases of the other mounts relies on them
+	builtin := func(entry *MountEntry) bool {
+		return strutil.StrListContains(singletonMounts, entry.Type)
+	}
+	namespaceEntries := func(table *MountTable) []*MountEntry {
+		var entries []*MountEntry
+		if table == nil {
+			return nil
+		}
+		for _, entry := range table.Entries {
+			if entry.NamespaceID == ns.ID {
+				entries = append(entries, entry)
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return !builtin(entries[i]) && builtin(entries[j])
+		})
+		return entries
+	}
+
+	var retErr *multierror.Error
+
+	c.authLock.RLock()
+	auths := namespaceEntries(c.auth)
+	c.authLock.RUnlock()
+	for _, entry := range auths {
+		if err := c.disableCredentialInternal(nsCtx, entry.Path, MountTableUpdateStorage); err != nil {
+			retErr = multierror.Append(retErr, fmt.Errorf("failed to disable auth method %q: %w", entry.Path, err))
+		}
+	}
+
+	c.mountsLock.RLock()
+	mounts := namespaceEntries(c.mounts)
+	c.mountsLock.RUnlock()
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return mounts[i].Type != nsSystemMountType && mounts[j].Type == nsSystemMountType
+	})
+	for _, entry := range mounts {
+		if err := c.unmountInternal(nsCtx, entry.Path, MountTableUpdateStorage); err != nil {
+			retErr = multierror.Append(retErr, fmt.Errorf("failed to unmount %q: %w", entry.Path, err))
+		}
+	}
+	if err := retErr.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	if err := logical.ClearView(ctx, NewBarrierView(c.barrier, namespaceBarrierPrefix+ns.ID+"/")); err != nil {
+		return fmt.Errorf("failed to clear namespace storage: %w", err)
+	}
+	if c.policyStore != nil {
+		c.policyStore.invalidateNamespace(ns)
+	}
+
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+	if err := NewBarrierView(c.barrier, namespaceStoreSubPath).Delete(ctx, ns.ID); err != nil {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	delete(c.namespaces, ns.ID)
+
+	c.logger.Info("deleted namespace", "namespace", ns.Path, "namespace_id", ns.ID)
+	return nil
+}
+
+// namespaceSystemBackend restricts the system backend of a namespace other
+// than root to the paths in namespaceSystemPathPrefixes.
+func namespaceSystemBackend(b logical.Backend) (logical.Backend, error) {
+	sys, ok := b.(*SystemBackend)
+	if !ok {
+		return nil, errors.New("unexpected system backend type")
+	}
+
+	paths := sys.Backend.Paths[:0]
+	for _, p := range sys.Backend.Paths {
+		for _, prefix := range namespaceSystemPathPrefixes {
+			if strings.HasPrefix(p.Pattern, prefix) {
+				paths = append(paths, p)
+				break
+			}
+		}
+	}
+	sys.Backend.Paths = paths
+	return sys, nil
+}
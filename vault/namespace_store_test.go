@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testNamespaceRequest(t *testing.T, c *Core, ctx context.Context, token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	req := logical.TestRequest(t, op, path)
+	req.ClientToken = token
+	if data != nil {
+		req.Data = data
+	}
+	return c.HandleRequest(ctx, req)
+}
+
+// TestNamespaces ensures namespaces isolate their mounts, policies and tokens,
+// survive a seal, and are deleted along with everything in them.
+func TestNamespaces(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+	rootCtx := namespace.RootContext(nil)
+
+	// Namespaces which were not created through sys/namespaces are rejected
+	unknownCtx := namespace.ContextWithNamespace(rootCtx, &namespace.Namespace{ID: "unknown", Path: "unknown/"})
+	_, err := testNamespaceRequest(t, c, unknownCtx, root, logical.ReadOperation, "sys/mounts", nil)
+	require.ErrorContains(t, err, "namespaces feature not enabled")
+
+	// Reserved names and names of existing mounts are rejected
+	_, err = testNamespaceRequest(t, c, rootCtx, root, logical.UpdateOperation, "sys/namespaces/sys", nil)
+	require.Error(t, err)
+	_, err = testNamespaceRequest(t, c, rootCtx, root, logical.UpdateOperation, "sys/namespaces/secret", nil)
+	require.Error(t, err)
+
+	resp, err := testNamespaceRequest(t, c, rootCtx, root, logical.UpdateOperation, "sys/namespaces/team-a", map[string]interface{}{
+		"custom_metadata": map[string]interface{}{"owner": "a"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "team-a/", resp.Data["path"])
+	nsID := resp.Data["id"].(string)
+	require.Len(t, nsID, namespaceIDLength)
+
+	ns := c.NamespaceByPath("team-a")
+	require.NotNil(t, ns)
+	require.Equal(t, nsID, ns.ID)
+	nsCtx := namespace.ContextWithNamespace(rootCtx, ns)
+
+	// Mount a secrets engine with a policy in the namespace
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.UpdateOperation, "sys/mounts/secret", map[string]interface{}{"type": "kv"})
+	require.NoError(t, err)
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{"team": "a"})
+	require.NoError(t, err)
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.UpdateOperation, "sys/policy/reader", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	require.NoError(t, err)
+
+	// System paths which manage the cluster are only served in the root
+	// namespace
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.ReadOperation, "sys/audit", nil)
+	require.Error(t, err)
+
+	resp, err = testNamespaceRequest(t, c, nsCtx, root, logical.UpdateOperation, "auth/token/create", map[string]interface{}{
+		"policies": []string{"reader"},
+	})
+	require.NoError(t, err)
+	token := resp.Auth.ClientToken
+	te, err := c.tokenStore.Lookup(nsCtx, token)
+	require.NoError(t, err)
+	require.Equal(t, nsID, te.NamespaceID)
+	require.True(t, strings.HasSuffix(te.ID, "."+nsID))
+
+	// The token of the namespace can only use the mounts of the namespace
+	resp, err = testNamespaceRequest(t, c, nsCtx, token, logical.ReadOperation, "secret/foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", resp.Data["team"])
+	_, err = testNamespaceRequest(t, c, nsCtx, token, logical.UpdateOperation, "secret/foo", map[string]interface{}{"team": "b"})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	_, err = testNamespaceRequest(t, c, rootCtx, token, logical.ReadOperation, "secret/foo", nil)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	// Mounts of the root namespace only list the root namespace mounts
+	resp, err = testNamespaceRequest(t, c, nsCtx, root, logical.ReadOperation, "sys/mounts", nil)
+	require.NoError(t, err)
+	require.Contains(t, resp.Data, "secret/")
+	require.Contains(t, resp.Data, "sys/")
+	require.NotContains(t, resp.Data, "identity/")
+
+	// Namespaces are nested
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.UpdateOperation, "sys/namespaces/child", nil)
+	require.NoError(t, err)
+	require.NotNil(t, c.NamespaceByPath("team-a/child/"))
+	require.Equal(t, "team-a/child/", c.NamespaceByPathPrefix("team-a/child/secret/foo").Path)
+
+	resp, err = testNamespaceRequest(t, c, rootCtx, root, logical.ListOperation, "sys/namespaces", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team-a/"}, resp.Data["keys"])
+
+	resp, err = testNamespaceRequest(t, c, rootCtx, root, logical.PatchOperation, "sys/namespaces/team-a", map[string]interface{}{
+		"custom_metadata": map[string]interface{}{"owner": nil, "tier": "gold"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"tier": "gold"}, resp.Data["custom_metadata"])
+
+	// The namespaces are restored on unseal
+	require.NoError(t, c.Seal(root))
+	for _, key := range keys {
+		_, err := TestCoreUnseal(c, key)
+		require.NoError(t, err)
+	}
+	ns = c.NamespaceByPath("team-a")
+	require.NotNil(t, ns)
+	require.Equal(t, map[string]string{"tier": "gold"}, ns.CustomMetadata)
+	nsCtx = namespace.ContextWithNamespace(rootCtx, ns)
+	resp, err = testNamespaceRequest(t, c, nsCtx, token, logical.ReadOperation, "secret/foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", resp.Data["team"])
+
+	// Namespaces with children can't be deleted
+	_, err = testNamespaceRequest(t, c, rootCtx, root, logical.DeleteOperation, "sys/namespaces/team-a", nil)
+	require.Error(t, err)
+	_, err = testNamespaceRequest(t, c, nsCtx, root, logical.DeleteOperation, "sys/namespaces/child", nil)
+	require.NoError(t, err)
+	_, err = testNamespaceRequest(t, c, rootCtx, root, logical.DeleteOperation, "sys/namespaces/team-a", nil)
+	require.NoError(t, err)
+	require.Nil(t, c.NamespaceByPath("team-a"))
+
+	// The tokens and storage of the namespace are gone
+	te, err = c.tokenStore.Lookup(rootCtx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+	keysLeft, err := logical.CollectKeys(rootCtx, NewBarrierView(c.barrier, namespaceBarrierPrefix))
+	require.NoError(t, err)
+	require.Empty(t, keysLeft)
+	for _, entry := range c.mounts.Entries {
+		require.Equal(t, namespace.RootNamespaceID, entry.NamespaceID)
+	}
+}
@@ -15,5 +15,11 @@ func namespaceByID(ctx context.Context, nsID string, c *Core) (*namespace.Namesp
 	if nsID == namespace.RootNamespaceID {
 		return namespace.RootNamespace, nil
 	}
-	return nil, namespace.ErrNoNamespace
+	if c == nil {
+		return nil, namespace.ErrNoNamespace
+	}
+
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+	return c.namespaces[nsID], nil
 }
@@ -15,6 +15,8 @@ func (c *Core) NamespaceByID(ctx context.Context, nsID string) (*namespace.Names
 	return namespaceByID(ctx, nsID, c)
 }
 
+// ListNamespaces returns the root namespace followed by the other namespaces,
+// sorted by path.
 func (c *Core) ListNamespaces(includePath bool) []*namespace.Namespace {
-	return []*namespace.Namespace{namespace.RootNamespace}
+	return append([]*namespace.Namespace{namespace.RootNamespace}, c.childNamespaces(namespace.RootNamespace, true)...)
 }
@@ -11,14 +11,17 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/armon/go-metrics"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/go-secure-stdlib/base62"
 	semver "github.com/hashicorp/go-version"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/versions"
 	v4 "github.com/hashicorp/vault/sdk/database/dbplugin"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
@@ -29,6 +32,7 @@ import (
 	backendplugin "github.com/hashicorp/vault/sdk/plugin"
 	"github.com/hashicorp/vault/version"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -303,6 +307,96 @@ func (c *PluginCatalog) cleanupExternalPlugin(key externalPluginsKey, id, plugin
 	return nil
 }
 
+// processes returns the number of running external plugin processes, and the
+// sorted names of the plugins whose process has exited while still in use.
+func (c *PluginCatalog) processes() (int, []string) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	running := 0
+	exited := make(map[string]struct{})
+	for key, extPlugin := range c.externalPlugins {
+		seen := make(map[*plugin.Client]struct{})
+		for _, pc := range extPlugin.connections {
+			if _, ok := seen[pc.client]; ok {
+				continue
+			}
+			seen[pc.client] = struct{}{}
+			if pc.client.Exited() {
+				exited[key.name] = struct{}{}
+			} else {
+				running++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(exited))
+	for name := range exited {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return running, names
+}
+
+// exited returns true if the process shared by the connections of a
+// multiplexed plugin has exited.
+func (p *externalPlugin) exited() bool {
+	for _, pc := range p.connections {
+		return pc.client.Exited()
+	}
+	return false
+}
+
+// pluginMetricLabels returns the labels of the metrics of an external plugin.
+func pluginMetricLabels(key externalPluginsKey) []metrics.Label {
+	return []metrics.Label{
+		{Name: "plugin_name", Value: key.name},
+		{Name: "plugin_type", Value: key.typ.String()},
+	}
+}
+
+// emitMetrics emits the number of external plugin processes and connections
+// to them, by plugin, and the number of connections by gRPC connectivity
+// state, e.g. to spot connections stuck in TRANSIENT_FAILURE after a plugin
+// process was killed.
+func (c *PluginCatalog) emitMetrics(sink *metricsutil.ClusterMetricSink) {
+	type pluginStats struct {
+		processes   int
+		connections int
+	}
+
+	c.lock.RLock()
+	stats := make(map[externalPluginsKey]*pluginStats)
+	states := make(map[connectivity.State]int)
+	for key, extPlugin := range c.externalPlugins {
+		keyStats := &pluginStats{}
+		stats[key] = keyStats
+
+		seen := make(map[*plugin.Client]struct{})
+		for _, pc := range extPlugin.connections {
+			keyStats.connections++
+			if _, ok := seen[pc.client]; !ok {
+				seen[pc.client] = struct{}{}
+				keyStats.processes++
+			}
+			if conn, ok := pc.clientConn.(*pluginClientConn); ok {
+				states[conn.GetState()]++
+			}
+		}
+	}
+	c.lock.RUnlock()
+
+	for key, keyStats := range stats {
+		labels := pluginMetricLabels(key)
+		sink.SetGaugeWithLabels([]string{"plugin", "external", "processes"}, float32(keyStats.processes), labels)
+		sink.SetGaugeWithLabels([]string{"plugin", "external", "connections"}, float32(keyStats.connections), labels)
+	}
+	for _, state := range []connectivity.State{connectivity.Idle, connectivity.Connecting, connectivity.Ready, connectivity.TransientFailure, connectivity.Shutdown} {
+		sink.SetGaugeWithLabels([]string{"plugin", "external", "connections", "by_state"}, float32(states[state]),
+			[]metrics.Label{{Name: "state", Value: strings.ToLower(state.String())}})
+	}
+}
+
 func (c *PluginCatalog) getExternalPlugin(key externalPluginsKey) *externalPlugin {
 	if extPlugin, ok := c.externalPlugins[key]; ok {
 		return extPlugin
@@ -381,6 +475,15 @@ func (c *PluginCatalog) newPluginClient(ctx context.Context, pluginRunner *plugi
 		},
 	}
 
+	// A multiplexed plugin process which exited can't be reused. The
+	// connections to it are left to be cleaned up by their backends, as on
+	// reload.
+	if extPlugin.multiplexingSupport && extPlugin.exited() {
+		c.logger.Debug("multiplexed plugin process exited, spawning a new one", "plugin_name", pluginRunner.Name)
+		delete(c.externalPlugins, key)
+		extPlugin = c.getExternalPlugin(key)
+	}
+
 	// Multiplexing support will always be false initially, but will be
 	// adjusted once we query from the plugin whether it can multiplex or not
 	var spawnedPlugin bool
@@ -401,8 +504,10 @@ func (c *PluginCatalog) newPluginClient(ctx context.Context, pluginRunner *plugi
 
 		spawnedPlugin = true
 		pc.client = client
+		metrics.IncrCounterWithLabels([]string{"plugin", "external", "spawn"}, 1, pluginMetricLabels(key))
 	} else {
 		c.logger.Debug("returning existing plugin client for multiplexed plugin", "id", id)
+		metrics.IncrCounterWithLabels([]string{"plugin", "external", "reuse"}, 1, pluginMetricLabels(key))
 
 		// get the first client, since they are all the same
 		for k := range extPlugin.connections {
@@ -432,14 +537,16 @@ func (c *PluginCatalog) newPluginClient(ctx context.Context, pluginRunner *plugi
 
 	clientConn := rpcClient.(*plugin.GRPCClient).Conn
 
-	muxed, err := pluginutil.MultiplexingSupported(ctx, clientConn, config.Name)
-	if err != nil {
-		// Make sure we kill any spawned plugins that didn't make it into our
-		// map of connections.
-		if spawnedPlugin {
+	// The multiplexing support of a running process is already known
+	muxed := extPlugin.multiplexingSupport
+	if spawnedPlugin {
+		muxed, err = pluginutil.MultiplexingSupported(ctx, clientConn, config.Name)
+		if err != nil {
+			// Make sure we kill any spawned plugins that didn't make it into our
+			// map of connections.
 			pc.client.Kill()
+			return nil, err
 		}
-		return nil, err
 	}
 
 	pc.clientConn = &pluginClientConn{
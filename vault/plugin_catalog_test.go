@@ -14,9 +14,13 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/versions"
 	"github.com/hashicorp/vault/plugins/database/postgresql"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
@@ -606,6 +610,18 @@ func TestPluginCatalog_NewPluginClient(t *testing.T) {
 	expectMultiplexingSupport(t, false, externalPlugins[getKey("single-userpass-1", consts.PluginTypeCredential)].multiplexingSupport)
 	expectMultiplexingSupport(t, false, externalPlugins[getKey("single-userpass-2", consts.PluginTypeCredential)].multiplexingSupport)
 
+	// a multiplexed plugin process which exited is not reused
+	muxKey := getKey("mux-userpass", consts.PluginTypeCredential)
+	exitedPluginID := pluginClients[4].pluginID
+	pluginClients[4].client.Kill()
+	c = TestRunTestPlugin(t, core, consts.PluginTypeCredential, "mux-userpass")
+	pluginClients = append(pluginClients, c)
+	if c.pluginID == exitedPluginID {
+		t.Fatalf("expected a new plugin process, got the exited one %q", exitedPluginID)
+	}
+	expectConnectionLen(t, 1, externalPlugins[muxKey].connections)
+	expectMultiplexingSupport(t, true, externalPlugins[muxKey].multiplexingSupport)
+
 	// cleanup all of the external plugin processes
 	for _, client := range pluginClients {
 		client.Close()
@@ -617,6 +633,54 @@ func TestPluginCatalog_NewPluginClient(t *testing.T) {
 	}
 }
 
+func TestPluginCatalog_EmitMetrics(t *testing.T) {
+	muxKey := externalPluginsKey{name: "mux", typ: consts.PluginTypeDatabase}
+	singleKey := externalPluginsKey{name: "single", typ: consts.PluginTypeCredential}
+
+	muxClient := &plugin.Client{}
+	catalog := &PluginCatalog{
+		externalPlugins: map[externalPluginsKey]*externalPlugin{
+			muxKey: {
+				connections: map[string]*pluginClient{
+					"a": {client: muxClient},
+					"b": {client: muxClient},
+				},
+				multiplexingSupport: true,
+			},
+			singleKey: {
+				connections: map[string]*pluginClient{
+					"c": {client: &plugin.Client{}},
+				},
+			},
+		},
+	}
+
+	inmemSink := metrics.NewInmemSink(1000000*time.Hour, 2000000*time.Hour)
+	catalog.emitMetrics(metricsutil.NewClusterMetricSink("test-cluster", inmemSink))
+
+	gauges := inmemSink.Data()[0].Gauges
+	for name, expected := range map[string]float32{
+		"plugin.external.processes;plugin_name=mux;plugin_type=database;cluster=test-cluster":   1,
+		"plugin.external.connections;plugin_name=mux;plugin_type=database;cluster=test-cluster": 2,
+		"plugin.external.processes;plugin_name=single;plugin_type=auth;cluster=test-cluster":    1,
+		"plugin.external.connections;plugin_name=single;plugin_type=auth;cluster=test-cluster":  1,
+		"plugin.external.connections.by_state;state=ready;cluster=test-cluster":                 0,
+		"plugin.external.connections.by_state;state=transient_failure;cluster=test-cluster":     0,
+	} {
+		gauge, ok := gauges[name]
+		if !ok {
+			t.Fatalf("missing gauge %q in %v", name, gauges)
+		}
+		if gauge.Value != expected {
+			t.Fatalf("expected %v for gauge %q, got %v", expected, name, gauge.Value)
+		}
+	}
+
+	if catalog.externalPlugins[muxKey].exited() {
+		t.Fatal("expected the multiplexed plugin process not to have exited")
+	}
+}
+
 func TestPluginCatalog_MakeExternalPluginsKey_Comparable(t *testing.T) {
 	var plugins []pluginutil.PluginRunner
 	hasher := sha256.New()
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/vault/helper/pluginoci"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pluginTrustRootsPath is the path of the keys trusted to sign the plugins
+// pulled from OCI registries
+const pluginTrustRootsPath = "core/plugin-trust-roots/"
+
+// pluginTrustRoot is a public key trusted to sign plugin artifacts.
+type pluginTrustRoot struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+// pluginArtifact is a plugin binary pulled from an OCI registry into the
+// plugin directory.
+type pluginArtifact struct {
+	Command        string
+	Sha256         []byte
+	ManifestDigest string
+}
+
+func (c *Core) pluginTrustRootsView() *BarrierView {
+	return NewBarrierView(c.barrier, pluginTrustRootsPath)
+}
+
+// setPluginTrustRoot validates and stores a trust root.
+func (c *Core) setPluginTrustRoot(ctx context.Context, root *pluginTrustRoot) error {
+	if _, err := pluginoci.ParsePublicKey(root.PublicKey); err != nil {
+		return fmt.Errorf("invalid public_key: %w", err)
+	}
+
+	entry, err := logical.StorageEntryJSON(root.Name, root)
+	if err != nil {
+		return fmt.Errorf("failed to create plugin trust root entry: %w", err)
+	}
+	if err := c.pluginTrustRootsView().Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist plugin trust root: %w", err)
+	}
+	return nil
+}
+
+// pluginTrustRoot returns the trust root with the name, or nil if there is
+// none.
+func (c *Core) pluginTrustRoot(ctx context.Context, name string) (*pluginTrustRoot, error) {
+	entry, err := c.pluginTrustRootsView().Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin trust root: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	root := new(pluginTrustRoot)
+	if err := entry.DecodeJSON(root); err != nil {
+		return nil, fmt.Errorf("failed to decode plugin trust root: %w", err)
+	}
+	return root, nil
+}
+
+func (c *Core) deletePluginTrustRoot(ctx context.Context, name string) error {
+	return c.pluginTrustRootsView().Delete(ctx, name)
+}
+
+// listPluginTrustRoots returns the sorted names of the trust roots.
+func (c *Core) listPluginTrustRoots(ctx context.Context) ([]string, error) {
+	keys, err := c.pluginTrustRootsView().List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// pluginTrustKeys returns the public keys of all the trust roots.
+func (c *Core) pluginTrustKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	names, err := c.listPluginTrustRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]crypto.PublicKey, 0, len(names))
+	for _, name := range names {
+		root, err := c.pluginTrustRoot(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+		key, err := pluginoci.ParsePublicKey(root.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key of plugin trust root %q: %w", name, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// pullPluginArtifact pulls the plugin binary of the artifact reference into
+// the plugin directory under the command, after verifying that the artifact
+// is signed by one of the trust roots. The binary is only moved into place
+// once its digest is verified, so a failed pull never leaves a partial binary
+// behind.
+func (c *Core) pullPluginArtifact(ctx context.Context, reference, command, username, password string) (*pluginArtifact, error) {
+	if c.pluginDirectory == "" {
+		return nil, errors.New("a plugin directory must be configured to pull plugins from OCI registries")
+	}
+	if command == "" || filepath.Base(command) != command || command == "." || command == ".." {
+		return nil, fmt.Errorf("command %q must be a file name in the plugin directory", command)
+	}
+
+	ref, err := pluginoci.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := c.pluginTrustKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no plugin trust roots are configured to verify the signature of the artifact")
+	}
+
+	client := &pluginoci.Client{
+		HTTPClient: c.pluginOCIHTTPClient,
+		PlainHTTP:  c.pluginOCIPlainHTTP,
+		Username:   username,
+		Password:   password,
+	}
+	artifact, err := client.Resolve(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve artifact: %w", err)
+	}
+	if err := client.VerifySignature(ctx, ref, artifact, keys); err != nil {
+		return nil, err
+	}
+
+	sha256, err := hex.DecodeString(artifact.Binary.Digest.Encoded())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode digest of plugin binary: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.pluginDirectory, ".oci-"+command+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := client.FetchBinary(ctx, ref, artifact, tmp); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to pull plugin binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write plugin binary: %w", err)
+	}
+
+	perms := os.FileMode(0o755)
+	if c.pluginFilePermissions != 0 {
+		perms = os.FileMode(c.pluginFilePermissions)
+	}
+	if err := os.Chmod(tmp.Name(), perms); err != nil {
+		return nil, fmt.Errorf("failed to set permissions of plugin binary: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.pluginDirectory, command)); err != nil {
+		return nil, fmt.Errorf("failed to move plugin binary into the plugin directory: %w", err)
+	}
+
+	c.logger.Info("pulled plugin from OCI registry", "reference", ref.String(), "manifest_digest", artifact.ManifestDigest, "command", command)
+	return &pluginArtifact{
+		Command:        command,
+		Sha256:         sha256,
+		ManifestDigest: artifact.ManifestDigest.String(),
+	}, nil
+}
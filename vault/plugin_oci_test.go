@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// testPluginRegistry serves the manifests and blobs of plugin artifacts, keyed
+// by the path of their distribution API endpoint.
+type testPluginRegistry map[string][]byte
+
+func (r testPluginRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, ok := r[req.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write(body)
+}
+
+func (r testPluginRegistry) push(t *testing.T, repository, tag string, layer []byte, annotations map[string]string) digest.Digest {
+	t.Helper()
+	layerDigest := digest.FromBytes(layer)
+	r[fmt.Sprintf("/v2/%s/blobs/%s", repository, layerDigest)] = layer
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Layers: []ocispec.Descriptor{{
+			MediaType:   "application/octet-stream",
+			Digest:      layerDigest,
+			Size:        int64(len(layer)),
+			Annotations: annotations,
+		}},
+	})
+	require.NoError(t, err)
+	r[fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)] = manifest
+	return digest.FromBytes(manifest)
+}
+
+func TestSystemBackend_PluginCatalog_OCIArtifact(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	c.pluginDirectory = dir
+	c.pluginCatalog.directory = dir
+	c.pluginOCIPlainHTTP = true
+
+	registry := testPluginRegistry{}
+	srv := httptest.NewServer(registry)
+	defer srv.Close()
+	binary := []byte("#!/bin/sh\nexit 1\n")
+	manifestDigest := registry.push(t, "vault/my-plugin", "v1.0.0", binary, nil)
+	reference := strings.TrimPrefix(srv.URL, "http://") + "/vault/my-plugin:v1.0.0"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	ctx := namespace.RootContext(nil)
+	register := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "plugins/catalog/secret/my-plugin")
+		req.Data = data
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Artifacts can't be pulled without trust roots
+	resp := register(map[string]interface{}{"oci_artifact": reference})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "no plugin trust roots")
+
+	// Configure a trust root
+	req := logical.TestRequest(t, logical.UpdateOperation, "plugins/trust-roots/release")
+	req.Data["public_key"] = "not a key"
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+	req.Data["public_key"] = publicKey
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	req = logical.TestRequest(t, logical.ListOperation, "plugins/trust-roots")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"release"}, resp.Data["keys"])
+
+	// Unsigned artifacts are rejected, and nothing is written
+	resp = register(map[string]interface{}{"oci_artifact": reference})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "no signature found")
+	_, err = os.Stat(filepath.Join(dir, "my-plugin"))
+	require.True(t, os.IsNotExist(err))
+
+	// Sign the artifact like cosign
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"vault/my-plugin"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, manifestDigest))
+	hash := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+	registry.push(t, "vault/my-plugin", strings.Replace(manifestDigest.String(), ":", "-", 1)+".sig", payload, map[string]string{
+		"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(sig),
+	})
+
+	// A SHA-256 which doesn't match the artifact is rejected
+	resp = register(map[string]interface{}{"oci_artifact": reference, "sha256": hex.EncodeToString(hash[:])})
+	require.True(t, resp.IsError())
+
+	binarySHA := sha256.Sum256(binary)
+	resp = register(map[string]interface{}{"oci_artifact": reference})
+	require.False(t, resp.IsError(), resp.Error())
+	require.Equal(t, map[string]interface{}{
+		"command":             "my-plugin",
+		"sha256":              hex.EncodeToString(binarySHA[:]),
+		"oci_manifest_digest": manifestDigest.String(),
+	}, resp.Data)
+
+	written, err := os.ReadFile(filepath.Join(dir, "my-plugin"))
+	require.NoError(t, err)
+	require.Equal(t, binary, written)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "plugins/catalog/secret/my-plugin")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "my-plugin", resp.Data["command"])
+	require.Equal(t, hex.EncodeToString(binarySHA[:]), resp.Data["sha256"])
+
+	// Commands must stay in the plugin directory
+	resp = register(map[string]interface{}{"oci_artifact": reference, "command": "../my-plugin"})
+	require.True(t, resp.IsError())
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// pluginRollout is the outcome of rolling the mounts of a plugin to a version
+// of the plugin catalog. Mounts are keyed by their path relative to the
+// namespace, with auth mounts prefixed with auth/.
+type pluginRollout struct {
+	// Mounts maps the mounts which were rolled to their previous version
+	Mounts map[string]string
+
+	// Errors maps the mounts which failed to be rolled to their error. These
+	// mounts keep running their previous version.
+	Errors map[string]string
+}
+
+// rolloutPluginVersion pins the mounts of the plugin in the namespace of the
+// context to the version, and reloads them. If mounts is not empty, only the
+// listed mounts are rolled, which allows staging upgrades. The version must
+// be canonical, with the empty version standing for the unversioned or builtin
+// plugin. A mount which fails to be reloaded with the version is restored to
+// its previous version.
+func (c *Core) rolloutPluginVersion(ctx context.Context, pluginType consts.PluginType, pluginName, version string, mounts []string) (*pluginRollout, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := c.pluginCatalog.Get(ctx, pluginName, pluginType, version)
+	if err != nil {
+		return nil, err
+	}
+	if runner == nil {
+		errContext := pluginName
+		if version != "" {
+			errContext += fmt.Sprintf(", version=%s", version)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, errContext)
+	}
+
+	isAuth := pluginType == consts.PluginTypeCredential
+	lock, table, prefix := &c.mountsLock, c.mounts, ""
+	if isAuth {
+		lock, table, prefix = &c.authLock, c.auth, credentialRoutePrefix
+	}
+
+	// Normalize the requested mounts to the paths of the mount entries
+	requested := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		mount = strings.TrimPrefix(strings.TrimPrefix(mount, "/"), prefix)
+		if !strings.HasSuffix(mount, "/") {
+			mount += "/"
+		}
+		requested = append(requested, mount)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	rollout := &pluginRollout{
+		Mounts: make(map[string]string),
+		Errors: make(map[string]string),
+	}
+	matched := make(map[string]struct{}, len(requested))
+	for _, entry := range table.Entries {
+		if ns.ID != entry.Namespace().ID {
+			continue
+		}
+		if entry.Type != pluginName && (entry.Type != "plugin" || entry.Config.PluginName != pluginName) {
+			continue
+		}
+		if len(requested) > 0 && !strutil.StrListContains(requested, entry.Path) {
+			continue
+		}
+		matched[entry.Path] = struct{}{}
+		if entry.Version == version {
+			continue
+		}
+
+		previous := entry.Version
+		if err := c.setMountPluginVersion(ctx, table, entry, version, isAuth); err != nil {
+			rollout.Errors[prefix+entry.Path] = err.Error()
+			if restoreErr := c.setMountPluginVersion(ctx, table, entry, previous, isAuth); restoreErr != nil {
+				rollout.Errors[prefix+entry.Path] += fmt.Sprintf("; failed to restore previous version: %s", restoreErr)
+			}
+			c.logger.Error("failed to roll out plugin version", "plugin", pluginName, "path", prefix+entry.Path, "version", version, "error", err)
+			continue
+		}
+		rollout.Mounts[prefix+entry.Path] = previous
+		c.logger.Info("rolled out plugin version", "plugin", pluginName, "path", prefix+entry.Path, "previous_version", previous, "version", version)
+	}
+
+	for _, mount := range requested {
+		if _, ok := matched[mount]; !ok {
+			rollout.Errors[prefix+mount] = fmt.Sprintf("no mount of plugin %q at %q", pluginName, prefix+mount)
+		}
+	}
+
+	return rollout, nil
+}
+
+// setMountPluginVersion persists the plugin version of the mount entry, and
+// reloads its backend with it. The caller must hold the lock of the table
+// for writing.
+func (c *Core) setMountPluginVersion(ctx context.Context, table *MountTable, entry *MountEntry, version string, isAuth bool) error {
+	entry.Version = version
+
+	var err error
+	if isAuth {
+		err = c.persistAuth(ctx, table, &entry.Local)
+	} else {
+		err = c.persistMounts(ctx, table, &entry.Local)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to persist mount table: %w", err)
+	}
+
+	if err := c.reloadBackendCommon(ctx, entry, isAuth); err != nil {
+		return fmt.Errorf("failed to reload plugin: %w", err)
+	}
+	return nil
+}
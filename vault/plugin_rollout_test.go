@@ -0,0 +1,78 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_PluginRollout ensures the mounts of a plugin can be rolled forward
+// and back between the versions of the plugin catalog, one at a time or all
+// at once, and that mounts which fail to be reloaded keep their version.
+func TestCore_PluginRollout(t *testing.T) {
+	c, plugins := testCoreWithPlugins(t, consts.PluginTypeSecrets, "")
+	ctx := namespace.RootContext(nil)
+	plugin := plugins[0]
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		registerPlugin(t, c.systemBackend, plugin.Name, consts.PluginTypeSecrets.String(), version, plugin.Sha256, plugin.FileName)
+	}
+	mountPlugin(t, c.systemBackend, plugin.Name, consts.PluginTypeSecrets, "v1.0.0", "foo")
+	mountPlugin(t, c.systemBackend, plugin.Name, consts.PluginTypeSecrets, "v1.0.0", "bar")
+
+	rollout := func(version string, mounts ...string) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "plugins/rollout/secret/"+plugin.Name)
+		req.Data["version"] = version
+		if len(mounts) > 0 {
+			req.Data["mounts"] = strings.Join(mounts, ",")
+		}
+		resp, err := c.systemBackend.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+	requireVersion := func(path, version string) {
+		t.Helper()
+		entry := c.router.MatchingMountEntry(ctx, path)
+		require.Equal(t, version, entry.Version)
+		require.Equal(t, version, entry.RunningVersion)
+	}
+
+	// Stage the upgrade on a single mount
+	resp := rollout("1.1.0", "foo")
+	require.False(t, resp.IsError(), resp.Error())
+	require.Equal(t, "v1.1.0", resp.Data["version"])
+	require.Equal(t, map[string]interface{}{"foo/": map[string]interface{}{"previous_version": "v1.0.0"}}, resp.Data["mounts"])
+	requireVersion("foo/", "v1.1.0")
+	requireVersion("bar/", "v1.0.0")
+
+	// Roll out the upgrade to the remaining mounts
+	resp = rollout("v1.1.0")
+	require.Equal(t, map[string]interface{}{"bar/": map[string]interface{}{"previous_version": "v1.0.0"}}, resp.Data["mounts"])
+	requireVersion("bar/", "v1.1.0")
+
+	// Roll back
+	resp = rollout("v1.0.0")
+	require.Len(t, resp.Data["mounts"], 2)
+	requireVersion("foo/", "v1.0.0")
+	requireVersion("bar/", "v1.0.0")
+
+	// A version which fails to be reloaded is not rolled out
+	registerPlugin(t, c.systemBackend, plugin.Name, consts.PluginTypeSecrets.String(), "v2.0.0", strings.Repeat("0", 64), plugin.FileName)
+	resp = rollout("v2.0.0", "foo", "nonexistent")
+	require.Empty(t, resp.Data["mounts"])
+	errs := resp.Data["errors"].(map[string]interface{})
+	require.Contains(t, errs["foo/"], "failed to reload plugin")
+	require.Contains(t, errs["nonexistent/"], "no mount of plugin")
+	require.NotEmpty(t, resp.Warnings)
+	requireVersion("foo/", "v1.0.0")
+
+	resp = rollout("v3.0.0")
+	require.True(t, resp.IsError())
+}
@@ -0,0 +1,388 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// opaConfigPath is the path of the OPA configuration in the config/ view
+	// of the system barrier view.
+	opaConfigPath = "opa"
+
+	// defaultOPATimeout is the default timeout of policy evaluations.
+	defaultOPATimeout = 5 * time.Second
+
+	// maxOPAResponseSize is the maximum size of the responses read from OPA.
+	maxOPAResponseSize = 1024 * 1024
+)
+
+// OPAConfig is the configuration of the evaluation of requests against the
+// policies of an Open Policy Agent server, in addition to ACL policies.
+type OPAConfig struct {
+	// Address is the address of the OPA server, such as
+	// http://127.0.0.1:8181.
+	Address string `json:"address"`
+
+	// DecisionPath is the path of the decision evaluated for each request,
+	// such as vault/authz, which is queried through the OPA Data API.
+	DecisionPath string `json:"decision_path"`
+
+	// BearerToken is sent to the OPA server when it requires
+	// authentication.
+	BearerToken string `json:"bearer_token,omitempty"`
+
+	// Timeout is the timeout of each evaluation.
+	Timeout time.Duration `json:"timeout"`
+
+	// FailOpen allows requests when the OPA server can't be reached or
+	// returns an error, instead of denying them.
+	FailOpen bool `json:"fail_open"`
+}
+
+// opaEvaluator evaluates requests against the decision of an OPA server.
+type opaEvaluator struct {
+	config *OPAConfig
+	url    string
+	client *http.Client
+}
+
+// opaInput is the input document of the policy evaluations.
+type opaInput struct {
+	Request opaInputRequest `json:"request"`
+	Token   *opaInputToken  `json:"token,omitempty"`
+	Entity  *opaInputEntity `json:"entity,omitempty"`
+	Time    string          `json:"time"`
+}
+
+type opaInputRequest struct {
+	ID            string   `json:"id"`
+	Path          string   `json:"path"`
+	Operation     string   `json:"operation"`
+	NamespaceID   string   `json:"namespace_id"`
+	NamespacePath string   `json:"namespace_path"`
+	MountPath     string   `json:"mount_path,omitempty"`
+	MountType     string   `json:"mount_type,omitempty"`
+	MountAccessor string   `json:"mount_accessor,omitempty"`
+	RemoteAddress string   `json:"remote_address,omitempty"`
+	Parameters    []string `json:"parameters"`
+}
+
+type opaInputToken struct {
+	Accessor    string            `json:"accessor"`
+	DisplayName string            `json:"display_name"`
+	Policies    []string          `json:"policies"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Type        string            `json:"type"`
+	NamespaceID string            `json:"namespace_id"`
+}
+
+type opaInputEntity struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	GroupIDs []string          `json:"group_ids,omitempty"`
+}
+
+// opaDecision is the result of a decision, which is either a boolean or an
+// object with an allow field and an optional reason.
+type opaDecision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason"`
+}
+
+func (d *opaDecision) UnmarshalJSON(data []byte) error {
+	var allow bool
+	if err := json.Unmarshal(data, &allow); err == nil {
+		d.Allow = allow
+		return nil
+	}
+
+	type decision opaDecision
+	var dec decision
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return fmt.Errorf("decision must be a boolean or an object with an allow field: %w", err)
+	}
+	*d = opaDecision(dec)
+	return nil
+}
+
+// validate ensures the configuration is usable and sets its defaults.
+func (conf *OPAConfig) validate() error {
+	if conf.Address == "" {
+		return errors.New("address is required")
+	}
+	u, err := url.Parse(conf.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid address scheme %q: must be http or https", u.Scheme)
+	}
+
+	conf.DecisionPath = strings.Trim(conf.DecisionPath, "/")
+	if conf.DecisionPath == "" {
+		return errors.New("decision_path is required")
+	}
+
+	if conf.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = defaultOPATimeout
+	}
+	return nil
+}
+
+func newOPAEvaluator(conf *OPAConfig) *opaEvaluator {
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = conf.Timeout
+	return &opaEvaluator{
+		config: conf,
+		url:    strings.TrimRight(conf.Address, "/") + "/v1/data/" + conf.DecisionPath,
+		client: client,
+	}
+}
+
+// evaluate queries the decision for the input, returning whether the request
+// is allowed and the reason it is denied, if any.
+func (e *opaEvaluator) evaluate(ctx context.Context, input *opaInput) (*opaDecision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if e.config.BearerToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+e.config.BearerToken)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxOPAResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Result *opaDecision `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// An undefined decision denies the request, so that a missing or
+	// mistyped policy doesn't allow everything.
+	if result.Result == nil {
+		return &opaDecision{Reason: fmt.Sprintf("decision %q is undefined", e.config.DecisionPath)}, nil
+	}
+	return result.Result, nil
+}
+
+// opaInputFor builds the input document of the request.
+func (c *Core) opaInputFor(ctx context.Context, req *logical.Request, te *logical.TokenEntry, entity *identity.Entity) (*opaInput, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &opaInput{
+		Request: opaInputRequest{
+			ID:            req.ID,
+			Path:          req.Path,
+			Operation:     string(req.Operation),
+			NamespaceID:   ns.ID,
+			NamespacePath: ns.Path,
+			Parameters:    make([]string, 0, len(req.Data)),
+		},
+		Time: time.Now().UTC().Format(time.RFC3339),
+	}
+	if req.Connection != nil {
+		input.Request.RemoteAddress = req.Connection.RemoteAddr
+	}
+	for k := range req.Data {
+		input.Request.Parameters = append(input.Request.Parameters, k)
+	}
+	sort.Strings(input.Request.Parameters)
+
+	if entry := c.router.MatchingMountEntry(ctx, req.Path); entry != nil {
+		input.Request.MountPath = entry.Path
+		if entry.Table == credentialTableType {
+			input.Request.MountPath = credentialRoutePrefix + entry.Path
+		}
+		input.Request.MountType = entry.Type
+		input.Request.MountAccessor = entry.Accessor
+	}
+
+	if te != nil {
+		input.Token = &opaInputToken{
+			Accessor:    te.Accessor,
+			DisplayName: te.DisplayName,
+			Policies:    te.Policies,
+			Metadata:    te.Meta,
+			Type:        te.Type.String(),
+			NamespaceID: te.NamespaceID,
+		}
+	}
+
+	if entity != nil {
+		input.Entity = &opaInputEntity{
+			ID:       entity.ID,
+			Name:     entity.Name,
+			Metadata: entity.Metadata,
+		}
+		if c.identityStore != nil {
+			groups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch groups of entity: %w", err)
+			}
+			for _, group := range append(groups, inheritedGroups...) {
+				input.Entity.GroupIDs = append(input.Entity.GroupIDs, group.ID)
+			}
+		}
+	}
+
+	return input, nil
+}
+
+// performOPAPolicyCheck evaluates the request against the configured OPA
+// decision, if any, denying it in the results when the decision does.
+func (c *Core) performOPAPolicyCheck(ctx context.Context, te *logical.TokenEntry, req *logical.Request, entity *identity.Entity, ret *AuthResults) {
+	c.opaLock.RLock()
+	evaluator := c.opaEvaluator
+	c.opaLock.RUnlock()
+	if evaluator == nil {
+		return
+	}
+
+	defer metrics.MeasureSince([]string{"policy", "opa", "evaluate"}, time.Now())
+
+	deny := func(err error) {
+		ret.Allowed = false
+		ret.DeniedError = true
+		ret.Error = multierror.Append(ret.Error, err)
+	}
+
+	input, err := c.opaInputFor(ctx, req, te, entity)
+	if err != nil {
+		c.logger.Error("failed to build OPA policy input", "path", req.Path, "error", err)
+		deny(ErrInternalError)
+		return
+	}
+
+	decision, err := evaluator.evaluate(ctx, input)
+	if err != nil {
+		metrics.IncrCounter([]string{"policy", "opa", "error"}, 1)
+		if evaluator.config.FailOpen {
+			c.logger.Warn("failed to evaluate OPA policy, allowing request", "path", req.Path, "error", err)
+			return
+		}
+		c.logger.Error("failed to evaluate OPA policy, denying request", "path", req.Path, "error", err)
+		deny(errors.New("failed to evaluate OPA policy"))
+		return
+	}
+
+	if !decision.Allow {
+		metrics.IncrCounter([]string{"policy", "opa", "denied"}, 1)
+		msg := "denied by OPA policy"
+		if decision.Reason != "" {
+			msg += ": " + decision.Reason
+		}
+		deny(errors.New(msg))
+	}
+}
+
+// setOPAConfig validates, persists and applies the OPA configuration.
+func (c *Core) setOPAConfig(ctx context.Context, conf *OPAConfig) error {
+	if err := conf.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(opaConfigPath, conf)
+	if err != nil {
+		return fmt.Errorf("failed to create OPA config entry: %w", err)
+	}
+	if err := c.systemBarrierView.SubView("config/").Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save OPA config: %w", err)
+	}
+
+	c.opaLock.Lock()
+	c.opaEvaluator = newOPAEvaluator(conf)
+	c.opaLock.Unlock()
+	return nil
+}
+
+// deleteOPAConfig disables the OPA policy evaluation.
+func (c *Core) deleteOPAConfig(ctx context.Context) error {
+	if err := c.systemBarrierView.SubView("config/").Delete(ctx, opaConfigPath); err != nil {
+		return fmt.Errorf("failed to delete OPA config: %w", err)
+	}
+
+	c.opaLock.Lock()
+	c.opaEvaluator = nil
+	c.opaLock.Unlock()
+	return nil
+}
+
+// opaConfig returns the current OPA configuration, or nil if the OPA policy
+// evaluation is disabled.
+func (c *Core) opaConfig() *OPAConfig {
+	c.opaLock.RLock()
+	defer c.opaLock.RUnlock()
+	if c.opaEvaluator == nil {
+		return nil
+	}
+	return c.opaEvaluator.config
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadOPAConfig(ctx context.Context) error {
+	out, err := c.systemBarrierView.SubView("config/").Get(ctx, opaConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read OPA config: %w", err)
+	}
+
+	var evaluator *opaEvaluator
+	if out != nil {
+		conf := new(OPAConfig)
+		if err := out.DecodeJSON(conf); err != nil {
+			return fmt.Errorf("failed to decode OPA config: %w", err)
+		}
+		evaluator = newOPAEvaluator(conf)
+	}
+
+	c.opaLock.Lock()
+	c.opaEvaluator = evaluator
+	c.opaLock.Unlock()
+	return nil
+}
@@ -0,0 +1,213 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testOPAServer is an OPA Data API which denies deletes under secret/prod/
+// and records the inputs it receives.
+type testOPAServer struct {
+	l      sync.Mutex
+	inputs []opaInput
+	status int
+	result string
+}
+
+func (s *testOPAServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Input opaInput `json:"input"`
+	}
+	if r.URL.Path != "/v1/data/vault/authz" || json.NewDecoder(r.Body).Decode(&body) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.inputs = append(s.inputs, body.Input)
+
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	if s.result != "" {
+		w.Write([]byte(s.result))
+		return
+	}
+
+	allow := !(body.Input.Request.Operation == string(logical.DeleteOperation) && strings.HasPrefix(body.Input.Request.Path, "secret/prod/"))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"result": map[string]interface{}{
+			"allow":  allow,
+			"reason": "deletes of production secrets are not allowed",
+		},
+	})
+}
+
+func (s *testOPAServer) respondWith(status int, result string) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.status, s.result = status, result
+}
+
+// testCoreOPA returns a core which evaluates requests against a test OPA
+// server, along with the server and a token with a policy allowing access to
+// secret/.
+func testCoreOPA(t *testing.T, failOpen bool) (*Core, *testOPAServer, string) {
+	t.Helper()
+
+	c, _, root := TestCoreUnsealed(t)
+	server := &testOPAServer{}
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/config/opa")
+	req.ClientToken = root
+	req.Data["address"] = srv.URL
+	req.Data["decision_path"] = "/vault/authz/"
+	req.Data["fail_open"] = failOpen
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `path "secret/*" { capabilities = ["create", "read", "update", "delete"] }`)
+	require.NoError(t, err)
+	policy.Name = "secrets"
+	require.NoError(t, c.policyStore.SetPolicy(namespace.RootContext(nil), policy))
+	testMakeServiceTokenViaCore(t, c, root, "opatoken", "", []string{"secrets"})
+
+	return c, server, root
+}
+
+// TestOPA_Decision ensures requests are denied when the OPA decision denies
+// them, and that root tokens are not evaluated.
+func TestOPA_Decision(t *testing.T) {
+	c, server, root := testCoreOPA(t, false)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/prod/db")
+	req.ClientToken = "opatoken"
+	req.Data["password"] = "foo"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "secret/prod/db")
+	req.ClientToken = "opatoken"
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.ErrorContains(t, err, "denied by OPA policy: deletes of production secrets are not allowed")
+
+	server.l.Lock()
+	input := server.inputs[len(server.inputs)-1]
+	require.Equal(t, "secret/prod/db", input.Request.Path)
+	require.Equal(t, "delete", input.Request.Operation)
+	require.Equal(t, namespace.RootNamespaceID, input.Request.NamespaceID)
+	require.Equal(t, "secret/", input.Request.MountPath)
+	require.Equal(t, "kv", input.Request.MountType)
+	require.NotNil(t, input.Token)
+	require.Contains(t, input.Token.Policies, "secrets")
+	require.Equal(t, []string{"password"}, server.inputs[len(server.inputs)-2].Request.Parameters)
+	evaluations := len(server.inputs)
+	server.l.Unlock()
+
+	// Root tokens are never evaluated
+	req = logical.TestRequest(t, logical.DeleteOperation, "secret/prod/db")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// Requests denied by ACL policies are not evaluated
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/mounts")
+	req.ClientToken = "opatoken"
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	server.l.Lock()
+	require.Len(t, server.inputs, evaluations)
+	server.l.Unlock()
+}
+
+// TestOPA_Failures ensures undefined decisions deny requests, and evaluation
+// errors deny requests unless the configuration fails open.
+func TestOPA_Failures(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+
+	c, server, _ := testCoreOPA(t, false)
+	server.respondWith(0, `{}`)
+	req := logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = "opatoken"
+	_, err := c.HandleRequest(ctx, req)
+	require.ErrorContains(t, err, `decision "vault/authz" is undefined`)
+
+	server.respondWith(0, `{"result": true}`)
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	server.respondWith(http.StatusInternalServerError, "")
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorContains(t, err, "failed to evaluate OPA policy")
+
+	c, server, _ = testCoreOPA(t, true)
+	server.respondWith(http.StatusInternalServerError, "")
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+}
+
+// TestOPA_Config ensures the configuration is validated, persisted and can be
+// removed.
+func TestOPA_Config(t *testing.T) {
+	c, _, root := testCoreOPA(t, false)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/config/opa")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["enabled"])
+	require.Equal(t, "vault/authz", resp.Data["decision_path"])
+	require.Equal(t, int64(5), resp.Data["timeout"])
+	require.NotContains(t, resp.Data, "bearer_token")
+
+	// The configuration is loaded on unseal
+	conf := c.opaConfig()
+	require.NoError(t, c.loadOPAConfig(ctx))
+	require.Equal(t, conf, c.opaConfig())
+
+	for _, data := range []map[string]interface{}{
+		{"decision_path": "vault/authz"},
+		{"address": "tcp://127.0.0.1:8181", "decision_path": "vault/authz"},
+		{"address": "http://127.0.0.1:8181"},
+	} {
+		req = logical.TestRequest(t, logical.UpdateOperation, "sys/config/opa")
+		req.ClientToken = root
+		req.Data = data
+		_, err = c.HandleRequest(ctx, req)
+		require.ErrorIs(t, err, logical.ErrInvalidRequest)
+	}
+
+	// The configuration can only be changed with sudo
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/config/opa")
+	req.ClientToken = "opatoken"
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, c.opaConfig())
+
+	require.NoError(t, c.loadOPAConfig(ctx))
+	require.Nil(t, c.opaConfig())
+}
@@ -5,8 +5,11 @@ package vault
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +22,7 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -32,6 +36,10 @@ const (
 	// policyCacheSize is the number of policies that are kept cached
 	policyCacheSize = 1024
 
+	// aclCacheSize is the number of ACLs built from sets of token policies
+	// that are kept cached
+	aclCacheSize = 1024
+
 	// defaultPolicyName is the name of the default policy
 	defaultPolicyName = "default"
 
@@ -186,6 +194,14 @@ type PolicyStore struct {
 	tokenPoliciesLRU *lru.TwoQueueCache
 	egpLRU           *lru.TwoQueueCache
 
+	// aclLRU caches the ACLs built from sets of token policies, and is
+	// purged whenever an ACL or RGP policy changes. Each purge increments
+	// aclGeneration, so that an ACL built from policies read before a purge
+	// isn't cached after it.
+	aclLRU        *lru.TwoQueueCache
+	aclCacheLock  sync.Mutex
+	aclGeneration uint64
+
 	// This is used to ensure that writes to the store (acl/rgp) or to the egp
 	// path tree don't happen concurrently. We are okay reading stale data so
 	// long as there aren't concurrent writes.
@@ -227,6 +243,8 @@ func NewPolicyStore(ctx context.Context, core *Core, baseView *BarrierView, syst
 		ps.tokenPoliciesLRU = cache
 		cache, _ = lru.New2Q(policyCacheSize)
 		ps.egpLRU = cache
+		cache, _ = lru.New2Q(aclCacheSize)
+		ps.aclLRU = cache
 	}
 
 	aclView := ps.getACLView(namespace.RootNamespace)
@@ -315,6 +333,7 @@ func (ps *PolicyStore) invalidate(ctx context.Context, name string, policyType P
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		defer ps.purgeACLCache()
 
 	case PolicyTypeEGP:
 		if ps.egpLRU != nil {
@@ -410,6 +429,7 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Add(index, p)
 		}
+		ps.purgeACLCache()
 
 	case PolicyTypeRGP:
 		aclView := ps.getACLView(p.namespace)
@@ -432,6 +452,7 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Add(index, p)
 		}
+		ps.purgeACLCache()
 
 	case PolicyTypeEGP:
 		if err := ps.handleSentinelPolicy(ctx, p, view, entry); err != nil {
@@ -786,6 +807,7 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 			// Clear the cache
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		ps.purgeACLCache()
 
 		ps.policyTypeMap.Delete(index)
 
@@ -801,6 +823,7 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 			// Clear the cache
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		ps.purgeACLCache()
 
 		ps.policyTypeMap.Delete(index)
 
@@ -830,6 +853,16 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 // ACL is used to return an ACL which is built using the
 // named policies and pre-fetched policies if given.
 func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyNames map[string][]string, additionalPolicies ...*Policy) (*ACL, error) {
+	// Pre-fetched policies are specific to a token, so only the ACLs built
+	// from named policies alone are cached
+	cacheable := ps.aclLRU != nil && len(additionalPolicies) == 0
+	var generation uint64
+	if cacheable {
+		ps.aclCacheLock.Lock()
+		generation = ps.aclGeneration
+		ps.aclCacheLock.Unlock()
+	}
+
 	var allPolicies []*Policy
 
 	// Fetch the named policies
@@ -856,20 +889,37 @@ func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyN
 	// Append any pre-fetched policies that were given
 	allPolicies = append(allPolicies, additionalPolicies...)
 
-	var fetchedGroups bool
+	var templated bool
+	for _, policy := range allPolicies {
+		if policy.Type == PolicyTypeACL && policy.Templated {
+			templated = true
+			break
+		}
+	}
+
 	var groups []*identity.Group
+	if templated && entity != nil {
+		directGroups, inheritedGroups, err := ps.core.identityStore.groupsByEntityID(entity.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
+		}
+		groups = append(directGroups, inheritedGroups...)
+	}
+
+	var cacheKey string
+	if cacheable {
+		var err error
+		cacheKey, err = aclCacheKey(ctx, entity, groups, policyNames, templated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute ACL cache key: %w", err)
+		}
+		if raw, ok := ps.aclLRU.Get(cacheKey); ok {
+			return raw.(*ACL), nil
+		}
+	}
+
 	for i, policy := range allPolicies {
 		if policy.Type == PolicyTypeACL && policy.Templated {
-			if !fetchedGroups {
-				fetchedGroups = true
-				if entity != nil {
-					directGroups, inheritedGroups, err := ps.core.identityStore.groupsByEntityID(entity.ID)
-					if err != nil {
-						return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
-					}
-					groups = append(directGroups, inheritedGroups...)
-				}
-			}
 			p, err := parseACLPolicyWithTemplating(policy.namespace, policy.Raw, true, entity, groups)
 			if err != nil {
 				return nil, fmt.Errorf("error parsing templated policy %q: %w", policy.Name, err)
@@ -885,9 +935,71 @@ func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyN
 		return nil, fmt.Errorf("failed to construct ACL: %w", err)
 	}
 
+	if cacheable {
+		ps.aclCacheLock.Lock()
+		if ps.aclGeneration == generation {
+			ps.aclLRU.Add(cacheKey, acl)
+		}
+		ps.aclCacheLock.Unlock()
+	}
+
 	return acl, nil
 }
 
+// purgeACLCache clears the cached ACLs, as well as the ACLs being built from
+// policies which were read before.
+func (ps *PolicyStore) purgeACLCache() {
+	if ps.aclLRU == nil {
+		return
+	}
+
+	ps.aclCacheLock.Lock()
+	defer ps.aclCacheLock.Unlock()
+
+	ps.aclGeneration++
+	ps.aclLRU.Purge()
+}
+
+// aclCacheKey returns the key of the ACL built from the named policies in the
+// namespace of the context. The ACLs built from templated policies also depend
+// on the entity and its groups, a fingerprint of which is then added to the
+// key.
+func aclCacheKey(ctx context.Context, entity *identity.Entity, groups []*identity.Group, policyNames map[string][]string, templated bool) (string, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(policyNames))
+	for nsID, nsPolicyNames := range policyNames {
+		for _, name := range nsPolicyNames {
+			names = append(names, nsID+"/"+name)
+		}
+	}
+	sort.Strings(names)
+
+	key := fmt.Sprintf("%q %q", ns.ID, names)
+	if !templated || entity == nil {
+		return key, nil
+	}
+
+	entityBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(entity)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write(entityBytes)
+
+	groupVersions := make([]string, 0, len(groups))
+	for _, group := range groups {
+		groupVersions = append(groupVersions, fmt.Sprintf("%s:%d", group.ID, group.ModifyIndex))
+	}
+	sort.Strings(groupVersions)
+	fmt.Fprintf(hash, "%q", groupVersions)
+
+	return key + " " + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // loadACLPolicy is used to load default ACL policies. The default policies will
 // be loaded to all namespaces.
 func (ps *PolicyStore) loadACLPolicy(ctx context.Context, policyName, policyText string) error {
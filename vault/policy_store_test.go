@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/require"
@@ -280,6 +281,61 @@ func testPolicyStoreACL(t *testing.T, ps *PolicyStore, ns *namespace.Namespace)
 	testLayeredACL(t, acl, ns)
 }
 
+// TestPolicyStore_ACLCache checks that the ACLs built from the same set of
+// policies are cached until one of the policies changes, and that the ACLs
+// built from templated policies are cached per entity.
+func TestPolicyStore_ACLCache(t *testing.T) {
+	_, ps := mockPolicyWithCore(t, false)
+	ctx := namespace.RootContext(context.Background())
+
+	setPolicy := func(name, raw string) {
+		t.Helper()
+		policy, err := ParseACLPolicy(namespace.RootNamespace, raw)
+		require.NoError(t, err)
+		policy.Name = name
+		require.NoError(t, ps.SetPolicy(ctx, policy))
+	}
+	setPolicy("dev", `path "secret/dev/*" { capabilities = ["read"] }`)
+	setPolicy("ops", `path "secret/ops/*" { capabilities = ["read"] }`)
+
+	acl, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespaceID: {"dev", "ops"}})
+	require.NoError(t, err)
+	cached, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespaceID: {"ops", "dev"}})
+	require.NoError(t, err)
+	require.Same(t, acl, cached)
+
+	// Pre-fetched policies are not cached
+	inline, err := ParseACLPolicy(namespace.RootNamespace, `path "secret/inline" { capabilities = ["read"] }`)
+	require.NoError(t, err)
+	withInline, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespaceID: {"dev", "ops"}}, inline)
+	require.NoError(t, err)
+	require.NotSame(t, acl, withInline)
+	require.Equal(t, []string{"read"}, withInline.Capabilities(ctx, "secret/inline"))
+
+	// Writing a policy invalidates the cached ACLs
+	setPolicy("dev", `path "secret/dev/*" { capabilities = ["read", "update"] }`)
+	updated, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespaceID: {"dev", "ops"}})
+	require.NoError(t, err)
+	require.NotSame(t, acl, updated)
+	require.Equal(t, []string{"read", "update"}, updated.Capabilities(ctx, "secret/dev/foo"))
+
+	// Templated policies are cached per entity
+	setPolicy("templated", `path "secret/{{identity.entity.name}}/*" { capabilities = ["read"] }`)
+	policyNames := map[string][]string{namespace.RootNamespaceID: {"templated"}}
+	aliceACL, err := ps.ACL(ctx, &identity.Entity{ID: "alice-id", Name: "alice"}, policyNames)
+	require.NoError(t, err)
+	bobACL, err := ps.ACL(ctx, &identity.Entity{ID: "bob-id", Name: "bob"}, policyNames)
+	require.NoError(t, err)
+	require.NotSame(t, aliceACL, bobACL)
+	require.Equal(t, []string{"read"}, aliceACL.Capabilities(ctx, "secret/alice/foo"))
+	require.Equal(t, []string{"deny"}, aliceACL.Capabilities(ctx, "secret/bob/foo"))
+	require.Equal(t, []string{"read"}, bobACL.Capabilities(ctx, "secret/bob/foo"))
+
+	cached, err = ps.ACL(ctx, &identity.Entity{ID: "alice-id", Name: "alice"}, policyNames)
+	require.NoError(t, err)
+	require.Same(t, aliceACL, cached)
+}
+
 func TestDefaultPolicy(t *testing.T) {
 	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
 
@@ -7,7 +7,9 @@ package vault
 
 import (
 	"context"
+	"strings"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -17,18 +19,70 @@ type entPolicyStore struct{}
 func (ps *PolicyStore) extraInit() {
 }
 
-func (ps *PolicyStore) loadNamespacePolicies(context.Context, *Core) error { return nil }
+// loadNamespacePolicies populates the policy types of the ACL policies of the
+// namespaces other than root.
+func (ps *PolicyStore) loadNamespacePolicies(ctx context.Context, c *Core) error {
+	for _, ns := range c.ListNamespaces(true) {
+		if ns.ID == namespace.RootNamespaceID {
+			continue
+		}
+
+		keys, err := logical.CollectKeys(namespace.ContextWithNamespace(ctx, ns), ps.getACLView(ns))
+		if err != nil {
+			ps.logger.Error("error collecting acl policy keys", "namespace", ns.Path, "error", err)
+			return err
+		}
+		for _, key := range keys {
+			index := ps.cacheKey(ns, ps.sanitizeName(key))
+			ps.policyTypeMap.Store(index, PolicyTypeACL)
+		}
+	}
+	return nil
+}
 
-func (ps *PolicyStore) getACLView(*namespace.Namespace) *BarrierView {
-	return ps.aclView
+// invalidateNamespace drops the cached policies of a deleted namespace.
+func (ps *PolicyStore) invalidateNamespace(ns *namespace.Namespace) {
+	prefix := ns.ID + "/"
+	ps.policyTypeMap.Range(func(key, _ interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			ps.policyTypeMap.Delete(key)
+		}
+		return true
+	})
+
+	ps.modifyLock.Lock()
+	defer ps.modifyLock.Unlock()
+	for _, cache := range []*lru.TwoQueueCache{ps.tokenPoliciesLRU, ps.egpLRU} {
+		if cache == nil {
+			continue
+		}
+		for _, key := range cache.Keys() {
+			if strings.HasPrefix(key.(string), prefix) {
+				cache.Remove(key)
+			}
+		}
+	}
+}
+
+func (ps *PolicyStore) getACLView(ns *namespace.Namespace) *BarrierView {
+	if ns == nil || ns.ID == namespace.RootNamespaceID {
+		return ps.aclView
+	}
+	return ps.core.namespaceSystemView(ns).SubView(policyACLSubPath)
 }
 
 func (ps *PolicyStore) getRGPView(ns *namespace.Namespace) *BarrierView {
-	return ps.rgpView
+	if ns == nil || ns.ID == namespace.RootNamespaceID {
+		return ps.rgpView
+	}
+	return ps.core.namespaceSystemView(ns).SubView(policyRGPSubPath)
 }
 
 func (ps *PolicyStore) getEGPView(ns *namespace.Namespace) *BarrierView {
-	return ps.egpView
+	if ns == nil || ns.ID == namespace.RootNamespaceID {
+		return ps.egpView
+	}
+	return ps.core.namespaceSystemView(ns).SubView(policyEGPSubPath)
 }
 
 func (ps *PolicyStore) getBarrierView(ns *namespace.Namespace, _ PolicyType) *BarrierView {
@@ -46,5 +100,14 @@ func (ps *PolicyStore) invalidateEGPTreePath(string) {}
 func (ps *PolicyStore) pathsToEGPPaths(*Policy) ([]*egpPath, error) { return nil, nil }
 
 func (ps *PolicyStore) loadACLPolicyNamespaces(ctx context.Context, policyName, policyText string) error {
-	return ps.loadACLPolicyInternal(namespace.RootContext(ctx), policyName, policyText)
+	if ps.core == nil {
+		return ps.loadACLPolicyInternal(namespace.RootContext(ctx), policyName, policyText)
+	}
+
+	for _, ns := range ps.core.ListNamespaces(true) {
+		if err := ps.loadACLPolicyInternal(namespace.ContextWithNamespace(ctx, ns), policyName, policyText); err != nil {
+			return err
+		}
+	}
+	return nil
 }
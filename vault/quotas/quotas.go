@@ -141,6 +141,9 @@ var _ Access = (*access)(nil)
 // access implements the Access interface
 type access struct {
 	quotaID string
+
+	// release, if set, gives back what the quota reserved for the request
+	release func()
 }
 
 // QuotaID returns the identifier of the quota rule to which this access refers
@@ -271,6 +274,10 @@ type Request struct {
 	// ClientAddress is client unique addressable string (e.g. IP address). It can
 	// be empty if the quota type does not need it.
 	ClientAddress string
+
+	// EntityID is the identifier of the entity of the client token. It is only
+	// resolved for rate limit quotas that group requests by entity.
+	EntityID string
 }
 
 // NewManager creates and initializes a new quota manager to hold all the quota
@@ -648,6 +655,25 @@ func (m *Manager) QueryResolveRoleQuotas(req *Request) (bool, error) {
 	return false, nil
 }
 
+// QueryResolveEntityQuotas checks if the rate limit quota that applies to the
+// request groups requests by entity, which requires resolving the entity of
+// the client token.
+func (m *Manager) QueryResolveEntityQuotas(req *Request) (bool, error) {
+	quota, err := m.QueryQuota(&Request{
+		Type:          TypeRateLimit,
+		Path:          req.Path,
+		Role:          req.Role,
+		NamespacePath: req.NamespacePath,
+		MountPath:     req.MountPath,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	rlq, ok := quota.(*RateLimitQuota)
+	return ok && rlq.GroupBy == RateLimitGroupByEntity, nil
+}
+
 // DeleteQuota removes a quota rule from the db for a given name
 func (m *Manager) DeleteQuota(ctx context.Context, qType string, name string) error {
 	m.quotaLock.Lock()
@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/cryptoutil"
+)
+
+const (
+	// LeaseCountEnforcementReject rejects requests that would create leases
+	// once a lease count quota is reached.
+	LeaseCountEnforcementReject = "reject"
+
+	// LeaseCountEnforcementAlert allows requests once a lease count quota is
+	// reached, but logs a warning and emits a violation metric.
+	LeaseCountEnforcementAlert = "alert"
+)
+
+// Ensure that LeaseCountQuota implements the Quota interface
+var _ Quota = (*LeaseCountQuota)(nil)
+
+// LeaseCountQuota represents the quota rule properties that is used to limit the
+// number of active leases for a namespace, mount, path or role.
+type LeaseCountQuota struct {
+	// ID is the identifier of the quota
+	ID string `json:"id"`
+
+	// Type of quota this represents
+	Type Type `json:"type"`
+
+	// Name of the quota rule
+	Name string `json:"name"`
+
+	// NamespacePath is the path of the namespace to which this quota is
+	// applicable.
+	NamespacePath string `json:"namespace_path"`
+
+	// MountPath is the path of the mount to which this quota is applicable
+	MountPath string `json:"mount_path"`
+
+	// Role is the role on an auth mount to apply the quota to upon /login requests
+	// Not applicable for use with path suffixes
+	Role string `json:"role"`
+
+	// PathSuffix is the path suffix to which this quota is applicable
+	PathSuffix string `json:"path_suffix"`
+
+	// Inheritable indicates whether the quota will be inherited by child namespaces
+	Inheritable bool `json:"inheritable"`
+
+	// MaxLeases is the maximum number of active leases allowed by the quota.
+	MaxLeases int64 `json:"max_leases"`
+
+	// Enforcement defines whether requests are rejected or only alerted on
+	// once MaxLeases is reached.
+	Enforcement string `json:"enforcement"`
+
+	// leaseCount is the number of active leases the quota applies to
+	leaseCount atomic.Int64
+
+	// reserved is the number of allowed requests that have not completed yet,
+	// each of which may create a lease
+	reserved atomic.Int64
+
+	isPerfStandby bool
+	logger        log.Logger
+	metricSink    *metricsutil.ClusterMetricSink
+}
+
+// NewLeaseCountQuota creates a quota checker for imposing limits on the number
+// of active leases. An empty enforcement defaults to rejecting requests.
+func NewLeaseCountQuota(name, nsPath, mountPath, pathSuffix, role string, inheritable bool, maxLeases int64, enforcement string) *LeaseCountQuota {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		// Fall back to generating with a hash of the name, later in initialize
+		id = ""
+	}
+	return &LeaseCountQuota{
+		Name:          name,
+		ID:            id,
+		Type:          TypeLeaseCount,
+		NamespacePath: nsPath,
+		MountPath:     mountPath,
+		Role:          role,
+		PathSuffix:    pathSuffix,
+		Inheritable:   inheritable,
+		MaxLeases:     maxLeases,
+		Enforcement:   enforcement,
+	}
+}
+
+func (q *LeaseCountQuota) Clone() Quota {
+	return &LeaseCountQuota{
+		ID:            q.ID,
+		Name:          q.Name,
+		MountPath:     q.MountPath,
+		Role:          q.Role,
+		Inheritable:   q.Inheritable,
+		Type:          q.Type,
+		NamespacePath: q.NamespacePath,
+		PathSuffix:    q.PathSuffix,
+		MaxLeases:     q.MaxLeases,
+		Enforcement:   q.Enforcement,
+	}
+}
+
+func (q *LeaseCountQuota) IsInheritable() bool {
+	return q.Inheritable
+}
+
+// initialize ensures the namespace, max leases and enforcement are valid, and
+// sets the ID if it's currently empty. The lease count is computed by the quota
+// manager.
+func (q *LeaseCountQuota) initialize(logger log.Logger, ms *metricsutil.ClusterMetricSink) error {
+	// Memdb requires a non-empty value for indexing
+	if q.NamespacePath == "" {
+		q.NamespacePath = "root"
+	}
+
+	if q.MaxLeases <= 0 {
+		return fmt.Errorf("invalid max leases: %v", q.MaxLeases)
+	}
+
+	switch q.Enforcement {
+	case "":
+		q.Enforcement = LeaseCountEnforcementReject
+	case LeaseCountEnforcementReject, LeaseCountEnforcementAlert:
+	default:
+		return fmt.Errorf("invalid enforcement: %q", q.Enforcement)
+	}
+
+	if logger != nil {
+		q.logger = logger
+	}
+
+	if q.metricSink == nil {
+		q.metricSink = ms
+	}
+
+	if q.ID == "" {
+		// Generate a deterministic ID so that performance standby nodes
+		// initializing their copy of the quota agree on it.
+		q.ID = hex.EncodeToString(cryptoutil.Blake2b256Hash(q.Name))
+	}
+
+	return nil
+}
+
+// quotaID returns the identifier of the quota rule
+func (q *LeaseCountQuota) quotaID() string {
+	return q.ID
+}
+
+// QuotaName returns the name of the quota rule
+func (q *LeaseCountQuota) QuotaName() string {
+	return q.Name
+}
+
+// LeaseCount returns the number of active leases the quota applies to.
+func (q *LeaseCountQuota) LeaseCount() int64 {
+	return q.leaseCount.Load()
+}
+
+// allow decides if the request is allowed by the quota. Allowed requests
+// reserve a lease until they complete, so that concurrent requests cannot
+// exceed the quota together. The reservation is released through the access
+// handle of the response.
+func (q *LeaseCountQuota) allow(_ context.Context, req *Request) (Response, error) {
+	var resp Response
+
+	// Leases are created and counted on the active node
+	if q.isPerfStandby {
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	reserved := q.reserved.Add(1)
+	resp.Access = &access{
+		quotaID: q.ID,
+		release: func() { q.reserved.Add(-1) },
+	}
+	if q.leaseCount.Load()+reserved <= q.MaxLeases {
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	q.metricSink.IncrCounterWithLabels([]string{"quota", "lease_count", "violation"}, 1, []metrics.Label{{Name: "name", Value: q.Name}})
+
+	if q.Enforcement == LeaseCountEnforcementAlert {
+		if q.logger != nil {
+			q.logger.Warn("lease count quota exceeded", "name", q.Name, "max_leases", q.MaxLeases, "request_path", req.Path)
+		}
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	q.reserved.Add(-1)
+	resp.Access = nil
+	return resp, nil
+}
+
+// addLeases adjusts the number of active leases, never going below zero.
+func (q *LeaseCountQuota) addLeases(delta int64) {
+	for {
+		count := q.leaseCount.Load()
+		updated := count + delta
+		if updated < 0 {
+			updated = 0
+		}
+		if q.leaseCount.CompareAndSwap(count, updated) {
+			return
+		}
+	}
+}
+
+// close is a no-op as lease count quotas have no background routines.
+func (q *LeaseCountQuota) close(_ context.Context) error {
+	return nil
+}
+
+func (q *LeaseCountQuota) handleRemount(mountpath, nspath string) {
+	q.MountPath = mountpath
+	q.NamespacePath = nspath
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseCountQuota_Initialize(t *testing.T) {
+	q := NewLeaseCountQuota("test", "", "", "", "", true, 0, "")
+	require.Error(t, q.initialize(nil, metricsutil.BlackholeSink()))
+
+	q = NewLeaseCountQuota("test", "", "", "", "", true, 10, "bogus")
+	require.Error(t, q.initialize(nil, metricsutil.BlackholeSink()))
+
+	q = NewLeaseCountQuota("test", "", "", "", "", true, 10, "")
+	require.NoError(t, q.initialize(nil, metricsutil.BlackholeSink()))
+	require.Equal(t, LeaseCountEnforcementReject, q.Enforcement)
+	require.Equal(t, "root", q.NamespacePath)
+}
+
+func TestLeaseCountQuota_Allow(t *testing.T) {
+	ctx := context.Background()
+
+	// Two existing leases on the kv mount and one on another mount
+	leases := []*Request{
+		{Path: "kv/creds/foo", MountPath: "kv/", NamespacePath: "root"},
+		{Path: "kv/creds/foo", MountPath: "kv/", NamespacePath: "root"},
+		{Path: "other/creds/foo", MountPath: "other/", NamespacePath: "root"},
+	}
+	walkFunc := func(_ context.Context, cb func(*Request) bool) error {
+		for _, lease := range leases {
+			if !cb(&Request{Path: lease.Path, MountPath: lease.MountPath, NamespacePath: lease.NamespacePath}) {
+				break
+			}
+		}
+		return nil
+	}
+
+	qm, err := NewManager(logging.NewVaultLogger(log.Trace), walkFunc, metricsutil.BlackholeSink())
+	require.NoError(t, err)
+
+	quota := NewLeaseCountQuota("lcq", "", "kv/", "", "", false, 3, LeaseCountEnforcementReject)
+	require.NoError(t, qm.SetQuota(ctx, TypeLeaseCount.String(), quota, false))
+
+	raw, err := qm.QuotaByName(TypeLeaseCount.String(), "lcq")
+	require.NoError(t, err)
+	quota = raw.(*LeaseCountQuota)
+	require.Equal(t, int64(2), quota.LeaseCount())
+
+	apply := func(path string) Response {
+		t.Helper()
+		resp, err := qm.ApplyQuota(ctx, &Request{
+			Type:          TypeLeaseCount,
+			Path:          path,
+			MountPath:     "kv/",
+			NamespacePath: "root",
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Paths that never created leases are not restricted
+	for i := 0; i < 5; i++ {
+		require.True(t, apply("kv/config").Allowed)
+	}
+
+	// The first request reserves the last lease, so that a concurrent request
+	// is rejected
+	first := apply("kv/creds/foo")
+	require.True(t, first.Allowed)
+	require.False(t, apply("kv/creds/foo").Allowed)
+
+	// Releasing the reservation without creating a lease allows requests again
+	qm.AckLeaseCountQuota(first.Access)
+	second := apply("kv/creds/foo")
+	require.True(t, second.Allowed)
+
+	// Once the request created its lease, the quota is reached
+	require.NoError(t, qm.HandleLeaseActions(LeaseActionCreated, []*Request{{Path: "kv/creds/foo", MountPath: "kv/", NamespacePath: "root"}}))
+	qm.AckLeaseCountQuota(second.Access)
+	require.Equal(t, int64(3), quota.LeaseCount())
+	require.False(t, apply("kv/creds/foo").Allowed)
+
+	// Revoking a lease frees up room
+	require.NoError(t, qm.HandleLeaseActions(LeaseActionDeleted, []*Request{{Path: "kv/creds/foo", MountPath: "kv/", NamespacePath: "root"}}))
+	require.Equal(t, int64(2), quota.LeaseCount())
+	resp := apply("kv/creds/foo")
+	require.True(t, resp.Allowed)
+	qm.AckLeaseCountQuota(resp.Access)
+}
+
+func TestLeaseCountQuota_Alert(t *testing.T) {
+	ctx := context.Background()
+
+	qm, err := NewManager(logging.NewVaultLogger(log.Trace), nil, metricsutil.BlackholeSink())
+	require.NoError(t, err)
+
+	quota := NewLeaseCountQuota("lcq", "", "kv/", "", "", false, 1, LeaseCountEnforcementAlert)
+	require.NoError(t, qm.SetQuota(ctx, TypeLeaseCount.String(), quota, false))
+	require.NoError(t, qm.HandleLeaseActions(LeaseActionCreated, []*Request{{Path: "kv/creds/foo", MountPath: "kv/", NamespacePath: "root"}}))
+
+	// The quota is exceeded, but only alerted on
+	resp, err := qm.ApplyQuota(ctx, &Request{
+		Type:          TypeLeaseCount,
+		Path:          "kv/creds/foo",
+		MountPath:     "kv/",
+		NamespacePath: "root",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	qm.AckLeaseCountQuota(resp.Access)
+}
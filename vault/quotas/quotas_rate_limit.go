@@ -33,6 +33,20 @@ const (
 	// EnvVaultEnableRateLimitAuditLogging is used to enable audit logging of
 	// requests that get rejected due to rate limit quota violations.
 	EnvVaultEnableRateLimitAuditLogging = "VAULT_ENABLE_RATE_LIMIT_AUDIT_LOGGING"
+
+	// RateLimitGroupByIP applies the rate limit to each client IP address.
+	RateLimitGroupByIP = "ip"
+
+	// RateLimitGroupByEntity applies the rate limit to each entity, falling
+	// back to the client IP address for requests without an entity.
+	RateLimitGroupByEntity = "entity"
+
+	// RateLimitGroupByNamespace applies the rate limit to each namespace the
+	// requests are made to.
+	RateLimitGroupByNamespace = "namespace"
+
+	// RateLimitGroupByNone applies the rate limit to all the requests together.
+	RateLimitGroupByNone = "none"
 )
 
 // Ensure that RateLimitQuota implements the Quota interface
@@ -78,6 +92,15 @@ type RateLimitQuota struct {
 	// reaches the rate limit.
 	BlockInterval time.Duration `json:"block_interval"`
 
+	// GroupBy defines how requests are grouped into separate rate limits, e.g.
+	// per client IP address or per entity.
+	GroupBy string `json:"group_by"`
+
+	// Burst defines the number of requests a client can make at once. Requests
+	// are still allowed at Rate per Interval on average. A zero value allows
+	// bursts of Rate requests.
+	Burst int `json:"burst"`
+
 	lock                *sync.RWMutex
 	store               limiter.Store
 	logger              log.Logger
@@ -95,6 +118,13 @@ type RateLimitQuota struct {
 // duration may be provided, where if set, when a client reaches the rate limit,
 // subsequent requests will fail until the block duration has passed.
 func NewRateLimitQuota(name, nsPath, mountPath, pathSuffix, role string, inheritable bool, interval, block time.Duration, rate float64) *RateLimitQuota {
+	return NewRateLimitQuotaWithGroupBy(name, nsPath, mountPath, pathSuffix, role, inheritable, interval, block, rate, RateLimitGroupByIP, 0)
+}
+
+// NewRateLimitQuotaWithGroupBy creates a rate limit quota like
+// NewRateLimitQuota, grouping requests by the given dimension and allowing
+// bursts of the given size.
+func NewRateLimitQuotaWithGroupBy(name, nsPath, mountPath, pathSuffix, role string, inheritable bool, interval, block time.Duration, rate float64, groupBy string, burst int) *RateLimitQuota {
 	id, err := uuid.GenerateUUID()
 	if err != nil {
 		// Fall back to generating with a hash of the name, later in initialize
@@ -112,6 +142,8 @@ func NewRateLimitQuota(name, nsPath, mountPath, pathSuffix, role string, inherit
 		Rate:          rate,
 		Interval:      interval,
 		BlockInterval: block,
+		GroupBy:       groupBy,
+		Burst:         burst,
 		purgeInterval: DefaultRateLimitPurgeInterval,
 		staleAge:      DefaultRateLimitStaleAge,
 	}
@@ -130,6 +162,8 @@ func (q *RateLimitQuota) Clone() Quota {
 		BlockInterval: q.BlockInterval,
 		Rate:          q.Rate,
 		Interval:      q.Interval,
+		GroupBy:       q.GroupBy,
+		Burst:         q.Burst,
 	}
 	return rlq
 }
@@ -167,6 +201,18 @@ func (rlq *RateLimitQuota) initialize(logger log.Logger, ms *metricsutil.Cluster
 		return fmt.Errorf("invalid block interval: %v", rlq.BlockInterval)
 	}
 
+	switch rlq.GroupBy {
+	case "":
+		rlq.GroupBy = RateLimitGroupByIP
+	case RateLimitGroupByIP, RateLimitGroupByEntity, RateLimitGroupByNamespace, RateLimitGroupByNone:
+	default:
+		return fmt.Errorf("invalid group by: %q", rlq.GroupBy)
+	}
+
+	if rlq.Burst < 0 {
+		return fmt.Errorf("invalid burst: %v", rlq.Burst)
+	}
+
 	if logger != nil {
 		rlq.logger = logger
 	}
@@ -196,11 +242,21 @@ func (rlq *RateLimitQuota) initialize(logger log.Logger, ms *metricsutil.Cluster
 		rlq.staleAge = DefaultRateLimitStaleAge
 	}
 
+	// allow 'rlq.Rate' number of requests per 'Interval'
+	tokens := uint64(math.Round(rlq.Rate))
+	interval := rlq.Interval
+	if rlq.Burst > 0 {
+		// Allow 'rlq.Burst' requests at once, refilled over the time it takes
+		// to make as many requests at 'rlq.Rate' per 'Interval'
+		tokens = uint64(rlq.Burst)
+		interval = time.Duration(float64(rlq.Interval) * float64(rlq.Burst) / rlq.Rate)
+	}
+
 	rlStore, err := memorystore.New(&memorystore.Config{
-		Tokens:        uint64(math.Round(rlq.Rate)), // allow 'rlq.Rate' number of requests per 'Interval'
-		Interval:      rlq.Interval,                 // time interval in which to enforce rate limiting
-		SweepInterval: rlq.purgeInterval,            // how often stale clients are removed
-		SweepMinTTL:   rlq.staleAge,                 // how long since the last request a client is considered stale
+		Tokens:        tokens,            // number of requests allowed per interval
+		Interval:      interval,          // time interval in which to enforce rate limiting
+		SweepInterval: rlq.purgeInterval, // how often stale clients are removed
+		SweepMinTTL:   rlq.staleAge,      // how long since the last request a client is considered stale
 	})
 	if err != nil {
 		return err
@@ -287,17 +343,39 @@ func (rlq *RateLimitQuota) QuotaName() string {
 	return rlq.Name
 }
 
+// clientKey returns the key of the rate limiter the request counts towards,
+// depending on how the quota groups requests.
+func (rlq *RateLimitQuota) clientKey(req *Request) (string, error) {
+	switch rlq.GroupBy {
+	case RateLimitGroupByNone:
+		return "", nil
+	case RateLimitGroupByNamespace:
+		return "namespace:" + req.NamespacePath, nil
+	case RateLimitGroupByEntity:
+		if req.EntityID != "" {
+			return "entity:" + req.EntityID, nil
+		}
+	}
+
+	if req.ClientAddress == "" {
+		return "", fmt.Errorf("missing request client address in quota request")
+	}
+	return req.ClientAddress, nil
+}
+
 // allow decides if the request is allowed by the quota. An error will be
-// returned if the request ID or address is empty. If the path is exempt, the
-// quota will not be evaluated. Otherwise, the client rate limiter is retrieved
-// by address and the rate limit quota is checked against that limiter.
+// returned if the request is grouped by address and the address is empty.
+// If the path is exempt, the quota will not be evaluated. Otherwise, the client
+// rate limiter is retrieved by the group of the request and the rate limit
+// quota is checked against that limiter.
 func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, error) {
 	resp := Response{
 		Headers: make(map[string]string),
 	}
 
-	if req.ClientAddress == "" {
-		return resp, fmt.Errorf("missing request client address in quota request")
+	key, err := rlq.clientKey(req)
+	if err != nil {
+		return resp, err
 	}
 
 	var retryAfter string
@@ -305,7 +383,7 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 	defer func() {
 		if !resp.Allowed {
 			resp.Headers[httplimit.HeaderRetryAfter] = retryAfter
-			rlq.metricSink.IncrCounterWithLabels([]string{"quota", "rate_limit", "violation"}, 1, []metrics.Label{{"name", rlq.Name}})
+			rlq.metricSink.IncrCounterWithLabels([]string{"quota", "rate_limit", "violation"}, 1, []metrics.Label{{Name: "name", Value: rlq.Name}})
 		}
 	}()
 
@@ -314,11 +392,11 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 	// of purging blocked clients may not yield a false negative. In other words,
 	// a client may no longer be considered blocked whereas the purging interval
 	// has yet to run.
-	if v, ok := rlq.blockedClients.Load(req.ClientAddress); ok {
+	if v, ok := rlq.blockedClients.Load(key); ok {
 		blockedAt := v.(time.Time)
 		if time.Since(blockedAt) >= rlq.BlockInterval {
 			// allow the request and remove the blocked client
-			rlq.blockedClients.Delete(req.ClientAddress)
+			rlq.blockedClients.Delete(key)
 		} else {
 			// deny the request and return early
 			resp.Allowed = false
@@ -327,7 +405,7 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 		}
 	}
 
-	limit, remaining, reset, allow, err := rlq.store.Take(ctx, req.ClientAddress)
+	limit, remaining, reset, allow, err := rlq.store.Take(ctx, key)
 	if err != nil {
 		return resp, err
 	}
@@ -343,7 +421,7 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 	if !resp.Allowed && rlq.purgeBlocked {
 		blockedAt := time.Now()
 		retryAfter = strconv.Itoa(int(time.Until(blockedAt.Add(rlq.BlockInterval)).Seconds()))
-		rlq.blockedClients.Store(req.ClientAddress, blockedAt)
+		rlq.blockedClients.Store(key, blockedAt)
 	}
 
 	return resp, nil
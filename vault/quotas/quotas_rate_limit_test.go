@@ -227,3 +227,76 @@ func TestRateLimitQuota_Update(t *testing.T) {
 
 	require.Nil(t, quota.close(context.Background()))
 }
+
+func TestRateLimitQuota_GroupBy(t *testing.T) {
+	testCases := []struct {
+		groupBy string
+		reqs    []*Request
+		// allowed is the number of requests allowed out of 3 per request, with
+		// a rate of 2 shared within each group
+		allowed int
+	}{
+		{
+			groupBy: RateLimitGroupByIP,
+			reqs:    []*Request{{ClientAddress: "127.0.0.1"}, {ClientAddress: "127.0.0.2"}},
+			allowed: 4,
+		},
+		{
+			groupBy: RateLimitGroupByNone,
+			reqs:    []*Request{{ClientAddress: "127.0.0.1"}, {ClientAddress: "127.0.0.2"}},
+			allowed: 2,
+		},
+		{
+			groupBy: RateLimitGroupByEntity,
+			reqs:    []*Request{{ClientAddress: "127.0.0.1", EntityID: "foo"}, {ClientAddress: "127.0.0.2", EntityID: "foo"}, {ClientAddress: "127.0.0.3"}},
+			allowed: 4,
+		},
+		{
+			groupBy: RateLimitGroupByNamespace,
+			reqs:    []*Request{{ClientAddress: "127.0.0.1", NamespacePath: "ns1/"}, {ClientAddress: "127.0.0.2", NamespacePath: "ns1/"}, {ClientAddress: "127.0.0.3", NamespacePath: "ns2/"}},
+			allowed: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.groupBy, func(t *testing.T) {
+			rlq := NewRateLimitQuotaWithGroupBy("test-rate-limiter", "", "", "", "", true, time.Hour, 0, 2, tc.groupBy, 0)
+			require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+			defer rlq.close(context.Background())
+
+			var allowed int
+			for _, req := range tc.reqs {
+				for i := 0; i < 3; i++ {
+					resp, err := rlq.allow(context.Background(), req)
+					require.NoError(t, err)
+					if resp.Allowed {
+						allowed++
+					}
+				}
+			}
+			require.Equal(t, tc.allowed, allowed)
+		})
+	}
+
+	rlq := NewRateLimitQuotaWithGroupBy("test-rate-limiter", "", "", "", "", true, time.Hour, 0, 2, "bogus", 0)
+	require.Error(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+}
+
+func TestRateLimitQuota_Burst(t *testing.T) {
+	rlq := NewRateLimitQuotaWithGroupBy("test-rate-limiter", "", "", "", "", true, time.Hour, 0, 2, RateLimitGroupByIP, 5)
+	require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+	defer rlq.close(context.Background())
+
+	var allowed int
+	for i := 0; i < 10; i++ {
+		resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+		require.NoError(t, err)
+		if resp.Allowed {
+			allowed++
+			require.Equal(t, "5", resp.Headers["X-RateLimit-Limit"])
+		}
+	}
+	require.Equal(t, 5, allowed)
+}
@@ -7,70 +7,143 @@ package quotas
 
 import (
 	"context"
-
-	log "github.com/hashicorp/go-hclog"
-	"github.com/hashicorp/vault/helper/metricsutil"
+	"sync"
 
 	"github.com/hashicorp/go-memdb"
 )
 
 func quotaTypes() []string {
 	return []string{
+		TypeLeaseCount.String(),
 		TypeRateLimit.String(),
 	}
 }
 
-func (m *Manager) init(walkFunc leaseWalkFunc) {}
+func (m *Manager) init(walkFunc leaseWalkFunc) {
+	m.leaseWalkFunc = walkFunc
+	m.leasePaths = make(map[string]struct{})
+}
 
+// recomputeLeaseCounts resets the lease counts of all the lease count quotas in
+// the transaction and counts the active leases again. It is needed whenever the
+// set of lease count quotas changes, since that changes which quota each lease
+// counts towards.
 func (m *Manager) recomputeLeaseCounts(ctx context.Context, txn *memdb.Txn) error {
-	return nil
-}
+	iter, err := txn.Get(TypeLeaseCount.String(), indexID)
+	if err != nil {
+		return err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		raw.(*LeaseCountQuota).leaseCount.Store(0)
+	}
 
-func (m *Manager) setIsPerfStandby(quota Quota) {}
+	if m.leaseWalkFunc == nil {
+		return nil
+	}
 
-func (m *Manager) inLeasePathCache(path string) bool {
-	return false
+	var queryErr error
+	err = m.leaseWalkFunc(ctx, func(req *Request) bool {
+		m.addLeasePath(req.Path)
+
+		req.Type = TypeLeaseCount
+		quota, err := m.queryQuota(txn, req)
+		if err != nil {
+			queryErr = err
+			return false
+		}
+		if quota != nil {
+			quota.(*LeaseCountQuota).addLeases(1)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return queryErr
 }
 
-type entManager struct {
-	isPerfStandby bool
-	isDRSecondary bool
-}
+// HandleLeaseActions updates the lease counts of the lease count quotas that
+// apply to the given leases, as reported by the expiration manager. Each
+// request describes the request path, mount, namespace and login role of a
+// lease.
+func (m *Manager) HandleLeaseActions(action LeaseAction, reqs []*Request) error {
+	m.dbAndCacheLock.RLock()
+	defer m.dbAndCacheLock.RUnlock()
+
+	var delta int64
+	switch action {
+	case LeaseActionCreated, LeaseActionLoaded:
+		delta = 1
+	case LeaseActionDeleted:
+		delta = -1
+	default:
+		return nil
+	}
 
-func (*entManager) Reset() error {
+	for _, req := range reqs {
+		if delta > 0 {
+			m.addLeasePath(req.Path)
+		}
+
+		req.Type = TypeLeaseCount
+		quota, err := m.queryQuota(nil, req)
+		if err != nil {
+			return err
+		}
+		if quota != nil {
+			quota.(*LeaseCountQuota).addLeases(delta)
+		}
+	}
 	return nil
 }
 
-type LeaseCountQuota struct{}
-
-func (l LeaseCountQuota) IsInheritable() bool {
-	panic("implement me")
+// AckLeaseCountQuota releases the lease reserved when a lease count quota
+// allowed a request. Any lease the request created has been counted by then.
+func (m *Manager) AckLeaseCountQuota(a Access) {
+	if a, ok := a.(*access); ok && a.release != nil {
+		a.release()
+	}
 }
 
-func (l LeaseCountQuota) allow(_ context.Context, _ *Request) (Response, error) {
-	panic("implement me")
+func (m *Manager) setIsPerfStandby(quota Quota) {
+	if lcq, ok := quota.(*LeaseCountQuota); ok {
+		lcq.isPerfStandby = m.isPerfStandby
+	}
 }
 
-func (l LeaseCountQuota) quotaID() string {
-	panic("implement me")
-}
+// inLeasePathCache reports whether the given request path is known to create
+// leases. Requests to other paths are not subject to lease count quotas.
+func (m *Manager) inLeasePathCache(path string) bool {
+	m.leasePathsLock.RLock()
+	defer m.leasePathsLock.RUnlock()
 
-func (l LeaseCountQuota) QuotaName() string {
-	panic("implement me")
+	_, ok := m.leasePaths[path]
+	return ok
 }
 
-func (l LeaseCountQuota) initialize(logger log.Logger, sink *metricsutil.ClusterMetricSink) error {
-	panic("implement me")
-}
+func (m *Manager) addLeasePath(path string) {
+	m.leasePathsLock.Lock()
+	defer m.leasePathsLock.Unlock()
 
-func (l LeaseCountQuota) close(_ context.Context) error {
-	panic("implement me")
+	m.leasePaths[path] = struct{}{}
 }
 
-func (l LeaseCountQuota) Clone() Quota {
-	panic("implement me")
+type entManager struct {
+	isPerfStandby bool
+	isDRSecondary bool
+
+	// leaseWalkFunc walks the active leases to compute lease counts
+	leaseWalkFunc leaseWalkFunc
+
+	// leasePaths holds the request paths known to create leases
+	leasePathsLock sync.RWMutex
+	leasePaths     map[string]struct{}
 }
 
-func (l LeaseCountQuota) handleRemount(mountPath, nsPath string) {
-	panic("implement me")
+func (e *entManager) Reset() error {
+	e.leasePathsLock.Lock()
+	defer e.leasePathsLock.Unlock()
+
+	e.leasePaths = make(map[string]struct{})
+	return nil
 }
@@ -49,6 +49,7 @@ var (
 	raftTLSRotationPeriod = 24 * time.Hour
 
 	raftAutopilotConfigurationStoragePath = "core/raft/autopilot/configuration"
+	raftSuffrageOverridesStoragePath      = "core/raft/suffrage-overrides"
 
 	// TestingUpdateClusterAddr is used in tests to override the cluster address
 	TestingUpdateClusterAddr uint32
@@ -316,6 +317,12 @@ func (c *Core) setupRaftActiveNode(ctx context.Context) error {
 	}
 	disableAutopilot := c.disableAutopilot
 
+	overrides, err := c.loadRaftSuffrageOverrides(ctx)
+	if err != nil {
+		return err
+	}
+	raftBackend.SetSuffrageOverrides(overrides)
+
 	raftBackend.SetupAutopilot(c.activeContext, autopilotConfig, c.raftFollowerStates, disableAutopilot)
 
 	c.pendingRaftPeers = &sync.Map{}
@@ -330,6 +337,10 @@ func (c *Core) setupRaftActiveNode(ctx context.Context) error {
 	if err := c.monitorUndoLogs(); err != nil {
 		return err
 	}
+
+	if err := c.startRaftAutoSnapshots(c.activeContext); err != nil {
+		return err
+	}
 	return c.startPeriodicRaftTLSRotate(ctx)
 }
 
@@ -346,6 +357,7 @@ func (c *Core) stopRaftActiveNode() {
 	}
 
 	c.pendingRaftPeers = nil
+	c.stopRaftAutoSnapshots()
 	c.stopPeriodicRaftTLSRotate()
 }
 
@@ -1371,6 +1383,61 @@ func (c *Core) loadAutopilotConfiguration(ctx context.Context) (*raft.AutopilotC
 	return autopilotConfig, nil
 }
 
+// loadRaftSuffrageOverrides returns the suffrage operators assigned to peers
+// via the promote and demote APIs, keyed by node ID.
+func (c *Core) loadRaftSuffrageOverrides(ctx context.Context) (map[string]string, error) {
+	entry, err := c.barrier.Get(ctx, raftSuffrageOverridesStoragePath)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]string)
+	if entry == nil {
+		return overrides, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, &overrides); err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+// setRaftSuffrageOverride persists the suffrage of the given peer and hands the
+// overrides to the raft backend. An empty suffrage removes the override.
+func (c *Core) setRaftSuffrageOverride(ctx context.Context, raftBackend *raft.RaftBackend, serverID, suffrage string) error {
+	c.raftSuffrageLock.Lock()
+	defer c.raftSuffrageLock.Unlock()
+
+	overrides, err := c.loadRaftSuffrageOverrides(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current, ok := overrides[serverID]; ok && current == suffrage {
+		return nil
+	}
+	if suffrage == "" {
+		if _, ok := overrides[serverID]; !ok {
+			return nil
+		}
+		delete(overrides, serverID)
+	} else {
+		overrides[serverID] = suffrage
+	}
+
+	entry, err := logical.StorageEntryJSON(raftSuffrageOverridesStoragePath, overrides)
+	if err != nil {
+		return err
+	}
+	if err := c.barrier.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	raftBackend.SetSuffrageOverrides(overrides)
+	return nil
+}
+
 // RaftBootstrap performs bootstrapping of a raft cluster if core contains a raft
 // backend. If raft is not part for the storage or HA storage backend, this
 // call results in an error.
@@ -0,0 +1,289 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/autosnapshots"
+)
+
+// raftAutoSnapshotConfigPrefix is the barrier prefix named automated snapshot
+// configurations are stored under.
+const raftAutoSnapshotConfigPrefix = "core/raft/snapshot-auto/config/"
+
+// raftAutoSnapshotStatus describes the outcome of the snapshots taken for a
+// configuration. The last_snapshot_* fields describe the most recent attempt,
+// the others the most recent successful snapshot.
+type raftAutoSnapshotStatus struct {
+	SnapshotStart     time.Time
+	SnapshotURL       string
+	LastSnapshotStart time.Time
+	LastSnapshotEnd   time.Time
+	LastSnapshotURL   string
+	LastSnapshotError string
+}
+
+// raftAutoSnapshotManager runs the automated snapshot configurations on the
+// active node.
+type raftAutoSnapshotManager struct {
+	core   *Core
+	logger hclog.Logger
+	ctx    context.Context
+
+	l       sync.Mutex
+	runners map[string]*raftAutoSnapshotRunner
+}
+
+// raftAutoSnapshotRunner takes the snapshots of a single configuration.
+type raftAutoSnapshotRunner struct {
+	name   string
+	config *autosnapshots.Config
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	statusLock sync.RWMutex
+	status     raftAutoSnapshotStatus
+}
+
+// startRaftAutoSnapshots loads the automated snapshot configurations and
+// starts taking snapshots for each of them. It is a no-op unless raft is the
+// storage backend.
+func (c *Core) startRaftAutoSnapshots(ctx context.Context) error {
+	if c.getRaftBackend() == nil || c.isRaftHAOnly() {
+		return nil
+	}
+
+	m := &raftAutoSnapshotManager{
+		core:    c,
+		logger:  c.logger.Named("raft").Named("snapshot-auto"),
+		ctx:     ctx,
+		runners: make(map[string]*raftAutoSnapshotRunner),
+	}
+
+	names, err := c.barrier.List(ctx, raftAutoSnapshotConfigPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list automated snapshot configs: %w", err)
+	}
+	for _, name := range names {
+		config, err := c.raftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			return err
+		}
+		if config != nil {
+			m.reload(name, config)
+		}
+	}
+
+	c.raftAutoSnapshots = m
+	return nil
+}
+
+// stopRaftAutoSnapshots stops all the automated snapshot runners, waiting for
+// in-flight snapshots to finish.
+func (c *Core) stopRaftAutoSnapshots() {
+	m := c.raftAutoSnapshots
+	if m == nil {
+		return
+	}
+	c.raftAutoSnapshots = nil
+
+	m.l.Lock()
+	defer m.l.Unlock()
+	for name, r := range m.runners {
+		r.stop()
+		delete(m.runners, name)
+	}
+}
+
+func (c *Core) raftAutoSnapshotConfig(ctx context.Context, name string) (*autosnapshots.Config, error) {
+	entry, err := c.barrier.Get(ctx, raftAutoSnapshotConfigPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automated snapshot config %q: %w", name, err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config autosnapshots.Config
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode automated snapshot config %q: %w", name, err)
+	}
+	return &config, nil
+}
+
+// reload replaces the runner of the named configuration. A nil config only
+// stops the current runner.
+func (m *raftAutoSnapshotManager) reload(name string, config *autosnapshots.Config) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	var status raftAutoSnapshotStatus
+	if r, ok := m.runners[name]; ok {
+		r.stop()
+		status = r.getStatus()
+		delete(m.runners, name)
+	}
+	if config == nil {
+		return
+	}
+
+	r := &raftAutoSnapshotRunner{
+		name:   name,
+		config: config,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+		status: status,
+	}
+	m.runners[name] = r
+	go m.run(r)
+}
+
+// status returns the status of the named configuration, or nil if it is not
+// running.
+func (m *raftAutoSnapshotManager) status(name string) *raftAutoSnapshotStatus {
+	m.l.Lock()
+	r, ok := m.runners[name]
+	m.l.Unlock()
+	if !ok {
+		return nil
+	}
+
+	status := r.getStatus()
+	return &status
+}
+
+func (m *raftAutoSnapshotManager) run(r *raftAutoSnapshotRunner) {
+	defer close(r.doneCh)
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.snapshot(r)
+		case <-r.stopCh:
+			return
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// snapshot takes a snapshot for the runner's configuration, writes it to the
+// configured target and applies the retention policy.
+func (m *raftAutoSnapshotManager) snapshot(r *raftAutoSnapshotRunner) {
+	logger := m.logger.With("name", r.name)
+	start := time.Now()
+
+	url, err := m.writeSnapshot(r.config, start)
+	if err != nil {
+		logger.Error("failed to take automated snapshot", "error", err)
+	} else {
+		logger.Info("took automated snapshot", "url", url)
+	}
+
+	r.statusLock.Lock()
+	defer r.statusLock.Unlock()
+	r.status.LastSnapshotStart = start
+	r.status.LastSnapshotEnd = time.Now()
+	r.status.LastSnapshotURL = url
+	r.status.LastSnapshotError = ""
+	if err != nil {
+		r.status.LastSnapshotError = err.Error()
+	}
+
+	// A snapshot that was written is usable even if pruning older ones
+	// failed afterwards
+	if url != "" {
+		r.status.SnapshotStart = start
+		r.status.SnapshotURL = url
+	}
+}
+
+func (m *raftAutoSnapshotManager) writeSnapshot(config *autosnapshots.Config, start time.Time) (string, error) {
+	raftBackend := m.core.getRaftBackend()
+	if raftBackend == nil {
+		return "", errors.New("raft storage is not in use")
+	}
+
+	target, err := autosnapshots.NewTarget(m.ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	// Buffer the snapshot on disk so its size is known and the upload can be
+	// retried by the storage clients
+	file, err := os.CreateTemp("", "vault-snapshot-auto")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	sealer := NewSealAccessSealer(m.core.seal.GetAccess(), m.logger, "snapshot_auto")
+	if err := raftBackend.Snapshot(file, sealer); err != nil {
+		return "", fmt.Errorf("error taking snapshot: %w", err)
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	url, err := target.Put(m.ctx, config.SnapshotName(start), file, size)
+	if err != nil {
+		return "", fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	if err := autosnapshots.Prune(m.ctx, config, target); err != nil {
+		return url, fmt.Errorf("snapshot written but retention failed: %w", err)
+	}
+	return url, nil
+}
+
+func (r *raftAutoSnapshotRunner) stop() {
+	close(r.stopCh)
+	<-r.doneCh
+}
+
+func (r *raftAutoSnapshotRunner) getStatus() raftAutoSnapshotStatus {
+	r.statusLock.RLock()
+	defer r.statusLock.RUnlock()
+	return r.status
+}
+
+// statusResponse renders the status the way the status endpoint returns it.
+func (s *raftAutoSnapshotStatus) statusResponse() *logical.Response {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"snapshot_start":      formatTime(s.SnapshotStart),
+			"snapshot_url":        s.SnapshotURL,
+			"last_snapshot_start": formatTime(s.LastSnapshotStart),
+			"last_snapshot_end":   formatTime(s.LastSnapshotEnd),
+			"last_snapshot_url":   s.LastSnapshotURL,
+			"last_snapshot_error": s.LastSnapshotError,
+		},
+	}
+}
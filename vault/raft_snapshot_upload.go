@@ -0,0 +1,321 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	raftlib "github.com/hashicorp/raft"
+	snapshot "github.com/hashicorp/raft-snapshot"
+	"github.com/hashicorp/vault/physical/raft"
+)
+
+const (
+	raftSnapshotUploadStateUploading = "uploading"
+	raftSnapshotUploadStateVerifying = "verifying"
+	raftSnapshotUploadStateRestoring = "restoring"
+	raftSnapshotUploadStateRestored  = "restored"
+	raftSnapshotUploadStateFailed    = "failed"
+
+	// raftSnapshotUploadIdleTimeout is the time after which an upload which
+	// received no data is abandoned, letting another upload start.
+	raftSnapshotUploadIdleTimeout = time.Hour
+
+	// raftSnapshotUploadBufferSize is the size of the reads of the chunks.
+	raftSnapshotUploadBufferSize = 1024 * 1024
+
+	// raftSnapshotUploadProgressInterval is the interval at which the progress
+	// of the restore of an uploaded snapshot is logged.
+	raftSnapshotUploadProgressInterval = 10 * time.Second
+)
+
+var (
+	errRaftSnapshotUploadInProgress = errors.New("another snapshot upload is in progress")
+	errRaftSnapshotUploadBusy       = errors.New("a chunk of the snapshot upload is already being written")
+)
+
+// raftSnapshotUploads tracks the snapshots uploaded in chunks to be restored.
+// A single upload may be in progress at a time, the finished ones being kept
+// for their status to be read until the next upload starts.
+type raftSnapshotUploads struct {
+	l       sync.Mutex
+	uploads map[string]*raftSnapshotUpload
+}
+
+// raftSnapshotUpload is a snapshot being uploaded in chunks, which are
+// appended to a temporary file and hashed as they are received. Once the whole
+// snapshot is received, its hash is verified and it is restored.
+type raftSnapshotUpload struct {
+	id        string
+	size      int64
+	sha256    []byte
+	force     bool
+	startTime time.Time
+
+	// writeLock serializes the writes of the chunks
+	writeLock sync.Mutex
+	file      *os.File
+	hash      hash.Hash
+
+	received    atomic.Int64
+	verified    atomic.Int64
+	restored    atomic.Int64
+	restoreSize atomic.Int64
+
+	l          sync.RWMutex
+	state      string
+	err        string
+	updateTime time.Time
+}
+
+// raftSnapshotUploadStatus is the progress of a snapshot upload.
+type raftSnapshotUploadStatus struct {
+	ID            string
+	State         string
+	Error         string
+	Size          int64
+	Force         bool
+	BytesReceived int64
+	BytesVerified int64
+	BytesRestored int64
+	RestoreSize   int64
+	StartTime     time.Time
+	UpdateTime    time.Time
+}
+
+// start begins the upload of a snapshot of the given size and SHA256 sum,
+// discarding the previous uploads unless one of them is still in progress.
+func (u *raftSnapshotUploads) start(size int64, sum []byte, force bool) (*raftSnapshotUpload, error) {
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	for id, up := range u.uploads {
+		if up.inProgress() {
+			return nil, errRaftSnapshotUploadInProgress
+		}
+		up.discard()
+		delete(u.uploads, id)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "vault-snapshot-upload-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot upload file: %w", err)
+	}
+
+	now := time.Now()
+	up := &raftSnapshotUpload{
+		id:         id,
+		size:       size,
+		sha256:     sum,
+		force:      force,
+		startTime:  now,
+		file:       file,
+		hash:       sha256.New(),
+		state:      raftSnapshotUploadStateUploading,
+		updateTime: now,
+	}
+
+	if u.uploads == nil {
+		u.uploads = make(map[string]*raftSnapshotUpload)
+	}
+	u.uploads[id] = up
+	return up, nil
+}
+
+// get returns the upload with the given ID, or nil if there is none.
+func (u *raftSnapshotUploads) get(id string) *raftSnapshotUpload {
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	return u.uploads[id]
+}
+
+// abort discards the upload with the given ID, unless it is being restored.
+func (u *raftSnapshotUploads) abort(id string) error {
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	up, ok := u.uploads[id]
+	if !ok {
+		return nil
+	}
+	switch up.status().State {
+	case raftSnapshotUploadStateVerifying, raftSnapshotUploadStateRestoring:
+		return errors.New("the snapshot is being restored")
+	}
+
+	up.discard()
+	delete(u.uploads, id)
+	return nil
+}
+
+// inProgress returns whether the upload is being restored, or is still
+// receiving data.
+func (up *raftSnapshotUpload) inProgress() bool {
+	status := up.status()
+	switch status.State {
+	case raftSnapshotUploadStateVerifying, raftSnapshotUploadStateRestoring:
+		return true
+	case raftSnapshotUploadStateUploading:
+		return time.Since(status.UpdateTime) < raftSnapshotUploadIdleTimeout
+	default:
+		return false
+	}
+}
+
+// write appends a chunk of the snapshot starting at the offset, which must be
+// the number of bytes received so far. The data read before a failure to read
+// the chunk is kept, so that the upload resumes from there. It returns whether
+// the whole snapshot was received.
+func (up *raftSnapshotUpload) write(offset int64, r io.Reader) (bool, error) {
+	if !up.writeLock.TryLock() {
+		return false, errRaftSnapshotUploadBusy
+	}
+	defer up.writeLock.Unlock()
+
+	if state := up.status().State; state != raftSnapshotUploadStateUploading {
+		return false, fmt.Errorf("the snapshot upload is %s", state)
+	}
+	if received := up.received.Load(); offset != received {
+		return false, fmt.Errorf("offset %d doesn't match the %d bytes received", offset, received)
+	}
+
+	buf := make([]byte, raftSnapshotUploadBufferSize)
+	for {
+		remaining := up.size - up.received.Load()
+		if remaining == 0 {
+			// Make sure the chunk doesn't overflow the snapshot
+			if n, _ := r.Read(buf[:1]); n > 0 {
+				err := errors.New("the snapshot exceeds the size of the upload")
+				up.fail(err)
+				return false, err
+			}
+			break
+		}
+		if remaining < int64(len(buf)) {
+			buf = buf[:remaining]
+		}
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := up.file.Write(buf[:n]); err != nil {
+				up.fail(fmt.Errorf("failed to write snapshot upload file: %w", err))
+				return false, err
+			}
+			up.hash.Write(buf[:n])
+			up.received.Add(int64(n))
+			up.touch()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read chunk: %w", err)
+		}
+	}
+
+	if up.received.Load() < up.size {
+		return false, nil
+	}
+
+	// Only a single write completes the upload
+	up.setState(raftSnapshotUploadStateVerifying)
+	return true, nil
+}
+
+// verify checks the SHA256 sum of the uploaded snapshot, and buffers its
+// content to a temporary file, checking its sealed hashes unless the restore
+// is forced.
+func (up *raftSnapshotUpload) verify(raftStorage *raft.RaftBackend, sealer snapshot.Sealer) (*os.File, func(), raftlib.SnapshotMeta, error) {
+	if sum := up.hash.Sum(nil); !bytes.Equal(sum, up.sha256) {
+		return nil, nil, raftlib.SnapshotMeta{}, fmt.Errorf("the SHA256 sum of the snapshot is %x, expected %x", sum, up.sha256)
+	}
+	if _, err := up.file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, raftlib.SnapshotMeta{}, err
+	}
+
+	in := io.NopCloser(&raftSnapshotProgressReader{r: up.file, n: &up.verified})
+	return raftStorage.WriteSnapshotToTemp(in, sealer)
+}
+
+// status returns the progress of the upload.
+func (up *raftSnapshotUpload) status() *raftSnapshotUploadStatus {
+	up.l.RLock()
+	defer up.l.RUnlock()
+
+	return &raftSnapshotUploadStatus{
+		ID:            up.id,
+		State:         up.state,
+		Error:         up.err,
+		Size:          up.size,
+		Force:         up.force,
+		BytesReceived: up.received.Load(),
+		BytesVerified: up.verified.Load(),
+		BytesRestored: up.restored.Load(),
+		RestoreSize:   up.restoreSize.Load(),
+		StartTime:     up.startTime,
+		UpdateTime:    up.updateTime,
+	}
+}
+
+func (up *raftSnapshotUpload) touch() {
+	up.l.Lock()
+	defer up.l.Unlock()
+
+	up.updateTime = time.Now()
+}
+
+func (up *raftSnapshotUpload) setState(state string) {
+	up.l.Lock()
+	defer up.l.Unlock()
+
+	up.state = state
+	up.updateTime = time.Now()
+}
+
+// fail marks the upload as failed, discarding the data received.
+func (up *raftSnapshotUpload) fail(err error) {
+	up.l.Lock()
+	up.state = raftSnapshotUploadStateFailed
+	up.err = err.Error()
+	up.updateTime = time.Now()
+	up.l.Unlock()
+
+	up.discard()
+}
+
+// discard removes the temporary file holding the data received.
+func (up *raftSnapshotUpload) discard() {
+	if up.file == nil {
+		return
+	}
+	up.file.Close()
+	os.Remove(up.file.Name())
+}
+
+// raftSnapshotProgressReader counts the bytes read from a snapshot.
+type raftSnapshotProgressReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (r *raftSnapshotProgressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRaftSnapshotUpload_Write checks that the chunks of a snapshot upload are
+// appended at the offset of the data received, which is kept when a chunk
+// fails midway.
+func TestRaftSnapshotUpload_Write(t *testing.T) {
+	data := []byte("some snapshot data")
+	sum := sha256.Sum256(data)
+
+	var uploads raftSnapshotUploads
+	up, err := uploads.start(int64(len(data)), sum[:], false)
+	require.NoError(t, err)
+	t.Cleanup(up.discard)
+
+	_, err = uploads.start(int64(len(data)), sum[:], false)
+	require.ErrorIs(t, err, errRaftSnapshotUploadInProgress)
+
+	complete, err := up.write(0, io.MultiReader(bytes.NewReader(data[:5]), iotest.ErrReader(errors.New("connection reset"))))
+	require.Error(t, err)
+	require.False(t, complete)
+	require.EqualValues(t, 5, up.status().BytesReceived)
+
+	_, err = up.write(0, bytes.NewReader(data))
+	require.ErrorContains(t, err, "offset 0 doesn't match the 5 bytes received")
+
+	complete, err = up.write(5, bytes.NewReader(data[5:]))
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Equal(t, raftSnapshotUploadStateVerifying, up.status().State)
+	require.Equal(t, sum[:], up.hash.Sum(nil))
+
+	_, err = up.write(int64(len(data)), bytes.NewReader(nil))
+	require.ErrorContains(t, err, "the snapshot upload is verifying")
+	require.ErrorContains(t, uploads.abort(up.id), "the snapshot is being restored")
+}
+
+// TestRaftSnapshotUpload_Overflow checks that an upload fails when it receives
+// more data than its size.
+func TestRaftSnapshotUpload_Overflow(t *testing.T) {
+	data := []byte("some snapshot data")
+	sum := sha256.Sum256(data)
+
+	var uploads raftSnapshotUploads
+	up, err := uploads.start(int64(len(data))-1, sum[:], false)
+	require.NoError(t, err)
+
+	complete, err := up.write(0, bytes.NewReader(data))
+	require.Error(t, err)
+	require.False(t, complete)
+
+	status := up.status()
+	require.Equal(t, raftSnapshotUploadStateFailed, status.State)
+	require.Equal(t, "the snapshot exceeds the size of the upload", status.Error)
+
+	// A failed upload doesn't prevent another one from starting
+	up, err = uploads.start(int64(len(data)), sum[:], false)
+	require.NoError(t, err)
+	require.NoError(t, uploads.abort(up.id))
+	require.Nil(t, uploads.get(up.id))
+}
@@ -0,0 +1,471 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// admissionWebhooksPath is the path of the admission webhooks in the
+	// config/ view of the system barrier view.
+	admissionWebhooksPath = "admission-webhooks/"
+
+	// defaultAdmissionWebhookTimeout is the default timeout of webhook calls.
+	defaultAdmissionWebhookTimeout = 5 * time.Second
+
+	// maxAdmissionWebhookResponseSize is the maximum size of the responses
+	// read from webhooks.
+	maxAdmissionWebhookResponseSize = 1024 * 1024
+)
+
+// AdmissionRequest is the metadata of a request inspected by admission
+// handlers. The values of the request data are never included, only the
+// names of its parameters.
+type AdmissionRequest struct {
+	ID            string            `json:"id"`
+	Path          string            `json:"path"`
+	Operation     logical.Operation `json:"operation"`
+	NamespaceID   string            `json:"namespace_id"`
+	NamespacePath string            `json:"namespace_path"`
+	MountPath     string            `json:"mount_path,omitempty"`
+	MountType     string            `json:"mount_type,omitempty"`
+	MountAccessor string            `json:"mount_accessor,omitempty"`
+	RemoteAddress string            `json:"remote_address,omitempty"`
+	Parameters    []string          `json:"parameters"`
+	Token         *AdmissionToken   `json:"token,omitempty"`
+}
+
+// AdmissionToken is the metadata of the token of an admitted request.
+type AdmissionToken struct {
+	Accessor    string            `json:"accessor"`
+	DisplayName string            `json:"display_name"`
+	Policies    []string          `json:"policies"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Type        string            `json:"type"`
+	EntityID    string            `json:"entity_id,omitempty"`
+	NamespaceID string            `json:"namespace_id"`
+}
+
+// AdmissionResponse is the decision of an admission handler. Annotations are
+// added to the audit entries of the request, whether it is allowed or not.
+type AdmissionResponse struct {
+	Allowed     bool              `json:"allowed"`
+	Reason      string            `json:"reason,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AdmissionHandler inspects requests once they are authorized, before they
+// are audited and routed to their backend, and allows or rejects them.
+type AdmissionHandler interface {
+	Admit(context.Context, *AdmissionRequest) (*AdmissionResponse, error)
+}
+
+// AdmissionHandlerFunc is an AdmissionHandler implemented by a function.
+type AdmissionHandlerFunc func(context.Context, *AdmissionRequest) (*AdmissionResponse, error)
+
+func (f AdmissionHandlerFunc) Admit(ctx context.Context, req *AdmissionRequest) (*AdmissionResponse, error) {
+	return f(ctx, req)
+}
+
+// namedAdmissionHandler is an admission handler registered with
+// RegisterAdmissionHandler.
+type namedAdmissionHandler struct {
+	name     string
+	handler  AdmissionHandler
+	failOpen bool
+}
+
+// AdmissionWebhookConfig is the configuration of an external admission
+// handler, called over HTTP for the requests it matches.
+type AdmissionWebhookConfig struct {
+	Name string `json:"name"`
+
+	// URL is the address the admission requests are posted to.
+	URL string `json:"url"`
+
+	// Paths are the request paths, including their namespace, which are
+	// sent to the webhook. They may start or end with a * to match any
+	// prefix or suffix. All requests are sent when empty.
+	Paths []string `json:"paths,omitempty"`
+
+	// Operations are the operations of the requests which are sent to the
+	// webhook. All operations are sent when empty.
+	Operations []string `json:"operations,omitempty"`
+
+	// BearerToken is sent to the webhook when it requires authentication.
+	BearerToken string `json:"bearer_token,omitempty"`
+
+	// Timeout is the timeout of each call.
+	Timeout time.Duration `json:"timeout"`
+
+	// FailOpen allows requests when the webhook can't be reached or returns
+	// an error, instead of rejecting them.
+	FailOpen bool `json:"fail_open"`
+}
+
+// admissionWebhook is an admission handler calling a webhook.
+type admissionWebhook struct {
+	config *AdmissionWebhookConfig
+	client *http.Client
+}
+
+// validate ensures the configuration is usable and sets its defaults.
+func (conf *AdmissionWebhookConfig) validate() error {
+	if conf.URL == "" {
+		return errors.New("url is required")
+	}
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme %q: must be http or https", u.Scheme)
+	}
+
+	for _, op := range conf.Operations {
+		switch logical.Operation(op) {
+		case logical.CreateOperation, logical.ReadOperation, logical.UpdateOperation,
+			logical.PatchOperation, logical.DeleteOperation, logical.ListOperation:
+		default:
+			return fmt.Errorf("invalid operation %q", op)
+		}
+	}
+
+	if conf.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = defaultAdmissionWebhookTimeout
+	}
+	return nil
+}
+
+func newAdmissionWebhook(conf *AdmissionWebhookConfig) *admissionWebhook {
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = conf.Timeout
+	return &admissionWebhook{
+		config: conf,
+		client: client,
+	}
+}
+
+// matches returns whether the request is sent to the webhook.
+func (w *admissionWebhook) matches(req *AdmissionRequest) bool {
+	if len(w.config.Operations) > 0 && !strutil.StrListContains(w.config.Operations, string(req.Operation)) {
+		return false
+	}
+	if len(w.config.Paths) == 0 {
+		return true
+	}
+	path := req.NamespacePath + req.Path
+	for _, p := range w.config.Paths {
+		if strutil.GlobbedStringsMatch(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Admit posts the request to the webhook, which responds with an
+// AdmissionResponse.
+func (w *admissionWebhook) Admit(ctx context.Context, req *AdmissionRequest) (*AdmissionResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"request": req})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if w.config.BearerToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+w.config.BearerToken)
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxAdmissionWebhookResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	admission := new(AdmissionResponse)
+	if err := json.Unmarshal(respBody, admission); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return admission, nil
+}
+
+// RegisterAdmissionHandler registers an admission handler under the name.
+// Handlers are called in the order they are registered, before the admission
+// webhooks.
+func (c *Core) RegisterAdmissionHandler(name string, handler AdmissionHandler) error {
+	if name == "" || handler == nil {
+		return errors.New("admission handlers require a name and a handler")
+	}
+
+	c.admissionLock.Lock()
+	defer c.admissionLock.Unlock()
+	for _, h := range c.admissionHandlers {
+		if h.name == name {
+			return fmt.Errorf("admission handler %q is already registered", name)
+		}
+	}
+	c.admissionHandlers = append(c.admissionHandlers, namedAdmissionHandler{name: name, handler: handler})
+	return nil
+}
+
+// DeregisterAdmissionHandler removes the admission handler registered under
+// the name, if any.
+func (c *Core) DeregisterAdmissionHandler(name string) {
+	c.admissionLock.Lock()
+	defer c.admissionLock.Unlock()
+	for i, h := range c.admissionHandlers {
+		if h.name == name {
+			c.admissionHandlers = append(c.admissionHandlers[:i:i], c.admissionHandlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// admissionRequestFor builds the admission request of the request.
+func (c *Core) admissionRequestFor(ctx context.Context, req *logical.Request, te *logical.TokenEntry, entry *MountEntry) (*AdmissionRequest, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	admReq := &AdmissionRequest{
+		ID:            req.ID,
+		Path:          req.Path,
+		Operation:     req.Operation,
+		NamespaceID:   ns.ID,
+		NamespacePath: ns.Path,
+		Parameters:    make([]string, 0, len(req.Data)),
+	}
+	if req.Connection != nil {
+		admReq.RemoteAddress = req.Connection.RemoteAddr
+	}
+	for k := range req.Data {
+		admReq.Parameters = append(admReq.Parameters, k)
+	}
+	sort.Strings(admReq.Parameters)
+
+	if entry != nil {
+		admReq.MountPath = entry.Path
+		if entry.Table == credentialTableType {
+			admReq.MountPath = credentialRoutePrefix + entry.Path
+		}
+		admReq.MountType = entry.Type
+		admReq.MountAccessor = entry.Accessor
+	}
+
+	if te != nil {
+		admReq.Token = &AdmissionToken{
+			Accessor:    te.Accessor,
+			DisplayName: te.DisplayName,
+			Policies:    te.Policies,
+			Metadata:    te.Meta,
+			Type:        te.Type.String(),
+			EntityID:    te.EntityID,
+			NamespaceID: te.NamespaceID,
+		}
+	}
+
+	return admReq, nil
+}
+
+// admitRequest calls the admission handlers and the matching admission
+// webhooks for the authorized request, setting their annotations on the
+// request. It returns a permission denied error when one of them rejects the
+// request. Requests of root tokens are always admitted, so that a failing
+// webhook can be removed.
+func (c *Core) admitRequest(ctx context.Context, req *logical.Request, te *logical.TokenEntry, entry *MountEntry) error {
+	if te != nil && strutil.StrListContains(te.Policies, "root") {
+		return nil
+	}
+
+	c.admissionLock.RLock()
+	handlers := make([]namedAdmissionHandler, 0, len(c.admissionHandlers)+len(c.admissionWebhooks))
+	handlers = append(handlers, c.admissionHandlers...)
+	webhookNames := make([]string, 0, len(c.admissionWebhooks))
+	for name := range c.admissionWebhooks {
+		webhookNames = append(webhookNames, name)
+	}
+	sort.Strings(webhookNames)
+	webhooks := make([]*admissionWebhook, 0, len(webhookNames))
+	for _, name := range webhookNames {
+		webhooks = append(webhooks, c.admissionWebhooks[name])
+	}
+	c.admissionLock.RUnlock()
+
+	if len(handlers) == 0 && len(webhooks) == 0 {
+		return nil
+	}
+
+	admReq, err := c.admissionRequestFor(ctx, req, te, entry)
+	if err != nil {
+		c.logger.Error("failed to build admission request", "path", req.Path, "error", err)
+		return ErrInternalError
+	}
+
+	for _, w := range webhooks {
+		if w.matches(admReq) {
+			handlers = append(handlers, namedAdmissionHandler{name: w.config.Name, handler: w, failOpen: w.config.FailOpen})
+		}
+	}
+
+	for _, h := range handlers {
+		resp, err := h.handler.Admit(ctx, admReq)
+		if err == nil && resp == nil {
+			err = errors.New("no admission response")
+		}
+		if err != nil {
+			metrics.IncrCounterWithLabels([]string{"core", "admission", "error"}, 1, []metrics.Label{{Name: "handler", Value: h.name}})
+			if h.failOpen {
+				c.logger.Warn("admission handler failed, allowing request", "handler", h.name, "path", req.Path, "error", err)
+				continue
+			}
+			c.logger.Error("admission handler failed, rejecting request", "handler", h.name, "path", req.Path, "error", err)
+			return multierror.Append(fmt.Errorf("admission handler %q failed", h.name), logical.ErrPermissionDenied)
+		}
+
+		if len(resp.Annotations) > 0 {
+			annotations := req.AdmissionAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string, len(resp.Annotations))
+			}
+			for k, v := range resp.Annotations {
+				annotations[k] = v
+			}
+			req.SetAdmissionAnnotations(annotations)
+		}
+
+		if !resp.Allowed {
+			metrics.IncrCounterWithLabels([]string{"core", "admission", "rejected"}, 1, []metrics.Label{{Name: "handler", Value: h.name}})
+			msg := fmt.Sprintf("rejected by admission handler %q", h.name)
+			if resp.Reason != "" {
+				msg += ": " + resp.Reason
+			}
+			return multierror.Append(errors.New(msg), logical.ErrPermissionDenied)
+		}
+	}
+
+	return nil
+}
+
+// setAdmissionWebhook validates, persists and applies the configuration of
+// an admission webhook.
+func (c *Core) setAdmissionWebhook(ctx context.Context, conf *AdmissionWebhookConfig) error {
+	if err := conf.validate(); err != nil {
+		return err
+	}
+
+	entry, err := logical.StorageEntryJSON(admissionWebhooksPath+conf.Name, conf)
+	if err != nil {
+		return fmt.Errorf("failed to create admission webhook entry: %w", err)
+	}
+	if err := c.systemBarrierView.SubView("config/").Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to save admission webhook: %w", err)
+	}
+
+	c.admissionLock.Lock()
+	if c.admissionWebhooks == nil {
+		c.admissionWebhooks = make(map[string]*admissionWebhook)
+	}
+	c.admissionWebhooks[conf.Name] = newAdmissionWebhook(conf)
+	c.admissionLock.Unlock()
+	return nil
+}
+
+// deleteAdmissionWebhook removes the admission webhook with the name.
+func (c *Core) deleteAdmissionWebhook(ctx context.Context, name string) error {
+	if err := c.systemBarrierView.SubView("config/").Delete(ctx, admissionWebhooksPath+name); err != nil {
+		return fmt.Errorf("failed to delete admission webhook: %w", err)
+	}
+
+	c.admissionLock.Lock()
+	delete(c.admissionWebhooks, name)
+	c.admissionLock.Unlock()
+	return nil
+}
+
+// admissionWebhook returns the configuration of the admission webhook with
+// the name, or nil if there is none.
+func (c *Core) admissionWebhook(name string) *AdmissionWebhookConfig {
+	c.admissionLock.RLock()
+	defer c.admissionLock.RUnlock()
+	if w, ok := c.admissionWebhooks[name]; ok {
+		return w.config
+	}
+	return nil
+}
+
+// listAdmissionWebhooks returns the sorted names of the admission webhooks.
+func (c *Core) listAdmissionWebhooks() []string {
+	c.admissionLock.RLock()
+	defer c.admissionLock.RUnlock()
+	names := make([]string, 0, len(c.admissionWebhooks))
+	for name := range c.admissionWebhooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadAdmissionWebhooks(ctx context.Context) error {
+	view := c.systemBarrierView.SubView("config/" + admissionWebhooksPath)
+	names, err := view.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list admission webhooks: %w", err)
+	}
+
+	webhooks := make(map[string]*admissionWebhook, len(names))
+	for _, name := range names {
+		out, err := view.Get(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to read admission webhook %q: %w", name, err)
+		}
+		if out == nil {
+			continue
+		}
+		conf := new(AdmissionWebhookConfig)
+		if err := out.DecodeJSON(conf); err != nil {
+			return fmt.Errorf("failed to decode admission webhook %q: %w", name, err)
+		}
+		webhooks[name] = newAdmissionWebhook(conf)
+	}
+
+	c.admissionLock.Lock()
+	c.admissionWebhooks = webhooks
+	c.admissionLock.Unlock()
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testAdmissionCore returns a core along with its root token and a token
+// with a policy allowing access to secret/.
+func testAdmissionCore(t *testing.T) (*Core, string, string) {
+	t.Helper()
+
+	c, _, root := TestCoreUnsealed(t)
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `path "secret/*" { capabilities = ["create", "read", "update", "delete"] }`)
+	require.NoError(t, err)
+	policy.Name = "secrets"
+	require.NoError(t, c.policyStore.SetPolicy(namespace.RootContext(nil), policy))
+	testMakeServiceTokenViaCore(t, c, root, "admissiontoken", "", []string{"secrets"})
+
+	return c, root, "admissiontoken"
+}
+
+// TestAdmission_Handler ensures registered admission handlers can reject and
+// annotate requests, and that root tokens are always admitted.
+func TestAdmission_Handler(t *testing.T) {
+	c, root, token := testAdmissionCore(t)
+	ctx := namespace.RootContext(nil)
+
+	var admitted []*AdmissionRequest
+	require.NoError(t, c.RegisterAdmissionHandler("guardrails", AdmissionHandlerFunc(func(_ context.Context, req *AdmissionRequest) (*AdmissionResponse, error) {
+		admitted = append(admitted, req)
+		if _, ok := req.Token.Metadata["ticket"]; !ok && req.Operation == logical.DeleteOperation {
+			return &AdmissionResponse{Reason: "deletes require a ticket"}, nil
+		}
+		return &AdmissionResponse{Allowed: true, Annotations: map[string]string{"checked_by": "guardrails"}}, nil
+	})))
+	require.Error(t, c.RegisterAdmissionHandler("guardrails", AdmissionHandlerFunc(nil)))
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.ClientToken = token
+	req.Data["password"] = "bar"
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"checked_by": "guardrails"}, req.AdmissionAnnotations())
+
+	admReq := admitted[len(admitted)-1]
+	require.Equal(t, "secret/foo", admReq.Path)
+	require.Equal(t, logical.CreateOperation, admReq.Operation)
+	require.Equal(t, "secret/", admReq.MountPath)
+	require.Equal(t, "kv", admReq.MountType)
+	require.Equal(t, []string{"password"}, admReq.Parameters)
+	require.Contains(t, admReq.Token.Policies, "secrets")
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "secret/foo")
+	req.ClientToken = token
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.ErrorContains(t, err, `rejected by admission handler "guardrails": deletes require a ticket`)
+
+	// Root tokens aren't inspected
+	count := len(admitted)
+	req = logical.TestRequest(t, logical.DeleteOperation, "secret/foo")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, admitted, count)
+
+	// Failing handlers reject requests
+	c.DeregisterAdmissionHandler("guardrails")
+	require.NoError(t, c.RegisterAdmissionHandler("broken", AdmissionHandlerFunc(func(context.Context, *AdmissionRequest) (*AdmissionResponse, error) {
+		return nil, errors.New("unavailable")
+	})))
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = token
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.ErrorContains(t, err, `admission handler "broken" failed`)
+
+	c.DeregisterAdmissionHandler("broken")
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+}
+
+// testAdmissionWebhook rejects the requests of its paths and records the
+// requests it receives.
+type testAdmissionWebhook struct {
+	l        sync.Mutex
+	requests []AdmissionRequest
+	status   int
+}
+
+func (s *testAdmissionWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Request AdmissionRequest `json:"request"`
+	}
+	if r.Header.Get("Authorization") != "Bearer hook-token" || json.NewDecoder(r.Body).Decode(&body) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.requests = append(s.requests, body.Request)
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	json.NewEncoder(w).Encode(&AdmissionResponse{
+		Reason:      "production secrets are frozen",
+		Annotations: map[string]string{"freeze": "true"},
+	})
+}
+
+// TestAdmission_Webhook ensures admission webhooks are configurable and only
+// receive the requests they match.
+func TestAdmission_Webhook(t *testing.T) {
+	c, root, token := testAdmissionCore(t)
+	ctx := namespace.RootContext(nil)
+
+	hook := &testAdmissionWebhook{}
+	srv := httptest.NewServer(hook)
+	defer srv.Close()
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/config/admission-webhooks/freeze")
+	req.ClientToken = root
+	req.Data["url"] = "ftp://example.com"
+	_, err := c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data["url"] = srv.URL
+	req.Data["paths"] = "secret/prod/*"
+	req.Data["operations"] = "create,update,delete"
+	req.Data["bearer_token"] = "hook-token"
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/config/admission-webhooks/freeze")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"name":       "freeze",
+		"url":        srv.URL,
+		"paths":      []string{"secret/prod/*"},
+		"operations": []string{"create", "update", "delete"},
+		"timeout":    int64(5),
+		"fail_open":  false,
+	}, resp.Data)
+
+	req = logical.TestRequest(t, logical.ListOperation, "sys/config/admission-webhooks")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"freeze"}, resp.Data["keys"])
+
+	// Requests the webhook doesn't match aren't sent to it
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/dev/db")
+	req.ClientToken = token
+	req.Data["password"] = "foo"
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/prod/db")
+	req.ClientToken = token
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, hook.requests)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/prod/db")
+	req.ClientToken = token
+	req.Data["password"] = "foo"
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	require.ErrorContains(t, err, `rejected by admission handler "freeze": production secrets are frozen`)
+	require.Equal(t, map[string]string{"freeze": "true"}, req.AdmissionAnnotations())
+	require.Len(t, hook.requests, 1)
+	require.Equal(t, "secret/prod/db", hook.requests[0].Path)
+	require.Equal(t, []string{"password"}, hook.requests[0].Parameters)
+
+	// Webhooks failing open allow requests
+	hook.status = http.StatusInternalServerError
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/prod/db")
+	req.ClientToken = token
+	_, err = c.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	conf := *c.admissionWebhook("freeze")
+	conf.FailOpen = true
+	require.NoError(t, c.setAdmissionWebhook(ctx, &conf))
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	// Webhooks are reloaded on unseal
+	require.NoError(t, c.loadAdmissionWebhooks(ctx))
+	require.Equal(t, []string{"freeze"}, c.listAdmissionWebhooks())
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/config/admission-webhooks/freeze")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, c.listAdmissionWebhooks())
+	require.NoError(t, c.loadAdmissionWebhooks(ctx))
+	require.Empty(t, c.listAdmissionWebhooks())
+}
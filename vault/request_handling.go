@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/go-uuid"
+	"go.opentelemetry.io/otel/trace"
 	uberAtomic "go.uber.org/atomic"
 
 	"github.com/hashicorp/vault/command/server"
@@ -343,6 +344,8 @@ func (c *Core) CheckTokenWithLock(ctx context.Context, req *logical.Request, una
 
 func (c *Core) CheckToken(ctx context.Context, req *logical.Request, unauth bool) (*logical.Auth, *logical.TokenEntry, error) {
 	defer metrics.MeasureSince([]string{"core", "check_token"}, time.Now())
+	ctx, span := startRequestSpan(ctx, "vault.core.check_token")
+	defer span.End()
 
 	var acl *ACL
 	var te *logical.TokenEntry
@@ -354,7 +357,9 @@ func (c *Core) CheckToken(ctx context.Context, req *logical.Request, unauth bool
 	// trace mode for EGPs.
 	if !unauth || (unauth && req.ClientToken != "") {
 		var err error
-		acl, te, entity, identityPolicies, err = c.fetchACLTokenEntryAndEntity(ctx, req)
+		fetchCtx, fetchSpan := startRequestSpan(ctx, "vault.core.fetch_acl")
+		acl, te, entity, identityPolicies, err = c.fetchACLTokenEntryAndEntity(fetchCtx, req)
+		endSpan(fetchSpan, err)
 		// In the unauth case we don't want to fail the command, since it's
 		// unauth, we just have no information to attach to the request, so
 		// ignore errors...this was best-effort anyways
@@ -471,10 +476,13 @@ func (c *Core) CheckToken(ctx context.Context, req *logical.Request, unauth bool
 
 	// Check the standard non-root ACLs. Return the token entry if it's not
 	// allowed so we can decrement the use count.
-	authResults := c.performPolicyChecks(ctx, acl, te, req, entity, &PolicyCheckOpts{
+	policyCtx, policySpan := startRequestSpan(ctx, "vault.core.policy_checks")
+	authResults := c.performPolicyChecks(policyCtx, acl, te, req, entity, &PolicyCheckOpts{
 		Unauth:            unauth,
 		RootPrivsRequired: rootPath,
 	})
+	policySpan.SetAttributes(spanAttrAllowed.Bool(authResults.Allowed))
+	policySpan.End()
 
 	auth.PolicyResults = &logical.PolicyResults{
 		Allowed: authResults.Allowed,
@@ -570,7 +578,26 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	if ok {
 		ctx = context.WithValue(ctx, logical.CtxKeyRequestRole{}, requestRole)
 	}
+
+	// The request is handled in a context derived from the active one, so the
+	// span of the HTTP request, if any, is carried over for the trace of the
+	// request to continue it.
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(httpCtx))
+	ctx, span := tracer.Start(ctx, "vault.request",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			spanAttrOperation.String(string(req.Operation)),
+			spanAttrNamespace.String(ns.Path),
+		))
+
 	resp, err = c.handleCancelableRequest(ctx, req)
+
+	span.SetAttributes(
+		spanAttrMountPoint.String(req.MountPoint),
+		spanAttrMountType.String(req.MountType),
+	)
+	endSpan(span, err)
+
 	req.SetTokenEntry(nil)
 	cancel()
 	return resp, err
@@ -754,7 +781,11 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 	}
 
 	if !hasNamespaces(c) && ns.Path != "" {
-		return nil, logical.CodedError(403, "namespaces feature not enabled")
+		// Without the namespaces feature, only the namespaces created through
+		// sys/namespaces are known to the core.
+		if known, err := namespaceByID(ctx, ns.ID, c); err != nil || known == nil {
+			return nil, logical.CodedError(403, "namespaces feature not enabled")
+		}
 	}
 
 	walState := &logical.WALState{}
@@ -954,10 +985,12 @@ func (c *Core) isLoginRequest(ctx context.Context, req *logical.Request) bool {
 }
 
 func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
-	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
+	start := time.Now()
 
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(ctx, req.Path)
+	defer metrics.MeasureSinceWithLabels([]string{"core", "handle_request"}, start, c.requestMetricLabels(ctx, entry))
+
 	if entry != nil {
 		// Set here so the audit log has it even if authorization fails
 		req.MountType = entry.Type
@@ -1037,6 +1070,12 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		}
 	}
 
+	// Admission handlers inspect the request once it is authorized, before it
+	// is audited, so that their annotations and rejections are audited
+	if ctErr == nil && !isControlGroupRun(req) {
+		ctErr = c.admitRequest(ctx, req, te, entry)
+	}
+
 	if ctErr != nil {
 		newCtErr, cgResp, cgAuth, cgRetErr := checkNeedsCG(ctx, c, req, auth, ctErr, nonHMACReqDataKeys)
 		switch {
@@ -1268,9 +1307,9 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 				[]string{"secret", "lease", "creation"},
 				1,
 				[]metrics.Label{
-					metricsutil.NamespaceLabel(ns),
+					c.MetricSink().TelemetryConsts.NamespaceLabel(ns),
 					{"secret_engine", req.MountType},
-					{"mount_point", mountPointWithoutNs},
+					c.MetricSink().TelemetryConsts.MountPointLabel(mountPointWithoutNs),
 					{"creation_ttl", ttl_label},
 				},
 			)
@@ -1381,12 +1420,14 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 // handleLoginRequest is used to handle a login request, which is an
 // unauthenticated request to the backend.
 func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
-	defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
+	start := time.Now()
 
 	req.Unauthenticated = true
 
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(ctx, req.Path)
+	defer metrics.MeasureSinceWithLabels([]string{"core", "handle_login_request"}, start, c.requestMetricLabels(ctx, entry))
+
 	if entry != nil {
 		// Set here so the audit log has it even if authorization fails
 		req.MountType = entry.Type
@@ -1919,9 +1960,9 @@ func (c *Core) LoginCreateToken(ctx context.Context, ns *namespace.Namespace, re
 		[]string{"token", "creation"},
 		1,
 		[]metrics.Label{
-			metricsutil.NamespaceLabel(ns),
+			c.metricSink.TelemetryConsts.NamespaceLabel(ns),
 			{"auth_method", mountEntry.Type},
-			{"mount_point", mountPointWithoutNs},
+			c.metricSink.TelemetryConsts.MountPointLabel(mountPointWithoutNs),
 			{"creation_ttl", ttl_label},
 			{"token_type", auth.TokenType.String()},
 		},
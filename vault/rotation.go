@@ -0,0 +1,460 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// rotationJobPrefix is the barrier prefix scheduled root credential
+	// rotation jobs are stored under, keyed by mount accessor.
+	rotationJobPrefix = "core/rotation/job/"
+
+	// rotationSuccessEventType and rotationFailureEventType are the types of
+	// the events sent after each scheduled rotation.
+	rotationSuccessEventType = "rotation/success"
+	rotationFailureEventType = "rotation/failure"
+)
+
+var (
+	// rotationCheckInterval is how often the rotation manager checks for jobs
+	// which are due.
+	rotationCheckInterval = time.Minute
+
+	// rotationRetryInterval is the delay before a failed rotation is retried.
+	rotationRetryInterval = 10 * time.Minute
+
+	errRotationMountNotFound = errors.New("mount not found")
+)
+
+// secretsRotationEndpoints and credentialRotationEndpoints are the root
+// credential rotation endpoints of the secrets engines and auth methods which
+// have one, used when a job doesn't specify the endpoint.
+var (
+	secretsRotationEndpoints = map[string]string{
+		"aws":      "config/rotate-root",
+		"rabbitmq": "config/rotate-root",
+		"ad":       "rotate-root",
+		"ldap":     "rotate-root",
+		"openldap": "rotate-root",
+	}
+	credentialRotationEndpoints = map[string]string{
+		"aws": "config/rotate-root",
+	}
+)
+
+// rotationJob is the schedule of the root credential rotation of a mount,
+// along with the outcome of its rotations.
+type rotationJob struct {
+	MountAccessor    string        `json:"mount_accessor"`
+	Endpoint         string        `json:"endpoint"`
+	RotationPeriod   time.Duration `json:"rotation_period"`
+	RotationSchedule string        `json:"rotation_schedule"`
+	CreatedAt        time.Time     `json:"created_at"`
+
+	LastAttempt         time.Time `json:"last_attempt"`
+	LastRotation        time.Time `json:"last_rotation"`
+	LastError           string    `json:"last_error"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// defaultRotationEndpoint returns the root credential rotation endpoint of the
+// mount, or an empty string if its type isn't known to have one.
+func defaultRotationEndpoint(entry *MountEntry) string {
+	if entry.Table == credentialTableType {
+		return credentialRotationEndpoints[entry.Type]
+	}
+	return secretsRotationEndpoints[entry.Type]
+}
+
+// rotationMountPath returns the path of the mount relative to its namespace.
+func rotationMountPath(entry *MountEntry) string {
+	if entry.Table == credentialTableType {
+		return credentialRoutePrefix + entry.Path
+	}
+	return entry.Path
+}
+
+// validate ensures exactly one of the rotation period and schedule is set.
+func (j *rotationJob) validate() error {
+	switch {
+	case j.Endpoint == "":
+		return errors.New("endpoint is required")
+	case j.RotationPeriod == 0 && j.RotationSchedule == "":
+		return errors.New("one of rotation_period or rotation_schedule is required")
+	case j.RotationPeriod != 0 && j.RotationSchedule != "":
+		return errors.New("only one of rotation_period or rotation_schedule can be set")
+	case j.RotationPeriod < 0:
+		return errors.New("rotation_period must be positive")
+	}
+
+	if j.RotationSchedule != "" {
+		if _, err := cron.ParseStandard(j.RotationSchedule); err != nil {
+			return fmt.Errorf("invalid rotation_schedule: %w", err)
+		}
+	}
+	return nil
+}
+
+// nextRotation returns when the job is due, which is at the next scheduled
+// time after the last rotation, and no earlier than the retry interval after
+// a failed attempt.
+func (j *rotationJob) nextRotation() time.Time {
+	last := j.LastRotation
+	if last.IsZero() {
+		last = j.CreatedAt
+	}
+
+	var next time.Time
+	if j.RotationSchedule != "" {
+		schedule, err := cron.ParseStandard(j.RotationSchedule)
+		if err != nil {
+			return time.Time{}
+		}
+		next = schedule.Next(last)
+	} else {
+		next = last.Add(j.RotationPeriod)
+	}
+
+	if j.LastError != "" {
+		if retry := j.LastAttempt.Add(rotationRetryInterval); retry.After(next) {
+			next = retry
+		}
+	}
+	return next
+}
+
+// rotationManager runs the scheduled root credential rotations on the active
+// node.
+type rotationManager struct {
+	core   *Core
+	logger hclog.Logger
+	ctx    context.Context
+
+	l    sync.Mutex
+	jobs map[string]*rotationJob
+
+	// rotateLock serializes rotations, so that a mount is never rotated
+	// concurrently by its schedule and a manual rotation.
+	rotateLock sync.Mutex
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// setupRotationManager loads the rotation jobs and starts running them.
+func (c *Core) setupRotationManager(ctx context.Context) error {
+	m := &rotationManager{
+		core:   c,
+		logger: c.logger.Named("rotation"),
+		ctx:    ctx,
+		jobs:   make(map[string]*rotationJob),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	accessors, err := c.barrier.List(ctx, rotationJobPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list rotation jobs: %w", err)
+	}
+	for _, accessor := range accessors {
+		entry, err := c.barrier.Get(ctx, rotationJobPrefix+accessor)
+		if err != nil {
+			return fmt.Errorf("failed to read rotation job %q: %w", accessor, err)
+		}
+		if entry == nil {
+			continue
+		}
+
+		var job rotationJob
+		if err := entry.DecodeJSON(&job); err != nil {
+			return fmt.Errorf("failed to decode rotation job %q: %w", accessor, err)
+		}
+		m.jobs[accessor] = &job
+	}
+
+	c.rotationManager = m
+	go m.run()
+	return nil
+}
+
+// stopRotationManager stops running the rotation jobs, waiting for in-flight
+// rotations to finish.
+func (c *Core) stopRotationManager() {
+	m := c.rotationManager
+	if m == nil {
+		return
+	}
+	c.rotationManager = nil
+
+	close(m.stopCh)
+	<-m.doneCh
+}
+
+func (m *rotationManager) run() {
+	defer close(m.doneCh)
+
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.rotateDue(time.Now())
+		case <-m.stopCh:
+			return
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// rotateDue rotates the root credentials of the jobs which are due.
+func (m *rotationManager) rotateDue(now time.Time) {
+	m.l.Lock()
+	var due []string
+	for accessor, job := range m.jobs {
+		if !job.nextRotation().After(now) {
+			due = append(due, accessor)
+		}
+	}
+	m.l.Unlock()
+
+	for _, accessor := range due {
+		select {
+		case <-m.stopCh:
+			return
+		default:
+		}
+
+		if _, err := m.rotate(accessor); err != nil && !errors.Is(err, errRotationMountNotFound) {
+			m.logger.Error("failed to rotate root credentials", "mount_accessor", accessor, "error", err)
+		}
+	}
+}
+
+// job returns a copy of the job of the mount, or nil if there is none.
+func (m *rotationManager) job(accessor string) *rotationJob {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	job, ok := m.jobs[accessor]
+	if !ok {
+		return nil
+	}
+	jobCopy := *job
+	return &jobCopy
+}
+
+// listJobs returns copies of the jobs, keyed by mount accessor.
+func (m *rotationManager) listJobs() map[string]*rotationJob {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	jobs := make(map[string]*rotationJob, len(m.jobs))
+	for accessor, job := range m.jobs {
+		jobCopy := *job
+		jobs[accessor] = &jobCopy
+	}
+	return jobs
+}
+
+// setJob creates or replaces the job of a mount, keeping the outcome of its
+// previous rotations.
+func (m *rotationManager) setJob(ctx context.Context, job *rotationJob) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if existing, ok := m.jobs[job.MountAccessor]; ok {
+		job.CreatedAt = existing.CreatedAt
+		job.LastAttempt = existing.LastAttempt
+		job.LastRotation = existing.LastRotation
+		job.LastError = existing.LastError
+		job.ConsecutiveFailures = existing.ConsecutiveFailures
+	} else {
+		job.CreatedAt = time.Now()
+	}
+
+	if err := m.persistJob(ctx, job); err != nil {
+		return err
+	}
+	m.jobs[job.MountAccessor] = job
+	return nil
+}
+
+// deleteJob removes the job of a mount.
+func (m *rotationManager) deleteJob(ctx context.Context, accessor string) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if err := m.core.barrier.Delete(ctx, rotationJobPrefix+accessor); err != nil {
+		return fmt.Errorf("failed to delete rotation job: %w", err)
+	}
+	delete(m.jobs, accessor)
+	return nil
+}
+
+func (m *rotationManager) persistJob(ctx context.Context, job *rotationJob) error {
+	entry, err := logical.StorageEntryJSON(rotationJobPrefix+job.MountAccessor, job)
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation job: %w", err)
+	}
+	if err := m.core.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist rotation job: %w", err)
+	}
+	return nil
+}
+
+// rotate rotates the root credential of the mount, records the outcome in its
+// job and sends a success or failure event. Jobs of mounts which no longer
+// exist are removed.
+func (m *rotationManager) rotate(accessor string) (*rotationJob, error) {
+	m.rotateLock.Lock()
+	defer m.rotateLock.Unlock()
+
+	job := m.job(accessor)
+	if job == nil {
+		return nil, fmt.Errorf("no rotation job for mount accessor %q", accessor)
+	}
+
+	entry := m.core.router.MatchingMountByAccessor(accessor)
+	if entry == nil {
+		m.logger.Info("removing rotation job of deleted mount", "mount_accessor", accessor)
+		if err := m.deleteJob(m.ctx, accessor); err != nil {
+			return nil, err
+		}
+		return nil, errRotationMountNotFound
+	}
+
+	rotateErr := m.sendRotateRequest(entry, job.Endpoint)
+
+	job.LastAttempt = time.Now()
+	if rotateErr == nil {
+		job.LastRotation = job.LastAttempt
+		job.LastError = ""
+		job.ConsecutiveFailures = 0
+		m.logger.Info("rotated root credentials", "mount_accessor", accessor, "path", rotationMountPath(entry))
+	} else {
+		job.LastError = rotateErr.Error()
+		job.ConsecutiveFailures++
+	}
+
+	m.l.Lock()
+	// The job may have been deleted or replaced during the rotation
+	if current, ok := m.jobs[accessor]; ok {
+		current.LastAttempt = job.LastAttempt
+		current.LastRotation = job.LastRotation
+		current.LastError = job.LastError
+		current.ConsecutiveFailures = job.ConsecutiveFailures
+		if err := m.persistJob(m.ctx, current); err != nil {
+			m.logger.Error("failed to persist rotation job", "mount_accessor", accessor, "error", err)
+		}
+	}
+	m.l.Unlock()
+
+	m.sendEvent(entry, job)
+	return job, rotateErr
+}
+
+// sendRotateRequest issues an update request to the root credential rotation
+// endpoint of the mount.
+func (m *rotationManager) sendRotateRequest(entry *MountEntry, endpoint string) error {
+	ctx := namespace.ContextWithNamespace(m.ctx, entry.Namespace())
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      rotationMountPath(entry) + endpoint,
+	}
+
+	resp, err := m.core.router.Route(ctx, req)
+	if err != nil {
+		return err
+	}
+	if resp != nil && resp.IsError() {
+		return resp.Error()
+	}
+	return nil
+}
+
+// sendEvent sends the outcome of a rotation to the event system, on behalf of
+// the rotated mount.
+func (m *rotationManager) sendEvent(entry *MountEntry, job *rotationJob) {
+	if m.core.events == nil {
+		return
+	}
+
+	ev, err := logical.NewEvent()
+	if err != nil {
+		m.logger.Error("failed to create rotation event", "error", err)
+		return
+	}
+
+	eventType := rotationSuccessEventType
+	fields := map[string]*structpb.Value{
+		"path":          structpb.NewStringValue(job.Endpoint),
+		"last_rotation": structpb.NewStringValue(formatRotationTime(job.LastRotation)),
+	}
+	if job.LastError != "" {
+		eventType = rotationFailureEventType
+		fields["error"] = structpb.NewStringValue(job.LastError)
+		fields["consecutive_failures"] = structpb.NewStringValue(strconv.Itoa(job.ConsecutiveFailures))
+	}
+	ev.Metadata = &structpb.Struct{Fields: fields}
+
+	mountClass := consts.PluginTypeSecrets.String()
+	if entry.Table == credentialTableType {
+		mountClass = consts.PluginTypeCredential.String()
+	}
+	pluginInfo := &logical.EventPluginInfo{
+		MountClass:    mountClass,
+		MountAccessor: entry.Accessor,
+		MountPath:     entry.Path,
+		Plugin:        entry.Type,
+		PluginVersion: entry.RunningVersion,
+		Version:       entry.Version,
+	}
+
+	if err := m.core.events.SendEventInternal(m.ctx, entry.Namespace(), pluginInfo, logical.EventType(eventType), ev); err != nil {
+		m.logger.Warn("failed to send rotation event", "event_type", eventType, "error", err)
+	}
+}
+
+func formatRotationTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// responseData renders the job the way the sys/rotation endpoints return it.
+func (j *rotationJob) responseData(entry *MountEntry) map[string]interface{} {
+	data := map[string]interface{}{
+		"mount_accessor":       j.MountAccessor,
+		"endpoint":             j.Endpoint,
+		"rotation_period":      int64(j.RotationPeriod.Seconds()),
+		"rotation_schedule":    j.RotationSchedule,
+		"last_attempt":         formatRotationTime(j.LastAttempt),
+		"last_rotation":        formatRotationTime(j.LastRotation),
+		"next_rotation":        formatRotationTime(j.nextRotation()),
+		"last_error":           j.LastError,
+		"consecutive_failures": j.ConsecutiveFailures,
+	}
+	if entry != nil {
+		data["mount_path"] = rotationMountPath(entry)
+		data["mount_type"] = entry.Type
+	}
+	return data
+}
@@ -0,0 +1,269 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// rotateTestBackend is a secrets engine with a root credential rotation
+// endpoint which counts its calls and can be made to fail.
+type rotateTestBackend struct {
+	*framework.Backend
+	rotations atomic.Int64
+	fail      atomic.Bool
+}
+
+func (b *rotateTestBackend) pathRotateRoot(context.Context, *logical.Request, *framework.FieldData) (*logical.Response, error) {
+	if b.fail.Load() {
+		return nil, errors.New("invalid credentials")
+	}
+	b.rotations.Add(1)
+	return nil, nil
+}
+
+// testCoreRotation returns a core with a rotatetest secrets engine mounted at
+// rotatetest/, along with the backend of the mount.
+func testCoreRotation(t *testing.T) (*Core, *rotateTestBackend) {
+	t.Helper()
+
+	var backend *rotateTestBackend
+	factory := func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		backend = &rotateTestBackend{}
+		backend.Backend = &framework.Backend{
+			Paths: []*framework.Path{
+				{
+					Pattern: "config/rotate-root",
+					Callbacks: map[logical.Operation]framework.OperationFunc{
+						logical.UpdateOperation: backend.pathRotateRoot,
+					},
+				},
+			},
+			BackendType: logical.TypeLogical,
+		}
+		if err := backend.Setup(ctx, conf); err != nil {
+			return nil, err
+		}
+		return backend, nil
+	}
+
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"rotatetest": factory,
+		},
+	})
+	err := c.mount(namespace.RootContext(nil), &MountEntry{
+		Table: mountTableType,
+		Path:  "rotatetest/",
+		Type:  "rotatetest",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, backend)
+
+	return c, backend
+}
+
+// TestRotation_Jobs ensures rotation jobs can be created, read, listed, run
+// and deleted through the sys/rotation endpoints.
+func TestRotation_Jobs(t *testing.T) {
+	c, backend := testCoreRotation(t)
+	ctx := namespace.RootContext(nil)
+	b := c.systemBackend
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "rotation/jobs/rotatetest")
+	req.Data["endpoint"] = "config/rotate-root"
+	req.Data["rotation_period"] = "1h"
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "rotation/jobs/rotatetest/")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, "rotatetest/", resp.Data["mount_path"])
+	require.Equal(t, "rotatetest", resp.Data["mount_type"])
+	require.Equal(t, "config/rotate-root", resp.Data["endpoint"])
+	require.Equal(t, int64(3600), resp.Data["rotation_period"])
+	require.Empty(t, resp.Data["last_rotation"])
+	require.NotEmpty(t, resp.Data["next_rotation"])
+
+	req = logical.TestRequest(t, logical.ListOperation, "rotation/jobs")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"rotatetest/"}, resp.Data["keys"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotation/rotate/rotatetest")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), backend.rotations.Load())
+	require.NotEmpty(t, resp.Data["last_rotation"])
+	require.Empty(t, resp.Data["last_error"])
+
+	// The job is persisted along with the outcome of the rotation
+	entry, err := c.barrier.Get(ctx, rotationJobPrefix+c.router.MatchingMountEntry(ctx, "rotatetest/").Accessor)
+	require.NoError(t, err)
+	var job rotationJob
+	require.NoError(t, entry.DecodeJSON(&job))
+	require.False(t, job.LastRotation.IsZero())
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "rotation/jobs/rotatetest")
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "rotation/jobs/rotatetest")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+// TestRotation_Failure ensures failed rotations are recorded, retried after
+// the retry interval, and reported with a rotation/failure event.
+func TestRotation_Failure(t *testing.T) {
+	c, backend := testCoreRotation(t)
+	ctx := namespace.RootContext(nil)
+	backend.fail.Store(true)
+
+	ch, cancel, err := c.events.Subscribe(ctx, namespace.RootNamespace, rotationFailureEventType, "")
+	require.NoError(t, err)
+	defer cancel()
+
+	accessor := c.router.MatchingMountEntry(ctx, "rotatetest/").Accessor
+	m := c.rotationManager
+	require.NoError(t, m.setJob(ctx, &rotationJob{
+		MountAccessor:  accessor,
+		Endpoint:       "config/rotate-root",
+		RotationPeriod: time.Hour,
+	}))
+
+	// The job isn't due until the rotation period has elapsed
+	m.rotateDue(time.Now())
+	require.Zero(t, m.job(accessor).LastAttempt)
+
+	m.rotateDue(time.Now().Add(2 * time.Hour))
+	job := m.job(accessor)
+	require.Equal(t, 1, job.ConsecutiveFailures)
+	require.Contains(t, job.LastError, "invalid credentials")
+	require.True(t, job.LastRotation.IsZero())
+	require.False(t, job.nextRotation().Before(job.LastAttempt.Add(rotationRetryInterval)))
+
+	select {
+	case ev := <-ch:
+		received := ev.Payload.(*logical.EventReceived)
+		require.Equal(t, "rotatetest", received.PluginInfo.Plugin)
+		require.Equal(t, accessor, received.PluginInfo.MountAccessor)
+		metadata := received.Event.Metadata.AsMap()
+		require.Equal(t, "1", metadata["consecutive_failures"])
+		require.Contains(t, metadata["error"], "invalid credentials")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for rotation failure event")
+	}
+
+	backend.fail.Store(false)
+	m.rotateDue(time.Now().Add(3 * time.Hour))
+	job = m.job(accessor)
+	require.Equal(t, int64(1), backend.rotations.Load())
+	require.Zero(t, job.ConsecutiveFailures)
+	require.Empty(t, job.LastError)
+	require.False(t, job.LastRotation.IsZero())
+}
+
+// TestRotation_Validation ensures invalid rotation jobs are rejected.
+func TestRotation_Validation(t *testing.T) {
+	c, _ := testCoreRotation(t)
+	ctx := namespace.RootContext(nil)
+
+	tests := map[string]struct {
+		path string
+		data map[string]interface{}
+	}{
+		"unknown-mount": {
+			path: "rotation/jobs/nonexistent",
+			data: map[string]interface{}{"endpoint": "config/rotate-root", "rotation_period": "1h"},
+		},
+		"mount-subpath": {
+			path: "rotation/jobs/rotatetest/config",
+			data: map[string]interface{}{"endpoint": "config/rotate-root", "rotation_period": "1h"},
+		},
+		"no-default-endpoint": {
+			path: "rotation/jobs/rotatetest",
+			data: map[string]interface{}{"rotation_period": "1h"},
+		},
+		"no-schedule": {
+			path: "rotation/jobs/rotatetest",
+			data: map[string]interface{}{"endpoint": "config/rotate-root"},
+		},
+		"period-and-schedule": {
+			path: "rotation/jobs/rotatetest",
+			data: map[string]interface{}{"endpoint": "config/rotate-root", "rotation_period": "1h", "rotation_schedule": "0 0 * * *"},
+		},
+		"invalid-schedule": {
+			path: "rotation/jobs/rotatetest",
+			data: map[string]interface{}{"endpoint": "config/rotate-root", "rotation_schedule": "every day"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.UpdateOperation, tt.path)
+			req.Data = tt.data
+			resp, err := c.systemBackend.HandleRequest(ctx, req)
+			require.ErrorIs(t, err, logical.ErrInvalidRequest)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	// Rotating a mount without a job is rejected
+	req := logical.TestRequest(t, logical.UpdateOperation, "rotation/rotate/rotatetest")
+	_, err := c.systemBackend.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+}
+
+// TestRotation_Schedule ensures jobs with a cron schedule are due at the next
+// scheduled time after their last rotation.
+func TestRotation_Schedule(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local)
+	job := &rotationJob{
+		Endpoint:         "config/rotate-root",
+		RotationSchedule: "0 0 * * *",
+		CreatedAt:        created,
+	}
+	require.NoError(t, job.validate())
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), job.nextRotation())
+
+	job.LastRotation = time.Date(2024, 1, 2, 0, 0, 1, 0, time.Local)
+	require.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.Local), job.nextRotation())
+}
+
+// TestRotation_DeletedMount ensures the job of a mount which no longer exists
+// is removed when it is due.
+func TestRotation_DeletedMount(t *testing.T) {
+	c, _ := testCoreRotation(t)
+	ctx := namespace.RootContext(nil)
+
+	accessor := c.router.MatchingMountEntry(ctx, "rotatetest/").Accessor
+	m := c.rotationManager
+	require.NoError(t, m.setJob(ctx, &rotationJob{
+		MountAccessor:  accessor,
+		Endpoint:       "config/rotate-root",
+		RotationPeriod: time.Hour,
+	}))
+	require.NoError(t, c.unmount(ctx, "rotatetest/"))
+
+	m.rotateDue(time.Now().Add(2 * time.Hour))
+	require.Nil(t, m.job(accessor))
+
+	entry, err := c.barrier.Get(ctx, rotationJobPrefix+accessor)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
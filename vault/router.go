@@ -776,12 +776,30 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		req.ControlGroup = originalControlGroup
 	}()
 
-	// Invoke the backend
+	// Invoke the backend, which handles the request over an RPC for the
+	// external plugins
+	spanName := "vault.backend"
+	if re.mountEntry.IsExternalPlugin() {
+		spanName = "vault.plugin"
+	}
+	if existenceCheck {
+		spanName += ".existence_check"
+	} else {
+		spanName += ".handle_request"
+	}
+	ctx, span := startRequestSpan(ctx, spanName,
+		spanAttrMountPoint.String(mount),
+		spanAttrMountType.String(re.mountEntry.Type),
+		spanAttrExternalPlugin.Bool(re.mountEntry.IsExternalPlugin()),
+		spanAttrPluginVersion.String(re.mountEntry.RunningVersion),
+	)
 	if existenceCheck {
 		ok, exists, err := re.backend.HandleExistenceCheck(ctx, req)
+		endSpan(span, err)
 		return nil, ok, exists, err
 	} else {
 		resp, err := re.backend.HandleRequest(ctx, req)
+		endSpan(span, err)
 		if resp != nil {
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
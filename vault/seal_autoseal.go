@@ -506,6 +506,11 @@ error and restart Vault.`)
 			if allHealthy {
 				if !lastTestOk {
 					d.logger.Info("seal backend is fully healthy again", "downtime", now.Sub(lastSeenOk).String())
+
+					// Finish re-wrapping with the seals that were unavailable
+					if err := d.core.postSealMigration(ctx); err != nil {
+						d.logger.Warn("failed to re-wrap seal keys after seal recovery", "error", err)
+					}
 				}
 				lastTestOk = true
 				lastSeenOk = now
@@ -14,6 +14,7 @@ import (
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/metricsutil"
 
 	"github.com/hashicorp/vault/sdk/physical"
@@ -167,6 +168,102 @@ func TestAutoSeal_UpgradeKeys(t *testing.T) {
 	check()
 }
 
+// TestAutoSeal_PostSealMigration verifies that adding a seal re-wraps the
+// stored keys with both seals, once the added seal is available.
+func TestAutoSeal_PostSealMigration(t *testing.T) {
+	t.Setenv(server.EnvVaultEnableSealHABeta, "true")
+
+	core, _, _ := TestCoreUnsealed(t)
+	pBackend := newTestBackend(t)
+	core.physical = pBackend
+	ctx := context.Background()
+
+	// Store the keys with a single seal
+	oldAccess, wrappers := seal.NewTestSeal(nil)
+	oldSeal := NewAutoSeal(oldAccess)
+	oldSeal.SetCore(core)
+	inkeys := [][]byte{[]byte("grist"), []byte("house")}
+	if err := oldSeal.SetStoredKeys(ctx, inkeys); err != nil {
+		t.Fatalf("SetStoredKeys: want no error, got %v", err)
+	}
+	if err := oldSeal.SetRecoveryKey(ctx, []byte("falernum")); err != nil {
+		t.Fatalf("SetRecoveryKey: want no error, got %v", err)
+	}
+
+	// Add a second seal, starting a new seal generation
+	addedWrapper := &seal.ToggleableWrapper{Wrapper: wrapping.NewTestWrapper(nil)}
+	addedWrapper.Wrapper.(*wrapping.TestWrapper).SetKeyId("added")
+	newAccess, err := seal.NewAccessFromSealWrappers(nil, 2, false, []*seal.SealWrapper{
+		seal.NewSealWrapper(wrappers[0], 1, "test-1", wrapping.WrapperTypeTest.String(), false, true),
+		seal.NewSealWrapper(addedWrapper, 2, "test-2", wrapping.WrapperTypeTest.String(), false, true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	autoSeal := NewAutoSeal(newAccess)
+	autoSeal.SetCore(core)
+	core.seal = autoSeal
+
+	storedSlots := func() int {
+		t.Helper()
+		entries := pBackend.entries[StoredBarrierKeysPath]
+		wrapped, err := UnmarshalSealWrappedValue(entries[len(entries)-1].Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(wrapped.GetSlots())
+	}
+
+	// The re-wrap cannot complete while the added seal is unavailable
+	addedWrapper.SetError(errors.New("unavailable"))
+	if err := core.postSealMigration(ctx); err == nil {
+		t.Fatal("expected re-wrap to fail")
+	}
+	if newAccess.GetSealGenerationInfo().IsRewrapped() {
+		t.Fatal("expected seal generation not to be re-wrapped")
+	}
+
+	// The health check marks the added seal healthy again once it recovers
+	checkHealth := func() {
+		t.Helper()
+		for _, sealWrapper := range newAccess.GetAllSealWrappersByPriority() {
+			if err := sealWrapper.CheckHealth(ctx, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	addedWrapper.SetError(nil)
+	checkHealth()
+	if err := core.postSealMigration(ctx); err != nil {
+		t.Fatalf("postSealMigration: want no error, got %v", err)
+	}
+	if want, got := 2, storedSlots(); want != got {
+		t.Fatalf("stored keys wrapped by unexpected number of seals: want %d, got %d", want, got)
+	}
+
+	sealGenInfo, err := PhysicalSealGenInfo(ctx, pBackend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealGenInfo == nil || sealGenInfo.Generation != 2 || !sealGenInfo.IsRewrapped() {
+		t.Fatalf("unexpected stored seal generation info: %+v", sealGenInfo)
+	}
+
+	// Either seal can now unwrap the stored keys on its own
+	for _, unavailable := range []*seal.ToggleableWrapper{wrappers[0], addedWrapper} {
+		unavailable.SetError(errors.New("unavailable"))
+		outkeys, err := autoSeal.GetStoredKeys(ctx)
+		if err != nil {
+			t.Fatalf("GetStoredKeys: want no error, got %v", err)
+		}
+		if !reflect.DeepEqual(inkeys, outkeys) {
+			t.Errorf("incorrect stored keys: want %v, got %v", inkeys, outkeys)
+		}
+		unavailable.SetError(nil)
+		checkHealth()
+	}
+}
+
 func TestAutoSeal_HealthCheck(t *testing.T) {
 	inmemSink := metrics.NewInmemSink(
 		1000000*time.Hour,
@@ -146,6 +146,9 @@ const (
 	SealConfigTypeHsmAutoDeprecated = SealConfigType(wrapping.WrapperTypeHsmAuto)
 	SealConfigTypeTransit           = SealConfigType(wrapping.WrapperTypeTransit)
 	SealConfigTypeGcpCkms           = SealConfigType(wrapping.WrapperTypeGcpCkms)
+	SealConfigTypeAzureKeyVault     = SealConfigType(wrapping.WrapperTypeAzureKeyVault)
+	SealConfigTypeOciKms            = SealConfigType(wrapping.WrapperTypeOciKms)
+	SealConfigTypeAliCloudKms       = SealConfigType(wrapping.WrapperTypeAliCloudKms)
 
 	// SealConfigTypeRecovery is an alias for SealConfigTypeShamir since all recovery seals are
 	// defaultSeals using shamir wrappers.
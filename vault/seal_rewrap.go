@@ -0,0 +1,293 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// sealRewrapCounters counts the seal wrapped entries a rewrap went through.
+type sealRewrapCounters struct {
+	Processed int64
+	Succeeded int64
+	Failed    int64
+}
+
+func (s *sealRewrapCounters) add(err error) {
+	s.Processed++
+	if err != nil {
+		s.Failed++
+	} else {
+		s.Succeeded++
+	}
+}
+
+func (s *sealRewrapCounters) response() map[string]interface{} {
+	return map[string]interface{}{
+		"processed": s.Processed,
+		"succeeded": s.Succeeded,
+		"failed":    s.Failed,
+	}
+}
+
+// sealRewrapper re-wraps the values protected by the seal with the current seal
+// configuration, e.g. after the key of a seal was rotated. The root key and
+// recovery key are re-wrapped first, followed by the seal wrapped entries in
+// storage in key order, which allows a paused rewrap to resume where it left
+// off.
+type sealRewrapper struct {
+	core   *Core
+	logger hclog.Logger
+
+	l       sync.Mutex
+	running bool
+	paused  bool
+	cancel  context.CancelFunc
+	doneCh  chan struct{}
+
+	// resumeAfter is the last storage key the rewrap went through
+	resumeAfter string
+	startTime   time.Time
+	endTime     time.Time
+	lastError   string
+	entries     sealRewrapCounters
+	mounts      map[string]*sealRewrapCounters
+}
+
+func newSealRewrapper(c *Core) *sealRewrapper {
+	return &sealRewrapper{
+		core:   c,
+		logger: c.logger.Named("seal-rewrap"),
+		mounts: make(map[string]*sealRewrapCounters),
+	}
+}
+
+// start starts a rewrap in the background, resuming a paused one. It returns
+// false if a rewrap is already running.
+func (r *sealRewrapper) start(ctx context.Context) bool {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.running {
+		return false
+	}
+	if !r.paused {
+		r.resumeAfter = ""
+		r.startTime = time.Now()
+		r.entries = sealRewrapCounters{}
+		r.mounts = make(map[string]*sealRewrapCounters)
+	}
+	r.running = true
+	r.paused = false
+	r.endTime = time.Time{}
+	r.lastError = ""
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.doneCh = make(chan struct{})
+	go r.run(ctx, r.resumeAfter)
+	return true
+}
+
+// pause stops the running rewrap, keeping its progress so that it can be
+// resumed.
+func (r *sealRewrapper) pause() error {
+	r.l.Lock()
+	if !r.running {
+		r.l.Unlock()
+		return errors.New("no seal rewrap is running")
+	}
+	r.paused = true
+	r.cancel()
+	doneCh := r.doneCh
+	r.l.Unlock()
+
+	<-doneCh
+	return nil
+}
+
+// stop pauses the running rewrap, if any, when the node steps down or seals.
+func (r *sealRewrapper) stop() {
+	if err := r.pause(); err == nil {
+		r.logger.Info("paused seal rewrap")
+	}
+}
+
+func (r *sealRewrapper) run(ctx context.Context, resumeAfter string) {
+	var err error
+	defer func() {
+		r.l.Lock()
+		defer r.l.Unlock()
+		r.running = false
+		r.endTime = time.Now()
+		if err != nil && !r.paused {
+			r.lastError = err.Error()
+		}
+		close(r.doneCh)
+	}()
+
+	if resumeAfter == "" {
+		r.logger.Info("starting seal rewrap")
+	} else {
+		r.logger.Info("resuming seal rewrap", "after", resumeAfter)
+	}
+
+	if err = r.rewrapKeys(ctx); err != nil {
+		r.logger.Error("failed to rewrap seal keys", "error", err)
+		return
+	}
+	if err = r.walk(ctx, "", resumeAfter); err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("seal rewrap failed", "error", err)
+		}
+		return
+	}
+
+	entries := r.counters()
+	r.logger.Info("seal rewrap complete", "processed", entries.Processed, "succeeded", entries.Succeeded, "failed", entries.Failed)
+	if entries.Failed > 0 {
+		err = errors.New("failed to rewrap some entries, check the server logs for details")
+		return
+	}
+
+	// All seal wrapped values are now wrapped by every configured seal
+	err = r.core.postSealMigration(ctx)
+}
+
+// rewrapKeys re-wraps the root key and the recovery key, which are always
+// wrapped by an auto seal.
+func (r *sealRewrapper) rewrapKeys(ctx context.Context) error {
+	autoSeal, ok := r.core.seal.(*autoSeal)
+	if !ok {
+		return nil
+	}
+	if !autoSeal.GetAccess().AllSealWrappersHealthy() {
+		return errors.New("cannot rewrap while a seal is unhealthy")
+	}
+	return autoSeal.UpgradeKeys(ctx)
+}
+
+// walk re-wraps the seal wrapped entries under the given prefix that sort
+// after resumeAfter. Keys are visited in lexical order.
+func (r *sealRewrapper) walk(ctx context.Context, prefix, resumeAfter string) error {
+	keys, err := r.core.underlyingPhysical.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		key = prefix + key
+		if strings.HasSuffix(key, "/") {
+			// Skip the folders that were fully processed before pausing
+			if key < resumeAfter && !strings.HasPrefix(resumeAfter, key) {
+				continue
+			}
+			if err := r.walk(ctx, key, resumeAfter); err != nil {
+				return err
+			}
+			continue
+		}
+		if key <= resumeAfter {
+			continue
+		}
+
+		r.rewrapEntry(ctx, key)
+	}
+	return nil
+}
+
+func (r *sealRewrapper) rewrapEntry(ctx context.Context, key string) {
+	wrapped, err := r.core.rewrapSealWrappedEntry(ctx, key)
+	if err != nil {
+		r.logger.Error("failed to rewrap entry", "key", key, "error", err)
+	}
+
+	mount := r.mountForKey(ctx, key)
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.resumeAfter = key
+	if !wrapped {
+		return
+	}
+	r.entries.add(err)
+	if _, ok := r.mounts[mount]; !ok {
+		r.mounts[mount] = &sealRewrapCounters{}
+	}
+	r.mounts[mount].add(err)
+}
+
+// mountForKey returns the path of the mount the given storage key belongs to.
+// Keys outside of any mount are attributed to their top-level folder, such as
+// core/.
+func (r *sealRewrapper) mountForKey(ctx context.Context, key string) string {
+	if _, mountPath, _, found := r.core.router.MatchingAPIPrefixByStoragePath(ctx, key); found {
+		return mountPath
+	}
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i+1]
+	}
+	return key
+}
+
+func (r *sealRewrapper) counters() sealRewrapCounters {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return r.entries
+}
+
+// status renders the rewrap status the way the status endpoint returns it.
+func (r *sealRewrapper) status() map[string]interface{} {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	mounts := make(map[string]interface{}, len(r.mounts))
+	for mount, counters := range r.mounts {
+		mounts[mount] = counters.response()
+	}
+
+	return map[string]interface{}{
+		"is_running": r.running,
+		"is_paused":  r.paused,
+		"entries":    r.entries.response(),
+		"mounts":     mounts,
+		"start_time": formatTime(r.startTime),
+		"end_time":   formatTime(r.endTime),
+		"last_error": r.lastError,
+	}
+}
+
+// rewrapSealWrappedEntry re-wraps the given storage entry with the current seal
+// configuration if it is seal wrapped and not up-to-date. It reports whether
+// the entry is seal wrapped.
+func (c *Core) rewrapSealWrappedEntry(ctx context.Context, key string) (bool, error) {
+	switch unwrapper := c.sealUnwrapper.(type) {
+	case *sealUnwrapper:
+		return unwrapper.rewrap(ctx, c.seal.GetAccess(), key)
+	case *transactionalSealUnwrapper:
+		return unwrapper.rewrap(ctx, c.seal.GetAccess(), key)
+	default:
+		return false, nil
+	}
+}
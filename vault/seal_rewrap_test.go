@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/seal"
+)
+
+// testSealRewrapCore returns an unsealed core using a test auto seal, along with
+// the test wrapper of that seal.
+func testSealRewrapCore(t *testing.T) (*Core, *wrapping.TestWrapper) {
+	t.Helper()
+
+	core, _, _ := TestCoreUnsealed(t)
+	ctx := context.Background()
+
+	access, wrappers := seal.NewTestSeal(nil)
+	autoSeal := NewAutoSeal(access)
+	autoSeal.SetCore(core)
+	core.seal = autoSeal
+	if err := autoSeal.SetStoredKeys(ctx, [][]byte{[]byte("grist")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := autoSeal.SetRecoveryKey(ctx, []byte("falernum")); err != nil {
+		t.Fatal(err)
+	}
+
+	return core, wrappers[0].Wrapper.(*wrapping.TestWrapper)
+}
+
+// putSealWrappedEntry stores a value seal wrapped with the core's seal.
+func putSealWrappedEntry(t *testing.T, core *Core, key string) {
+	t.Helper()
+	ctx := context.Background()
+
+	wrappedEntryValue, err := SealWrapValue(ctx, core.seal.GetAccess(), true, []byte("bar"), DisallowPartialSealWrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := MarshalSealWrappedValue(wrappedEntryValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := core.underlyingPhysical.Put(ctx, &physical.Entry{Key: key, Value: append(value, 's'), SealWrap: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sealWrappedEntryKeyID returns the key ID the given entry is seal wrapped with.
+func sealWrappedEntryKeyID(t *testing.T, core *Core, key string) string {
+	t.Helper()
+
+	entry, err := core.underlyingPhysical.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappedEntryValue, ok := UnmarshalSealWrappedValueWithCanary(entry.Value)
+	if !ok {
+		t.Fatalf("entry %q is not seal wrapped", key)
+	}
+	return wrappedEntryValue.GetSlots()[0].KeyInfo.KeyId
+}
+
+func waitForSealRewrap(t *testing.T, core *Core) map[string]interface{} {
+	t.Helper()
+
+	for i := 0; i < 50; i++ {
+		status := core.sealRewrap.status()
+		if !status["is_running"].(bool) {
+			return status
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("seal rewrap did not complete")
+	return nil
+}
+
+func TestSealRewrap(t *testing.T) {
+	core, wrapper := testSealRewrapCore(t)
+	ctx := namespace.RootContext(nil)
+
+	cubbyhole := core.router.MatchingMountEntry(ctx, "cubbyhole/")
+	key := "logical/" + cubbyhole.UUID + "/foo"
+	putSealWrappedEntry(t, core, key)
+
+	// Rotate the seal key
+	wrapper.SetKeyId("rotated")
+
+	if err := core.sealRewrap.pause(); err == nil {
+		t.Fatal("expected pausing an idle rewrap to fail")
+	}
+	if !core.sealRewrap.start(ctx) {
+		t.Fatal("expected rewrap to start")
+	}
+	status := waitForSealRewrap(t, core)
+
+	if status["last_error"] != "" {
+		t.Fatalf("unexpected rewrap error: %v", status["last_error"])
+	}
+	entries := status["entries"].(map[string]interface{})
+	if entries["processed"] != int64(1) || entries["succeeded"] != int64(1) || entries["failed"] != int64(0) {
+		t.Fatalf("unexpected entry counters: %v", entries)
+	}
+	mounts := status["mounts"].(map[string]interface{})
+	if len(mounts) != 1 || mounts["cubbyhole/"].(map[string]interface{})["succeeded"] != int64(1) {
+		t.Fatalf("unexpected mount counters: %v", mounts)
+	}
+
+	if keyID := sealWrappedEntryKeyID(t, core, key); keyID != "rotated" {
+		t.Fatalf("entry not rewrapped with the rotated key: got key ID %q", keyID)
+	}
+	storedKeys, err := core.underlyingPhysical.Get(ctx, StoredBarrierKeysPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappedEntryValue, err := UnmarshalSealWrappedValue(storedKeys.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID := wrappedEntryValue.GetSlots()[0].KeyInfo.KeyId; keyID != "rotated" {
+		t.Fatalf("stored keys not rewrapped with the rotated key: got key ID %q", keyID)
+	}
+}
+
+func TestSealRewrap_Resume(t *testing.T) {
+	core, wrapper := testSealRewrapCore(t)
+	ctx := namespace.RootContext(nil)
+
+	putSealWrappedEntry(t, core, "core/rewrap/a")
+	putSealWrappedEntry(t, core, "core/rewrap/b")
+	wrapper.SetKeyId("rotated")
+
+	// Pretend a previous rewrap was paused after the first entry
+	core.sealRewrap.l.Lock()
+	core.sealRewrap.paused = true
+	core.sealRewrap.resumeAfter = "core/rewrap/a"
+	core.sealRewrap.l.Unlock()
+
+	if !core.sealRewrap.start(ctx) {
+		t.Fatal("expected rewrap to resume")
+	}
+	status := waitForSealRewrap(t, core)
+
+	if status["is_paused"].(bool) {
+		t.Fatal("expected rewrap not to be paused after resuming")
+	}
+	entries := status["entries"].(map[string]interface{})
+	if entries["processed"] != int64(1) {
+		t.Fatalf("unexpected entry counters: %v", entries)
+	}
+	if keyID := sealWrappedEntryKeyID(t, core, "core/rewrap/a"); keyID != "static-key" {
+		t.Fatalf("entry processed before pausing was rewrapped again: got key ID %q", keyID)
+	}
+	if keyID := sealWrappedEntryKeyID(t, core, "core/rewrap/b"); keyID != "rotated" {
+		t.Fatalf("entry not rewrapped with the rotated key: got key ID %q", keyID)
+	}
+}
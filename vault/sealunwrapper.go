@@ -13,6 +13,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/seal"
 )
 
 // NewSealUnwrapper creates a new seal unwrapper
@@ -122,6 +123,46 @@ func (d *sealUnwrapper) Get(ctx context.Context, key string) (*physical.Entry, e
 	return unwrappedEntry, d.underlying.Put(ctx, unwrappedEntry)
 }
 
+// rewrap re-wraps the given entry with the seal access if it is seal wrapped and
+// not up-to-date. It reports whether the entry is seal wrapped.
+func (d *sealUnwrapper) rewrap(ctx context.Context, access seal.Access, key string) (bool, error) {
+	locksutil.LockForKey(d.locks, key).Lock()
+	defer locksutil.LockForKey(d.locks, key).Unlock()
+
+	entry, err := d.underlying.Get(ctx, key)
+	if err != nil || entry == nil {
+		return false, err
+	}
+
+	wrappedEntryValue, unmarshaled := UnmarshalSealWrappedValueWithCanary(entry.Value)
+	if !unmarshaled || !wrappedEntryValue.isEncrypted() {
+		return false, nil
+	}
+
+	pt, uptodate, err := UnsealWrapValue(ctx, access, key, wrappedEntryValue)
+	if err != nil {
+		return true, err
+	}
+	if uptodate {
+		return true, nil
+	}
+
+	wrappedEntryValue, err = SealWrapValue(ctx, access, true, pt, DisallowPartialSealWrap)
+	if err != nil {
+		return true, err
+	}
+	value, err := MarshalSealWrappedValue(wrappedEntryValue)
+	if err != nil {
+		return true, err
+	}
+
+	return true, d.underlying.Put(ctx, &physical.Entry{
+		Key:      key,
+		Value:    append(value, 's'),
+		SealWrap: true,
+	})
+}
+
 func (d *sealUnwrapper) Delete(ctx context.Context, key string) error {
 	locksutil.LockForKey(d.locks, key).Lock()
 	defer locksutil.LockForKey(d.locks, key).Unlock()
@@ -133,6 +174,10 @@ func (d *sealUnwrapper) List(ctx context.Context, prefix string) ([]string, erro
 	return d.underlying.List(ctx, prefix)
 }
 
+func (d *sealUnwrapper) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, d.underlying, prefix, after, limit)
+}
+
 func (d *transactionalSealUnwrapper) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
 	// Collect keys that need to be locked
 	var keys []string
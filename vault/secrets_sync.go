@@ -0,0 +1,876 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/secretsync"
+)
+
+const (
+	// secretsSyncDestinationPrefix, secretsSyncAssociationPrefix and
+	// secretsSyncStatusPrefix are the barrier prefixes the destinations, their
+	// associations and the sync status of their secrets are stored under.
+	secretsSyncDestinationPrefix = "core/sync/destinations/"
+	secretsSyncAssociationPrefix = "core/sync/associations/"
+	secretsSyncStatusPrefix      = "core/sync/status/"
+
+	// secretsSyncEventPattern matches the events sent by KV v2 mounts when
+	// their secrets are changed.
+	secretsSyncEventPattern = "kv-v2/*"
+
+	secretsSyncStatusSynced   = "SYNCED"
+	secretsSyncStatusUnsynced = "UNSYNCED"
+	secretsSyncStatusFailed   = "FAILED"
+	secretsSyncStatusDrifted  = "DRIFTED"
+)
+
+var (
+	// secretsSyncReconcileInterval is how often the secrets of the
+	// destinations are reconciled with their source, and checked for drift.
+	secretsSyncReconcileInterval = 10 * time.Minute
+
+	errSecretsSyncUnavailable = errors.New("secrets sync is only available on the active node")
+)
+
+// secretsSyncAssociation associates the secrets of a KV v2 mount to a
+// destination. The secret name is either the path of a single secret, or a
+// glob pattern matching the paths of several ones. The keys synced can be
+// filtered with glob patterns.
+type secretsSyncAssociation struct {
+	Accessor   string    `json:"accessor"`
+	SecretName string    `json:"secret_name"`
+	Keys       []string  `json:"keys,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// secretsSyncStatus is the sync status of a secret in a destination.
+type secretsSyncStatus struct {
+	Accessor   string `json:"accessor"`
+	SecretName string `json:"secret_name"`
+
+	// Association is the secret name of the association the secret is
+	// synced by.
+	Association string    `json:"association"`
+	SyncStatus  string    `json:"sync_status"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Error       string    `json:"error,omitempty"`
+
+	// Checksums are the SHA256 sums of the values of the external secrets
+	// the secret is synced as, keyed by their names.
+	Checksums map[string]string `json:"checksums,omitempty"`
+}
+
+// secretsSyncDestination is a destination along with its associations.
+type secretsSyncDestination struct {
+	config       *secretsync.Destination
+	associations []*secretsSyncAssociation
+
+	// client is created when the destination is first synced
+	client secretsync.Client
+}
+
+// secretsSyncSecret identifies a secret of a KV v2 mount.
+type secretsSyncSecret struct {
+	accessor string
+	path     string
+}
+
+// secretsSyncManager syncs the secrets associated to the destinations on the
+// active node. Secrets are synced when the events of their mount report they
+// changed, and periodically reconciled, which also detects the external
+// secrets which drifted from the values synced.
+type secretsSyncManager struct {
+	core   *Core
+	logger hclog.Logger
+	ctx    context.Context
+
+	// l guards the destinations, whose associations are replaced rather
+	// than modified.
+	l            sync.RWMutex
+	destinations map[string]*secretsSyncDestination
+
+	// syncLock serializes the changes to the destinations and the syncs of
+	// their secrets.
+	syncLock sync.Mutex
+
+	// newClient creates the clients of the destinations.
+	newClient func(context.Context, *secretsync.Destination) (secretsync.Client, error)
+
+	pendingLock sync.Mutex
+	pending     map[secretsSyncSecret]struct{}
+	notifyCh    chan struct{}
+
+	lastReconcile time.Time
+
+	cancelSubscription context.CancelFunc
+	stopCh             chan struct{}
+	doneCh             chan struct{}
+}
+
+func secretsSyncDestinationKey(typ, name string) string {
+	return typ + "/" + name
+}
+
+// setupSecretsSync loads the destinations and their associations, and starts
+// syncing their secrets as they change.
+func (c *Core) setupSecretsSync(ctx context.Context) error {
+	m := &secretsSyncManager{
+		core:         c,
+		logger:       c.logger.Named("secrets-sync"),
+		ctx:          ctx,
+		destinations: make(map[string]*secretsSyncDestination),
+		newClient:    secretsync.NewClient,
+		pending:      make(map[secretsSyncSecret]struct{}),
+		notifyCh:     make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+
+	for _, typ := range secretsync.Types {
+		names, err := c.barrier.List(ctx, secretsSyncDestinationPrefix+typ+"/")
+		if err != nil {
+			return fmt.Errorf("failed to list sync destinations: %w", err)
+		}
+		for _, name := range names {
+			dest, err := m.loadDestination(ctx, typ, name)
+			if err != nil {
+				return err
+			}
+			if dest != nil {
+				m.destinations[secretsSyncDestinationKey(typ, name)] = dest
+			}
+		}
+	}
+
+	events, cancel, err := c.events.Subscribe(ctx, namespace.RootNamespace, secretsSyncEventPattern, "")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to KV v2 events: %w", err)
+	}
+	m.cancelSubscription = cancel
+
+	go m.receive(events)
+	go m.run()
+
+	c.secretsSync = m
+	return nil
+}
+
+// stopSecretsSync stops syncing secrets, waiting for in-flight syncs to
+// finish.
+func (c *Core) stopSecretsSync() {
+	m := c.secretsSync
+	if m == nil {
+		return
+	}
+	c.secretsSync = nil
+
+	m.cancelSubscription()
+	close(m.stopCh)
+	<-m.doneCh
+}
+
+func (m *secretsSyncManager) loadDestination(ctx context.Context, typ, name string) (*secretsSyncDestination, error) {
+	key := secretsSyncDestinationKey(typ, name)
+
+	entry, err := m.core.barrier.Get(ctx, secretsSyncDestinationPrefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync destination %q: %w", key, err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	dest := &secretsSyncDestination{
+		config: &secretsync.Destination{},
+	}
+	if err := entry.DecodeJSON(dest.config); err != nil {
+		return nil, fmt.Errorf("failed to decode sync destination %q: %w", key, err)
+	}
+
+	entry, err = m.core.barrier.Get(ctx, secretsSyncAssociationPrefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read associations of sync destination %q: %w", key, err)
+	}
+	if entry != nil {
+		if err := entry.DecodeJSON(&dest.associations); err != nil {
+			return nil, fmt.Errorf("failed to decode associations of sync destination %q: %w", key, err)
+		}
+	}
+	return dest, nil
+}
+
+// receive queues the secrets changed according to the KV v2 events, for the
+// sync loop to sync them. Receiving events never waits for syncs, so that
+// the subscription doesn't fall behind.
+func (m *secretsSyncManager) receive(events <-chan *eventlogger.Event) {
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case e := <-events:
+			received, ok := e.Payload.(*logical.EventReceived)
+			if !ok || received.PluginInfo == nil || received.Event == nil {
+				continue
+			}
+			secretPath, ok := secretsSyncEventSecretPath(received)
+			if !ok {
+				continue
+			}
+
+			m.pendingLock.Lock()
+			m.pending[secretsSyncSecret{accessor: received.PluginInfo.MountAccessor, path: secretPath}] = struct{}{}
+			m.pendingLock.Unlock()
+
+			select {
+			case m.notifyCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// secretsSyncEventSecretPath returns the path of the secret a KV v2 event is
+// about, relative to its mount. The path in the metadata of the events is
+// prefixed with the mount path and the endpoint, e.g. data/ or metadata/.
+func secretsSyncEventSecretPath(received *logical.EventReceived) (string, bool) {
+	path := received.Event.GetMetadata().GetFields()["path"].GetStringValue()
+	path, ok := strings.CutPrefix(path, received.PluginInfo.MountPath)
+	if !ok {
+		return "", false
+	}
+	_, secretPath, ok := strings.Cut(path, "/")
+	if !ok || secretPath == "" {
+		return "", false
+	}
+	return secretPath, true
+}
+
+func (m *secretsSyncManager) run() {
+	defer close(m.doneCh)
+
+	ticker := time.NewTicker(secretsSyncReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.notifyCh:
+			m.syncPending()
+		case <-ticker.C:
+			m.reconcileAll(false)
+		case <-m.stopCh:
+			return
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// syncPending syncs the secrets changed since the last call to the
+// destinations they are associated to.
+func (m *secretsSyncManager) syncPending() {
+	m.pendingLock.Lock()
+	pending := m.pending
+	m.pending = make(map[secretsSyncSecret]struct{})
+	m.pendingLock.Unlock()
+
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	for secret := range pending {
+		for _, dest := range m.listDestinations() {
+			assoc := dest.matchAssociation(secret.accessor, secret.path)
+			if assoc == nil {
+				continue
+			}
+			if _, err := m.syncSecret(m.ctx, dest, assoc, secret.accessor, secret.path, false); err != nil {
+				m.logger.Error("failed to sync secret", "destination", secretsSyncDestinationKey(dest.config.Type, dest.config.Name),
+					"mount_accessor", secret.accessor, "secret_name", secret.path, "error", err)
+			}
+		}
+	}
+}
+
+// reconcileAll reconciles the secrets of all the destinations.
+func (m *secretsSyncManager) reconcileAll(force bool) {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	for _, dest := range m.listDestinations() {
+		if err := m.reconcile(m.ctx, dest, force); err != nil {
+			m.logger.Error("failed to reconcile sync destination", "destination", secretsSyncDestinationKey(dest.config.Type, dest.config.Name), "error", err)
+		}
+	}
+
+	m.l.Lock()
+	m.lastReconcile = time.Now()
+	m.l.Unlock()
+}
+
+// reconcile syncs the secrets matching the associations of the destination,
+// removes the ones which no longer match any association, and checks the
+// external secrets which are up to date for drift. Forcing the reconcile
+// writes all the external secrets again instead. It must be called with the
+// sync lock held.
+func (m *secretsSyncManager) reconcile(ctx context.Context, dest *secretsSyncDestination, force bool) error {
+	statuses, err := m.listStatuses(ctx, dest.config)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	synced := make(map[secretsSyncSecret]struct{})
+	for _, assoc := range dest.associations {
+		paths, err := m.matchingSecrets(ctx, assoc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, path := range paths {
+			secret := secretsSyncSecret{accessor: assoc.Accessor, path: path}
+			if _, ok := synced[secret]; ok {
+				continue
+			}
+			synced[secret] = struct{}{}
+
+			// The first association matching a secret syncs it
+			if owner := dest.matchAssociation(assoc.Accessor, path); owner != assoc {
+				continue
+			}
+			if _, err := m.syncSecret(ctx, dest, assoc, assoc.Accessor, path, force); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		secret := secretsSyncSecret{accessor: status.Accessor, path: status.SecretName}
+		if _, ok := synced[secret]; ok {
+			continue
+		}
+		// The secret may have been deleted, or no longer matches any
+		// association, which was either removed or changed
+		assoc := dest.matchAssociation(status.Accessor, status.SecretName)
+		if _, err := m.syncSecret(ctx, dest, assoc, status.Accessor, status.SecretName, force); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// syncSecret syncs a secret to the destination, removing the external
+// secrets it was synced as but no longer is, and records the outcome in its
+// sync status. Without an association, the secret is removed from the
+// destination along with its status. External secrets already holding the
+// current value are only written again when forced, otherwise they are
+// checked for drift. It must be called with the sync lock held.
+func (m *secretsSyncManager) syncSecret(ctx context.Context, dest *secretsSyncDestination, assoc *secretsSyncAssociation, accessor, secretPath string, force bool) (*secretsSyncStatus, error) {
+	status, err := m.loadStatus(ctx, dest.config, accessor, secretPath)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		if assoc == nil {
+			return nil, nil
+		}
+		status = &secretsSyncStatus{
+			Accessor:   accessor,
+			SecretName: secretPath,
+		}
+	}
+
+	var values map[string]string
+	syncErr := func() error {
+		if assoc != nil {
+			status.Association = assoc.SecretName
+			values, err = m.secretValues(ctx, dest.config, assoc, secretPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		client, err := m.client(ctx, dest)
+		if err != nil {
+			return err
+		}
+
+		changed := force || status.SyncStatus == secretsSyncStatusFailed
+		for name, value := range values {
+			sum := secretsSyncChecksum(value)
+			if !force && status.Checksums[name] == sum {
+				continue
+			}
+			changed = true
+			if err := client.Set(ctx, name, value); err != nil {
+				return fmt.Errorf("failed to write secret %q: %w", name, err)
+			}
+			if status.Checksums == nil {
+				status.Checksums = make(map[string]string)
+			}
+			status.Checksums[name] = sum
+		}
+		for name := range status.Checksums {
+			if _, ok := values[name]; ok {
+				continue
+			}
+			changed = true
+			if err := client.Delete(ctx, name); err != nil {
+				return fmt.Errorf("failed to delete secret %q: %w", name, err)
+			}
+			delete(status.Checksums, name)
+		}
+
+		status.Error = ""
+		switch {
+		case len(values) == 0:
+			status.SyncStatus = secretsSyncStatusUnsynced
+		case changed:
+			status.SyncStatus = secretsSyncStatusSynced
+		default:
+			drift, err := m.checkDrift(ctx, client, status)
+			if err != nil {
+				return err
+			}
+			if drift != "" {
+				status.SyncStatus = secretsSyncStatusDrifted
+				status.Error = drift
+				m.logger.Warn("synced secret drifted", "destination", secretsSyncDestinationKey(dest.config.Type, dest.config.Name),
+					"mount_accessor", accessor, "secret_name", secretPath, "drift", drift)
+			} else {
+				status.SyncStatus = secretsSyncStatusSynced
+			}
+		}
+		return nil
+	}()
+
+	status.UpdatedAt = time.Now()
+	if syncErr != nil {
+		status.SyncStatus = secretsSyncStatusFailed
+		status.Error = syncErr.Error()
+	}
+
+	if assoc == nil && syncErr == nil {
+		return nil, m.core.barrier.Delete(ctx, secretsSyncStatusKey(dest.config, accessor, secretPath))
+	}
+	if err := m.persistStatus(ctx, dest.config, status); err != nil {
+		return nil, err
+	}
+	return status, syncErr
+}
+
+// checkDrift compares the external secrets of a synced secret with the values
+// they were synced with, returning a description of the drift, if any.
+// Secrets of destinations which can't be read back are only checked for
+// existence.
+func (m *secretsSyncManager) checkDrift(ctx context.Context, client secretsync.Client, status *secretsSyncStatus) (string, error) {
+	names := make([]string, 0, len(status.Checksums))
+	for name := range status.Checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := client.Get(ctx, name)
+		switch {
+		case errors.Is(err, secretsync.ErrSecretNotFound):
+			return fmt.Sprintf("secret %q was deleted from the destination", name), nil
+		case errors.Is(err, secretsync.ErrWriteOnly):
+			continue
+		case err != nil:
+			return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+		case secretsSyncChecksum(value) != status.Checksums[name]:
+			return fmt.Sprintf("secret %q was modified in the destination", name), nil
+		}
+	}
+	return "", nil
+}
+
+// secretValues reads a secret of a KV v2 mount and returns the external
+// secrets it is synced as to the destination. There are none when the secret
+// or its mount was deleted.
+func (m *secretsSyncManager) secretValues(ctx context.Context, config *secretsync.Destination, assoc *secretsSyncAssociation, secretPath string) (map[string]string, error) {
+	entry := m.core.router.MatchingMountByAccessor(assoc.Accessor)
+	if entry == nil {
+		return nil, nil
+	}
+
+	resp, err := m.core.router.Route(namespace.ContextWithNamespace(ctx, entry.Namespace()), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      entry.Path + "data/" + secretPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+	if resp == nil {
+		return nil, nil
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to read secret: %w", resp.Error())
+	}
+
+	// Deleted and destroyed versions have no data
+	data, _ := resp.Data["data"].(map[string]interface{})
+	data = secretsync.FilterKeys(data, assoc.Keys)
+
+	return config.Values(&secretsync.SecretNameData{
+		MountAccessor: entry.Accessor,
+		MountPath:     strings.TrimSuffix(entry.Path, "/"),
+		SecretPath:    secretPath,
+	}, data)
+}
+
+// matchingSecrets returns the paths of the secrets matching an association.
+// The secrets of glob patterns are listed from the longest directory of the
+// pattern without wildcards.
+func (m *secretsSyncManager) matchingSecrets(ctx context.Context, assoc *secretsSyncAssociation) ([]string, error) {
+	wildcard := strings.Index(assoc.SecretName, "*")
+	if wildcard < 0 {
+		return []string{assoc.SecretName}, nil
+	}
+
+	entry := m.core.router.MatchingMountByAccessor(assoc.Accessor)
+	if entry == nil {
+		return nil, nil
+	}
+	ctx = namespace.ContextWithNamespace(ctx, entry.Namespace())
+
+	var paths []string
+	dirs := []string{assoc.SecretName[:strings.LastIndex(assoc.SecretName[:wildcard], "/")+1]}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		resp, err := m.core.router.Route(ctx, &logical.Request{
+			Operation: logical.ListOperation,
+			Path:      entry.Path + "metadata/" + dir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		if resp == nil {
+			continue
+		}
+		keys, _ := resp.Data["keys"].([]string)
+		for _, key := range keys {
+			switch {
+			case strings.HasSuffix(key, "/"):
+				dirs = append(dirs, dir+key)
+			case secretsync.MatchSecret(assoc.SecretName, dir+key):
+				paths = append(paths, dir+key)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// client returns the client of the destination, creating it if needed.
+func (m *secretsSyncManager) client(ctx context.Context, dest *secretsSyncDestination) (secretsync.Client, error) {
+	if dest.client != nil {
+		return dest.client, nil
+	}
+
+	client, err := m.newClient(ctx, dest.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	dest.client = client
+	return client, nil
+}
+
+// matchAssociation returns the first association of the destination matching
+// a secret, or nil if there is none.
+func (dest *secretsSyncDestination) matchAssociation(accessor, secretPath string) *secretsSyncAssociation {
+	for _, assoc := range dest.associations {
+		if assoc.Accessor == accessor && secretsync.MatchSecret(assoc.SecretName, secretPath) {
+			return assoc
+		}
+	}
+	return nil
+}
+
+// listDestinations returns the destinations, sorted by type and name.
+func (m *secretsSyncManager) listDestinations() []*secretsSyncDestination {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	keys := make([]string, 0, len(m.destinations))
+	for key := range m.destinations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	dests := make([]*secretsSyncDestination, 0, len(keys))
+	for _, key := range keys {
+		dests = append(dests, m.destinations[key])
+	}
+	return dests
+}
+
+// destination returns the destination of the given type and name, or nil if
+// there is none.
+func (m *secretsSyncManager) destination(typ, name string) *secretsSyncDestination {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	return m.destinations[secretsSyncDestinationKey(typ, name)]
+}
+
+// setDestination creates or replaces a destination. The secrets of an
+// existing destination are written again, as their names or the store they
+// are synced to may have changed.
+func (m *secretsSyncManager) setDestination(ctx context.Context, config *secretsync.Destination) error {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	key := secretsSyncDestinationKey(config.Type, config.Name)
+	entry, err := logical.StorageEntryJSON(secretsSyncDestinationPrefix+key, config)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync destination: %w", err)
+	}
+	if err := m.core.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist sync destination: %w", err)
+	}
+
+	dest := &secretsSyncDestination{config: config}
+	m.l.Lock()
+	existing, ok := m.destinations[key]
+	if ok {
+		dest.associations = existing.associations
+	}
+	m.destinations[key] = dest
+	m.l.Unlock()
+
+	if ok {
+		if err := m.reconcile(ctx, dest, true); err != nil {
+			m.logger.Error("failed to sync secrets to updated destination", "destination", key, "error", err)
+		}
+	}
+	return nil
+}
+
+// deleteDestination removes a destination, which must not have associations
+// left.
+func (m *secretsSyncManager) deleteDestination(ctx context.Context, typ, name string) error {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	key := secretsSyncDestinationKey(typ, name)
+	dest := m.destination(typ, name)
+	if dest == nil {
+		return nil
+	}
+	if len(dest.associations) > 0 {
+		return fmt.Errorf("destination %q still has associations", key)
+	}
+
+	for _, prefix := range []string{secretsSyncDestinationPrefix, secretsSyncAssociationPrefix} {
+		if err := m.core.barrier.Delete(ctx, prefix+key); err != nil {
+			return fmt.Errorf("failed to delete sync destination: %w", err)
+		}
+	}
+
+	m.l.Lock()
+	delete(m.destinations, key)
+	m.l.Unlock()
+	return nil
+}
+
+// setAssociation adds an association to a destination, or replaces the
+// equivalent one, and syncs the secrets it matches, writing them again if
+// they were already synced.
+func (m *secretsSyncManager) setAssociation(ctx context.Context, typ, name string, assoc *secretsSyncAssociation) error {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	dest := m.destination(typ, name)
+	if dest == nil {
+		return fmt.Errorf("destination %q not found", secretsSyncDestinationKey(typ, name))
+	}
+
+	associations := make([]*secretsSyncAssociation, 0, len(dest.associations)+1)
+	replaced := false
+	for _, existing := range dest.associations {
+		if existing.Accessor == assoc.Accessor && existing.SecretName == assoc.SecretName {
+			assoc.CreatedAt = existing.CreatedAt
+			associations = append(associations, assoc)
+			replaced = true
+			continue
+		}
+		associations = append(associations, existing)
+	}
+	if !replaced {
+		assoc.CreatedAt = time.Now()
+		associations = append(associations, assoc)
+	}
+	if err := m.persistAssociations(ctx, dest, associations); err != nil {
+		return err
+	}
+
+	// Failed syncs are recorded in the status of their secrets, and retried
+	// when the secrets change or are reconciled
+	paths, err := m.matchingSecrets(ctx, assoc)
+	if err != nil {
+		m.logger.Error("failed to list associated secrets", "destination", secretsSyncDestinationKey(typ, name), "error", err)
+	}
+	for _, path := range paths {
+		if owner := dest.matchAssociation(assoc.Accessor, path); owner != assoc {
+			continue
+		}
+		if _, err := m.syncSecret(ctx, dest, assoc, assoc.Accessor, path, true); err != nil {
+			m.logger.Error("failed to sync secret", "destination", secretsSyncDestinationKey(typ, name),
+				"mount_accessor", assoc.Accessor, "secret_name", path, "error", err)
+		}
+	}
+	if replaced {
+		// Secrets may no longer match the keys of the association
+		if err := m.reconcile(ctx, dest, false); err != nil {
+			m.logger.Error("failed to reconcile sync destination", "destination", secretsSyncDestinationKey(typ, name), "error", err)
+		}
+	}
+	return nil
+}
+
+// removeAssociation removes an association from a destination, along with
+// the secrets it synced, unless another association of the destination
+// matches them.
+func (m *secretsSyncManager) removeAssociation(ctx context.Context, typ, name, accessor, secretName string) error {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	dest := m.destination(typ, name)
+	if dest == nil {
+		return fmt.Errorf("destination %q not found", secretsSyncDestinationKey(typ, name))
+	}
+
+	associations := make([]*secretsSyncAssociation, 0, len(dest.associations))
+	for _, existing := range dest.associations {
+		if existing.Accessor != accessor || existing.SecretName != secretName {
+			associations = append(associations, existing)
+		}
+	}
+	if len(associations) == len(dest.associations) {
+		return nil
+	}
+	if err := m.persistAssociations(ctx, dest, associations); err != nil {
+		return err
+	}
+
+	statuses, err := m.listStatuses(ctx, dest.config)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.Accessor != accessor || status.Association != secretName {
+			continue
+		}
+		assoc := dest.matchAssociation(status.Accessor, status.SecretName)
+		if _, err := m.syncSecret(ctx, dest, assoc, status.Accessor, status.SecretName, false); err != nil {
+			m.logger.Error("failed to remove synced secret", "destination", secretsSyncDestinationKey(typ, name),
+				"mount_accessor", status.Accessor, "secret_name", status.SecretName, "error", err)
+		}
+	}
+	return nil
+}
+
+func (m *secretsSyncManager) persistAssociations(ctx context.Context, dest *secretsSyncDestination, associations []*secretsSyncAssociation) error {
+	key := secretsSyncAssociationPrefix + secretsSyncDestinationKey(dest.config.Type, dest.config.Name)
+	entry, err := logical.StorageEntryJSON(key, associations)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync associations: %w", err)
+	}
+	if err := m.core.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist sync associations: %w", err)
+	}
+
+	m.l.Lock()
+	dest.associations = associations
+	m.l.Unlock()
+	return nil
+}
+
+// secretsSyncStatusKey returns the barrier key of the sync status of a secret
+// in a destination, which is hashed as secret paths are nested.
+func secretsSyncStatusKey(config *secretsync.Destination, accessor, secretPath string) string {
+	sum := sha256.Sum256([]byte(accessor + "/" + secretPath))
+	return secretsSyncStatusPrefix + secretsSyncDestinationKey(config.Type, config.Name) + "/" + hex.EncodeToString(sum[:])
+}
+
+func (m *secretsSyncManager) loadStatus(ctx context.Context, config *secretsync.Destination, accessor, secretPath string) (*secretsSyncStatus, error) {
+	entry, err := m.core.barrier.Get(ctx, secretsSyncStatusKey(config, accessor, secretPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync status: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var status secretsSyncStatus
+	if err := entry.DecodeJSON(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode sync status: %w", err)
+	}
+	return &status, nil
+}
+
+func (m *secretsSyncManager) persistStatus(ctx context.Context, config *secretsync.Destination, status *secretsSyncStatus) error {
+	entry, err := logical.StorageEntryJSON(secretsSyncStatusKey(config, status.Accessor, status.SecretName), status)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync status: %w", err)
+	}
+	if err := m.core.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist sync status: %w", err)
+	}
+	return nil
+}
+
+// listStatuses returns the sync statuses of the secrets of a destination,
+// sorted by mount accessor and secret name.
+func (m *secretsSyncManager) listStatuses(ctx context.Context, config *secretsync.Destination) ([]*secretsSyncStatus, error) {
+	prefix := secretsSyncStatusPrefix + secretsSyncDestinationKey(config.Type, config.Name) + "/"
+	keys, err := m.core.barrier.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync statuses: %w", err)
+	}
+
+	statuses := make([]*secretsSyncStatus, 0, len(keys))
+	for _, key := range keys {
+		entry, err := m.core.barrier.Get(ctx, prefix+key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sync status: %w", err)
+		}
+		if entry == nil {
+			continue
+		}
+		var status secretsSyncStatus
+		if err := entry.DecodeJSON(&status); err != nil {
+			return nil, fmt.Errorf("failed to decode sync status: %w", err)
+		}
+		statuses = append(statuses, &status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Accessor != statuses[j].Accessor {
+			return statuses[i].Accessor < statuses[j].Accessor
+		}
+		return statuses[i].SecretName < statuses[j].SecretName
+	})
+	return statuses, nil
+}
+
+func secretsSyncChecksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,304 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/secretsync"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySyncClient is a destination client keeping the secrets in memory.
+type memorySyncClient struct {
+	l       sync.Mutex
+	secrets map[string]string
+}
+
+func (c *memorySyncClient) Set(_ context.Context, name, value string) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.secrets[name] = value
+	return nil
+}
+
+func (c *memorySyncClient) Get(_ context.Context, name string) (string, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	value, ok := c.secrets[name]
+	if !ok {
+		return "", secretsync.ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (c *memorySyncClient) Delete(_ context.Context, name string) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	delete(c.secrets, name)
+	return nil
+}
+
+func (c *memorySyncClient) snapshot() map[string]string {
+	c.l.Lock()
+	defer c.l.Unlock()
+	secrets := make(map[string]string, len(c.secrets))
+	for name, value := range c.secrets {
+		secrets[name] = value
+	}
+	return secrets
+}
+
+// testCoreSecretsSync returns a core with a KV v2 secrets engine mounted at
+// kv/, whose destinations all sync to the returned in-memory client, along
+// with the accessor of the mount.
+func testCoreSecretsSync(t *testing.T) (*Core, *memorySyncClient, string) {
+	t.Helper()
+
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	client := &memorySyncClient{secrets: make(map[string]string)}
+	c.secretsSync.newClient = func(context.Context, *secretsync.Destination) (secretsync.Client, error) {
+		return client, nil
+	}
+
+	err := c.mount(ctx, &MountEntry{
+		Table:   mountTableType,
+		Path:    "kv/",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	})
+	require.NoError(t, err)
+	accessor := c.router.MatchingMountEntry(ctx, "kv/").Accessor
+
+	// Writes fail until the mount is done upgrading
+	corehelpers.RetryUntil(t, 10*time.Second, func() error {
+		return testSecretsSyncWrite(c, "probe", map[string]interface{}{"foo": "bar"})
+	})
+	return c, client, accessor
+}
+
+func testSecretsSyncWrite(c *Core, path string, data map[string]interface{}) error {
+	resp, err := c.router.Route(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "kv/data/" + path,
+		Data: map[string]interface{}{
+			"data": data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return resp.Error()
+	}
+	return nil
+}
+
+func testSecretsSyncRequest(t *testing.T, c *Core, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	req := logical.TestRequest(t, op, path)
+	req.Data = data
+	resp, err := c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	return resp
+}
+
+// TestSecretsSync_Destinations ensures destinations can be written, read with
+// their sensitive connection details masked, listed and deleted once they
+// have no associations.
+func TestSecretsSync_Destinations(t *testing.T) {
+	c, _, _ := testCoreSecretsSync(t)
+	ctx := namespace.RootContext(nil)
+	b := c.systemBackend
+
+	resp := testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store", map[string]interface{}{
+		"access_key_id":     "AKIA",
+		"secret_access_key": "secret",
+		"region":            "us-west-1",
+	})
+	require.Equal(t, "*****", resp.Data["connection_details"].(map[string]interface{})["secret_access_key"])
+	require.Equal(t, secretsync.GranularitySecretPath, resp.Data["granularity"])
+
+	// Updates keep the omitted fields
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store", map[string]interface{}{
+		"region": "eu-west-1",
+	})
+	dest := c.secretsSync.destination("aws-sm", "my-store")
+	require.Equal(t, "secret", dest.config.AWSSecretAccessKey)
+	require.Equal(t, "eu-west-1", dest.config.AWSRegion)
+
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/gh/my-repo", map[string]interface{}{
+		"access_token":     "github_pat",
+		"repository_owner": "hashicorp",
+		"repository_name":  "vault",
+	})
+
+	resp = testSecretsSyncRequest(t, c, logical.ListOperation, "sync/destinations", nil)
+	require.Equal(t, []string{"aws-sm", "gh"}, resp.Data["keys"])
+	require.Equal(t, []string{"my-store"}, resp.Data["key_info"].(map[string]interface{})["aws-sm"])
+
+	resp = testSecretsSyncRequest(t, c, logical.ReadOperation, "sync/destinations/gh/my-repo", nil)
+	require.Equal(t, map[string]interface{}{
+		"access_token":     "*****",
+		"repository_owner": "hashicorp",
+		"repository_name":  "vault",
+		"api_url":          "",
+	}, resp.Data["connection_details"])
+
+	for name, data := range map[string]map[string]interface{}{
+		"invalid type":           nil,
+		"other type field":       {"access_token": "github_pat"},
+		"missing required field": {"repository_owner": "hashicorp"},
+		"invalid granularity":    {"granularity": "secret-value"},
+		"invalid template":       {"secret_name_template": "{{ .Missing }}"},
+	} {
+		path := "sync/destinations/aws-sm/invalid"
+		if name == "invalid type" {
+			path = "sync/destinations/vault/invalid"
+		} else if name == "missing required field" {
+			path = "sync/destinations/gh/invalid"
+		}
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Data = data
+		_, err := b.HandleRequest(ctx, req)
+		require.ErrorIs(t, err, logical.ErrInvalidRequest, name)
+	}
+
+	// Destinations with associations can't be deleted
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store/associations/set", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "my-secret",
+	})
+	req := logical.TestRequest(t, logical.DeleteOperation, "sync/destinations/aws-sm/my-store")
+	_, err := b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store/associations/remove", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "my-secret",
+	})
+	testSecretsSyncRequest(t, c, logical.DeleteOperation, "sync/destinations/aws-sm/my-store", nil)
+	resp = testSecretsSyncRequest(t, c, logical.ReadOperation, "sync/destinations/aws-sm/my-store", nil)
+	require.Nil(t, resp)
+
+	// Destinations are loaded again when the manager is set up
+	c.stopSecretsSync()
+	require.NoError(t, c.setupSecretsSync(ctx))
+	require.Nil(t, c.secretsSync.destination("aws-sm", "my-store"))
+	require.NotNil(t, c.secretsSync.destination("gh", "my-repo"))
+}
+
+// TestSecretsSync_Events ensures associated secrets are synced when they are
+// associated, and again when the events of their mount report they changed.
+func TestSecretsSync_Events(t *testing.T) {
+	c, client, accessor := testCoreSecretsSync(t)
+
+	require.NoError(t, testSecretsSyncWrite(c, "my-secret", map[string]interface{}{"password": "hunter2"}))
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store", nil)
+
+	resp := testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/aws-sm/my-store/associations/set", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "my-secret",
+	})
+	secret := resp.Data["associated_secrets"].(map[string]interface{})[accessor+"/my-secret"].(map[string]interface{})
+	require.Equal(t, secretsSyncStatusSynced, secret["sync_status"])
+
+	name := fmt.Sprintf("vault/%s/my-secret", accessor)
+	require.Equal(t, map[string]string{name: `{"password":"hunter2"}`}, client.snapshot())
+
+	require.NoError(t, testSecretsSyncWrite(c, "my-secret", map[string]interface{}{"password": "correct-horse"}))
+	corehelpers.RetryUntil(t, 10*time.Second, func() error {
+		if value := client.snapshot()[name]; value != `{"password":"correct-horse"}` {
+			return fmt.Errorf("secret not synced, value is %q", value)
+		}
+		return nil
+	})
+
+	// Secrets which aren't associated aren't synced
+	require.NoError(t, testSecretsSyncWrite(c, "other-secret", map[string]interface{}{"password": "hunter2"}))
+
+	_, err := c.router.Route(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "kv/data/my-secret",
+	})
+	require.NoError(t, err)
+	corehelpers.RetryUntil(t, 10*time.Second, func() error {
+		if secrets := client.snapshot(); len(secrets) > 0 {
+			return fmt.Errorf("secrets not removed: %v", secrets)
+		}
+		return nil
+	})
+
+	resp = testSecretsSyncRequest(t, c, logical.ReadOperation, "sync/destinations/aws-sm/my-store/associations", nil)
+	secret = resp.Data["associated_secrets"].(map[string]interface{})[accessor+"/my-secret"].(map[string]interface{})
+	require.Equal(t, secretsSyncStatusUnsynced, secret["sync_status"])
+}
+
+// TestSecretsSync_Drift ensures the secrets of glob associations are synced
+// key by key with their keys filtered, that reconciling detects the external
+// secrets modified outside of Vault and that removing the association
+// removes them.
+func TestSecretsSync_Drift(t *testing.T) {
+	c, client, accessor := testCoreSecretsSync(t)
+
+	require.NoError(t, testSecretsSyncWrite(c, "apps/api", map[string]interface{}{"db_password": "hunter2", "api_key": "abcd"}))
+	require.NoError(t, testSecretsSyncWrite(c, "apps/nested/web", map[string]interface{}{"db_user": "web"}))
+	require.NoError(t, testSecretsSyncWrite(c, "other", map[string]interface{}{"db_password": "hunter2"}))
+
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/gh/my-repo", map[string]interface{}{
+		"access_token":         "github_pat",
+		"repository_owner":     "hashicorp",
+		"repository_name":      "vault",
+		"granularity":          secretsync.GranularitySecretKey,
+		"secret_name_template": `{{ .SecretPath }}_{{ .SecretKey }}`,
+	})
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/gh/my-repo/associations/set", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "apps/*",
+		"keys":        "db_*",
+	})
+	require.Equal(t, map[string]string{
+		"APPS_API_DB_PASSWORD":    "hunter2",
+		"APPS_NESTED_WEB_DB_USER": "web",
+	}, client.snapshot())
+
+	require.NoError(t, client.Set(context.Background(), "APPS_API_DB_PASSWORD", "modified"))
+	c.secretsSync.reconcileAll(false)
+
+	resp := testSecretsSyncRequest(t, c, logical.ReadOperation, "sync/status", nil)
+	status := resp.Data["destinations"].(map[string]interface{})["gh/my-repo"].(map[string]interface{})
+	require.Equal(t, 1, status["status_counts"].(map[string]int)[secretsSyncStatusDrifted])
+	require.Equal(t, []string{accessor + "/apps/api"}, status["unhealthy_secrets"])
+	require.NotEmpty(t, resp.Data["last_reconcile"])
+
+	// Setting the association again syncs the secrets again
+	testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/gh/my-repo/associations/set", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "apps/*",
+		"keys":        "db_*",
+	})
+	require.Equal(t, "hunter2", client.snapshot()["APPS_API_DB_PASSWORD"])
+
+	resp = testSecretsSyncRequest(t, c, logical.UpdateOperation, "sync/destinations/gh/my-repo/associations/remove", map[string]interface{}{
+		"mount":       "kv",
+		"secret_name": "apps/*",
+	})
+	require.Empty(t, resp.Data["associated_secrets"])
+	require.Empty(t, client.snapshot())
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+)
+
+// awsTagKey tags the secrets created in AWS Secrets Manager, telling them
+// apart from the secrets managed by other means.
+const awsTagKey = "hashicorp:vault"
+
+// awsClient syncs secrets to AWS Secrets Manager.
+type awsClient struct {
+	client *secretsmanager.SecretsManager
+}
+
+var _ Client = (*awsClient)(nil)
+
+func newAWSClient(d *Destination) (*awsClient, error) {
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:  d.AWSAccessKeyID,
+		SecretKey:  d.AWSSecretAccessKey,
+		Region:     d.AWSRegion,
+		HTTPClient: cleanhttp.DefaultClient(),
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS credentials: %w", err)
+	}
+
+	config := &aws.Config{
+		Credentials: creds,
+		HTTPClient:  cleanhttp.DefaultClient(),
+	}
+	if d.AWSRegion != "" {
+		config.Region = aws.String(d.AWSRegion)
+	}
+	if d.AWSEndpoint != "" {
+		config.Endpoint = aws.String(d.AWSEndpoint)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	return &awsClient{client: secretsmanager.New(sess)}, nil
+}
+
+func (c *awsClient) Set(ctx context.Context, name string, value string) error {
+	_, err := c.client.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(value),
+	})
+	if !isAWSNotFound(err) {
+		return err
+	}
+
+	_, err = c.client.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+		Tags: []*secretsmanager.Tag{
+			{Key: aws.String(awsTagKey), Value: aws.String("")},
+		},
+	})
+	return err
+}
+
+func (c *awsClient) Get(ctx context.Context, name string) (string, error) {
+	out, err := c.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	switch {
+	case isAWSNotFound(err):
+		return "", ErrSecretNotFound
+	case err != nil:
+		return "", err
+	}
+	return aws.StringValue(out.SecretString), nil
+}
+
+func (c *awsClient) Delete(ctx context.Context, name string) error {
+	// Secrets are deleted without a recovery window, so that they can be
+	// created again if they are synced again
+	_, err := c.client.DeleteSecretWithContext(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(name),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	if isAWSNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isAWSNotFound(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+// azureClient syncs secrets to an Azure Key Vault.
+type azureClient struct {
+	client  keyvault.BaseClient
+	baseURL string
+}
+
+var _ Client = (*azureClient)(nil)
+
+func newAzureClient(d *Destination) (*azureClient, error) {
+	environmentName := d.AzureCloud
+	if environmentName == "" {
+		environmentName = azure.PublicCloud.Name
+	}
+	environment, err := azure.EnvironmentFromName(environmentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up Azure environment descriptor for name %q: %w", environmentName, err)
+	}
+	resource := strings.TrimSuffix(environment.ResourceIdentifiers.KeyVault, "/")
+
+	var authorizer autorest.Authorizer
+	if d.AzureClientSecret != "" {
+		config := auth.NewClientCredentialsConfig(d.AzureClientID, d.AzureClientSecret, d.AzureTenantID)
+		config.AADEndpoint = environment.ActiveDirectoryEndpoint
+		config.Resource = resource
+		authorizer, err = config.Authorizer()
+	} else {
+		// Fall back to the managed identity of the host
+		config := auth.NewMSIConfig()
+		config.Resource = resource
+		config.ClientID = d.AzureClientID
+		authorizer, err = config.Authorizer()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure authorizer: %w", err)
+	}
+
+	client := keyvault.New()
+	client.Authorizer = authorizer
+	return &azureClient{
+		client:  client,
+		baseURL: strings.TrimSuffix(d.AzureKeyVaultURI, "/"),
+	}, nil
+}
+
+func (c *azureClient) Set(ctx context.Context, name string, value string) error {
+	_, err := c.client.SetSecret(ctx, c.baseURL, name, keyvault.SecretSetParameters{
+		Value: &value,
+		Tags: map[string]*string{
+			"hashicorp-vault": new(string),
+		},
+	})
+	return err
+}
+
+func (c *azureClient) Get(ctx context.Context, name string) (string, error) {
+	bundle, err := c.client.GetSecret(ctx, c.baseURL, name, "")
+	switch {
+	case isAzureNotFound(err):
+		return "", ErrSecretNotFound
+	case err != nil:
+		return "", err
+	case bundle.Value == nil:
+		return "", nil
+	}
+	return *bundle.Value, nil
+}
+
+func (c *azureClient) Delete(ctx context.Context, name string) error {
+	_, err := c.client.DeleteSecret(ctx, c.baseURL, name)
+	if isAzureNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isAzureNotFound(err error) bool {
+	var detailedErr autorest.DetailedError
+	return errors.As(err, &detailedErr) && detailedErr.StatusCode == http.StatusNotFound
+}
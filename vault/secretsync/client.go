@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSecretNotFound is returned by Client.Get when the secret doesn't
+	// exist in the destination.
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrWriteOnly is returned by Client.Get when the secret exists, but the
+	// destination doesn't allow reading its value back.
+	ErrWriteOnly = errors.New("secret values cannot be read from the destination")
+)
+
+// Client manages the secrets of a destination. Names passed to a Client are
+// normalized for the destination type.
+type Client interface {
+	// Set creates the named secret, or replaces its value.
+	Set(ctx context.Context, name string, value string) error
+
+	// Get returns the value of the named secret.
+	Get(ctx context.Context, name string) (string, error)
+
+	// Delete removes the named secret, if it exists.
+	Delete(ctx context.Context, name string) error
+}
+
+// NewClient returns the Client for the destination's type.
+func NewClient(ctx context.Context, d *Destination) (Client, error) {
+	switch d.Type {
+	case TypeAWSSecretsManager:
+		return newAWSClient(d)
+	case TypeAzureKeyVault:
+		return newAzureClient(d)
+	case TypeGCPSecretManager:
+		return newGCPClient(ctx, d)
+	case TypeGitHubActions:
+		return newGitHubClient(d), nil
+	default:
+		return nil, fmt.Errorf("invalid destination type %q", d.Type)
+	}
+}
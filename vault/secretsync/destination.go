@@ -0,0 +1,335 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ryanuber/go-glob"
+)
+
+const (
+	TypeAWSSecretsManager = "aws-sm"
+	TypeAzureKeyVault     = "azure-kv"
+	TypeGCPSecretManager  = "gcp-sm"
+	TypeGitHubActions     = "gh"
+
+	// GranularitySecretPath syncs each secret as a single external secret
+	// holding its keys as a JSON object, and GranularitySecretKey syncs each
+	// key of a secret as its own external secret.
+	GranularitySecretPath = "secret-path"
+	GranularitySecretKey  = "secret-key"
+
+	// DefaultSecretNameTemplate is the template used to name the external
+	// secrets when none is configured.
+	DefaultSecretNameTemplate = `vault/{{ .MountAccessor }}/{{ .SecretPath }}{{ if .SecretKey }}/{{ .SecretKey }}{{ end }}`
+
+	// maskedValue replaces the sensitive connection details when they are
+	// read back.
+	maskedValue = "*****"
+)
+
+// Types are the supported destination types.
+var Types = []string{
+	TypeAWSSecretsManager,
+	TypeAzureKeyVault,
+	TypeGCPSecretManager,
+	TypeGitHubActions,
+}
+
+// templateFuncs are the functions available to the secret name templates.
+var templateFuncs = template.FuncMap{
+	"lowercase": strings.ToLower,
+	"uppercase": strings.ToUpper,
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
+
+// Destination is a named external secret store secrets are synced to. It
+// holds the connection details of the store and how the synced secrets are
+// named and laid out in it.
+type Destination struct {
+	Type               string `json:"type"`
+	Name               string `json:"name"`
+	SecretNameTemplate string `json:"secret_name_template"`
+	Granularity        string `json:"granularity"`
+
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+	AWSRegion          string `json:"aws_region,omitempty"`
+	AWSEndpoint        string `json:"aws_endpoint,omitempty"`
+
+	AzureKeyVaultURI  string `json:"azure_key_vault_uri,omitempty"`
+	AzureTenantID     string `json:"azure_tenant_id,omitempty"`
+	AzureClientID     string `json:"azure_client_id,omitempty"`
+	AzureClientSecret string `json:"azure_client_secret,omitempty"`
+	AzureCloud        string `json:"azure_cloud,omitempty"`
+
+	GCPCredentials string `json:"gcp_credentials,omitempty"`
+	GCPProjectID   string `json:"gcp_project_id,omitempty"`
+	GCPEndpoint    string `json:"gcp_endpoint,omitempty"`
+
+	GitHubAccessToken string `json:"github_access_token,omitempty"`
+	GitHubOwner       string `json:"github_repository_owner,omitempty"`
+	GitHubRepository  string `json:"github_repository_name,omitempty"`
+	GitHubAPIURL      string `json:"github_api_url,omitempty"`
+}
+
+// SecretNameData is the data the secret name templates are executed with.
+type SecretNameData struct {
+	// MountAccessor and MountPath identify the KV v2 mount of the secret,
+	// the mount path having no trailing slash.
+	MountAccessor string
+	MountPath     string
+
+	// SecretPath is the path of the secret relative to the mount, and
+	// SecretKey the key being synced when the granularity is secret-key.
+	SecretPath string
+	SecretKey  string
+}
+
+// Validate checks that the destination is complete for its type, and that
+// its secret name template can be executed.
+func (d *Destination) Validate() error {
+	switch d.Type {
+	case TypeAWSSecretsManager:
+		if (d.AWSAccessKeyID == "") != (d.AWSSecretAccessKey == "") {
+			return errors.New("access_key_id and secret_access_key must be set together")
+		}
+	case TypeAzureKeyVault:
+		if d.AzureKeyVaultURI == "" {
+			return errors.New("key_vault_uri is required")
+		}
+		if d.AzureClientSecret != "" && (d.AzureClientID == "" || d.AzureTenantID == "") {
+			return errors.New("client_id and tenant_id are required with client_secret")
+		}
+	case TypeGCPSecretManager:
+		if d.GCPCredentials == "" && d.GCPProjectID == "" {
+			return errors.New("project_id is required without credentials")
+		}
+	case TypeGitHubActions:
+		switch {
+		case d.GitHubAccessToken == "":
+			return errors.New("access_token is required")
+		case d.GitHubOwner == "":
+			return errors.New("repository_owner is required")
+		case d.GitHubRepository == "":
+			return errors.New("repository_name is required")
+		}
+	default:
+		return fmt.Errorf("invalid destination type %q", d.Type)
+	}
+
+	switch d.Granularity {
+	case GranularitySecretPath, GranularitySecretKey:
+	default:
+		return fmt.Errorf("invalid granularity %q", d.Granularity)
+	}
+
+	_, err := d.SecretName(&SecretNameData{
+		MountAccessor: "kv_1234abcd",
+		MountPath:     "kv",
+		SecretPath:    "path/to/secret",
+		SecretKey:     "key",
+	})
+	if err != nil {
+		return fmt.Errorf("invalid secret_name_template: %w", err)
+	}
+	return nil
+}
+
+// ConnectionDetails returns the connection details of the destination as
+// named by the API, with the sensitive ones masked.
+func (d *Destination) ConnectionDetails() map[string]interface{} {
+	mask := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return maskedValue
+	}
+
+	switch d.Type {
+	case TypeAWSSecretsManager:
+		return map[string]interface{}{
+			"access_key_id":     mask(d.AWSAccessKeyID),
+			"secret_access_key": mask(d.AWSSecretAccessKey),
+			"region":            d.AWSRegion,
+			"endpoint":          d.AWSEndpoint,
+		}
+	case TypeAzureKeyVault:
+		return map[string]interface{}{
+			"key_vault_uri": d.AzureKeyVaultURI,
+			"tenant_id":     d.AzureTenantID,
+			"client_id":     d.AzureClientID,
+			"client_secret": mask(d.AzureClientSecret),
+			"cloud":         d.AzureCloud,
+		}
+	case TypeGCPSecretManager:
+		return map[string]interface{}{
+			"credentials": mask(d.GCPCredentials),
+			"project_id":  d.GCPProjectID,
+			"endpoint":    d.GCPEndpoint,
+		}
+	case TypeGitHubActions:
+		return map[string]interface{}{
+			"access_token":     mask(d.GitHubAccessToken),
+			"repository_owner": d.GitHubOwner,
+			"repository_name":  d.GitHubRepository,
+			"api_url":          d.GitHubAPIURL,
+		}
+	default:
+		return nil
+	}
+}
+
+// SecretName executes the secret name template of the destination, and
+// normalizes the result for the destination type, whose stores only accept
+// some characters in the names of their secrets.
+func (d *Destination) SecretName(data *SecretNameData) (string, error) {
+	text := d.SecretNameTemplate
+	if text == "" {
+		text = DefaultSecretNameTemplate
+	}
+
+	tmpl, err := template.New("secret_name").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return normalizeName(d.Type, buf.String())
+}
+
+// normalizeName replaces the characters the store of the destination type
+// doesn't accept in secret names, and checks the length of the name.
+func normalizeName(typ, name string) (string, error) {
+	var allowed func(r rune) bool
+	var replacement rune
+	var maxLength int
+
+	alnum := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+	}
+	switch typ {
+	case TypeAWSSecretsManager:
+		allowed = func(r rune) bool { return alnum(r) || strings.ContainsRune("/_+=.@-", r) }
+		replacement, maxLength = '_', 512
+	case TypeAzureKeyVault:
+		allowed = func(r rune) bool { return alnum(r) || r == '-' }
+		replacement, maxLength = '-', 127
+	case TypeGCPSecretManager:
+		allowed = func(r rune) bool { return alnum(r) || r == '_' || r == '-' }
+		replacement, maxLength = '-', 255
+	case TypeGitHubActions:
+		name = strings.ToUpper(name)
+		allowed = func(r rune) bool { return alnum(r) || r == '_' }
+		replacement, maxLength = '_', 255
+	default:
+		return "", fmt.Errorf("invalid destination type %q", typ)
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if allowed(r) {
+			return r
+		}
+		return replacement
+	}, name)
+
+	switch {
+	case name == "":
+		return "", errors.New("secret name is empty")
+	case len(name) > maxLength:
+		return "", fmt.Errorf("secret name %q is longer than %d characters", name, maxLength)
+	case typ == TypeGitHubActions && name[0] >= '0' && name[0] <= '9':
+		return "", fmt.Errorf("secret name %q cannot start with a number", name)
+	case typ == TypeGitHubActions && strings.HasPrefix(name, "GITHUB_"):
+		return "", fmt.Errorf("secret name %q cannot start with GITHUB_", name)
+	}
+	return name, nil
+}
+
+// MatchSecret returns whether the path of a secret matches the secret name of
+// an association, which is either the path of a single secret or a glob
+// pattern.
+func MatchSecret(pattern, secretPath string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == secretPath
+	}
+	return glob.Glob(pattern, secretPath)
+}
+
+// FilterKeys returns the keys of the data of a secret matching one of the
+// glob patterns, or all its keys when there are no patterns.
+func FilterKeys(data map[string]interface{}, patterns []string) map[string]interface{} {
+	if len(patterns) == 0 {
+		return data
+	}
+
+	filtered := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		for _, pattern := range patterns {
+			if glob.Glob(pattern, key) {
+				filtered[key] = value
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Values returns the external secrets a secret is synced as, keyed by their
+// normalized names, according to the granularity of the destination.
+func (d *Destination) Values(nameData *SecretNameData, data map[string]interface{}) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if d.Granularity != GranularitySecretKey {
+		if len(data) == 0 {
+			return values, nil
+		}
+		name, err := d.SecretName(nameData)
+		if err != nil {
+			return nil, err
+		}
+		// Keys are marshaled in order, so the value only changes along
+		// with the secret
+		value, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = string(value)
+		return values, nil
+	}
+
+	for key, raw := range data {
+		keyData := *nameData
+		keyData.SecretKey = key
+		name, err := d.SecretName(&keyData)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("keys of secret %q are synced to the same secret name %q", nameData.SecretPath, name)
+		}
+
+		value, ok := raw.(string)
+		if !ok {
+			b, err := json.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+			value = string(b)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/vault/helper/useragent"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// gcpClient syncs secrets to Google Cloud Secret Manager.
+type gcpClient struct {
+	service *secretmanager.Service
+	project string
+}
+
+var _ Client = (*gcpClient)(nil)
+
+func newGCPClient(ctx context.Context, d *Destination) (*gcpClient, error) {
+	project := d.GCPProjectID
+	opts := []option.ClientOption{option.WithUserAgent(useragent.String())}
+	if d.GCPCredentials != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(d.GCPCredentials)))
+
+		if project == "" {
+			var creds struct {
+				ProjectID string `json:"project_id"`
+			}
+			if err := json.Unmarshal([]byte(d.GCPCredentials), &creds); err != nil {
+				return nil, fmt.Errorf("failed to parse GCP credentials: %w", err)
+			}
+			project = creds.ProjectID
+		}
+	}
+	if project == "" {
+		return nil, errors.New("project_id is required when the credentials don't name a project")
+	}
+	if d.GCPEndpoint != "" {
+		opts = append(opts, option.WithEndpoint(d.GCPEndpoint))
+	}
+
+	service, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	return &gcpClient{
+		service: service,
+		project: "projects/" + project,
+	}, nil
+}
+
+func (c *gcpClient) secretName(name string) string {
+	return c.project + "/secrets/" + name
+}
+
+func (c *gcpClient) Set(ctx context.Context, name string, value string) error {
+	version := &secretmanager.AddSecretVersionRequest{
+		Payload: &secretmanager.SecretPayload{
+			Data: base64.StdEncoding.EncodeToString([]byte(value)),
+		},
+	}
+
+	_, err := c.service.Projects.Secrets.AddVersion(c.secretName(name), version).Context(ctx).Do()
+	if !isGCPNotFound(err) {
+		return err
+	}
+
+	secret := &secretmanager.Secret{
+		Labels: map[string]string{
+			"hashicorp-vault": "",
+		},
+		Replication: &secretmanager.Replication{
+			Automatic: &secretmanager.Automatic{},
+		},
+	}
+	if _, err := c.service.Projects.Secrets.Create(c.project, secret).SecretId(name).Context(ctx).Do(); err != nil {
+		return err
+	}
+	_, err = c.service.Projects.Secrets.AddVersion(c.secretName(name), version).Context(ctx).Do()
+	return err
+}
+
+func (c *gcpClient) Get(ctx context.Context, name string) (string, error) {
+	resp, err := c.service.Projects.Secrets.Versions.Access(c.secretName(name) + "/versions/latest").Context(ctx).Do()
+	switch {
+	case isGCPNotFound(err):
+		return "", ErrSecretNotFound
+	case err != nil:
+		return "", err
+	case resp.Payload == nil:
+		return "", nil
+	}
+
+	value, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(value), nil
+}
+
+func (c *gcpClient) Delete(ctx context.Context, name string) error {
+	_, err := c.service.Projects.Secrets.Delete(c.secretName(name)).Context(ctx).Do()
+	if isGCPNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isGCPNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
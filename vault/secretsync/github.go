@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/useragent"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// defaultGitHubAPIURL is the URL of the GitHub REST API used when none is
+	// configured, e.g. for GitHub Enterprise Server.
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	githubAPIVersion = "2022-11-28"
+)
+
+// githubClient syncs secrets to the Actions secrets of a GitHub repository.
+// Their values are encrypted with the public key of the repository, and can't
+// be read back.
+type githubClient struct {
+	client  *http.Client
+	baseURL string
+	token   string
+
+	// keyLock guards the public key of the repository, which is fetched
+	// once and fetched again when GitHub rejects it.
+	keyLock sync.Mutex
+	keyID   string
+	key     *[32]byte
+}
+
+var _ Client = (*githubClient)(nil)
+
+func newGitHubClient(d *Destination) *githubClient {
+	apiURL := d.GitHubAPIURL
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	return &githubClient{
+		client: cleanhttp.DefaultClient(),
+		baseURL: fmt.Sprintf("%s/repos/%s/%s/actions/secrets", strings.TrimSuffix(apiURL, "/"),
+			url.PathEscape(d.GitHubOwner), url.PathEscape(d.GitHubRepository)),
+		token: d.GitHubAccessToken,
+	}
+}
+
+// do sends a request to the Actions secrets API of the repository, decoding
+// the JSON response into out, if any. It returns the status code of the
+// response, failing for the ones other than 2xx and 404.
+func (c *githubClient) do(ctx context.Context, method, path string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", useragent.String())
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return resp.StatusCode, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("%s %s returned %d: %s", method, req.URL.Path, resp.StatusCode, bytes.TrimSpace(msg))
+	case out != nil:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// publicKey returns the public key secrets are encrypted with, fetching it
+// when it isn't known yet.
+func (c *githubClient) publicKey(ctx context.Context) (string, *[32]byte, error) {
+	c.keyLock.Lock()
+	defer c.keyLock.Unlock()
+
+	if c.key != nil {
+		return c.keyID, c.key, nil
+	}
+
+	var resp struct {
+		KeyID string `json:"key_id"`
+		Key   string `json:"key"`
+	}
+	status, err := c.do(ctx, http.MethodGet, "/public-key", nil, &resp)
+	switch {
+	case err != nil:
+		return "", nil, fmt.Errorf("failed to fetch repository public key: %w", err)
+	case status == http.StatusNotFound:
+		return "", nil, fmt.Errorf("failed to fetch repository public key: repository not found")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(resp.Key)
+	if err != nil || len(raw) != 32 {
+		return "", nil, fmt.Errorf("invalid repository public key %q", resp.Key)
+	}
+	c.keyID, c.key = resp.KeyID, new([32]byte)
+	copy(c.key[:], raw)
+	return c.keyID, c.key, nil
+}
+
+func (c *githubClient) Set(ctx context.Context, name string, value string) error {
+	keyID, key, err := c.publicKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := box.SealAnonymous(nil, []byte(value), key, rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	status, err := c.do(ctx, http.MethodPut, "/"+url.PathEscape(name), map[string]string{
+		"encrypted_value": base64.StdEncoding.EncodeToString(encrypted),
+		"key_id":          keyID,
+	}, nil)
+	if err != nil {
+		// The key may have been rotated
+		c.keyLock.Lock()
+		c.key = nil
+		c.keyLock.Unlock()
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("failed to write secret %q: repository not found", name)
+	}
+	return nil
+}
+
+func (c *githubClient) Get(ctx context.Context, name string) (string, error) {
+	status, err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(name), nil, nil)
+	switch {
+	case err != nil:
+		return "", err
+	case status == http.StatusNotFound:
+		return "", ErrSecretNotFound
+	}
+	return "", ErrWriteOnly
+}
+
+func (c *githubClient) Delete(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(name), nil, nil)
+	return err
+}
@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretsync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// TestDestination_SecretName ensures the secret name templates are executed
+// and normalized for the stores of each destination type.
+func TestDestination_SecretName(t *testing.T) {
+	data := &SecretNameData{
+		MountAccessor: "kv_1234abcd",
+		MountPath:     "kv",
+		SecretPath:    "apps/my.secret",
+		SecretKey:     "db-password",
+	}
+
+	for _, tc := range []struct {
+		typ      string
+		template string
+		expected string
+		err      string
+	}{
+		{typ: TypeAWSSecretsManager, expected: "vault/kv_1234abcd/apps/my.secret/db-password"},
+		{typ: TypeAzureKeyVault, expected: "vault-kv-1234abcd-apps-my-secret-db-password"},
+		{typ: TypeGCPSecretManager, expected: "vault-kv_1234abcd-apps-my-secret-db-password"},
+		{typ: TypeGitHubActions, expected: "VAULT_KV_1234ABCD_APPS_MY_SECRET_DB_PASSWORD"},
+		{typ: TypeAWSSecretsManager, template: `{{ .MountPath }}/{{ replace "/" "_" .SecretPath | uppercase }}`, expected: "kv/APPS_MY.SECRET"},
+		{typ: TypeGitHubActions, template: `1{{ .SecretKey }}`, err: "cannot start with a number"},
+		{typ: TypeGitHubActions, template: `github_{{ .SecretKey }}`, err: "cannot start with GITHUB_"},
+		{typ: TypeAzureKeyVault, template: `{{ .Missing }}`, err: "can't evaluate field Missing"},
+		{typ: TypeAzureKeyVault, template: `{{ .SecretPath }}` + strings.Repeat("a", 127), err: "longer than 127 characters"},
+	} {
+		d := &Destination{Type: tc.typ, SecretNameTemplate: tc.template}
+		name, err := d.SecretName(data)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, name)
+	}
+}
+
+// TestDestination_Validate ensures incomplete destinations are rejected.
+func TestDestination_Validate(t *testing.T) {
+	valid := []*Destination{
+		{Type: TypeAWSSecretsManager, Granularity: GranularitySecretPath},
+		{Type: TypeAzureKeyVault, Granularity: GranularitySecretKey, AzureKeyVaultURI: "https://keyvault.vault.azure.net"},
+		{Type: TypeGCPSecretManager, Granularity: GranularitySecretPath, GCPProjectID: "my-project"},
+		{Type: TypeGitHubActions, Granularity: GranularitySecretPath, GitHubAccessToken: "token", GitHubOwner: "hashicorp", GitHubRepository: "vault"},
+	}
+	for _, d := range valid {
+		require.NoError(t, d.Validate(), d.Type)
+	}
+
+	invalid := []*Destination{
+		{Type: "vercel-project", Granularity: GranularitySecretPath},
+		{Type: TypeAWSSecretsManager, Granularity: GranularitySecretPath, AWSAccessKeyID: "AKIA"},
+		{Type: TypeAzureKeyVault, Granularity: GranularitySecretPath},
+		{Type: TypeAzureKeyVault, Granularity: GranularitySecretPath, AzureKeyVaultURI: "https://keyvault.vault.azure.net", AzureClientSecret: "secret"},
+		{Type: TypeGCPSecretManager, Granularity: GranularitySecretPath},
+		{Type: TypeGitHubActions, Granularity: GranularitySecretPath, GitHubAccessToken: "token"},
+		{Type: TypeAWSSecretsManager},
+		{Type: TypeAWSSecretsManager, Granularity: GranularitySecretPath, SecretNameTemplate: "{{ .SecretPath "},
+	}
+	for _, d := range invalid {
+		require.Error(t, d.Validate(), d.Type)
+	}
+}
+
+// TestDestination_Values ensures secrets are synced as a single JSON object
+// or key by key depending on the granularity, with their keys filtered.
+func TestDestination_Values(t *testing.T) {
+	nameData := &SecretNameData{MountAccessor: "kv_1234abcd", MountPath: "kv", SecretPath: "my-secret"}
+	data := map[string]interface{}{
+		"db_password": "hunter2",
+		"db_port":     json.Number("5432"),
+		"api_key":     "abcd",
+	}
+
+	d := &Destination{Type: TypeAWSSecretsManager, Granularity: GranularitySecretPath}
+	values, err := d.Values(nameData, data)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"vault/kv_1234abcd/my-secret": `{"api_key":"abcd","db_password":"hunter2","db_port":5432}`,
+	}, values)
+
+	values, err = d.Values(nameData, nil)
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	d = &Destination{Type: TypeGitHubActions, Granularity: GranularitySecretKey, SecretNameTemplate: "{{ .SecretKey }}"}
+	values, err = d.Values(nameData, FilterKeys(data, []string{"db_*"}))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"DB_PASSWORD": "hunter2",
+		"DB_PORT":     "5432",
+	}, values)
+
+	// Keys normalized to the same name can't be synced
+	_, err = d.Values(nameData, map[string]interface{}{"db-port": "5432", "db.port": "5432"})
+	require.ErrorContains(t, err, "same secret name")
+}
+
+// TestMatchSecret ensures association secret names match either a single
+// secret or the secrets matching a glob pattern.
+func TestMatchSecret(t *testing.T) {
+	require.True(t, MatchSecret("apps/api", "apps/api"))
+	require.False(t, MatchSecret("apps/api", "apps/api2"))
+	require.True(t, MatchSecret("apps/*", "apps/nested/web"))
+	require.True(t, MatchSecret("*-prod", "apps/api-prod"))
+	require.False(t, MatchSecret("apps/*", "other/api"))
+}
+
+// testGitHubServer is a fake of the Actions secrets API of a repository,
+// decrypting the secrets written with its private key.
+type testGitHubServer struct {
+	l       sync.Mutex
+	public  *[32]byte
+	private *[32]byte
+	secrets map[string]string
+}
+
+func (s *testGitHubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer github_pat" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/repos/hashicorp/vault/actions/secrets/")
+	switch {
+	case r.Method == http.MethodGet && name == "public-key":
+		json.NewEncoder(w).Encode(map[string]string{
+			"key_id": "1234",
+			"key":    base64.StdEncoding.EncodeToString(s.public[:]),
+		})
+	case r.Method == http.MethodPut:
+		var body struct {
+			EncryptedValue string `json:"encrypted_value"`
+			KeyID          string `json:"key_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.KeyID != "1234" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		encrypted, _ := base64.StdEncoding.DecodeString(body.EncryptedValue)
+		value, ok := box.OpenAnonymous(nil, encrypted, s.public, s.private)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.secrets[name] = string(value)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet:
+		if _, ok := s.secrets[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"name": name})
+	case r.Method == http.MethodDelete:
+		delete(s.secrets, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// TestGitHubClient ensures secrets are encrypted with the public key of the
+// repository, and can only be checked for existence.
+func TestGitHubClient(t *testing.T) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	server := &testGitHubServer{public: public, private: private, secrets: make(map[string]string)}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, &Destination{
+		Type:              TypeGitHubActions,
+		GitHubAccessToken: "github_pat",
+		GitHubOwner:       "hashicorp",
+		GitHubRepository:  "vault",
+		GitHubAPIURL:      ts.URL,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Set(ctx, "DB_PASSWORD", "hunter2"))
+	require.Equal(t, map[string]string{"DB_PASSWORD": "hunter2"}, server.secrets)
+
+	_, err = client.Get(ctx, "DB_PASSWORD")
+	require.True(t, errors.Is(err, ErrWriteOnly))
+
+	require.NoError(t, client.Delete(ctx, "DB_PASSWORD"))
+	_, err = client.Get(ctx, "DB_PASSWORD")
+	require.True(t, errors.Is(err, ErrSecretNotFound))
+
+	unauthorized, err := NewClient(ctx, &Destination{
+		Type:              TypeGitHubActions,
+		GitHubAccessToken: "invalid",
+		GitHubOwner:       "hashicorp",
+		GitHubRepository:  "vault",
+		GitHubAPIURL:      ts.URL,
+	})
+	require.NoError(t, err)
+	require.ErrorContains(t, unauthorized.Set(ctx, "DB_PASSWORD", "hunter2"), "401")
+}
@@ -33,12 +33,14 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/helper/policyutil"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/helper/tokenutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
 	"github.com/hashicorp/vault/sdk/plugin/pb"
 	"github.com/hashicorp/vault/vault/tokens"
 )
@@ -652,6 +654,21 @@ func (ts *TokenStore) paths() []*framework.Path {
 				Type:        framework.TypeCommaStringSlice,
 				Description: "String or JSON list of allowed entity aliases. If set, specifies the entity aliases which are allowed to be used during token generation. This field supports globbing.",
 			},
+
+			"token_bound_cidrs_template": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `String or JSON list of identity templates, such as "{{identity.entity.metadata.team_cidrs}}", rendered against the requesting entity. Each rendered value may hold a comma-separated list of CIDRs. If token_bound_cidrs is set, the tokens are bound to the addresses within both the templated CIDRs and token_bound_cidrs, otherwise to the templated CIDRs.`,
+			},
+
+			"token_ttl_template": {
+				Type:        framework.TypeString,
+				Description: "Identity template rendered against the requesting entity to a TTL for created tokens. If a TTL is also requested, the lesser value is used.",
+			},
+
+			"token_explicit_max_ttl_template": {
+				Type:        framework.TypeString,
+				Description: "Identity template rendered against the requesting entity to an explicit max TTL for created tokens. If token_explicit_max_ttl or a requested value is also set, the lesser value is used.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -892,6 +909,19 @@ type tsRoleEntry struct {
 
 	// The set of allowed entity aliases used during token creation
 	AllowedEntityAliases []string `json:"allowed_entity_aliases" mapstructure:"allowed_entity_aliases" structs:"allowed_entity_aliases"`
+
+	// Identity templates rendered against the requesting entity at token
+	// creation time. Templated CIDRs are added to TokenBoundCIDRs and the
+	// templated TTLs are combined with the static ones, using the lesser.
+	TokenBoundCIDRsTemplate     []string `json:"token_bound_cidrs_template" mapstructure:"token_bound_cidrs_template" structs:"token_bound_cidrs_template"`
+	TokenTTLTemplate            string   `json:"token_ttl_template" mapstructure:"token_ttl_template" structs:"token_ttl_template"`
+	TokenExplicitMaxTTLTemplate string   `json:"token_explicit_max_ttl_template" mapstructure:"token_explicit_max_ttl_template" structs:"token_explicit_max_ttl_template"`
+}
+
+// hasTemplates returns whether any of the role's token parameters have to be
+// rendered against the requesting entity.
+func (r *tsRoleEntry) hasTemplates() bool {
+	return len(r.TokenBoundCIDRsTemplate) > 0 || r.TokenTTLTemplate != "" || r.TokenExplicitMaxTTLTemplate != ""
 }
 
 type accessorEntry struct {
@@ -955,7 +985,7 @@ func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.R
 	}
 	nsID := ns.ID
 
-	entries, err := ts.accessorView(ns).List(ctx, "")
+	entries, err := ts.listSaltedAccessors(ctx, ns)
 	if err != nil {
 		return nil, err
 	}
@@ -990,23 +1020,25 @@ func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.R
 }
 
 // createAccessor is used to create an identifier for the token ID.
-// A storage index, mapping the accessor to the token ID is also created.
-func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEntry) error {
+// A storage index, mapping the accessor to the token ID is also created. The
+// index is returned as a write relative to the base view of the token
+// namespace, to be persisted along with the token by storeCommon.
+func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEntry) (*logical.TxnEntry, error) {
 	defer metrics.MeasureSince([]string{"token", "createAccessor"}, time.Now())
 
 	var err error
 	// Create a random accessor
 	entry.Accessor, err = base62.Random(TokenLength)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tokenNS, err := NamespaceByID(ctx, entry.NamespaceID, ts.core)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if tokenNS == nil {
-		return namespace.ErrNoNamespace
+		return nil, namespace.ErrNoNamespace
 	}
 
 	if tokenNS.ID != namespace.RootNamespaceID {
@@ -1017,7 +1049,7 @@ func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEn
 	saltCtx := namespace.ContextWithNamespace(ctx, tokenNS)
 	saltID, err := ts.SaltID(saltCtx, entry.Accessor)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	aEntry := &accessorEntry{
@@ -1028,14 +1060,13 @@ func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEn
 
 	aEntryBytes, err := jsonutil.EncodeJSON(aEntry)
 	if err != nil {
-		return fmt.Errorf("failed to marshal accessor index entry: %w", err)
+		return nil, fmt.Errorf("failed to marshal accessor index entry: %w", err)
 	}
 
-	le := &logical.StorageEntry{Key: saltID, Value: aEntryBytes}
-	if err := ts.accessorView(tokenNS).Put(ctx, le); err != nil {
-		return fmt.Errorf("failed to persist accessor index entry: %w", err)
-	}
-	return nil
+	return &logical.TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     &logical.StorageEntry{Key: accessorPrefix + indexKey(saltID), Value: aEntryBytes},
+	}, nil
 }
 
 // Create is used to create a new token entry. The entry is assigned
@@ -1129,12 +1160,12 @@ func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) err
 			}
 		}
 
-		err = ts.createAccessor(ctx, entry)
+		accessorTxn, err := ts.createAccessor(ctx, entry)
 		if err != nil {
 			return err
 		}
 
-		err = ts.storeCommon(ctx, entry, true)
+		err = ts.storeCommon(ctx, entry, true, accessorTxn)
 		if err != nil {
 			return err
 		}
@@ -1304,8 +1335,10 @@ func (ts *TokenStore) store(ctx context.Context, entry *logical.TokenEntry) erro
 }
 
 // storeCommon handles the actual storage of an entry, possibly generating
-// secondary indexes
-func (ts *TokenStore) storeCommon(ctx context.Context, entry *logical.TokenEntry, writeSecondary bool) error {
+// secondary indexes. The entry, its indexes and the given additional writes,
+// relative to the base view of the token namespace, are persisted in a single
+// storage transaction when the storage backend supports it.
+func (ts *TokenStore) storeCommon(ctx context.Context, entry *logical.TokenEntry, writeSecondary bool, txns ...*logical.TxnEntry) error {
 	tokenNS, err := NamespaceByID(ctx, entry.NamespaceID, ts.core)
 	if err != nil {
 		return err
@@ -1357,24 +1390,33 @@ func (ts *TokenStore) storeCommon(ctx context.Context, entry *logical.TokenEntry
 				return err
 			}
 
-			path := parentSaltedID + "/" + saltedID
+			path := indexKey(parentSaltedID) + "/" + saltedID
 			if tokenNS.ID != namespace.RootNamespaceID {
 				path = fmt.Sprintf("%s.%s", path, tokenNS.ID)
 			}
 
 			le := &logical.StorageEntry{Key: path}
-			if err := ts.parentView(parentNS).Put(ctx, le); err != nil {
+			if parentNS.ID == tokenNS.ID {
+				txns = append(txns, &logical.TxnEntry{
+					Operation: physical.PutOperation,
+					Entry:     &logical.StorageEntry{Key: parentPrefix + le.Key},
+				})
+			} else if err := ts.parentView(parentNS).Put(ctx, le); err != nil {
 				return fmt.Errorf("failed to persist entry: %w", err)
 			}
 		}
 	}
 
 	// Write the primary ID
-	le := &logical.StorageEntry{Key: saltedID, Value: enc}
+	le := &logical.StorageEntry{Key: idPrefix + saltedID, Value: enc}
 	if len(entry.Policies) == 1 && entry.Policies[0] == "root" {
 		le.SealWrap = true
 	}
-	if err := ts.idView(tokenNS).Put(ctx, le); err != nil {
+	txns = append(txns, &logical.TxnEntry{
+		Operation: physical.PutOperation,
+		Entry:     le,
+	})
+	if err := logical.StorageTransaction(ctx, ts.baseView(tokenNS), txns); err != nil {
 		return fmt.Errorf("failed to persist entry: %w", err)
 	}
 	return nil
@@ -1872,12 +1914,12 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 			return err
 		}
 
-		path := parentSaltedID + "/" + saltedID
+		child := saltedID
 		if tokenNS.ID != namespace.RootNamespaceID {
-			path = fmt.Sprintf("%s.%s", path, tokenNS.ID)
+			child = fmt.Sprintf("%s.%s", child, tokenNS.ID)
 		}
 
-		if err = ts.parentView(parentNS).Delete(ctx, path); err != nil {
+		if err = ts.deleteChildIndex(ctx, parentNS, parentSaltedID, child); err != nil {
 			return fmt.Errorf("failed to delete entry: %w", err)
 		}
 	}
@@ -1889,7 +1931,7 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 			return err
 		}
 
-		if err = ts.accessorView(tokenNS).Delete(ctx, accessorSaltedID); err != nil {
+		if err = ts.deleteAccessorIndex(ctx, tokenNS, accessorSaltedID); err != nil {
 			return fmt.Errorf("failed to delete entry: %w", err)
 		}
 	}
@@ -1902,14 +1944,13 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		// revokeTreeInternal to avoid unnecessary view.List operations. Since
 		// the deletion occurs in a DFS fashion we don't need to perform a delete
 		// on child prefixes as there will be none (as saltedID entry is a leaf node).
-		children, err := ts.parentView(tokenNS).List(ctx, saltedID+"/")
+		children, err := ts.listChildIndexes(ctx, tokenNS, saltedID)
 		if err != nil {
 			return fmt.Errorf("failed to scan for children: %w", err)
 		}
-		for _, child := range children {
-			var childNSID string
+		for _, childIndex := range children {
 			childCtx := revokeCtx
-			child, childNSID = namespace.SplitIDFromString(child)
+			child, childNSID := namespace.SplitIDFromString(childIndex)
 			if childNSID != "" {
 				childNS, err := NamespaceByID(ctx, childNSID, ts.core)
 				if err != nil {
@@ -1928,7 +1969,7 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 			}
 			if entry == nil {
 				// Seems it's already revoked, so nothing to do here except delete the index
-				err = ts.parentView(tokenNS).Delete(ctx, child)
+				err = ts.deleteChildIndex(ctx, tokenNS, saltedID, childIndex)
 				if err != nil {
 					return fmt.Errorf("failed to delete child entry: %w", err)
 				}
@@ -1948,9 +1989,9 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 
 			// Delete the the child storage entry after we update the token entry Since
 			// paths are not deeply nested (i.e. they are simply
-			// parenPrefix/<parentID>/<childID>), we can simply call view.Delete instead
-			// of logical.ClearView
-			err = ts.parentView(tokenNS).Delete(ctx, child)
+			// parenPrefix/<shard>/<parentID>/<childID>), we can simply call view.Delete
+			// instead of logical.ClearView
+			err = ts.deleteChildIndex(ctx, tokenNS, saltedID, childIndex)
 			if err != nil {
 				return fmt.Errorf("failed to delete child entry: %w", err)
 			}
@@ -2032,8 +2073,7 @@ func (ts *TokenStore) revokeTreeInternal(ctx context.Context, id string) error {
 			saltedCtx = namespace.ContextWithNamespace(ctx, saltedNS)
 		}
 
-		path := saltedID + "/"
-		childrenRaw, err := ts.parentView(saltedNS).List(saltedCtx, path)
+		childrenRaw, err := ts.listChildIndexes(saltedCtx, saltedNS, saltedID)
 		if err != nil {
 			return fmt.Errorf("failed to scan for children: %w", err)
 		}
@@ -2045,7 +2085,7 @@ func (ts *TokenStore) revokeTreeInternal(ctx context.Context, id string) error {
 			if _, seen := seenIDs[child]; !seen {
 				children = append(children, child)
 			} else {
-				if err = ts.parentView(saltedNS).Delete(saltedCtx, path+child); err != nil {
+				if err = ts.deleteChildIndex(saltedCtx, saltedNS, saltedID, child); err != nil {
 					return fmt.Errorf("failed to delete entry: %w", err)
 				}
 
@@ -2128,7 +2168,7 @@ func (ts *TokenStore) lookupByAccessor(ctx context.Context, id string, salted, t
 		}
 	}
 
-	entry, err := ts.accessorView(ns).Get(ctx, lookupID)
+	entry, err := ts.getAccessorIndex(ctx, ns, lookupID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read index using accessor: %w", err)
 	}
@@ -2189,100 +2229,99 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 
 			quitCtx := namespace.ContextWithNamespace(ts.quitContext, ns)
 
-			// List out all the accessors
-			saltedAccessorList, err := ts.accessorView(ns).List(quitCtx, "")
+			// Resume after the last shard tidied by an interrupted tidy
+			checkpoint, err := ts.loadTidyCheckpoint(quitCtx, ns)
 			if err != nil {
-				return fmt.Errorf("failed to fetch accessor index entries: %w", err)
+				return fmt.Errorf("failed to load tidy checkpoint: %w", err)
 			}
-
-			// First, clean up secondary index entries that are no longer valid
-			parentList, err := ts.parentView(ns).List(quitCtx, "")
-			if err != nil {
-				return fmt.Errorf("failed to fetch secondary index entries: %w", err)
-			}
-
-			// List all the cubbyhole storage keys
-			view := ts.core.router.MatchingStorageByAPIPath(ctx, cubbyholeMountPath)
-			if view == nil {
-				return fmt.Errorf("no cubby mount entry")
-			}
-			bview := view.(*BarrierView)
-
-			cubbyholeKeys, err := bview.List(quitCtx, "")
-			if err != nil {
-				return fmt.Errorf("failed to fetch cubbyhole storage keys: %w", err)
+			resumed := checkpoint.Completed > 0
+			if resumed {
+				ts.logger.Info("resuming tidy operation from checkpoint", "completed_shards", checkpoint.Completed, "start_time", checkpoint.StartTime)
+			} else {
+				checkpoint.StartTime = time.Now()
 			}
 
 			var countParentEntries, deletedCountParentEntries, countParentList, deletedCountParentList int64
 
-			// Scan through the secondary index entries; if there is an entry
-			// with the token's salt ID at the end, remove it
-			for _, parent := range parentList {
-				countParentEntries++
-
-				// Get the children
-				children, err := ts.parentView(ns).List(quitCtx, parent)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read secondary index: %w", err))
-					continue
-				}
+			// Scan through the secondary index entries under the prefix; if
+			// there is an entry with the token's salt ID at the end, remove it
+			tidyParents := func(prefix string, parentList []string) {
+				for _, parent := range parentList {
+					countParentEntries++
 
-				// First check if the salt ID of the parent exists, and if not mark this so
-				// that deletion of children later with this loop below applies to all
-				// children
-				originalChildrenCount := int64(len(children))
-				exists, _ := ts.lookupInternal(quitCtx, strings.TrimSuffix(parent, "/"), true, true)
-				if exists == nil {
-					ts.logger.Debug("deleting invalid parent prefix entry", "index", parentPrefix+parent)
-				}
+					// Get the children
+					children, err := ts.parentView(ns).List(quitCtx, prefix+parent)
+					if err != nil {
+						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read secondary index: %w", err))
+						continue
+					}
 
-				var deletedChildrenCount int64
-				for index, child := range children {
-					countParentList++
-					if countParentList%500 == 0 {
-						percentComplete := float64(index) / float64(len(children)) * 100
-						ts.logger.Info("checking validity of tokens in secondary index list", "progress", countParentList, "percent_complete", percentComplete)
+					// First check if the salt ID of the parent exists, and if not mark this so
+					// that deletion of children later with this loop below applies to all
+					// children
+					originalChildrenCount := int64(len(children))
+					parentSaltedID := strings.TrimSuffix(parent, "/")
+					exists, _ := ts.lookupInternal(quitCtx, parentSaltedID, true, true)
+					if exists == nil {
+						ts.logger.Debug("deleting invalid parent prefix entry", "index", parentPrefix+prefix+parent)
 					}
 
-					// Look up tainted entries so we can be sure that if this isn't
-					// found, it doesn't exist. Doing the following without locking
-					// since appropriate locks cannot be held with salted token IDs.
-					// Also perform deletion if the parent doesn't exist any more.
-					te, _ := ts.lookupInternal(quitCtx, child, true, true)
-					// If the child entry is not nil, but the parent doesn't exist, then turn
-					// that child token into an orphan token. Theres no deletion in this case.
-					if te != nil && exists == nil {
-						lock := locksutil.LockForKey(ts.tokenLocks, te.ID)
-						lock.Lock()
-
-						te.Parent = ""
-						err = ts.store(quitCtx, te)
-						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to convert child token into an orphan token: %w", err))
+					var deletedChildrenCount int64
+					for index, child := range children {
+						countParentList++
+						if countParentList%500 == 0 {
+							percentComplete := float64(index) / float64(len(children)) * 100
+							ts.logger.Info("checking validity of tokens in secondary index list", "progress", countParentList, "percent_complete", percentComplete)
 						}
-						lock.Unlock()
-						continue
-					}
-					// Otherwise, if the entry doesn't exist, or if the parent doesn't exist go
-					// on with the delete on the secondary index
-					if te == nil || exists == nil {
-						index := parent + child
-						ts.logger.Debug("deleting invalid secondary index", "index", index)
-						err = ts.parentView(ns).Delete(quitCtx, index)
-						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete secondary index: %w", err))
+
+						// Look up tainted entries so we can be sure that if this isn't
+						// found, it doesn't exist. Doing the following without locking
+						// since appropriate locks cannot be held with salted token IDs.
+						// Also perform deletion if the parent doesn't exist any more.
+						te, _ := ts.lookupInternal(quitCtx, child, true, true)
+						// If the child entry is not nil, but the parent doesn't exist, then turn
+						// that child token into an orphan token. Theres no deletion in this case.
+						if te != nil && exists == nil {
+							lock := locksutil.LockForKey(ts.tokenLocks, te.ID)
+							lock.Lock()
+
+							te.Parent = ""
+							err = ts.store(quitCtx, te)
+							if err != nil {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to convert child token into an orphan token: %w", err))
+							}
+							lock.Unlock()
+							continue
+						}
+						// Otherwise, if the entry doesn't exist, or if the parent doesn't exist go
+						// on with the delete on the secondary index
+						if te == nil || exists == nil {
+							index := prefix + parent + child
+							ts.logger.Debug("deleting invalid secondary index", "index", index)
+							err = ts.parentView(ns).Delete(quitCtx, index)
+							if err != nil {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete secondary index: %w", err))
+								continue
+							}
+							deletedChildrenCount++
 							continue
 						}
-						deletedChildrenCount++
+						// Move the valid entries written before the index was sharded
+						if prefix == "" {
+							err = shardIndexEntry(quitCtx, ts.parentView(ns), parent+child, indexKey(parentSaltedID)+"/"+child)
+							if err != nil {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to shard secondary index: %w", err))
+							}
+						}
+					}
+					// Add current children deleted count to the total count
+					deletedCountParentList += deletedChildrenCount
+					// N.B.: We don't call delete on the parent prefix since physical.Backend.Delete
+					// implementations should be in charge of deleting empty prefixes.
+					// If we deleted all the children, then add that to our deleted parent entries count.
+					if originalChildrenCount == deletedChildrenCount {
+						deletedCountParentEntries++
 					}
-				}
-				// Add current children deleted count to the total count
-				deletedCountParentList += deletedChildrenCount
-				// N.B.: We don't call delete on the parent prefix since physical.Backend.Delete
-				// implementations should be in charge of deleting empty prefixes.
-				// If we deleted all the children, then add that to our deleted parent entries count.
-				if originalChildrenCount == deletedChildrenCount {
-					deletedCountParentEntries++
 				}
 			}
 
@@ -2295,128 +2334,208 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 
 			validCubbyholeKeys := make(map[string]bool)
 
-			// For each of the accessor, see if the token ID associated with it is
-			// a valid one. If not, delete the leases associated with that token
-			// and delete the accessor as well.
-			for index, saltedAccessor := range saltedAccessorList {
-				countAccessorList++
-				if countAccessorList%500 == 0 {
-					percentComplete := float64(index) / float64(len(saltedAccessorList)) * 100
-					ts.logger.Info("checking if accessors contain valid tokens", "progress", countAccessorList, "percent_complete", percentComplete)
-				}
-
-				accessorEntry, err := ts.lookupByAccessor(quitCtx, saltedAccessor, true, true)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: %w", err))
-					continue
-				}
-				if accessorEntry == nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: invalid accessor"))
-					continue
-				}
+			// For each of the accessor under the prefix, see if the token ID
+			// associated with it is a valid one. If not, delete the leases
+			// associated with that token and delete the accessor as well.
+			tidyAccessors := func(prefix string, saltedAccessorList []string) {
+				for index, saltedAccessor := range saltedAccessorList {
+					countAccessorList++
+					if countAccessorList%500 == 0 {
+						percentComplete := float64(index) / float64(len(saltedAccessorList)) * 100
+						ts.logger.Info("checking if accessors contain valid tokens", "progress", countAccessorList, "percent_complete", percentComplete)
+					}
 
-				// A valid accessor storage entry should always have a token ID
-				// in it. If not, it is an invalid accessor entry and needs to
-				// be deleted.
-				if accessorEntry.TokenID == "" {
-					// If deletion of accessor fails, move on to the next
-					// item since this is just a best-effort operation
-					err = ts.accessorView(ns).Delete(quitCtx, saltedAccessor)
+					accessorEntry, err := ts.lookupByAccessor(quitCtx, saltedAccessor, true, true)
 					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete the accessor index: %w", err))
+						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: %w", err))
 						continue
 					}
-					deletedCountAccessorEmptyToken++
-				}
-
-				lock := locksutil.LockForKey(ts.tokenLocks, accessorEntry.TokenID)
-				lock.RLock()
-
-				// Look up tainted variants so we only find entries that truly don't
-				// exist
-				te, err := ts.lookupInternal(quitCtx, accessorEntry.TokenID, false, true)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to lookup tainted ID: %w", err))
-					lock.RUnlock()
-					continue
-				}
-
-				lock.RUnlock()
-
-				switch {
-				case te == nil:
-					// If token entry is not found assume that the token is not valid any
-					// more and conclude that accessor, leases, and secondary index entries
-					// for this token should not exist as well.
-
-					ts.logger.Info("deleting token with nil entry referenced by accessor", "salted_accessor", saltedAccessor)
-
-					// RevokeByToken expects a '*logical.TokenEntry'. For the
-					// purposes of tidying, it is sufficient if the token
-					// entry only has ID set.
-					tokenEntry := &logical.TokenEntry{
-						ID:          accessorEntry.TokenID,
-						NamespaceID: accessorEntry.NamespaceID,
+					if accessorEntry == nil {
+						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: invalid accessor"))
+						continue
 					}
 
-					// Attempt to revoke the token. This will also revoke
-					// the leases associated with the token.
-					err = ts.expiration.RevokeByToken(quitCtx, tokenEntry)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke leases of expired token: %w", err))
-						continue
+					// A valid accessor storage entry should always have a token ID
+					// in it. If not, it is an invalid accessor entry and needs to
+					// be deleted.
+					if accessorEntry.TokenID == "" {
+						// If deletion of accessor fails, move on to the next
+						// item since this is just a best-effort operation
+						err = ts.deleteAccessorIndex(quitCtx, ns, saltedAccessor)
+						if err != nil {
+							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete the accessor index: %w", err))
+							continue
+						}
+						deletedCountAccessorEmptyToken++
 					}
-					deletedCountInvalidTokenInAccessor++
 
-					// If deletion of accessor fails, move on to the next item since
-					// this is just a best-effort operation. We do this last so that on
-					// next run if something above failed we still have the accessor
-					// entry to try again.
-					err = ts.accessorView(ns).Delete(quitCtx, saltedAccessor)
+					lock := locksutil.LockForKey(ts.tokenLocks, accessorEntry.TokenID)
+					lock.RLock()
+
+					// Look up tainted variants so we only find entries that truly don't
+					// exist
+					te, err := ts.lookupInternal(quitCtx, accessorEntry.TokenID, false, true)
 					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete accessor entry: %w", err))
+						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to lookup tainted ID: %w", err))
+						lock.RUnlock()
 						continue
 					}
-					deletedCountAccessorInvalidToken++
-				default:
-					// Cache the cubbyhole storage key when the token is valid
+
+					lock.RUnlock()
+
 					switch {
-					case te.NamespaceID == namespace.RootNamespaceID && !IsServiceToken(te.ID):
-						saltedID, err := ts.SaltID(quitCtx, te.ID)
+					case te == nil:
+						// If token entry is not found assume that the token is not valid any
+						// more and conclude that accessor, leases, and secondary index entries
+						// for this token should not exist as well.
+
+						ts.logger.Info("deleting token with nil entry referenced by accessor", "salted_accessor", saltedAccessor)
+
+						// RevokeByToken expects a '*logical.TokenEntry'. For the
+						// purposes of tidying, it is sufficient if the token
+						// entry only has ID set.
+						tokenEntry := &logical.TokenEntry{
+							ID:          accessorEntry.TokenID,
+							NamespaceID: accessorEntry.NamespaceID,
+						}
+
+						// Attempt to revoke the token. This will also revoke
+						// the leases associated with the token.
+						err = ts.expiration.RevokeByToken(quitCtx, tokenEntry)
 						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to create salted token id: %w", err))
+							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke leases of expired token: %w", err))
 							continue
 						}
-						validCubbyholeKeys[salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)] = true
-					default:
-						if te.CubbyholeID == "" {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("missing cubbyhole ID for a valid token"))
+						deletedCountInvalidTokenInAccessor++
+
+						// If deletion of accessor fails, move on to the next item since
+						// this is just a best-effort operation. We do this last so that on
+						// next run if something above failed we still have the accessor
+						// entry to try again.
+						err = ts.deleteAccessorIndex(quitCtx, ns, saltedAccessor)
+						if err != nil {
+							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete accessor entry: %w", err))
 							continue
 						}
-						validCubbyholeKeys[te.CubbyholeID] = true
+						deletedCountAccessorInvalidToken++
+					default:
+						// Move the valid entries written before the index was sharded
+						if prefix == "" {
+							err = shardIndexEntry(quitCtx, ts.accessorView(ns), saltedAccessor, indexKey(saltedAccessor))
+							if err != nil {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to shard accessor entry: %w", err))
+							}
+						}
+
+						// Cache the cubbyhole storage key when the token is valid
+						switch {
+						case te.NamespaceID == namespace.RootNamespaceID && !IsServiceToken(te.ID):
+							saltedID, err := ts.SaltID(quitCtx, te.ID)
+							if err != nil {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to create salted token id: %w", err))
+								continue
+							}
+							validCubbyholeKeys[salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)] = true
+						default:
+							if te.CubbyholeID == "" {
+								tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("missing cubbyhole ID for a valid token"))
+								continue
+							}
+							validCubbyholeKeys[te.CubbyholeID] = true
+						}
 					}
 				}
 			}
 
-			// Revoke invalid cubbyhole storage keys
-			for index, key := range cubbyholeKeys {
-				countCubbyholeKeys++
-				if countCubbyholeKeys%500 == 0 {
-					percentComplete := float64(index) / float64(len(cubbyholeKeys)) * 100
-					ts.logger.Info("checking if there are invalid cubbyholes", "progress", countCubbyholeKeys, "percent_complete", percentComplete)
+			// The entries written before the indexes were sharded are tidied
+			// first, then each shard in turn, checkpointing the progress after
+			// each of them so that an interrupted tidy doesn't start over.
+			shards := append([]string{""}, indexShards()...)
+			for i := checkpoint.Completed; i < len(shards); i++ {
+				if err := quitCtx.Err(); err != nil {
+					return err
 				}
 
-				key := strings.TrimSuffix(key, "/")
-				if !validCubbyholeKeys[key] {
-					ts.logger.Info("deleting invalid cubbyhole", "key", key)
-					err = ts.cubbyholeBackend.revoke(quitCtx, bview, key)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke cubbyhole key %q: %w", key, err))
+				shard := shards[i]
+				prefix := ""
+				if shard != "" {
+					prefix = shard + "/"
+				}
+
+				// First, clean up secondary index entries that are no longer valid
+				parentList, err := ts.parentView(ns).List(quitCtx, prefix)
+				if err != nil {
+					return fmt.Errorf("failed to fetch secondary index entries: %w", err)
+				}
+
+				// List out the accessors
+				saltedAccessorList, err := ts.accessorView(ns).List(quitCtx, prefix)
+				if err != nil {
+					return fmt.Errorf("failed to fetch accessor index entries: %w", err)
+				}
+
+				if shard == "" {
+					parentList = unshardedIndexEntries(parentList)
+					saltedAccessorList = unshardedIndexEntries(saltedAccessorList)
+				}
+
+				tidyParents(prefix, parentList)
+				tidyAccessors(prefix, saltedAccessorList)
+
+				// Don't checkpoint a shard whose tidy was cut short
+				if err := quitCtx.Err(); err != nil {
+					return err
+				}
+
+				checkpoint.Completed = i + 1
+				if err := ts.storeTidyCheckpoint(quitCtx, ns, checkpoint); err != nil {
+					return fmt.Errorf("failed to store tidy checkpoint: %w", err)
+				}
+				ts.logger.Debug("checkpointed tidy operation on tokens", "completed_shards", checkpoint.Completed, "total_shards", len(shards))
+			}
+
+			// The cubbyholes of valid tokens are only all known when this run
+			// scanned the accessors of every shard, so they are left for the
+			// next tidy when this one resumed from a checkpoint.
+			if resumed {
+				ts.logger.Info("skipping tidy of cubbyholes since the tidy operation resumed from a checkpoint")
+			} else {
+				// List all the cubbyhole storage keys
+				view := ts.core.router.MatchingStorageByAPIPath(ctx, cubbyholeMountPath)
+				if view == nil {
+					return fmt.Errorf("no cubby mount entry")
+				}
+				bview := view.(*BarrierView)
+
+				cubbyholeKeys, err := bview.List(quitCtx, "")
+				if err != nil {
+					return fmt.Errorf("failed to fetch cubbyhole storage keys: %w", err)
+				}
+
+				// Revoke invalid cubbyhole storage keys
+				for index, key := range cubbyholeKeys {
+					countCubbyholeKeys++
+					if countCubbyholeKeys%500 == 0 {
+						percentComplete := float64(index) / float64(len(cubbyholeKeys)) * 100
+						ts.logger.Info("checking if there are invalid cubbyholes", "progress", countCubbyholeKeys, "percent_complete", percentComplete)
+					}
+
+					key := strings.TrimSuffix(key, "/")
+					if !validCubbyholeKeys[key] {
+						ts.logger.Info("deleting invalid cubbyhole", "key", key)
+						err = ts.cubbyholeBackend.revoke(quitCtx, bview, key)
+						if err != nil {
+							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke cubbyhole key %q: %w", key, err))
+						}
+						deletedCountInvalidCubbyholeKey++
 					}
-					deletedCountInvalidCubbyholeKey++
 				}
 			}
 
+			if err := ts.deleteTidyCheckpoint(quitCtx, ns); err != nil {
+				tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete tidy checkpoint: %w", err))
+			}
+
 			ts.logger.Info("number of entries scanned in parent prefix", "count", countParentEntries)
 			ts.logger.Info("number of entries deleted in parent prefix", "count", deletedCountParentEntries)
 			ts.logger.Info("number of tokens scanned in parent index list", "count", countParentList)
@@ -2999,6 +3118,14 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	// to be the last checks as they must look at the final policy set.
 	//
 
+	var templated *tsRoleTemplateValues
+	if role != nil && role.hasTemplates() {
+		templated, err = ts.populateRoleTemplates(role, parent.EntityID)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
 	switch {
 	case role != nil:
 		if role.Orphan {
@@ -3008,6 +3135,18 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		if len(role.TokenBoundCIDRs) > 0 {
 			te.BoundCIDRs = role.TokenBoundCIDRs
 		}
+		if templated != nil && len(templated.boundCIDRs) > 0 {
+			// Templated CIDRs replace the static ones when the role has none,
+			// and otherwise are intersected with them, so that a template can
+			// only narrow the addresses the role allows.
+			te.BoundCIDRs = templated.boundCIDRs
+			if len(role.TokenBoundCIDRs) > 0 {
+				te.BoundCIDRs = intersectCIDRs(role.TokenBoundCIDRs, templated.boundCIDRs)
+				if len(te.BoundCIDRs) == 0 {
+					return logical.ErrorResponse("the templated bound CIDRs are not within the bound CIDRs of the role"), logical.ErrInvalidRequest
+				}
+			}
+		}
 
 	case d.Get("no_parent").(bool):
 		// Only allow an orphan token if the client has sudo policy
@@ -3101,6 +3240,12 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		te.TTL = dur
 	}
 
+	// A templated role TTL caps the requested TTL, or is used as is if none
+	// was requested
+	if templated != nil && templated.ttl > 0 && (te.TTL == 0 || templated.ttl < te.TTL) {
+		te.TTL = templated.ttl
+	}
+
 	// Set the lesser period/explicit max TTL if defined both in arguments and
 	// in role. Batch tokens will error out if not set via role, but here we
 	// need to explicitly check
@@ -3116,6 +3261,14 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 				resp.AddWarning(fmt.Sprintf("Explicit max TTL specified both during creation call and in role; using the lesser value of %d seconds", int64(explicitMaxTTLToUse.Seconds())))
 			}
 		}
+		// The templated value differs per entity, so it is kept on the token
+		// entry for renewals to enforce
+		if templated != nil && templated.explicitMaxTTL > 0 {
+			if explicitMaxTTLToUse == 0 || templated.explicitMaxTTL < explicitMaxTTLToUse {
+				explicitMaxTTLToUse = templated.explicitMaxTTL
+			}
+			te.ExplicitMaxTTL = explicitMaxTTLToUse
+		}
 		if role.TokenPeriod != 0 {
 			switch {
 			case periodToUse == 0:
@@ -3187,9 +3340,9 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		[]string{"token", "creation"},
 		1,
 		[]metrics.Label{
-			metricsutil.NamespaceLabel(ns),
+			ts.core.metricSink.TelemetryConsts.NamespaceLabel(ns),
 			{"auth_method", "token"},
-			{"mount_point", mountPointWithoutNs}, // path, not accessor
+			ts.core.metricSink.TelemetryConsts.MountPointLabel(mountPointWithoutNs), // path, not accessor
 			{"creation_ttl", ttl_label},
 			{"token_type", tokenType.String()},
 		},
@@ -3504,9 +3657,139 @@ func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *fr
 
 	req.Auth.Period = role.TokenPeriod
 	req.Auth.ExplicitMaxTTL = role.TokenExplicitMaxTTL
+	if role.TokenExplicitMaxTTLTemplate != "" && te.ExplicitMaxTTL != 0 {
+		req.Auth.ExplicitMaxTTL = te.ExplicitMaxTTL
+	}
 	return &logical.Response{Auth: req.Auth}, nil
 }
 
+// tsRoleTemplateValues holds the token parameters rendered from a role's
+// identity templates.
+type tsRoleTemplateValues struct {
+	boundCIDRs     []*sockaddr.SockAddrMarshaler
+	ttl            time.Duration
+	explicitMaxTTL time.Duration
+}
+
+// intersectCIDRs returns the CIDRs covering the addresses within both sets of
+// CIDRs: for each overlapping pair, the narrower of the two.
+func intersectCIDRs(a, b []*sockaddr.SockAddrMarshaler) []*sockaddr.SockAddrMarshaler {
+	var out []*sockaddr.SockAddrMarshaler
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case cidrContains(x, y):
+				out = append(out, y)
+			case cidrContains(y, x):
+				out = append(out, x)
+			}
+		}
+	}
+	return out
+}
+
+// cidrContains returns whether the outer CIDR contains the inner one.
+func cidrContains(outer, inner *sockaddr.SockAddrMarshaler) bool {
+	outerAddr, ok := outer.SockAddr.(sockaddr.IPAddr)
+	if !ok {
+		return false
+	}
+	innerAddr, ok := inner.SockAddr.(sockaddr.IPAddr)
+	if !ok {
+		return false
+	}
+
+	outerNet, innerNet := outerAddr.NetIPNet(), innerAddr.NetIPNet()
+	outerOnes, outerBits := outerNet.Mask.Size()
+	innerOnes, innerBits := innerNet.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outerNet.Contains(innerNet.IP)
+}
+
+// populateRoleTemplates renders the role's identity templates against the
+// given entity and the groups it belongs to.
+func (ts *TokenStore) populateRoleTemplates(role *tsRoleEntry, entityID string) (*tsRoleTemplateValues, error) {
+	if entityID == "" {
+		return nil, fmt.Errorf("role %q uses identity templates but the requesting token has no entity", role.Name)
+	}
+	entity, err := ts.core.identityStore.MemDBEntityByID(entityID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch entity: %w", err)
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("role %q uses identity templates but the requesting entity was not found", role.Name)
+	}
+	directGroups, inheritedGroups, err := ts.core.identityStore.groupsByEntityID(entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
+	}
+	groups := append(directGroups, inheritedGroups...)
+
+	populate := func(field, tpl string) (string, error) {
+		_, out, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:        identitytpl.ACLTemplating,
+			String:      tpl,
+			Entity:      identity.ToSDKEntity(entity),
+			Groups:      identity.ToSDKGroups(groups),
+			NamespaceID: entity.NamespaceID,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to populate %q: %w", field, err)
+		}
+		return out, nil
+	}
+
+	values := &tsRoleTemplateValues{}
+	for _, tpl := range role.TokenBoundCIDRsTemplate {
+		out, err := populate("token_bound_cidrs_template", tpl)
+		if err != nil {
+			return nil, err
+		}
+		cidrs, err := parseutil.ParseAddrs(strutil.ParseDedupAndSortStrings(out, ","))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing templated bound CIDRs: %w", err)
+		}
+		values.boundCIDRs = append(values.boundCIDRs, cidrs...)
+	}
+
+	parseTTL := func(field, tpl string) (time.Duration, error) {
+		if tpl == "" {
+			return 0, nil
+		}
+		out, err := populate(field, tpl)
+		if err != nil {
+			return 0, err
+		}
+		dur, err := parseutil.ParseDurationSecond(out)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing templated %q: %w", field, err)
+		}
+		if dur < 0 {
+			return 0, fmt.Errorf("templated %q must be positive", field)
+		}
+		return dur, nil
+	}
+	if values.ttl, err = parseTTL("token_ttl_template", role.TokenTTLTemplate); err != nil {
+		return nil, err
+	}
+	if values.explicitMaxTTL, err = parseTTL("token_explicit_max_ttl_template", role.TokenExplicitMaxTTLTemplate); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// validateRoleTemplate checks that a token role template is well formed.
+func validateRoleTemplate(field, tpl string) error {
+	if _, _, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+		Mode:              identitytpl.ACLTemplating,
+		ValidityCheckOnly: true,
+		String:            tpl,
+	}); err != nil {
+		return fmt.Errorf("invalid %q: %w", field, err)
+	}
+	return nil
+}
+
 func (ts *TokenStore) tokenStoreRole(ctx context.Context, name string) (*tsRoleEntry, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -3617,6 +3900,15 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 	if role.TokenNumUses > 0 {
 		resp.Data["token_num_uses"] = role.TokenNumUses
 	}
+	if len(role.TokenBoundCIDRsTemplate) > 0 {
+		resp.Data["token_bound_cidrs_template"] = role.TokenBoundCIDRsTemplate
+	}
+	if role.TokenTTLTemplate != "" {
+		resp.Data["token_ttl_template"] = role.TokenTTLTemplate
+	}
+	if role.TokenExplicitMaxTTLTemplate != "" {
+		resp.Data["token_explicit_max_ttl_template"] = role.TokenExplicitMaxTTLTemplate
+	}
 
 	return resp, nil
 }
@@ -3841,6 +4133,29 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		entry.TokenNumUses = tokenNumUses.(int)
 	}
 
+	if boundCIDRsTemplateRaw, ok := data.GetOk("token_bound_cidrs_template"); ok {
+		entry.TokenBoundCIDRsTemplate = strutil.RemoveDuplicates(boundCIDRsTemplateRaw.([]string), false)
+	}
+	if ttlTemplateRaw, ok := data.GetOk("token_ttl_template"); ok {
+		entry.TokenTTLTemplate = ttlTemplateRaw.(string)
+	}
+	if explicitMaxTTLTemplateRaw, ok := data.GetOk("token_explicit_max_ttl_template"); ok {
+		entry.TokenExplicitMaxTTLTemplate = explicitMaxTTLTemplateRaw.(string)
+	}
+	for _, tpl := range entry.TokenBoundCIDRsTemplate {
+		if err := validateRoleTemplate("token_bound_cidrs_template", tpl); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+	for field, tpl := range map[string]string{
+		"token_ttl_template":              entry.TokenTTLTemplate,
+		"token_explicit_max_ttl_template": entry.TokenExplicitMaxTTLTemplate,
+	} {
+		if err := validateRoleTemplate(field, tpl); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	// Run validity checks on token type
 	if entry.TokenType == logical.TokenTypeBatch {
 		if !entry.Orphan {
@@ -3852,7 +4167,7 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		if entry.Renewable {
 			return logical.ErrorResponse("'token_type' cannot be 'batch' when role is set to generate renewable tokens"), nil
 		}
-		if entry.ExplicitMaxTTL != 0 || entry.TokenExplicitMaxTTL != 0 {
+		if entry.ExplicitMaxTTL != 0 || entry.TokenExplicitMaxTTL != 0 || entry.TokenExplicitMaxTTLTemplate != "" {
 			return logical.ErrorResponse("'token_type' cannot be 'batch' when role is set to generate tokens with an explicit max TTL"), nil
 		}
 	}
@@ -0,0 +1,220 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// indexShardPrefix is the prefix of the shards of the accessor and parent
+	// indexes. Salted IDs are hex encoded, optionally prefixed with "h", so
+	// the shards can't be mistaken for the unsharded entries written by
+	// older versions, which are still read and deleted until tidy moves them.
+	indexShardPrefix = "s"
+
+	// indexShardWidth is the number of trailing characters of the salted IDs
+	// naming their shard, for 256 shards.
+	indexShardWidth = 2
+
+	// tidyCheckpointPath is the path used to store the progress of a tidy
+	// operation
+	tidyCheckpointPath = "tidy-checkpoint"
+)
+
+// tokenTidyCheckpoint records the progress of a tidy operation, which resumes
+// from it when it is interrupted, e.g. by a seal.
+type tokenTidyCheckpoint struct {
+	// Completed is the number of index shards tidied, the entries written
+	// before the indexes were sharded being tidied first.
+	Completed int       `json:"completed"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// indexShard returns the shard holding the accessor or parent index entries
+// of a salted ID.
+func indexShard(saltedID string) string {
+	if len(saltedID) < indexShardWidth {
+		return indexShardPrefix + saltedID
+	}
+	return indexShardPrefix + saltedID[len(saltedID)-indexShardWidth:]
+}
+
+// indexKey returns the key of the accessor index entry of a salted accessor,
+// or of the parent index prefix of a salted token ID.
+func indexKey(saltedID string) string {
+	return indexShard(saltedID) + "/" + saltedID
+}
+
+// isIndexShard returns whether a key listed at the root of the accessor or
+// parent index is a shard, rather than an unsharded entry.
+func isIndexShard(key string) bool {
+	return len(key) == len(indexShardPrefix)+indexShardWidth+1 &&
+		strings.HasPrefix(key, indexShardPrefix) &&
+		strings.HasSuffix(key, "/")
+}
+
+// indexShards returns all the shards of the accessor and parent indexes, in
+// order.
+func indexShards() []string {
+	shards := make([]string, 0, 1<<(4*indexShardWidth))
+	for i := 0; i < cap(shards); i++ {
+		shards = append(shards, fmt.Sprintf("%s%0*x", indexShardPrefix, indexShardWidth, i))
+	}
+	return shards
+}
+
+// unshardedIndexEntries filters the shards out of the keys listed at the root
+// of the accessor or parent index.
+func unshardedIndexEntries(keys []string) []string {
+	var entries []string
+	for _, key := range keys {
+		if !isIndexShard(key) {
+			entries = append(entries, key)
+		}
+	}
+	return entries
+}
+
+// listSaltedAccessors lists the salted accessors of the accessor index of the
+// namespace, whether their entries are sharded or not.
+func (ts *TokenStore) listSaltedAccessors(ctx context.Context, ns *namespace.Namespace) ([]string, error) {
+	view := ts.accessorView(ns)
+
+	keys, err := view.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	saltedAccessors := unshardedIndexEntries(keys)
+	for _, key := range keys {
+		if !isIndexShard(key) {
+			continue
+		}
+
+		shardKeys, err := view.List(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		saltedAccessors = append(saltedAccessors, shardKeys...)
+	}
+
+	return saltedAccessors, nil
+}
+
+// getAccessorIndex reads the accessor index entry of a salted accessor,
+// falling back to its unsharded entry.
+func (ts *TokenStore) getAccessorIndex(ctx context.Context, ns *namespace.Namespace, saltedAccessor string) (*logical.StorageEntry, error) {
+	view := ts.accessorView(ns)
+
+	entry, err := view.Get(ctx, indexKey(saltedAccessor))
+	if err != nil || entry != nil {
+		return entry, err
+	}
+	return view.Get(ctx, saltedAccessor)
+}
+
+// deleteAccessorIndex deletes the accessor index entry of a salted accessor,
+// along with its unsharded entry.
+func (ts *TokenStore) deleteAccessorIndex(ctx context.Context, ns *namespace.Namespace, saltedAccessor string) error {
+	view := ts.accessorView(ns)
+
+	if err := view.Delete(ctx, indexKey(saltedAccessor)); err != nil {
+		return err
+	}
+	return view.Delete(ctx, saltedAccessor)
+}
+
+// listChildIndexes lists the children of a salted token ID in the parent
+// index of the namespace, whether their entries are sharded or not.
+func (ts *TokenStore) listChildIndexes(ctx context.Context, ns *namespace.Namespace, parentSaltedID string) ([]string, error) {
+	view := ts.parentView(ns)
+
+	children, err := view.List(ctx, indexKey(parentSaltedID)+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	legacyChildren, err := view.List(ctx, parentSaltedID+"/")
+	if err != nil {
+		return nil, err
+	}
+	if len(legacyChildren) == 0 {
+		return children, nil
+	}
+
+	// An entry may have been written in both layouts if moving it was
+	// interrupted
+	return strutil.RemoveDuplicates(append(children, legacyChildren...), false), nil
+}
+
+// deleteChildIndex deletes the parent index entry of a child of a salted
+// token ID, along with its unsharded entry.
+func (ts *TokenStore) deleteChildIndex(ctx context.Context, ns *namespace.Namespace, parentSaltedID, child string) error {
+	view := ts.parentView(ns)
+
+	if err := view.Delete(ctx, indexKey(parentSaltedID)+"/"+child); err != nil {
+		return err
+	}
+	return view.Delete(ctx, parentSaltedID+"/"+child)
+}
+
+// shardIndexEntry moves an unsharded index entry to its sharded key.
+func shardIndexEntry(ctx context.Context, view *BarrierView, legacyKey, key string) error {
+	entry, err := view.Get(ctx, legacyKey)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return nil
+	}
+
+	if err := view.Put(ctx, &logical.StorageEntry{Key: key, Value: entry.Value}); err != nil {
+		return err
+	}
+	return view.Delete(ctx, legacyKey)
+}
+
+// loadTidyCheckpoint reads the progress of an interrupted tidy operation in
+// the namespace, returning an empty checkpoint when there is none.
+func (ts *TokenStore) loadTidyCheckpoint(ctx context.Context, ns *namespace.Namespace) (*tokenTidyCheckpoint, error) {
+	checkpoint := &tokenTidyCheckpoint{}
+
+	entry, err := ts.baseView(ns).Get(ctx, tidyCheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return checkpoint, nil
+	}
+
+	if err := entry.DecodeJSON(checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// storeTidyCheckpoint persists the progress of the tidy operation in the
+// namespace.
+func (ts *TokenStore) storeTidyCheckpoint(ctx context.Context, ns *namespace.Namespace, checkpoint *tokenTidyCheckpoint) error {
+	value, err := jsonutil.EncodeJSON(checkpoint)
+	if err != nil {
+		return err
+	}
+	return ts.baseView(ns).Put(ctx, &logical.StorageEntry{Key: tidyCheckpointPath, Value: value})
+}
+
+// deleteTidyCheckpoint clears the progress of the tidy operation in the
+// namespace once it completed.
+func (ts *TokenStore) deleteTidyCheckpoint(ctx context.Context, ns *namespace.Namespace) error {
+	return ts.baseView(ns).Delete(ctx, tidyCheckpointPath)
+}
@@ -4385,6 +4385,143 @@ func TestTokenStore_RoleExplicitMaxTTL(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RoleTemplates(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := core.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name": "templated",
+			"metadata": map[string]string{
+				"cidrs":   "10.0.0.0/8,192.168.0.0/16",
+				"ttl":     "1h",
+				"max_ttl": "2h",
+			},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+
+	// Invalid templates are rejected on role write
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_ttl_template": "{{identity.entity.metadata.ttl",
+	}
+	resp, err = core.HandleRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got: %#v", resp)
+	}
+
+	req.Data = map[string]interface{}{
+		"token_bound_cidrs":               []string{"10.1.0.0/16"},
+		"token_bound_cidrs_template":      []string{"{{identity.entity.metadata.cidrs}}"},
+		"token_ttl_template":              "{{identity.entity.metadata.ttl}}",
+		"token_explicit_max_ttl_template": "{{identity.entity.metadata.max_ttl}}",
+	}
+	resp, err = core.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err = core.HandleRequest(ctx, req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["token_ttl_template"] != "{{identity.entity.metadata.ttl}}" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// A token without an entity cannot render the templates
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+	req.ClientToken = root
+	resp, err = core.HandleRequest(ctx, req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %v %#v", err, resp)
+	}
+
+	parent := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: []string{"root"},
+		EntityID: entityID,
+		TTL:      time.Hour * 24,
+	}
+	testMakeTokenDirectly(t, core.tokenStore, parent)
+
+	req.ClientToken = parent.ID
+	req.Data = map[string]interface{}{
+		"ttl": "3h",
+	}
+	resp, err = core.HandleRequest(ctx, req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Auth.TTL != time.Hour {
+		t.Fatalf("expected the templated TTL to cap the requested one, got %s", resp.Auth.TTL)
+	}
+	if resp.Auth.ExplicitMaxTTL != 2*time.Hour {
+		t.Fatalf("expected the templated explicit max TTL, got %s", resp.Auth.ExplicitMaxTTL)
+	}
+
+	te, err := core.tokenStore.Lookup(ctx, resp.Auth.ClientToken)
+	if err != nil || te == nil {
+		t.Fatalf("err: %v te: %#v", err, te)
+	}
+	var cidrs []string
+	for _, cidr := range te.BoundCIDRs {
+		cidrs = append(cidrs, cidr.String())
+	}
+	// The templated CIDRs can't widen the CIDRs of the role
+	expected := []string{"10.1.0.0/16"}
+	if !reflect.DeepEqual(cidrs, expected) {
+		t.Fatalf("expected bound CIDRs %v, got %v", expected, cidrs)
+	}
+	if te.ExplicitMaxTTL != 2*time.Hour {
+		t.Fatalf("expected explicit max TTL to be stored on the token, got %s", te.ExplicitMaxTTL)
+	}
+
+	// Templated CIDRs outside of the CIDRs of the role fail token creation
+	roleReq := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	roleReq.ClientToken = root
+	roleReq.Data = map[string]interface{}{
+		"token_bound_cidrs": []string{"172.16.0.0/12"},
+	}
+	resp, err = core.HandleRequest(ctx, roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	resp, err = core.HandleRequest(ctx, req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %v %#v", err, resp)
+	}
+
+	// A missing metadata key fails token creation
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_ttl_template": "{{identity.entity.metadata.missing}}",
+	}
+	resp, err = core.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+	req.ClientToken = parent.ID
+	resp, err = core.HandleRequest(ctx, req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %v %#v", err, resp)
+	}
+}
+
 func TestTokenStore_RoleTokenFields(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 	// c, _, root := TestCoreUnsealed(t)
@@ -5760,6 +5897,209 @@ func TestTokenStore_HandleTidy_parentCleanup(t *testing.T) {
 	}
 }
 
+// waitForTokenTidy waits for the tidy operation of the token store to finish.
+func waitForTokenTidy(t *testing.T, ts *TokenStore) {
+	t.Helper()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for atomic.LoadUint32(ts.tidyLock) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for tidy to finish")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Write the accessor and parent index entries of a token and its child in the
+// unsharded layout of older versions, check that they are still read, and
+// that tidy moves them to their shard.
+func TestTokenStore_HandleTidy_indexSharding(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	resp := testMakeTokenViaRequest(t, ts, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "create",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"policies": []string{"policy1"},
+		},
+	})
+	parent, parentAccessor := resp.Auth.ClientToken, resp.Auth.Accessor
+
+	resp = testMakeTokenViaRequest(t, ts, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "create",
+		ClientToken: parent,
+		Data: map[string]interface{}{
+			"policies": []string{"policy1"},
+		},
+	})
+	child := resp.Auth.ClientToken
+
+	saltedParent, err := ts.SaltID(ctx, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltedChild, err := ts.SaltID(ctx, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltedAccessor, err := ts.SaltID(ctx, parentAccessor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []struct {
+		view      *BarrierView
+		key       string
+		legacyKey string
+	}{
+		{ts.accessorView(namespace.RootNamespace), indexKey(saltedAccessor), saltedAccessor},
+		{ts.parentView(namespace.RootNamespace), indexKey(saltedParent) + "/" + saltedChild, saltedParent + "/" + saltedChild},
+	}
+	for _, e := range entries {
+		if err := shardIndexEntry(ctx, e.view, e.key, e.legacyKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	aEntry, err := ts.lookupByAccessor(ctx, parentAccessor, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aEntry == nil || aEntry.TokenID != parent {
+		t.Fatalf("bad: accessor entry: %#v", aEntry)
+	}
+	children, err := ts.listChildIndexes(ctx, namespace.RootNamespace, saltedParent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 1 || children[0] != saltedChild {
+		t.Fatalf("bad: children: %v", children)
+	}
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Path:        "tidy",
+		Operation:   logical.UpdateOperation,
+		ClientToken: root,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%v", err, resp)
+	}
+	waitForTokenTidy(t, ts)
+
+	for _, e := range entries {
+		entry, err := e.view.Get(ctx, e.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil {
+			t.Fatalf("expected entry %q to be moved to its shard", e.legacyKey)
+		}
+		entry, err = e.view.Get(ctx, e.legacyKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry != nil {
+			t.Fatalf("expected unsharded entry %q to be deleted", e.legacyKey)
+		}
+	}
+
+	checkpoint, err := ts.loadTidyCheckpoint(ctx, namespace.RootNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Completed != 0 {
+		t.Fatalf("expected the checkpoint to be deleted, got %#v", checkpoint)
+	}
+
+	// Revoking the parent revokes its child through the sharded index
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Path:        "revoke",
+		Operation:   logical.UpdateOperation,
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"token": parent,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%v", err, resp)
+	}
+	out, err := ts.Lookup(ctx, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		t.Fatalf("expected child token to be revoked: %#v", out)
+	}
+}
+
+// Leak an accessor, and check that a tidy resuming from a checkpoint skips the
+// shards already tidied.
+func TestTokenStore_HandleTidy_checkpoint(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	resp := testMakeTokenViaRequest(t, ts, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "create",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"policies": []string{"policy1"},
+		},
+	})
+	saltedTut, err := ts.SaltID(ctx, resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.idView(namespace.RootNamespace).Delete(ctx, saltedTut); err != nil {
+		t.Fatal(err)
+	}
+
+	accessorListReq := &logical.Request{
+		Operation:   logical.ListOperation,
+		Path:        "accessors/",
+		ClientToken: root,
+	}
+	tidyReq := &logical.Request{
+		Path:        "tidy",
+		Operation:   logical.UpdateOperation,
+		ClientToken: root,
+	}
+
+	checkpoint := &tokenTidyCheckpoint{Completed: len(indexShards()) + 1}
+	if err := ts.storeTidyCheckpoint(ctx, namespace.RootNamespace, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []int{2, 1} {
+		resp, err = ts.HandleRequest(ctx, tidyReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%v", err, resp)
+		}
+		waitForTokenTidy(t, ts)
+
+		resp, err = ts.HandleRequest(ctx, accessorListReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%v", err, resp)
+		}
+		if keys := resp.Data["keys"].([]string); len(keys) != expected {
+			t.Fatalf("bad: number of accessors. Expected: %d, Actual: %d", expected, len(keys))
+		}
+
+		checkpoint, err = ts.loadTidyCheckpoint(ctx, namespace.RootNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint.Completed != 0 {
+			t.Fatalf("expected the checkpoint to be deleted, got %#v", checkpoint)
+		}
+	}
+}
+
 func TestTokenStore_TidyLeaseRevocation(t *testing.T) {
 	exp := mockExpiration(t)
 	ts := exp.tokenStore
@@ -156,12 +156,12 @@ DONELISTHANDLING:
 		[]string{"token", "creation"},
 		1,
 		[]metrics.Label{
-			metricsutil.NamespaceLabel(ns),
+			c.metricSink.TelemetryConsts.NamespaceLabel(ns),
 			// The type of the secret engine is not all that useful;
 			// we could use "token" but let's be more descriptive,
 			// even if it's not a real auth method.
 			{"auth_method", "response_wrapping"},
-			{"mount_point", mountPointWithoutNs},
+			c.metricSink.TelemetryConsts.MountPointLabel(mountPointWithoutNs),
 			{"creation_ttl", ttl_label},
 			// *Should* be service, but let's use whatever create() did..
 			{"token_type", te.Type.String()},
@@ -305,6 +305,11 @@ DONELISTHANDLING:
 	} else {
 		cubbyReq.Data["creation_path"] = resp.WrapInfo.CreationPath
 	}
+	// Store the accessor of the requesting token so that outstanding wrapping
+	// tokens can be audited by their creator
+	if auth != nil && auth.Accessor != "" {
+		cubbyReq.Data["creator_accessor"] = auth.Accessor
+	}
 	cubbyResp, err = c.router.Route(ctx, cubbyReq)
 	if err != nil {
 		// Revoke since it's not yet being tracked for expiration
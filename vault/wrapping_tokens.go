@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// wrappingTokenInfo describes an outstanding response-wrapping token, as
+// recorded in the token entry and the cubbyhole of the token. The wrapped
+// response itself is never read.
+type wrappingTokenInfo struct {
+	Accessor        string
+	CreationPath    string
+	CreationTime    time.Time
+	CreationTTL     time.Duration
+	ExpireTime      time.Time
+	CreatorAccessor string
+
+	// HasResponse reports whether the wrapped response is still stored in
+	// the cubbyhole of the token.
+	HasResponse bool
+
+	te *logical.TokenEntry
+}
+
+func (i *wrappingTokenInfo) data() map[string]interface{} {
+	return map[string]interface{}{
+		"creation_path":    i.CreationPath,
+		"creation_time":    i.CreationTime.Format(time.RFC3339Nano),
+		"creation_ttl":     int64(i.CreationTTL.Seconds()),
+		"expire_time":      i.ExpireTime.Format(time.RFC3339Nano),
+		"creator_accessor": i.CreatorAccessor,
+		"has_response":     i.HasResponse,
+	}
+}
+
+// wrappingTokenFilter selects wrapping tokens. Empty fields match every token.
+type wrappingTokenFilter struct {
+	Accessors          []string
+	CreatorAccessor    string
+	CreationPathPrefix string
+}
+
+func (f *wrappingTokenFilter) matches(info *wrappingTokenInfo) bool {
+	if len(f.Accessors) > 0 && !strutil.StrListContains(f.Accessors, info.Accessor) {
+		return false
+	}
+	if f.CreatorAccessor != "" && info.CreatorAccessor != f.CreatorAccessor {
+		return false
+	}
+	if f.CreationPathPrefix != "" && !strings.HasPrefix(info.CreationPath, f.CreationPathPrefix) {
+		return false
+	}
+	return true
+}
+
+// listWrappingTokens returns the outstanding wrapping tokens of the namespace
+// of the context which match the filter, sorted by creation time. Tokens which
+// can't be inspected are skipped and reported as warnings.
+func (c *Core) listWrappingTokens(ctx context.Context, filter *wrappingTokenFilter) ([]*wrappingTokenInfo, []string, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ts := c.tokenStore
+	saltedAccessors, err := ts.listSaltedAccessors(ctx, ns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var infos []*wrappingTokenInfo
+	var warnings []string
+	for _, saltedAccessor := range saltedAccessors {
+		aEntry, err := ts.lookupByAccessor(ctx, saltedAccessor, true, false)
+		if err != nil || aEntry == nil || aEntry.TokenID == "" || aEntry.NamespaceID != ns.ID {
+			continue
+		}
+
+		te, err := ts.lookupInternal(ctx, aEntry.TokenID, false, false)
+		if err != nil || te == nil {
+			continue
+		}
+		if len(te.Policies) != 1 || te.Policies[0] != responseWrappingPolicyName {
+			continue
+		}
+
+		info, err := c.inspectWrappingToken(ctx, te)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to inspect wrapping token with accessor %q: %v", te.Accessor, err))
+			continue
+		}
+		if filter != nil && !filter.matches(info) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].CreationTime.Equal(infos[j].CreationTime) {
+			return infos[i].CreationTime.Before(infos[j].CreationTime)
+		}
+		return infos[i].Accessor < infos[j].Accessor
+	})
+	return infos, warnings, nil
+}
+
+// inspectWrappingToken reads the wrapping information stored in the cubbyhole
+// of the wrapping token and checks whether the wrapped response is still
+// there, without reading it.
+func (c *Core) inspectWrappingToken(ctx context.Context, te *logical.TokenEntry) (*wrappingTokenInfo, error) {
+	info := &wrappingTokenInfo{
+		Accessor:     te.Accessor,
+		CreationPath: te.Path,
+		CreationTime: time.Unix(te.CreationTime, 0),
+		CreationTTL:  te.TTL,
+		ExpireTime:   time.Unix(te.CreationTime, 0).Add(te.TTL),
+		te:           te,
+	}
+
+	cubbyReq := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "cubbyhole/wrapinfo",
+		ClientToken: te.ID,
+	}
+	cubbyReq.SetTokenEntry(te)
+	cubbyResp, err := c.router.Route(ctx, cubbyReq)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up wrapping information: %w", err)
+	}
+	if cubbyResp != nil && cubbyResp.IsError() {
+		return nil, cubbyResp.Error()
+	}
+	if cubbyResp != nil && cubbyResp.Data != nil {
+		if raw, ok := cubbyResp.Data["creation_path"].(string); ok {
+			info.CreationPath = raw
+		}
+		if raw, ok := cubbyResp.Data["creation_time"].(string); ok {
+			if creationTime, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+				info.CreationTime = creationTime
+			}
+		}
+		if raw, ok := cubbyResp.Data["creation_ttl"].(json.Number); ok {
+			if creationTTL, err := raw.Int64(); err == nil {
+				info.CreationTTL = time.Duration(creationTTL)
+			}
+		}
+		if raw, ok := cubbyResp.Data["creator_accessor"].(string); ok {
+			info.CreatorAccessor = raw
+		}
+	}
+
+	cubbyReq = &logical.Request{
+		Operation:   logical.ListOperation,
+		Path:        "cubbyhole/",
+		ClientToken: te.ID,
+	}
+	cubbyReq.SetTokenEntry(te)
+	cubbyResp, err = c.router.Route(ctx, cubbyReq)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cubbyhole: %w", err)
+	}
+	if cubbyResp != nil && !cubbyResp.IsError() {
+		if keys, ok := cubbyResp.Data["keys"].([]string); ok {
+			info.HasResponse = strutil.StrListContains(keys, "response")
+		}
+	}
+
+	return info, nil
+}
+
+// revokeWrappingTokens revokes the outstanding wrapping tokens of the
+// namespace of the context which match the filter, along with their
+// cubbyholes, and returns the accessors of the revoked tokens.
+func (c *Core) revokeWrappingTokens(ctx context.Context, filter *wrappingTokenFilter) ([]string, []string, error) {
+	infos, warnings, err := c.listWrappingTokens(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revoked := make([]string, 0, len(infos))
+	for _, info := range infos {
+		leaseID, err := c.expiration.CreateOrFetchRevocationLeaseByToken(ctx, info.te)
+		if err == nil {
+			err = c.expiration.Revoke(ctx, leaseID)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to revoke wrapping token with accessor %q: %v", info.Accessor, err))
+			continue
+		}
+		revoked = append(revoked, info.Accessor)
+	}
+
+	if len(revoked) > 0 {
+		c.logger.Info("revoked wrapping tokens", "count", len(revoked))
+	}
+	return revoked, warnings, nil
+}